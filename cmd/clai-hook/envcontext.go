@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/runger/clai/internal/suggestions/event"
+)
+
+// kubeconfigScanLines bounds how far into a kubeconfig the current context
+// is looked for; the hook runs after every command and must stay cheap.
+const kubeconfigScanLines = 500
+
+// readPrevCwd returns CLAI_PREV_CWD when the command changed directory,
+// or "" when it is unset or equal to cwd.
+func readPrevCwd(cwd string) string {
+	prev := os.Getenv("CLAI_PREV_CWD")
+	if prev == cwd {
+		return ""
+	}
+	return toValidUTF8(prev)
+}
+
+// readEnvContext collects the environment context of a command from the
+// hook's environment. Only names of environments and versions are kept,
+// never full paths or other values.
+func readEnvContext() map[string]string {
+	env := make(map[string]string)
+	if venv := os.Getenv("VIRTUAL_ENV"); venv != "" {
+		env[event.EnvVirtualEnv] = filepath.Base(venv)
+	}
+	env[event.EnvCondaEnv] = os.Getenv("CONDA_DEFAULT_ENV")
+	// NVM_BIN is <nvm dir>/versions/node/<version>/bin.
+	if nvmBin := os.Getenv("NVM_BIN"); nvmBin != "" {
+		env[event.EnvNodeVersion] = filepath.Base(filepath.Dir(nvmBin))
+	}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(key, "ASDF_") && event.IsContextEnvKey(key) {
+			env[key] = value
+		}
+	}
+	env[event.EnvKubeContext] = kubeContext(os.Getenv("KUBECONFIG"))
+
+	for k, v := range env {
+		env[k] = toValidUTF8(v)
+	}
+	return event.FilterEnvContext(env)
+}
+
+// kubeContext returns the current-context of the first file in kubeconfig,
+// a KUBECONFIG-style path list. It returns "" when KUBECONFIG is unset:
+// the default ~/.kube/config is not read, so only an explicitly selected
+// cluster becomes context.
+func kubeContext(kubeconfig string) string {
+	path, _, _ := strings.Cut(kubeconfig, string(os.PathListSeparator))
+	if path == "" {
+		return ""
+	}
+	f, err := os.Open(path) //nolint:gosec // G304: path is the user's own KUBECONFIG
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < kubeconfigScanLines && scanner.Scan(); i++ {
+		value, ok := strings.CutPrefix(scanner.Text(), "current-context:")
+		if ok {
+			return strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return ""
+}
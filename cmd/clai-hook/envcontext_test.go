@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPrevCwd(t *testing.T) {
+	t.Setenv("CLAI_PREV_CWD", "/home/user")
+	assert.Equal(t, "/home/user", readPrevCwd("/home/user/project"))
+	assert.Empty(t, readPrevCwd("/home/user"), "unchanged directory is not a transition")
+
+	t.Setenv("CLAI_PREV_CWD", "")
+	assert.Empty(t, readPrevCwd("/home/user"))
+}
+
+func TestReadEnvContext(t *testing.T) {
+	dir := t.TempDir()
+	kubeconfig := filepath.Join(dir, "config")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte("apiVersion: v1\ncurrent-context: \"staging\"\nkind: Config\n"), 0o600))
+
+	t.Setenv("VIRTUAL_ENV", "/home/user/project/.venv")
+	t.Setenv("CONDA_DEFAULT_ENV", "")
+	t.Setenv("NVM_BIN", "/home/user/.nvm/versions/node/v20.11.0/bin")
+	t.Setenv("ASDF_PYTHON_VERSION", "3.12.1")
+	t.Setenv("ASDF_DIR", "/home/user/.asdf")
+	t.Setenv("KUBECONFIG", kubeconfig+string(os.PathListSeparator)+filepath.Join(dir, "other"))
+
+	assert.Equal(t, map[string]string{
+		"VIRTUAL_ENV":         ".venv",
+		"NVM_NODE_VERSION":    "v20.11.0",
+		"ASDF_PYTHON_VERSION": "3.12.1",
+		"KUBE_CONTEXT":        "staging",
+	}, readEnvContext())
+}
+
+func TestKubeContext(t *testing.T) {
+	assert.Empty(t, kubeContext(""))
+	assert.Empty(t, kubeContext(filepath.Join(t.TempDir(), "missing")))
+
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(path, []byte("apiVersion: v1\nkind: Config\n"), 0o600))
+	assert.Empty(t, kubeContext(path))
+}
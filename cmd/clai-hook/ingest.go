@@ -2,12 +2,15 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"unicode/utf8"
 
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/ipc"
 	"github.com/runger/clai/internal/suggestions/event"
 )
 
@@ -37,10 +40,11 @@ func parseIngestArgs(args []string) (*ingestConfig, error) {
 
 // runIngest handles the ingest subcommand.
 // It reads command event data from environment variables (and optionally stdin),
-// validates the input, builds an event struct, and returns success.
+// validates the input, builds an event struct, and sends it to the daemon,
+// spooling it when the daemon is unreachable.
 //
 // Exit codes:
-//   - 0: Success (or daemon unavailable - silent drop)
+//   - 0: Success (or daemon unavailable - event spooled or dropped)
 //   - 1: Invalid arguments
 func runIngest(args []string) int {
 	// Check for no-record flag first
@@ -57,23 +61,41 @@ func runIngest(args []string) int {
 	}
 
 	// Read and validate environment variables
-	ev, err := readIngestEnv(cfg)
+	settings := config.LoadHookSettings()
+	ev, err := readIngestEnv(cfg, settings)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "clai-hook ingest: %v\n", err)
 		return 1
 	}
 
-	// Event is now built and validated.
-	// In the future (SUGG-006), we will send this to the daemon.
-	// For now, we just return success.
-	_ = ev
+	// Ephemeral events are neither sent nor written to disk.
+	if ev.Ephemeral {
+		return 0
+	}
+
+	// Neither are commands suggestions.ingest_filters drops. The hook does
+	// not know the previous command; the daemon collapses duplicates.
+	if _, ignored := settings.IngestFilters.Ignores(ev.CmdRaw, ""); ignored {
+		return 0
+	}
+
+	// Do not spawn the daemon from the hook; without one the event is
+	// spooled until the next ingest that reaches it, or `clai-hook flush`.
+	var client batchSender
+	if conn, err := ipc.QuickDial(); err == nil {
+		c := ipc.NewClientWithConn(conn)
+		defer c.Close()
+		client = c
+	}
+	// Failing to deliver must not fail the shell hook.
+	_ = deliverEvent(context.Background(), config.DefaultPaths().HookSpoolFile(), client, ev)
 
 	return 0
 }
 
 // readIngestEnv reads the environment variables and builds a CommandEvent.
 // It returns an error if any required field is missing or invalid.
-func readIngestEnv(cfg *ingestConfig) (*event.CommandEvent, error) {
+func readIngestEnv(cfg *ingestConfig, settings config.HookSettings) (*event.CommandEvent, error) {
 	ev := event.NewCommandEvent()
 
 	cmdRaw, err := readCommandRaw(cfg)
@@ -120,10 +142,19 @@ func readIngestEnv(cfg *ingestConfig) (*event.CommandEvent, error) {
 		ev.DurationMs = &duration
 	}
 
+	// Incognito sessions, suggestions.ephemeral_dirs and repositories whose
+	// trusted .clai/config.yaml sets incognito are never recorded.
 	if os.Getenv("CLAI_EPHEMERAL") == "1" {
 		ev.Ephemeral = true
+	} else if _, ok := config.MatchEphemeralDir(settings.EphemeralDirs, cwd); ok {
+		ev.Ephemeral = true
+	} else if config.RepoIncognito(cwd) {
+		ev.Ephemeral = true
 	}
 
+	ev.PrevCwd = readPrevCwd(cwd)
+	ev.Env = readEnvContext()
+
 	return ev, nil
 }
 
@@ -185,7 +216,7 @@ func readRequiredShell() (event.Shell, error) {
 		return "", err
 	}
 	if !event.ValidShell(v) {
-		return "", fmt.Errorf("CLAI_SHELL must be one of: bash, zsh, fish")
+		return "", fmt.Errorf("CLAI_SHELL must be one of: bash, zsh, fish, pwsh, nu")
 	}
 	return event.Shell(v), nil
 }
@@ -2,10 +2,13 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/runger/clai/internal/config"
 )
 
 func TestParseIngestArgs(t *testing.T) {
@@ -99,7 +102,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		ev, err := readIngestEnv(cfg)
+		ev, err := readIngestEnv(cfg, config.HookSettings{})
 		require.NoError(t, err)
 
 		assert.Equal(t, "git status", ev.CmdRaw)
@@ -128,7 +131,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		ev, err := readIngestEnv(cfg)
+		ev, err := readIngestEnv(cfg, config.HookSettings{})
 		require.NoError(t, err)
 
 		assert.Equal(t, "npm test", ev.CmdRaw)
@@ -151,7 +154,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		_, err := readIngestEnv(cfg)
+		_, err := readIngestEnv(cfg, config.HookSettings{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CLAI_CMD")
 	})
@@ -168,7 +171,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		_, err := readIngestEnv(cfg)
+		_, err := readIngestEnv(cfg, config.HookSettings{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CLAI_CWD")
 	})
@@ -185,7 +188,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		_, err := readIngestEnv(cfg)
+		_, err := readIngestEnv(cfg, config.HookSettings{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CLAI_EXIT")
 	})
@@ -202,7 +205,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		_, err := readIngestEnv(cfg)
+		_, err := readIngestEnv(cfg, config.HookSettings{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CLAI_EXIT")
 		assert.Contains(t, err.Error(), "integer")
@@ -220,7 +223,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		_, err := readIngestEnv(cfg)
+		_, err := readIngestEnv(cfg, config.HookSettings{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CLAI_TS")
 	})
@@ -237,7 +240,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		_, err := readIngestEnv(cfg)
+		_, err := readIngestEnv(cfg, config.HookSettings{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CLAI_TS")
 		assert.Contains(t, err.Error(), "integer")
@@ -255,7 +258,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		_, err := readIngestEnv(cfg)
+		_, err := readIngestEnv(cfg, config.HookSettings{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CLAI_SHELL")
 	})
@@ -272,7 +275,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		_, err := readIngestEnv(cfg)
+		_, err := readIngestEnv(cfg, config.HookSettings{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CLAI_SHELL")
 		assert.Contains(t, err.Error(), "bash, zsh, fish")
@@ -290,7 +293,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		_, err := readIngestEnv(cfg)
+		_, err := readIngestEnv(cfg, config.HookSettings{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CLAI_SESSION_ID")
 	})
@@ -308,7 +311,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		_, err := readIngestEnv(cfg)
+		_, err := readIngestEnv(cfg, config.HookSettings{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CLAI_DURATION_MS")
 		assert.Contains(t, err.Error(), "integer")
@@ -326,7 +329,7 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		ev, err := readIngestEnv(cfg)
+		ev, err := readIngestEnv(cfg, config.HookSettings{})
 		require.NoError(t, err)
 		assert.Equal(t, "fish", string(ev.Shell))
 	})
@@ -344,10 +347,31 @@ func TestReadIngestEnv(t *testing.T) {
 		defer cleanup()
 
 		cfg := &ingestConfig{cmdStdin: false}
-		ev, err := readIngestEnv(cfg)
+		ev, err := readIngestEnv(cfg, config.HookSettings{})
 		require.NoError(t, err)
 		assert.False(t, ev.Ephemeral)
 	})
+
+	t.Run("ephemeral_dirs match is ephemeral", func(t *testing.T) {
+		home := t.TempDir()
+		cfgYAML := "suggestions:\n  ephemeral_dirs: [\"/home/user/secrets\"]\n"
+		require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte(cfgYAML), 0o600))
+		cleanup := setEnv(map[string]string{
+			"CLAI_HOME":       home,
+			"CLAI_CMD":        "aws sts get-caller-identity",
+			"CLAI_CWD":        "/home/user/secrets/aws",
+			"CLAI_EXIT":       "0",
+			"CLAI_TS":         "1730000000123",
+			"CLAI_SHELL":      "zsh",
+			"CLAI_SESSION_ID": "abc",
+		})
+		defer cleanup()
+
+		cfg := &ingestConfig{cmdStdin: false}
+		ev, err := readIngestEnv(cfg, config.LoadHookSettings())
+		require.NoError(t, err)
+		assert.True(t, ev.Ephemeral)
+	})
 }
 
 func TestToValidUTF8(t *testing.T) {
@@ -423,3 +447,27 @@ func TestRunIngestNoRecord(t *testing.T) {
 	exitCode := runIngest([]string{})
 	assert.Equal(t, 0, exitCode)
 }
+
+func TestRunIngestIngestFilters(t *testing.T) {
+	home := t.TempDir()
+	cfgYAML := "suggestions:\n  ingest_filters:\n    ignore_patterns: [\"ls\", \"cd *\"]\n    ignore_space_prefixed: true\n"
+	require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte(cfgYAML), 0o600))
+	spoolPath := filepath.Join(home, "cache", "hook.spool")
+
+	t.Setenv("CLAI_HOME", home)
+	t.Setenv("CLAI_CWD", "/tmp")
+	t.Setenv("CLAI_EXIT", "0")
+	t.Setenv("CLAI_TS", "1730000000123")
+	t.Setenv("CLAI_SHELL", "zsh")
+	t.Setenv("CLAI_SESSION_ID", "abc")
+
+	for _, cmd := range []string{"ls", "cd /tmp", " export TOKEN=secret"} {
+		t.Setenv("CLAI_CMD", cmd)
+		assert.Equal(t, 0, runIngest([]string{}), cmd)
+		assert.NoFileExists(t, spoolPath, "%q must be filtered", cmd)
+	}
+
+	t.Setenv("CLAI_CMD", "make test")
+	assert.Equal(t, 0, runIngest([]string{}))
+	assert.FileExists(t, spoolPath)
+}
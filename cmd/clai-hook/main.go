@@ -8,6 +8,7 @@
 // Subcommands:
 //   - ingest: Ingest a command execution event
 //   - session-start: Request a session ID from the daemon
+//   - flush: Replay events spooled while the daemon was unreachable
 //
 // See specs/tech_suggestions_v3.md for details.
 package main
@@ -16,6 +17,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 )
 
 // Version info - injected at build time via ldflags
@@ -25,6 +27,10 @@ var (
 	BuildDate = "unknown"
 )
 
+// processStart approximates when the process started, for the latency an
+// ingest reports to the daemon.
+var processStart = time.Now()
+
 func main() {
 	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -43,6 +49,8 @@ func run(args []string, stdout, stderr io.Writer) int {
 		return runIngest(cmdArgs)
 	case "session-start":
 		return runSessionStart(cmdArgs)
+	case "flush":
+		return runFlush(cmdArgs, stdout, stderr)
 	case "version", "--version", "-v":
 		printVersion(stdout)
 		return 0
@@ -68,23 +76,32 @@ Usage: clai-hook <command> [flags...]
 Commands:
   ingest           Ingest a command event from environment variables
   session-start    Request a session ID from daemon
+  flush            Replay events spooled while the daemon was unreachable
 
 Environment variables for 'ingest':
   CLAI_CMD         Raw command string (required unless --cmd-stdin)
   CLAI_CWD         Current working directory (required)
   CLAI_EXIT        Exit code of command (required)
   CLAI_TS          Timestamp in Unix milliseconds (required)
-  CLAI_SHELL       Shell type: bash, zsh, fish (required)
+  CLAI_SHELL       Shell type: bash, zsh, fish, pwsh, nu (required)
   CLAI_SESSION_ID  Session identifier (required)
   CLAI_DURATION_MS Command duration in milliseconds (optional)
   CLAI_EPHEMERAL   If "1", event is ephemeral/incognito (optional)
   CLAI_NO_RECORD   If "1", skip ingestion entirely (optional)
+  CLAI_PREV_CWD    Working directory before the command (optional)
+
+The environment context of the command is read from VIRTUAL_ENV,
+CONDA_DEFAULT_ENV, NVM_BIN, ASDF_<TOOL>_VERSION and the current-context
+of the first KUBECONFIG file, when set.
 
 Flags for 'ingest':
   --cmd-stdin      Read command from stdin instead of CLAI_CMD
 
+Events that cannot reach the daemon are spooled in the cache directory
+and replayed on the next ingest that reaches it, or by 'flush'.
+
 Exit codes:
-  0  Success (or daemon unavailable - silent drop)
+  0  Success (or daemon unavailable - event spooled)
   1  Invalid arguments
 
 For more information, see: https://github.com/runger/clai`)
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/ipc"
+	"github.com/runger/clai/internal/suggestions/batch"
+	"github.com/runger/clai/internal/suggestions/event"
+)
+
+// Events that cannot be delivered because the daemon is unreachable are
+// appended to a spool in the cache directory. The spool is replayed through
+// the CommandEventsBatch RPC on the next ingest that reaches the daemon, or
+// by `clai-hook flush`.
+const (
+	// hookSpoolMaxEvents and hookSpoolMaxBytes cap the spool; events past
+	// either limit are dropped.
+	hookSpoolMaxEvents = 5000
+	hookSpoolMaxBytes  = 4 << 20
+
+	// spoolFlushBatch is how many spooled events are replayed per call.
+	// Each becomes a start and an end event, well within the daemon's
+	// batch limit.
+	spoolFlushBatch = 250
+
+	// ingestFlushTimeout bounds the replay an ingest does on its way.
+	ingestFlushTimeout = 2 * time.Second
+
+	// spoolLockWait is how long a hook waits for another one to finish with
+	// the spool. It outlasts ingestFlushTimeout, so events are not dropped
+	// while another ingest replays.
+	spoolLockWait = 3 * time.Second
+
+	// spoolLockStale is the age at which a spool lock is taken to be left
+	// by a crashed hook and is broken.
+	spoolLockStale = 30 * time.Second
+)
+
+// errSpoolBusy is returned when the spool lock cannot be taken in time.
+var errSpoolBusy = errors.New("spool is locked by another clai-hook")
+
+// batchSender is the part of the daemon client the spool replays through.
+type batchSender interface {
+	CommandEventsBatch(ctx context.Context, events []*pb.CommandLifecycleEvent) (*pb.CommandEventsBatchResponse, error)
+}
+
+// replayResult counts what a replay delivered.
+type replayResult struct {
+	Sent     int // Events the daemon answered for
+	Rejected int // Start and end events it refused
+}
+
+// deliverEvent sends ev to the daemon, replaying spooled events first so
+// they stay in order. When client is nil or the daemon cannot be reached,
+// ev is spooled instead.
+func deliverEvent(ctx context.Context, spoolPath string, client batchSender, ev *event.CommandEvent) error {
+	unlock, err := lockSpool(spoolPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	spool, err := batch.OpenSpool(spoolPath, hookSpoolMaxEvents, hookSpoolMaxBytes)
+	if err != nil {
+		return err
+	}
+	defer spool.Close()
+
+	if client != nil {
+		ctx, cancel := context.WithTimeout(ctx, ingestFlushTimeout)
+		defer cancel()
+		if _, err := replaySpool(ctx, client, spool); err == nil {
+			if err := sendFresh(ctx, client, ev); err == nil {
+				return nil
+			}
+		}
+	}
+	return spool.Append(ev)
+}
+
+// flushSpool replays the spool at spoolPath to the daemon.
+func flushSpool(ctx context.Context, spoolPath string, client batchSender) (replayResult, error) {
+	unlock, err := lockSpool(spoolPath)
+	if err != nil {
+		return replayResult{}, err
+	}
+	defer unlock()
+
+	spool, err := batch.OpenSpool(spoolPath, hookSpoolMaxEvents, hookSpoolMaxBytes)
+	if err != nil {
+		return replayResult{}, err
+	}
+	defer spool.Close()
+	return replaySpool(ctx, client, spool)
+}
+
+// replaySpool sends the spooled events to the daemon, oldest first. Events
+// leave the spool once the daemon answered for them, including ones it
+// rejected, which sending again would not fix.
+func replaySpool(ctx context.Context, client batchSender, spool *batch.Spool) (replayResult, error) {
+	var res replayResult
+	for pending := spool.Len(); pending > 0; pending = spool.Len() {
+		events, pos, err := spool.Peek(spoolFlushBatch)
+		if err != nil {
+			return res, err
+		}
+		resp, err := sendEvents(ctx, client, events)
+		if err != nil {
+			return res, err
+		}
+		res.Sent += len(events)
+		res.Rejected += int(resp.GetFailed())
+		if err := spool.Ack(pos); err != nil {
+			return res, err
+		}
+		if spool.Len() >= pending {
+			break
+		}
+	}
+	return res, nil
+}
+
+// sendFresh delivers ev, which this hook just recorded, with how long the
+// hook has taken so far, for the daemon's latency budget.
+func sendFresh(ctx context.Context, client batchSender, ev *event.CommandEvent) error {
+	events := lifecycleEvents(ev)
+	events[0].GetStart().ClientLatencyMs = max(time.Since(processStart).Milliseconds(), 1)
+	_, err := client.CommandEventsBatch(ctx, events)
+	return err
+}
+
+// sendEvents delivers events through one CommandEventsBatch call.
+func sendEvents(ctx context.Context, client batchSender, events []*event.CommandEvent) (*pb.CommandEventsBatchResponse, error) {
+	if len(events) == 0 {
+		return &pb.CommandEventsBatchResponse{}, nil
+	}
+	batchEvents := make([]*pb.CommandLifecycleEvent, 0, 2*len(events))
+	for _, ev := range events {
+		batchEvents = append(batchEvents, lifecycleEvents(ev)...)
+	}
+	return client.CommandEventsBatch(ctx, batchEvents)
+}
+
+// lifecycleEvents turns a finished command into the start and end events
+// the daemon ingests. The command ID is derived from the event, so
+// replaying an event the daemon already took does not record it twice.
+func lifecycleEvents(ev *event.CommandEvent) []*pb.CommandLifecycleEvent {
+	var duration int64
+	if ev.DurationMs != nil {
+		duration = *ev.DurationMs
+	}
+	commandID := uuid.NewSHA1(uuid.NameSpaceOID,
+		fmt.Appendf(nil, "%s\x00%d\x00%s", ev.SessionID, ev.TS, ev.CmdRaw)).String()
+
+	return []*pb.CommandLifecycleEvent{
+		{Event: &pb.CommandLifecycleEvent_Start{Start: &pb.CommandStartRequest{
+			SessionId: ev.SessionID,
+			CommandId: commandID,
+			TsUnixMs:  ev.TS - duration,
+			Cwd:       ev.Cwd,
+			Command:   ev.CmdRaw,
+			PrevCwd:   ev.PrevCwd,
+			Env:       ev.Env,
+		}}},
+		{Event: &pb.CommandLifecycleEvent_End{End: &pb.CommandEndRequest{
+			SessionId:       ev.SessionID,
+			CommandId:       commandID,
+			TsUnixMs:        ev.TS,
+			ExitCode:        int32(ev.ExitCode), //nolint:gosec // G115: exit codes are bounded 0-255
+			DurationMs:      duration,
+			StdoutTail:      ev.Stdout,
+			StderrTail:      ev.Stderr,
+			OutputTruncated: ev.OutputTruncated,
+		}}},
+	}
+}
+
+// lockSpool takes the lock file guarding the spool, so concurrent hooks do
+// not replay the same events or lose ones appended during a replay. It
+// returns the func releasing the lock.
+func lockSpool(spoolPath string) (func(), error) {
+	lockPath := spoolPath + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o700); err != nil {
+		return nil, fmt.Errorf("create spool dir: %w", err)
+	}
+
+	deadline := time.Now().Add(spoolLockWait)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) //nolint:gosec // G304: lock path derived from the cache dir
+		if err == nil {
+			f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("lock spool: %w", err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > spoolLockStale {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errSpoolBusy
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// runFlush handles the flush subcommand.
+// It replays the events spooled while the daemon was unreachable.
+//
+// Exit codes:
+//   - 0: Spool replayed (or empty)
+//   - 1: Invalid arguments, or the daemon could not take the events
+func runFlush(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		fmt.Fprintf(stderr, "clai-hook flush: unexpected arguments: %v\n", args)
+		return 1
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		fmt.Fprintf(stderr, "clai-hook flush: daemon not reachable: %v\n", err)
+		return 1
+	}
+	defer client.Close()
+
+	res, err := flushSpool(context.Background(), config.DefaultPaths().HookSpoolFile(), client)
+	if res.Sent > 0 || err == nil {
+		fmt.Fprintf(stdout, "replayed %d spooled events", res.Sent)
+		if res.Rejected > 0 {
+			fmt.Fprintf(stdout, " (daemon rejected %d start/end events)", res.Rejected)
+		}
+		fmt.Fprintln(stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "clai-hook flush: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/suggestions/batch"
+	"github.com/runger/clai/internal/suggestions/event"
+)
+
+// fakeBatchSender records the commands it was sent, with the latency each
+// start reported, or fails with err.
+type fakeBatchSender struct {
+	err       error
+	failed    int32
+	commands  []string
+	latencies []int64
+}
+
+func (f *fakeBatchSender) CommandEventsBatch(_ context.Context, events []*pb.CommandLifecycleEvent) (*pb.CommandEventsBatchResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, ev := range events {
+		if start := ev.GetStart(); start != nil {
+			f.commands = append(f.commands, start.Command)
+			f.latencies = append(f.latencies, start.ClientLatencyMs)
+		}
+	}
+	return &pb.CommandEventsBatchResponse{Accepted: int32(len(events)) - f.failed, Failed: f.failed}, nil //nolint:gosec // G115: test batches are small
+}
+
+func testEvent(cmd string, ts int64) *event.CommandEvent {
+	ev := event.NewCommandEvent()
+	ev.SessionID = "session-1"
+	ev.Shell = event.ShellZsh
+	ev.Cwd = "/tmp"
+	ev.CmdRaw = cmd
+	ev.TS = ts
+	return ev
+}
+
+func spoolLen(t *testing.T, path string) int {
+	t.Helper()
+	spool, err := batch.OpenSpool(path, 0, 0)
+	require.NoError(t, err)
+	defer spool.Close()
+	return spool.Len()
+}
+
+func TestDeliverEvent_SpoolsUntilDaemonIsReachable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook.spool")
+	ctx := context.Background()
+
+	// No daemon: the event is spooled.
+	require.NoError(t, deliverEvent(ctx, path, nil, testEvent("make build", 1000)))
+	assert.Equal(t, 1, spoolLen(t, path))
+
+	// Daemon down mid-call: the spool keeps both events.
+	require.NoError(t, deliverEvent(ctx, path, &fakeBatchSender{err: errors.New("unavailable")}, testEvent("make test", 2000)))
+	assert.Equal(t, 2, spoolLen(t, path))
+
+	// Daemon back: spooled events are replayed first, in order.
+	client := &fakeBatchSender{}
+	require.NoError(t, deliverEvent(ctx, path, client, testEvent("git push", 3000)))
+	assert.Equal(t, []string{"make build", "make test", "git push"}, client.commands)
+	// Only the fresh event reports the hook's latency; replays are stale.
+	require.Len(t, client.latencies, 3)
+	assert.Zero(t, client.latencies[0])
+	assert.Zero(t, client.latencies[1])
+	assert.Positive(t, client.latencies[2])
+	assert.Equal(t, 0, spoolLen(t, path))
+	_, err := os.Stat(path + ".lock")
+	assert.True(t, os.IsNotExist(err), "spool lock should be released")
+}
+
+func TestFlushSpool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook.spool")
+	ctx := context.Background()
+	for i := range 3 {
+		require.NoError(t, deliverEvent(ctx, path, nil, testEvent("ls", int64(i))))
+	}
+
+	// Rejected events are not kept for another try.
+	client := &fakeBatchSender{failed: 1}
+	res, err := flushSpool(ctx, path, client)
+	require.NoError(t, err)
+	assert.Equal(t, replayResult{Sent: 3, Rejected: 1}, res)
+	assert.Len(t, client.commands, 3)
+	assert.Equal(t, 0, spoolLen(t, path))
+
+	res, err = flushSpool(ctx, path, client)
+	require.NoError(t, err)
+	assert.Equal(t, replayResult{}, res)
+}
+
+func TestDeliverEvent_SpoolLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook.spool")
+	ev := testEvent(string(make([]byte, hookSpoolMaxBytes)), 1)
+
+	err := deliverEvent(context.Background(), path, nil, ev)
+	assert.ErrorIs(t, err, batch.ErrSpoolFull)
+	assert.Equal(t, 0, spoolLen(t, path))
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	duration := int64(250)
+	ev := testEvent("go test ./...", 10_000)
+	ev.ExitCode = 2
+	ev.DurationMs = &duration
+
+	events := lifecycleEvents(ev)
+	require.Len(t, events, 2)
+	start, end := events[0].GetStart(), events[1].GetEnd()
+	require.NotNil(t, start)
+	require.NotNil(t, end)
+
+	assert.Equal(t, int64(9_750), start.TsUnixMs)
+	assert.Equal(t, "go test ./...", start.Command)
+	assert.Equal(t, int64(10_000), end.TsUnixMs)
+	assert.Equal(t, int32(2), end.ExitCode)
+	assert.Equal(t, start.CommandId, end.CommandId)
+
+	// Replaying the same event yields the same command ID.
+	assert.Equal(t, start.CommandId, lifecycleEvents(ev)[0].GetStart().CommandId)
+	assert.NotEqual(t, start.CommandId, lifecycleEvents(testEvent("go vet", 10_000))[0].GetStart().CommandId)
+}
+
+func TestLockSpool_BreaksStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook.spool")
+	lockPath := path + ".lock"
+	require.NoError(t, os.WriteFile(lockPath, nil, 0o600))
+	old := time.Now().Add(-2 * spoolLockStale)
+	require.NoError(t, os.Chtimes(lockPath, old, old))
+
+	unlock, err := lockSpool(path)
+	require.NoError(t, err)
+	unlock()
+	_, err = os.Stat(lockPath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -33,7 +33,7 @@ var (
 	defaultPathsFn   = config.DefaultPaths
 	acquireLockFn    = acquireLock
 	releaseLockFn    = releaseLock
-	loadConfigFn     = config.Load
+	loadConfigFn     = loadConfig
 
 	dispatchHistoryFn = dispatchHistory
 	dispatchSuggestFn = dispatchSuggest
@@ -437,6 +437,21 @@ func substituteTabArgs(srcTabs []config.TabDef, session string) []config.TabDef
 	return tabs
 }
 
+// withTabProviders returns history, with the "command" tabs of tabs served
+// by their commands, run in dir.
+func withTabProviders(tabs []config.TabDef, history picker.Provider, dir string) picker.Provider {
+	providers := make(map[string]picker.Provider)
+	for _, t := range tabs {
+		if t.Provider == "command" {
+			providers[t.ID] = picker.NewCommandProvider(t.Command, dir)
+		}
+	}
+	if len(providers) == 0 {
+		return history
+	}
+	return &picker.TabProviders{Default: history, Tabs: providers}
+}
+
 // socketPath returns the daemon socket path from config or the default.
 func socketPath(cfg *config.Config) string {
 	if cfg.Daemon.SocketPath != "" {
@@ -486,7 +501,7 @@ func runTUI(model picker.Model) (int, string) { //nolint:gocritic // bubbletea t
 // dispatchBuiltin runs the built-in Bubble Tea TUI for history.
 func dispatchBuiltin(cfg *config.Config, opts *pickerOpts) int {
 	tabs := resolveTabs(cfg, opts)
-	provider := newHistoryProviderFn(socketPath(cfg))
+	provider := withTabProviders(tabs, newHistoryProviderFn(socketPath(cfg)), opts.cwd)
 
 	model := picker.NewModel(tabs, provider).WithLayout(picker.LayoutBottomUp)
 	if opts.query != "" {
@@ -580,8 +595,8 @@ func dispatchFzf(cfg *config.Config, opts *pickerOpts) int {
 
 // runFzfBackend fetches all history and pipes it through fzf.
 func runFzfBackend(cfg *config.Config, opts *pickerOpts) (string, error) {
-	provider := newHistoryProviderFn(socketPath(cfg))
 	tabs := resolveTabs(cfg, opts)
+	provider := withTabProviders(tabs, newHistoryProviderFn(socketPath(cfg)), opts.cwd)
 
 	// Use the first tab for fzf (fzf doesn't support tabs).
 	var tabID string
@@ -646,6 +661,24 @@ func debugLog(format string, args ...any) {
 	}
 }
 
+// loadConfig loads the config, with the picker tabs of a trusted
+// .clai/config.yaml in the current repository merged over it.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return cfg, nil
+	}
+	merged, _, status, repoErr := cfg.LoadRepo(config.DefaultPaths(), cwd)
+	if status != config.RepoApplied && status != config.RepoNone {
+		debugLog("repository config %s: %v", status, repoErr)
+	}
+	return merged, nil
+}
+
 // printUsage prints the top-level usage message.
 func printUsage() {
 	fmt.Fprintln(os.Stderr, `Usage: clai-picker <command> [flags]
@@ -389,6 +389,23 @@ func TestResolveTabs_UnknownFallsBackToAll(t *testing.T) {
 	}
 }
 
+func TestWithTabProviders(t *testing.T) {
+	history := picker.NewHistoryProvider("/tmp/none.sock")
+	tabs := config.DefaultConfig().History.PickerTabs
+	if got := withTabProviders(tabs, history, ""); got != picker.Provider(history) {
+		t.Errorf("withTabProviders(history tabs) = %T, want the history provider", got)
+	}
+
+	tabs = append(tabs, config.TabDef{ID: "hosts", Provider: "command", Command: "echo a"})
+	routed, ok := withTabProviders(tabs, history, "").(*picker.TabProviders)
+	if !ok {
+		t.Fatalf("withTabProviders(command tab) is not a *picker.TabProviders")
+	}
+	if _, ok := routed.Tabs["hosts"].(*picker.CommandProvider); !ok || len(routed.Tabs) != 1 {
+		t.Errorf("Tabs = %v, want a command provider for hosts only", routed.Tabs)
+	}
+}
+
 // --- Socket path tests ---
 
 func TestSocketPath_DefaultWhenEmpty(t *testing.T) {
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Output formats for `clai-shim suggest --format`. Every format prints one
+// suggestion per line; the shell formats quote each suggestion as a single
+// word of that shell's syntax, so multi-line and quoted commands survive
+// the trip into the line editor:
+//
+//	zsh:   BUFFER=${(Q)line}
+//	bash:  eval "READLINE_LINE=$line"
+//	fish:  commandline -r -- (string unescape -- $line)
+const (
+	formatPlain = "plain"
+	formatZsh   = "zsh"
+	formatBash  = "bash"
+	formatFish  = "fish"
+)
+
+// resolveFormat returns the output format for name, falling back to plain
+// for empty or unknown names.
+func resolveFormat(name string) string {
+	switch name {
+	case formatZsh, formatBash, formatFish:
+		return name
+	default:
+		return formatPlain
+	}
+}
+
+// formatSuggestion renders text for the given output format.
+func formatSuggestion(text, format string) string {
+	switch format {
+	case formatZsh, formatBash:
+		return quotePOSIX(text)
+	case formatFish:
+		return quoteFish(text)
+	default:
+		return text
+	}
+}
+
+// quotePOSIX quotes s for zsh and bash. Printable text is single-quoted;
+// text with control characters uses ANSI-C $'...' quoting, which both
+// shells understand, so a newline never splits the line.
+func quotePOSIX(s string) string {
+	if !strings.ContainsFunc(s, isControl) {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+	var b strings.Builder
+	b.WriteString("$'")
+	for _, r := range s {
+		switch r {
+		case '\\', '\'':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			if esc, ok := controlEscape(r); ok {
+				b.WriteString(esc)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteString("'")
+	return b.String()
+}
+
+// quoteFish quotes s for fish. Inside fish single quotes only \' and \\
+// are escapes, so control characters are written as bare escapes between
+// quoted runs: 'echo a'\n'echo b'.
+func quoteFish(s string) string {
+	var b strings.Builder
+	quoted := false
+	for _, r := range s {
+		if esc, ok := controlEscape(r); ok {
+			if quoted {
+				b.WriteByte('\'')
+				quoted = false
+			}
+			b.WriteString(esc)
+			continue
+		}
+		if !quoted {
+			b.WriteByte('\'')
+			quoted = true
+		}
+		if r == '\\' || r == '\'' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	if quoted {
+		b.WriteByte('\'')
+	}
+	if b.Len() == 0 {
+		return "''"
+	}
+	return b.String()
+}
+
+func isControl(r rune) bool {
+	return unicode.IsControl(r)
+}
+
+// controlEscape returns the backslash escape for a control character,
+// using the forms zsh, bash and fish share.
+func controlEscape(r rune) (string, bool) {
+	if !isControl(r) {
+		return "", false
+	}
+	switch r {
+	case '\n':
+		return `\n`, true
+	case '\t':
+		return `\t`, true
+	case '\r':
+		return `\r`, true
+	case '\x1b':
+		return `\e`, true
+	}
+	if r < 0x80 {
+		return fmt.Sprintf(`\x%02x`, r), true
+	}
+	return fmt.Sprintf(`\u%04x`, r), true
+}
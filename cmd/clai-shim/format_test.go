@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFormat(t *testing.T) {
+	assert.Equal(t, formatZsh, resolveFormat("zsh"))
+	assert.Equal(t, formatBash, resolveFormat("bash"))
+	assert.Equal(t, formatFish, resolveFormat("fish"))
+	assert.Equal(t, formatPlain, resolveFormat("plain"))
+	assert.Equal(t, formatPlain, resolveFormat(""))
+	assert.Equal(t, formatPlain, resolveFormat("pwsh"))
+}
+
+func TestFormatSuggestion(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		format string
+		want   string
+	}{
+		{"plain is verbatim", "echo 'hi'", formatPlain, "echo 'hi'"},
+		{"zsh simple", "git status", formatZsh, "'git status'"},
+		{"zsh single quote", "echo 'hi'", formatZsh, `'echo '\''hi'\'''`},
+		{"bash multi-line", "echo a\necho 'b'", formatBash, `$'echo a\necho \'b\''`},
+		{"bash backslash and tab", "printf '\\t'\t#", formatBash, `$'printf \'\\t\'\t#'`},
+		{"bash escape byte", "echo \x1b[0m\x01", formatBash, `$'echo \e[0m\x01'`},
+		{"fish simple", "git status", formatFish, "'git status'"},
+		{"fish quotes", `echo 'a\b'`, formatFish, `'echo \'a\\b\''`},
+		{"fish multi-line", "echo a\necho b", formatFish, `'echo a'\n'echo b'`},
+		{"fish trailing newline", "ls\n", formatFish, `'ls'\n`},
+		{"fish empty", "", formatFish, "''"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatSuggestion(tt.text, tt.format))
+		})
+	}
+}
+
+func TestFormatSuggestion_BashRoundTrip(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	for _, text := range []string{
+		"git commit -m 'fix: it'",
+		"echo \"$HOME\" \\\n  && ls",
+		"printf '%s\\n' a\tb",
+		"echo `date` $(pwd) !!",
+	} {
+		line := formatSuggestion(text, formatBash)
+		out, err := exec.Command(bash, "-c", `eval "v=$1"; printf '%s' "$v"`, "bash", line).Output()
+		require.NoError(t, err, line)
+		assert.Equal(t, text, string(out), line)
+	}
+}
@@ -6,8 +6,10 @@
 //   - session-end: Notify daemon of shell session ending
 //   - log-start: Log command start
 //   - log-end: Log command completion
-//   - suggest: Get command suggestions
+//   - suggest: Get command suggestions, quoted for a shell with --format
 //   - text-to-command: Convert natural language to commands
+//   - feedback: Rate the suggestion shown before the last command
+//   - confirm: Warn about a destructive command before the shell runs it
 //   - --persistent: Enter persistent mode (NDJSON stdin loop)
 package main
 
@@ -15,14 +17,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/runger/clai/internal/config"
 	"github.com/runger/clai/internal/ipc"
+	"github.com/runger/clai/internal/sanitize"
 	"github.com/runger/clai/internal/shim"
+	"github.com/runger/clai/internal/statusview"
 )
 
 // Version info - injected at build time via ldflags
@@ -32,6 +40,10 @@ var (
 	BuildDate = "unknown"
 )
 
+// processStart approximates when the process started, for the latency
+// log-start and suggest report to the daemon.
+var processStart = time.Now()
+
 // Flag name constants to avoid duplication
 const (
 	flagSessionID     = "session-id"
@@ -42,8 +54,11 @@ const (
 	flagBuffer        = "buffer"
 	flagCursor        = "cursor"
 	flagPrompt        = "prompt"
+	flagFormat        = "format"
 	flagExitCode      = "exit-code"
 	flagDuration      = "duration"
+	flagStdoutFile    = "stdout-file"
+	flagStderrFile    = "stderr-file"
 	flagGitBranch     = "git-branch"
 	flagGitRepoName   = "git-repo-name"
 	flagGitRepoRoot   = "git-repo-root"
@@ -51,6 +66,9 @@ const (
 	flagHistoryPath   = "history-path"
 	flagIfNotExists   = "if-not-exists"
 	flagForce         = "force"
+	flagJSON          = "json"
+	flagVerbose       = "verbose"
+	flagLast          = "last"
 )
 
 func main() {
@@ -82,6 +100,10 @@ func main() {
 		runSuggest()
 	case "text-to-command":
 		runTextToCommand()
+	case "feedback":
+		runFeedback()
+	case "confirm":
+		runConfirm()
 	case "ping":
 		runPing()
 	case "status":
@@ -111,12 +133,17 @@ Usage: clai-shim <command> [flags...]
 
 Commands:
   --persistent                                Enter persistent NDJSON stdin mode
-  session-start, session-end, log-start, log-end, suggest, text-to-command
-  import-history, ping, status, version, help
+  session-start, session-end, log-start, log-end, text-to-command
+  suggest [--format zsh|bash|fish|plain]      Quote suggestions for a shell
+  feedback up|down [--last], confirm --command CMD, import-history, ping
+  status [--json] [--verbose]
+  version, help
 
 Environment:
   CLAI_SOCKET       Override daemon socket path
-  CLAI_DAEMON_PATH  Override daemon binary path`
+  CLAI_DAEMON_PATH  Override daemon binary path
+  CLAI_EPHEMERAL    If "1", the daemon keeps logged commands in memory only
+  CLAI_NO_RECORD    If "1", log-start and log-end send nothing`
 	fmt.Println(usage)
 }
 
@@ -200,7 +227,7 @@ func runLogStart() {
 	commandID := flags[flagCommandID]
 	cwd := flags[flagCwd]
 	command := flags[flagCommand]
-	if sessionID == "" || commandID == "" {
+	if sessionID == "" || commandID == "" || os.Getenv("CLAI_NO_RECORD") == "1" {
 		return
 	}
 	if cwd == "" {
@@ -211,11 +238,13 @@ func runLogStart() {
 		return
 	}
 	defer client.Close()
+	client.MeasureFrom(processStart)
 	cmdCtx := &ipc.CommandContext{
 		GitBranch:     flags[flagGitBranch],
 		GitRepoName:   flags[flagGitRepoName],
 		GitRepoRoot:   flags[flagGitRepoRoot],
 		PrevCommandID: flags[flagPrevCommandID],
+		Ephemeral:     os.Getenv("CLAI_EPHEMERAL") == "1",
 	}
 	client.LogStartWithContext(sessionID, commandID, cwd, command, cmdCtx)
 }
@@ -226,7 +255,7 @@ func runLogEnd() {
 	commandID := flags[flagCommandID]
 	exitCodeStr := flags[flagExitCode]
 	durationStr := flags[flagDuration]
-	if sessionID == "" || commandID == "" {
+	if sessionID == "" || commandID == "" || os.Getenv("CLAI_NO_RECORD") == "1" {
 		return
 	}
 	exitCode, _ := strconv.Atoi(exitCodeStr)
@@ -236,7 +265,33 @@ func runLogEnd() {
 		return
 	}
 	defer client.Close()
-	client.LogEnd(sessionID, commandID, exitCode, durationMs)
+	stdout := readTail(flags[flagStdoutFile], ipc.MaxOutputTailBytes)
+	stderr := readTail(flags[flagStderrFile], ipc.MaxOutputTailBytes)
+	client.LogEndWithOutput(sessionID, commandID, exitCode, durationMs, stdout, stderr)
+}
+
+// readTail returns up to the last maxBytes bytes of the file at path, for
+// shells that capture a command's output to a file. Errors yield "".
+func readTail(path string, maxBytes int64) string {
+	if path == "" {
+		return ""
+	}
+	f, err := os.Open(path) //nolint:gosec // G304: path is the shell's own capture file
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() > maxBytes {
+		if _, err := f.Seek(-maxBytes, io.SeekEnd); err != nil {
+			return ""
+		}
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes))
+	if err != nil {
+		return ""
+	}
+	return strings.ToValidUTF8(string(data), "")
 }
 
 func runSuggest() {
@@ -246,6 +301,7 @@ func runSuggest() {
 	buffer := flags[flagBuffer]
 	cursorStr := flags[flagCursor]
 	limitStr := flags["limit"]
+	format := resolveFormat(flags[flagFormat])
 	if sessionID == "" {
 		if len(os.Args) >= 5 {
 			sessionID = os.Args[2]
@@ -274,6 +330,7 @@ func runSuggest() {
 		return
 	}
 	defer client.Close()
+	client.MeasureFrom(processStart)
 	ctx, cancel := signalAwareContext()
 	defer cancel()
 	suggestions := client.Suggest(ctx, sessionID, cwd, buffer, cursorPos, false, limit)
@@ -281,7 +338,7 @@ func runSuggest() {
 		return
 	}
 	for _, s := range suggestions {
-		fmt.Println(s.Text)
+		fmt.Println(formatSuggestion(s.Text, format))
 	}
 }
 
@@ -317,6 +374,65 @@ func runTextToCommand() {
 	fmt.Println(resp.Suggestions[0].Text)
 }
 
+// runFeedback records a thumbs up or down for the suggestion shown before
+// the session's last command: clai-shim feedback up|down [--last].
+func runFeedback() {
+	if len(os.Args) < 3 || (os.Args[2] != "up" && os.Args[2] != "down") {
+		fmt.Println("usage: clai-shim feedback up|down [--last] [--session-id ID]")
+		return
+	}
+	flags := parseFlags(os.Args[3:])
+	sessionID := flags[flagSessionID]
+	if sessionID == "" {
+		sessionID = os.Getenv("CLAI_SESSION_ID")
+	}
+	if sessionID == "" {
+		fmt.Println("no clai session in this shell")
+		return
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		fmt.Println("not connected")
+		return
+	}
+	defer client.Close()
+	resp, err := client.SnapshotFeedback(context.Background(), sessionID, os.Args[2] == "up", flags[flagLast] == "true")
+	switch {
+	case err != nil:
+		fmt.Println("failed to record feedback")
+	case !resp.Ok:
+		fmt.Println(resp.GetError().GetMessage())
+	default:
+		fmt.Printf("%s: %s\n", resp.Action, resp.SuggestedText)
+	}
+}
+
+// runConfirm prints a warning when the command about to run looks
+// destructive, for the shell to ask whether to run it anyway
+// (suggestions.confirm_risky). It prints nothing otherwise.
+func runConfirm() {
+	flags := parseFlags(os.Args[2:])
+	if warning := riskWarning(flags[flagCommand], config.LoadHookSettings()); warning != "" {
+		fmt.Println(warning)
+	}
+}
+
+// riskWarning returns the warning for command, or "" when no risk pattern
+// flags it or the pattern is listed in suggestions.confirm_risky.allow.
+func riskWarning(command string, settings config.HookSettings) string {
+	// Invalid custom patterns are skipped, as in the daemon.
+	_ = sanitize.SetRiskRules(sanitize.RulesFromConfig(settings.RiskPatterns))
+	name, message, ok := sanitize.Match(command)
+	if !ok || slices.Contains(settings.ConfirmRisky.Allow, name) {
+		return ""
+	}
+	if message != "" {
+		return fmt.Sprintf("clai: this looks destructive (%s): %s", name, message)
+	}
+	return fmt.Sprintf("clai: this looks destructive (%s)", name)
+}
+
 func runPing() {
 	client, err := ipc.NewClient()
 	if err != nil {
@@ -332,25 +448,46 @@ func runPing() {
 }
 
 func runStatus() {
+	flags := parseFlags(os.Args[2:])
+	asJSON := flags[flagJSON] == "true"
+	verbose := flags[flagVerbose] == "true"
+
 	client, err := ipc.NewClient()
 	if err != nil {
-		fmt.Println(`{"error": "not connected"}`)
+		printStatusError(asJSON, "not connected")
 		return
 	}
 	defer client.Close()
 	status, err := client.GetStatus()
 	if err != nil {
-		fmt.Println(`{"error": "failed to get status"}`)
+		printStatusError(asJSON, "failed to get status")
 		return
 	}
-	output := map[string]interface{}{
-		"version":         status.Version,
-		"active_sessions": status.ActiveSessions,
-		"uptime_seconds":  status.UptimeSeconds,
-		"commands_logged": status.CommandsLogged,
+	view := statusview.FromProto(status, Version)
+	if verbose {
+		health, err := client.Health()
+		if err != nil {
+			printStatusError(asJSON, "failed to get health")
+			return
+		}
+		view.SetHealth(health)
 	}
-	data, _ := json.Marshal(output)
-	fmt.Println(string(data))
+	if asJSON {
+		_ = view.WriteJSON(os.Stdout)
+		return
+	}
+	fmt.Println("clai daemon")
+	view.Render(os.Stdout, time.Now())
+}
+
+// printStatusError reports a status failure as JSON or as plain text.
+func printStatusError(asJSON bool, msg string) {
+	if asJSON {
+		data, _ := json.Marshal(map[string]string{"error": msg})
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Printf("clai daemon: %s\n", msg)
 }
 
 func runImportHistory() {
@@ -359,6 +496,10 @@ func runImportHistory() {
 	historyPath := flags[flagHistoryPath]
 	ifNotExists := flags[flagIfNotExists] == "true"
 	force := flags[flagForce] == "true"
+	sessionID := flags[flagSessionID]
+	if sessionID == "" {
+		sessionID = os.Getenv("CLAI_SESSION_ID")
+	}
 	if shell == "" {
 		shell = "auto"
 	}
@@ -370,7 +511,7 @@ func runImportHistory() {
 	defer client.Close()
 	ctx, cancel := signalAwareContext()
 	defer cancel()
-	resp, err := client.ImportHistory(ctx, shell, historyPath, ifNotExists, force)
+	resp, err := client.ImportHistory(ctx, sessionID, shell, historyPath, ifNotExists, force)
 	if err != nil {
 		output := map[string]interface{}{
 			"error": err.Error(),
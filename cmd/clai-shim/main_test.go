@@ -1,9 +1,15 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/sanitize"
 )
 
 func TestParseFlags_EmptyArgs(t *testing.T) {
@@ -82,3 +88,30 @@ func TestParseFlags_SingleDashValue(t *testing.T) {
 	result := parseFlags([]string{"--flag", "-value"})
 	assert.Equal(t, "-value", result["flag"])
 }
+
+func TestReadTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stderr")
+	require.NoError(t, os.WriteFile(path, []byte("line 1\nline 2\n"), 0o600))
+
+	assert.Equal(t, "line 1\nline 2\n", readTail(path, 100))
+	assert.Equal(t, "line 2\n", readTail(path, 7))
+	assert.Empty(t, readTail(filepath.Join(t.TempDir(), "missing"), 100))
+	assert.Empty(t, readTail("", 100))
+}
+
+func TestRiskWarning(t *testing.T) {
+	t.Cleanup(func() { _ = sanitize.SetRiskRules(nil) })
+
+	settings := config.HookSettings{
+		ConfirmRisky: config.ConfirmRisky{Enabled: true, Allow: []string{"kill -9"}},
+		RiskPatterns: []config.RiskPattern{
+			{Name: "terraform destroy", Pattern: `\bterraform\s+destroy\b`, Message: "destroys infrastructure"},
+		},
+	}
+
+	assert.Equal(t, "clai: this looks destructive (rm -rf)", riskWarning("rm -rf build", settings))
+	assert.Equal(t, "clai: this looks destructive (terraform destroy): destroys infrastructure",
+		riskWarning("terraform destroy -auto-approve", settings))
+	assert.Empty(t, riskWarning("kill -9 1234", settings), "allowed pattern")
+	assert.Empty(t, riskWarning("ls -la", settings))
+}
@@ -4,15 +4,23 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/runger/clai/internal/claude"
 	"github.com/runger/clai/internal/config"
 	"github.com/runger/clai/internal/daemon"
+	"github.com/runger/clai/internal/dbcrypt"
+	"github.com/runger/clai/internal/logging"
 	"github.com/runger/clai/internal/storage"
+	"github.com/runger/clai/internal/suggestions/clock"
 	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/maintenance"
+	"github.com/runger/clai/internal/suggestions/normalize"
+	"github.com/runger/clai/internal/suggestions/rekey"
 )
 
 // claudeLLM adapts claude.QueryWithContext to the daemon.LLMQuerier interface.
@@ -29,23 +37,131 @@ func main() {
 	}
 }
 
+// clockFromEnv returns the daemon clock. CLAI_CLOCK_OFFSET (a Go duration
+// such as "720h") shifts "now" for scoring and decay so users can preview
+// how their current history will rank in the future.
+func clockFromEnv(logger *slog.Logger) clock.Clock {
+	raw := os.Getenv("CLAI_CLOCK_OFFSET")
+	if raw == "" {
+		return clock.System
+	}
+	offset, err := time.ParseDuration(raw)
+	if err != nil {
+		logger.Warn("ignoring invalid CLAI_CLOCK_OFFSET", "value", raw, "error", err)
+		return clock.System
+	}
+	logger.Warn("daemon clock is offset from wall time", "offset", offset)
+	return clock.Offset(clock.System, offset)
+}
+
+// rekeyStaleTemplates moves learned stats to the current template ID scheme
+// after an upgrade changed it. Failures are logged; the daemon keeps
+// running with the old IDs.
+func rekeyStaleTemplates(ctx context.Context, db *sql.DB, logger *slog.Logger) {
+	stale, err := rekey.NeedsRekey(ctx, db)
+	if err != nil {
+		logger.Warn("failed to check template ID version", "error", err)
+		return
+	}
+	if !stale {
+		return
+	}
+	result, err := rekey.Run(ctx, db, rekey.Options{})
+	if err != nil {
+		logger.Warn("failed to re-key templates", "error", err)
+		return
+	}
+	logger.Info("re-keyed templates to new ID scheme",
+		"version", normalize.TemplateIDVersion,
+		"templates", result.Templates,
+		"remapped", result.Remapped,
+		"merged", result.Merged,
+	)
+}
+
+// migrateLegacyDBs moves suggestion databases left in ~/.clai by versions
+// that ignored CLAI_HOME and the data directory. On failure the daemon
+// starts with a fresh database and the legacy one stays where it was.
+func migrateLegacyDBs(paths *config.Paths, logger *slog.Logger) {
+	legacyDir, err := suggestdb.LegacyDir()
+	if err != nil {
+		return
+	}
+	moved, err := suggestdb.MigrateLegacyDBs(legacyDir, paths.DataDir())
+	for _, path := range moved {
+		logger.Info("moved suggestions database", "from", legacyDir, "to", path)
+	}
+	if err != nil {
+		logger.Warn("failed to move suggestions database", "from", legacyDir, "error", err)
+	}
+}
+
+// loadDatabaseKey loads the privacy.db_encryption key, if any, and encrypts
+// plaintext databases in place. It runs before the databases are opened.
+func loadDatabaseKey(cfg *config.Config, paths *config.Paths, logger *slog.Logger) ([]byte, error) {
+	key, err := dbcrypt.LoadKey(cfg, paths)
+	if err != nil || key == nil {
+		return nil, err
+	}
+
+	v2Path, err := suggestdb.DefaultDBPath()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range []string{paths.DatabaseFile(), v2Path} {
+		encrypted, err := dbcrypt.Encrypt(path, key)
+		if err != nil {
+			return nil, err
+		}
+		if encrypted {
+			logger.Info("encrypted database", "path", path)
+		}
+	}
+	return key, nil
+}
+
 func run() error {
-	// Set up logging
-	logHandler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	})
-	logger := slog.New(logHandler)
+	if err := config.ValidateProfile(config.Profile()); err != nil {
+		return err
+	}
 
 	// Load configuration
 	paths := config.DefaultPaths()
+	appCfg, cfgErr := config.LoadFromFile(paths.ConfigFile())
+	if cfgErr != nil {
+		appCfg = config.DefaultConfig()
+	}
+
+	// Set up logging. The level is adjustable at runtime via config reload;
+	// format and output are fixed until restart.
+	logOut, err := logging.OpenOutput(&appCfg.Daemon, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "claid: %v; logging to stderr\n", err)
+		stderrCfg := appCfg.Daemon
+		stderrCfg.LogFile = logging.Stderr
+		logOut, _ = logging.OpenOutput(&stderrCfg, paths)
+	}
+	defer logOut.Close()
+	logLevel := new(slog.LevelVar)
+	logger := slog.New(logging.NewHandler(logOut, appCfg.Daemon.LogFormat, logLevel))
+	if cfgErr != nil {
+		logger.Warn("failed to load config, using defaults", "error", cfgErr)
+	}
 
 	// Ensure directories exist
 	if err := paths.EnsureDirectories(); err != nil {
 		return fmt.Errorf("failed to create directories: %w", err)
 	}
 
+	migrateLegacyDBs(paths, logger)
+
+	dbKey, err := loadDatabaseKey(appCfg, paths, logger)
+	if err != nil {
+		return fmt.Errorf("failed to set up database encryption: %w", err)
+	}
+
 	// Open database
-	store, err := storage.NewSQLiteStore(paths.DatabaseFile())
+	store, err := storage.NewSQLiteStoreWithKey(paths.DatabaseFile(), dbKey)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -53,22 +169,42 @@ func run() error {
 
 	// Open V2 suggestions database (graceful degradation if unavailable)
 	ctx := context.Background()
-	v2db, err := suggestdb.Open(ctx, suggestdb.Options{})
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		EncryptionKey:  dbKey,
+		Logger:         logger,
+		EnableRecovery: true,
+		ReadConns:      suggestdb.DefaultReadConns,
+	})
 	if err != nil {
 		logger.Warn("V2 suggestions database unavailable, continuing with V1 only", "error", err)
 		// v2db stays nil — graceful degradation
 	}
+	clk := clockFromEnv(logger)
+
+	// Retention, archival, index upkeep and the storage quota run on the
+	// suggestions database; the server starts and stops the runner.
+	var runner *maintenance.Runner
 	if v2db != nil {
 		defer v2db.Close()
+		rekeyStaleTemplates(ctx, v2db.DB(), logger)
+
+		maintCfg := daemon.MaintenanceConfig(appCfg, v2db.Path())
+		maintCfg.Logger = logger
+		maintCfg.Clock = clk
+		runner = maintenance.NewRunner(v2db.DB(), maintCfg)
 	}
 
 	// Create server config
 	cfg := &daemon.ServerConfig{
-		Store:  store,
-		V2DB:   v2db,
-		Paths:  paths,
-		Logger: logger,
-		LLM:    &claudeLLM{},
+		Store:             store,
+		V2DB:              v2db,
+		Paths:             paths,
+		Logger:            logger,
+		LogLevel:          logLevel,
+		Config:            appCfg,
+		LLM:               &claudeLLM{},
+		Clock:             clk,
+		MaintenanceRunner: runner,
 	}
 
 	// Run the daemon (blocks until shutdown)
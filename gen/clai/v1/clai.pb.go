@@ -78,11 +78,69 @@ func (SearchMode) EnumDescriptor() ([]byte, []int) {
 	return file_clai_v1_clai_proto_rawDescGZIP(), []int{0}
 }
 
+type ShellEventType int32
+
+const (
+	ShellEventType_SHELL_EVENT_TYPE_UNSPECIFIED   ShellEventType = 0
+	ShellEventType_SHELL_EVENT_TYPE_SESSION_START ShellEventType = 1
+	ShellEventType_SHELL_EVENT_TYPE_SESSION_END   ShellEventType = 2
+	ShellEventType_SHELL_EVENT_TYPE_COMMAND_START ShellEventType = 3
+	ShellEventType_SHELL_EVENT_TYPE_COMMAND_END   ShellEventType = 4
+	ShellEventType_SHELL_EVENT_TYPE_DIAGNOSIS     ShellEventType = 5 // A command failed repeatedly and was diagnosed (ai.auto_diagnose)
+)
+
+// Enum value maps for ShellEventType.
+var (
+	ShellEventType_name = map[int32]string{
+		0: "SHELL_EVENT_TYPE_UNSPECIFIED",
+		1: "SHELL_EVENT_TYPE_SESSION_START",
+		2: "SHELL_EVENT_TYPE_SESSION_END",
+		3: "SHELL_EVENT_TYPE_COMMAND_START",
+		4: "SHELL_EVENT_TYPE_COMMAND_END",
+		5: "SHELL_EVENT_TYPE_DIAGNOSIS",
+	}
+	ShellEventType_value = map[string]int32{
+		"SHELL_EVENT_TYPE_UNSPECIFIED":   0,
+		"SHELL_EVENT_TYPE_SESSION_START": 1,
+		"SHELL_EVENT_TYPE_SESSION_END":   2,
+		"SHELL_EVENT_TYPE_COMMAND_START": 3,
+		"SHELL_EVENT_TYPE_COMMAND_END":   4,
+		"SHELL_EVENT_TYPE_DIAGNOSIS":     5,
+	}
+)
+
+func (x ShellEventType) Enum() *ShellEventType {
+	p := new(ShellEventType)
+	*p = x
+	return p
+}
+
+func (x ShellEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ShellEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_clai_v1_clai_proto_enumTypes[1].Descriptor()
+}
+
+func (ShellEventType) Type() protoreflect.EnumType {
+	return &file_clai_v1_clai_proto_enumTypes[1]
+}
+
+func (x ShellEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ShellEventType.Descriptor instead.
+func (ShellEventType) EnumDescriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{1}
+}
+
 type ClientInfo struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
 	Os            string                 `protobuf:"bytes,2,opt,name=os,proto3" json:"os,omitempty"`             // darwin, linux, windows
-	Shell         string                 `protobuf:"bytes,3,opt,name=shell,proto3" json:"shell,omitempty"`       // zsh, bash, pwsh
+	Shell         string                 `protobuf:"bytes,3,opt,name=shell,proto3" json:"shell,omitempty"`       // zsh, bash, fish, pwsh, nu
 	Hostname      string                 `protobuf:"bytes,4,opt,name=hostname,proto3" json:"hostname,omitempty"` // machine hostname
 	Username      string                 `protobuf:"bytes,5,opt,name=username,proto3" json:"username,omitempty"` // current user
 	unknownFields protoimpl.UnknownFields
@@ -389,37 +447,32 @@ func (x *SessionEndRequest) GetEndedAtUnixMs() int64 {
 	return 0
 }
 
-type CommandStartRequest struct {
-	state     protoimpl.MessageState `protogen:"open.v1"`
-	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	CommandId string                 `protobuf:"bytes,2,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"` // UUID v4
-	TsUnixMs  int64                  `protobuf:"varint,3,opt,name=ts_unix_ms,json=tsUnixMs,proto3" json:"ts_unix_ms,omitempty"`
-	Cwd       string                 `protobuf:"bytes,4,opt,name=cwd,proto3" json:"cwd,omitempty"`
-	Command   string                 `protobuf:"bytes,5,opt,name=command,proto3" json:"command,omitempty"` // Raw command string
-	// Git context (optional, captured by shell shim)
-	GitBranch   string `protobuf:"bytes,6,opt,name=git_branch,json=gitBranch,proto3" json:"git_branch,omitempty"`
-	GitRepoName string `protobuf:"bytes,7,opt,name=git_repo_name,json=gitRepoName,proto3" json:"git_repo_name,omitempty"`
-	GitRepoRoot string `protobuf:"bytes,8,opt,name=git_repo_root,json=gitRepoRoot,proto3" json:"git_repo_root,omitempty"`
-	// Sequence tracking
-	PrevCommandId string `protobuf:"bytes,9,opt,name=prev_command_id,json=prevCommandId,proto3" json:"prev_command_id,omitempty"`
+// SessionNoteRequest sets, clears, or reads the free-text note attached to a
+// session. The note is included in AI prompts for that session.
+// An empty note with clear=false only reads the current note.
+type SessionNoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Note          string                 `protobuf:"bytes,2,opt,name=note,proto3" json:"note,omitempty"`
+	Clear         bool                   `protobuf:"varint,3,opt,name=clear,proto3" json:"clear,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CommandStartRequest) Reset() {
-	*x = CommandStartRequest{}
+func (x *SessionNoteRequest) Reset() {
+	*x = SessionNoteRequest{}
 	mi := &file_clai_v1_clai_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CommandStartRequest) String() string {
+func (x *SessionNoteRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CommandStartRequest) ProtoMessage() {}
+func (*SessionNoteRequest) ProtoMessage() {}
 
-func (x *CommandStartRequest) ProtoReflect() protoreflect.Message {
+func (x *SessionNoteRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_clai_v1_clai_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -431,100 +484,116 @@ func (x *CommandStartRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CommandStartRequest.ProtoReflect.Descriptor instead.
-func (*CommandStartRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use SessionNoteRequest.ProtoReflect.Descriptor instead.
+func (*SessionNoteRequest) Descriptor() ([]byte, []int) {
 	return file_clai_v1_clai_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *CommandStartRequest) GetSessionId() string {
+func (x *SessionNoteRequest) GetSessionId() string {
 	if x != nil {
 		return x.SessionId
 	}
 	return ""
 }
 
-func (x *CommandStartRequest) GetCommandId() string {
+func (x *SessionNoteRequest) GetNote() string {
 	if x != nil {
-		return x.CommandId
+		return x.Note
 	}
 	return ""
 }
 
-func (x *CommandStartRequest) GetTsUnixMs() int64 {
+func (x *SessionNoteRequest) GetClear() bool {
 	if x != nil {
-		return x.TsUnixMs
+		return x.Clear
 	}
-	return 0
+	return false
 }
 
-func (x *CommandStartRequest) GetCwd() string {
-	if x != nil {
-		return x.Cwd
-	}
-	return ""
+type SessionNoteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Note          string                 `protobuf:"bytes,2,opt,name=note,proto3" json:"note,omitempty"`   // Note in effect after the request
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"` // populated if ok=false
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CommandStartRequest) GetCommand() string {
-	if x != nil {
-		return x.Command
-	}
-	return ""
+func (x *SessionNoteResponse) Reset() {
+	*x = SessionNoteResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *CommandStartRequest) GetGitBranch() string {
+func (x *SessionNoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionNoteResponse) ProtoMessage() {}
+
+func (x *SessionNoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[6]
 	if x != nil {
-		return x.GitBranch
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *CommandStartRequest) GetGitRepoName() string {
+// Deprecated: Use SessionNoteResponse.ProtoReflect.Descriptor instead.
+func (*SessionNoteResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SessionNoteResponse) GetOk() bool {
 	if x != nil {
-		return x.GitRepoName
+		return x.Ok
 	}
-	return ""
+	return false
 }
 
-func (x *CommandStartRequest) GetGitRepoRoot() string {
+func (x *SessionNoteResponse) GetNote() string {
 	if x != nil {
-		return x.GitRepoRoot
+		return x.Note
 	}
 	return ""
 }
 
-func (x *CommandStartRequest) GetPrevCommandId() string {
+func (x *SessionNoteResponse) GetError() string {
 	if x != nil {
-		return x.PrevCommandId
+		return x.Error
 	}
 	return ""
 }
 
-type CommandEndRequest struct {
+type SessionNameRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	CommandId     string                 `protobuf:"bytes,2,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
-	TsUnixMs      int64                  `protobuf:"varint,3,opt,name=ts_unix_ms,json=tsUnixMs,proto3" json:"ts_unix_ms,omitempty"`
-	ExitCode      int32                  `protobuf:"varint,4,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
-	DurationMs    int64                  `protobuf:"varint,5,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"` // NOTE: stdout/stderr fields removed for Phase 1 stability
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Clear         bool                   `protobuf:"varint,3,opt,name=clear,proto3" json:"clear,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CommandEndRequest) Reset() {
-	*x = CommandEndRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[6]
+func (x *SessionNameRequest) Reset() {
+	*x = SessionNameRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CommandEndRequest) String() string {
+func (x *SessionNameRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CommandEndRequest) ProtoMessage() {}
+func (*SessionNameRequest) ProtoMessage() {}
 
-func (x *CommandEndRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[6]
+func (x *SessionNameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -535,80 +604,131 @@ func (x *CommandEndRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CommandEndRequest.ProtoReflect.Descriptor instead.
-func (*CommandEndRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use SessionNameRequest.ProtoReflect.Descriptor instead.
+func (*SessionNameRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *CommandEndRequest) GetSessionId() string {
+func (x *SessionNameRequest) GetSessionId() string {
 	if x != nil {
 		return x.SessionId
 	}
 	return ""
 }
 
-func (x *CommandEndRequest) GetCommandId() string {
+func (x *SessionNameRequest) GetName() string {
 	if x != nil {
-		return x.CommandId
+		return x.Name
 	}
 	return ""
 }
 
-func (x *CommandEndRequest) GetTsUnixMs() int64 {
+func (x *SessionNameRequest) GetClear() bool {
 	if x != nil {
-		return x.TsUnixMs
+		return x.Clear
 	}
-	return 0
+	return false
 }
 
-func (x *CommandEndRequest) GetExitCode() int32 {
+type SessionNameResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`   // Name in effect after the request
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"` // populated if ok=false
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionNameResponse) Reset() {
+	*x = SessionNameResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionNameResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionNameResponse) ProtoMessage() {}
+
+func (x *SessionNameResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[8]
 	if x != nil {
-		return x.ExitCode
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *CommandEndRequest) GetDurationMs() int64 {
+// Deprecated: Use SessionNameResponse.ProtoReflect.Descriptor instead.
+func (*SessionNameResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SessionNameResponse) GetOk() bool {
 	if x != nil {
-		return x.DurationMs
+		return x.Ok
 	}
-	return 0
+	return false
 }
 
-type SuggestRequest struct {
-	state      protoimpl.MessageState `protogen:"open.v1"`
-	SessionId  string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	Cwd        string                 `protobuf:"bytes,2,opt,name=cwd,proto3" json:"cwd,omitempty"`
-	Buffer     string                 `protobuf:"bytes,3,opt,name=buffer,proto3" json:"buffer,omitempty"`                            // Current typing buffer (prefix)
-	CursorPos  int32                  `protobuf:"varint,4,opt,name=cursor_pos,json=cursorPos,proto3" json:"cursor_pos,omitempty"`    // Cursor position in buffer
-	IncludeAi  bool                   `protobuf:"varint,5,opt,name=include_ai,json=includeAi,proto3" json:"include_ai,omitempty"`    // Request AI suggestions (explicit trigger)
-	MaxResults int32                  `protobuf:"varint,6,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"` // Max suggestions to return (default: 5)
-	// V2 fields: last-command context for improved suggestion ranking
-	RepoKey              string `protobuf:"bytes,7,opt,name=repo_key,json=repoKey,proto3" json:"repo_key,omitempty"`                                            // Repository identifier for scoped suggestions
-	LastCmdRaw           string `protobuf:"bytes,8,opt,name=last_cmd_raw,json=lastCmdRaw,proto3" json:"last_cmd_raw,omitempty"`                                 // Raw text of the last executed command
-	LastCmdNorm          string `protobuf:"bytes,9,opt,name=last_cmd_norm,json=lastCmdNorm,proto3" json:"last_cmd_norm,omitempty"`                              // Normalized form of the last executed command
-	LastCmdTsMs          int64  `protobuf:"varint,10,opt,name=last_cmd_ts_ms,json=lastCmdTsMs,proto3" json:"last_cmd_ts_ms,omitempty"`                          // Timestamp of last command (unix ms)
-	LastEventSeq         int64  `protobuf:"varint,11,opt,name=last_event_seq,json=lastEventSeq,proto3" json:"last_event_seq,omitempty"`                         // Monotonic event sequence number
-	IncludeLowConfidence bool   `protobuf:"varint,12,opt,name=include_low_confidence,json=includeLowConfidence,proto3" json:"include_low_confidence,omitempty"` // Include lower-confidence suggestions
-	unknownFields        protoimpl.UnknownFields
-	sizeCache            protoimpl.SizeCache
+func (x *SessionNameResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
 }
 
-func (x *SuggestRequest) Reset() {
-	*x = SuggestRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[7]
+func (x *SessionNameResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type CommandStartRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	CommandId string                 `protobuf:"bytes,2,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"` // UUID v4
+	TsUnixMs  int64                  `protobuf:"varint,3,opt,name=ts_unix_ms,json=tsUnixMs,proto3" json:"ts_unix_ms,omitempty"`
+	Cwd       string                 `protobuf:"bytes,4,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	Command   string                 `protobuf:"bytes,5,opt,name=command,proto3" json:"command,omitempty"` // Raw command string
+	// Git context (optional, captured by shell shim)
+	GitBranch   string `protobuf:"bytes,6,opt,name=git_branch,json=gitBranch,proto3" json:"git_branch,omitempty"`
+	GitRepoName string `protobuf:"bytes,7,opt,name=git_repo_name,json=gitRepoName,proto3" json:"git_repo_name,omitempty"`
+	GitRepoRoot string `protobuf:"bytes,8,opt,name=git_repo_root,json=gitRepoRoot,proto3" json:"git_repo_root,omitempty"`
+	// Sequence tracking
+	PrevCommandId string `protobuf:"bytes,9,opt,name=prev_command_id,json=prevCommandId,proto3" json:"prev_command_id,omitempty"`
+	// Environment context (optional, captured by clai-hook)
+	PrevCwd string            `protobuf:"bytes,10,opt,name=prev_cwd,json=prevCwd,proto3" json:"prev_cwd,omitempty"`                                                    // Working directory before the command, when it changed it
+	Env     map[string]string `protobuf:"bytes,11,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Allowlisted env context, e.g. VIRTUAL_ENV
+	// Incognito session: keep the command in memory only, never persist it
+	Ephemeral bool `protobuf:"varint,12,opt,name=ephemeral,proto3" json:"ephemeral,omitempty"`
+	// Client-side latency of this call before sending (startup, dial), for the latency budget; 0 if not measured
+	ClientLatencyMs int64 `protobuf:"varint,13,opt,name=client_latency_ms,json=clientLatencyMs,proto3" json:"client_latency_ms,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CommandStartRequest) Reset() {
+	*x = CommandStartRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SuggestRequest) String() string {
+func (x *CommandStartRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SuggestRequest) ProtoMessage() {}
+func (*CommandStartRequest) ProtoMessage() {}
 
-func (x *SuggestRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[7]
+func (x *CommandStartRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -619,125 +739,133 @@ func (x *SuggestRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SuggestRequest.ProtoReflect.Descriptor instead.
-func (*SuggestRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use CommandStartRequest.ProtoReflect.Descriptor instead.
+func (*CommandStartRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *SuggestRequest) GetSessionId() string {
+func (x *CommandStartRequest) GetSessionId() string {
 	if x != nil {
 		return x.SessionId
 	}
 	return ""
 }
 
-func (x *SuggestRequest) GetCwd() string {
+func (x *CommandStartRequest) GetCommandId() string {
 	if x != nil {
-		return x.Cwd
+		return x.CommandId
 	}
 	return ""
 }
 
-func (x *SuggestRequest) GetBuffer() string {
+func (x *CommandStartRequest) GetTsUnixMs() int64 {
 	if x != nil {
-		return x.Buffer
+		return x.TsUnixMs
 	}
-	return ""
+	return 0
 }
 
-func (x *SuggestRequest) GetCursorPos() int32 {
+func (x *CommandStartRequest) GetCwd() string {
 	if x != nil {
-		return x.CursorPos
+		return x.Cwd
 	}
-	return 0
+	return ""
 }
 
-func (x *SuggestRequest) GetIncludeAi() bool {
+func (x *CommandStartRequest) GetCommand() string {
 	if x != nil {
-		return x.IncludeAi
+		return x.Command
 	}
-	return false
+	return ""
 }
 
-func (x *SuggestRequest) GetMaxResults() int32 {
+func (x *CommandStartRequest) GetGitBranch() string {
 	if x != nil {
-		return x.MaxResults
+		return x.GitBranch
 	}
-	return 0
+	return ""
 }
 
-func (x *SuggestRequest) GetRepoKey() string {
+func (x *CommandStartRequest) GetGitRepoName() string {
 	if x != nil {
-		return x.RepoKey
+		return x.GitRepoName
 	}
 	return ""
 }
 
-func (x *SuggestRequest) GetLastCmdRaw() string {
+func (x *CommandStartRequest) GetGitRepoRoot() string {
 	if x != nil {
-		return x.LastCmdRaw
+		return x.GitRepoRoot
 	}
 	return ""
 }
 
-func (x *SuggestRequest) GetLastCmdNorm() string {
+func (x *CommandStartRequest) GetPrevCommandId() string {
 	if x != nil {
-		return x.LastCmdNorm
+		return x.PrevCommandId
 	}
 	return ""
 }
 
-func (x *SuggestRequest) GetLastCmdTsMs() int64 {
+func (x *CommandStartRequest) GetPrevCwd() string {
 	if x != nil {
-		return x.LastCmdTsMs
+		return x.PrevCwd
 	}
-	return 0
+	return ""
 }
 
-func (x *SuggestRequest) GetLastEventSeq() int64 {
+func (x *CommandStartRequest) GetEnv() map[string]string {
 	if x != nil {
-		return x.LastEventSeq
+		return x.Env
 	}
-	return 0
+	return nil
 }
 
-func (x *SuggestRequest) GetIncludeLowConfidence() bool {
+func (x *CommandStartRequest) GetEphemeral() bool {
 	if x != nil {
-		return x.IncludeLowConfidence
+		return x.Ephemeral
 	}
 	return false
 }
 
-type Suggestion struct {
-	state       protoimpl.MessageState `protogen:"open.v1"`
-	Text        string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`               // The suggested command
-	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"` // Optional description
-	Source      string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`           // "session", "cwd", "global", "ai"
-	Score       float64                `protobuf:"fixed64,4,opt,name=score,proto3" json:"score,omitempty"`           // Ranking score (0.0 to 1.0)
-	Risk        string                 `protobuf:"bytes,5,opt,name=risk,proto3" json:"risk,omitempty"`               // "safe", "destructive", or empty
-	// V2 fields: per-suggestion enrichment
-	CmdNorm       string              `protobuf:"bytes,6,opt,name=cmd_norm,json=cmdNorm,proto3" json:"cmd_norm,omitempty"` // Normalized command form
-	Confidence    float64             `protobuf:"fixed64,7,opt,name=confidence,proto3" json:"confidence,omitempty"`        // Confidence score (0.0 to 1.0)
-	Reasons       []*SuggestionReason `protobuf:"bytes,8,rep,name=reasons,proto3" json:"reasons,omitempty"`                // Why this suggestion was ranked here
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *CommandStartRequest) GetClientLatencyMs() int64 {
+	if x != nil {
+		return x.ClientLatencyMs
+	}
+	return 0
 }
 
-func (x *Suggestion) Reset() {
-	*x = Suggestion{}
-	mi := &file_clai_v1_clai_proto_msgTypes[8]
+type CommandEndRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	SessionId  string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	CommandId  string                 `protobuf:"bytes,2,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
+	TsUnixMs   int64                  `protobuf:"varint,3,opt,name=ts_unix_ms,json=tsUnixMs,proto3" json:"ts_unix_ms,omitempty"`
+	ExitCode   int32                  `protobuf:"varint,4,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	DurationMs int64                  `protobuf:"varint,5,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	// Tails of what the command printed, for clients that capture output.
+	// Stored only when suggestions.capture_output is on.
+	StdoutTail      string `protobuf:"bytes,6,opt,name=stdout_tail,json=stdoutTail,proto3" json:"stdout_tail,omitempty"`
+	StderrTail      string `protobuf:"bytes,7,opt,name=stderr_tail,json=stderrTail,proto3" json:"stderr_tail,omitempty"`
+	OutputTruncated bool   `protobuf:"varint,8,opt,name=output_truncated,json=outputTruncated,proto3" json:"output_truncated,omitempty"` // The client cut stdout or stderr to its tail
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CommandEndRequest) Reset() {
+	*x = CommandEndRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Suggestion) String() string {
+func (x *CommandEndRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Suggestion) ProtoMessage() {}
+func (*CommandEndRequest) ProtoMessage() {}
 
-func (x *Suggestion) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[8]
+func (x *CommandEndRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -748,92 +876,94 @@ func (x *Suggestion) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Suggestion.ProtoReflect.Descriptor instead.
-func (*Suggestion) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use CommandEndRequest.ProtoReflect.Descriptor instead.
+func (*CommandEndRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *Suggestion) GetText() string {
+func (x *CommandEndRequest) GetSessionId() string {
 	if x != nil {
-		return x.Text
+		return x.SessionId
 	}
 	return ""
 }
 
-func (x *Suggestion) GetDescription() string {
+func (x *CommandEndRequest) GetCommandId() string {
 	if x != nil {
-		return x.Description
+		return x.CommandId
 	}
 	return ""
 }
 
-func (x *Suggestion) GetSource() string {
+func (x *CommandEndRequest) GetTsUnixMs() int64 {
 	if x != nil {
-		return x.Source
+		return x.TsUnixMs
 	}
-	return ""
+	return 0
 }
 
-func (x *Suggestion) GetScore() float64 {
+func (x *CommandEndRequest) GetExitCode() int32 {
 	if x != nil {
-		return x.Score
+		return x.ExitCode
 	}
 	return 0
 }
 
-func (x *Suggestion) GetRisk() string {
+func (x *CommandEndRequest) GetDurationMs() int64 {
 	if x != nil {
-		return x.Risk
+		return x.DurationMs
 	}
-	return ""
+	return 0
 }
 
-func (x *Suggestion) GetCmdNorm() string {
+func (x *CommandEndRequest) GetStdoutTail() string {
 	if x != nil {
-		return x.CmdNorm
+		return x.StdoutTail
 	}
 	return ""
 }
 
-func (x *Suggestion) GetConfidence() float64 {
+func (x *CommandEndRequest) GetStderrTail() string {
 	if x != nil {
-		return x.Confidence
+		return x.StderrTail
 	}
-	return 0
+	return ""
 }
 
-func (x *Suggestion) GetReasons() []*SuggestionReason {
+func (x *CommandEndRequest) GetOutputTruncated() bool {
 	if x != nil {
-		return x.Reasons
+		return x.OutputTruncated
 	}
-	return nil
+	return false
 }
 
-// SuggestionReason explains why a particular suggestion was ranked.
-type SuggestionReason struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`                   // Reason type (e.g. "recency", "frequency", "cwd_match")
-	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`     // Human-readable description
-	Contribution  float32                `protobuf:"fixed32,3,opt,name=contribution,proto3" json:"contribution,omitempty"` // Weight contribution to final score (0.0 to 1.0)
+// CommandLifecycleEvent is a command start or end inside a batch.
+type CommandLifecycleEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*CommandLifecycleEvent_Start
+	//	*CommandLifecycleEvent_End
+	Event         isCommandLifecycleEvent_Event `protobuf_oneof:"event"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SuggestionReason) Reset() {
-	*x = SuggestionReason{}
-	mi := &file_clai_v1_clai_proto_msgTypes[9]
+func (x *CommandLifecycleEvent) Reset() {
+	*x = CommandLifecycleEvent{}
+	mi := &file_clai_v1_clai_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SuggestionReason) String() string {
+func (x *CommandLifecycleEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SuggestionReason) ProtoMessage() {}
+func (*CommandLifecycleEvent) ProtoMessage() {}
 
-func (x *SuggestionReason) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[9]
+func (x *CommandLifecycleEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -844,56 +974,77 @@ func (x *SuggestionReason) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SuggestionReason.ProtoReflect.Descriptor instead.
-func (*SuggestionReason) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use CommandLifecycleEvent.ProtoReflect.Descriptor instead.
+func (*CommandLifecycleEvent) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *SuggestionReason) GetType() string {
+func (x *CommandLifecycleEvent) GetEvent() isCommandLifecycleEvent_Event {
 	if x != nil {
-		return x.Type
+		return x.Event
 	}
-	return ""
+	return nil
 }
 
-func (x *SuggestionReason) GetDescription() string {
+func (x *CommandLifecycleEvent) GetStart() *CommandStartRequest {
 	if x != nil {
-		return x.Description
+		if x, ok := x.Event.(*CommandLifecycleEvent_Start); ok {
+			return x.Start
+		}
 	}
-	return ""
+	return nil
 }
 
-func (x *SuggestionReason) GetContribution() float32 {
+func (x *CommandLifecycleEvent) GetEnd() *CommandEndRequest {
 	if x != nil {
-		return x.Contribution
+		if x, ok := x.Event.(*CommandLifecycleEvent_End); ok {
+			return x.End
+		}
 	}
-	return 0
+	return nil
 }
 
-// TimingHint provides adaptive timing information for shell integrations.
-type TimingHint struct {
-	state                     protoimpl.MessageState `protogen:"open.v1"`
-	UserSpeedClass            string                 `protobuf:"bytes,1,opt,name=user_speed_class,json=userSpeedClass,proto3" json:"user_speed_class,omitempty"`                                     // e.g. "fast", "moderate", "slow"
-	SuggestedPauseThresholdMs int32                  `protobuf:"varint,2,opt,name=suggested_pause_threshold_ms,json=suggestedPauseThresholdMs,proto3" json:"suggested_pause_threshold_ms,omitempty"` // Suggested pause before showing suggestions
-	unknownFields             protoimpl.UnknownFields
-	sizeCache                 protoimpl.SizeCache
+type isCommandLifecycleEvent_Event interface {
+	isCommandLifecycleEvent_Event()
 }
 
-func (x *TimingHint) Reset() {
-	*x = TimingHint{}
-	mi := &file_clai_v1_clai_proto_msgTypes[10]
+type CommandLifecycleEvent_Start struct {
+	Start *CommandStartRequest `protobuf:"bytes,1,opt,name=start,proto3,oneof"`
+}
+
+type CommandLifecycleEvent_End struct {
+	End *CommandEndRequest `protobuf:"bytes,2,opt,name=end,proto3,oneof"`
+}
+
+func (*CommandLifecycleEvent_Start) isCommandLifecycleEvent_Event() {}
+
+func (*CommandLifecycleEvent_End) isCommandLifecycleEvent_Event() {}
+
+// CommandEventsBatchRequest delivers many events in one call, e.g. when a
+// client flushes events it spooled while the daemon was unreachable.
+// Events are applied in order.
+type CommandEventsBatchRequest struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Events        []*CommandLifecycleEvent `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandEventsBatchRequest) Reset() {
+	*x = CommandEventsBatchRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TimingHint) String() string {
+func (x *CommandEventsBatchRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TimingHint) ProtoMessage() {}
+func (*CommandEventsBatchRequest) ProtoMessage() {}
 
-func (x *TimingHint) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[10]
+func (x *CommandEventsBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -904,52 +1055,42 @@ func (x *TimingHint) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TimingHint.ProtoReflect.Descriptor instead.
-func (*TimingHint) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{10}
-}
-
-func (x *TimingHint) GetUserSpeedClass() string {
-	if x != nil {
-		return x.UserSpeedClass
-	}
-	return ""
+// Deprecated: Use CommandEventsBatchRequest.ProtoReflect.Descriptor instead.
+func (*CommandEventsBatchRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *TimingHint) GetSuggestedPauseThresholdMs() int32 {
+func (x *CommandEventsBatchRequest) GetEvents() []*CommandLifecycleEvent {
 	if x != nil {
-		return x.SuggestedPauseThresholdMs
+		return x.Events
 	}
-	return 0
+	return nil
 }
 
-type SuggestResponse struct {
-	state       protoimpl.MessageState `protogen:"open.v1"`
-	Suggestions []*Suggestion          `protobuf:"bytes,1,rep,name=suggestions,proto3" json:"suggestions,omitempty"`
-	FromCache   bool                   `protobuf:"varint,2,opt,name=from_cache,json=fromCache,proto3" json:"from_cache,omitempty"` // True if served from cache
-	// V2 fields: response-level metadata
-	CacheStatus   string      `protobuf:"bytes,3,opt,name=cache_status,json=cacheStatus,proto3" json:"cache_status,omitempty"` // "hit", "miss", "stale" (more granular than from_cache)
-	LatencyMs     int64       `protobuf:"varint,4,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`      // Server-side processing time
-	TimingHint    *TimingHint `protobuf:"bytes,5,opt,name=timing_hint,json=timingHint,proto3" json:"timing_hint,omitempty"`    // Adaptive timing guidance for shell integration
+type CommandEventsBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      int32                  `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Failed        int32                  `protobuf:"varint,2,opt,name=failed,proto3" json:"failed,omitempty"`
+	Errors        []string               `protobuf:"bytes,3,rep,name=errors,proto3" json:"errors,omitempty"` // "event <index>: <error>" for each failed event
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SuggestResponse) Reset() {
-	*x = SuggestResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[11]
+func (x *CommandEventsBatchResponse) Reset() {
+	*x = CommandEventsBatchResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SuggestResponse) String() string {
+func (x *CommandEventsBatchResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SuggestResponse) ProtoMessage() {}
+func (*CommandEventsBatchResponse) ProtoMessage() {}
 
-func (x *SuggestResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[11]
+func (x *CommandEventsBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -960,75 +1101,67 @@ func (x *SuggestResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SuggestResponse.ProtoReflect.Descriptor instead.
-func (*SuggestResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{11}
-}
-
-func (x *SuggestResponse) GetSuggestions() []*Suggestion {
-	if x != nil {
-		return x.Suggestions
-	}
-	return nil
-}
-
-func (x *SuggestResponse) GetFromCache() bool {
-	if x != nil {
-		return x.FromCache
-	}
-	return false
+// Deprecated: Use CommandEventsBatchResponse.ProtoReflect.Descriptor instead.
+func (*CommandEventsBatchResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *SuggestResponse) GetCacheStatus() string {
+func (x *CommandEventsBatchResponse) GetAccepted() int32 {
 	if x != nil {
-		return x.CacheStatus
+		return x.Accepted
 	}
-	return ""
+	return 0
 }
 
-func (x *SuggestResponse) GetLatencyMs() int64 {
+func (x *CommandEventsBatchResponse) GetFailed() int32 {
 	if x != nil {
-		return x.LatencyMs
+		return x.Failed
 	}
 	return 0
 }
 
-func (x *SuggestResponse) GetTimingHint() *TimingHint {
+func (x *CommandEventsBatchResponse) GetErrors() []string {
 	if x != nil {
-		return x.TimingHint
+		return x.Errors
 	}
 	return nil
 }
 
-// RecordFeedbackRequest captures user feedback on suggestions.
-// Primary feedback path is automatic from shell integrations.
-type RecordFeedbackRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`             // Session that generated the suggestion
-	Action        string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`                                    // "accepted", "dismissed", "edited", "never", "unblock"
-	SuggestedText string                 `protobuf:"bytes,3,opt,name=suggested_text,json=suggestedText,proto3" json:"suggested_text,omitempty"` // The suggestion that was shown
-	ExecutedText  string                 `protobuf:"bytes,4,opt,name=executed_text,json=executedText,proto3" json:"executed_text,omitempty"`    // What the user actually executed (for "edited")
-	Prefix        string                 `protobuf:"bytes,5,opt,name=prefix,proto3" json:"prefix,omitempty"`                                    // The prefix/buffer when suggestion was shown
-	LatencyMs     int64                  `protobuf:"varint,6,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`            // Time from suggestion display to user action
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type SuggestRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	SessionId  string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Cwd        string                 `protobuf:"bytes,2,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	Buffer     string                 `protobuf:"bytes,3,opt,name=buffer,proto3" json:"buffer,omitempty"`                            // Current typing buffer (prefix)
+	CursorPos  int32                  `protobuf:"varint,4,opt,name=cursor_pos,json=cursorPos,proto3" json:"cursor_pos,omitempty"`    // Cursor position in buffer
+	IncludeAi  bool                   `protobuf:"varint,5,opt,name=include_ai,json=includeAi,proto3" json:"include_ai,omitempty"`    // Request AI suggestions (explicit trigger)
+	MaxResults int32                  `protobuf:"varint,6,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"` // Max suggestions to return (default: 5)
+	// V2 fields: last-command context for improved suggestion ranking
+	RepoKey              string `protobuf:"bytes,7,opt,name=repo_key,json=repoKey,proto3" json:"repo_key,omitempty"`                                            // Repository identifier for scoped suggestions
+	LastCmdRaw           string `protobuf:"bytes,8,opt,name=last_cmd_raw,json=lastCmdRaw,proto3" json:"last_cmd_raw,omitempty"`                                 // Raw text of the last executed command
+	LastCmdNorm          string `protobuf:"bytes,9,opt,name=last_cmd_norm,json=lastCmdNorm,proto3" json:"last_cmd_norm,omitempty"`                              // Normalized form of the last executed command
+	LastCmdTsMs          int64  `protobuf:"varint,10,opt,name=last_cmd_ts_ms,json=lastCmdTsMs,proto3" json:"last_cmd_ts_ms,omitempty"`                          // Timestamp of last command (unix ms)
+	LastEventSeq         int64  `protobuf:"varint,11,opt,name=last_event_seq,json=lastEventSeq,proto3" json:"last_event_seq,omitempty"`                         // Monotonic event sequence number
+	IncludeLowConfidence bool   `protobuf:"varint,12,opt,name=include_low_confidence,json=includeLowConfidence,proto3" json:"include_low_confidence,omitempty"` // Include lower-confidence suggestions
+	ClientLatencyMs      int64  `protobuf:"varint,13,opt,name=client_latency_ms,json=clientLatencyMs,proto3" json:"client_latency_ms,omitempty"`                // Client-side latency before sending, for the latency budget; 0 if not measured
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
-func (x *RecordFeedbackRequest) Reset() {
-	*x = RecordFeedbackRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[12]
+func (x *SuggestRequest) Reset() {
+	*x = SuggestRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RecordFeedbackRequest) String() string {
+func (x *SuggestRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RecordFeedbackRequest) ProtoMessage() {}
+func (*SuggestRequest) ProtoMessage() {}
 
-func (x *RecordFeedbackRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[12]
+func (x *SuggestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1039,77 +1172,147 @@ func (x *RecordFeedbackRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RecordFeedbackRequest.ProtoReflect.Descriptor instead.
-func (*RecordFeedbackRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use SuggestRequest.ProtoReflect.Descriptor instead.
+func (*SuggestRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *RecordFeedbackRequest) GetSessionId() string {
+func (x *SuggestRequest) GetSessionId() string {
 	if x != nil {
 		return x.SessionId
 	}
 	return ""
 }
 
-func (x *RecordFeedbackRequest) GetAction() string {
+func (x *SuggestRequest) GetCwd() string {
 	if x != nil {
-		return x.Action
+		return x.Cwd
 	}
 	return ""
 }
 
-func (x *RecordFeedbackRequest) GetSuggestedText() string {
+func (x *SuggestRequest) GetBuffer() string {
 	if x != nil {
-		return x.SuggestedText
+		return x.Buffer
 	}
 	return ""
 }
 
-func (x *RecordFeedbackRequest) GetExecutedText() string {
+func (x *SuggestRequest) GetCursorPos() int32 {
 	if x != nil {
-		return x.ExecutedText
+		return x.CursorPos
+	}
+	return 0
+}
+
+func (x *SuggestRequest) GetIncludeAi() bool {
+	if x != nil {
+		return x.IncludeAi
+	}
+	return false
+}
+
+func (x *SuggestRequest) GetMaxResults() int32 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+func (x *SuggestRequest) GetRepoKey() string {
+	if x != nil {
+		return x.RepoKey
 	}
 	return ""
 }
 
-func (x *RecordFeedbackRequest) GetPrefix() string {
+func (x *SuggestRequest) GetLastCmdRaw() string {
 	if x != nil {
-		return x.Prefix
+		return x.LastCmdRaw
 	}
 	return ""
 }
 
-func (x *RecordFeedbackRequest) GetLatencyMs() int64 {
+func (x *SuggestRequest) GetLastCmdNorm() string {
 	if x != nil {
-		return x.LatencyMs
+		return x.LastCmdNorm
+	}
+	return ""
+}
+
+func (x *SuggestRequest) GetLastCmdTsMs() int64 {
+	if x != nil {
+		return x.LastCmdTsMs
 	}
 	return 0
 }
 
-// RecordFeedbackResponse confirms feedback recording.
-type RecordFeedbackResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
-	Error         *ApiError              `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"` // Structured error if ok=false
+func (x *SuggestRequest) GetLastEventSeq() int64 {
+	if x != nil {
+		return x.LastEventSeq
+	}
+	return 0
+}
+
+func (x *SuggestRequest) GetIncludeLowConfidence() bool {
+	if x != nil {
+		return x.IncludeLowConfidence
+	}
+	return false
+}
+
+func (x *SuggestRequest) GetClientLatencyMs() int64 {
+	if x != nil {
+		return x.ClientLatencyMs
+	}
+	return 0
+}
+
+type Suggestion struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Text        string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`               // The suggested command
+	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"` // Optional description
+	Source      string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`           // "session", "cwd", "global", "ai"
+	Score       float64                `protobuf:"fixed64,4,opt,name=score,proto3" json:"score,omitempty"`           // Ranking score (0.0 to 1.0)
+	Risk        string                 `protobuf:"bytes,5,opt,name=risk,proto3" json:"risk,omitempty"`               // "safe", "destructive", or empty
+	// V2 fields: per-suggestion enrichment
+	CmdNorm    string              `protobuf:"bytes,6,opt,name=cmd_norm,json=cmdNorm,proto3" json:"cmd_norm,omitempty"` // Normalized command form
+	Confidence float64             `protobuf:"fixed64,7,opt,name=confidence,proto3" json:"confidence,omitempty"`        // Confidence score (0.0 to 1.0)
+	Reasons    []*SuggestionReason `protobuf:"bytes,8,rep,name=reasons,proto3" json:"reasons,omitempty"`                // Why this suggestion was ranked here
+	// Workflow suggestions: kind is "workflow" and steps holds the commands
+	// the shell widget can run or expand one by one. Empty for single commands.
+	Kind        string   `protobuf:"bytes,9,opt,name=kind,proto3" json:"kind,omitempty"`
+	Steps       []string `protobuf:"bytes,10,rep,name=steps,proto3" json:"steps,omitempty"`
+	RiskMessage string   `protobuf:"bytes,11,opt,name=risk_message,json=riskMessage,proto3" json:"risk_message,omitempty"` // Why the command is risky, from suggestions.risk_patterns
+	// Estimated chance (0.0 to 1.0) that the command exits 0 if run now,
+	// from its past exit codes; 0 when unknown.
+	SuccessProbability float64 `protobuf:"fixed64,12,opt,name=success_probability,json=successProbability,proto3" json:"success_probability,omitempty"`
+	// Character offsets in text where accepting it word by word stops,
+	// ascending; the last is the length of text. Lets shell widgets accept
+	// the next word without tokenizing the command themselves.
+	WordBoundaries []int32 `protobuf:"varint,13,rep,packed,name=word_boundaries,json=wordBoundaries,proto3" json:"word_boundaries,omitempty"`
+	// Score contributed by each ranking feature, from the V2 scorer. Kept
+	// for ExplainSuggestions; Suggest responses leave it empty.
+	Breakdown     []*ScoreComponent `protobuf:"bytes,14,rep,name=breakdown,proto3" json:"breakdown,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RecordFeedbackResponse) Reset() {
-	*x = RecordFeedbackResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[13]
+func (x *Suggestion) Reset() {
+	*x = Suggestion{}
+	mi := &file_clai_v1_clai_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RecordFeedbackResponse) String() string {
+func (x *Suggestion) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RecordFeedbackResponse) ProtoMessage() {}
+func (*Suggestion) ProtoMessage() {}
 
-func (x *RecordFeedbackResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[13]
+func (x *Suggestion) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1120,50 +1323,134 @@ func (x *RecordFeedbackResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RecordFeedbackResponse.ProtoReflect.Descriptor instead.
-func (*RecordFeedbackResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use Suggestion.ProtoReflect.Descriptor instead.
+func (*Suggestion) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *RecordFeedbackResponse) GetOk() bool {
+func (x *Suggestion) GetText() string {
 	if x != nil {
-		return x.Ok
+		return x.Text
 	}
-	return false
+	return ""
 }
 
-func (x *RecordFeedbackResponse) GetError() *ApiError {
+func (x *Suggestion) GetDescription() string {
 	if x != nil {
-		return x.Error
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Suggestion) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Suggestion) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Suggestion) GetRisk() string {
+	if x != nil {
+		return x.Risk
+	}
+	return ""
+}
+
+func (x *Suggestion) GetCmdNorm() string {
+	if x != nil {
+		return x.CmdNorm
+	}
+	return ""
+}
+
+func (x *Suggestion) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *Suggestion) GetReasons() []*SuggestionReason {
+	if x != nil {
+		return x.Reasons
 	}
 	return nil
 }
 
-type TextToCommandRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	SessionId      string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	Prompt         string                 `protobuf:"bytes,2,opt,name=prompt,proto3" json:"prompt,omitempty"` // Natural language prompt
-	Cwd            string                 `protobuf:"bytes,3,opt,name=cwd,proto3" json:"cwd,omitempty"`
-	MaxSuggestions int32                  `protobuf:"varint,4,opt,name=max_suggestions,json=maxSuggestions,proto3" json:"max_suggestions,omitempty"` // Default: 3
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+func (x *Suggestion) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
 }
 
-func (x *TextToCommandRequest) Reset() {
-	*x = TextToCommandRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[14]
+func (x *Suggestion) GetSteps() []string {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+func (x *Suggestion) GetRiskMessage() string {
+	if x != nil {
+		return x.RiskMessage
+	}
+	return ""
+}
+
+func (x *Suggestion) GetSuccessProbability() float64 {
+	if x != nil {
+		return x.SuccessProbability
+	}
+	return 0
+}
+
+// SuggestionReason explains why a particular suggestion was ranked.
+func (x *Suggestion) GetWordBoundaries() []int32 {
+	if x != nil {
+		return x.WordBoundaries
+	}
+	return nil
+}
+
+func (x *Suggestion) GetBreakdown() []*ScoreComponent {
+	if x != nil {
+		return x.Breakdown
+	}
+	return nil
+}
+
+type SuggestionReason struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`                   // Reason type (e.g. "recency", "frequency", "cwd_match")
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`     // Human-readable description
+	Contribution  float32                `protobuf:"fixed32,3,opt,name=contribution,proto3" json:"contribution,omitempty"` // Weight contribution to final score (0.0 to 1.0)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuggestionReason) Reset() {
+	*x = SuggestionReason{}
+	mi := &file_clai_v1_clai_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TextToCommandRequest) String() string {
+func (x *SuggestionReason) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TextToCommandRequest) ProtoMessage() {}
+func (*SuggestionReason) ProtoMessage() {}
 
-func (x *TextToCommandRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[14]
+func (x *SuggestionReason) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1174,124 +1461,3098 @@ func (x *TextToCommandRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TextToCommandRequest.ProtoReflect.Descriptor instead.
-func (*TextToCommandRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{14}
-}
-
-func (x *TextToCommandRequest) GetSessionId() string {
-	if x != nil {
-		return x.SessionId
-	}
-	return ""
+// Deprecated: Use SuggestionReason.ProtoReflect.Descriptor instead.
+func (*SuggestionReason) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *TextToCommandRequest) GetPrompt() string {
+func (x *SuggestionReason) GetType() string {
 	if x != nil {
-		return x.Prompt
+		return x.Type
 	}
 	return ""
 }
 
-func (x *TextToCommandRequest) GetCwd() string {
+func (x *SuggestionReason) GetDescription() string {
 	if x != nil {
-		return x.Cwd
+		return x.Description
 	}
 	return ""
 }
 
-func (x *TextToCommandRequest) GetMaxSuggestions() int32 {
+func (x *SuggestionReason) GetContribution() float32 {
 	if x != nil {
-		return x.MaxSuggestions
+		return x.Contribution
 	}
 	return 0
 }
 
-type TextToCommandResponse struct {
+// ScoreComponent is a named part of a score, or a named weight.
+type ScoreComponent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Suggestions   []*Suggestion          `protobuf:"bytes,1,rep,name=suggestions,proto3" json:"suggestions,omitempty"`
-	Provider      string                 `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`                     // Which AI provider was used
-	LatencyMs     int64                  `protobuf:"varint,3,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"` // AI response time
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // e.g. "repo_transition", "dir_frequency"
+	Value         float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TextToCommandResponse) Reset() {
-	*x = TextToCommandResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[15]
+func (x *ScoreComponent) Reset() {
+	*x = ScoreComponent{}
+	mi := &file_clai_v1_clai_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TextToCommandResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *ScoreComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoreComponent) ProtoMessage() {}
+
+func (x *ScoreComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoreComponent.ProtoReflect.Descriptor instead.
+func (*ScoreComponent) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ScoreComponent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ScoreComponent) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+// LearnedWeightLevel is a learned weight profile that was blended into the
+// ranking weights.
+type LearnedWeightLevel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scope         string                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`                                 // "global", "project_type:<type>" or "repo:<key>"
+	SampleCount   int64                  `protobuf:"varint,2,opt,name=sample_count,json=sampleCount,proto3" json:"sample_count,omitempty"` // Feedback samples the profile learned from
+	Trust         float64                `protobuf:"fixed64,3,opt,name=trust,proto3" json:"trust,omitempty"`                               // Share of the blend the profile took (0.0 to 1.0)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LearnedWeightLevel) Reset() {
+	*x = LearnedWeightLevel{}
+	mi := &file_clai_v1_clai_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LearnedWeightLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LearnedWeightLevel) ProtoMessage() {}
+
+func (x *LearnedWeightLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LearnedWeightLevel.ProtoReflect.Descriptor instead.
+func (*LearnedWeightLevel) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *LearnedWeightLevel) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *LearnedWeightLevel) GetSampleCount() int64 {
+	if x != nil {
+		return x.SampleCount
+	}
+	return 0
+}
+
+func (x *LearnedWeightLevel) GetTrust() float64 {
+	if x != nil {
+		return x.Trust
+	}
+	return 0
+}
+
+// SuggestScoring describes how a set of suggestions was ranked.
+type SuggestScoring struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scorer        string                 `protobuf:"bytes,1,opt,name=scorer,proto3" json:"scorer,omitempty"`                  // "v1" or "v2"
+	Weights       []*ScoreComponent      `protobuf:"bytes,2,rep,name=weights,proto3" json:"weights,omitempty"`                // Feature weights ranked with (V2)
+	Learned       []*LearnedWeightLevel  `protobuf:"bytes,3,rep,name=learned,proto3" json:"learned,omitempty"`                // Learned profiles blended into weights
+	RepoKey       string                 `protobuf:"bytes,4,opt,name=repo_key,json=repoKey,proto3" json:"repo_key,omitempty"` // Repository scope of the ranking
+	LastCmd       string                 `protobuf:"bytes,5,opt,name=last_cmd,json=lastCmd,proto3" json:"last_cmd,omitempty"` // Previous command transitions were scored from
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuggestScoring) Reset() {
+	*x = SuggestScoring{}
+	mi := &file_clai_v1_clai_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuggestScoring) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuggestScoring) ProtoMessage() {}
+
+func (x *SuggestScoring) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuggestScoring.ProtoReflect.Descriptor instead.
+func (*SuggestScoring) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SuggestScoring) GetScorer() string {
+	if x != nil {
+		return x.Scorer
+	}
+	return ""
+}
+
+func (x *SuggestScoring) GetWeights() []*ScoreComponent {
+	if x != nil {
+		return x.Weights
+	}
+	return nil
+}
+
+func (x *SuggestScoring) GetLearned() []*LearnedWeightLevel {
+	if x != nil {
+		return x.Learned
+	}
+	return nil
+}
+
+func (x *SuggestScoring) GetRepoKey() string {
+	if x != nil {
+		return x.RepoKey
+	}
+	return ""
+}
+
+func (x *SuggestScoring) GetLastCmd() string {
+	if x != nil {
+		return x.LastCmd
+	}
+	return ""
+}
+
+// SuggestSlotsRequest asks for values of the argument being typed at the
+// cursor, for shell completion.
+type SuggestSlotsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Cwd           string                 `protobuf:"bytes,2,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	Buffer        string                 `protobuf:"bytes,3,opt,name=buffer,proto3" json:"buffer,omitempty"`                            // Current typing buffer
+	CursorPos     int32                  `protobuf:"varint,4,opt,name=cursor_pos,json=cursorPos,proto3" json:"cursor_pos,omitempty"`    // Cursor position in buffer (default: end)
+	MaxResults    int32                  `protobuf:"varint,5,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"` // Max values to return (default: 10)
+	RepoKey       string                 `protobuf:"bytes,6,opt,name=repo_key,json=repoKey,proto3" json:"repo_key,omitempty"`           // Repository identifier (default: the session's)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuggestSlotsRequest) Reset() {
+	*x = SuggestSlotsRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuggestSlotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuggestSlotsRequest) ProtoMessage() {}
+
+func (x *SuggestSlotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuggestSlotsRequest.ProtoReflect.Descriptor instead.
+func (*SuggestSlotsRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SuggestSlotsRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SuggestSlotsRequest) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *SuggestSlotsRequest) GetBuffer() string {
+	if x != nil {
+		return x.Buffer
+	}
+	return ""
+}
+
+func (x *SuggestSlotsRequest) GetCursorPos() int32 {
+	if x != nil {
+		return x.CursorPos
+	}
+	return 0
+}
+
+func (x *SuggestSlotsRequest) GetMaxResults() int32 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+func (x *SuggestSlotsRequest) GetRepoKey() string {
+	if x != nil {
+		return x.RepoKey
+	}
+	return ""
+}
+
+type SlotSuggestion struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`   // Argument value
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"` // Decayed use count
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SlotSuggestion) Reset() {
+	*x = SlotSuggestion{}
+	mi := &file_clai_v1_clai_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SlotSuggestion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SlotSuggestion) ProtoMessage() {}
+
+func (x *SlotSuggestion) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SlotSuggestion.ProtoReflect.Descriptor instead.
+func (*SlotSuggestion) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SlotSuggestion) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *SlotSuggestion) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type SuggestSlotsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []*SlotSuggestion      `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuggestSlotsResponse) Reset() {
+	*x = SuggestSlotsResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuggestSlotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuggestSlotsResponse) ProtoMessage() {}
+
+func (x *SuggestSlotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuggestSlotsResponse.ProtoReflect.Descriptor instead.
+func (*SuggestSlotsResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SuggestSlotsResponse) GetValues() []*SlotSuggestion {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// SuggestInlineRequest asks for the one completion of the buffer shown as
+// ghost text. Shells send one per keystroke; a newer revision from the same
+// session cancels older requests still in flight.
+type SuggestInlineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Cwd           string                 `protobuf:"bytes,2,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	Buffer        string                 `protobuf:"bytes,3,opt,name=buffer,proto3" json:"buffer,omitempty"`                            // Current typing buffer
+	CursorPos     int32                  `protobuf:"varint,4,opt,name=cursor_pos,json=cursorPos,proto3" json:"cursor_pos,omitempty"`    // Cursor position in buffer (default: end)
+	Revision      int64                  `protobuf:"varint,5,opt,name=revision,proto3" json:"revision,omitempty"`                       // Increases with every edit of the buffer; 0 = untracked
+	DebounceMs    int32                  `protobuf:"varint,6,opt,name=debounce_ms,json=debounceMs,proto3" json:"debounce_ms,omitempty"` // Wait this long for a newer revision before ranking (max 500)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuggestInlineRequest) Reset() {
+	*x = SuggestInlineRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuggestInlineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuggestInlineRequest) ProtoMessage() {}
+
+func (x *SuggestInlineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuggestInlineRequest.ProtoReflect.Descriptor instead.
+func (*SuggestInlineRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SuggestInlineRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SuggestInlineRequest) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *SuggestInlineRequest) GetBuffer() string {
+	if x != nil {
+		return x.Buffer
+	}
+	return ""
+}
+
+func (x *SuggestInlineRequest) GetCursorPos() int32 {
+	if x != nil {
+		return x.CursorPos
+	}
+	return 0
+}
+
+func (x *SuggestInlineRequest) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *SuggestInlineRequest) GetDebounceMs() int32 {
+	if x != nil {
+		return x.DebounceMs
+	}
+	return 0
+}
+
+type SuggestInlineResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Completion     string                 `protobuf:"bytes,1,opt,name=completion,proto3" json:"completion,omitempty"`                                       // Whole suggested command; empty when there is none
+	Suffix         string                 `protobuf:"bytes,2,opt,name=suffix,proto3" json:"suffix,omitempty"`                                               // What completion adds after the buffer, to show as ghost text
+	Revision       int64                  `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"`                                          // Revision this answers
+	Superseded     bool                   `protobuf:"varint,4,opt,name=superseded,proto3" json:"superseded,omitempty"`                                      // A newer revision arrived first; discard this answer
+	Source         string                 `protobuf:"bytes,5,opt,name=source,proto3" json:"source,omitempty"`                                               // Source of the completion, as in Suggestion.source
+	LatencyMs      int64                  `protobuf:"varint,6,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`                       // Server-side processing time
+	WordBoundaries []int32                `protobuf:"varint,7,rep,packed,name=word_boundaries,json=wordBoundaries,proto3" json:"word_boundaries,omitempty"` // As in Suggestion.word_boundaries, for completion
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SuggestInlineResponse) Reset() {
+	*x = SuggestInlineResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuggestInlineResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuggestInlineResponse) ProtoMessage() {}
+
+func (x *SuggestInlineResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuggestInlineResponse.ProtoReflect.Descriptor instead.
+func (*SuggestInlineResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SuggestInlineResponse) GetCompletion() string {
+	if x != nil {
+		return x.Completion
+	}
+	return ""
+}
+
+func (x *SuggestInlineResponse) GetSuffix() string {
+	if x != nil {
+		return x.Suffix
+	}
+	return ""
+}
+
+func (x *SuggestInlineResponse) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *SuggestInlineResponse) GetSuperseded() bool {
+	if x != nil {
+		return x.Superseded
+	}
+	return false
+}
+
+func (x *SuggestInlineResponse) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *SuggestInlineResponse) GetLatencyMs() int64 {
+	if x != nil {
+		return x.LatencyMs
+	}
+	return 0
+}
+
+// TimingHint provides adaptive timing information for shell integrations.
+func (x *SuggestInlineResponse) GetWordBoundaries() []int32 {
+	if x != nil {
+		return x.WordBoundaries
+	}
+	return nil
+}
+
+type TimingHint struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	UserSpeedClass            string                 `protobuf:"bytes,1,opt,name=user_speed_class,json=userSpeedClass,proto3" json:"user_speed_class,omitempty"`                                     // e.g. "fast", "moderate", "slow"
+	SuggestedPauseThresholdMs int32                  `protobuf:"varint,2,opt,name=suggested_pause_threshold_ms,json=suggestedPauseThresholdMs,proto3" json:"suggested_pause_threshold_ms,omitempty"` // Suggested pause before showing suggestions
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *TimingHint) Reset() {
+	*x = TimingHint{}
+	mi := &file_clai_v1_clai_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimingHint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimingHint) ProtoMessage() {}
+
+func (x *TimingHint) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimingHint.ProtoReflect.Descriptor instead.
+func (*TimingHint) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *TimingHint) GetUserSpeedClass() string {
+	if x != nil {
+		return x.UserSpeedClass
+	}
+	return ""
+}
+
+func (x *TimingHint) GetSuggestedPauseThresholdMs() int32 {
+	if x != nil {
+		return x.SuggestedPauseThresholdMs
+	}
+	return 0
+}
+
+type SuggestResponse struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Suggestions []*Suggestion          `protobuf:"bytes,1,rep,name=suggestions,proto3" json:"suggestions,omitempty"`
+	FromCache   bool                   `protobuf:"varint,2,opt,name=from_cache,json=fromCache,proto3" json:"from_cache,omitempty"` // True if served from cache
+	// V2 fields: response-level metadata
+	CacheStatus   string          `protobuf:"bytes,3,opt,name=cache_status,json=cacheStatus,proto3" json:"cache_status,omitempty"` // "hit", "miss", "stale" (more granular than from_cache)
+	LatencyMs     int64           `protobuf:"varint,4,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`      // Server-side processing time
+	TimingHint    *TimingHint     `protobuf:"bytes,5,opt,name=timing_hint,json=timingHint,proto3" json:"timing_hint,omitempty"`    // Adaptive timing guidance for shell integration
+	Degraded      bool            `protobuf:"varint,6,opt,name=degraded,proto3" json:"degraded,omitempty"`                         // Session is fire-and-forget only (latency budget exceeded); nothing was computed
+	Scoring       *SuggestScoring `protobuf:"bytes,7,opt,name=scoring,proto3" json:"scoring,omitempty"`                            // How the suggestions were ranked; kept for ExplainSuggestions, left empty in responses
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuggestResponse) Reset() {
+	*x = SuggestResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuggestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuggestResponse) ProtoMessage() {}
+
+func (x *SuggestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuggestResponse.ProtoReflect.Descriptor instead.
+func (*SuggestResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *SuggestResponse) GetSuggestions() []*Suggestion {
+	if x != nil {
+		return x.Suggestions
+	}
+	return nil
+}
+
+func (x *SuggestResponse) GetFromCache() bool {
+	if x != nil {
+		return x.FromCache
+	}
+	return false
+}
+
+func (x *SuggestResponse) GetCacheStatus() string {
+	if x != nil {
+		return x.CacheStatus
+	}
+	return ""
+}
+
+func (x *SuggestResponse) GetLatencyMs() int64 {
+	if x != nil {
+		return x.LatencyMs
+	}
+	return 0
+}
+
+func (x *SuggestResponse) GetTimingHint() *TimingHint {
+	if x != nil {
+		return x.TimingHint
+	}
+	return nil
+}
+
+// RecordFeedbackRequest captures user feedback on suggestions.
+// Primary feedback path is automatic from shell integrations.
+func (x *SuggestResponse) GetDegraded() bool {
+	if x != nil {
+		return x.Degraded
+	}
+	return false
+}
+
+func (x *SuggestResponse) GetScoring() *SuggestScoring {
+	if x != nil {
+		return x.Scoring
+	}
+	return nil
+}
+
+type RecordFeedbackRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`             // Session that generated the suggestion
+	Action        string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`                                    // "accepted", "dismissed", "edited", "never", "unblock"
+	SuggestedText string                 `protobuf:"bytes,3,opt,name=suggested_text,json=suggestedText,proto3" json:"suggested_text,omitempty"` // The suggestion that was shown
+	ExecutedText  string                 `protobuf:"bytes,4,opt,name=executed_text,json=executedText,proto3" json:"executed_text,omitempty"`    // What the user actually executed (for "edited")
+	Prefix        string                 `protobuf:"bytes,5,opt,name=prefix,proto3" json:"prefix,omitempty"`                                    // The prefix/buffer when suggestion was shown
+	LatencyMs     int64                  `protobuf:"varint,6,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`            // Time from suggestion display to user action
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordFeedbackRequest) Reset() {
+	*x = RecordFeedbackRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordFeedbackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordFeedbackRequest) ProtoMessage() {}
+
+func (x *RecordFeedbackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordFeedbackRequest.ProtoReflect.Descriptor instead.
+func (*RecordFeedbackRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *RecordFeedbackRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *RecordFeedbackRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *RecordFeedbackRequest) GetSuggestedText() string {
+	if x != nil {
+		return x.SuggestedText
+	}
+	return ""
+}
+
+func (x *RecordFeedbackRequest) GetExecutedText() string {
+	if x != nil {
+		return x.ExecutedText
+	}
+	return ""
+}
+
+func (x *RecordFeedbackRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *RecordFeedbackRequest) GetLatencyMs() int64 {
+	if x != nil {
+		return x.LatencyMs
+	}
+	return 0
+}
+
+// RecordFeedbackResponse confirms feedback recording.
+type RecordFeedbackResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Error         *ApiError              `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"` // Structured error if ok=false
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordFeedbackResponse) Reset() {
+	*x = RecordFeedbackResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordFeedbackResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordFeedbackResponse) ProtoMessage() {}
+
+func (x *RecordFeedbackResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordFeedbackResponse.ProtoReflect.Descriptor instead.
+func (*RecordFeedbackResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RecordFeedbackResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *RecordFeedbackResponse) GetError() *ApiError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+// SnapshotFeedbackRequest rates a suggestion shown at the prompt the
+// session's last command ran from, whether or not the suggestion was used.
+type SnapshotFeedbackRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Helpful       bool                   `protobuf:"varint,2,opt,name=helpful,proto3" json:"helpful,omitempty"`                            // Thumbs up; thumbs down otherwise
+	LastCommand   bool                   `protobuf:"varint,3,opt,name=last_command,json=lastCommand,proto3" json:"last_command,omitempty"` // Rate the last command run instead of the suggestion
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotFeedbackRequest) Reset() {
+	*x = SnapshotFeedbackRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotFeedbackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotFeedbackRequest) ProtoMessage() {}
+
+func (x *SnapshotFeedbackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotFeedbackRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotFeedbackRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *SnapshotFeedbackRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SnapshotFeedbackRequest) GetHelpful() bool {
+	if x != nil {
+		return x.Helpful
+	}
+	return false
+}
+
+func (x *SnapshotFeedbackRequest) GetLastCommand() bool {
+	if x != nil {
+		return x.LastCommand
+	}
+	return false
+}
+
+type SnapshotFeedbackResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Error         *ApiError              `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`                                      // Structured error if ok=false
+	SuggestedText string                 `protobuf:"bytes,3,opt,name=suggested_text,json=suggestedText,proto3" json:"suggested_text,omitempty"` // The command the feedback was recorded for
+	Action        string                 `protobuf:"bytes,4,opt,name=action,proto3" json:"action,omitempty"`                                    // "accepted" or "dismissed"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotFeedbackResponse) Reset() {
+	*x = SnapshotFeedbackResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotFeedbackResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotFeedbackResponse) ProtoMessage() {}
+
+func (x *SnapshotFeedbackResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotFeedbackResponse.ProtoReflect.Descriptor instead.
+func (*SnapshotFeedbackResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *SnapshotFeedbackResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SnapshotFeedbackResponse) GetError() *ApiError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *SnapshotFeedbackResponse) GetSuggestedText() string {
+	if x != nil {
+		return x.SuggestedText
+	}
+	return ""
+}
+
+func (x *SnapshotFeedbackResponse) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+// ExplainSuggestionsRequest asks how the suggestions shown at the prompt the
+// session's last command ran from were scored.
+type ExplainSuggestionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainSuggestionsRequest) Reset() {
+	*x = ExplainSuggestionsRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainSuggestionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainSuggestionsRequest) ProtoMessage() {}
+
+func (x *ExplainSuggestionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainSuggestionsRequest.ProtoReflect.Descriptor instead.
+func (*ExplainSuggestionsRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ExplainSuggestionsRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type ExplainSuggestionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Error         *ApiError              `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`                                           // Structured error if ok=false
+	Prefix        string                 `protobuf:"bytes,3,opt,name=prefix,proto3" json:"prefix,omitempty"`                                         // Buffer the suggestions were made for
+	Command       string                 `protobuf:"bytes,4,opt,name=command,proto3" json:"command,omitempty"`                                       // Command run from the prompt
+	ShownAtUnixMs int64                  `protobuf:"varint,5,opt,name=shown_at_unix_ms,json=shownAtUnixMs,proto3" json:"shown_at_unix_ms,omitempty"` // When the suggestions were shown
+	Suggestions   []*Suggestion          `protobuf:"bytes,6,rep,name=suggestions,proto3" json:"suggestions,omitempty"`                               // Best first, with reasons and breakdown
+	Scoring       *SuggestScoring        `protobuf:"bytes,7,opt,name=scoring,proto3" json:"scoring,omitempty"`                                       // Unset if the scorer did not report it
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainSuggestionsResponse) Reset() {
+	*x = ExplainSuggestionsResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainSuggestionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainSuggestionsResponse) ProtoMessage() {}
+
+func (x *ExplainSuggestionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainSuggestionsResponse.ProtoReflect.Descriptor instead.
+func (*ExplainSuggestionsResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ExplainSuggestionsResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *ExplainSuggestionsResponse) GetError() *ApiError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *ExplainSuggestionsResponse) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ExplainSuggestionsResponse) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *ExplainSuggestionsResponse) GetShownAtUnixMs() int64 {
+	if x != nil {
+		return x.ShownAtUnixMs
+	}
+	return 0
+}
+
+func (x *ExplainSuggestionsResponse) GetSuggestions() []*Suggestion {
+	if x != nil {
+		return x.Suggestions
+	}
+	return nil
+}
+
+func (x *ExplainSuggestionsResponse) GetScoring() *SuggestScoring {
+	if x != nil {
+		return x.Scoring
+	}
+	return nil
+}
+
+// ListDismissalsRequest lists the dismissal patterns learned from feedback.
+type ListDismissalsRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Scope            string                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`                                                // Only this scope; empty for every scope
+	IncludeTemporary bool                   `protobuf:"varint,2,opt,name=include_temporary,json=includeTemporary,proto3" json:"include_temporary,omitempty"` // Also list patterns that only lower scores
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ListDismissalsRequest) Reset() {
+	*x = ListDismissalsRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDismissalsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDismissalsRequest) ProtoMessage() {}
+
+func (x *ListDismissalsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDismissalsRequest.ProtoReflect.Descriptor instead.
+func (*ListDismissalsRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ListDismissalsRequest) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *ListDismissalsRequest) GetIncludeTemporary() bool {
+	if x != nil {
+		return x.IncludeTemporary
+	}
+	return false
+}
+
+// Dismissal is a suggestion suppressed after a given previous command.
+type Dismissal struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Scope               string                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`
+	ContextTemplateId   string                 `protobuf:"bytes,2,opt,name=context_template_id,json=contextTemplateId,proto3" json:"context_template_id,omitempty"`       // Template of the previous command
+	DismissedTemplateId string                 `protobuf:"bytes,3,opt,name=dismissed_template_id,json=dismissedTemplateId,proto3" json:"dismissed_template_id,omitempty"` // Template of the suppressed suggestion
+	ContextCommand      string                 `protobuf:"bytes,4,opt,name=context_command,json=contextCommand,proto3" json:"context_command,omitempty"`                  // Normalized previous command, if known
+	DismissedCommand    string                 `protobuf:"bytes,5,opt,name=dismissed_command,json=dismissedCommand,proto3" json:"dismissed_command,omitempty"`            // Normalized suggestion, if known
+	Level               string                 `protobuf:"bytes,6,opt,name=level,proto3" json:"level,omitempty"`                                                          // "temporary", "learned" or "permanent"
+	DismissalCount      int32                  `protobuf:"varint,7,opt,name=dismissal_count,json=dismissalCount,proto3" json:"dismissal_count,omitempty"`
+	LastDismissedMs     int64                  `protobuf:"varint,8,opt,name=last_dismissed_ms,json=lastDismissedMs,proto3" json:"last_dismissed_ms,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Dismissal) Reset() {
+	*x = Dismissal{}
+	mi := &file_clai_v1_clai_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Dismissal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Dismissal) ProtoMessage() {}
+
+func (x *Dismissal) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Dismissal.ProtoReflect.Descriptor instead.
+func (*Dismissal) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *Dismissal) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *Dismissal) GetContextTemplateId() string {
+	if x != nil {
+		return x.ContextTemplateId
+	}
+	return ""
+}
+
+func (x *Dismissal) GetDismissedTemplateId() string {
+	if x != nil {
+		return x.DismissedTemplateId
+	}
+	return ""
+}
+
+func (x *Dismissal) GetContextCommand() string {
+	if x != nil {
+		return x.ContextCommand
+	}
+	return ""
+}
+
+func (x *Dismissal) GetDismissedCommand() string {
+	if x != nil {
+		return x.DismissedCommand
+	}
+	return ""
+}
+
+func (x *Dismissal) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *Dismissal) GetDismissalCount() int32 {
+	if x != nil {
+		return x.DismissalCount
+	}
+	return 0
+}
+
+func (x *Dismissal) GetLastDismissedMs() int64 {
+	if x != nil {
+		return x.LastDismissedMs
+	}
+	return 0
+}
+
+type ListDismissalsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dismissals    []*Dismissal           `protobuf:"bytes,1,rep,name=dismissals,proto3" json:"dismissals,omitempty"` // Most recently dismissed first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDismissalsResponse) Reset() {
+	*x = ListDismissalsResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDismissalsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDismissalsResponse) ProtoMessage() {}
+
+func (x *ListDismissalsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDismissalsResponse.ProtoReflect.Descriptor instead.
+func (*ListDismissalsResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ListDismissalsResponse) GetDismissals() []*Dismissal {
+	if x != nil {
+		return x.Dismissals
+	}
+	return nil
+}
+
+// ClearDismissalsRequest un-suppresses a template, or every template when
+// dismissed_template_id is empty.
+type ClearDismissalsRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Scope               string                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"` // Only this scope; empty for every scope
+	DismissedTemplateId string                 `protobuf:"bytes,2,opt,name=dismissed_template_id,json=dismissedTemplateId,proto3" json:"dismissed_template_id,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ClearDismissalsRequest) Reset() {
+	*x = ClearDismissalsRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearDismissalsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearDismissalsRequest) ProtoMessage() {}
+
+func (x *ClearDismissalsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearDismissalsRequest.ProtoReflect.Descriptor instead.
+func (*ClearDismissalsRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ClearDismissalsRequest) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *ClearDismissalsRequest) GetDismissedTemplateId() string {
+	if x != nil {
+		return x.DismissedTemplateId
+	}
+	return ""
+}
+
+type ClearDismissalsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cleared       int32                  `protobuf:"varint,1,opt,name=cleared,proto3" json:"cleared,omitempty"` // Dismissal patterns removed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearDismissalsResponse) Reset() {
+	*x = ClearDismissalsResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearDismissalsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearDismissalsResponse) ProtoMessage() {}
+
+func (x *ClearDismissalsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearDismissalsResponse.ProtoReflect.Descriptor instead.
+func (*ClearDismissalsResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ClearDismissalsResponse) GetCleared() int32 {
+	if x != nil {
+		return x.Cleared
+	}
+	return 0
+}
+
+// CompareScorersRequest reads how the V1 ranker and the V2 scorer compare
+// in shadow scoring.
+type CompareScorersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Clear         bool                   `protobuf:"varint,1,opt,name=clear,proto3" json:"clear,omitempty"` // Restart counting after reading
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompareScorersRequest) Reset() {
+	*x = CompareScorersRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompareScorersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompareScorersRequest) ProtoMessage() {}
+
+func (x *CompareScorersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompareScorersRequest.ProtoReflect.Descriptor instead.
+func (*CompareScorersRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *CompareScorersRequest) GetClear() bool {
+	if x != nil {
+		return x.Clear
+	}
+	return false
+}
+
+// ScorerHits counts how often one scorer's suggestions held the command
+// that was run next.
+type ScorerHits struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Hits              int64                  `protobuf:"varint,1,opt,name=hits,proto3" json:"hits,omitempty"`                                                       // Commands among the suggestions
+	Top1              int64                  `protobuf:"varint,2,opt,name=top1,proto3" json:"top1,omitempty"`                                                       // Commands that were the first suggestion
+	Exclusive         int64                  `protobuf:"varint,3,opt,name=exclusive,proto3" json:"exclusive,omitempty"`                                             // Hits the other scorer missed
+	ReciprocalRankSum float64                `protobuf:"fixed64,4,opt,name=reciprocal_rank_sum,json=reciprocalRankSum,proto3" json:"reciprocal_rank_sum,omitempty"` // Sum of 1/rank over the hits
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ScorerHits) Reset() {
+	*x = ScorerHits{}
+	mi := &file_clai_v1_clai_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScorerHits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScorerHits) ProtoMessage() {}
+
+func (x *ScorerHits) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScorerHits.ProtoReflect.Descriptor instead.
+func (*ScorerHits) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ScorerHits) GetHits() int64 {
+	if x != nil {
+		return x.Hits
+	}
+	return 0
+}
+
+func (x *ScorerHits) GetTop1() int64 {
+	if x != nil {
+		return x.Top1
+	}
+	return 0
+}
+
+func (x *ScorerHits) GetExclusive() int64 {
+	if x != nil {
+		return x.Exclusive
+	}
+	return 0
+}
+
+func (x *ScorerHits) GetReciprocalRankSum() float64 {
+	if x != nil {
+		return x.ReciprocalRankSum
+	}
+	return 0
+}
+
+type CompareScorersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`                                 // suggestions.shadow_scoring is on
+	ScorerVersion string                 `protobuf:"bytes,2,opt,name=scorer_version,json=scorerVersion,proto3" json:"scorer_version,omitempty"` // Scorer serving suggestions
+	SinceUnixMs   int64                  `protobuf:"varint,3,opt,name=since_unix_ms,json=sinceUnixMs,proto3" json:"since_unix_ms,omitempty"`    // When counting started; 0 if nothing was compared
+	Commands      int64                  `protobuf:"varint,4,opt,name=commands,proto3" json:"commands,omitempty"`                               // Commands compared
+	V1            *ScorerHits            `protobuf:"bytes,5,opt,name=v1,proto3" json:"v1,omitempty"`
+	V2            *ScorerHits            `protobuf:"bytes,6,opt,name=v2,proto3" json:"v2,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompareScorersResponse) Reset() {
+	*x = CompareScorersResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompareScorersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompareScorersResponse) ProtoMessage() {}
+
+func (x *CompareScorersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompareScorersResponse.ProtoReflect.Descriptor instead.
+func (*CompareScorersResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *CompareScorersResponse) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *CompareScorersResponse) GetScorerVersion() string {
+	if x != nil {
+		return x.ScorerVersion
+	}
+	return ""
+}
+
+func (x *CompareScorersResponse) GetSinceUnixMs() int64 {
+	if x != nil {
+		return x.SinceUnixMs
+	}
+	return 0
+}
+
+func (x *CompareScorersResponse) GetCommands() int64 {
+	if x != nil {
+		return x.Commands
+	}
+	return 0
+}
+
+func (x *CompareScorersResponse) GetV1() *ScorerHits {
+	if x != nil {
+		return x.V1
+	}
+	return nil
+}
+
+func (x *CompareScorersResponse) GetV2() *ScorerHits {
+	if x != nil {
+		return x.V2
+	}
+	return nil
+}
+
+type TextToCommandRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SessionId      string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Prompt         string                 `protobuf:"bytes,2,opt,name=prompt,proto3" json:"prompt,omitempty"` // Natural language prompt
+	Cwd            string                 `protobuf:"bytes,3,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	MaxSuggestions int32                  `protobuf:"varint,4,opt,name=max_suggestions,json=maxSuggestions,proto3" json:"max_suggestions,omitempty"` // Default: 3
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TextToCommandRequest) Reset() {
+	*x = TextToCommandRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextToCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextToCommandRequest) ProtoMessage() {}
+
+func (x *TextToCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextToCommandRequest.ProtoReflect.Descriptor instead.
+func (*TextToCommandRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *TextToCommandRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *TextToCommandRequest) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *TextToCommandRequest) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *TextToCommandRequest) GetMaxSuggestions() int32 {
+	if x != nil {
+		return x.MaxSuggestions
+	}
+	return 0
+}
+
+type TextToCommandResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Suggestions   []*Suggestion          `protobuf:"bytes,1,rep,name=suggestions,proto3" json:"suggestions,omitempty"`
+	Provider      string                 `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`                     // Which AI provider was used
+	LatencyMs     int64                  `protobuf:"varint,3,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"` // AI response time
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TextToCommandResponse) Reset() {
+	*x = TextToCommandResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextToCommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextToCommandResponse) ProtoMessage() {}
+
+func (x *TextToCommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextToCommandResponse.ProtoReflect.Descriptor instead.
+func (*TextToCommandResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *TextToCommandResponse) GetSuggestions() []*Suggestion {
+	if x != nil {
+		return x.Suggestions
+	}
+	return nil
+}
+
+func (x *TextToCommandResponse) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *TextToCommandResponse) GetLatencyMs() int64 {
+	if x != nil {
+		return x.LatencyMs
+	}
+	return 0
+}
+
+type NextStepRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	LastCommand   string                 `protobuf:"bytes,2,opt,name=last_command,json=lastCommand,proto3" json:"last_command,omitempty"`
+	LastExitCode  int32                  `protobuf:"varint,3,opt,name=last_exit_code,json=lastExitCode,proto3" json:"last_exit_code,omitempty"`
+	Cwd           string                 `protobuf:"bytes,4,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NextStepRequest) Reset() {
+	*x = NextStepRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NextStepRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NextStepRequest) ProtoMessage() {}
+
+func (x *NextStepRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NextStepRequest.ProtoReflect.Descriptor instead.
+func (*NextStepRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *NextStepRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *NextStepRequest) GetLastCommand() string {
+	if x != nil {
+		return x.LastCommand
+	}
+	return ""
+}
+
+func (x *NextStepRequest) GetLastExitCode() int32 {
+	if x != nil {
+		return x.LastExitCode
+	}
+	return 0
+}
+
+func (x *NextStepRequest) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+type NextStepResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Suggestions   []*Suggestion          `protobuf:"bytes,1,rep,name=suggestions,proto3" json:"suggestions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NextStepResponse) Reset() {
+	*x = NextStepResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NextStepResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NextStepResponse) ProtoMessage() {}
+
+func (x *NextStepResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NextStepResponse.ProtoReflect.Descriptor instead.
+func (*NextStepResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *NextStepResponse) GetSuggestions() []*Suggestion {
+	if x != nil {
+		return x.Suggestions
+	}
+	return nil
+}
+
+type DiagnoseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Command       string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	ExitCode      int32                  `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	Cwd           string                 `protobuf:"bytes,4,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiagnoseRequest) Reset() {
+	*x = DiagnoseRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiagnoseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiagnoseRequest) ProtoMessage() {}
+
+func (x *DiagnoseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiagnoseRequest.ProtoReflect.Descriptor instead.
+func (*DiagnoseRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *DiagnoseRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *DiagnoseRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *DiagnoseRequest) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *DiagnoseRequest) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+type DiagnoseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Explanation   string                 `protobuf:"bytes,1,opt,name=explanation,proto3" json:"explanation,omitempty"` // Human-readable explanation
+	Fixes         []*Suggestion          `protobuf:"bytes,2,rep,name=fixes,proto3" json:"fixes,omitempty"`             // Suggested fix commands
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiagnoseResponse) Reset() {
+	*x = DiagnoseResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiagnoseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiagnoseResponse) ProtoMessage() {}
+
+func (x *DiagnoseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiagnoseResponse.ProtoReflect.Descriptor instead.
+func (*DiagnoseResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *DiagnoseResponse) GetExplanation() string {
+	if x != nil {
+		return x.Explanation
+	}
+	return ""
+}
+
+func (x *DiagnoseResponse) GetFixes() []*Suggestion {
+	if x != nil {
+		return x.Fixes
+	}
+	return nil
+}
+
+type HistoryFetchRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"` // Optional: filter by session (UUID v4)
+	Query     string                 `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`                          // Substring filter (provider-side)
+	Limit     int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`                         // Page size
+	Offset    int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`                       // Pagination offset
+	Global    bool                   `protobuf:"varint,5,opt,name=global,proto3" json:"global,omitempty"`                       // True = all sessions
+	// V2 fields: extended search capabilities
+	RepoKey       string     `protobuf:"bytes,6,opt,name=repo_key,json=repoKey,proto3" json:"repo_key,omitempty"`     // Filter by repository
+	Mode          SearchMode `protobuf:"varint,7,opt,name=mode,proto3,enum=clai.v1.SearchMode" json:"mode,omitempty"` // Search strategy (fts, prefix, describe, auto)
+	Scope         string     `protobuf:"bytes,8,opt,name=scope,proto3" json:"scope,omitempty"`                        // "session", "repo", "global"
+	Host          string     `protobuf:"bytes,9,opt,name=host,proto3" json:"host,omitempty"`                          // Filter by the host sessions ran on
+	Cwd           string     `protobuf:"bytes,10,opt,name=cwd,proto3" json:"cwd,omitempty"`                           // Filter by working directory
+	Status        string     `protobuf:"bytes,11,opt,name=status,proto3" json:"status,omitempty"`                     // "success", "failure", or "" for any
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryFetchRequest) Reset() {
+	*x = HistoryFetchRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryFetchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryFetchRequest) ProtoMessage() {}
+
+func (x *HistoryFetchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryFetchRequest.ProtoReflect.Descriptor instead.
+func (*HistoryFetchRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *HistoryFetchRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *HistoryFetchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *HistoryFetchRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *HistoryFetchRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *HistoryFetchRequest) GetGlobal() bool {
+	if x != nil {
+		return x.Global
+	}
+	return false
+}
+
+func (x *HistoryFetchRequest) GetRepoKey() string {
+	if x != nil {
+		return x.RepoKey
+	}
+	return ""
+}
+
+func (x *HistoryFetchRequest) GetMode() SearchMode {
+	if x != nil {
+		return x.Mode
+	}
+	return SearchMode_SEARCH_MODE_UNSPECIFIED
+}
+
+func (x *HistoryFetchRequest) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *HistoryFetchRequest) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *HistoryFetchRequest) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *HistoryFetchRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type HistoryFetchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Items []*HistoryItem         `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	AtEnd bool                   `protobuf:"varint,2,opt,name=at_end,json=atEnd,proto3" json:"at_end,omitempty"`
+	// V2 fields: search metadata
+	LatencyMs     int64  `protobuf:"varint,3,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"` // Server-side search time
+	Backend       string `protobuf:"bytes,4,opt,name=backend,proto3" json:"backend,omitempty"`                       // Which backend served the query ("fts5", "fallback")
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryFetchResponse) Reset() {
+	*x = HistoryFetchResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryFetchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryFetchResponse) ProtoMessage() {}
+
+func (x *HistoryFetchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryFetchResponse.ProtoReflect.Descriptor instead.
+func (*HistoryFetchResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *HistoryFetchResponse) GetItems() []*HistoryItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *HistoryFetchResponse) GetAtEnd() bool {
+	if x != nil {
+		return x.AtEnd
+	}
+	return false
+}
+
+func (x *HistoryFetchResponse) GetLatencyMs() int64 {
+	if x != nil {
+		return x.LatencyMs
+	}
+	return 0
+}
+
+func (x *HistoryFetchResponse) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+type HistoryItem struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Command     string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	TimestampMs int64                  `protobuf:"varint,2,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"`
+	// V2 fields: enriched result data
+	CmdNorm       string   `protobuf:"bytes,3,opt,name=cmd_norm,json=cmdNorm,proto3" json:"cmd_norm,omitempty"`             // Normalized command form
+	RepoKey       string   `protobuf:"bytes,4,opt,name=repo_key,json=repoKey,proto3" json:"repo_key,omitempty"`             // Repository key for this command
+	RankScore     float64  `protobuf:"fixed64,5,opt,name=rank_score,json=rankScore,proto3" json:"rank_score,omitempty"`     // Relevance score from search
+	Tags          []string `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`                                  // Descriptive tags for the command
+	MatchedTags   []string `protobuf:"bytes,7,rep,name=matched_tags,json=matchedTags,proto3" json:"matched_tags,omitempty"` // Tags that matched the query
+	Truncated     bool     `protobuf:"varint,8,opt,name=truncated,proto3" json:"truncated,omitempty"`                       // Command was cut to the configured byte limit
+	EventId       int64    `protobuf:"varint,9,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`            // Suggestions database event, for GetCommandDetail (0 if none)
+	Origin        string   `protobuf:"bytes,10,opt,name=origin,proto3" json:"origin,omitempty"`                             // Database the item was read from ("v1" or "v2")
+	Cwd           string   `protobuf:"bytes,11,opt,name=cwd,proto3" json:"cwd,omitempty"`                                   // Working directory of the latest use
+	ExitCode      *int32   `protobuf:"varint,12,opt,name=exit_code,json=exitCode,proto3,oneof" json:"exit_code,omitempty"`  // Exit code of the latest use (unset if unknown)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryItem) Reset() {
+	*x = HistoryItem{}
+	mi := &file_clai_v1_clai_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryItem) ProtoMessage() {}
+
+func (x *HistoryItem) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryItem.ProtoReflect.Descriptor instead.
+func (*HistoryItem) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *HistoryItem) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *HistoryItem) GetTimestampMs() int64 {
+	if x != nil {
+		return x.TimestampMs
+	}
+	return 0
+}
+
+func (x *HistoryItem) GetCmdNorm() string {
+	if x != nil {
+		return x.CmdNorm
+	}
+	return ""
+}
+
+func (x *HistoryItem) GetRepoKey() string {
+	if x != nil {
+		return x.RepoKey
+	}
+	return ""
+}
+
+func (x *HistoryItem) GetRankScore() float64 {
+	if x != nil {
+		return x.RankScore
+	}
+	return 0
+}
+
+func (x *HistoryItem) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *HistoryItem) GetMatchedTags() []string {
+	if x != nil {
+		return x.MatchedTags
+	}
+	return nil
+}
+
+func (x *HistoryItem) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *HistoryItem) GetEventId() int64 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+func (x *HistoryItem) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *HistoryItem) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *HistoryItem) GetExitCode() int32 {
+	if x != nil && x.ExitCode != nil {
+		return *x.ExitCode
+	}
+	return 0
+}
+
+type HistoryImportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Shell         string                 `protobuf:"bytes,1,opt,name=shell,proto3" json:"shell,omitempty"`                                   // "bash", "zsh", "fish", or "auto"
+	HistoryPath   string                 `protobuf:"bytes,2,opt,name=history_path,json=historyPath,proto3" json:"history_path,omitempty"`    // Optional custom path (empty = default)
+	IfNotExists   bool                   `protobuf:"varint,3,opt,name=if_not_exists,json=ifNotExists,proto3" json:"if_not_exists,omitempty"` // Skip if already imported for this shell
+	Force         bool                   `protobuf:"varint,4,opt,name=force,proto3" json:"force,omitempty"`                                  // Replace existing import
+	SessionId     string                 `protobuf:"bytes,5,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`          // Optional: calling session, for rate limiting
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryImportRequest) Reset() {
+	*x = HistoryImportRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryImportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryImportRequest) ProtoMessage() {}
+
+func (x *HistoryImportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryImportRequest.ProtoReflect.Descriptor instead.
+func (*HistoryImportRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *HistoryImportRequest) GetShell() string {
+	if x != nil {
+		return x.Shell
+	}
+	return ""
+}
+
+func (x *HistoryImportRequest) GetHistoryPath() string {
+	if x != nil {
+		return x.HistoryPath
+	}
+	return ""
+}
+
+func (x *HistoryImportRequest) GetIfNotExists() bool {
+	if x != nil {
+		return x.IfNotExists
+	}
+	return false
+}
+
+func (x *HistoryImportRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+func (x *HistoryImportRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type HistoryImportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ImportedCount int32                  `protobuf:"varint,1,opt,name=imported_count,json=importedCount,proto3" json:"imported_count,omitempty"` // Number of entries imported
+	Skipped       bool                   `protobuf:"varint,2,opt,name=skipped,proto3" json:"skipped,omitempty"`                                  // True if skipped due to if_not_exists
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`                                       // Error message if failed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryImportResponse) Reset() {
+	*x = HistoryImportResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryImportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryImportResponse) ProtoMessage() {}
+
+func (x *HistoryImportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryImportResponse.ProtoReflect.Descriptor instead.
+func (*HistoryImportResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *HistoryImportResponse) GetImportedCount() int32 {
+	if x != nil {
+		return x.ImportedCount
+	}
+	return 0
+}
+
+func (x *HistoryImportResponse) GetSkipped() bool {
+	if x != nil {
+		return x.Skipped
+	}
+	return false
+}
+
+func (x *HistoryImportResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// ImportedEvent is one command from a `clai history export` file.
+type ImportedEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	TsUnixMs      int64                  `protobuf:"varint,2,opt,name=ts_unix_ms,json=tsUnixMs,proto3" json:"ts_unix_ms,omitempty"` // Command start time
+	Command       string                 `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"`
+	Cwd           string                 `protobuf:"bytes,4,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	ExitCode      *int32                 `protobuf:"varint,5,opt,name=exit_code,json=exitCode,proto3,oneof" json:"exit_code,omitempty"`
+	DurationMs    *int64                 `protobuf:"varint,6,opt,name=duration_ms,json=durationMs,proto3,oneof" json:"duration_ms,omitempty"`
+	GitBranch     string                 `protobuf:"bytes,7,opt,name=git_branch,json=gitBranch,proto3" json:"git_branch,omitempty"`
+	GitRepoRoot   string                 `protobuf:"bytes,8,opt,name=git_repo_root,json=gitRepoRoot,proto3" json:"git_repo_root,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportedEvent) Reset() {
+	*x = ImportedEvent{}
+	mi := &file_clai_v1_clai_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportedEvent) ProtoMessage() {}
+
+func (x *ImportedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportedEvent.ProtoReflect.Descriptor instead.
+func (*ImportedEvent) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *ImportedEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ImportedEvent) GetTsUnixMs() int64 {
+	if x != nil {
+		return x.TsUnixMs
+	}
+	return 0
+}
+
+func (x *ImportedEvent) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *ImportedEvent) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *ImportedEvent) GetExitCode() int32 {
+	if x != nil && x.ExitCode != nil {
+		return *x.ExitCode
+	}
+	return 0
+}
+
+func (x *ImportedEvent) GetDurationMs() int64 {
+	if x != nil && x.DurationMs != nil {
+		return *x.DurationMs
+	}
+	return 0
+}
+
+func (x *ImportedEvent) GetGitBranch() string {
+	if x != nil {
+		return x.GitBranch
+	}
+	return ""
+}
+
+func (x *ImportedEvent) GetGitRepoRoot() string {
+	if x != nil {
+		return x.GitRepoRoot
+	}
+	return ""
+}
+
+// ImportEventsRequest imports exported commands into both history stores.
+// Commands already present (same session, start time and text) are skipped.
+type ImportEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*ImportedEvent       `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportEventsRequest) Reset() {
+	*x = ImportEventsRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportEventsRequest) ProtoMessage() {}
+
+func (x *ImportEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportEventsRequest.ProtoReflect.Descriptor instead.
+func (*ImportEventsRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *ImportEventsRequest) GetEvents() []*ImportedEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type ImportEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Imported      int32                  `protobuf:"varint,1,opt,name=imported,proto3" json:"imported,omitempty"`     // Commands added to the history database
+	Duplicates    int32                  `protobuf:"varint,2,opt,name=duplicates,proto3" json:"duplicates,omitempty"` // Commands it already had
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportEventsResponse) Reset() {
+	*x = ImportEventsResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportEventsResponse) ProtoMessage() {}
+
+func (x *ImportEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportEventsResponse.ProtoReflect.Descriptor instead.
+func (*ImportEventsResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *ImportEventsResponse) GetImported() int32 {
+	if x != nil {
+		return x.Imported
+	}
+	return 0
+}
+
+func (x *ImportEventsResponse) GetDuplicates() int32 {
+	if x != nil {
+		return x.Duplicates
+	}
+	return 0
+}
+
+// DeleteCommandRequest removes every command whose text contains pattern
+// (case-sensitive), or with exact, every command that is exactly pattern.
+// DeleteCommand hides the commands from history and suggestions;
+// PurgeCommand erases them and everything learned from them.
+type DeleteCommandRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pattern       string                 `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	DryRun        bool                   `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"` // Only report what would be removed
+	Exact         bool                   `protobuf:"varint,3,opt,name=exact,proto3" json:"exact,omitempty"`                 // Match the whole command text
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCommandRequest) Reset() {
+	*x = DeleteCommandRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCommandRequest) ProtoMessage() {}
+
+func (x *DeleteCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCommandRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCommandRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *DeleteCommandRequest) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *DeleteCommandRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *DeleteCommandRequest) GetExact() bool {
+	if x != nil {
+		return x.Exact
+	}
+	return false
+}
+
+type DeleteCommandResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commands      int32                  `protobuf:"varint,1,opt,name=commands,proto3" json:"commands,omitempty"` // Commands removed from the history database
+	Events        int32                  `protobuf:"varint,2,opt,name=events,proto3" json:"events,omitempty"`     // Events removed from the suggestions database
+	Samples       []string               `protobuf:"bytes,3,rep,name=samples,proto3" json:"samples,omitempty"`    // Distinct matching commands, most recent first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCommandResponse) Reset() {
+	*x = DeleteCommandResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCommandResponse) ProtoMessage() {}
+
+func (x *DeleteCommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCommandResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCommandResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *DeleteCommandResponse) GetCommands() int32 {
+	if x != nil {
+		return x.Commands
+	}
+	return 0
+}
+
+func (x *DeleteCommandResponse) GetEvents() int32 {
+	if x != nil {
+		return x.Events
+	}
+	return 0
+}
+
+func (x *DeleteCommandResponse) GetSamples() []string {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+// GetCommandDetailRequest asks for one recorded command, by the event_id of
+// its HistoryItem.
+type GetCommandDetailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       int64                  `protobuf:"varint,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCommandDetailRequest) Reset() {
+	*x = GetCommandDetailRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCommandDetailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCommandDetailRequest) ProtoMessage() {}
+
+func (x *GetCommandDetailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCommandDetailRequest.ProtoReflect.Descriptor instead.
+func (*GetCommandDetailRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *GetCommandDetailRequest) GetEventId() int64 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+type GetCommandDetailResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Found           bool                   `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"` // False if the event does not exist
+	Command         string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	TimestampMs     int64                  `protobuf:"varint,3,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"`
+	Cwd             string                 `protobuf:"bytes,4,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	ExitCode        int32                  `protobuf:"varint,5,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	DurationMs      int64                  `protobuf:"varint,6,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	HasOutput       bool                   `protobuf:"varint,7,opt,name=has_output,json=hasOutput,proto3" json:"has_output,omitempty"` // Output was captured for the command
+	StdoutTail      string                 `protobuf:"bytes,8,opt,name=stdout_tail,json=stdoutTail,proto3" json:"stdout_tail,omitempty"`
+	StderrTail      string                 `protobuf:"bytes,9,opt,name=stderr_tail,json=stderrTail,proto3" json:"stderr_tail,omitempty"`
+	OutputTruncated bool                   `protobuf:"varint,10,opt,name=output_truncated,json=outputTruncated,proto3" json:"output_truncated,omitempty"` // stdout or stderr was cut to its tail
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetCommandDetailResponse) Reset() {
+	*x = GetCommandDetailResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCommandDetailResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCommandDetailResponse) ProtoMessage() {}
+
+func (x *GetCommandDetailResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCommandDetailResponse.ProtoReflect.Descriptor instead.
+func (*GetCommandDetailResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *GetCommandDetailResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *GetCommandDetailResponse) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *GetCommandDetailResponse) GetTimestampMs() int64 {
+	if x != nil {
+		return x.TimestampMs
+	}
+	return 0
+}
+
+func (x *GetCommandDetailResponse) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *GetCommandDetailResponse) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *GetCommandDetailResponse) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *GetCommandDetailResponse) GetHasOutput() bool {
+	if x != nil {
+		return x.HasOutput
+	}
+	return false
+}
+
+func (x *GetCommandDetailResponse) GetStdoutTail() string {
+	if x != nil {
+		return x.StdoutTail
+	}
+	return ""
+}
+
+func (x *GetCommandDetailResponse) GetStderrTail() string {
+	if x != nil {
+		return x.StderrTail
+	}
+	return ""
+}
+
+func (x *GetCommandDetailResponse) GetOutputTruncated() bool {
+	if x != nil {
+		return x.OutputTruncated
+	}
+	return false
+}
+
+type StatusResponse struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Version               string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	ActiveSessions        int32                  `protobuf:"varint,2,opt,name=active_sessions,json=activeSessions,proto3" json:"active_sessions,omitempty"`
+	UptimeSeconds         int64                  `protobuf:"varint,3,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	CommandsLogged        int64                  `protobuf:"varint,4,opt,name=commands_logged,json=commandsLogged,proto3" json:"commands_logged,omitempty"`
+	Pid                   int32                  `protobuf:"varint,5,opt,name=pid,proto3" json:"pid,omitempty"`
+	StateDbBytes          int64                  `protobuf:"varint,6,opt,name=state_db_bytes,json=stateDbBytes,proto3" json:"state_db_bytes,omitempty"`                   // Size of the history database (state.db)
+	SuggestionsDbBytes    int64                  `protobuf:"varint,7,opt,name=suggestions_db_bytes,json=suggestionsDbBytes,proto3" json:"suggestions_db_bytes,omitempty"` // Size of the V2 suggestions database
+	IngestQueueDepth      int32                  `protobuf:"varint,8,opt,name=ingest_queue_depth,json=ingestQueueDepth,proto3" json:"ingest_queue_depth,omitempty"`       // Events waiting in the ingestion queue and batch writer
+	Providers             []*ProviderStatus      `protobuf:"bytes,9,rep,name=providers,proto3" json:"providers,omitempty"`
+	LastMaintenanceUnixMs int64                  `protobuf:"varint,10,opt,name=last_maintenance_unix_ms,json=lastMaintenanceUnixMs,proto3" json:"last_maintenance_unix_ms,omitempty"` // 0 if maintenance has not run yet
+	ScorerVersion         string                 `protobuf:"bytes,11,opt,name=scorer_version,json=scorerVersion,proto3" json:"scorer_version,omitempty"`
+	DegradedSessions      int32                  `protobuf:"varint,12,opt,name=degraded_sessions,json=degradedSessions,proto3" json:"degraded_sessions,omitempty"` // Sessions in fire-and-forget-only mode
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *StatusResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetActiveSessions() int32 {
+	if x != nil {
+		return x.ActiveSessions
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetUptimeSeconds() int64 {
+	if x != nil {
+		return x.UptimeSeconds
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetCommandsLogged() int64 {
+	if x != nil {
+		return x.CommandsLogged
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
 }
 
-func (*TextToCommandResponse) ProtoMessage() {}
+func (x *StatusResponse) GetStateDbBytes() int64 {
+	if x != nil {
+		return x.StateDbBytes
+	}
+	return 0
+}
 
-func (x *TextToCommandResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[15]
+func (x *StatusResponse) GetSuggestionsDbBytes() int64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.SuggestionsDbBytes
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use TextToCommandResponse.ProtoReflect.Descriptor instead.
-func (*TextToCommandResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{15}
+func (x *StatusResponse) GetIngestQueueDepth() int32 {
+	if x != nil {
+		return x.IngestQueueDepth
+	}
+	return 0
 }
 
-func (x *TextToCommandResponse) GetSuggestions() []*Suggestion {
+func (x *StatusResponse) GetProviders() []*ProviderStatus {
 	if x != nil {
-		return x.Suggestions
+		return x.Providers
 	}
 	return nil
 }
 
-func (x *TextToCommandResponse) GetProvider() string {
+func (x *StatusResponse) GetLastMaintenanceUnixMs() int64 {
 	if x != nil {
-		return x.Provider
+		return x.LastMaintenanceUnixMs
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetScorerVersion() string {
+	if x != nil {
+		return x.ScorerVersion
 	}
 	return ""
 }
 
-func (x *TextToCommandResponse) GetLatencyMs() int64 {
+func (x *StatusResponse) GetDegradedSessions() int32 {
 	if x != nil {
-		return x.LatencyMs
+		return x.DegradedSessions
 	}
 	return 0
 }
 
-type NextStepRequest struct {
+type ProviderStatus struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	LastCommand   string                 `protobuf:"bytes,2,opt,name=last_command,json=lastCommand,proto3" json:"last_command,omitempty"`
-	LastExitCode  int32                  `protobuf:"varint,3,opt,name=last_exit_code,json=lastExitCode,proto3" json:"last_exit_code,omitempty"`
-	Cwd           string                 `protobuf:"bytes,4,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Available     bool                   `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"`
+	Preferred     bool                   `protobuf:"varint,3,opt,name=preferred,proto3" json:"preferred,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NextStepRequest) Reset() {
-	*x = NextStepRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[16]
+func (x *ProviderStatus) Reset() {
+	*x = ProviderStatus{}
+	mi := &file_clai_v1_clai_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NextStepRequest) String() string {
+func (x *ProviderStatus) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NextStepRequest) ProtoMessage() {}
+func (*ProviderStatus) ProtoMessage() {}
 
-func (x *NextStepRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[16]
+func (x *ProviderStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1302,61 +4563,56 @@ func (x *NextStepRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NextStepRequest.ProtoReflect.Descriptor instead.
-func (*NextStepRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{16}
-}
-
-func (x *NextStepRequest) GetSessionId() string {
-	if x != nil {
-		return x.SessionId
-	}
-	return ""
+// Deprecated: Use ProviderStatus.ProtoReflect.Descriptor instead.
+func (*ProviderStatus) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{60}
 }
 
-func (x *NextStepRequest) GetLastCommand() string {
+func (x *ProviderStatus) GetName() string {
 	if x != nil {
-		return x.LastCommand
+		return x.Name
 	}
 	return ""
 }
 
-func (x *NextStepRequest) GetLastExitCode() int32 {
+func (x *ProviderStatus) GetAvailable() bool {
 	if x != nil {
-		return x.LastExitCode
+		return x.Available
 	}
-	return 0
+	return false
 }
 
-func (x *NextStepRequest) GetCwd() string {
+func (x *ProviderStatus) GetPreferred() bool {
 	if x != nil {
-		return x.Cwd
+		return x.Preferred
 	}
-	return ""
+	return false
 }
 
-type NextStepResponse struct {
+// HealthResponse reports the state of each daemon subsystem.
+type HealthResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Suggestions   []*Suggestion          `protobuf:"bytes,1,rep,name=suggestions,proto3" json:"suggestions,omitempty"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // Worst subsystem status: "ok", "warn" or "error"
+	Subsystems    []*SubsystemHealth     `protobuf:"bytes,2,rep,name=subsystems,proto3" json:"subsystems,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NextStepResponse) Reset() {
-	*x = NextStepResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[17]
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NextStepResponse) String() string {
+func (x *HealthResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NextStepResponse) ProtoMessage() {}
+func (*HealthResponse) ProtoMessage() {}
 
-func (x *NextStepResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[17]
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1367,43 +4623,50 @@ func (x *NextStepResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NextStepResponse.ProtoReflect.Descriptor instead.
-func (*NextStepResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{61}
 }
 
-func (x *NextStepResponse) GetSuggestions() []*Suggestion {
+func (x *HealthResponse) GetStatus() string {
 	if x != nil {
-		return x.Suggestions
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetSubsystems() []*SubsystemHealth {
+	if x != nil {
+		return x.Subsystems
 	}
 	return nil
 }
 
-type DiagnoseRequest struct {
+type SubsystemHealth struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	Command       string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
-	ExitCode      int32                  `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
-	Cwd           string                 `protobuf:"bytes,4,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                                             // "store", "suggestions_db", "fts", "providers", "maintenance"
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`                                         // "ok", "warn", "error" or "disabled"
+	Detail        string                 `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`                                         // Human-readable explanation
+	LastRunUnixMs int64                  `protobuf:"varint,4,opt,name=last_run_unix_ms,json=lastRunUnixMs,proto3" json:"last_run_unix_ms,omitempty"` // Last run of periodic subsystems; 0 if not applicable or never run
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DiagnoseRequest) Reset() {
-	*x = DiagnoseRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[18]
+func (x *SubsystemHealth) Reset() {
+	*x = SubsystemHealth{}
+	mi := &file_clai_v1_clai_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DiagnoseRequest) String() string {
+func (x *SubsystemHealth) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DiagnoseRequest) ProtoMessage() {}
+func (*SubsystemHealth) ProtoMessage() {}
 
-func (x *DiagnoseRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[18]
+func (x *SubsystemHealth) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1414,62 +4677,63 @@ func (x *DiagnoseRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DiagnoseRequest.ProtoReflect.Descriptor instead.
-func (*DiagnoseRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use SubsystemHealth.ProtoReflect.Descriptor instead.
+func (*SubsystemHealth) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{62}
 }
 
-func (x *DiagnoseRequest) GetSessionId() string {
+func (x *SubsystemHealth) GetName() string {
 	if x != nil {
-		return x.SessionId
+		return x.Name
 	}
 	return ""
 }
 
-func (x *DiagnoseRequest) GetCommand() string {
+func (x *SubsystemHealth) GetStatus() string {
 	if x != nil {
-		return x.Command
+		return x.Status
 	}
 	return ""
 }
 
-func (x *DiagnoseRequest) GetExitCode() int32 {
+func (x *SubsystemHealth) GetDetail() string {
 	if x != nil {
-		return x.ExitCode
+		return x.Detail
 	}
-	return 0
+	return ""
 }
 
-func (x *DiagnoseRequest) GetCwd() string {
+func (x *SubsystemHealth) GetLastRunUnixMs() int64 {
 	if x != nil {
-		return x.Cwd
+		return x.LastRunUnixMs
 	}
-	return ""
+	return 0
 }
 
-type DiagnoseResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Explanation   string                 `protobuf:"bytes,1,opt,name=explanation,proto3" json:"explanation,omitempty"` // Human-readable explanation
-	Fixes         []*Suggestion          `protobuf:"bytes,2,rep,name=fixes,proto3" json:"fixes,omitempty"`             // Suggested fix commands
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type SubscribeEventsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Types           []ShellEventType       `protobuf:"varint,1,rep,packed,name=types,proto3,enum=clai.v1.ShellEventType" json:"types,omitempty"`         // Empty = all types
+	SessionId       string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`                    // Empty = all sessions
+	IncludeCommands bool                   `protobuf:"varint,3,opt,name=include_commands,json=includeCommands,proto3" json:"include_commands,omitempty"` // Include raw command text
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *DiagnoseResponse) Reset() {
-	*x = DiagnoseResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[19]
+func (x *SubscribeEventsRequest) Reset() {
+	*x = SubscribeEventsRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[63]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DiagnoseResponse) String() string {
+func (x *SubscribeEventsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DiagnoseResponse) ProtoMessage() {}
+func (*SubscribeEventsRequest) ProtoMessage() {}
 
-func (x *DiagnoseResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[19]
+func (x *SubscribeEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[63]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1480,55 +4744,67 @@ func (x *DiagnoseResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DiagnoseResponse.ProtoReflect.Descriptor instead.
-func (*DiagnoseResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{19}
+// Deprecated: Use SubscribeEventsRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeEventsRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{63}
 }
 
-func (x *DiagnoseResponse) GetExplanation() string {
+func (x *SubscribeEventsRequest) GetTypes() []ShellEventType {
 	if x != nil {
-		return x.Explanation
+		return x.Types
+	}
+	return nil
+}
+
+func (x *SubscribeEventsRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
 	}
 	return ""
 }
 
-func (x *DiagnoseResponse) GetFixes() []*Suggestion {
+func (x *SubscribeEventsRequest) GetIncludeCommands() bool {
 	if x != nil {
-		return x.Fixes
+		return x.IncludeCommands
 	}
-	return nil
+	return false
 }
 
-type HistoryFetchRequest struct {
-	state     protoimpl.MessageState `protogen:"open.v1"`
-	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"` // Optional: filter by session (UUID v4)
-	Query     string                 `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`                          // Substring filter (provider-side)
-	Limit     int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`                         // Page size
-	Offset    int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`                       // Pagination offset
-	Global    bool                   `protobuf:"varint,5,opt,name=global,proto3" json:"global,omitempty"`                       // True = all sessions
-	// V2 fields: extended search capabilities
-	RepoKey       string     `protobuf:"bytes,6,opt,name=repo_key,json=repoKey,proto3" json:"repo_key,omitempty"`     // Filter by repository
-	Mode          SearchMode `protobuf:"varint,7,opt,name=mode,proto3,enum=clai.v1.SearchMode" json:"mode,omitempty"` // Search strategy (fts, prefix, describe, auto)
-	Scope         string     `protobuf:"bytes,8,opt,name=scope,proto3" json:"scope,omitempty"`                        // "session", "repo", "global"
+// ShellEvent is shell activity as ingested by the daemon.
+type ShellEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          ShellEventType         `protobuf:"varint,1,opt,name=type,proto3,enum=clai.v1.ShellEventType" json:"type,omitempty"`
+	SessionId     string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	TsUnixMs      int64                  `protobuf:"varint,3,opt,name=ts_unix_ms,json=tsUnixMs,proto3" json:"ts_unix_ms,omitempty"`
+	Cwd           string                 `protobuf:"bytes,4,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	Shell         string                 `protobuf:"bytes,5,opt,name=shell,proto3" json:"shell,omitempty"`                                  // Session events: zsh, bash, fish, pwsh or nu
+	CommandId     string                 `protobuf:"bytes,6,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`         // Command events
+	Command       string                 `protobuf:"bytes,7,opt,name=command,proto3" json:"command,omitempty"`                              // Command events, only with include_commands
+	GitRepoName   string                 `protobuf:"bytes,8,opt,name=git_repo_name,json=gitRepoName,proto3" json:"git_repo_name,omitempty"` // Command events
+	GitBranch     string                 `protobuf:"bytes,9,opt,name=git_branch,json=gitBranch,proto3" json:"git_branch,omitempty"`         // Command events
+	ExitCode      int32                  `protobuf:"varint,10,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`          // COMMAND_END, DIAGNOSIS
+	DurationMs    int64                  `protobuf:"varint,11,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`    // COMMAND_END
+	Diagnosis     string                 `protobuf:"bytes,12,opt,name=diagnosis,proto3" json:"diagnosis,omitempty"`                         // DIAGNOSIS: explanation of the failure
+	Fixes         []string               `protobuf:"bytes,13,rep,name=fixes,proto3" json:"fixes,omitempty"`                                 // DIAGNOSIS: suggested fix commands
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HistoryFetchRequest) Reset() {
-	*x = HistoryFetchRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[20]
+func (x *ShellEvent) Reset() {
+	*x = ShellEvent{}
+	mi := &file_clai_v1_clai_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HistoryFetchRequest) String() string {
+func (x *ShellEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HistoryFetchRequest) ProtoMessage() {}
+func (*ShellEvent) ProtoMessage() {}
 
-func (x *HistoryFetchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[20]
+func (x *ShellEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1539,165 +4815,135 @@ func (x *HistoryFetchRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HistoryFetchRequest.ProtoReflect.Descriptor instead.
-func (*HistoryFetchRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{20}
+// Deprecated: Use ShellEvent.ProtoReflect.Descriptor instead.
+func (*ShellEvent) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{64}
 }
 
-func (x *HistoryFetchRequest) GetSessionId() string {
+func (x *ShellEvent) GetType() ShellEventType {
 	if x != nil {
-		return x.SessionId
+		return x.Type
 	}
-	return ""
+	return ShellEventType_SHELL_EVENT_TYPE_UNSPECIFIED
 }
 
-func (x *HistoryFetchRequest) GetQuery() string {
+func (x *ShellEvent) GetSessionId() string {
 	if x != nil {
-		return x.Query
+		return x.SessionId
 	}
 	return ""
 }
 
-func (x *HistoryFetchRequest) GetLimit() int32 {
-	if x != nil {
-		return x.Limit
-	}
-	return 0
-}
-
-func (x *HistoryFetchRequest) GetOffset() int32 {
+func (x *ShellEvent) GetTsUnixMs() int64 {
 	if x != nil {
-		return x.Offset
+		return x.TsUnixMs
 	}
 	return 0
 }
 
-func (x *HistoryFetchRequest) GetGlobal() bool {
+func (x *ShellEvent) GetCwd() string {
 	if x != nil {
-		return x.Global
+		return x.Cwd
 	}
-	return false
+	return ""
 }
 
-func (x *HistoryFetchRequest) GetRepoKey() string {
+func (x *ShellEvent) GetShell() string {
 	if x != nil {
-		return x.RepoKey
+		return x.Shell
 	}
 	return ""
 }
 
-func (x *HistoryFetchRequest) GetMode() SearchMode {
+func (x *ShellEvent) GetCommandId() string {
 	if x != nil {
-		return x.Mode
+		return x.CommandId
 	}
-	return SearchMode_SEARCH_MODE_UNSPECIFIED
+	return ""
 }
 
-func (x *HistoryFetchRequest) GetScope() string {
+func (x *ShellEvent) GetCommand() string {
 	if x != nil {
-		return x.Scope
+		return x.Command
 	}
 	return ""
 }
 
-type HistoryFetchResponse struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	Items []*HistoryItem         `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
-	AtEnd bool                   `protobuf:"varint,2,opt,name=at_end,json=atEnd,proto3" json:"at_end,omitempty"`
-	// V2 fields: search metadata
-	LatencyMs     int64  `protobuf:"varint,3,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"` // Server-side search time
-	Backend       string `protobuf:"bytes,4,opt,name=backend,proto3" json:"backend,omitempty"`                       // Which backend served the query ("fts5", "fallback")
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *HistoryFetchResponse) Reset() {
-	*x = HistoryFetchResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[21]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *HistoryFetchResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*HistoryFetchResponse) ProtoMessage() {}
-
-func (x *HistoryFetchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[21]
+func (x *ShellEvent) GetGitRepoName() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.GitRepoName
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use HistoryFetchResponse.ProtoReflect.Descriptor instead.
-func (*HistoryFetchResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{21}
+	return ""
 }
 
-func (x *HistoryFetchResponse) GetItems() []*HistoryItem {
+func (x *ShellEvent) GetGitBranch() string {
 	if x != nil {
-		return x.Items
+		return x.GitBranch
 	}
-	return nil
+	return ""
 }
 
-func (x *HistoryFetchResponse) GetAtEnd() bool {
+func (x *ShellEvent) GetExitCode() int32 {
 	if x != nil {
-		return x.AtEnd
+		return x.ExitCode
 	}
-	return false
+	return 0
 }
 
-func (x *HistoryFetchResponse) GetLatencyMs() int64 {
+func (x *ShellEvent) GetDurationMs() int64 {
 	if x != nil {
-		return x.LatencyMs
+		return x.DurationMs
 	}
 	return 0
 }
 
-func (x *HistoryFetchResponse) GetBackend() string {
+func (x *ShellEvent) GetDiagnosis() string {
 	if x != nil {
-		return x.Backend
+		return x.Diagnosis
 	}
 	return ""
 }
 
-type HistoryItem struct {
-	state       protoimpl.MessageState `protogen:"open.v1"`
-	Command     string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
-	TimestampMs int64                  `protobuf:"varint,2,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"`
-	// V2 fields: enriched result data
-	CmdNorm       string   `protobuf:"bytes,3,opt,name=cmd_norm,json=cmdNorm,proto3" json:"cmd_norm,omitempty"`             // Normalized command form
-	RepoKey       string   `protobuf:"bytes,4,opt,name=repo_key,json=repoKey,proto3" json:"repo_key,omitempty"`             // Repository key for this command
-	RankScore     float64  `protobuf:"fixed64,5,opt,name=rank_score,json=rankScore,proto3" json:"rank_score,omitempty"`     // Relevance score from search
-	Tags          []string `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`                                  // Descriptive tags for the command
-	MatchedTags   []string `protobuf:"bytes,7,rep,name=matched_tags,json=matchedTags,proto3" json:"matched_tags,omitempty"` // Tags that matched the query
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *ShellEvent) GetFixes() []string {
+	if x != nil {
+		return x.Fixes
+	}
+	return nil
 }
 
-func (x *HistoryItem) Reset() {
-	*x = HistoryItem{}
-	mi := &file_clai_v1_clai_proto_msgTypes[22]
+type SessionSummary struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	SessionId           string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Shell               string                 `protobuf:"bytes,2,opt,name=shell,proto3" json:"shell,omitempty"`
+	Os                  string                 `protobuf:"bytes,3,opt,name=os,proto3" json:"os,omitempty"`
+	Hostname            string                 `protobuf:"bytes,4,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Username            string                 `protobuf:"bytes,5,opt,name=username,proto3" json:"username,omitempty"`
+	Cwd                 string                 `protobuf:"bytes,6,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	StartedAtUnixMs     int64                  `protobuf:"varint,7,opt,name=started_at_unix_ms,json=startedAtUnixMs,proto3" json:"started_at_unix_ms,omitempty"`
+	LastActivityUnixMs  int64                  `protobuf:"varint,8,opt,name=last_activity_unix_ms,json=lastActivityUnixMs,proto3" json:"last_activity_unix_ms,omitempty"`
+	Note                string                 `protobuf:"bytes,9,opt,name=note,proto3" json:"note,omitempty"`
+	Degraded            string                 `protobuf:"bytes,10,opt,name=degraded,proto3" json:"degraded,omitempty"`                                                       // Why the session is fire-and-forget only; empty if it is not
+	DegradedUntilUnixMs int64                  `protobuf:"varint,11,opt,name=degraded_until_unix_ms,json=degradedUntilUnixMs,proto3" json:"degraded_until_unix_ms,omitempty"` // When the session is measured again
+	Name                string                 `protobuf:"bytes,12,opt,name=name,proto3" json:"name,omitempty"`                                                               // Set with `clai session name`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *SessionSummary) Reset() {
+	*x = SessionSummary{}
+	mi := &file_clai_v1_clai_proto_msgTypes[65]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HistoryItem) String() string {
+func (x *SessionSummary) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HistoryItem) ProtoMessage() {}
+func (*SessionSummary) ProtoMessage() {}
 
-func (x *HistoryItem) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[22]
+func (x *SessionSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[65]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1708,152 +4954,117 @@ func (x *HistoryItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HistoryItem.ProtoReflect.Descriptor instead.
-func (*HistoryItem) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{22}
+// Deprecated: Use SessionSummary.ProtoReflect.Descriptor instead.
+func (*SessionSummary) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{65}
 }
 
-func (x *HistoryItem) GetCommand() string {
+func (x *SessionSummary) GetSessionId() string {
 	if x != nil {
-		return x.Command
+		return x.SessionId
 	}
 	return ""
 }
 
-func (x *HistoryItem) GetTimestampMs() int64 {
+func (x *SessionSummary) GetShell() string {
 	if x != nil {
-		return x.TimestampMs
+		return x.Shell
 	}
-	return 0
+	return ""
 }
 
-func (x *HistoryItem) GetCmdNorm() string {
+func (x *SessionSummary) GetOs() string {
 	if x != nil {
-		return x.CmdNorm
+		return x.Os
 	}
 	return ""
 }
 
-func (x *HistoryItem) GetRepoKey() string {
+func (x *SessionSummary) GetHostname() string {
 	if x != nil {
-		return x.RepoKey
+		return x.Hostname
 	}
 	return ""
 }
 
-func (x *HistoryItem) GetRankScore() float64 {
+func (x *SessionSummary) GetUsername() string {
 	if x != nil {
-		return x.RankScore
+		return x.Username
 	}
-	return 0
+	return ""
 }
 
-func (x *HistoryItem) GetTags() []string {
+func (x *SessionSummary) GetCwd() string {
 	if x != nil {
-		return x.Tags
+		return x.Cwd
 	}
-	return nil
+	return ""
 }
 
-func (x *HistoryItem) GetMatchedTags() []string {
+func (x *SessionSummary) GetStartedAtUnixMs() int64 {
 	if x != nil {
-		return x.MatchedTags
+		return x.StartedAtUnixMs
 	}
-	return nil
-}
-
-type HistoryImportRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Shell         string                 `protobuf:"bytes,1,opt,name=shell,proto3" json:"shell,omitempty"`                                   // "bash", "zsh", "fish", or "auto"
-	HistoryPath   string                 `protobuf:"bytes,2,opt,name=history_path,json=historyPath,proto3" json:"history_path,omitempty"`    // Optional custom path (empty = default)
-	IfNotExists   bool                   `protobuf:"varint,3,opt,name=if_not_exists,json=ifNotExists,proto3" json:"if_not_exists,omitempty"` // Skip if already imported for this shell
-	Force         bool                   `protobuf:"varint,4,opt,name=force,proto3" json:"force,omitempty"`                                  // Replace existing import
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *HistoryImportRequest) Reset() {
-	*x = HistoryImportRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[23]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *HistoryImportRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return 0
 }
 
-func (*HistoryImportRequest) ProtoMessage() {}
-
-func (x *HistoryImportRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[23]
+func (x *SessionSummary) GetLastActivityUnixMs() int64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.LastActivityUnixMs
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use HistoryImportRequest.ProtoReflect.Descriptor instead.
-func (*HistoryImportRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{23}
+	return 0
 }
 
-func (x *HistoryImportRequest) GetShell() string {
+func (x *SessionSummary) GetNote() string {
 	if x != nil {
-		return x.Shell
+		return x.Note
 	}
 	return ""
 }
 
-func (x *HistoryImportRequest) GetHistoryPath() string {
+func (x *SessionSummary) GetDegraded() string {
 	if x != nil {
-		return x.HistoryPath
+		return x.Degraded
 	}
 	return ""
 }
 
-func (x *HistoryImportRequest) GetIfNotExists() bool {
+func (x *SessionSummary) GetDegradedUntilUnixMs() int64 {
 	if x != nil {
-		return x.IfNotExists
+		return x.DegradedUntilUnixMs
 	}
-	return false
+	return 0
 }
 
-func (x *HistoryImportRequest) GetForce() bool {
+func (x *SessionSummary) GetName() string {
 	if x != nil {
-		return x.Force
+		return x.Name
 	}
-	return false
+	return ""
 }
 
-type HistoryImportResponse struct {
+type ListSessionsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ImportedCount int32                  `protobuf:"varint,1,opt,name=imported_count,json=importedCount,proto3" json:"imported_count,omitempty"` // Number of entries imported
-	Skipped       bool                   `protobuf:"varint,2,opt,name=skipped,proto3" json:"skipped,omitempty"`                                  // True if skipped due to if_not_exists
-	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`                                       // Error message if failed
+	Sessions      []*SessionSummary      `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"` // Oldest first
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HistoryImportResponse) Reset() {
-	*x = HistoryImportResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[24]
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[66]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HistoryImportResponse) String() string {
+func (x *ListSessionsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HistoryImportResponse) ProtoMessage() {}
+func (*ListSessionsResponse) ProtoMessage() {}
 
-func (x *HistoryImportResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[24]
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[66]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1864,57 +5075,87 @@ func (x *HistoryImportResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HistoryImportResponse.ProtoReflect.Descriptor instead.
-func (*HistoryImportResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{24}
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{66}
 }
 
-func (x *HistoryImportResponse) GetImportedCount() int32 {
+func (x *ListSessionsResponse) GetSessions() []*SessionSummary {
 	if x != nil {
-		return x.ImportedCount
+		return x.Sessions
 	}
-	return 0
+	return nil
 }
 
-func (x *HistoryImportResponse) GetSkipped() bool {
+type KillSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KillSessionRequest) Reset() {
+	*x = KillSessionRequest{}
+	mi := &file_clai_v1_clai_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KillSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KillSessionRequest) ProtoMessage() {}
+
+func (x *KillSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[67]
 	if x != nil {
-		return x.Skipped
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *HistoryImportResponse) GetError() string {
+// Deprecated: Use KillSessionRequest.ProtoReflect.Descriptor instead.
+func (*KillSessionRequest) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *KillSessionRequest) GetSessionId() string {
 	if x != nil {
-		return x.Error
+		return x.SessionId
 	}
 	return ""
 }
 
-type StatusResponse struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Version        string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
-	ActiveSessions int32                  `protobuf:"varint,2,opt,name=active_sessions,json=activeSessions,proto3" json:"active_sessions,omitempty"`
-	UptimeSeconds  int64                  `protobuf:"varint,3,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
-	CommandsLogged int64                  `protobuf:"varint,4,opt,name=commands_logged,json=commandsLogged,proto3" json:"commands_logged,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+type FlushCachesResponse struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Ok                     bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Error                  string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	AiCacheEntries         int64                  `protobuf:"varint,3,opt,name=ai_cache_entries,json=aiCacheEntries,proto3" json:"ai_cache_entries,omitempty"`                         // AI response cache entries removed
+	SuggestionCacheEntries int64                  `protobuf:"varint,4,opt,name=suggestion_cache_entries,json=suggestionCacheEntries,proto3" json:"suggestion_cache_entries,omitempty"` // V2 suggestion cache entries removed
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
-func (x *StatusResponse) Reset() {
-	*x = StatusResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[25]
+func (x *FlushCachesResponse) Reset() {
+	*x = FlushCachesResponse{}
+	mi := &file_clai_v1_clai_proto_msgTypes[68]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StatusResponse) String() string {
+func (x *FlushCachesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatusResponse) ProtoMessage() {}
+func (*FlushCachesResponse) ProtoMessage() {}
 
-func (x *StatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[25]
+func (x *FlushCachesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_clai_v1_clai_proto_msgTypes[68]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1925,35 +5166,35 @@ func (x *StatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
-func (*StatusResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{25}
+// Deprecated: Use FlushCachesResponse.ProtoReflect.Descriptor instead.
+func (*FlushCachesResponse) Descriptor() ([]byte, []int) {
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{68}
 }
 
-func (x *StatusResponse) GetVersion() string {
+func (x *FlushCachesResponse) GetOk() bool {
 	if x != nil {
-		return x.Version
+		return x.Ok
 	}
-	return ""
+	return false
 }
 
-func (x *StatusResponse) GetActiveSessions() int32 {
+func (x *FlushCachesResponse) GetError() string {
 	if x != nil {
-		return x.ActiveSessions
+		return x.Error
 	}
-	return 0
+	return ""
 }
 
-func (x *StatusResponse) GetUptimeSeconds() int64 {
+func (x *FlushCachesResponse) GetAiCacheEntries() int64 {
 	if x != nil {
-		return x.UptimeSeconds
+		return x.AiCacheEntries
 	}
 	return 0
 }
 
-func (x *StatusResponse) GetCommandsLogged() int64 {
+func (x *FlushCachesResponse) GetSuggestionCacheEntries() int64 {
 	if x != nil {
-		return x.CommandsLogged
+		return x.SuggestionCacheEntries
 	}
 	return 0
 }
@@ -1971,7 +5212,7 @@ type WorkflowRunStartRequest struct {
 
 func (x *WorkflowRunStartRequest) Reset() {
 	*x = WorkflowRunStartRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[26]
+	mi := &file_clai_v1_clai_proto_msgTypes[69]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1983,7 +5224,7 @@ func (x *WorkflowRunStartRequest) String() string {
 func (*WorkflowRunStartRequest) ProtoMessage() {}
 
 func (x *WorkflowRunStartRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[26]
+	mi := &file_clai_v1_clai_proto_msgTypes[69]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1996,7 +5237,7 @@ func (x *WorkflowRunStartRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WorkflowRunStartRequest.ProtoReflect.Descriptor instead.
 func (*WorkflowRunStartRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{26}
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{69}
 }
 
 func (x *WorkflowRunStartRequest) GetRunId() string {
@@ -2044,7 +5285,7 @@ type WorkflowRunStartResponse struct {
 
 func (x *WorkflowRunStartResponse) Reset() {
 	*x = WorkflowRunStartResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[27]
+	mi := &file_clai_v1_clai_proto_msgTypes[70]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2056,7 +5297,7 @@ func (x *WorkflowRunStartResponse) String() string {
 func (*WorkflowRunStartResponse) ProtoMessage() {}
 
 func (x *WorkflowRunStartResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[27]
+	mi := &file_clai_v1_clai_proto_msgTypes[70]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2069,7 +5310,7 @@ func (x *WorkflowRunStartResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WorkflowRunStartResponse.ProtoReflect.Descriptor instead.
 func (*WorkflowRunStartResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{27}
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{70}
 }
 
 func (x *WorkflowRunStartResponse) GetOk() bool {
@@ -2098,7 +5339,7 @@ type WorkflowRunEndRequest struct {
 
 func (x *WorkflowRunEndRequest) Reset() {
 	*x = WorkflowRunEndRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[28]
+	mi := &file_clai_v1_clai_proto_msgTypes[71]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2110,7 +5351,7 @@ func (x *WorkflowRunEndRequest) String() string {
 func (*WorkflowRunEndRequest) ProtoMessage() {}
 
 func (x *WorkflowRunEndRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[28]
+	mi := &file_clai_v1_clai_proto_msgTypes[71]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2123,7 +5364,7 @@ func (x *WorkflowRunEndRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WorkflowRunEndRequest.ProtoReflect.Descriptor instead.
 func (*WorkflowRunEndRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{28}
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{71}
 }
 
 func (x *WorkflowRunEndRequest) GetRunId() string {
@@ -2164,7 +5405,7 @@ type WorkflowRunEndResponse struct {
 
 func (x *WorkflowRunEndResponse) Reset() {
 	*x = WorkflowRunEndResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[29]
+	mi := &file_clai_v1_clai_proto_msgTypes[72]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2176,7 +5417,7 @@ func (x *WorkflowRunEndResponse) String() string {
 func (*WorkflowRunEndResponse) ProtoMessage() {}
 
 func (x *WorkflowRunEndResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[29]
+	mi := &file_clai_v1_clai_proto_msgTypes[72]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2189,7 +5430,7 @@ func (x *WorkflowRunEndResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WorkflowRunEndResponse.ProtoReflect.Descriptor instead.
 func (*WorkflowRunEndResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{29}
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{72}
 }
 
 func (x *WorkflowRunEndResponse) GetOk() bool {
@@ -2224,7 +5465,7 @@ type WorkflowStepUpdateRequest struct {
 
 func (x *WorkflowStepUpdateRequest) Reset() {
 	*x = WorkflowStepUpdateRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[30]
+	mi := &file_clai_v1_clai_proto_msgTypes[73]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2236,7 +5477,7 @@ func (x *WorkflowStepUpdateRequest) String() string {
 func (*WorkflowStepUpdateRequest) ProtoMessage() {}
 
 func (x *WorkflowStepUpdateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[30]
+	mi := &file_clai_v1_clai_proto_msgTypes[73]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2249,7 +5490,7 @@ func (x *WorkflowStepUpdateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WorkflowStepUpdateRequest.ProtoReflect.Descriptor instead.
 func (*WorkflowStepUpdateRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{30}
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{73}
 }
 
 func (x *WorkflowStepUpdateRequest) GetRunId() string {
@@ -2332,7 +5573,7 @@ type WorkflowStepUpdateResponse struct {
 
 func (x *WorkflowStepUpdateResponse) Reset() {
 	*x = WorkflowStepUpdateResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[31]
+	mi := &file_clai_v1_clai_proto_msgTypes[74]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2344,7 +5585,7 @@ func (x *WorkflowStepUpdateResponse) String() string {
 func (*WorkflowStepUpdateResponse) ProtoMessage() {}
 
 func (x *WorkflowStepUpdateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[31]
+	mi := &file_clai_v1_clai_proto_msgTypes[74]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2357,7 +5598,7 @@ func (x *WorkflowStepUpdateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WorkflowStepUpdateResponse.ProtoReflect.Descriptor instead.
 func (*WorkflowStepUpdateResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{31}
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{74}
 }
 
 func (x *WorkflowStepUpdateResponse) GetOk() bool {
@@ -2389,7 +5630,7 @@ type AnalyzeStepOutputRequest struct {
 
 func (x *AnalyzeStepOutputRequest) Reset() {
 	*x = AnalyzeStepOutputRequest{}
-	mi := &file_clai_v1_clai_proto_msgTypes[32]
+	mi := &file_clai_v1_clai_proto_msgTypes[75]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2401,7 +5642,7 @@ func (x *AnalyzeStepOutputRequest) String() string {
 func (*AnalyzeStepOutputRequest) ProtoMessage() {}
 
 func (x *AnalyzeStepOutputRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[32]
+	mi := &file_clai_v1_clai_proto_msgTypes[75]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2414,7 +5655,7 @@ func (x *AnalyzeStepOutputRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AnalyzeStepOutputRequest.ProtoReflect.Descriptor instead.
 func (*AnalyzeStepOutputRequest) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{32}
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{75}
 }
 
 func (x *AnalyzeStepOutputRequest) GetRunId() string {
@@ -2477,7 +5718,7 @@ type AnalyzeStepOutputResponse struct {
 
 func (x *AnalyzeStepOutputResponse) Reset() {
 	*x = AnalyzeStepOutputResponse{}
-	mi := &file_clai_v1_clai_proto_msgTypes[33]
+	mi := &file_clai_v1_clai_proto_msgTypes[76]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2489,7 +5730,7 @@ func (x *AnalyzeStepOutputResponse) String() string {
 func (*AnalyzeStepOutputResponse) ProtoMessage() {}
 
 func (x *AnalyzeStepOutputResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_clai_v1_clai_proto_msgTypes[33]
+	mi := &file_clai_v1_clai_proto_msgTypes[76]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2502,7 +5743,7 @@ func (x *AnalyzeStepOutputResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AnalyzeStepOutputResponse.ProtoReflect.Descriptor instead.
 func (*AnalyzeStepOutputResponse) Descriptor() ([]byte, []int) {
-	return file_clai_v1_clai_proto_rawDescGZIP(), []int{33}
+	return file_clai_v1_clai_proto_rawDescGZIP(), []int{76}
 }
 
 func (x *AnalyzeStepOutputResponse) GetDecision() string {
@@ -2554,7 +5795,25 @@ const file_clai_v1_clai_proto_rawDesc = "" +
 	"\x11SessionEndRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12'\n" +
-	"\x10ended_at_unix_ms\x18\x02 \x01(\x03R\rendedAtUnixMs\"\xac\x02\n" +
+	"\x10ended_at_unix_ms\x18\x02 \x01(\x03R\rendedAtUnixMs\"]\n" +
+	"\x12SessionNoteRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04note\x18\x02 \x01(\tR\x04note\x12\x14\n" +
+	"\x05clear\x18\x03 \x01(\bR\x05clear\"O\n" +
+	"\x13SessionNoteResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x12\n" +
+	"\x04note\x18\x02 \x01(\tR\x04note\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"]\n" +
+	"\x12SessionNameRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05clear\x18\x03 \x01(\bR\x05clear\"O\n" +
+	"\x13SessionNameResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\x82\x04\n" +
 	"\x13CommandStartRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1d\n" +
@@ -2568,7 +5827,15 @@ const file_clai_v1_clai_proto_rawDesc = "" +
 	"git_branch\x18\x06 \x01(\tR\tgitBranch\x12\"\n" +
 	"\rgit_repo_name\x18\a \x01(\tR\vgitRepoName\x12\"\n" +
 	"\rgit_repo_root\x18\b \x01(\tR\vgitRepoRoot\x12&\n" +
-	"\x0fprev_command_id\x18\t \x01(\tR\rprevCommandId\"\xad\x01\n" +
+	"\x0fprev_command_id\x18\t \x01(\tR\rprevCommandId\x12\x19\n" +
+	"\bprev_cwd\x18\n" +
+	" \x01(\tR\aprevCwd\x127\n" +
+	"\x03env\x18\v \x03(\v2%.clai.v1.CommandStartRequest.EnvEntryR\x03env\x12\x1c\n" +
+	"\tephemeral\x18\f \x01(\bR\tephemeral\x12*\n" +
+	"\x11client_latency_ms\x18\r \x01(\x03R\x0fclientLatencyMs\x1a6\n" +
+	"\bEnvEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9a\x02\n" +
 	"\x11CommandEndRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1d\n" +
@@ -2578,7 +5845,22 @@ const file_clai_v1_clai_proto_rawDesc = "" +
 	"ts_unix_ms\x18\x03 \x01(\x03R\btsUnixMs\x12\x1b\n" +
 	"\texit_code\x18\x04 \x01(\x05R\bexitCode\x12\x1f\n" +
 	"\vduration_ms\x18\x05 \x01(\x03R\n" +
-	"durationMs\"\x9a\x03\n" +
+	"durationMs\x12\x1f\n" +
+	"\vstdout_tail\x18\x06 \x01(\tR\n" +
+	"stdoutTail\x12\x1f\n" +
+	"\vstderr_tail\x18\a \x01(\tR\n" +
+	"stderrTail\x12)\n" +
+	"\x10output_truncated\x18\b \x01(\bR\x0foutputTruncated\"\x86\x01\n" +
+	"\x15CommandLifecycleEvent\x124\n" +
+	"\x05start\x18\x01 \x01(\v2\x1c.clai.v1.CommandStartRequestH\x00R\x05start\x12.\n" +
+	"\x03end\x18\x02 \x01(\v2\x1a.clai.v1.CommandEndRequestH\x00R\x03endB\a\n" +
+	"\x05event\"S\n" +
+	"\x19CommandEventsBatchRequest\x126\n" +
+	"\x06events\x18\x01 \x03(\v2\x1e.clai.v1.CommandLifecycleEventR\x06events\"h\n" +
+	"\x1aCommandEventsBatchResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x05R\baccepted\x12\x16\n" +
+	"\x06failed\x18\x02 \x01(\x05R\x06failed\x12\x16\n" +
+	"\x06errors\x18\x03 \x03(\tR\x06errors\"\xc6\x03\n" +
 	"\x0eSuggestRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x10\n" +
@@ -2597,7 +5879,8 @@ const file_clai_v1_clai_proto_rawDesc = "" +
 	"\x0elast_cmd_ts_ms\x18\n" +
 	" \x01(\x03R\vlastCmdTsMs\x12$\n" +
 	"\x0elast_event_seq\x18\v \x01(\x03R\flastEventSeq\x124\n" +
-	"\x16include_low_confidence\x18\f \x01(\bR\x14includeLowConfidence\"\xf4\x01\n" +
+	"\x16include_low_confidence\x18\f \x01(\bR\x14includeLowConfidence\x12*\n" +
+	"\x11client_latency_ms\x18\r \x01(\x03R\x0fclientLatencyMs\"\xd2\x03\n" +
 	"\n" +
 	"Suggestion\x12\x12\n" +
 	"\x04text\x18\x01 \x01(\tR\x04text\x12 \n" +
@@ -2609,15 +5892,73 @@ const file_clai_v1_clai_proto_rawDesc = "" +
 	"\n" +
 	"confidence\x18\a \x01(\x01R\n" +
 	"confidence\x123\n" +
-	"\areasons\x18\b \x03(\v2\x19.clai.v1.SuggestionReasonR\areasons\"l\n" +
+	"\areasons\x18\b \x03(\v2\x19.clai.v1.SuggestionReasonR\areasons\x12\x12\n" +
+	"\x04kind\x18\t \x01(\tR\x04kind\x12\x14\n" +
+	"\x05steps\x18\n" +
+	" \x03(\tR\x05steps\x12!\n" +
+	"\frisk_message\x18\v \x01(\tR\vriskMessage\x12/\n" +
+	"\x13success_probability\x18\f \x01(\x01R\x12successProbability\x12'\n" +
+	"\x0fword_boundaries\x18\r \x03(\x05R\x0ewordBoundaries\x125\n" +
+	"\tbreakdown\x18\x0e \x03(\v2\x17.clai.v1.ScoreComponentR\tbreakdown\"l\n" +
 	"\x10SuggestionReason\x12\x12\n" +
 	"\x04type\x18\x01 \x01(\tR\x04type\x12 \n" +
 	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\"\n" +
-	"\fcontribution\x18\x03 \x01(\x02R\fcontribution\"w\n" +
+	"\fcontribution\x18\x03 \x01(\x02R\fcontribution\":\n" +
+	"\x0eScoreComponent\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\"c\n" +
+	"\x12LearnedWeightLevel\x12\x14\n" +
+	"\x05scope\x18\x01 \x01(\tR\x05scope\x12!\n" +
+	"\fsample_count\x18\x02 \x01(\x03R\vsampleCount\x12\x14\n" +
+	"\x05trust\x18\x03 \x01(\x01R\x05trust\"\xc8\x01\n" +
+	"\x0eSuggestScoring\x12\x16\n" +
+	"\x06scorer\x18\x01 \x01(\tR\x06scorer\x121\n" +
+	"\aweights\x18\x02 \x03(\v2\x17.clai.v1.ScoreComponentR\aweights\x125\n" +
+	"\alearned\x18\x03 \x03(\v2\x1b.clai.v1.LearnedWeightLevelR\alearned\x12\x19\n" +
+	"\brepo_key\x18\x04 \x01(\tR\arepoKey\x12\x19\n" +
+	"\blast_cmd\x18\x05 \x01(\tR\alastCmd\"\xb9\x01\n" +
+	"\x13SuggestSlotsRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x10\n" +
+	"\x03cwd\x18\x02 \x01(\tR\x03cwd\x12\x16\n" +
+	"\x06buffer\x18\x03 \x01(\tR\x06buffer\x12\x1d\n" +
+	"\n" +
+	"cursor_pos\x18\x04 \x01(\x05R\tcursorPos\x12\x1f\n" +
+	"\vmax_results\x18\x05 \x01(\x05R\n" +
+	"maxResults\x12\x19\n" +
+	"\brepo_key\x18\x06 \x01(\tR\arepoKey\"<\n" +
+	"\x0eSlotSuggestion\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\"G\n" +
+	"\x14SuggestSlotsResponse\x12/\n" +
+	"\x06values\x18\x01 \x03(\v2\x17.clai.v1.SlotSuggestionR\x06values\"\xbb\x01\n" +
+	"\x14SuggestInlineRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x10\n" +
+	"\x03cwd\x18\x02 \x01(\tR\x03cwd\x12\x16\n" +
+	"\x06buffer\x18\x03 \x01(\tR\x06buffer\x12\x1d\n" +
+	"\n" +
+	"cursor_pos\x18\x04 \x01(\x05R\tcursorPos\x12\x1a\n" +
+	"\brevision\x18\x05 \x01(\x03R\brevision\x12\x1f\n" +
+	"\vdebounce_ms\x18\x06 \x01(\x05R\n" +
+	"debounceMs\"\xeb\x01\n" +
+	"\x15SuggestInlineResponse\x12\x1e\n" +
+	"\n" +
+	"completion\x18\x01 \x01(\tR\n" +
+	"completion\x12\x16\n" +
+	"\x06suffix\x18\x02 \x01(\tR\x06suffix\x12\x1a\n" +
+	"\brevision\x18\x03 \x01(\x03R\brevision\x12\x1e\n" +
+	"\n" +
+	"superseded\x18\x04 \x01(\bR\n" +
+	"superseded\x12\x16\n" +
+	"\x06source\x18\x05 \x01(\tR\x06source\x12\x1d\n" +
+	"\n" +
+	"latency_ms\x18\x06 \x01(\x03R\tlatencyMs\x12'\n" +
+	"\x0fword_boundaries\x18\a \x03(\x05R\x0ewordBoundaries\"w\n" +
 	"\n" +
 	"TimingHint\x12(\n" +
 	"\x10user_speed_class\x18\x01 \x01(\tR\x0euserSpeedClass\x12?\n" +
-	"\x1csuggested_pause_threshold_ms\x18\x02 \x01(\x05R\x19suggestedPauseThresholdMs\"\xdf\x01\n" +
+	"\x1csuggested_pause_threshold_ms\x18\x02 \x01(\x05R\x19suggestedPauseThresholdMs\"\xae\x02\n" +
 	"\x0fSuggestResponse\x125\n" +
 	"\vsuggestions\x18\x01 \x03(\v2\x13.clai.v1.SuggestionR\vsuggestions\x12\x1d\n" +
 	"\n" +
@@ -2626,7 +5967,9 @@ const file_clai_v1_clai_proto_rawDesc = "" +
 	"\n" +
 	"latency_ms\x18\x04 \x01(\x03R\tlatencyMs\x124\n" +
 	"\vtiming_hint\x18\x05 \x01(\v2\x13.clai.v1.TimingHintR\n" +
-	"timingHint\"\xd1\x01\n" +
+	"timingHint\x12\x1a\n" +
+	"\bdegraded\x18\x06 \x01(\bR\bdegraded\x121\n" +
+	"\ascoring\x18\a \x01(\v2\x17.clai.v1.SuggestScoringR\ascoring\"\xd1\x01\n" +
 	"\x15RecordFeedbackRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
@@ -2638,7 +5981,64 @@ const file_clai_v1_clai_proto_rawDesc = "" +
 	"latency_ms\x18\x06 \x01(\x03R\tlatencyMs\"Q\n" +
 	"\x16RecordFeedbackResponse\x12\x0e\n" +
 	"\x02ok\x18\x01 \x01(\bR\x02ok\x12'\n" +
-	"\x05error\x18\x02 \x01(\v2\x11.clai.v1.ApiErrorR\x05error\"\x88\x01\n" +
+	"\x05error\x18\x02 \x01(\v2\x11.clai.v1.ApiErrorR\x05error\"u\n" +
+	"\x17SnapshotFeedbackRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x18\n" +
+	"\ahelpful\x18\x02 \x01(\bR\ahelpful\x12!\n" +
+	"\flast_command\x18\x03 \x01(\bR\vlastCommand\"\x92\x01\n" +
+	"\x18SnapshotFeedbackResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12'\n" +
+	"\x05error\x18\x02 \x01(\v2\x11.clai.v1.ApiErrorR\x05error\x12%\n" +
+	"\x0esuggested_text\x18\x03 \x01(\tR\rsuggestedText\x12\x16\n" +
+	"\x06action\x18\x04 \x01(\tR\x06action\":\n" +
+	"\x19ExplainSuggestionsRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\x9a\x02\n" +
+	"\x1aExplainSuggestionsResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12'\n" +
+	"\x05error\x18\x02 \x01(\v2\x11.clai.v1.ApiErrorR\x05error\x12\x16\n" +
+	"\x06prefix\x18\x03 \x01(\tR\x06prefix\x12\x18\n" +
+	"\acommand\x18\x04 \x01(\tR\acommand\x12'\n" +
+	"\x10shown_at_unix_ms\x18\x05 \x01(\x03R\rshownAtUnixMs\x125\n" +
+	"\vsuggestions\x18\x06 \x03(\v2\x13.clai.v1.SuggestionR\vsuggestions\x121\n" +
+	"\ascoring\x18\a \x01(\v2\x17.clai.v1.SuggestScoringR\ascoring\"Z\n" +
+	"\x15ListDismissalsRequest\x12\x14\n" +
+	"\x05scope\x18\x01 \x01(\tR\x05scope\x12+\n" +
+	"\x11include_temporary\x18\x02 \x01(\bR\x10includeTemporary\"\xc6\x02\n" +
+	"\tDismissal\x12\x14\n" +
+	"\x05scope\x18\x01 \x01(\tR\x05scope\x12.\n" +
+	"\x13context_template_id\x18\x02 \x01(\tR\x11contextTemplateId\x122\n" +
+	"\x15dismissed_template_id\x18\x03 \x01(\tR\x13dismissedTemplateId\x12'\n" +
+	"\x0fcontext_command\x18\x04 \x01(\tR\x0econtextCommand\x12+\n" +
+	"\x11dismissed_command\x18\x05 \x01(\tR\x10dismissedCommand\x12\x14\n" +
+	"\x05level\x18\x06 \x01(\tR\x05level\x12'\n" +
+	"\x0fdismissal_count\x18\a \x01(\x05R\x0edismissalCount\x12*\n" +
+	"\x11last_dismissed_ms\x18\b \x01(\x03R\x0flastDismissedMs\"L\n" +
+	"\x16ListDismissalsResponse\x122\n" +
+	"\n" +
+	"dismissals\x18\x01 \x03(\v2\x12.clai.v1.DismissalR\n" +
+	"dismissals\"b\n" +
+	"\x16ClearDismissalsRequest\x12\x14\n" +
+	"\x05scope\x18\x01 \x01(\tR\x05scope\x122\n" +
+	"\x15dismissed_template_id\x18\x02 \x01(\tR\x13dismissedTemplateId\"3\n" +
+	"\x17ClearDismissalsResponse\x12\x18\n" +
+	"\acleared\x18\x01 \x01(\x05R\acleared\"-\n" +
+	"\x15CompareScorersRequest\x12\x14\n" +
+	"\x05clear\x18\x01 \x01(\bR\x05clear\"\x82\x01\n" +
+	"\n" +
+	"ScorerHits\x12\x12\n" +
+	"\x04hits\x18\x01 \x01(\x03R\x04hits\x12\x12\n" +
+	"\x04top1\x18\x02 \x01(\x03R\x04top1\x12\x1c\n" +
+	"\texclusive\x18\x03 \x01(\x03R\texclusive\x12.\n" +
+	"\x13reciprocal_rank_sum\x18\x04 \x01(\x01R\x11reciprocalRankSum\"\xe3\x01\n" +
+	"\x16CompareScorersResponse\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12%\n" +
+	"\x0escorer_version\x18\x02 \x01(\tR\rscorerVersion\x12\"\n" +
+	"\rsince_unix_ms\x18\x03 \x01(\x03R\vsinceUnixMs\x12\x1a\n" +
+	"\bcommands\x18\x04 \x01(\x03R\bcommands\x12#\n" +
+	"\x02v1\x18\x05 \x01(\v2\x13.clai.v1.ScorerHitsR\x02v1\x12#\n" +
+	"\x02v2\x18\x06 \x01(\v2\x13.clai.v1.ScorerHitsR\x02v2\"\x88\x01\n" +
 	"\x14TextToCommandRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
@@ -2666,7 +6066,7 @@ const file_clai_v1_clai_proto_rawDesc = "" +
 	"\x03cwd\x18\x04 \x01(\tR\x03cwd\"_\n" +
 	"\x10DiagnoseResponse\x12 \n" +
 	"\vexplanation\x18\x01 \x01(\tR\vexplanation\x12)\n" +
-	"\x05fixes\x18\x02 \x03(\v2\x13.clai.v1.SuggestionR\x05fixes\"\xea\x01\n" +
+	"\x05fixes\x18\x02 \x03(\v2\x13.clai.v1.SuggestionR\x05fixes\"\xa8\x02\n" +
 	"\x13HistoryFetchRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x14\n" +
@@ -2676,13 +6076,17 @@ const file_clai_v1_clai_proto_rawDesc = "" +
 	"\x06global\x18\x05 \x01(\bR\x06global\x12\x19\n" +
 	"\brepo_key\x18\x06 \x01(\tR\arepoKey\x12'\n" +
 	"\x04mode\x18\a \x01(\x0e2\x13.clai.v1.SearchModeR\x04mode\x12\x14\n" +
-	"\x05scope\x18\b \x01(\tR\x05scope\"\x92\x01\n" +
+	"\x05scope\x18\b \x01(\tR\x05scope\x12\x12\n" +
+	"\x04host\x18\t \x01(\tR\x04host\x12\x10\n" +
+	"\x03cwd\x18\n" +
+	" \x01(\tR\x03cwd\x12\x16\n" +
+	"\x06status\x18\v \x01(\tR\x06status\"\x92\x01\n" +
 	"\x14HistoryFetchResponse\x12*\n" +
 	"\x05items\x18\x01 \x03(\v2\x14.clai.v1.HistoryItemR\x05items\x12\x15\n" +
 	"\x06at_end\x18\x02 \x01(\bR\x05atEnd\x12\x1d\n" +
 	"\n" +
 	"latency_ms\x18\x03 \x01(\x03R\tlatencyMs\x12\x18\n" +
-	"\abackend\x18\x04 \x01(\tR\abackend\"\xd6\x01\n" +
+	"\abackend\x18\x04 \x01(\tR\abackend\"\xe9\x02\n" +
 	"\vHistoryItem\x12\x18\n" +
 	"\acommand\x18\x01 \x01(\tR\acommand\x12!\n" +
 	"\ftimestamp_ms\x18\x02 \x01(\x03R\vtimestampMs\x12\x19\n" +
@@ -2691,21 +6095,154 @@ const file_clai_v1_clai_proto_rawDesc = "" +
 	"\n" +
 	"rank_score\x18\x05 \x01(\x01R\trankScore\x12\x12\n" +
 	"\x04tags\x18\x06 \x03(\tR\x04tags\x12!\n" +
-	"\fmatched_tags\x18\a \x03(\tR\vmatchedTags\"\x89\x01\n" +
+	"\fmatched_tags\x18\a \x03(\tR\vmatchedTags\x12\x1c\n" +
+	"\ttruncated\x18\b \x01(\bR\ttruncated\x12\x19\n" +
+	"\bevent_id\x18\t \x01(\x03R\aeventId\x12\x16\n" +
+	"\x06origin\x18\n" +
+	" \x01(\tR\x06origin\x12\x10\n" +
+	"\x03cwd\x18\v \x01(\tR\x03cwd\x12 \n" +
+	"\texit_code\x18\f \x01(\x05H\x00R\bexitCode\x88\x01\x01B\f\n" +
+	"\n" +
+	"_exit_code\"\xa8\x01\n" +
 	"\x14HistoryImportRequest\x12\x14\n" +
 	"\x05shell\x18\x01 \x01(\tR\x05shell\x12!\n" +
 	"\fhistory_path\x18\x02 \x01(\tR\vhistoryPath\x12\"\n" +
 	"\rif_not_exists\x18\x03 \x01(\bR\vifNotExists\x12\x14\n" +
-	"\x05force\x18\x04 \x01(\bR\x05force\"n\n" +
+	"\x05force\x18\x04 \x01(\bR\x05force\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x05 \x01(\tR\tsessionId\"n\n" +
 	"\x15HistoryImportResponse\x12%\n" +
 	"\x0eimported_count\x18\x01 \x01(\x05R\rimportedCount\x12\x18\n" +
 	"\askipped\x18\x02 \x01(\bR\askipped\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\"\xa3\x01\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xa1\x02\n" +
+	"\rImportedEvent\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1c\n" +
+	"\n" +
+	"ts_unix_ms\x18\x02 \x01(\x03R\btsUnixMs\x12\x18\n" +
+	"\acommand\x18\x03 \x01(\tR\acommand\x12\x10\n" +
+	"\x03cwd\x18\x04 \x01(\tR\x03cwd\x12 \n" +
+	"\texit_code\x18\x05 \x01(\x05H\x00R\bexitCode\x88\x01\x01\x12$\n" +
+	"\vduration_ms\x18\x06 \x01(\x03H\x01R\n" +
+	"durationMs\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"git_branch\x18\a \x01(\tR\tgitBranch\x12\"\n" +
+	"\rgit_repo_root\x18\b \x01(\tR\vgitRepoRootB\f\n" +
+	"\n" +
+	"_exit_codeB\x0e\n" +
+	"\f_duration_ms\"E\n" +
+	"\x13ImportEventsRequest\x12.\n" +
+	"\x06events\x18\x01 \x03(\v2\x16.clai.v1.ImportedEventR\x06events\"R\n" +
+	"\x14ImportEventsResponse\x12\x1a\n" +
+	"\bimported\x18\x01 \x01(\x05R\bimported\x12\x1e\n" +
+	"\n" +
+	"duplicates\x18\x02 \x01(\x05R\n" +
+	"duplicates\"_\n" +
+	"\x14DeleteCommandRequest\x12\x18\n" +
+	"\apattern\x18\x01 \x01(\tR\apattern\x12\x17\n" +
+	"\adry_run\x18\x02 \x01(\bR\x06dryRun\x12\x14\n" +
+	"\x05exact\x18\x03 \x01(\bR\x05exact\"e\n" +
+	"\x15DeleteCommandResponse\x12\x1a\n" +
+	"\bcommands\x18\x01 \x01(\x05R\bcommands\x12\x16\n" +
+	"\x06events\x18\x02 \x01(\x05R\x06events\x12\x18\n" +
+	"\asamples\x18\x03 \x03(\tR\asamples\"4\n" +
+	"\x17GetCommandDetailRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\x03R\aeventId\"\xc9\x02\n" +
+	"\x18GetCommandDetailResponse\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\bR\x05found\x12\x18\n" +
+	"\acommand\x18\x02 \x01(\tR\acommand\x12!\n" +
+	"\ftimestamp_ms\x18\x03 \x01(\x03R\vtimestampMs\x12\x10\n" +
+	"\x03cwd\x18\x04 \x01(\tR\x03cwd\x12\x1b\n" +
+	"\texit_code\x18\x05 \x01(\x05R\bexitCode\x12\x1f\n" +
+	"\vduration_ms\x18\x06 \x01(\x03R\n" +
+	"durationMs\x12\x1d\n" +
+	"\n" +
+	"has_output\x18\a \x01(\bR\thasOutput\x12\x1f\n" +
+	"\vstdout_tail\x18\b \x01(\tR\n" +
+	"stdoutTail\x12\x1f\n" +
+	"\vstderr_tail\x18\t \x01(\tR\n" +
+	"stderrTail\x12)\n" +
+	"\x10output_truncated\x18\n" +
+	" \x01(\bR\x0foutputTruncated\"\xff\x03\n" +
 	"\x0eStatusResponse\x12\x18\n" +
 	"\aversion\x18\x01 \x01(\tR\aversion\x12'\n" +
 	"\x0factive_sessions\x18\x02 \x01(\x05R\x0eactiveSessions\x12%\n" +
 	"\x0euptime_seconds\x18\x03 \x01(\x03R\ruptimeSeconds\x12'\n" +
-	"\x0fcommands_logged\x18\x04 \x01(\x03R\x0ecommandsLogged\"\xcc\x01\n" +
+	"\x0fcommands_logged\x18\x04 \x01(\x03R\x0ecommandsLogged\x12\x10\n" +
+	"\x03pid\x18\x05 \x01(\x05R\x03pid\x12$\n" +
+	"\x0estate_db_bytes\x18\x06 \x01(\x03R\fstateDbBytes\x120\n" +
+	"\x14suggestions_db_bytes\x18\a \x01(\x03R\x12suggestionsDbBytes\x12,\n" +
+	"\x12ingest_queue_depth\x18\b \x01(\x05R\x10ingestQueueDepth\x125\n" +
+	"\tproviders\x18\t \x03(\v2\x17.clai.v1.ProviderStatusR\tproviders\x127\n" +
+	"\x18last_maintenance_unix_ms\x18\n" +
+	" \x01(\x03R\x15lastMaintenanceUnixMs\x12%\n" +
+	"\x0escorer_version\x18\v \x01(\tR\rscorerVersion\x12+\n" +
+	"\x11degraded_sessions\x18\f \x01(\x05R\x10degradedSessions\"`\n" +
+	"\x0eProviderStatus\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
+	"\tavailable\x18\x02 \x01(\bR\tavailable\x12\x1c\n" +
+	"\tpreferred\x18\x03 \x01(\bR\tpreferred\"b\n" +
+	"\x0eHealthResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x128\n" +
+	"\n" +
+	"subsystems\x18\x02 \x03(\v2\x18.clai.v1.SubsystemHealthR\n" +
+	"subsystems\"~\n" +
+	"\x0fSubsystemHealth\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\x12'\n" +
+	"\x10last_run_unix_ms\x18\x04 \x01(\x03R\rlastRunUnixMs\"\x91\x01\n" +
+	"\x16SubscribeEventsRequest\x12-\n" +
+	"\x05types\x18\x01 \x03(\x0e2\x17.clai.v1.ShellEventTypeR\x05types\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12)\n" +
+	"\x10include_commands\x18\x03 \x01(\bR\x0fincludeCommands\"\x8c\x03\n" +
+	"\n" +
+	"ShellEvent\x12+\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x17.clai.v1.ShellEventTypeR\x04type\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12\x1c\n" +
+	"\n" +
+	"ts_unix_ms\x18\x03 \x01(\x03R\btsUnixMs\x12\x10\n" +
+	"\x03cwd\x18\x04 \x01(\tR\x03cwd\x12\x14\n" +
+	"\x05shell\x18\x05 \x01(\tR\x05shell\x12\x1d\n" +
+	"\n" +
+	"command_id\x18\x06 \x01(\tR\tcommandId\x12\x18\n" +
+	"\acommand\x18\a \x01(\tR\acommand\x12\"\n" +
+	"\rgit_repo_name\x18\b \x01(\tR\vgitRepoName\x12\x1d\n" +
+	"\n" +
+	"git_branch\x18\t \x01(\tR\tgitBranch\x12\x1b\n" +
+	"\texit_code\x18\n" +
+	" \x01(\x05R\bexitCode\x12\x1f\n" +
+	"\vduration_ms\x18\v \x01(\x03R\n" +
+	"durationMs\x12\x1c\n" +
+	"\tdiagnosis\x18\f \x01(\tR\tdiagnosis\x12\x14\n" +
+	"\x05fixes\x18\r \x03(\tR\x05fixes\"\xf8\x02\n" +
+	"\x0eSessionSummary\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x14\n" +
+	"\x05shell\x18\x02 \x01(\tR\x05shell\x12\x0e\n" +
+	"\x02os\x18\x03 \x01(\tR\x02os\x12\x1a\n" +
+	"\bhostname\x18\x04 \x01(\tR\bhostname\x12\x1a\n" +
+	"\busername\x18\x05 \x01(\tR\busername\x12\x10\n" +
+	"\x03cwd\x18\x06 \x01(\tR\x03cwd\x12+\n" +
+	"\x12started_at_unix_ms\x18\a \x01(\x03R\x0fstartedAtUnixMs\x121\n" +
+	"\x15last_activity_unix_ms\x18\b \x01(\x03R\x12lastActivityUnixMs\x12\x12\n" +
+	"\x04note\x18\t \x01(\tR\x04note\x12\x1a\n" +
+	"\bdegraded\x18\n" +
+	" \x01(\tR\bdegraded\x123\n" +
+	"\x16degraded_until_unix_ms\x18\v \x01(\x03R\x13degradedUntilUnixMs\x12\x12\n" +
+	"\x04name\x18\f \x01(\tR\x04name\"K\n" +
+	"\x14ListSessionsResponse\x123\n" +
+	"\bsessions\x18\x01 \x03(\v2\x17.clai.v1.SessionSummaryR\bsessions\"3\n" +
+	"\x12KillSessionRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\x9f\x01\n" +
+	"\x13FlushCachesResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12(\n" +
+	"\x10ai_cache_entries\x18\x03 \x01(\x03R\x0eaiCacheEntries\x128\n" +
+	"\x18suggestion_cache_entries\x18\x04 \x01(\x03R\x16suggestionCacheEntries\"\xcc\x01\n" +
 	"\x17WorkflowRunStartRequest\x12\x15\n" +
 	"\x06run_id\x18\x01 \x01(\tR\x05runId\x12#\n" +
 	"\rworkflow_name\x18\x02 \x01(\tR\fworkflowName\x12#\n" +
@@ -2764,24 +6301,49 @@ const file_clai_v1_clai_proto_rawDesc = "" +
 	"\x0fSEARCH_MODE_FTS\x10\x01\x12\x16\n" +
 	"\x12SEARCH_MODE_PREFIX\x10\x02\x12\x18\n" +
 	"\x14SEARCH_MODE_DESCRIBE\x10\x03\x12\x14\n" +
-	"\x10SEARCH_MODE_AUTO\x10\x042\x8c\n" +
-	"\n" +
+	"\x10SEARCH_MODE_AUTO\x10\x04*\xde\x01\n" +
+	"\x0eShellEventType\x12 \n" +
+	"\x1cSHELL_EVENT_TYPE_UNSPECIFIED\x10\x00\x12\"\n" +
+	"\x1eSHELL_EVENT_TYPE_SESSION_START\x10\x01\x12 \n" +
+	"\x1cSHELL_EVENT_TYPE_SESSION_END\x10\x02\x12\"\n" +
+	"\x1eSHELL_EVENT_TYPE_COMMAND_START\x10\x03\x12 \n" +
+	"\x1cSHELL_EVENT_TYPE_COMMAND_END\x10\x04\x12\x1e\n" +
+	"\x1aSHELL_EVENT_TYPE_DIAGNOSIS\x10\x052\xc3\x15\n" +
 	"\vClaiService\x12:\n" +
 	"\fSessionStart\x12\x1c.clai.v1.SessionStartRequest\x1a\f.clai.v1.Ack\x126\n" +
 	"\n" +
 	"SessionEnd\x12\x1a.clai.v1.SessionEndRequest\x1a\f.clai.v1.Ack\x12<\n" +
 	"\x0eCommandStarted\x12\x1c.clai.v1.CommandStartRequest\x1a\f.clai.v1.Ack\x128\n" +
-	"\fCommandEnded\x12\x1a.clai.v1.CommandEndRequest\x1a\f.clai.v1.Ack\x12<\n" +
-	"\aSuggest\x12\x17.clai.v1.SuggestRequest\x1a\x18.clai.v1.SuggestResponse\x12N\n" +
+	"\fCommandEnded\x12\x1a.clai.v1.CommandEndRequest\x1a\f.clai.v1.Ack\x12]\n" +
+	"\x12CommandEventsBatch\x12\".clai.v1.CommandEventsBatchRequest\x1a#.clai.v1.CommandEventsBatchResponse\x12H\n" +
+	"\vSessionNote\x12\x1b.clai.v1.SessionNoteRequest\x1a\x1c.clai.v1.SessionNoteResponse\x12H\n" +
+	"\vSessionName\x12\x1b.clai.v1.SessionNameRequest\x1a\x1c.clai.v1.SessionNameResponse\x12<\n" +
+	"\aSuggest\x12\x17.clai.v1.SuggestRequest\x1a\x18.clai.v1.SuggestResponse\x12K\n" +
+	"\fSuggestSlots\x12\x1c.clai.v1.SuggestSlotsRequest\x1a\x1d.clai.v1.SuggestSlotsResponse\x12N\n" +
+	"\rSuggestInline\x12\x1d.clai.v1.SuggestInlineRequest\x1a\x1e.clai.v1.SuggestInlineResponse\x12N\n" +
 	"\rTextToCommand\x12\x1d.clai.v1.TextToCommandRequest\x1a\x1e.clai.v1.TextToCommandResponse\x12?\n" +
 	"\bNextStep\x12\x18.clai.v1.NextStepRequest\x1a\x19.clai.v1.NextStepResponse\x12?\n" +
 	"\bDiagnose\x12\x18.clai.v1.DiagnoseRequest\x1a\x19.clai.v1.DiagnoseResponse\x12Q\n" +
 	"\x0eRecordFeedback\x12\x1e.clai.v1.RecordFeedbackRequest\x1a\x1f.clai.v1.RecordFeedbackResponse\x12R\n" +
-	"\x0fSuggestFeedback\x12\x1e.clai.v1.RecordFeedbackRequest\x1a\x1f.clai.v1.RecordFeedbackResponse\x12K\n" +
+	"\x0fSuggestFeedback\x12\x1e.clai.v1.RecordFeedbackRequest\x1a\x1f.clai.v1.RecordFeedbackResponse\x12W\n" +
+	"\x10SnapshotFeedback\x12 .clai.v1.SnapshotFeedbackRequest\x1a!.clai.v1.SnapshotFeedbackResponse\x12]\n" +
+	"\x12ExplainSuggestions\x12\".clai.v1.ExplainSuggestionsRequest\x1a#.clai.v1.ExplainSuggestionsResponse\x12Q\n" +
+	"\x0eListDismissals\x12\x1e.clai.v1.ListDismissalsRequest\x1a\x1f.clai.v1.ListDismissalsResponse\x12T\n" +
+	"\x0fClearDismissals\x12\x1f.clai.v1.ClearDismissalsRequest\x1a .clai.v1.ClearDismissalsResponse\x12Q\n" +
+	"\x0eCompareScorers\x12\x1e.clai.v1.CompareScorersRequest\x1a\x1f.clai.v1.CompareScorersResponse\x12K\n" +
 	"\fFetchHistory\x12\x1c.clai.v1.HistoryFetchRequest\x1a\x1d.clai.v1.HistoryFetchResponse\x12N\n" +
-	"\rImportHistory\x12\x1d.clai.v1.HistoryImportRequest\x1a\x1e.clai.v1.HistoryImportResponse\x12\"\n" +
+	"\rImportHistory\x12\x1d.clai.v1.HistoryImportRequest\x1a\x1e.clai.v1.HistoryImportResponse\x12K\n" +
+	"\fImportEvents\x12\x1c.clai.v1.ImportEventsRequest\x1a\x1d.clai.v1.ImportEventsResponse\x12N\n" +
+	"\rDeleteCommand\x12\x1d.clai.v1.DeleteCommandRequest\x1a\x1e.clai.v1.DeleteCommandResponse\x12M\n" +
+	"\fPurgeCommand\x12\x1d.clai.v1.DeleteCommandRequest\x1a\x1e.clai.v1.DeleteCommandResponse\x12W\n" +
+	"\x10GetCommandDetail\x12 .clai.v1.GetCommandDetailRequest\x1a!.clai.v1.GetCommandDetailResponse\x12\"\n" +
 	"\x04Ping\x12\f.clai.v1.Ack\x1a\f.clai.v1.Ack\x122\n" +
-	"\tGetStatus\x12\f.clai.v1.Ack\x1a\x17.clai.v1.StatusResponse\x12W\n" +
+	"\tGetStatus\x12\f.clai.v1.Ack\x1a\x17.clai.v1.StatusResponse\x12/\n" +
+	"\x06Health\x12\f.clai.v1.Ack\x1a\x17.clai.v1.HealthResponse\x12;\n" +
+	"\fListSessions\x12\f.clai.v1.Ack\x1a\x1d.clai.v1.ListSessionsResponse\x128\n" +
+	"\vKillSession\x12\x1b.clai.v1.KillSessionRequest\x1a\f.clai.v1.Ack\x129\n" +
+	"\vFlushCaches\x12\f.clai.v1.Ack\x1a\x1c.clai.v1.FlushCachesResponse\x12I\n" +
+	"\x0fSubscribeEvents\x12\x1f.clai.v1.SubscribeEventsRequest\x1a\x13.clai.v1.ShellEvent0\x01\x12W\n" +
 	"\x10WorkflowRunStart\x12 .clai.v1.WorkflowRunStartRequest\x1a!.clai.v1.WorkflowRunStartResponse\x12Q\n" +
 	"\x0eWorkflowRunEnd\x12\x1e.clai.v1.WorkflowRunEndRequest\x1a\x1f.clai.v1.WorkflowRunEndResponse\x12]\n" +
 	"\x12WorkflowStepUpdate\x12\".clai.v1.WorkflowStepUpdateRequest\x1a#.clai.v1.WorkflowStepUpdateResponse\x12Z\n" +
@@ -2799,97 +6361,202 @@ func file_clai_v1_clai_proto_rawDescGZIP() []byte {
 	return file_clai_v1_clai_proto_rawDescData
 }
 
-var file_clai_v1_clai_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_clai_v1_clai_proto_msgTypes = make([]protoimpl.MessageInfo, 34)
+var file_clai_v1_clai_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_clai_v1_clai_proto_msgTypes = make([]protoimpl.MessageInfo, 78)
 var file_clai_v1_clai_proto_goTypes = []any{
 	(SearchMode)(0),                    // 0: clai.v1.SearchMode
-	(*ClientInfo)(nil),                 // 1: clai.v1.ClientInfo
-	(*Ack)(nil),                        // 2: clai.v1.Ack
-	(*ApiError)(nil),                   // 3: clai.v1.ApiError
-	(*SessionStartRequest)(nil),        // 4: clai.v1.SessionStartRequest
-	(*SessionEndRequest)(nil),          // 5: clai.v1.SessionEndRequest
-	(*CommandStartRequest)(nil),        // 6: clai.v1.CommandStartRequest
-	(*CommandEndRequest)(nil),          // 7: clai.v1.CommandEndRequest
-	(*SuggestRequest)(nil),             // 8: clai.v1.SuggestRequest
-	(*Suggestion)(nil),                 // 9: clai.v1.Suggestion
-	(*SuggestionReason)(nil),           // 10: clai.v1.SuggestionReason
-	(*TimingHint)(nil),                 // 11: clai.v1.TimingHint
-	(*SuggestResponse)(nil),            // 12: clai.v1.SuggestResponse
-	(*RecordFeedbackRequest)(nil),      // 13: clai.v1.RecordFeedbackRequest
-	(*RecordFeedbackResponse)(nil),     // 14: clai.v1.RecordFeedbackResponse
-	(*TextToCommandRequest)(nil),       // 15: clai.v1.TextToCommandRequest
-	(*TextToCommandResponse)(nil),      // 16: clai.v1.TextToCommandResponse
-	(*NextStepRequest)(nil),            // 17: clai.v1.NextStepRequest
-	(*NextStepResponse)(nil),           // 18: clai.v1.NextStepResponse
-	(*DiagnoseRequest)(nil),            // 19: clai.v1.DiagnoseRequest
-	(*DiagnoseResponse)(nil),           // 20: clai.v1.DiagnoseResponse
-	(*HistoryFetchRequest)(nil),        // 21: clai.v1.HistoryFetchRequest
-	(*HistoryFetchResponse)(nil),       // 22: clai.v1.HistoryFetchResponse
-	(*HistoryItem)(nil),                // 23: clai.v1.HistoryItem
-	(*HistoryImportRequest)(nil),       // 24: clai.v1.HistoryImportRequest
-	(*HistoryImportResponse)(nil),      // 25: clai.v1.HistoryImportResponse
-	(*StatusResponse)(nil),             // 26: clai.v1.StatusResponse
-	(*WorkflowRunStartRequest)(nil),    // 27: clai.v1.WorkflowRunStartRequest
-	(*WorkflowRunStartResponse)(nil),   // 28: clai.v1.WorkflowRunStartResponse
-	(*WorkflowRunEndRequest)(nil),      // 29: clai.v1.WorkflowRunEndRequest
-	(*WorkflowRunEndResponse)(nil),     // 30: clai.v1.WorkflowRunEndResponse
-	(*WorkflowStepUpdateRequest)(nil),  // 31: clai.v1.WorkflowStepUpdateRequest
-	(*WorkflowStepUpdateResponse)(nil), // 32: clai.v1.WorkflowStepUpdateResponse
-	(*AnalyzeStepOutputRequest)(nil),   // 33: clai.v1.AnalyzeStepOutputRequest
-	(*AnalyzeStepOutputResponse)(nil),  // 34: clai.v1.AnalyzeStepOutputResponse
+	(ShellEventType)(0),                // 1: clai.v1.ShellEventType
+	(*ClientInfo)(nil),                 // 2: clai.v1.ClientInfo
+	(*Ack)(nil),                        // 3: clai.v1.Ack
+	(*ApiError)(nil),                   // 4: clai.v1.ApiError
+	(*SessionStartRequest)(nil),        // 5: clai.v1.SessionStartRequest
+	(*SessionEndRequest)(nil),          // 6: clai.v1.SessionEndRequest
+	(*SessionNoteRequest)(nil),         // 7: clai.v1.SessionNoteRequest
+	(*SessionNoteResponse)(nil),        // 8: clai.v1.SessionNoteResponse
+	(*SessionNameRequest)(nil),         // 9: clai.v1.SessionNameRequest
+	(*SessionNameResponse)(nil),        // 10: clai.v1.SessionNameResponse
+	(*CommandStartRequest)(nil),        // 11: clai.v1.CommandStartRequest
+	(*CommandEndRequest)(nil),          // 12: clai.v1.CommandEndRequest
+	(*CommandLifecycleEvent)(nil),      // 13: clai.v1.CommandLifecycleEvent
+	(*CommandEventsBatchRequest)(nil),  // 14: clai.v1.CommandEventsBatchRequest
+	(*CommandEventsBatchResponse)(nil), // 15: clai.v1.CommandEventsBatchResponse
+	(*SuggestRequest)(nil),             // 16: clai.v1.SuggestRequest
+	(*Suggestion)(nil),                 // 17: clai.v1.Suggestion
+	(*SuggestionReason)(nil),           // 18: clai.v1.SuggestionReason
+	(*ScoreComponent)(nil),             // 19: clai.v1.ScoreComponent
+	(*LearnedWeightLevel)(nil),         // 20: clai.v1.LearnedWeightLevel
+	(*SuggestScoring)(nil),             // 21: clai.v1.SuggestScoring
+	(*SuggestSlotsRequest)(nil),        // 22: clai.v1.SuggestSlotsRequest
+	(*SlotSuggestion)(nil),             // 23: clai.v1.SlotSuggestion
+	(*SuggestSlotsResponse)(nil),       // 24: clai.v1.SuggestSlotsResponse
+	(*SuggestInlineRequest)(nil),       // 25: clai.v1.SuggestInlineRequest
+	(*SuggestInlineResponse)(nil),      // 26: clai.v1.SuggestInlineResponse
+	(*TimingHint)(nil),                 // 27: clai.v1.TimingHint
+	(*SuggestResponse)(nil),            // 28: clai.v1.SuggestResponse
+	(*RecordFeedbackRequest)(nil),      // 29: clai.v1.RecordFeedbackRequest
+	(*RecordFeedbackResponse)(nil),     // 30: clai.v1.RecordFeedbackResponse
+	(*SnapshotFeedbackRequest)(nil),    // 31: clai.v1.SnapshotFeedbackRequest
+	(*SnapshotFeedbackResponse)(nil),   // 32: clai.v1.SnapshotFeedbackResponse
+	(*ExplainSuggestionsRequest)(nil),  // 33: clai.v1.ExplainSuggestionsRequest
+	(*ExplainSuggestionsResponse)(nil), // 34: clai.v1.ExplainSuggestionsResponse
+	(*ListDismissalsRequest)(nil),      // 35: clai.v1.ListDismissalsRequest
+	(*Dismissal)(nil),                  // 36: clai.v1.Dismissal
+	(*ListDismissalsResponse)(nil),     // 37: clai.v1.ListDismissalsResponse
+	(*ClearDismissalsRequest)(nil),     // 38: clai.v1.ClearDismissalsRequest
+	(*ClearDismissalsResponse)(nil),    // 39: clai.v1.ClearDismissalsResponse
+	(*CompareScorersRequest)(nil),      // 40: clai.v1.CompareScorersRequest
+	(*ScorerHits)(nil),                 // 41: clai.v1.ScorerHits
+	(*CompareScorersResponse)(nil),     // 42: clai.v1.CompareScorersResponse
+	(*TextToCommandRequest)(nil),       // 43: clai.v1.TextToCommandRequest
+	(*TextToCommandResponse)(nil),      // 44: clai.v1.TextToCommandResponse
+	(*NextStepRequest)(nil),            // 45: clai.v1.NextStepRequest
+	(*NextStepResponse)(nil),           // 46: clai.v1.NextStepResponse
+	(*DiagnoseRequest)(nil),            // 47: clai.v1.DiagnoseRequest
+	(*DiagnoseResponse)(nil),           // 48: clai.v1.DiagnoseResponse
+	(*HistoryFetchRequest)(nil),        // 49: clai.v1.HistoryFetchRequest
+	(*HistoryFetchResponse)(nil),       // 50: clai.v1.HistoryFetchResponse
+	(*HistoryItem)(nil),                // 51: clai.v1.HistoryItem
+	(*HistoryImportRequest)(nil),       // 52: clai.v1.HistoryImportRequest
+	(*HistoryImportResponse)(nil),      // 53: clai.v1.HistoryImportResponse
+	(*ImportedEvent)(nil),              // 54: clai.v1.ImportedEvent
+	(*ImportEventsRequest)(nil),        // 55: clai.v1.ImportEventsRequest
+	(*ImportEventsResponse)(nil),       // 56: clai.v1.ImportEventsResponse
+	(*DeleteCommandRequest)(nil),       // 57: clai.v1.DeleteCommandRequest
+	(*DeleteCommandResponse)(nil),      // 58: clai.v1.DeleteCommandResponse
+	(*GetCommandDetailRequest)(nil),    // 59: clai.v1.GetCommandDetailRequest
+	(*GetCommandDetailResponse)(nil),   // 60: clai.v1.GetCommandDetailResponse
+	(*StatusResponse)(nil),             // 61: clai.v1.StatusResponse
+	(*ProviderStatus)(nil),             // 62: clai.v1.ProviderStatus
+	(*HealthResponse)(nil),             // 63: clai.v1.HealthResponse
+	(*SubsystemHealth)(nil),            // 64: clai.v1.SubsystemHealth
+	(*SubscribeEventsRequest)(nil),     // 65: clai.v1.SubscribeEventsRequest
+	(*ShellEvent)(nil),                 // 66: clai.v1.ShellEvent
+	(*SessionSummary)(nil),             // 67: clai.v1.SessionSummary
+	(*ListSessionsResponse)(nil),       // 68: clai.v1.ListSessionsResponse
+	(*KillSessionRequest)(nil),         // 69: clai.v1.KillSessionRequest
+	(*FlushCachesResponse)(nil),        // 70: clai.v1.FlushCachesResponse
+	(*WorkflowRunStartRequest)(nil),    // 71: clai.v1.WorkflowRunStartRequest
+	(*WorkflowRunStartResponse)(nil),   // 72: clai.v1.WorkflowRunStartResponse
+	(*WorkflowRunEndRequest)(nil),      // 73: clai.v1.WorkflowRunEndRequest
+	(*WorkflowRunEndResponse)(nil),     // 74: clai.v1.WorkflowRunEndResponse
+	(*WorkflowStepUpdateRequest)(nil),  // 75: clai.v1.WorkflowStepUpdateRequest
+	(*WorkflowStepUpdateResponse)(nil), // 76: clai.v1.WorkflowStepUpdateResponse
+	(*AnalyzeStepOutputRequest)(nil),   // 77: clai.v1.AnalyzeStepOutputRequest
+	(*AnalyzeStepOutputResponse)(nil),  // 78: clai.v1.AnalyzeStepOutputResponse
+	nil,                                // 79: clai.v1.CommandStartRequest.EnvEntry
 }
 var file_clai_v1_clai_proto_depIdxs = []int32{
-	1,  // 0: clai.v1.SessionStartRequest.client:type_name -> clai.v1.ClientInfo
-	10, // 1: clai.v1.Suggestion.reasons:type_name -> clai.v1.SuggestionReason
-	9,  // 2: clai.v1.SuggestResponse.suggestions:type_name -> clai.v1.Suggestion
-	11, // 3: clai.v1.SuggestResponse.timing_hint:type_name -> clai.v1.TimingHint
-	3,  // 4: clai.v1.RecordFeedbackResponse.error:type_name -> clai.v1.ApiError
-	9,  // 5: clai.v1.TextToCommandResponse.suggestions:type_name -> clai.v1.Suggestion
-	9,  // 6: clai.v1.NextStepResponse.suggestions:type_name -> clai.v1.Suggestion
-	9,  // 7: clai.v1.DiagnoseResponse.fixes:type_name -> clai.v1.Suggestion
-	0,  // 8: clai.v1.HistoryFetchRequest.mode:type_name -> clai.v1.SearchMode
-	23, // 9: clai.v1.HistoryFetchResponse.items:type_name -> clai.v1.HistoryItem
-	4,  // 10: clai.v1.ClaiService.SessionStart:input_type -> clai.v1.SessionStartRequest
-	5,  // 11: clai.v1.ClaiService.SessionEnd:input_type -> clai.v1.SessionEndRequest
-	6,  // 12: clai.v1.ClaiService.CommandStarted:input_type -> clai.v1.CommandStartRequest
-	7,  // 13: clai.v1.ClaiService.CommandEnded:input_type -> clai.v1.CommandEndRequest
-	8,  // 14: clai.v1.ClaiService.Suggest:input_type -> clai.v1.SuggestRequest
-	15, // 15: clai.v1.ClaiService.TextToCommand:input_type -> clai.v1.TextToCommandRequest
-	17, // 16: clai.v1.ClaiService.NextStep:input_type -> clai.v1.NextStepRequest
-	19, // 17: clai.v1.ClaiService.Diagnose:input_type -> clai.v1.DiagnoseRequest
-	13, // 18: clai.v1.ClaiService.RecordFeedback:input_type -> clai.v1.RecordFeedbackRequest
-	13, // 19: clai.v1.ClaiService.SuggestFeedback:input_type -> clai.v1.RecordFeedbackRequest
-	21, // 20: clai.v1.ClaiService.FetchHistory:input_type -> clai.v1.HistoryFetchRequest
-	24, // 21: clai.v1.ClaiService.ImportHistory:input_type -> clai.v1.HistoryImportRequest
-	2,  // 22: clai.v1.ClaiService.Ping:input_type -> clai.v1.Ack
-	2,  // 23: clai.v1.ClaiService.GetStatus:input_type -> clai.v1.Ack
-	27, // 24: clai.v1.ClaiService.WorkflowRunStart:input_type -> clai.v1.WorkflowRunStartRequest
-	29, // 25: clai.v1.ClaiService.WorkflowRunEnd:input_type -> clai.v1.WorkflowRunEndRequest
-	31, // 26: clai.v1.ClaiService.WorkflowStepUpdate:input_type -> clai.v1.WorkflowStepUpdateRequest
-	33, // 27: clai.v1.ClaiService.AnalyzeStepOutput:input_type -> clai.v1.AnalyzeStepOutputRequest
-	2,  // 28: clai.v1.ClaiService.SessionStart:output_type -> clai.v1.Ack
-	2,  // 29: clai.v1.ClaiService.SessionEnd:output_type -> clai.v1.Ack
-	2,  // 30: clai.v1.ClaiService.CommandStarted:output_type -> clai.v1.Ack
-	2,  // 31: clai.v1.ClaiService.CommandEnded:output_type -> clai.v1.Ack
-	12, // 32: clai.v1.ClaiService.Suggest:output_type -> clai.v1.SuggestResponse
-	16, // 33: clai.v1.ClaiService.TextToCommand:output_type -> clai.v1.TextToCommandResponse
-	18, // 34: clai.v1.ClaiService.NextStep:output_type -> clai.v1.NextStepResponse
-	20, // 35: clai.v1.ClaiService.Diagnose:output_type -> clai.v1.DiagnoseResponse
-	14, // 36: clai.v1.ClaiService.RecordFeedback:output_type -> clai.v1.RecordFeedbackResponse
-	14, // 37: clai.v1.ClaiService.SuggestFeedback:output_type -> clai.v1.RecordFeedbackResponse
-	22, // 38: clai.v1.ClaiService.FetchHistory:output_type -> clai.v1.HistoryFetchResponse
-	25, // 39: clai.v1.ClaiService.ImportHistory:output_type -> clai.v1.HistoryImportResponse
-	2,  // 40: clai.v1.ClaiService.Ping:output_type -> clai.v1.Ack
-	26, // 41: clai.v1.ClaiService.GetStatus:output_type -> clai.v1.StatusResponse
-	28, // 42: clai.v1.ClaiService.WorkflowRunStart:output_type -> clai.v1.WorkflowRunStartResponse
-	30, // 43: clai.v1.ClaiService.WorkflowRunEnd:output_type -> clai.v1.WorkflowRunEndResponse
-	32, // 44: clai.v1.ClaiService.WorkflowStepUpdate:output_type -> clai.v1.WorkflowStepUpdateResponse
-	34, // 45: clai.v1.ClaiService.AnalyzeStepOutput:output_type -> clai.v1.AnalyzeStepOutputResponse
-	28, // [28:46] is the sub-list for method output_type
-	10, // [10:28] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	2,  // 0: clai.v1.SessionStartRequest.client:type_name -> clai.v1.ClientInfo
+	79, // 1: clai.v1.CommandStartRequest.env:type_name -> clai.v1.CommandStartRequest.EnvEntry
+	11, // 2: clai.v1.CommandLifecycleEvent.start:type_name -> clai.v1.CommandStartRequest
+	12, // 3: clai.v1.CommandLifecycleEvent.end:type_name -> clai.v1.CommandEndRequest
+	13, // 4: clai.v1.CommandEventsBatchRequest.events:type_name -> clai.v1.CommandLifecycleEvent
+	18, // 5: clai.v1.Suggestion.reasons:type_name -> clai.v1.SuggestionReason
+	19, // 6: clai.v1.Suggestion.breakdown:type_name -> clai.v1.ScoreComponent
+	19, // 7: clai.v1.SuggestScoring.weights:type_name -> clai.v1.ScoreComponent
+	20, // 8: clai.v1.SuggestScoring.learned:type_name -> clai.v1.LearnedWeightLevel
+	23, // 9: clai.v1.SuggestSlotsResponse.values:type_name -> clai.v1.SlotSuggestion
+	17, // 10: clai.v1.SuggestResponse.suggestions:type_name -> clai.v1.Suggestion
+	27, // 11: clai.v1.SuggestResponse.timing_hint:type_name -> clai.v1.TimingHint
+	21, // 12: clai.v1.SuggestResponse.scoring:type_name -> clai.v1.SuggestScoring
+	4,  // 13: clai.v1.RecordFeedbackResponse.error:type_name -> clai.v1.ApiError
+	4,  // 14: clai.v1.SnapshotFeedbackResponse.error:type_name -> clai.v1.ApiError
+	4,  // 15: clai.v1.ExplainSuggestionsResponse.error:type_name -> clai.v1.ApiError
+	17, // 16: clai.v1.ExplainSuggestionsResponse.suggestions:type_name -> clai.v1.Suggestion
+	21, // 17: clai.v1.ExplainSuggestionsResponse.scoring:type_name -> clai.v1.SuggestScoring
+	36, // 18: clai.v1.ListDismissalsResponse.dismissals:type_name -> clai.v1.Dismissal
+	41, // 19: clai.v1.CompareScorersResponse.v1:type_name -> clai.v1.ScorerHits
+	41, // 20: clai.v1.CompareScorersResponse.v2:type_name -> clai.v1.ScorerHits
+	17, // 21: clai.v1.TextToCommandResponse.suggestions:type_name -> clai.v1.Suggestion
+	17, // 22: clai.v1.NextStepResponse.suggestions:type_name -> clai.v1.Suggestion
+	17, // 23: clai.v1.DiagnoseResponse.fixes:type_name -> clai.v1.Suggestion
+	0,  // 24: clai.v1.HistoryFetchRequest.mode:type_name -> clai.v1.SearchMode
+	51, // 25: clai.v1.HistoryFetchResponse.items:type_name -> clai.v1.HistoryItem
+	54, // 26: clai.v1.ImportEventsRequest.events:type_name -> clai.v1.ImportedEvent
+	62, // 27: clai.v1.StatusResponse.providers:type_name -> clai.v1.ProviderStatus
+	64, // 28: clai.v1.HealthResponse.subsystems:type_name -> clai.v1.SubsystemHealth
+	1,  // 29: clai.v1.SubscribeEventsRequest.types:type_name -> clai.v1.ShellEventType
+	1,  // 30: clai.v1.ShellEvent.type:type_name -> clai.v1.ShellEventType
+	67, // 31: clai.v1.ListSessionsResponse.sessions:type_name -> clai.v1.SessionSummary
+	5,  // 32: clai.v1.ClaiService.SessionStart:input_type -> clai.v1.SessionStartRequest
+	6,  // 33: clai.v1.ClaiService.SessionEnd:input_type -> clai.v1.SessionEndRequest
+	11, // 34: clai.v1.ClaiService.CommandStarted:input_type -> clai.v1.CommandStartRequest
+	12, // 35: clai.v1.ClaiService.CommandEnded:input_type -> clai.v1.CommandEndRequest
+	14, // 36: clai.v1.ClaiService.CommandEventsBatch:input_type -> clai.v1.CommandEventsBatchRequest
+	7,  // 37: clai.v1.ClaiService.SessionNote:input_type -> clai.v1.SessionNoteRequest
+	9,  // 38: clai.v1.ClaiService.SessionName:input_type -> clai.v1.SessionNameRequest
+	16, // 39: clai.v1.ClaiService.Suggest:input_type -> clai.v1.SuggestRequest
+	22, // 40: clai.v1.ClaiService.SuggestSlots:input_type -> clai.v1.SuggestSlotsRequest
+	25, // 41: clai.v1.ClaiService.SuggestInline:input_type -> clai.v1.SuggestInlineRequest
+	43, // 42: clai.v1.ClaiService.TextToCommand:input_type -> clai.v1.TextToCommandRequest
+	45, // 43: clai.v1.ClaiService.NextStep:input_type -> clai.v1.NextStepRequest
+	47, // 44: clai.v1.ClaiService.Diagnose:input_type -> clai.v1.DiagnoseRequest
+	29, // 45: clai.v1.ClaiService.RecordFeedback:input_type -> clai.v1.RecordFeedbackRequest
+	29, // 46: clai.v1.ClaiService.SuggestFeedback:input_type -> clai.v1.RecordFeedbackRequest
+	31, // 47: clai.v1.ClaiService.SnapshotFeedback:input_type -> clai.v1.SnapshotFeedbackRequest
+	33, // 48: clai.v1.ClaiService.ExplainSuggestions:input_type -> clai.v1.ExplainSuggestionsRequest
+	35, // 49: clai.v1.ClaiService.ListDismissals:input_type -> clai.v1.ListDismissalsRequest
+	38, // 50: clai.v1.ClaiService.ClearDismissals:input_type -> clai.v1.ClearDismissalsRequest
+	40, // 51: clai.v1.ClaiService.CompareScorers:input_type -> clai.v1.CompareScorersRequest
+	49, // 52: clai.v1.ClaiService.FetchHistory:input_type -> clai.v1.HistoryFetchRequest
+	52, // 53: clai.v1.ClaiService.ImportHistory:input_type -> clai.v1.HistoryImportRequest
+	55, // 54: clai.v1.ClaiService.ImportEvents:input_type -> clai.v1.ImportEventsRequest
+	57, // 55: clai.v1.ClaiService.DeleteCommand:input_type -> clai.v1.DeleteCommandRequest
+	57, // 56: clai.v1.ClaiService.PurgeCommand:input_type -> clai.v1.DeleteCommandRequest
+	59, // 57: clai.v1.ClaiService.GetCommandDetail:input_type -> clai.v1.GetCommandDetailRequest
+	3,  // 58: clai.v1.ClaiService.Ping:input_type -> clai.v1.Ack
+	3,  // 59: clai.v1.ClaiService.GetStatus:input_type -> clai.v1.Ack
+	3,  // 60: clai.v1.ClaiService.Health:input_type -> clai.v1.Ack
+	3,  // 61: clai.v1.ClaiService.ListSessions:input_type -> clai.v1.Ack
+	69, // 62: clai.v1.ClaiService.KillSession:input_type -> clai.v1.KillSessionRequest
+	3,  // 63: clai.v1.ClaiService.FlushCaches:input_type -> clai.v1.Ack
+	65, // 64: clai.v1.ClaiService.SubscribeEvents:input_type -> clai.v1.SubscribeEventsRequest
+	71, // 65: clai.v1.ClaiService.WorkflowRunStart:input_type -> clai.v1.WorkflowRunStartRequest
+	73, // 66: clai.v1.ClaiService.WorkflowRunEnd:input_type -> clai.v1.WorkflowRunEndRequest
+	75, // 67: clai.v1.ClaiService.WorkflowStepUpdate:input_type -> clai.v1.WorkflowStepUpdateRequest
+	77, // 68: clai.v1.ClaiService.AnalyzeStepOutput:input_type -> clai.v1.AnalyzeStepOutputRequest
+	3,  // 69: clai.v1.ClaiService.SessionStart:output_type -> clai.v1.Ack
+	3,  // 70: clai.v1.ClaiService.SessionEnd:output_type -> clai.v1.Ack
+	3,  // 71: clai.v1.ClaiService.CommandStarted:output_type -> clai.v1.Ack
+	3,  // 72: clai.v1.ClaiService.CommandEnded:output_type -> clai.v1.Ack
+	15, // 73: clai.v1.ClaiService.CommandEventsBatch:output_type -> clai.v1.CommandEventsBatchResponse
+	8,  // 74: clai.v1.ClaiService.SessionNote:output_type -> clai.v1.SessionNoteResponse
+	10, // 75: clai.v1.ClaiService.SessionName:output_type -> clai.v1.SessionNameResponse
+	28, // 76: clai.v1.ClaiService.Suggest:output_type -> clai.v1.SuggestResponse
+	24, // 77: clai.v1.ClaiService.SuggestSlots:output_type -> clai.v1.SuggestSlotsResponse
+	26, // 78: clai.v1.ClaiService.SuggestInline:output_type -> clai.v1.SuggestInlineResponse
+	44, // 79: clai.v1.ClaiService.TextToCommand:output_type -> clai.v1.TextToCommandResponse
+	46, // 80: clai.v1.ClaiService.NextStep:output_type -> clai.v1.NextStepResponse
+	48, // 81: clai.v1.ClaiService.Diagnose:output_type -> clai.v1.DiagnoseResponse
+	30, // 82: clai.v1.ClaiService.RecordFeedback:output_type -> clai.v1.RecordFeedbackResponse
+	30, // 83: clai.v1.ClaiService.SuggestFeedback:output_type -> clai.v1.RecordFeedbackResponse
+	32, // 84: clai.v1.ClaiService.SnapshotFeedback:output_type -> clai.v1.SnapshotFeedbackResponse
+	34, // 85: clai.v1.ClaiService.ExplainSuggestions:output_type -> clai.v1.ExplainSuggestionsResponse
+	37, // 86: clai.v1.ClaiService.ListDismissals:output_type -> clai.v1.ListDismissalsResponse
+	39, // 87: clai.v1.ClaiService.ClearDismissals:output_type -> clai.v1.ClearDismissalsResponse
+	42, // 88: clai.v1.ClaiService.CompareScorers:output_type -> clai.v1.CompareScorersResponse
+	50, // 89: clai.v1.ClaiService.FetchHistory:output_type -> clai.v1.HistoryFetchResponse
+	53, // 90: clai.v1.ClaiService.ImportHistory:output_type -> clai.v1.HistoryImportResponse
+	56, // 91: clai.v1.ClaiService.ImportEvents:output_type -> clai.v1.ImportEventsResponse
+	58, // 92: clai.v1.ClaiService.DeleteCommand:output_type -> clai.v1.DeleteCommandResponse
+	58, // 93: clai.v1.ClaiService.PurgeCommand:output_type -> clai.v1.DeleteCommandResponse
+	60, // 94: clai.v1.ClaiService.GetCommandDetail:output_type -> clai.v1.GetCommandDetailResponse
+	3,  // 95: clai.v1.ClaiService.Ping:output_type -> clai.v1.Ack
+	61, // 96: clai.v1.ClaiService.GetStatus:output_type -> clai.v1.StatusResponse
+	63, // 97: clai.v1.ClaiService.Health:output_type -> clai.v1.HealthResponse
+	68, // 98: clai.v1.ClaiService.ListSessions:output_type -> clai.v1.ListSessionsResponse
+	3,  // 99: clai.v1.ClaiService.KillSession:output_type -> clai.v1.Ack
+	70, // 100: clai.v1.ClaiService.FlushCaches:output_type -> clai.v1.FlushCachesResponse
+	66, // 101: clai.v1.ClaiService.SubscribeEvents:output_type -> clai.v1.ShellEvent
+	72, // 102: clai.v1.ClaiService.WorkflowRunStart:output_type -> clai.v1.WorkflowRunStartResponse
+	74, // 103: clai.v1.ClaiService.WorkflowRunEnd:output_type -> clai.v1.WorkflowRunEndResponse
+	76, // 104: clai.v1.ClaiService.WorkflowStepUpdate:output_type -> clai.v1.WorkflowStepUpdateResponse
+	78, // 105: clai.v1.ClaiService.AnalyzeStepOutput:output_type -> clai.v1.AnalyzeStepOutputResponse
+	69, // [69:106] is the sub-list for method output_type
+	32, // [32:69] is the sub-list for method input_type
+	32, // [32:32] is the sub-list for extension type_name
+	32, // [32:32] is the sub-list for extension extendee
+	0,  // [0:32] is the sub-list for field type_name
 }
 
 func init() { file_clai_v1_clai_proto_init() }
@@ -2897,13 +6564,19 @@ func file_clai_v1_clai_proto_init() {
 	if File_clai_v1_clai_proto != nil {
 		return
 	}
+	file_clai_v1_clai_proto_msgTypes[11].OneofWrappers = []any{
+		(*CommandLifecycleEvent_Start)(nil),
+		(*CommandLifecycleEvent_End)(nil),
+	}
+	file_clai_v1_clai_proto_msgTypes[49].OneofWrappers = []any{}
+	file_clai_v1_clai_proto_msgTypes[52].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_clai_v1_clai_proto_rawDesc), len(file_clai_v1_clai_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   34,
+			NumEnums:      2,
+			NumMessages:   78,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -24,16 +24,35 @@ const (
 	ClaiService_SessionEnd_FullMethodName         = "/clai.v1.ClaiService/SessionEnd"
 	ClaiService_CommandStarted_FullMethodName     = "/clai.v1.ClaiService/CommandStarted"
 	ClaiService_CommandEnded_FullMethodName       = "/clai.v1.ClaiService/CommandEnded"
+	ClaiService_CommandEventsBatch_FullMethodName = "/clai.v1.ClaiService/CommandEventsBatch"
+	ClaiService_SessionNote_FullMethodName        = "/clai.v1.ClaiService/SessionNote"
+	ClaiService_SessionName_FullMethodName        = "/clai.v1.ClaiService/SessionName"
 	ClaiService_Suggest_FullMethodName            = "/clai.v1.ClaiService/Suggest"
+	ClaiService_SuggestSlots_FullMethodName       = "/clai.v1.ClaiService/SuggestSlots"
+	ClaiService_SuggestInline_FullMethodName      = "/clai.v1.ClaiService/SuggestInline"
 	ClaiService_TextToCommand_FullMethodName      = "/clai.v1.ClaiService/TextToCommand"
 	ClaiService_NextStep_FullMethodName           = "/clai.v1.ClaiService/NextStep"
 	ClaiService_Diagnose_FullMethodName           = "/clai.v1.ClaiService/Diagnose"
 	ClaiService_RecordFeedback_FullMethodName     = "/clai.v1.ClaiService/RecordFeedback"
 	ClaiService_SuggestFeedback_FullMethodName    = "/clai.v1.ClaiService/SuggestFeedback"
+	ClaiService_SnapshotFeedback_FullMethodName   = "/clai.v1.ClaiService/SnapshotFeedback"
+	ClaiService_ExplainSuggestions_FullMethodName = "/clai.v1.ClaiService/ExplainSuggestions"
+	ClaiService_ListDismissals_FullMethodName     = "/clai.v1.ClaiService/ListDismissals"
+	ClaiService_ClearDismissals_FullMethodName    = "/clai.v1.ClaiService/ClearDismissals"
+	ClaiService_CompareScorers_FullMethodName     = "/clai.v1.ClaiService/CompareScorers"
 	ClaiService_FetchHistory_FullMethodName       = "/clai.v1.ClaiService/FetchHistory"
 	ClaiService_ImportHistory_FullMethodName      = "/clai.v1.ClaiService/ImportHistory"
+	ClaiService_ImportEvents_FullMethodName       = "/clai.v1.ClaiService/ImportEvents"
+	ClaiService_DeleteCommand_FullMethodName      = "/clai.v1.ClaiService/DeleteCommand"
+	ClaiService_PurgeCommand_FullMethodName       = "/clai.v1.ClaiService/PurgeCommand"
+	ClaiService_GetCommandDetail_FullMethodName   = "/clai.v1.ClaiService/GetCommandDetail"
 	ClaiService_Ping_FullMethodName               = "/clai.v1.ClaiService/Ping"
 	ClaiService_GetStatus_FullMethodName          = "/clai.v1.ClaiService/GetStatus"
+	ClaiService_Health_FullMethodName             = "/clai.v1.ClaiService/Health"
+	ClaiService_ListSessions_FullMethodName       = "/clai.v1.ClaiService/ListSessions"
+	ClaiService_KillSession_FullMethodName        = "/clai.v1.ClaiService/KillSession"
+	ClaiService_FlushCaches_FullMethodName        = "/clai.v1.ClaiService/FlushCaches"
+	ClaiService_SubscribeEvents_FullMethodName    = "/clai.v1.ClaiService/SubscribeEvents"
 	ClaiService_WorkflowRunStart_FullMethodName   = "/clai.v1.ClaiService/WorkflowRunStart"
 	ClaiService_WorkflowRunEnd_FullMethodName     = "/clai.v1.ClaiService/WorkflowRunEnd"
 	ClaiService_WorkflowStepUpdate_FullMethodName = "/clai.v1.ClaiService/WorkflowStepUpdate"
@@ -49,20 +68,42 @@ type ClaiServiceClient interface {
 	SessionEnd(ctx context.Context, in *SessionEndRequest, opts ...grpc.CallOption) (*Ack, error)
 	CommandStarted(ctx context.Context, in *CommandStartRequest, opts ...grpc.CallOption) (*Ack, error)
 	CommandEnded(ctx context.Context, in *CommandEndRequest, opts ...grpc.CallOption) (*Ack, error)
+	CommandEventsBatch(ctx context.Context, in *CommandEventsBatchRequest, opts ...grpc.CallOption) (*CommandEventsBatchResponse, error)
+	// Session context
+	SessionNote(ctx context.Context, in *SessionNoteRequest, opts ...grpc.CallOption) (*SessionNoteResponse, error)
+	SessionName(ctx context.Context, in *SessionNameRequest, opts ...grpc.CallOption) (*SessionNameResponse, error)
 	// Interactive (Client waits with timeout)
 	Suggest(ctx context.Context, in *SuggestRequest, opts ...grpc.CallOption) (*SuggestResponse, error)
+	SuggestSlots(ctx context.Context, in *SuggestSlotsRequest, opts ...grpc.CallOption) (*SuggestSlotsResponse, error)
+	SuggestInline(ctx context.Context, in *SuggestInlineRequest, opts ...grpc.CallOption) (*SuggestInlineResponse, error)
 	TextToCommand(ctx context.Context, in *TextToCommandRequest, opts ...grpc.CallOption) (*TextToCommandResponse, error)
 	NextStep(ctx context.Context, in *NextStepRequest, opts ...grpc.CallOption) (*NextStepResponse, error)
 	Diagnose(ctx context.Context, in *DiagnoseRequest, opts ...grpc.CallOption) (*DiagnoseResponse, error)
 	// Feedback (V2)
 	RecordFeedback(ctx context.Context, in *RecordFeedbackRequest, opts ...grpc.CallOption) (*RecordFeedbackResponse, error)
 	SuggestFeedback(ctx context.Context, in *RecordFeedbackRequest, opts ...grpc.CallOption) (*RecordFeedbackResponse, error)
+	SnapshotFeedback(ctx context.Context, in *SnapshotFeedbackRequest, opts ...grpc.CallOption) (*SnapshotFeedbackResponse, error)
+	ExplainSuggestions(ctx context.Context, in *ExplainSuggestionsRequest, opts ...grpc.CallOption) (*ExplainSuggestionsResponse, error)
+	ListDismissals(ctx context.Context, in *ListDismissalsRequest, opts ...grpc.CallOption) (*ListDismissalsResponse, error)
+	ClearDismissals(ctx context.Context, in *ClearDismissalsRequest, opts ...grpc.CallOption) (*ClearDismissalsResponse, error)
+	CompareScorers(ctx context.Context, in *CompareScorersRequest, opts ...grpc.CallOption) (*CompareScorersResponse, error)
 	// History
 	FetchHistory(ctx context.Context, in *HistoryFetchRequest, opts ...grpc.CallOption) (*HistoryFetchResponse, error)
 	ImportHistory(ctx context.Context, in *HistoryImportRequest, opts ...grpc.CallOption) (*HistoryImportResponse, error)
+	ImportEvents(ctx context.Context, in *ImportEventsRequest, opts ...grpc.CallOption) (*ImportEventsResponse, error)
+	DeleteCommand(ctx context.Context, in *DeleteCommandRequest, opts ...grpc.CallOption) (*DeleteCommandResponse, error)
+	PurgeCommand(ctx context.Context, in *DeleteCommandRequest, opts ...grpc.CallOption) (*DeleteCommandResponse, error)
+	GetCommandDetail(ctx context.Context, in *GetCommandDetailRequest, opts ...grpc.CallOption) (*GetCommandDetailResponse, error)
 	// Ops
 	Ping(ctx context.Context, in *Ack, opts ...grpc.CallOption) (*Ack, error)
 	GetStatus(ctx context.Context, in *Ack, opts ...grpc.CallOption) (*StatusResponse, error)
+	Health(ctx context.Context, in *Ack, opts ...grpc.CallOption) (*HealthResponse, error)
+	// Admin
+	ListSessions(ctx context.Context, in *Ack, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	KillSession(ctx context.Context, in *KillSessionRequest, opts ...grpc.CallOption) (*Ack, error)
+	FlushCaches(ctx context.Context, in *Ack, opts ...grpc.CallOption) (*FlushCachesResponse, error)
+	// Events (server streaming; runs until the client cancels or claid stops)
+	SubscribeEvents(ctx context.Context, in *SubscribeEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ShellEvent], error)
 	// Workflow RPCs — Tier 0 (§13.1)
 	WorkflowRunStart(ctx context.Context, in *WorkflowRunStartRequest, opts ...grpc.CallOption) (*WorkflowRunStartResponse, error)
 	WorkflowRunEnd(ctx context.Context, in *WorkflowRunEndRequest, opts ...grpc.CallOption) (*WorkflowRunEndResponse, error)
@@ -118,6 +159,36 @@ func (c *claiServiceClient) CommandEnded(ctx context.Context, in *CommandEndRequ
 	return out, nil
 }
 
+func (c *claiServiceClient) CommandEventsBatch(ctx context.Context, in *CommandEventsBatchRequest, opts ...grpc.CallOption) (*CommandEventsBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandEventsBatchResponse)
+	err := c.cc.Invoke(ctx, ClaiService_CommandEventsBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) SessionNote(ctx context.Context, in *SessionNoteRequest, opts ...grpc.CallOption) (*SessionNoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionNoteResponse)
+	err := c.cc.Invoke(ctx, ClaiService_SessionNote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) SessionName(ctx context.Context, in *SessionNameRequest, opts ...grpc.CallOption) (*SessionNameResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionNameResponse)
+	err := c.cc.Invoke(ctx, ClaiService_SessionName_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *claiServiceClient) Suggest(ctx context.Context, in *SuggestRequest, opts ...grpc.CallOption) (*SuggestResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SuggestResponse)
@@ -128,6 +199,26 @@ func (c *claiServiceClient) Suggest(ctx context.Context, in *SuggestRequest, opt
 	return out, nil
 }
 
+func (c *claiServiceClient) SuggestSlots(ctx context.Context, in *SuggestSlotsRequest, opts ...grpc.CallOption) (*SuggestSlotsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SuggestSlotsResponse)
+	err := c.cc.Invoke(ctx, ClaiService_SuggestSlots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) SuggestInline(ctx context.Context, in *SuggestInlineRequest, opts ...grpc.CallOption) (*SuggestInlineResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SuggestInlineResponse)
+	err := c.cc.Invoke(ctx, ClaiService_SuggestInline_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *claiServiceClient) TextToCommand(ctx context.Context, in *TextToCommandRequest, opts ...grpc.CallOption) (*TextToCommandResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(TextToCommandResponse)
@@ -178,6 +269,56 @@ func (c *claiServiceClient) SuggestFeedback(ctx context.Context, in *RecordFeedb
 	return out, nil
 }
 
+func (c *claiServiceClient) SnapshotFeedback(ctx context.Context, in *SnapshotFeedbackRequest, opts ...grpc.CallOption) (*SnapshotFeedbackResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SnapshotFeedbackResponse)
+	err := c.cc.Invoke(ctx, ClaiService_SnapshotFeedback_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) ExplainSuggestions(ctx context.Context, in *ExplainSuggestionsRequest, opts ...grpc.CallOption) (*ExplainSuggestionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExplainSuggestionsResponse)
+	err := c.cc.Invoke(ctx, ClaiService_ExplainSuggestions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) ListDismissals(ctx context.Context, in *ListDismissalsRequest, opts ...grpc.CallOption) (*ListDismissalsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDismissalsResponse)
+	err := c.cc.Invoke(ctx, ClaiService_ListDismissals_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) ClearDismissals(ctx context.Context, in *ClearDismissalsRequest, opts ...grpc.CallOption) (*ClearDismissalsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClearDismissalsResponse)
+	err := c.cc.Invoke(ctx, ClaiService_ClearDismissals_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) CompareScorers(ctx context.Context, in *CompareScorersRequest, opts ...grpc.CallOption) (*CompareScorersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompareScorersResponse)
+	err := c.cc.Invoke(ctx, ClaiService_CompareScorers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *claiServiceClient) FetchHistory(ctx context.Context, in *HistoryFetchRequest, opts ...grpc.CallOption) (*HistoryFetchResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(HistoryFetchResponse)
@@ -198,6 +339,46 @@ func (c *claiServiceClient) ImportHistory(ctx context.Context, in *HistoryImport
 	return out, nil
 }
 
+func (c *claiServiceClient) ImportEvents(ctx context.Context, in *ImportEventsRequest, opts ...grpc.CallOption) (*ImportEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportEventsResponse)
+	err := c.cc.Invoke(ctx, ClaiService_ImportEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) DeleteCommand(ctx context.Context, in *DeleteCommandRequest, opts ...grpc.CallOption) (*DeleteCommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCommandResponse)
+	err := c.cc.Invoke(ctx, ClaiService_DeleteCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) PurgeCommand(ctx context.Context, in *DeleteCommandRequest, opts ...grpc.CallOption) (*DeleteCommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCommandResponse)
+	err := c.cc.Invoke(ctx, ClaiService_PurgeCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) GetCommandDetail(ctx context.Context, in *GetCommandDetailRequest, opts ...grpc.CallOption) (*GetCommandDetailResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCommandDetailResponse)
+	err := c.cc.Invoke(ctx, ClaiService_GetCommandDetail_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *claiServiceClient) Ping(ctx context.Context, in *Ack, opts ...grpc.CallOption) (*Ack, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Ack)
@@ -218,6 +399,65 @@ func (c *claiServiceClient) GetStatus(ctx context.Context, in *Ack, opts ...grpc
 	return out, nil
 }
 
+func (c *claiServiceClient) Health(ctx context.Context, in *Ack, opts ...grpc.CallOption) (*HealthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, ClaiService_Health_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) ListSessions(ctx context.Context, in *Ack, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, ClaiService_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) KillSession(ctx context.Context, in *KillSessionRequest, opts ...grpc.CallOption) (*Ack, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, ClaiService_KillSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) FlushCaches(ctx context.Context, in *Ack, opts ...grpc.CallOption) (*FlushCachesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FlushCachesResponse)
+	err := c.cc.Invoke(ctx, ClaiService_FlushCaches_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *claiServiceClient) SubscribeEvents(ctx context.Context, in *SubscribeEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ShellEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ClaiService_ServiceDesc.Streams[0], ClaiService_SubscribeEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeEventsRequest, ShellEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ClaiService_SubscribeEventsClient = grpc.ServerStreamingClient[ShellEvent]
+
 func (c *claiServiceClient) WorkflowRunStart(ctx context.Context, in *WorkflowRunStartRequest, opts ...grpc.CallOption) (*WorkflowRunStartResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(WorkflowRunStartResponse)
@@ -267,20 +507,42 @@ type ClaiServiceServer interface {
 	SessionEnd(context.Context, *SessionEndRequest) (*Ack, error)
 	CommandStarted(context.Context, *CommandStartRequest) (*Ack, error)
 	CommandEnded(context.Context, *CommandEndRequest) (*Ack, error)
+	CommandEventsBatch(context.Context, *CommandEventsBatchRequest) (*CommandEventsBatchResponse, error)
+	// Session context
+	SessionNote(context.Context, *SessionNoteRequest) (*SessionNoteResponse, error)
+	SessionName(context.Context, *SessionNameRequest) (*SessionNameResponse, error)
 	// Interactive (Client waits with timeout)
 	Suggest(context.Context, *SuggestRequest) (*SuggestResponse, error)
+	SuggestSlots(context.Context, *SuggestSlotsRequest) (*SuggestSlotsResponse, error)
+	SuggestInline(context.Context, *SuggestInlineRequest) (*SuggestInlineResponse, error)
 	TextToCommand(context.Context, *TextToCommandRequest) (*TextToCommandResponse, error)
 	NextStep(context.Context, *NextStepRequest) (*NextStepResponse, error)
 	Diagnose(context.Context, *DiagnoseRequest) (*DiagnoseResponse, error)
 	// Feedback (V2)
 	RecordFeedback(context.Context, *RecordFeedbackRequest) (*RecordFeedbackResponse, error)
 	SuggestFeedback(context.Context, *RecordFeedbackRequest) (*RecordFeedbackResponse, error)
+	SnapshotFeedback(context.Context, *SnapshotFeedbackRequest) (*SnapshotFeedbackResponse, error)
+	ExplainSuggestions(context.Context, *ExplainSuggestionsRequest) (*ExplainSuggestionsResponse, error)
+	ListDismissals(context.Context, *ListDismissalsRequest) (*ListDismissalsResponse, error)
+	ClearDismissals(context.Context, *ClearDismissalsRequest) (*ClearDismissalsResponse, error)
+	CompareScorers(context.Context, *CompareScorersRequest) (*CompareScorersResponse, error)
 	// History
 	FetchHistory(context.Context, *HistoryFetchRequest) (*HistoryFetchResponse, error)
 	ImportHistory(context.Context, *HistoryImportRequest) (*HistoryImportResponse, error)
+	ImportEvents(context.Context, *ImportEventsRequest) (*ImportEventsResponse, error)
+	DeleteCommand(context.Context, *DeleteCommandRequest) (*DeleteCommandResponse, error)
+	PurgeCommand(context.Context, *DeleteCommandRequest) (*DeleteCommandResponse, error)
+	GetCommandDetail(context.Context, *GetCommandDetailRequest) (*GetCommandDetailResponse, error)
 	// Ops
 	Ping(context.Context, *Ack) (*Ack, error)
 	GetStatus(context.Context, *Ack) (*StatusResponse, error)
+	Health(context.Context, *Ack) (*HealthResponse, error)
+	// Admin
+	ListSessions(context.Context, *Ack) (*ListSessionsResponse, error)
+	KillSession(context.Context, *KillSessionRequest) (*Ack, error)
+	FlushCaches(context.Context, *Ack) (*FlushCachesResponse, error)
+	// Events (server streaming; runs until the client cancels or claid stops)
+	SubscribeEvents(*SubscribeEventsRequest, grpc.ServerStreamingServer[ShellEvent]) error
 	// Workflow RPCs — Tier 0 (§13.1)
 	WorkflowRunStart(context.Context, *WorkflowRunStartRequest) (*WorkflowRunStartResponse, error)
 	WorkflowRunEnd(context.Context, *WorkflowRunEndRequest) (*WorkflowRunEndResponse, error)
@@ -308,9 +570,24 @@ func (UnimplementedClaiServiceServer) CommandStarted(context.Context, *CommandSt
 func (UnimplementedClaiServiceServer) CommandEnded(context.Context, *CommandEndRequest) (*Ack, error) {
 	return nil, status.Error(codes.Unimplemented, "method CommandEnded not implemented")
 }
+func (UnimplementedClaiServiceServer) CommandEventsBatch(context.Context, *CommandEventsBatchRequest) (*CommandEventsBatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CommandEventsBatch not implemented")
+}
+func (UnimplementedClaiServiceServer) SessionNote(context.Context, *SessionNoteRequest) (*SessionNoteResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SessionNote not implemented")
+}
+func (UnimplementedClaiServiceServer) SessionName(context.Context, *SessionNameRequest) (*SessionNameResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SessionName not implemented")
+}
 func (UnimplementedClaiServiceServer) Suggest(context.Context, *SuggestRequest) (*SuggestResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Suggest not implemented")
 }
+func (UnimplementedClaiServiceServer) SuggestSlots(context.Context, *SuggestSlotsRequest) (*SuggestSlotsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SuggestSlots not implemented")
+}
+func (UnimplementedClaiServiceServer) SuggestInline(context.Context, *SuggestInlineRequest) (*SuggestInlineResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SuggestInline not implemented")
+}
 func (UnimplementedClaiServiceServer) TextToCommand(context.Context, *TextToCommandRequest) (*TextToCommandResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method TextToCommand not implemented")
 }
@@ -326,18 +603,60 @@ func (UnimplementedClaiServiceServer) RecordFeedback(context.Context, *RecordFee
 func (UnimplementedClaiServiceServer) SuggestFeedback(context.Context, *RecordFeedbackRequest) (*RecordFeedbackResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method SuggestFeedback not implemented")
 }
+func (UnimplementedClaiServiceServer) SnapshotFeedback(context.Context, *SnapshotFeedbackRequest) (*SnapshotFeedbackResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SnapshotFeedback not implemented")
+}
+func (UnimplementedClaiServiceServer) ExplainSuggestions(context.Context, *ExplainSuggestionsRequest) (*ExplainSuggestionsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExplainSuggestions not implemented")
+}
+func (UnimplementedClaiServiceServer) ListDismissals(context.Context, *ListDismissalsRequest) (*ListDismissalsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListDismissals not implemented")
+}
+func (UnimplementedClaiServiceServer) ClearDismissals(context.Context, *ClearDismissalsRequest) (*ClearDismissalsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ClearDismissals not implemented")
+}
+func (UnimplementedClaiServiceServer) CompareScorers(context.Context, *CompareScorersRequest) (*CompareScorersResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CompareScorers not implemented")
+}
 func (UnimplementedClaiServiceServer) FetchHistory(context.Context, *HistoryFetchRequest) (*HistoryFetchResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method FetchHistory not implemented")
 }
 func (UnimplementedClaiServiceServer) ImportHistory(context.Context, *HistoryImportRequest) (*HistoryImportResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ImportHistory not implemented")
 }
+func (UnimplementedClaiServiceServer) ImportEvents(context.Context, *ImportEventsRequest) (*ImportEventsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ImportEvents not implemented")
+}
+func (UnimplementedClaiServiceServer) DeleteCommand(context.Context, *DeleteCommandRequest) (*DeleteCommandResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteCommand not implemented")
+}
+func (UnimplementedClaiServiceServer) PurgeCommand(context.Context, *DeleteCommandRequest) (*DeleteCommandResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PurgeCommand not implemented")
+}
+func (UnimplementedClaiServiceServer) GetCommandDetail(context.Context, *GetCommandDetailRequest) (*GetCommandDetailResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCommandDetail not implemented")
+}
 func (UnimplementedClaiServiceServer) Ping(context.Context, *Ack) (*Ack, error) {
 	return nil, status.Error(codes.Unimplemented, "method Ping not implemented")
 }
 func (UnimplementedClaiServiceServer) GetStatus(context.Context, *Ack) (*StatusResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetStatus not implemented")
 }
+func (UnimplementedClaiServiceServer) Health(context.Context, *Ack) (*HealthResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedClaiServiceServer) ListSessions(context.Context, *Ack) (*ListSessionsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedClaiServiceServer) KillSession(context.Context, *KillSessionRequest) (*Ack, error) {
+	return nil, status.Error(codes.Unimplemented, "method KillSession not implemented")
+}
+func (UnimplementedClaiServiceServer) FlushCaches(context.Context, *Ack) (*FlushCachesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method FlushCaches not implemented")
+}
+func (UnimplementedClaiServiceServer) SubscribeEvents(*SubscribeEventsRequest, grpc.ServerStreamingServer[ShellEvent]) error {
+	return status.Error(codes.Unimplemented, "method SubscribeEvents not implemented")
+}
 func (UnimplementedClaiServiceServer) WorkflowRunStart(context.Context, *WorkflowRunStartRequest) (*WorkflowRunStartResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method WorkflowRunStart not implemented")
 }
@@ -443,6 +762,60 @@ func _ClaiService_CommandEnded_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ClaiService_CommandEventsBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommandEventsBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).CommandEventsBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_CommandEventsBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).CommandEventsBatch(ctx, req.(*CommandEventsBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_SessionNote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionNoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).SessionNote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_SessionNote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).SessionNote(ctx, req.(*SessionNoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_SessionName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionNameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).SessionName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_SessionName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).SessionName(ctx, req.(*SessionNameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ClaiService_Suggest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SuggestRequest)
 	if err := dec(in); err != nil {
@@ -461,6 +834,42 @@ func _ClaiService_Suggest_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ClaiService_SuggestSlots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuggestSlotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).SuggestSlots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_SuggestSlots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).SuggestSlots(ctx, req.(*SuggestSlotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_SuggestInline_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuggestInlineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).SuggestInline(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_SuggestInline_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).SuggestInline(ctx, req.(*SuggestInlineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ClaiService_TextToCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(TextToCommandRequest)
 	if err := dec(in); err != nil {
@@ -551,6 +960,96 @@ func _ClaiService_SuggestFeedback_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ClaiService_SnapshotFeedback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotFeedbackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).SnapshotFeedback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_SnapshotFeedback_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).SnapshotFeedback(ctx, req.(*SnapshotFeedbackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_ExplainSuggestions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExplainSuggestionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).ExplainSuggestions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_ExplainSuggestions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).ExplainSuggestions(ctx, req.(*ExplainSuggestionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_ListDismissals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDismissalsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).ListDismissals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_ListDismissals_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).ListDismissals(ctx, req.(*ListDismissalsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_ClearDismissals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearDismissalsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).ClearDismissals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_ClearDismissals_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).ClearDismissals(ctx, req.(*ClearDismissalsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_CompareScorers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareScorersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).CompareScorers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_CompareScorers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).CompareScorers(ctx, req.(*CompareScorersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ClaiService_FetchHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(HistoryFetchRequest)
 	if err := dec(in); err != nil {
@@ -587,6 +1086,78 @@ func _ClaiService_ImportHistory_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ClaiService_ImportEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).ImportEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_ImportEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).ImportEvents(ctx, req.(*ImportEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_DeleteCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).DeleteCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_DeleteCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).DeleteCommand(ctx, req.(*DeleteCommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_PurgeCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).PurgeCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_PurgeCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).PurgeCommand(ctx, req.(*DeleteCommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_GetCommandDetail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCommandDetailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).GetCommandDetail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_GetCommandDetail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).GetCommandDetail(ctx, req.(*GetCommandDetailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ClaiService_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Ack)
 	if err := dec(in); err != nil {
@@ -623,6 +1194,89 @@ func _ClaiService_GetStatus_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ClaiService_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Ack)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).Health(ctx, req.(*Ack))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Ack)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).ListSessions(ctx, req.(*Ack))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_KillSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KillSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).KillSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_KillSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).KillSession(ctx, req.(*KillSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_FlushCaches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Ack)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClaiServiceServer).FlushCaches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClaiService_FlushCaches_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClaiServiceServer).FlushCaches(ctx, req.(*Ack))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaiService_SubscribeEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ClaiServiceServer).SubscribeEvents(m, &grpc.GenericServerStream[SubscribeEventsRequest, ShellEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ClaiService_SubscribeEventsServer = grpc.ServerStreamingServer[ShellEvent]
+
 func _ClaiService_WorkflowRunStart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(WorkflowRunStartRequest)
 	if err := dec(in); err != nil {
@@ -718,10 +1372,30 @@ var ClaiService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CommandEnded",
 			Handler:    _ClaiService_CommandEnded_Handler,
 		},
+		{
+			MethodName: "CommandEventsBatch",
+			Handler:    _ClaiService_CommandEventsBatch_Handler,
+		},
+		{
+			MethodName: "SessionNote",
+			Handler:    _ClaiService_SessionNote_Handler,
+		},
+		{
+			MethodName: "SessionName",
+			Handler:    _ClaiService_SessionName_Handler,
+		},
 		{
 			MethodName: "Suggest",
 			Handler:    _ClaiService_Suggest_Handler,
 		},
+		{
+			MethodName: "SuggestSlots",
+			Handler:    _ClaiService_SuggestSlots_Handler,
+		},
+		{
+			MethodName: "SuggestInline",
+			Handler:    _ClaiService_SuggestInline_Handler,
+		},
 		{
 			MethodName: "TextToCommand",
 			Handler:    _ClaiService_TextToCommand_Handler,
@@ -742,6 +1416,26 @@ var ClaiService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SuggestFeedback",
 			Handler:    _ClaiService_SuggestFeedback_Handler,
 		},
+		{
+			MethodName: "SnapshotFeedback",
+			Handler:    _ClaiService_SnapshotFeedback_Handler,
+		},
+		{
+			MethodName: "ExplainSuggestions",
+			Handler:    _ClaiService_ExplainSuggestions_Handler,
+		},
+		{
+			MethodName: "ListDismissals",
+			Handler:    _ClaiService_ListDismissals_Handler,
+		},
+		{
+			MethodName: "ClearDismissals",
+			Handler:    _ClaiService_ClearDismissals_Handler,
+		},
+		{
+			MethodName: "CompareScorers",
+			Handler:    _ClaiService_CompareScorers_Handler,
+		},
 		{
 			MethodName: "FetchHistory",
 			Handler:    _ClaiService_FetchHistory_Handler,
@@ -750,6 +1444,22 @@ var ClaiService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ImportHistory",
 			Handler:    _ClaiService_ImportHistory_Handler,
 		},
+		{
+			MethodName: "ImportEvents",
+			Handler:    _ClaiService_ImportEvents_Handler,
+		},
+		{
+			MethodName: "DeleteCommand",
+			Handler:    _ClaiService_DeleteCommand_Handler,
+		},
+		{
+			MethodName: "PurgeCommand",
+			Handler:    _ClaiService_PurgeCommand_Handler,
+		},
+		{
+			MethodName: "GetCommandDetail",
+			Handler:    _ClaiService_GetCommandDetail_Handler,
+		},
 		{
 			MethodName: "Ping",
 			Handler:    _ClaiService_Ping_Handler,
@@ -758,6 +1468,22 @@ var ClaiService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetStatus",
 			Handler:    _ClaiService_GetStatus_Handler,
 		},
+		{
+			MethodName: "Health",
+			Handler:    _ClaiService_Health_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _ClaiService_ListSessions_Handler,
+		},
+		{
+			MethodName: "KillSession",
+			Handler:    _ClaiService_KillSession_Handler,
+		},
+		{
+			MethodName: "FlushCaches",
+			Handler:    _ClaiService_FlushCaches_Handler,
+		},
 		{
 			MethodName: "WorkflowRunStart",
 			Handler:    _ClaiService_WorkflowRunStart_Handler,
@@ -775,6 +1501,12 @@ var ClaiService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _ClaiService_AnalyzeStepOutput_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeEvents",
+			Handler:       _ClaiService_SubscribeEvents_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "clai/v1/clai.proto",
 }
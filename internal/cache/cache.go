@@ -5,15 +5,22 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/runger/clai/internal/config"
 )
 
-// Dir returns the cache directory path
+// Dir returns the cache directory path: CLAI_CACHE, else the cache root of
+// config.DefaultPaths when one is set, else ~/.cache/clai.
+// Under a CLAI_PROFILE the default is ~/.cache/clai/profiles/<name>.
 func Dir() string {
 	if dir := os.Getenv("CLAI_CACHE"); dir != "" {
 		return dir
 	}
+	if root := config.DefaultPaths().CacheRoot; root != "" {
+		return root
+	}
 	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".cache", "clai")
+	return config.ProfileDir(filepath.Join(home, ".cache", "clai"))
 }
 
 // EnsureDir creates the cache directory if it doesn't exist
@@ -30,6 +30,19 @@ func TestDir(t *testing.T) {
 			t.Errorf("Dir() = %q, want %q", got, want)
 		}
 	})
+
+	t.Run("uses profile subdirectory when CLAI_PROFILE set", func(t *testing.T) {
+		t.Setenv("CLAI_CACHE", "")
+		t.Setenv("CLAI_PROFILE", "work")
+
+		got := Dir()
+		home, _ := os.UserHomeDir()
+		want := filepath.Join(home, ".cache", "clai", "profiles", "work")
+
+		if got != want {
+			t.Errorf("Dir() = %q, want %q", got, want)
+		}
+	})
 }
 
 func TestEnsureDir(t *testing.T) {
@@ -0,0 +1,85 @@
+// Package changelog exposes the user-facing changelog embedded in the clai
+// binary, so `clai changelog` works offline and always matches the build.
+package changelog
+
+import (
+	_ "embed"
+	"regexp"
+	"strings"
+)
+
+//go:embed CHANGELOG.md
+var raw string
+
+// Unreleased is the version heading for changes not yet in a release.
+const Unreleased = "Unreleased"
+
+// Release is one version section of the changelog.
+type Release struct {
+	// Version is the heading without brackets, e.g. "0.5.0" or "Unreleased".
+	Version string
+
+	// Date is the release date, or "" if the heading has none.
+	Date string
+
+	// Notes is the markdown body of the section, without the heading.
+	Notes string
+}
+
+// headingRe matches "## [1.2.3] - 2026-01-01" and "## [Unreleased]".
+var headingRe = regexp.MustCompile(`^## \[([^\]]+)\](?:\s+-\s+(\S+))?\s*$`)
+
+// describeSuffixRe matches the "-<n>-g<sha>" and "-dirty" suffixes git
+// describe appends to builds made after a tag.
+var describeSuffixRe = regexp.MustCompile(`(-\d+-g[0-9a-f]+)?(-dirty)?$`)
+
+// Releases returns the embedded changelog sections, newest first.
+func Releases() []Release {
+	return Parse(raw)
+}
+
+// Parse splits a Keep a Changelog style document into its version sections.
+// Text before the first version heading is ignored.
+func Parse(doc string) []Release {
+	var releases []Release
+	var body []string
+	flush := func() {
+		if len(releases) > 0 {
+			releases[len(releases)-1].Notes = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+		body = body[:0]
+	}
+
+	for _, line := range strings.Split(doc, "\n") {
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			flush()
+			releases = append(releases, Release{Version: m[1], Date: m[2]})
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return releases
+}
+
+// Find returns the section for version. A leading "v" and any git describe
+// suffix are ignored, so "v0.5.0-3-gabc1234-dirty" finds "0.5.0".
+// Development builds ("dev" or "") find the Unreleased section.
+func Find(releases []Release, version string) (Release, bool) {
+	want := NormalizeVersion(version)
+	for _, r := range releases {
+		if strings.EqualFold(r.Version, want) {
+			return r, true
+		}
+	}
+	return Release{}, false
+}
+
+// NormalizeVersion maps a build version to the changelog heading it belongs to.
+func NormalizeVersion(version string) string {
+	if version == "" || version == "dev" {
+		return Unreleased
+	}
+	version = strings.TrimPrefix(version, "v")
+	return describeSuffixRe.ReplaceAllString(version, "")
+}
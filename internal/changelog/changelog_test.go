@@ -0,0 +1,88 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+)
+
+const sample = `# Changelog
+
+Intro text.
+
+## [Unreleased]
+
+- Next thing.
+
+## [0.5.0] - 2026-03-01
+
+### Added
+
+- Thing one.
+
+## [0.4.0] - 2026-01-15
+
+- Thing zero.
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	releases := Parse(sample)
+	if len(releases) != 3 {
+		t.Fatalf("len(releases) = %d, want 3", len(releases))
+	}
+	if releases[0].Version != Unreleased || releases[0].Date != "" || releases[0].Notes != "- Next thing." {
+		t.Errorf("releases[0] = %+v", releases[0])
+	}
+	if releases[1].Version != "0.5.0" || releases[1].Date != "2026-03-01" {
+		t.Errorf("releases[1] = %+v", releases[1])
+	}
+	if releases[1].Notes != "### Added\n\n- Thing one." {
+		t.Errorf("releases[1].Notes = %q", releases[1].Notes)
+	}
+	if releases[2].Notes != "- Thing zero." {
+		t.Errorf("releases[2].Notes = %q", releases[2].Notes)
+	}
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	releases := Parse(sample)
+	tests := []struct {
+		version string
+		want    string
+		found   bool
+	}{
+		{"0.5.0", "0.5.0", true},
+		{"v0.5.0", "0.5.0", true},
+		{"v0.4.0-3-gabc1234-dirty", "0.4.0", true},
+		{"v0.4.0-dirty", "0.4.0", true},
+		{"dev", Unreleased, true},
+		{"", Unreleased, true},
+		{"0.3.0", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Find(releases, tt.version)
+		if ok != tt.found || got.Version != tt.want {
+			t.Errorf("Find(%q) = %q, %v; want %q, %v", tt.version, got.Version, ok, tt.want, tt.found)
+		}
+	}
+}
+
+func TestReleases_Embedded(t *testing.T) {
+	t.Parallel()
+
+	releases := Releases()
+	if len(releases) == 0 {
+		t.Fatal("embedded changelog has no releases")
+	}
+	if _, ok := Find(releases, "dev"); !ok {
+		t.Error("embedded changelog has no Unreleased section")
+	}
+	for _, r := range releases {
+		if strings.TrimSpace(r.Notes) == "" {
+			t.Errorf("release %s has no notes", r.Version)
+		}
+	}
+}
@@ -16,6 +16,8 @@ import (
 	"time"
 
 	"golang.org/x/sys/execabs"
+
+	"github.com/runger/clai/internal/config"
 )
 
 const defaultIdleTimeout = 2 * time.Hour
@@ -39,7 +41,7 @@ func daemonDir() string {
 	cacheDir := os.Getenv("CLAI_CACHE")
 	if cacheDir == "" {
 		home, _ := os.UserHomeDir()
-		cacheDir = filepath.Join(home, ".cache", "clai")
+		cacheDir = config.ProfileDir(filepath.Join(home, ".cache", "clai"))
 	}
 	return cacheDir
 }
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/daemon"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/replay"
+	"github.com/runger/clai/internal/suggestions/suggest"
+	"github.com/runger/clai/internal/suggestions/usage"
+)
+
+// benchmarkTopK is how many suggestions 'clai benchmark' ranks each command
+// among; commands ranked lower count as misses.
+const benchmarkTopK = 10
+
+var (
+	benchmarkLimit  int
+	benchmarkWarmup int
+	benchmarkFormat string
+)
+
+var benchmarkCmd = &cobra.Command{
+	Use:     "benchmark",
+	Short:   "Measure how well suggestions predict your next command",
+	GroupID: groupSetup,
+	Long: `Replay your recent history through the suggestion ranker and measure how
+well it predicted each command you ran next.
+
+The last --limit commands of the suggestions database are replayed in the
+order they ran into a scratch database, so the ranker only knows what came
+before each command. After the first --warmup commands, which build up
+history, the ranker is asked for suggestions before every command:
+
+  hit@1  share of commands that were the top suggestion
+  hit@5  share of commands among the top five suggestions
+  MRR    mean reciprocal rank: 1 for a top suggestion, 1/2 for the second,
+         0 for commands not among the top ten
+
+Commands are ranked with the configured suggestions.weights. When they
+differ from the defaults, the defaults are measured as well, so you can see
+whether a weight change improved predictions. Signals that depend on live
+state, such as learned weight profiles, project tasks and dismissed
+suggestions, are not replayed. Your history is not changed.
+
+Examples:
+  clai benchmark
+  clai benchmark --limit 5000 --warmup 1000
+  clai benchmark --format json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runBenchmark,
+}
+
+func init() {
+	benchmarkCmd.Flags().IntVar(&benchmarkLimit, "limit", 2000, "Number of most recent commands to replay")
+	benchmarkCmd.Flags().IntVar(&benchmarkWarmup, "warmup", 200, "Number of replayed commands to learn from before predicting")
+	benchmarkCmd.Flags().StringVar(&benchmarkFormat, "format", "text", "Output format: text or json")
+	addJSONFlag(benchmarkCmd, &benchmarkFormat)
+}
+
+// benchmarkReport is the output of 'clai benchmark'.
+type benchmarkReport struct {
+	From     string         `json:"from"` // Day of the first replayed command
+	To       string         `json:"to"`   // Day of the last replayed command
+	Runs     []benchmarkRun `json:"runs"`
+	Commands int            `json:"commands"`
+	Sessions int            `json:"sessions"`
+	Warmup   int            `json:"warmup"`
+}
+
+// benchmarkRun is the result of replaying with one set of weights.
+type benchmarkRun struct {
+	Weights     string  `json:"weights"` // "configured" or "defaults"
+	Predictions int     `json:"predictions"`
+	HitAt1      float64 `json:"hit_at_1"`
+	HitAt5      float64 `json:"hit_at_5"`
+	MRR         float64 `json:"mrr"`
+}
+
+// benchmarkWeights is a named set of ranking weights to replay with.
+type benchmarkWeights struct {
+	name    string
+	weights suggest.Weights
+}
+
+func runBenchmark(cmd *cobra.Command, _ []string) error {
+	format := strings.ToLower(strings.TrimSpace(benchmarkFormat))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format: %s (use text or json)", benchmarkFormat)
+	}
+	if benchmarkLimit <= 0 {
+		return errors.New("invalid limit: must be > 0")
+	}
+	if benchmarkWarmup < 0 {
+		return errors.New("invalid warmup: must be >= 0")
+	}
+	if benchmarkWarmup >= benchmarkLimit {
+		return errors.New("invalid warmup: must be less than --limit")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	sessions, err := loadBenchmarkHistory(cmd.Context(), benchmarkLimit)
+	if err != nil {
+		return err
+	}
+	commands := countCommands(sessions)
+	if commands <= benchmarkWarmup {
+		return fmt.Errorf("only %d commands recorded; run more commands or lower --warmup", commands)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "clai-benchmark-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fmt.Fprintf(os.Stderr, "%sReplaying %d commands...%s\n", colorDim, commands, colorReset)
+	report, err := benchmarkHistory(cmd.Context(), tmpDir, sessions, benchmarkWeightSets(&cfg.Suggestions.Weights), benchmarkWarmup)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	writeBenchmarkText(os.Stdout, report)
+	return nil
+}
+
+// loadBenchmarkHistory reads the most recent limit commands from the
+// suggestions database.
+func loadBenchmarkHistory(ctx context.Context, limit int) ([]replay.Session, error) {
+	dbPath, err := suggestdb.DefaultDBPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, statErr := os.Stat(dbPath); os.IsNotExist(statErr) {
+		return nil, errors.New("no suggestions database yet; run some commands with clai enabled first")
+	}
+	key, err := databaseKey()
+	if err != nil {
+		return nil, err
+	}
+	sdb, err := suggestdb.Open(ctx, suggestdb.Options{Path: dbPath, ReadOnly: true, SkipLock: true, EncryptionKey: key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open suggestions database: %w", err)
+	}
+	defer sdb.Close()
+	return replay.LoadHistory(ctx, sdb.DB(), limit)
+}
+
+// benchmarkWeightSets returns the configured weights and, if they differ,
+// the defaults.
+func benchmarkWeightSets(configured *config.SuggestionsWeights) []benchmarkWeights {
+	sets := []benchmarkWeights{{name: "configured", weights: daemon.ScorerWeightsFromConfig(configured)}}
+	if defaults := config.DefaultSuggestionsConfig().Weights; *configured != defaults {
+		sets = append(sets, benchmarkWeights{name: "defaults", weights: daemon.ScorerWeightsFromConfig(&defaults)})
+	}
+	return sets
+}
+
+// benchmarkHistory replays sessions once per weight set, each into its own
+// database under tmpDir.
+func benchmarkHistory(ctx context.Context, tmpDir string, sessions []replay.Session, sets []benchmarkWeights, warmup int) (*benchmarkReport, error) {
+	report := &benchmarkReport{
+		Commands: countCommands(sessions),
+		Sessions: len(sessions),
+		Warmup:   warmup,
+		Runs:     make([]benchmarkRun, 0, len(sets)),
+	}
+	var first, last int64
+	for _, s := range sessions {
+		for _, c := range s.Commands {
+			if first == 0 || c.TimestampMs < first {
+				first = c.TimestampMs
+			}
+			last = max(last, c.TimestampMs)
+		}
+	}
+	if first > 0 {
+		report.From = time.UnixMilli(first).Local().Format(usage.DayLayout)
+		report.To = time.UnixMilli(last).Local().Format(usage.DayLayout)
+	}
+
+	for _, set := range sets {
+		runner := replay.NewRunner(replay.RunnerConfig{Weights: &set.weights, TopK: benchmarkTopK})
+		m, err := runner.Evaluate(ctx, filepath.Join(tmpDir, set.name), sessions, warmup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay history: %w", err)
+		}
+		report.Runs = append(report.Runs, benchmarkRun{
+			Weights:     set.name,
+			Predictions: m.Predictions,
+			HitAt1:      m.HitAt1(),
+			HitAt5:      m.HitAt5(),
+			MRR:         m.MRR(),
+		})
+	}
+	return report, nil
+}
+
+// countCommands returns the number of commands in sessions.
+func countCommands(sessions []replay.Session) int {
+	n := 0
+	for _, s := range sessions {
+		n += len(s.Commands)
+	}
+	return n
+}
+
+func writeBenchmarkText(w io.Writer, r *benchmarkReport) {
+	fmt.Fprintf(w, "Replayed %d commands from %d sessions (%s to %s)\n", r.Commands, r.Sessions, r.From, r.To)
+	if len(r.Runs) > 0 {
+		fmt.Fprintf(w, "Predicted %d commands after %d warm-up commands\n", r.Runs[0].Predictions, r.Warmup)
+	}
+
+	fmt.Fprintf(w, "\n  %-12s %7s %7s %7s\n", "Weights", "hit@1", "hit@5", "MRR")
+	for _, run := range r.Runs {
+		fmt.Fprintf(w, "  %-12s %6.1f%% %6.1f%% %7.3f\n", run.Weights, 100*run.HitAt1, 100*run.HitAt5, run.MRR)
+	}
+}
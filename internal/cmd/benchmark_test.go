@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/suggestions/replay"
+)
+
+func TestBenchmarkWeightSets(t *testing.T) {
+	t.Parallel()
+
+	defaults := config.DefaultSuggestionsConfig().Weights
+	if sets := benchmarkWeightSets(&defaults); len(sets) != 1 || sets[0].name != "configured" {
+		t.Errorf("default weights: sets = %+v, want only the configured weights", sets)
+	}
+
+	changed := defaults
+	changed.Transition *= 2
+	sets := benchmarkWeightSets(&changed)
+	if len(sets) != 2 || sets[0].name != "configured" || sets[1].name != "defaults" {
+		t.Fatalf("changed weights: sets = %+v, want configured and defaults", sets)
+	}
+	if sets[0].weights.RepoTransition != 2*sets[1].weights.RepoTransition {
+		t.Errorf("configured repo transition = %v, want twice the default %v",
+			sets[0].weights.RepoTransition, sets[1].weights.RepoTransition)
+	}
+}
+
+func TestBenchmarkHistory(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local).UnixMilli()
+	var sessions []replay.Session
+	for i := range 4 {
+		s := replay.Session{ID: fmt.Sprintf("s%d", i)}
+		for j, c := range []string{"git status", "git add .", "git commit", "git push"} {
+			s.Commands = append(s.Commands, replay.Command{CmdRaw: c, CWD: "/src", TimestampMs: start + int64(i*10+j)*60_000})
+		}
+		sessions = append(sessions, s)
+	}
+	defaults := config.DefaultSuggestionsConfig().Weights
+
+	report, err := benchmarkHistory(context.Background(), t.TempDir(), sessions, benchmarkWeightSets(&defaults), 4)
+	if err != nil {
+		t.Fatalf("benchmarkHistory() error = %v", err)
+	}
+	if report.Commands != 16 || report.Sessions != 4 || report.From != "2026-03-01" || report.To != "2026-03-01" {
+		t.Errorf("report = %+v", report)
+	}
+	if len(report.Runs) != 1 || report.Runs[0].Predictions != 12 || report.Runs[0].HitAt5 == 0 {
+		t.Errorf("runs = %+v, want 12 predictions with hits", report.Runs)
+	}
+
+	var out bytes.Buffer
+	writeBenchmarkText(&out, report)
+	for _, want := range []string{
+		"Replayed 16 commands from 4 sessions (2026-03-01 to 2026-03-01)",
+		"Predicted 12 commands after 4 warm-up commands",
+		"Weights        hit@1   hit@5     MRR",
+		"configured",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
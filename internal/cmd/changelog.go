@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/changelog"
+)
+
+var (
+	changelogAll     bool
+	changelogVersion string
+)
+
+var changelogCmd = &cobra.Command{
+	Use:     "changelog",
+	Short:   "Show what changed in this version of clai",
+	GroupID: groupSetup,
+	Long: `Show the changelog entry for the installed version of clai. The
+changelog is built into the binary, so this works offline.
+
+Development builds show the changes not yet released.
+
+Examples:
+  clai changelog
+  clai changelog --version v0.5.0
+  clai changelog --all`,
+	Args: cobra.NoArgs,
+	RunE: runChangelog,
+}
+
+func init() {
+	changelogCmd.Flags().BoolVar(&changelogAll, "all", false, "Show the full changelog")
+	changelogCmd.Flags().StringVar(&changelogVersion, "version", "", "Show the entry for this version instead of the installed one")
+}
+
+func runChangelog(_ *cobra.Command, _ []string) error {
+	releases := changelog.Releases()
+	if changelogAll {
+		for _, r := range releases {
+			renderRelease(os.Stdout, r)
+		}
+		return nil
+	}
+
+	version := changelogVersion
+	if version == "" {
+		version = Version
+	}
+	r, ok := changelog.Find(releases, version)
+	if !ok {
+		return fmt.Errorf("no changelog entry for %s (use --all to see every release)", version)
+	}
+	renderRelease(os.Stdout, r)
+	return nil
+}
+
+func renderRelease(w io.Writer, r changelog.Release) {
+	heading := r.Version
+	if r.Date != "" {
+		heading += " (" + r.Date + ")"
+	}
+	fmt.Fprintf(w, "%s%s%s\n\n%s\n\n", colorBold, heading, colorReset, r.Notes)
+}
@@ -10,16 +10,22 @@ func TestRootCmd_HasCommands(t *testing.T) {
 	// Verify expected commands are registered
 	expectedCommands := []string{
 		"ask",
+		"changelog",
 		"cmd",
 		"config",
 		"daemon",
 		"doctor",
+		"features",
+		"feedback",
 		"history",
 		"init",
 		"install",
 		"logs",
+		"note",
 		"off",
 		"on",
+		"scope",
+		"stats",
 		"status",
 		"suggest",
 		"uninstall",
@@ -65,7 +71,7 @@ func TestDaemonCmd_HasSubcommands(t *testing.T) {
 		t.Fatal("daemon command not found")
 	}
 
-	expectedSubs := []string{"start", "stop", "status"}
+	expectedSubs := []string{"start", "stop", "status", "upgrade"}
 	subCmds := make(map[string]bool)
 	for _, cmd := range daemon.Commands() {
 		subCmds[cmd.Name()] = true
@@ -100,7 +106,7 @@ func TestRootCmd_CommandGroups(t *testing.T) {
 
 func TestRootCmd_HiddenCommands(t *testing.T) {
 	// These commands should be hidden but still functional
-	hiddenCommands := []string{"daemon", "logs", "doctor"}
+	hiddenCommands := []string{"daemon", "logs", "suggest-rekey", "db"}
 
 	for _, name := range hiddenCommands {
 		var found *cobra.Command
@@ -124,7 +130,7 @@ func TestRootCmd_HiddenCommands(t *testing.T) {
 
 func TestRootCmd_CoreCommandsGrouped(t *testing.T) {
 	// Core commands should be in the core group
-	coreCommands := []string{"ask", "cmd", "suggest", "history", "on", "off"}
+	coreCommands := []string{"ask", "cmd", "suggest", "history", "stats", "note", "feedback", "on", "off"}
 
 	for _, name := range coreCommands {
 		var found *cobra.Command
@@ -148,7 +154,7 @@ func TestRootCmd_CoreCommandsGrouped(t *testing.T) {
 
 func TestRootCmd_SetupCommandsGrouped(t *testing.T) {
 	// Setup commands should be in the setup group
-	setupCommands := []string{"status", "config", "install", "uninstall", "init", "version"}
+	setupCommands := []string{"status", "doctor", "config", "setup", "install", "uninstall", "init", "scope", "features", "changelog", "version"}
 
 	for _, name := range setupCommands {
 		var found *cobra.Command
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/ipc"
+	"github.com/runger/clai/internal/workflow"
+)
+
+var completionCmd = &cobra.Command{
+	Use:     "completion bash|zsh|fish",
+	Short:   "Generate the completion script for clai itself",
+	GroupID: groupSetup,
+	Long: `Print a script that completes clai's commands, flags and arguments in
+the given shell. Config keys, workflow names and, when the daemon is
+running, session IDs are completed too.
+
+This completes the clai command line; suggestions for the commands you
+type come from 'clai init'.
+
+Examples:
+  source <(clai completion bash)     # Current bash session
+  clai completion zsh > "${fpath[1]}/_clai"
+  clai completion fish > ~/.config/fish/completions/clai.fish`,
+	Args:                  cobra.ExactArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	DisableFlagsInUseLine: true,
+	SilenceUsage:          true,
+	RunE:                  runCompletion,
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	switch args[0] {
+	case "bash":
+		return cmd.Root().GenBashCompletionV2(out, true)
+	case "zsh":
+		return cmd.Root().GenZshCompletion(out)
+	case "fish":
+		return cmd.Root().GenFishCompletion(out, true)
+	default:
+		return fmt.Errorf("unsupported shell: %s (use bash, zsh or fish)", args[0])
+	}
+}
+
+// completeConfigKeys completes the key of 'clai config <key> [value]'.
+func completeConfigKeys(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var keys []string
+	for _, key := range config.ListKeys() {
+		if strings.HasPrefix(key, toComplete) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeWorkflowNames completes a workflow name from the workflow
+// directories, falling back to file names when there are none.
+func completeWorkflowNames(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, name := range workflow.ListWorkflows() {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveDefault
+}
+
+// sessionLister is the part of the daemon client session ID completion uses.
+type sessionLister interface {
+	ListSessions(ctx context.Context) ([]*pb.SessionSummary, error)
+}
+
+// completeSessionIDArg completes the session ID argument of
+// 'clai daemon kill-session <id>'.
+func completeSessionIDArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeSessionIDs(cmd, args, toComplete)
+}
+
+// completeSessionIDs completes the IDs of the sessions the daemon is
+// tracking. It completes nothing when the daemon is not running.
+func completeSessionIDs(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	client, err := ipc.NewClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer client.Close()
+	return sessionIDCompletions(cmd.Context(), client, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// sessionIDCompletions returns the session IDs starting with toComplete,
+// each described by its shell, directory and note, and the current session
+// first.
+func sessionIDCompletions(ctx context.Context, client sessionLister, toComplete string) []string {
+	sessions, err := client.ListSessions(ctx)
+	if err != nil {
+		return nil
+	}
+	current := os.Getenv("CLAI_SESSION_ID")
+	var ids []string
+	for _, s := range sessions {
+		if !strings.HasPrefix(s.SessionId, toComplete) {
+			continue
+		}
+		desc := s.Shell
+		if s.Name != "" {
+			desc = s.Name + ", " + desc
+		}
+		if s.Cwd != "" {
+			desc += " in " + s.Cwd
+		}
+		if s.Note != "" {
+			desc += ": " + s.Note
+		}
+		if s.SessionId == current {
+			ids = append([]string{s.SessionId + "\t(this shell) " + desc}, ids...)
+			continue
+		}
+		ids = append(ids, s.SessionId+"\t"+desc)
+	}
+	return ids
+}
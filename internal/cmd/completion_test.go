@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+func TestCompletionCmd_Scripts(t *testing.T) {
+	for shell, want := range map[string]string{
+		"bash": "__start_clai",
+		"zsh":  "#compdef clai",
+		"fish": "complete -c clai",
+	} {
+		var out bytes.Buffer
+		completionCmd.SetOut(&out)
+		if err := runCompletion(completionCmd, []string{shell}); err != nil {
+			t.Fatalf("completion %s: %v", shell, err)
+		}
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("completion %s does not contain %q", shell, want)
+		}
+	}
+	completionCmd.SetOut(nil)
+
+	if err := runCompletion(completionCmd, []string{"tcsh"}); err == nil {
+		t.Error("completion tcsh: want an error")
+	}
+}
+
+func TestCompleteConfigKeys(t *testing.T) {
+	t.Parallel()
+
+	keys, directive := completeConfigKeys(configCmd, nil, "suggestions.max_")
+	if len(keys) == 0 || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Fatalf("completeConfigKeys() = %v, %v", keys, directive)
+	}
+	for _, k := range keys {
+		if !strings.HasPrefix(k, "suggestions.max_") {
+			t.Errorf("key %q does not match the prefix", k)
+		}
+	}
+	if keys, _ := completeConfigKeys(configCmd, []string{"ai.enabled"}, ""); len(keys) != 0 {
+		t.Errorf("value completion = %v, want none", keys)
+	}
+}
+
+func TestCompleteWorkflowNames(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+	project := t.TempDir()
+	t.Chdir(project)
+
+	for _, path := range []string{
+		filepath.Join(project, ".clai", "workflows", "deploy.yaml"),
+		filepath.Join(project, ".clai", "workflows", "README.md"),
+		filepath.Join(home, "workflows", "deploy.yml"),
+		filepath.Join(home, "workflows", "backup.yml"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("name: x\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, _ := completeWorkflowNames(workflowRunCmd, nil, "")
+	if want := []string{"backup", "deploy"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("completeWorkflowNames() = %v, want %v", names, want)
+	}
+	if names, _ := completeWorkflowNames(workflowRunCmd, nil, "dep"); !reflect.DeepEqual(names, []string{"deploy"}) {
+		t.Errorf("completeWorkflowNames(dep) = %v", names)
+	}
+	if got, want := resolveWorkflowPath("deploy"), filepath.Join(".clai", "workflows", "deploy.yaml"); got != want {
+		t.Errorf("resolveWorkflowPath(deploy) = %q, want %q", got, want)
+	}
+	if got := resolveWorkflowPath("missing"); got != "missing" {
+		t.Errorf("resolveWorkflowPath(missing) = %q, want it unchanged", got)
+	}
+}
+
+type fakeSessionLister struct {
+	sessions []*pb.SessionSummary
+}
+
+func (f *fakeSessionLister) ListSessions(context.Context) ([]*pb.SessionSummary, error) {
+	return f.sessions, nil
+}
+
+func TestSessionIDCompletions(t *testing.T) {
+	t.Setenv("CLAI_SESSION_ID", "b2")
+
+	client := &fakeSessionLister{sessions: []*pb.SessionSummary{
+		{SessionId: "a1", Shell: "zsh", Cwd: "/src"},
+		{SessionId: "b2", Shell: "bash", Note: "deploy"},
+		{SessionId: "c3", Shell: "fish"},
+	}}
+	got := sessionIDCompletions(context.Background(), client, "")
+	want := []string{"b2\t(this shell) bash: deploy", "a1\tzsh in /src", "c3\tfish"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sessionIDCompletions() = %q, want %q", got, want)
+	}
+	if got := sessionIDCompletions(context.Background(), client, "c"); !reflect.DeepEqual(got, []string{"c3\tfish"}) {
+		t.Errorf("sessionIDCompletions(c) = %q", got)
+	}
+}
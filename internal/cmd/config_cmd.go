@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -19,21 +24,285 @@ Without arguments, lists all configuration keys.
 With one argument, shows the value of that key.
 With two arguments, sets the key to the value.
 
+With --json, values are printed as JSON, typed by the setting: strings,
+booleans and numbers, lists as arrays, sections as objects, and null for
+lists and sections that are not set.
+
 Configuration is stored in ~/.clai/config.yaml.
 
-Keys are in the format: section.key
-Sections: daemon, client, ai, suggestions, privacy
+Keys are in the format: section.key, with deeper keys and list indices
+where the setting has them (suggestions.weights.task,
+history.picker_tabs[1].label). Lists and sections are read and written as
+YAML. Sections: daemon, client, ai, suggestions, history, workflows,
+privacy, profiles
 
 Examples:
   clai config                        # List all keys
   clai config ai.enabled             # Get ai.enabled value
+  clai config ai.enabled --json      # {"key": "ai.enabled", "value": true}
   clai config ai.enabled true        # Enable AI features
-  clai config daemon.idle_timeout_mins 30`,
-	Args: cobra.MaximumNArgs(2),
-	RunE: runConfig,
+  clai config daemon.idle_timeout_mins 30
+  clai config suggestions.weights.task 0.3
+  clai config suggestions.ephemeral_dirs '[~/secrets, ~/tmp]'
+  clai config add history.picker_tabs '{id: repo, label: Repo}'
+  clai config remove history.picker_tabs[2]
+  clai config edit                   # Edit in $EDITOR, validate and reload
+  clai config validate               # Check for typos and invalid values
+  clai config env                    # List CLAI_* environment overrides`,
+	Args:              cobra.MaximumNArgs(2),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfig,
+}
+
+var (
+	configFormat string
+	configEnvAll bool
+)
+
+var configEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "List CLAI_* environment overrides and check them",
+	Long: `List every CLAI_* environment variable clai honors, its current value
+and its effect, then report invalid values and conflicting combinations,
+such as CLAI_SOCKET overriding daemon.socket_path.
+
+Every config setting can also be overridden by a variable named after
+its key, such as CLAI_SUGGESTIONS_MAX_RESULTS for suggestions.max_results;
+those that are set are listed under Config Settings. --all lists all of
+them, and the variables the shell integration sets itself.
+Exits non-zero when a problem is found.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		issues := config.CheckEnv(os.Getenv, config.DefaultPaths().ConfigFile())
+		renderEnv(os.Stdout, os.Getenv, issues, configEnvAll)
+		if len(issues) > 0 {
+			return fmt.Errorf("found %d environment problem(s)", len(issues))
+		}
+		return nil
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a config file for unknown keys and invalid values",
+	Long: `Load a config file (default: the active one) the way clai does and report
+what it would otherwise ignore or change silently:
+
+  unknown     keys clai does not read, usually typos
+  error       values of the wrong type or that keep the file from loading
+  warning     values out of range, with the replacement clai uses
+  deprecated  keys that no longer have an effect, with a migration hint
+
+Profile presets are checked as well. Exits non-zero on errors and unknown
+keys.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := config.DefaultPaths().ConfigFile()
+		if len(args) == 1 {
+			path = args[0]
+		}
+		diags, err := config.CheckFile(path)
+		if err != nil {
+			return err
+		}
+		renderDiagnostics(cmd.OutOrStdout(), path, diags)
+		if config.HasErrors(diags) {
+			return fmt.Errorf("%s is invalid", path)
+		}
+		return nil
+	},
+}
+
+// renderDiagnostics prints the findings of config.CheckFile.
+func renderDiagnostics(w io.Writer, path string, diags []config.Diagnostic) {
+	if len(diags) == 0 {
+		fmt.Fprintf(w, "%s%s%s: ok\n", colorGreen, path, colorReset)
+		return
+	}
+	fmt.Fprintf(w, "%s%s%s\n", colorBold, path, colorReset)
+	counts := map[string]int{}
+	for _, d := range diags {
+		counts[d.Severity]++
+		color := colorYellow
+		switch d.Severity {
+		case config.SeverityError, config.SeverityUnknown:
+			color = colorRed
+		case config.SeverityDeprecated:
+			color = colorDim
+		}
+		line := ""
+		if d.Line > 0 {
+			line = fmt.Sprintf("line %d", d.Line)
+		}
+		fmt.Fprintf(w, "  %-9s %s%-10s%s %s%s%s: %s\n", line, color, d.Severity, colorReset, colorCyan, d.Key, colorReset, d.Message)
+	}
+	var summary []string
+	for _, sev := range []string{config.SeverityError, config.SeverityUnknown, config.SeverityWarning, config.SeverityDeprecated} {
+		if counts[sev] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[sev], sev))
+		}
+	}
+	fmt.Fprintf(w, "\n%s\n", strings.Join(summary, ", "))
+}
+
+var configAddCmd = &cobra.Command{
+	Use:   "add <key> <value>",
+	Short: "Append an entry to a list setting",
+	Long: `Append an entry to a list setting. The value is YAML, so structured
+entries such as picker tabs can be given inline.
+
+Examples:
+  clai config add suggestions.ephemeral_dirs ~/secrets
+  clai config add history.picker_tabs '{id: repo, label: Repo, provider: history}'`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateConfigFile(cmd.OutOrStdout(), config.DefaultPaths(), args[0], func(cfg *config.Config) error {
+			return cfg.Add(args[0], args[1])
+		})
+	},
+}
+
+var configRemoveCmd = &cobra.Command{
+	Use:   "remove <key>",
+	Short: "Remove a list entry, map entry or profile preset",
+	Long: `Remove the list entry, map entry, or profile preset override at key.
+
+Examples:
+  clai config remove history.picker_tabs[2]
+  clai config remove profiles.work
+  clai config remove profiles.work.ai.enabled`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateConfigFile(cmd.OutOrStdout(), config.DefaultPaths(), parentKey(args[0]), func(cfg *config.Config) error {
+			return cfg.Remove(args[0])
+		})
+	},
+}
+
+var configTrustYes bool
+
+var configTrustCmd = &cobra.Command{
+	Use:   "trust [dir]",
+	Short: "Trust the .clai/config.yaml of a repository",
+	Long: `Show the .clai/config.yaml of the repository containing dir (default:
+the current directory) and, once confirmed, let clai apply it to commands
+run in that repository.
+
+A repository config may only set suggestions.weights,
+suggestions.task_playbook_path, history.picker_tabs and incognito. It is
+ignored until trusted, and again after any change to the file.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigTrust(cmd.InOrStdin(), cmd.OutOrStdout(), config.DefaultPaths(), dirArg(args), configTrustYes)
+	},
+}
+
+var configUntrustCmd = &cobra.Command{
+	Use:          "untrust [dir]",
+	Short:        "Stop applying the .clai/config.yaml of a repository",
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := repoRootOf(dirArg(args))
+		if err != nil {
+			return err
+		}
+		if err := config.TrustRepo(config.DefaultPaths(), root, nil); err != nil {
+			return fmt.Errorf("failed to update trusted repositories: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "No longer applying %s\n", filepath.Join(root, config.RepoConfigFile))
+		return nil
+	},
+}
+
+func init() {
+	configCmd.Flags().StringVar(&configFormat, "format", "text", "Output format of values: text or json")
+	addJSONFlag(configCmd, &configFormat)
+	configEnvCmd.Flags().BoolVar(&configEnvAll, "all", false, "also list unset config variables and those set by the shell integration")
+	configTrustCmd.Flags().BoolVarP(&configTrustYes, "yes", "y", false, "Do not ask for confirmation")
+	configCmd.AddCommand(configAddCmd)
+	configCmd.AddCommand(configEnvCmd)
+	configCmd.AddCommand(configRemoveCmd)
+	configCmd.AddCommand(configTrustCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configUntrustCmd)
+}
+
+func dirArg(args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+	return "."
+}
+
+// repoRootOf returns the absolute root of the repository containing dir.
+func repoRootOf(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	root := config.FindRepoRoot(abs)
+	if root == "" {
+		return "", fmt.Errorf("%s is not inside a git repository", abs)
+	}
+	return root, nil
+}
+
+// runConfigTrust validates and shows the repository config, then records
+// it as trusted once confirmed.
+func runConfigTrust(in io.Reader, out io.Writer, paths *config.Paths, dir string, yes bool) error {
+	root, err := repoRootOf(dir)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(root, config.RepoConfigFile)
+	data, err := os.ReadFile(file) //nolint:gosec // G304: fixed name under the repository root
+	if err != nil {
+		return fmt.Errorf("failed to read repository config: %w", err)
+	}
+	if _, err := config.ParseRepoConfig(data); err != nil {
+		return err
+	}
+	if config.IsRepoTrusted(paths, root, data) {
+		fmt.Fprintf(out, "%s is already trusted.\n", file)
+		return nil
+	}
+
+	fmt.Fprintf(out, "%s%s%s\n", colorBold, file, colorReset)
+	fmt.Fprintln(out, strings.Repeat("-", 40))
+	fmt.Fprintln(out, strings.TrimRight(string(data), "\n"))
+	fmt.Fprintln(out, strings.Repeat("-", 40))
+	if !yes {
+		fmt.Fprint(out, "Apply these settings to commands run in this repository? [y/N] ")
+		response, _ := bufio.NewReader(in).ReadString('\n')
+		switch strings.TrimSpace(strings.ToLower(response)) {
+		case "y", "yes":
+		default:
+			fmt.Fprintln(out, "Not trusted.")
+			return nil
+		}
+	}
+	if err := config.TrustRepo(paths, root, data); err != nil {
+		return fmt.Errorf("failed to update trusted repositories: %w", err)
+	}
+	fmt.Fprintf(out, "Trusted %s\n", file)
+	return nil
 }
 
 func runConfig(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(strings.TrimSpace(configFormat))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format: %s (use text or json)", configFormat)
+	}
+	if format == "json" && len(args) == 2 {
+		return errors.New("--json applies to getting values, not setting them")
+	}
+
 	paths := config.DefaultPaths()
 	cfg, err := config.Load()
 	if err != nil {
@@ -43,18 +312,48 @@ func runConfig(cmd *cobra.Command, args []string) error {
 	switch len(args) {
 	case 0:
 		// List all keys
+		if format == "json" {
+			return writeJSON(os.Stdout, configListJSON(cfg, paths))
+		}
 		return listConfig(cfg, paths)
 	case 1:
 		// Get value
+		if format == "json" {
+			value, err := cfg.GetValue(args[0])
+			if err != nil {
+				return err
+			}
+			return writeJSON(os.Stdout, configValueJSON{Key: args[0], Value: value})
+		}
 		return getConfig(cfg, args[0])
 	case 2:
-		// Set value
-		return setConfig(cfg, paths, args[0], args[1])
+		// Set value in the file as written, without the profile preset
+		// and env overrides applied to cfg
+		raw, err := config.ReadFile(paths.ConfigFile())
+		if err != nil {
+			return err
+		}
+		if err := setConfig(raw, paths, args[0], args[1]); err != nil {
+			return err
+		}
+		if effective, err := loadEffective(args[0]); err == nil && effective != args[1] {
+			fmt.Printf("%sNote:%s the effective value is %s (profile preset or environment override)\n", colorYellow, colorReset, effective)
+		}
 	}
 
 	return nil
 }
 
+// loadEffective returns the value of key with the profile preset and env
+// overrides applied.
+func loadEffective(key string) (string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Get(key)
+}
+
 func listConfig(cfg *config.Config, paths *config.Paths) error {
 	fmt.Printf("%sConfiguration Keys%s\n", colorBold, colorReset)
 	fmt.Println(strings.Repeat("-", 40))
@@ -84,10 +383,53 @@ func listConfig(cfg *config.Config, paths *config.Paths) error {
 
 	fmt.Println()
 	fmt.Printf("Config file: %s\n", paths.ConfigFile())
+	if presets := cfg.PresetNames(); len(presets) > 0 {
+		fmt.Printf("Profile presets: %s\n", strings.Join(presets, ", "))
+	}
+	if profile := config.Profile(); profile != "" {
+		fmt.Printf("Active profile: %s\n", profile)
+	}
 
 	return nil
 }
 
+// configValueJSON is the output of 'clai config <key> --json'.
+type configValueJSON struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"` // null when a list or section is not set
+}
+
+// configValuesJSON is the output of 'clai config --json'.
+type configValuesJSON struct {
+	Values     map[string]any `json:"values"`
+	ConfigFile string         `json:"config_file"`
+	Profile    string         `json:"profile"` // The active profile; "" if none
+	Presets    []string       `json:"presets"`
+}
+
+// configListJSON collects the keys listConfig shows. Keys that fail to
+// resolve are reported on stderr and left out.
+func configListJSON(cfg *config.Config, paths *config.Paths) configValuesJSON {
+	out := configValuesJSON{
+		Values:     make(map[string]any),
+		ConfigFile: paths.ConfigFile(),
+		Profile:    config.Profile(),
+		Presets:    cfg.PresetNames(),
+	}
+	if out.Presets == nil {
+		out.Presets = []string{}
+	}
+	for _, key := range config.ListKeys() {
+		value, err := cfg.GetValue(key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to retrieve %s: %v\n", key, err)
+			continue
+		}
+		out.Values[key] = value
+	}
+	return out
+}
+
 func getConfig(cfg *config.Config, key string) error {
 	value, err := cfg.Get(key)
 	if err != nil {
@@ -107,7 +449,18 @@ func setConfig(cfg *config.Config, paths *config.Paths, key, value string) error
 	if err := cfg.Set(key, value); err != nil {
 		return err
 	}
+	if err := saveConfig(cfg, paths); err != nil {
+		return err
+	}
 
+	fmt.Printf("%s%s%s = %s\n", colorCyan, key, colorReset, value)
+	fmt.Printf("Saved to: %s\n", paths.ConfigFile())
+
+	return nil
+}
+
+// saveConfig validates cfg and writes it to the config file.
+func saveConfig(cfg *config.Config, paths *config.Paths) error {
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
@@ -117,12 +470,114 @@ func setConfig(cfg *config.Config, paths *config.Paths, key, value string) error
 		return fmt.Errorf("failed to create directories: %w", err)
 	}
 
-	if err := cfg.SaveToFile(paths.ConfigFile()); err != nil {
+	return cfg.SaveToFile(paths.ConfigFile())
+}
+
+// updateConfigFile applies update to the config file as written and saves
+// it, then prints the new value of show.
+func updateConfigFile(out io.Writer, paths *config.Paths, show string, update func(*config.Config) error) error {
+	cfg, err := config.ReadFile(paths.ConfigFile())
+	if err != nil {
+		return err
+	}
+	if err := update(cfg); err != nil {
+		return err
+	}
+	if err := saveConfig(cfg, paths); err != nil {
 		return err
 	}
+	value, err := cfg.Get(show)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s%s%s = %s\n", colorCyan, show, colorReset, value)
+	fmt.Fprintf(out, "Saved to: %s\n", paths.ConfigFile())
+	return nil
+}
 
-	fmt.Printf("%s%s%s = %s\n", colorCyan, key, colorReset, value)
-	fmt.Printf("Saved to: %s\n", paths.ConfigFile())
+// parentKey returns the list or map holding the entry at key:
+// "history.picker_tabs" for "history.picker_tabs[1]".
+func parentKey(key string) string {
+	if i := strings.LastIndexAny(key, ".["); i > 0 {
+		return key[:i]
+	}
+	return key
+}
 
-	return nil
+// renderConfigEnv prints the generated variables that are set, or all of
+// them with all.
+func renderConfigEnv(w io.Writer, lookup func(string) string, all bool) {
+	registered := make(map[string]bool, len(config.EnvVars))
+	for _, v := range config.EnvVars {
+		registered[v.Name] = true
+	}
+	var vars []config.ConfigEnvVar
+	for _, v := range config.ConfigEnvVars() {
+		if !registered[v.Name] && (all || lookup(v.Name) != "") {
+			vars = append(vars, v)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%sConfig Settings%s\n", colorBold, colorReset)
+	fmt.Fprintln(w, strings.Repeat("-", 40))
+	if len(vars) == 0 {
+		fmt.Fprintf(w, "  %sNone set. Every setting has one, e.g. CLAI_SUGGESTIONS_MAX_RESULTS; --all lists them.%s\n", colorDim, colorReset)
+		return
+	}
+	for _, v := range vars {
+		value := lookup(v.Name)
+		if value == "" {
+			value = colorDim + "(not set)" + colorReset
+		}
+		fmt.Fprintf(w, "  %s%s%s = %s\n", colorCyan, v.Name, colorReset, value)
+		fmt.Fprintf(w, "      Overrides %s\n", v.Key)
+	}
+}
+
+// renderEnv prints the CLAI_* variables in sections, followed by issues.
+func renderEnv(w io.Writer, lookup func(string) string, issues []config.EnvIssue, all bool) {
+	sections := []struct {
+		title string
+		match func(config.EnvVar) bool
+	}{
+		{"Environment Overrides", func(v config.EnvVar) bool { return !v.Shell && !v.Internal }},
+		{"Shell Integration", func(v config.EnvVar) bool { return v.Shell }},
+		{"Set by the Shell Integration", func(v config.EnvVar) bool { return all && v.Internal }},
+	}
+	for i, sec := range sections {
+		var vars []config.EnvVar
+		for _, v := range config.EnvVars {
+			if sec.match(v) {
+				vars = append(vars, v)
+			}
+		}
+		if len(vars) == 0 {
+			continue
+		}
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s%s%s\n", colorBold, sec.title, colorReset)
+		fmt.Fprintln(w, strings.Repeat("-", 40))
+		for _, v := range vars {
+			value := lookup(v.Name)
+			if value == "" {
+				value = colorDim + "(not set)" + colorReset
+			}
+			fmt.Fprintf(w, "  %s%s%s = %s\n", colorCyan, v.Name, colorReset, value)
+			fmt.Fprintf(w, "      %s [%s; %s]\n", v.Effect, v.Kind, v.UsedBy)
+		}
+	}
+
+	renderConfigEnv(w, lookup, all)
+
+	if len(issues) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%sProblems%s\n", colorBold, colorReset)
+	fmt.Fprintln(w, strings.Repeat("-", 40))
+	for _, issue := range issues {
+		fmt.Fprintf(w, "  %s!%s %s: %s\n", colorYellow, colorReset, issue.Name, issue.Message)
+	}
 }
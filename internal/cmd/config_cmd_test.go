@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/runger/clai/internal/config"
@@ -16,6 +21,7 @@ func TestConfigCmd_List(t *testing.T) {
 		"suggestions.max_history",
 		"suggestions.show_risk_warning",
 		"suggestions.scorer_version",
+		"suggestions.weights_preset",
 		"suggestions.picker_view",
 		"history.picker_backend",
 		"history.picker_open_on_empty",
@@ -23,6 +29,8 @@ func TestConfigCmd_List(t *testing.T) {
 		"history.picker_case_sensitive",
 		"history.up_arrow_trigger",
 		"history.up_arrow_double_window_ms",
+		"client.keybindings.history_picker",
+		"client.keybindings.suggest_widget",
 	}
 
 	if len(keys) != len(expectedKeys) {
@@ -43,6 +51,34 @@ func TestConfigCmd_List(t *testing.T) {
 	}
 }
 
+func TestConfigListJSON(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.History.PickerPageSize = 42
+	paths := &config.Paths{BaseDir: t.TempDir()}
+
+	var out bytes.Buffer
+	if err := writeJSON(&out, configListJSON(cfg, paths)); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Values     map[string]any `json:"values"`
+		ConfigFile string         `json:"config_file"`
+		Presets    []string       `json:"presets"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(got.Values) != len(config.ListKeys()) {
+		t.Errorf("got %d values, want one per key: %v", len(got.Values), got.Values)
+	}
+	if got.Values["history.picker_page_size"] != 42.0 || got.Values["suggestions.enabled"] != cfg.Suggestions.Enabled {
+		t.Errorf("values not typed: %v", got.Values)
+	}
+	if got.ConfigFile != paths.ConfigFile() || got.Presets == nil {
+		t.Errorf("config_file = %q, presets = %v", got.ConfigFile, got.Presets)
+	}
+}
+
 func TestFormatSize(t *testing.T) {
 	tests := []struct {
 		expected string
@@ -63,3 +99,144 @@ func TestFormatSize(t *testing.T) {
 		}
 	}
 }
+
+func TestRenderEnv(t *testing.T) {
+	env := map[string]string{"CLAI_SOCKET": "/tmp/clai.sock", "CLAI_SESSION_ID": "abc"}
+	lookup := func(name string) string { return env[name] }
+	issues := []config.EnvIssue{{Name: "CLAI_SOCKET", Message: "wins over CLAI_REMOTE=1"}}
+
+	var buf bytes.Buffer
+	renderEnv(&buf, lookup, issues, false)
+	out := buf.String()
+	for _, want := range []string{"CLAI_SOCKET" + colorReset + " = /tmp/clai.sock", "CLAI_HOME", "CLAI_MENU_LIMIT", "Problems", "CLAI_SOCKET: wins over CLAI_REMOTE=1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "CLAI_SESSION_ID") {
+		t.Errorf("internal variables listed without --all:\n%s", out)
+	}
+
+	buf.Reset()
+	renderEnv(&buf, lookup, nil, true)
+	out = buf.String()
+	if !strings.Contains(out, "CLAI_SESSION_ID"+colorReset+" = abc") {
+		t.Errorf("--all output missing CLAI_SESSION_ID:\n%s", out)
+	}
+	if strings.Contains(out, "Problems") {
+		t.Errorf("problems section without issues:\n%s", out)
+	}
+}
+
+func TestRunConfigTrust(t *testing.T) {
+	paths := &config.Paths{BaseDir: t.TempDir()}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".clai"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, config.RepoConfigFile)
+	data := []byte("incognito: true\n")
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "sub")
+
+	var out bytes.Buffer
+	if err := runConfigTrust(strings.NewReader("n\n"), &out, paths, sub, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "incognito: true") || !strings.Contains(out.String(), "Not trusted.") {
+		t.Errorf("declined prompt output:\n%s", out.String())
+	}
+	if config.IsRepoTrusted(paths, root, data) {
+		t.Fatal("trusted without confirmation")
+	}
+
+	out.Reset()
+	if err := runConfigTrust(strings.NewReader("y\n"), &out, paths, sub, false); err != nil {
+		t.Fatal(err)
+	}
+	if !config.IsRepoTrusted(paths, root, data) {
+		t.Fatalf("not trusted after confirmation:\n%s", out.String())
+	}
+
+	if err := os.WriteFile(file, []byte("daemon:\n  log_level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runConfigTrust(strings.NewReader(""), &out, paths, root, true); err == nil {
+		t.Error("invalid repository config was trusted")
+	}
+	if err := runConfigTrust(strings.NewReader(""), &out, paths, t.TempDir(), true); err == nil {
+		t.Error("expected an error outside a repository")
+	}
+}
+
+func TestRenderDiagnostics(t *testing.T) {
+	var buf bytes.Buffer
+	renderDiagnostics(&buf, "/tmp/config.yaml", nil)
+	if !strings.Contains(buf.String(), "ok") {
+		t.Errorf("valid file output = %q", buf.String())
+	}
+
+	buf.Reset()
+	renderDiagnostics(&buf, "/tmp/config.yaml", []config.Diagnostic{
+		{Severity: config.SeverityUnknown, Key: "ai.enabeld", Message: "unknown key", Line: 3},
+		{Severity: config.SeverityWarning, Key: "suggestions.weights.task", Message: "clamping to 1.0", Line: 8},
+		{Severity: config.SeverityWarning, Key: "suggestions.max_results", Message: "using 10"},
+	})
+	out := buf.String()
+	for _, want := range []string{"line 3", "ai.enabeld" + colorReset + ": unknown key", "1 unknown, 2 warning"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestUpdateConfigFile(t *testing.T) {
+	paths := &config.Paths{BaseDir: t.TempDir()}
+	var out bytes.Buffer
+	err := updateConfigFile(&out, paths, "suggestions.ephemeral_dirs", func(cfg *config.Config) error {
+		return cfg.Add("suggestions.ephemeral_dirs", "~/secrets")
+	})
+	if err != nil {
+		t.Fatalf("updateConfigFile() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "[~/secrets]") {
+		t.Errorf("output = %q", out.String())
+	}
+	cfg, err := config.ReadFile(paths.ConfigFile())
+	if err != nil || len(cfg.Suggestions.EphemeralDirs) != 1 {
+		t.Errorf("saved EphemeralDirs = %v, %v", cfg.Suggestions.EphemeralDirs, err)
+	}
+
+	if got := parentKey("history.picker_tabs[1]"); got != "history.picker_tabs" {
+		t.Errorf("parentKey() = %q", got)
+	}
+	if got := parentKey("profiles.work"); got != "profiles" {
+		t.Errorf("parentKey() = %q", got)
+	}
+}
+
+func TestRenderConfigEnv(t *testing.T) {
+	env := map[string]string{"CLAI_SUGGESTIONS_MAX_RESULTS": "9", "CLAI_SUGGESTIONS_ENABLED": "false"}
+	lookup := func(name string) string { return env[name] }
+
+	var buf bytes.Buffer
+	renderConfigEnv(&buf, lookup, false)
+	out := buf.String()
+	if !strings.Contains(out, "CLAI_SUGGESTIONS_MAX_RESULTS"+colorReset+" = 9") || !strings.Contains(out, "Overrides suggestions.max_results") {
+		t.Errorf("set variable missing:\n%s", out)
+	}
+	if strings.Contains(out, "CLAI_SUGGESTIONS_ENABLED") || strings.Contains(out, "CLAI_AI_ENABLED") {
+		t.Errorf("registered or unset variables listed:\n%s", out)
+	}
+
+	buf.Reset()
+	renderConfigEnv(&buf, lookup, true)
+	if !strings.Contains(buf.String(), "CLAI_AI_ENABLED") {
+		t.Errorf("--all output missing CLAI_AI_ENABLED")
+	}
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/daemon"
+)
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the config file and apply it to the running daemon",
+	Long: `Open the config file in $VISUAL or $EDITOR (default vi).
+
+On save the file is checked like 'clai config validate'. If it has errors
+you can edit it again or discard the changes; the file on disk is only
+replaced by a valid version. clai then lists the settings whose effective
+value changed and tells the running daemon to reload, naming the settings
+that still need 'clai daemon restart'.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths := config.DefaultPaths()
+		reload := func() error {
+			if !daemon.IsRunningWithPaths(paths) {
+				return errDaemonNotRunning
+			}
+			return daemon.ReloadWithPaths(paths)
+		}
+		return runConfigEdit(os.Stdin, cmd.OutOrStdout(), paths.ConfigFile(), runEditor, reload)
+	},
+}
+
+var errDaemonNotRunning = errors.New("daemon not running")
+
+func init() {
+	configCmd.AddCommand(configEditCmd)
+}
+
+// runEditor opens file in the user's editor, which may carry arguments
+// ("code --wait").
+func runEditor(file string) error {
+	args := strings.Fields(cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi"))
+	c := exec.Command(args[0], append(args[1:], file)...) //nolint:gosec // G204: the user's own editor
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return c.Run()
+}
+
+// runConfigEdit edits a copy of the config file at path with edit until it
+// is valid or the user gives up, replaces the file, prints the effective
+// changes and calls reload when there are any.
+func runConfigEdit(in io.Reader, out io.Writer, path string, edit func(string) error, reload func() error) error {
+	original, err := os.ReadFile(path) //nolint:gosec // G304: config file path is from trusted source
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	mode := os.FileMode(0o644)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+	before, loadErr := config.LoadFromFile(path)
+
+	// Edit a copy next to the file, so a rename replaces it atomically.
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec // G301: config directory needs standard permissions
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(original)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	responses := bufio.NewReader(in)
+	var edited []byte
+	for {
+		if err := edit(tmp.Name()); err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+		edited, err = os.ReadFile(tmp.Name())
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Fprintln(out, "No changes.")
+			return nil
+		}
+		diags, err := config.CheckFile(tmp.Name())
+		if err != nil {
+			diags = []config.Diagnostic{{Severity: config.SeverityError, Message: err.Error()}}
+		}
+		if len(diags) > 0 {
+			renderDiagnostics(out, path, diags)
+		}
+		if !config.HasErrors(diags) {
+			break
+		}
+		fmt.Fprint(out, "Edit again? [Y/n] ")
+		response, _ := responses.ReadString('\n')
+		if r := strings.TrimSpace(strings.ToLower(response)); r == "n" || r == "no" {
+			return fmt.Errorf("changes discarded; %s is unchanged", path)
+		}
+	}
+
+	after, afterErr := config.LoadFromFile(tmp.Name())
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	fmt.Fprintf(out, "Saved %s\n", path)
+
+	if afterErr != nil {
+		// Valid as a file, but not with the current CLAI_* overrides.
+		fmt.Fprintf(out, "%sWarning:%s %v\n", colorYellow, colorReset, afterErr)
+		return nil
+	}
+	if loadErr != nil {
+		fmt.Fprintln(out, "The previous file did not load; changes are shown against the defaults.")
+		before = config.DefaultConfig()
+	}
+	changes, err := config.Diff(before, after)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "No effective changes.")
+		return nil
+	}
+	renderChanges(out, changes)
+
+	var restart []string
+	for _, c := range changes {
+		if daemon.RequiresRestart(c.Key) {
+			restart = append(restart, c.Key)
+		}
+	}
+	switch err := reload(); {
+	case errors.Is(err, errDaemonNotRunning):
+		fmt.Fprintln(out, "The daemon is not running; the changes apply when it starts.")
+		return nil
+	case err != nil:
+		fmt.Fprintf(out, "%sWarning:%s could not notify the daemon: %v\n", colorYellow, colorReset, err)
+		fmt.Fprintln(out, "It picks up the file within a few seconds.")
+	default:
+		fmt.Fprintln(out, "Daemon notified to reload.")
+	}
+	if len(restart) > 0 {
+		fmt.Fprintf(out, "Run 'clai daemon restart' to apply %s.\n", strings.Join(restart, ", "))
+	}
+	return nil
+}
+
+// renderChanges prints settings as "key: old -> new".
+func renderChanges(w io.Writer, changes []config.Change) {
+	show := func(v string) string {
+		if v == "" {
+			return `""`
+		}
+		return v
+	}
+	fmt.Fprintf(w, "\n%sEffective changes:%s\n", colorBold, colorReset)
+	for _, c := range changes {
+		fmt.Fprintf(w, "  %s%s%s: %s%s%s -> %s\n", colorCyan, c.Key, colorReset, colorDim, show(c.Old), colorReset, show(c.New))
+	}
+	fmt.Fprintln(w)
+}
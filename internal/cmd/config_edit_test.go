@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeWith(content string) func(string) error {
+	return func(file string) error {
+		return os.WriteFile(file, []byte(content), 0o600)
+	}
+}
+
+func TestRunConfigEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("suggestions:\n  max_results: 5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edited := "daemon:\n  socket_path: /tmp/other.sock\nsuggestions:\n  max_results: 8\n"
+	reloaded := false
+	reload := func() error { reloaded = true; return nil }
+
+	var out bytes.Buffer
+	if err := runConfigEdit(strings.NewReader(""), &out, path, writeWith(edited), reload); err != nil {
+		t.Fatalf("runConfigEdit() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != edited {
+		t.Errorf("config file = %q, %v; want the edited content", data, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("config file mode = %v, %v; want 0644", info.Mode().Perm(), err)
+	}
+	if !reloaded {
+		t.Error("daemon not notified")
+	}
+	got := out.String()
+	for _, want := range []string{"suggestions.max_results", "8", "Run 'clai daemon restart' to apply daemon.socket_path"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRunConfigEdit_InvalidDiscarded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	original := "ai:\n  enabled: true\n"
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edits := 0
+	edit := func(file string) error {
+		edits++
+		return os.WriteFile(file, []byte("ai:\n  enabeld: false\n"), 0o600)
+	}
+	reload := func() error { t.Error("reload called for an invalid file"); return nil }
+
+	var out bytes.Buffer
+	err := runConfigEdit(strings.NewReader("y\nn\n"), &out, path, edit, reload)
+	if err == nil {
+		t.Fatal("runConfigEdit() error = nil, want changes discarded")
+	}
+	if edits != 2 {
+		t.Errorf("editor opened %d times, want 2", edits)
+	}
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Errorf("config file changed to %q", data)
+	}
+	if !strings.Contains(out.String(), `did you mean "enabled"`) {
+		t.Errorf("output missing diagnostics:\n%s", out.String())
+	}
+}
+
+func TestRunConfigEdit_NoChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	var out bytes.Buffer
+	err := runConfigEdit(strings.NewReader(""), &out, path, func(string) error { return nil }, func() error {
+		t.Error("reload called without changes")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runConfigEdit() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "No changes.") {
+		t.Errorf("output = %q", out.String())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("config file created without changes: %v", err)
+	}
+}
+
+func TestRunConfigEdit_DaemonNotRunning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	var out bytes.Buffer
+	err := runConfigEdit(strings.NewReader(""), &out, path, writeWith("ai:\n  enabled: true\n"), func() error {
+		return errDaemonNotRunning
+	})
+	if err != nil {
+		t.Fatalf("runConfigEdit() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "ai.enabled") || !strings.Contains(out.String(), "not running") {
+		t.Errorf("output = %q", out.String())
+	}
+}
@@ -24,10 +24,16 @@ The daemon handles shell integration, command history, and suggestions.
 It starts automatically when needed but can be managed manually.
 
 Subcommands:
-  start    Start the daemon
-  stop     Stop the daemon
-  restart  Restart the daemon
-  status   Show daemon status`,
+  start         Start the daemon
+  stop          Stop the daemon
+  restart       Restart the daemon
+  status        Show daemon status
+  sessions      List tracked shell sessions
+  kill-session  End a tracked session
+  flush-cache   Clear cached AI responses and suggestions
+  events        Stream shell activity as JSON lines
+  install       Install claid as a user service (systemd or launchd)
+  upgrade       Download the latest claid release and restart the daemon`,
 }
 
 var daemonStartCmd = &cobra.Command{
@@ -87,15 +93,22 @@ var daemonStopCmd = &cobra.Command{
 var daemonRestartCmd = &cobra.Command{
 	Use:   "restart",
 	Short: "Restart the clai daemon",
+	Long: `Restart the clai daemon.
+
+A running daemon saves its active sessions (including notes and the
+in-flight command) and re-execs its binary, so after an upgrade the new
+version picks up where the old one left off. If the daemon is not running,
+it is started.`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		// Stop if running
+		// Gracefully restart if running
 		if daemon.IsRunning() {
-			fmt.Print("Stopping daemon...")
-			if err := daemon.Stop(); err != nil {
+			fmt.Print("Restarting daemon...")
+			if err := daemon.Restart(); err != nil {
 				fmt.Printf(daemonFailedFmt, colorRed, colorReset)
 				return err
 			}
-			fmt.Printf(" %sstopped%s\n", colorGreen, colorReset)
+			fmt.Printf(" %srestarted%s\n", colorGreen, colorReset)
+			return nil
 		}
 
 		// Start
@@ -149,4 +162,10 @@ func init() {
 	daemonCmd.AddCommand(daemonStopCmd)
 	daemonCmd.AddCommand(daemonRestartCmd)
 	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonSessionsCmd)
+	daemonCmd.AddCommand(daemonKillSessionCmd)
+	daemonCmd.AddCommand(daemonFlushCacheCmd)
+	daemonCmd.AddCommand(daemonEventsCmd)
+	daemonCmd.AddCommand(daemonInstallCmd)
+	daemonCmd.AddCommand(daemonUpgradeCmd)
 }
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/ipc"
+	"github.com/runger/clai/internal/statusview"
+)
+
+var daemonSessionsJSON bool
+
+var daemonSessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List shell sessions tracked by the daemon",
+	Long: `List the shell sessions the daemon is currently tracking, oldest first.
+The current shell's session is marked with '*'.
+
+Examples:
+  clai daemon sessions
+  clai daemon sessions --json`,
+	Args: cobra.NoArgs,
+	RunE: runDaemonSessions,
+}
+
+var daemonKillSessionCmd = &cobra.Command{
+	Use:   "kill-session <id>",
+	Short: "End a session tracked by the daemon",
+	Long: `End a session as if its shell had exited. Use this to drop sessions
+left behind by shells that were killed without running their exit hook.
+
+Examples:
+  clai daemon kill-session 3f2a9c1e-5b7d-4e8f-a1b2-c3d4e5f60718`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSessionIDArg,
+	RunE:              runDaemonKillSession,
+}
+
+var daemonFlushCacheCmd = &cobra.Command{
+	Use:   "flush-cache",
+	Short: "Clear the daemon's cached AI responses and suggestions",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonFlushCache,
+}
+
+func init() {
+	daemonSessionsCmd.Flags().BoolVar(&daemonSessionsJSON, "json", false, "Print sessions as JSON")
+}
+
+// dialRunningDaemon connects to the daemon without spawning one.
+func dialRunningDaemon() (*ipc.Client, error) {
+	conn, err := ipc.QuickDial()
+	if err != nil {
+		return nil, errors.New("daemon is not running")
+	}
+	return ipc.NewClientWithConn(conn), nil
+}
+
+func runDaemonSessions(cmd *cobra.Command, _ []string) error {
+	client, err := dialRunningDaemon()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	sessions, err := client.ListSessions(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	if daemonSessionsJSON {
+		return writeSessionsJSON(os.Stdout, sessions)
+	}
+	renderSessions(os.Stdout, sessions, os.Getenv("CLAI_SESSION_ID"), time.Now())
+	return nil
+}
+
+func writeSessionsJSON(w io.Writer, sessions []*pb.SessionSummary) error {
+	if sessions == nil {
+		sessions = []*pb.SessionSummary{}
+	}
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// renderSessions prints one line per session, marking current with '*'.
+func renderSessions(w io.Writer, sessions []*pb.SessionSummary, current string, now time.Time) {
+	if len(sessions) == 0 {
+		fmt.Fprintln(w, "No active sessions.")
+		return
+	}
+
+	fmt.Fprintf(w, "  %-36s  %-5s  %-8s  %-8s  %s\n", "SESSION", "SHELL", "STARTED", "IDLE", "CWD")
+	for _, s := range sessions {
+		marker := " "
+		if s.SessionId == current {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %-36s  %-5s  %-8s  %-8s  %s\n",
+			marker, s.SessionId, s.Shell,
+			sinceUnixMs(s.StartedAtUnixMs, now), sinceUnixMs(s.LastActivityUnixMs, now), s.Cwd)
+		if s.Name != "" {
+			fmt.Fprintf(w, "    name: %s\n", s.Name)
+		}
+		if s.Note != "" {
+			fmt.Fprintf(w, "    note: %s\n", s.Note)
+		}
+		if s.Degraded != "" {
+			fmt.Fprintf(w, "    fire-and-forget only: %s (for %s)\n",
+				s.Degraded, statusview.FormatUptime(time.UnixMilli(s.DegradedUntilUnixMs).Sub(now)))
+		}
+	}
+}
+
+func sinceUnixMs(unixMs int64, now time.Time) string {
+	if unixMs == 0 {
+		return "-"
+	}
+	return statusview.FormatUptime(now.Sub(time.UnixMilli(unixMs)))
+}
+
+func runDaemonKillSession(cmd *cobra.Command, args []string) error {
+	client, err := dialRunningDaemon()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.KillSession(cmd.Context(), args[0]); err != nil {
+		return fmt.Errorf("failed to kill session %s: %w", args[0], err)
+	}
+	fmt.Printf("Session %s %sended%s\n", args[0], colorGreen, colorReset)
+	return nil
+}
+
+func runDaemonFlushCache(cmd *cobra.Command, _ []string) error {
+	client, err := dialRunningDaemon()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	flushed, err := client.FlushCaches(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to flush cache: %w", err)
+	}
+	fmt.Printf("Cache %sflushed%s (%d AI responses, %d suggestions removed)\n",
+		colorGreen, colorReset, flushed.AiCacheEntries, flushed.SuggestionCacheEntries)
+	return nil
+}
+
+var (
+	daemonEventsSession  string
+	daemonEventsTypes    []string
+	daemonEventsCommands bool
+)
+
+var daemonEventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Stream shell activity from the daemon as JSON lines",
+	Long: `Stream session and command events as the daemon ingests them, one JSON
+object per line, until interrupted. Status bars, tmux plugins and time
+trackers can read this instead of talking gRPC.
+
+Event types: session_start, session_end, command_start, command_end and
+diagnosis, sent when a command failed twice in a row and ai.auto_diagnose
+is on. Command text is omitted unless --commands is given.
+
+Examples:
+  clai daemon events
+  clai daemon events --type command_end --commands
+  clai daemon events --session "$CLAI_SESSION_ID"`,
+	Args: cobra.NoArgs,
+	RunE: runDaemonEvents,
+}
+
+func init() {
+	daemonEventsCmd.Flags().StringVar(&daemonEventsSession, "session", "", "Only stream events for this session ID")
+	_ = daemonEventsCmd.RegisterFlagCompletionFunc("session", completeSessionIDs)
+	daemonEventsCmd.Flags().StringSliceVar(&daemonEventsTypes, "type", nil, "Only stream these event types (repeatable)")
+	daemonEventsCmd.Flags().BoolVar(&daemonEventsCommands, "commands", false, "Include command text")
+}
+
+// shellEventLine is the JSON form of a shell event printed by `clai daemon events`.
+type shellEventLine struct {
+	Type        string   `json:"type"`
+	SessionID   string   `json:"session_id"`
+	Cwd         string   `json:"cwd,omitempty"`
+	Shell       string   `json:"shell,omitempty"`
+	CommandID   string   `json:"command_id,omitempty"`
+	Command     string   `json:"command,omitempty"`
+	GitRepoName string   `json:"git_repo_name,omitempty"`
+	GitBranch   string   `json:"git_branch,omitempty"`
+	TsUnixMs    int64    `json:"ts_unix_ms"`
+	DurationMs  int64    `json:"duration_ms,omitempty"`
+	ExitCode    *int32   `json:"exit_code,omitempty"`
+	Diagnosis   string   `json:"diagnosis,omitempty"`
+	Fixes       []string `json:"fixes,omitempty"`
+}
+
+const shellEventTypePrefix = "SHELL_EVENT_TYPE_"
+
+// parseShellEventTypes maps names like "command_end" to event types.
+func parseShellEventTypes(names []string) ([]pb.ShellEventType, error) {
+	types := make([]pb.ShellEventType, 0, len(names))
+	for _, name := range names {
+		v, ok := pb.ShellEventType_value[shellEventTypePrefix+strings.ToUpper(name)]
+		if !ok || v == 0 {
+			return nil, fmt.Errorf("unknown event type %q (use session_start, session_end, command_start, command_end or diagnosis)", name)
+		}
+		types = append(types, pb.ShellEventType(v))
+	}
+	return types, nil
+}
+
+func newShellEventLine(ev *pb.ShellEvent) shellEventLine {
+	line := shellEventLine{
+		Type:        strings.ToLower(strings.TrimPrefix(ev.Type.String(), shellEventTypePrefix)),
+		SessionID:   ev.SessionId,
+		Cwd:         ev.Cwd,
+		Shell:       ev.Shell,
+		CommandID:   ev.CommandId,
+		Command:     ev.Command,
+		GitRepoName: ev.GitRepoName,
+		GitBranch:   ev.GitBranch,
+		TsUnixMs:    ev.TsUnixMs,
+		DurationMs:  ev.DurationMs,
+		Diagnosis:   ev.Diagnosis,
+		Fixes:       ev.Fixes,
+	}
+	if ev.Type == pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_END || ev.Type == pb.ShellEventType_SHELL_EVENT_TYPE_DIAGNOSIS {
+		exitCode := ev.ExitCode
+		line.ExitCode = &exitCode
+	}
+	return line
+}
+
+func runDaemonEvents(cmd *cobra.Command, _ []string) error {
+	types, err := parseShellEventTypes(daemonEventsTypes)
+	if err != nil {
+		return err
+	}
+
+	client, err := dialRunningDaemon()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	stream, err := client.SubscribeEvents(cmd.Context(), &pb.SubscribeEventsRequest{
+		Types:           types,
+		SessionId:       daemonEventsSession,
+		IncludeCommands: daemonEventsCommands,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to events: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) || cmd.Context().Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("event stream ended: %w", err)
+		}
+		if err := enc.Encode(newShellEventLine(ev)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+func TestRenderSessions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	sessions := []*pb.SessionSummary{
+		{
+			SessionId:          "s1",
+			Shell:              "zsh",
+			Cwd:                "/repo",
+			StartedAtUnixMs:    now.Add(-2 * time.Hour).UnixMilli(),
+			LastActivityUnixMs: now.Add(-30 * time.Second).UnixMilli(),
+			Note:               "debugging auth",
+		},
+		{
+			SessionId:           "s2",
+			Shell:               "bash",
+			Cwd:                 "/tmp",
+			Degraded:            "suggest p95 400ms over the 250ms budget",
+			DegradedUntilUnixMs: now.Add(5 * time.Minute).UnixMilli(),
+		},
+	}
+
+	var buf bytes.Buffer
+	renderSessions(&buf, sessions, "s2", now)
+	out := buf.String()
+
+	for _, want := range []string{"SESSION", "s1", "2h 0m", "30s", "/repo", "note: debugging auth", "* s2",
+		"fire-and-forget only: suggest p95 400ms over the 250ms budget (for 5m 0s)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("renderSessions() missing %q\nOutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderSessions_Empty(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	renderSessions(&buf, nil, "", time.Now())
+	if !strings.Contains(buf.String(), "No active sessions") {
+		t.Errorf("renderSessions(nil) = %q", buf.String())
+	}
+}
+
+func TestWriteSessionsJSON_EmptyIsArray(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := writeSessionsJSON(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil || got == nil {
+		t.Errorf("writeSessionsJSON(nil) = %q, want []", buf.String())
+	}
+}
+
+func TestParseShellEventTypes(t *testing.T) {
+	t.Parallel()
+
+	types, err := parseShellEventTypes([]string{"command_end", "SESSION_START"})
+	if err != nil {
+		t.Fatalf("parseShellEventTypes() error = %v", err)
+	}
+	want := []pb.ShellEventType{
+		pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_END,
+		pb.ShellEventType_SHELL_EVENT_TYPE_SESSION_START,
+	}
+	if len(types) != len(want) || types[0] != want[0] || types[1] != want[1] {
+		t.Errorf("parseShellEventTypes() = %v, want %v", types, want)
+	}
+
+	for _, bad := range []string{"unspecified", "command"} {
+		if _, err := parseShellEventTypes([]string{bad}); err == nil {
+			t.Errorf("parseShellEventTypes(%q) expected error", bad)
+		}
+	}
+}
+
+func TestNewShellEventLine(t *testing.T) {
+	t.Parallel()
+
+	end, _ := json.Marshal(newShellEventLine(&pb.ShellEvent{
+		Type:      pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_END,
+		SessionId: "s1",
+		ExitCode:  0,
+	}))
+	if !strings.Contains(string(end), `"type":"command_end"`) || !strings.Contains(string(end), `"exit_code":0`) {
+		t.Errorf("command_end line = %s", end)
+	}
+
+	start, _ := json.Marshal(newShellEventLine(&pb.ShellEvent{
+		Type:      pb.ShellEventType_SHELL_EVENT_TYPE_SESSION_START,
+		SessionId: "s1",
+	}))
+	if strings.Contains(string(start), "exit_code") {
+		t.Errorf("session_start line should not carry exit_code: %s", start)
+	}
+
+	diag, _ := json.Marshal(newShellEventLine(&pb.ShellEvent{
+		Type:      pb.ShellEventType_SHELL_EVENT_TYPE_DIAGNOSIS,
+		SessionId: "s1",
+		ExitCode:  1,
+		Diagnosis: "the script is misspelled",
+		Fixes:     []string{"npm run build"},
+	}))
+	for _, want := range []string{`"type":"diagnosis"`, `"exit_code":1`, `"diagnosis":"the script is misspelled"`, `"fixes":["npm run build"]`} {
+		if !strings.Contains(string(diag), want) {
+			t.Errorf("diagnosis line = %s, missing %s", diag, want)
+		}
+	}
+}
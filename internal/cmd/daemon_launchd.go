@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/runger/clai/internal/config"
+)
+
+const launchAgentLabel = "com.runger.claid"
+
+// launchAgentPlistFmt starts claid at login and restarts it if it crashes.
+// A clean exit, such as the idle timeout, leaves it stopped until clai
+// spawns it again.
+
+const launchAgentPlistFmt = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+	</array>
+	<key>EnvironmentVariables</key>
+	<dict>%s
+	</dict>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`
+
+func installLaunchAgent(daemonPath string, paths *config.Paths) error {
+	plistPath, err := launchAgentPath()
+	if err != nil {
+		return err
+	}
+
+	if err := writeLaunchAgent(plistPath, daemonPath, paths); err != nil {
+		return err
+	}
+	fmt.Printf("  %sWrote%s %s\n", colorGreen, colorReset, plistPath)
+
+	if err := stopAutoSpawnedDaemon(); err != nil {
+		return err
+	}
+
+	if err := loadLaunchAgent(plistPath); err != nil {
+		fmt.Printf("%sCould not load the LaunchAgent automatically:%s %v\n", colorYellow, colorReset, err)
+		fmt.Println("Run manually:")
+		fmt.Printf("  launchctl bootstrap gui/%d %s\n", os.Getuid(), plistPath)
+		return nil
+	}
+	fmt.Printf("%sLoaded %s%s\n", colorGreen, launchAgentLabel, colorReset)
+	return nil
+}
+
+// launchAgentPath returns ~/Library/LaunchAgents/<label>.plist.
+func launchAgentPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchAgentLabel+".plist"), nil
+}
+
+func writeLaunchAgent(plistPath, daemonPath string, paths *config.Paths) error {
+	if err := os.MkdirAll(filepath.Dir(plistPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(plistPath), err)
+	}
+	content := launchAgentPlist(daemonPath, paths, serviceEnv())
+	if err := os.WriteFile(plistPath, []byte(content), 0o644); err != nil { //nolint:gosec // G306: plist is not secret
+		return fmt.Errorf("failed to write %s: %w", plistPath, err)
+	}
+	return nil
+}
+
+func launchAgentPlist(daemonPath string, paths *config.Paths, env []envVar) string {
+	var envXML strings.Builder
+	for _, v := range env {
+		fmt.Fprintf(&envXML, "\n\t\t<key>%s</key>\n\t\t<string>%s</string>", xmlEscape(v.name), xmlEscape(v.value))
+	}
+	return fmt.Sprintf(launchAgentPlistFmt,
+		launchAgentLabel,
+		xmlEscape(daemonPath),
+		envXML.String(),
+		xmlEscape(paths.StderrFile()),
+	)
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// loadLaunchAgent (re)loads the agent into the user's GUI domain.
+func loadLaunchAgent(plistPath string) error {
+	domain := "gui/" + strconv.Itoa(os.Getuid())
+	// Unload a previous version first; failure just means it wasn't loaded.
+	_ = runLaunchctl("bootout", domain+"/"+launchAgentLabel)
+	return runLaunchctl("bootstrap", domain, plistPath)
+}
+
+// removeLaunchAgent unloads and deletes the claid LaunchAgent if present.
+// Returns the number of files removed, or that would be with dryRun.
+func removeLaunchAgent(dryRun bool) int {
+	plistPath, err := launchAgentPath()
+	if err != nil {
+		return 0
+	}
+	if _, err := os.Stat(plistPath); err != nil {
+		return 0
+	}
+	if !dryRun {
+		_ = runLaunchctl("bootout", "gui/"+strconv.Itoa(os.Getuid())+"/"+launchAgentLabel)
+	}
+	return removeFiles("service unit", []string{plistPath}, dryRun)
+}
+
+func runLaunchctl(args ...string) error {
+	launchctl, err := exec.LookPath("launchctl")
+	if err != nil {
+		return errors.New("launchctl not found")
+	}
+	out, err := exec.Command(launchctl, args...).CombinedOutput() //nolint:gosec // G204: fixed launchctl arguments
+	if err != nil {
+		return fmt.Errorf("launchctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runger/clai/internal/config"
+)
+
+func TestLaunchAgentPlist(t *testing.T) {
+	paths := &config.Paths{BaseDir: "/Users/me/.clai"}
+
+	plist := launchAgentPlist("/opt/clai & co/claid", paths, []envVar{{"CLAI_DATA_DIR", "/Volumes/data/clai"}})
+
+	if err := xml.Unmarshal([]byte(plist), new(struct{})); err != nil {
+		t.Fatalf("plist is not well-formed XML: %v\n%s", err, plist)
+	}
+	for _, want := range []string{
+		"<string>" + launchAgentLabel + "</string>",
+		"<string>/opt/clai &amp; co/claid</string>",
+		"<key>CLAI_DATA_DIR</key>\n\t\t<string>/Volumes/data/clai</string>",
+		"<key>RunAtLoad</key>",
+		"<key>SuccessfulExit</key>",
+		"<string>/Users/me/.clai/logs/daemon.stderr</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist missing %q:\n%s", want, plist)
+		}
+	}
+}
+
+func TestRemoveLaunchAgent(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", "") // keep launchctl out of reach
+
+	plistPath, err := launchAgentPath()
+	if err != nil {
+		t.Fatalf("launchAgentPath error: %v", err)
+	}
+	if err := writeLaunchAgent(plistPath, "/usr/local/bin/claid", &config.Paths{BaseDir: filepath.Join(home, ".clai")}); err != nil {
+		t.Fatalf("writeLaunchAgent error: %v", err)
+	}
+
+	var removed int
+	captureStdout(t, func() { removed = removeLaunchAgent(false) })
+
+	if removed != 1 {
+		t.Errorf("removeLaunchAgent() = %d, want 1", removed)
+	}
+	if _, err := os.Stat(plistPath); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", plistPath)
+	}
+}
@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/daemon"
+	"github.com/runger/clai/internal/ipc"
+)
+
+const (
+	systemdSocketUnitName  = "claid.socket"
+	systemdServiceUnitName = "claid.service"
+)
+
+// serviceEnvNames are the environment variables that decide where clai
+// keeps its files. A service manager starts claid without the user's
+// environment, so the units carry the ones set at install time.
+var serviceEnvNames = []string{
+	"CLAI_HOME", "CLAI_CONFIG_DIR", "CLAI_DATA_DIR", "CLAI_CACHE_DIR",
+	"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_STATE_HOME", "XDG_CACHE_HOME",
+}
+
+// envVar is a name and value passed to claid by its service unit.
+type envVar struct{ name, value string }
+
+// serviceEnv returns the serviceEnvNames that are set, in order. Unset ones
+// are left out so claid resolves them the same way clai does.
+func serviceEnv() []envVar {
+	var env []envVar
+	for _, name := range serviceEnvNames {
+		if value := os.Getenv(name); value != "" {
+			env = append(env, envVar{name, value})
+		}
+	}
+	return env
+}
+
+var (
+	daemonInstallSystemd bool
+	daemonInstallLaunchd bool
+)
+
+var daemonInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install claid as a user service",
+	Long: `Install claid as a user service so a service manager starts it
+instead of clai spawning it.
+
+With --systemd (Linux), a socket unit and a service unit are written to
+the systemd user unit directory and the socket is enabled. systemd owns
+the daemon socket and starts claid on the first connection; a
+socket-activated claid does not exit when idle.
+
+With --launchd (macOS), a LaunchAgent plist is written to
+~/Library/LaunchAgents and loaded. launchd starts claid at login and
+restarts it if it crashes; after an idle exit clai spawns it again.
+
+Only the default profile can be installed as a service; daemons for
+other CLAI_PROFILE values are spawned on demand.
+
+Examples:
+  clai daemon install --systemd
+  clai daemon install --launchd`,
+	RunE: runDaemonInstall,
+}
+
+func init() {
+	daemonInstallCmd.Flags().BoolVar(&daemonInstallSystemd, "systemd", false, "Install systemd user units with socket activation")
+	daemonInstallCmd.Flags().BoolVar(&daemonInstallLaunchd, "launchd", false, "Install a launchd LaunchAgent that starts claid at login")
+	daemonInstallCmd.MarkFlagsMutuallyExclusive("systemd", "launchd")
+}
+
+func runDaemonInstall(cmd *cobra.Command, _ []string) error {
+	if !daemonInstallSystemd && !daemonInstallLaunchd {
+		return errors.New("choose a service manager: --systemd or --launchd")
+	}
+	// Unit names and the launchd label are fixed, so only the default
+	// profile can be managed by a service; other profiles auto-spawn.
+	if profile := config.Profile(); profile != "" {
+		return fmt.Errorf("service install is only supported for the default profile (unset %s=%s)", config.ProfileEnv, profile)
+	}
+
+	daemonPath, err := ipc.FindDaemonBinary()
+	if err != nil {
+		return err
+	}
+	paths := config.DefaultPaths()
+
+	if daemonInstallLaunchd {
+		return installLaunchAgent(daemonPath, paths)
+	}
+	return installSystemdUnits(daemonPath, paths)
+}
+
+func installSystemdUnits(daemonPath string, paths *config.Paths) error {
+	unitDir, err := systemdUserUnitDir()
+	if err != nil {
+		return err
+	}
+
+	written, err := writeSystemdUnits(unitDir, daemonPath, paths)
+	if err != nil {
+		return err
+	}
+	for _, path := range written {
+		fmt.Printf("  %sWrote%s %s\n", colorGreen, colorReset, path)
+	}
+
+	if err := stopAutoSpawnedDaemon(); err != nil {
+		return err
+	}
+
+	if err := enableSystemdSocket(); err != nil {
+		fmt.Printf("%sCould not enable the socket automatically:%s %v\n", colorYellow, colorReset, err)
+		fmt.Println("Run manually:")
+		fmt.Println("  systemctl --user daemon-reload")
+		fmt.Println("  systemctl --user enable --now " + systemdSocketUnitName)
+		return nil
+	}
+	fmt.Printf("%sEnabled %s%s\n", colorGreen, systemdSocketUnitName, colorReset)
+	return nil
+}
+
+// stopAutoSpawnedDaemon stops a running daemon, since the service manager
+// binds the same socket path an auto-spawned daemon listens on.
+func stopAutoSpawnedDaemon() error {
+	if !daemon.IsRunning() {
+		return nil
+	}
+	fmt.Print("Stopping auto-spawned daemon...")
+	if err := daemon.Stop(); err != nil {
+		fmt.Printf(daemonFailedFmt, colorRed, colorReset)
+		return err
+	}
+	fmt.Printf(" %sstopped%s\n", colorGreen, colorReset)
+	return nil
+}
+
+// systemdUserUnitDir returns $XDG_CONFIG_HOME/systemd/user (default ~/.config/systemd/user).
+func systemdUserUnitDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "systemd", "user"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "systemd", "user"), nil
+}
+
+// writeSystemdUnits writes the claid socket and service units into unitDir
+// and returns the paths written.
+func writeSystemdUnits(unitDir, daemonPath string, paths *config.Paths) ([]string, error) {
+	if err := os.MkdirAll(unitDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", unitDir, err)
+	}
+
+	units := []struct {
+		name    string
+		content string
+	}{
+		{systemdSocketUnitName, systemdSocketUnit(paths.SocketFile())},
+		{systemdServiceUnitName, systemdServiceUnit(daemonPath, serviceEnv())},
+	}
+
+	written := make([]string, 0, len(units))
+	for _, u := range units {
+		path := filepath.Join(unitDir, u.name)
+		if err := os.WriteFile(path, []byte(u.content), 0o644); err != nil { //nolint:gosec // G306: unit files are not secret
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+func systemdSocketUnit(socketPath string) string {
+	return strings.Join([]string{
+		"[Unit]",
+		"Description=clai daemon socket",
+		"",
+		"[Socket]",
+		"ListenStream=" + socketPath,
+		"SocketMode=0600",
+		"DirectoryMode=0700",
+		"",
+		"[Install]",
+		"WantedBy=sockets.target",
+		"",
+	}, "\n")
+}
+
+func systemdServiceUnit(daemonPath string, env []envVar) string {
+	lines := []string{
+		"[Unit]",
+		"Description=clai daemon",
+		"Requires=" + systemdSocketUnitName,
+		"After=" + systemdSocketUnitName,
+		"",
+		"[Service]",
+		"Type=simple",
+		"ExecStart=" + daemonPath,
+	}
+	for _, v := range env {
+		lines = append(lines, "Environment="+systemdQuote(v.name+"="+v.value))
+	}
+	return strings.Join(append(lines, "Restart=on-failure", ""), "\n")
+}
+
+// systemdQuote double-quotes s for a unit file setting, escaping what
+// systemd would otherwise interpret: quotes, backslashes and % specifiers.
+func systemdQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%").Replace(s)
+	return `"` + s + `"`
+}
+
+// removeSystemdUnits disables the claid units found in unitDir and deletes
+// them. Returns the number of unit files removed, or that would be with
+// dryRun.
+func removeSystemdUnits(unitDir string, dryRun bool) int {
+	units := []string{
+		filepath.Join(unitDir, systemdSocketUnitName),
+		filepath.Join(unitDir, systemdServiceUnitName),
+	}
+	if _, err := os.Stat(units[0]); err != nil {
+		if _, err := os.Stat(units[1]); err != nil {
+			return 0
+		}
+	}
+
+	if dryRun {
+		return removeFiles("service unit", units, true)
+	}
+	if err := runSystemctl("--user", "disable", "--now", systemdSocketUnitName, systemdServiceUnitName); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	removed := removeFiles("service unit", units, false)
+	_ = runSystemctl("--user", "daemon-reload")
+	return removed
+}
+
+func enableSystemdSocket() error {
+	if err := runSystemctl("--user", "daemon-reload"); err != nil {
+		return err
+	}
+	return runSystemctl("--user", "enable", "--now", systemdSocketUnitName)
+}
+
+func runSystemctl(args ...string) error {
+	systemctl, err := exec.LookPath("systemctl")
+	if err != nil {
+		return errors.New("systemctl not found")
+	}
+	out, err := exec.Command(systemctl, args...).CombinedOutput() //nolint:gosec // G204: fixed systemctl arguments
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
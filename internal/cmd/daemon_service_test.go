@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runger/clai/internal/config"
+)
+
+func TestWriteSystemdUnits(t *testing.T) {
+	for _, name := range serviceEnvNames {
+		t.Setenv(name, "")
+	}
+	t.Setenv("CLAI_HOME", "/home/user/100% clai")
+	t.Setenv("XDG_CACHE_HOME", "/home/user/.cache")
+	unitDir := filepath.Join(t.TempDir(), "systemd", "user")
+	paths := &config.Paths{BaseDir: "/home/user/.clai"}
+
+	written, err := writeSystemdUnits(unitDir, "/usr/local/bin/claid", paths)
+	if err != nil {
+		t.Fatalf("writeSystemdUnits error: %v", err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("expected 2 units written, got %d", len(written))
+	}
+
+	socket, err := os.ReadFile(filepath.Join(unitDir, systemdSocketUnitName))
+	if err != nil {
+		t.Fatalf("ReadFile socket unit: %v", err)
+	}
+	for _, want := range []string{"ListenStream=/home/user/.clai/clai.sock", "SocketMode=0600", "WantedBy=sockets.target"} {
+		if !strings.Contains(string(socket), want) {
+			t.Errorf("socket unit missing %q:\n%s", want, socket)
+		}
+	}
+
+	service, err := os.ReadFile(filepath.Join(unitDir, systemdServiceUnitName))
+	if err != nil {
+		t.Fatalf("ReadFile service unit: %v", err)
+	}
+	for _, want := range []string{
+		"ExecStart=/usr/local/bin/claid",
+		`Environment="CLAI_HOME=/home/user/100%% clai"`,
+		`Environment="XDG_CACHE_HOME=/home/user/.cache"`,
+		"Requires=claid.socket",
+	} {
+		if !strings.Contains(string(service), want) {
+			t.Errorf("service unit missing %q:\n%s", want, service)
+		}
+	}
+	if strings.Contains(string(service), "CLAI_DATA_DIR") {
+		t.Errorf("service unit sets CLAI_DATA_DIR, which the user did not set:\n%s", service)
+	}
+}
+
+func TestSystemdUserUnitDir_XDGConfigHome(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+
+	dir, err := systemdUserUnitDir()
+	if err != nil {
+		t.Fatalf("systemdUserUnitDir error: %v", err)
+	}
+	if dir != "/tmp/xdg/systemd/user" {
+		t.Errorf("systemdUserUnitDir() = %q, want %q", dir, "/tmp/xdg/systemd/user")
+	}
+}
+
+func TestDaemonInstallCmd_RequiresServiceManager(t *testing.T) {
+	daemonInstallSystemd = false
+
+	err := runDaemonInstall(daemonInstallCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "--systemd") {
+		t.Fatalf("runDaemonInstall() error = %v, want service manager error", err)
+	}
+}
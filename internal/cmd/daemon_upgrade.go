@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/daemon"
+	"github.com/runger/clai/internal/ipc"
+	"github.com/runger/clai/internal/selfupdate"
+)
+
+var (
+	daemonUpgradeVersion string
+	daemonUpgradeCheck   bool
+	daemonUpgradeForce   bool
+)
+
+var daemonUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Download the latest clai release and restart the daemon",
+	Long: `Download clai, clai-shim and claid from the latest GitHub release (or
+the one given with --version), verify them against the release checksums,
+replace the installed binaries and restart the daemon gracefully.
+
+The three binaries are upgraded together: if any of them cannot be found
+or written, none is replaced. Active sessions survive the restart. If the
+daemon is not running, only the binaries are replaced.
+
+Examples:
+  clai daemon upgrade --check
+  clai daemon upgrade
+  clai daemon upgrade --version v0.5.0`,
+	Args: cobra.NoArgs,
+	RunE: runDaemonUpgrade,
+}
+
+func init() {
+	daemonUpgradeCmd.Flags().StringVar(&daemonUpgradeVersion, "version", "", "Install this release instead of the latest")
+	daemonUpgradeCmd.Flags().BoolVar(&daemonUpgradeCheck, "check", false, "Only report whether an upgrade is available")
+	daemonUpgradeCmd.Flags().BoolVar(&daemonUpgradeForce, "force", false, "Reinstall even if the daemon already runs this version")
+}
+
+func runDaemonUpgrade(cmd *cobra.Command, _ []string) error {
+	if runtime.GOOS == "windows" {
+		return errors.New("daemon upgrade is not supported on Windows; download the release manually")
+	}
+
+	targets, err := upgradeTargets()
+	if err != nil {
+		return err
+	}
+
+	client := selfupdate.NewClient()
+	rel, err := client.FetchRelease(cmd.Context(), daemonUpgradeVersion)
+	if err != nil {
+		return err
+	}
+
+	running := daemon.IsRunning()
+	current := runningDaemonVersion(running)
+	upToDate := current != "" && strings.TrimPrefix(current, "v") == rel.Version()
+
+	if daemonUpgradeCheck {
+		switch {
+		case current == "":
+			fmt.Printf("Latest release: %s%s%s (installed version unknown)\n", colorCyan, rel.Tag, colorReset)
+		case upToDate:
+			fmt.Printf("claid %s is %sup to date%s\n", current, colorGreen, colorReset)
+		default:
+			fmt.Printf("Upgrade available: %s → %s%s%s\n", current, colorCyan, rel.Tag, colorReset)
+		}
+		return nil
+	}
+	if upToDate && !daemonUpgradeForce {
+		fmt.Printf("claid %s is %salready current%s (use --force to reinstall)\n", current, colorGreen, colorReset)
+		return nil
+	}
+
+	fmt.Printf("Downloading clai %s...", rel.Tag)
+	archive, err := client.DownloadArchive(cmd.Context(), rel, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		fmt.Printf(daemonFailedFmt, colorRed, colorReset)
+		return err
+	}
+	files, err := upgradeFiles(archive, targets)
+	if err != nil {
+		fmt.Printf(daemonFailedFmt, colorRed, colorReset)
+		return err
+	}
+	fmt.Printf(" %sverified%s\n", colorGreen, colorReset)
+
+	if err := selfupdate.ReplaceFiles(files); err != nil {
+		return fmt.Errorf("failed to install the upgrade: %w", err)
+	}
+	for _, f := range files {
+		fmt.Printf("Installed %s\n", f.Path)
+	}
+
+	if !running {
+		return nil
+	}
+	fmt.Print("Restarting daemon...")
+	if err := daemon.Restart(); err != nil {
+		fmt.Printf(daemonFailedFmt, colorRed, colorReset)
+		return err
+	}
+	fmt.Printf(" %srestarted%s\n", colorGreen, colorReset)
+	return nil
+}
+
+// shimBinaryName is the shell hook binary shipped alongside clai and claid.
+const shimBinaryName = "clai-shim"
+
+// upgradeTarget is an installed binary that daemon upgrade replaces.
+type upgradeTarget struct {
+	name string
+	path string
+}
+
+// upgradeTargets locates the installed clai, clai-shim and claid. Upgrading
+// only some of them would leave the shell hooks and the daemon speaking
+// different protocol versions, so a missing binary fails the upgrade.
+func upgradeTargets() ([]upgradeTarget, error) {
+	claiPath, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot find installed clai: %w", err)
+	}
+	shimPath, err := findShimBinary(filepath.Dir(claiPath))
+	if err != nil {
+		return nil, fmt.Errorf("cannot find installed %s: %w", shimBinaryName, err)
+	}
+	daemonPath, err := ipc.FindDaemonBinary()
+	if err != nil {
+		return nil, fmt.Errorf("cannot find installed %s: %w", ipc.DaemonBinaryName, err)
+	}
+	return []upgradeTarget{
+		{name: "clai", path: claiPath},
+		{name: shimBinaryName, path: shimPath},
+		{name: ipc.DaemonBinaryName, path: daemonPath},
+	}, nil
+}
+
+// findShimBinary looks for clai-shim next to clai, then on PATH.
+func findShimBinary(dir string) (string, error) {
+	path := filepath.Join(dir, shimBinaryName)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	path, err := exec.LookPath(shimBinaryName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// upgradeFiles extracts every target's binary from the release archive. A
+// release missing any of them fails before anything is replaced.
+func upgradeFiles(archive []byte, targets []upgradeTarget) ([]selfupdate.File, error) {
+	files := make([]selfupdate.File, 0, len(targets))
+	for _, t := range targets {
+		data, err := selfupdate.ExtractBinary(archive, t.name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, selfupdate.File{Path: t.path, Data: data})
+	}
+	return files, nil
+}
+
+// runningDaemonVersion asks a running daemon for its version. It returns ""
+// when the daemon is not running or reports a development build.
+func runningDaemonVersion(running bool) string {
+	if !running {
+		return ""
+	}
+	client, err := dialRunningDaemon()
+	if err != nil {
+		return ""
+	}
+	defer client.Close()
+
+	resp, err := client.GetStatus()
+	if err != nil || resp.Version == "dev" {
+		return ""
+	}
+	return resp.Version
+}
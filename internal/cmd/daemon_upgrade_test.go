@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func makeUpgradeArchive(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(name)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUpgradeFiles(t *testing.T) {
+	targets := []upgradeTarget{
+		{name: "clai", path: "/bin/clai"},
+		{name: shimBinaryName, path: "/bin/clai-shim"},
+		{name: "claid", path: "/bin/claid"},
+	}
+
+	files, err := upgradeFiles(makeUpgradeArchive(t, "clai", "clai-shim", "claid"), targets)
+	if err != nil {
+		t.Fatalf("upgradeFiles error: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(files))
+	}
+	for i, f := range files {
+		if f.Path != targets[i].path || string(f.Data) != targets[i].name {
+			t.Errorf("files[%d] = %s %q, want %s %q", i, f.Path, f.Data, targets[i].path, targets[i].name)
+		}
+	}
+
+	_, err = upgradeFiles(makeUpgradeArchive(t, "clai", "claid"), targets)
+	if err == nil || !strings.Contains(err.Error(), shimBinaryName) {
+		t.Errorf("expected an error naming %s, got %v", shimBinaryName, err)
+	}
+}
+
+func TestFindShimBinary(t *testing.T) {
+	t.Setenv("PATH", "")
+	dir := t.TempDir()
+	if _, err := findShimBinary(dir); err == nil {
+		t.Error("expected an error when clai-shim is missing")
+	}
+
+	shim := filepath.Join(dir, shimBinaryName)
+	if err := os.WriteFile(shim, nil, 0o755); err != nil { //nolint:gosec // test executable
+		t.Fatal(err)
+	}
+	got, err := findShimBinary(dir)
+	if err != nil || got != shim {
+		t.Errorf("findShimBinary = %q, %v; want %q", got, err, shim)
+	}
+}
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/daemon"
+	"github.com/runger/clai/internal/dbcrypt"
+	"github.com/runger/clai/internal/storage"
+	"github.com/runger/clai/internal/suggestions/backfill"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/maintenance"
+	"github.com/runger/clai/internal/suggestions/recompute"
+)
+
+var dbCmd = &cobra.Command{
+	Use:    "db",
+	Short:  "Maintain clai's databases",
+	Hidden: true,
+	Long: `Maintain clai's databases.
+
+Subcommands:
+  migrate-v2       Backfill the suggestions database from the V1 history store
+  reindex          Rebuild and optimize the full-text history index
+  recompute-stats  Rebuild the learned suggestion statistics from history`,
+}
+
+var dbMigrateV2Cmd = &cobra.Command{
+	Use:   "migrate-v2",
+	Short: "Backfill the suggestions database from the V1 history store",
+	Long: `Copy command history recorded before the V2 suggestions engine was
+enabled from the V1 history store (state.db) into the suggestions database
+(suggestions_v2.db): events, templates, stats, transitions, pipelines and
+the full-text index. Each command keeps its session, directory, git repo,
+exit code and duration.
+
+Commands the suggestions database already has are skipped, so this is safe
+on an installation that has been using V2 for a while. The migration runs
+once; afterwards the daemon reads history and suggestions from V2 first.
+
+The daemon must be stopped first (clai daemon stop).
+
+Examples:
+  clai db migrate-v2`,
+	Args: cobra.NoArgs,
+	RunE: runDBMigrateV2,
+}
+
+var dbReindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild and optimize the full-text history index",
+	Long: `Rebuild the full-text index used by history search (command_event_fts)
+from the command events in the suggestions database, then merge it into a
+single segment.
+
+The index is kept in sync by triggers as commands are recorded; a crash can
+leave it missing events or pointing at events that are gone, so searches
+miss commands or return stale results. The daemon's maintenance repairs
+drift and fragmentation on its own when it is idle; this forces it.
+
+The daemon must be stopped first (clai daemon stop).
+
+Examples:
+  clai db reindex`,
+	Args: cobra.NoArgs,
+	RunE: runDBReindex,
+}
+
+var dbRecomputeScope string
+
+var dbRecomputeStatsCmd = &cobra.Command{
+	Use:   "recompute-stats",
+	Short: "Rebuild the learned suggestion statistics from history",
+	Long: `Rebuild the frequency, time-of-day, transition and slot statistics
+suggestions are ranked by (command_stat, command_time_stat, transition_stat
+and slot_stat) by replaying every recorded command in the suggestions
+database, and clear the suggestion cache.
+
+The statistics are updated incrementally as commands are recorded. Bulk
+changes such as imports, restored backups or interrupted purges can leave
+them out of step with the recorded commands; this makes them consistent
+again. The rebuild runs in one transaction, so an interrupted run changes
+nothing.
+
+--scope rebuilds a single scope (global, repo:<key>, host:<name> or
+dir:<hash>) and leaves the others alone.
+
+The daemon must be stopped first (clai daemon stop).
+
+Examples:
+  clai db recompute-stats
+  clai db recompute-stats --scope repo:github.com/runger/clai`,
+	Args: cobra.NoArgs,
+	RunE: runDBRecomputeStats,
+}
+
+func init() {
+	dbRecomputeStatsCmd.Flags().StringVar(&dbRecomputeScope, "scope", "", "Only rebuild this scope, e.g. global or repo:<key>")
+	dbCmd.AddCommand(dbMigrateV2Cmd)
+	dbCmd.AddCommand(dbReindexCmd)
+	dbCmd.AddCommand(dbRecomputeStatsCmd)
+}
+
+func runDBMigrateV2(cmd *cobra.Command, _ []string) error {
+	if daemon.IsRunning() {
+		return errors.New("the daemon is running; stop it first with 'clai daemon stop'")
+	}
+
+	v1Path, err := storage.DefaultDBPath()
+	if err != nil {
+		return err
+	}
+	v2Path, err := suggestdb.DefaultDBPath()
+	if err != nil {
+		return err
+	}
+
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+
+	result, err := migrateV2(cmd.Context(), v1Path, v2Path, key, time.Now())
+	if errors.Is(err, backfill.ErrAlreadyMigrated) {
+		fmt.Println("V1 history was already migrated; nothing to do")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrated %s%d%s commands from %d sessions\n",
+		colorGreen, result.Migrated, colorReset, result.Sessions)
+	if result.Skipped > 0 {
+		fmt.Printf("  %sskipped %d already in the suggestions database%s\n", colorDim, result.Skipped, colorReset)
+	}
+	fmt.Println("Start the daemon to use the migrated history: clai daemon start")
+	return nil
+}
+
+func runDBReindex(cmd *cobra.Command, _ []string) error {
+	if daemon.IsRunning() {
+		return errors.New("the daemon is running; stop it first with 'clai daemon stop'")
+	}
+
+	v2Path, err := suggestdb.DefaultDBPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(v2Path); os.IsNotExist(err) {
+		return fmt.Errorf("no suggestions database at %s; nothing to reindex", v2Path)
+	}
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+
+	before, indexed, err := reindexFTS(cmd.Context(), v2Path, key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Reindexed %s%d%s commands\n", colorGreen, indexed, colorReset)
+	if before.Drifted() {
+		fmt.Printf("  %sthe index had %d of %d searchable commands%s\n",
+			colorDim, before.Indexed, before.Searchable, colorReset)
+	}
+	if before.Segments > 1 {
+		fmt.Printf("  %smerged %d index segments into one%s\n", colorDim, before.Segments, colorReset)
+	}
+	return nil
+}
+
+func runDBRecomputeStats(cmd *cobra.Command, _ []string) error {
+	if daemon.IsRunning() {
+		return errors.New("the daemon is running; stop it first with 'clai daemon stop'")
+	}
+
+	v2Path, err := suggestdb.DefaultDBPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(v2Path); os.IsNotExist(err) {
+		return fmt.Errorf("no suggestions database at %s; nothing to recompute", v2Path)
+	}
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+
+	result, err := recomputeStats(cmd.Context(), v2Path, key, dbRecomputeScope)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Recomputed statistics from %s%d%s commands\n", colorGreen, result.Events, colorReset)
+	fmt.Printf("  %s%d command, %d time, %d transition and %d slot stats%s\n",
+		colorDim, result.CommandStats, result.TimeStats, result.TransitionStats, result.SlotStats, colorReset)
+	return nil
+}
+
+// recomputeStats rebuilds the statistics of the V2 database at v2Path from
+// its command events, in scope or in every scope when scope is empty.
+func recomputeStats(ctx context.Context, v2Path string, key []byte, scope string) (recompute.Result, error) {
+	sdb, err := suggestdb.Open(ctx, suggestdb.Options{Path: v2Path, EncryptionKey: key})
+	if err != nil {
+		return recompute.Result{}, fmt.Errorf("failed to open suggestions database: %w", err)
+	}
+	defer sdb.Close()
+
+	result, err := recompute.Run(ctx, sdb.DB(), recompute.Options{Scope: scope})
+	if err != nil {
+		return result, fmt.Errorf("failed to recompute statistics: %w", err)
+	}
+	return result, nil
+}
+
+// reindexFTS rebuilds the full-text index of the V2 database at v2Path and
+// returns its state beforehand and the number of commands indexed.
+func reindexFTS(ctx context.Context, v2Path string, key []byte) (maintenance.FTSStatus, int64, error) {
+	sdb, err := suggestdb.Open(ctx, suggestdb.Options{Path: v2Path, EncryptionKey: key})
+	if err != nil {
+		return maintenance.FTSStatus{}, 0, fmt.Errorf("failed to open suggestions database: %w", err)
+	}
+	defer sdb.Close()
+
+	before, err := maintenance.CheckFTS(ctx, sdb.DB())
+	if err != nil {
+		return before, 0, err
+	}
+	indexed, err := maintenance.RebuildFTS(ctx, sdb.DB())
+	return before, indexed, err
+}
+
+// migrateV2 backfills the V2 database at v2Path from the V1 store at v1Path.
+// key is the privacy.db_encryption key, or nil.
+func migrateV2(ctx context.Context, v1Path, v2Path string, key []byte, now time.Time) (backfill.V1Result, error) {
+	if _, err := os.Stat(v1Path); os.IsNotExist(err) {
+		return backfill.V1Result{}, errors.New("no V1 history store; nothing to migrate")
+	}
+
+	v1, err := storage.NewSQLiteStoreWithKey(v1Path, key)
+	if err != nil {
+		return backfill.V1Result{}, fmt.Errorf("failed to open history store: %w", err)
+	}
+	defer v1.Close()
+
+	sdb, err := suggestdb.Open(ctx, suggestdb.Options{Path: v2Path, EncryptionKey: key})
+	if err != nil {
+		return backfill.V1Result{}, fmt.Errorf("failed to open suggestions database: %w", err)
+	}
+	defer sdb.Close()
+
+	return backfill.MigrateV1(ctx, sdb.DB(), v1, now.UnixMilli())
+}
+
+// databaseKey returns the privacy.db_encryption key for commands that open
+// the databases directly, or nil when they are not encrypted.
+func databaseKey() ([]byte, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	key, err := dbcrypt.LoadKey(cfg, config.DefaultPaths())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load database key: %w", err)
+	}
+	return key, nil
+}
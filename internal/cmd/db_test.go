@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/runger/clai/internal/storage"
+	"github.com/runger/clai/internal/suggestions/backfill"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+)
+
+func TestMigrateV2(t *testing.T) {
+	dir := t.TempDir()
+	v1Path := filepath.Join(dir, "state.db")
+	v2Path := filepath.Join(dir, "suggestions_v2.db")
+	ctx := context.Background()
+
+	store, err := storage.NewSQLiteStore(v1Path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	if err := store.CreateSession(ctx, &storage.Session{
+		SessionID: "s1", StartedAtUnixMs: 1000, Shell: "zsh", OS: "linux", InitialCWD: "/tmp",
+	}); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	for i, command := range []string{"make build", "make test"} {
+		if err := store.CreateCommand(ctx, &storage.Command{
+			CommandID:     "c" + command,
+			SessionID:     "s1",
+			TSStartUnixMs: int64(1000 + i),
+			CWD:           "/tmp",
+			Command:       command,
+		}); err != nil {
+			t.Fatalf("CreateCommand() error = %v", err)
+		}
+	}
+	store.Close()
+
+	result, err := migrateV2(ctx, v1Path, v2Path, nil, time.Now())
+	if err != nil {
+		t.Fatalf("migrateV2() error = %v", err)
+	}
+	if result.Migrated != 2 || result.Sessions != 1 {
+		t.Errorf("result = %+v, want 2 commands from 1 session", result)
+	}
+
+	if _, err := migrateV2(ctx, v1Path, v2Path, nil, time.Now()); !errors.Is(err, backfill.ErrAlreadyMigrated) {
+		t.Errorf("second migrateV2() error = %v, want ErrAlreadyMigrated", err)
+	}
+}
+
+func TestMigrateV2_NoV1Store(t *testing.T) {
+	dir := t.TempDir()
+	_, err := migrateV2(context.Background(), filepath.Join(dir, "state.db"), filepath.Join(dir, "suggestions_v2.db"), nil, time.Now())
+	if err == nil {
+		t.Error("migrateV2() without a V1 store should fail")
+	}
+}
+
+func TestReindexFTS(t *testing.T) {
+	dir := t.TempDir()
+	v2Path := filepath.Join(dir, "suggestions_v2.db")
+	ctx := context.Background()
+
+	sdb, err := suggestdb.Open(ctx, suggestdb.Options{Path: v2Path, SkipLock: true})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := sdb.DB().Exec(`
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm)
+		VALUES ('s1', 1000, '/tmp', 'make build', 'make build')
+	`); err != nil {
+		t.Fatal(err)
+	}
+	// Drop the event from the index, as an interrupted write would.
+	if _, err := sdb.DB().Exec(`INSERT INTO command_event_fts(command_event_fts) VALUES('delete-all')`); err != nil {
+		t.Fatal(err)
+	}
+	sdb.Close()
+
+	before, indexed, err := reindexFTS(ctx, v2Path, nil)
+	if err != nil {
+		t.Fatalf("reindexFTS() error = %v", err)
+	}
+	if !before.Drifted() || indexed != 1 {
+		t.Errorf("reindexFTS() = %+v, %d; want drift repaired with 1 indexed", before, indexed)
+	}
+}
+
+func TestRecomputeStats(t *testing.T) {
+	dir := t.TempDir()
+	v2Path := filepath.Join(dir, "suggestions_v2.db")
+	ctx := context.Background()
+
+	sdb, err := suggestdb.Open(ctx, suggestdb.Options{Path: v2Path, SkipLock: true})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := sdb.DB().Exec(`
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, template_id, exit_code)
+		VALUES ('s1', 1000, '/tmp', 'make build', 'make build', 't1', 0),
+		       ('s1', 2000, '/tmp', 'make build', 'make build', 't1', 2)
+	`); err != nil {
+		t.Fatal(err)
+	}
+	sdb.Close()
+
+	result, err := recomputeStats(ctx, v2Path, nil, "global")
+	if err != nil {
+		t.Fatalf("recomputeStats() error = %v", err)
+	}
+	if result.Events != 2 || result.CommandStats != 1 || result.TransitionStats != 1 {
+		t.Errorf("recomputeStats() = %+v; want 2 events, 1 command and 1 transition stat", result)
+	}
+}
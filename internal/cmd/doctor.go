@@ -1,40 +1,56 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/runger/clai/internal/config"
 	"github.com/runger/clai/internal/daemon"
+	"github.com/runger/clai/internal/dbcrypt"
+	"github.com/runger/clai/internal/dbrepair"
+	"github.com/runger/clai/internal/ipc"
 )
 
 var doctorCmd = &cobra.Command{
-	Use:    "doctor",
-	Short:  "Check clai installation and dependencies",
-	Hidden: true,
-	Long: `Run diagnostic checks on your clai installation.
+	Use:     "doctor",
+	Short:   "Diagnose problems with your clai setup and suggest fixes",
+	GroupID: groupSetup,
+	Long: `Run diagnostic checks on your clai installation and print a fix for each
+problem found.
 
 This command checks:
-- Binary installation
-- Shell integration
-- Daemon status
-- AI provider availability
+- Binary installation and the data directory
 - Configuration validity
-- File permissions
+- Shell integration for the current shell
+- Whether the daemon is running and answers on its socket
+- Socket permissions
+- Database integrity and sizes
+- AI provider availability
+
+It exits non-zero when a check fails; warnings alone do not fail it.
 
 Examples:
   clai doctor`,
-	RunE: runDoctor,
+	SilenceUsage: true,
+	RunE:         runDoctor,
 }
 
 type checkResult struct {
 	name    string
 	status  string // "ok", "warn", "error"
 	message string
+	fix     string // What to do about a warning or error ("" = nothing)
 }
 
 func runDoctor(cmd *cobra.Command, args []string) error {
@@ -50,6 +66,9 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	results = append(results, checkConfiguration())
 	results = append(results, checkShellIntegrationDoctor())
 	results = append(results, checkDaemon())
+	results = append(results, checkSocketPermissions()...)
+	results = append(results, checkDaemonInstances()...)
+	results = append(results, checkDatabases()...)
 	results = append(results, checkAIProviders()...)
 
 	// Print results
@@ -73,12 +92,15 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		if r.message != "" {
 			fmt.Printf("       %s%s%s\n", colorDim, r.message, colorReset)
 		}
+		if r.fix != "" && r.status != "ok" {
+			fmt.Printf("       %sFix:%s %s\n", colorCyan, colorReset, r.fix)
+		}
 	}
 
 	fmt.Println()
 
 	if hasErrors {
-		fmt.Printf("%sSome checks failed. Please fix the errors above.%s\n", colorRed, colorReset)
+		fmt.Printf("%sSome checks failed. Apply the fixes above and run 'clai doctor' again.%s\n", colorRed, colorReset)
 		return fmt.Errorf("doctor found errors")
 	}
 
@@ -99,6 +121,7 @@ func checkBinary() checkResult {
 			name:    "clai binary",
 			status:  "error",
 			message: "clai not found in PATH",
+			fix:     "Add the directory clai is installed in to PATH in your shell config",
 		}
 	}
 
@@ -128,6 +151,7 @@ func checkDirectories() []checkResult {
 			name:    checkNameDataDir,
 			status:  "error",
 			message: fmt.Sprintf("Error accessing: %s", paths.BaseDir),
+			fix:     fmt.Sprintf("Make sure you own %s and can read it", paths.BaseDir),
 		})
 	} else {
 		results = append(results, checkResult{
@@ -150,6 +174,7 @@ func checkConfiguration() checkResult {
 			name:    "Configuration",
 			status:  "error",
 			message: fmt.Sprintf("Failed to load: %v", err),
+			fix:     "Correct the file with 'clai config edit', or move it aside to use the defaults",
 		}
 	}
 
@@ -158,6 +183,7 @@ func checkConfiguration() checkResult {
 			name:    "Configuration",
 			status:  "error",
 			message: fmt.Sprintf("Invalid: %v", err),
+			fix:     "Correct the setting with 'clai config edit'; 'clai config validate' lists every problem",
 		}
 	}
 
@@ -176,50 +202,347 @@ func checkConfiguration() checkResult {
 	}
 }
 
+// checkNameShell is the label used for the shell integration check.
+const checkNameShell = "Shell integration"
+
+// checkShellIntegrationDoctor checks that the hooks are installed for the
+// current shell, and loaded, from this clai version, in this shell.
 func checkShellIntegrationDoctor() checkResult {
-	shells := checkShellIntegration()
+	detection := DetectShell()
+	if detection.Active {
+		if initVersion := os.Getenv("CLAI_INIT_VERSION"); initVersion != Version {
+			if initVersion == "" {
+				initVersion = "an older clai"
+			}
+			return checkResult{
+				name:    checkNameShell,
+				status:  "warn",
+				message: fmt.Sprintf("%s hooks are from %s, the binary is %s", detection.Shell, initVersion, Version),
+				fix:     "Start a new terminal to load the current hooks",
+			}
+		}
+		return checkResult{
+			name:    checkNameShell,
+			status:  "ok",
+			message: fmt.Sprintf("%s (active in this shell)", detection.Shell),
+		}
+	}
 
+	shells := checkShellIntegration()
 	if len(shells) == 0 {
+		fix := "Run 'clai install' (or 'clai setup' for a guided setup)"
+		if detection.Shell != "" {
+			fix = fmt.Sprintf("Run 'clai install --shell=%s' (or 'clai setup' for a guided setup)", detection.Shell)
+		}
 		return checkResult{
-			name:    "Shell integration",
+			name:    checkNameShell,
 			status:  "warn",
-			message: "Not installed. Run 'clai install' to set up.",
+			message: "Not installed",
+			fix:     fix,
+		}
+	}
+	if detection.Shell == "" {
+		return checkResult{
+			name:    checkNameShell,
+			status:  "ok",
+			message: strings.Join(shells, ", "),
 		}
 	}
-
 	return checkResult{
-		name:    "Shell integration",
-		status:  "ok",
-		message: strings.Join(shells, ", "),
+		name:    checkNameShell,
+		status:  "warn",
+		message: fmt.Sprintf("Installed for %s but not loaded in this shell", strings.Join(shells, ", ")),
+		fix:     fmt.Sprintf("Start a new terminal, or run: %s", evalCommand(detection.Shell)),
 	}
 }
 
+// checkDaemon checks that the daemon answers on its socket, which is what
+// the shell hooks need, rather than only that its process exists.
 func checkDaemon() checkResult {
-	if daemon.IsRunning() {
+	if ipc.RemoteMode() {
+		if client, err := dialRunningDaemon(); err == nil {
+			defer client.Close()
+			if client.Ping() {
+				return checkResult{name: "Daemon", status: "ok", message: "Remote, forwarded to " + ipc.SocketPath()}
+			}
+		}
 		return checkResult{
 			name:    "Daemon",
-			status:  "ok",
-			message: "Running",
+			status:  "error",
+			message: "Remote, no daemon answers on " + ipc.SocketPath(),
+			fix:     "Reconnect with the SSH socket forward (see 'clai init --help' for remote use)",
 		}
 	}
 
+	running := daemon.IsRunning()
+	if client, err := dialRunningDaemon(); err == nil {
+		defer client.Close()
+		if client.Ping() {
+			return checkResult{name: "Daemon", status: "ok", message: "Running"}
+		}
+	}
+	if running {
+		return checkResult{
+			name:    "Daemon",
+			status:  "error",
+			message: "Running but not answering on " + ipc.SocketPath(),
+			fix:     "Run 'clai daemon restart'; 'clai logs' shows why it stopped answering",
+		}
+	}
 	return checkResult{
 		name:    "Daemon",
 		status:  "warn",
 		message: "Not running. Will start automatically when needed.",
+		fix:     "Nothing to do, or run 'clai daemon start' to start it now",
+	}
+}
+
+// checkNameSocket is the label used for the socket permission check.
+const checkNameSocket = "Daemon socket"
+
+// checkSocketPermissions checks that the daemon socket is a socket and no
+// more open than daemon.socket_mode allows. It reports nothing while there
+// is no socket, and on Windows.
+func checkSocketPermissions() []checkResult {
+	if runtime.GOOS == "windows" || ipc.RemoteMode() {
+		return nil
+	}
+	socketPath := ipc.SocketPath()
+	info, err := os.Lstat(socketPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return []checkResult{{
+			name:    checkNameSocket,
+			status:  "error",
+			message: fmt.Sprintf("Cannot access %s: %v", socketPath, err),
+			fix:     fmt.Sprintf("Make sure you own %s", filepath.Dir(socketPath)),
+		}}
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return []checkResult{{
+			name:    checkNameSocket,
+			status:  "error",
+			message: fmt.Sprintf("%s is not a socket", socketPath),
+			fix:     fmt.Sprintf("Remove %s and run 'clai daemon start'", socketPath),
+		}}
+	}
+
+	allowed := os.FileMode(0o600)
+	if cfg, err := config.Load(); err == nil {
+		if mode, err := config.ParseSocketMode(cfg.Daemon.SocketMode); err == nil {
+			allowed = mode
+		}
 	}
+	if extra := info.Mode().Perm() &^ allowed; extra != 0 {
+		return []checkResult{{
+			name:    checkNameSocket,
+			status:  "warn",
+			message: fmt.Sprintf("%s has mode %04o, more open than daemon.socket_mode %04o", socketPath, info.Mode().Perm(), allowed),
+			fix:     "Run 'clai daemon restart' to recreate the socket with daemon.socket_mode",
+		}}
+	}
+	return []checkResult{{
+		name:    checkNameSocket,
+		status:  "ok",
+		message: fmt.Sprintf("%s (mode %04o)", socketPath, info.Mode().Perm()),
+	}}
+}
+
+// checkDatabases runs SQLite's quick_check on the history and suggestions
+// databases and reports their sizes. Databases not created yet are left
+// out.
+func checkDatabases() []checkResult {
+	paths := config.DefaultPaths()
+	dbs := []struct{ name, path string }{
+		{"History database", paths.DatabaseFile()},
+		{"Suggestions database", paths.SuggestionsDBFile()},
+	}
+
+	var results []checkResult
+	var key []byte
+	var keyErr error
+	keyLoaded := false
+	for _, db := range dbs {
+		if _, err := os.Stat(db.path); err != nil {
+			continue
+		}
+		if !keyLoaded {
+			key, keyErr = databaseKey()
+			keyLoaded = true
+		}
+		if keyErr != nil {
+			results = append(results, checkResult{
+				name:    db.name,
+				status:  "error",
+				message: keyErr.Error(),
+				fix:     "Check privacy.db_encryption and the key file or keychain entry it uses",
+			})
+			continue
+		}
+		results = append(results, checkDatabase(db.name, db.path, key))
+	}
+	return results
+}
+
+// checkDatabase checks the database at path, opened read-only.
+func checkDatabase(name, path string, key []byte) checkResult {
+	size := databaseSize(path)
+	db, err := dbcrypt.Open(path, "mode=ro&_pragma=busy_timeout(5000)", key)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err = dbrepair.QuickCheck(ctx, db)
+		db.Close()
+	}
+	switch {
+	case err == nil:
+		return checkResult{name: name, status: "ok", message: fmt.Sprintf("%s (%s)", path, formatSize(size))}
+	case errors.Is(err, dbcrypt.ErrEncrypted) || errors.Is(err, dbcrypt.ErrWrongKey):
+		return checkResult{
+			name:    name,
+			status:  "error",
+			message: fmt.Sprintf("Cannot open %s: %v", path, err),
+			fix:     "Set privacy.db_encryption to the key source the database was created with",
+		}
+	case dbrepair.IsCorruption(err):
+		return checkResult{
+			name:    name,
+			status:  "error",
+			message: fmt.Sprintf("%s is damaged: %v", path, err),
+			fix:     "Run 'clai daemon restart'; the daemon moves a damaged database aside and salvages what it can",
+		}
+	default:
+		return checkResult{
+			name:    name,
+			status:  "error",
+			message: fmt.Sprintf("Cannot read %s: %v", path, err),
+			fix:     fmt.Sprintf("Make sure you own %s and can read it", path),
+		}
+	}
+}
+
+// databaseSize returns the size of the database at path with its WAL.
+func databaseSize(path string) int64 {
+	var size int64
+	for _, f := range []string{path, path + "-wal"} {
+		if info, err := os.Stat(f); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// checkNameDaemonInstances is the label used for the multiple-daemon check.
+const checkNameDaemonInstances = "Daemon instances"
+
+// checkDaemonInstances warns when more than one daemon serves the current
+// data directory, or when claid processes run that no data directory
+// records. It reports nothing in the normal single-daemon case.
+func checkDaemonInstances() []checkResult {
+	var results []checkResult
+	paths := config.DefaultPaths()
+
+	serving := daemon.RecordedPIDs(paths)
+	if pid := socketDaemonPID(); pid > 0 && !slices.Contains(serving, pid) {
+		serving = append(serving, pid)
+	}
+	if len(serving) > 1 {
+		results = append(results, checkResult{
+			name:   checkNameDaemonInstances,
+			status: "warn",
+			message: fmt.Sprintf("%d daemons serve %s (PIDs %s). The one that lost the socket hands over its sessions and exits within a minute; if it does not, stop it with 'kill <pid>'.",
+				len(serving), paths.BaseDir, joinPIDs(serving)),
+		})
+	}
+
+	known := serving
+	for _, p := range config.AllProfilePaths() {
+		known = append(known, daemon.RecordedPIDs(p)...)
+	}
+	var stray []int
+	for _, pid := range runningClaidPIDs() {
+		if !slices.Contains(known, pid) {
+			stray = append(stray, pid)
+		}
+	}
+	if len(stray) > 0 {
+		results = append(results, checkResult{
+			name:   checkNameDaemonInstances,
+			status: "warn",
+			message: fmt.Sprintf("claid PIDs %s are not registered in any data directory. A daemon that lost its lock exits on its own; stop leftovers with 'kill <pid>'.",
+				joinPIDs(stray)),
+		})
+	}
+	return results
+}
+
+// socketDaemonPID returns the PID of the daemon answering on the socket,
+// or 0 if none does.
+func socketDaemonPID() int {
+	client, err := dialRunningDaemon()
+	if err != nil {
+		return 0
+	}
+	defer client.Close()
+	resp, err := client.GetStatus()
+	if err != nil {
+		return 0
+	}
+	return int(resp.Pid)
+}
+
+// runningClaidPIDs lists the current user's claid processes using ps.
+// Errors yield an empty list.
+func runningClaidPIDs() []int {
+	out, err := exec.Command("ps", "-U", strconv.Itoa(os.Getuid()), "-o", "pid=,comm=").Output() //nolint:gosec // G204: fixed command, numeric uid
+
+	if err != nil {
+		return nil
+	}
+	return parseClaidPIDs(string(out))
+}
+
+// parseClaidPIDs extracts the PIDs of claid processes from "pid comm" lines.
+func parseClaidPIDs(out string) []int {
+	var pids []int
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || filepath.Base(strings.Join(fields[1:], " ")) != ipc.DaemonBinaryName {
+			continue
+		}
+		if pid, err := strconv.Atoi(fields[0]); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+func joinPIDs(pids []int) string {
+	parts := make([]string, len(pids))
+	for i, pid := range pids {
+		parts[i] = strconv.Itoa(pid)
+	}
+	return strings.Join(parts, ", ")
 }
 
 func checkAIProviders() []checkResult {
 	var results []checkResult
 
-	// Check Claude CLI (only supported AI provider)
+	// Check Claude CLI (only supported AI provider). It is only required
+	// while AI features are on.
 	claudePath, err := exec.LookPath("claude")
 	if err != nil {
+		status := "error"
+		if cfg, cfgErr := config.Load(); cfgErr == nil && !cfg.AI.Enabled {
+			status = "warn"
+		}
 		results = append(results, checkResult{
 			name:    "Claude CLI",
-			status:  "error",
-			message: "Not found. Install from https://claude.ai/cli",
+			status:  status,
+			message: "Not found. AI features (clai cmd, clai ask, error diagnosis) need it.",
+			fix:     "Install it from https://claude.ai/cli and run 'claude login'",
 		})
 	} else {
 		results = append(results, checkResult{
@@ -2,12 +2,16 @@ package cmd
 
 import (
 	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/dbcrypt"
 )
 
 func TestCheckBinary(t *testing.T) {
@@ -181,6 +185,133 @@ func TestCheckDaemon(t *testing.T) {
 	}
 }
 
+func TestCheckDaemon_NotRunning(t *testing.T) {
+	t.Setenv("CLAI_HOME", t.TempDir())
+	t.Setenv("CLAI_SOCKET", filepath.Join(t.TempDir(), "missing.sock"))
+
+	result := checkDaemon()
+	if result.status != "warn" || result.fix == "" {
+		t.Errorf("checkDaemon() = %+v, want a warning with a fix", result)
+	}
+}
+
+func TestCheckSocketPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix sockets")
+	}
+	t.Setenv("CLAI_HOME", t.TempDir())
+	// Socket paths are limited to about 100 bytes; t.TempDir can be longer.
+	dir, err := os.MkdirTemp("", "clai-doctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "d.sock")
+	t.Setenv("CLAI_SOCKET", socketPath)
+
+	if results := checkSocketPermissions(); len(results) != 0 {
+		t.Errorf("checkSocketPermissions() without a socket = %+v, want nothing", results)
+	}
+
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for _, tt := range []struct {
+		mode os.FileMode
+		want string
+	}{
+		{0o600, "ok"},
+		{0o666, "warn"},
+	} {
+		if err := os.Chmod(socketPath, tt.mode); err != nil {
+			t.Fatal(err)
+		}
+		results := checkSocketPermissions()
+		if len(results) != 1 || results[0].status != tt.want {
+			t.Errorf("checkSocketPermissions() with mode %04o = %+v, want %s", tt.mode, results, tt.want)
+		}
+	}
+
+	l.Close()
+	if err := os.WriteFile(socketPath, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if results := checkSocketPermissions(); len(results) != 1 || results[0].status != "error" {
+		t.Errorf("checkSocketPermissions() with a regular file = %+v, want an error", results)
+	}
+}
+
+func TestCheckDatabase(t *testing.T) {
+	dir := t.TempDir()
+	healthy := filepath.Join(dir, "healthy.db")
+	db, err := dbcrypt.Open(healthy, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE t (x INTEGER); INSERT INTO t VALUES (1)`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if r := checkDatabase("History database", healthy, nil); r.status != "ok" || !strings.Contains(r.message, healthy) {
+		t.Errorf("checkDatabase(healthy) = %+v, want ok with the path", r)
+	}
+
+	// Keep the header, so the file is still SQLite, and overwrite the pages
+	// after the first.
+	damaged := filepath.Join(dir, "damaged.db")
+	db, err = dbcrypt.Open(damaged, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE t (x TEXT); CREATE INDEX t_x ON t(x)`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		if _, err := db.Exec(`INSERT INTO t VALUES (?)`, strings.Repeat("row", 20)+strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+	f, err := os.OpenFile(damaged, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte(strings.Repeat("garbage!", 1024)), 4096); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if r := checkDatabase("History database", damaged, nil); r.status != "error" || !strings.Contains(r.fix, "clai daemon restart") {
+		t.Errorf("checkDatabase(damaged) = %+v, want an error with a restart fix", r)
+	}
+}
+
+func TestCheckDatabases_NoneYet(t *testing.T) {
+	t.Setenv("CLAI_HOME", t.TempDir())
+	if results := checkDatabases(); len(results) != 0 {
+		t.Errorf("checkDatabases() = %+v, want nothing before any database exists", results)
+	}
+}
+
+func TestParseClaidPIDs(t *testing.T) {
+	out := "  101 zsh\n  202 claid\n  303 /usr/local/bin/claid\n  404 claid-helper\nbogus claid\n"
+	got := parseClaidPIDs(out)
+	if len(got) != 2 || got[0] != 202 || got[1] != 303 {
+		t.Errorf("parseClaidPIDs() = %v, want [202 303]", got)
+	}
+}
+
+func TestCheckDaemonInstances_NoDaemon(t *testing.T) {
+	t.Setenv("CLAI_HOME", t.TempDir())
+	for _, r := range checkDaemonInstances() {
+		if strings.Contains(r.message, "daemons serve") {
+			t.Errorf("reported multiple daemons for an empty data directory: %s", r.message)
+		}
+	}
+}
+
 func TestCheckAIProviders(t *testing.T) {
 	results := checkAIProviders()
 
@@ -196,9 +327,9 @@ func TestCheckAIProviders(t *testing.T) {
 			t.Errorf("checkAIProviders()[0].name = %q, want %q", r.name, "Claude CLI")
 		}
 
-		// Status should be ok or error
-		if r.status != "ok" && r.status != "error" {
-			t.Errorf("checkAIProviders()[0].status = %q, want ok or error", r.status)
+		// Status should be ok, or warn or error depending on ai.enabled
+		if r.status != "ok" && r.status != "warn" && r.status != "error" {
+			t.Errorf("checkAIProviders()[0].status = %q, want ok, warn or error", r.status)
 		}
 
 		// Message should be set
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/provider"
+	"github.com/runger/clai/internal/sanitize"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/normalize"
+	"github.com/runger/clai/internal/suggestions/usage"
+)
+
+// explainPartWidth caps the part column of 'clai explain'; longer parts
+// push their meaning to the right.
+const explainPartWidth = 24
+
+var (
+	explainNoStats bool
+	explainFormat  string
+)
+
+var explainCmd = &cobra.Command{
+	Use:     "explain <command...>",
+	Short:   "Explain what a shell command does, flag by flag",
+	GroupID: groupCore,
+	Long: `Ask the AI provider what a command does: a short summary, then each
+program, subcommand, flag and argument in order. The command is not run.
+
+Secrets are redacted from the command before it is sent, the same way
+they are before commands are stored: known token formats, values of
+flags such as --password, high-entropy strings and the expressions in
+suggestions.redact_patterns. Commands matching a risk pattern are flagged.
+
+Unless --no-stats is given, the explanation ends with how often you ran
+commands of the same shape (the command with paths, numbers and URLs
+replaced) and how often they succeeded, from the suggestions database.
+
+Flags of clai explain go before the command; everything from the first
+word of the command on is part of it. Quote commands with pipes or
+redirections so the shell does not act on them.
+
+Examples:
+  clai explain tar -xzf backup.tgz -C /tmp
+  clai explain 'find . -name "*.log" -mtime +7 -delete'
+  clai explain --format json git rebase -i --autosquash main`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runExplain,
+}
+
+func init() {
+	explainCmd.Flags().BoolVar(&explainNoStats, "no-stats", false, "Do not add your usage statistics for the command")
+	explainCmd.Flags().StringVar(&explainFormat, "format", "text", "Output format: text or json")
+	addJSONFlag(explainCmd, &explainFormat)
+	// The command's own flags are not clai explain's.
+	explainCmd.Flags().SetInterspersed(false)
+}
+
+// explainReport is what 'clai explain' prints.
+type explainReport struct {
+	Usage       *usage.TemplateUsage `json:"usage,omitempty"`
+	Command     string               `json:"command"` // As sent, secrets redacted
+	Summary     string               `json:"summary"`
+	Risk        string               `json:"risk"`
+	RiskMessage string               `json:"risk_message,omitempty"`
+	Provider    string               `json:"provider"`
+	Parts       []explainPart        `json:"parts"`
+}
+
+// explainPart is one explained part of the command.
+type explainPart struct {
+	Text    string `json:"text"`
+	Meaning string `json:"meaning"`
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(strings.TrimSpace(explainFormat))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format: %s (use text or json)", explainFormat)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	redactor, err := sanitize.NewCommandSanitizer(cfg.Suggestions.RedactPatterns)
+	if err != nil {
+		return err
+	}
+	command := redactor.Sanitize(strings.Join(args, " "))
+
+	var stats *usage.TemplateUsage
+	if !explainNoStats {
+		stats, err = loadTemplateUsage(cmd.Context(), command)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not read usage statistics: %v\n", err)
+		}
+	}
+
+	prov, err := provider.GetDefaultProvider()
+	if err != nil {
+		return err
+	}
+
+	// Set up context with Ctrl+C handling
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer cancel()
+
+	report, err := explainCommand(ctx, prov, command, stats)
+	if err != nil {
+		if err.Error() == "interrupted" {
+			fmt.Printf("\n%sCancelled%s\n", colorDim, colorReset)
+			return nil
+		}
+		return err
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	writeExplainText(os.Stdout, report)
+	return nil
+}
+
+// commandExplainer is the part of an AI provider explainCommand uses.
+type commandExplainer interface {
+	Name() string
+	Explain(ctx context.Context, req *provider.ExplainRequest) (*provider.ExplainResponse, error)
+}
+
+// explainCommand asks p to explain the already redacted command and builds
+// the report, with stats if not nil.
+func explainCommand(ctx context.Context, p commandExplainer, command string, stats *usage.TemplateUsage) (*explainReport, error) {
+	pwd, _ := os.Getwd()
+	resp, err := p.Explain(ctx, &provider.ExplainRequest{
+		Command: command,
+		CWD:     pwd,
+		OS:      runtime.GOOS,
+		Shell:   cmp.Or(DetectShell().Shell, "bash"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Summary == "" && len(resp.Parts) == 0 {
+		return nil, fmt.Errorf("%s returned no explanation", p.Name())
+	}
+
+	risk, message := sanitize.Classify(command)
+	report := &explainReport{
+		Command:     command,
+		Summary:     resp.Summary,
+		Parts:       make([]explainPart, 0, len(resp.Parts)),
+		Risk:        string(risk),
+		RiskMessage: message,
+		Usage:       stats,
+		Provider:    p.Name(),
+	}
+	for _, part := range resp.Parts {
+		report.Parts = append(report.Parts, explainPart{Text: part.Text, Meaning: part.Meaning})
+	}
+	return report, nil
+}
+
+// loadTemplateUsage looks up the usage of command's template in the
+// suggestions database. It returns nil without a database.
+func loadTemplateUsage(ctx context.Context, command string) (*usage.TemplateUsage, error) {
+	dbPath, err := suggestdb.DefaultDBPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, statErr := os.Stat(dbPath); os.IsNotExist(statErr) {
+		return nil, nil
+	}
+	key, err := databaseKey()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	sdb, err := suggestdb.Open(ctx, suggestdb.Options{Path: dbPath, ReadOnly: true, SkipLock: true, EncryptionKey: key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open suggestions database: %w", err)
+	}
+	defer sdb.Close()
+	return templateUsage(ctx, sdb.DB(), command)
+}
+
+// templateUsage returns the usage of the template command is recorded
+// under.
+func templateUsage(ctx context.Context, db *sql.DB, command string) (*usage.TemplateUsage, error) {
+	preNorm := normalize.PreNormalize(command, normalize.PreNormConfig{})
+	if preNorm.TemplateID == "" {
+		return nil, errors.New("the command has no template")
+	}
+	return usage.ForTemplate(ctx, db, preNorm.TemplateID)
+}
+
+// writeExplainText writes the report: the command, the summary, a table of
+// parts, the risk warning and the usage line.
+func writeExplainText(w io.Writer, r *explainReport) {
+	fmt.Fprintf(w, "%s%s%s\n", colorBold, r.Command, colorReset)
+	if r.Summary != "" {
+		fmt.Fprintf(w, "%s\n", r.Summary)
+	}
+
+	if len(r.Parts) > 0 {
+		width := 0
+		for _, part := range r.Parts {
+			width = max(width, min(utf8.RuneCountInString(part.Text), explainPartWidth))
+		}
+		fmt.Fprintln(w)
+		for _, part := range r.Parts {
+			fmt.Fprintf(w, "  %s%-*s%s  %s\n", colorCyan, width, part.Text, colorReset, part.Meaning)
+		}
+	}
+
+	if r.Risk == string(sanitize.RiskDestructive) {
+		fmt.Fprintf(w, "\n%sWarning: this command may be destructive", colorYellow)
+		if r.RiskMessage != "" {
+			fmt.Fprintf(w, ": %s", r.RiskMessage)
+		}
+		fmt.Fprintf(w, "%s\n", colorReset)
+	}
+
+	if u := r.Usage; u != nil {
+		fmt.Fprintf(w, "\n%s%s%s\n", colorDim, templateUsageLine(u), colorReset)
+	}
+}
+
+// templateUsageLine describes u in a sentence.
+func templateUsageLine(u *usage.TemplateUsage) string {
+	if u.Runs == 0 {
+		return "You have not run this command before."
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "You ran this command %s on %s", plural(u.Runs, "time"), plural(u.Days, "day"))
+	if u.FirstDay == u.LastDay {
+		fmt.Fprintf(&sb, " (%s)", u.LastDay)
+	} else {
+		fmt.Fprintf(&sb, " (%s to %s)", u.FirstDay, u.LastDay)
+	}
+	if u.Successes+u.Failures > 0 {
+		fmt.Fprintf(&sb, "; %.0f%% succeeded", u.SuccessRate()*100)
+	}
+	sb.WriteString(".")
+	return sb.String()
+}
+
+// plural formats n with noun, adding an s unless n is 1.
+func plural(n int64, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runger/clai/internal/provider"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/normalize"
+	"github.com/runger/clai/internal/suggestions/usage"
+)
+
+type fakeCommandExplainer struct {
+	req  *provider.ExplainRequest
+	resp *provider.ExplainResponse
+}
+
+func (f *fakeCommandExplainer) Name() string { return "fake" }
+
+func (f *fakeCommandExplainer) Explain(_ context.Context, req *provider.ExplainRequest) (*provider.ExplainResponse, error) {
+	f.req = req
+	return f.resp, nil
+}
+
+func TestExplainCommand(t *testing.T) {
+	t.Parallel()
+
+	p := &fakeCommandExplainer{resp: &provider.ExplainResponse{
+		Summary: "Deletes the build directory.",
+		Parts: []provider.ExplainedPart{
+			{Text: "rm", Meaning: "removes files"},
+			{Text: "-rf", Meaning: "recursively, without asking"},
+			{Text: "build/", Meaning: "the directory to remove"},
+		},
+	}}
+	stats := &usage.TemplateUsage{Runs: 3, Days: 2, FirstDay: "2026-03-01", LastDay: "2026-03-04", Successes: 3}
+
+	report, err := explainCommand(context.Background(), p, "rm -rf build/", stats)
+	if err != nil {
+		t.Fatalf("explainCommand() error = %v", err)
+	}
+	if p.req.Command != "rm -rf build/" || p.req.OS == "" || p.req.Shell == "" {
+		t.Errorf("request = %+v, want the command with OS and shell", p.req)
+	}
+	if report.Risk != "destructive" || report.Provider != "fake" || len(report.Parts) != 3 || report.Usage != stats {
+		t.Errorf("report = %+v", report)
+	}
+
+	var out bytes.Buffer
+	writeExplainText(&out, report)
+	for _, want := range []string{
+		"rm -rf build/", "Deletes the build directory.",
+		"rm      ", "removes files", "build/  ", "the directory to remove",
+		"may be destructive",
+		"You ran this command 3 times on 2 days (2026-03-01 to 2026-03-04); 100% succeeded.",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	p.resp = &provider.ExplainResponse{}
+	if _, err := explainCommand(context.Background(), p, "ls", nil); err == nil {
+		t.Error("empty explanation: explainCommand() = nil error")
+	}
+}
+
+func TestTemplateUsageLine(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		u    usage.TemplateUsage
+		want string
+	}{
+		{usage.TemplateUsage{}, "You have not run this command before."},
+		{usage.TemplateUsage{Runs: 1, Days: 1, FirstDay: "2026-03-01", LastDay: "2026-03-01"}, "You ran this command 1 time on 1 day (2026-03-01)."},
+		{usage.TemplateUsage{Runs: 4, Days: 1, FirstDay: "2026-03-01", LastDay: "2026-03-01", Successes: 1, Failures: 3}, "You ran this command 4 times on 1 day (2026-03-01); 25% succeeded."},
+	} {
+		if got := templateUsageLine(&tt.u); got != tt.want {
+			t.Errorf("templateUsageLine(%+v) = %q, want %q", tt.u, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateUsage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sdb, err := suggestdb.Open(ctx, suggestdb.Options{Path: filepath.Join(t.TempDir(), "suggestions.db"), SkipLock: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sdb.Close()
+
+	// Recorded under the template of another command of the same shape.
+	templateID := normalize.PreNormalize("tar -xzf backup.tgz -C /srv", normalize.PreNormConfig{}).TemplateID
+	if err := usage.Record(ctx, sdb.DB(), usage.Event{TSMs: 1_700_000_000_000, TemplateID: templateID}); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := templateUsage(ctx, sdb.DB(), "tar -xzf backup.tgz -C /tmp")
+	if err != nil {
+		t.Fatalf("templateUsage() error = %v", err)
+	}
+	if u.TemplateID != templateID || u.Runs != 1 {
+		t.Errorf("usage = %+v, want one run of %s", u, templateID)
+	}
+}
+
+func TestExplainCmd_CommandFlagsAreArgs(t *testing.T) {
+	oldFormat := explainFormat
+	t.Cleanup(func() { explainFormat = oldFormat })
+
+	flags := explainCmd.Flags()
+	if err := flags.Parse([]string{"--format", "json", "tar", "-xzf", "backup.tgz", "--no-stats"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := strings.Join(flags.Args(), " "); got != "tar -xzf backup.tgz --no-stats" {
+		t.Errorf("args = %q, want the whole command", got)
+	}
+	if explainNoStats {
+		t.Error("--no-stats of the command was taken by clai explain")
+	}
+}
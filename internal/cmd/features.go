@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/config"
+)
+
+var featuresJSON bool
+
+var featuresCmd = &cobra.Command{
+	Use:     "features",
+	Short:   "Show which optional features are enabled",
+	GroupID: groupSetup,
+	Long: `List clai's optional subsystems, whether each is enabled and the
+effective values of the settings that control it, after the config file
+and environment overrides are applied.
+
+Use this to find out why clai behaves differently on two machines.
+Suggestion features are only active while suggestions.enabled is true.
+Whether the full-text index is actually available is reported by
+'clai-shim status --verbose'.
+
+Examples:
+  clai features
+  clai features --json`,
+	Args: cobra.NoArgs,
+	RunE: runFeatures,
+}
+
+func init() {
+	featuresCmd.Flags().BoolVar(&featuresJSON, "json", false, "Print features as JSON")
+}
+
+// featureSetting is one effective config value behind a feature.
+type featureSetting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// featureInfo describes an optional subsystem and its effective state.
+type featureInfo struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Key         string           `json:"key"`
+	Settings    []featureSetting `json:"settings"`
+	Enabled     bool             `json:"enabled"`
+	// Inactive is set when the feature is enabled but a parent switch
+	// (suggestions.enabled) turns it off.
+	Inactive bool `json:"inactive,omitempty"`
+}
+
+func runFeatures(_ *cobra.Command, _ []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	features := listFeatures(cfg)
+	if featuresJSON {
+		data, err := json.Marshal(features)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	renderFeatures(os.Stdout, features)
+	return nil
+}
+
+func setting(key string, value any) featureSetting {
+	return featureSetting{Key: key, Value: fmt.Sprint(value)}
+}
+
+// listFeatures reports the optional subsystems in cfg.
+func listFeatures(cfg *config.Config) []featureInfo {
+	s := cfg.Suggestions
+	features := []featureInfo{
+		{
+			Name:        "fts",
+			Description: "Full-text index over command history for search and ?describe lookups",
+			Key:         "suggestions.search_fts_enabled",
+			Enabled:     s.SearchFTSEnabled,
+			Settings: []featureSetting{
+				setting("suggestions.search_fts_tokenizer", s.SearchFTSTokenizer),
+				setting("suggestions.search_fallback_scan_limit", s.SearchFallbackScanLimit),
+				setting("suggestions.search_describe_enabled", s.SearchDescribeEnabled),
+			},
+		},
+		{
+			Name:        "workflows",
+			Description: "Detects recurring command sequences and suggests the next step",
+			Key:         "suggestions.workflow_detection_enabled",
+			Enabled:     s.WorkflowDetectionEnabled,
+			Settings: []featureSetting{
+				setting("suggestions.workflow_min_steps", s.WorkflowMinSteps),
+				setting("suggestions.workflow_max_steps", s.WorkflowMaxSteps),
+				setting("suggestions.workflow_min_occurrences", s.WorkflowMinOccurrences),
+				setting("suggestions.workflow_boost", s.WorkflowBoost),
+			},
+		},
+		{
+			Name:        "learning",
+			Description: "Adjusts ranking weights from accepted and dismissed suggestions",
+			Key:         "suggestions.online_learning_enabled",
+			Enabled:     s.OnlineLearningEnabled,
+			Settings: []featureSetting{
+				setting("suggestions.online_learning_eta", s.OnlineLearningEta),
+				setting("suggestions.online_learning_min_samples", s.OnlineLearningMinSamples),
+				setting("suggestions.decay_half_life_hours", s.DecayHalfLifeHours),
+			},
+		},
+		{
+			Name:        "pipeline-awareness",
+			Description: "Learns multi-command pipelines and suggests the next segment after |",
+			Key:         "suggestions.pipeline_awareness_enabled",
+			Enabled:     s.PipelineAwarenessEnabled,
+			Settings: []featureSetting{
+				setting("suggestions.pipeline_max_segments", s.PipelineMaxSegments),
+				setting("suggestions.pipeline_pattern_min_count", s.PipelinePatternMinCount),
+			},
+		},
+		{
+			Name:        "discovery",
+			Description: "Fills new or empty sessions with playbook tasks and common project and tool commands",
+			Key:         "suggestions.discovery_enabled",
+			Enabled:     s.DiscoveryEnabled,
+			Settings: []featureSetting{
+				setting("suggestions.discovery_source_playbook", s.DiscoverySourcePlaybook),
+				setting("suggestions.discovery_source_tool_common", s.DiscoverySourceToolCommon),
+				setting("suggestions.discovery_source_project_type", s.DiscoverySourceProjectType),
+				setting("suggestions.discovery_cooldown_hours", s.DiscoveryCooldownHours),
+			},
+		},
+	}
+	if !s.Enabled {
+		for i := range features {
+			features[i].Inactive = features[i].Enabled
+		}
+	}
+
+	return append(features, featureInfo{
+		Name:        "workflow-runner",
+		Description: "Runs workflow files with 'clai workflow run'",
+		Key:         "workflows.enabled",
+		Enabled:     cfg.Workflows.Enabled,
+		Settings: []featureSetting{
+			setting("workflows.default_mode", cfg.Workflows.DefaultMode),
+			setting("workflows.retain_runs", cfg.Workflows.RetainRuns),
+		},
+	})
+}
+
+// renderFeatures prints each feature with its state and settings.
+func renderFeatures(w io.Writer, features []featureInfo) {
+	fmt.Fprintf(w, "%sclai features%s\n", colorBold, colorReset)
+	for _, f := range features {
+		var state string
+		switch {
+		case f.Inactive:
+			state = colorYellow + "[OFF]" + colorReset
+		case f.Enabled:
+			state = colorGreen + "[ON]" + colorReset
+		default:
+			state = colorDim + "[OFF]" + colorReset
+		}
+
+		fmt.Fprintf(w, "\n  %s %s%s%s\n", state, colorBold, f.Name, colorReset)
+		fmt.Fprintf(w, "      %s\n", f.Description)
+		if f.Inactive {
+			fmt.Fprintf(w, "      %s = true, but suggestions.enabled = false\n", f.Key)
+		} else {
+			fmt.Fprintf(w, "      %s = %t\n", f.Key, f.Enabled)
+		}
+		for _, st := range f.Settings {
+			fmt.Fprintf(w, "      %s%s = %s%s\n", colorDim, st.Key, st.Value, colorReset)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/runger/clai/internal/config"
+)
+
+func featureByName(features []featureInfo, name string) *featureInfo {
+	for i := range features {
+		if features[i].Name == name {
+			return &features[i]
+		}
+	}
+	return nil
+}
+
+func TestListFeatures_Defaults(t *testing.T) {
+	features := listFeatures(config.DefaultConfig())
+
+	for _, name := range []string{"fts", "workflows", "learning", "pipeline-awareness", "discovery", "workflow-runner"} {
+		f := featureByName(features, name)
+		if f == nil {
+			t.Errorf("feature %q missing", name)
+			continue
+		}
+		if f.Description == "" || f.Key == "" || len(f.Settings) == 0 {
+			t.Errorf("feature %q incomplete: %+v", name, f)
+		}
+		if f.Inactive {
+			t.Errorf("feature %q inactive with suggestions enabled", name)
+		}
+	}
+
+	fts := featureByName(features, "fts")
+	if !fts.Enabled || fts.Settings[0] != (featureSetting{Key: "suggestions.search_fts_tokenizer", Value: "trigram"}) {
+		t.Errorf("fts = %+v, want enabled with the trigram tokenizer", fts)
+	}
+	if featureByName(features, "workflow-runner").Enabled {
+		t.Error("workflow-runner enabled by default")
+	}
+}
+
+func TestListFeatures_SuggestionsDisabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.Enabled = false
+	cfg.Suggestions.DiscoveryEnabled = false
+	cfg.Workflows.Enabled = true
+	features := listFeatures(cfg)
+
+	if f := featureByName(features, "learning"); !f.Enabled || !f.Inactive {
+		t.Errorf("learning = %+v, want enabled but inactive", f)
+	}
+	if f := featureByName(features, "discovery"); f.Enabled || f.Inactive {
+		t.Errorf("discovery = %+v, want disabled and not inactive", f)
+	}
+	if f := featureByName(features, "workflow-runner"); !f.Enabled || f.Inactive {
+		t.Errorf("workflow-runner = %+v, want active regardless of suggestions.enabled", f)
+	}
+
+	var buf bytes.Buffer
+	renderFeatures(&buf, features)
+	if !strings.Contains(buf.String(), "suggestions.online_learning_enabled = true, but suggestions.enabled = false") {
+		t.Errorf("render does not explain the inactive feature:\n%s", buf.String())
+	}
+}
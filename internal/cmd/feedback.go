@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/ipc"
+)
+
+var feedbackLast bool
+
+var feedbackCmd = &cobra.Command{
+	Use:     "feedback up|down",
+	Short:   "Rate the suggestion shown before your last command",
+	GroupID: groupCore,
+	Long: `Give a thumbs up or down to the suggestion shown at the prompt your last
+command ran from, so suggestions learn from you without the picker.
+
+The rated suggestion is the one your last command matches, even if you
+typed it by hand, otherwise the top suggestion. With --last the command
+you ran is rated instead, as a suggestion for that prompt: up to have it
+suggested there, down to have it suggested less.
+
+Examples:
+  clai feedback up
+  clai feedback down
+  clai feedback up --last`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"up", "down"},
+	RunE:      runFeedback,
+}
+
+func init() {
+	feedbackCmd.Flags().BoolVar(&feedbackLast, "last", false, "Rate the last command you ran instead of the suggestion")
+}
+
+// snapshotFeedbackSender is the part of the daemon client the feedback
+// command uses.
+type snapshotFeedbackSender interface {
+	SnapshotFeedback(ctx context.Context, sessionID string, helpful, lastCommand bool) (*pb.SnapshotFeedbackResponse, error)
+}
+
+func runFeedback(cmd *cobra.Command, args []string) error {
+	if args[0] != "up" && args[0] != "down" {
+		return fmt.Errorf("invalid rating: %q (use up or down)", args[0])
+	}
+	sessionID := os.Getenv("CLAI_SESSION_ID")
+	if sessionID == "" {
+		return errors.New("no active clai session (CLAI_SESSION_ID is not set)")
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		return fmt.Errorf("daemon not available: %w", err)
+	}
+	defer client.Close()
+
+	return sendFeedback(cmd.Context(), client, sessionID, args[0] == "up", feedbackLast, os.Stdout)
+}
+
+// sendFeedback records the rating and reports what it was recorded for.
+func sendFeedback(ctx context.Context, client snapshotFeedbackSender, sessionID string, helpful, lastCommand bool, out io.Writer) error {
+	resp, err := client.SnapshotFeedback(ctx, sessionID, helpful, lastCommand)
+	if err != nil {
+		return fmt.Errorf("failed to record feedback: %w", err)
+	}
+	if !resp.Ok {
+		return errors.New(resp.GetError().GetMessage())
+	}
+	rating := "down"
+	if helpful {
+		rating = "up"
+	}
+	fmt.Fprintf(out, "Thumbs %s recorded for: %s\n", rating, resp.SuggestedText)
+	return nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+type fakeFeedbackSender struct {
+	resp        *pb.SnapshotFeedbackResponse
+	helpful     bool
+	lastCommand bool
+}
+
+func (f *fakeFeedbackSender) SnapshotFeedback(_ context.Context, _ string, helpful, lastCommand bool) (*pb.SnapshotFeedbackResponse, error) {
+	f.helpful, f.lastCommand = helpful, lastCommand
+	return f.resp, nil
+}
+
+func TestRunFeedback_RequiresSession(t *testing.T) {
+	t.Setenv("CLAI_SESSION_ID", "")
+
+	err := runFeedback(feedbackCmd, []string{"up"})
+	if err == nil || !strings.Contains(err.Error(), "CLAI_SESSION_ID") {
+		t.Fatalf("runFeedback() error = %v, want missing session error", err)
+	}
+}
+
+func TestRunFeedback_InvalidRating(t *testing.T) {
+	t.Setenv("CLAI_SESSION_ID", "session-1")
+
+	err := runFeedback(feedbackCmd, []string{"sideways"})
+	if err == nil || !strings.Contains(err.Error(), "up or down") {
+		t.Fatalf("runFeedback() error = %v, want invalid rating error", err)
+	}
+}
+
+func TestSendFeedback(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeFeedbackSender{resp: &pb.SnapshotFeedbackResponse{Ok: true, SuggestedText: "make build", Action: "dismissed"}}
+	var out bytes.Buffer
+	if err := sendFeedback(context.Background(), client, "session-1", false, true, &out); err != nil {
+		t.Fatalf("sendFeedback() error = %v", err)
+	}
+	if client.helpful || !client.lastCommand {
+		t.Errorf("sent helpful=%v last=%v, want false, true", client.helpful, client.lastCommand)
+	}
+	if want := "Thumbs down recorded for: make build"; !strings.Contains(out.String(), want) {
+		t.Errorf("output %q does not contain %q", out.String(), want)
+	}
+
+	client.resp = &pb.SnapshotFeedbackResponse{Error: &pb.ApiError{Message: "no command has run in this session yet"}}
+	err := sendFeedback(context.Background(), client, "session-1", true, false, &out)
+	if err == nil || !strings.Contains(err.Error(), "no command has run") {
+		t.Errorf("sendFeedback() error = %v, want the daemon's message", err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/dbcrypt"
 	"github.com/runger/clai/internal/ipc"
 	"github.com/runger/clai/internal/storage"
 )
@@ -20,6 +21,7 @@ var (
 	historyLimit   int
 	historyOffset  int
 	historyCWD     string
+	historyHost    string
 	historySession string
 	historyGlobal  bool
 	historyStatus  string
@@ -44,6 +46,7 @@ Examples:
   clai history --limit=50         # Show last 50 commands
   clai history git                # Show commands starting with "git"
   clai history -c /tmp            # Show commands from /tmp directory
+  clai history -g --host=laptop   # Show commands run on host "laptop"
   clai history --session=abc123   # Show specific session (8+ char prefix)
   clai history -s success         # Show only successful commands
   clai history -s failure         # Show only failed commands
@@ -55,16 +58,27 @@ func init() {
 	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Maximum number of commands to show")
 	historyCmd.Flags().IntVar(&historyOffset, "offset", 0, "Skip this many results (for pagination)")
 	historyCmd.Flags().StringVarP(&historyCWD, "cwd", "c", "", "Filter by working directory")
+	historyCmd.Flags().StringVar(&historyHost, "host", "", "Filter by the host the command ran on")
 	historyCmd.Flags().StringVar(&historySession, "session", "", "Filter by specific session ID")
+	_ = historyCmd.RegisterFlagCompletionFunc("session", completeSessionIDs)
 	historyCmd.Flags().BoolVarP(&historyGlobal, "global", "g", false, "Show history across all sessions")
 	historyCmd.Flags().StringVarP(&historyStatus, "status", "s", "", "Filter by status: 'success' or 'failure'")
 	historyCmd.Flags().StringVar(&historyFormat, "format", "raw", "Output format: raw or json")
+	addJSONFlag(historyCmd, &historyFormat)
 }
 
 func runHistory(cmd *cobra.Command, args []string) error {
 	paths := config.DefaultPaths()
 
-	store, err := storage.NewSQLiteStore(paths.DatabaseFile())
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+
+	store, err := storage.NewSQLiteStoreWithKey(paths.DatabaseFile(), key)
+	if errors.Is(err, dbcrypt.ErrEncrypted) || errors.Is(err, dbcrypt.ErrWrongKey) {
+		return err
+	}
 	if err != nil {
 		fmt.Printf("No history available. Database not found at: %s\n", paths.DatabaseFile())
 		return nil
@@ -156,6 +170,7 @@ func buildHistoryQuery(args []string) (storage.CommandQuery, error) {
 	if historyCWD != "" {
 		query.CWD = &historyCWD
 	}
+	query.Host = historyHost
 
 	return query, nil
 }
@@ -233,8 +248,8 @@ var (
 )
 
 var historyImportCmd = &cobra.Command{
-	Use:   "import",
-	Short: "Import shell history from your shell's history file",
+	Use:   "import [file.jsonl]",
+	Short: "Import shell history, or a file from 'clai history export'",
 	Long: `Import command history from bash, zsh, or fish into the clai database.
 
 This allows clai's command suggestions and history search to include
@@ -243,10 +258,17 @@ commands you ran before installing clai.
 The import is idempotent: running it again will replace the previous
 import for that shell (not accumulate duplicates).
 
+With a file argument, imports a JSON Lines file written by
+'clai history export' instead, e.g. from another machine. Commands keep
+their session, directory, exit code, duration and branch, and commands
+already in the database are skipped. Use '-' to read from stdin.
+
 Examples:
   clai history import              # Auto-detect shell and import
   clai history import --shell=zsh  # Import zsh history
-  clai history import --force      # Force re-import even if already done`,
+  clai history import --force      # Force re-import even if already done
+  clai history import laptop.jsonl # Import another machine's export`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runHistoryImport,
 }
 
@@ -260,6 +282,10 @@ func init() {
 }
 
 func runHistoryImport(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		return runEventsImport(cmd.Context(), args[0])
+	}
+
 	client, err := ipc.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
@@ -271,7 +297,7 @@ func runHistoryImport(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("Importing %s history...\n", importShell)
 
-	resp, err := client.ImportHistory(ctx, importShell, importHistoryPath, !importForce, importForce)
+	resp, err := client.ImportHistory(ctx, os.Getenv("CLAI_SESSION_ID"), importShell, importHistoryPath, !importForce, importForce)
 	if err != nil {
 		return fmt.Errorf("import failed: %w", err)
 	}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/ipc"
+)
+
+var (
+	deletePurge  bool
+	deleteDryRun bool
+	deleteYes    bool
+)
+
+var historyDeleteCmd = &cobra.Command{
+	Use:   "delete <pattern>",
+	Short: "Delete commands from history, e.g. ones containing a secret",
+	Long: `Delete every recorded command that contains pattern (case-sensitive)
+from history and suggestions.
+
+By default the commands are hidden: they no longer appear in history,
+search, exports or suggestions, but stay in the databases and keep counting
+towards what clai has learned. With --purge they are erased instead, along
+with their search index entries, archived copies and everything learned
+from them. Use --purge for secrets; it also erases commands an earlier
+delete hid.
+
+The matching commands are listed and confirmed before anything is removed.
+
+Examples:
+  clai history delete 'API_TOKEN='          # Hide commands setting the token
+  clai history delete --purge hunter2       # Erase a leaked password everywhere
+  clai history delete --dry-run 'curl -u'   # Only list what would be removed`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistoryDelete,
+}
+
+func init() {
+	historyDeleteCmd.Flags().BoolVar(&deletePurge, "purge", false, "Erase the commands and everything learned from them instead of hiding them")
+	historyDeleteCmd.Flags().BoolVar(&deleteDryRun, "dry-run", false, "List the matching commands without removing them")
+	historyDeleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Do not ask for confirmation")
+	historyCmd.AddCommand(historyDeleteCmd)
+}
+
+// commandRemover is the part of the daemon client deleteCommands uses.
+type commandRemover interface {
+	DeleteCommand(ctx context.Context, pattern string, dryRun bool) (*pb.DeleteCommandResponse, error)
+	PurgeCommand(ctx context.Context, pattern string, dryRun bool) (*pb.DeleteCommandResponse, error)
+}
+
+// deleteOptions are the flags of 'clai history delete'.
+type deleteOptions struct {
+	purge  bool
+	dryRun bool
+	yes    bool
+}
+
+func runHistoryDelete(cmd *cobra.Command, args []string) error {
+	client, err := ipc.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	opts := deleteOptions{purge: deletePurge, dryRun: deleteDryRun, yes: deleteYes}
+	return deleteCommands(cmd.Context(), client, args[0], opts, os.Stdin, os.Stdout)
+}
+
+// deleteCommands lists the commands matching pattern, asks for confirmation
+// on in unless opts.yes is set, and removes them.
+func deleteCommands(ctx context.Context, client commandRemover, pattern string, opts deleteOptions, in io.Reader, out io.Writer) error {
+	remove := client.DeleteCommand
+	verb := "Deleted"
+	if opts.purge {
+		remove = client.PurgeCommand
+		verb = "Purged"
+	}
+
+	preview, err := remove(ctx, pattern, true)
+	if err != nil {
+		return fmt.Errorf("failed to match commands: %w", err)
+	}
+	if preview.Commands == 0 && preview.Events == 0 {
+		fmt.Fprintf(out, "No commands match %q.\n", pattern)
+		return nil
+	}
+
+	fmt.Fprintf(out, "%d history commands and %d suggestion events match %q:\n", preview.Commands, preview.Events, pattern)
+	for _, sample := range preview.Samples {
+		fmt.Fprintf(out, "  %s\n", sample)
+	}
+	if opts.dryRun {
+		return nil
+	}
+	if !opts.yes && !confirmDelete(in, out, opts.purge) {
+		fmt.Fprintln(out, "Nothing removed.")
+		return nil
+	}
+
+	resp, err := remove(ctx, pattern, false)
+	if err != nil {
+		return fmt.Errorf("failed to remove commands: %w", err)
+	}
+	fmt.Fprintf(out, "%s %d history commands and %d suggestion events.\n", verb, resp.Commands, resp.Events)
+	return nil
+}
+
+// confirmDelete asks whether to remove the listed commands.
+func confirmDelete(in io.Reader, out io.Writer, purge bool) bool {
+	if purge {
+		fmt.Fprintf(out, "%sThis permanently erases these commands.%s Continue? [y/N] ", colorYellow, colorReset)
+	} else {
+		fmt.Fprint(out, "Delete these commands? [y/N] ")
+	}
+	response, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.TrimSpace(strings.ToLower(response)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+type fakeCommandRemover struct {
+	calls []string // "delete" or "purge", with " dry-run" for previews
+}
+
+func (f *fakeCommandRemover) respond(call string, dryRun bool) *pb.DeleteCommandResponse {
+	if dryRun {
+		call += " dry-run"
+	}
+	f.calls = append(f.calls, call)
+	return &pb.DeleteCommandResponse{Commands: 2, Events: 3, Samples: []string{"export TOKEN=hunter2"}}
+}
+
+func (f *fakeCommandRemover) DeleteCommand(_ context.Context, _ string, dryRun bool) (*pb.DeleteCommandResponse, error) {
+	return f.respond("delete", dryRun), nil
+}
+
+func (f *fakeCommandRemover) PurgeCommand(_ context.Context, _ string, dryRun bool) (*pb.DeleteCommandResponse, error) {
+	return f.respond("purge", dryRun), nil
+}
+
+func TestDeleteCommands(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		opts      deleteOptions
+		input     string
+		wantCalls []string
+		wantOut   string
+	}{
+		{"confirmed", deleteOptions{}, "y\n", []string{"delete dry-run", "delete"}, "Deleted 2 history commands and 3 suggestion events."},
+		{"declined", deleteOptions{}, "\n", []string{"delete dry-run"}, "Nothing removed."},
+		{"purge with yes", deleteOptions{purge: true, yes: true}, "", []string{"purge dry-run", "purge"}, "Purged 2"},
+		{"dry run", deleteOptions{purge: true, dryRun: true}, "", []string{"purge dry-run"}, "  export TOKEN=hunter2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			client := &fakeCommandRemover{}
+			var out bytes.Buffer
+			err := deleteCommands(context.Background(), client, "hunter2", tt.opts, strings.NewReader(tt.input), &out)
+			if err != nil {
+				t.Fatalf("deleteCommands() error = %v", err)
+			}
+			if strings.Join(client.calls, ",") != strings.Join(tt.wantCalls, ",") {
+				t.Errorf("calls = %v, want %v", client.calls, tt.wantCalls)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("output %q does not contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/storage"
+)
+
+// exportPageSize is how many commands are read from the store at a time.
+const exportPageSize = 1000
+
+var (
+	exportFormat string
+	exportSince  string
+	exportOutput string
+)
+
+var historyExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export command history as JSON Lines, JSON or CSV",
+	Long: `Export command history from the clai database, oldest first, with each
+command's working directory, exit code, duration and git branch.
+
+Commands are streamed from the database, so large histories export without
+being loaded into memory. The export contains raw commands, which can
+include secrets typed on the command line; store it accordingly.
+
+--since takes a duration (90m, 12h, 30d, 2w) or a date (2026-01-31).
+
+Examples:
+  clai history export > history.jsonl          # Everything, one JSON object per line
+  clai history export --since 30d --format csv # The last 30 days as CSV
+  clai history export --format json -o backup.json`,
+	Args: cobra.NoArgs,
+	RunE: runHistoryExport,
+}
+
+func init() {
+	historyExportCmd.Flags().StringVar(&exportFormat, "format", "jsonl", "Output format: jsonl, json or csv")
+	historyExportCmd.Flags().StringVar(&exportSince, "since", "", "Only export commands from this duration ago or date onwards")
+	historyExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write the export to a file instead of stdout")
+	historyCmd.AddCommand(historyExportCmd)
+}
+
+// exportRecord is one exported command.
+type exportRecord struct {
+	Time       string  `json:"time"`
+	Command    string  `json:"command"`
+	CWD        string  `json:"cwd"`
+	ExitCode   *int    `json:"exit_code"`
+	DurationMs *int64  `json:"duration_ms"`
+	Branch     *string `json:"branch"`
+	Repo       *string `json:"repo"`
+	SessionID  string  `json:"session_id"`
+	TSUnixMs   int64   `json:"ts_unix_ms"`
+}
+
+var exportCSVHeader = []string{"time", "ts_unix_ms", "command", "cwd", "exit_code", "duration_ms", "branch", "repo", "session_id"}
+
+func newExportRecord(cmd *storage.Command) exportRecord {
+	return exportRecord{
+		Time:       time.UnixMilli(cmd.TSStartUnixMs).UTC().Format(time.RFC3339),
+		TSUnixMs:   cmd.TSStartUnixMs,
+		Command:    cmd.Command,
+		CWD:        cmd.CWD,
+		ExitCode:   cmd.ExitCode,
+		DurationMs: cmd.DurationMs,
+		Branch:     cmd.GitBranch,
+		Repo:       cmd.GitRepoRoot,
+		SessionID:  cmd.SessionID,
+	}
+}
+
+func runHistoryExport(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(strings.TrimSpace(exportFormat))
+	switch format {
+	case "jsonl", "json", "csv":
+	default:
+		return fmt.Errorf("invalid format: %s (use jsonl, json or csv)", exportFormat)
+	}
+
+	var sinceMs int64
+	if exportSince != "" {
+		since, err := parseSince(exportSince, time.Now())
+		if err != nil {
+			return err
+		}
+		sinceMs = since.UnixMilli()
+	}
+
+	paths := config.DefaultPaths()
+	if _, err := os.Stat(paths.DatabaseFile()); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no history yet: database not found at %s", paths.DatabaseFile())
+	}
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+	store, err := storage.NewSQLiteStoreWithKey(paths.DatabaseFile(), key)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	if exportOutput == "" {
+		_, err := exportHistory(cmd.Context(), store, os.Stdout, format, sinceMs)
+		return err
+	}
+
+	f, err := os.OpenFile(exportOutput, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", exportOutput, err)
+	}
+	n, err := exportHistory(cmd.Context(), store, f, format, sinceMs)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", exportOutput, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", exportOutput, err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d commands to %s\n", n, exportOutput)
+	return nil
+}
+
+// exportHistory writes every command started at or after sinceMs to w in
+// format, oldest first, and returns how many it wrote.
+func exportHistory(ctx context.Context, store *storage.SQLiteStore, w io.Writer, format string, sinceMs int64) (int, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	cw := csv.NewWriter(bw)
+
+	switch format {
+	case "json":
+		if _, err := bw.WriteString("[\n"); err != nil {
+			return 0, err
+		}
+	case "csv":
+		if err := cw.Write(exportCSVHeader); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	var afterID int64
+	for {
+		page, err := store.ScanCommands(ctx, afterID, exportPageSize)
+		if err != nil {
+			return n, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		afterID = page[len(page)-1].ID
+
+		for i := range page {
+			if page[i].TSStartUnixMs < sinceMs {
+				continue
+			}
+			rec := newExportRecord(&page[i])
+			switch format {
+			case "json":
+				if n > 0 {
+					if _, err := bw.WriteString(","); err != nil {
+						return n, err
+					}
+				}
+				if err := enc.Encode(rec); err != nil {
+					return n, err
+				}
+			case "csv":
+				if err := cw.Write(rec.csvRow()); err != nil {
+					return n, err
+				}
+			default:
+				if err := enc.Encode(rec); err != nil {
+					return n, err
+				}
+			}
+			n++
+		}
+	}
+
+	switch format {
+	case "json":
+		if _, err := bw.WriteString("]\n"); err != nil {
+			return n, err
+		}
+	case "csv":
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+func (r *exportRecord) csvRow() []string {
+	row := []string{r.Time, strconv.FormatInt(r.TSUnixMs, 10), r.Command, r.CWD, "", "", "", "", r.SessionID}
+	if r.ExitCode != nil {
+		row[4] = strconv.Itoa(*r.ExitCode)
+	}
+	if r.DurationMs != nil {
+		row[5] = strconv.FormatInt(*r.DurationMs, 10)
+	}
+	if r.Branch != nil {
+		row[6] = *r.Branch
+	}
+	if r.Repo != nil {
+		row[7] = *r.Repo
+	}
+	return row
+}
+
+// parseSince turns a --since value into a point in time: a duration before
+// now (time.ParseDuration units plus d for days and w for weeks) or a
+// YYYY-MM-DD date in local time.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation(time.DateOnly, s, now.Location()); err == nil {
+		return t, nil
+	}
+
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit > 0 {
+		n, err := strconv.Atoi(strings.TrimSpace(s[:len(s)-1]))
+		if err == nil && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since: %s (use a duration such as 30d, 12h or 2w, or a date such as 2026-01-31)", s)
+}
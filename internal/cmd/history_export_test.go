@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runger/clai/internal/storage"
+)
+
+func seedExportStore(t *testing.T) *storage.SQLiteStore {
+	t.Helper()
+	store := newTestStore(t)
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	if err := store.CreateSession(ctx, &storage.Session{
+		SessionID:       "export-session",
+		StartedAtUnixMs: 1700000000000,
+		Shell:           "zsh",
+		OS:              "linux",
+		InitialCWD:      "/home/user",
+	}); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	branch := "main"
+	exitOK, exitFail := 0, 2
+	duration := int64(1500)
+	cmds := []*storage.Command{
+		{CommandID: "export-1", TSStartUnixMs: 1700000001000, CWD: "/home/user", Command: "ls", ExitCode: &exitOK},
+		{CommandID: "export-2", TSStartUnixMs: 1700000002000, CWD: "/src/app", Command: `echo "a,b"`, ExitCode: &exitOK, GitBranch: &branch, DurationMs: &duration},
+		{CommandID: "export-3", TSStartUnixMs: 1700000003000, CWD: "/src/app", Command: "make test", ExitCode: &exitFail, GitBranch: &branch},
+	}
+	for _, cmd := range cmds {
+		cmd.SessionID = "export-session"
+		if err := store.CreateCommand(ctx, cmd); err != nil {
+			t.Fatalf("CreateCommand() error = %v", err)
+		}
+	}
+	return store
+}
+
+func TestExportHistory_JSONL(t *testing.T) {
+	t.Parallel()
+	store := seedExportStore(t)
+
+	var out bytes.Buffer
+	n, err := exportHistory(context.Background(), store, &out, "jsonl", 1700000002000)
+	if err != nil {
+		t.Fatalf("exportHistory() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("exported %d commands, want 2", n)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), out.String())
+	}
+	var rec exportRecord
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("line 1 is not JSON: %v", err)
+	}
+	if rec.Command != `echo "a,b"` || rec.CWD != "/src/app" || rec.Branch == nil || *rec.Branch != "main" {
+		t.Errorf("record = %+v", rec)
+	}
+	if rec.DurationMs == nil || *rec.DurationMs != 1500 || rec.ExitCode == nil || *rec.ExitCode != 0 {
+		t.Errorf("duration/exit code = %v/%v", rec.DurationMs, rec.ExitCode)
+	}
+	if rec.Time != time.UnixMilli(1700000002000).UTC().Format(time.RFC3339) {
+		t.Errorf("time = %s", rec.Time)
+	}
+}
+
+func TestExportHistory_JSON(t *testing.T) {
+	t.Parallel()
+	store := seedExportStore(t)
+
+	var out bytes.Buffer
+	if _, err := exportHistory(context.Background(), store, &out, "json", 0); err != nil {
+		t.Fatalf("exportHistory() error = %v", err)
+	}
+	var recs []exportRecord
+	if err := json.Unmarshal(out.Bytes(), &recs); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, out.String())
+	}
+	if len(recs) != 3 || recs[0].Command != "ls" || recs[2].Command != "make test" {
+		t.Errorf("records = %+v", recs)
+	}
+
+	out.Reset()
+	if _, err := exportHistory(context.Background(), store, &out, "json", 1800000000000); err != nil {
+		t.Fatalf("exportHistory() error = %v", err)
+	}
+	if err := json.Unmarshal(out.Bytes(), &recs); err != nil || len(recs) != 0 {
+		t.Errorf("empty export = %q, %v; want []", out.String(), err)
+	}
+}
+
+func TestExportHistory_CSV(t *testing.T) {
+	t.Parallel()
+	store := seedExportStore(t)
+
+	var out bytes.Buffer
+	if _, err := exportHistory(context.Background(), store, &out, "csv", 0); err != nil {
+		t.Fatalf("exportHistory() error = %v", err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not CSV: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want header + 3", len(rows))
+	}
+	if strings.Join(rows[0], ",") != strings.Join(exportCSVHeader, ",") {
+		t.Errorf("header = %v", rows[0])
+	}
+	want := []string{"", "1700000002000", `echo "a,b"`, "/src/app", "0", "1500", "main", "", "export-session"}
+	for i, v := range want {
+		if i > 0 && rows[2][i] != v {
+			t.Errorf("row 2 column %s = %q, want %q", exportCSVHeader[i], rows[2][i], v)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "30d", want: now.AddDate(0, 0, -30)},
+		{in: "2w", want: now.AddDate(0, 0, -14)},
+		{in: "12h", want: now.Add(-12 * time.Hour)},
+		{in: "90m", want: now.Add(-90 * time.Minute)},
+		{in: "2026-01-31", want: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{in: "-3d", wantErr: true},
+		{in: "yesterday", wantErr: true},
+		{in: "d", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.in, now)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseSince(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
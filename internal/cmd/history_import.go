@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/ipc"
+)
+
+// importBatchSize is how many exported commands are sent per ImportEvents call.
+const importBatchSize = 1000
+
+// maxExportLineBytes bounds one line of an export file.
+const maxExportLineBytes = 4 << 20
+
+// eventImporter is the part of the daemon client runEventsImport uses.
+type eventImporter interface {
+	ImportEvents(ctx context.Context, events []*pb.ImportedEvent) (*pb.ImportEventsResponse, error)
+}
+
+func runEventsImport(ctx context.Context, path string) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path) //nolint:gosec // G304: user-supplied import file
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	imported, duplicates, err := importEvents(ctx, client, r)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d commands (%d already present).\n", imported, duplicates)
+	return nil
+}
+
+// importEvents reads a JSON Lines export from r and sends it to the daemon
+// in batches. It returns the number of commands imported and skipped.
+func importEvents(ctx context.Context, client eventImporter, r io.Reader) (imported, duplicates int, err error) {
+	send := func(batch []*pb.ImportedEvent) error {
+		resp, err := client.ImportEvents(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("import failed: %w", err)
+		}
+		imported += int(resp.Imported)
+		duplicates += int(resp.Duplicates)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxExportLineBytes)
+	var batch []*pb.ImportedEvent
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		ev, err := parseExportLine(text)
+		if err != nil {
+			return imported, duplicates, fmt.Errorf("line %d: %w", line, err)
+		}
+		batch = append(batch, ev)
+		if len(batch) == importBatchSize {
+			if err := send(batch); err != nil {
+				return imported, duplicates, err
+			}
+			batch = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return imported, duplicates, fmt.Errorf("failed to read export: %w", err)
+	}
+	if len(batch) > 0 {
+		if err := send(batch); err != nil {
+			return imported, duplicates, err
+		}
+	}
+	return imported, duplicates, nil
+}
+
+// parseExportLine decodes one line written by 'clai history export --format jsonl'.
+func parseExportLine(text string) (*pb.ImportedEvent, error) {
+	if strings.HasPrefix(text, "[") {
+		return nil, errors.New("looks like a JSON array; export with --format jsonl")
+	}
+	var rec exportRecord
+	if err := json.Unmarshal([]byte(text), &rec); err != nil {
+		return nil, fmt.Errorf("not an exported command: %w", err)
+	}
+	if rec.Command == "" || rec.SessionID == "" || rec.TSUnixMs <= 0 {
+		return nil, errors.New("missing command, session_id or ts_unix_ms")
+	}
+
+	ev := &pb.ImportedEvent{
+		SessionId:  rec.SessionID,
+		TsUnixMs:   rec.TSUnixMs,
+		Command:    rec.Command,
+		Cwd:        rec.CWD,
+		DurationMs: rec.DurationMs,
+	}
+	if rec.ExitCode != nil {
+		code := int32(*rec.ExitCode) //nolint:gosec // G115: exit codes fit in int32
+		ev.ExitCode = &code
+	}
+	if rec.Branch != nil {
+		ev.GitBranch = *rec.Branch
+	}
+	if rec.Repo != nil {
+		ev.GitRepoRoot = *rec.Repo
+	}
+	return ev, nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+type fakeEventImporter struct {
+	batches [][]*pb.ImportedEvent
+}
+
+func (f *fakeEventImporter) ImportEvents(_ context.Context, events []*pb.ImportedEvent) (*pb.ImportEventsResponse, error) {
+	f.batches = append(f.batches, events)
+	return &pb.ImportEventsResponse{Imported: int32(len(events)) - 1, Duplicates: 1}, nil
+}
+
+func TestImportEvents_RoundTripsExport(t *testing.T) {
+	t.Parallel()
+	store := seedExportStore(t)
+
+	var export bytes.Buffer
+	if _, err := exportHistory(context.Background(), store, &export, "jsonl", 0); err != nil {
+		t.Fatalf("exportHistory() error = %v", err)
+	}
+
+	client := &fakeEventImporter{}
+	imported, duplicates, err := importEvents(context.Background(), client, &export)
+	if err != nil {
+		t.Fatalf("importEvents() error = %v", err)
+	}
+	if imported != 2 || duplicates != 1 {
+		t.Errorf("imported, duplicates = %d, %d; want 2, 1", imported, duplicates)
+	}
+	if len(client.batches) != 1 || len(client.batches[0]) != 3 {
+		t.Fatalf("batches = %v, want one batch of 3", client.batches)
+	}
+
+	ev := client.batches[0][1]
+	if ev.Command != `echo "a,b"` || ev.SessionId != "export-session" || ev.TsUnixMs != 1700000002000 {
+		t.Errorf("event = %v", ev)
+	}
+	if ev.GetExitCode() != 0 || ev.ExitCode == nil || ev.GetDurationMs() != 1500 || ev.GitBranch != "main" {
+		t.Errorf("event context = %v", ev)
+	}
+	if first := client.batches[0][0]; first.DurationMs != nil {
+		t.Errorf("missing duration imported as %d", first.GetDurationMs())
+	}
+}
+
+func TestImportEvents_RejectsBadLines(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"json array", `[{"command":"ls"}]`, "line 1: looks like a JSON array"},
+		{"not json", "ls -la\n", "line 1: not an exported command"},
+		{"missing fields", "\n" + `{"command":"ls"}`, "line 2: missing command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeEventImporter{}
+			_, _, err := importEvents(context.Background(), client, strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("importEvents() error = %v, want %q", err, tt.want)
+			}
+			if len(client.batches) != 0 {
+				t.Errorf("sent %d batches for a bad file", len(client.batches))
+			}
+		})
+	}
+}
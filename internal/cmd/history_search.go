@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/ipc"
+)
+
+// historySearchCwdWidth caps the directory column of 'clai history search'.
+const historySearchCwdWidth = 40
+
+var (
+	historySearchLimit   int
+	historySearchOffset  int
+	historySearchCWD     string
+	historySearchHost    string
+	historySearchSession string
+	historySearchGlobal  bool
+	historySearchStatus  string
+	historySearchFormat  string
+)
+
+var historySearchCmd = &cobra.Command{
+	Use:   "search [query...]",
+	Short: "Search history without opening the picker",
+	Long: `Search command history through the daemon, like the history picker
+does, and print the matches for scripts and other tools.
+
+The query matches anywhere in a command, ignoring case, through the
+full-text index. Each command is listed once, most recent use first, with
+when and where it last ran and how it exited.
+
+Like the picker, the search is scoped to the current shell session unless
+-g/--global or --session is given. --cwd, --host and --status narrow it
+further.
+
+Examples:
+  clai history search docker           # Commands containing "docker"
+  clai history search -g kubectl apply # Across all sessions
+  clai history search -g -s failure -c ~/src/app make
+  clai history search -g --json ssh | jq -r '.[].text'`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runHistorySearch,
+}
+
+func init() {
+	historySearchCmd.Flags().IntVarP(&historySearchLimit, "limit", "n", 50, "Maximum number of commands to show")
+	historySearchCmd.Flags().IntVar(&historySearchOffset, "offset", 0, "Skip this many results (for pagination)")
+	historySearchCmd.Flags().StringVarP(&historySearchCWD, "cwd", "c", "", "Filter by working directory")
+	historySearchCmd.Flags().StringVar(&historySearchHost, "host", "", "Filter by the host the command ran on")
+	historySearchCmd.Flags().StringVar(&historySearchSession, "session", "", "Search this session ID instead of the current one")
+	_ = historySearchCmd.RegisterFlagCompletionFunc("session", completeSessionIDs)
+	historySearchCmd.Flags().BoolVarP(&historySearchGlobal, "global", "g", false, "Search across all sessions")
+	historySearchCmd.Flags().StringVarP(&historySearchStatus, "status", "s", "", "Filter by status: 'success' or 'failure'")
+	historySearchCmd.Flags().StringVar(&historySearchFormat, "format", "table", "Output format: table or json")
+	addJSONFlag(historySearchCmd, &historySearchFormat)
+	historyCmd.AddCommand(historySearchCmd)
+}
+
+// historySearcher is the part of the daemon client searchHistory uses.
+type historySearcher interface {
+	FetchHistory(ctx context.Context, req *pb.HistoryFetchRequest) (*pb.HistoryFetchResponse, error)
+}
+
+func runHistorySearch(cmd *cobra.Command, args []string) error {
+	req, err := buildHistorySearchRequest(args)
+	if err != nil {
+		return err
+	}
+	format := strings.ToLower(strings.TrimSpace(historySearchFormat))
+	if format != "table" && format != "json" {
+		return fmt.Errorf("invalid format: %s (use table or json)", historySearchFormat)
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	items, err := searchHistory(cmd.Context(), client, req)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeHistorySearchJSON(os.Stdout, items, historySource(historySearchGlobal, historySearchCWD, historySearchSession))
+	}
+	writeHistorySearchTable(os.Stdout, items)
+	return nil
+}
+
+// buildHistorySearchRequest turns the query words and flags into a full-text
+// FetchHistory request.
+func buildHistorySearchRequest(args []string) (*pb.HistoryFetchRequest, error) {
+	if historySearchLimit <= 0 {
+		return nil, errors.New("invalid limit: must be > 0")
+	}
+	if historySearchOffset < 0 {
+		return nil, errors.New("invalid offset: must be >= 0")
+	}
+	if historySearchStatus != "" && historySearchStatus != "success" && historySearchStatus != "failure" {
+		return nil, fmt.Errorf("invalid status: %s (use 'success' or 'failure')", historySearchStatus)
+	}
+
+	req := &pb.HistoryFetchRequest{
+		Query:  strings.Join(args, " "),
+		Mode:   pb.SearchMode_SEARCH_MODE_FTS,
+		Limit:  int32(historySearchLimit),  //nolint:gosec // G115: a page size
+		Offset: int32(historySearchOffset), //nolint:gosec // G115: a page offset
+		Host:   historySearchHost,
+		Cwd:    historySearchCWD,
+		Status: historySearchStatus,
+	}
+	switch {
+	case historySearchSession != "":
+		req.SessionId = historySearchSession
+	case historySearchGlobal:
+		req.Global = true
+	default:
+		req.SessionId = os.Getenv("CLAI_SESSION_ID")
+		if req.SessionId == "" {
+			return nil, errors.New("not in a clai shell session; use -g/--global or --session")
+		}
+	}
+	return req, nil
+}
+
+// searchHistory runs req against the daemon.
+func searchHistory(ctx context.Context, client historySearcher, req *pb.HistoryFetchRequest) ([]*pb.HistoryItem, error) {
+	resp, err := client.FetchHistory(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search history: %w", err)
+	}
+	return resp.Items, nil
+}
+
+// writeHistorySearchTable prints one match per line: last use, exit code,
+// directory and command. Commands are not quoted, so the last column can be
+// cut out with a fixed offset.
+func writeHistorySearchTable(w io.Writer, items []*pb.HistoryItem) {
+	if len(items) == 0 {
+		return
+	}
+	width := len("CWD")
+	for _, it := range items {
+		width = max(width, min(utf8.RuneCountInString(it.Cwd), historySearchCwdWidth))
+	}
+	fmt.Fprintf(w, "%-16s  %4s  %-*s  %s\n", "TIME", "EXIT", width, "CWD", "COMMAND")
+	for _, it := range items {
+		exit := "-"
+		if it.ExitCode != nil {
+			exit = fmt.Sprint(*it.ExitCode)
+		}
+		fmt.Fprintf(w, "%-16s  %4s  %-*s  %s\n",
+			time.UnixMilli(it.TimestampMs).Format("2006-01-02 15:04"), exit,
+			width, truncateCwd(it.Cwd, width), it.Command)
+	}
+}
+
+// truncateCwd shortens cwd to width, keeping its end, which names the
+// directory.
+func truncateCwd(cwd string, width int) string {
+	r := []rune(cwd)
+	if len(r) <= width {
+		return cwd
+	}
+	return "..." + string(r[len(r)-width+3:])
+}
+
+// writeHistorySearchJSON prints the matches in the format of
+// 'clai history --format json'.
+func writeHistorySearchJSON(w io.Writer, items []*pb.HistoryItem, source string) error {
+	entries := make([]historyOutput, 0, len(items))
+	for _, it := range items {
+		e := historyOutput{
+			Text:     it.Command,
+			Cwd:      it.Cwd,
+			TSUnixMs: it.TimestampMs,
+			Source:   source,
+		}
+		if it.ExitCode != nil {
+			code := int(*it.ExitCode)
+			e.ExitCode = &code
+		}
+		entries = append(entries, e)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(entries)
+}
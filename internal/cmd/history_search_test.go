@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+type fakeHistorySearcher struct {
+	req   *pb.HistoryFetchRequest
+	items []*pb.HistoryItem
+}
+
+func (f *fakeHistorySearcher) FetchHistory(_ context.Context, req *pb.HistoryFetchRequest) (*pb.HistoryFetchResponse, error) {
+	f.req = req
+	return &pb.HistoryFetchResponse{Items: f.items, AtEnd: true}, nil
+}
+
+// setHistorySearchFlags sets the flags of 'clai history search' for one test.
+func setHistorySearchFlags(t *testing.T, limit int, session string, global bool, status string) {
+	t.Helper()
+	oldLimit, oldSession, oldGlobal, oldStatus := historySearchLimit, historySearchSession, historySearchGlobal, historySearchStatus
+	t.Cleanup(func() {
+		historySearchLimit, historySearchSession, historySearchGlobal, historySearchStatus = oldLimit, oldSession, oldGlobal, oldStatus
+	})
+	historySearchLimit, historySearchSession, historySearchGlobal, historySearchStatus = limit, session, global, status
+}
+
+func TestBuildHistorySearchRequest(t *testing.T) {
+	t.Setenv("CLAI_SESSION_ID", "current-session")
+
+	setHistorySearchFlags(t, 50, "", false, "failure")
+	req, err := buildHistorySearchRequest([]string{"kubectl", "apply"})
+	if err != nil {
+		t.Fatalf("buildHistorySearchRequest() error = %v", err)
+	}
+	if req.Query != "kubectl apply" || req.Mode != pb.SearchMode_SEARCH_MODE_FTS || req.SessionId != "current-session" || req.Global || req.Status != "failure" {
+		t.Errorf("request = %v, want a full-text failure search of the current session", req)
+	}
+
+	setHistorySearchFlags(t, 50, "", true, "")
+	req, err = buildHistorySearchRequest(nil)
+	if err != nil {
+		t.Fatalf("buildHistorySearchRequest() error = %v", err)
+	}
+	if !req.Global || req.SessionId != "" {
+		t.Errorf("request = %v, want a global search", req)
+	}
+
+	for name, set := range map[string]func(){
+		"limit":  func() { setHistorySearchFlags(t, 0, "", true, "") },
+		"status": func() { setHistorySearchFlags(t, 50, "", true, "ok") },
+	} {
+		set()
+		if _, err := buildHistorySearchRequest(nil); err == nil {
+			t.Errorf("invalid %s: buildHistorySearchRequest() = nil error", name)
+		}
+	}
+
+	t.Setenv("CLAI_SESSION_ID", "")
+	setHistorySearchFlags(t, 50, "", false, "")
+	if _, err := buildHistorySearchRequest(nil); err == nil {
+		t.Error("no session: buildHistorySearchRequest() = nil error")
+	}
+}
+
+func TestHistorySearchOutput(t *testing.T) {
+	t.Parallel()
+
+	code := int32(2)
+	client := &fakeHistorySearcher{items: []*pb.HistoryItem{
+		{Command: "make test", TimestampMs: 1_700_000_000_000, Cwd: "/home/user/src/app", ExitCode: &code},
+		{Command: "ls -la", TimestampMs: 1_690_000_000_000},
+	}}
+	req := &pb.HistoryFetchRequest{Query: "a", Global: true}
+	items, err := searchHistory(context.Background(), client, req)
+	if err != nil {
+		t.Fatalf("searchHistory() error = %v", err)
+	}
+	if client.req != req {
+		t.Errorf("sent %v, want %v", client.req, req)
+	}
+
+	var table bytes.Buffer
+	writeHistorySearchTable(&table, items)
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	wantTime := time.UnixMilli(1_700_000_000_000).Format("2006-01-02 15:04")
+	want := []string{
+		"TIME              EXIT  CWD                 COMMAND",
+		wantTime + "     2  /home/user/src/app  make test",
+	}
+	if len(lines) != 3 || lines[0] != want[0] || lines[1] != want[1] {
+		t.Errorf("table =\n%s\nwant it to start with\n%s", table.String(), strings.Join(want, "\n"))
+	}
+	if !strings.Contains(lines[2], "    -                      ls -la") {
+		t.Errorf("unknown exit code row = %q", lines[2])
+	}
+
+	var js bytes.Buffer
+	if err := writeHistorySearchJSON(&js, items, "global"); err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `[{"exit_code":2,"text":"make test","cwd":"/home/user/src/app","source":"global","ts_unix_ms":1700000000000},` +
+		`{"exit_code":null,"text":"ls -la","cwd":"","source":"global","ts_unix_ms":1690000000000}]` + "\n"
+	if js.String() != wantJSON {
+		t.Errorf("json = %s, want %s", js.String(), wantJSON)
+	}
+}
+
+func TestTruncateCwd(t *testing.T) {
+	t.Parallel()
+	if got := truncateCwd("/home/user", 20); got != "/home/user" {
+		t.Errorf("truncateCwd(short) = %q", got)
+	}
+	if got := truncateCwd("/home/user/src/project", 12); got != "...c/project" {
+		t.Errorf("truncateCwd(long) = %q", got)
+	}
+	if got := truncateCwd("/home/ünïcödé/dïr", 8); got != "...é/dïr" {
+		t.Errorf("truncateCwd(unicode) = %q", got)
+	}
+}
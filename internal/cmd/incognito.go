@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/runger/clai/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -31,6 +32,11 @@ Modes:
   default (ephemeral): Send events with ephemeral=true, keeps current-session suggestions useful
   --no-send: Skip sending events entirely (simplest but loses current-session suggestions)
 
+Directories listed in suggestions.ephemeral_dirs are always ephemeral,
+whatever the session's mode:
+  suggestions:
+    ephemeral_dirs: ["~/secrets", "~/work/*/credentials"]
+
 Usage:
   eval "$(clai incognito on)"     # Enable incognito mode (ephemeral)
   eval "$(clai incognito on --no-send)"  # Enable incognito mode (no-send)
@@ -108,6 +114,14 @@ func showIncognitoStatus() error {
 		return nil
 	}
 
+	if cwd, err := os.Getwd(); err == nil {
+		if dir, ok := config.MatchEphemeralDir(config.LoadEphemeralDirs(), cwd); ok {
+			fmt.Printf("Incognito mode: ON (directory rule %s)\n", dir)
+			fmt.Println("Commands run here are not persisted (suggestions.ephemeral_dirs)")
+			return nil
+		}
+	}
+
 	fmt.Println("Incognito mode: OFF")
 	fmt.Println("Commands are recorded and persisted normally")
 	return nil
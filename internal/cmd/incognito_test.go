@@ -3,6 +3,7 @@ package cmd
 import (
 	"bytes"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -274,3 +275,30 @@ func TestDisableIncognito(t *testing.T) {
 		t.Errorf("expected disabled message on stderr, got: %s", errStr)
 	}
 }
+
+func TestShowIncognitoStatus_DirectoryRule(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+	t.Setenv("CLAI_NO_RECORD", "")
+	t.Setenv("CLAI_EPHEMERAL", "")
+	secrets := filepath.Join(home, "secrets")
+	if err := os.MkdirAll(filepath.Join(secrets, "aws"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := "suggestions:\n  ephemeral_dirs: [\"" + secrets + "\"]\n"
+	if err := os.WriteFile(filepath.Join(home, "config.yaml"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(filepath.Join(secrets, "aws"))
+	output := captureStdout(t, func() { _ = showIncognitoStatus() })
+	if !strings.Contains(output, "ON (directory rule "+secrets+")") {
+		t.Errorf("status in a ruled directory = %q, want directory rule", output)
+	}
+
+	t.Chdir(home)
+	output = captureStdout(t, func() { _ = showIncognitoStatus() })
+	if !strings.Contains(output, "Incognito mode: OFF") {
+		t.Errorf("status outside ruled directories = %q, want OFF", output)
+	}
+}
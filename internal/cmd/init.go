@@ -16,6 +16,8 @@ import (
 //go:embed shell/zsh/clai.zsh
 //go:embed shell/bash/clai.bash
 //go:embed shell/fish/clai.fish
+//go:embed shell/pwsh/clai.ps1
+//go:embed shell/nu/clai.nu
 var shellScripts embed.FS
 
 var initCmd = &cobra.Command{
@@ -33,9 +35,16 @@ Add this to your shell configuration file:
   eval "$(clai init bash)"
 
   # For Fish (~/.config/fish/config.fish):
-  clai init fish | source`,
+  clai init fish | source
+
+  # For PowerShell 7+ ($PROFILE):
+  clai init pwsh | Out-String | Invoke-Expression
+
+  # For Nushell, save it from env.nu and source it from config.nu:
+  clai init nu | save --force ~/.cache/clai/init.nu
+  source ~/.cache/clai/init.nu`,
 	Args:      cobra.ExactArgs(1),
-	ValidArgs: []string{"zsh", "bash", "fish"},
+	ValidArgs: []string{"zsh", "bash", "fish", "pwsh", "nu"},
 	RunE:      runInit,
 }
 
@@ -54,8 +63,12 @@ func runInit(cmd *cobra.Command, args []string) error {
 		filename = "shell/bash/clai.bash"
 	case "fish":
 		filename = "shell/fish/clai.fish"
+	case "pwsh":
+		filename = "shell/pwsh/clai.ps1"
+	case "nu":
+		filename = "shell/nu/clai.nu"
 	default:
-		return fmt.Errorf("unsupported shell: %s (supported: zsh, bash, fish)", shell)
+		return fmt.Errorf("unsupported shell: %s (supported: zsh, bash, fish, pwsh, nu)", shell)
 	}
 
 	content, err := shellScripts.ReadFile(filename)
@@ -64,26 +77,39 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Generate or reuse session ID
-	// If CLAI_SESSION_ID is already set (re-sourcing), preserve it
-	sessionID := os.Getenv("CLAI_SESSION_ID")
+	// Inside tmux the ID is derived from the pane: panes inherit the
+	// CLAI_SESSION_ID of the shell that started tmux, which must not be
+	// shared. Otherwise, if CLAI_SESSION_ID is already set (re-sourcing),
+	// preserve it
+	sessionID := tmuxPaneSessionID(os.Getenv("TMUX"), os.Getenv("TMUX_PANE"))
+	if sessionID == "" {
+		sessionID = os.Getenv("CLAI_SESSION_ID")
+	}
 	if sessionID == "" {
 		sessionID = uuid.New().String()
 	}
 
-	// Init must be extremely fast; avoid disk I/O (config file read) here.
-	// Users can override via env vars before sourcing, and other commands read
-	// config normally.
+	// Init must be extremely fast; avoid loading the full config here. Only
+	// the key bindings and suggestions.confirm_risky are read from the
+	// config file. Users can override the rest via env vars before
+	// sourcing, and other commands read config normally.
 	cfg := config.DefaultConfig()
 	cfg.ApplyEnvOverrides()
+	keys, warnings := keybindingReplacements(shell, config.LoadKeybindings())
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "clai init: %s\n", w)
+	}
 
 	// Replace placeholders with actual values.
 	// Use a single-pass replacer to avoid repeated full-script copies (helps keep init fast).
-	replacer := strings.NewReplacer(
+	replacer := strings.NewReplacer(append([]string{
 		"{{CLAI_SESSION_ID}}", sessionID,
+		"{{CLAI_VERSION}}", Version,
 		"{{CLAI_UP_ARROW_HISTORY}}", strconv.FormatBool(cfg.History.UpArrowOpensHistory),
 		"{{CLAI_UP_ARROW_TRIGGER}}", cfg.History.UpArrowTrigger,
 		"{{CLAI_UP_ARROW_DOUBLE_WINDOW_MS}}", strconv.Itoa(cfg.History.UpArrowDoubleWindowMs),
-	)
+		"{{CLAI_CONFIRM_RISKY}}", strconv.FormatBool(config.LoadHookSettings().ConfirmRisky.Enabled),
+	}, keys...)...)
 	fmt.Print(replacer.Replace(string(content)))
 	return nil
 }
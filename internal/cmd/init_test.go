@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/runger/clai/internal/config"
 )
 
 func TestRunInit_Zsh(t *testing.T) {
@@ -116,6 +119,31 @@ func TestRunInit_Fish(t *testing.T) {
 	}
 }
 
+func TestFishScript_SessionLifecycle(t *testing.T) {
+	content, err := shellScripts.ReadFile("shell/fish/clai.fish")
+	if err != nil {
+		t.Fatalf("Failed to read fish script: %v", err)
+	}
+	script := string(content)
+
+	for _, req := range []string{
+		"--on-event fish_preexec",
+		"--on-event fish_postexec",
+		"--on-event fish_exit",
+		"clai-shim session-start",
+		"clai-shim import-history",
+	} {
+		if !strings.Contains(script, req) {
+			t.Errorf("fish script missing %q", req)
+		}
+	}
+
+	body := extractFishFunctionBody(script, "_clai_cleanup")
+	if !strings.Contains(body, "clai-shim session-end") {
+		t.Error("fish _clai_cleanup should end the daemon session on exit")
+	}
+}
+
 func TestShellScripts_SuggestUsesPlainFormat(t *testing.T) {
 	tests := []struct {
 		path     string
@@ -215,7 +243,60 @@ func TestRunInit_UnsupportedShell(t *testing.T) {
 	}
 }
 
+func TestRunInit_NonPOSIXShells(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("CLAI_SESSION_ID", "session-fixed-123")
+
+	tests := []struct {
+		shell    string
+		required []string
+	}{
+		{"pwsh", []string{
+			"Set-PSReadLineKeyHandler",
+			"function global:prompt",
+			"PowerShell.Exiting",
+			"'--shell=pwsh'",
+		}},
+		{"nu", []string{
+			"hooks.pre_execution",
+			"hooks.pre_prompt",
+			"executehostcommand",
+			`"--shell=nu"`,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			output := captureStdout(t, func() {
+				if err := runInit(initCmd, []string{tt.shell}); err != nil {
+					t.Fatalf("runInit error: %v", err)
+				}
+			})
+
+			required := append([]string{
+				"session-fixed-123",
+				"CLAI_CURRENT_SHELL",
+				"CLAI_CACHE",
+				"session-start",
+				"log-start",
+				"log-end",
+				"clai suggest",
+				"clai-picker",
+			}, tt.required...)
+			for _, req := range required {
+				if !strings.Contains(output, req) {
+					t.Errorf("%s script missing %q", tt.shell, req)
+				}
+			}
+			if strings.Contains(output, "{{") {
+				t.Errorf("%s script has an unreplaced placeholder", tt.shell)
+			}
+		})
+	}
+}
+
 func TestRunInit_PreservesSessionID(t *testing.T) {
+	t.Setenv("TMUX", "")
 	t.Setenv("CLAI_SESSION_ID", "session-fixed-123")
 
 	output := captureStdout(t, func() {
@@ -230,6 +311,7 @@ func TestRunInit_PreservesSessionID(t *testing.T) {
 }
 
 func TestRunInit_GeneratesNewSessionID(t *testing.T) {
+	t.Setenv("TMUX", "")
 	os.Unsetenv("CLAI_SESSION_ID")
 
 	output1 := captureStdout(t, func() {
@@ -255,6 +337,74 @@ func TestRunInit_GeneratesNewSessionID(t *testing.T) {
 	}
 }
 
+func TestRunInit_StampsVersion(t *testing.T) {
+	want := map[string]string{
+		"zsh":  `export CLAI_INIT_VERSION="` + Version + `"`,
+		"bash": `export CLAI_INIT_VERSION="` + Version + `"`,
+		"fish": `set -gx CLAI_INIT_VERSION "` + Version + `"`,
+		"pwsh": `$env:CLAI_INIT_VERSION = '` + Version + `'`,
+		"nu":   `$env.CLAI_INIT_VERSION = "` + Version + `"`,
+	}
+	for shell, line := range want {
+		output := captureStdout(t, func() {
+			if err := runInit(initCmd, []string{shell}); err != nil {
+				t.Fatalf("runInit error: %v", err)
+			}
+		})
+		if !strings.Contains(output, line) {
+			t.Errorf("%s script missing %q", shell, line)
+		}
+	}
+}
+
+func TestRunInit_ConfirmRisky(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+	cfg := "suggestions:\n  confirm_risky:\n    enabled: true\n"
+	if err := os.WriteFile(filepath.Join(home, "config.yaml"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"zsh":  ": ${CLAI_CONFIRM_RISKY:=true}",
+		"bash": ": ${CLAI_CONFIRM_RISKY:=true}",
+		"fish": "set -gx CLAI_CONFIRM_RISKY true",
+	}
+	for shell, line := range want {
+		output := captureStdout(t, func() {
+			if err := runInit(initCmd, []string{shell}); err != nil {
+				t.Fatalf("runInit error: %v", err)
+			}
+		})
+		if !strings.Contains(output, line) {
+			t.Errorf("%s script missing %q", shell, line)
+		}
+		if !strings.Contains(output, "clai-shim confirm --command=") {
+			t.Errorf("%s script does not ask clai-shim about risky commands", shell)
+		}
+	}
+}
+
+func TestRunInit_TmuxPaneSessionID(t *testing.T) {
+	t.Setenv("CLAI_SESSION_ID", "inherited-from-tmux-server")
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,4242,0")
+	t.Setenv("TMUX_PANE", "%3")
+
+	output := captureStdout(t, func() {
+		if err := runInit(initCmd, []string{"zsh"}); err != nil {
+			t.Fatalf("runInit error: %v", err)
+		}
+	})
+
+	want := tmuxPaneSessionID("/tmp/tmux-1000/default,4242,0", "%3")
+	if !strings.Contains(output, `CLAI_SESSION_ID="`+want+`"`) {
+		t.Fatalf("expected pane session ID %s in output", want)
+	}
+	if strings.Contains(output, "inherited-from-tmux-server") {
+		t.Fatal("inherited session ID must not be shared across tmux panes")
+	}
+}
+
 func TestShellScripts_AllHaveCommonFeatures(t *testing.T) {
 	shells := map[string]string{
 		"zsh":  "shell/zsh/clai.zsh",
@@ -857,17 +1007,19 @@ func extractFishFunctionBody(script, funcName string) string {
 }
 
 // TestShellScripts_MacOSOptionH verifies that all shell scripts bind both
-// \eh (ESC+h, for terminals that send ESC for Alt) and the literal ˙ character
-// (U+02D9, which macOS Option+H produces with US keyboard layout).
+// \eh (ESC+h, for terminals that send ESC for Alt; the default history picker
+// key) and the literal ˙ character (U+02D9, which macOS Option+H produces with
+// US keyboard layout).
 func TestShellScripts_MacOSOptionH(t *testing.T) {
 	shells := []struct {
+		shell      string
 		path       string
 		escBinding string // ESC-based binding
 		macBinding string // macOS literal character binding
 	}{
-		{"shell/zsh/clai.zsh", `'\eh'`, `'˙'`},
-		{"shell/bash/clai.bash", `"\eh"`, `"˙"`},
-		{"shell/fish/clai.fish", `\eh`, `˙`},
+		{"zsh", "shell/zsh/clai.zsh", `'\eh'`, `'˙'`},
+		{"bash", "shell/bash/clai.bash", `"\eh"`, `"˙"`},
+		{"fish", "shell/fish/clai.fish", `\eh`, `˙`},
 	}
 
 	for _, sh := range shells {
@@ -876,7 +1028,8 @@ func TestShellScripts_MacOSOptionH(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to read %s: %v", sh.path, err)
 			}
-			script := string(content)
+			keys, _ := keybindingReplacements(sh.shell, config.KeybindingsConfig{}.WithDefaults())
+			script := strings.NewReplacer(keys...).Replace(string(content))
 
 			if !strings.Contains(script, sh.escBinding) {
 				t.Errorf("%s missing ESC-based Alt+H binding %s", sh.path, sh.escBinding)
@@ -46,60 +46,66 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	addedFiles, err := installShellIntegration(shell)
+	if err != nil || len(addedFiles) == 0 {
+		return err
+	}
+
+	fmt.Printf("%sInstalled successfully!%s\n", colorGreen, colorReset)
+	for _, f := range addedFiles {
+		fmt.Printf("  Added to: %s\n", f)
+	}
+	fmt.Printf("\nTo activate, either:\n")
+	fmt.Printf("  1. Start a new terminal session, or\n")
+	fmt.Printf("  2. Run: %s%s%s\n", colorCyan, evalCommand(shell), colorReset)
+
+	return nil
+}
+
+// installShellIntegration writes the hook file for shell and sources it
+// from the shell's rc files. It returns the rc files it changed, none when
+// clai was already installed in all of them.
+func installShellIntegration(shell string) ([]string, error) {
 	paths := config.DefaultPaths()
-	if err = paths.EnsureDirectories(); err != nil {
-		return fmt.Errorf("failed to create directories: %w", err)
+	if err := paths.EnsureDirectories(); err != nil {
+		return nil, fmt.Errorf("failed to create directories: %w", err)
 	}
 
 	hookFile := filepath.Join(paths.HooksDir(), fmt.Sprintf("clai.%s", shell))
 	hookContent, err := getHookContent(shell)
 	if err != nil {
-		return fmt.Errorf("failed to get hook content: %w", err)
+		return nil, fmt.Errorf("failed to get hook content: %w", err)
 	}
 
 	if err := os.WriteFile(hookFile, []byte(hookContent), 0o644); err != nil { //nolint:gosec // G306: hook file must be readable by shell
-		return fmt.Errorf("failed to write hook file: %w", err)
+		return nil, fmt.Errorf("failed to write hook file: %w", err)
 	}
 	fmt.Printf("Wrote hook file: %s\n", hookFile)
 
 	rcFiles := getRCFiles(shell)
 	if len(rcFiles) == 0 {
-		return fmt.Errorf("could not determine rc file for %s", shell)
+		return nil, fmt.Errorf("could not determine rc file for %s", shell)
 	}
 
 	sourceLine := fmt.Sprintf(`source "%s"`, hookFile) //nolint:gocritic // shell syntax, not Go string
-	allInstalled := true
 	addedFiles := make([]string, 0, len(rcFiles))
 	for _, rcFile := range rcFiles {
 		installed, installedLine, err := isInstalled(rcFile, hookFile, shell)
 		if err != nil {
-			return fmt.Errorf("failed to check rc file: %w", err)
+			return nil, fmt.Errorf("failed to check rc file: %w", err)
 		}
 		if installed {
 			fmt.Printf("clai is already installed in %s\n", rcFile)
 			fmt.Printf("  Line: %s\n", installedLine)
 			continue
 		}
-		allInstalled = false
 
 		if err := appendToRCFile(rcFile, sourceLine); err != nil {
-			return err
+			return nil, err
 		}
 		addedFiles = append(addedFiles, rcFile)
 	}
-	if allInstalled {
-		return nil
-	}
-
-	fmt.Printf("%sInstalled successfully!%s\n", colorGreen, colorReset)
-	for _, f := range addedFiles {
-		fmt.Printf("  Added to: %s\n", f)
-	}
-	fmt.Printf("\nTo activate, either:\n")
-	fmt.Printf("  1. Start a new terminal session, or\n")
-	fmt.Printf("  2. Run: %s%s%s\n", colorCyan, evalCommand(shell), colorReset)
-
-	return nil
+	return addedFiles, nil
 }
 
 func resolveInstallShell() (string, error) {
@@ -123,6 +129,8 @@ func resolveInstallShell() (string, error) {
 	switch shell {
 	case "zsh", "bash", "fish":
 		return shell, nil
+	case "pwsh", "nu":
+		return "", fmt.Errorf("clai install does not support %s yet; load `clai init %s` from your shell config instead (see clai init --help)", shell, shell)
 	default:
 		return "", fmt.Errorf("unsupported shell: %s (supported: zsh, bash, fish)", shell)
 	}
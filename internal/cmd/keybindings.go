@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runger/clai/internal/config"
+)
+
+// keybindingReplacements returns the placeholders `clai init` fills with
+// the configured key bindings for shell: the binding in the shell's own
+// notation and a label for the startup banner. A binding the shell cannot
+// express falls back to the default, with a warning.
+func keybindingReplacements(shell string, kb config.KeybindingsConfig) (pairs []string, warnings []string) {
+	for _, b := range []struct {
+		placeholder string
+		field       string
+		spec        string
+		def         string
+	}{
+		{"CLAI_KEY_HISTORY_PICKER", "history_picker", kb.HistoryPicker, config.DefaultHistoryPickerKey},
+		{"CLAI_KEY_SUGGEST_WIDGET", "suggest_widget", kb.SuggestWidget, config.DefaultSuggestWidgetKey},
+	} {
+		keys, bind, err := shellKeyBinding(shell, b.spec)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("client.keybindings.%s: %v; using %s", b.field, err, b.def))
+			keys, bind, _ = shellKeyBinding(shell, b.def)
+		}
+		labels := make([]string, len(keys))
+		for i, k := range keys {
+			labels[i] = k.Label()
+		}
+		pairs = append(pairs,
+			"{{"+b.placeholder+"}}", bind,
+			"{{"+b.placeholder+"_LABEL}}", strings.Join(labels, " "))
+	}
+	return pairs, warnings
+}
+
+// shellKeyBinding parses spec and renders it the way shell's script binds
+// keys.
+func shellKeyBinding(shell, spec string) ([]config.Key, string, error) {
+	keys, err := config.ParseKeySpec(spec)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var parts []string
+	for _, k := range keys {
+		lower := strings.ToLower(string(k.Char))
+		var part string
+		switch shell {
+		case "zsh":
+			// bindkey takes ^X and \e as is.
+			switch {
+			case k.Ctrl:
+				part = "^" + string(k.Char)
+			case k.Alt:
+				part = `\e` + lower
+			default:
+				part = lower
+			}
+		case "bash":
+			switch {
+			case k.Ctrl:
+				part = `\C-` + lower
+			case k.Alt:
+				part = `\e` + lower
+			default:
+				part = lower
+			}
+		case "fish":
+			switch {
+			case k.Ctrl && k.Char == '@':
+				if len(keys) > 1 {
+					return nil, "", fmt.Errorf("fish cannot bind Ctrl+Space within a key sequence")
+				}
+				return keys, "-k nul", nil
+			case k.Ctrl:
+				part = `\c` + lower
+			case k.Alt:
+				part = `\e` + lower
+			default:
+				part = lower
+			}
+		case "pwsh":
+			// PSReadLine chords, comma-separated for a sequence.
+			switch {
+			case k.Ctrl && k.Char == '@':
+				part = "Ctrl+Spacebar"
+			case k.Ctrl:
+				part = "Ctrl+" + lower
+			case k.Alt:
+				part = "Alt+" + lower
+			default:
+				part = lower
+			}
+		case "nu":
+			// The modifier and keycode fields of a keybinding record.
+			if len(keys) > 1 {
+				return nil, "", fmt.Errorf("nushell cannot bind key sequences")
+			}
+			switch {
+			case k.Ctrl && k.Char == '@':
+				return keys, "modifier: control keycode: space", nil
+			case k.Ctrl:
+				return keys, "modifier: control keycode: char_" + lower, nil
+			default:
+				return keys, "modifier: alt keycode: char_" + lower, nil
+			}
+		}
+		parts = append(parts, part)
+	}
+	if shell == "pwsh" {
+		return keys, strings.Join(parts, ","), nil
+	}
+	return keys, strings.Join(parts, ""), nil
+}
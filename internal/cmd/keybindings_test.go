@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runger/clai/internal/config"
+)
+
+func TestShellKeyBinding(t *testing.T) {
+	tests := []struct {
+		shell, spec, want string
+	}{
+		{"zsh", "M-h", `\eh`},
+		{"zsh", "^R", "^R"},
+		{"zsh", "^Xh", "^Xh"},
+		{"zsh", "^@", "^@"},
+		{"bash", "M-h", `\eh`},
+		{"bash", "^R", `\C-r`},
+		{"bash", "^X^R", `\C-x\C-r`},
+		{"bash", "^@", `\C-@`},
+		{"fish", "M-s", `\es`},
+		{"fish", "^R", `\cr`},
+		{"fish", "^@", "-k nul"},
+		{"pwsh", "M-h", "Alt+h"},
+		{"pwsh", "^X^R", "Ctrl+x,Ctrl+r"},
+		{"pwsh", "^@", "Ctrl+Spacebar"},
+		{"nu", "M-h", "modifier: alt keycode: char_h"},
+		{"nu", "^R", "modifier: control keycode: char_r"},
+		{"nu", "^@", "modifier: control keycode: space"},
+	}
+	for _, tt := range tests {
+		_, got, err := shellKeyBinding(tt.shell, tt.spec)
+		if err != nil {
+			t.Errorf("shellKeyBinding(%s, %q) error: %v", tt.shell, tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("shellKeyBinding(%s, %q) = %q, want %q", tt.shell, tt.spec, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ shell, spec string }{
+		{"nu", "^X^R"},
+		{"fish", "^X^@"},
+		{"zsh", "ctrl-r"},
+	} {
+		if _, _, err := shellKeyBinding(tt.shell, tt.spec); err == nil {
+			t.Errorf("shellKeyBinding(%s, %q) should fail", tt.shell, tt.spec)
+		}
+	}
+}
+
+func TestKeybindingReplacements_FallsBackToDefault(t *testing.T) {
+	pairs, warnings := keybindingReplacements("nu", config.KeybindingsConfig{
+		HistoryPicker: "^X^R",
+		SuggestWidget: "^@",
+	})
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "history_picker") {
+		t.Fatalf("expected one history_picker warning, got %v", warnings)
+	}
+	got := strings.NewReplacer(pairs...).Replace(
+		"{{CLAI_KEY_HISTORY_PICKER}}|{{CLAI_KEY_HISTORY_PICKER_LABEL}}|{{CLAI_KEY_SUGGEST_WIDGET}}|{{CLAI_KEY_SUGGEST_WIDGET_LABEL}}")
+	want := "modifier: alt keycode: char_h|Alt+H|modifier: control keycode: space|Ctrl+Space"
+	if got != want {
+		t.Errorf("replacements = %q, want %q", got, want)
+	}
+}
+
+func TestRunInit_HonorsKeybindings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+	cfg := "client:\n  keybindings:\n    history_picker: \"^R\"\n    suggest_widget: \"^@\"\n"
+	if err := os.WriteFile(filepath.Join(home, "config.yaml"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	output := captureStdout(t, func() {
+		if err := runInit(initCmd, []string{"zsh"}); err != nil {
+			t.Fatalf("runInit error: %v", err)
+		}
+	})
+
+	for _, want := range []string{
+		"bindkey '^R' _clai_tui_picker_open",
+		"bindkey '^@' _clai_tui_suggest_picker_open",
+		"bindkey -r '^R'",
+		"Ctrl+Space suggestions | Ctrl+R history",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("zsh script missing %q", want)
+		}
+	}
+	if strings.Contains(output, "{{CLAI_KEY_") {
+		t.Error("zsh script has an unreplaced key binding placeholder")
+	}
+}
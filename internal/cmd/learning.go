@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/config"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/learning"
+)
+
+var (
+	learningShowScope  string
+	learningShowFormat string
+)
+
+var learningCmd = &cobra.Command{
+	Use:     "learning",
+	Short:   "Inspect the ranking weights clai has learned",
+	GroupID: groupSetup,
+	Long: `Inspect the ranking weights clai has learned from your feedback on
+suggestions.
+
+Weights are learned per repository (repo:<key>), per project type
+(project_type:<type>, e.g. project_type:go) and globally. Suggestions are
+ranked with every profile that applies, blended from least to most
+specific: the global profile over the configured suggestions.weights, then
+the project types detected in the current directory, then the repository.
+A profile with few samples only nudges the weights below it; from 200
+samples on it replaces them.
+
+Examples:
+  clai learning show
+  clai learning show --scope repo:clai
+  clai learning show --scope project_type:go --format json`,
+}
+
+var learningShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the learned weights that apply to a scope",
+	Args:  cobra.NoArgs,
+	RunE:  runLearningShow,
+}
+
+func init() {
+	learningShowCmd.Flags().StringVar(&learningShowScope, "scope", learning.ScopeGlobal,
+		"Scope to resolve: global, repo:<key> or project_type:<type>")
+	learningShowCmd.Flags().StringVar(&learningShowFormat, "format", "text", "Output format: text or json")
+	addJSONFlag(learningShowCmd, &learningShowFormat)
+	learningCmd.AddCommand(learningShowCmd)
+}
+
+// learningReport is the output of 'clai learning show'.
+type learningReport struct {
+	Scope      string                   `json:"scope"`
+	Configured learning.Weights         `json:"configured"`
+	Resolved   learning.Weights         `json:"resolved"`
+	Levels     []learning.ResolvedLevel `json:"levels"`
+}
+
+func runLearningShow(cmd *cobra.Command, _ []string) error {
+	format := strings.ToLower(strings.TrimSpace(learningShowFormat))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format: %s (use text or json)", learningShowFormat)
+	}
+	chain, err := learning.ParseScope(learningShowScope)
+	if err != nil {
+		return err
+	}
+
+	dbPath, err := suggestdb.DefaultDBPath()
+	if err != nil {
+		return err
+	}
+	if _, statErr := os.Stat(dbPath); os.IsNotExist(statErr) {
+		return errors.New("no suggestions database yet; run some commands with clai enabled first")
+	}
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+	sdb, err := suggestdb.Open(cmd.Context(), suggestdb.Options{Path: dbPath, ReadOnly: true, SkipLock: true, EncryptionKey: key})
+	if err != nil {
+		return fmt.Errorf("failed to open suggestions database: %w", err)
+	}
+	defer sdb.Close()
+
+	weights := config.DefaultSuggestionsConfig().Weights
+	if cfg, err := config.Load(); err == nil {
+		weights = cfg.Suggestions.Weights
+	}
+	report := learningReport{
+		Scope: learningShowScope,
+		Configured: learning.Weights{
+			Transition:          weights.Transition,
+			Frequency:           weights.Frequency,
+			Success:             weights.Success,
+			Prefix:              weights.Prefix,
+			Affinity:            weights.Affinity,
+			Task:                weights.Task,
+			Feedback:            weights.Feedback,
+			ProjectTypeAffinity: weights.ProjectTypeAffinity,
+			FailureRecovery:     weights.FailureRecovery,
+			RiskPenalty:         weights.RiskPenalty,
+		},
+	}
+	res, err := learning.NewStore(sdb.DB()).Resolve(cmd.Context(), &report.Configured, chain)
+	if err != nil {
+		return err
+	}
+	report.Resolved = res.Weights
+	report.Levels = res.Levels
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	writeLearningText(os.Stdout, &report)
+	return nil
+}
+
+func writeLearningText(w io.Writer, r *learningReport) {
+	fmt.Fprintf(w, "Ranking weights for %s\n", r.Scope)
+	if len(r.Levels) == 0 {
+		fmt.Fprintln(w, "  No learned profiles apply; the configured weights are used.")
+		return
+	}
+
+	fmt.Fprintln(w, "\nProfiles, least to most specific:")
+	for _, l := range r.Levels {
+		fmt.Fprintf(w, "  %-30s %6d samples  %3.0f%% trust\n", l.Scope, l.SampleCount, 100*l.Trust)
+	}
+
+	fmt.Fprintf(w, "\n  %-22s %10s %8s\n", "Weight", "Configured", "Learned")
+	for _, row := range []struct {
+		name                string
+		configured, learned float64
+	}{
+		{"transition", r.Configured.Transition, r.Resolved.Transition},
+		{"frequency", r.Configured.Frequency, r.Resolved.Frequency},
+		{"success", r.Configured.Success, r.Resolved.Success},
+		{"prefix", r.Configured.Prefix, r.Resolved.Prefix},
+		{"affinity", r.Configured.Affinity, r.Resolved.Affinity},
+		{"task", r.Configured.Task, r.Resolved.Task},
+		{"feedback", r.Configured.Feedback, r.Resolved.Feedback},
+		{"project_type_affinity", r.Configured.ProjectTypeAffinity, r.Resolved.ProjectTypeAffinity},
+		{"failure_recovery", r.Configured.FailureRecovery, r.Resolved.FailureRecovery},
+		{"risk_penalty", r.Configured.RiskPenalty, r.Resolved.RiskPenalty},
+	} {
+		fmt.Fprintf(w, "  %-22s %10.3f %8.3f\n", row.name, row.configured, row.learned)
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/runger/clai/internal/suggestions/learning"
+)
+
+func TestWriteLearningText_NoProfiles(t *testing.T) {
+	var buf bytes.Buffer
+	writeLearningText(&buf, &learningReport{Scope: "repo:clai"})
+
+	out := buf.String()
+	if !strings.Contains(out, "Ranking weights for repo:clai") {
+		t.Errorf("missing header:\n%s", out)
+	}
+	if !strings.Contains(out, "No learned profiles apply") {
+		t.Errorf("missing empty notice:\n%s", out)
+	}
+}
+
+func TestWriteLearningText(t *testing.T) {
+	r := &learningReport{
+		Scope:      "repo:clai",
+		Configured: learning.Weights{Transition: 0.3, Frequency: 0.2},
+		Resolved:   learning.Weights{Transition: 0.45, Frequency: 0.2},
+		Levels: []learning.ResolvedLevel{
+			{Scope: "global", SampleCount: 50, Trust: 0.25},
+			{Scope: "repo:clai", SampleCount: 200, Trust: 1},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeLearningText(&buf, r)
+
+	out := buf.String()
+	for _, want := range []string{
+		"global",
+		"50 samples   25% trust",
+		"repo:clai",
+		"100% trust",
+		"transition                  0.300    0.450",
+		"risk_penalty",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -42,9 +42,9 @@ func init() {
 
 func runLogs(cmd *cobra.Command, args []string) error {
 	paths := config.DefaultPaths()
-	logFile, ok := resolveLogFile(paths)
+	logFile, ok := resolveLogFile(paths, configuredLogFile(paths))
 	if !ok {
-		fmt.Printf("No log file found at: %s\n", paths.LogFile())
+		fmt.Printf("No log file found at: %s\n", logFile)
 		fmt.Println("The daemon may not have been started yet.")
 		return nil
 	}
@@ -56,7 +56,23 @@ func runLogs(cmd *cobra.Command, args []string) error {
 	return tailLogs(logFile, logsLines)
 }
 
-func resolveLogFile(paths *config.Paths) (string, bool) {
+// configuredLogFile returns daemon.log_file from the config file, or "" when
+// the default location (or stderr) is used.
+func configuredLogFile(paths *config.Paths) string {
+	cfg, err := config.LoadFromFile(paths.ConfigFile())
+	if err != nil || cfg.Daemon.LogFile == "-" {
+		return ""
+	}
+	return cfg.Daemon.LogFile
+}
+
+// resolveLogFile returns the daemon log file to read. A configured path takes
+// precedence; otherwise the default location and then the legacy one are tried.
+func resolveLogFile(paths *config.Paths, configured string) (string, bool) {
+	if configured != "" {
+		_, err := os.Stat(configured)
+		return configured, err == nil
+	}
 	primary := paths.LogFile()
 	if _, err := os.Stat(primary); err == nil {
 		return primary, true
@@ -185,7 +201,7 @@ func followLogs(ctx context.Context, filename string) error {
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %w", err)
 	}
-	defer f.Close()
+	defer func() { f.Close() }()
 
 	// Seek to end
 	_, err = f.Seek(0, io.SeekEnd)
@@ -212,6 +228,13 @@ func followLogs(ctx context.Context, filename string) error {
 				if line != "" {
 					fmt.Print(line)
 				}
+				// The daemon rotated the log: continue from the new file.
+				if next, ok := reopenIfRotated(f, filename); ok {
+					f.Close()
+					f = next
+					reader.Reset(f)
+					continue
+				}
 				select {
 				case <-ctx.Done():
 					return ctx.Err()
@@ -225,3 +248,21 @@ func followLogs(ctx context.Context, filename string) error {
 		fmt.Print(line)
 	}
 }
+
+// reopenIfRotated opens filename when it no longer refers to the file f has
+// open, i.e. after the daemon rotated its log.
+func reopenIfRotated(f *os.File, filename string) (*os.File, bool) {
+	current, err := f.Stat()
+	if err != nil {
+		return nil, false
+	}
+	latest, err := os.Stat(filename)
+	if err != nil || os.SameFile(current, latest) {
+		return nil, false
+	}
+	next, err := os.Open(filename) //nolint:gosec // G304: log file path from trusted config
+	if err != nil {
+		return nil, false
+	}
+	return next, true
+}
@@ -26,7 +26,7 @@ func TestResolveLogFile_PrimaryPreferred(t *testing.T) {
 		t.Fatalf("WriteFile(legacy) error = %v", err)
 	}
 
-	got, ok := resolveLogFile(paths)
+	got, ok := resolveLogFile(paths, "")
 	if !ok {
 		t.Fatal("resolveLogFile() ok = false, want true")
 	}
@@ -45,7 +45,7 @@ func TestResolveLogFile_FallbackLegacy(t *testing.T) {
 		t.Fatalf("WriteFile(legacy) error = %v", err)
 	}
 
-	got, ok := resolveLogFile(paths)
+	got, ok := resolveLogFile(paths, "")
 	if !ok {
 		t.Fatal("resolveLogFile() ok = false, want true")
 	}
@@ -60,7 +60,7 @@ func TestResolveLogFile_NotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	paths := &config.Paths{BaseDir: tmpDir}
 
-	got, ok := resolveLogFile(paths)
+	got, ok := resolveLogFile(paths, "")
 	if ok {
 		t.Fatal("resolveLogFile() ok = true, want false")
 	}
@@ -121,3 +121,52 @@ func TestCollectTailLines_PropagatesReadError(t *testing.T) {
 		t.Fatal("expected error from closed file")
 	}
 }
+
+func TestResolveLogFile_ConfiguredPath(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	paths := &config.Paths{BaseDir: tmpDir}
+	custom := filepath.Join(tmpDir, "custom.log")
+
+	if _, ok := resolveLogFile(paths, custom); ok {
+		t.Fatal("resolveLogFile() ok = true for missing configured file")
+	}
+	if err := os.WriteFile(custom, []byte("custom"), 0o644); err != nil {
+		t.Fatalf("WriteFile(custom) error = %v", err)
+	}
+	got, ok := resolveLogFile(paths, custom)
+	if !ok || got != custom {
+		t.Fatalf("resolveLogFile() = (%q, %v), want (%q, true)", got, ok, custom)
+	}
+}
+
+func TestReopenIfRotated(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "claid.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, ok := reopenIfRotated(f, path); ok {
+		t.Fatal("reopenIfRotated() reported rotation for the same file")
+	}
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	next, ok := reopenIfRotated(f, path)
+	if !ok {
+		t.Fatal("reopenIfRotated() did not detect rotation")
+	}
+	next.Close()
+}
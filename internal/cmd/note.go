@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/ipc"
+)
+
+var noteClear bool
+
+var noteCmd = &cobra.Command{
+	Use:     "note [text]",
+	Short:   "Attach a note to this shell session for AI context",
+	GroupID: groupCore,
+	Long: `Attach a short free-text note to the current shell session.
+
+The note is sanitized and included in AI prompts (text-to-command and
+diagnose) for the rest of the session, so generated commands take your
+current task into account. Notes live in the daemon only and end with
+the session.
+
+Examples:
+  clai note "we're debugging the flaky auth test"
+  clai note            # show the current note
+  clai note --clear    # remove the note`,
+	RunE: runNote,
+}
+
+func init() {
+	noteCmd.Flags().BoolVar(&noteClear, "clear", false, "Remove the session note")
+}
+
+func runNote(cmd *cobra.Command, args []string) error {
+	note := strings.TrimSpace(strings.Join(args, " "))
+	if noteClear && note != "" {
+		return errors.New("--clear cannot be combined with note text")
+	}
+
+	sessionID := os.Getenv("CLAI_SESSION_ID")
+	if sessionID == "" {
+		return errors.New("no active clai session (CLAI_SESSION_ID is not set)")
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		return fmt.Errorf("daemon not available: %w", err)
+	}
+	defer client.Close()
+
+	current, err := client.SessionNote(context.Background(), sessionID, note, noteClear)
+	if err != nil {
+		return fmt.Errorf("failed to update session note: %w", err)
+	}
+
+	switch {
+	case noteClear:
+		fmt.Println("Session note cleared")
+	case note != "":
+		fmt.Printf("Session note set: %s\n", current)
+	case current == "":
+		fmt.Printf("%sNo session note set%s\n", colorDim, colorReset)
+	default:
+		fmt.Println(current)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunNote_RequiresSession(t *testing.T) {
+	t.Setenv("CLAI_SESSION_ID", "")
+
+	err := runNote(noteCmd, []string{"debugging auth"})
+	if err == nil || !strings.Contains(err.Error(), "CLAI_SESSION_ID") {
+		t.Fatalf("runNote() error = %v, want missing session error", err)
+	}
+}
+
+func TestRunNote_ClearWithTextRejected(t *testing.T) {
+	t.Setenv("CLAI_SESSION_ID", "session-1")
+	noteClear = true
+	t.Cleanup(func() { noteClear = false })
+
+	err := runNote(noteCmd, []string{"text"})
+	if err == nil || !strings.Contains(err.Error(), "--clear") {
+		t.Fatalf("runNote() error = %v, want --clear conflict error", err)
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// Commands with machine-readable output take --format json, with --json as
+// shorthand. Field names of the JSON output are snake_case and stable:
+// fields may be added, but are not renamed or removed.
+
+// jsonFlag is the --json flag of a command with a --format flag. Setting it
+// sets the format to json.
+type jsonFlag struct {
+	format *string
+	set    bool
+}
+
+func (f *jsonFlag) String() string { return strconv.FormatBool(f.set) }
+
+func (f *jsonFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	f.set = v
+	if v {
+		*f.format = "json"
+	}
+	return nil
+}
+
+func (f *jsonFlag) Type() string { return "bool" }
+
+// addJSONFlag adds --json to cmd as shorthand for --format json, storing
+// into format, the variable of its --format flag.
+func addJSONFlag(cmd *cobra.Command, format *string) {
+	flag := cmd.Flags().VarPF(&jsonFlag{format: format}, "json", "", "Output JSON (shorthand for --format json)")
+	flag.NoOptDefVal = "true"
+	cmd.MarkFlagsMutuallyExclusive("json", "format")
+}
+
+// writeJSON writes v as indented JSON, leaving <, > and & unescaped so
+// commands read as typed.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestAddJSONFlag(t *testing.T) {
+	t.Parallel()
+
+	var format string
+	newCmd := func() *cobra.Command {
+		format = ""
+		c := &cobra.Command{Use: "x", RunE: func(*cobra.Command, []string) error { return nil }}
+		c.Flags().StringVar(&format, "format", "text", "")
+		addJSONFlag(c, &format)
+		c.SetOut(new(bytes.Buffer))
+		c.SetErr(new(bytes.Buffer))
+		return c
+	}
+
+	for _, tt := range []struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{args: nil, want: "text"},
+		{args: []string{"--json"}, want: "json"},
+		{args: []string{"--json=false"}, want: "text"},
+		{args: []string{"--format", "json"}, want: "json"},
+		{args: []string{"--json", "--format", "text"}, wantErr: true},
+	} {
+		c := newCmd()
+		c.SetArgs(tt.args)
+		err := c.Execute()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%v: Execute() = nil error, want --json and --format to conflict", tt.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Execute() error = %v", tt.args, err)
+		} else if format != tt.want {
+			t.Errorf("%v: format = %q, want %q", tt.args, format, tt.want)
+		}
+	}
+}
+
+func TestWriteJSON_NoHTMLEscape(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if err := writeJSON(&out, map[string]string{"command": "make && ls > out"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"command\": \"make && ls > out\"\n}\n"; out.String() != want {
+		t.Errorf("writeJSON() = %q, want %q", out.String(), want)
+	}
+}
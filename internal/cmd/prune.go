@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/ipc"
+	"github.com/runger/clai/internal/sanitize"
+	"github.com/runger/clai/internal/storage"
+)
+
+// pruneSampleLimit is how many commands of a category prune lists.
+const pruneSampleLimit = 10
+
+var (
+	pruneTypos     bool
+	pruneLong      bool
+	pruneSensitive bool
+	pruneMinLength int
+	pruneOlderThan string
+	pruneDryRun    bool
+	pruneYes       bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:     "prune",
+	Short:   "Clean typos, one-off commands and secrets out of history",
+	GroupID: groupCore,
+	Long: `Find history that is unlikely to be useful again and remove it in bulk.
+
+Candidates fall into three categories:
+  typos      Commands that exited 127 (command not found) every time they ran
+  long       Long commands that ran only once, and not recently
+  sensitive  Commands that match a secret pattern, including the
+             suggestions.redact_patterns of your config
+
+Each category is listed with how many commands and runs it covers, and
+confirmed on its own before anything is removed. Only the listed commands
+are removed, matched by their whole text: typos and long commands are
+hidden, as 'clai history delete' hides them; sensitive commands are erased
+with everything learned from them, as with --purge.
+
+Without a category flag, all three are considered.
+
+Examples:
+  clai prune                         # Review every category
+  clai prune --typos --yes           # Hide typos without asking
+  clai prune --long --min-length 200 --older-than 30d
+  clai prune --dry-run               # Only list the candidates`,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+func init() {
+	pruneCmd.Flags().BoolVar(&pruneTypos, "typos", false, "Consider commands that always exited 127")
+	pruneCmd.Flags().BoolVar(&pruneLong, "long", false, "Consider long commands that ran only once")
+	pruneCmd.Flags().BoolVar(&pruneSensitive, "sensitive", false, "Consider commands matching a secret pattern")
+	pruneCmd.Flags().IntVar(&pruneMinLength, "min-length", 120, "Minimum length in characters of a long command")
+	pruneCmd.Flags().StringVar(&pruneOlderThan, "older-than", "7d", "Only consider long commands last run before this (e.g. 7d, 12h, 2026-01-31)")
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "List the candidates without removing them")
+	pruneCmd.Flags().BoolVarP(&pruneYes, "yes", "y", false, "Do not ask for confirmation")
+}
+
+// pruneRemover is the part of the daemon client pruneHistory uses.
+type pruneRemover interface {
+	DeleteExactCommand(ctx context.Context, command string, dryRun bool) (*pb.DeleteCommandResponse, error)
+	PurgeExactCommand(ctx context.Context, command string, dryRun bool) (*pb.DeleteCommandResponse, error)
+}
+
+// pruneOptions are the flags of 'clai prune'.
+type pruneOptions struct {
+	typos     bool
+	long      bool
+	sensitive bool
+	minLength int
+	cutoffMs  int64 // Long commands must have last run before this
+	dryRun    bool
+	yes       bool
+}
+
+// pruneCategory is a kind of junk history and the commands found for it.
+type pruneCategory struct {
+	name     string
+	reason   string
+	purge    bool
+	commands []storage.CommandSummary
+}
+
+// runs returns how many times the commands of c ran in total.
+func (c *pruneCategory) runs() int64 {
+	var n int64
+	for _, s := range c.commands {
+		n += s.Runs
+	}
+	return n
+}
+
+func runPrune(cmd *cobra.Command, _ []string) error {
+	if pruneMinLength < 1 {
+		return fmt.Errorf("invalid --min-length: %d (must be at least 1)", pruneMinLength)
+	}
+	cutoff, err := parseSince(pruneOlderThan, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid --older-than: %s (use a duration such as 7d, 12h or 2w, or a date such as 2026-01-31)", pruneOlderThan)
+	}
+
+	opts := pruneOptions{
+		typos:     pruneTypos,
+		long:      pruneLong,
+		sensitive: pruneSensitive,
+		minLength: pruneMinLength,
+		cutoffMs:  cutoff.UnixMilli(),
+		dryRun:    pruneDryRun,
+		yes:       pruneYes,
+	}
+	if !opts.typos && !opts.long && !opts.sensitive {
+		opts.typos, opts.long, opts.sensitive = true, true, true
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	redactor, err := sanitize.NewCommandSanitizer(cfg.Suggestions.RedactPatterns)
+	if err != nil {
+		return err
+	}
+
+	paths := config.DefaultPaths()
+	if _, err := os.Stat(paths.DatabaseFile()); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no history yet: database not found at %s", paths.DatabaseFile())
+	}
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+	store, err := storage.NewSQLiteStoreWithKey(paths.DatabaseFile(), key)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	summaries, err := store.SummarizeCommands(cmd.Context())
+	store.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	categories := findPruneCandidates(summaries, redactor.Sanitize, opts)
+
+	// Listing candidates needs only the database; removing them goes
+	// through the daemon so the suggestion data is cleaned as well.
+	var client pruneRemover
+	if !opts.dryRun && len(categories) > 0 {
+		c, err := ipc.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+		defer c.Close()
+		client = c
+	}
+	return pruneHistory(cmd.Context(), client, categories, redactor.Sanitize, opts, os.Stdin, os.Stdout)
+}
+
+// findPruneCandidates sorts the summarized commands into the categories
+// opts asks for. A command is sensitive when redact changes it. Each command
+// lands in at most one category, the first of sensitive, typos and long it
+// qualifies for, so a secret is never merely hidden.
+func findPruneCandidates(summaries []storage.CommandSummary, redact func(string) string, opts pruneOptions) []*pruneCategory {
+	typos := &pruneCategory{name: "Typos", reason: "always exited 127"}
+	long := &pruneCategory{name: "One-off long commands", reason: fmt.Sprintf("ran once, at least %d characters", opts.minLength)}
+	sensitive := &pruneCategory{name: "Sensitive commands", reason: "match a secret pattern", purge: true}
+
+	for _, s := range summaries {
+		switch {
+		case redact(s.Command) != s.Command:
+			if opts.sensitive {
+				sensitive.commands = append(sensitive.commands, s)
+			}
+		case s.NotFound > 0 && s.NotFound == s.Runs:
+			if opts.typos {
+				typos.commands = append(typos.commands, s)
+			}
+		case s.Runs == 1 && utf8.RuneCountInString(s.Command) >= opts.minLength && s.LastUnixMs < opts.cutoffMs:
+			if opts.long {
+				long.commands = append(long.commands, s)
+			}
+		}
+	}
+
+	var categories []*pruneCategory
+	for _, c := range []*pruneCategory{typos, long, sensitive} {
+		if len(c.commands) > 0 {
+			categories = append(categories, c)
+		}
+	}
+	return categories
+}
+
+// pruneHistory lists each category, asks for confirmation on in unless
+// opts.yes is set, and removes its commands through client. Sensitive
+// commands are listed redacted.
+func pruneHistory(ctx context.Context, client pruneRemover, categories []*pruneCategory, redact func(string) string, opts pruneOptions, in io.Reader, out io.Writer) error {
+	if len(categories) == 0 {
+		fmt.Fprintln(out, "Nothing to prune.")
+		return nil
+	}
+
+	// One reader for all prompts, so answers typed ahead are not lost.
+	in = bufio.NewReader(in)
+	for i, c := range categories {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s%s%s: %d commands, %d runs %s(%s)%s\n",
+			colorBold, c.name, colorReset, len(c.commands), c.runs(), colorDim, c.reason, colorReset)
+		for _, s := range c.commands[:min(len(c.commands), pruneSampleLimit)] {
+			command := s.Command
+			if c.purge {
+				command = redact(command)
+			}
+			fmt.Fprintf(out, "  %s\n", command)
+		}
+		if more := len(c.commands) - pruneSampleLimit; more > 0 {
+			fmt.Fprintf(out, "  %s… and %d more%s\n", colorDim, more, colorReset)
+		}
+		if opts.dryRun {
+			continue
+		}
+		if !opts.yes && !confirmDelete(in, out, c.purge) {
+			fmt.Fprintln(out, "Nothing removed.")
+			continue
+		}
+
+		remove, verb := client.DeleteExactCommand, "Deleted"
+		if c.purge {
+			remove, verb = client.PurgeExactCommand, "Purged"
+		}
+		var commands, events int64
+		for _, s := range c.commands {
+			resp, err := remove(ctx, s.Command, false)
+			if err != nil {
+				return fmt.Errorf("failed to remove commands: %w", err)
+			}
+			commands += int64(resp.Commands)
+			events += int64(resp.Events)
+		}
+		fmt.Fprintf(out, "%s %d history commands and %d suggestion events.\n", verb, commands, events)
+	}
+	return nil
+}
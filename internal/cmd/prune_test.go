@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/storage"
+)
+
+type fakePruneRemover struct {
+	calls []string // "delete <command>" or "purge <command>"
+}
+
+func (f *fakePruneRemover) DeleteExactCommand(_ context.Context, command string, _ bool) (*pb.DeleteCommandResponse, error) {
+	f.calls = append(f.calls, "delete "+command)
+	return &pb.DeleteCommandResponse{Commands: 1, Events: 2}, nil
+}
+
+func (f *fakePruneRemover) PurgeExactCommand(_ context.Context, command string, _ bool) (*pb.DeleteCommandResponse, error) {
+	f.calls = append(f.calls, "purge "+command)
+	return &pb.DeleteCommandResponse{Commands: 3, Events: 4}, nil
+}
+
+// redactHunter2 stands in for the command sanitizer.
+func redactHunter2(s string) string {
+	return strings.ReplaceAll(s, "hunter2", "[REDACTED]")
+}
+
+var pruneSummaries = []storage.CommandSummary{
+	{Command: "gti status", Runs: 3, NotFound: 3, LastUnixMs: 900},
+	{Command: "git status", Runs: 5, NotFound: 0, LastUnixMs: 900},
+	{Command: "sl", Runs: 2, NotFound: 1, LastUnixMs: 900},
+	{Command: "curl -u admin:hunter2 https://example.com", Runs: 1, NotFound: 0, LastUnixMs: 100},
+	{Command: "hunter2", Runs: 1, NotFound: 1, LastUnixMs: 100},
+	{Command: "ffmpeg -i in.mov -vf scale=1280:-1 out.mp4", Runs: 1, LastUnixMs: 100},
+	{Command: "ffmpeg -i in.mov -vf scale=640:-1 out.mp4", Runs: 1, LastUnixMs: 950},
+	{Command: "make build", Runs: 1, LastUnixMs: 100},
+}
+
+func TestFindPruneCandidates(t *testing.T) {
+	t.Parallel()
+
+	all := pruneOptions{typos: true, long: true, sensitive: true, minLength: 30, cutoffMs: 500}
+	onlyTypos := pruneOptions{typos: true, minLength: 30, cutoffMs: 500}
+
+	tests := []struct {
+		name string
+		opts pruneOptions
+		want map[string][]string
+	}{
+		{"all categories", all, map[string][]string{
+			"Typos":                 {"gti status"},
+			"One-off long commands": {"ffmpeg -i in.mov -vf scale=1280:-1 out.mp4"},
+			"Sensitive commands":    {"curl -u admin:hunter2 https://example.com", "hunter2"},
+		}},
+		// A secret that also failed to run is never offered as a typo.
+		{"typos only", onlyTypos, map[string][]string{
+			"Typos": {"gti status"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := map[string][]string{}
+			for _, c := range findPruneCandidates(pruneSummaries, redactHunter2, tt.opts) {
+				for _, s := range c.commands {
+					got[c.name] = append(got[c.name], s.Command)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("categories = %v, want %v", got, tt.want)
+			}
+			for name, want := range tt.want {
+				if strings.Join(got[name], "|") != strings.Join(want, "|") {
+					t.Errorf("%s = %v, want %v", name, got[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPruneHistory(t *testing.T) {
+	t.Parallel()
+
+	opts := pruneOptions{typos: true, long: true, sensitive: true, minLength: 30, cutoffMs: 500}
+
+	tests := []struct {
+		name      string
+		opts      func(pruneOptions) pruneOptions
+		input     string
+		wantCalls []string
+		wantOut   []string
+	}{
+		{
+			name:  "confirm each category",
+			input: "y\nn\ny\n",
+			wantCalls: []string{
+				"delete gti status",
+				"purge curl -u admin:hunter2 https://example.com",
+				"purge hunter2",
+			},
+			wantOut: []string{
+				"Typos: 1 commands, 3 runs",
+				"Deleted 1 history commands and 2 suggestion events.",
+				"Nothing removed.",
+				"Purged 6 history commands and 8 suggestion events.",
+			},
+		},
+		{
+			name:      "dry run",
+			opts:      func(o pruneOptions) pruneOptions { o.dryRun = true; return o },
+			wantCalls: nil,
+			wantOut:   []string{"Sensitive commands: 2 commands, 2 runs", "  curl -u admin:[REDACTED] https://example.com"},
+		},
+		{
+			name:      "yes",
+			opts:      func(o pruneOptions) pruneOptions { o.yes = true; o.sensitive = false; return o },
+			wantCalls: []string{"delete gti status", "delete ffmpeg -i in.mov -vf scale=1280:-1 out.mp4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			o := opts
+			if tt.opts != nil {
+				o = tt.opts(o)
+			}
+			client := &fakePruneRemover{}
+			var out bytes.Buffer
+			categories := findPruneCandidates(pruneSummaries, redactHunter2, o)
+			if err := pruneHistory(context.Background(), client, categories, redactHunter2, o, strings.NewReader(tt.input), &out); err != nil {
+				t.Fatalf("pruneHistory() error = %v", err)
+			}
+			if strings.Join(client.calls, ",") != strings.Join(tt.wantCalls, ",") {
+				t.Errorf("calls = %v, want %v", client.calls, tt.wantCalls)
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output %q does not contain %q", out.String(), want)
+				}
+			}
+			if strings.Contains(out.String(), "hunter2") {
+				t.Errorf("output %q shows a secret", out.String())
+			}
+		})
+	}
+}
+
+func TestPruneHistory_NothingToPrune(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	err := pruneHistory(context.Background(), nil, nil, redactHunter2, pruneOptions{}, strings.NewReader(""), &out)
+	if err != nil {
+		t.Fatalf("pruneHistory() error = %v", err)
+	}
+	if out.String() != "Nothing to prune.\n" {
+		t.Errorf("output = %q, want %q", out.String(), "Nothing to prune.")
+	}
+}
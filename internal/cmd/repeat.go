@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/ipc"
+)
+
+// recallPageSize is how many history entries 'clai last' and 'clai repeat'
+// fetch from the daemon at a time.
+const recallPageSize = 50
+
+var (
+	recallNth     int
+	recallSession string
+	recallGlobal  bool
+	recallEdit    bool
+)
+
+var lastCmd = &cobra.Command{
+	Use:     "last [pattern...]",
+	Short:   "Print a recent command from history",
+	GroupID: groupCore,
+	Long: `Print the most recent command containing the pattern, or the N-th most
+recent with -n, from the history the daemon keeps for all terminals.
+Each command counts once, however often it ran.
+
+Like 'clai history', the current session is searched unless -g/--global or
+--session is given, so -g finds commands run in other terminals.
+'clai last' and 'clai repeat' themselves are skipped.
+
+Examples:
+  clai last                  # The previous command, like !!
+  clai last -n 2 make        # The second most recent command containing "make"
+  clai last -g deploy        # From any terminal
+  clai last > step.sh        # Save it for a script`,
+	Args:         cobra.ArbitraryArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command, err := recallCommand(cmd.Context(), args)
+		if err != nil {
+			return err
+		}
+		fmt.Println(command)
+		return nil
+	},
+}
+
+var repeatCmd = &cobra.Command{
+	Use:     "repeat [pattern...]",
+	Short:   "Run a recent command from history again",
+	GroupID: groupCore,
+	Long: `Run the command 'clai last' would print, with the same pattern and flags,
+in the current directory through $SHELL. The command is echoed before it
+runs. With --edit it is opened in $VISUAL or $EDITOR first, and the saved
+text is run.
+
+Shell aliases and functions are not available to the command, since it
+runs in a new, non-interactive shell.
+
+Examples:
+  clai repeat                # Run the previous command again
+  clai repeat -g make test   # The latest "make test" from any terminal
+  clai repeat --edit deploy  # Adjust the latest deploy command, then run it`,
+	Args:         cobra.ArbitraryArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command, err := recallCommand(cmd.Context(), args)
+		if err != nil {
+			return err
+		}
+		return runShellCommand(os.Stderr, command)
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{lastCmd, repeatCmd} {
+		c.Flags().IntVarP(&recallNth, "nth", "n", 1, "Pick the N-th most recent match")
+		c.Flags().StringVar(&recallSession, "session", "", "Search this session ID instead of the current one")
+		_ = c.RegisterFlagCompletionFunc("session", completeSessionIDs)
+		c.Flags().BoolVarP(&recallGlobal, "global", "g", false, "Search across all sessions")
+		c.Flags().BoolVarP(&recallEdit, "edit", "e", false, "Edit the command in $EDITOR first")
+	}
+}
+
+// recallCommand finds the command selected by the pattern words and flags,
+// editing it first with --edit.
+func recallCommand(ctx context.Context, args []string) (string, error) {
+	if recallNth <= 0 {
+		return "", errors.New("invalid --nth: must be > 0")
+	}
+	req := &pb.HistoryFetchRequest{Query: strings.Join(args, " ")}
+	switch {
+	case recallSession != "":
+		req.SessionId = recallSession
+	case recallGlobal:
+		req.Global = true
+	default:
+		req.SessionId = os.Getenv("CLAI_SESSION_ID")
+		req.Global = req.SessionId == ""
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	command, err := findRecentCommand(ctx, client, req, recallNth)
+	if err != nil || !recallEdit {
+		return command, err
+	}
+	return editCommand(command, runEditor)
+}
+
+// findRecentCommand returns the nth most recent command matching req,
+// paging through history and skipping invocations of 'clai last' and
+// 'clai repeat'.
+func findRecentCommand(ctx context.Context, client historySearcher, req *pb.HistoryFetchRequest, nth int) (string, error) {
+	req.Limit = recallPageSize
+	seen := 0
+	for {
+		resp, err := client.FetchHistory(ctx, req)
+		if err != nil {
+			return "", fmt.Errorf("failed to search history: %w", err)
+		}
+		for _, it := range resp.Items {
+			if isRecallCommand(it.Command) {
+				continue
+			}
+			if seen++; seen == nth {
+				return it.Command, nil
+			}
+		}
+		if resp.AtEnd || len(resp.Items) == 0 {
+			break
+		}
+		req.Offset += int32(len(resp.Items)) //nolint:gosec // G115: a page offset
+	}
+
+	what := "no command"
+	if req.Query != "" {
+		what = fmt.Sprintf("no command containing %q", req.Query)
+	}
+	if nth > 1 {
+		return "", fmt.Errorf("%s at position %d in history (found %d)", what, nth, seen)
+	}
+	return "", fmt.Errorf("%s in history", what)
+}
+
+// isRecallCommand reports whether command runs 'clai last' or 'clai repeat',
+// which would otherwise find themselves.
+func isRecallCommand(command string) bool {
+	fields := strings.Fields(command)
+	return len(fields) >= 2 && fields[0] == "clai" && (fields[1] == "last" || fields[1] == "repeat")
+}
+
+// editCommand lets the user change command with edit, which opens a file in
+// an editor, and returns the saved text.
+func editCommand(command string, edit func(string) error) (string, error) {
+	f, err := os.CreateTemp("", "clai-command-*.sh")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(command + "\n"); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	if err := edit(f.Name()); err != nil {
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	edited := strings.TrimSpace(string(data))
+	if edited == "" {
+		return "", errors.New("the command is empty, nothing to do")
+	}
+	return edited, nil
+}
+
+// runShellCommand echoes command to echo and runs it with $SHELL -c,
+// connected to the terminal.
+func runShellCommand(echo io.Writer, command string) error {
+	fmt.Fprintf(echo, "%s%s%s\n", colorDim, command, colorReset)
+	shell := cmp.Or(os.Getenv("SHELL"), "/bin/sh")
+	c := exec.Command(shell, "-c", command) //nolint:gosec // G204: the user's own command from history
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := c.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("command exited with status %d", exitErr.ExitCode())
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+// pagedHistorySearcher serves its commands a page at a time, like the daemon.
+type pagedHistorySearcher struct {
+	commands []string
+	requests []*pb.HistoryFetchRequest
+}
+
+func (p *pagedHistorySearcher) FetchHistory(_ context.Context, req *pb.HistoryFetchRequest) (*pb.HistoryFetchResponse, error) {
+	p.requests = append(p.requests, &pb.HistoryFetchRequest{Query: req.Query, Offset: req.Offset, Limit: req.Limit})
+	start := min(int(req.Offset), len(p.commands))
+	end := min(start+int(req.Limit), len(p.commands))
+	resp := &pb.HistoryFetchResponse{AtEnd: end == len(p.commands)}
+	for _, c := range p.commands[start:end] {
+		resp.Items = append(resp.Items, &pb.HistoryItem{Command: c})
+	}
+	return resp, nil
+}
+
+func TestFindRecentCommand(t *testing.T) {
+	t.Parallel()
+
+	commands := []string{"clai last", "clai repeat -g make"}
+	for i := range 2 * recallPageSize {
+		commands = append(commands, "make target-"+string(rune('a'+i%26)))
+	}
+	client := &pagedHistorySearcher{commands: commands}
+
+	got, err := findRecentCommand(context.Background(), client, &pb.HistoryFetchRequest{Query: "make"}, 1)
+	if err != nil || got != "make target-a" {
+		t.Errorf("findRecentCommand(1) = %q, %v, want the latest command that is not clai last/repeat", got, err)
+	}
+
+	client.requests = nil
+	got, err = findRecentCommand(context.Background(), client, &pb.HistoryFetchRequest{Query: "make"}, recallPageSize+1)
+	if err != nil || got != commands[recallPageSize+2] {
+		t.Errorf("findRecentCommand(%d) = %q, %v, want %q", recallPageSize+1, got, err, commands[recallPageSize+2])
+	}
+	if len(client.requests) != 2 || client.requests[1].Offset != recallPageSize {
+		t.Errorf("requests = %v, want a second page at offset %d", client.requests, recallPageSize)
+	}
+
+	_, err = findRecentCommand(context.Background(), client, &pb.HistoryFetchRequest{Query: "make"}, 500)
+	if err == nil || !strings.Contains(err.Error(), `no command containing "make" at position 500 in history (found 100)`) {
+		t.Errorf("findRecentCommand(500) error = %v", err)
+	}
+	_, err = findRecentCommand(context.Background(), &pagedHistorySearcher{}, &pb.HistoryFetchRequest{}, 1)
+	if err == nil || err.Error() != "no command in history" {
+		t.Errorf("empty history error = %v", err)
+	}
+}
+
+func TestIsRecallCommand(t *testing.T) {
+	t.Parallel()
+
+	for cmd, want := range map[string]bool{
+		"clai last":           true,
+		"  clai repeat -g ls": true,
+		"clai lastly":         false,
+		"clai history":        false,
+		"echo clai last":      false,
+	} {
+		if got := isRecallCommand(cmd); got != want {
+			t.Errorf("isRecallCommand(%q) = %v, want %v", cmd, got, want)
+		}
+	}
+}
+
+func TestEditCommand(t *testing.T) {
+	t.Parallel()
+
+	var seen string
+	edited, err := editCommand("make test", func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		seen = string(data)
+		return os.WriteFile(path, []byte("make test -j8\n\n"), 0o600)
+	})
+	if err != nil || edited != "make test -j8" {
+		t.Errorf("editCommand() = %q, %v", edited, err)
+	}
+	if seen != "make test\n" {
+		t.Errorf("editor got %q", seen)
+	}
+
+	_, err = editCommand("make test", func(path string) error {
+		return os.WriteFile(path, nil, 0o600)
+	})
+	if err == nil {
+		t.Error("emptied command: editCommand() = nil error")
+	}
+}
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/config"
 )
 
 // Command group IDs
@@ -16,14 +20,30 @@ var rootCmd = &cobra.Command{
 	Long: `clai - fish-like intelligence for any shell
   - ?describe task → get the right command
   - ↑↓ smart suggestions matching context`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if profileFlag == "" {
+			return nil
+		}
+		if err := config.ValidateProfile(profileFlag); err != nil {
+			return err
+		}
+		// Through the environment so a daemon spawned by this command
+		// runs under the same profile.
+		return os.Setenv(config.ProfileEnv, profileFlag)
+	},
 }
 
+// profileFlag is --profile, which overrides CLAI_PROFILE.
+var profileFlag string
+
 // Execute runs the root command
 func Execute() error {
 	return rootCmd.Execute()
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "use this profile and its preset (overrides CLAI_PROFILE)")
+
 	// Define command groups
 	rootCmd.AddGroup(
 		&cobra.Group{ID: groupCore, Title: "Core Commands:"},
@@ -35,23 +55,45 @@ func init() {
 	rootCmd.AddCommand(cmdCmd)
 	rootCmd.AddCommand(suggestCmd)
 	rootCmd.AddCommand(suggestFeedbackCmd)
+	rootCmd.AddCommand(suggestSlotsCmd)
+	rootCmd.AddCommand(suggestInlineCmd)
 	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(lastCmd)
+	rootCmd.AddCommand(repeatCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(noteCmd)
+	rootCmd.AddCommand(sessionCmd)
+	rootCmd.AddCommand(feedbackCmd)
+	rootCmd.AddCommand(whyCmd)
+	rootCmd.AddCommand(explainCmd)
 	rootCmd.AddCommand(onCmd)
 	rootCmd.AddCommand(offCmd)
 	rootCmd.AddCommand(workflowCmd)
 
 	// Setup commands
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(doctorCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(setupCmd)
 	rootCmd.AddCommand(installCmd)
 	rootCmd.AddCommand(uninstallCmd)
 	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(tmuxCmd)
+	rootCmd.AddCommand(scopeCmd)
+	rootCmd.AddCommand(learningCmd)
+	rootCmd.AddCommand(benchmarkCmd)
+	rootCmd.AddCommand(suggestionsCmd)
+	rootCmd.AddCommand(featuresCmd)
+	rootCmd.AddCommand(changelogCmd)
 	rootCmd.AddCommand(versionCmd)
 
 	// Hidden commands (still functional but not shown in help)
 	rootCmd.AddCommand(daemonCmd)       // Go daemon (claid)
 	rootCmd.AddCommand(claudeDaemonCmd) // Claude CLI daemon
 	rootCmd.AddCommand(logsCmd)
-	rootCmd.AddCommand(doctorCmd)
 	rootCmd.AddCommand(suggestDoctorCmd)
+	rootCmd.AddCommand(suggestRekeyCmd)
+	rootCmd.AddCommand(dbCmd)
 }
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/scopebundle"
+)
+
+var (
+	scopeExportOutput string
+	scopeImportInto   string
+)
+
+var scopeCmd = &cobra.Command{
+	Use:     "scope",
+	Short:   "Share learned suggestions for a repository",
+	GroupID: groupSetup,
+	Long: `Export and import the suggestion data clai has learned for one repository.
+
+A bundle contains the repository's command, transition, slot and pipeline
+stats, discovered tasks, workflow patterns and the command templates they
+use. It never contains raw command history, but templates and slot values
+can include arguments, so review a bundle before sharing it.
+
+Repositories are identified as repo:<key>, where <key> is the repository
+name clai records for commands run inside it.
+
+Examples:
+  clai scope export repo:clai -o clai.json
+  clai scope import clai.json
+  clai scope import clai.json --into repo:clai-fork`,
+}
+
+var scopeExportCmd = &cobra.Command{
+	Use:   "export repo:<key>",
+	Short: "Export a repository's learned stats, tasks and workflows",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runScopeExport,
+}
+
+var scopeImportCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Import a bundle produced by 'clai scope export'",
+	Long: `Import a bundle produced by 'clai scope export'.
+
+Data is merged into the bundle's repository, or into --into if given.
+Stats you have already learned locally are kept; only missing rows are
+added. Use '-' to read the bundle from stdin.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runScopeImport,
+}
+
+func init() {
+	scopeExportCmd.Flags().StringVarP(&scopeExportOutput, "output", "o", "", "Write the bundle to a file instead of stdout")
+	scopeImportCmd.Flags().StringVar(&scopeImportInto, "into", "", "Import into this scope (repo:<key>) instead of the bundle's own")
+	scopeCmd.AddCommand(scopeExportCmd)
+	scopeCmd.AddCommand(scopeImportCmd)
+}
+
+func runScopeExport(cmd *cobra.Command, args []string) error {
+	repoKey, err := scopebundle.ParseRepoScope(args[0])
+	if err != nil {
+		return err
+	}
+
+	dbPath, err := suggestdb.DefaultDBPath()
+	if err != nil {
+		return err
+	}
+	if _, statErr := os.Stat(dbPath); os.IsNotExist(statErr) {
+		return errors.New("no suggestions database yet; run some commands with clai enabled first")
+	}
+
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+
+	sdb, err := suggestdb.Open(cmd.Context(), suggestdb.Options{Path: dbPath, ReadOnly: true, SkipLock: true, EncryptionKey: key})
+	if err != nil {
+		return fmt.Errorf("failed to open suggestions database: %w", err)
+	}
+	defer sdb.Close()
+
+	bundle, err := scopebundle.Export(cmd.Context(), sdb.DB(), repoKey, time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+
+	if scopeExportOutput == "" {
+		return bundle.Write(os.Stdout)
+	}
+	f, err := os.OpenFile(scopeExportOutput, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", scopeExportOutput, err)
+	}
+	if err := bundle.Write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", scopeExportOutput, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", scopeExportOutput, err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %s%s to %s\n", scopebundle.RepoScopePrefix, repoKey, scopeExportOutput)
+	return nil
+}
+
+func runScopeImport(cmd *cobra.Command, args []string) error {
+	bundle, err := readScopeBundle(args[0])
+	if err != nil {
+		return err
+	}
+
+	target := bundle.RepoKey
+	if scopeImportInto != "" {
+		if target, err = scopebundle.ParseRepoScope(scopeImportInto); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+	defer cancel()
+
+	// The daemon may hold the database lock; SQLite's own locking keeps
+	// this write safe alongside it.
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+
+	sdb, err := suggestdb.Open(ctx, suggestdb.Options{SkipLock: true, EncryptionKey: key})
+	if err != nil {
+		return fmt.Errorf("failed to open suggestions database: %w", err)
+	}
+	defer sdb.Close()
+
+	result, err := scopebundle.Import(ctx, sdb.DB(), bundle, target)
+	if err != nil {
+		return fmt.Errorf("failed to import bundle: %w", err)
+	}
+	fmt.Printf("Imported %s%s: %s%d rows added%s, %d already present\n",
+		scopebundle.RepoScopePrefix, target, colorGreen, result.Imported, colorReset, result.Skipped)
+	return nil
+}
+
+func readScopeBundle(path string) (*scopebundle.Bundle, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path) //nolint:gosec // G304: user-supplied bundle path is intended
+		if err != nil {
+			return nil, fmt.Errorf("failed to open bundle: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	return scopebundle.Read(r)
+}
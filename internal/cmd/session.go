@@ -0,0 +1,487 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/dbcrypt"
+	"github.com/runger/clai/internal/ipc"
+	"github.com/runger/clai/internal/storage"
+)
+
+var (
+	sessionListLimit  int
+	sessionListActive bool
+	sessionListEnded  bool
+	sessionListFormat string
+	sessionNameClear  bool
+	sessionShowLimit  int
+	sessionExportFmt  string
+)
+
+var sessionCmd = &cobra.Command{
+	Use:     "session",
+	Short:   "List, name, review and export shell sessions",
+	GroupID: groupCore,
+	Long: `Work with the shell sessions clai has recorded.
+
+A session is referred to by its ID, an ID prefix (8+ characters) or its
+name; without one, the current session is used.
+
+Examples:
+  clai session list                  # Recent sessions
+  clai session name "deploy v2"      # Name this session
+  clai session show                  # Timeline of this session
+  clai session show "deploy v2"
+  clai session export --format md > runbook.md`,
+}
+
+var sessionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded sessions, most recent first",
+	Long: `List recorded sessions, most recent first, with their name, shell, start
+time, number of commands and starting directory. The current session is
+marked with '*'. A session is active until its shell exits.
+
+Examples:
+  clai session list --active
+  clai session list --json | jq -r '.[] | select(.name != "") | .session_id'`,
+	Args: cobra.NoArgs,
+	RunE: runSessionList,
+}
+
+var sessionNameCmd = &cobra.Command{
+	Use:   "name [name]",
+	Short: "Name the current session",
+	Long: `Name the current session, so it can be found in 'clai session list' and
+referred to by name in the other session commands. Without a name, the
+current name is shown.
+
+Examples:
+  clai session name "deploy v2"
+  clai session name            # show the current name
+  clai session name --clear    # remove the name`,
+	Args:         cobra.ArbitraryArgs,
+	SilenceUsage: true,
+	RunE:         runSessionName,
+}
+
+var sessionShowCmd = &cobra.Command{
+	Use:     "show [session]",
+	Aliases: []string{"timeline"},
+	Short:   "Show the timeline of a session",
+	Long: `Show the commands of a session in the order they ran, with their start
+time, duration and exit code, and where the working directory changed.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeSessionIDArg,
+	SilenceUsage:      true,
+	RunE:              runSessionShow,
+}
+
+var sessionExportCmd = &cobra.Command{
+	Use:   "export [session]",
+	Short: "Export a session as a shell script or markdown runbook",
+	Long: `Print the commands of a session as a shell script (--format sh) or a
+markdown runbook (--format md), changing directory where the session did.
+Failed commands are kept as comments, with their exit code.
+
+Examples:
+  clai session export > replay.sh
+  clai session export "deploy v2" --format md > deploy.md`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeSessionIDArg,
+	SilenceUsage:      true,
+	RunE:              runSessionExport,
+}
+
+func init() {
+	sessionListCmd.Flags().IntVarP(&sessionListLimit, "limit", "n", 20, "Maximum number of sessions to show")
+	sessionListCmd.Flags().BoolVar(&sessionListActive, "active", false, "Only show active sessions")
+	sessionListCmd.Flags().BoolVar(&sessionListEnded, "ended", false, "Only show ended sessions")
+	sessionListCmd.MarkFlagsMutuallyExclusive("active", "ended")
+	sessionListCmd.Flags().StringVar(&sessionListFormat, "format", "text", "Output format: text or json")
+	addJSONFlag(sessionListCmd, &sessionListFormat)
+	sessionNameCmd.Flags().BoolVar(&sessionNameClear, "clear", false, "Remove the session name")
+	sessionShowCmd.Flags().IntVarP(&sessionShowLimit, "limit", "n", 500, "Show at most this many of the latest commands")
+	sessionExportCmd.Flags().StringVar(&sessionExportFmt, "format", "sh", "Output format: sh or md")
+
+	sessionCmd.AddCommand(sessionListCmd)
+	sessionCmd.AddCommand(sessionNameCmd)
+	sessionCmd.AddCommand(sessionShowCmd)
+	sessionCmd.AddCommand(sessionExportCmd)
+}
+
+// openSessionStore opens the history database for reading sessions.
+func openSessionStore() (*storage.SQLiteStore, error) {
+	paths := config.DefaultPaths()
+	key, err := databaseKey()
+	if err != nil {
+		return nil, err
+	}
+	store, err := storage.NewSQLiteStoreWithKey(paths.DatabaseFile(), key)
+	if errors.Is(err, dbcrypt.ErrEncrypted) || errors.Is(err, dbcrypt.ErrWrongKey) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no sessions available, database not found at: %s", paths.DatabaseFile())
+	}
+	return store, nil
+}
+
+func runSessionList(cmd *cobra.Command, _ []string) error {
+	format := strings.ToLower(strings.TrimSpace(sessionListFormat))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format: %s (use text or json)", sessionListFormat)
+	}
+	if sessionListLimit <= 0 {
+		return errors.New("invalid limit: must be > 0")
+	}
+	store, err := openSessionStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	sessions, err := store.ListSessions(cmd.Context(), storage.SessionQuery{
+		Limit:      sessionListLimit,
+		ActiveOnly: sessionListActive,
+		EndedOnly:  sessionListEnded,
+	})
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(os.Stdout, sessionListJSON(sessions, os.Getenv("CLAI_SESSION_ID")))
+	}
+	renderSessionList(os.Stdout, sessions, os.Getenv("CLAI_SESSION_ID"))
+	return nil
+}
+
+// sessionListEntry is one session in the output of
+// 'clai session list --json'.
+type sessionListEntry struct {
+	EndedAtUnixMs       *int64 `json:"ended_at_unix_ms"` // null while active
+	SessionID           string `json:"session_id"`
+	Name                string `json:"name"`
+	Status              string `json:"status"` // "active" or "ended"
+	Shell               string `json:"shell"`
+	Hostname            string `json:"hostname"`
+	CWD                 string `json:"cwd"` // Where the session started
+	StartedAtUnixMs     int64  `json:"started_at_unix_ms"`
+	LastCommandAtUnixMs int64  `json:"last_command_at_unix_ms"` // 0 without commands
+	CommandCount        int64  `json:"command_count"`
+	Current             bool   `json:"current"`
+}
+
+// sessionListJSON converts sessions for 'clai session list --json'.
+func sessionListJSON(sessions []storage.SessionListing, current string) []sessionListEntry {
+	entries := make([]sessionListEntry, 0, len(sessions))
+	for _, s := range sessions {
+		status := "active"
+		if s.EndedAtUnixMs != nil {
+			status = "ended"
+		}
+		entries = append(entries, sessionListEntry{
+			SessionID:           s.SessionID,
+			Name:                s.Name,
+			Status:              status,
+			Shell:               s.Shell,
+			Hostname:            s.Hostname,
+			CWD:                 s.InitialCWD,
+			StartedAtUnixMs:     s.StartedAtUnixMs,
+			EndedAtUnixMs:       s.EndedAtUnixMs,
+			LastCommandAtUnixMs: s.LastCommandUnixMs,
+			CommandCount:        s.CommandCount,
+			Current:             s.SessionID == current,
+		})
+	}
+	return entries
+}
+
+// renderSessionList prints one line per session, marking current with '*'.
+func renderSessionList(w io.Writer, sessions []storage.SessionListing, current string) {
+	if len(sessions) == 0 {
+		fmt.Fprintln(w, "No sessions found.")
+		return
+	}
+
+	fmt.Fprintf(w, "  %-8s  %-20s  %-6s  %-5s  %-16s  %5s  %s\n", "SESSION", "NAME", "STATUS", "SHELL", "STARTED", "CMDS", "CWD")
+	for _, s := range sessions {
+		marker := " "
+		if s.SessionID == current {
+			marker = "*"
+		}
+		status := "active"
+		if s.EndedAtUnixMs != nil {
+			status = "ended"
+		}
+		name := s.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(w, "%s %-8s  %-20s  %-6s  %-5s  %-16s  %5d  %s\n",
+			marker, shortSessionID(s.SessionID), truncateRunes(name, 20), status, s.Shell,
+			time.UnixMilli(s.StartedAtUnixMs).Format("2006-01-02 15:04"), s.CommandCount, s.InitialCWD)
+	}
+}
+
+func runSessionName(cmd *cobra.Command, args []string) error {
+	name := strings.TrimSpace(strings.Join(args, " "))
+	if sessionNameClear && name != "" {
+		return errors.New("--clear cannot be combined with a name")
+	}
+
+	sessionID := os.Getenv("CLAI_SESSION_ID")
+	if sessionID == "" {
+		return errors.New("no active clai session (CLAI_SESSION_ID is not set)")
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		return fmt.Errorf("daemon not available: %w", err)
+	}
+	defer client.Close()
+
+	current, err := client.SessionName(cmd.Context(), sessionID, name, sessionNameClear)
+	if err != nil {
+		return fmt.Errorf("failed to name session: %w", err)
+	}
+
+	switch {
+	case sessionNameClear:
+		fmt.Println("Session name cleared")
+	case name != "":
+		fmt.Printf("Session named: %s\n", current)
+	case current == "":
+		fmt.Printf("%sThis session has no name%s\n", colorDim, colorReset)
+	default:
+		fmt.Println(current)
+	}
+	return nil
+}
+
+func runSessionShow(cmd *cobra.Command, args []string) error {
+	if sessionShowLimit <= 0 {
+		return errors.New("invalid limit: must be > 0")
+	}
+	store, err := openSessionStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	session, commands, err := loadSession(cmd.Context(), store, args, sessionShowLimit)
+	if err != nil {
+		return err
+	}
+	writeSessionTimeline(os.Stdout, session, commands)
+	return nil
+}
+
+func runSessionExport(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(strings.TrimSpace(sessionExportFmt))
+	if format != "sh" && format != "md" {
+		return fmt.Errorf("invalid format: %s (use sh or md)", sessionExportFmt)
+	}
+	store, err := openSessionStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	session, commands, err := loadSession(cmd.Context(), store, args, 0)
+	if err != nil {
+		return err
+	}
+	if format == "md" {
+		writeSessionRunbook(os.Stdout, session, commands)
+	} else {
+		writeSessionScript(os.Stdout, session, commands)
+	}
+	return nil
+}
+
+// loadSession resolves the session named by args, or the current session,
+// and returns it with up to limit of its latest commands, oldest first.
+// A limit of 0 uses the store's default cap.
+func loadSession(ctx context.Context, store *storage.SQLiteStore, args []string, limit int) (*storage.Session, []storage.Command, error) {
+	ref := os.Getenv("CLAI_SESSION_ID")
+	if len(args) > 0 {
+		ref = args[0]
+	}
+	if ref == "" {
+		return nil, nil, errors.New("not in a clai shell session; pass a session ID or name")
+	}
+
+	session, err := store.GetSessionByName(ctx, ref)
+	if errors.Is(err, storage.ErrSessionNotFound) {
+		var id string
+		if id, err = resolveSessionID(ctx, store, ref); err == nil {
+			session, err = store.GetSession(ctx, id)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commands, err := store.QueryCommands(ctx, storage.CommandQuery{SessionID: &session.SessionID, Limit: limit})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query session commands: %w", err)
+	}
+	slices.Reverse(commands)
+	return session, commands, nil
+}
+
+// sessionTitle names a session by its name, or its short ID.
+func sessionTitle(s *storage.Session) string {
+	if s.Name != "" {
+		return fmt.Sprintf("%s (%s)", s.Name, shortSessionID(s.SessionID))
+	}
+	return shortSessionID(s.SessionID)
+}
+
+// sessionSummaryLine describes where and when a session ran.
+func sessionSummaryLine(s *storage.Session) string {
+	line := s.Shell
+	if s.Hostname != "" {
+		line += " on " + s.Hostname
+	}
+	line += ", started " + time.UnixMilli(s.StartedAtUnixMs).Format("2006-01-02 15:04")
+	if s.EndedAtUnixMs != nil {
+		line += ", ended " + time.UnixMilli(*s.EndedAtUnixMs).Format("2006-01-02 15:04")
+	} else {
+		line += ", active"
+	}
+	return line
+}
+
+// writeSessionTimeline prints the commands of a session with their start
+// time, duration and exit code, and a line where the directory changes.
+func writeSessionTimeline(w io.Writer, s *storage.Session, commands []storage.Command) {
+	fmt.Fprintf(w, "%sSession %s%s\n", colorBold, sessionTitle(s), colorReset)
+	fmt.Fprintf(w, "%s%s%s\n", colorDim, sessionSummaryLine(s), colorReset)
+	if len(commands) == 0 {
+		fmt.Fprintln(w, "\nNo commands recorded.")
+		return
+	}
+
+	fmt.Fprintf(w, "\n%-8s  %8s  %4s  %s\n", "TIME", "DURATION", "EXIT", "COMMAND")
+	cwd := ""
+	for i := range commands {
+		c := &commands[i]
+		if c.CWD != cwd {
+			cwd = c.CWD
+			fmt.Fprintf(w, "%s%-8s  %8s  %4s  cd %s%s\n", colorDim, "", "", "", cwd, colorReset)
+		}
+		duration, exit := "-", "-"
+		if c.DurationMs != nil {
+			duration = formatDurationMs(*c.DurationMs)
+		}
+		if c.ExitCode != nil {
+			exit = fmt.Sprint(*c.ExitCode)
+		}
+		fmt.Fprintf(w, "%-8s  %8s  %4s  %s\n",
+			time.UnixMilli(c.TSStartUnixMs).Format("15:04:05"), duration, exit, c.Command)
+	}
+}
+
+// writeSessionScript prints the commands of a session as a script for its
+// shell, with a cd wherever the directory changed. Failed commands are
+// commented out.
+func writeSessionScript(w io.Writer, s *storage.Session, commands []storage.Command) {
+	shell := exportShell(s.Shell)
+	fmt.Fprintf(w, "#!/usr/bin/env %s\n", shell)
+	fmt.Fprintf(w, "# clai session %s\n# %s\n", sessionTitle(s), sessionSummaryLine(s))
+	writeSessionCommands(w, shell, commands, "\n")
+}
+
+// writeSessionRunbook prints the commands of a session as a markdown
+// runbook, one section per working directory.
+func writeSessionRunbook(w io.Writer, s *storage.Session, commands []storage.Command) {
+	shell := exportShell(s.Shell)
+	title := s.Name
+	if title == "" {
+		title = "Session " + shortSessionID(s.SessionID)
+	}
+	fmt.Fprintf(w, "# %s\n\n", title)
+	fmt.Fprintf(w, "Recorded by clai from session `%s`: %s.\n", s.SessionID, sessionSummaryLine(s))
+	if len(commands) == 0 {
+		fmt.Fprintln(w, "\nNo commands recorded.")
+		return
+	}
+
+	for i := 0; i < len(commands); {
+		cwd := commands[i].CWD
+		end := i
+		for end < len(commands) && commands[end].CWD == cwd {
+			end++
+		}
+		fmt.Fprintf(w, "\n## In `%s`\n\n```%s\n", cwd, shell)
+		writeSessionCommands(w, shell, commands[i:end], "")
+		fmt.Fprintln(w, "```")
+		i = end
+	}
+}
+
+// writeSessionCommands prints one command per line, commenting out failed
+// ones. A non-empty cdPrefix writes a cd before the first command and
+// wherever the directory changes, preceded by cdPrefix.
+func writeSessionCommands(w io.Writer, shell string, commands []storage.Command, cdPrefix string) {
+	cwd := ""
+	for i := range commands {
+		c := &commands[i]
+		if cdPrefix != "" && c.CWD != cwd {
+			cwd = c.CWD
+			fmt.Fprintf(w, "%scd %s\n", cdPrefix, quoteForShell(shell, cwd))
+		}
+		if c.ExitCode != nil && *c.ExitCode != 0 {
+			fmt.Fprintf(w, "# failed (exit %d): %s\n", *c.ExitCode, strings.ReplaceAll(c.Command, "\n", "\n# "))
+			continue
+		}
+		fmt.Fprintln(w, c.Command)
+	}
+}
+
+// exportShell is the shell a session export is written for.
+func exportShell(shell string) string {
+	switch shell {
+	case "bash", "zsh", "fish":
+		return shell
+	default:
+		return "sh"
+	}
+}
+
+// quoteForShell single-quotes s for shell.
+func quoteForShell(shell, s string) string {
+	if shell == "fish" {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shortSessionID is the 8-character prefix sessions are listed by.
+func shortSessionID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// truncateRunes shortens s to width runes, ending it with "..." if cut.
+func truncateRunes(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-3]) + "..."
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runger/clai/internal/storage"
+)
+
+func sessionTestCommands() []storage.Command {
+	dur := int64(1500)
+	ok, failed := 0, 2
+	return []storage.Command{
+		{CWD: "/src/app", Command: "make build", TSStartUnixMs: 1_700_000_001_000, DurationMs: &dur, ExitCode: &ok},
+		{CWD: "/src/app", Command: "make test", TSStartUnixMs: 1_700_000_002_000, ExitCode: &failed},
+		{CWD: "/tmp/it's", Command: "ls", TSStartUnixMs: 1_700_000_003_000, ExitCode: &ok},
+	}
+}
+
+func TestLoadSession(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	id := "3f2a9c1e-5b7d-4e8f-a1b2-c3d4e5f60718"
+	if err := store.CreateSession(ctx, &storage.Session{
+		SessionID: id, StartedAtUnixMs: 1_700_000_000_000, Shell: "zsh", OS: "linux", InitialCWD: "/src/app",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetSessionName(ctx, id, "deploy"); err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range sessionTestCommands() {
+		c.CommandID = "load-" + string(rune('a'+i))
+		c.SessionID = id
+		if err := store.CreateCommand(ctx, &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("CLAI_SESSION_ID", id)
+	for _, args := range [][]string{nil, {"deploy"}, {"3f2a9c1e"}} {
+		session, commands, err := loadSession(ctx, store, args, 2)
+		if err != nil {
+			t.Fatalf("loadSession(%v) error = %v", args, err)
+		}
+		if session.SessionID != id {
+			t.Errorf("loadSession(%v) = %s", args, session.SessionID)
+		}
+		if len(commands) != 2 || commands[0].Command != "make test" || commands[1].Command != "ls" {
+			t.Errorf("loadSession(%v) commands = %v, want the latest 2, oldest first", args, commands)
+		}
+	}
+
+	if _, _, err := loadSession(ctx, store, []string{"nope"}, 0); err == nil {
+		t.Error("loadSession(unknown) = nil error")
+	}
+	t.Setenv("CLAI_SESSION_ID", "")
+	if _, _, err := loadSession(ctx, store, nil, 0); err == nil {
+		t.Error("loadSession() outside a session = nil error")
+	}
+}
+
+func TestRenderSessionList(t *testing.T) {
+	t.Parallel()
+
+	ended := int64(1_700_000_100_000)
+	sessions := []storage.SessionListing{
+		{Session: storage.Session{SessionID: "aaaaaaaa-1111", Name: "deploy", Shell: "zsh", InitialCWD: "/src", StartedAtUnixMs: 1_700_000_000_000}, CommandCount: 12},
+		{Session: storage.Session{SessionID: "bbbbbbbb-2222", Shell: "bash", InitialCWD: "/tmp", StartedAtUnixMs: 1_690_000_000_000, EndedAtUnixMs: &ended}},
+	}
+	var out bytes.Buffer
+	renderSessionList(&out, sessions, "aaaaaaaa-1111")
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("output =\n%s", out.String())
+	}
+	started := time.UnixMilli(1_700_000_000_000).Format("2006-01-02 15:04")
+	if want := "* aaaaaaaa  deploy                active  zsh    " + started + "     12  /src"; lines[1] != want {
+		t.Errorf("line = %q, want %q", lines[1], want)
+	}
+	if !strings.HasPrefix(lines[2], "  bbbbbbbb  -                     ended   bash") {
+		t.Errorf("line = %q", lines[2])
+	}
+
+	out.Reset()
+	renderSessionList(&out, nil, "")
+	if out.String() != "No sessions found.\n" {
+		t.Errorf("empty list = %q", out.String())
+	}
+}
+
+func TestSessionListJSON(t *testing.T) {
+	t.Parallel()
+
+	ended := int64(1_700_000_100_000)
+	sessions := []storage.SessionListing{
+		{Session: storage.Session{SessionID: "aaaaaaaa-1111", Name: "deploy", Shell: "zsh", InitialCWD: "/src", StartedAtUnixMs: 1_700_000_000_000}, CommandCount: 12, LastCommandUnixMs: 1_700_000_050_000},
+		{Session: storage.Session{SessionID: "bbbbbbbb-2222", Shell: "bash", InitialCWD: "/tmp", StartedAtUnixMs: 1_690_000_000_000, EndedAtUnixMs: &ended}},
+	}
+	var out bytes.Buffer
+	if err := writeJSON(&out, sessionListJSON(sessions, "aaaaaaaa-1111")); err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(got))
+	}
+	for key, want := range map[string]any{
+		"session_id": "aaaaaaaa-1111", "name": "deploy", "status": "active", "shell": "zsh", "cwd": "/src",
+		"started_at_unix_ms": 1.7e12, "last_command_at_unix_ms": 1_700_000_050_000.0, "command_count": 12.0,
+		"current": true, "ended_at_unix_ms": nil,
+	} {
+		if got[0][key] != want {
+			t.Errorf("%s = %v, want %v", key, got[0][key], want)
+		}
+	}
+	if got[1]["status"] != "ended" || got[1]["ended_at_unix_ms"] != float64(ended) || got[1]["current"] != false {
+		t.Errorf("ended session = %v", got[1])
+	}
+
+	out.Reset()
+	if err := writeJSON(&out, sessionListJSON(nil, "")); err != nil || out.String() != "[]\n" {
+		t.Errorf("empty list = %q, %v", out.String(), err)
+	}
+}
+
+func TestWriteSessionTimeline(t *testing.T) {
+	t.Parallel()
+
+	session := &storage.Session{SessionID: "3f2a9c1e-5b7d", Name: "deploy", Shell: "zsh", Hostname: "laptop", StartedAtUnixMs: 1_700_000_000_000}
+	var out bytes.Buffer
+	writeSessionTimeline(&out, session, sessionTestCommands())
+	for _, want := range []string{
+		"Session deploy (3f2a9c1e)",
+		"zsh on laptop, started",
+		"active",
+		"cd /src/app",
+		time.UnixMilli(1_700_000_001_000).Format("15:04:05") + "      1.5s     0  make build",
+		"       -     2  make test",
+		"cd /tmp/it's",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("timeline is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWriteSessionExport(t *testing.T) {
+	t.Parallel()
+
+	session := &storage.Session{SessionID: "3f2a9c1e-5b7d", Shell: "bash", StartedAtUnixMs: 1_700_000_000_000}
+
+	var script bytes.Buffer
+	writeSessionScript(&script, session, sessionTestCommands())
+	lines := strings.Split(script.String(), "\n")
+	if lines[0] != "#!/usr/bin/env bash" || lines[1] != "# clai session 3f2a9c1e" {
+		t.Errorf("script header = %q", lines[:2])
+	}
+	wantBody := "\ncd '/src/app'\nmake build\n# failed (exit 2): make test\n\ncd '/tmp/it'\\''s'\nls\n"
+	if !strings.HasSuffix(script.String(), wantBody) {
+		t.Errorf("script =\n%s\nwant it to end with\n%s", script.String(), wantBody)
+	}
+
+	var md bytes.Buffer
+	writeSessionRunbook(&md, session, sessionTestCommands())
+	wantMD := "\n## In `/src/app`\n\n```bash\nmake build\n# failed (exit 2): make test\n```\n" +
+		"\n## In `/tmp/it's`\n\n```bash\nls\n```\n"
+	if !strings.HasPrefix(md.String(), "# Session 3f2a9c1e\n") || !strings.HasSuffix(md.String(), wantMD) {
+		t.Errorf("runbook =\n%s\nwant it to end with\n%s", md.String(), wantMD)
+	}
+}
+
+func TestQuoteForShell(t *testing.T) {
+	t.Parallel()
+
+	if got := quoteForShell("zsh", "it's"); got != `'it'\''s'` {
+		t.Errorf("posix = %s", got)
+	}
+	if got := quoteForShell("fish", `it's \`); got != `'it\'s \\'` {
+		t.Errorf("fish = %s", got)
+	}
+}
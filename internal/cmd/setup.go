@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/ipc"
+)
+
+var (
+	setupYes   bool
+	setupShell string
+)
+
+var setupCmd = &cobra.Command{
+	Use:     "setup",
+	Short:   "Set up clai step by step",
+	GroupID: groupSetup,
+	Long: `Walk through the first-run setup of clai:
+
+  1. Pick the shell to integrate with (detected by default)
+  2. Turn suggestions, the Up-arrow history picker and AI features on or off
+  3. Write the config file
+  4. Add the shell integration to your rc files
+  5. Import your existing shell history so suggestions work from day one
+
+Each step asks before changing anything. Running it again updates the
+existing config instead of replacing it. With --yes every question takes
+its default answer, for scripted installs.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w := &setupWizard{
+			in:              bufio.NewReader(os.Stdin),
+			out:             cmd.OutOrStdout(),
+			paths:           config.DefaultPaths(),
+			yes:             setupYes,
+			shell:           setupShell,
+			detectShell:     DetectShell,
+			install:         installShellIntegration,
+			importHistory:   importShellHistory,
+			claudeAvailable: func() bool { _, err := exec.LookPath("claude"); return err == nil },
+		}
+		return w.run()
+	},
+}
+
+func init() {
+	setupCmd.Flags().BoolVarP(&setupYes, "yes", "y", false, "Take the default answer to every question")
+	setupCmd.Flags().StringVar(&setupShell, "shell", "", "Shell to set up (zsh, bash, fish); detected by default")
+}
+
+// setupWizard holds the steps of `clai setup`; the side effects are
+// functions so tests can replace them.
+type setupWizard struct {
+	in              *bufio.Reader
+	out             io.Writer
+	paths           *config.Paths
+	detectShell     func() ShellDetection
+	install         func(shell string) ([]string, error)
+	importHistory   func(shell string) (imported int, skipped bool, err error)
+	claudeAvailable func() bool
+	shell           string
+	yes             bool
+}
+
+func (w *setupWizard) run() error {
+	fmt.Fprintf(w.out, "%sWelcome to clai!%s This sets up your shell and writes %s.\n", colorBold, colorReset, w.paths.ConfigFile())
+
+	cfg, err := config.ReadFile(w.paths.ConfigFile())
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(w.paths.ConfigFile()); err == nil {
+		fmt.Fprintln(w.out, "Your existing config is kept; only the settings asked about change.")
+	}
+
+	w.step("Shell")
+	shell, err := w.chooseShell()
+	if err != nil {
+		return err
+	}
+
+	w.step("Features")
+	cfg.Suggestions.Enabled = w.ask("Show command suggestions as you type?", cfg.Suggestions.Enabled)
+	cfg.History.UpArrowOpensHistory = w.ask("Open the history picker with the Up arrow?", cfg.History.UpArrowOpensHistory)
+	cfg.AI.Enabled = w.ask("Enable AI features (clai cmd, clai ask, error diagnosis)?", cfg.AI.Enabled)
+	if cfg.AI.Enabled {
+		w.checkAI()
+	}
+
+	w.step("Config")
+	if err := saveConfig(cfg, w.paths); err != nil {
+		return err
+	}
+	fmt.Fprintf(w.out, "Saved %s\n", w.paths.ConfigFile())
+
+	w.step("Shell integration")
+	installed := false
+	if rcFiles := getRCFiles(shell); w.ask(fmt.Sprintf("Load clai from %s?", strings.Join(rcFiles, ", ")), true) {
+		if _, err := w.install(shell); err != nil {
+			return fmt.Errorf("failed to install shell integration: %w", err)
+		}
+		installed = true
+	} else {
+		fmt.Fprintf(w.out, "Skipped. Add this to your shell config yourself: %s\n", evalCommand(shell))
+	}
+
+	w.step("History")
+	if w.ask(fmt.Sprintf("Import your %s history so suggestions know your commands?", shell), true) {
+		switch imported, skipped, err := w.importHistory(shell); {
+		case err != nil:
+			fmt.Fprintf(w.out, "%sWarning:%s import failed: %v\n", colorYellow, colorReset, err)
+			fmt.Fprintf(w.out, "Retry later with: clai history import --shell=%s\n", shell)
+		case skipped:
+			fmt.Fprintln(w.out, "Already imported; use 'clai history import --force' to import again.")
+		default:
+			fmt.Fprintf(w.out, "Imported %d commands.\n", imported)
+		}
+	}
+
+	fmt.Fprintf(w.out, "\n%sDone!%s\n", colorGreen, colorReset)
+	if installed {
+		fmt.Fprintf(w.out, "Start a new terminal, or run: %s%s%s\n", colorCyan, evalCommand(shell), colorReset)
+	}
+	fmt.Fprintln(w.out, "Check everything with 'clai status'; change settings later with 'clai config edit'.")
+	return nil
+}
+
+func (w *setupWizard) step(title string) {
+	fmt.Fprintf(w.out, "\n%s%s%s\n", colorBold, title, colorReset)
+}
+
+// ask asks a yes/no question; an empty answer, or --yes, takes def.
+func (w *setupWizard) ask(question string, def bool) bool {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	fmt.Fprintf(w.out, "%s %s ", question, hint)
+	if w.yes {
+		fmt.Fprintln(w.out, yesNo(def))
+		return def
+	}
+	for {
+		response, err := w.in.ReadString('\n')
+		switch strings.TrimSpace(strings.ToLower(response)) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		case "":
+			if err != nil {
+				fmt.Fprintln(w.out)
+			}
+			return def
+		}
+		fmt.Fprintf(w.out, "Please answer y or n. %s ", hint)
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "y"
+	}
+	return "n"
+}
+
+// chooseShell returns --shell, or the detected shell once confirmed.
+func (w *setupWizard) chooseShell() (string, error) {
+	supported := []string{"zsh", "bash", "fish"}
+	if w.shell != "" {
+		if !slices.Contains(supported, w.shell) {
+			return "", fmt.Errorf("unsupported shell: %s (supported: zsh, bash, fish)", w.shell)
+		}
+		fmt.Fprintf(w.out, "Setting up %s.\n", w.shell)
+		return w.shell, nil
+	}
+
+	detected := w.detectShell().Shell
+	if slices.Contains(supported, detected) && w.ask(fmt.Sprintf("Set up clai for %s?", detected), true) {
+		return detected, nil
+	}
+	if detected != "" && !slices.Contains(supported, detected) {
+		fmt.Fprintf(w.out, "clai setup does not support %s; see 'clai init --help'.\n", detected)
+	}
+	if w.yes {
+		return "", errors.New("could not detect a supported shell; use --shell")
+	}
+	for {
+		fmt.Fprintf(w.out, "Which shell? (%s) ", strings.Join(supported, ", "))
+		response, err := w.in.ReadString('\n')
+		shell := strings.TrimSpace(strings.ToLower(response))
+		if slices.Contains(supported, shell) {
+			return shell, nil
+		}
+		if err != nil {
+			return "", errors.New("no shell chosen")
+		}
+		fmt.Fprintf(w.out, "Invalid shell: %q\n", shell)
+	}
+}
+
+// checkAI explains how AI features authenticate and whether they can run.
+func (w *setupWizard) checkAI() {
+	fmt.Fprintln(w.out, "AI features run through the Claude CLI, which keeps its own login;")
+	fmt.Fprintln(w.out, "clai never stores API keys. The CLI uses 'claude login' or ANTHROPIC_API_KEY.")
+	if w.claudeAvailable() {
+		fmt.Fprintf(w.out, "%sFound the claude CLI.%s\n", colorGreen, colorReset)
+		return
+	}
+	fmt.Fprintf(w.out, "%sWarning:%s the claude CLI is not on your PATH. Install it and run\n", colorYellow, colorReset)
+	fmt.Fprintln(w.out, "'claude login'; AI features stay unavailable until then.")
+}
+
+// importShellHistory asks the daemon to import the shell's history file.
+func importShellHistory(shell string) (int, bool, error) {
+	client, err := ipc.NewClient()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	resp, err := client.ImportHistory(ctx, os.Getenv("CLAI_SESSION_ID"), shell, "", true, false)
+	if err != nil {
+		return 0, false, err
+	}
+	if resp.Error != "" {
+		return 0, false, errors.New(resp.Error)
+	}
+	return int(resp.ImportedCount), resp.Skipped, nil
+}
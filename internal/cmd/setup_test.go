@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/runger/clai/internal/config"
+)
+
+// newTestWizard returns a wizard answering from answers, with every side
+// effect other than the config file faked.
+func newTestWizard(t *testing.T, answers string, out *bytes.Buffer) (*setupWizard, *[]string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	var calls []string
+	w := &setupWizard{
+		in:          bufio.NewReader(strings.NewReader(answers)),
+		out:         out,
+		paths:       &config.Paths{BaseDir: t.TempDir()},
+		detectShell: func() ShellDetection { return ShellDetection{Shell: "zsh", Confident: true} },
+		install: func(shell string) ([]string, error) {
+			calls = append(calls, "install "+shell)
+			return []string{"~/.zshrc"}, nil
+		},
+		importHistory: func(shell string) (int, bool, error) {
+			calls = append(calls, "import "+shell)
+			return 42, false, nil
+		},
+		claudeAvailable: func() bool { return false },
+	}
+	return w, &calls
+}
+
+func TestSetupWizard_Defaults(t *testing.T) {
+	var out bytes.Buffer
+	w, calls := newTestWizard(t, "", &out)
+	w.yes = true
+	if err := w.run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	cfg, err := config.LoadFromFile(w.paths.ConfigFile())
+	if err != nil {
+		t.Fatalf("config not written: %v", err)
+	}
+	def := config.DefaultConfig()
+	if cfg.Suggestions.Enabled != def.Suggestions.Enabled || cfg.AI.Enabled != def.AI.Enabled {
+		t.Errorf("config = suggestions %v, ai %v; want the defaults", cfg.Suggestions.Enabled, cfg.AI.Enabled)
+	}
+	if got := strings.Join(*calls, ", "); got != "install zsh, import zsh" {
+		t.Errorf("calls = %q", got)
+	}
+	if !strings.Contains(out.String(), "Imported 42 commands") {
+		t.Errorf("output missing import count:\n%s", out.String())
+	}
+}
+
+func TestSetupWizard_Answers(t *testing.T) {
+	// Shell: no, then fish; suggestions: n; up arrow: y; AI: y;
+	// install: n; import: n.
+	var out bytes.Buffer
+	w, calls := newTestWizard(t, "n\nfish\nn\ny\nyes\nn\nn\n", &out)
+	if err := w.run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	cfg, err := config.LoadFromFile(w.paths.ConfigFile())
+	if err != nil {
+		t.Fatalf("config not written: %v", err)
+	}
+	if cfg.Suggestions.Enabled || !cfg.History.UpArrowOpensHistory || !cfg.AI.Enabled {
+		t.Errorf("config = suggestions %v, up arrow %v, ai %v; want false, true, true",
+			cfg.Suggestions.Enabled, cfg.History.UpArrowOpensHistory, cfg.AI.Enabled)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("calls = %v, want none", *calls)
+	}
+	got := out.String()
+	for _, want := range []string{"claude CLI is not on your PATH", evalCommand("fish")} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSetupWizard_KeepsExistingConfig(t *testing.T) {
+	var out bytes.Buffer
+	w, _ := newTestWizard(t, "", &out)
+	w.yes = true
+	existing := config.DefaultConfig()
+	existing.Suggestions.MaxResults = 7
+	if err := saveConfig(existing, w.paths); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	cfg, err := config.LoadFromFile(w.paths.ConfigFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Suggestions.MaxResults != 7 {
+		t.Errorf("max_results = %d, want 7 kept", cfg.Suggestions.MaxResults)
+	}
+	if !strings.Contains(out.String(), "existing config is kept") {
+		t.Errorf("output missing existing config note:\n%s", out.String())
+	}
+}
+
+func TestSetupWizard_ImportFailureIsWarning(t *testing.T) {
+	var out bytes.Buffer
+	w, _ := newTestWizard(t, "", &out)
+	w.yes = true
+	w.importHistory = func(string) (int, bool, error) { return 0, false, errors.New("daemon down") }
+	if err := w.run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "clai history import --shell=zsh") {
+		t.Errorf("output missing retry hint:\n%s", out.String())
+	}
+}
+
+func TestSetupWizard_Shell(t *testing.T) {
+	var out bytes.Buffer
+	w, _ := newTestWizard(t, "", &out)
+	w.shell = "pwsh"
+	if _, err := w.chooseShell(); err == nil {
+		t.Error("chooseShell(--shell=pwsh) = nil error, want unsupported")
+	}
+
+	w.shell = ""
+	w.yes = true
+	w.detectShell = func() ShellDetection { return ShellDetection{} }
+	if _, err := w.chooseShell(); err == nil {
+		t.Error("chooseShell() with no detected shell and --yes = nil error")
+	}
+}
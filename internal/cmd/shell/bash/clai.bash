@@ -16,12 +16,16 @@
 # Export current shell for clai doctor/status detection
 export CLAI_CURRENT_SHELL=bash
 
+# Version of clai that generated this script (clai status flags a stale one)
+export CLAI_INIT_VERSION="{{CLAI_VERSION}}"
+
 : ${CLAI_AUTO_EXTRACT:=true}
-: ${CLAI_CACHE:="$HOME/.cache/clai"}
+: ${CLAI_CACHE:="$HOME/.cache/clai${CLAI_PROFILE:+/profiles/$CLAI_PROFILE}"}
 : ${CLAI_MENU_LIMIT:=5}
 : ${CLAI_UP_ARROW_HISTORY:={{CLAI_UP_ARROW_HISTORY}}}
 : ${CLAI_UP_ARROW_TRIGGER:={{CLAI_UP_ARROW_TRIGGER}}}
 : ${CLAI_UP_ARROW_DOUBLE_WINDOW_MS:={{CLAI_UP_ARROW_DOUBLE_WINDOW_MS}}}
+: ${CLAI_CONFIRM_RISKY:={{CLAI_CONFIRM_RISKY}}}
 
 # Only initialize in interactive shells.
 # This avoids installing hooks in non-interactive contexts like `bash -c` or scripts.
@@ -518,19 +522,19 @@ _clai_history_scope_global() {
 # sequences — it fails with "cannot find keymap for command". Work around by
 # using a readline macro to translate arrow escapes to Ctrl-X prefixed
 # sequences, then bind those with -x.
-# Alt/Option+H opens TUI picker.
-# '\eh' works when the terminal sends ESC for Alt (Linux, macOS with Meta key).
+# client.keybindings.history_picker (Alt/Option+H by default) opens TUI picker.
+# Alt+H is '\eh' when the terminal sends ESC for Alt (Linux, macOS with Meta key).
 # On macOS, Option+H produces ˙ (U+02D9). bash 3.2 cannot bind -x to multi-byte
 # chars, so we use a macro to translate it to a Ctrl sequence we can bind -x to.
-bind -x '"\eh": _clai_tui_picker_open'
+bind -x '"{{CLAI_KEY_HISTORY_PICKER}}": _clai_tui_picker_open'
 bind -x '"\C-x\C-h": _clai_tui_picker_open'
 bind '"˙": "\C-x\C-h"'
 
-# Alt/Option+S opens the suggestions TUI picker.
-# '\es' works when the terminal sends ESC for Alt. On macOS, Option+S often
-# produces ß (U+00DF); bash 3.2 cannot bind -x to multi-byte chars, so we
-# translate it to a Ctrl sequence first.
-bind -x '"\es": _clai_tui_suggest_picker_open'
+# client.keybindings.suggest_widget (Alt/Option+S by default) opens the
+# suggestions TUI picker. Alt+S is '\es' when the terminal sends ESC for Alt.
+# On macOS, Option+S often produces ß (U+00DF); bash 3.2 cannot bind -x to
+# multi-byte chars, so we translate it to a Ctrl sequence first.
+bind -x '"{{CLAI_KEY_SUGGEST_WIDGET}}": _clai_tui_suggest_picker_open'
 bind -x '"\C-x\C-s": _clai_tui_suggest_picker_open'
 bind '"ß": "\C-x\C-s"'
 
@@ -810,6 +814,24 @@ _ai_check_nl_prefix() {
     return 1  # Not a natural language command
 }
 
+# Print the warning for a destructive command and ask whether to run it
+# anyway (suggestions.confirm_risky). Returns 1 when the user declines.
+# Only top-level user commands are checked, as in _clai_log_command_start.
+_ai_confirm_risky() {
+    local cmd="$1"
+    [[ -z "$cmd" || ${#FUNCNAME[@]} -gt 2 ]] && return 0
+    case "$cmd" in
+        _ai_*|_clai_*|_AI_*|_CLAI_*) return 0 ;;
+    esac
+
+    local warning answer
+    warning=$(clai-shim confirm --command="$cmd" 2>/dev/null)
+    [[ -z "$warning" ]] && return 0
+    printf '%s\n' "$warning" >&2
+    read -r -p "Run anyway? [y/N] " answer </dev/tty
+    [[ "$answer" == [yY]* ]]
+}
+
 # Hook into DEBUG trap to catch ? prefix before execution and log commands
 # Note: extdebug must be enabled for the trap to block command execution
 _ai_debug_trap() {
@@ -819,6 +841,11 @@ _ai_debug_trap() {
         return 1
     fi
 
+    # Ask before running a command the risk classifier flags
+    if [[ "$CLAI_CONFIRM_RISKY" == "true" ]] && ! _ai_confirm_risky "$BASH_COMMAND"; then
+        return 1
+    fi
+
     # Log command start (for session history)
     _clai_log_command_start "$BASH_COMMAND"
 
@@ -985,10 +1012,10 @@ _clai_disable() {
     bind -r '\C-xs'
     bind -r '\C-xd'
     bind -r '\C-xg'
-    bind -r '\eh'
+    bind -r '{{CLAI_KEY_HISTORY_PICKER}}'
     bind -r '\C-x\C-h'
     bind -r '˙'
-    bind -r '\es' 2>/dev/null
+    bind -r '{{CLAI_KEY_SUGGEST_WIDGET}}' 2>/dev/null
     bind -r '\C-x\C-s' 2>/dev/null
     bind -r 'ß' 2>/dev/null
 
@@ -1044,9 +1071,9 @@ if [[ $- == *i* && -z "$_CLAI_REINIT" ]]; then
     # Use printf for better portability across bash versions.
     # Keep emoji + dim styling when UTF-8 is supported.
     if _clai_supports_utf8; then
-        printf '\033[2m🤖 clai [%s] Alt+S suggestions | Alt+H history | ?"describe task"\033[0m\n' "${CLAI_SESSION_ID:0:8}"
+        printf '\033[2m🤖 clai [%s] {{CLAI_KEY_SUGGEST_WIDGET_LABEL}} suggestions | {{CLAI_KEY_HISTORY_PICKER_LABEL}} history | ?"describe task"\033[0m\n' "${CLAI_SESSION_ID:0:8}"
     else
-        printf 'clai [%s] Alt+S suggestions | Alt+H history | ?"describe task"\n' "${CLAI_SESSION_ID:0:8}"
+        printf 'clai [%s] {{CLAI_KEY_SUGGEST_WIDGET_LABEL}} suggestions | {{CLAI_KEY_HISTORY_PICKER_LABEL}} history | ?"describe task"\n' "${CLAI_SESSION_ID:0:8}"
     fi
 
     # Register session + import history (fire and forget).
@@ -16,12 +16,19 @@
 # Export current shell for clai doctor/status detection
 set -gx CLAI_CURRENT_SHELL fish
 
+# Version of clai that generated this script (clai status flags a stale one)
+set -gx CLAI_INIT_VERSION "{{CLAI_VERSION}}"
+
 if not set -q CLAI_AUTO_EXTRACT
     set -gx CLAI_AUTO_EXTRACT true
 end
 
 if not set -q CLAI_CACHE
-    set -gx CLAI_CACHE "$HOME/.cache/clai"
+    if test -n "$CLAI_PROFILE"
+        set -gx CLAI_CACHE "$HOME/.cache/clai/profiles/$CLAI_PROFILE"
+    else
+        set -gx CLAI_CACHE "$HOME/.cache/clai"
+    end
 end
 
 if not set -q CLAI_MENU_LIMIT
@@ -36,6 +43,9 @@ end
 if not set -q CLAI_UP_ARROW_DOUBLE_WINDOW_MS
     set -gx CLAI_UP_ARROW_DOUBLE_WINDOW_MS {{CLAI_UP_ARROW_DOUBLE_WINDOW_MS}}
 end
+if not set -q CLAI_CONFIRM_RISKY
+    set -gx CLAI_CONFIRM_RISKY {{CLAI_CONFIRM_RISKY}}
+end
 
 # Ensure cache directory exists
 mkdir -p $CLAI_CACHE
@@ -501,17 +511,18 @@ for mode in default insert visual
     bind -M $mode \cxg _clai_history_scope_global
 end
 
-# Alt/Option+H opens TUI picker.
-# \eh works when the terminal sends ESC for Alt. The literal ˙ covers
+# client.keybindings.history_picker (Alt/Option+H by default) opens TUI picker.
+# Alt+H is \eh when the terminal sends ESC for Alt. The literal ˙ covers
 # macOS Terminal.app/iTerm2 defaults where Option+H produces U+02D9.
 for mode in default insert visual
-    bind -M $mode \eh _clai_tui_picker_open
+    bind -M $mode {{CLAI_KEY_HISTORY_PICKER}} _clai_tui_picker_open
     bind -M $mode ˙ _clai_tui_picker_open
 end
 
-# Alt/Option+S opens the suggestions TUI picker.
+# client.keybindings.suggest_widget (Alt/Option+S by default) opens the
+# suggestions TUI picker.
 for mode in default insert visual
-    bind -M $mode \es _clai_tui_suggest_picker_open
+    bind -M $mode {{CLAI_KEY_SUGGEST_WIDGET}} _clai_tui_suggest_picker_open
     bind -M $mode ß _clai_tui_suggest_picker_open
 end
 
@@ -605,6 +616,17 @@ function _ai_enter_voice_mode
     commandline -f repaint
 end
 
+# Print the warning for a destructive command and ask whether to run it
+# anyway (suggestions.confirm_risky). Returns 1 when the user declines.
+function _ai_confirm_risky
+    set -l warning (clai-shim confirm --command="$argv[1]" 2>/dev/null)
+    test -z "$warning"; and return 0
+    echo
+    printf '%s\n' $warning
+    read -l -n 1 -P "Run anyway? [y/N] " answer
+    string match -qi 'y' -- $answer
+end
+
 function _ai_voice_execute
     # If picker is open, accept the current selection (don't execute)
     if test "$_CLAI_PICKER_ACTIVE" = "true"
@@ -655,6 +677,15 @@ function _ai_voice_execute
         return
     end
 
+    # Ask before running a command the risk classifier flags; declining
+    # keeps it in the command line
+    if test "$CLAI_CONFIRM_RISKY" = "true"; and test -n "$current_cmd"
+        if not _ai_confirm_risky "$current_cmd"
+            commandline -f repaint
+            return
+        end
+    end
+
     # Normal execute
     set -g _AI_VOICE_MODE false
     _clai_picker_clear_menu
@@ -894,8 +925,10 @@ function _clai_disable
         # Remove custom keybindings
         bind -M $mode \e ''
         bind -M $mode \e\r ''
-        bind -M $mode \eh ''
+        bind -M $mode {{CLAI_KEY_HISTORY_PICKER}} ''
         bind -M $mode ˙ ''
+        bind -M $mode {{CLAI_KEY_SUGGEST_WIDGET}} ''
+        bind -M $mode ß ''
         bind -M $mode \cx\cv ''
         bind -M $mode \cxs ''
         bind -M $mode \cxd ''
@@ -956,6 +989,15 @@ end
 # Startup Message
 # ============================================
 
+# Notify daemon the session is ending when the shell exits
+function _clai_cleanup --on-event fish_exit
+    if test -z "$CLAI_SESSION_ID"
+        return
+    end
+    clai-shim session-end --session-id="$CLAI_SESSION_ID" >/dev/null 2>&1 &
+    disown %1 2>/dev/null
+end
+
 if status is-interactive; and not set -q _CLAI_REINIT
     # Register session with daemon (fire and forget)
     # This notifies the daemon of the new shell session
@@ -988,8 +1030,8 @@ if status is-interactive; and not set -q _CLAI_REINIT
     end
 
     if test "$supports_utf8" -eq 1
-        printf '\e[2m🤖 clai [%s] Alt+S suggestions | Alt+H history | ?"describe task"\e[0m\n' "$short_id"
+        printf '\e[2m🤖 clai [%s] {{CLAI_KEY_SUGGEST_WIDGET_LABEL}} suggestions | {{CLAI_KEY_HISTORY_PICKER_LABEL}} history | ?"describe task"\e[0m\n' "$short_id"
     else
-        echo "clai [$short_id] Alt+S suggestions | Alt+H history | ?\"describe task\""
+        echo "clai [$short_id] {{CLAI_KEY_SUGGEST_WIDGET_LABEL}} suggestions | {{CLAI_KEY_HISTORY_PICKER_LABEL}} history | ?\"describe task\""
     end
 end
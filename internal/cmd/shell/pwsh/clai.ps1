@@ -0,0 +1,204 @@
+# clai.ps1 - clai shell integration for PowerShell
+# Generated by: clai init pwsh
+#
+# Features:
+#   1. Command logging via PSReadLine (Enter) and the prompt function
+#   2. Suggestion for the current line on Alt+Enter
+#   3. TUI pickers: {{CLAI_KEY_HISTORY_PICKER_LABEL}} history, {{CLAI_KEY_SUGGEST_WIDGET_LABEL}} suggestions (needs clai-picker)
+#
+# Requires PowerShell 7+ with PSReadLine. Add to $PROFILE:
+#   clai init pwsh | Out-String | Invoke-Expression
+#
+# Configuration (set these BEFORE loading):
+#   $env:CLAI_CACHE = "$HOME/.cache/clai"
+
+# ============================================
+# Configuration
+# ============================================
+
+# Export current shell for clai doctor/status detection
+$env:CLAI_CURRENT_SHELL = 'pwsh'
+
+# Version of clai that generated this script (clai status flags a stale one)
+$env:CLAI_INIT_VERSION = '{{CLAI_VERSION}}'
+
+if (-not $env:CLAI_CACHE) {
+    if ($env:CLAI_PROFILE) {
+        $env:CLAI_CACHE = Join-Path $HOME ".cache/clai/profiles/$env:CLAI_PROFILE"
+    } else {
+        $env:CLAI_CACHE = Join-Path $HOME '.cache/clai'
+    }
+}
+
+# Ensure cache directory exists
+$null = New-Item -ItemType Directory -Force -Path $env:CLAI_CACHE -ErrorAction SilentlyContinue
+
+# Session ID for this shell instance (generated by clai init)
+$env:CLAI_SESSION_ID = '{{CLAI_SESSION_ID}}'
+
+$global:_ClaiCommandId = ''
+$global:_ClaiCommandStart = $null
+
+function global:_clai_session_off {
+    Test-Path (Join-Path $env:CLAI_CACHE 'off')
+}
+
+function global:_clai_disabled {
+    ($env:CLAI_OFF -eq '1') -or (_clai_session_off)
+}
+
+# Run a clai binary in the background, discarding its output (fire and forget).
+function global:_clai_spawn {
+    param([string]$Program, [string[]]$Arguments)
+    $psi = [System.Diagnostics.ProcessStartInfo]::new($Program)
+    foreach ($arg in $Arguments) {
+        $psi.ArgumentList.Add($arg)
+    }
+    $psi.UseShellExecute = $false
+    $psi.RedirectStandardOutput = $true
+    $psi.RedirectStandardError = $true
+    try {
+        $null = [System.Diagnostics.Process]::Start($psi)
+    } catch {
+        # Not on PATH - nothing to notify
+    }
+}
+
+# ============================================
+# Command Logging (for history daemon)
+# ============================================
+
+# Log command start (called by the Enter handler before the line runs)
+function global:_clai_preexec {
+    param([string]$Command)
+    if ((_clai_disabled) -or -not $env:CLAI_SESSION_ID -or -not $Command.Trim()) {
+        return
+    }
+    $global:_ClaiCommandId = "$env:CLAI_SESSION_ID-$([DateTimeOffset]::UtcNow.ToUnixTimeSeconds())-$(Get-Random)"
+    $global:_ClaiCommandStart = [DateTimeOffset]::UtcNow.ToUnixTimeMilliseconds()
+
+    # Export last command for child processes (used to suppress suggesting it again).
+    $env:CLAI_LAST_COMMAND = $Command
+
+    _clai_spawn clai-shim @(
+        'log-start',
+        "--session-id=$env:CLAI_SESSION_ID",
+        "--command-id=$global:_ClaiCommandId",
+        "--cwd=$($PWD.ProviderPath)",
+        "--command=$Command"
+    )
+}
+
+# Log command end (called from the prompt once the command finished)
+function global:_clai_postexec {
+    param([int]$ExitCode)
+    if (-not $global:_ClaiCommandId) {
+        return
+    }
+    $duration = [DateTimeOffset]::UtcNow.ToUnixTimeMilliseconds() - $global:_ClaiCommandStart
+    _clai_spawn clai-shim @(
+        'log-end',
+        "--session-id=$env:CLAI_SESSION_ID",
+        "--command-id=$global:_ClaiCommandId",
+        "--exit-code=$ExitCode",
+        "--duration=$duration"
+    )
+    $global:_ClaiCommandId = ''
+    $global:_ClaiCommandStart = $null
+}
+
+# Wrap the existing prompt so each command's end is logged before it renders.
+# Keep the original across re-init so the wrapper does not wrap itself.
+if (-not $global:_ClaiOriginalPrompt) {
+    $global:_ClaiOriginalPrompt = $function:prompt
+}
+function global:prompt {
+    # Capture status first: anything below overwrites $?
+    $ok = $?
+    $exitCode = 0
+    if (-not $ok) {
+        $exitCode = if ($global:LASTEXITCODE) { $global:LASTEXITCODE } else { 1 }
+    }
+    _clai_postexec $exitCode
+    & $global:_ClaiOriginalPrompt
+}
+
+# ============================================
+# Key Handlers (PSReadLine)
+# ============================================
+
+function global:_clai_buffer {
+    $line = $null
+    $cursor = $null
+    [Microsoft.PowerShell.PSConsoleReadLine]::GetBufferState([ref]$line, [ref]$cursor)
+    $line
+}
+
+function global:_clai_replace_buffer {
+    param([string]$Text)
+    $line = _clai_buffer
+    [Microsoft.PowerShell.PSConsoleReadLine]::Replace(0, $line.Length, $Text)
+    [Microsoft.PowerShell.PSConsoleReadLine]::SetCursorPosition($Text.Length)
+}
+
+# Run clai-picker and put the selection on the command line.
+# Exit codes: 0 = selection, 1 = cancel, 2 = fallback to native behavior.
+function global:_clai_picker {
+    param([string]$Mode)
+    if ((_clai_disabled) -or -not (Get-Command clai-picker -CommandType Application -ErrorAction SilentlyContinue)) {
+        return
+    }
+    $result = & clai-picker $Mode "--query=$(_clai_buffer)" "--session=$env:CLAI_SESSION_ID" "--cwd=$($PWD.ProviderPath)" 2>$null
+    if ($LASTEXITCODE -eq 0 -and $result) {
+        _clai_replace_buffer (@($result) -join "`n")
+    }
+    [Microsoft.PowerShell.PSConsoleReadLine]::InvokePrompt()
+}
+
+if (Get-Module PSReadLine) {
+    # Enter: log the command, then run it
+    Set-PSReadLineKeyHandler -Key Enter -BriefDescription 'ClaiAcceptLine' -ScriptBlock {
+        _clai_preexec (_clai_buffer)
+        [Microsoft.PowerShell.PSConsoleReadLine]::AcceptLine()
+    }
+
+    # Alt+Enter: replace the line with clai's suggestion for it
+    Set-PSReadLineKeyHandler -Chord 'Alt+Enter' -BriefDescription 'ClaiAcceptSuggestion' -ScriptBlock {
+        if (_clai_disabled) {
+            return
+        }
+        $suggestion = & clai suggest --format fzf --limit 1 (_clai_buffer) 2>$null | Select-Object -First 1
+        if ($suggestion) {
+            _clai_replace_buffer $suggestion
+            _clai_spawn clai @('suggest-feedback', '--action=accepted', "--suggested=$suggestion")
+        }
+    }
+
+    # History and suggestion pickers (client.keybindings in the clai config)
+    Set-PSReadLineKeyHandler -Chord '{{CLAI_KEY_HISTORY_PICKER}}' -BriefDescription 'ClaiHistoryPicker' -ScriptBlock {
+        _clai_picker history
+    }
+    Set-PSReadLineKeyHandler -Chord '{{CLAI_KEY_SUGGEST_WIDGET}}' -BriefDescription 'ClaiSuggestPicker' -ScriptBlock {
+        _clai_picker suggest
+    }
+}
+
+# ============================================
+# Startup
+# ============================================
+
+if (-not $global:_ClaiInitialized) {
+    $global:_ClaiInitialized = $true
+
+    # Register session with daemon (fire and forget)
+    # Note: claid starts lazily via clai-shim -> ipc.NewClient() -> EnsureDaemon()
+    _clai_spawn clai-shim @('session-start', "--session-id=$env:CLAI_SESSION_ID", "--cwd=$($PWD.ProviderPath)", '--shell=pwsh')
+
+    # Notify daemon the session is ending when the shell exits
+    $null = Register-EngineEvent -SourceIdentifier PowerShell.Exiting -Action {
+        & clai-shim session-end "--session-id=$env:CLAI_SESSION_ID" *> $null
+    }
+
+    $shortId = $env:CLAI_SESSION_ID.Substring(0, [Math]::Min(8, $env:CLAI_SESSION_ID.Length))
+    Write-Host "clai [$shortId] {{CLAI_KEY_SUGGEST_WIDGET_LABEL}} suggestions | {{CLAI_KEY_HISTORY_PICKER_LABEL}} history" -ForegroundColor DarkGray
+}
@@ -11,7 +11,7 @@ import (
 
 // ShellDetection contains the result of shell detection.
 type ShellDetection struct {
-	Shell     string // "zsh", "bash", "fish", or ""
+	Shell     string // "zsh", "bash", "fish", "pwsh", "nu", or ""
 	Confident bool   // true if detected via parent process, false if fell back to $SHELL
 	Active    bool   // true if clai shell integration is active in this shell
 }
@@ -103,7 +103,7 @@ func extractShellName(name string) string {
 // isKnownShell returns true if the shell name is one we support.
 func isKnownShell(name string) bool {
 	switch name {
-	case "zsh", "bash", "fish":
+	case "zsh", "bash", "fish", "pwsh", "nu":
 		return true
 	}
 	return false
@@ -111,5 +111,5 @@ func isKnownShell(name string) bool {
 
 // SupportedShells returns the list of supported shell names.
 func SupportedShells() []string {
-	return []string{"zsh", "bash", "fish"}
+	return []string{"zsh", "bash", "fish", "pwsh", "nu"}
 }
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/usage"
+)
+
+var (
+	statsDays   int
+	statsTop    int
+	statsFormat string
+)
+
+var statsCmd = &cobra.Command{
+	Use:     "stats",
+	Short:   "Show how you use the shell: commands per day, success rate, top commands",
+	GroupID: groupCore,
+	Long: `Show usage statistics for the last --days days (today included): commands
+run per day, how many of them succeeded compared with the days before, how
+often you accepted suggestions, the busiest hours of the day, and the most
+frequently run commands and most used directories.
+
+Statistics come from daily aggregates the daemon keeps as commands are
+recorded, so they stay fast on large histories and cover commands that
+retention has since deleted. Days and hours are in local time. Commands
+imported from shell history have no exit code or directory and do not count
+towards the success rate or the directories.
+
+Examples:
+  clai stats                  # The last 30 days
+  clai stats --days 7 --top 5 # The last week's five most-run commands
+  clai stats --json`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+func init() {
+	statsCmd.Flags().IntVar(&statsDays, "days", 30, "Number of days to cover, ending today")
+	statsCmd.Flags().IntVar(&statsTop, "top", 10, "Number of most-run commands and most-used directories to show")
+	statsCmd.Flags().StringVar(&statsFormat, "format", "text", "Output format: text or json")
+	addJSONFlag(statsCmd, &statsFormat)
+}
+
+func runStats(cmd *cobra.Command, _ []string) error {
+	format := strings.ToLower(strings.TrimSpace(statsFormat))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format: %s (use text or json)", statsFormat)
+	}
+	if statsDays <= 0 {
+		return fmt.Errorf("invalid days: must be > 0")
+	}
+	if statsTop < 0 {
+		return fmt.Errorf("invalid top: must be >= 0")
+	}
+
+	dbPath, err := suggestdb.DefaultDBPath()
+	if err != nil {
+		return err
+	}
+	if _, statErr := os.Stat(dbPath); os.IsNotExist(statErr) {
+		return errors.New("no suggestions database yet; run some commands with clai enabled first")
+	}
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Second)
+	defer cancel()
+
+	// Not read-only: opening migrates a database the running daemon created
+	// before the usage tables existed. SQLite's locking keeps that safe.
+	sdb, err := suggestdb.Open(ctx, suggestdb.Options{Path: dbPath, SkipLock: true, EncryptionKey: key})
+	if err != nil {
+		return fmt.Errorf("failed to open suggestions database: %w", err)
+	}
+	defer sdb.Close()
+
+	now := time.Now()
+	from, to := statsRange(now, statsDays)
+	summary, err := usage.Report(ctx, sdb.DB(), from, to, statsTop)
+	if err != nil {
+		return err
+	}
+	// The same number of days before, for the success rate trend.
+	prevFrom, prevTo := statsRange(now.AddDate(0, 0, -statsDays), statsDays)
+	previous, err := usage.Report(ctx, sdb.DB(), prevFrom, prevTo, 0)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statsJSON{Summary: summary, Previous: &previous.Total})
+	}
+	writeStatsText(os.Stdout, summary, previous, statsDays)
+	return nil
+}
+
+// statsJSON is the --format json output: the summary, plus the totals of
+// the period before it.
+type statsJSON struct {
+	*usage.Summary
+	Previous *usage.Counts `json:"previous_total"`
+}
+
+// statsRange returns the first and last day, in usage.DayLayout, of the
+// days days ending with now's local day.
+func statsRange(now time.Time, days int) (from, to string) {
+	now = now.Local()
+	return now.AddDate(0, 0, -(days - 1)).Format(usage.DayLayout), now.Format(usage.DayLayout)
+}
+
+// writeStatsText writes the report for s; previous, if not nil, is the
+// period of the same length before it.
+func writeStatsText(w io.Writer, s, previous *usage.Summary, days int) {
+	fmt.Fprintf(w, "Last %d days (%s to %s)\n", days, s.From, s.To)
+	if s.Total.Commands == 0 {
+		fmt.Fprintln(w, "  No commands recorded.")
+		return
+	}
+
+	fmt.Fprintf(w, "  Commands:      %d (%d active days, %.0f per active day)\n",
+		s.Total.Commands, len(s.Days), float64(s.Total.Commands)/float64(len(s.Days)))
+	if s.Total.Successes+s.Total.Failures > 0 {
+		trend := ""
+		if previous != nil && previous.Total.Successes+previous.Total.Failures > 0 {
+			trend = fmt.Sprintf(" (%+.1f points on the %d days before)",
+				100*(s.Total.SuccessRate()-previous.Total.SuccessRate()), days)
+		}
+		fmt.Fprintf(w, "  Success rate:  %.1f%%%s\n", 100*s.Total.SuccessRate(), trend)
+	}
+	if f := s.Feedback; f.Accepted+f.Rejected > 0 {
+		fmt.Fprintf(w, "  Suggestions:   %.1f%% accepted (%d of %d)\n",
+			100*f.AcceptanceRate(), f.Accepted, f.Accepted+f.Rejected)
+	}
+
+	busiest := s.BusiestHours(3)
+	parts := make([]string, len(busiest))
+	for i, h := range busiest {
+		parts[i] = fmt.Sprintf("%02d:00 (%d)", h, s.Hours[h])
+	}
+	fmt.Fprintf(w, "  Busiest hours: %s\n", strings.Join(parts, ", "))
+
+	fmt.Fprintln(w, "\nBy hour:")
+	fmt.Fprintf(w, "  %s\n", hourSparkline(s.Hours))
+	fmt.Fprintln(w, "  00    06    12    18")
+
+	if len(s.TopTemplates) > 0 {
+		fmt.Fprintln(w, "\nTop commands:")
+		for _, t := range s.TopTemplates {
+			name := t.CmdNorm
+			if name == "" {
+				name = "(template " + t.TemplateID + ")"
+			}
+			fmt.Fprintf(w, "  %6d  %s\n", t.Count, name)
+		}
+	}
+
+	if len(s.TopDirs) > 0 {
+		fmt.Fprintln(w, "\nBusiest directories:")
+		for _, d := range s.TopDirs {
+			fmt.Fprintf(w, "  %6d  %s\n", d.Count, d.Cwd)
+		}
+	}
+
+	var most int64
+	for _, d := range s.Days {
+		most = max(most, d.Commands)
+	}
+	fmt.Fprintln(w, "\nBy day:")
+	for _, d := range s.Days {
+		rate := "-"
+		if d.Successes+d.Failures > 0 {
+			rate = fmt.Sprintf("%.1f%%", 100*d.SuccessRate())
+		}
+		fmt.Fprintf(w, "  %s  %6d  %6s  %s\n", d.Day, d.Commands, rate, statsBar(d.Commands, most))
+	}
+}
+
+// statsBarWidth is the width of the bar of the busiest day.
+const statsBarWidth = 30
+
+// statsBar returns a bar for n out of most, at least one block wide when n
+// is positive.
+func statsBar(n, most int64) string {
+	if n <= 0 || most <= 0 {
+		return ""
+	}
+	return strings.Repeat("█", max(1, int(n*statsBarWidth/most)))
+}
+
+// sparkBlocks are the sparkline levels, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// hourSparkline returns one block per hour of the day, scaled to the
+// busiest hour; hours without commands are blank.
+func hourSparkline(hours [24]int64) string {
+	var most int64
+	for _, n := range hours {
+		most = max(most, n)
+	}
+	var b strings.Builder
+	for _, n := range hours {
+		if n <= 0 || most <= 0 {
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteRune(sparkBlocks[(n*int64(len(sparkBlocks))-1)/most])
+	}
+	return strings.TrimRight(b.String(), " ")
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runger/clai/internal/suggestions/usage"
+)
+
+func TestStatsRange(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+
+	from, to := statsRange(now, 7)
+	if from != "2026-02-24" || to != "2026-03-02" {
+		t.Errorf("statsRange(7) = %s..%s, want 2026-02-24..2026-03-02", from, to)
+	}
+	from, to = statsRange(now, 1)
+	if from != to {
+		t.Errorf("statsRange(1) = %s..%s, want a single day", from, to)
+	}
+}
+
+func TestWriteStatsText(t *testing.T) {
+	s := &usage.Summary{
+		From: "2026-03-01",
+		To:   "2026-03-02",
+		Days: []usage.Day{
+			{Day: "2026-03-01", Counts: usage.Counts{Commands: 3, Successes: 2, Failures: 1}},
+			{Day: "2026-03-02", Counts: usage.Counts{Commands: 1}},
+		},
+		TopTemplates: []usage.Template{{TemplateID: "tpl-git", CmdNorm: "git status", Count: 3}},
+		TopDirs:      []usage.Dir{{Cwd: "/src/clai", Count: 4}},
+		Total:        usage.Counts{Commands: 4, Successes: 2, Failures: 1},
+		Feedback:     usage.Feedback{Accepted: 3, Rejected: 1},
+	}
+	s.Hours[9] = 3
+	s.Hours[14] = 1
+	previous := &usage.Summary{Total: usage.Counts{Commands: 2, Successes: 1, Failures: 1}}
+
+	var buf bytes.Buffer
+	writeStatsText(&buf, s, previous, 2)
+	out := buf.String()
+
+	for _, want := range []string{
+		"Last 2 days (2026-03-01 to 2026-03-02)",
+		"Commands:      4 (2 active days, 2 per active day)",
+		"Success rate:  66.7% (+16.7 points on the 2 days before)",
+		"Suggestions:   75.0% accepted (3 of 4)",
+		"Busiest hours: 09:00 (3), 14:00 (1)",
+		"3  git status",
+		"4  /src/clai",
+		"2026-03-01       3   66.7%  " + strings.Repeat("█", 30),
+		"2026-03-02       1       -  " + strings.Repeat("█", 10),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteStatsText_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	writeStatsText(&buf, &usage.Summary{From: "2026-03-01", To: "2026-03-30"}, nil, 30)
+	if !strings.Contains(buf.String(), "No commands recorded.") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestHourSparkline(t *testing.T) {
+	var hours [24]int64
+	hours[0] = 1
+	hours[2] = 8
+	hours[3] = 4
+	if got, want := hourSparkline(hours), "▁ █▄"; got != want {
+		t.Errorf("hourSparkline() = %q, want %q", got, want)
+	}
+	if got := hourSparkline([24]int64{}); got != "" {
+		t.Errorf("hourSparkline(empty) = %q, want blank", got)
+	}
+}
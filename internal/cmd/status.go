@@ -1,16 +1,21 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/runger/clai/internal/config"
 	"github.com/runger/clai/internal/daemon"
+	"github.com/runger/clai/internal/ipc"
+	"github.com/runger/clai/internal/statusview"
 )
 
 var statusCmd = &cobra.Command{
@@ -23,11 +28,24 @@ var statusCmd = &cobra.Command{
 - Daemon status
 - Storage and configuration
 
+When the daemon is running, its version, uptime, sessions, database
+sizes, queue depth, provider health and last maintenance run are shown
+as well. Use --json (or --format json) for machine-readable daemon
+status; when the daemon is not running, it prints {"error": "not connected"}.
+
 Examples:
-  clai status`,
+  clai status
+  clai status --json`,
 	RunE: runStatus,
 }
 
+var statusFormat string
+
+func init() {
+	statusCmd.Flags().StringVar(&statusFormat, "format", "text", "Output format: text or json")
+	addJSONFlag(statusCmd, &statusFormat)
+}
+
 type statusCheck struct {
 	name    string
 	status  string // "ok", "warn", "error"
@@ -35,6 +53,14 @@ type statusCheck struct {
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(strings.TrimSpace(statusFormat))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format: %s (use text or json)", statusFormat)
+	}
+	if format == "json" {
+		return writeStatusJSON()
+	}
+
 	paths := config.DefaultPaths()
 
 	fmt.Printf("%sclai status%s\n", colorBold, colorReset)
@@ -45,10 +71,14 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		checkClaudeCLI(),        // Claude CLI
 		checkShellStatus(paths), // shell integration
 		checkSessionID(),        // session ID
+		checkProfile(paths),     // profile
 		checkDaemonStatus(),     // daemon
 		checkStorage(paths),     // storage
 		checkConfig(paths),      // configuration
 	}
+	if c, ok := checkRepoConfig(paths); ok {
+		checks = append(checks, c)
+	}
 
 	// Print results
 	hasErrors := false
@@ -76,6 +106,12 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	fmt.Println()
 
+	if view, err := fetchDaemonStatus(); err == nil {
+		fmt.Printf("%sDaemon%s\n", colorBold, colorReset)
+		view.Render(os.Stdout, time.Now())
+		fmt.Println()
+	}
+
 	if hasErrors {
 		fmt.Printf("%sSome checks failed.%s\n", colorRed, colorReset)
 		return fmt.Errorf("status check found errors")
@@ -90,6 +126,33 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// fetchDaemonStatus queries a running daemon without spawning one.
+func fetchDaemonStatus() (*statusview.View, error) {
+	conn, err := ipc.QuickDial()
+	if err != nil {
+		return nil, err
+	}
+	client := ipc.NewClientWithConn(conn)
+	defer client.Close()
+
+	resp, err := client.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+	return statusview.FromProto(resp, Version), nil
+}
+
+// writeStatusJSON prints daemon status as JSON, or an error object if the daemon is unreachable.
+func writeStatusJSON() error {
+	view, err := fetchDaemonStatus()
+	if err != nil {
+		data, _ := json.Marshal(map[string]string{"error": "not connected"})
+		fmt.Println(string(data))
+		return nil
+	}
+	return view.WriteJSON(os.Stdout)
+}
+
 func checkOnOff() statusCheck {
 	if integrationDisabled() {
 		return statusCheck{
@@ -152,6 +215,18 @@ func checkSessionID() statusCheck {
 	if len(sessionID) > 8 {
 		shortID = sessionID[:8]
 	}
+	// A script generated by another clai version may miss hooks this
+	// binary expects (or call commands it no longer has).
+	if initVersion := os.Getenv("CLAI_INIT_VERSION"); initVersion != Version {
+		if initVersion == "" {
+			initVersion = "an older clai"
+		}
+		return statusCheck{
+			name:    "Session",
+			status:  "warn",
+			message: fmt.Sprintf("%s (shell integration from %s, binary is %s; restart the shell)", shortID, initVersion, Version),
+		}
+	}
 	return statusCheck{
 		name:    "Session",
 		status:  "ok",
@@ -159,7 +234,37 @@ func checkSessionID() statusCheck {
 	}
 }
 
+func checkProfile(paths *config.Paths) statusCheck {
+	profile := config.Profile()
+	if err := config.ValidateProfile(profile); err != nil {
+		return statusCheck{name: "Profile", status: "error", message: err.Error()}
+	}
+	if profile == "" {
+		return statusCheck{name: "Profile", status: "ok", message: "default"}
+	}
+	message := fmt.Sprintf("%s (%s)", profile, paths.BaseDir)
+	if cfg, err := config.Load(); err == nil && slices.Contains(cfg.PresetNames(), profile) {
+		message += ", preset profiles." + profile
+	}
+	return statusCheck{name: "Profile", status: "ok", message: message}
+}
+
 func checkDaemonStatus() statusCheck {
+	if ipc.RemoteMode() {
+		if conn, err := ipc.QuickDial(); err == nil {
+			conn.Close()
+			return statusCheck{
+				name:    "Daemon",
+				status:  "ok",
+				message: "remote, forwarded to " + ipc.SocketPath(),
+			}
+		}
+		return statusCheck{
+			name:    "Daemon",
+			status:  "warn",
+			message: "remote, no forwarded socket at " + ipc.SocketPath() + " (is the SSH forward up?)",
+		}
+	}
 	if daemon.IsRunning() {
 		return statusCheck{
 			name:    "Daemon",
@@ -193,10 +298,34 @@ func checkStorage(paths *config.Paths) statusCheck {
 		dbSize = fmt.Sprintf(" (db: %s)", formatSize(info.Size()))
 	}
 
+	message := baseDir + dbSize
+	if dataDir := paths.DataDir(); dataDir != baseDir {
+		message = fmt.Sprintf("%s, data in %s%s", baseDir, dataDir, dbSize)
+	}
 	return statusCheck{
 		name:    "Storage",
 		status:  "ok",
-		message: baseDir + dbSize,
+		message: message,
+	}
+}
+
+// checkRepoConfig reports the .clai/config.yaml of the current
+// repository; ok is false when there is none.
+func checkRepoConfig(paths *config.Paths) (check statusCheck, ok bool) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return statusCheck{}, false
+	}
+	_, _, status, err := config.DefaultConfig().LoadRepo(paths, cwd)
+	switch status {
+	case config.RepoApplied:
+		return statusCheck{name: "Repo config", status: "ok", message: "applied"}, true
+	case config.RepoUntrusted:
+		return statusCheck{name: "Repo config", status: "warn", message: "ignored until trusted: run 'clai config trust'"}, true
+	case config.RepoInvalid:
+		return statusCheck{name: "Repo config", status: "error", message: err.Error()}, true
+	default:
+		return statusCheck{}, false
 	}
 }
 
@@ -284,14 +413,5 @@ func checkShellIntegration() []string {
 }
 
 func formatSize(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
-	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	return statusview.FormatSize(bytes)
 }
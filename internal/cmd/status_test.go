@@ -408,3 +408,29 @@ func TestCheckShellIntegrationWithPaths_SessionIDWithoutShell(t *testing.T) {
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
+
+func TestStatusCmd_HasJSONFlag(t *testing.T) {
+	if statusCmd.Flags().Lookup("json") == nil {
+		t.Fatal("status command should have a --json flag")
+	}
+}
+
+func TestCheckSessionID_InitVersion(t *testing.T) {
+	t.Setenv("CLAI_SESSION_ID", "0123456789abcdef")
+
+	t.Setenv("CLAI_INIT_VERSION", Version)
+	if got := checkSessionID(); got.status != "ok" || got.message != "01234567" {
+		t.Errorf("current script: got %+v", got)
+	}
+
+	t.Setenv("CLAI_INIT_VERSION", "v0.0.1")
+	got := checkSessionID()
+	if got.status != "warn" || !strings.Contains(got.message, "v0.0.1") {
+		t.Errorf("stale script: got %+v", got)
+	}
+
+	t.Setenv("CLAI_INIT_VERSION", "")
+	if got := checkSessionID(); got.status != "warn" || !strings.Contains(got.message, "an older clai") {
+		t.Errorf("unversioned script: got %+v", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +15,7 @@ import (
 
 	pb "github.com/runger/clai/gen/clai/v1"
 	"github.com/runger/clai/internal/cache"
+	"github.com/runger/clai/internal/cmdutil"
 	"github.com/runger/clai/internal/config"
 	"github.com/runger/clai/internal/history"
 	"github.com/runger/clai/internal/ipc"
@@ -38,6 +40,10 @@ var (
 	sessionTimingMachines = make(map[string]*timing.Machine)
 )
 
+// processStart approximates when the process started, for the latency
+// `clai suggest` reports to the daemon.
+var processStart = time.Now()
+
 var suggestCmd = &cobra.Command{
 	Use:     "suggest [prefix]",
 	Short:   "Get command suggestion from session history or shell history",
@@ -60,7 +66,7 @@ Examples:
 
 func init() {
 	suggestCmd.Flags().IntVarP(&suggestLimit, "limit", "n", 1, "maximum number of suggestions to return")
-	suggestCmd.Flags().BoolVar(&suggestJSON, "json", false, "output suggestions as JSON (deprecated: use --format=json)")
+	suggestCmd.Flags().BoolVar(&suggestJSON, "json", false, "output suggestions as JSON (shorthand for --format json)")
 	suggestCmd.Flags().StringVar(&suggestFormat, "format", "text", "output format: text, json, fzf, or ghost")
 	suggestCmd.Flags().StringVar(&colorMode, "color", "auto", "color output: auto, always, or never")
 	suggestCmd.Flags().BoolVar(&suggestExplain, "explain", false, "include reasons explaining why each suggestion was ranked")
@@ -220,24 +226,36 @@ func formatGhostMeta(s *suggestOutput) string {
 	risk := strings.TrimSpace(strings.ToLower(s.Risk))
 	if risk == "destructive" {
 		meta += "  · [!] destructive"
+		if msg := strings.TrimSpace(s.RiskMessage); msg != "" {
+			meta += ": " + msg
+		}
 	}
 	return meta
 }
 
 // outputSuggestions formats and outputs suggestions based on format type.
 func outputSuggestions(suggestions []suggestOutput, format string, hint *timing.TimingHint) error {
+	// Suggestions from shell history, or from an older daemon, have no word
+	// boundaries yet.
+	for i := range suggestions {
+		if suggestions[i].Boundaries == nil {
+			suggestions[i].Boundaries = cmdutil.WordBoundaries(suggestions[i].Text)
+		}
+	}
+
 	switch format {
 	case "json":
 		return writeSuggestJSON(suggestions, hint)
 	case "fzf":
 		outputPlainSuggestions(suggestions)
 	case "ghost":
-		// ghost format: one suggestion per line as "command<TAB>meta".
-		// This is used for inline ghost text in shells where accepting the
-		// suggestion must insert only the command text, but the UI can display
-		// additional metadata.
+		// ghost format: one suggestion per line as
+		// "command<TAB>meta<TAB>boundaries". This is used for inline ghost
+		// text in shells where accepting the suggestion must insert only the
+		// command text, but the UI can display additional metadata. The
+		// comma-separated word boundaries let widgets accept it word by word.
 		for i := range suggestions {
-			fmt.Printf("%s\t%s\n", suggestions[i].Text, formatGhostMeta(&suggestions[i]))
+			fmt.Printf("%s\t%s\t%s\n", suggestions[i].Text, formatGhostMeta(&suggestions[i]), formatBoundaries(suggestions[i].Boundaries))
 		}
 	case "text":
 		// text format: numbered list with metadata
@@ -255,6 +273,15 @@ func outputSuggestions(suggestions []suggestOutput, format string, hint *timing.
 	return nil
 }
 
+// formatBoundaries joins word boundaries with commas for the ghost format.
+func formatBoundaries(bounds []int) string {
+	parts := make([]string, len(bounds))
+	for i, b := range bounds {
+		parts[i] = strconv.Itoa(b)
+	}
+	return strings.Join(parts, ",")
+}
+
 func outputPlainSuggestions(suggestions []suggestOutput) {
 	for _, s := range suggestions {
 		fmt.Println(s.Text)
@@ -266,7 +293,11 @@ type suggestOutput struct {
 	Source      string           `json:"source"`
 	Description string           `json:"description"`
 	Risk        string           `json:"risk"`
+	RiskMessage string           `json:"risk_message,omitempty"`
 	Recency     string           `json:"recency,omitempty"`
+	Kind        string           `json:"kind,omitempty"`            // "workflow" for a multi-command suggestion
+	Steps       []string         `json:"steps,omitempty"`           // Commands of a workflow suggestion, in order
+	Boundaries  []int            `json:"word_boundaries,omitempty"` // Where accepting Text word by word stops
 	Reasons     []explain.Reason `json:"reasons,omitempty"`
 	Score       float64          `json:"score"`
 	CwdMatch    bool             `json:"cwd_match,omitempty"`
@@ -334,6 +365,7 @@ func getSuggestionsFromDaemon(prefix string, limit int) []suggestOutput {
 		return nil
 	}
 	defer client.Close()
+	client.MeasureFrom(processStart)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer cancel()
@@ -370,8 +402,14 @@ func daemonSuggestionToOutput(s *pb.Suggestion, includeReasons bool) suggestOutp
 		Score:       float64(s.Score),
 		Description: s.Description,
 		Risk:        s.Risk,
+		RiskMessage: s.RiskMessage,
 		CwdMatch:    cwdMatch,
 		Recency:     recency,
+		Kind:        s.Kind,
+		Steps:       s.Steps,
+	}
+	for _, b := range s.WordBoundaries {
+		out.Boundaries = append(out.Boundaries, int(b))
 	}
 	if includeReasons {
 		out.Reasons = daemonReasonsToExplain(s.Reasons)
@@ -144,6 +144,9 @@ func buildSuggestDoctorReport() suggestDoctorReport {
 	if cfg == nil {
 		cfg = config.DefaultConfig()
 	}
+	if cwd, err := os.Getwd(); err == nil {
+		cfg, _, _, _ = cfg.LoadRepo(paths, cwd)
+	}
 
 	report := suggestDoctorReport{
 		DaemonHealth:    checkDaemonHealth(paths),
@@ -307,6 +310,8 @@ func checkActiveFeatures(cfg *config.Config) activeFeatureInfo {
 		"task_playbook_extended":  s.TaskPlaybookExtendedEnabled,
 		"discovery":               s.DiscoveryEnabled,
 		"redact_sensitive_tokens": s.RedactSensitiveTokens,
+		"capture_output":          s.CaptureOutput,
+		"cold_start_seeding":      s.ColdStartSeeding,
 	}
 	return activeFeatureInfo{Features: features}
 }
@@ -487,10 +492,16 @@ func openSuggestionsDBReadOnly() *sql.DB {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
+	key, err := databaseKey()
+	if err != nil {
+		return nil
+	}
+
 	sdb, err := db.Open(ctx, db.Options{
-		Path:     dbPath,
-		ReadOnly: true,
-		SkipLock: true,
+		Path:          dbPath,
+		ReadOnly:      true,
+		SkipLock:      true,
+		EncryptionKey: key,
 	})
 	if err != nil {
 		return nil
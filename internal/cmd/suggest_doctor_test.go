@@ -96,6 +96,7 @@ func TestBuildSuggestDoctorReport_ActiveFeatures(t *testing.T) {
 		"task_playbook_extended",
 		"discovery",
 		"redact_sensitive_tokens",
+		"capture_output",
 	}
 
 	for _, feat := range expectedFeatures {
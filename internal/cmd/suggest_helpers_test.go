@@ -184,6 +184,24 @@ func TestDaemonSuggestionToOutput(t *testing.T) {
 	}
 }
 
+func TestDaemonSuggestionToOutput_Workflow(t *testing.T) {
+	s := &pb.Suggestion{
+		Text:        "git commit -m wip && git push",
+		Source:      "workflow",
+		Description: "next: git commit -m <msg> → git push",
+		Kind:        "workflow",
+		Steps:       []string{"git commit -m wip", "git push"},
+	}
+
+	out := daemonSuggestionToOutput(s, false)
+	if out.Kind != "workflow" {
+		t.Fatalf("kind = %q, want %q", out.Kind, "workflow")
+	}
+	if len(out.Steps) != 2 || out.Steps[0] != "git commit -m wip" || out.Steps[1] != "git push" {
+		t.Fatalf("steps = %q, want the workflow's commands", out.Steps)
+	}
+}
+
 func TestDeriveSuggestionMeta(t *testing.T) {
 	s := &pb.Suggestion{
 		Source: "global",
@@ -255,3 +273,22 @@ func TestGetSessionTimingMachine(t *testing.T) {
 		}
 	})
 }
+
+func TestFormatGhostMeta_RiskMessage(t *testing.T) {
+	s := daemonSuggestionToOutput(&pb.Suggestion{
+		Text:        "terraform destroy -var env=prod",
+		Source:      "global",
+		Risk:        "destructive",
+		RiskMessage: "destroys production infrastructure",
+	}, false)
+
+	meta := formatGhostMeta(&s)
+	if !strings.HasSuffix(meta, "[!] destructive: destroys production infrastructure") {
+		t.Fatalf("meta = %q, want the risk message after the warning", meta)
+	}
+
+	s.RiskMessage = ""
+	if meta := formatGhostMeta(&s); !strings.HasSuffix(meta, "[!] destructive") {
+		t.Fatalf("meta = %q, want the plain warning", meta)
+	}
+}
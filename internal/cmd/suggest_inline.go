@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/ipc"
+)
+
+var (
+	suggestInlineRevision   int64
+	suggestInlineDebounceMs int
+)
+
+var suggestInlineCmd = &cobra.Command{
+	Use:   "suggest-inline <buffer>",
+	Short: "Print the ghost-text completion of the buffer",
+	Long: `Print the single best completion of the buffer, the whole command, for
+showing the rest of it as ghost text while typing.
+
+Shell widgets that call this on every keystroke pass an increasing
+--revision, so the daemon drops requests a newer keystroke superseded, and
+may ask it to --debounce-ms before ranking. It prints nothing when there is
+no completion, the request was superseded, or the daemon is not running.`,
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE:   runSuggestInline,
+}
+
+func init() {
+	suggestInlineCmd.Flags().Int64Var(&suggestInlineRevision, "revision", 0, "Edit counter of the buffer (0 = untracked)")
+	suggestInlineCmd.Flags().IntVar(&suggestInlineDebounceMs, "debounce-ms", 0, "Wait this long for a newer revision (max 500)")
+}
+
+// inlineSuggester is the part of the daemon client suggest-inline uses.
+type inlineSuggester interface {
+	SuggestInline(ctx context.Context, sessionID, cwd, buffer string, revision int64, debounce time.Duration) *pb.SuggestInlineResponse
+}
+
+func runSuggestInline(cmd *cobra.Command, args []string) error {
+	if integrationDisabled() {
+		return nil
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		// Daemon not available - complete nothing
+		return nil
+	}
+	defer client.Close()
+
+	cwd, _ := os.Getwd()
+	debounce := time.Duration(suggestInlineDebounceMs) * time.Millisecond
+	writeInlineCompletion(cmd.Context(), client, os.Getenv("CLAI_SESSION_ID"), cwd, args[0], suggestInlineRevision, debounce, os.Stdout)
+	return nil
+}
+
+// writeInlineCompletion writes the completion of buffer to out, if there is
+// one that was not superseded.
+func writeInlineCompletion(ctx context.Context, client inlineSuggester, sessionID, cwd, buffer string, revision int64, debounce time.Duration, out io.Writer) {
+	resp := client.SuggestInline(ctx, sessionID, cwd, buffer, revision, debounce)
+	if resp == nil || resp.Superseded || resp.Completion == "" {
+		return
+	}
+	fmt.Fprintln(out, resp.Completion)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+type fakeInlineSuggester struct {
+	resp     *pb.SuggestInlineResponse
+	revision int64
+	debounce time.Duration
+}
+
+func (f *fakeInlineSuggester) SuggestInline(_ context.Context, _, _, _ string, revision int64, debounce time.Duration) *pb.SuggestInlineResponse {
+	f.revision, f.debounce = revision, debounce
+	return f.resp
+}
+
+func TestWriteInlineCompletion(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeInlineSuggester{resp: &pb.SuggestInlineResponse{Completion: "git status", Suffix: "tatus"}}
+	var out bytes.Buffer
+	writeInlineCompletion(context.Background(), client, "session-1", "/tmp", "git s", 7, 30*time.Millisecond, &out)
+
+	if got, want := out.String(), "git status\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if client.revision != 7 || client.debounce != 30*time.Millisecond {
+		t.Errorf("SuggestInline called with revision %d debounce %v", client.revision, client.debounce)
+	}
+}
+
+func TestWriteInlineCompletion_Nothing(t *testing.T) {
+	t.Parallel()
+
+	for name, resp := range map[string]*pb.SuggestInlineResponse{
+		"no daemon":  nil,
+		"none":       {},
+		"superseded": {Completion: "git status", Superseded: true},
+	} {
+		var out bytes.Buffer
+		writeInlineCompletion(context.Background(), &fakeInlineSuggester{resp: resp}, "", "", "git s", 1, 0, &out)
+		if out.Len() != 0 {
+			t.Errorf("%s: output = %q, want nothing", name, out.String())
+		}
+	}
+}
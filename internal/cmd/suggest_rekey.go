@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/daemon"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/normalize"
+	"github.com/runger/clai/internal/suggestions/rekey"
+)
+
+var suggestRekeyDryRun bool
+
+var suggestRekeyCmd = &cobra.Command{
+	Use:    "suggest-rekey",
+	Short:  "Re-key learned suggestion data to the current template IDs",
+	Hidden: true,
+	Long: `Re-normalize every stored command template and move its stats,
+transitions, pipelines, workflows and history to the template's current ID.
+
+The daemon does this automatically on startup when the template ID
+version changes. Run it by hand after changing normalization without a
+version bump, or with --dry-run to see how many templates would move.
+
+The daemon must be stopped first (clai daemon stop).
+
+Examples:
+  clai suggest-rekey --dry-run
+  clai suggest-rekey`,
+	RunE: runSuggestRekey,
+}
+
+func init() {
+	suggestRekeyCmd.Flags().BoolVar(&suggestRekeyDryRun, "dry-run", false, "Report what would change without writing")
+}
+
+func runSuggestRekey(cmd *cobra.Command, _ []string) error {
+	if !suggestRekeyDryRun && daemon.IsRunning() {
+		return errors.New("the daemon is running; stop it first with 'clai daemon stop'")
+	}
+
+	dbPath, err := suggestdb.DefaultDBPath()
+	if err != nil {
+		return err
+	}
+	if _, statErr := os.Stat(dbPath); os.IsNotExist(statErr) {
+		return errors.New("no suggestions database yet; nothing to re-key")
+	}
+
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+
+	sdb, err := suggestdb.Open(cmd.Context(), suggestdb.Options{
+		Path:          dbPath,
+		ReadOnly:      suggestRekeyDryRun,
+		SkipLock:      suggestRekeyDryRun,
+		EncryptionKey: key,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open suggestions database: %w", err)
+	}
+	defer sdb.Close()
+
+	result, err := rekey.Run(cmd.Context(), sdb.DB(), rekey.Options{DryRun: suggestRekeyDryRun})
+	if err != nil {
+		return err
+	}
+
+	verb := "Re-keyed"
+	if suggestRekeyDryRun {
+		verb = "Would re-key"
+	}
+	fmt.Printf("%s %s%d%s of %d templates to ID version %d (%d merged)\n",
+		verb, colorGreen, result.Remapped, colorReset, result.Templates, normalize.TemplateIDVersion, result.Merged)
+	if summary := result.Summary(); summary != "" {
+		fmt.Printf("  %s%s%s\n", colorDim, summary, colorReset)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/ipc"
+)
+
+var suggestSlotsLimit int
+
+var suggestSlotsCmd = &cobra.Command{
+	Use:   "suggest-slots <buffer>",
+	Short: "Complete the argument being typed from its past values",
+	Long: `Print the values the argument at the end of the buffer took in past
+commands of the same shape, one per line, most used first. For example,
+after "kubectl get pods -n " it prints the namespaces used there before.
+
+Shell completion hooks call this; it prints nothing when the word being
+typed is a command, a subcommand or a flag, or when the daemon is not
+running.`,
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE:   runSuggestSlots,
+}
+
+func init() {
+	suggestSlotsCmd.Flags().IntVar(&suggestSlotsLimit, "limit", 10, "Maximum number of values")
+}
+
+// slotSuggester is the part of the daemon client suggest-slots uses.
+type slotSuggester interface {
+	SuggestSlots(ctx context.Context, sessionID, cwd, buffer string, maxResults int) []*pb.SlotSuggestion
+}
+
+func runSuggestSlots(cmd *cobra.Command, args []string) error {
+	if integrationDisabled() {
+		return nil
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		// Daemon not available - complete nothing
+		return nil
+	}
+	defer client.Close()
+
+	cwd, _ := os.Getwd()
+	writeSlotValues(cmd.Context(), client, os.Getenv("CLAI_SESSION_ID"), cwd, args[0], suggestSlotsLimit, os.Stdout)
+	return nil
+}
+
+// writeSlotValues writes the completions for buffer to out, one per line.
+func writeSlotValues(ctx context.Context, client slotSuggester, sessionID, cwd, buffer string, limit int, out io.Writer) {
+	for _, v := range client.SuggestSlots(ctx, sessionID, cwd, buffer, limit) {
+		fmt.Fprintln(out, v.Value)
+	}
+}
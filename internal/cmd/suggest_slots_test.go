@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+type fakeSlotSuggester struct {
+	values []*pb.SlotSuggestion
+	buffer string
+	limit  int
+}
+
+func (f *fakeSlotSuggester) SuggestSlots(_ context.Context, _, _, buffer string, maxResults int) []*pb.SlotSuggestion {
+	f.buffer, f.limit = buffer, maxResults
+	return f.values
+}
+
+func TestWriteSlotValues(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeSlotSuggester{values: []*pb.SlotSuggestion{
+		{Value: "prod", Score: 3},
+		{Value: "staging", Score: 1},
+	}}
+	var out bytes.Buffer
+	writeSlotValues(context.Background(), client, "session-1", "/tmp", "kubectl get pods -n ", 5, &out)
+
+	if got, want := out.String(), "prod\nstaging\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if client.buffer != "kubectl get pods -n " || client.limit != 5 {
+		t.Errorf("SuggestSlots called with buffer %q limit %d", client.buffer, client.limit)
+	}
+}
+
+func TestWriteSlotValues_None(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	writeSlotValues(context.Background(), &fakeSlotSuggester{}, "", "", "kubectl ", 10, &out)
+	if out.Len() != 0 {
+		t.Errorf("output = %q, want nothing", out.String())
+	}
+}
@@ -159,10 +159,10 @@ func TestOutputSuggestions_GhostFormat(t *testing.T) {
 	if len(lines) != 2 {
 		t.Fatalf("expected 2 lines, got %d: %q", len(lines), out)
 	}
-	if lines[0] != "make install\t· global  · score 0.47" {
+	if lines[0] != "make install\t· global  · score 0.47\t5,12" {
 		t.Fatalf("unexpected ghost line 1: %q", lines[0])
 	}
-	if lines[1] != "rm -rf /\t· global  · score 0.99  · [!] destructive" {
+	if lines[1] != "rm -rf /\t· global  · score 0.99  · [!] destructive\t3,7,8" {
 		t.Fatalf("unexpected ghost line 2: %q", lines[1])
 	}
 }
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/ipc"
+)
+
+var (
+	dismissalsScope  string
+	dismissalsAll    bool
+	dismissalsFormat string
+	dismissalsClear  bool
+
+	compareScorersFormat string
+	compareScorersReset  bool
+)
+
+var suggestionsCmd = &cobra.Command{
+	Use:     "suggestions",
+	Short:   "Manage what suggestions have learned",
+	GroupID: groupSetup,
+}
+
+var dismissalsCmd = &cobra.Command{
+	Use:   "dismissals",
+	Short: "List and clear suggestions suppressed by dismissal learning",
+	Long: `List and clear the suggestions clai has learned to suppress.
+
+Dismissing a suggestion after a command lowers its score the next time that
+command runs; after three dismissals it is suppressed there for good, and
+choosing "never" suppresses it immediately. Accepting a suggestion again
+resets what was learned about it.
+
+Examples:
+  clai suggestions dismissals list
+  clai suggestions dismissals list --all --format json
+  clai suggestions dismissals clear 'git push --force'
+  clai suggestions dismissals clear --all`,
+}
+
+var dismissalsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List suppressed suggestions",
+	Args:  cobra.NoArgs,
+	RunE:  runDismissalsList,
+}
+
+var dismissalsClearCmd = &cobra.Command{
+	Use:   "clear [command|template-id]",
+	Short: "Suggest a suppressed command again",
+	Long: `Clear what clai learned from dismissing a suggestion, so it is suggested
+again after every command. Name the suggestion by its command as shown by
+'clai suggestions dismissals list' or by its template ID, or pass --all to
+clear every dismissal.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDismissalsClear,
+}
+
+var compareScorersCmd = &cobra.Command{
+	Use:   "compare-scorers",
+	Short: "Compare the V1 and V2 scorers on the commands you run",
+	Long: `Report how often the V1 ranker and the V2 scorer would have suggested the
+command you ran next, to guide the choice of suggestions.scorer_version.
+
+With suggestions.shadow_scoring on, the daemon ranks every suggestion
+request with both scorers, whichever one serves suggestions, and checks
+each command you run against both rankings. Hits counts commands that were
+among a scorer's suggestions, top-1 those that were its first suggestion,
+MRR is the mean reciprocal rank over all compared commands, and only counts
+hits the other scorer missed.
+
+Examples:
+  clai suggestions compare-scorers
+  clai suggestions compare-scorers --format json
+  clai suggestions compare-scorers --reset`,
+	Args: cobra.NoArgs,
+	RunE: runCompareScorers,
+}
+
+func init() {
+	dismissalsListCmd.Flags().StringVar(&dismissalsScope, "scope", "", "Only list this scope (default: every scope)")
+	dismissalsListCmd.Flags().BoolVar(&dismissalsAll, "all", false, "Also list temporary dismissals, which only lower scores")
+	dismissalsListCmd.Flags().StringVar(&dismissalsFormat, "format", "text", "Output format: text or json")
+	addJSONFlag(dismissalsListCmd, &dismissalsFormat)
+	dismissalsClearCmd.Flags().StringVar(&dismissalsScope, "scope", "", "Only clear this scope (default: every scope)")
+	dismissalsClearCmd.Flags().BoolVar(&dismissalsClear, "all", false, "Clear every dismissal")
+	dismissalsCmd.AddCommand(dismissalsListCmd, dismissalsClearCmd)
+	compareScorersCmd.Flags().StringVar(&compareScorersFormat, "format", "text", "Output format: text or json")
+	addJSONFlag(compareScorersCmd, &compareScorersFormat)
+	compareScorersCmd.Flags().BoolVar(&compareScorersReset, "reset", false, "Restart counting after the report")
+	suggestionsCmd.AddCommand(dismissalsCmd, compareScorersCmd)
+}
+
+// dismissalManager is the part of the daemon client the dismissals
+// commands use.
+type dismissalManager interface {
+	ListDismissals(ctx context.Context, scope string, includeTemporary bool) (*pb.ListDismissalsResponse, error)
+	ClearDismissals(ctx context.Context, scope, dismissedTemplateID string) (*pb.ClearDismissalsResponse, error)
+}
+
+func runDismissalsList(cmd *cobra.Command, _ []string) error {
+	format := strings.ToLower(strings.TrimSpace(dismissalsFormat))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format: %s (use text or json)", dismissalsFormat)
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	resp, err := client.ListDismissals(cmd.Context(), dismissalsScope, dismissalsAll)
+	if err != nil {
+		return fmt.Errorf("failed to list dismissals: %w", err)
+	}
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(resp.Dismissals)
+	}
+	writeDismissalsText(os.Stdout, resp.Dismissals)
+	return nil
+}
+
+func runDismissalsClear(cmd *cobra.Command, args []string) error {
+	if dismissalsClear == (len(args) == 1) {
+		return errors.New("name one suggestion to clear, or pass --all")
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	target := ""
+	if len(args) == 1 {
+		target = args[0]
+	}
+	return clearDismissals(cmd.Context(), client, dismissalsScope, target, os.Stdout)
+}
+
+// clearDismissals clears the dismissals of target, a dismissed command or
+// template ID, in scope. An empty target clears every dismissal.
+func clearDismissals(ctx context.Context, client dismissalManager, scope, target string, out io.Writer) error {
+	templateIDs := []string{""}
+	if target != "" {
+		list, err := client.ListDismissals(ctx, scope, true)
+		if err != nil {
+			return fmt.Errorf("failed to list dismissals: %w", err)
+		}
+		templateIDs = matchDismissals(list.Dismissals, target)
+		if len(templateIDs) == 0 {
+			fmt.Fprintf(out, "No dismissals match %q.\n", target)
+			return nil
+		}
+	}
+
+	var cleared int32
+	for _, id := range templateIDs {
+		resp, err := client.ClearDismissals(ctx, scope, id)
+		if err != nil {
+			return fmt.Errorf("failed to clear dismissals: %w", err)
+		}
+		cleared += resp.Cleared
+	}
+	fmt.Fprintf(out, "Cleared %d dismissals.\n", cleared)
+	return nil
+}
+
+// matchDismissals returns the distinct template IDs of the dismissals whose
+// dismissed command or template ID is target.
+func matchDismissals(dismissals []*pb.Dismissal, target string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, d := range dismissals {
+		if d.DismissedTemplateId != target && d.DismissedCommand != target {
+			continue
+		}
+		if !seen[d.DismissedTemplateId] {
+			seen[d.DismissedTemplateId] = true
+			ids = append(ids, d.DismissedTemplateId)
+		}
+	}
+	return ids
+}
+
+func writeDismissalsText(w io.Writer, dismissals []*pb.Dismissal) {
+	if len(dismissals) == 0 {
+		fmt.Fprintln(w, "No suggestions are suppressed.")
+		return
+	}
+
+	fmt.Fprintf(w, "%-10s %5s  %-16s %-10s %s\n", "Level", "Count", "Last dismissed", "Scope", "Suggestion (after)")
+	for _, d := range dismissals {
+		last := time.UnixMilli(d.LastDismissedMs).Format("2006-01-02 15:04")
+		fmt.Fprintf(w, "%-10s %5d  %-16s %-10s %s (after %s)\n",
+			d.Level, d.DismissalCount, last, d.Scope,
+			orTemplateID(d.DismissedCommand, d.DismissedTemplateId),
+			orTemplateID(d.ContextCommand, d.ContextTemplateId))
+	}
+}
+
+// orTemplateID returns cmd, or the template ID when the command is unknown.
+func orTemplateID(cmd, templateID string) string {
+	if cmd != "" {
+		return cmd
+	}
+	return templateID
+}
+
+func runCompareScorers(cmd *cobra.Command, _ []string) error {
+	format := strings.ToLower(strings.TrimSpace(compareScorersFormat))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format: %s (use text or json)", compareScorersFormat)
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	resp, err := client.CompareScorers(cmd.Context(), compareScorersReset)
+	if err != nil {
+		return fmt.Errorf("failed to compare scorers: %w", err)
+	}
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(resp)
+	}
+	writeCompareScorersText(os.Stdout, resp)
+	return nil
+}
+
+func writeCompareScorersText(w io.Writer, resp *pb.CompareScorersResponse) {
+	state := "off"
+	if resp.Enabled {
+		state = "on"
+	}
+	fmt.Fprintf(w, "Shadow scoring: %s (serving: %s)\n", state, resp.ScorerVersion)
+	if resp.Commands == 0 {
+		fmt.Fprintln(w, "No commands compared yet.")
+		if !resp.Enabled {
+			fmt.Fprintln(w, "Set suggestions.shadow_scoring: true in the config file to start comparing.")
+		}
+		return
+	}
+
+	since := time.UnixMilli(resp.SinceUnixMs).Format("2006-01-02 15:04")
+	fmt.Fprintf(w, "Compared %d commands since %s\n\n", resp.Commands, since)
+	fmt.Fprintf(w, "%-7s %13s %13s %6s %6s\n", "Scorer", "Hits", "Top-1", "MRR", "Only")
+	for _, row := range []struct {
+		name string
+		hits *pb.ScorerHits
+	}{{"v1", resp.V1}, {"v2", resp.V2}} {
+		fmt.Fprintf(w, "%-7s %13s %13s %6.2f %6d\n", row.name,
+			countShare(row.hits.GetHits(), resp.Commands),
+			countShare(row.hits.GetTop1(), resp.Commands),
+			row.hits.GetReciprocalRankSum()/float64(resp.Commands),
+			row.hits.GetExclusive())
+	}
+
+	v1, v2 := resp.V1.GetHits(), resp.V2.GetHits()
+	fmt.Fprintln(w)
+	switch {
+	case v2 > v1:
+		fmt.Fprintf(w, "v2 suggested the next command more often (%d vs %d).\n", v2, v1)
+	case v1 > v2:
+		fmt.Fprintf(w, "v1 suggested the next command more often (%d vs %d).\n", v1, v2)
+	default:
+		fmt.Fprintln(w, "Both scorers suggested the next command equally often.")
+	}
+}
+
+// countShare formats n as a count and its share of total, e.g. "54 (45%)".
+func countShare(n, total int64) string {
+	return fmt.Sprintf("%d (%d%%)", n, n*100/total)
+}
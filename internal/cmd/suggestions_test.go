@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+type fakeDismissalManager struct {
+	dismissals []*pb.Dismissal
+	cleared    []string // template IDs passed to ClearDismissals
+}
+
+func (f *fakeDismissalManager) ListDismissals(_ context.Context, _ string, _ bool) (*pb.ListDismissalsResponse, error) {
+	return &pb.ListDismissalsResponse{Dismissals: f.dismissals}, nil
+}
+
+func (f *fakeDismissalManager) ClearDismissals(_ context.Context, _ string, id string) (*pb.ClearDismissalsResponse, error) {
+	f.cleared = append(f.cleared, id)
+	return &pb.ClearDismissalsResponse{Cleared: 2}, nil
+}
+
+func TestClearDismissals(t *testing.T) {
+	t.Parallel()
+
+	dismissals := []*pb.Dismissal{
+		{DismissedTemplateId: "tpl-push", DismissedCommand: "git push --force", ContextTemplateId: "tpl-a"},
+		{DismissedTemplateId: "tpl-push", DismissedCommand: "git push --force", ContextTemplateId: "tpl-b"},
+		{DismissedTemplateId: "tpl-rm", DismissedCommand: "rm -rf <path>"},
+	}
+	tests := []struct {
+		name        string
+		target      string
+		wantCleared []string
+		wantOut     string
+	}{
+		{"by command", "git push --force", []string{"tpl-push"}, "Cleared 2 dismissals."},
+		{"by template ID", "tpl-rm", []string{"tpl-rm"}, "Cleared 2 dismissals."},
+		{"all", "", []string{""}, "Cleared 2 dismissals."},
+		{"no match", "ls", nil, `No dismissals match "ls".`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			client := &fakeDismissalManager{dismissals: dismissals}
+			var out bytes.Buffer
+			if err := clearDismissals(context.Background(), client, "", tt.target, &out); err != nil {
+				t.Fatalf("clearDismissals() error = %v", err)
+			}
+			if strings.Join(client.cleared, ",") != strings.Join(tt.wantCleared, ",") {
+				t.Errorf("cleared = %q, want %q", client.cleared, tt.wantCleared)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("output %q does not contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestWriteDismissalsText(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	writeDismissalsText(&buf, nil)
+	if !strings.Contains(buf.String(), "No suggestions are suppressed.") {
+		t.Errorf("missing empty notice:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	writeDismissalsText(&buf, []*pb.Dismissal{{
+		Scope:               "global",
+		ContextTemplateId:   "tpl-status",
+		DismissedTemplateId: "tpl-push",
+		ContextCommand:      "git status",
+		Level:               "learned",
+		DismissalCount:      3,
+		LastDismissedMs:     1000,
+	}})
+	out := buf.String()
+	for _, want := range []string{"learned", "global", "tpl-push (after git status)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteCompareScorersText(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	writeCompareScorersText(&buf, &pb.CompareScorersResponse{ScorerVersion: "v1"})
+	for _, want := range []string{"Shadow scoring: off (serving: v1)", "No commands compared yet.", "suggestions.shadow_scoring: true"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	writeCompareScorersText(&buf, &pb.CompareScorersResponse{
+		Enabled:       true,
+		ScorerVersion: "v2",
+		SinceUnixMs:   1000,
+		Commands:      20,
+		V1:            &pb.ScorerHits{Hits: 8, Top1: 4, Exclusive: 1, ReciprocalRankSum: 5},
+		V2:            &pb.ScorerHits{Hits: 12, Top1: 9, Exclusive: 5, ReciprocalRankSum: 10},
+	})
+	out := buf.String()
+	for _, want := range []string{"Compared 20 commands", "8 (40%)", "12 (60%)", "0.50", "v2 suggested the next command more often (12 vs 8)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/runger/clai/internal/ipc"
+)
+
+var tmuxCmd = &cobra.Command{
+	Use:     "tmux",
+	Short:   "tmux integration helpers",
+	GroupID: groupSetup,
+	Long: `Helpers for running clai inside tmux.
+
+Inside tmux, 'clai init' derives the session ID from the tmux server and
+pane, so every pane is its own clai session even though panes inherit
+the environment of the shell that started tmux. The ID stays the same
+when the shell in a pane is restarted or re-sources its config.
+
+Add the status-line widget to ~/.tmux.conf:
+  clai tmux conf >> ~/.tmux.conf
+
+Examples:
+  clai tmux session-id
+  clai tmux status
+  clai tmux conf`,
+}
+
+var tmuxSessionIDCmd = &cobra.Command{
+	Use:   "session-id",
+	Short: "Print the clai session ID of the current tmux pane",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		sessionID := tmuxPaneSessionID(os.Getenv("TMUX"), os.Getenv("TMUX_PANE"))
+		if sessionID == "" {
+			return errors.New("not inside tmux (TMUX and TMUX_PANE are not set)")
+		}
+		fmt.Println(sessionID)
+		return nil
+	},
+}
+
+var tmuxStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print the tmux status-line widget",
+	Long: `Print one line for the tmux status line: whether clai is on and the
+daemon is running, followed by the feedback on the pane's last suggestion.
+
+  clai ok (accepted)   daemon running, last suggestion accepted
+  clai ok              daemon running, no suggestion feedback yet
+  clai down            daemon not running
+  clai off             integrations disabled
+
+tmux runs status-line commands outside the pane, so pass the server and
+pane with --tmux and --pane; 'clai tmux conf' does.`,
+	Args: cobra.NoArgs,
+	RunE: runTmuxStatus,
+}
+
+var tmuxConfCmd = &cobra.Command{
+	Use:   "conf",
+	Short: "Print tmux.conf lines for the status-line widget",
+	Args:  cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		fmt.Print(tmuxConfSnippet)
+	},
+}
+
+var (
+	tmuxStatusServer string
+	tmuxStatusPane   string
+)
+
+// tmuxConfSnippet adds the widget to the right of the status line. tmux
+// expands the formats before running the command, so the widget follows
+// the active pane.
+const tmuxConfSnippet = `# clai: daemon health and last suggestion feedback of the active pane
+set -g status-interval 15
+set -ga status-right ' #(clai tmux status --tmux "#{socket_path},#{pid}" --pane "#{pane_id}")'
+`
+
+// tmuxStatusTimeout bounds the widget; tmux blocks the status line on it.
+const tmuxStatusTimeout = time.Second
+
+func init() {
+	tmuxStatusCmd.Flags().StringVar(&tmuxStatusServer, "tmux", "", `tmux server as "socket_path,pid" (default: $TMUX)`)
+	tmuxStatusCmd.Flags().StringVar(&tmuxStatusPane, "pane", "", "tmux pane ID (default: $TMUX_PANE)")
+
+	tmuxCmd.AddCommand(tmuxSessionIDCmd)
+	tmuxCmd.AddCommand(tmuxStatusCmd)
+	tmuxCmd.AddCommand(tmuxConfCmd)
+}
+
+// tmuxPaneSessionID derives a stable session ID for a tmux pane. tmuxEnv
+// is $TMUX ("socket_path,server_pid,session"); only the server part is
+// used, so the ID survives moving the pane to another session. Pane IDs
+// are reused by a new server, which has another pid. It returns "" outside
+// tmux.
+func tmuxPaneSessionID(tmuxEnv, pane string) string {
+	socket, rest, _ := strings.Cut(tmuxEnv, ",")
+	pid, _, _ := strings.Cut(rest, ",")
+	if socket == "" || pane == "" {
+		return ""
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("tmux://"+socket+"/"+pid+"/"+pane)).String()
+}
+
+func runTmuxStatus(_ *cobra.Command, _ []string) error {
+	if integrationDisabled() {
+		fmt.Println(renderTmuxStatus("off", ""))
+		return nil
+	}
+	conn, err := ipc.QuickDial()
+	if err != nil {
+		fmt.Println(renderTmuxStatus("down", ""))
+		return nil
+	}
+	conn.Close()
+
+	server := tmuxStatusServer
+	if server == "" {
+		server = os.Getenv("TMUX")
+	}
+	pane := tmuxStatusPane
+	if pane == "" {
+		pane = os.Getenv("TMUX_PANE")
+	}
+
+	var action string
+	if sessionID := tmuxPaneSessionID(server, pane); sessionID != "" {
+		if sdb := openSuggestionsDBReadOnly(); sdb != nil {
+			defer sdb.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), tmuxStatusTimeout)
+			defer cancel()
+			action = lastFeedbackAction(ctx, sdb, sessionID)
+		}
+	}
+	fmt.Println(renderTmuxStatus("ok", action))
+	return nil
+}
+
+// lastFeedbackAction returns the feedback action recorded last in the
+// session, or "" when there is none.
+func lastFeedbackAction(ctx context.Context, sdb *sql.DB, sessionID string) string {
+	var action string
+	err := sdb.QueryRowContext(ctx, `
+		SELECT action FROM suggestion_feedback
+		WHERE session_id = ?
+		ORDER BY ts_ms DESC, id DESC
+		LIMIT 1
+	`, sessionID).Scan(&action)
+	if err != nil {
+		return ""
+	}
+	return action
+}
+
+// renderTmuxStatus formats the widget. It is plain text: tmux applies
+// the status line's own style.
+func renderTmuxStatus(state, lastAction string) string {
+	if lastAction == "" {
+		return "clai " + state
+	}
+	return fmt.Sprintf("clai %s (%s)", state, lastAction)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/runger/clai/internal/suggestions/db"
+)
+
+func TestTmuxPaneSessionID(t *testing.T) {
+	const server = "/tmp/tmux-1000/default,4242,0"
+
+	id := tmuxPaneSessionID(server, "%1")
+	if id == "" {
+		t.Fatal("expected a session ID inside tmux")
+	}
+	if got := tmuxPaneSessionID(server, "%1"); got != id {
+		t.Errorf("session ID not stable: %s != %s", got, id)
+	}
+	if got := tmuxPaneSessionID("/tmp/tmux-1000/default,4242,5", "%1"); got != id {
+		t.Errorf("session ID should not depend on the tmux session, got %s", got)
+	}
+	if tmuxPaneSessionID(server, "%2") == id {
+		t.Error("panes should get different session IDs")
+	}
+	if tmuxPaneSessionID("/tmp/tmux-1000/default,9999,0", "%1") == id {
+		t.Error("a new tmux server should give new session IDs")
+	}
+	if got := tmuxPaneSessionID("", "%1"); got != "" {
+		t.Errorf("expected no session ID outside tmux, got %s", got)
+	}
+	if got := tmuxPaneSessionID(server, ""); got != "" {
+		t.Errorf("expected no session ID without a pane, got %s", got)
+	}
+}
+
+func TestRenderTmuxStatus(t *testing.T) {
+	tests := []struct {
+		state, action, want string
+	}{
+		{"ok", "accepted", "clai ok (accepted)"},
+		{"ok", "", "clai ok"},
+		{"down", "", "clai down"},
+		{"off", "", "clai off"},
+	}
+	for _, tt := range tests {
+		if got := renderTmuxStatus(tt.state, tt.action); got != tt.want {
+			t.Errorf("renderTmuxStatus(%q, %q) = %q, want %q", tt.state, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestLastFeedbackAction(t *testing.T) {
+	ctx := context.Background()
+	sdb, err := db.Open(ctx, db.Options{Path: filepath.Join(t.TempDir(), "suggestions.db"), SkipLock: true})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer sdb.Close()
+
+	if got := lastFeedbackAction(ctx, sdb.DB(), "pane"); got != "" {
+		t.Errorf("expected no action without feedback, got %q", got)
+	}
+
+	for _, row := range []struct {
+		session, action string
+		ts              int64
+	}{
+		{"pane", "dismissed", 1000},
+		{"pane", "accepted", 2000},
+		{"other", "dismissed", 3000},
+	} {
+		_, err := sdb.DB().ExecContext(ctx,
+			`INSERT INTO suggestion_feedback (session_id, ts_ms, suggested_text, action) VALUES (?, ?, 'ls', ?)`,
+			row.session, row.ts, row.action)
+		if err != nil {
+			t.Fatalf("insert feedback: %v", err)
+		}
+	}
+	if got := lastFeedbackAction(ctx, sdb.DB(), "pane"); got != "accepted" {
+		t.Errorf("lastFeedbackAction = %q, want accepted", got)
+	}
+}
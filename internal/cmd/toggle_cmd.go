@@ -50,12 +50,15 @@ func toggleIntegration(enable, sessionOnly bool) error {
 		return nil
 	}
 
-	cfg, err := config.Load()
+	// Edit the file as written, so the profile preset, weights preset and
+	// env overrides are not saved into it.
+	paths := config.DefaultPaths()
+	cfg, err := config.ReadFile(paths.ConfigFile())
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
 	cfg.Suggestions.Enabled = enable
-	if err := cfg.Save(); err != nil {
+	if err := saveConfig(cfg, paths); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 	// Also clear/set session flag so all disable sources are consistent.
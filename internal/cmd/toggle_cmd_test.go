@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/runger/clai/internal/cache"
@@ -52,3 +54,59 @@ func TestToggleIntegration_PersistentConfig(t *testing.T) {
 		t.Fatal("expected suggestions.enabled to be true")
 	}
 }
+
+func TestToggleIntegration_KeepsOverridesOutOfFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+	t.Setenv("CLAI_SUGGESTIONS_MAX_RESULTS", "9")
+
+	if err := toggleIntegration(false, false); err != nil {
+		t.Fatalf("toggleIntegration disable error: %v", err)
+	}
+	cfg, err := config.ReadFile(config.DefaultPaths().ConfigFile())
+	if err != nil {
+		t.Fatalf("ReadFile error: %v", err)
+	}
+	if cfg.Suggestions.Enabled {
+		t.Error("expected suggestions.enabled to be false in the file")
+	}
+	if want := config.DefaultConfig().Suggestions.MaxResults; cfg.Suggestions.MaxResults != want {
+		t.Errorf("file max_results = %d, want the default %d, not the env override", cfg.Suggestions.MaxResults, want)
+	}
+}
+
+func TestToggleIntegration_WeightsPresetStaysSwitchable(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+	t.Setenv(config.ProfileEnv, "")
+	paths := config.DefaultPaths()
+	if err := os.MkdirAll(filepath.Dir(paths.ConfigFile()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(paths.ConfigFile(), []byte("suggestions:\n  weights_preset: prefix-heavy\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := toggleIntegration(false, false); err != nil {
+		t.Fatalf("toggleIntegration disable error: %v", err)
+	}
+
+	// Switching the preset after the toggle still changes the weights.
+	cfg, err := config.ReadFile(paths.ConfigFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Set("suggestions.weights_preset", "conservative"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SaveToFile(paths.ConfigFile()); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load config error: %v", err)
+	}
+	if want, _ := config.WeightsPreset("conservative"); loaded.Suggestions.Weights != want {
+		t.Errorf("weights = %+v, want the conservative preset %+v", loaded.Suggestions.Weights, want)
+	}
+}
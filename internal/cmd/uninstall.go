@@ -3,29 +3,58 @@ package cmd
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/runger/clai/internal/cache"
 	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/daemon"
+)
+
+var (
+	uninstallPurge  bool
+	uninstallYes    bool
+	uninstallDryRun bool
 )
 
 var uninstallCmd = &cobra.Command{
 	Use:     "uninstall",
-	Short:   "Remove shell integration",
+	Short:   "Remove shell integration, services, and optionally all data",
 	GroupID: groupSetup,
-	Long: `Remove clai shell integration from your shell configuration file.
+	Long: `Remove everything clai installed: the inverse of 'clai install'.
+
+This command removes the source line from your shell's rc file (.zshrc,
+.bashrc, etc.), the hook files, any claid service units, and stops the
+daemon and removes its socket and PID file. Every removed path is printed.
+
+With --purge, the data directories (history databases, config, logs and
+cache) are deleted as well, after an explicit confirmation. A config, data
+or cache directory moved elsewhere (paths.data_dir, CLAI_CACHE, ...) is
+only cleared of the files clai created there, and removed once empty.
 
-This command removes the source line from your shell's rc file (.zshrc, .bashrc, etc.)
-and optionally removes the hook files.
+With --dry-run, nothing is changed: every path that would be removed, and
+whether the daemon would be stopped, is printed instead.
 
 Examples:
-  clai uninstall`,
+  clai uninstall --dry-run
+  clai uninstall --purge --dry-run
+  clai uninstall
+  clai uninstall --purge
+  clai uninstall --purge --yes   # skip the confirmation prompt`,
 	RunE: runUninstall,
 }
 
+func init() {
+	uninstallCmd.Flags().BoolVar(&uninstallPurge, "purge", false, "Also delete all clai data directories")
+	uninstallCmd.Flags().BoolVarP(&uninstallYes, "yes", "y", false, "Do not ask for confirmation before purging data")
+	uninstallCmd.Flags().BoolVarP(&uninstallDryRun, "dry-run", "n", false, "Only list what would be removed")
+}
+
 func runUninstall(cmd *cobra.Command, args []string) error {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -34,16 +63,72 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 
 	paths := config.DefaultPaths()
 
-	// RC files to check
+	// Ask before touching anything so a declined purge leaves a clean state.
+	purge, dryRun := uninstallPurge, uninstallDryRun
+	dataDirs := existingDataDirs(paths)
+	if purge && len(dataDirs) > 0 && !uninstallYes && !dryRun {
+		purge = confirmPurge(os.Stdin, purgeLabels(dataDirs))
+		if !purge {
+			fmt.Println("Keeping data directories.")
+		}
+	}
+
+	removed := removeShellIntegration(home, paths, dryRun)
+	removed += removeHookFiles(home, paths, dryRun)
+	removed += removeServiceUnits(dryRun)
+	removed += stopDaemonAndRemoveRuntimeFiles(paths, dryRun)
+	if purge {
+		removed += removeDataDirs(dataDirs, dryRun)
+	}
+
+	if removed == 0 {
+		fmt.Println("No clai installation found.")
+		return nil
+	}
+	if dryRun {
+		fmt.Printf("\n%sDry run: nothing was removed.%s\n", colorYellow, colorReset)
+		if !purge {
+			fmt.Printf("%sData in %s would be kept (add --purge to remove it).%s\n", colorDim, paths.BaseDir, colorReset)
+		}
+		return nil
+	}
+
+	fmt.Printf("\n%sUninstalled successfully!%s\n", colorGreen, colorReset)
+	fmt.Println("Please restart your terminal or source your rc file.")
+	if !purge {
+		fmt.Printf("%sData kept in %s (remove with 'clai uninstall --purge').%s\n", colorDim, paths.BaseDir, colorReset)
+	}
+
+	return nil
+}
+
+// removeShellIntegration strips clai lines from shell rc files.
+// Returns the number of files changed, or that would be with dryRun.
+func removeShellIntegration(home string, paths *config.Paths, dryRun bool) int {
 	rcFiles := []string{
 		filepath.Join(home, ".zshrc"),
 		filepath.Join(home, ".bashrc"),
 		filepath.Join(home, ".bash_profile"),
 		filepath.Join(home, ".config", "fish", "config.fish"),
+		// $PROFILE of PowerShell 7+ on Unix and on Windows
+		filepath.Join(home, ".config", "powershell", "Microsoft.PowerShell_profile.ps1"),
+		filepath.Join(home, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1"),
+		// Nushell on Linux and on macOS
+		filepath.Join(home, ".config", "nushell", "env.nu"),
+		filepath.Join(home, ".config", "nushell", "config.nu"),
+		filepath.Join(home, "Library", "Application Support", "nushell", "env.nu"),
+		filepath.Join(home, "Library", "Application Support", "nushell", "config.nu"),
 	}
 
-	removed := false
+	removed := 0
 	for _, rcFile := range rcFiles {
+		if dryRun {
+			if found, err := rcFileHasClai(rcFile, paths.HooksDir()); err == nil && found {
+				fmt.Printf("Would remove clai from: %s\n", rcFile)
+				removed++
+			}
+			continue
+		}
 		wasRemoved, err := removeFromRCFile(rcFile, paths.HooksDir())
 		if err != nil {
 			fmt.Printf("Warning: failed to process %s: %v\n", rcFile, err)
@@ -51,36 +136,230 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 		}
 		if wasRemoved {
 			fmt.Printf("Removed clai from: %s\n", rcFile)
-			removed = true
+			removed++
 		}
 	}
+	return removed
+}
 
-	// Remove hook files
+// removeHookFiles deletes the generated shell hook scripts, including the
+// one Nushell's env.nu saves as shown in 'clai init --help'.
+func removeHookFiles(home string, paths *config.Paths, dryRun bool) int {
 	hookFiles := []string{
 		filepath.Join(paths.HooksDir(), "clai.zsh"),
 		filepath.Join(paths.HooksDir(), "clai.bash"),
 		filepath.Join(paths.HooksDir(), "clai.fish"),
+		filepath.Join(home, ".cache", "clai", "init.nu"),
+	}
+	return removeFiles("hook file", hookFiles, dryRun)
+}
+
+// removeServiceUnits disables and deletes service manager units for claid.
+func removeServiceUnits(dryRun bool) int {
+	removed := removeLaunchAgent(dryRun)
+	if unitDir, err := systemdUserUnitDir(); err == nil {
+		removed += removeSystemdUnits(unitDir, dryRun)
+	}
+	return removed
+}
+
+// stopDaemonAndRemoveRuntimeFiles stops a running daemon and removes its
+// socket and PID file if they were left behind.
+func stopDaemonAndRemoveRuntimeFiles(paths *config.Paths, dryRun bool) int {
+	removed := 0
+	if daemon.IsRunning() {
+		if dryRun {
+			fmt.Println("Would stop daemon")
+			removed++
+		} else if err := daemon.StopWithPaths(paths); err != nil {
+			fmt.Printf("Warning: failed to stop daemon: %v\n", err)
+		} else {
+			fmt.Println("Stopped daemon")
+			removed++
+		}
+	}
+	return removed + removeFiles("runtime file", []string{paths.SocketFile(), paths.PIDFile()}, dryRun)
+}
+
+// dataDir is a directory uninstall --purge clears.
+type dataDir struct {
+	path string
+	// entries are the name patterns of what clai creates in path. They are
+	// nil for the base directory, which clai owns and removes outright. The
+	// config, data and cache roots can be relocated into directories shared
+	// with other files, so only these entries are removed from them, and
+	// the directory itself once empty.
+	entries []string
+}
+
+// The entries clai creates in the config, data and cache roots. A profile
+// keeps its files in profiles/<name> below each root.
+var (
+	configDirEntries = []string{"config.yaml", "trusted_repos.json*", "workflows", "profiles"}
+	dataDirEntries   = []string{"state.db*", "suggestions_v2.db*", "suggestions.db*", "db.key", "profiles"}
+	cacheDirEntries  = []string{"ingest.spool*", "hook.spool*", "suggestion", "last_output", "off", "picker.lock", "init.nu", "profiles"}
+)
+
+// existingDataDirs returns the clai data directories that exist on disk,
+// leaving out those inside the base directory.
+func existingDataDirs(paths *config.Paths) []dataDir {
+	roots := []dataDir{
+		{path: paths.BaseDir},
+		{path: paths.ConfigDir(), entries: configDirEntries},
+		{path: paths.DataDir(), entries: dataDirEntries},
+		{path: paths.CacheDir(), entries: cacheDirEntries},
+		{path: cache.Dir(), entries: cacheDirEntries},
+	}
+	var dirs []dataDir
+	for _, root := range roots {
+		if _, err := os.Stat(root.path); err != nil {
+			continue
+		}
+		if slices.ContainsFunc(dirs, func(d dataDir) bool { return d.entries == nil && isWithin(root.path, d.path) }) {
+			continue
+		}
+		// One directory can serve as several roots.
+		if i := slices.IndexFunc(dirs, func(d dataDir) bool { return d.path == root.path }); i >= 0 {
+			dirs[i].entries = slices.Concat(dirs[i].entries, root.entries)
+			continue
+		}
+		dirs = append(dirs, root)
 	}
+	return dirs
+}
+
+// purgeLabels describes dirs for the purge confirmation.
+func purgeLabels(dirs []dataDir) []string {
+	labels := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if dir.entries == nil {
+			labels = append(labels, dir.path)
+		} else {
+			labels = append(labels, dir.path+" (only the files clai created)")
+		}
+	}
+	return labels
+}
+
+// isWithin reports whether path is dir or below it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
 
-	for _, hookFile := range hookFiles {
-		if _, err := os.Stat(hookFile); err == nil {
-			if err := os.Remove(hookFile); err != nil {
-				fmt.Printf("Warning: failed to remove %s: %v\n", hookFile, err)
-			} else {
-				fmt.Printf("Removed hook file: %s\n", hookFile)
+// confirmPurge lists the directories to delete and asks for a "yes".
+func confirmPurge(in io.Reader, dirs []string) bool {
+	fmt.Printf("%sThis permanently deletes all clai data, including command history:%s\n", colorYellow, colorReset)
+	for _, dir := range dirs {
+		fmt.Printf("  %s\n", dir)
+	}
+	fmt.Print("Type 'yes' to continue: ")
+
+	response, _ := bufio.NewReader(in).ReadString('\n')
+	return strings.TrimSpace(strings.ToLower(response)) == "yes"
+}
+
+func removeDataDirs(dirs []dataDir, dryRun bool) int {
+	// Deeper directories first, so one emptied inside another is gone by
+	// the time the outer one is checked for other files.
+	dirs = slices.Clone(dirs)
+	slices.SortStableFunc(dirs, func(a, b dataDir) int { return len(b.path) - len(a.path) })
+
+	removed := 0
+	for _, dir := range dirs {
+		if dir.entries != nil {
+			if removeClaiEntries(dir, dryRun) {
+				removed++
 			}
+			continue
 		}
+		if dryRun {
+			fmt.Printf("Would remove data directory: %s\n", dir.path)
+			removed++
+			continue
+		}
+		if err := os.RemoveAll(dir.path); err != nil {
+			fmt.Printf("Warning: failed to remove %s: %v\n", dir.path, err)
+			continue
+		}
+		fmt.Printf("Removed data directory: %s\n", dir.path)
+		removed++
 	}
+	return removed
+}
 
-	if !removed {
-		fmt.Println("No clai installation found in shell configuration files.")
-		return nil
+// removeClaiEntries removes the entries of dir that clai created, and dir
+// itself when nothing else is left in it. Reports whether anything was
+// removed, or would be with dryRun.
+func removeClaiEntries(dir dataDir, dryRun bool) bool {
+	entries, err := os.ReadDir(dir.path)
+	if err != nil {
+		fmt.Printf("Warning: failed to read %s: %v\n", dir.path, err)
+		return false
+	}
+	var matched []string
+	for _, e := range entries {
+		if slices.ContainsFunc(dir.entries, func(pattern string) bool {
+			ok, _ := filepath.Match(pattern, e.Name())
+			return ok
+		}) {
+			matched = append(matched, filepath.Join(dir.path, e.Name()))
+		}
 	}
+	others := len(entries) - len(matched)
 
-	fmt.Printf("\n%sUninstalled successfully!%s\n", colorGreen, colorReset)
-	fmt.Println("Please restart your terminal or source your rc file.")
+	if dryRun {
+		switch {
+		case others == 0:
+			fmt.Printf("Would remove data directory: %s\n", dir.path)
+		case len(matched) > 0:
+			fmt.Printf("Would remove clai files from: %s (keeping %d other entries)\n", dir.path, others)
+		}
+		return others == 0 || len(matched) > 0
+	}
 
-	return nil
+	for _, path := range matched {
+		if err := os.RemoveAll(path); err != nil {
+			fmt.Printf("Warning: failed to remove %s: %v\n", path, err)
+			others++
+		}
+	}
+	if others == 0 {
+		if err := os.Remove(dir.path); err != nil {
+			fmt.Printf("Warning: failed to remove %s: %v\n", dir.path, err)
+			return len(matched) > 0
+		}
+		fmt.Printf("Removed data directory: %s\n", dir.path)
+		return true
+	}
+	if len(matched) == 0 {
+		return false
+	}
+	fmt.Printf("Removed clai files from: %s (kept %d other entries)\n", dir.path, others)
+	return true
+}
+
+// removeFiles deletes the files that exist and prints each one as "Removed <kind>: <path>".
+// With dryRun it only prints them, as "Would remove <kind>: <path>".
+func removeFiles(kind string, files []string, dryRun bool) int {
+	removed := 0
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		if dryRun {
+			fmt.Printf("Would remove %s: %s\n", kind, file)
+			removed++
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			fmt.Printf("Warning: failed to remove %s: %v\n", file, err)
+			continue
+		}
+		fmt.Printf("Removed %s: %s\n", kind, file)
+		removed++
+	}
+	return removed
 }
 
 func removeFromRCFile(rcFile, hooksDir string) (bool, error) {
@@ -117,6 +396,19 @@ func removeFromRCFile(rcFile, hooksDir string) (bool, error) {
 	return true, nil
 }
 
+// rcFileHasClai reports whether removeFromRCFile would change rcFile.
+func rcFileHasClai(rcFile, hooksDir string) (bool, error) {
+	content, err := os.ReadFile(rcFile) //nolint:gosec // G304: rcFile is a known shell rc path
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	_, found := filterClaiLines(string(content), claiRCPatterns(hooksDir))
+	return found, nil
+}
+
 func claiRCPatterns(hooksDir string) []string {
 	return []string{
 		"source \"" + hooksDir,
@@ -126,6 +418,11 @@ func claiRCPatterns(hooksDir string) []string {
 		"clai init zsh",
 		"clai init bash",
 		"clai init fish",
+		"clai init pwsh",
+		"clai init nu",
+		// The lines of env.nu and config.nu around the saved Nushell script
+		"mkdir ~/.cache/clai",
+		"source ~/.cache/clai/init.nu",
 		"# clai shell integration",
 	}
 }
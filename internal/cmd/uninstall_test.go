@@ -58,6 +58,24 @@ func TestRemoveFromRCFile(t *testing.T) {
 			expected: "alias ll='ls -la'\n",
 			removed:  true,
 		},
+		{
+			name:     "powershell profile",
+			content:  "Set-PSReadLineOption -EditMode Emacs\nclai init pwsh | Out-String | Invoke-Expression\n",
+			expected: "Set-PSReadLineOption -EditMode Emacs\n",
+			removed:  true,
+		},
+		{
+			name:     "nushell env.nu",
+			content:  "$env.EDITOR = 'vim'\nmkdir ~/.cache/clai\nclai init nu | save --force ~/.cache/clai/init.nu\n",
+			expected: "$env.EDITOR = 'vim'\n",
+			removed:  true,
+		},
+		{
+			name:     "nushell config.nu",
+			content:  "source ~/.cache/clai/init.nu\n$env.config.show_banner = false\n",
+			expected: "$env.config.show_banner = false\n",
+			removed:  true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -163,3 +181,263 @@ source ~/.fzf.zsh
 		}
 	}
 }
+
+func TestConfirmPurge(t *testing.T) {
+	tests := map[string]bool{
+		"yes\n": true,
+		"YES\n": true,
+		"y\n":   false,
+		"\n":    false,
+		"":      false,
+	}
+	for input, want := range tests {
+		var got bool
+		captureStdout(t, func() {
+			got = confirmPurge(strings.NewReader(input), []string{"/tmp/clai"})
+		})
+		if got != want {
+			t.Errorf("confirmPurge(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestRunUninstall_PurgeRemovesEverything(t *testing.T) {
+	home := t.TempDir()
+	claiHome := filepath.Join(home, ".clai")
+	cacheDir := filepath.Join(home, ".cache", "clai")
+	unitDir := filepath.Join(home, ".config", "systemd", "user")
+	t.Setenv("HOME", home)
+	t.Setenv("CLAI_HOME", claiHome)
+	t.Setenv("CLAI_CACHE", cacheDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("PATH", "") // keep systemctl out of reach
+
+	for _, dir := range []string{filepath.Join(claiHome, "hooks"), cacheDir, unitDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("MkdirAll(%s) error: %v", dir, err)
+		}
+	}
+	hookFile := filepath.Join(claiHome, "hooks", "clai.zsh")
+	files := map[string]string{
+		hookFile:                               "# hook",
+		filepath.Join(home, ".zshrc"):          "source \"" + hookFile + "\"\n",
+		filepath.Join(unitDir, "claid.socket"): "[Socket]",
+		filepath.Join(claiHome, "state.db"):    "db",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile(%s) error: %v", path, err)
+		}
+	}
+
+	uninstallPurge, uninstallYes = true, true
+	t.Cleanup(func() { uninstallPurge, uninstallYes = false, false })
+
+	output := captureStdout(t, func() {
+		if err := runUninstall(uninstallCmd, nil); err != nil {
+			t.Fatalf("runUninstall error: %v", err)
+		}
+	})
+
+	for _, path := range []string{claiHome, cacheDir, filepath.Join(unitDir, "claid.socket")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", path)
+		}
+	}
+	for _, want := range []string{"Removed clai from:", "Removed hook file:", "Removed service unit:", "Removed data directory:"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestRunUninstall_KeepsDataWithoutPurge(t *testing.T) {
+	home := t.TempDir()
+	claiHome := filepath.Join(home, ".clai")
+	t.Setenv("HOME", home)
+	t.Setenv("CLAI_HOME", claiHome)
+	t.Setenv("CLAI_CACHE", filepath.Join(home, ".cache", "clai"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	if err := os.MkdirAll(claiHome, 0o755); err != nil {
+		t.Fatalf("MkdirAll error: %v", err)
+	}
+	dbFile := filepath.Join(claiHome, "state.db")
+	if err := os.WriteFile(dbFile, []byte("db"), 0o600); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	output := captureStdout(t, func() {
+		if err := runUninstall(uninstallCmd, nil); err != nil {
+			t.Fatalf("runUninstall error: %v", err)
+		}
+	})
+
+	if _, err := os.Stat(dbFile); err != nil {
+		t.Errorf("expected data to be kept, stat error: %v", err)
+	}
+	if !strings.Contains(output, "No clai installation found.") {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+}
+
+func TestRunUninstall_DryRunRemovesNothing(t *testing.T) {
+	home := t.TempDir()
+	claiHome := filepath.Join(home, ".clai")
+	unitDir := filepath.Join(home, ".config", "systemd", "user")
+	t.Setenv("HOME", home)
+	t.Setenv("CLAI_HOME", claiHome)
+	t.Setenv("CLAI_CACHE", filepath.Join(home, ".cache", "clai"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("PATH", "")
+
+	for _, dir := range []string{filepath.Join(claiHome, "hooks"), unitDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("MkdirAll(%s) error: %v", dir, err)
+		}
+	}
+	hookFile := filepath.Join(claiHome, "hooks", "clai.zsh")
+	rcFile := filepath.Join(home, ".zshrc")
+	rcContent := "alias ll='ls -la'\nsource \"" + hookFile + "\"\n"
+	files := map[string]string{
+		hookFile:                               "# hook",
+		rcFile:                                 rcContent,
+		filepath.Join(unitDir, "claid.socket"): "[Socket]",
+		filepath.Join(claiHome, "state.db"):    "db",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile(%s) error: %v", path, err)
+		}
+	}
+
+	uninstallPurge, uninstallDryRun = true, true
+	t.Cleanup(func() { uninstallPurge, uninstallDryRun = false, false })
+
+	output := captureStdout(t, func() {
+		if err := runUninstall(uninstallCmd, nil); err != nil {
+			t.Fatalf("runUninstall error: %v", err)
+		}
+	})
+
+	for path, content := range files {
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != content {
+			t.Errorf("%s changed by a dry run: %q, %v", path, got, err)
+		}
+	}
+	for _, want := range []string{
+		"Would remove clai from: " + rcFile,
+		"Would remove hook file: " + hookFile,
+		"Would remove service unit:",
+		"Would remove data directory: " + claiHome,
+		"Dry run: nothing was removed.",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "This permanently deletes") {
+		t.Errorf("dry run asked for confirmation:\n%s", output)
+	}
+}
+
+func TestRunUninstall_PurgeKeepsOtherFilesInRelocatedDir(t *testing.T) {
+	home := t.TempDir()
+	claiHome := filepath.Join(home, ".clai")
+	dataDir := filepath.Join(home, "shared")
+	t.Setenv("HOME", home)
+	t.Setenv("CLAI_HOME", claiHome)
+	t.Setenv("CLAI_DATA_DIR", dataDir)
+	t.Setenv("CLAI_CACHE", filepath.Join(home, ".cache", "clai"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("PATH", "")
+
+	for _, dir := range []string{filepath.Join(claiHome, "hooks"), dataDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("MkdirAll(%s) error: %v", dir, err)
+		}
+	}
+	hookFile := filepath.Join(claiHome, "hooks", "clai.zsh")
+	otherFile := filepath.Join(dataDir, "notes.txt")
+	files := map[string]string{
+		hookFile:                               "# hook",
+		filepath.Join(home, ".zshrc"):          "source \"" + hookFile + "\"\n",
+		filepath.Join(dataDir, "state.db"):     "db",
+		filepath.Join(dataDir, "state.db-wal"): "wal",
+		filepath.Join(dataDir, "db.key"):       "key",
+		otherFile:                              "not clai's",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile(%s) error: %v", path, err)
+		}
+	}
+
+	uninstallPurge, uninstallYes = true, true
+	t.Cleanup(func() { uninstallPurge, uninstallYes = false, false })
+
+	output := captureStdout(t, func() {
+		if err := runUninstall(uninstallCmd, nil); err != nil {
+			t.Fatalf("runUninstall error: %v", err)
+		}
+	})
+
+	for _, name := range []string{"state.db", "state.db-wal", "db.key"} {
+		if _, err := os.Stat(filepath.Join(dataDir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", name)
+		}
+	}
+	if _, err := os.Stat(otherFile); err != nil {
+		t.Errorf("expected %s to be kept, stat error: %v", otherFile, err)
+	}
+	if !strings.Contains(output, "Removed clai files from: "+dataDir) {
+		t.Errorf("output missing partial removal of %s:\n%s", dataDir, output)
+	}
+}
+
+func TestRunUninstall_RemovesPwshAndNuIntegration(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAI_HOME", filepath.Join(home, ".clai"))
+	t.Setenv("CLAI_CACHE", filepath.Join(home, ".cache", "clai"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("PATH", "")
+
+	profile := filepath.Join(home, ".config", "powershell", "Microsoft.PowerShell_profile.ps1")
+	envNu := filepath.Join(home, ".config", "nushell", "env.nu")
+	configNu := filepath.Join(home, ".config", "nushell", "config.nu")
+	initNu := filepath.Join(home, ".cache", "clai", "init.nu")
+	files := map[string]string{
+		profile:  "clai init pwsh | Out-String | Invoke-Expression\n",
+		envNu:    "mkdir ~/.cache/clai\nclai init nu | save --force ~/.cache/clai/init.nu\n",
+		configNu: "source ~/.cache/clai/init.nu\n",
+		initNu:   "# clai",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll(%s) error: %v", path, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile(%s) error: %v", path, err)
+		}
+	}
+
+	output := captureStdout(t, func() {
+		if err := runUninstall(uninstallCmd, nil); err != nil {
+			t.Fatalf("runUninstall error: %v", err)
+		}
+	})
+
+	for _, rcFile := range []string{profile, envNu, configNu} {
+		if !strings.Contains(output, "Removed clai from: "+rcFile) {
+			t.Errorf("output missing %s:\n%s", rcFile, output)
+		}
+		if content, err := os.ReadFile(rcFile); err != nil || len(content) != 0 {
+			t.Errorf("%s = %q (error %v), want it emptied", rcFile, content, err)
+		}
+	}
+	if _, err := os.Stat(initNu); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", initNu)
+	}
+}
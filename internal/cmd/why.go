@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/ipc"
+)
+
+var whyCmd = &cobra.Command{
+	Use:     "why",
+	Short:   "Explain why the suggestions before your last command were shown",
+	GroupID: groupCore,
+	Long: `Show how the suggestions at the prompt your last command ran from were
+ranked: for each suggestion its score, the reasons behind it and what every
+ranking feature contributed, then the weights the features were ranked
+with, including learned weight profiles that were blended in.
+
+Like 'clai feedback', it looks at the prompt before your last command, so
+run it right after a suggestion surprised you.
+
+Examples:
+  clai why`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runWhy,
+}
+
+// suggestionExplainer is the part of the daemon client the why command uses.
+type suggestionExplainer interface {
+	ExplainSuggestions(ctx context.Context, sessionID string) (*pb.ExplainSuggestionsResponse, error)
+}
+
+func runWhy(cmd *cobra.Command, _ []string) error {
+	sessionID := os.Getenv("CLAI_SESSION_ID")
+	if sessionID == "" {
+		return errors.New("no active clai session (CLAI_SESSION_ID is not set)")
+	}
+
+	client, err := ipc.NewClient()
+	if err != nil {
+		return fmt.Errorf("daemon not available: %w", err)
+	}
+	defer client.Close()
+
+	return explainSuggestions(cmd.Context(), client, sessionID, time.Now(), os.Stdout)
+}
+
+// explainSuggestions fetches the session's suggestion snapshot and writes
+// the report.
+func explainSuggestions(ctx context.Context, client suggestionExplainer, sessionID string, now time.Time, out io.Writer) error {
+	resp, err := client.ExplainSuggestions(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to explain suggestions: %w", err)
+	}
+	if !resp.Ok {
+		return errors.New(resp.GetError().GetMessage())
+	}
+	writeWhyReport(out, resp, now)
+	return nil
+}
+
+func writeWhyReport(w io.Writer, r *pb.ExplainSuggestionsResponse, now time.Time) {
+	ago := now.Sub(time.UnixMilli(r.ShownAtUnixMs)).Round(time.Second)
+	fmt.Fprintf(w, "%sSuggestions for %q%s, shown %s ago before: %s\n", colorBold, r.Prefix, colorReset, ago, r.Command)
+	if sc := r.Scoring; sc != nil {
+		fmt.Fprintf(w, "Ranked by the %s scorer", whyScorerName(sc.Scorer))
+		if sc.RepoKey != "" {
+			fmt.Fprintf(w, " in repository %s", sc.RepoKey)
+		}
+		if sc.LastCmd != "" {
+			fmt.Fprintf(w, " after %q", sc.LastCmd)
+		}
+		fmt.Fprintln(w)
+	}
+
+	for i, sug := range r.Suggestions {
+		fmt.Fprintf(w, "\n%d. %s%s%s\n", i+1, colorCyan, sug.Text, colorReset)
+		fmt.Fprintf(w, "   score %.3f", sug.Score)
+		if sug.Confidence > 0 {
+			fmt.Fprintf(w, "  confidence %.0f%%", 100*sug.Confidence)
+		}
+		if sug.Source != "" {
+			fmt.Fprintf(w, "  scope %s", sug.Source)
+		}
+		if sug.SuccessProbability > 0 {
+			fmt.Fprintf(w, "  succeeds %.0f%%", 100*sug.SuccessProbability)
+		}
+		fmt.Fprintln(w)
+		if sug.Description != "" {
+			fmt.Fprintf(w, "   %s%s%s\n", colorDim, sug.Description, colorReset)
+		}
+		for _, reason := range sug.Reasons {
+			if reason.Contribution != 0 {
+				fmt.Fprintf(w, "   - %-18s %s (%+.2f)\n", reason.Type, reason.Description, reason.Contribution)
+			} else {
+				fmt.Fprintf(w, "   - %-18s %s\n", reason.Type, reason.Description)
+			}
+		}
+		if len(sug.Breakdown) > 0 {
+			fmt.Fprintln(w, "   Score breakdown:")
+			for _, c := range sug.Breakdown {
+				fmt.Fprintf(w, "     %-20s %+8.3f\n", c.Name, c.Value)
+			}
+		}
+	}
+
+	sc := r.Scoring
+	if sc == nil || (len(sc.Weights) == 0 && len(sc.Learned) == 0) {
+		return
+	}
+	if len(sc.Weights) > 0 {
+		fmt.Fprintf(w, "\n%sWeights used:%s\n", colorBold, colorReset)
+		for _, c := range sc.Weights {
+			fmt.Fprintf(w, "  %-20s %8.3f\n", c.Name, c.Value)
+		}
+	}
+	if len(sc.Learned) == 0 {
+		fmt.Fprintln(w, "No learned weight profiles applied; these are the configured weights.")
+		return
+	}
+	fmt.Fprintln(w, "Learned profiles blended in, least to most specific:")
+	for _, l := range sc.Learned {
+		fmt.Fprintf(w, "  %-30s %6d samples  %3.0f%% trust\n", l.Scope, l.SampleCount, 100*l.Trust)
+	}
+}
+
+// whyScorerName names a SuggestScoring scorer for the report.
+func whyScorerName(scorer string) string {
+	switch scorer {
+	case "v1":
+		return "V1 (history)"
+	case "v2":
+		return "V2"
+	case "blend":
+		return "V1 and V2 (interleaved)"
+	default:
+		return scorer
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+type fakeSuggestionExplainer struct {
+	sessionID string
+	resp      *pb.ExplainSuggestionsResponse
+}
+
+func (f *fakeSuggestionExplainer) ExplainSuggestions(_ context.Context, sessionID string) (*pb.ExplainSuggestionsResponse, error) {
+	f.sessionID = sessionID
+	return f.resp, nil
+}
+
+func TestExplainSuggestions(t *testing.T) {
+	t.Parallel()
+
+	now := time.UnixMilli(1_700_000_060_000)
+	client := &fakeSuggestionExplainer{resp: &pb.ExplainSuggestionsResponse{
+		Ok:            true,
+		Prefix:        "git",
+		Command:       "git status",
+		ShownAtUnixMs: 1_700_000_000_000,
+		Suggestions: []*pb.Suggestion{{
+			Text:       "git push",
+			Source:     "repo",
+			Score:      0.84,
+			Confidence: 0.9,
+			Reasons: []*pb.SuggestionReason{
+				{Type: "transition", Description: "often follows git commit", Contribution: 0.6},
+			},
+			Breakdown: []*pb.ScoreComponent{{Name: "repo_transition", Value: 48}},
+		}},
+		Scoring: &pb.SuggestScoring{
+			Scorer:  "v2",
+			RepoKey: "/src/app",
+			LastCmd: "git commit",
+			Weights: []*pb.ScoreComponent{{Name: "transition", Value: 0.3}},
+			Learned: []*pb.LearnedWeightLevel{{Scope: "global", SampleCount: 120, Trust: 0.5}},
+		},
+	}}
+
+	var out bytes.Buffer
+	if err := explainSuggestions(context.Background(), client, "sess-1", now, &out); err != nil {
+		t.Fatalf("explainSuggestions() error = %v", err)
+	}
+	if client.sessionID != "sess-1" {
+		t.Errorf("session = %q, want sess-1", client.sessionID)
+	}
+	for _, want := range []string{
+		`"git"`, "shown 1m0s ago before: git status",
+		"V2 scorer in repository /src/app after \"git commit\"",
+		"git push", "score 0.840", "confidence 90%", "scope repo",
+		"often follows git commit (+0.60)",
+		"repo_transition", "+48.000",
+		"Weights used:", "transition", "0.300",
+		"global", "120 samples", "50% trust",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, out.String())
+		}
+	}
+
+	client.resp = &pb.ExplainSuggestionsResponse{Error: &pb.ApiError{Code: "E_NO_SNAPSHOT", Message: "no suggestions were shown"}}
+	err := explainSuggestions(context.Background(), client, "sess-1", now, &out)
+	if err == nil || err.Error() != "no suggestions were shown" {
+		t.Errorf("explainSuggestions() error = %v, want the daemon's message", err)
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
@@ -53,30 +54,55 @@ func (e *WorkflowExitError) Error() string {
 var workflowCmd = &cobra.Command{
 	Use:     "workflow",
 	Aliases: []string{"w"},
-	Short:   "Run and validate workflow files",
+	Short:   "List, run and validate workflow files",
 	GroupID: groupCore,
 }
 
-var workflowRunCmd = &cobra.Command{
-	Use:          "run <path>",
-	Short:        "Execute a workflow file",
-	Args:         cobra.ExactArgs(1),
-	RunE:         runWorkflow,
+var workflowListFormat string
+
+var workflowListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the workflows in the workflow directories",
+	Long: `List the workflow files in .clai/workflows of the current directory and
+in the workflows directory of the clai config, with the name to pass to
+'clai workflow run', the workflow's title and description, and its jobs.
+A project workflow shadows a global one of the same name. Files that do
+not parse or validate are listed with the problem.
+
+Examples:
+  clai workflow list
+  clai workflow list --json | jq -r '.[] | select(.valid) | .name'`,
+	Args:         cobra.NoArgs,
 	SilenceUsage: true,
+	RunE:         listWorkflows,
+}
+
+var workflowRunCmd = &cobra.Command{
+	Use:               "run <name|path>",
+	Short:             "Execute a workflow file",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkflowNames,
+	RunE:              runWorkflow,
+	SilenceUsage:      true,
 }
 
 var workflowValidateCmd = &cobra.Command{
-	Use:          "validate <path>",
-	Short:        "Validate a workflow file without executing",
-	Args:         cobra.ExactArgs(1),
-	RunE:         validateWorkflow,
-	SilenceUsage: true,
+	Use:               "validate <name|path>",
+	Short:             "Validate a workflow file without executing",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkflowNames,
+	RunE:              validateWorkflow,
+	SilenceUsage:      true,
 }
 
 func init() {
+	workflowCmd.AddCommand(workflowListCmd)
 	workflowCmd.AddCommand(workflowRunCmd)
 	workflowCmd.AddCommand(workflowValidateCmd)
 
+	workflowListCmd.Flags().StringVar(&workflowListFormat, "format", "text", "Output format: text or json")
+	addJSONFlag(workflowListCmd, &workflowListFormat)
+
 	workflowRunCmd.Flags().String("mode", "auto", "Execution mode: auto, attended, unattended")
 	workflowRunCmd.Flags().StringSlice("var", nil, "Set workflow variable (key=value)")
 	workflowRunCmd.Flags().Bool("no-daemon", false, "Skip daemon connection")
@@ -101,7 +127,8 @@ type workflowRunContext struct {
 
 func runWorkflow(cmd *cobra.Command, args []string) error {
 	// Phase 1: Parse and validate.
-	def, data, err := loadWorkflow(args[0])
+	path := resolveWorkflowPath(args[0])
+	def, data, err := loadWorkflow(path)
 	if err != nil {
 		return err
 	}
@@ -112,7 +139,7 @@ func runWorkflow(cmd *cobra.Command, args []string) error {
 	}
 
 	// Phase 2: Setup run context.
-	rc, cancel, err := setupRunContext(cmd, def, data, args[0])
+	rc, cancel, err := setupRunContext(cmd, def, data, path)
 	if err != nil {
 		return err
 	}
@@ -482,16 +509,11 @@ func reportResults(rc *workflowRunContext, result *jobExecutionResult) error {
 }
 
 func validateWorkflow(_ *cobra.Command, args []string) error {
-	data, err := readWorkflowBytes(args[0])
+	def, err := readWorkflowDef(resolveWorkflowPath(args[0]))
 	if err != nil {
 		return err
 	}
 
-	def, err := workflow.ParseWorkflow(data)
-	if err != nil {
-		return fmt.Errorf("parsing workflow: %w", err)
-	}
-
 	errs := workflow.ValidateWorkflow(def)
 	if len(errs) > 0 {
 		for _, e := range errs {
@@ -507,6 +529,107 @@ func validateWorkflow(_ *cobra.Command, args []string) error {
 	return nil
 }
 
+// workflowListEntry is one workflow in the output of 'clai workflow list'.
+type workflowListEntry struct {
+	Name        string   `json:"name"` // File name without extension, for 'clai workflow run'
+	Path        string   `json:"path"`
+	Title       string   `json:"title"` // The workflow's name field
+	Description string   `json:"description"`
+	Jobs        []string `json:"jobs"`
+	Requires    []string `json:"requires"`
+	Error       string   `json:"error,omitempty"` // Why the file is not valid
+	Valid       bool     `json:"valid"`
+}
+
+func listWorkflows(cmd *cobra.Command, _ []string) error {
+	format := strings.ToLower(strings.TrimSpace(workflowListFormat))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format: %s (use text or json)", workflowListFormat)
+	}
+	entries := describeWorkflows(workflow.FindWorkflows())
+	if format == "json" {
+		return writeJSON(cmd.OutOrStdout(), entries)
+	}
+	renderWorkflowList(cmd.OutOrStdout(), entries)
+	return nil
+}
+
+// describeWorkflows reads and validates each of files.
+func describeWorkflows(files []workflow.WorkflowFile) []workflowListEntry {
+	entries := make([]workflowListEntry, 0, len(files))
+	for _, f := range files {
+		entry := workflowListEntry{Name: f.Name, Path: f.Path, Jobs: []string{}, Requires: []string{}}
+		def, err := readWorkflowDef(f.Path)
+		if err != nil {
+			entry.Error = err.Error()
+			entries = append(entries, entry)
+			continue
+		}
+		entry.Title = def.Name
+		entry.Description = def.Description
+		for name := range def.Jobs {
+			entry.Jobs = append(entry.Jobs, name)
+		}
+		sort.Strings(entry.Jobs)
+		if def.Requires != nil {
+			entry.Requires = def.Requires
+		}
+		entry.Valid = true
+		if errs := workflow.ValidateWorkflow(def); len(errs) > 0 {
+			entry.Valid = false
+			entry.Error = errs[0].Error()
+			if len(errs) > 1 {
+				entry.Error += fmt.Sprintf(" (and %d more)", len(errs)-1)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// readWorkflowDef reads and parses the workflow file at path.
+func readWorkflowDef(path string) (*workflow.WorkflowDef, error) {
+	data, err := readWorkflowBytes(path)
+	if err != nil {
+		return nil, err
+	}
+	def, err := workflow.ParseWorkflow(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing workflow: %w", err)
+	}
+	return def, nil
+}
+
+// renderWorkflowList prints one line per workflow, with its description
+// or the problem with it.
+func renderWorkflowList(w io.Writer, entries []workflowListEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintf(w, "No workflows found in %s.\n", strings.Join(workflow.WorkflowSearchDirs(), " or "))
+		return
+	}
+	for _, e := range entries {
+		detail := cmp.Or(e.Description, e.Title)
+		if !e.Valid {
+			detail = colorRed + "invalid: " + e.Error + colorReset
+		}
+		fmt.Fprintf(w, "  %s%-20s%s %s\n", colorCyan, e.Name, colorReset, detail)
+		fmt.Fprintf(w, "  %-20s %s%s%s\n", "", colorDim, e.Path, colorReset)
+	}
+}
+
+// resolveWorkflowPath resolves a workflow name, such as "deploy", to its file
+// in the workflow directories. Paths, and names that are not found, are
+// returned unchanged.
+func resolveWorkflowPath(arg string) string {
+	if arg == "-" {
+		return arg
+	}
+	if path, err := workflow.DiscoverWorkflow(arg); err == nil {
+		return path
+	}
+	return arg
+}
+
 func readWorkflowBytes(path string) ([]byte, error) {
 	var (
 		data []byte
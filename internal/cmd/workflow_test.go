@@ -854,3 +854,65 @@ func TestWorkflowRunCmd_RequiresArg(t *testing.T) {
 	err := cmd.Execute()
 	assert.Error(t, err, "run without args should error")
 }
+
+func TestDescribeWorkflows(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := []workflow.WorkflowFile{
+		{Name: "broken", Path: filepath.Join(dir, "broken.yaml")},
+		{Name: "nameless", Path: filepath.Join(dir, "nameless.yaml")},
+		{Name: "test", Path: filepath.Join(dir, "test.yml")},
+	}
+	require.NoError(t, os.WriteFile(files[0].Path, []byte("jobs: [\n"), 0o600))
+	require.NoError(t, os.WriteFile(files[1].Path, []byte("jobs:\n  build:\n    steps:\n      - id: a\n        run: echo\n"), 0o600))
+	require.NoError(t, os.WriteFile(files[2].Path, []byte(validWorkflowYAML), 0o600))
+
+	entries := describeWorkflows(files)
+	require.Len(t, entries, 3)
+	assert.False(t, entries[0].Valid)
+	assert.Contains(t, entries[0].Error, "parsing workflow")
+	assert.False(t, entries[1].Valid)
+	assert.Contains(t, entries[1].Error, "name")
+	assert.Equal(t, []string{"build"}, entries[1].Jobs)
+	assert.Equal(t, workflowListEntry{
+		Name:        "test",
+		Path:        files[2].Path,
+		Title:       "test-workflow",
+		Description: "A test workflow for unit tests",
+		Jobs:        []string{"build"},
+		Requires:    []string{},
+		Valid:       true,
+	}, entries[2])
+
+	var out bytes.Buffer
+	renderWorkflowList(&out, entries)
+	assert.Contains(t, out.String(), "invalid: parsing workflow")
+	assert.Contains(t, out.String(), "A test workflow for unit tests")
+	assert.Contains(t, out.String(), files[2].Path)
+}
+
+func TestWorkflowListCmd_JSON(t *testing.T) {
+	t.Setenv("CLAI_HOME", t.TempDir())
+	project := t.TempDir()
+	t.Chdir(project)
+	require.NoError(t, os.MkdirAll(filepath.Join(project, ".clai", "workflows"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".clai", "workflows", "test.yaml"), []byte(validWorkflowYAML), 0o600))
+	oldFormat := workflowListFormat
+	t.Cleanup(func() { workflowListFormat = oldFormat })
+
+	require.NoError(t, workflowListCmd.Flags().Set("json", "true"))
+	var out bytes.Buffer
+	workflowListCmd.SetOut(&out)
+	t.Cleanup(func() { workflowListCmd.SetOut(nil) })
+	require.NoError(t, listWorkflows(workflowListCmd, nil))
+	assert.JSONEq(t, `[{
+		"name": "test",
+		"path": ".clai/workflows/test.yaml",
+		"title": "test-workflow",
+		"description": "A test workflow for unit tests",
+		"jobs": ["build"],
+		"requires": [],
+		"valid": true
+	}]`, out.String())
+}
@@ -2,6 +2,7 @@ package cmdutil
 
 import (
 	"strings"
+	"unicode"
 )
 
 // IsSudo returns true if the command starts with "sudo".
@@ -54,3 +55,60 @@ func CountWords(cmd string) int {
 	}
 	return len(strings.Fields(cmd))
 }
+
+// WordBoundaries returns the character offsets in cmd where accepting it
+// word by word stops, ascending: after each shell word with the whitespace
+// that follows it, and after each / inside a word, so paths are accepted a
+// directory at a time. Quoted strings and escaped characters stay inside
+// their word. The last offset is the length of cmd in characters.
+func WordBoundaries(cmd string) []int {
+	var bounds []int
+	add := func(pos int) {
+		if pos > 0 && (len(bounds) == 0 || bounds[len(bounds)-1] < pos) {
+			bounds = append(bounds, pos)
+		}
+	}
+
+	inSingleQuote := false
+	inDoubleQuote := false
+	escaped := false
+	inWord := false
+	seenWord := false
+	slashEnd := 0 // Offset after a / that ends a word part, once the word goes on
+	pos := 0
+	for _, r := range cmd {
+		if !escaped && !inSingleQuote && !inDoubleQuote && unicode.IsSpace(r) {
+			inWord = false
+			slashEnd = 0
+			pos++
+			continue
+		}
+
+		if !inWord {
+			if seenWord {
+				add(pos)
+			}
+			inWord, seenWord = true, true
+		} else if slashEnd > 0 {
+			add(slashEnd)
+		}
+		slashEnd = 0
+
+		literal := escaped
+		escaped = false
+		switch {
+		case literal:
+		case r == '\\' && !inSingleQuote:
+			escaped = true
+		case r == '\'' && !inDoubleQuote:
+			inSingleQuote = !inSingleQuote
+		case r == '"' && !inSingleQuote:
+			inDoubleQuote = !inDoubleQuote
+		case r == '/' && !inSingleQuote && !inDoubleQuote:
+			slashEnd = pos + 1
+		}
+		pos++
+	}
+	add(pos)
+	return bounds
+}
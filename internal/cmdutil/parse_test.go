@@ -1,6 +1,9 @@
 package cmdutil
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestIsSudo(t *testing.T) {
 	tests := []struct {
@@ -69,3 +72,26 @@ func TestCountWords(t *testing.T) {
 		}
 	}
 }
+
+func TestWordBoundaries(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want []int
+	}{
+		{"ls", []int{2}},
+		{"git commit -m 'fix the bug'", []int{4, 11, 14, 27}},
+		{"cd src/internal/cmd", []int{3, 7, 16, 19}},
+		{"ls src/ -la", []int{3, 8, 11}},
+		{`cat "a b/c" d\ e`, []int{4, 12, 16}},
+		{"  ls   -la  ", []int{7, 12}},
+		{"echo héllo", []int{5, 10}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got := WordBoundaries(tt.cmd)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WordBoundaries(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package cmdutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"unicode/utf8"
+)
+
+// TruncateUTF8 shortens s to at most maxBytes bytes without splitting a
+// multi-byte rune. It reports whether s was shortened. A maxBytes of zero
+// or less leaves s unchanged.
+func TruncateUTF8(s string, maxBytes int) (string, bool) {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s, false
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut], true
+}
+
+// TailUTF8 keeps the last maxBytes bytes of s at most, without splitting a
+// multi-byte rune. It reports whether s was shortened. A maxBytes of zero or
+// less leaves s unchanged.
+func TailUTF8(s string, maxBytes int) (string, bool) {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s, false
+	}
+	cut := len(s) - maxBytes
+	for cut < len(s) && !utf8.RuneStart(s[cut]) {
+		cut++
+	}
+	return s[cut:], true
+}
+
+// HashRaw returns the SHA256 hash of the exact command text. Unlike
+// HashCommand it is meant for raw, unnormalized commands, so it can
+// identify a command whose stored text was truncated.
+func HashRaw(cmd string) string {
+	hash := sha256.Sum256([]byte(cmd))
+	return hex.EncodeToString(hash[:])
+}
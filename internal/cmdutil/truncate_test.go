@@ -0,0 +1,71 @@
+package cmdutil
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		max           int
+		want          string
+		wantTruncated bool
+	}{
+		{"short", "ls -la", 10, "ls -la", false},
+		{"exact", "ls -la", 6, "ls -la", false},
+		{"ascii", "echo hello", 4, "echo", true},
+		{"no limit", "echo hello", 0, "echo hello", false},
+		// "é" is two bytes; cutting at 6 would split it.
+		{"multibyte boundary", "echo é", 6, "echo ", true},
+		{"emoji", "echo 🚀🚀", 8, "echo ", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := TruncateUTF8(tt.in, tt.max)
+			if got != tt.want || truncated != tt.wantTruncated {
+				t.Errorf("TruncateUTF8(%q, %d) = %q, %v; want %q, %v", tt.in, tt.max, got, truncated, tt.want, tt.wantTruncated)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("TruncateUTF8(%q, %d) returned invalid UTF-8", tt.in, tt.max)
+			}
+		})
+	}
+}
+
+func TestTailUTF8(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		max           int
+		want          string
+		wantTruncated bool
+	}{
+		{"short", "done", 10, "done", false},
+		{"ascii", "line 1\nline 2", 6, "line 2", true},
+		{"no limit", "line 1\nline 2", 0, "line 1\nline 2", false},
+		// "é" is two bytes; keeping 6 would start inside it.
+		{"multibyte boundary", "é error", 7, " error", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := TailUTF8(tt.in, tt.max)
+			if got != tt.want || truncated != tt.wantTruncated {
+				t.Errorf("TailUTF8(%q, %d) = %q, %v; want %q, %v", tt.in, tt.max, got, truncated, tt.want, tt.wantTruncated)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("TailUTF8(%q, %d) returned invalid UTF-8", tt.in, tt.max)
+			}
+		})
+	}
+}
+
+func TestHashRaw(t *testing.T) {
+	if HashRaw("ls -la") == HashRaw("LS -la") {
+		t.Error("HashRaw should not normalize case")
+	}
+	if len(HashRaw("ls")) != 64 {
+		t.Errorf("HashRaw length = %d, want 64", len(HashRaw("ls")))
+	}
+}
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Diagnostic severities.
+const (
+	SeverityError      = "error"      // The file does not load
+	SeverityUnknown    = "unknown"    // A key clai does not read, usually a typo
+	SeverityWarning    = "warning"    // A value clai replaces when loading
+	SeverityDeprecated = "deprecated" // A key that no longer has an effect
+)
+
+// Diagnostic is one finding of CheckFile. Key is the dotted path of the
+// setting ("suggestions.weights.task"); Line is 0 when unknown.
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Key      string `json:"key,omitempty"`
+	Message  string `json:"message"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// deprecatedKeys maps keys that no longer have an effect to a migration
+// hint.
+var deprecatedKeys = map[string]string{
+	"suggestions.max_history":       "the menu size comes from suggestions.max_results (CLAI_MENU_LIMIT in zsh)",
+	"suggestions.max_ai":            "AI suggestions are not mixed into the list; remove it",
+	"suggestions.show_risk_warning": "risky suggestions are always flagged; use suggestions.confirm_risky to be asked before running them",
+	"suggestions.socket_path":       "the suggestions engine runs in claid; use daemon.socket_path",
+	"suggestions.incognito_mode":    "use `clai incognito`, suggestions.ephemeral_dirs or a repository config with incognito: true",
+}
+
+// CheckFile loads the config file at path the way clai does and reports
+// everything it would silently ignore or change: unknown keys, values of
+// the wrong type, values outside their range with the replacement used,
+// and deprecated keys. Profile presets are checked as well. The error is
+// non-nil only when the file cannot be read or is not YAML.
+func CheckFile(path string) ([]Diagnostic, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: config file path is from trusted source
+	if err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	lines := map[string]int{}
+	var diags []Diagnostic
+	if len(root.Content) > 0 {
+		diags = checkNode(root.Content[0], reflect.TypeOf(Config{}), "", lines)
+	}
+
+	cfg := DefaultConfig()
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		for _, d := range typeErrorDiagnostics(err) {
+			d.Key = keyAt(lines, d.Line)
+			diags = append(diags, d)
+		}
+	}
+	diags = append(diags, checkValues(cfg, lines)...)
+	for _, name := range cfg.PresetNames() {
+		// Type errors were reported above; yaml.v3 decodes everything else.
+		merged := DefaultConfig()
+		_ = yaml.Unmarshal(data, merged)
+		var typeErr *yaml.TypeError
+		if err := applyPreset(data, name, merged); err != nil && !errors.As(err, &typeErr) {
+			continue
+		}
+		for _, d := range checkValues(merged, lines) {
+			if containsDiagnostic(diags, d) {
+				continue
+			}
+			if d.Key != "" {
+				d.Key = "profiles." + name + "." + d.Key
+				d.Line = lineOf(lines, d.Key)
+			}
+			d.Message = fmt.Sprintf("with profile %s: %s", name, d.Message)
+			diags = append(diags, d)
+		}
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		return diags[i].Key < diags[j].Key
+	})
+	return diags, nil
+}
+
+// HasErrors reports whether diags would keep the file from loading or
+// hold keys clai ignores.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError || d.Severity == SeverityUnknown {
+			return true
+		}
+	}
+	return false
+}
+
+// checkValues reports the hard validation error and the suggestions
+// values ValidateAndFix would replace.
+func checkValues(cfg *Config, lines map[string]int) []Diagnostic {
+	var diags []Diagnostic
+	for _, w := range cfg.Suggestions.validateAndFix() {
+		key := "suggestions." + w.Field
+		diags = append(diags, Diagnostic{Severity: SeverityWarning, Key: key, Message: w.Message, Line: lineOf(lines, key)})
+	}
+	if err := cfg.Validate(); err != nil {
+		key := leadingKey(err.Error())
+		diags = append(diags, Diagnostic{Severity: SeverityError, Key: key, Message: err.Error(), Line: lineOf(lines, key)})
+	}
+	return diags
+}
+
+// checkNode reports the unknown and deprecated keys under n, decoded into
+// t, and records the line of every key below prefix.
+func checkNode(n *yaml.Node, t reflect.Type, prefix string, lines map[string]int) []Diagnostic {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var diags []Diagnostic
+	switch {
+	case t == reflect.TypeOf(yaml.Node{}):
+		// Profile presets are partial configs.
+		if strings.HasPrefix(prefix, "profiles.") {
+			diags = append(diags, checkNode(n, reflect.TypeOf(Config{}), prefix, lines)...)
+		}
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			key := joinKey(prefix, k.Value)
+			lines[key] = k.Line
+			ft, ok := fields[k.Value]
+			if !ok {
+				diags = append(diags, Diagnostic{Severity: SeverityUnknown, Key: key, Message: unknownKeyMessage(k.Value, fields), Line: k.Line})
+				continue
+			}
+			if hint, ok := deprecatedKeys[strings.TrimPrefix(key, profilePrefix(key))]; ok {
+				diags = append(diags, Diagnostic{Severity: SeverityDeprecated, Key: key, Message: hint, Line: k.Line})
+			}
+			diags = append(diags, checkNode(v, ft, key, lines)...)
+		}
+	case t.Kind() == reflect.Map && n.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := joinKey(prefix, n.Content[i].Value)
+			lines[key] = n.Content[i].Line
+			diags = append(diags, checkNode(n.Content[i+1], t.Elem(), key, lines)...)
+		}
+	case t.Kind() == reflect.Slice && n.Kind == yaml.SequenceNode:
+		for i, item := range n.Content {
+			key := prefix + "[" + strconv.Itoa(i) + "]"
+			lines[key] = item.Line
+			diags = append(diags, checkNode(item, t.Elem(), key, lines)...)
+		}
+	}
+	return diags
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// yamlFields returns the yaml key -> type of the fields of struct t.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// unknownKeyMessage names the closest known key, if one is close enough
+// to be a typo.
+func unknownKeyMessage(key string, fields map[string]reflect.Type) string {
+	best, bestDist := "", len(key)/2+1
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDist || d == bestDist && name < best {
+			best, bestDist = name, d
+		}
+	}
+	if best == "" {
+		return "unknown key; it is ignored"
+	}
+	return fmt.Sprintf("unknown key; it is ignored (did you mean %q?)", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+var typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// typeErrorDiagnostics turns the errors of yaml.Unmarshal into
+// diagnostics.
+func typeErrorDiagnostics(err error) []Diagnostic {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return []Diagnostic{{Severity: SeverityError, Message: err.Error()}}
+	}
+	diags := make([]Diagnostic, 0, len(typeErr.Errors))
+	for _, msg := range typeErr.Errors {
+		d := Diagnostic{Severity: SeverityError, Message: msg}
+		if m := typeErrorLine.FindStringSubmatch(msg); m != nil {
+			d.Line, _ = strconv.Atoi(m[1])
+			d.Message = m[2]
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// leadingKey returns the dotted key a Validate error starts with.
+func leadingKey(msg string) string {
+	key, _, _ := strings.Cut(msg, " ")
+	if !strings.Contains(key, ".") {
+		return ""
+	}
+	return strings.TrimRight(key, ":,")
+}
+
+func lineOf(lines map[string]int, key string) int {
+	if line, ok := lines[key]; ok {
+		return line
+	}
+	// Fall back to the nearest parent that appears in the file.
+	for i := strings.LastIndexAny(key, ".["); i > 0; i = strings.LastIndexAny(key, ".[") {
+		key = key[:i]
+		if line, ok := lines[key]; ok {
+			return line
+		}
+	}
+	return 0
+}
+
+// keyAt returns the innermost key on line, or "" when there is none.
+func keyAt(lines map[string]int, line int) string {
+	best := ""
+	for key, l := range lines {
+		if l == line && len(key) > len(best) {
+			best = key
+		}
+	}
+	return best
+}
+
+// profilePrefix returns the "profiles.<name>." prefix of key, if any.
+func profilePrefix(key string) string {
+	if !strings.HasPrefix(key, "profiles.") {
+		return ""
+	}
+	rest := strings.TrimPrefix(key, "profiles.")
+	name, _, ok := strings.Cut(rest, ".")
+	if !ok {
+		return ""
+	}
+	return "profiles." + name + "."
+}
+
+func containsDiagnostic(diags []Diagnostic, d Diagnostic) bool {
+	for _, have := range diags {
+		if have.Key == d.Key && have.Message == d.Message {
+			return true
+		}
+	}
+	return false
+}
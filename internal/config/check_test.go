@@ -0,0 +1,89 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func findDiagnostic(diags []Diagnostic, key string) (Diagnostic, bool) {
+	for _, d := range diags {
+		if d.Key == key {
+			return d, true
+		}
+	}
+	return Diagnostic{}, false
+}
+
+func TestCheckFile(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), `daemon:
+  idle_timeout_mins: 10
+suggestions:
+  max_ai: 3
+  weigths:
+    task: 0.3
+  weights:
+    task: 2
+  max_results: lots
+profiles:
+  work:
+    ai:
+      enabeld: false
+    suggestions:
+      hard_timeout_ms: -1
+`)
+
+	diags, err := CheckFile(path)
+	if err != nil {
+		t.Fatalf("CheckFile() error = %v", err)
+	}
+	if !HasErrors(diags) {
+		t.Error("HasErrors() = false, want true")
+	}
+
+	tests := []struct {
+		key      string
+		severity string
+		line     int
+		contains string
+	}{
+		{"suggestions.max_ai", SeverityDeprecated, 4, "remove it"},
+		{"suggestions.weigths", SeverityUnknown, 5, `did you mean "weights"`},
+		{"suggestions.weights.task", SeverityWarning, 8, "clamping to 1.0"},
+		{"suggestions.max_results", SeverityError, 9, "lots"},
+		{"profiles.work.ai.enabeld", SeverityUnknown, 13, `did you mean "enabled"`},
+		{"profiles.work.suggestions.hard_timeout_ms", SeverityWarning, 15, "with profile work"},
+	}
+	for _, tt := range tests {
+		d, ok := findDiagnostic(diags, tt.key)
+		if !ok {
+			t.Errorf("no diagnostic for %s in %+v", tt.key, diags)
+			continue
+		}
+		if d.Severity != tt.severity || d.Line != tt.line || !strings.Contains(d.Message, tt.contains) {
+			t.Errorf("%s = %+v, want severity %s, line %d, message containing %q", tt.key, d, tt.severity, tt.line, tt.contains)
+		}
+	}
+	if _, ok := findDiagnostic(diags, "daemon.idle_timeout_mins"); ok {
+		t.Error("known key daemon.idle_timeout_mins reported")
+	}
+}
+
+func TestCheckFile_Valid(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), presetConfig)
+
+	diags, err := CheckFile(path)
+	if err != nil {
+		t.Fatalf("CheckFile() error = %v", err)
+	}
+	// presetConfig sets the deprecated suggestions.max_history.
+	if len(diags) != 1 || diags[0].Severity != SeverityDeprecated || HasErrors(diags) {
+		t.Errorf("CheckFile() = %+v, want only the max_history deprecation", diags)
+	}
+}
+
+func TestCheckFile_NotYAML(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "daemon: [")
+	if _, err := CheckFile(path); err == nil {
+		t.Error("CheckFile() error = nil, want parse error")
+	}
+}
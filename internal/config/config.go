@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -23,22 +24,40 @@ type Config struct {
 	Suggestions SuggestionsConfig `yaml:"suggestions"`
 	Client      ClientConfig      `yaml:"client"`
 	Privacy     PrivacyConfig     `yaml:"privacy"`
+	Paths       PathsConfig       `yaml:"paths"`
+	// Profiles holds named partial configs merged over the rest when
+	// CLAI_PROFILE selects them. See applyPreset.
+	Profiles map[string]yaml.Node `yaml:"profiles,omitempty" env:"-"`
 }
 
 // DaemonConfig holds daemon-related settings.
 type DaemonConfig struct {
 	SocketPath      string `yaml:"socket_path"`
+	SocketMode      string `yaml:"socket_mode"`  // Octal file mode of the socket, e.g. "0600"
+	SocketGroup     string `yaml:"socket_group"` // Group name or GID to own the socket ("" = unchanged)
 	LogLevel        string `yaml:"log_level"`
-	LogFile         string `yaml:"log_file"`
+	LogFile         string `yaml:"log_file"`   // "-" logs to stderr
+	LogFormat       string `yaml:"log_format"` // "text" or "json"
 	IdleTimeoutMins int    `yaml:"idle_timeout_mins"`
+	LogMaxSizeMB    int    `yaml:"log_max_size_mb"`  // Rotate when the log file exceeds this size
+	LogMaxAgeDays   int    `yaml:"log_max_age_days"` // Delete rotated logs older than this (0 = keep)
+	LogMaxBackups   int    `yaml:"log_max_backups"`  // Rotated logs to keep (0 = keep all)
+	SlowRPCMs       int    `yaml:"slow_rpc_ms"`      // Log RPCs slower than this (0 = off)
+
+	// Per-session limits on expensive RPCs (0 = unlimited)
+	RateLimitAIPerMin      int `yaml:"rate_limit_ai_per_min"`      // TextToCommand and Diagnose, each
+	RateLimitImportPerHour int `yaml:"rate_limit_import_per_hour"` // ImportHistory
+
+	DebugReflection bool `yaml:"debug_reflection"` // Serve gRPC reflection for grpcurl and similar tools
 }
 
 // ClientConfig holds client-related settings.
 type ClientConfig struct {
-	SuggestTimeoutMs int  `yaml:"suggest_timeout_ms"` // Max wait for suggestions
-	ConnectTimeoutMs int  `yaml:"connect_timeout_ms"` // Socket connection timeout
-	FireAndForget    bool `yaml:"fire_and_forget"`    // Don't wait for logging acks
-	AutoStartDaemon  bool `yaml:"auto_start_daemon"`  // Auto-start daemon if not running
+	Keybindings      KeybindingsConfig `yaml:"keybindings"`        // Shell key bindings installed by clai init
+	SuggestTimeoutMs int               `yaml:"suggest_timeout_ms"` // Max wait for suggestions
+	ConnectTimeoutMs int               `yaml:"connect_timeout_ms"` // Socket connection timeout
+	FireAndForget    bool              `yaml:"fire_and_forget"`    // Don't wait for logging acks
+	AutoStartDaemon  bool              `yaml:"auto_start_daemon"`  // Auto-start daemon if not running
 }
 
 // AIConfig holds AI-related settings.
@@ -50,6 +69,67 @@ type AIConfig struct {
 	AutoDiagnose  bool   `yaml:"auto_diagnose"`
 }
 
+// RetentionRule overrides suggestions.retention_days for the command events
+// it matches: all set criteria must hold, and the first matching rule wins.
+type RetentionRule struct {
+	Repo      string `yaml:"repo,omitempty"`      // Repository root (repo_key)
+	Ephemeral bool   `yaml:"ephemeral,omitempty"` // Events from incognito sessions
+	Failed    bool   `yaml:"failed,omitempty"`    // Events with a non-zero exit code
+	Days      int    `yaml:"days"`                // Days to keep matching events; 0 keeps them forever
+}
+
+// RiskPattern flags commands as destructive, or safe, in addition to the
+// built-in patterns. A pattern with only the name of a built-in pattern
+// adds to that pattern's allowlist and message.
+type RiskPattern struct {
+	Name    string   `yaml:"name,omitempty"`
+	Pattern string   `yaml:"pattern,omitempty"` // Regular expression matched against the command
+	Level   string   `yaml:"level,omitempty"`   // destructive (default) or safe
+	Message string   `yaml:"message,omitempty"` // Shown with the warning
+	Allow   []string `yaml:"allow,omitempty"`   // Regular expressions exempting matching commands
+}
+
+// ProjectType defines a project type in addition to the built-in ones. It
+// is detected like them, so it counts toward learned weights and project
+// type statistics, and its common commands seed discovery.
+type ProjectType struct {
+	Name           string   `yaml:"name"`
+	Markers        []string `yaml:"markers"`                   // File names or globs; a trailing / marks a directory
+	CommonCommands []string `yaml:"common_commands,omitempty"` // Suggested in projects of this type without history
+}
+
+// SessionRecencyBoost favors commands run in the last minutes of the
+// current session over what the history decay alone would rank.
+type SessionRecencyBoost struct {
+	WindowMinutes int     `yaml:"window_minutes"` // How long a command stays boosted; 0 disables
+	Strength      float64 `yaml:"strength"`       // Rank boost for a command run just now, fading to 0 over the window
+}
+
+// IngestFilters drops noise before commands are stored, in the spirit of
+// bash's HISTIGNORE and HISTCONTROL.
+type IngestFilters struct {
+	IgnorePatterns      []string `yaml:"ignore_patterns"`       // Globs matched against the whole command, as in HISTIGNORE ("ls", "cd *")
+	IgnoreSpacePrefixed bool     `yaml:"ignore_space_prefixed"` // Skip commands typed with a leading space
+	IgnoreSingleChar    bool     `yaml:"ignore_single_char"`    // Skip one-character commands
+	CollapseDuplicates  bool     `yaml:"collapse_duplicates"`   // Skip a command repeating the session's previous one
+}
+
+// LatencyBudget bounds the latency the shell integration may add. When a
+// session's p95 ingest or suggest latency, as measured by its clients,
+// exceeds its budget, the daemon serves the session fire-and-forget only.
+type LatencyBudget struct {
+	IngestP95Ms     int `yaml:"ingest_p95_ms"`    // Budget for logging a command; 0 disables
+	SuggestP95Ms    int `yaml:"suggest_p95_ms"`   // Budget for fetching suggestions; 0 disables
+	CooldownMinutes int `yaml:"cooldown_minutes"` // How long a session stays fire-and-forget only
+}
+
+// ConfirmRisky configures the shell's gate asking for confirmation before
+// running a command the risk classifier flags as destructive.
+type ConfirmRisky struct {
+	Enabled bool     `yaml:"enabled"`
+	Allow   []string `yaml:"allow"` // Names of risk patterns that never ask ("git force push")
+}
+
 // SuggestionsWeights holds ranking weight configuration.
 type SuggestionsWeights struct {
 	Transition          float64 `yaml:"transition"`            // Transition probability weight
@@ -62,6 +142,8 @@ type SuggestionsWeights struct {
 	RiskPenalty         float64 `yaml:"risk_penalty"`          // Risk penalty weight
 	ProjectTypeAffinity float64 `yaml:"project_type_affinity"` // Project type affinity weight
 	FailureRecovery     float64 `yaml:"failure_recovery"`      // Failure recovery weight
+	TimeOfDay           float64 `yaml:"time_of_day"`           // Hour-of-day usage weight
+	DayOfWeek           float64 `yaml:"day_of_week"`           // Day-of-week usage weight
 }
 
 // SuggestionsConfig holds suggestion-related settings.
@@ -69,22 +151,31 @@ type SuggestionsConfig struct {
 	SocketPath                      string             `yaml:"socket_path"`
 	IncognitoMode                   string             `yaml:"incognito_mode"`
 	ScorerVersion                   string             `yaml:"scorer_version"`
+	Exploration                     string             `yaml:"exploration"` // Ranking exploration: off, epsilon or thompson
 	SearchTagVocabularyPath         string             `yaml:"search_tag_vocabulary_path"`
 	SearchFTSTokenizer              string             `yaml:"search_fts_tokenizer"`
 	TaskPlaybookPath                string             `yaml:"task_playbook_path"`
 	PickerView                      string             `yaml:"picker_view"`
 	ShimMode                        string             `yaml:"shim_mode"`
 	Weights                         SuggestionsWeights `yaml:"weights"`
+	WeightsPreset                   string             `yaml:"weights_preset"` // Named weights bundle: default, conservative, exploratory or prefix-heavy
+	weightsSet                      map[string]bool    // Keys of the weights the config sets, which WeightsPreset leaves alone
 	DismissalLearnedHalflifeHrs     int                `yaml:"dismissal_learned_halflife_hours"`
 	FailureRecoveryMinCount         int                `yaml:"failure_recovery_min_count"`
 	IngestSyncWaitMs                int                `yaml:"ingest_sync_wait_ms"`
 	MaxAI                           int                `yaml:"max_ai"`
 	CmdRawMaxBytes                  int                `yaml:"cmd_raw_max_bytes"`
+	CmdRawMaxBytesByShell           map[string]int     `yaml:"cmd_raw_max_bytes_by_shell"`
+	RedactPatterns                  []string           `yaml:"redact_patterns"`  // Extra regexes redacted from stored commands
+	RiskPatterns                    []RiskPattern      `yaml:"risk_patterns"`    // Extra destructive-command patterns
+	OutputMaxBytes                  int                `yaml:"output_max_bytes"` // Longest stdout/stderr tail stored per stream
 	HookConnectTimeoutMs            int                `yaml:"hook_connect_timeout_ms"`
 	HardTimeoutMs                   int                `yaml:"hard_timeout_ms"`
+	InlineTimeoutMs                 int                `yaml:"inline_timeout_ms"`
 	DecayHalfLifeHours              int                `yaml:"decay_half_life_hours"`
 	FeedbackBoostAccept             float64            `yaml:"feedback_boost_accept"`
 	FeedbackPenaltyDismiss          float64            `yaml:"feedback_penalty_dismiss"`
+	ExplorationRate                 float64            `yaml:"exploration_rate"` // Share of suggestion requests that may explore
 	SlotMaxValuesPerSlot            int                `yaml:"slot_max_values_per_slot"`
 	FeedbackMatchWindowMs           int                `yaml:"feedback_match_window_ms"`
 	CacheMemoryBudgetMB             int                `yaml:"cache_memory_budget_mb"`
@@ -111,6 +202,10 @@ type SuggestionsConfig struct {
 	MaintenanceIntervalMs           int                `yaml:"maintenance_interval_ms"`
 	RetentionMaxEvents              int                `yaml:"retention_max_events"`
 	RetentionDays                   int                `yaml:"retention_days"`
+	RetentionRules                  []RetentionRule    `yaml:"retention_rules"`    // Per-scope overrides of retention_days
+	EphemeralDirs                   []string           `yaml:"ephemeral_dirs"`     // Directories whose commands are never persisted
+	ArchiveAfterDays                int                `yaml:"archive_after_days"` // Compress cmd_raw of older events; 0 disables
+	MaxDBSizeMB                     int                `yaml:"max_db_size_mb"`     // Evict rarely used templates above this size; 0 disables
 	DiscoveryMaxConfidenceThreshold float64            `yaml:"discovery_max_confidence_threshold"`
 	ProjectTypeCacheTTLMs           int                `yaml:"project_type_cache_ttl_ms"`
 	DiscoveryCooldownHours          int                `yaml:"discovery_cooldown_hours"`
@@ -132,6 +227,7 @@ type SuggestionsConfig struct {
 	TypingPauseThresholdMs          int                `yaml:"typing_pause_threshold_ms"`
 	TypingEagerPrefixLength         int                `yaml:"typing_eager_prefix_length"`
 	DirectoryScopeMaxDepth          int                `yaml:"directory_scope_max_depth"`
+	DirectoryAffinityWeights        []float64          `yaml:"directory_affinity_weights"` // Weight of cwd's directory signals, then its parent's, ...
 	AliasMaxExpansionDepth          int                `yaml:"alias_max_expansion_depth"`
 	DismissalTemporaryHalflifeMs    int                `yaml:"dismissal_temporary_halflife_ms"`
 	DismissalLearnedThreshold       int                `yaml:"dismissal_learned_threshold"`
@@ -160,19 +256,49 @@ type SuggestionsConfig struct {
 	OnlineLearningEnabled           bool               `yaml:"online_learning_enabled"`
 	InteractiveRequireTTY           bool               `yaml:"interactive_require_tty"`
 	RedactSensitiveTokens           bool               `yaml:"redact_sensitive_tokens"`
+	CaptureOutput                   bool               `yaml:"capture_output"` // Store output tails clients send with commands
+	ShadowScoring                   bool               `yaml:"shadow_scoring"` // Run both scorers on every request and compare them
+
+	ColdStartSeeding bool `yaml:"cold_start_seeding"` // Seed curated commands while history is nearly empty
+
+	SessionRecencyBoost SessionRecencyBoost `yaml:"session_recency_boost"`
+
+	IngestFilters IngestFilters `yaml:"ingest_filters"`
+
+	ConfirmRisky ConfirmRisky `yaml:"confirm_risky"`
+
+	LatencyBudget LatencyBudget `yaml:"latency_budget"`
+
+	ProjectTypes []ProjectType `yaml:"project_types"` // Custom project types; read at daemon start
 }
 
 // PrivacyConfig holds privacy-related settings.
 type PrivacyConfig struct {
-	SanitizeAICalls bool `yaml:"sanitize_ai_calls"` // Apply regex sanitization before AI calls
+	DBEncryption    string `yaml:"db_encryption"`     // Encrypt history databases at rest: off, keychain or file
+	DBKeyFile       string `yaml:"db_key_file"`       // Key file for db_encryption=file ("" = ~/.clai/db.key)
+	SanitizeAICalls bool   `yaml:"sanitize_ai_calls"` // Apply regex sanitization before AI calls
+}
+
+// PathsConfig relocates clai's databases and caches, for example off a
+// network-mounted home directory. Empty keeps the default location; see
+// DefaultPaths. clai writes its files directly into each directory, so a
+// dedicated one is best, but a shared one works: uninstall --purge removes
+// only the files clai created there. Profiles get a profiles/<name>
+// directory below each.
+type PathsConfig struct {
+	DataDir  string `yaml:"data_dir" env:"CLAI_DATA_DIR"`   // Databases and the database key
+	CacheDir string `yaml:"cache_dir" env:"CLAI_CACHE_DIR"` // Spools, picker and suggestion caches
 }
 
-// TabDef defines a tab in the history picker.
+// TabDef defines a tab in the history picker. Provider "history" (the
+// default) lists history as Args select it; "command" lists the lines
+// printed by Command, run with sh -c in the shell's working directory.
 type TabDef struct {
 	Args     map[string]string `yaml:"args"`
 	ID       string            `yaml:"id"`
 	Label    string            `yaml:"label"`
 	Provider string            `yaml:"provider"`
+	Command  string            `yaml:"command,omitempty"`
 }
 
 // WorkflowsConfig holds workflow execution settings.
@@ -205,10 +331,26 @@ func DefaultConfig() *Config {
 		Daemon: DaemonConfig{
 			IdleTimeoutMins: 0,  // Never timeout - daemon runs until shell exits
 			SocketPath:      "", // Use default from paths
+			SocketMode:      "0600",
+			SocketGroup:     "",
 			LogLevel:        "info",
 			LogFile:         "", // Use default from paths
+			LogFormat:       "text",
+			LogMaxSizeMB:    10,
+			LogMaxAgeDays:   14,
+			LogMaxBackups:   3,
+			SlowRPCMs:       100,
+
+			RateLimitAIPerMin:      20,
+			RateLimitImportPerHour: 10,
+
+			DebugReflection: false,
 		},
 		Client: ClientConfig{
+			Keybindings: KeybindingsConfig{
+				HistoryPicker: DefaultHistoryPickerKey,
+				SuggestWidget: DefaultSuggestWidgetKey,
+			},
 			SuggestTimeoutMs: 50,
 			ConnectTimeoutMs: 10,
 			FireAndForget:    true,
@@ -223,6 +365,7 @@ func DefaultConfig() *Config {
 		},
 		Suggestions: DefaultSuggestionsConfig(),
 		Privacy: PrivacyConfig{
+			DBEncryption:    "off",
 			SanitizeAICalls: true,
 		},
 		Workflows: WorkflowsConfig{
@@ -264,6 +407,9 @@ func DefaultSuggestionsConfig() SuggestionsConfig {
 		CacheTTLMs:    30000,
 		HardTimeoutMs: 150,
 
+		// Ghost text
+		InlineTimeoutMs: 50,
+
 		// UI
 		PickerView: "detailed",
 
@@ -274,9 +420,12 @@ func DefaultSuggestionsConfig() SuggestionsConfig {
 		IngestSyncWaitMs:      5,
 		InteractiveRequireTTY: true,
 		CmdRawMaxBytes:        16384,
+		OutputMaxBytes:        4096,
 		ShimMode:              "auto",
+		LatencyBudget:         LatencyBudget{IngestP95Ms: 500, SuggestP95Ms: 250, CooldownMinutes: 10},
 
 		// Ranking weights
+		WeightsPreset: "default",
 		Weights: SuggestionsWeights{
 			Transition:          0.30,
 			Frequency:           0.20,
@@ -288,6 +437,8 @@ func DefaultSuggestionsConfig() SuggestionsConfig {
 			RiskPenalty:         0.20,
 			ProjectTypeAffinity: 0.08,
 			FailureRecovery:     0.12,
+			TimeOfDay:           0.10,
+			DayOfWeek:           0.08,
 		},
 
 		// Learning
@@ -306,6 +457,9 @@ func DefaultSuggestionsConfig() SuggestionsConfig {
 		WeightRiskMin:               0.10,
 		WeightRiskMax:               0.60,
 		SlotCorrelationMinConf:      0.65,
+		Exploration:                 "off",
+		ExplorationRate:             0.10,
+		SessionRecencyBoost:         SessionRecencyBoost{WindowMinutes: 15, Strength: 2.0},
 
 		// Backpressure
 		BurstEventsThreshold: 10,
@@ -330,6 +484,7 @@ func DefaultSuggestionsConfig() SuggestionsConfig {
 		// Project type
 		ProjectTypeDetectionEnabled: true,
 		ProjectTypeCacheTTLMs:       60000,
+		ColdStartSeeding:            true,
 
 		// Pipeline
 		PipelineAwarenessEnabled: true,
@@ -368,8 +523,9 @@ func DefaultSuggestionsConfig() SuggestionsConfig {
 		DismissalTemporaryHalflifeMs: 1800000,
 
 		// Directory scope
-		DirectoryScopingEnabled: true,
-		DirectoryScopeMaxDepth:  3,
+		DirectoryScopingEnabled:  true,
+		DirectoryScopeMaxDepth:   3,
+		DirectoryAffinityWeights: []float64{1.0, 0.5, 0.25},
 
 		// Scorer version
 		ScorerVersion: "v2",
@@ -394,6 +550,8 @@ func DefaultSuggestionsConfig() SuggestionsConfig {
 
 		// Storage
 		RetentionDays:                90,
+		ArchiveAfterDays:             0,
+		MaxDBSizeMB:                  0,
 		RetentionMaxEvents:           500000,
 		MaintenanceIntervalMs:        300000,
 		MaintenanceVacuumThresholdMB: 100,
@@ -405,6 +563,7 @@ func DefaultSuggestionsConfig() SuggestionsConfig {
 		// Privacy
 		IncognitoMode:         "ephemeral",
 		RedactSensitiveTokens: true,
+		CaptureOutput:         false, // Must opt-in
 	}
 }
 
@@ -416,7 +575,8 @@ func Load() (*Config, error) {
 
 // LoadFromFile loads configuration from the specified file.
 // If the file doesn't exist, returns default configuration.
-// Environment variable overrides are applied after file loading.
+// The active profile's preset is merged over the file, then environment
+// variable overrides are applied, then suggestions.weights_preset.
 func LoadFromFile(path string) (*Config, error) {
 	cfg := DefaultConfig()
 
@@ -427,20 +587,36 @@ func LoadFromFile(path string) (*Config, error) {
 			if err = cfg.Validate(); err != nil {
 				return nil, fmt.Errorf("invalid config from environment: %w", err)
 			}
+			cfg.Suggestions.applyWeightsPreset()
 			return cfg, nil // Return defaults if file doesn't exist
 		}
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	if err = yaml.Unmarshal(data, cfg); err != nil {
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if err = doc.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err = cfg.validatePresets(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err = applyPreset(data, Profile(), cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	cfg.Suggestions.markWeightsSet(&doc)
+	if preset, ok := cfg.Profiles[Profile()]; ok {
+		cfg.Suggestions.markWeightsSet(&preset)
+	}
 
 	cfg.ApplyEnvOverrides()
 
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
+	cfg.Suggestions.applyWeightsPreset()
 
 	return cfg, nil
 }
@@ -459,7 +635,12 @@ func (c *Config) SaveToFile(path string) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(c)
+	var doc yaml.Node
+	if err := doc.Encode(c); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	c.Suggestions.omitUnsetWeights(&doc)
+	data, err := yaml.Marshal(&doc)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -472,62 +653,81 @@ func (c *Config) SaveToFile(path string) error {
 }
 
 // Get retrieves a configuration value by dot-separated key.
-// For example: "daemon.idle_timeout_mins" or "ai.enabled"
+// For example: "daemon.idle_timeout_mins" or "ai.enabled". Deeper keys
+// and list indices ("history.picker_tabs[1].label") are resolved by
+// getPath; lists and sections are returned as flow YAML.
 func (c *Config) Get(key string) (string, error) {
-	parts := strings.Split(key, ".")
+	parts := strings.SplitN(key, ".", 2)
 	if len(parts) != 2 {
 		return "", errors.New("key must be in format 'section.key'")
 	}
 
 	section, field := parts[0], parts[1]
 
+	var value string
+	var err error
 	switch section {
 	case "daemon":
-		return c.getDaemonField(field)
+		value, err = c.getDaemonField(field)
 	case "client":
-		return c.getClientField(field)
+		value, err = c.getClientField(field)
 	case "ai":
-		return c.getAIField(field)
+		value, err = c.getAIField(field)
 	case "suggestions":
-		return c.getSuggestionsField(field)
+		value, err = c.getSuggestionsField(field)
 	case "privacy":
-		return c.getPrivacyField(field)
+		value, err = c.getPrivacyField(field)
 	case "history":
-		return c.getHistoryField(field)
+		value, err = c.getHistoryField(field)
 	case "workflows":
-		return c.getWorkflowsField(field)
+		value, err = c.getWorkflowsField(field)
 	default:
-		return "", fmt.Errorf("unknown section: %s", section)
+		err = errUnknownField
+	}
+	if errors.Is(err, errUnknownField) {
+		return c.getPath(key)
 	}
+	return value, err
 }
 
-// Set sets a configuration value by dot-separated key.
+// Set sets a configuration value by dot-separated key. Keys without a
+// dedicated setter are resolved by setPath, which parses value as YAML
+// for non-string settings ("0.3", "[a, b]") and rejects values that
+// Validate or ValidateAndFix would not accept.
 func (c *Config) Set(key, value string) error {
-	parts := strings.Split(key, ".")
+	parts := strings.SplitN(key, ".", 2)
 	if len(parts) != 2 {
 		return errors.New("key must be in format 'section.key'")
 	}
 
 	section, field := parts[0], parts[1]
 
+	var err error
 	switch section {
 	case "daemon":
-		return c.setDaemonField(field, value)
+		err = c.setDaemonField(field, value)
 	case "client":
-		return c.setClientField(field, value)
+		err = c.setClientField(field, value)
 	case "ai":
-		return c.setAIField(field, value)
+		err = c.setAIField(field, value)
 	case "suggestions":
-		return c.setSuggestionsField(field, value)
+		err = c.setSuggestionsField(field, value)
 	case "privacy":
-		return c.setPrivacyField(field, value)
+		err = c.setPrivacyField(field, value)
 	case "history":
-		return c.setHistoryField(field, value)
+		err = c.setHistoryField(field, value)
 	case "workflows":
-		return c.setWorkflowsField(field, value)
+		err = c.setWorkflowsField(field, value)
 	default:
-		return fmt.Errorf("unknown section: %s", section)
+		err = errUnknownField
+	}
+	if errors.Is(err, errUnknownField) {
+		err = c.setPath(key, value)
 	}
+	if weight, ok := strings.CutPrefix(key, "suggestions.weights."); ok && err == nil {
+		c.Suggestions.markWeightSet(weight)
+	}
+	return err
 }
 
 func (c *Config) getDaemonField(field string) (string, error) {
@@ -536,12 +736,32 @@ func (c *Config) getDaemonField(field string) (string, error) {
 		return strconv.Itoa(c.Daemon.IdleTimeoutMins), nil
 	case "socket_path":
 		return c.Daemon.SocketPath, nil
+	case "socket_mode":
+		return c.Daemon.SocketMode, nil
+	case "socket_group":
+		return c.Daemon.SocketGroup, nil
 	case "log_level":
 		return c.Daemon.LogLevel, nil
 	case "log_file":
 		return c.Daemon.LogFile, nil
+	case "log_format":
+		return c.Daemon.LogFormat, nil
+	case "log_max_size_mb":
+		return strconv.Itoa(c.Daemon.LogMaxSizeMB), nil
+	case "log_max_age_days":
+		return strconv.Itoa(c.Daemon.LogMaxAgeDays), nil
+	case "log_max_backups":
+		return strconv.Itoa(c.Daemon.LogMaxBackups), nil
+	case "slow_rpc_ms":
+		return strconv.Itoa(c.Daemon.SlowRPCMs), nil
+	case "rate_limit_ai_per_min":
+		return strconv.Itoa(c.Daemon.RateLimitAIPerMin), nil
+	case "rate_limit_import_per_hour":
+		return strconv.Itoa(c.Daemon.RateLimitImportPerHour), nil
+	case "debug_reflection":
+		return strconv.FormatBool(c.Daemon.DebugReflection), nil
 	default:
-		return "", fmt.Errorf("unknown field: daemon.%s", field)
+		return "", fmt.Errorf("%w: daemon.%s", errUnknownField, field)
 	}
 }
 
@@ -558,6 +778,13 @@ func (c *Config) setDaemonField(field, value string) error {
 		c.Daemon.IdleTimeoutMins = v
 	case "socket_path":
 		c.Daemon.SocketPath = value
+	case "socket_mode":
+		if _, err := ParseSocketMode(value); err != nil {
+			return err
+		}
+		c.Daemon.SocketMode = value
+	case "socket_group":
+		c.Daemon.SocketGroup = value
 	case "log_level":
 		if !isValidLogLevel(value) {
 			return fmt.Errorf("invalid log_level: %s (must be debug, info, warn, or error)", value)
@@ -565,8 +792,47 @@ func (c *Config) setDaemonField(field, value string) error {
 		c.Daemon.LogLevel = value
 	case "log_file":
 		c.Daemon.LogFile = value
+	case "log_format":
+		if !isValidLogFormat(value) {
+			return fmt.Errorf("invalid log_format: %s (must be text or json)", value)
+		}
+		c.Daemon.LogFormat = value
+	case "log_max_size_mb", "log_max_age_days", "log_max_backups", "slow_rpc_ms",
+		"rate_limit_ai_per_min", "rate_limit_import_per_hour":
+		return c.setDaemonNonNegativeInt(field, value)
+	case "debug_reflection":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for debug_reflection: %w", err)
+		}
+		c.Daemon.DebugReflection = v
 	default:
-		return fmt.Errorf("unknown field: daemon.%s", field)
+		return fmt.Errorf("%w: daemon.%s", errUnknownField, field)
+	}
+	return nil
+}
+
+func (c *Config) setDaemonNonNegativeInt(field, value string) error {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", field, err)
+	}
+	if v < 0 {
+		return fmt.Errorf("invalid %s: must be non-negative", field)
+	}
+	switch field {
+	case "log_max_size_mb":
+		c.Daemon.LogMaxSizeMB = v
+	case "log_max_age_days":
+		c.Daemon.LogMaxAgeDays = v
+	case "log_max_backups":
+		c.Daemon.LogMaxBackups = v
+	case "slow_rpc_ms":
+		c.Daemon.SlowRPCMs = v
+	case "rate_limit_ai_per_min":
+		c.Daemon.RateLimitAIPerMin = v
+	case "rate_limit_import_per_hour":
+		c.Daemon.RateLimitImportPerHour = v
 	}
 	return nil
 }
@@ -581,8 +847,12 @@ func (c *Config) getClientField(field string) (string, error) {
 		return strconv.FormatBool(c.Client.FireAndForget), nil
 	case "auto_start_daemon":
 		return strconv.FormatBool(c.Client.AutoStartDaemon), nil
+	case "keybindings.history_picker":
+		return c.Client.Keybindings.HistoryPicker, nil
+	case "keybindings.suggest_widget":
+		return c.Client.Keybindings.SuggestWidget, nil
 	default:
-		return "", fmt.Errorf("unknown field: client.%s", field)
+		return "", fmt.Errorf("%w: client.%s", errUnknownField, field)
 	}
 }
 
@@ -618,8 +888,19 @@ func (c *Config) setClientField(field, value string) error {
 			return fmt.Errorf("invalid value for auto_start_daemon: %w", err)
 		}
 		c.Client.AutoStartDaemon = v
+	case "keybindings.history_picker", "keybindings.suggest_widget":
+		if value != "" {
+			if _, err := ParseKeySpec(value); err != nil {
+				return err
+			}
+		}
+		if field == "keybindings.history_picker" {
+			c.Client.Keybindings.HistoryPicker = value
+		} else {
+			c.Client.Keybindings.SuggestWidget = value
+		}
 	default:
-		return fmt.Errorf("unknown field: client.%s", field)
+		return fmt.Errorf("%w: client.%s", errUnknownField, field)
 	}
 	return nil
 }
@@ -637,7 +918,7 @@ func (c *Config) getAIField(field string) (string, error) {
 	case "cache_ttl_hours":
 		return strconv.Itoa(c.AI.CacheTTLHours), nil
 	default:
-		return "", fmt.Errorf("unknown field: ai.%s", field)
+		return "", fmt.Errorf("%w: ai.%s", errUnknownField, field)
 	}
 }
 
@@ -672,7 +953,7 @@ func (c *Config) setAIField(field, value string) error {
 		}
 		c.AI.CacheTTLHours = v
 	default:
-		return fmt.Errorf("unknown field: ai.%s", field)
+		return fmt.Errorf("%w: ai.%s", errUnknownField, field)
 	}
 	return nil
 }
@@ -692,7 +973,7 @@ func (c *Config) getSuggestionsField(field string) (string, error) {
 	case "picker_view":
 		return c.Suggestions.PickerView, nil
 	default:
-		return "", fmt.Errorf("unknown field: suggestions.%s", field)
+		return "", fmt.Errorf("%w: suggestions.%s", errUnknownField, field)
 	}
 }
 
@@ -711,7 +992,7 @@ func (c *Config) setSuggestionsField(field, value string) error {
 	case "picker_view":
 		return c.setSuggestionsPickerView(value)
 	default:
-		return fmt.Errorf("unknown field: suggestions.%s", field)
+		return fmt.Errorf("%w: suggestions.%s", errUnknownField, field)
 	}
 }
 
@@ -777,8 +1058,12 @@ func (c *Config) getPrivacyField(field string) (string, error) {
 	switch field {
 	case "sanitize_ai_calls":
 		return strconv.FormatBool(c.Privacy.SanitizeAICalls), nil
+	case "db_encryption":
+		return c.Privacy.DBEncryption, nil
+	case "db_key_file":
+		return c.Privacy.DBKeyFile, nil
 	default:
-		return "", fmt.Errorf("unknown field: privacy.%s", field)
+		return "", fmt.Errorf("%w: privacy.%s", errUnknownField, field)
 	}
 }
 
@@ -790,8 +1075,15 @@ func (c *Config) setPrivacyField(field, value string) error {
 			return fmt.Errorf("invalid value for sanitize_ai_calls: %w", err)
 		}
 		c.Privacy.SanitizeAICalls = v
+	case "db_encryption":
+		if !isValidDBEncryption(value) {
+			return fmt.Errorf("invalid db_encryption: %s (must be off, keychain, or file)", value)
+		}
+		c.Privacy.DBEncryption = value
+	case "db_key_file":
+		c.Privacy.DBKeyFile = value
 	default:
-		return fmt.Errorf("unknown field: privacy.%s", field)
+		return fmt.Errorf("%w: privacy.%s", errUnknownField, field)
 	}
 	return nil
 }
@@ -813,7 +1105,7 @@ func (c *Config) getHistoryField(field string) (string, error) {
 	case "up_arrow_double_window_ms":
 		return strconv.Itoa(c.History.UpArrowDoubleWindowMs), nil
 	default:
-		return "", fmt.Errorf("unknown field: history.%s", field)
+		return "", fmt.Errorf("%w: history.%s", errUnknownField, field)
 	}
 }
 
@@ -834,7 +1126,7 @@ func (c *Config) setHistoryField(field, value string) error {
 	case "up_arrow_double_window_ms":
 		return c.setHistoryUpArrowDoubleWindowMs(value)
 	default:
-		return fmt.Errorf("unknown field: history.%s", field)
+		return fmt.Errorf("%w: history.%s", errUnknownField, field)
 	}
 }
 
@@ -938,7 +1230,7 @@ func (c *Config) getWorkflowsField(field string) (string, error) {
 	case "secret_file":
 		return c.Workflows.SecretFile, nil
 	default:
-		return "", fmt.Errorf("unknown field: workflows.%s", field)
+		return "", fmt.Errorf("%w: workflows.%s", errUnknownField, field)
 	}
 }
 
@@ -983,13 +1275,17 @@ func (c *Config) setWorkflowsField(field, value string) error {
 	case "secret_file":
 		c.Workflows.SecretFile = value
 	default:
-		return fmt.Errorf("unknown field: workflows.%s", field)
+		return fmt.Errorf("%w: workflows.%s", errUnknownField, field)
 	}
 	return nil
 }
 
 // Validate validates the configuration.
 func (c *Config) Validate() error {
+	if err := c.validatePresets(); err != nil {
+		return err
+	}
+
 	if c.Daemon.IdleTimeoutMins < 0 {
 		return errors.New("daemon.idle_timeout_mins must be >= 0")
 	}
@@ -998,6 +1294,26 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("daemon.log_level must be debug, info, warn, or error (got: %s)", c.Daemon.LogLevel)
 	}
 
+	if !isValidLogFormat(c.Daemon.LogFormat) {
+		return fmt.Errorf("daemon.log_format must be text or json (got: %s)", c.Daemon.LogFormat)
+	}
+
+	if c.Daemon.LogMaxSizeMB < 0 || c.Daemon.LogMaxAgeDays < 0 || c.Daemon.LogMaxBackups < 0 {
+		return errors.New("daemon.log_max_size_mb, log_max_age_days and log_max_backups must be >= 0")
+	}
+
+	if c.Daemon.SlowRPCMs < 0 {
+		return errors.New("daemon.slow_rpc_ms must be >= 0")
+	}
+
+	if c.Daemon.RateLimitAIPerMin < 0 || c.Daemon.RateLimitImportPerHour < 0 {
+		return errors.New("daemon.rate_limit_ai_per_min and rate_limit_import_per_hour must be >= 0")
+	}
+
+	if _, err := ParseSocketMode(c.Daemon.SocketMode); err != nil {
+		return fmt.Errorf("daemon.%w", err)
+	}
+
 	if c.Client.SuggestTimeoutMs < 0 {
 		return errors.New("client.suggest_timeout_ms must be >= 0")
 	}
@@ -1006,6 +1322,10 @@ func (c *Config) Validate() error {
 		return errors.New("client.connect_timeout_ms must be >= 0")
 	}
 
+	if err := c.Client.Keybindings.validate(); err != nil {
+		return err
+	}
+
 	if !isValidProvider(c.AI.Provider) {
 		return fmt.Errorf("ai.provider must be anthropic or auto (got: %s)", c.AI.Provider)
 	}
@@ -1033,6 +1353,23 @@ func (c *Config) Validate() error {
 		c.History.PickerPageSize = 500
 	}
 
+	for i, t := range c.History.PickerTabs {
+		switch {
+		case t.Provider == "command" && strings.TrimSpace(t.Command) == "":
+			return fmt.Errorf("history.picker_tabs[%d]: provider command needs a command", i)
+		case t.Provider != "command" && t.Command != "":
+			return fmt.Errorf("history.picker_tabs[%d]: command is only used with provider: command", i)
+		}
+	}
+
+	if err := c.Paths.validate(); err != nil {
+		return err
+	}
+
+	if !isValidDBEncryption(c.Privacy.DBEncryption) {
+		return fmt.Errorf("privacy.db_encryption must be off, keychain, or file (got: %s)", c.Privacy.DBEncryption)
+	}
+
 	if !isValidPickerBackend(c.History.PickerBackend) {
 		return fmt.Errorf("history.picker_backend must be builtin, fzf, or clai (got: %s)", c.History.PickerBackend)
 	}
@@ -1056,6 +1393,28 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// ParseSocketMode parses an octal socket file mode such as "0600" or "660".
+// The owner must keep read and write access, and other users may not be
+// granted any, so the setting can only share the socket with a group.
+// An empty mode means the default, 0600.
+func ParseSocketMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0o600, nil
+	}
+	v, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || v > 0o777 {
+		return 0, fmt.Errorf("socket_mode must be an octal permission like 0600 (got: %s)", mode)
+	}
+	perm := os.FileMode(v)
+	if perm&0o600 != 0o600 {
+		return 0, fmt.Errorf("socket_mode must give the owner read and write access (got: %s)", mode)
+	}
+	if perm&0o007 != 0 {
+		return 0, fmt.Errorf("socket_mode must not grant access to other users; use socket_group to share (got: %s)", mode)
+	}
+	return perm, nil
+}
+
 func isValidLogLevel(level string) bool {
 	switch level {
 	case "debug", "info", "warn", "error":
@@ -1065,6 +1424,17 @@ func isValidLogLevel(level string) bool {
 	}
 }
 
+// isValidLogFormat reports whether format is a supported daemon log format.
+// Empty means the default (text).
+func isValidLogFormat(format string) bool {
+	switch format {
+	case "", "text", "json":
+		return true
+	default:
+		return false
+	}
+}
+
 func isValidProvider(provider string) bool {
 	switch provider {
 	case "anthropic", "auto":
@@ -1074,6 +1444,15 @@ func isValidProvider(provider string) bool {
 	}
 }
 
+func isValidDBEncryption(mode string) bool {
+	switch mode {
+	case "", "off", "keychain", "file":
+		return true
+	default:
+		return false
+	}
+}
+
 func isValidPickerBackend(backend string) bool {
 	switch backend {
 	case "builtin", "fzf", "clai":
@@ -1101,14 +1480,12 @@ func isValidWorkflowMode(mode string) bool {
 	}
 }
 
-// ApplyEnvOverrides applies environment variable overrides to the config.
-// Environment variables override config file values per spec Section 16.
+// ApplyEnvOverrides applies environment variable overrides to the config:
+// the variable generated for each setting (see ConfigEnvVars), then the
+// older shorthands CLAI_DEBUG, CLAI_LOG_LEVEL and CLAI_SOCKET, which win.
 func (c *Config) ApplyEnvOverrides() {
-	if v := os.Getenv("CLAI_SUGGESTIONS_ENABLED"); v != "" {
-		if b, err := strconv.ParseBool(v); err == nil {
-			c.Suggestions.Enabled = b
-		}
-	}
+	applyEnvOverlay(os.Getenv, c)
+	c.Suggestions.markWeightsSet(envOverlay(os.Getenv))
 	if v := os.Getenv("CLAI_DEBUG"); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil && b {
 			c.Daemon.LogLevel = "debug"
@@ -1125,13 +1502,14 @@ func (c *Config) ApplyEnvOverrides() {
 }
 
 // ListKeys returns user-facing configuration keys.
-// Internal settings (daemon, client, ai, privacy) are not exposed.
+// Internal settings (daemon, ai, privacy and most of client) are not exposed.
 func ListKeys() []string {
 	return []string{
 		"suggestions.enabled",
 		"suggestions.max_history",
 		"suggestions.show_risk_warning",
 		"suggestions.scorer_version",
+		"suggestions.weights_preset",
 		"suggestions.picker_view",
 		"history.picker_backend",
 		"history.picker_open_on_empty",
@@ -1139,6 +1517,8 @@ func ListKeys() []string {
 		"history.picker_case_sensitive",
 		"history.up_arrow_trigger",
 		"history.up_arrow_double_window_ms",
+		"client.keybindings.history_picker",
+		"client.keybindings.suggest_widget",
 	}
 }
 
@@ -1152,18 +1532,32 @@ type ValidationWarning struct {
 // Invalid values are fixed by falling back to defaults or clamping.
 // Returns a list of warnings for diagnostics. Validation never prevents startup.
 func (s *SuggestionsConfig) ValidateAndFix() []ValidationWarning {
+	warnings := s.validateAndFix()
+	for _, w := range warnings {
+		slog.Warn("config validation warning", "section", "suggestions", "field", w.Field, "message", w.Message)
+	}
+	return warnings
+}
+
+// validateAndFix is ValidateAndFix without logging.
+func (s *SuggestionsConfig) validateAndFix() []ValidationWarning {
 	defaults := DefaultSuggestionsConfig()
 	var warnings []ValidationWarning
 
 	warn := func(field, msg string) {
 		warnings = append(warnings, ValidationWarning{Field: field, Message: msg})
-		slog.Warn("config validation warning", "section", "suggestions", "field", field, "message", msg)
 	}
 
 	s.validateMinOneIntFields(warn, &defaults)
 	s.validateWeightFields(warn)
 	s.validateScalarFields(warn, &defaults)
 	s.validateEnumFields(warn, &defaults)
+	s.validateRetentionRules(warn)
+	s.validateEphemeralDirs(warn)
+	s.validateIngestFilters(warn)
+	s.validateRedactPatterns(warn)
+	s.validateRiskPatterns(warn)
+	s.validateProjectTypes(warn)
 
 	return warnings
 }
@@ -1178,6 +1572,7 @@ func (s *SuggestionsConfig) validateMinOneIntFields(warn func(string, string), d
 	}{
 		// Timeouts
 		{&s.HardTimeoutMs, "hard_timeout_ms", defaults.HardTimeoutMs},
+		{&s.InlineTimeoutMs, "inline_timeout_ms", defaults.InlineTimeoutMs},
 		{&s.HookConnectTimeoutMs, "hook_connect_timeout_ms", defaults.HookConnectTimeoutMs},
 		{&s.HookWriteTimeoutMs, "hook_write_timeout_ms", defaults.HookWriteTimeoutMs},
 		{&s.IngestSyncWaitMs, "ingest_sync_wait_ms", defaults.IngestSyncWaitMs},
@@ -1188,6 +1583,7 @@ func (s *SuggestionsConfig) validateMinOneIntFields(warn func(string, string), d
 		{&s.BurstEventsThreshold, "burst_events_threshold", defaults.BurstEventsThreshold},
 		// Byte sizes
 		{&s.CmdRawMaxBytes, "cmd_raw_max_bytes", defaults.CmdRawMaxBytes},
+		{&s.OutputMaxBytes, "output_max_bytes", defaults.OutputMaxBytes},
 		{&s.IngestQueueMaxBytes, "ingest_queue_max_bytes", defaults.IngestQueueMaxBytes},
 		{&s.CacheMemoryBudgetMB, "cache_memory_budget_mb", defaults.CacheMemoryBudgetMB},
 		// Online learning
@@ -1217,6 +1613,8 @@ func (s *SuggestionsConfig) validateWeightFields(warn func(string, string)) {
 		{&s.Weights.RiskPenalty, "weights.risk_penalty"},
 		{&s.Weights.ProjectTypeAffinity, "weights.project_type_affinity"},
 		{&s.Weights.FailureRecovery, "weights.failure_recovery"},
+		{&s.Weights.TimeOfDay, "weights.time_of_day"},
+		{&s.Weights.DayOfWeek, "weights.day_of_week"},
 	}
 	for _, f := range fields {
 		if *f.val < 0.0 {
@@ -1236,6 +1634,14 @@ func (s *SuggestionsConfig) validateScalarFields(warn func(string, string), defa
 		warn("retention_days", fmt.Sprintf("must be >= 0, got %d; falling back to default %d", s.RetentionDays, defaults.RetentionDays))
 		s.RetentionDays = defaults.RetentionDays
 	}
+	if s.ArchiveAfterDays < 0 {
+		warn("archive_after_days", fmt.Sprintf("must be >= 0, got %d; falling back to default %d", s.ArchiveAfterDays, defaults.ArchiveAfterDays))
+		s.ArchiveAfterDays = defaults.ArchiveAfterDays
+	}
+	if s.MaxDBSizeMB < 0 {
+		warn("max_db_size_mb", fmt.Sprintf("must be >= 0, got %d; falling back to default %d", s.MaxDBSizeMB, defaults.MaxDBSizeMB))
+		s.MaxDBSizeMB = defaults.MaxDBSizeMB
+	}
 	if s.RetentionMaxEvents < 1000 {
 		warn("retention_max_events", fmt.Sprintf("must be >= 1000, got %d; clamping to 1000", s.RetentionMaxEvents))
 		s.RetentionMaxEvents = 1000
@@ -1250,8 +1656,174 @@ func (s *SuggestionsConfig) validateScalarFields(warn func(string, string), defa
 		s.WorkflowMinSteps = defaults.WorkflowMinSteps
 		s.WorkflowMaxSteps = defaults.WorkflowMaxSteps
 	}
+	if s.ExplorationRate < 0.0 || s.ExplorationRate > 1.0 {
+		warn("exploration_rate", fmt.Sprintf("must be in [0.0, 1.0], got %f; falling back to default %f", s.ExplorationRate, defaults.ExplorationRate))
+		s.ExplorationRate = defaults.ExplorationRate
+	}
+	if s.SessionRecencyBoost.WindowMinutes < 0 {
+		warn("session_recency_boost.window_minutes", fmt.Sprintf("must be >= 0, got %d; falling back to default %d",
+			s.SessionRecencyBoost.WindowMinutes, defaults.SessionRecencyBoost.WindowMinutes))
+		s.SessionRecencyBoost.WindowMinutes = defaults.SessionRecencyBoost.WindowMinutes
+	}
+	if s.SessionRecencyBoost.Strength < 0 {
+		warn("session_recency_boost.strength", fmt.Sprintf("must be >= 0, got %f; falling back to default %f",
+			s.SessionRecencyBoost.Strength, defaults.SessionRecencyBoost.Strength))
+		s.SessionRecencyBoost.Strength = defaults.SessionRecencyBoost.Strength
+	}
+	s.validateLatencyBudget(warn, defaults)
 	clampIntRange(warn, "pipeline_max_segments", &s.PipelineMaxSegments, 2, 32)
 	clampIntRange(warn, "directory_scope_max_depth", &s.DirectoryScopeMaxDepth, 1, 10)
+	for i, w := range s.DirectoryAffinityWeights {
+		if w < 0.0 || w > 1.0 {
+			warn(fmt.Sprintf("directory_affinity_weights[%d]", i), fmt.Sprintf("must be in [0.0, 1.0], got %f; falling back to default %v",
+				w, defaults.DirectoryAffinityWeights))
+			s.DirectoryAffinityWeights = defaults.DirectoryAffinityWeights
+			break
+		}
+	}
+	for shell, n := range s.CmdRawMaxBytesByShell {
+		if n < 1 {
+			warn("cmd_raw_max_bytes_by_shell."+shell, fmt.Sprintf("must be >= 1, got %d; using cmd_raw_max_bytes", n))
+			delete(s.CmdRawMaxBytesByShell, shell)
+		}
+	}
+}
+
+// validateLatencyBudget falls back to the default for negative budgets and
+// a cooldown below one minute.
+func (s *SuggestionsConfig) validateLatencyBudget(warn func(string, string), defaults *SuggestionsConfig) {
+	b, d := &s.LatencyBudget, defaults.LatencyBudget
+	if b.IngestP95Ms < 0 {
+		warn("latency_budget.ingest_p95_ms", fmt.Sprintf("must be >= 0, got %d; falling back to default %d", b.IngestP95Ms, d.IngestP95Ms))
+		b.IngestP95Ms = d.IngestP95Ms
+	}
+	if b.SuggestP95Ms < 0 {
+		warn("latency_budget.suggest_p95_ms", fmt.Sprintf("must be >= 0, got %d; falling back to default %d", b.SuggestP95Ms, d.SuggestP95Ms))
+		b.SuggestP95Ms = d.SuggestP95Ms
+	}
+	if b.CooldownMinutes < 1 {
+		warn("latency_budget.cooldown_minutes", fmt.Sprintf("must be >= 1, got %d; falling back to default %d", b.CooldownMinutes, d.CooldownMinutes))
+		b.CooldownMinutes = d.CooldownMinutes
+	}
+}
+
+// validateRedactPatterns drops redact patterns that do not compile.
+func (s *SuggestionsConfig) validateRedactPatterns(warn func(string, string)) {
+	var kept []string
+	for i, expr := range s.RedactPatterns {
+		if _, err := regexp.Compile(expr); err != nil {
+			warn(fmt.Sprintf("redact_patterns[%d]", i), fmt.Sprintf("invalid regular expression: %v; ignoring pattern", err))
+			continue
+		}
+		kept = append(kept, expr)
+	}
+	s.RedactPatterns = kept
+}
+
+// validateRiskPatterns drops risk patterns whose regular expressions do not
+// compile, whose level is unknown, or that have neither a pattern nor the
+// name of a built-in pattern to extend.
+func (s *SuggestionsConfig) validateRiskPatterns(warn func(string, string)) {
+	var kept []RiskPattern
+	for i, rp := range s.RiskPatterns {
+		field := fmt.Sprintf("risk_patterns[%d]", i)
+		if rp.Pattern == "" && rp.Name == "" {
+			warn(field, "needs a pattern, or the name of a built-in pattern; ignoring pattern")
+			continue
+		}
+		if rp.Level != "" && rp.Level != "destructive" && rp.Level != "safe" {
+			warn(field, fmt.Sprintf("level must be destructive or safe, got %q; ignoring pattern", rp.Level))
+			continue
+		}
+		if err := compileAll(rp.Pattern, rp.Allow); err != nil {
+			warn(field, fmt.Sprintf("invalid regular expression: %v; ignoring pattern", err))
+			continue
+		}
+		kept = append(kept, rp)
+	}
+	s.RiskPatterns = kept
+}
+
+// validateProjectTypes drops project types without a name or markers, with
+// a name that cannot be stored in a session's project type list, or with a
+// malformed marker glob.
+func (s *SuggestionsConfig) validateProjectTypes(warn func(string, string)) {
+	var kept []ProjectType
+	for i, pt := range s.ProjectTypes {
+		field := fmt.Sprintf("project_types[%d]", i)
+		pt.Name = strings.TrimSpace(pt.Name)
+		if pt.Name == "" || strings.Contains(pt.Name, "|") {
+			warn(field, fmt.Sprintf("needs a name without |, got %q; ignoring project type", pt.Name))
+			continue
+		}
+		if len(pt.Markers) == 0 {
+			warn(field, "needs at least one marker; ignoring project type")
+			continue
+		}
+		if err := checkGlobs(pt.Markers); err != nil {
+			warn(field, fmt.Sprintf("invalid marker: %v; ignoring project type", err))
+			continue
+		}
+		kept = append(kept, pt)
+	}
+	s.ProjectTypes = kept
+}
+
+// checkGlobs reports the first of patterns that is empty or not a valid
+// glob.
+func checkGlobs(patterns []string) error {
+	for _, p := range patterns {
+		if strings.TrimSuffix(p, "/") == "" {
+			return errors.New("empty marker")
+		}
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("%q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// compileAll reports the first of pattern, when set, and allow that does
+// not compile.
+func compileAll(pattern string, allow []string) error {
+	if pattern != "" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return err
+		}
+	}
+	for _, expr := range allow {
+		if _, err := regexp.Compile(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRetentionRules drops retention rules that match nothing or have a
+// negative day count.
+func (s *SuggestionsConfig) validateRetentionRules(warn func(string, string)) {
+	var kept []RetentionRule
+	for i, rule := range s.RetentionRules {
+		field := fmt.Sprintf("retention_rules[%d]", i)
+		switch {
+		case rule.Repo == "" && !rule.Ephemeral && !rule.Failed:
+			warn(field, "needs at least one of repo, ephemeral or failed; ignoring rule")
+		case rule.Days < 0:
+			warn(field, fmt.Sprintf("days must be >= 0, got %d; ignoring rule", rule.Days))
+		default:
+			kept = append(kept, rule)
+		}
+	}
+	s.RetentionRules = kept
+}
+
+// CmdRawMaxBytesFor returns the maximum stored command length in bytes for
+// shell, falling back to CmdRawMaxBytes when the shell has no override.
+func (s *SuggestionsConfig) CmdRawMaxBytesFor(shell string) int {
+	if n, ok := s.CmdRawMaxBytesByShell[shell]; ok && n > 0 {
+		return n
+	}
+	return s.CmdRawMaxBytes
 }
 
 // validateEnumFields validates string fields that must match a set of allowed values.
@@ -1279,6 +1851,15 @@ func (s *SuggestionsConfig) validateEnumFields(warn func(string, string), defaul
 		)
 		s.SearchFTSTokenizer = defaults.SearchFTSTokenizer
 	}
+	if !isValidExploration(s.Exploration) {
+		warn("exploration", fmt.Sprintf("must be off, epsilon, or thompson, got %q; falling back to default %q", s.Exploration, defaults.Exploration))
+		s.Exploration = defaults.Exploration
+	}
+	if _, ok := weightsPresets[s.WeightsPreset]; !ok && s.WeightsPreset != "" {
+		warn("weights_preset", fmt.Sprintf("must be one of %s, got %q; falling back to default %q",
+			strings.Join(WeightsPresetNames(), ", "), s.WeightsPreset, defaults.WeightsPreset))
+		s.WeightsPreset = defaults.WeightsPreset
+	}
 	if !isValidSuggestionsPickerView(s.PickerView) {
 		warn("picker_view", fmt.Sprintf("must be compact or detailed, got %q; falling back to %q", s.PickerView, defaults.PickerView))
 		s.PickerView = defaults.PickerView
@@ -1333,6 +1914,15 @@ func isValidScorerVersion(version string) bool {
 	}
 }
 
+func isValidExploration(mode string) bool {
+	switch mode {
+	case "off", "epsilon", "thompson":
+		return true
+	default:
+		return false
+	}
+}
+
 func isValidSuggestionsPickerView(v string) bool {
 	switch v {
 	case "compact", "detailed":
@@ -59,7 +59,10 @@ func TestConfigGet(t *testing.T) {
 		{"daemon.idle_timeout_mins", "0"},
 		{"daemon.log_level", "info"},
 		{"daemon.socket_path", ""},
+		{"daemon.socket_mode", "0600"},
+		{"daemon.socket_group", ""},
 		{"daemon.log_file", ""},
+		{"daemon.debug_reflection", "false"},
 		// Client section
 		{"client.suggest_timeout_ms", "50"},
 		{"client.connect_timeout_ms", "10"},
@@ -78,6 +81,8 @@ func TestConfigGet(t *testing.T) {
 		{"suggestions.show_risk_warning", "true"},
 		// Privacy section
 		{"privacy.sanitize_ai_calls", "true"},
+		{"privacy.db_encryption", "off"},
+		{"privacy.db_key_file", ""},
 		// History section
 		{"history.picker_backend", "builtin"},
 		{"history.picker_open_on_empty", "false"},
@@ -111,10 +116,21 @@ func TestConfigSet(t *testing.T) {
 		{"daemon.idle_timeout_mins", "30", "30"},
 		{"daemon.idle_timeout_mins", "0", "0"},
 		{"daemon.socket_path", "/custom/path.sock", "/custom/path.sock"},
+		{"daemon.socket_mode", "0660", "0660"},
+		{"daemon.socket_group", "clai", "clai"},
 		{"daemon.log_level", "debug", "debug"},
 		{"daemon.log_level", "warn", "warn"},
 		{"daemon.log_level", "error", "error"},
 		{"daemon.log_file", "/tmp/test.log", "/tmp/test.log"},
+		{"daemon.log_format", "json", "json"},
+		{"daemon.log_max_size_mb", "50", "50"},
+		{"daemon.log_max_age_days", "0", "0"},
+		{"daemon.log_max_backups", "5", "5"},
+		{"daemon.slow_rpc_ms", "250", "250"},
+		{"daemon.slow_rpc_ms", "0", "0"},
+		{"daemon.rate_limit_ai_per_min", "5", "5"},
+		{"daemon.rate_limit_import_per_hour", "0", "0"},
+		{"daemon.debug_reflection", "true", "true"},
 		// Client section
 		{"client.suggest_timeout_ms", "100", "100"},
 		{"client.connect_timeout_ms", "50", "50"},
@@ -140,6 +156,9 @@ func TestConfigSet(t *testing.T) {
 		// Privacy section
 		{"privacy.sanitize_ai_calls", "false", "false"},
 		{"privacy.sanitize_ai_calls", "true", "true"},
+		{"privacy.db_encryption", "keychain", "keychain"},
+		{"privacy.db_encryption", "file", "file"},
+		{"privacy.db_key_file", "/secure/clai.key", "/secure/clai.key"},
 		// History section
 		{"history.picker_backend", "fzf", "fzf"},
 		{"history.picker_backend", "clai", "clai"},
@@ -343,6 +362,7 @@ func TestConfigSetInvalidValue(t *testing.T) {
 		{"ai.auto_diagnose", "on"},
 		{"suggestions.show_risk_warning", "off"},
 		{"privacy.sanitize_ai_calls", "maybe"},
+		{"privacy.db_encryption", "sqlcipher"},
 		{"history.picker_open_on_empty", "yes"},
 		{"history.picker_case_sensitive", "maybe"},
 		{"history.up_arrow_double_window_ms", "not_a_number"},
@@ -353,6 +373,19 @@ func TestConfigSetInvalidValue(t *testing.T) {
 		{"daemon.log_level", "WARNING"},
 		{"daemon.log_level", "fatal"},
 		{"daemon.log_level", ""},
+		// Invalid log format and limits
+		{"daemon.log_format", "logfmt"},
+		{"daemon.log_max_size_mb", "-1"},
+		{"daemon.log_max_backups", "many"},
+		{"daemon.slow_rpc_ms", "-5"},
+		{"daemon.rate_limit_ai_per_min", "-1"},
+		{"daemon.rate_limit_import_per_hour", "lots"},
+		{"daemon.debug_reflection", "sometimes"},
+		// Invalid socket modes
+		{"daemon.socket_mode", "rw-------"},
+		{"daemon.socket_mode", "0400"},
+		{"daemon.socket_mode", "0666"},
+		{"daemon.socket_mode", "01600"},
 		// Invalid provider
 		{"ai.provider", "claude"},
 		{"ai.provider", "gpt4"},
@@ -444,6 +477,11 @@ func TestConfigValidate(t *testing.T) {
 			modify:  func(c *Config) { c.Suggestions.MaxAI = -1 },
 			wantErr: "suggestions.max_ai must be >= 0",
 		},
+		{
+			name:    "invalid_db_encryption",
+			modify:  func(c *Config) { c.Privacy.DBEncryption = "sqlcipher" },
+			wantErr: "privacy.db_encryption",
+		},
 		{
 			name:    "invalid_picker_backend",
 			modify:  func(c *Config) { c.History.PickerBackend = "invalid" },
@@ -459,6 +497,27 @@ func TestConfigValidate(t *testing.T) {
 			modify:  func(c *Config) { c.History.UpArrowTrigger = "invalid" },
 			wantErr: "history.up_arrow_trigger",
 		},
+		{
+			name: "command_tab_without_command",
+			modify: func(c *Config) {
+				c.History.PickerTabs = append(c.History.PickerTabs, TabDef{ID: "hosts", Provider: "command"})
+			},
+			wantErr: "history.picker_tabs[2]: provider command needs a command",
+		},
+		{
+			name: "command_on_history_tab",
+			modify: func(c *Config) {
+				c.History.PickerTabs[0].Command = "ls"
+			},
+			wantErr: "history.picker_tabs[0]: command is only used with provider: command",
+		},
+		{
+			name: "command_tab",
+			modify: func(c *Config) {
+				c.History.PickerTabs = append(c.History.PickerTabs, TabDef{ID: "hosts", Provider: "command", Command: "ls"})
+			},
+			wantErr: "",
+		},
 		{
 			name: "zero_values_are_valid",
 			modify: func(c *Config) {
@@ -494,6 +553,39 @@ func TestConfigValidate(t *testing.T) {
 	}
 }
 
+func TestParseSocketMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{"", 0o600, false},
+		{"0600", 0o600, false},
+		{"660", 0o660, false},
+		{"0640", 0o640, false},
+		{"0700", 0o700, false},
+		{"0644", 0, true},
+		{"0200", 0, true},
+		{"0888", 0, true},
+		{"1777", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSocketMode(tt.mode)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSocketMode(%q) = %o, %v; want %o, error %v", tt.mode, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateRejectsWorldSocketMode(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Daemon.SocketMode = "0666"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "daemon.socket_mode") {
+		t.Errorf("Validate() = %v, want a daemon.socket_mode error", err)
+	}
+}
+
 func TestValidatePickerPageSizeClamping(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -1005,6 +1097,7 @@ func TestListKeys(t *testing.T) {
 		"suggestions.max_history",
 		"suggestions.show_risk_warning",
 		"suggestions.scorer_version",
+		"suggestions.weights_preset",
 		"suggestions.picker_view",
 		"history.picker_backend",
 		"history.picker_open_on_empty",
@@ -1012,6 +1105,8 @@ func TestListKeys(t *testing.T) {
 		"history.picker_case_sensitive",
 		"history.up_arrow_trigger",
 		"history.up_arrow_double_window_ms",
+		"client.keybindings.history_picker",
+		"client.keybindings.suggest_widget",
 	}
 
 	if len(keys) != len(expectedKeys) {
@@ -1052,6 +1147,7 @@ func TestListKeysAllSettable(t *testing.T) {
 		"suggestions.max_history":           "10",
 		"suggestions.show_risk_warning":     "false",
 		"suggestions.scorer_version":        "v2",
+		"suggestions.weights_preset":        "exploratory",
 		"suggestions.picker_view":           "compact",
 		"history.picker_backend":            "fzf",
 		"history.picker_open_on_empty":      "true",
@@ -1059,6 +1155,8 @@ func TestListKeysAllSettable(t *testing.T) {
 		"history.picker_case_sensitive":     "true",
 		"history.up_arrow_trigger":          "double",
 		"history.up_arrow_double_window_ms": "300",
+		"client.keybindings.history_picker": "^R",
+		"client.keybindings.suggest_widget": "^@",
 	}
 
 	for _, key := range keys {
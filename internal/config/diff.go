@@ -0,0 +1,85 @@
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Change is a setting whose effective value differs between two configs.
+// Old or New is "" when the setting is unset on that side.
+type Change struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Diff returns the settings that differ between from and to, sorted by
+// key. Lists are compared and shown as a whole; profile presets are left
+// out, as only the active one has an effect and it is already merged.
+func Diff(from, to *Config) ([]Change, error) {
+	before, err := flatten(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := flatten(to)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for key, v := range after {
+		if before[key] != v {
+			changes = append(changes, Change{Key: key, Old: before[key], New: v})
+		}
+	}
+	for key, v := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, Change{Key: key, Old: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes, nil
+}
+
+// flatten returns dotted key -> value for every setting of c.
+func flatten(c *Config) (map[string]string, error) {
+	stripped := *c
+	stripped.Profiles = nil
+	var root yaml.Node
+	if err := root.Encode(&stripped); err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	if err := flattenNode(&root, "", values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flattenNode(n *yaml.Node, prefix string, values map[string]string) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			if err := flattenNode(c, prefix, values); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if err := flattenNode(n.Content[i+1], joinKey(prefix, n.Content[i].Value), values); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		n.Style = yaml.FlowStyle
+		out, err := yaml.Marshal(n)
+		if err != nil {
+			return err
+		}
+		values[prefix] = strings.TrimSpace(string(out))
+	default:
+		values[prefix] = n.Value
+	}
+	return nil
+}
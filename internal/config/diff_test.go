@@ -0,0 +1,28 @@
+package config
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	from := DefaultConfig()
+	to := DefaultConfig()
+	to.AI.Enabled = !from.AI.Enabled
+	to.Suggestions.EphemeralDirs = []string{"~/secrets"}
+
+	changes, err := Diff(from, to)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Diff() = %+v, want 2 changes", changes)
+	}
+	if c := changes[0]; c.Key != "ai.enabled" || c.New != "true" {
+		t.Errorf("changes[0] = %+v", c)
+	}
+	if c := changes[1]; c.Key != "suggestions.ephemeral_dirs" || c.New != "[~/secrets]" {
+		t.Errorf("changes[1] = %+v", c)
+	}
+
+	if changes, _ := Diff(from, DefaultConfig()); len(changes) != 0 {
+		t.Errorf("Diff() of equal configs = %+v", changes)
+	}
+}
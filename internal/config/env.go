@@ -0,0 +1,364 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvKind is the kind of value an environment variable takes.
+type EnvKind string
+
+// Environment variable kinds.
+const (
+	EnvPath     EnvKind = "path"
+	EnvFlag     EnvKind = "flag" // only "1" enables it
+	EnvBool     EnvKind = "bool"
+	EnvInt      EnvKind = "int"
+	EnvDuration EnvKind = "duration"
+	EnvLogLevel EnvKind = "log level"
+	EnvProfile  EnvKind = "profile"
+	EnvTrigger  EnvKind = "single|double"
+	EnvText     EnvKind = "text"
+)
+
+// EnvVar describes a CLAI_* environment variable clai reads.
+type EnvVar struct {
+	Name   string
+	Kind   EnvKind
+	UsedBy string
+	Effect string
+	// Shell marks variables read by the shell integration rather than the
+	// binaries; their defaults come from the config file at `clai init`.
+	Shell bool
+	// Internal marks variables the shell integration sets itself.
+	Internal bool
+}
+
+// EnvVars lists every CLAI_* environment variable, user overrides first.
+var EnvVars = []EnvVar{
+	{Name: "CLAI_HOME", Kind: EnvPath, UsedBy: "all", Effect: "Base directory for config, databases, hooks and logs (default ~/.clai)"},
+	{Name: "CLAI_CONFIG_DIR", Kind: EnvPath, UsedBy: "all", Effect: "Directory of config.yaml and trusted repositories; overrides CLAI_HOME and XDG_CONFIG_HOME for them"},
+	{Name: ProfileEnv, Kind: EnvProfile, UsedBy: "all", Effect: "Run a separate daemon with its own socket, databases, logs and cache, and apply the profiles.<name> preset"},
+	{Name: "CLAI_CACHE", Kind: EnvPath, UsedBy: "clai, Claude daemon", Effect: "Cache directory for suggestions, last output and the Claude daemon"},
+	{Name: "CLAI_SOCKET", Kind: EnvPath, UsedBy: "all", Effect: "History daemon socket; overrides daemon.socket_path, the profile socket and CLAI_REMOTE"},
+	{Name: "CLAI_REMOTE", Kind: EnvFlag, UsedBy: "all", Effect: "Talk to the local machine's daemon through an SSH-forwarded socket; never spawn one"},
+	{Name: "CLAI_DAEMON_PATH", Kind: EnvPath, UsedBy: "clai, clai-shim", Effect: "claid binary to spawn"},
+	{Name: "CLAI_SUGGESTIONS_ENABLED", Kind: EnvBool, UsedBy: "claid, clai", Effect: "Overrides suggestions.enabled"},
+	{Name: "CLAI_DEBUG", Kind: EnvBool, UsedBy: "claid, clai-picker", Effect: "Debug logging; sets daemon.log_level to debug (clai-picker and suggestion logs need \"1\")"},
+	{Name: "CLAI_LOG_LEVEL", Kind: EnvLogLevel, UsedBy: "claid", Effect: "Overrides daemon.log_level; wins over CLAI_DEBUG"},
+	{Name: "CLAI_OFF", Kind: EnvFlag, UsedBy: "clai suggest", Effect: "Disable suggestions"},
+	{Name: "CLAI_NO_RECORD", Kind: EnvFlag, UsedBy: "clai-hook, clai-shim", Effect: "Do not send commands to the daemon (incognito)"},
+	{Name: "CLAI_EPHEMERAL", Kind: EnvFlag, UsedBy: "clai-hook, clai-shim", Effect: "Send commands for this session only; never persist them (incognito)"},
+	{Name: "CLAI_CLOCK_OFFSET", Kind: EnvDuration, UsedBy: "claid", Effect: "Shift the daemon's \"now\" for scoring and decay"},
+	{Name: "CLAI_IDLE_TIMEOUT", Kind: EnvDuration, UsedBy: "Claude daemon", Effect: "Idle time before the Claude daemon exits"},
+	{Name: "CLAI_CONNECT_TIMEOUT_MS", Kind: EnvInt, UsedBy: "clai-hook", Effect: "Daemon connect timeout in ms (10-20)"},
+
+	{Name: "CLAI_MENU_LIMIT", Kind: EnvInt, UsedBy: "zsh", Shell: true, Effect: "Maximum suggestions in the menu (default 5)"},
+	{Name: "CLAI_UP_ARROW_HISTORY", Kind: EnvBool, UsedBy: "shell", Shell: true, Effect: "Open the history picker on Up arrow; defaults from history.up_arrow_opens_history"},
+	{Name: "CLAI_UP_ARROW_TRIGGER", Kind: EnvTrigger, UsedBy: "shell", Shell: true, Effect: "Up presses that open the picker; defaults from history.up_arrow_trigger"},
+	{Name: "CLAI_UP_ARROW_DOUBLE_WINDOW_MS", Kind: EnvInt, UsedBy: "shell", Shell: true, Effect: "Window for a double Up press; defaults from history.up_arrow_double_window_ms"},
+	{Name: "CLAI_CONFIRM_RISKY", Kind: EnvBool, UsedBy: "shell", Shell: true, Effect: "Confirm risky commands before running them; defaults from suggestions.confirm_risky"},
+
+	{Name: "CLAI_SESSION_ID", Kind: EnvText, UsedBy: "all", Internal: true, Effect: "Current shell session"},
+	{Name: "CLAI_CURRENT_SHELL", Kind: EnvText, UsedBy: "clai", Internal: true, Effect: "Shell the integration was loaded into"},
+	{Name: "CLAI_INIT_VERSION", Kind: EnvText, UsedBy: "clai status", Internal: true, Effect: "clai version that generated the loaded integration"},
+	{Name: "CLAI_LAST_COMMAND", Kind: EnvText, UsedBy: "clai suggest", Internal: true, Effect: "Previous command line"},
+}
+
+// EnvIssue is an invalid value or a conflicting combination of
+// environment variables.
+type EnvIssue struct {
+	Name    string
+	Message string
+}
+
+// CheckEnv reports invalid values and conflicting combinations among
+// EnvVars. lookup returns a variable's value ("" when unset); configFile
+// is consulted for daemon.socket_path, before env overrides.
+func CheckEnv(lookup func(string) string, configFile string) []EnvIssue {
+	var issues []EnvIssue
+	add := func(name, format string, args ...any) {
+		issues = append(issues, EnvIssue{Name: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, v := range EnvVars {
+		if val := lookup(v.Name); val != "" {
+			if msg := checkEnvValue(v, val); msg != "" {
+				add(v.Name, "%s", msg)
+			}
+		}
+	}
+
+	registered := make(map[string]bool, len(EnvVars))
+	for _, v := range EnvVars {
+		registered[v.Name] = true
+	}
+	for _, v := range ConfigEnvVars() {
+		if val := lookup(v.Name); val != "" && !registered[v.Name] {
+			if msg := checkConfigEnvValue(v, val); msg != "" {
+				add(v.Name, "%s", msg)
+			}
+		}
+	}
+
+	set := func(name string) bool { return lookup(name) != "" }
+	on := func(name string) bool { return lookup(name) == "1" }
+
+	if set("CLAI_DAEMON_SOCKET_PATH") && set("CLAI_SOCKET") {
+		add("CLAI_DAEMON_SOCKET_PATH", "ignored: CLAI_SOCKET wins")
+	}
+	if set("CLAI_DAEMON_LOG_LEVEL") && (set("CLAI_LOG_LEVEL") || set("CLAI_DEBUG")) {
+		add("CLAI_DAEMON_LOG_LEVEL", "ignored: CLAI_LOG_LEVEL and CLAI_DEBUG win")
+	}
+
+	if socket := lookup("CLAI_SOCKET"); socket != "" {
+		if fileSocket := fileSocketPath(configFile); fileSocket != "" && fileSocket != socket {
+			add("CLAI_SOCKET", "overrides daemon.socket_path (%s); claid listens on %s", fileSocket, socket)
+		}
+		if on("CLAI_REMOTE") {
+			add("CLAI_SOCKET", "wins over CLAI_REMOTE=1; the forwarded remote socket is not used")
+		}
+		if set(ProfileEnv) {
+			add("CLAI_SOCKET", "bypasses the socket of profile %q; every profile sharing it talks to one daemon", lookup(ProfileEnv))
+		}
+	}
+	if debug, err := strconv.ParseBool(lookup("CLAI_DEBUG")); err == nil && debug {
+		if level := lookup("CLAI_LOG_LEVEL"); isValidLogLevel(level) && level != "debug" {
+			add("CLAI_DEBUG", "ignored by claid: CLAI_LOG_LEVEL=%s wins", level)
+		}
+	}
+	if on("CLAI_NO_RECORD") && on("CLAI_EPHEMERAL") {
+		add("CLAI_EPHEMERAL", "redundant: CLAI_NO_RECORD=1 already keeps commands from the daemon")
+	}
+	if on("CLAI_OFF") {
+		if enabled, err := strconv.ParseBool(lookup("CLAI_SUGGESTIONS_ENABLED")); err == nil && enabled {
+			add("CLAI_SUGGESTIONS_ENABLED", "clai suggest prints nothing while CLAI_OFF=1")
+		}
+	}
+	if on("CLAI_REMOTE") && set("CLAI_DAEMON_PATH") {
+		add("CLAI_DAEMON_PATH", "ignored: no daemon is spawned with CLAI_REMOTE=1")
+	}
+	if set("CLAI_CACHE") && set("CLAI_CACHE_DIR") {
+		add("CLAI_CACHE", "wins over CLAI_CACHE_DIR for the suggestion and Claude daemon caches")
+	}
+	if set("CLAI_CACHE") && set(ProfileEnv) {
+		add("CLAI_CACHE", "shared by every profile; profile %q no longer gets its own cache", lookup(ProfileEnv))
+	}
+	return issues
+}
+
+// checkEnvValue validates a single value against its kind. It returns ""
+// when the value is usable.
+func checkEnvValue(v EnvVar, val string) string {
+	switch v.Kind {
+	case EnvPath:
+		if !filepath.IsAbs(val) {
+			return fmt.Sprintf("%q is relative; it resolves against each process's working directory", val)
+		}
+		if v.Name == "CLAI_DAEMON_PATH" {
+			if info, err := os.Stat(val); err != nil {
+				return fmt.Sprintf("%q: %v", val, err)
+			} else if info.IsDir() || info.Mode()&0o111 == 0 {
+				return fmt.Sprintf("%q is not an executable file", val)
+			}
+		}
+	case EnvFlag:
+		if val != "1" && val != "0" {
+			return fmt.Sprintf("%q has no effect; only \"1\" enables it", val)
+		}
+	case EnvBool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Sprintf("%q is not a boolean; ignored", val)
+		}
+		if v.Name == "CLAI_DEBUG" && b && val != "1" {
+			return fmt.Sprintf("%q only raises the daemon log level; use \"1\" for clai-picker and suggestion logs too", val)
+		}
+	case EnvInt:
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return fmt.Sprintf("%q is not a non-negative integer; ignored", val)
+		}
+		if v.Name == "CLAI_CONNECT_TIMEOUT_MS" && (n < 10 || n > 20) {
+			return fmt.Sprintf("%d is outside 10-20; the default is used", n)
+		}
+	case EnvDuration:
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Sprintf("%q is not a duration such as \"720h\"; ignored", val)
+		}
+		if v.Name == "CLAI_IDLE_TIMEOUT" && d <= 0 {
+			return fmt.Sprintf("%q must be positive; ignored", val)
+		}
+	case EnvLogLevel:
+		if !isValidLogLevel(val) {
+			return fmt.Sprintf("%q is not one of debug, info, warn, error; ignored", val)
+		}
+	case EnvProfile:
+		if err := ValidateProfile(strings.TrimSpace(val)); err != nil {
+			return err.Error()
+		}
+	case EnvTrigger:
+		if val != "single" && val != "double" {
+			return fmt.Sprintf("%q is not single or double", val)
+		}
+	}
+	return ""
+}
+
+// checkConfigEnvValue checks the value of a generated variable the way
+// loading the config would: it must parse, pass Validate and not be
+// clamped by ValidateAndFix. It returns "" when the value is usable.
+func checkConfigEnvValue(v ConfigEnvVar, val string) string {
+	n, err := envValueNode(v, val)
+	if err != nil {
+		return err.Error()
+	}
+	overlay := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setNode(overlay, v.Key, n)
+	cfg := DefaultConfig()
+	if err := overlay.Decode(cfg); err != nil {
+		return err.Error()
+	}
+	for _, w := range cfg.Suggestions.validateAndFix() {
+		if strings.HasPrefix("suggestions."+w.Field, v.Key) {
+			return w.Message
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Sprintf("clai fails to load the config: %v", err)
+	}
+	return ""
+}
+
+// fileSocketPath reads daemon.socket_path from the config file and the
+// active profile preset, without env overrides. A missing or invalid file
+// yields "".
+func fileSocketPath(configFile string) string {
+	var file struct {
+		Daemon struct {
+			SocketPath string `yaml:"socket_path"`
+		} `yaml:"daemon"`
+	}
+	if decodeConfigFile(configFile, &file) != nil {
+		return ""
+	}
+	return file.Daemon.SocketPath
+}
+
+// ConfigEnvVar is the environment variable generated for a config setting:
+// CLAI_ followed by its key in upper case with dots as underscores, as in
+// CLAI_SUGGESTIONS_MAX_RESULTS for suggestions.max_results. A field's env
+// tag renames the variable, or with "-" leaves the field out. Lists and
+// maps are set as a whole, in YAML: CLAI_SUGGESTIONS_EPHEMERAL_DIRS='[~/a]'.
+type ConfigEnvVar struct {
+	Name string
+	Key  string
+}
+
+// ConfigEnvVars returns the generated variable of every config setting,
+// in field order.
+func ConfigEnvVars() []ConfigEnvVar {
+	var vars []ConfigEnvVar
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			env := f.Tag.Get("env")
+			if !f.IsExported() || name == "-" || env == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			key := joinKey(prefix, name)
+			if f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(yaml.Node{}) {
+				walk(f.Type, key)
+				continue
+			}
+			if env == "" {
+				env = "CLAI_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+			}
+			vars = append(vars, ConfigEnvVar{Name: env, Key: key})
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	return vars
+}
+
+// envValueNode parses the value of v for its setting.
+func envValueNode(v ConfigEnvVar, val string) (*yaml.Node, error) {
+	segs, err := parseKeyPath(v.Key)
+	if err != nil {
+		return nil, err
+	}
+	t, err := keyType(segs)
+	if err != nil {
+		return nil, err
+	}
+	n, err := valueNode(t, v.Key, val)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid %s; ignored", val, kindName(t))
+	}
+	return n, nil
+}
+
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int64:
+		return "integer"
+	case reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		return "YAML list"
+	case reflect.Map, reflect.Struct:
+		return "YAML mapping"
+	}
+	return t.Kind().String()
+}
+
+// envOverlay returns the config keys set by the generated variables as a
+// YAML mapping, to decode over a Config or a partial struct of one.
+// Values that do not parse are skipped.
+func envOverlay(lookup func(string) string) *yaml.Node {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, v := range ConfigEnvVars() {
+		val := lookup(v.Name)
+		if val == "" {
+			continue
+		}
+		n, err := envValueNode(v, val)
+		if err != nil {
+			continue
+		}
+		setNode(root, v.Key, n)
+	}
+	return root
+}
+
+// applyEnvOverlay decodes the generated variables over out, a *Config or
+// a partial struct of one.
+func applyEnvOverlay(lookup func(string) string, out any) {
+	if overlay := envOverlay(lookup); len(overlay.Content) > 0 {
+		_ = overlay.Decode(out)
+	}
+}
+
+// setNode sets key, a dotted path of mapping keys, to n under root.
+func setNode(root *yaml.Node, key string, n *yaml.Node) {
+	cur := root
+	for _, name := range strings.Split(key, ".") {
+		cur, _ = child(cur, keySegment{name: name, index: -1}, true)
+	}
+	*cur = *n
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func envLookup(env map[string]string) func(string) string {
+	return func(name string) string { return env[name] }
+}
+
+func issueFor(issues []EnvIssue, name, substr string) bool {
+	for _, issue := range issues {
+		if issue.Name == name && strings.Contains(issue.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEnvVars_Unique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, v := range EnvVars {
+		if seen[v.Name] {
+			t.Errorf("%s listed twice", v.Name)
+		}
+		seen[v.Name] = true
+		if !strings.HasPrefix(v.Name, "CLAI_") || v.Effect == "" || v.UsedBy == "" {
+			t.Errorf("incomplete entry %+v", v)
+		}
+	}
+}
+
+func TestCheckEnv_Clean(t *testing.T) {
+	issues := CheckEnv(envLookup(map[string]string{
+		"CLAI_HOME":         "/tmp/clai",
+		"CLAI_DEBUG":        "1",
+		"CLAI_LOG_LEVEL":    "debug",
+		"CLAI_CLOCK_OFFSET": "720h",
+		"CLAI_NO_RECORD":    "1",
+	}), filepath.Join(t.TempDir(), "missing.yaml"))
+	if len(issues) != 0 {
+		t.Errorf("unexpected issues: %+v", issues)
+	}
+}
+
+func TestCheckEnv_InvalidValues(t *testing.T) {
+	issues := CheckEnv(envLookup(map[string]string{
+		"CLAI_HOME":                "relative/dir",
+		ProfileEnv:                 "../work",
+		"CLAI_DAEMON_PATH":         "/nonexistent/claid",
+		"CLAI_SUGGESTIONS_ENABLED": "maybe",
+		"CLAI_DEBUG":               "true",
+		"CLAI_LOG_LEVEL":           "trace",
+		"CLAI_OFF":                 "true",
+		"CLAI_CLOCK_OFFSET":        "30d",
+		"CLAI_IDLE_TIMEOUT":        "0s",
+		"CLAI_CONNECT_TIMEOUT_MS":  "50",
+		"CLAI_MENU_LIMIT":          "-1",
+		"CLAI_UP_ARROW_TRIGGER":    "triple",
+	}), "")
+
+	for name, substr := range map[string]string{
+		"CLAI_HOME":                "relative",
+		ProfileEnv:                 "invalid",
+		"CLAI_DAEMON_PATH":         "/nonexistent/claid",
+		"CLAI_SUGGESTIONS_ENABLED": "not a boolean",
+		"CLAI_DEBUG":               "use \"1\"",
+		"CLAI_LOG_LEVEL":           "not one of",
+		"CLAI_OFF":                 "only \"1\"",
+		"CLAI_CLOCK_OFFSET":        "not a duration",
+		"CLAI_IDLE_TIMEOUT":        "positive",
+		"CLAI_CONNECT_TIMEOUT_MS":  "outside 10-20",
+		"CLAI_MENU_LIMIT":          "non-negative",
+		"CLAI_UP_ARROW_TRIGGER":    "single or double",
+	} {
+		if !issueFor(issues, name, substr) {
+			t.Errorf("missing %s issue containing %q in %+v", name, substr, issues)
+		}
+	}
+}
+
+func TestCheckEnv_DaemonPathNotExecutable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claid")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	issues := CheckEnv(envLookup(map[string]string{"CLAI_DAEMON_PATH": path}), "")
+	if !issueFor(issues, "CLAI_DAEMON_PATH", "not an executable") {
+		t.Errorf("issues = %+v", issues)
+	}
+}
+
+func TestCheckEnv_Conflicts(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("daemon:\n  socket_path: /run/clai.sock\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	issues := CheckEnv(envLookup(map[string]string{
+		"CLAI_SOCKET":              "/tmp/other.sock",
+		"CLAI_REMOTE":              "1",
+		ProfileEnv:                 "work",
+		"CLAI_DEBUG":               "1",
+		"CLAI_LOG_LEVEL":           "warn",
+		"CLAI_NO_RECORD":           "1",
+		"CLAI_EPHEMERAL":           "1",
+		"CLAI_OFF":                 "1",
+		"CLAI_SUGGESTIONS_ENABLED": "true",
+		"CLAI_DAEMON_PATH":         "/bin/sh",
+		"CLAI_CACHE":               "/tmp/cache",
+	}), configFile)
+
+	for _, want := range []struct{ name, substr string }{
+		{"CLAI_SOCKET", "daemon.socket_path (/run/clai.sock)"},
+		{"CLAI_SOCKET", "CLAI_REMOTE=1"},
+		{"CLAI_SOCKET", `profile "work"`},
+		{"CLAI_DEBUG", "CLAI_LOG_LEVEL=warn wins"},
+		{"CLAI_EPHEMERAL", "redundant"},
+		{"CLAI_SUGGESTIONS_ENABLED", "CLAI_OFF=1"},
+		{"CLAI_DAEMON_PATH", "CLAI_REMOTE=1"},
+		{"CLAI_CACHE", `profile "work"`},
+	} {
+		if !issueFor(issues, want.name, want.substr) {
+			t.Errorf("missing %s issue containing %q in %+v", want.name, want.substr, issues)
+		}
+	}
+}
+
+func TestCheckEnv_SocketMatchesConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("daemon:\n  socket_path: /run/clai.sock\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	issues := CheckEnv(envLookup(map[string]string{"CLAI_SOCKET": "/run/clai.sock"}), configFile)
+	if len(issues) != 0 {
+		t.Errorf("unexpected issues: %+v", issues)
+	}
+}
+
+func TestConfigEnvVars(t *testing.T) {
+	registered := map[string]bool{}
+	for _, v := range EnvVars {
+		registered[v.Name] = true
+	}
+	seen := map[string]string{}
+	for _, v := range ConfigEnvVars() {
+		if other, ok := seen[v.Name]; ok {
+			t.Errorf("%s generated for %s and %s", v.Name, other, v.Key)
+		}
+		seen[v.Name] = v.Key
+		if strings.HasPrefix(v.Key, "profiles") {
+			t.Errorf("profile presets have a variable: %+v", v)
+		}
+		// A registered variable may only share the name of the setting it overrides.
+		if registered[v.Name] && v.Name != "CLAI_SUGGESTIONS_ENABLED" {
+			t.Errorf("%s collides with a registered variable", v.Name)
+		}
+	}
+	for name, key := range map[string]string{
+		"CLAI_SUGGESTIONS_MAX_RESULTS":           "suggestions.max_results",
+		"CLAI_SUGGESTIONS_WEIGHTS_TRANSITION":    "suggestions.weights.transition",
+		"CLAI_CLIENT_KEYBINDINGS_HISTORY_PICKER": "client.keybindings.history_picker",
+		"CLAI_HISTORY_PICKER_TABS":               "history.picker_tabs",
+	} {
+		if seen[name] != key {
+			t.Errorf("%s overrides %q, want %q", name, seen[name], key)
+		}
+	}
+}
+
+func TestApplyEnvOverrides_Generated(t *testing.T) {
+	t.Setenv("CLAI_SUGGESTIONS_MAX_RESULTS", "9")
+	t.Setenv("CLAI_SUGGESTIONS_WEIGHTS_TASK", "0.4")
+	t.Setenv("CLAI_AI_ENABLED", "1")
+	t.Setenv("CLAI_SUGGESTIONS_EPHEMERAL_DIRS", "[~/a, ~/b]")
+	t.Setenv("CLAI_DAEMON_IDLE_TIMEOUT_MINS", "soon") // invalid: ignored
+	t.Setenv("CLAI_DAEMON_LOG_LEVEL", "warn")
+	t.Setenv("CLAI_LOG_LEVEL", "error") // the shorthand wins
+
+	cfg := DefaultConfig()
+	cfg.ApplyEnvOverrides()
+
+	if cfg.Suggestions.MaxResults != 9 || cfg.Suggestions.Weights.Task != 0.4 || !cfg.AI.Enabled {
+		t.Errorf("MaxResults, Task, AI.Enabled = %d, %v, %v", cfg.Suggestions.MaxResults, cfg.Suggestions.Weights.Task, cfg.AI.Enabled)
+	}
+	if strings.Join(cfg.Suggestions.EphemeralDirs, ",") != "~/a,~/b" {
+		t.Errorf("EphemeralDirs = %v", cfg.Suggestions.EphemeralDirs)
+	}
+	if cfg.Daemon.IdleTimeoutMins != DefaultConfig().Daemon.IdleTimeoutMins {
+		t.Errorf("invalid override applied: IdleTimeoutMins = %d", cfg.Daemon.IdleTimeoutMins)
+	}
+	if cfg.Daemon.LogLevel != "error" {
+		t.Errorf("LogLevel = %q, want error", cfg.Daemon.LogLevel)
+	}
+}
+
+func TestLoadHookSettings_Env(t *testing.T) {
+	t.Setenv("CLAI_HOME", t.TempDir())
+	t.Setenv("CLAI_SUGGESTIONS_EPHEMERAL_DIRS", "[~/secrets]")
+
+	if dirs := LoadEphemeralDirs(); len(dirs) != 1 || dirs[0] != "~/secrets" {
+		t.Errorf("LoadEphemeralDirs() = %v", dirs)
+	}
+}
+
+func TestCheckEnv_ConfigVars(t *testing.T) {
+	issues := CheckEnv(envLookup(map[string]string{
+		"CLAI_SUGGESTIONS_MAX_RESULTS":  "lots",
+		"CLAI_SUGGESTIONS_WEIGHTS_TASK": "3",
+		"CLAI_DAEMON_LOG_FORMAT":        "xml",
+		"CLAI_AI_ENABLED":               "true",
+		"CLAI_DAEMON_SOCKET_PATH":       "/tmp/a.sock",
+		"CLAI_SOCKET":                   "/tmp/b.sock",
+	}), filepath.Join(t.TempDir(), "missing.yaml"))
+
+	for name, substr := range map[string]string{
+		"CLAI_SUGGESTIONS_MAX_RESULTS":  "not a valid integer",
+		"CLAI_SUGGESTIONS_WEIGHTS_TASK": "clamping",
+		"CLAI_DAEMON_LOG_FORMAT":        "log_format",
+		"CLAI_DAEMON_SOCKET_PATH":       "CLAI_SOCKET wins",
+	} {
+		if !issueFor(issues, name, substr) {
+			t.Errorf("missing %s issue %q in %+v", name, substr, issues)
+		}
+	}
+	if issueFor(issues, "CLAI_AI_ENABLED", "") {
+		t.Errorf("valid CLAI_AI_ENABLED reported: %+v", issues)
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EphemeralDirFor returns the suggestions.ephemeral_dirs entry covering
+// cwd, if any. Commands run there are kept in memory for the session but
+// never persisted, as in an incognito session.
+func (s *SuggestionsConfig) EphemeralDirFor(cwd string) (string, bool) {
+	return MatchEphemeralDir(s.EphemeralDirs, cwd)
+}
+
+// MatchEphemeralDir returns the first of dirs that is cwd or one of its
+// parents. An entry may start with ~ for the home directory and may hold
+// glob patterns, matched against each parent in turn ("~/work/*/secrets").
+func MatchEphemeralDir(dirs []string, cwd string) (string, bool) {
+	if cwd == "" || len(dirs) == 0 {
+		return "", false
+	}
+	cwd = filepath.Clean(cwd)
+	for _, dir := range dirs {
+		pattern := expandHomeDir(dir)
+		if pattern == "" {
+			continue
+		}
+		for p := cwd; ; p = filepath.Dir(p) {
+			if ok, err := filepath.Match(pattern, p); err == nil && ok {
+				return dir, true
+			}
+			if parent := filepath.Dir(p); parent == p {
+				break
+			}
+		}
+	}
+	return "", false
+}
+
+// expandHomeDir cleans dir and replaces a leading ~ with the home directory.
+func expandHomeDir(dir string) string {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return ""
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = home + dir[1:]
+	}
+	return filepath.Clean(dir)
+}
+
+// validateEphemeralDirs drops ephemeral directories that are empty or not
+// valid glob patterns.
+func (s *SuggestionsConfig) validateEphemeralDirs(warn func(string, string)) {
+	var kept []string
+	for i, dir := range s.EphemeralDirs {
+		field := fmt.Sprintf("ephemeral_dirs[%d]", i)
+		pattern := expandHomeDir(dir)
+		if pattern == "" {
+			warn(field, "empty directory; ignoring entry")
+			continue
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			warn(field, fmt.Sprintf("invalid pattern %q: %v; ignoring entry", dir, err))
+			continue
+		}
+		kept = append(kept, dir)
+	}
+	s.EphemeralDirs = kept
+}
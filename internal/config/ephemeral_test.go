@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchEphemeralDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dirs := []string{"~/secrets", "/src/*/credentials"}
+
+	tests := []struct {
+		cwd  string
+		want string
+	}{
+		{filepath.Join(home, "secrets"), "~/secrets"},
+		{filepath.Join(home, "secrets", "aws"), "~/secrets"},
+		{"/src/clai/credentials/prod", "/src/*/credentials"},
+		{filepath.Join(home, "secrets-other"), ""},
+		{"/src/clai", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got, ok := MatchEphemeralDir(dirs, tt.cwd)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("MatchEphemeralDir(%q) = %q, %v; want %q", tt.cwd, got, ok, tt.want)
+		}
+	}
+}
+
+func TestValidateAndFix_EphemeralDirs(t *testing.T) {
+	s := DefaultSuggestionsConfig()
+	s.EphemeralDirs = []string{"~/secrets", " ", "/src/[x"}
+	warnings := s.ValidateAndFix()
+	assertNoWarning(t, warnings, "ephemeral_dirs[0]")
+	assertWarningPresent(t, warnings, "ephemeral_dirs[1]")
+	assertWarningPresent(t, warnings, "ephemeral_dirs[2]")
+
+	if len(s.EphemeralDirs) != 1 || s.EphemeralDirs[0] != "~/secrets" {
+		t.Errorf("EphemeralDirs = %q, want only ~/secrets", s.EphemeralDirs)
+	}
+}
+
+func TestLoadEphemeralDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+	if got := LoadEphemeralDirs(); got != nil {
+		t.Errorf("LoadEphemeralDirs() without a config file = %q, want nil", got)
+	}
+
+	cfg := "suggestions:\n  ephemeral_dirs: [\"~/secrets\"]\n"
+	if err := os.WriteFile(filepath.Join(home, "config.yaml"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := LoadEphemeralDirs(); len(got) != 1 || got[0] != "~/secrets" {
+		t.Errorf("LoadEphemeralDirs() = %q, want [~/secrets]", got)
+	}
+}
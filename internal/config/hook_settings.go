@@ -0,0 +1,36 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// HookSettings holds the settings the shell hooks consult for every
+// command.
+type HookSettings struct {
+	EphemeralDirs []string      `yaml:"ephemeral_dirs"`
+	IngestFilters IngestFilters `yaml:"ingest_filters"`
+	ConfirmRisky  ConfirmRisky  `yaml:"confirm_risky"`
+	RiskPatterns  []RiskPattern `yaml:"risk_patterns"`
+}
+
+// LoadHookSettings reads only the suggestions settings in HookSettings
+// from the config file and their env overrides, for the shell hooks,
+// which must stay fast. An invalid file yields the zero settings.
+func LoadHookSettings() HookSettings {
+	var file struct {
+		Suggestions HookSettings `yaml:"suggestions"`
+	}
+	if err := decodeConfigFile(DefaultPaths().ConfigFile(), &file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return HookSettings{}
+	}
+	applyEnvOverlay(os.Getenv, &file)
+	return file.Suggestions
+}
+
+// LoadEphemeralDirs reads only suggestions.ephemeral_dirs from the config
+// file. See LoadHookSettings.
+func LoadEphemeralDirs() []string {
+	return LoadHookSettings().EphemeralDirs
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Reasons IngestFilters.Ignores reports for a dropped command.
+const (
+	IgnoreReasonPattern       = "pattern"
+	IgnoreReasonSpacePrefixed = "space_prefixed"
+	IgnoreReasonSingleChar    = "single_char"
+	IgnoreReasonDuplicate     = "duplicate"
+)
+
+// Ignores reports whether cmd must not be stored, and why. prev is the
+// command run just before it in the same session, or "" when unknown, in
+// which case duplicates are not collapsed.
+func (f IngestFilters) Ignores(cmd, prev string) (string, bool) {
+	if f.IgnoreSpacePrefixed && (strings.HasPrefix(cmd, " ") || strings.HasPrefix(cmd, "\t")) {
+		return IgnoreReasonSpacePrefixed, true
+	}
+	trimmed := strings.TrimSpace(cmd)
+	if trimmed == "" {
+		return "", false
+	}
+	if f.IgnoreSingleChar && utf8.RuneCountInString(trimmed) == 1 {
+		return IgnoreReasonSingleChar, true
+	}
+	if f.CollapseDuplicates && trimmed == strings.TrimSpace(prev) {
+		return IgnoreReasonDuplicate, true
+	}
+	for _, pattern := range f.IgnorePatterns {
+		re, err := compileIgnorePattern(pattern)
+		if err == nil && re.MatchString(trimmed) {
+			return IgnoreReasonPattern, true
+		}
+	}
+	return "", false
+}
+
+// compileIgnorePattern translates a HISTIGNORE-style glob to an anchored
+// regexp. Unlike file globs, * and ? also match "/" and spaces, so
+// "git *" covers every git command. \ escapes the next character and
+// [...] is a character class, negated by a leading ! or ^.
+func compileIgnorePattern(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	var b strings.Builder
+	b.WriteString(`^`)
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case '[':
+			end := i + 1
+			if end < len(runes) && (runes[end] == '!' || runes[end] == '^') {
+				end++
+			}
+			if end < len(runes) && runes[end] == ']' {
+				end++
+			}
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := runes[i+1 : end]
+			b.WriteString(`[`)
+			if class[0] == '!' || class[0] == '^' {
+				b.WriteString(`^`)
+				class = class[1:]
+			}
+			for _, c := range class {
+				if c == '\\' || c == '[' || c == ']' {
+					b.WriteByte('\\')
+				}
+				b.WriteRune(c)
+			}
+			b.WriteString(`]`)
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(`$`)
+	return regexp.Compile(b.String())
+}
+
+// validateIngestFilters drops ignore patterns that are empty or malformed.
+func (s *SuggestionsConfig) validateIngestFilters(warn func(string, string)) {
+	var kept []string
+	for i, pattern := range s.IngestFilters.IgnorePatterns {
+		if _, err := compileIgnorePattern(pattern); err != nil {
+			warn(fmt.Sprintf("ingest_filters.ignore_patterns[%d]", i),
+				fmt.Sprintf("invalid pattern %q: %v; ignoring entry", pattern, err))
+			continue
+		}
+		kept = append(kept, pattern)
+	}
+	s.IngestFilters.IgnorePatterns = kept
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIngestFilters_Ignores(t *testing.T) {
+	f := IngestFilters{
+		IgnorePatterns:      []string{"ls", "cd *", "[bf]g", "history*", `echo \*`},
+		IgnoreSpacePrefixed: true,
+		IgnoreSingleChar:    true,
+		CollapseDuplicates:  true,
+	}
+
+	tests := []struct {
+		cmd, prev string
+		want      string
+	}{
+		{"ls", "", IgnoreReasonPattern},
+		{"ls -la", "", ""},
+		{"cd /usr/local/bin", "", IgnoreReasonPattern},
+		{"cd", "", ""},
+		{"fg", "", IgnoreReasonPattern},
+		{"jg", "", ""},
+		{"history | grep ssh", "", IgnoreReasonPattern},
+		{"echo *", "", IgnoreReasonPattern},
+		{"echo hi", "", ""},
+		{" export TOKEN=secret", "", IgnoreReasonSpacePrefixed},
+		{"x", "", IgnoreReasonSingleChar},
+		{"make test", "make test ", IgnoreReasonDuplicate},
+		{"make test", "make build", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		got, ok := f.Ignores(tt.cmd, tt.prev)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("Ignores(%q, %q) = %q, %v; want %q", tt.cmd, tt.prev, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := (IngestFilters{}).Ignores(" ls", "ls"); ok {
+		t.Error("zero IngestFilters must keep every command")
+	}
+}
+
+func TestValidateAndFix_IngestFilters(t *testing.T) {
+	s := DefaultSuggestionsConfig()
+	s.IngestFilters.IgnorePatterns = []string{"ls", " ", "[abc"}
+	warnings := s.ValidateAndFix()
+	assertNoWarning(t, warnings, "ingest_filters.ignore_patterns[0]")
+	assertWarningPresent(t, warnings, "ingest_filters.ignore_patterns[1]")
+	assertWarningPresent(t, warnings, "ingest_filters.ignore_patterns[2]")
+
+	if got := s.IngestFilters.IgnorePatterns; len(got) != 1 || got[0] != "ls" {
+		t.Errorf("IgnorePatterns = %q, want only ls", got)
+	}
+}
+
+func TestLoadHookSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+
+	cfg := "suggestions:\n  ephemeral_dirs: [\"~/secrets\"]\n  ingest_filters:\n    ignore_patterns: [\"ls\"]\n    collapse_duplicates: true\n"
+	if err := os.WriteFile(filepath.Join(home, "config.yaml"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got := LoadHookSettings()
+	if len(got.EphemeralDirs) != 1 || len(got.IngestFilters.IgnorePatterns) != 1 || !got.IngestFilters.CollapseDuplicates {
+		t.Errorf("LoadHookSettings() = %+v", got)
+	}
+}
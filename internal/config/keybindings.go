@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// KeybindingsConfig holds the shell key bindings `clai init` installs.
+//
+// A binding is a sequence of keys: "^R" is Ctrl+R, "^@" Ctrl+Space, "M-h"
+// Alt+H, and a lower-case letter or digit after a first key is typed as
+// is ("^Xh"). `clai init` translates bindings to each shell's notation.
+// An empty binding keeps the default.
+type KeybindingsConfig struct {
+	HistoryPicker string `yaml:"history_picker"` // Opens the history picker
+	SuggestWidget string `yaml:"suggest_widget"` // Opens the suggestion picker
+}
+
+// Default key bindings.
+const (
+	DefaultHistoryPickerKey = "M-h"
+	DefaultSuggestWidgetKey = "M-s"
+)
+
+// maxKeySequence caps the keys of one binding.
+const maxKeySequence = 3
+
+// Key is one key press of a binding.
+type Key struct {
+	Char byte // Upper-case letter or '@' with Ctrl; lower-case letter or digit otherwise
+	Ctrl bool
+	Alt  bool
+}
+
+// Label returns the key as shown to users, such as "Ctrl+R" or "Alt+H".
+func (k Key) Label() string {
+	switch {
+	case k.Ctrl && k.Char == '@':
+		return "Ctrl+Space"
+	case k.Ctrl:
+		return "Ctrl+" + string(k.Char)
+	case k.Alt:
+		return "Alt+" + strings.ToUpper(string(k.Char))
+	}
+	return string(k.Char)
+}
+
+// ParseKeySpec parses a key binding such as "^R", "M-h" or "^Xh".
+func ParseKeySpec(spec string) ([]Key, error) {
+	var keys []Key
+	for rest := spec; rest != ""; {
+		var key Key
+		switch {
+		case strings.HasPrefix(rest, "^") && len(rest) > 1:
+			c := rest[1]
+			if c >= 'a' && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			if (c < 'A' || c > 'Z') && c != '@' {
+				return nil, fmt.Errorf("invalid key binding %q: ^ must be followed by a letter or @", spec)
+			}
+			key = Key{Char: c, Ctrl: true}
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "M-") && len(rest) > 2:
+			if !isPlainKey(rest[2]) {
+				return nil, fmt.Errorf("invalid key binding %q: M- must be followed by a lower-case letter or digit", spec)
+			}
+			key = Key{Char: rest[2], Alt: true}
+			rest = rest[3:]
+		case isPlainKey(rest[0]) && len(keys) > 0:
+			key = Key{Char: rest[0]}
+			rest = rest[1:]
+		default:
+			return nil, fmt.Errorf("invalid key binding %q: use ^X for Ctrl+X or M-x for Alt+X", spec)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("empty key binding")
+	}
+	if len(keys) > maxKeySequence {
+		return nil, fmt.Errorf("invalid key binding %q: at most %d keys", spec, maxKeySequence)
+	}
+	if len(keys) == 1 && keys[0] == (Key{Char: 'X', Ctrl: true}) {
+		return nil, fmt.Errorf("invalid key binding %q: Ctrl+X is the prefix of clai's own bindings", spec)
+	}
+	return keys, nil
+}
+
+func isPlainKey(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
+
+// WithDefaults returns the bindings with empty ones set to the defaults.
+func (k KeybindingsConfig) WithDefaults() KeybindingsConfig {
+	if k.HistoryPicker == "" {
+		k.HistoryPicker = DefaultHistoryPickerKey
+	}
+	if k.SuggestWidget == "" {
+		k.SuggestWidget = DefaultSuggestWidgetKey
+	}
+	return k
+}
+
+// validate checks both bindings parse and differ.
+func (k KeybindingsConfig) validate() error {
+	k = k.WithDefaults()
+	if _, err := ParseKeySpec(k.HistoryPicker); err != nil {
+		return fmt.Errorf("client.keybindings.history_picker: %w", err)
+	}
+	if _, err := ParseKeySpec(k.SuggestWidget); err != nil {
+		return fmt.Errorf("client.keybindings.suggest_widget: %w", err)
+	}
+	if k.HistoryPicker == k.SuggestWidget {
+		return fmt.Errorf("client.keybindings: history_picker and suggest_widget are both %q", k.HistoryPicker)
+	}
+	return nil
+}
+
+// LoadKeybindings reads only the key bindings from the config file and
+// their env overrides, for `clai init`, which must stay fast. An invalid
+// file or binding yields the defaults.
+func LoadKeybindings() KeybindingsConfig {
+	var file struct {
+		Client struct {
+			Keybindings KeybindingsConfig `yaml:"keybindings"`
+		} `yaml:"client"`
+	}
+	if err := decodeConfigFile(DefaultPaths().ConfigFile(), &file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return KeybindingsConfig{}.WithDefaults()
+	}
+	applyEnvOverlay(os.Getenv, &file)
+	k := file.Client.Keybindings.WithDefaults()
+	if k.validate() != nil {
+		return KeybindingsConfig{}.WithDefaults()
+	}
+	return k
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKeySpec(t *testing.T) {
+	tests := []struct {
+		spec   string
+		want   []Key
+		labels string
+	}{
+		{"^R", []Key{{Char: 'R', Ctrl: true}}, "Ctrl+R"},
+		{"^r", []Key{{Char: 'R', Ctrl: true}}, "Ctrl+R"},
+		{"^@", []Key{{Char: '@', Ctrl: true}}, "Ctrl+Space"},
+		{"M-h", []Key{{Char: 'h', Alt: true}}, "Alt+H"},
+		{"^Xh", []Key{{Char: 'X', Ctrl: true}, {Char: 'h'}}, "Ctrl+X h"},
+		{"^X^R", []Key{{Char: 'X', Ctrl: true}, {Char: 'R', Ctrl: true}}, "Ctrl+X Ctrl+R"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			keys, err := ParseKeySpec(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseKeySpec(%q) error: %v", tt.spec, err)
+			}
+			if len(keys) != len(tt.want) {
+				t.Fatalf("ParseKeySpec(%q) = %+v, want %+v", tt.spec, keys, tt.want)
+			}
+			var labels []string
+			for i := range keys {
+				if keys[i] != tt.want[i] {
+					t.Errorf("key %d = %+v, want %+v", i, keys[i], tt.want[i])
+				}
+				labels = append(labels, keys[i].Label())
+			}
+			if got := strings.Join(labels, " "); got != tt.labels {
+				t.Errorf("labels = %q, want %q", got, tt.labels)
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "h", "^", "^1", "M-", "M-H", "M-'", "^R'", "^X^X^X^X", "^X", `\eh`} {
+		if _, err := ParseKeySpec(spec); err == nil {
+			t.Errorf("ParseKeySpec(%q) should fail", spec)
+		}
+	}
+}
+
+func TestKeybindingsValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default keybindings invalid: %v", err)
+	}
+
+	cfg.Client.Keybindings = KeybindingsConfig{}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("empty keybindings should fall back to defaults: %v", err)
+	}
+
+	cfg.Client.Keybindings = KeybindingsConfig{HistoryPicker: "^R", SuggestWidget: "^R"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for duplicate bindings")
+	}
+
+	cfg.Client.Keybindings = KeybindingsConfig{HistoryPicker: "ctrl-r"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for invalid binding")
+	}
+
+	if err := cfg.Set("client.keybindings.history_picker", "alt-h"); err == nil {
+		t.Error("Set should reject an invalid binding")
+	}
+}
@@ -0,0 +1,373 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Keys beyond the two-level settings handled field by field in Get and
+// Set are resolved against the YAML form of the config: dotted names walk
+// sections and map entries, and [i] indexes a list, as in
+// "suggestions.weights.transition" or "history.picker_tabs[1].label".
+
+var errUnknownField = errors.New("unknown field")
+
+// keySegment is one step of a key path: a field or map key, or a list
+// index when index >= 0.
+type keySegment struct {
+	name  string
+	index int
+}
+
+func (s keySegment) String() string {
+	if s.index >= 0 {
+		return "[" + strconv.Itoa(s.index) + "]"
+	}
+	return s.name
+}
+
+// parseKeyPath splits key into segments.
+func parseKeyPath(key string) ([]keySegment, error) {
+	var segs []keySegment
+	for _, part := range strings.Split(key, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		if name == "" {
+			return nil, fmt.Errorf("invalid key %q", key)
+		}
+		segs = append(segs, keySegment{name: name, index: -1})
+		if rest == "" {
+			continue
+		}
+		for _, idx := range strings.Split(strings.TrimSuffix(rest, "]"), "][") {
+			i, err := strconv.Atoi(idx)
+			if err != nil || i < 0 || !strings.HasSuffix(rest, "]") {
+				return nil, fmt.Errorf("invalid list index in key %q", key)
+			}
+			segs = append(segs, keySegment{index: i})
+		}
+	}
+	return segs, nil
+}
+
+// keyType returns the Go type of the setting at segs, or an error naming
+// the first segment that is not part of the schema.
+func keyType(segs []keySegment) (reflect.Type, error) {
+	t := reflect.TypeOf(Config{})
+	for i, seg := range segs {
+		if t == reflect.TypeOf(yaml.Node{}) {
+			// Inside a profile preset: a partial Config.
+			t = reflect.TypeOf(Config{})
+		}
+		var ok bool
+		switch {
+		case seg.index >= 0:
+			ok = t.Kind() == reflect.Slice
+			if ok {
+				t = t.Elem()
+			}
+		case t.Kind() == reflect.Struct:
+			t, ok = yamlFields(t)[seg.name]
+		case t.Kind() == reflect.Map:
+			t, ok = t.Elem(), true
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errUnknownField, joinSegments(segs[:i+1]))
+		}
+	}
+	return t, nil
+}
+
+func joinSegments(segs []keySegment) string {
+	var b strings.Builder
+	for i, seg := range segs {
+		if i > 0 && seg.index < 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(seg.String())
+	}
+	return b.String()
+}
+
+// configNode returns the YAML form of c, a mapping.
+func configNode(c *Config) (*yaml.Node, error) {
+	var root yaml.Node
+	if err := root.Encode(c); err != nil {
+		return nil, err
+	}
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		return root.Content[0], nil
+	}
+	return &root, nil
+}
+
+// child returns the node at seg below n, creating a missing mapping entry
+// when create is set. A missing list index is always an error.
+func child(n *yaml.Node, seg keySegment, create bool) (*yaml.Node, error) {
+	if seg.index >= 0 {
+		if n.Kind != yaml.SequenceNode || seg.index >= len(n.Content) {
+			return nil, fmt.Errorf("index %d out of range (%d entries)", seg.index, len(n.Content))
+		}
+		return n.Content[seg.index], nil
+	}
+	if n.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == seg.name {
+			return n.Content[i+1], nil
+		}
+	}
+	if !create {
+		return nil, nil
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg.name}, v)
+	return v, nil
+}
+
+// lookupPath returns the node at key, or nil when it is not set.
+func (c *Config) lookupPath(key string) (*yaml.Node, error) {
+	segs, err := parseKeyPath(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := keyType(segs); err != nil {
+		return nil, err
+	}
+	n, err := configNode(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, seg := range segs {
+		if n, err = child(n, seg, false); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if n == nil {
+			return nil, nil
+		}
+	}
+	return n, nil
+}
+
+// getPath returns the value at key: a scalar as is, a list or section as
+// flow YAML, and "" when it is not set.
+func (c *Config) getPath(key string) (string, error) {
+	n, err := c.lookupPath(key)
+	if err != nil || n == nil {
+		return "", err
+	}
+	return nodeString(n)
+}
+
+// GetValue returns the value of key as Get does, typed by the setting:
+// a string, bool or number, a list, or a map for a section. It returns nil
+// for a list or section that is not set.
+func (c *Config) GetValue(key string) (any, error) {
+	value, err := c.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	segs, err := parseKeyPath(key)
+	if err != nil {
+		return nil, err
+	}
+	t, err := keyType(segs)
+	if err != nil {
+		return nil, err
+	}
+	if t.Kind() == reflect.String {
+		return value, nil
+	}
+	if value == "" {
+		return nil, nil
+	}
+	var v any
+	if err := yaml.Unmarshal([]byte(value), &v); err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return v, nil
+}
+
+func nodeString(n *yaml.Node) (string, error) {
+	if n.Kind == yaml.ScalarNode {
+		return n.Value, nil
+	}
+	flow := *n
+	flow.Style = yaml.FlowStyle
+	out, err := yaml.Marshal(&flow)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// valueNode parses value for a setting of type t: strings are taken
+// literally, booleans as by strconv.ParseBool, anything else as YAML
+// ("0.3", "[a, b]", "{id: x}").
+func valueNode(t reflect.Type, key, value string) (*yaml.Node, error) {
+	var n *yaml.Node
+	switch t.Kind() {
+	case reflect.String:
+		n = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		n = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}
+	default:
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(value), &doc); err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		if len(doc.Content) == 0 {
+			return nil, fmt.Errorf("invalid value for %s: empty", key)
+		}
+		n = doc.Content[0]
+	}
+	if err := n.Decode(reflect.New(t).Interface()); err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// setPath sets the value at key; see editPath.
+func (c *Config) setPath(key, value string) error {
+	return c.editPath(key, func(parent, cur *yaml.Node, last keySegment, t reflect.Type) error {
+		v, err := valueNode(t, key, value)
+		if err != nil {
+			return err
+		}
+		*cur = *v
+		return nil
+	})
+}
+
+// Add appends value to the list at key, for example a picker tab:
+//
+//	cfg.Add("history.picker_tabs", "{id: repo, label: Repo, provider: history}")
+func (c *Config) Add(key, value string) error {
+	return c.editPath(key, func(parent, cur *yaml.Node, last keySegment, t reflect.Type) error {
+		if t.Kind() != reflect.Slice {
+			return fmt.Errorf("%s is not a list", key)
+		}
+		v, err := valueNode(t.Elem(), key, value)
+		if err != nil {
+			return err
+		}
+		if cur.Kind != yaml.SequenceNode {
+			// A missing or null list.
+			*cur = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		}
+		cur.Content = append(cur.Content, v)
+		return nil
+	})
+}
+
+// Remove deletes the list entry ("suggestions.ephemeral_dirs[0]") or map
+// entry ("profiles.work") at key.
+func (c *Config) Remove(key string) error {
+	if n, err := c.lookupPath(key); err != nil {
+		return err
+	} else if n == nil {
+		return fmt.Errorf("%s is not set", key)
+	}
+	return c.editPath(key, func(parent, cur *yaml.Node, last keySegment, t reflect.Type) error {
+		switch {
+		case last.index >= 0:
+			parent.Content = slices.Delete(parent.Content, last.index, last.index+1)
+		case parent.Kind == yaml.MappingNode && isRemovable(key):
+			for i := 0; i+1 < len(parent.Content); i += 2 {
+				if parent.Content[i].Value == last.name {
+					parent.Content = slices.Delete(parent.Content, i, i+2)
+					break
+				}
+			}
+		default:
+			return fmt.Errorf("%s is not a list or map entry; set it instead", key)
+		}
+		return nil
+	})
+}
+
+// isRemovable reports whether the last segment of key names a map entry
+// or an override inside a profile preset, rather than a field every
+// config has.
+func isRemovable(key string) bool {
+	segs, err := parseKeyPath(key)
+	if err != nil || len(segs) < 2 {
+		return false
+	}
+	if segs[0].name == "profiles" {
+		return true
+	}
+	t, err := keyType(segs[:len(segs)-1])
+	return err == nil && t.Kind() == reflect.Map
+}
+
+// editPath applies edit to the YAML form of c at key, then decodes and
+// validates the result. c changes only when the result is valid: a value
+// ValidateAndFix would clamp or drop is rejected as well.
+func (c *Config) editPath(key string, edit func(parent, cur *yaml.Node, last keySegment, t reflect.Type) error) error {
+	segs, err := parseKeyPath(key)
+	if err != nil {
+		return err
+	}
+	t, err := keyType(segs)
+	if err != nil {
+		return err
+	}
+	root, err := configNode(c)
+	if err != nil {
+		return err
+	}
+	before, err := decodeConfigNode(root)
+	if err != nil {
+		return err
+	}
+
+	parent, cur := root, root
+	for i, seg := range segs {
+		parent = cur
+		if cur, err = child(parent, seg, i < len(segs)-1 || seg.index < 0); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if cur == nil {
+			return fmt.Errorf("%s: %s is not a section", key, joinSegments(segs[:i]))
+		}
+	}
+	if err := edit(parent, cur, segs[len(segs)-1], t); err != nil {
+		return err
+	}
+
+	next, err := decodeConfigNode(root)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	probe, _ := decodeConfigNode(root)
+	known := before.Suggestions.validateAndFix()
+	for _, w := range probe.Suggestions.validateAndFix() {
+		if !slices.Contains(known, w) {
+			return fmt.Errorf("invalid suggestions.%s: %s", w.Field, w.Message)
+		}
+	}
+	*c = *next
+	return nil
+}
+
+func decodeConfigNode(n *yaml.Node) (*Config, error) {
+	var cfg Config
+	if err := n.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
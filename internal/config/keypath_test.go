@@ -0,0 +1,155 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigGetPath(t *testing.T) {
+	cfg := DefaultConfig()
+
+	tests := map[string]string{
+		"suggestions.weights.transition": "0.3",
+		"history.picker_tabs[1].label":   "Global",
+		"history.picker_tabs[0].args":    "{session: $CLAI_SESSION_ID}",
+		"suggestions.ephemeral_dirs":     "[]",
+	}
+	for key, want := range tests {
+		got, err := cfg.Get(key)
+		if err != nil {
+			t.Errorf("Get(%q) error = %v", key, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Get(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	for _, key := range []string{"suggestions.weights.nope", "history.picker_tabs[5].label", "history.picker_tabs[x]", "ai.enabled[0]", "suggestions.weights..task"} {
+		if _, err := cfg.Get(key); err == nil {
+			t.Errorf("Get(%q) should have failed", key)
+		}
+	}
+}
+
+func TestConfigGetValue(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Suggestions.EphemeralDirs = []string{"~/secrets"}
+
+	tests := map[string]any{
+		"ai.enabled":                     cfg.AI.Enabled,
+		"suggestions.weights.transition": 0.3,
+		"suggestions.max_history":        cfg.Suggestions.MaxHistory,
+		"history.picker_tabs[1].label":   "Global",
+		"suggestions.ephemeral_dirs":     []any{"~/secrets"},
+		"history.picker_tabs[1]":         map[string]any{"id": "global", "label": "Global", "provider": "history", "args": map[string]any{"global": "true"}},
+	}
+	for key, want := range tests {
+		got, err := cfg.GetValue(key)
+		if err != nil {
+			t.Errorf("GetValue(%q) error = %v", key, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetValue(%q) = %#v, want %#v", key, got, want)
+		}
+	}
+
+	// Strings stay strings, even when they read as another type.
+	cfg.Daemon.SocketPath = "123"
+	if got, err := cfg.GetValue("daemon.socket_path"); err != nil || got != "123" {
+		t.Errorf("GetValue(daemon.socket_path) = %#v, %v", got, err)
+	}
+	for _, key := range ListKeys() {
+		if _, err := cfg.GetValue(key); err != nil {
+			t.Errorf("GetValue(%q) error = %v", key, err)
+		}
+	}
+	if _, err := cfg.GetValue("suggestions.weights.nope"); err == nil {
+		t.Error("GetValue of an unknown key should have failed")
+	}
+}
+
+func TestConfigSetPath(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if err := cfg.Set("suggestions.weights.transition", "0.5"); err != nil {
+		t.Fatalf("Set weight: %v", err)
+	}
+	if cfg.Suggestions.Weights.Transition != 0.5 {
+		t.Errorf("Transition = %v, want 0.5", cfg.Suggestions.Weights.Transition)
+	}
+	if err := cfg.Set("history.picker_tabs[1].label", "Everything"); err != nil {
+		t.Fatalf("Set tab label: %v", err)
+	}
+	if cfg.History.PickerTabs[1].Label != "Everything" {
+		t.Errorf("tab label = %q", cfg.History.PickerTabs[1].Label)
+	}
+	if err := cfg.Set("suggestions.ephemeral_dirs", "[~/a, ~/b]"); err != nil {
+		t.Fatalf("Set list: %v", err)
+	}
+	if got := strings.Join(cfg.Suggestions.EphemeralDirs, ","); got != "~/a,~/b" {
+		t.Errorf("EphemeralDirs = %q", got)
+	}
+	if err := cfg.Set("profiles.work.ai.enabled", "false"); err != nil {
+		t.Fatalf("Set preset: %v", err)
+	}
+	if got, _ := cfg.Get("profiles.work.ai.enabled"); got != "false" {
+		t.Errorf("profiles.work.ai.enabled = %q", got)
+	}
+
+	before := *cfg
+	for key, value := range map[string]string{
+		"suggestions.weights.transition": "2",   // would be clamped
+		"suggestions.weights.task":       "abc", // wrong type
+		"history.picker_tabs[9].label":   "x",   // out of range
+		"suggestions.weights.nope":       "0.1", // unknown
+	} {
+		if err := cfg.Set(key, value); err == nil {
+			t.Errorf("Set(%q, %q) should have failed", key, value)
+		}
+	}
+	if cfg.Suggestions.Weights.Transition != before.Suggestions.Weights.Transition {
+		t.Error("failed Set changed the config")
+	}
+}
+
+func TestConfigAddRemove(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if err := cfg.Add("history.picker_tabs", "{id: repo, label: Repo, provider: history}"); err != nil {
+		t.Fatalf("Add tab: %v", err)
+	}
+	if n := len(cfg.History.PickerTabs); n != 3 || cfg.History.PickerTabs[2].ID != "repo" {
+		t.Fatalf("PickerTabs = %+v", cfg.History.PickerTabs)
+	}
+	if err := cfg.Add("suggestions.ephemeral_dirs", "~/secrets"); err != nil {
+		t.Fatalf("Add dir: %v", err)
+	}
+	if len(cfg.Suggestions.EphemeralDirs) != 1 || cfg.Suggestions.EphemeralDirs[0] != "~/secrets" {
+		t.Errorf("EphemeralDirs = %v", cfg.Suggestions.EphemeralDirs)
+	}
+
+	if err := cfg.Remove("history.picker_tabs[0]"); err != nil {
+		t.Fatalf("Remove tab: %v", err)
+	}
+	if cfg.History.PickerTabs[0].ID != "global" || len(cfg.History.PickerTabs) != 2 {
+		t.Errorf("PickerTabs after remove = %+v", cfg.History.PickerTabs)
+	}
+	if err := cfg.Remove("history.picker_tabs[0].args.global"); err != nil {
+		t.Fatalf("Remove map entry: %v", err)
+	}
+	if len(cfg.History.PickerTabs[0].Args) != 0 {
+		t.Errorf("Args = %v", cfg.History.PickerTabs[0].Args)
+	}
+
+	for _, key := range []string{"ai.enabled", "history.picker_tabs[7]", "suggestions.ephemeral_dirs[3]"} {
+		if err := cfg.Remove(key); err == nil {
+			t.Errorf("Remove(%q) should have failed", key)
+		}
+	}
+	if err := cfg.Add("ai.enabled", "true"); err == nil {
+		t.Error("Add to a scalar should have failed")
+	}
+}
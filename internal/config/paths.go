@@ -3,27 +3,166 @@ package config
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
+// ProfileEnv names the environment variable that selects a clai profile.
+// Each profile gets its own daemon, socket, databases and cache, so
+// histories recorded under different profiles never mix.
+const ProfileEnv = "CLAI_PROFILE"
+
+// maxProfileLen bounds profile names so socket paths stay short.
+const maxProfileLen = 32
+
 // Paths holds all the path configurations for clai.
-// All paths are relative to the base directory (~/.clai on Unix, %APPDATA%\clai on Windows).
+//
+// BaseDir holds the runtime and state files: socket, PID and lock files,
+// logs and shell hooks. The config file, the databases and the caches
+// live in BaseDir too unless ConfigRoot, DataRoot or CacheRoot relocate
+// them (see DefaultPaths).
 type Paths struct {
 	// BaseDir is the root directory for all clai files (~/.clai)
 	BaseDir string
+
+	ConfigRoot string // config.yaml, trusted repositories, workflows ("" = BaseDir)
+	DataRoot   string // Databases and the database key ("" = BaseDir)
+	CacheRoot  string // Caches and spools ("" = BaseDir/cache)
 }
 
 // DefaultPaths returns the default paths.
 // Unix: ~/.clai
 // Windows: %APPDATA%\clai
+//
+// On Unix, when ~/.clai does not exist and CLAI_HOME is not set, setting
+// any of XDG_CONFIG_HOME, XDG_DATA_HOME, XDG_STATE_HOME or XDG_CACHE_HOME
+// selects the XDG layout: config in $XDG_CONFIG_HOME/clai, databases in
+// $XDG_DATA_HOME/clai, caches in $XDG_CACHE_HOME/clai and everything else
+// in $XDG_STATE_HOME/clai, each defaulting as the XDG spec says.
+//
+// CLAI_CONFIG_DIR moves the config root; paths.data_dir and
+// paths.cache_dir in the config file, or CLAI_DATA_DIR and CLAI_CACHE_DIR,
+// move the data and cache roots. clai's files go directly into these
+// roots, not into a clai subdirectory, so they should name a directory of
+// clai's own; uninstall --purge still removes only clai's files from them.
+// When CLAI_PROFILE is set, each root gets a profiles/<name> subdirectory.
 func DefaultPaths() *Paths {
+	return resolveLayout().paths(Profile())
+}
+
+// layout holds the roots of the default profile; an empty root falls back
+// as described on Paths.
+type layout struct {
+	base, config, data, cache string
+}
+
+// paths returns the paths of the named profile in l.
+func (l layout) paths(profile string) *Paths {
+	dir := func(root string) string {
+		if root == "" || profile == "" {
+			return root
+		}
+		return filepath.Join(root, "profiles", sanitizeProfile(profile))
+	}
+	return &Paths{BaseDir: dir(l.base), ConfigRoot: dir(l.config), DataRoot: dir(l.data), CacheRoot: dir(l.cache)}
+}
+
+// resolveLayout returns defaultLayout with the paths settings of the
+// default profile's config file and their env overrides applied. Profile
+// presets cannot set them: they would move the root every profile lives
+// under.
+func resolveLayout() layout {
+	l := defaultLayout()
+	var file struct {
+		Paths PathsConfig `yaml:"paths"`
+	}
+	if data, err := os.ReadFile(filepath.Join(l.configDir(), "config.yaml")); err == nil { //nolint:gosec // G304: config file path is from trusted source
+		if yaml.Unmarshal(data, &file) != nil {
+			file.Paths = PathsConfig{}
+		}
+	}
+	applyEnvOverlay(os.Getenv, &file)
+	if file.Paths.validate() != nil {
+		return l
+	}
+	if dir := expandHomeDir(file.Paths.DataDir); dir != "" {
+		l.data = dir
+	}
+	if dir := expandHomeDir(file.Paths.CacheDir); dir != "" {
+		l.cache = dir
+	}
+	return l
+}
+
+// defaultLayout returns the roots of the default profile from CLAI_HOME,
+// the XDG variables and CLAI_CONFIG_DIR, without reading the config file.
+func defaultLayout() layout {
+	l := layout{base: defaultBaseDir()}
+	if os.Getenv("CLAI_HOME") == "" && runtime.GOOS != "windows" && useXDG() {
+		home := homeDir()
+		l = layout{
+			base:   filepath.Join(xdgDir("XDG_STATE_HOME", filepath.Join(home, ".local", "state")), "clai"),
+			config: filepath.Join(xdgDir("XDG_CONFIG_HOME", filepath.Join(home, ".config")), "clai"),
+			data:   filepath.Join(xdgDir("XDG_DATA_HOME", filepath.Join(home, ".local", "share")), "clai"),
+			cache:  filepath.Join(xdgDir("XDG_CACHE_HOME", filepath.Join(home, ".cache")), "clai"),
+		}
+	}
+	if dir := expandHomeDir(os.Getenv("CLAI_CONFIG_DIR")); dir != "" {
+		l.config = dir
+	}
+	return l
+}
+
+func (l layout) configDir() string {
+	if l.config != "" {
+		return l.config
+	}
+	return l.base
+}
+
+// useXDG reports whether the XDG layout applies: an XDG base directory
+// variable is set and there is no ~/.clai to keep using.
+func useXDG() bool {
+	if _, err := os.Stat(filepath.Join(homeDir(), ".clai")); err == nil {
+		return false
+	}
+	for _, name := range []string{"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_STATE_HOME", "XDG_CACHE_HOME"} {
+		if xdgDir(name, "") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// xdgDir returns the XDG variable name, or def when it is unset or not
+// absolute, which the spec says to ignore.
+func xdgDir(name, def string) string {
+	if dir := os.Getenv(name); filepath.IsAbs(dir) {
+		return dir
+	}
+	return def
+}
+
+// validate checks that the relocated roots are absolute paths.
+func (p PathsConfig) validate() error {
+	for _, dir := range []struct{ key, value string }{{"data_dir", p.DataDir}, {"cache_dir", p.CacheDir}} {
+		if dir.value != "" && !filepath.IsAbs(expandHomeDir(dir.value)) {
+			return fmt.Errorf("paths.%s must be an absolute path or start with ~/ (got: %s)", dir.key, dir.value)
+		}
+	}
+	return nil
+}
+
+// defaultBaseDir returns the base directory of the default profile.
+func defaultBaseDir() string {
 	// Check for CLAI_HOME override first (works on all platforms)
 	if claiHome := os.Getenv("CLAI_HOME"); claiHome != "" {
-		return &Paths{
-			BaseDir: claiHome,
-		}
+		return claiHome
 	}
 
 	home := homeDir()
@@ -33,24 +172,117 @@ func DefaultPaths() *Paths {
 		if appData == "" {
 			appData = filepath.Join(home, "AppData", "Roaming")
 		}
-		return &Paths{
-			BaseDir: filepath.Join(appData, "clai"),
+		return filepath.Join(appData, "clai")
+	}
+
+	return filepath.Join(home, ".clai")
+}
+
+// Profile returns the active profile name from CLAI_PROFILE.
+// An empty string means the default profile.
+func Profile() string {
+	return strings.TrimSpace(os.Getenv(ProfileEnv))
+}
+
+// ProfileDir returns the directory for the active profile under base.
+// The default profile uses base itself. Invalid profile names are
+// sanitized so a typo can never escape base; use ValidateProfile to
+// report them to the user.
+func ProfileDir(base string) string {
+	name := Profile()
+	if name == "" {
+		return base
+	}
+	return filepath.Join(base, "profiles", sanitizeProfile(name))
+}
+
+// AllProfilePaths returns the paths of the default profile and of every
+// profile that has a directory under it, regardless of CLAI_PROFILE.
+func AllProfilePaths() []*Paths {
+	l := resolveLayout()
+	all := []*Paths{l.paths("")}
+	entries, err := os.ReadDir(filepath.Join(l.base, "profiles"))
+	if err != nil {
+		return all
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			all = append(all, l.paths(e.Name()))
 		}
 	}
+	return all
+}
 
-	return &Paths{
-		BaseDir: filepath.Join(home, ".clai"),
+// ValidateProfile checks that name is usable as a profile name: 1-32
+// letters, digits, '-' or '_'. The empty name (default profile) is valid.
+func ValidateProfile(name string) error {
+	if name == "" {
+		return nil
 	}
+	if len(name) > maxProfileLen {
+		return fmt.Errorf("invalid %s %q: at most %d characters", ProfileEnv, name, maxProfileLen)
+	}
+	if sanitizeProfile(name) != name {
+		return fmt.Errorf("invalid %s %q: use only letters, digits, '-' and '_'", ProfileEnv, name)
+	}
+	return nil
+}
+
+// sanitizeProfile replaces characters not allowed in profile names with '_'
+// and truncates the result to maxProfileLen.
+func sanitizeProfile(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !isProfileChar(c) {
+			b[i] = '_'
+		}
+	}
+	if len(b) > maxProfileLen {
+		b = b[:maxProfileLen]
+	}
+	return string(b)
+}
+
+func isProfileChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
 }
 
-// ConfigFile returns the path to the main configuration file.
+// ConfigFile returns the path to the main configuration file. The active
+// profile shares the default profile's file, and the presets in it, until
+// it has a config file of its own.
 func (p *Paths) ConfigFile() string {
-	return filepath.Join(p.BaseDir, "config.yaml")
+	path := filepath.Join(p.ConfigDir(), "config.yaml")
+	if Profile() == "" {
+		return path
+	}
+	root := defaultLayout().configDir()
+	if p.ConfigDir() != ProfileDir(root) {
+		return path
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	return filepath.Join(root, "config.yaml")
 }
 
 // DatabaseFile returns the path to the SQLite database.
 func (p *Paths) DatabaseFile() string {
-	return filepath.Join(p.BaseDir, "state.db")
+	return filepath.Join(p.DataDir(), "state.db")
+}
+
+// SuggestionsDBFile returns the path to the V2 suggestions database.
+func (p *Paths) SuggestionsDBFile() string {
+	return filepath.Join(p.DataDir(), "suggestions_v2.db")
+}
+
+// SuggestionsV1DBFile returns the path to the V1 suggestions database.
+func (p *Paths) SuggestionsV1DBFile() string {
+	return filepath.Join(p.DataDir(), "suggestions.db")
+}
+
+// DBKeyFile returns the default key file for encrypted databases.
+func (p *Paths) DBKeyFile() string {
+	return filepath.Join(p.DataDir(), "db.key")
 }
 
 // SocketFile returns the path to the Unix domain socket.
@@ -58,6 +290,12 @@ func (p *Paths) SocketFile() string {
 	return filepath.Join(p.BaseDir, "clai.sock")
 }
 
+// RemoteSocketFile returns the path at which, in remote mode, the local
+// machine's daemon socket is forwarded over SSH.
+func (p *Paths) RemoteSocketFile() string {
+	return filepath.Join(p.BaseDir, "remote.sock")
+}
+
 // PIDFile returns the path to the daemon PID file.
 func (p *Paths) PIDFile() string {
 	return filepath.Join(p.BaseDir, "clai.pid")
@@ -78,6 +316,13 @@ func (p *Paths) LogFile() string {
 	return filepath.Join(p.LogDir(), "daemon.log")
 }
 
+// StderrFile returns the path that receives the output of a spawned daemon:
+// panics and anything written before its log file is set up. It is kept
+// apart from LogFile, which the daemon rotates by renaming.
+func (p *Paths) StderrFile() string {
+	return filepath.Join(p.LogDir(), "daemon.stderr")
+}
+
 // HooksDir returns the path to the hooks directory.
 func (p *Paths) HooksDir() string {
 	return filepath.Join(p.BaseDir, "hooks")
@@ -97,9 +342,24 @@ func (p *Paths) WorkflowLogDir(ctx context.Context) string {
 
 // CacheDir returns the path to the cache directory.
 func (p *Paths) CacheDir() string {
+	if p.CacheRoot != "" {
+		return p.CacheRoot
+	}
 	return filepath.Join(p.BaseDir, "cache")
 }
 
+// IngestSpoolFile returns the path to the on-disk spool that holds command
+// events while the suggestions database falls behind.
+func (p *Paths) IngestSpoolFile() string {
+	return filepath.Join(p.CacheDir(), "ingest.spool")
+}
+
+// HookSpoolFile returns the path to the spool where clai-hook keeps command
+// events while the daemon is unreachable.
+func (p *Paths) HookSpoolFile() string {
+	return filepath.Join(p.CacheDir(), "hook.spool")
+}
+
 // SuggestionFile returns the path to the suggestion cache file.
 func (p *Paths) SuggestionFile() string {
 	return filepath.Join(p.CacheDir(), "suggestion")
@@ -114,6 +374,8 @@ func (p *Paths) LastOutputFile() string {
 func (p *Paths) EnsureDirectories() error {
 	dirs := []string{
 		p.BaseDir,
+		p.ConfigDir(),
+		p.DataDir(),
 		p.LogDir(),
 		p.HooksDir(),
 		p.CacheDir(),
@@ -141,15 +403,19 @@ func homeDir() string {
 	return home
 }
 
-// Deprecated compatibility methods - these now all return paths under BaseDir
-
-// ConfigDir returns the base directory (for backward compatibility).
+// ConfigDir returns the directory holding the config file.
 func (p *Paths) ConfigDir() string {
+	if p.ConfigRoot != "" {
+		return p.ConfigRoot
+	}
 	return p.BaseDir
 }
 
-// DataDir returns the base directory (for backward compatibility).
+// DataDir returns the directory holding the databases.
 func (p *Paths) DataDir() string {
+	if p.DataRoot != "" {
+		return p.DataRoot
+	}
 	return p.BaseDir
 }
 
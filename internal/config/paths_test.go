@@ -48,6 +48,63 @@ func TestDefaultPaths_CLAIHome(t *testing.T) {
 	}
 }
 
+func TestDefaultPaths_Profile(t *testing.T) {
+	t.Setenv("CLAI_HOME", "/custom/clai/home")
+	t.Setenv(ProfileEnv, "work")
+
+	paths := DefaultPaths()
+
+	want := filepath.Join("/custom/clai/home", "profiles", "work")
+	if paths.BaseDir != want {
+		t.Errorf("BaseDir = %s, want %s", paths.BaseDir, want)
+	}
+	if paths.SocketFile() != filepath.Join(want, "clai.sock") {
+		t.Errorf("SocketFile not under profile dir: %s", paths.SocketFile())
+	}
+}
+
+func TestProfileDir_SanitizesName(t *testing.T) {
+	t.Setenv(ProfileEnv, "../personal")
+
+	got := ProfileDir("/base")
+	if got != filepath.Join("/base", "profiles", "___personal") {
+		t.Errorf("ProfileDir() = %s, want sanitized name under /base/profiles", got)
+	}
+}
+
+func TestAllProfilePaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+	t.Setenv(ProfileEnv, "work")
+	for _, name := range []string{"work", "personal"} {
+		if err := os.MkdirAll(filepath.Join(home, "profiles", name), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var dirs []string
+	for _, p := range AllProfilePaths() {
+		dirs = append(dirs, p.BaseDir)
+	}
+	want := []string{home, filepath.Join(home, "profiles", "personal"), filepath.Join(home, "profiles", "work")}
+	if strings.Join(dirs, ",") != strings.Join(want, ",") {
+		t.Errorf("AllProfilePaths() = %v, want %v", dirs, want)
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	for _, name := range []string{"", "work", "personal-2", "a_b"} {
+		if err := ValidateProfile(name); err != nil {
+			t.Errorf("ValidateProfile(%q) error = %v", name, err)
+		}
+	}
+	for _, name := range []string{"..", "a/b", "has space", strings.Repeat("x", maxProfileLen+1)} {
+		if err := ValidateProfile(name); err == nil {
+			t.Errorf("ValidateProfile(%q) expected error", name)
+		}
+	}
+}
+
 func TestPaths_DerivedDirs(t *testing.T) {
 	paths := &Paths{BaseDir: "/test/clai"}
 
@@ -205,3 +262,105 @@ func TestDefaultPaths_Windows(t *testing.T) {
 		t.Errorf("On Windows, BaseDir should be in AppData: %s", paths.BaseDir)
 	}
 }
+
+// isolateLayout points HOME at an empty directory and clears every
+// variable that selects the layout.
+func isolateLayout(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, name := range []string{"CLAI_HOME", "CLAI_CONFIG_DIR", "CLAI_DATA_DIR", "CLAI_CACHE_DIR", ProfileEnv,
+		"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_STATE_HOME", "XDG_CACHE_HOME"} {
+		t.Setenv(name, "")
+	}
+	return home
+}
+
+func TestDefaultPaths_XDG(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("XDG layout is Unix-only")
+	}
+	home := isolateLayout(t)
+	t.Setenv("XDG_DATA_HOME", "/xdg/data")
+
+	paths := DefaultPaths()
+	want := map[string]string{
+		"BaseDir":      filepath.Join(home, ".local", "state", "clai"),
+		"ConfigFile":   filepath.Join(home, ".config", "clai", "config.yaml"),
+		"DatabaseFile": "/xdg/data/clai/state.db",
+		"CacheDir":     filepath.Join(home, ".cache", "clai"),
+		"SocketFile":   filepath.Join(home, ".local", "state", "clai", "clai.sock"),
+	}
+	got := map[string]string{
+		"BaseDir":      paths.BaseDir,
+		"ConfigFile":   paths.ConfigFile(),
+		"DatabaseFile": paths.DatabaseFile(),
+		"CacheDir":     paths.CacheDir(),
+		"SocketFile":   paths.SocketFile(),
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s = %s, want %s", name, got[name], w)
+		}
+	}
+
+	// An existing ~/.clai keeps the legacy layout.
+	if err := os.Mkdir(filepath.Join(home, ".clai"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if paths := DefaultPaths(); paths.BaseDir != filepath.Join(home, ".clai") || paths.DataDir() != paths.BaseDir {
+		t.Errorf("with ~/.clai: BaseDir = %s, DataDir = %s; want ~/.clai for both", paths.BaseDir, paths.DataDir())
+	}
+}
+
+func TestDefaultPaths_Relocated(t *testing.T) {
+	isolateLayout(t)
+	base := t.TempDir()
+	t.Setenv("CLAI_HOME", base)
+	t.Setenv("CLAI_CONFIG_DIR", filepath.Join(base, "etc"))
+	if err := os.MkdirAll(filepath.Join(base, "etc"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "etc", "config.yaml"), []byte("paths:\n  data_dir: /local/clai\n  cache_dir: /tmp/c\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CLAI_CACHE_DIR", "/fast/cache")
+	t.Setenv(ProfileEnv, "work")
+
+	paths := DefaultPaths()
+	if want := filepath.Join(base, "etc", "profiles", "work"); paths.ConfigDir() != want {
+		t.Errorf("ConfigDir = %s, want %s", paths.ConfigDir(), want)
+	}
+	if want := filepath.Join(base, "etc", "config.yaml"); paths.ConfigFile() != want {
+		t.Errorf("ConfigFile = %s, want the shared %s", paths.ConfigFile(), want)
+	}
+	if want := "/local/clai/profiles/work/state.db"; paths.DatabaseFile() != want {
+		t.Errorf("DatabaseFile = %s, want %s", paths.DatabaseFile(), want)
+	}
+	if want := "/fast/cache/profiles/work"; paths.CacheDir() != want {
+		t.Errorf("CacheDir = %s, want %s (CLAI_CACHE_DIR wins over the file)", paths.CacheDir(), want)
+	}
+	if want := filepath.Join(base, "profiles", "work"); paths.BaseDir != want {
+		t.Errorf("BaseDir = %s, want %s", paths.BaseDir, want)
+	}
+}
+
+func TestDefaultPaths_RelativeDataDirIgnored(t *testing.T) {
+	isolateLayout(t)
+	base := t.TempDir()
+	t.Setenv("CLAI_HOME", base)
+	t.Setenv("CLAI_DATA_DIR", "relative/dir")
+
+	if paths := DefaultPaths(); paths.DataDir() != base {
+		t.Errorf("DataDir = %s, want %s", paths.DataDir(), base)
+	}
+	cfg := DefaultConfig()
+	cfg.Paths.DataDir = "relative/dir"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "paths.data_dir") {
+		t.Errorf("Validate() error = %v, want paths.data_dir error", err)
+	}
+	cfg.Paths.DataDir = "~/data"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate(~/data) error = %v", err)
+	}
+}
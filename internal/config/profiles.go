@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A profile preset is a partial config under profiles.<name> in the config
+// file. When CLAI_PROFILE (or `clai --profile`) selects that name, the
+// preset is merged over the rest of the file: scalars and lists replace
+// the base values, maps and sections merge key by key.
+//
+//	suggestions:
+//	  enabled: true
+//	profiles:
+//	  work:
+//	    ai:
+//	      enabled: false
+
+// applyPreset decodes the preset of the named profile from raw over out,
+// which may be a *Config or a partial struct of one. An empty name or a
+// missing preset leaves out unchanged.
+func applyPreset(data []byte, name string, out any) error {
+	if name == "" {
+		return nil
+	}
+	var file struct {
+		Profiles map[string]yaml.Node `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	node, ok := file.Profiles[name]
+	if !ok {
+		return nil
+	}
+	if err := node.Decode(out); err != nil {
+		return fmt.Errorf("profile %q: %w", name, err)
+	}
+	return nil
+}
+
+// validatePresets checks that every preset decodes into a Config and does
+// not nest profiles of its own.
+func (c *Config) validatePresets() error {
+	for _, name := range c.PresetNames() {
+		if err := ValidateProfile(name); err != nil {
+			return fmt.Errorf("profiles: %w", err)
+		}
+		node := c.Profiles[name]
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("profiles.%s: must be a mapping", name)
+		}
+		for i := 0; i < len(node.Content); i += 2 {
+			switch node.Content[i].Value {
+			case "profiles":
+				return fmt.Errorf("profiles.%s: presets cannot define profiles", name)
+			case "paths":
+				return fmt.Errorf("profiles.%s: presets cannot set paths; every profile already gets its own directories", name)
+			}
+		}
+		if err := node.Decode(DefaultConfig()); err != nil {
+			return fmt.Errorf("profiles.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// PresetNames returns the names of the profile presets, sorted.
+func (c *Config) PresetNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadFile reads the config file as written, for editing: no profile
+// preset and no env overrides are applied, so saving the result never
+// copies them into the file. A missing file yields the defaults.
+func ReadFile(path string) (*Config, error) {
+	cfg := DefaultConfig()
+	data, err := os.ReadFile(path) //nolint:gosec // G304: config file path is from trusted source
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := doc.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	cfg.Suggestions.markWeightsSet(&doc)
+	return cfg, nil
+}
+
+// decodeConfigFile decodes the config file at path into out, a partial
+// struct of Config, then the active profile's preset over it.
+func decodeConfigFile(path string, out any) error {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: config file path is from trusted source
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return err
+	}
+	return applyPreset(data, Profile(), out)
+}
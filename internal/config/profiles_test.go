@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const presetConfig = `suggestions:
+  enabled: true
+  max_history: 7
+ai:
+  enabled: true
+profiles:
+  work:
+    ai:
+      enabled: false
+    suggestions:
+      ephemeral_dirs: ["~/secrets"]
+  home:
+    suggestions:
+      enabled: false
+`
+
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFromFile_ProfilePreset(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), presetConfig)
+
+	t.Setenv(ProfileEnv, "")
+	base, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !base.AI.Enabled || !base.Suggestions.Enabled || len(base.Suggestions.EphemeralDirs) != 0 {
+		t.Errorf("preset applied without a profile: %+v", base)
+	}
+	if got := strings.Join(base.PresetNames(), ","); got != "home,work" {
+		t.Errorf("PresetNames() = %s", got)
+	}
+
+	t.Setenv(ProfileEnv, "work")
+	work, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work.AI.Enabled {
+		t.Error("work preset should disable AI")
+	}
+	if !work.Suggestions.Enabled || work.Suggestions.MaxHistory != 7 {
+		t.Errorf("base suggestions lost under preset: %+v", work.Suggestions)
+	}
+	if len(work.Suggestions.EphemeralDirs) != 1 {
+		t.Errorf("EphemeralDirs = %v", work.Suggestions.EphemeralDirs)
+	}
+
+	t.Setenv(ProfileEnv, "other")
+	other, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !other.AI.Enabled {
+		t.Error("profile without a preset should use the base config")
+	}
+}
+
+func TestLoadFromFile_InvalidPreset(t *testing.T) {
+	for name, content := range map[string]string{
+		"nested":   "profiles:\n  work:\n    profiles:\n      x: {}\n",
+		"scalar":   "profiles:\n  work: true\n",
+		"bad type": "profiles:\n  home:\n    suggestions:\n      max_history: lots\n",
+		"bad name": "profiles:\n  ../x:\n    ai:\n      enabled: false\n",
+		"paths":    "profiles:\n  work:\n    paths:\n      data_dir: /x\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(ProfileEnv, "")
+			if _, err := LoadFromFile(writeConfig(t, t.TempDir(), content)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestConfigFile_ProfileFallsBackToDefault(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+	t.Setenv(ProfileEnv, "work")
+
+	want := filepath.Join(home, "config.yaml")
+	if got := DefaultPaths().ConfigFile(); got != want {
+		t.Errorf("ConfigFile() = %s, want the default profile's %s", got, want)
+	}
+
+	own := writeConfig(t, filepath.Join(home, "profiles", "work"), "ai:\n  enabled: false\n")
+	if got := DefaultPaths().ConfigFile(); got != own {
+		t.Errorf("ConfigFile() = %s, want the profile's own %s", got, own)
+	}
+}
+
+func TestReadFile_KeepsFileValues(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), presetConfig)
+	t.Setenv(ProfileEnv, "work")
+	t.Setenv("CLAI_SOCKET", "/tmp/env.sock")
+
+	cfg, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.AI.Enabled || cfg.Daemon.SocketPath == "/tmp/env.sock" {
+		t.Errorf("ReadFile applied preset or env: ai=%v socket=%s", cfg.AI.Enabled, cfg.Daemon.SocketPath)
+	}
+
+	out := filepath.Join(t.TempDir(), "config.yaml")
+	if err := cfg.SaveToFile(out); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(saved.PresetNames(), ",") != "home,work" {
+		t.Errorf("presets lost on save: %v", saved.PresetNames())
+	}
+}
+
+func TestLoadHookSettings_ProfilePreset(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+	writeConfig(t, home, presetConfig)
+
+	t.Setenv(ProfileEnv, "")
+	if dirs := LoadHookSettings().EphemeralDirs; len(dirs) != 0 {
+		t.Errorf("EphemeralDirs = %v without a profile", dirs)
+	}
+	t.Setenv(ProfileEnv, "work")
+	if dirs := LoadHookSettings().EphemeralDirs; len(dirs) != 1 || dirs[0] != "~/secrets" {
+		t.Errorf("EphemeralDirs = %v under the work preset", dirs)
+	}
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoConfigFile is the repository-local config file, relative to the
+// repository root.
+const RepoConfigFile = ".clai/config.yaml"
+
+// RepoConfig is the subset of settings a repository may override for
+// commands run inside it. Anything else in the file is rejected.
+//
+//	suggestions:
+//	  weights:
+//	    task: 0.3
+//	  task_playbook_path: ops/tasks.yaml
+//	history:
+//	  picker_tabs:
+//	    - {id: repo, label: Repo, provider: history}
+//	incognito: true
+type RepoConfig struct {
+	Suggestions RepoSuggestionsConfig `yaml:"suggestions"`
+	History     RepoHistoryConfig     `yaml:"history"`
+	Incognito   bool                  `yaml:"incognito"` // Never persist commands run in the repository
+}
+
+// RepoSuggestionsConfig holds the suggestions settings of a RepoConfig.
+type RepoSuggestionsConfig struct {
+	Weights          SuggestionsWeights `yaml:"weights"`
+	TaskPlaybookPath string             `yaml:"task_playbook_path"` // Relative to the repository root
+}
+
+// RepoHistoryConfig holds the history picker settings of a RepoConfig.
+type RepoHistoryConfig struct {
+	PickerTabs []TabDef `yaml:"picker_tabs"`
+}
+
+// RepoStatus describes the repository config found for a directory.
+type RepoStatus string
+
+// Repository config states.
+const (
+	RepoNone      RepoStatus = ""          // No repository or no config file
+	RepoApplied   RepoStatus = "applied"   // Trusted and merged
+	RepoUntrusted RepoStatus = "untrusted" // New or changed since `clai config trust`
+	RepoInvalid   RepoStatus = "invalid"   // Failed validation; ignored
+)
+
+// FindRepoRoot returns the nearest directory at or above dir that contains
+// .git, or "" outside a repository.
+func FindRepoRoot(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	for p := filepath.Clean(dir); ; p = filepath.Dir(p) {
+		if _, err := os.Stat(filepath.Join(p, ".git")); err == nil {
+			return p
+		}
+		if parent := filepath.Dir(p); parent == p {
+			return ""
+		}
+	}
+}
+
+// ParseRepoConfig strictly parses and validates a repository config.
+func ParseRepoConfig(data []byte) (*RepoConfig, error) {
+	var rc RepoConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&rc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", RepoConfigFile, err)
+	}
+	if err := rc.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", RepoConfigFile, err)
+	}
+	return &rc, nil
+}
+
+func (rc *RepoConfig) validate() error {
+	var problems []string
+	s := SuggestionsConfig{Weights: rc.Suggestions.Weights}
+	s.validateWeightFields(func(field, msg string) {
+		problems = append(problems, "suggestions."+field+": "+msg)
+	})
+	if p := rc.Suggestions.TaskPlaybookPath; p != "" {
+		if filepath.IsAbs(p) || !filepath.IsLocal(p) {
+			problems = append(problems, fmt.Sprintf("suggestions.task_playbook_path: %q must be a path inside the repository", p))
+		}
+	}
+	seen := make(map[string]bool, len(rc.History.PickerTabs))
+	for i, t := range rc.History.PickerTabs {
+		switch {
+		case t.ID == "":
+			problems = append(problems, fmt.Sprintf("history.picker_tabs[%d]: id is required", i))
+		case seen[t.ID]:
+			problems = append(problems, fmt.Sprintf("history.picker_tabs[%d]: duplicate id %q", i, t.ID))
+		}
+		seen[t.ID] = true
+		if t.Provider == "command" {
+			problems = append(problems, fmt.Sprintf("history.picker_tabs[%d]: provider command runs a program and is only allowed in your own config", i))
+		} else if t.Provider != "" && t.Provider != "history" {
+			problems = append(problems, fmt.Sprintf("history.picker_tabs[%d]: unknown provider %q", i, t.Provider))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// WithRepo returns a copy of c with the settings of the repository config
+// data merged over it: the weights set in the file, the playbook path
+// resolved against root, and the picker tabs, which replace the global
+// ones. data must have passed ParseRepoConfig.
+func (c *Config) WithRepo(root string, data []byte) (*Config, error) {
+	merged := *c
+	var overlay struct {
+		Suggestions struct {
+			Weights          yaml.Node `yaml:"weights"`
+			TaskPlaybookPath string    `yaml:"task_playbook_path"`
+		} `yaml:"suggestions"`
+		History RepoHistoryConfig `yaml:"history"`
+	}
+	if err := yaml.Unmarshal(data, &overlay); err != nil {
+		return nil, err
+	}
+	if w := overlay.Suggestions.Weights; w.Kind != 0 {
+		if err := w.Decode(&merged.Suggestions.Weights); err != nil {
+			return nil, err
+		}
+	}
+	if p := overlay.Suggestions.TaskPlaybookPath; p != "" {
+		merged.Suggestions.TaskPlaybookPath = filepath.Join(root, p)
+	}
+	if len(overlay.History.PickerTabs) > 0 {
+		merged.History.PickerTabs = overlay.History.PickerTabs
+	}
+	return &merged, nil
+}
+
+// LoadRepo merges the config of the repository containing dir over c when
+// it is trusted. It returns c itself when there is nothing to merge, along
+// with the repository's status; err explains RepoInvalid.
+func (c *Config) LoadRepo(paths *Paths, dir string) (cfg *Config, rc *RepoConfig, status RepoStatus, err error) {
+	root := FindRepoRoot(dir)
+	if root == "" {
+		return c, nil, RepoNone, nil
+	}
+	file := filepath.Join(root, RepoConfigFile)
+	if file == paths.ConfigFile() || file == filepath.Join(defaultLayout().configDir(), "config.yaml") {
+		// A home directory under version control: that is the global config.
+		return c, nil, RepoNone, nil
+	}
+	data, err := os.ReadFile(file) //nolint:gosec // G304: fixed name under the repository root
+	if err != nil {
+		return c, nil, RepoNone, nil
+	}
+	rc, err = ParseRepoConfig(data)
+	if err != nil {
+		return c, nil, RepoInvalid, err
+	}
+	if !IsRepoTrusted(paths, root, data) {
+		return c, rc, RepoUntrusted, nil
+	}
+	merged, err := c.WithRepo(root, data)
+	if err != nil {
+		return c, nil, RepoInvalid, err
+	}
+	return merged, rc, RepoApplied, nil
+}
+
+// RepoConfigHash returns the digest trust is recorded against, so any
+// change to the file needs to be trusted again.
+func RepoConfigHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// TrustedReposFile returns the file recording which repository configs
+// the user trusted.
+func (p *Paths) TrustedReposFile() string {
+	return filepath.Join(p.ConfigDir(), "trusted_repos.json")
+}
+
+// readTrustedRepos returns repository root -> trusted config hash.
+func readTrustedRepos(paths *Paths) map[string]string {
+	trusted := map[string]string{}
+	data, err := os.ReadFile(paths.TrustedReposFile())
+	if err == nil {
+		_ = json.Unmarshal(data, &trusted)
+	}
+	return trusted
+}
+
+// IsRepoTrusted reports whether data is the repository config last
+// trusted for root.
+func IsRepoTrusted(paths *Paths, root string, data []byte) bool {
+	return readTrustedRepos(paths)[root] == RepoConfigHash(data)
+}
+
+// TrustRepo records data as the trusted config of root. A nil data
+// removes root's entry.
+func TrustRepo(paths *Paths, root string, data []byte) error {
+	trusted := readTrustedRepos(paths)
+	if data == nil {
+		delete(trusted, root)
+	} else {
+		trusted[root] = RepoConfigHash(data)
+	}
+	out, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := paths.TrustedReposFile()
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil { //nolint:gosec // G301: matches the config directory
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, append(out, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// RepoIncognito reports whether dir is in a repository whose trusted
+// config sets incognito, for the shell hooks.
+func RepoIncognito(dir string) bool {
+	_, rc, status, _ := DefaultConfig().LoadRepo(DefaultPaths(), dir)
+	return status == RepoApplied && rc.Incognito
+}
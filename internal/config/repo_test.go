@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newRepo creates a git repository with a .clai/config.yaml holding
+// content and returns its root.
+func newRepo(t *testing.T, content string) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeConfig(t, filepath.Join(root, ".clai"), content)
+	return root
+}
+
+func TestParseRepoConfig(t *testing.T) {
+	rc, err := ParseRepoConfig([]byte(`suggestions:
+  weights:
+    task: 0.5
+  task_playbook_path: ops/tasks.yaml
+history:
+  picker_tabs:
+    - {id: repo, label: Repo, provider: history}
+incognito: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc.Suggestions.Weights.Task != 0.5 || !rc.Incognito || len(rc.History.PickerTabs) != 1 {
+		t.Errorf("ParseRepoConfig() = %+v", rc)
+	}
+
+	if _, err := ParseRepoConfig(nil); err != nil {
+		t.Errorf("empty file: %v", err)
+	}
+
+	for name, content := range map[string]string{
+		"outside subset":  "daemon:\n  socket_path: /tmp/x.sock\n",
+		"unknown section": "ai:\n  enabled: true\n",
+		"unknown weight":  "suggestions:\n  weights:\n    bogus: 1\n",
+		"weight range":    "suggestions:\n  weights:\n    task: 2\n",
+		"absolute path":   "suggestions:\n  task_playbook_path: /etc/tasks.yaml\n",
+		"escaping path":   "suggestions:\n  task_playbook_path: ../tasks.yaml\n",
+		"tab without id":  "history:\n  picker_tabs:\n    - {label: X}\n",
+		"duplicate tab":   "history:\n  picker_tabs:\n    - {id: a}\n    - {id: a}\n",
+		"bad provider":    "history:\n  picker_tabs:\n    - {id: a, provider: shell}\n",
+		"command tab":     "history:\n  picker_tabs:\n    - {id: a, provider: command, command: ls}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseRepoConfig([]byte(content)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestConfig_WithRepo(t *testing.T) {
+	base := DefaultConfig()
+	merged, err := base.WithRepo("/src/app", []byte(`suggestions:
+  weights:
+    task: 0.5
+  task_playbook_path: ops/tasks.yaml
+history:
+  picker_tabs:
+    - {id: repo}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Suggestions.Weights.Task != 0.5 {
+		t.Errorf("Task = %v", merged.Suggestions.Weights.Task)
+	}
+	if merged.Suggestions.Weights.Frequency != base.Suggestions.Weights.Frequency {
+		t.Error("weights not set in the repository config should keep their value")
+	}
+	if merged.Suggestions.TaskPlaybookPath != filepath.Join("/src/app", "ops", "tasks.yaml") {
+		t.Errorf("TaskPlaybookPath = %s", merged.Suggestions.TaskPlaybookPath)
+	}
+	if len(merged.History.PickerTabs) != 1 || merged.History.PickerTabs[0].ID != "repo" {
+		t.Errorf("PickerTabs = %+v", merged.History.PickerTabs)
+	}
+	if base.Suggestions.Weights.Task == 0.5 || len(base.History.PickerTabs) != 2 {
+		t.Error("WithRepo modified the base config")
+	}
+}
+
+func TestConfig_LoadRepo(t *testing.T) {
+	paths := &Paths{BaseDir: t.TempDir()}
+	root := newRepo(t, "incognito: true\nsuggestions:\n  weights:\n    task: 0.5\n")
+	sub := filepath.Join(root, "pkg", "x")
+	base := DefaultConfig()
+
+	if _, _, status, _ := base.LoadRepo(paths, t.TempDir()); status != RepoNone {
+		t.Errorf("outside a repository: status = %q", status)
+	}
+
+	cfg, rc, status, _ := base.LoadRepo(paths, sub)
+	if status != RepoUntrusted || cfg != base || !rc.Incognito {
+		t.Errorf("before trust: status = %q, merged = %v", status, cfg != base)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, RepoConfigFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := TrustRepo(paths, root, data); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _, status, _ = base.LoadRepo(paths, sub)
+	if status != RepoApplied || cfg.Suggestions.Weights.Task != 0.5 {
+		t.Errorf("after trust: status = %q, task = %v", status, cfg.Suggestions.Weights.Task)
+	}
+
+	writeConfig(t, filepath.Join(root, ".clai"), "incognito: false\n")
+	if _, _, status, _ = base.LoadRepo(paths, sub); status != RepoUntrusted {
+		t.Errorf("after a change: status = %q, want untrusted", status)
+	}
+
+	writeConfig(t, filepath.Join(root, ".clai"), "ai:\n  enabled: true\n")
+	_, _, status, err = base.LoadRepo(paths, sub)
+	if status != RepoInvalid || err == nil || !strings.Contains(err.Error(), RepoConfigFile) {
+		t.Errorf("invalid file: status = %q, err = %v", status, err)
+	}
+
+	if err := TrustRepo(paths, root, nil); err != nil {
+		t.Fatal(err)
+	}
+	if IsRepoTrusted(paths, root, data) {
+		t.Error("TrustRepo(nil) should revoke trust")
+	}
+}
+
+func TestRepoIncognito(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", home)
+	t.Setenv(ProfileEnv, "")
+	root := newRepo(t, "incognito: true\n")
+
+	if RepoIncognito(root) {
+		t.Error("untrusted repository config applied")
+	}
+	data, _ := os.ReadFile(filepath.Join(root, RepoConfigFile))
+	if err := TrustRepo(DefaultPaths(), root, data); err != nil {
+		t.Fatal(err)
+	}
+	if !RepoIncognito(filepath.Join(root, "sub")) {
+		t.Error("trusted incognito repository not detected")
+	}
+}
+
+func TestConfig_LoadRepo_HomeRepository(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAI_HOME", filepath.Join(home, ".clai"))
+	t.Setenv(ProfileEnv, "")
+	if err := os.MkdirAll(filepath.Join(home, ".git"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeConfig(t, filepath.Join(home, ".clai"), "ai:\n  enabled: true\n")
+
+	if _, _, status, err := DefaultConfig().LoadRepo(DefaultPaths(), home); status != RepoNone {
+		t.Errorf("global config taken for a repository config: %q %v", status, err)
+	}
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -46,6 +48,8 @@ func TestDefaultSuggestionsConfig_Weights(t *testing.T) {
 	assertFloat(t, "Weights.RiskPenalty", s.Weights.RiskPenalty, 0.20)
 	assertFloat(t, "Weights.ProjectTypeAffinity", s.Weights.ProjectTypeAffinity, 0.08)
 	assertFloat(t, "Weights.FailureRecovery", s.Weights.FailureRecovery, 0.12)
+	assertFloat(t, "Weights.TimeOfDay", s.Weights.TimeOfDay, 0.10)
+	assertFloat(t, "Weights.DayOfWeek", s.Weights.DayOfWeek, 0.08)
 }
 
 func TestDefaultSuggestionsConfig_Learning(t *testing.T) {
@@ -66,6 +70,8 @@ func TestDefaultSuggestionsConfig_Learning(t *testing.T) {
 	assertFloat(t, "WeightRiskMin", s.WeightRiskMin, 0.10)
 	assertFloat(t, "WeightRiskMax", s.WeightRiskMax, 0.60)
 	assertFloat(t, "SlotCorrelationMinConf", s.SlotCorrelationMinConf, 0.65)
+	assertStr(t, "Exploration", s.Exploration, "off")
+	assertFloat(t, "ExplorationRate", s.ExplorationRate, 0.10)
 }
 
 func TestDefaultSuggestionsConfig_Backpressure(t *testing.T) {
@@ -213,6 +219,8 @@ func TestDefaultSuggestionsConfig_Privacy(t *testing.T) {
 
 	assertStr(t, "IncognitoMode", s.IncognitoMode, "ephemeral")
 	assertBool(t, "RedactSensitiveTokens", s.RedactSensitiveTokens, true)
+	assertBool(t, "CaptureOutput", s.CaptureOutput, false)
+	assertInt(t, "OutputMaxBytes", s.OutputMaxBytes, 4096)
 }
 
 func TestDefaultSuggestionsConfig_Legacy(t *testing.T) {
@@ -249,6 +257,7 @@ func TestValidateAndFix_NegativeTimeouts(t *testing.T) {
 	}{
 		{func(s *SuggestionsConfig) { s.HardTimeoutMs = 0 }, func(s *SuggestionsConfig) int { return s.HardTimeoutMs }, "hard_timeout_ms=0", "hard_timeout_ms", defaults.HardTimeoutMs},
 		{func(s *SuggestionsConfig) { s.HardTimeoutMs = -1 }, func(s *SuggestionsConfig) int { return s.HardTimeoutMs }, "hard_timeout_ms=-1", "hard_timeout_ms", defaults.HardTimeoutMs},
+		{func(s *SuggestionsConfig) { s.InlineTimeoutMs = 0 }, func(s *SuggestionsConfig) int { return s.InlineTimeoutMs }, "inline_timeout_ms=0", "inline_timeout_ms", defaults.InlineTimeoutMs},
 		{func(s *SuggestionsConfig) { s.HookConnectTimeoutMs = 0 }, func(s *SuggestionsConfig) int { return s.HookConnectTimeoutMs }, "hook_connect_timeout_ms=0", "hook_connect_timeout_ms", defaults.HookConnectTimeoutMs},
 		{func(s *SuggestionsConfig) { s.HookWriteTimeoutMs = -5 }, func(s *SuggestionsConfig) int { return s.HookWriteTimeoutMs }, "hook_write_timeout_ms=-5", "hook_write_timeout_ms", defaults.HookWriteTimeoutMs},
 		{func(s *SuggestionsConfig) { s.IngestSyncWaitMs = 0 }, func(s *SuggestionsConfig) int { return s.IngestSyncWaitMs }, "ingest_sync_wait_ms=0", "ingest_sync_wait_ms", defaults.IngestSyncWaitMs},
@@ -281,6 +290,8 @@ func TestValidateAndFix_WeightsClamping(t *testing.T) {
 		{"above_one_feedback", func(s *SuggestionsConfig) { s.Weights.Feedback = 2.0 }, "weights.feedback", 1.0},
 		{"above_one_project_type", func(s *SuggestionsConfig) { s.Weights.ProjectTypeAffinity = 99.0 }, "weights.project_type_affinity", 1.0},
 		{"negative_failure_recovery", func(s *SuggestionsConfig) { s.Weights.FailureRecovery = -1.0 }, "weights.failure_recovery", 0.0},
+		{"above_one_time_of_day", func(s *SuggestionsConfig) { s.Weights.TimeOfDay = 1.2 }, "weights.time_of_day", 1.0},
+		{"negative_day_of_week", func(s *SuggestionsConfig) { s.Weights.DayOfWeek = -0.3 }, "weights.day_of_week", 0.0},
 	}
 
 	for _, tt := range tests {
@@ -355,6 +366,17 @@ func TestValidateAndFix_ByteSizes(t *testing.T) {
 	}
 }
 
+func TestCmdRawMaxBytesFor(t *testing.T) {
+	s := DefaultSuggestionsConfig()
+	s.CmdRawMaxBytesByShell = map[string]int{"fish": 4096, "bash": 0}
+	warnings := s.ValidateAndFix()
+	assertWarningPresent(t, warnings, "cmd_raw_max_bytes_by_shell.bash")
+
+	assertInt(t, "fish", s.CmdRawMaxBytesFor("fish"), 4096)
+	assertInt(t, "bash", s.CmdRawMaxBytesFor("bash"), s.CmdRawMaxBytes)
+	assertInt(t, "zsh", s.CmdRawMaxBytesFor("zsh"), s.CmdRawMaxBytes)
+}
+
 func TestValidateAndFix_RetentionDays(t *testing.T) {
 	// 0 is valid (disables time pruning)
 	s := DefaultSuggestionsConfig()
@@ -389,6 +411,144 @@ func TestValidateAndFix_RetentionMaxEvents(t *testing.T) {
 	assertNoWarning(t, warnings, "retention_max_events")
 }
 
+func TestValidateAndFix_MaxDBSizeMB(t *testing.T) {
+	s := DefaultSuggestionsConfig()
+	s.MaxDBSizeMB = -5
+	warnings := s.ValidateAndFix()
+	assertWarningPresent(t, warnings, "max_db_size_mb")
+	if s.MaxDBSizeMB != 0 {
+		t.Errorf("max_db_size_mb = %d, want 0", s.MaxDBSizeMB)
+	}
+
+	s = DefaultSuggestionsConfig()
+	s.MaxDBSizeMB = 200
+	warnings = s.ValidateAndFix()
+	assertNoWarning(t, warnings, "max_db_size_mb")
+}
+
+func TestValidateAndFix_SessionRecencyBoost(t *testing.T) {
+	s := DefaultSuggestionsConfig()
+	s.SessionRecencyBoost = SessionRecencyBoost{WindowMinutes: -1, Strength: -0.5}
+	warnings := s.ValidateAndFix()
+	assertWarningPresent(t, warnings, "session_recency_boost.window_minutes")
+	assertWarningPresent(t, warnings, "session_recency_boost.strength")
+	if s.SessionRecencyBoost != DefaultSuggestionsConfig().SessionRecencyBoost {
+		t.Errorf("session_recency_boost = %+v, want defaults", s.SessionRecencyBoost)
+	}
+
+	s = DefaultSuggestionsConfig()
+	s.SessionRecencyBoost = SessionRecencyBoost{}
+	warnings = s.ValidateAndFix()
+	assertNoWarning(t, warnings, "session_recency_boost.window_minutes")
+	assertNoWarning(t, warnings, "session_recency_boost.strength")
+}
+
+func TestValidateAndFix_LatencyBudget(t *testing.T) {
+	s := DefaultSuggestionsConfig()
+	s.LatencyBudget = LatencyBudget{IngestP95Ms: -1, SuggestP95Ms: -1, CooldownMinutes: 0}
+	warnings := s.ValidateAndFix()
+	assertWarningPresent(t, warnings, "latency_budget.ingest_p95_ms")
+	assertWarningPresent(t, warnings, "latency_budget.suggest_p95_ms")
+	assertWarningPresent(t, warnings, "latency_budget.cooldown_minutes")
+	if s.LatencyBudget != DefaultSuggestionsConfig().LatencyBudget {
+		t.Errorf("latency_budget = %+v, want defaults", s.LatencyBudget)
+	}
+
+	// 0 disables a budget.
+	s = DefaultSuggestionsConfig()
+	s.LatencyBudget.IngestP95Ms, s.LatencyBudget.SuggestP95Ms = 0, 0
+	warnings = s.ValidateAndFix()
+	assertNoWarning(t, warnings, "latency_budget.ingest_p95_ms")
+	assertNoWarning(t, warnings, "latency_budget.suggest_p95_ms")
+}
+
+func TestValidateAndFix_RetentionRules(t *testing.T) {
+	s := DefaultSuggestionsConfig()
+	s.RetentionRules = []RetentionRule{
+		{Repo: "/src/clai", Days: 0},
+		{Days: 30},
+		{Failed: true, Days: -1},
+		{Ephemeral: true, Days: 7},
+	}
+	warnings := s.ValidateAndFix()
+	assertWarningPresent(t, warnings, "retention_rules[1]")
+	assertWarningPresent(t, warnings, "retention_rules[2]")
+	assertNoWarning(t, warnings, "retention_rules[0]")
+	assertNoWarning(t, warnings, "retention_rules[3]")
+
+	if len(s.RetentionRules) != 2 || s.RetentionRules[0].Repo != "/src/clai" || !s.RetentionRules[1].Ephemeral {
+		t.Errorf("RetentionRules = %+v, want the repo and ephemeral rules", s.RetentionRules)
+	}
+}
+
+func TestValidateAndFix_RedactPatterns(t *testing.T) {
+	s := DefaultSuggestionsConfig()
+	s.RedactPatterns = []string{`corp-[0-9]{6}`, `(unclosed`}
+	warnings := s.ValidateAndFix()
+	assertWarningPresent(t, warnings, "redact_patterns[1]")
+	assertNoWarning(t, warnings, "redact_patterns[0]")
+
+	if len(s.RedactPatterns) != 1 || s.RedactPatterns[0] != `corp-[0-9]{6}` {
+		t.Errorf("RedactPatterns = %q, want only the valid pattern", s.RedactPatterns)
+	}
+}
+
+func TestValidateAndFix_RiskPatterns(t *testing.T) {
+	s := DefaultSuggestionsConfig()
+	s.RiskPatterns = []RiskPattern{
+		{Name: "terraform destroy prod", Pattern: `terraform\s+destroy.*prod`, Message: "destroys production"},
+		{Pattern: `(unclosed`},
+		{Name: "kubectl delete", Allow: []string{`-n\s+dev`, `[`}},
+		{Pattern: `helm\s+rollback`, Level: "scary"},
+		{Message: "nothing to match"},
+		{Name: "kubectl delete", Allow: []string{`-n\s+dev`}},
+	}
+	warnings := s.ValidateAndFix()
+	assertNoWarning(t, warnings, "risk_patterns[0]")
+	assertWarningPresent(t, warnings, "risk_patterns[1]")
+	assertWarningPresent(t, warnings, "risk_patterns[2]")
+	assertWarningPresent(t, warnings, "risk_patterns[3]")
+	assertWarningPresent(t, warnings, "risk_patterns[4]")
+	assertNoWarning(t, warnings, "risk_patterns[5]")
+
+	if len(s.RiskPatterns) != 2 || s.RiskPatterns[0].Name != "terraform destroy prod" || s.RiskPatterns[1].Name != "kubectl delete" {
+		t.Errorf("RiskPatterns = %+v, want the terraform and kubectl patterns", s.RiskPatterns)
+	}
+}
+
+func TestValidateAndFix_ProjectTypes(t *testing.T) {
+	s := DefaultSuggestionsConfig()
+	s.ProjectTypes = []ProjectType{
+		{Name: " terraform ", Markers: []string{"*.tf", ".terraform/"}, CommonCommands: []string{"terraform plan"}},
+		{Markers: []string{"deno.json"}},
+		{Name: "a|b", Markers: []string{"x"}},
+		{Name: "bazel"},
+		{Name: "broken", Markers: []string{"[abc"}},
+		{Name: "empty", Markers: []string{"/"}},
+	}
+	warnings := s.ValidateAndFix()
+	assertNoWarning(t, warnings, "project_types[0]")
+	for i := 1; i <= 5; i++ {
+		assertWarningPresent(t, warnings, fmt.Sprintf("project_types[%d]", i))
+	}
+
+	if len(s.ProjectTypes) != 1 || s.ProjectTypes[0].Name != "terraform" {
+		t.Errorf("ProjectTypes = %+v, want only terraform", s.ProjectTypes)
+	}
+}
+
+func TestValidateAndFix_DirectoryAffinityWeights(t *testing.T) {
+	s := DefaultSuggestionsConfig()
+	s.DirectoryAffinityWeights = []float64{1, 0.8, 0}
+	assertNoWarning(t, s.ValidateAndFix(), "directory_affinity_weights")
+
+	s.DirectoryAffinityWeights = []float64{1, 1.5}
+	assertWarningPresent(t, s.ValidateAndFix(), "directory_affinity_weights[1]")
+	if want := DefaultSuggestionsConfig().DirectoryAffinityWeights; !slices.Equal(s.DirectoryAffinityWeights, want) {
+		t.Errorf("DirectoryAffinityWeights = %v, want default %v", s.DirectoryAffinityWeights, want)
+	}
+}
+
 func TestValidateAndFix_OnlineLearningEta(t *testing.T) {
 	defaults := DefaultSuggestionsConfig()
 
@@ -569,6 +729,27 @@ func TestValidateAndFix_ShimMode(t *testing.T) {
 	}
 }
 
+func TestValidateAndFix_Exploration(t *testing.T) {
+	for _, mode := range []string{"off", "epsilon", "thompson"} {
+		t.Run("valid_"+mode, func(t *testing.T) {
+			s := DefaultSuggestionsConfig()
+			s.Exploration = mode
+			warnings := s.ValidateAndFix()
+			assertNoWarning(t, warnings, "exploration")
+		})
+	}
+
+	s := DefaultSuggestionsConfig()
+	s.Exploration = "ucb"
+	s.ExplorationRate = 1.5
+	warnings := s.ValidateAndFix()
+	assertWarningPresent(t, warnings, "exploration")
+	assertWarningPresent(t, warnings, "exploration_rate")
+	if s.Exploration != "off" || s.ExplorationRate != 0.10 {
+		t.Errorf("exploration = %q at %f, want defaults off at 0.10", s.Exploration, s.ExplorationRate)
+	}
+}
+
 func TestValidateAndFix_SearchFTSTokenizer(t *testing.T) {
 	validTokenizers := []string{"trigram", "unicode61"}
 	for _, tok := range validTokenizers {
@@ -771,6 +952,7 @@ func TestSuggestionsConfigYAMLRoundTrip(t *testing.T) {
 	cfg.Suggestions.WorkflowMinSteps = 2
 	cfg.Suggestions.WorkflowMaxSteps = 8
 	cfg.Suggestions.PipelineMaxSegments = 16
+	cfg.Suggestions.RetentionRules = []RetentionRule{{Repo: "/src/clai"}, {Ephemeral: true, Days: 7}}
 
 	if err := cfg.SaveToFile(configFile); err != nil {
 		t.Fatalf("SaveToFile failed: %v", err)
@@ -789,6 +971,9 @@ func TestSuggestionsConfigYAMLRoundTrip(t *testing.T) {
 	assertInt(t, "WorkflowMinSteps", loaded.Suggestions.WorkflowMinSteps, 2)
 	assertInt(t, "WorkflowMaxSteps", loaded.Suggestions.WorkflowMaxSteps, 8)
 	assertInt(t, "PipelineMaxSegments", loaded.Suggestions.PipelineMaxSegments, 16)
+	if got := loaded.Suggestions.RetentionRules; len(got) != 2 || got[0].Repo != "/src/clai" || !got[1].Ephemeral || got[1].Days != 7 {
+		t.Errorf("RetentionRules = %+v", got)
+	}
 }
 
 func TestLoadFromFile_AppliesEnvOverrides(t *testing.T) {
@@ -950,6 +1135,10 @@ func getWeightByName(s *SuggestionsConfig, name string) float64 {
 		return s.Weights.ProjectTypeAffinity
 	case "weights.failure_recovery":
 		return s.Weights.FailureRecovery
+	case "weights.time_of_day":
+		return s.Weights.TimeOfDay
+	case "weights.day_of_week":
+		return s.Weights.DayOfWeek
 	default:
 		return -999
 	}
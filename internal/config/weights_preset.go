@@ -0,0 +1,160 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// weightsPresets are the named ranking weight bundles selected by
+// suggestions.weights_preset. "default" is DefaultSuggestionsConfig's.
+var weightsPresets = map[string]SuggestionsWeights{
+	"default": DefaultSuggestionsConfig().Weights,
+	// Proven commands: what you run often and what succeeds, with risky
+	// ones pushed further down.
+	"conservative": {
+		Transition:          0.25,
+		Frequency:           0.30,
+		Success:             0.20,
+		Prefix:              0.15,
+		Affinity:            0.10,
+		Task:                0.03,
+		Feedback:            0.15,
+		RiskPenalty:         0.35,
+		ProjectTypeAffinity: 0.05,
+		FailureRecovery:     0.10,
+		TimeOfDay:           0.05,
+		DayOfWeek:           0.03,
+	},
+	// Context over habit: what usually follows, project tasks and time
+	// patterns outrank raw frequency, surfacing less-used commands.
+	"exploratory": {
+		Transition:          0.35,
+		Frequency:           0.10,
+		Success:             0.05,
+		Prefix:              0.10,
+		Affinity:            0.15,
+		Task:                0.15,
+		Feedback:            0.10,
+		RiskPenalty:         0.15,
+		ProjectTypeAffinity: 0.12,
+		FailureRecovery:     0.15,
+		TimeOfDay:           0.12,
+		DayOfWeek:           0.10,
+	},
+	// Typed text first: suggestions follow what is already on the command
+	// line, for people who type a few characters and expect completion.
+	"prefix-heavy": {
+		Transition:          0.20,
+		Frequency:           0.20,
+		Success:             0.10,
+		Prefix:              0.40,
+		Affinity:            0.08,
+		Task:                0.03,
+		Feedback:            0.10,
+		RiskPenalty:         0.20,
+		ProjectTypeAffinity: 0.05,
+		FailureRecovery:     0.08,
+		TimeOfDay:           0.05,
+		DayOfWeek:           0.03,
+	},
+}
+
+// WeightsPresetNames returns the names suggestions.weights_preset accepts,
+// sorted.
+func WeightsPresetNames() []string {
+	names := make([]string, 0, len(weightsPresets))
+	for name := range weightsPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WeightsPreset returns the weights of the named preset.
+func WeightsPreset(name string) (SuggestionsWeights, bool) {
+	w, ok := weightsPresets[name]
+	return w, ok
+}
+
+// applyWeightsPreset replaces every weight not set by the config with the
+// value of s.WeightsPreset, so weights set in the config override the preset
+// one by one, even when set to their default value.
+func (s *SuggestionsConfig) applyWeightsPreset() {
+	preset, ok := weightsPresets[s.WeightsPreset]
+	if !ok {
+		return
+	}
+	cur := reflect.ValueOf(&s.Weights).Elem()
+	p := reflect.ValueOf(preset)
+	for i := 0; i < cur.NumField(); i++ {
+		if !s.weightsSet[weightKey(cur.Type().Field(i))] {
+			cur.Field(i).SetFloat(p.Field(i).Float())
+		}
+	}
+}
+
+// weightKey returns the key of a SuggestionsWeights field under
+// suggestions.weights.
+func weightKey(f reflect.StructField) string {
+	key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	return key
+}
+
+// markWeightSet records that the weight at key, e.g. "task", is set by the
+// config rather than left to the preset.
+func (s *SuggestionsConfig) markWeightSet(key string) {
+	if s.weightsSet == nil {
+		s.weightsSet = make(map[string]bool)
+	}
+	s.weightsSet[key] = true
+}
+
+// markWeightsSet records the weights n sets, n being a config document, a
+// profile preset or an env overlay.
+func (s *SuggestionsConfig) markWeightsSet(n *yaml.Node) {
+	var doc struct {
+		Suggestions struct {
+			Weights map[string]yaml.Node `yaml:"weights"`
+		} `yaml:"suggestions"`
+	}
+	if n == nil || n.Decode(&doc) != nil {
+		return
+	}
+	for key := range doc.Suggestions.Weights {
+		s.markWeightSet(key)
+	}
+}
+
+// omitUnsetWeights drops from doc, the encoded config, the weights that
+// were never set and are still at their default, so saving the config does
+// not pin them against suggestions.weights_preset.
+func (s *SuggestionsConfig) omitUnsetWeights(doc *yaml.Node) {
+	sugg, err := child(doc, keySegment{name: "suggestions", index: -1}, false)
+	if err != nil || sugg == nil {
+		return
+	}
+	weights, err := child(sugg, keySegment{name: "weights", index: -1}, false)
+	if err != nil || weights == nil || weights.Kind != yaml.MappingNode {
+		return
+	}
+
+	cur := reflect.ValueOf(s.Weights)
+	def := reflect.ValueOf(DefaultSuggestionsConfig().Weights)
+	unset := make(map[string]bool)
+	for i := 0; i < cur.NumField(); i++ {
+		key := weightKey(cur.Type().Field(i))
+		if !s.weightsSet[key] && cur.Field(i).Float() == def.Field(i).Float() {
+			unset[key] = true
+		}
+	}
+	kept := weights.Content[:0]
+	for i := 0; i+1 < len(weights.Content); i += 2 {
+		if !unset[weights.Content[i].Value] {
+			kept = append(kept, weights.Content[i], weights.Content[i+1])
+		}
+	}
+	weights.Content = kept
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWeightsPresets_InRange(t *testing.T) {
+	for _, name := range WeightsPresetNames() {
+		w, _ := WeightsPreset(name)
+		s := DefaultSuggestionsConfig()
+		s.Weights = w
+		var warnings []string
+		s.validateWeightFields(func(field, msg string) { warnings = append(warnings, field+": "+msg) })
+		if len(warnings) > 0 {
+			t.Errorf("preset %s: %v", name, warnings)
+		}
+	}
+}
+
+func TestLoadFromFile_WeightsPreset(t *testing.T) {
+	t.Setenv(ProfileEnv, "")
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "suggestions:\n  weights_preset: prefix-heavy\n  weights:\n    task: 0.5\n")
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	preset, _ := WeightsPreset("prefix-heavy")
+	if cfg.Suggestions.Weights.Prefix != preset.Prefix || cfg.Suggestions.Weights.Transition != preset.Transition {
+		t.Errorf("weights = %+v, want the prefix-heavy values", cfg.Suggestions.Weights)
+	}
+	if cfg.Suggestions.Weights.Task != 0.5 {
+		t.Errorf("weights.task = %v, want the file's 0.5 over the preset", cfg.Suggestions.Weights.Task)
+	}
+
+	// Saving through clai keeps the weights the file did not set out of
+	// it, so they still follow the preset.
+	saved, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saved.Set("suggestions.weights_preset", "conservative"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := saved.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conservative, _ := WeightsPreset("conservative")
+	if cfg.Suggestions.Weights.Frequency != conservative.Frequency || cfg.Suggestions.Weights.Task != 0.5 {
+		t.Errorf("weights = %+v, want conservative with task 0.5", cfg.Suggestions.Weights)
+	}
+}
+
+func TestLoadFromFile_WeightsPresetKeepsPinnedDefault(t *testing.T) {
+	t.Setenv(ProfileEnv, "")
+	defaults := DefaultSuggestionsConfig().Weights
+	path := writeConfig(t, t.TempDir(), fmt.Sprintf(
+		"suggestions:\n  weights_preset: prefix-heavy\n  weights:\n    prefix: %v\n", defaults.Prefix))
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	preset, _ := WeightsPreset("prefix-heavy")
+	if cfg.Suggestions.Weights.Prefix != defaults.Prefix {
+		t.Errorf("weights.prefix = %v, want the pinned default %v over the preset's %v",
+			cfg.Suggestions.Weights.Prefix, defaults.Prefix, preset.Prefix)
+	}
+	if cfg.Suggestions.Weights.Transition != preset.Transition {
+		t.Errorf("weights.transition = %v, want the preset's %v", cfg.Suggestions.Weights.Transition, preset.Transition)
+	}
+
+	// The pin survives a save through clai.
+	saved, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saved.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Suggestions.Weights.Prefix != defaults.Prefix {
+		t.Errorf("after save, weights.prefix = %v, want the pinned default %v", cfg.Suggestions.Weights.Prefix, defaults.Prefix)
+	}
+}
+
+func TestWeightsPreset_Invalid(t *testing.T) {
+	t.Setenv(ProfileEnv, "")
+	cfg, err := LoadFromFile(writeConfig(t, t.TempDir(), "suggestions:\n  weights_preset: fast\n"))
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if cfg.Suggestions.WeightsPreset != "default" || cfg.Suggestions.Weights != DefaultSuggestionsConfig().Weights {
+		t.Errorf("preset = %q, weights = %+v; want the defaults", cfg.Suggestions.WeightsPreset, cfg.Suggestions.Weights)
+	}
+
+	if err := DefaultConfig().Set("suggestions.weights_preset", "fast"); err == nil || !strings.Contains(err.Error(), "exploratory") {
+		t.Errorf("Set(fast) error = %v, want the valid names", err)
+	}
+}
+
+func TestWeightsPreset_Env(t *testing.T) {
+	t.Setenv(ProfileEnv, "")
+	t.Setenv("CLAI_SUGGESTIONS_WEIGHTS_PRESET", "exploratory")
+	cfg, err := LoadFromFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := WeightsPreset("exploratory"); cfg.Suggestions.Weights != want {
+		t.Errorf("weights = %+v, want exploratory", cfg.Suggestions.Weights)
+	}
+}
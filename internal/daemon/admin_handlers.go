@@ -0,0 +1,108 @@
+package daemon
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+// ListSessions handles the ListSessions RPC.
+// It returns the sessions currently tracked in memory, oldest first.
+func (s *Server) ListSessions(ctx context.Context, req *pb.Ack) (*pb.ListSessionsResponse, error) {
+	s.touchActivity()
+
+	infos := s.sessionManager.GetAll()
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].StartedAt.Equal(infos[j].StartedAt) {
+			return infos[i].StartedAt.Before(infos[j].StartedAt)
+		}
+		return infos[i].SessionID < infos[j].SessionID
+	})
+
+	now := time.Now()
+	sessions := make([]*pb.SessionSummary, 0, len(infos))
+	for _, info := range infos {
+		summary := &pb.SessionSummary{
+			SessionId:          info.SessionID,
+			Shell:              info.Shell,
+			Os:                 info.OS,
+			Hostname:           info.Hostname,
+			Username:           info.Username,
+			Cwd:                info.CWD,
+			StartedAtUnixMs:    unixMsOrZero(info.StartedAt),
+			LastActivityUnixMs: unixMsOrZero(info.LastActivity),
+			Note:               info.Note,
+			Name:               info.Name,
+		}
+		if info.Degraded != "" && now.Before(info.DegradedUntil) {
+			summary.Degraded = info.Degraded
+			summary.DegradedUntilUnixMs = info.DegradedUntil.UnixMilli()
+		}
+		sessions = append(sessions, summary)
+	}
+	return &pb.ListSessionsResponse{Sessions: sessions}, nil
+}
+
+// KillSession handles the KillSession RPC.
+// It ends a session as if its shell had exited. Commands the shell sends
+// afterwards are no longer attributed to a live session.
+func (s *Server) KillSession(ctx context.Context, req *pb.KillSessionRequest) (*pb.Ack, error) {
+	s.touchActivity()
+
+	if !s.sessionManager.Exists(req.SessionId) {
+		return &pb.Ack{Ok: false, Error: "session not found"}, nil
+	}
+
+	if err := s.store.EndSession(ctx, req.SessionId, time.Now().UnixMilli()); err != nil {
+		s.logger.Warn("failed to end killed session",
+			"session_id", req.SessionId,
+			"error", err,
+		)
+	}
+	s.sessionManager.End(req.SessionId)
+	s.publishEvent(&pb.ShellEvent{
+		Type:      pb.ShellEventType_SHELL_EVENT_TYPE_SESSION_END,
+		SessionId: req.SessionId,
+	})
+
+	s.logger.Info("session killed", "session_id", req.SessionId)
+	return &pb.Ack{Ok: true}, nil
+}
+
+// FlushCaches handles the FlushCaches RPC.
+// It drops cached AI responses and cached V2 suggestions so the next
+// request is computed from scratch.
+func (s *Server) FlushCaches(ctx context.Context, req *pb.Ack) (*pb.FlushCachesResponse, error) {
+	s.touchActivity()
+
+	aiRemoved, err := s.store.ClearCache(ctx)
+	if err != nil {
+		s.logger.Warn("failed to flush cache", "error", err)
+		return &pb.FlushCachesResponse{Ok: false, Error: err.Error()}, nil
+	}
+	resp := &pb.FlushCachesResponse{Ok: true, AiCacheEntries: aiRemoved}
+
+	if s.v2db != nil {
+		result, err := s.v2db.ExecContext(ctx, `DELETE FROM suggestion_cache`)
+		if err != nil {
+			s.logger.Warn("failed to flush suggestion cache", "error", err)
+			return &pb.FlushCachesResponse{Ok: false, Error: err.Error(), AiCacheEntries: aiRemoved}, nil
+		}
+		resp.SuggestionCacheEntries, _ = result.RowsAffected()
+	}
+
+	s.logger.Info("flushed caches",
+		"ai_cache_entries", resp.AiCacheEntries,
+		"suggestion_cache_entries", resp.SuggestionCacheEntries,
+	)
+	return resp, nil
+}
+
+func unixMsOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
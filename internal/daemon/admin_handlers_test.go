@@ -0,0 +1,123 @@
+package daemon
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/storage"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+)
+
+func TestHandler_ListSessions_OldestFirst(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	for i, id := range []string{"newer", "older"} {
+		_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+			SessionId:       id,
+			Cwd:             "/tmp",
+			StartedAtUnixMs: now.Add(-time.Duration(i) * time.Hour).UnixMilli(),
+			Client:          &pb.ClientInfo{Shell: "zsh"},
+		})
+	}
+	server.sessionManager.SetNote("older", "debugging auth")
+
+	resp, err := server.ListSessions(ctx, &pb.Ack{})
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(resp.Sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(resp.Sessions))
+	}
+	first := resp.Sessions[0]
+	if first.SessionId != "older" || first.Note != "debugging auth" || first.Shell != "zsh" {
+		t.Errorf("first session = %+v, want older with note", first)
+	}
+	if first.StartedAtUnixMs == 0 || first.LastActivityUnixMs == 0 {
+		t.Errorf("expected timestamps to be set: %+v", first)
+	}
+}
+
+func TestHandler_KillSession(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "victim", Cwd: "/tmp"})
+
+	resp, err := server.KillSession(ctx, &pb.KillSessionRequest{SessionId: "victim"})
+	if err != nil || !resp.Ok {
+		t.Fatalf("KillSession = %v, %v", resp, err)
+	}
+	if server.sessionManager.Exists("victim") {
+		t.Error("killed session still tracked")
+	}
+
+	resp, err = server.KillSession(ctx, &pb.KillSessionRequest{SessionId: "victim"})
+	if err != nil {
+		t.Fatalf("KillSession failed: %v", err)
+	}
+	if resp.Ok || resp.Error != "session not found" {
+		t.Errorf("second KillSession = %+v, want session not found", resp)
+	}
+}
+
+func TestHandler_FlushCaches(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	store := server.store.(*mockStore)
+	store.cache["a"] = &storage.CacheEntry{CacheKey: "a"}
+	store.cache["b"] = &storage.CacheEntry{CacheKey: "b"}
+
+	resp, err := server.FlushCaches(ctx, &pb.Ack{})
+	if err != nil || !resp.Ok {
+		t.Fatalf("FlushCaches = %v, %v", resp, err)
+	}
+	if resp.AiCacheEntries != 2 {
+		t.Errorf("AiCacheEntries = %d, want 2", resp.AiCacheEntries)
+	}
+	if len(store.cache) != 0 {
+		t.Errorf("cache not cleared: %d entries left", len(store.cache))
+	}
+}
+
+func TestHandler_FlushCaches_ClearsSuggestionCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "suggestions_v2.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	t.Cleanup(func() { v2db.Close() })
+
+	if _, err := v2db.ExecContext(ctx, `
+		INSERT INTO suggestion_cache (cache_key, session_id, context_hash, suggestions_json, created_ms, ttl_ms)
+		VALUES ('k1', 's1', 'h', '[]', 0, 1000), ('k2', 's1', 'h', '[]', 0, 1000)`); err != nil {
+		t.Fatalf("seed suggestion_cache: %v", err)
+	}
+
+	server := createTestServer(t)
+	server.v2db = v2db
+
+	resp, err := server.FlushCaches(ctx, &pb.Ack{})
+	if err != nil || !resp.Ok {
+		t.Fatalf("FlushCaches = %v, %v", resp, err)
+	}
+	if resp.SuggestionCacheEntries != 2 {
+		t.Errorf("SuggestionCacheEntries = %d, want 2", resp.SuggestionCacheEntries)
+	}
+}
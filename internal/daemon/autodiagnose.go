@@ -0,0 +1,116 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/suggest"
+)
+
+const (
+	sourceDiagnosis     = "diagnosis"
+	reasonAutoDiagnosis = "auto_diagnosis"
+
+	// autoDiagnoseFailures is how many failures in a row of the same
+	// normalized command trigger an automatic diagnosis.
+	autoDiagnoseFailures = 2
+
+	// autoDiagnoseTimeout bounds the background diagnosis.
+	autoDiagnoseTimeout = 30 * time.Second
+)
+
+// autoDiagnoseAsync diagnoses the command req ends in the background when
+// ai.auto_diagnose is on and the same normalized command has just failed
+// autoDiagnoseFailures times in a row in the session. Diagnoses count
+// against the session's Diagnose rate limit. See autoDiagnose.
+func (s *Server) autoDiagnoseAsync(req *pb.CommandEndRequest) {
+	info, ok := s.sessionManager.Get(req.SessionId)
+	if !ok || info.LastCmdID != req.CommandId || info.LastCmdRaw == "" {
+		return
+	}
+	streak := s.sessionManager.RecordExit(req.SessionId, suggest.NormalizeCommand(info.LastCmdRaw), int(req.ExitCode))
+	if streak != autoDiagnoseFailures || !s.runtimeConfig().AI.AutoDiagnose {
+		return
+	}
+	method := pb.ClaiService_Diagnose_FullMethodName
+	limit, period := s.rateLimit(method)
+	if ok, _, _ := s.rateLimiter.Allow(rateLimitKey(method, req.SessionId), limit, period); !ok {
+		s.logger.Debug("automatic diagnosis skipped: rate limit exceeded",
+			"command_id", req.CommandId,
+			"session_id", req.SessionId,
+		)
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), autoDiagnoseTimeout)
+		defer cancel()
+		s.autoDiagnose(ctx, info, req.ExitCode)
+	}()
+}
+
+// autoDiagnose diagnoses info's last command, which exited with exitCode,
+// publishes the diagnosis on the event stream and stashes its fixes for
+// Suggest to propose at the next prompt.
+func (s *Server) autoDiagnose(ctx context.Context, info *SessionInfo, exitCode int32) {
+	resp, ok := s.diagnose(ctx, &pb.DiagnoseRequest{
+		SessionId: info.SessionID,
+		Command:   info.LastCmdRaw,
+		ExitCode:  exitCode,
+		Cwd:       info.LastCmdCWD,
+	})
+	if !ok {
+		return
+	}
+
+	fixes := make([]*pb.Suggestion, len(resp.Fixes))
+	texts := make([]string, len(resp.Fixes))
+	for i, fix := range resp.Fixes {
+		fix.Source = sourceDiagnosis
+		fix.Reasons = append(fix.Reasons, &pb.SuggestionReason{
+			Type:        reasonAutoDiagnosis,
+			Description: fmt.Sprintf("fixes %q, which failed %d times in a row", info.LastCmdRaw, autoDiagnoseFailures),
+		})
+		fixes[i] = fix
+		texts[i] = fix.Text
+	}
+	s.sessionManager.SetDiagnosisFixes(info.SessionID, info.LastCmdID, fixes)
+
+	s.publishEvent(&pb.ShellEvent{
+		Type:        pb.ShellEventType_SHELL_EVENT_TYPE_DIAGNOSIS,
+		SessionId:   info.SessionID,
+		Cwd:         info.LastCmdCWD,
+		CommandId:   info.LastCmdID,
+		Command:     info.LastCmdRaw,
+		GitRepoName: info.LastGitRepo,
+		GitBranch:   info.LastGitBranch,
+		ExitCode:    exitCode,
+		Diagnosis:   resp.Explanation,
+		Fixes:       texts,
+	})
+	s.logger.Debug("command diagnosed automatically",
+		"command_id", info.LastCmdID,
+		"session_id", info.SessionID,
+		"fixes", len(fixes),
+	)
+}
+
+// diagnosisFixesFor returns copies of the fixes stashed for the session's
+// last command that start with buffer.
+func (s *Server) diagnosisFixesFor(sessionID, buffer string) []*pb.Suggestion {
+	info, ok := s.sessionManager.Get(sessionID)
+	if !ok {
+		return nil
+	}
+	var out []*pb.Suggestion
+	for _, fix := range info.DiagnosisFixes {
+		if strings.HasPrefix(fix.Text, buffer) {
+			out = append(out, proto.Clone(fix).(*pb.Suggestion))
+		}
+	}
+	return out
+}
@@ -0,0 +1,185 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/runger/clai/internal/config"
+)
+
+// rivalCheckInterval is how often a daemon checks that it still owns its
+// lock file and socket.
+const rivalCheckInterval = 30 * time.Second
+
+// Two daemons can end up serving the same data directory when the lock file
+// is deleted while a daemon holds it, or when a second daemon is started by
+// hand. The newer daemon then holds the lock file and socket at the
+// canonical paths, so it is the one clients reach. It wins: the older daemon
+// notices it no longer owns those paths, hands its sessions over through
+// the restart-state file and exits, so the two never serve diverging state
+// for long.
+
+// Owned reports whether the lock file currently at l's path is the file l
+// holds. It is false after Release, or when the file was deleted or
+// replaced by another process.
+func (l *LockFile) Owned() bool {
+	if l == nil || l.file == nil {
+		return false
+	}
+	held, err := l.file.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(l.path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(held, current)
+}
+
+// reacquire takes the lock again at l's path after the held file was
+// deleted, keeping the old lock until the new one is held.
+func (l *LockFile) reacquire() error {
+	old := l.file
+	l.file = nil
+	if err := l.Acquire(); err != nil {
+		l.file = old
+		return err
+	}
+	if old != nil {
+		_ = old.Close()
+	}
+	return nil
+}
+
+// RecordedPIDs returns the distinct live daemon PIDs recorded for paths in
+// the lock file and the PID file. More than one means two daemons are
+// serving the same data directory.
+func RecordedPIDs(paths *config.Paths) []int {
+	var pids []int
+	add := func(pid int) {
+		if pid <= 0 || !isProcessAlive(pid) {
+			return
+		}
+		for _, p := range pids {
+			if p == pid {
+				return
+			}
+		}
+		pids = append(pids, pid)
+	}
+
+	if pid, held, err := ReadHeldPID(LockFilePath(paths.BaseDir)); err == nil && held {
+		add(pid)
+	}
+	if pid, err := ReadPID(paths.PIDFile()); err == nil {
+		add(pid)
+	}
+	return pids
+}
+
+// recordSocket remembers the socket file this server created, so a later
+// rival check can tell whether another daemon replaced it.
+func (s *Server) recordSocket(socketPath string) {
+	if s.socketActivated {
+		return
+	}
+	if info, err := os.Stat(socketPath); err == nil {
+		s.socketInfo = info
+	}
+}
+
+// ownsSocket reports whether the socket file at the canonical path is the
+// one this server created. A socket-activated server never owns it.
+func (s *Server) ownsSocket() bool {
+	if s.socketInfo == nil {
+		return false
+	}
+	current, err := os.Stat(s.paths.SocketFile())
+	if err != nil {
+		return false
+	}
+	// A socket created after ours was unlinked may reuse its inode; the
+	// modification time tells them apart.
+	return os.SameFile(s.socketInfo, current) && s.socketInfo.ModTime().Equal(current.ModTime())
+}
+
+// findRival returns the PID of another live daemon that has taken over this
+// server's lock file or socket, or 0 if there is none.
+func (s *Server) findRival() int {
+	self := os.Getpid()
+	alive := func(pid int) bool { return pid > 0 && pid != self && isProcessAlive(pid) }
+
+	if s.lockFile != nil && !s.lockFile.Owned() {
+		if pid, held, err := ReadHeldPID(s.lockFile.Path()); err == nil && held && alive(pid) {
+			return pid
+		}
+	}
+	if s.socketInfo != nil && !s.ownsSocket() {
+		if pid, err := ReadPID(s.paths.PIDFile()); err == nil && alive(pid) {
+			return pid
+		}
+	}
+	return 0
+}
+
+// checkRivals yields to a daemon that took over this server's paths. It
+// also picks up sessions handed over by a daemon that yielded to this one.
+// It reports whether the server is shutting down.
+func (s *Server) checkRivals() bool {
+	if rival := s.findRival(); rival != 0 {
+		s.yield(rival)
+		return true
+	}
+
+	if s.lockFile != nil && !s.lockFile.Owned() {
+		// The lock file was deleted but nobody else took it: take it back
+		// so the next daemon started sees this one.
+		s.logger.Warn("lock file missing, re-acquiring", "path", s.lockFile.Path())
+		if err := s.lockFile.reacquire(); err != nil {
+			s.logger.Warn("failed to re-acquire lock file", "error", err)
+		}
+	}
+
+	if _, err := os.Stat(RestartStatePath(s.paths.BaseDir)); err == nil {
+		s.restoreRestartState()
+	}
+	return false
+}
+
+// yield hands this server's sessions to the rival daemon and shuts down.
+// The socket, PID file and lock file now belong to the rival and are left
+// alone.
+func (s *Server) yield(rival int) {
+	s.logger.Warn("another daemon owns the socket and lock; handing over sessions and exiting",
+		"rival_pid", rival,
+		"sessions", s.sessionManager.ActiveCount(),
+	)
+	s.yielded.Store(true)
+	if err := s.saveRestartState(); err != nil {
+		s.logger.Error("failed to hand over sessions", "error", err)
+	}
+	go s.Shutdown()
+}
+
+// watchRivals periodically runs checkRivals until shutdown.
+func (s *Server) watchRivals(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(rivalCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.shutdownChan:
+			return
+		case <-ticker.C:
+			if s.checkRivals() {
+				return
+			}
+		}
+	}
+}
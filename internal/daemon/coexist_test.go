@@ -0,0 +1,157 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/runger/clai/internal/config"
+)
+
+// takeOverLock replaces the lock file at path with one held by a second
+// LockFile and recorded as belonging to a live process other than this one.
+func takeOverLock(t *testing.T, path string) *LockFile {
+	t.Helper()
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	rival := NewLockFile(path)
+	if err := rival.Acquire(); err != nil {
+		t.Fatalf("rival Acquire() error = %v", err)
+	}
+	t.Cleanup(func() { rival.Release() })
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getppid())+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return rival
+}
+
+func TestLockFile_OwnedAndReacquire(t *testing.T) {
+	t.Parallel()
+
+	path := LockFilePath(t.TempDir())
+	lock := NewLockFile(path)
+	if lock.Owned() {
+		t.Error("Owned() = true before Acquire")
+	}
+	if err := lock.Acquire(); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer lock.Release()
+	if !lock.Owned() {
+		t.Error("Owned() = false after Acquire")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if lock.Owned() {
+		t.Error("Owned() = true after the lock file was deleted")
+	}
+	if err := lock.reacquire(); err != nil {
+		t.Fatalf("reacquire() error = %v", err)
+	}
+	if !lock.Owned() {
+		t.Error("Owned() = false after reacquire")
+	}
+}
+
+func TestLockFile_ReleaseKeepsRivalLock(t *testing.T) {
+	t.Parallel()
+
+	path := LockFilePath(t.TempDir())
+	lock := NewLockFile(path)
+	if err := lock.Acquire(); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	takeOverLock(t, path)
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Release() removed the rival's lock file: %v", err)
+	}
+}
+
+func TestCheckRivals_YieldsToLockOwner(t *testing.T) {
+	t.Parallel()
+
+	paths := &config.Paths{BaseDir: t.TempDir()}
+	s := createTestServer(t)
+	s.paths = paths
+	s.sessionManager.Start("session-1", "zsh", "linux", "host", "user", "/tmp", time.Now())
+
+	lock := NewLockFile(LockFilePath(paths.BaseDir))
+	if err := lock.Acquire(); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer lock.Release()
+	s.lockFile = lock
+
+	if s.checkRivals() {
+		t.Fatal("checkRivals() yielded without a rival")
+	}
+
+	takeOverLock(t, lock.Path())
+	if pid := s.findRival(); pid != os.Getppid() {
+		t.Errorf("findRival() = %d, want %d", pid, os.Getppid())
+	}
+	if !s.checkRivals() {
+		t.Fatal("checkRivals() did not yield to the lock owner")
+	}
+	if !s.yielded.Load() {
+		t.Error("server not marked as yielded")
+	}
+
+	// The winner picks up the handed-over session on its next check.
+	winner := createTestServer(t)
+	winner.paths = paths
+	if winner.checkRivals() {
+		t.Fatal("winner yielded")
+	}
+	if _, ok := winner.sessionManager.Get("session-1"); !ok {
+		t.Error("winner did not take over session-1")
+	}
+}
+
+func TestFindRival_ReplacedSocket(t *testing.T) {
+	t.Parallel()
+
+	paths := &config.Paths{BaseDir: t.TempDir()}
+	s := createTestServer(t)
+	s.paths = paths
+
+	if err := os.WriteFile(paths.SocketFile(), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s.recordSocket(paths.SocketFile())
+	if !s.ownsSocket() {
+		t.Fatal("ownsSocket() = false for the recorded socket")
+	}
+
+	// Another daemon removes the socket, binds its own and writes its PID.
+	if err := os.Remove(paths.SocketFile()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(paths.SocketFile(), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(paths.SocketFile(), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(paths.PIDFile(), []byte(strconv.Itoa(os.Getppid())+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.ownsSocket() {
+		t.Error("ownsSocket() = true after the socket was replaced")
+	}
+	if pid := s.findRival(); pid != os.Getppid() {
+		t.Errorf("findRival() = %d, want %d", pid, os.Getppid())
+	}
+}
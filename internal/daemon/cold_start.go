@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"context"
+	"time"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/suggestions/backfill"
+)
+
+// coldStartSeedTimeout bounds the background corpus seeding.
+const coldStartSeedTimeout = 5 * time.Second
+
+// seedColdStartAsync seeds the curated command corpora for the project
+// types at cwd in the background, while the suggestions database has
+// little history (suggestions.cold_start_seeding).
+func (s *Server) seedColdStartAsync(sessionID, cwd string) {
+	cfg := s.runtimeConfig()
+	if s.v2db == nil || !cfg.Suggestions.ColdStartSeeding {
+		return
+	}
+	var projectTypes []string
+	if cfg.Suggestions.ProjectTypeDetectionEnabled && cwd != "" {
+		projectTypes = s.detectProjectTypes(sessionID, cwd)
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), coldStartSeedTimeout)
+		defer cancel()
+		n, err := backfill.SeedCorpus(ctx, s.v2db.DB(), backfill.CorpusOptions{
+			ProjectTypes: projectTypes,
+			Extra:        projectTypeCommands(cfg.Suggestions.ProjectTypes),
+			NowMs:        time.Now().UnixMilli(),
+		})
+		if err != nil {
+			s.logger.Warn("cold-start seeding failed", "error", err)
+			return
+		}
+		if n > 0 {
+			s.logger.Info("seeded cold-start commands", "commands", n, "project_types", projectTypes)
+		}
+	}()
+}
+
+// projectTypeCommands returns the common commands of custom project types
+// by name.
+func projectTypeCommands(types []config.ProjectType) map[string][]string {
+	commands := make(map[string][]string, len(types))
+	for _, pt := range types {
+		if len(pt.CommonCommands) > 0 {
+			commands[pt.Name] = append(commands[pt.Name], pt.CommonCommands...)
+		}
+	}
+	return commands
+}
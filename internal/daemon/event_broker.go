@@ -0,0 +1,135 @@
+package daemon
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+// eventBufferSize is how many events a subscriber may fall behind before
+// further events are dropped for it.
+const eventBufferSize = 64
+
+// EventSubscription is one SubscribeEvents stream.
+type EventSubscription struct {
+	ch      chan *pb.ShellEvent
+	types   map[pb.ShellEventType]bool
+	session string
+	dropped atomic.Int64
+}
+
+// matches reports whether ev passes the subscriber's filters.
+func (sub *EventSubscription) matches(ev *pb.ShellEvent) bool {
+	if sub.session != "" && ev.SessionId != sub.session {
+		return false
+	}
+	return len(sub.types) == 0 || sub.types[ev.Type]
+}
+
+// EventBroker fans shell events out to SubscribeEvents streams.
+// Publish never blocks: a subscriber that falls behind loses events
+// instead of slowing down ingestion.
+type EventBroker struct {
+	subs map[*EventSubscription]struct{}
+	mu   sync.RWMutex
+}
+
+// NewEventBroker creates an EventBroker with no subscribers.
+func NewEventBroker() *EventBroker {
+	return &EventBroker{subs: make(map[*EventSubscription]struct{})}
+}
+
+// Subscribe registers a subscriber filtered by req.
+func (b *EventBroker) Subscribe(req *pb.SubscribeEventsRequest) *EventSubscription {
+	sub := &EventSubscription{
+		ch:      make(chan *pb.ShellEvent, eventBufferSize),
+		session: req.GetSessionId(),
+	}
+	if len(req.GetTypes()) > 0 {
+		sub.types = make(map[pb.ShellEventType]bool, len(req.GetTypes()))
+		for _, t := range req.GetTypes() {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes a subscriber. Its channel is not closed; the stream
+// handler owning it is the only reader and is already returning.
+func (b *EventBroker) Unsubscribe(sub *EventSubscription) {
+	b.mu.Lock()
+	delete(b.subs, sub)
+	b.mu.Unlock()
+}
+
+// Publish delivers ev to every matching subscriber.
+func (b *EventBroker) Publish(ev *pb.ShellEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if !sub.matches(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Count returns the number of active subscribers.
+func (b *EventBroker) Count() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// publishEvent stamps ev with the current time if unset and publishes it.
+func (s *Server) publishEvent(ev *pb.ShellEvent) {
+	if ev.TsUnixMs == 0 {
+		ev.TsUnixMs = time.Now().UnixMilli()
+	}
+	s.events.Publish(ev)
+}
+
+// SubscribeEvents handles the SubscribeEvents RPC.
+// It streams shell events until the client cancels or the daemon shuts down.
+// Command text is only sent to subscribers that ask for it.
+func (s *Server) SubscribeEvents(req *pb.SubscribeEventsRequest, stream pb.ClaiService_SubscribeEventsServer) error {
+	s.touchActivity()
+
+	sub := s.events.Subscribe(req)
+	defer func() {
+		s.events.Unsubscribe(sub)
+		if n := sub.dropped.Load(); n > 0 {
+			s.logger.Debug("event subscriber dropped events", "dropped", n)
+		}
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.shutdownChan:
+			return nil
+		case ev := <-sub.ch:
+			if !req.IncludeCommands && ev.Command != "" {
+				ev = proto.Clone(ev).(*pb.ShellEvent)
+				ev.Command = ""
+			}
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
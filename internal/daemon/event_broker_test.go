@@ -0,0 +1,164 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+// fakeEventStream captures events sent by SubscribeEvents.
+type fakeEventStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *pb.ShellEvent
+}
+
+func (f *fakeEventStream) Context() context.Context { return f.ctx }
+
+func (f *fakeEventStream) Send(ev *pb.ShellEvent) error {
+	f.sent <- ev
+	return nil
+}
+
+func TestEventBroker_Filters(t *testing.T) {
+	t.Parallel()
+
+	b := NewEventBroker()
+	all := b.Subscribe(&pb.SubscribeEventsRequest{})
+	ends := b.Subscribe(&pb.SubscribeEventsRequest{
+		Types: []pb.ShellEventType{pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_END},
+	})
+	sess := b.Subscribe(&pb.SubscribeEventsRequest{SessionId: "s2"})
+
+	b.Publish(&pb.ShellEvent{Type: pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_START, SessionId: "s1"})
+	b.Publish(&pb.ShellEvent{Type: pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_END, SessionId: "s1"})
+
+	if len(all.ch) != 2 || len(ends.ch) != 1 || len(sess.ch) != 0 {
+		t.Errorf("delivered all=%d ends=%d sess=%d, want 2/1/0", len(all.ch), len(ends.ch), len(sess.ch))
+	}
+
+	b.Unsubscribe(all)
+	if b.Count() != 2 {
+		t.Errorf("Count() = %d, want 2", b.Count())
+	}
+}
+
+func TestEventBroker_SlowSubscriberDoesNotBlock(t *testing.T) {
+	t.Parallel()
+
+	b := NewEventBroker()
+	sub := b.Subscribe(&pb.SubscribeEventsRequest{})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < eventBufferSize+10; i++ {
+			b.Publish(&pb.ShellEvent{SessionId: "s1"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full subscriber")
+	}
+	if got := sub.dropped.Load(); got != 10 {
+		t.Errorf("dropped = %d, want 10", got)
+	}
+}
+
+func TestSubscribeEvents_StreamsCommandLifecycle(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeEventStream{ctx: ctx, sent: make(chan *pb.ShellEvent, 8)}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.SubscribeEvents(&pb.SubscribeEventsRequest{}, stream)
+	}()
+	waitForSubscribers(t, server, 1)
+
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "s1", Cwd: "/repo"})
+	_, _ = server.CommandStarted(ctx, &pb.CommandStartRequest{
+		SessionId: "s1", CommandId: "c1", Cwd: "/repo", Command: "export TOKEN=secret", GitRepoName: "clai",
+	})
+	_, _ = server.CommandEnded(ctx, &pb.CommandEndRequest{SessionId: "s1", CommandId: "c1", ExitCode: 2, DurationMs: 40})
+
+	want := []pb.ShellEventType{
+		pb.ShellEventType_SHELL_EVENT_TYPE_SESSION_START,
+		pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_START,
+		pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_END,
+	}
+	var last *pb.ShellEvent
+	for _, typ := range want {
+		select {
+		case ev := <-stream.sent:
+			if ev.Type != typ {
+				t.Fatalf("event type = %v, want %v", ev.Type, typ)
+			}
+			if ev.Command != "" {
+				t.Errorf("command text sent without include_commands: %q", ev.Command)
+			}
+			last = ev
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %v", typ)
+		}
+	}
+	if last.ExitCode != 2 || last.DurationMs != 40 || last.GitRepoName != "clai" || last.Cwd != "/repo" {
+		t.Errorf("COMMAND_END = %+v", last)
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("SubscribeEvents() error = %v", err)
+	}
+	if server.events.Count() != 0 {
+		t.Error("subscriber not removed after cancel")
+	}
+}
+
+func TestSubscribeEvents_IncludeCommands(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeEventStream{ctx: ctx, sent: make(chan *pb.ShellEvent, 8)}
+
+	go func() {
+		_ = server.SubscribeEvents(&pb.SubscribeEventsRequest{
+			IncludeCommands: true,
+			Types:           []pb.ShellEventType{pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_START},
+		}, stream)
+	}()
+	waitForSubscribers(t, server, 1)
+
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "s1"})
+	_, _ = server.CommandStarted(ctx, &pb.CommandStartRequest{SessionId: "s1", CommandId: "c1", Command: "make test"})
+
+	select {
+	case ev := <-stream.sent:
+		if ev.Command != "make test" {
+			t.Errorf("Command = %q, want make test", ev.Command)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for COMMAND_START")
+	}
+}
+
+func waitForSubscribers(t *testing.T, s *Server, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for s.events.Count() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("subscriber count = %d, want %d", s.events.Count(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
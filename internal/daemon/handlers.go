@@ -2,21 +2,40 @@ package daemon
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/cmdutil"
 	"github.com/runger/clai/internal/history"
 	"github.com/runger/clai/internal/provider"
 	"github.com/runger/clai/internal/sanitize"
 	"github.com/runger/clai/internal/storage"
 	"github.com/runger/clai/internal/suggest"
+	"github.com/runger/clai/internal/suggestions/archive"
 	"github.com/runger/clai/internal/suggestions/backfill"
+	"github.com/runger/clai/internal/suggestions/clock"
+	"github.com/runger/clai/internal/suggestions/dismissal"
 	"github.com/runger/clai/internal/suggestions/event"
 	"github.com/runger/clai/internal/suggestions/feedback"
+	"github.com/runger/clai/internal/suggestions/forget"
+	"github.com/runger/clai/internal/suggestions/output"
+	"github.com/runger/clai/internal/suggestions/slotcomplete"
+	suggest2 "github.com/runger/clai/internal/suggestions/suggest"
+	"github.com/runger/clai/internal/suggestions/typo"
 )
 
 // Common string constants to avoid duplication
@@ -122,6 +141,14 @@ func (s *Server) SessionStart(ctx context.Context, req *pb.SessionStartRequest)
 
 	// Register with session manager
 	s.sessionManager.Start(req.SessionId, shell, osName, hostname, username, req.Cwd, startedAt)
+	s.publishEvent(&pb.ShellEvent{
+		Type:      pb.ShellEventType_SHELL_EVENT_TYPE_SESSION_START,
+		SessionId: req.SessionId,
+		TsUnixMs:  startedAt.UnixMilli(),
+		Cwd:       req.Cwd,
+		Shell:     shell,
+	})
+	s.seedColdStartAsync(req.SessionId, req.Cwd)
 
 	s.logger.Debug("session started",
 		"session_id", req.SessionId,
@@ -153,16 +180,114 @@ func (s *Server) SessionEnd(ctx context.Context, req *pb.SessionEndRequest) (*pb
 
 	// Remove from session manager
 	s.sessionManager.End(req.SessionId)
+	s.inline.forget(req.SessionId)
+	s.publishEvent(&pb.ShellEvent{
+		Type:      pb.ShellEventType_SHELL_EVENT_TYPE_SESSION_END,
+		SessionId: req.SessionId,
+		TsUnixMs:  endedAt.UnixMilli(),
+	})
 
 	s.logger.Debug("session ended", "session_id", req.SessionId)
 
 	return &pb.Ack{Ok: true}, nil
 }
 
+// maxSessionNoteLen caps the session note so it cannot crowd out the rest of
+// an AI prompt.
+const maxSessionNoteLen = 500
+
+// SessionNote handles the SessionNote RPC.
+// It sets, clears, or reads the free-text note attached to a session.
+func (s *Server) SessionNote(ctx context.Context, req *pb.SessionNoteRequest) (*pb.SessionNoteResponse, error) {
+	s.touchActivity()
+
+	info, ok := s.sessionManager.Get(req.SessionId)
+	if !ok {
+		return &pb.SessionNoteResponse{Ok: false, Error: "session not found"}, nil
+	}
+
+	note := normalizeSessionNote(req.Note)
+	if !req.Clear && note == "" {
+		return &pb.SessionNoteResponse{Ok: true, Note: info.Note}, nil
+	}
+	if req.Clear {
+		note = ""
+	}
+
+	s.sessionManager.SetNote(req.SessionId, note)
+	s.logger.Debug("session note updated",
+		"session_id", req.SessionId,
+		"cleared", note == "",
+	)
+
+	return &pb.SessionNoteResponse{Ok: true, Note: note}, nil
+}
+
+// maxSessionNameLen caps the session name, which is shown in session lists.
+const maxSessionNameLen = 64
+
+// SessionName sets, reads or clears the name of a session. The name is kept
+// with the session in the database, so it outlives the session.
+func (s *Server) SessionName(ctx context.Context, req *pb.SessionNameRequest) (*pb.SessionNameResponse, error) {
+	s.touchActivity()
+
+	info, ok := s.sessionManager.Get(req.SessionId)
+	if !ok {
+		return &pb.SessionNameResponse{Ok: false, Error: "session not found"}, nil
+	}
+
+	name := normalizeSessionNote(req.Name)
+	if runes := []rune(name); len(runes) > maxSessionNameLen {
+		name = strings.TrimSpace(string(runes[:maxSessionNameLen]))
+	}
+	if !req.Clear && name == "" {
+		return &pb.SessionNameResponse{Ok: true, Name: info.Name}, nil
+	}
+	if req.Clear {
+		name = ""
+	}
+
+	if err := s.store.SetSessionName(ctx, req.SessionId, name); err != nil {
+		s.logger.Warn("failed to name session",
+			"session_id", req.SessionId,
+			"error", err,
+		)
+		return &pb.SessionNameResponse{Ok: false, Error: err.Error()}, nil
+	}
+	s.sessionManager.SetName(req.SessionId, name)
+
+	return &pb.SessionNameResponse{Ok: true, Name: name}, nil
+}
+
+// normalizeSessionNote collapses whitespace (including newlines) into single
+// spaces, strips control characters, and truncates to maxSessionNoteLen runes.
+func normalizeSessionNote(note string) string {
+	note = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, stripANSI(note))
+	note = strings.Join(strings.Fields(note), " ")
+	if runes := []rune(note); len(runes) > maxSessionNoteLen {
+		note = string(runes[:maxSessionNoteLen])
+	}
+	return note
+}
+
+// sessionNote returns the note attached to a session, if any.
+func (s *Server) sessionNote(sessionID string) string {
+	if info, ok := s.sessionManager.Get(sessionID); ok {
+		return info.Note
+	}
+	return ""
+}
+
 // CommandStarted handles the CommandStarted RPC.
 // It logs the start of a command execution.
 func (s *Server) CommandStarted(ctx context.Context, req *pb.CommandStartRequest) (*pb.Ack, error) {
 	s.touchActivity()
+	defer s.recordLatency(req.SessionId, latencyOpIngest, req.ClientLatencyMs, time.Now())
 	s.sessionManager.Touch(req.SessionId)
 
 	// Update CWD if provided
@@ -175,16 +300,59 @@ func (s *Server) CommandStarted(ctx context.Context, req *pb.CommandStartRequest
 		tsStart = time.UnixMilli(req.TsUnixMs)
 	}
 
+	// Secrets are redacted before the command is stored anywhere or
+	// published; everything below uses rawCommand.
+	rawCommand := s.redact(req.Command)
+
+	// Cap the stored command at the shell's byte limit. A truncated command
+	// keeps a hash of the full text so it is not silently confused with
+	// other commands sharing the same prefix.
+	var shell, prevCommand string
+	if info, ok := s.sessionManager.Get(req.SessionId); ok {
+		shell, prevCommand = info.Shell, info.LastCmdRaw
+	}
+	command, truncated := cmdutil.TruncateUTF8(rawCommand, s.runtimeConfig().Suggestions.CmdRawMaxBytesFor(shell))
+
+	// Commands of incognito sessions and of suggestions.ephemeral_dirs are
+	// kept in memory for the session's context only.
+	if s.isEphemeral(req) {
+		s.sessionManager.StashCommand(req.SessionId, req.CommandId, command, req.Cwd, req.GitRepoName, req.GitRepoRoot, req.GitBranch, truncated)
+		s.sessionManager.MarkEphemeral(req.SessionId)
+		s.sessionManager.RecordUse(req.SessionId, strings.TrimSpace(command), tsStart)
+		s.logger.Debug("ephemeral command started",
+			"command_id", req.CommandId,
+			"session_id", req.SessionId,
+		)
+		return &pb.Ack{Ok: true}, nil
+	}
+
+	// Commands suggestions.ingest_filters drops are not stored either; they
+	// are stashed like ephemeral ones so CommandEnded skips them too.
+	if reason, ignored := s.runtimeConfig().Suggestions.IngestFilters.Ignores(command, prevCommand); ignored {
+		s.sessionManager.StashCommand(req.SessionId, req.CommandId, command, req.Cwd, req.GitRepoName, req.GitRepoRoot, req.GitBranch, truncated)
+		s.sessionManager.MarkEphemeral(req.SessionId)
+		s.logger.Debug("command filtered at ingest",
+			"command_id", req.CommandId,
+			"session_id", req.SessionId,
+			"reason", reason,
+		)
+		return &pb.Ack{Ok: true}, nil
+	}
+
 	// Create command in database
 	cmd := &storage.Command{
 		CommandID:     req.CommandId,
 		SessionID:     req.SessionId,
 		TSStartUnixMs: tsStart.UnixMilli(),
 		CWD:           req.Cwd,
-		Command:       req.Command,
-		CommandNorm:   suggest.NormalizeCommand(req.Command),
-		CommandHash:   suggest.Hash(req.Command),
+		Command:       command,
+		CommandNorm:   suggest.NormalizeCommand(command),
+		CommandHash:   suggest.Hash(command),
 		IsSuccess:     boolPtr(true), // Assume success until CommandEnded
+		Truncated:     truncated,
+	}
+	if truncated {
+		cmd.FullHash = cmdutil.HashRaw(rawCommand)
 	}
 
 	// Add git context if provided
@@ -201,7 +369,12 @@ func (s *Server) CommandStarted(ctx context.Context, req *pb.CommandStartRequest
 		cmd.PrevCommandID = &req.PrevCommandId
 	}
 
-	if err := s.store.CreateCommand(ctx, cmd); err != nil {
+	err := s.store.CreateCommand(ctx, cmd)
+	if errors.Is(err, storage.ErrCommandExists) {
+		// A replay of a command already recorded, e.g. from the hook spool.
+		return &pb.Ack{Ok: true}, nil
+	}
+	if err != nil {
 		s.logger.Warn("failed to create command",
 			"command_id", req.CommandId,
 			"session_id", req.SessionId,
@@ -211,12 +384,29 @@ func (s *Server) CommandStarted(ctx context.Context, req *pb.CommandStartRequest
 	}
 
 	// Stash command data in session for V2 pipeline (CommandEnded reads it back)
-	s.sessionManager.StashCommand(req.SessionId, req.CommandId, req.Command, req.Cwd, req.GitRepoName, req.GitRepoRoot, req.GitBranch)
+	s.sessionManager.StashCommand(req.SessionId, req.CommandId, command, req.Cwd, req.GitRepoName, req.GitRepoRoot, req.GitBranch, truncated)
+	if req.PrevCwd != "" || len(req.Env) > 0 {
+		s.sessionManager.StashContext(req.SessionId, req.PrevCwd, event.FilterEnvContext(req.Env))
+	}
+	s.compareShadowRanking(req.SessionId, command, tsStart)
+	s.freezePromptSnapshot(req.SessionId, command, tsStart)
+	s.sessionManager.RecordUse(req.SessionId, strings.TrimSpace(command), tsStart)
+	s.publishEvent(&pb.ShellEvent{
+		Type:        pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_START,
+		SessionId:   req.SessionId,
+		TsUnixMs:    tsStart.UnixMilli(),
+		Cwd:         req.Cwd,
+		CommandId:   req.CommandId,
+		Command:     rawCommand,
+		GitRepoName: req.GitRepoName,
+		GitBranch:   req.GitBranch,
+	})
 
 	s.logger.Debug("command started",
 		"command_id", req.CommandId,
 		"session_id", req.SessionId,
-		"command", truncate(req.Command, 50),
+		"command", truncate(rawCommand, 50),
+		"truncated", truncated,
 	)
 
 	return &pb.Ack{Ok: true}, nil
@@ -228,6 +418,10 @@ func (s *Server) CommandEnded(ctx context.Context, req *pb.CommandEndRequest) (*
 	s.touchActivity()
 	s.sessionManager.Touch(req.SessionId)
 
+	if s.sessionManager.IsEphemeral(req.SessionId, req.CommandId) {
+		return &pb.Ack{Ok: true}, nil
+	}
+
 	tsEnd := time.Now()
 	if req.TsUnixMs > 0 {
 		tsEnd = time.UnixMilli(req.TsUnixMs)
@@ -244,10 +438,12 @@ func (s *Server) CommandEnded(ctx context.Context, req *pb.CommandEndRequest) (*
 	}
 
 	s.incrementCommandsLogged()
+	s.publishCommandEnd(req, tsEnd)
 
-	// Feed V2 batch writer (async, non-blocking)
+	// Feed V2 batch writer (async, non-blocking). Only the command stashed
+	// by CommandStarted has the details, and only its first end counts.
 	if s.batchWriter != nil {
-		if info, ok := s.sessionManager.Get(req.SessionId); ok {
+		if info, ok := s.sessionManager.EndCommand(req.SessionId, req.CommandId); ok {
 			durationMs := req.DurationMs
 			ev := &event.CommandEvent{
 				Version:    event.EventVersion,
@@ -256,16 +452,26 @@ func (s *Server) CommandEnded(ctx context.Context, req *pb.CommandEndRequest) (*
 				Shell:      event.Shell(info.Shell),
 				Cwd:        info.LastCmdCWD,
 				CmdRaw:     info.LastCmdRaw,
+				Truncated:  info.LastCmdTruncated,
 				RepoKey:    info.LastGitRepo,
 				Branch:     info.LastGitBranch,
+				Host:       info.Hostname,
 				ExitCode:   int(req.ExitCode),
 				DurationMs: &durationMs,
 				TS:         tsEnd.UnixMilli(),
+				PrevCwd:    info.LastCmdPrevCWD,
+				Env:        info.LastCmdEnv,
 			}
+			s.attachOutput(ev, req)
 			s.batchWriter.Enqueue(ev)
 		}
 	}
 
+	if typo.ShouldCorrect(int(req.ExitCode)) {
+		s.correctTypoAsync(req.SessionId, req.CommandId)
+	}
+	s.autoDiagnoseAsync(req)
+
 	s.logger.Debug("command ended",
 		"command_id", req.CommandId,
 		"session_id", req.SessionId,
@@ -276,31 +482,207 @@ func (s *Server) CommandEnded(ctx context.Context, req *pb.CommandEndRequest) (*
 	return &pb.Ack{Ok: true}, nil
 }
 
+// isEphemeral reports whether the command req starts must not be persisted:
+// it comes from an incognito session, runs in a repository whose trusted
+// .clai/config.yaml sets incognito, or in a directory listed in
+// suggestions.ephemeral_dirs.
+func (s *Server) isEphemeral(req *pb.CommandStartRequest) bool {
+	if req.Ephemeral {
+		return true
+	}
+	root := req.GitRepoRoot
+	if root == "" {
+		root = s.sessionRepoRoot(req.SessionId)
+	}
+	if _, incognito := s.repoConfig(root); incognito {
+		return true
+	}
+	_, ok := s.runtimeConfig().Suggestions.EphemeralDirFor(req.Cwd)
+	return ok
+}
+
+// attachOutput adds the output tails req carries to ev, cut to
+// suggestions.output_max_bytes, when suggestions.capture_output is on.
+func (s *Server) attachOutput(ev *event.CommandEvent, req *pb.CommandEndRequest) {
+	cfg := s.runtimeConfig().Suggestions
+	if !cfg.CaptureOutput {
+		return
+	}
+	out := output.Tail(output.Output{
+		Stdout:    req.StdoutTail,
+		Stderr:    req.StderrTail,
+		Truncated: req.OutputTruncated,
+	}, cfg.OutputMaxBytes)
+	ev.Stdout, ev.Stderr, ev.OutputTruncated = out.Stdout, out.Stderr, out.Truncated
+}
+
+// maxCommandEventsBatch caps the events accepted in one CommandEventsBatch call.
+const maxCommandEventsBatch = 1000
+
+// CommandEventsBatch handles the CommandEventsBatch RPC.
+// It applies each start and end event in order, exactly as CommandStarted
+// and CommandEnded would, and reports per-event failures instead of
+// failing the whole batch.
+func (s *Server) CommandEventsBatch(ctx context.Context, req *pb.CommandEventsBatchRequest) (*pb.CommandEventsBatchResponse, error) {
+	s.touchActivity()
+
+	if len(req.Events) > maxCommandEventsBatch {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"batch of %d events exceeds the limit of %d", len(req.Events), maxCommandEventsBatch)
+	}
+
+	resp := &pb.CommandEventsBatchResponse{}
+	for i, ev := range req.Events {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+
+		var ack *pb.Ack
+		switch e := ev.Event.(type) {
+		case *pb.CommandLifecycleEvent_Start:
+			ack, _ = s.CommandStarted(ctx, e.Start)
+		case *pb.CommandLifecycleEvent_End:
+			ack, _ = s.CommandEnded(ctx, e.End)
+		default:
+			ack = &pb.Ack{Ok: false, Error: "empty event"}
+		}
+
+		if ack.Ok {
+			resp.Accepted++
+		} else {
+			resp.Failed++
+			resp.Errors = append(resp.Errors, fmt.Sprintf("event %d: %s", i, ack.Error))
+		}
+	}
+
+	s.logger.Debug("command events batch",
+		"events", len(req.Events),
+		"accepted", resp.Accepted,
+		"failed", resp.Failed,
+	)
+
+	return resp, nil
+}
+
+// publishCommandEnd publishes a COMMAND_END event, filling in the command
+// details stashed by CommandStarted when they belong to this command.
+func (s *Server) publishCommandEnd(req *pb.CommandEndRequest, tsEnd time.Time) {
+	ev := &pb.ShellEvent{
+		Type:       pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_END,
+		SessionId:  req.SessionId,
+		TsUnixMs:   tsEnd.UnixMilli(),
+		CommandId:  req.CommandId,
+		ExitCode:   req.ExitCode,
+		DurationMs: req.DurationMs,
+	}
+	if info, ok := s.sessionManager.Get(req.SessionId); ok && info.LastCmdID == req.CommandId {
+		ev.Cwd = info.LastCmdCWD
+		ev.Command = info.LastCmdRaw
+		ev.GitRepoName = info.LastGitRepo
+		ev.GitBranch = info.LastGitBranch
+	}
+	s.publishEvent(ev)
+}
+
 // Suggest handles the Suggest RPC.
 // It returns command suggestions based on history and optionally AI.
 // The scorer version (v1/v2) determines which scoring engine is used.
 func (s *Server) Suggest(ctx context.Context, req *pb.SuggestRequest) (*pb.SuggestResponse, error) {
 	s.touchActivity()
 
+	// A session over its latency budget gets nothing it would wait for.
+	if s.sessionManager.DegradedReason(req.SessionId, time.Now()) != "" {
+		return &pb.SuggestResponse{Degraded: true}, nil
+	}
+	defer s.recordLatency(req.SessionId, latencyOpSuggest, req.ClientLatencyMs, time.Now())
+
 	maxResults := int(req.MaxResults)
 	if maxResults <= 0 {
-		maxResults = 5
+		maxResults = s.getDefaultMaxResults()
 	}
 
+	// Rank a larger pool when recently run commands may be lifted into it.
+	now := time.Now()
+	recency := s.runtimeConfig().Suggestions.SessionRecencyBoost
+	recentUses := s.recentSessionUses(req.SessionId, recency, now)
+	rankResults := maxResults
+	if len(recentUses) > 0 {
+		rankResults = maxResults * sessionRecencyPoolFactor
+	}
+
+	var resp *pb.SuggestResponse
 	if s.scorerVersion == "v2" {
-		return s.suggestV2Blend(ctx, req, maxResults), nil
+		resp = s.suggestV2Blend(ctx, req, rankResults)
+	} else {
+		resp = s.suggestV1(ctx, req, rankResults)
+	}
+	if resp != nil && len(recentUses) > 0 {
+		resp.Suggestions = applySessionRecency(resp.Suggestions, recentUses, now, recency, maxResults)
+	}
+	if s.runtimeConfig().Suggestions.ShadowScoring {
+		go s.shadowScore(req, maxResults)
+	}
+
+	if fixes := s.diagnosisFixesFor(req.SessionId, req.Buffer); len(fixes) > 0 {
+		if resp == nil {
+			resp = &pb.SuggestResponse{}
+		}
+		resp.Suggestions = prependSuggestions(fixes, resp.Suggestions, maxResults)
+	}
+	if corrections := s.typoCorrectionsFor(req.SessionId, req.Buffer); len(corrections) > 0 {
+		if resp == nil {
+			resp = &pb.SuggestResponse{}
+		}
+		resp.Suggestions = withTypoCorrections(corrections, resp.Suggestions, maxResults)
+	}
+	if workflows := s.workflowSuggestions(ctx, req.SessionId, req.Buffer); len(workflows) > 0 {
+		if resp == nil {
+			resp = &pb.SuggestResponse{}
+		}
+		// Corrections of a command that was not found, and fixes of one
+		// that failed repeatedly, stay on top.
+		pos := 0
+		for pos < len(resp.Suggestions) && (resp.Suggestions[pos].Source == sourceTypo || resp.Suggestions[pos].Source == sourceDiagnosis) {
+			pos++
+		}
+		rest := prependSuggestions(workflows, resp.Suggestions[pos:], maxResults-pos)
+		resp.Suggestions = append(resp.Suggestions[:pos:pos], rest...)
+	}
+	if resp != nil {
+		for _, sugg := range resp.Suggestions {
+			sugg.WordBoundaries = wordBoundaries(sugg.Text)
+		}
 	}
+	s.recordPromptSnapshot(req, resp)
+	return resp, nil
+}
 
-	return s.suggestV1(ctx, req, maxResults), nil
+// wordBoundaries returns where accepting text word by word stops, for
+// Suggestion.word_boundaries.
+func wordBoundaries(text string) []int32 {
+	bounds := cmdutil.WordBoundaries(text)
+	if len(bounds) == 0 {
+		return nil
+	}
+	out := make([]int32, len(bounds))
+	for i, b := range bounds {
+		out[i] = int32(b) //nolint:gosec // G115: offsets are bounded by the command length
+	}
+	return out
 }
 
 // suggestV1 generates suggestions using the V1 ranker (history-based).
 func (s *Server) suggestV1(ctx context.Context, req *pb.SuggestRequest, maxResults int) *pb.SuggestResponse {
-	nowMs := time.Now().UnixMilli()
+	nowMs := clock.NowMs(s.clock)
 	lastCommand := s.lastCommandForSession(ctx, req.SessionId)
 	suggestions, err := s.rankV1Suggestions(ctx, req, maxResults, lastCommand)
 	if err != nil {
-		s.logger.Warn("failed to rank suggestions",
+		// Cancelled requests (deadlines, superseded ghost text) are expected.
+		level := slog.LevelWarn
+		if ctx.Err() != nil {
+			level = slog.LevelDebug
+		}
+		s.logger.Log(ctx, level, "failed to rank suggestions",
 			"session_id", req.SessionId,
 			"error", err,
 		)
@@ -316,6 +698,7 @@ func (s *Server) suggestV1(ctx context.Context, req *pb.SuggestRequest, maxResul
 	return &pb.SuggestResponse{
 		Suggestions: pbSuggestions,
 		FromCache:   false,
+		Scoring:     &pb.SuggestScoring{Scorer: scorerV1, LastCmd: lastCommand},
 	}
 }
 
@@ -363,10 +746,29 @@ func v1SuggestionToProto(sug *suggest.Suggestion, lastCommand string, nowMs int6
 		Source:      sug.Source,
 		Score:       sug.Score,
 		Risk:        v1SuggestionRisk(sug.Text),
+		RiskMessage: riskMessage(sug.Text),
 		CmdNorm:     cmdNorm,
 		Confidence:  0, // V1 ranker does not compute a separate confidence score.
 		Reasons:     v1SuggestionReasons(sug, nowMs),
+
+		SuccessProbability: v1SuccessProbability(sug),
+	}
+}
+
+// v1SuccessProbability estimates the chance the suggestion succeeds from
+// its recorded runs; 0 when it has none.
+func v1SuccessProbability(sug *suggest.Suggestion) float64 {
+	if sug.SuccessCount+sug.FailureCount == 0 {
+		return 0
 	}
+	return suggest2.SuccessProbability(float64(sug.SuccessCount), float64(sug.FailureCount))
+}
+
+// riskMessage returns the configured message of the risk pattern that
+// flags text, or "" when there is none.
+func riskMessage(text string) string {
+	_, message := sanitize.Classify(text)
+	return message
 }
 
 func v1SuggestionRisk(text string) string {
@@ -416,6 +818,41 @@ func v1BaseReasons(sug *suggest.Suggestion) []*pb.SuggestionReason {
 	return reasons
 }
 
+// SuggestSlots handles the SuggestSlots RPC.
+// It returns the most used values of the argument being typed at the
+// cursor, learned from the slots of matching command templates.
+func (s *Server) SuggestSlots(ctx context.Context, req *pb.SuggestSlotsRequest) (*pb.SuggestSlotsResponse, error) {
+	s.touchActivity()
+
+	resp := &pb.SuggestSlotsResponse{}
+	if s.v2db == nil || s.sessionManager.DegradedReason(req.SessionId, time.Now()) != "" {
+		return resp, nil
+	}
+
+	buffer := req.Buffer
+	if pos := int(req.CursorPos); pos > 0 && pos < len(buffer) {
+		buffer = buffer[:pos]
+	}
+	repoKey := req.RepoKey
+	if info, ok := s.sessionManager.Get(req.SessionId); ok && repoKey == "" {
+		repoKey = info.LastGitRepo
+	}
+
+	values, err := slotcomplete.New(s.v2db.ReadDB(), 0).Complete(ctx, slotcomplete.Request{
+		Buffer:  buffer,
+		RepoKey: repoKey,
+		NowMs:   clock.NowMs(s.clock),
+		Limit:   int(req.MaxResults),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		resp.Values = append(resp.Values, &pb.SlotSuggestion{Value: v.Value, Score: v.Score})
+	}
+	return resp, nil
+}
+
 // TextToCommand handles the TextToCommand RPC.
 // It converts natural language to shell commands using AI.
 func (s *Server) TextToCommand(ctx context.Context, req *pb.TextToCommandRequest) (*pb.TextToCommandResponse, error) {
@@ -433,10 +870,11 @@ func (s *Server) TextToCommand(ctx context.Context, req *pb.TextToCommandRequest
 
 	// Build AI request
 	aiReq := &provider.TextToCommandRequest{
-		Prompt: req.Prompt,
-		CWD:    req.Cwd,
-		OS:     osName,
-		Shell:  shell,
+		Prompt:      req.Prompt,
+		CWD:         req.Cwd,
+		OS:          osName,
+		Shell:       shell,
+		SessionNote: s.sessionNote(req.SessionId),
 	}
 
 	// Call AI provider
@@ -465,6 +903,7 @@ func (s *Server) TextToCommand(ctx context.Context, req *pb.TextToCommandRequest
 			Source:      sourceAI,
 			Score:       sug.Score,
 			Risk:        risk,
+			RiskMessage: riskMessage(sug.Text),
 		}
 	}
 
@@ -480,11 +919,14 @@ func (s *Server) TextToCommand(ctx context.Context, req *pb.TextToCommandRequest
 func (s *Server) NextStep(ctx context.Context, req *pb.NextStepRequest) (*pb.NextStepResponse, error) {
 	s.touchActivity()
 
+	// A command that was not found is most likely a typo; propose the fix first
+	corrections := s.nextStepCorrections(ctx, req)
+
 	// Get the best available provider
 	prov, err := s.registry.GetBest()
 	if err != nil {
 		s.logger.Warn(errNoAIProvider, "error", err)
-		return &pb.NextStepResponse{}, nil
+		return &pb.NextStepResponse{Suggestions: withTypoCorrections(corrections, nil, len(corrections))}, nil
 	}
 
 	// Get session info for context
@@ -507,7 +949,7 @@ func (s *Server) NextStep(ctx context.Context, req *pb.NextStepRequest) (*pb.Nex
 			"provider", prov.Name(),
 			"error", err,
 		)
-		return &pb.NextStepResponse{}, nil
+		return &pb.NextStepResponse{Suggestions: withTypoCorrections(corrections, nil, len(corrections))}, nil
 	}
 
 	// Convert to protobuf
@@ -523,11 +965,12 @@ func (s *Server) NextStep(ctx context.Context, req *pb.NextStepRequest) (*pb.Nex
 			Source:      sourceAI,
 			Score:       sug.Score,
 			Risk:        risk,
+			RiskMessage: riskMessage(sug.Text),
 		}
 	}
 
 	return &pb.NextStepResponse{
-		Suggestions: pbSuggestions,
+		Suggestions: withTypoCorrections(corrections, pbSuggestions, len(corrections)+len(pbSuggestions)),
 	}, nil
 }
 
@@ -535,14 +978,20 @@ func (s *Server) NextStep(ctx context.Context, req *pb.NextStepRequest) (*pb.Nex
 // It analyzes a failed command and suggests fixes using AI.
 func (s *Server) Diagnose(ctx context.Context, req *pb.DiagnoseRequest) (*pb.DiagnoseResponse, error) {
 	s.touchActivity()
+	resp, _ := s.diagnose(ctx, req)
+	return resp, nil
+}
 
+// diagnose asks the AI provider about the failed command in req. Without a
+// provider, or when it fails, it returns an explanation saying so and false.
+func (s *Server) diagnose(ctx context.Context, req *pb.DiagnoseRequest) (*pb.DiagnoseResponse, bool) {
 	// Get the best available provider
 	prov, err := s.registry.GetBest()
 	if err != nil {
 		s.logger.Warn(errNoAIProvider, "error", err)
 		return &pb.DiagnoseResponse{
 			Explanation: "No AI provider available for diagnosis",
-		}, nil
+		}, false
 	}
 
 	// Get session info for context
@@ -550,12 +999,14 @@ func (s *Server) Diagnose(ctx context.Context, req *pb.DiagnoseRequest) (*pb.Dia
 
 	// Build AI request
 	aiReq := &provider.DiagnoseRequest{
-		SessionID: req.SessionId,
-		Command:   req.Command,
-		ExitCode:  int(req.ExitCode),
-		CWD:       req.Cwd,
-		OS:        osName,
-		Shell:     shell,
+		SessionID:   req.SessionId,
+		Command:     req.Command,
+		ExitCode:    int(req.ExitCode),
+		CWD:         req.Cwd,
+		OS:          osName,
+		Shell:       shell,
+		SessionNote: s.sessionNote(req.SessionId),
+		StdErr:      s.recentOutput(ctx, req.SessionId, req.Command),
 	}
 
 	// Call AI provider
@@ -567,7 +1018,7 @@ func (s *Server) Diagnose(ctx context.Context, req *pb.DiagnoseRequest) (*pb.Dia
 		)
 		return &pb.DiagnoseResponse{
 			Explanation: "Failed to get diagnosis from AI provider",
-		}, nil
+		}, false
 	}
 
 	// Convert to protobuf
@@ -583,13 +1034,14 @@ func (s *Server) Diagnose(ctx context.Context, req *pb.DiagnoseRequest) (*pb.Dia
 			Source:      sourceAI,
 			Score:       sug.Score,
 			Risk:        risk,
+			RiskMessage: riskMessage(sug.Text),
 		}
 	}
 
 	return &pb.DiagnoseResponse{
 		Explanation: resp.Explanation,
 		Fixes:       pbFixes,
-	}, nil
+	}, true
 }
 
 // RecordFeedback handles the RecordFeedback RPC.
@@ -605,6 +1057,63 @@ func (s *Server) SuggestFeedback(ctx context.Context, req *pb.RecordFeedbackRequ
 	return s.RecordFeedback(ctx, req)
 }
 
+// ListDismissals returns the dismissal patterns learned from feedback, most
+// recently dismissed first. Without the V2 database there are none.
+func (s *Server) ListDismissals(ctx context.Context, req *pb.ListDismissalsRequest) (*pb.ListDismissalsResponse, error) {
+	s.touchActivity()
+
+	resp := &pb.ListDismissalsResponse{}
+	if s.v2db == nil {
+		return resp, nil
+	}
+	store := dismissal.NewStore(s.v2db.ReadDB(), dismissal.DefaultConfig(), s.logger)
+	records, err := store.List(ctx, dismissal.ListOptions{
+		Scope:            req.Scope,
+		IncludeTemporary: req.IncludeTemporary,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		r := &records[i]
+		resp.Dismissals = append(resp.Dismissals, &pb.Dismissal{
+			Scope:               r.Scope,
+			ContextTemplateId:   r.ContextTemplateID,
+			DismissedTemplateId: r.DismissedTemplateID,
+			ContextCommand:      r.ContextCmd,
+			DismissedCommand:    r.DismissedCmd,
+			Level:               string(r.SuppressionLevel),
+			DismissalCount:      int32(min(r.DismissalCount, math.MaxInt32)), //nolint:gosec // G115: clamped above
+			LastDismissedMs:     r.LastDismissedMs,
+		})
+	}
+	return resp, nil
+}
+
+// ClearDismissals deletes the dismissal patterns of req.DismissedTemplateId,
+// or of every template when it is empty, so they are suggested again.
+func (s *Server) ClearDismissals(ctx context.Context, req *pb.ClearDismissalsRequest) (*pb.ClearDismissalsResponse, error) {
+	s.touchActivity()
+
+	if s.v2db == nil {
+		return &pb.ClearDismissalsResponse{}, nil
+	}
+	store := dismissal.NewStore(s.v2db.DB(), dismissal.DefaultConfig(), s.logger)
+	n, err := store.Clear(ctx, req.Scope, req.DismissedTemplateId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("cleared dismissals",
+		"scope", req.Scope,
+		"dismissed_template_id", req.DismissedTemplateId,
+		"count", n,
+	)
+	return &pb.ClearDismissalsResponse{
+		Cleared: int32(min(n, math.MaxInt32)), //nolint:gosec // G115: clamped above
+	}, nil
+}
+
 // handleRecordFeedback is the shared implementation for RecordFeedback and SuggestFeedback.
 func (s *Server) handleRecordFeedback(ctx context.Context, req *pb.RecordFeedbackRequest) (*pb.RecordFeedbackResponse, error) {
 	if s.feedbackStore == nil {
@@ -692,12 +1201,66 @@ func (s *Server) GetStatus(ctx context.Context, req *pb.Ack) (*pb.StatusResponse
 
 	uptime := time.Since(s.startTime).Seconds()
 
-	return &pb.StatusResponse{
-		Version:        Version,
-		ActiveSessions: int32(s.sessionManager.ActiveCount()), //nolint:gosec // G115: session count is bounded
-		UptimeSeconds:  int64(uptime),
-		CommandsLogged: s.getCommandsLogged(),
-	}, nil
+	resp := &pb.StatusResponse{
+		Version:          Version,
+		ActiveSessions:   int32(s.sessionManager.ActiveCount()), //nolint:gosec // G115: session count is bounded
+		UptimeSeconds:    int64(uptime),
+		CommandsLogged:   s.getCommandsLogged(),
+		Pid:              int32(os.Getpid()), //nolint:gosec // G115: PIDs fit in int32
+		StateDbBytes:     fileSize(s.paths.DatabaseFile()),
+		ScorerVersion:    s.scorerVersion,
+		Providers:        s.providerStatuses(),
+		DegradedSessions: int32(s.sessionManager.DegradedCount(time.Now())), //nolint:gosec // G115: session count is bounded
+	}
+	if s.v2db != nil {
+		resp.SuggestionsDbBytes = fileSize(s.v2db.Path())
+	}
+
+	depth := s.ingestionQueue.Len()
+	if s.batchWriter != nil {
+		stats := s.batchWriter.Stats()
+		depth += stats.QueueLength + stats.SpoolLength
+	}
+	resp.IngestQueueDepth = int32(depth) //nolint:gosec // G115: queue depth is bounded by queue capacity
+
+	if s.maintenanceRunner != nil {
+		if last := s.maintenanceRunner.GetStats().LastTickTime; !last.IsZero() {
+			resp.LastMaintenanceUnixMs = last.UnixMilli()
+		}
+	}
+
+	return resp, nil
+}
+
+// providerStatuses reports availability of every registered provider, sorted by name.
+func (s *Server) providerStatuses() []*pb.ProviderStatus {
+	preferred := s.registry.GetPreferred()
+	all := s.registry.ListAll()
+
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	statuses := make([]*pb.ProviderStatus, 0, len(names))
+	for _, name := range names {
+		statuses = append(statuses, &pb.ProviderStatus{
+			Name:      name,
+			Available: all[name],
+			Preferred: name == preferred,
+		})
+	}
+	return statuses
+}
+
+// fileSize returns the size of path in bytes, or 0 if it cannot be stat'ed.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
 }
 
 // ansiRegexp matches ANSI escape sequences for stripping from command text.
@@ -709,13 +1272,16 @@ func stripANSI(s string) string {
 }
 
 // FetchHistory handles the FetchHistory RPC.
-// It returns paginated, deduplicated command history with optional substring filtering.
+// It returns paginated, deduplicated command history with optional substring,
+// directory and exit status filtering. SEARCH_MODE_FTS matches the query
+// through the full-text index.
 func (s *Server) FetchHistory(ctx context.Context, req *pb.HistoryFetchRequest) (*pb.HistoryFetchResponse, error) {
 	s.touchActivity()
 
+	// Without a limit, return a page of the size the picker uses.
 	limit := int(req.Limit)
 	if limit <= 0 {
-		limit = 50
+		limit = s.runtimeConfig().History.PickerPageSize
 	}
 
 	offset := int(req.Offset)
@@ -723,41 +1289,56 @@ func (s *Server) FetchHistory(ctx context.Context, req *pb.HistoryFetchRequest)
 		offset = 0
 	}
 
-	q := storage.CommandQuery{
-		Limit:  limit + 1, // Fetch one extra to determine at_end
-		Offset: offset,
-		// Deduplicate by raw command string for pickers; do not collapse by command_norm.
-		Deduplicate: true,
+	q := storage.HistoryQuery{
+		Limit:     limit + 1, // Fetch one extra to determine at_end
+		Offset:    offset,
+		Substring: req.Query,
+		FullText:  req.Mode == pb.SearchMode_SEARCH_MODE_FTS,
+		Host:      req.Host,
+		Cwd:       req.Cwd,
 	}
-
-	// Apply substring filter (normalize to lowercase for command_norm matching)
-	if req.Query != "" {
-		q.Substring = strings.ToLower(req.Query)
+	if !req.Global {
+		q.SessionID = req.SessionId
 	}
-
-	// Apply session scoping
-	if !req.Global && req.SessionId != "" {
-		q.SessionID = &req.SessionId
+	switch req.Status {
+	case "":
+	case "success":
+		q.SuccessOnly = true
+	case "failure":
+		q.FailureOnly = true
+	default:
+		return nil, status.Errorf(codes.InvalidArgument,
+			"invalid status %q (want success or failure)", req.Status)
 	}
 
-	rows, err := s.store.QueryHistoryCommands(ctx, q)
+	entries, err := s.historyReader().ReadHistory(ctx, q)
 	if err != nil {
 		s.logger.Warn("failed to query history",
 			"error", err,
 		)
-		return &pb.HistoryFetchResponse{}, nil
+		if entries == nil {
+			return &pb.HistoryFetchResponse{}, nil
+		}
 	}
 
-	atEnd := len(rows) <= limit
+	atEnd := len(entries) <= limit
 	if !atEnd {
-		rows = rows[:limit]
+		entries = entries[:limit]
 	}
 
-	items := make([]*pb.HistoryItem, len(rows))
-	for i, row := range rows {
+	items := make([]*pb.HistoryItem, len(entries))
+	for i, e := range entries {
 		items[i] = &pb.HistoryItem{
-			Command:     stripANSI(row.Command),
-			TimestampMs: row.TimestampMs,
+			Command:     stripANSI(e.Command),
+			TimestampMs: e.TimestampMs,
+			Truncated:   e.Truncated,
+			EventId:     e.EventID,
+			Origin:      e.Origin,
+			Cwd:         e.Cwd,
+		}
+		if e.ExitCode != nil {
+			code := int32(*e.ExitCode) //nolint:gosec // G115: exit codes fit in int32
+			items[i].ExitCode = &code
 		}
 	}
 
@@ -834,6 +1415,10 @@ func (s *Server) ImportHistory(ctx context.Context, req *pb.HistoryImportRequest
 		}, nil
 	}
 
+	for i := range entries {
+		entries[i].Command = s.redact(entries[i].Command)
+	}
+
 	// Import into database
 	count, err := s.store.ImportHistory(ctx, entries, shell)
 	if err != nil {
@@ -861,6 +1446,178 @@ func (s *Server) ImportHistory(ctx context.Context, req *pb.HistoryImportRequest
 	}, nil
 }
 
+// maxImportEventsBatch caps the events accepted in one ImportEvents call.
+const maxImportEventsBatch = 5000
+
+// ImportEvents handles the ImportEvents RPC.
+// It writes exported commands to the history database, skipping ones it
+// already has, and backfills the V2 suggestion tables (non-fatal).
+func (s *Server) ImportEvents(ctx context.Context, req *pb.ImportEventsRequest) (*pb.ImportEventsResponse, error) {
+	s.touchActivity()
+
+	if len(req.Events) > maxImportEventsBatch {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"batch of %d events exceeds the limit of %d", len(req.Events), maxImportEventsBatch)
+	}
+
+	cmds := make([]storage.Command, 0, len(req.Events))
+	for _, ev := range req.Events {
+		cmd := importedCommand(ev)
+		cmd.Command = s.redact(cmd.Command)
+		cmds = append(cmds, cmd)
+	}
+
+	count, err := s.store.ImportCommands(ctx, cmds)
+	if err != nil {
+		s.logger.Warn("failed to import events", "events", len(cmds), "error", err)
+		return nil, fmt.Errorf("failed to import events: %w", err)
+	}
+
+	if s.v2db != nil {
+		if n, err := backfill.ImportCommands(ctx, s.v2db.DB(), cmds); err != nil {
+			s.logger.Warn("V2 import failed (non-fatal)", "error", err)
+		} else {
+			s.logger.Debug("imported events into V2", "count", n)
+		}
+	}
+
+	s.logger.Info("imported events",
+		"events", len(cmds),
+		"imported", count,
+	)
+
+	return &pb.ImportEventsResponse{
+		Imported:   int32(count),             //nolint:gosec // G115: bounded by maxImportEventsBatch
+		Duplicates: int32(len(cmds) - count), //nolint:gosec // G115: bounded by maxImportEventsBatch
+	}, nil
+}
+
+// deleteSampleLimit caps the matching commands a DeleteCommandResponse lists.
+const deleteSampleLimit = 10
+
+// DeleteCommand hides the commands containing req.Pattern, or with
+// req.Exact the commands that are exactly req.Pattern, from history and
+// suggestions. They stay in both databases and keep counting towards the
+// learned stats.
+func (s *Server) DeleteCommand(ctx context.Context, req *pb.DeleteCommandRequest) (*pb.DeleteCommandResponse, error) {
+	return s.removeCommands(ctx, req, false)
+}
+
+// PurgeCommand permanently erases the commands containing req.Pattern, or
+// with req.Exact the commands that are exactly req.Pattern, including ones
+// already hidden, from both databases and from everything learned from them.
+func (s *Server) PurgeCommand(ctx context.Context, req *pb.DeleteCommandRequest) (*pb.DeleteCommandResponse, error) {
+	return s.removeCommands(ctx, req, true)
+}
+
+func (s *Server) removeCommands(ctx context.Context, req *pb.DeleteCommandRequest, purge bool) (*pb.DeleteCommandResponse, error) {
+	s.touchActivity()
+
+	if req.Pattern == "" {
+		return nil, status.Error(codes.InvalidArgument, "pattern is required")
+	}
+
+	// A purge also covers commands an earlier delete hid.
+	match, err := s.store.MatchCommands(ctx, req.Pattern, req.Exact, purge, deleteSampleLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match commands: %w", err)
+	}
+	resp := &pb.DeleteCommandResponse{
+		Commands: int32(min(match.Count, math.MaxInt32)), //nolint:gosec // G115: clamped above
+		Samples:  match.Samples,
+	}
+	if s.v2db != nil {
+		v2match, err := forget.Find(ctx, s.v2db.DB(), req.Pattern, req.Exact, purge, deleteSampleLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match V2 events: %w", err)
+		}
+		resp.Events = int32(min(v2match.Events, math.MaxInt32)) //nolint:gosec // G115: clamped above
+		resp.Samples = mergeSamples(resp.Samples, v2match.Samples, deleteSampleLimit)
+	}
+	if req.DryRun {
+		return resp, nil
+	}
+
+	var commands, events int64
+	if purge {
+		commands, err = s.store.PurgeCommands(ctx, req.Pattern, req.Exact)
+	} else {
+		commands, err = s.store.DeleteCommands(ctx, req.Pattern, req.Exact)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove commands: %w", err)
+	}
+	if s.v2db != nil {
+		if purge {
+			var res forget.PurgeResult
+			res, err = forget.Purge(ctx, s.v2db.DB(), req.Pattern, req.Exact)
+			events = res.Events
+		} else {
+			events, err = forget.Hide(ctx, s.v2db.DB(), req.Pattern, req.Exact)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove V2 events: %w", err)
+		}
+	}
+	if purge {
+		// Cached AI responses may quote the purged commands.
+		if _, err := s.store.ClearCache(ctx); err != nil {
+			s.logger.Warn("failed to clear AI cache after purge", "error", err)
+		}
+	}
+
+	s.logger.Info("removed commands",
+		"purge", purge,
+		"commands", commands,
+		"events", events,
+	)
+	resp.Commands = int32(min(commands, math.MaxInt32)) //nolint:gosec // G115: clamped above
+	resp.Events = int32(min(events, math.MaxInt32))     //nolint:gosec // G115: clamped above
+	return resp, nil
+}
+
+// mergeSamples appends the samples of b missing from a, up to limit in total.
+func mergeSamples(a, b []string, limit int) []string {
+	seen := make(map[string]bool, len(a))
+	for _, s := range a {
+		seen[s] = true
+	}
+	for _, s := range b {
+		if len(a) >= limit {
+			break
+		}
+		if !seen[s] {
+			seen[s] = true
+			a = append(a, s)
+		}
+	}
+	return a
+}
+
+// importedCommand converts an exported event to a history command.
+func importedCommand(ev *pb.ImportedEvent) storage.Command {
+	cmd := storage.Command{
+		SessionID:     ev.SessionId,
+		TSStartUnixMs: ev.TsUnixMs,
+		CWD:           ev.Cwd,
+		Command:       ev.Command,
+		DurationMs:    ev.DurationMs,
+	}
+	if ev.ExitCode != nil {
+		code := int(*ev.ExitCode)
+		cmd.ExitCode = &code
+	}
+	if ev.GitBranch != "" {
+		cmd.GitBranch = &ev.GitBranch
+	}
+	if ev.GitRepoRoot != "" {
+		name := filepath.Base(ev.GitRepoRoot)
+		cmd.GitRepoName = &name
+		cmd.GitRepoRoot = &ev.GitRepoRoot
+	}
+	return cmd
+}
+
 // truncate truncates a string to the given length with "..." suffix.
 func truncate(s string, maxLen int) string {
 	if maxLen <= 0 {
@@ -895,3 +1652,82 @@ func (s *Server) getSessionContext(sessionID string) (osName, shell string) {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+// GetCommandDetail returns one recorded command of the suggestions database
+// with the output captured for it, if any. Deleted events are not found.
+func (s *Server) GetCommandDetail(ctx context.Context, req *pb.GetCommandDetailRequest) (*pb.GetCommandDetailResponse, error) {
+	s.touchActivity()
+
+	if req.EventId <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "event_id is required")
+	}
+	if s.v2db == nil {
+		return &pb.GetCommandDetailResponse{}, nil
+	}
+	db := s.v2db.ReadDB()
+
+	resp := &pb.GetCommandDetailResponse{}
+	var exitCode, durationMs sql.NullInt64
+	err := db.QueryRowContext(ctx, `
+		SELECT ts_ms, cwd, exit_code, duration_ms FROM command_event
+		WHERE id = ? AND deleted = 0
+	`, req.EventId).Scan(&resp.TimestampMs, &resp.Cwd, &exitCode, &durationMs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load command: %w", err)
+	}
+	command, err := archive.CmdRaw(ctx, db, req.EventId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load command: %w", err)
+	}
+	out, ok, err := output.Get(ctx, db, req.EventId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Found = true
+	resp.Command = stripANSI(command)
+	resp.ExitCode = int32(exitCode.Int64) //nolint:gosec // G115: exit codes are bounded 0-255
+	resp.DurationMs = durationMs.Int64
+	resp.HasOutput = ok
+	resp.StdoutTail = out.Stdout
+	resp.StderrTail = out.Stderr
+	resp.OutputTruncated = out.Truncated
+	return resp, nil
+}
+
+// recentOutput returns what the latest run of command in the session
+// printed, stderr or else stdout, for diagnosis. It is empty when no output
+// was captured.
+func (s *Server) recentOutput(ctx context.Context, sessionID, command string) string {
+	if s.v2db == nil || sessionID == "" || command == "" {
+		return ""
+	}
+	db := s.v2db.ReadDB()
+
+	var eventID int64
+	err := db.QueryRowContext(ctx, `
+		SELECT e.id FROM command_event e
+		JOIN command_output o ON o.event_id = e.id
+		WHERE e.session_id = ? AND e.cmd_raw = ?
+		ORDER BY e.ts_ms DESC, e.id DESC
+		LIMIT 1
+	`, sessionID, command).Scan(&eventID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("failed to look up command output", "error", err)
+		}
+		return ""
+	}
+	out, _, err := output.Get(ctx, db, eventID)
+	if err != nil {
+		s.logger.Warn("failed to load command output", "event_id", eventID, "error", err)
+		return ""
+	}
+	if out.Stderr != "" {
+		return out.Stderr
+	}
+	return out.Stdout
+}
@@ -5,17 +5,28 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/cmdutil"
+	"github.com/runger/clai/internal/config"
 	"github.com/runger/clai/internal/history"
 	"github.com/runger/clai/internal/provider"
 	"github.com/runger/clai/internal/storage"
 	"github.com/runger/clai/internal/suggest"
+	"github.com/runger/clai/internal/suggestions/backfill"
+	"github.com/runger/clai/internal/suggestions/clock"
 	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/dismissal"
+	"github.com/runger/clai/internal/suggestions/event"
 	"github.com/runger/clai/internal/suggestions/feedback"
+	"github.com/runger/clai/internal/suggestions/ingest"
 )
 
 // mockStore implements storage.Store for testing.
@@ -73,6 +84,15 @@ func (m *mockStore) GetSession(ctx context.Context, sessionID string) (*storage.
 	return nil, storage.ErrSessionNotFound
 }
 
+func (m *mockStore) SetSessionName(ctx context.Context, sessionID, name string) error {
+	s, ok := m.sessions[sessionID]
+	if !ok {
+		return storage.ErrSessionNotFound
+	}
+	s.Name = name
+	return nil
+}
+
 func (m *mockStore) GetSessionByPrefix(ctx context.Context, prefix string) (*storage.Session, error) {
 	var matches []*storage.Session
 	for id, s := range m.sessions {
@@ -90,6 +110,9 @@ func (m *mockStore) GetSessionByPrefix(ctx context.Context, prefix string) (*sto
 }
 
 func (m *mockStore) CreateCommand(ctx context.Context, c *storage.Command) error {
+	if _, ok := m.commands[c.CommandID]; ok {
+		return storage.ErrCommandExists
+	}
 	m.commands[c.CommandID] = c
 	return nil
 }
@@ -132,6 +155,7 @@ func (m *mockStore) QueryHistoryCommands(ctx context.Context, q storage.CommandQ
 			seen[c.Command] = storage.HistoryRow{
 				Command:     c.Command,
 				TimestampMs: c.TSStartUnixMs,
+				Truncated:   c.Truncated,
 			}
 		}
 	}
@@ -161,6 +185,35 @@ func (m *mockStore) QueryHistoryCommands(ctx context.Context, q storage.CommandQ
 	return result, nil
 }
 
+func (m *mockStore) MatchCommands(ctx context.Context, pattern string, exact, includeDeleted bool, limit int) (*storage.CommandMatch, error) {
+	match := &storage.CommandMatch{}
+	for _, c := range m.commands {
+		if c.Command == pattern || !exact && strings.Contains(c.Command, pattern) {
+			match.Count++
+			if len(match.Samples) < limit {
+				match.Samples = append(match.Samples, c.Command)
+			}
+		}
+	}
+	return match, nil
+}
+
+// DeleteCommands drops matches from the mock; it does not model soft deletion.
+func (m *mockStore) DeleteCommands(ctx context.Context, pattern string, exact bool) (int64, error) {
+	return m.PurgeCommands(ctx, pattern, exact)
+}
+
+func (m *mockStore) PurgeCommands(ctx context.Context, pattern string, exact bool) (int64, error) {
+	var n int64
+	for id, c := range m.commands {
+		if c.Command == pattern || !exact && strings.Contains(c.Command, pattern) {
+			delete(m.commands, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
 func (m *mockStore) GetCached(ctx context.Context, key string) (*storage.CacheEntry, error) {
 	if e, ok := m.cache[key]; ok {
 		return e, nil
@@ -177,6 +230,12 @@ func (m *mockStore) PruneExpiredCache(ctx context.Context) (int64, error) {
 	return 0, nil
 }
 
+func (m *mockStore) ClearCache(ctx context.Context) (int64, error) {
+	n := int64(len(m.cache))
+	m.cache = make(map[string]*storage.CacheEntry)
+	return n, nil
+}
+
 func (m *mockStore) HasImportedHistory(ctx context.Context, shell string) (bool, error) {
 	return false, nil
 }
@@ -185,6 +244,10 @@ func (m *mockStore) ImportHistory(ctx context.Context, entries []history.ImportE
 	return len(entries), nil
 }
 
+func (m *mockStore) ImportCommands(ctx context.Context, cmds []storage.Command) (int, error) {
+	return len(cmds), nil
+}
+
 func (m *mockStore) Close() error {
 	return nil
 }
@@ -277,6 +340,13 @@ func (m *mockProvider) Diagnose(ctx context.Context, req *provider.DiagnoseReque
 	}, nil
 }
 
+func (m *mockProvider) Explain(ctx context.Context, req *provider.ExplainRequest) (*provider.ExplainResponse, error) {
+	return &provider.ExplainResponse{
+		Summary:      "Test summary",
+		ProviderName: m.name,
+	}, nil
+}
+
 func createTestServer(t *testing.T) *Server {
 	t.Helper()
 
@@ -417,6 +487,216 @@ func TestHandler_CommandStarted_Success(t *testing.T) {
 	}
 }
 
+func TestHandler_CommandStarted_TruncatesPerShell(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.CmdRawMaxBytesByShell = map[string]int{"zsh": 8}
+	server.config = cfg
+	ctx := context.Background()
+
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+		SessionId: "trunc-session",
+		Cwd:       "/tmp",
+		Client:    &pb.ClientInfo{Shell: "zsh"},
+	})
+
+	full := "echo héllo world"
+	resp, err := server.CommandStarted(ctx, &pb.CommandStartRequest{
+		SessionId: "trunc-session",
+		CommandId: "trunc-cmd",
+		Cwd:       "/tmp",
+		Command:   full,
+	})
+	if err != nil || !resp.Ok {
+		t.Fatalf("CommandStarted() = %v, %v", resp, err)
+	}
+
+	cmd := server.store.(*mockStore).commands["trunc-cmd"]
+	if cmd.Command != "echo hé" {
+		t.Errorf("stored command = %q, want %q", cmd.Command, "echo hé")
+	}
+	if !cmd.Truncated || cmd.FullHash != cmdutil.HashRaw(full) {
+		t.Errorf("Truncated = %v, FullHash = %q; want true and hash of full command", cmd.Truncated, cmd.FullHash)
+	}
+
+	history, err := server.FetchHistory(ctx, &pb.HistoryFetchRequest{Global: true})
+	if err != nil {
+		t.Fatalf("FetchHistory() error = %v", err)
+	}
+	if len(history.Items) != 1 || !history.Items[0].Truncated {
+		t.Errorf("FetchHistory() items = %v, want one truncated item", history.Items)
+	}
+}
+
+func TestHandler_CommandStarted_Redacts(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+	sub := server.events.Subscribe(&pb.SubscribeEventsRequest{})
+	defer server.events.Unsubscribe(sub)
+
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+		SessionId: "redact-session",
+		Cwd:       "/tmp",
+		Client:    &pb.ClientInfo{Shell: "zsh"},
+	})
+	resp, err := server.CommandStarted(ctx, &pb.CommandStartRequest{
+		SessionId: "redact-session",
+		CommandId: "redact-cmd",
+		Cwd:       "/tmp",
+		Command:   "tool --api-key abc123",
+	})
+	if err != nil || !resp.Ok {
+		t.Fatalf("CommandStarted() = %v, %v", resp, err)
+	}
+
+	want := "tool --api-key [REDACTED]"
+	if cmd := server.store.(*mockStore).commands["redact-cmd"]; cmd.Command != want {
+		t.Errorf("stored command = %q, want %q", cmd.Command, want)
+	}
+	for len(sub.ch) > 0 {
+		ev := <-sub.ch
+		if ev.Type == pb.ShellEventType_SHELL_EVENT_TYPE_COMMAND_START && ev.Command != want {
+			t.Errorf("published command = %q, want %q", ev.Command, want)
+		}
+	}
+}
+
+func TestHandler_CommandStarted_ReplayIsIdempotent(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+		SessionId: "replay-session",
+		Cwd:       "/tmp",
+		Client:    &pb.ClientInfo{Shell: "zsh"},
+	})
+
+	start := func(id, command string) *pb.Ack {
+		t.Helper()
+		resp, err := server.CommandStarted(ctx, &pb.CommandStartRequest{
+			SessionId: "replay-session",
+			CommandId: id,
+			Cwd:       "/tmp",
+			Command:   command,
+		})
+		if err != nil {
+			t.Fatalf("CommandStarted(%s) error = %v", id, err)
+		}
+		return resp
+	}
+	start("cmd-1", "make")
+	start("cmd-2", "make test")
+	if resp := start("cmd-1", "make"); !resp.Ok {
+		t.Errorf("replayed CommandStarted = %+v, want ok", resp)
+	}
+
+	info, _ := server.sessionManager.Get("replay-session")
+	if info.LastCmdID != "cmd-2" {
+		t.Errorf("LastCmdID = %q after a replay, want cmd-2 kept", info.LastCmdID)
+	}
+	if got := server.store.(*mockStore).commands["cmd-1"].Command; got != "make" {
+		t.Errorf("stored cmd-1 = %q, want the first record", got)
+	}
+}
+
+func TestHandler_CommandStarted_EphemeralNotPersisted(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.EphemeralDirs = []string{"/home/u/secrets"}
+	server.config = cfg
+	ctx := context.Background()
+
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+		SessionId: "eph-session",
+		Cwd:       "/tmp",
+		Client:    &pb.ClientInfo{Shell: "zsh"},
+	})
+
+	for _, req := range []*pb.CommandStartRequest{
+		{SessionId: "eph-session", CommandId: "eph-flag", Cwd: "/tmp", Command: "echo flag", Ephemeral: true},
+		{SessionId: "eph-session", CommandId: "eph-dir", Cwd: "/home/u/secrets/aws", Command: "echo dir"},
+	} {
+		resp, err := server.CommandStarted(ctx, req)
+		if err != nil || !resp.Ok {
+			t.Fatalf("CommandStarted(%s) = %v, %v", req.CommandId, resp, err)
+		}
+		if _, ok := server.store.(*mockStore).commands[req.CommandId]; ok {
+			t.Errorf("ephemeral command %s was stored", req.CommandId)
+		}
+		resp, err = server.CommandEnded(ctx, &pb.CommandEndRequest{SessionId: "eph-session", CommandId: req.CommandId})
+		if err != nil || !resp.Ok {
+			t.Fatalf("CommandEnded(%s) = %v, %v", req.CommandId, resp, err)
+		}
+	}
+	if n := server.getCommandsLogged(); n != 0 {
+		t.Errorf("commands logged = %d, want 0", n)
+	}
+	if uses := server.sessionManager.RecentUses("eph-session", time.Time{}); len(uses) != 2 {
+		t.Errorf("session uses = %v, want both ephemeral commands", uses)
+	}
+}
+
+func TestHandler_CommandStarted_IngestFilters(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.IngestFilters = config.IngestFilters{
+		IgnorePatterns:      []string{"ls", "cd *"},
+		IgnoreSpacePrefixed: true,
+		IgnoreSingleChar:    true,
+		CollapseDuplicates:  true,
+	}
+	server.config = cfg
+	ctx := context.Background()
+
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+		SessionId: "filter-session",
+		Cwd:       "/tmp",
+		Client:    &pb.ClientInfo{Shell: "zsh"},
+	})
+
+	tests := []struct {
+		id      string
+		command string
+		stored  bool
+	}{
+		{"f1", "make test", true},
+		{"f2", "make test", false},
+		{"f3", "ls", false},
+		{"f4", "cd src/internal", false},
+		{"f5", " export TOKEN=secret", false},
+		{"f6", "x", false},
+		{"f7", "ls -la", true},
+		{"f8", "make test", true},
+	}
+	for _, tt := range tests {
+		resp, err := server.CommandStarted(ctx, &pb.CommandStartRequest{
+			SessionId: "filter-session", CommandId: tt.id, Cwd: "/tmp", Command: tt.command,
+		})
+		if err != nil || !resp.Ok {
+			t.Fatalf("CommandStarted(%q) = %v, %v", tt.command, resp, err)
+		}
+		if _, ok := server.store.(*mockStore).commands[tt.id]; ok != tt.stored {
+			t.Errorf("%q stored = %v, want %v", tt.command, ok, tt.stored)
+		}
+		resp, err = server.CommandEnded(ctx, &pb.CommandEndRequest{SessionId: "filter-session", CommandId: tt.id})
+		if err != nil || !resp.Ok {
+			t.Fatalf("CommandEnded(%q) = %v, %v", tt.command, resp, err)
+		}
+	}
+	if n := server.getCommandsLogged(); n != 3 {
+		t.Errorf("commands logged = %d, want 3", n)
+	}
+}
+
 func TestHandler_CommandEnded_Success(t *testing.T) {
 	t.Parallel()
 
@@ -465,6 +745,253 @@ func TestHandler_CommandEnded_Success(t *testing.T) {
 	}
 }
 
+func TestHandler_CommandEventsBatch(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	store := server.store.(*mockStore)
+	ctx := context.Background()
+
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+		SessionId: "batch-session",
+		Cwd:       "/tmp",
+		Client:    &pb.ClientInfo{Shell: "zsh"},
+	})
+
+	start := func(id, command string) *pb.CommandLifecycleEvent {
+		return &pb.CommandLifecycleEvent{Event: &pb.CommandLifecycleEvent_Start{Start: &pb.CommandStartRequest{
+			SessionId: "batch-session", CommandId: id, Cwd: "/tmp", Command: command, TsUnixMs: 1000,
+		}}}
+	}
+	end := func(id string, exitCode int32) *pb.CommandLifecycleEvent {
+		return &pb.CommandLifecycleEvent{Event: &pb.CommandLifecycleEvent_End{End: &pb.CommandEndRequest{
+			SessionId: "batch-session", CommandId: id, ExitCode: exitCode, DurationMs: 10, TsUnixMs: 1010,
+		}}}
+	}
+
+	resp, err := server.CommandEventsBatch(ctx, &pb.CommandEventsBatchRequest{Events: []*pb.CommandLifecycleEvent{
+		start("b1", "make build"),
+		end("b1", 0),
+		start("b2", "make test"),
+		end("b2", 2),
+		{},
+	}})
+	if err != nil {
+		t.Fatalf("CommandEventsBatch failed: %v", err)
+	}
+	if resp.Accepted != 4 || resp.Failed != 1 {
+		t.Errorf("accepted=%d failed=%d, want 4 and 1", resp.Accepted, resp.Failed)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0] != "event 4: empty event" {
+		t.Errorf("errors = %q", resp.Errors)
+	}
+	if got := server.getCommandsLogged(); got != 2 {
+		t.Errorf("commands logged = %d, want 2", got)
+	}
+	if cmd := store.commands["b2"]; cmd == nil || cmd.ExitCode == nil || *cmd.ExitCode != 2 {
+		t.Errorf("b2 = %+v, want exit code 2", cmd)
+	}
+}
+
+func TestHandler_CommandEventsBatch_TooLarge(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	events := make([]*pb.CommandLifecycleEvent, maxCommandEventsBatch+1)
+	_, err := server.CommandEventsBatch(context.Background(), &pb.CommandEventsBatchRequest{Events: events})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestHandler_ImportEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_import_test.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), V2DB: v2db})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	exitCode := int32(1)
+	resp, err := server.ImportEvents(ctx, &pb.ImportEventsRequest{Events: []*pb.ImportedEvent{
+		{SessionId: "laptop", TsUnixMs: 1000, Command: "make test", Cwd: "/src/clai",
+			ExitCode: &exitCode, GitBranch: "main", GitRepoRoot: "/src/clai"},
+		{SessionId: "laptop", TsUnixMs: 2000, Command: "git status", Cwd: "/src/clai"},
+	}})
+	if err != nil {
+		t.Fatalf("ImportEvents failed: %v", err)
+	}
+	if resp.Imported != 2 || resp.Duplicates != 0 {
+		t.Errorf("imported=%d duplicates=%d, want 2 and 0", resp.Imported, resp.Duplicates)
+	}
+
+	var repoKey, branch string
+	var code int
+	if err := v2db.DB().QueryRowContext(ctx,
+		`SELECT repo_key, branch, exit_code FROM command_event WHERE cmd_raw = 'make test'`,
+	).Scan(&repoKey, &branch, &code); err != nil {
+		t.Fatalf("imported event missing from V2: %v", err)
+	}
+	if repoKey != "clai" || branch != "main" || code != 1 {
+		t.Errorf("V2 event = %s/%s/%d, want clai/main/1", repoKey, branch, code)
+	}
+}
+
+func TestHandler_ImportEvents_Redacts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_import_redact.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), V2DB: v2db})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	if _, err := server.ImportEvents(ctx, &pb.ImportEventsRequest{Events: []*pb.ImportedEvent{
+		{SessionId: "laptop", TsUnixMs: 1000, Command: "tool --api-key abc123", Cwd: "/src"},
+	}}); err != nil {
+		t.Fatalf("ImportEvents failed: %v", err)
+	}
+
+	var raw string
+	if err := v2db.DB().QueryRowContext(ctx, `SELECT cmd_raw FROM command_event`).Scan(&raw); err != nil {
+		t.Fatalf("imported event missing from V2: %v", err)
+	}
+	if raw != "tool --api-key [REDACTED]" {
+		t.Errorf("imported cmd_raw = %q, want the API key redacted", raw)
+	}
+}
+
+func TestHandler_ImportEvents_TooLarge(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	events := make([]*pb.ImportedEvent, maxImportEventsBatch+1)
+	_, err := server.ImportEvents(context.Background(), &pb.ImportEventsRequest{Events: events})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestHandler_DeleteAndPurgeCommand(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_delete_test.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+
+	store := newMockStore()
+	server, err := NewServer(&ServerConfig{Store: store, V2DB: v2db})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	if _, err := server.ImportEvents(ctx, &pb.ImportEventsRequest{Events: []*pb.ImportedEvent{
+		{SessionId: "laptop", TsUnixMs: 1000, Command: "deploy --target hunter2", Cwd: "/src"},
+		{SessionId: "laptop", TsUnixMs: 2000, Command: "git status", Cwd: "/src"},
+	}}); err != nil {
+		t.Fatalf("ImportEvents failed: %v", err)
+	}
+	for i, cmd := range []string{"deploy --target hunter2", "git status"} {
+		store.commands[fmt.Sprint(i)] = &storage.Command{CommandID: fmt.Sprint(i), Command: cmd}
+	}
+	countEvents := func() int {
+		var n int
+		if err := v2db.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM command_event WHERE deleted = 0`).Scan(&n); err != nil {
+			t.Fatalf("count events: %v", err)
+		}
+		return n
+	}
+
+	// A dry run reports the matches and changes nothing.
+	resp, err := server.DeleteCommand(ctx, &pb.DeleteCommandRequest{Pattern: "hunter2", DryRun: true})
+	if err != nil {
+		t.Fatalf("DeleteCommand dry run failed: %v", err)
+	}
+	if resp.Commands != 1 || resp.Events != 1 || len(resp.Samples) != 1 || resp.Samples[0] != "deploy --target hunter2" {
+		t.Errorf("dry run = %+v, want one command, one event and its sample", resp)
+	}
+	if countEvents() != 2 || len(store.commands) != 2 {
+		t.Fatal("dry run removed commands")
+	}
+
+	resp, err = server.DeleteCommand(ctx, &pb.DeleteCommandRequest{Pattern: "hunter2"})
+	if err != nil {
+		t.Fatalf("DeleteCommand failed: %v", err)
+	}
+	if resp.Commands != 1 || resp.Events != 1 || countEvents() != 1 {
+		t.Errorf("DeleteCommand = %+v with %d visible events, want one of each removed", resp, countEvents())
+	}
+
+	// Purge erases the event the delete only hid.
+	resp, err = server.PurgeCommand(ctx, &pb.DeleteCommandRequest{Pattern: "hunter2"})
+	if err != nil {
+		t.Fatalf("PurgeCommand failed: %v", err)
+	}
+	if resp.Events != 1 {
+		t.Errorf("PurgeCommand events = %d, want 1", resp.Events)
+	}
+	var total int
+	if err := v2db.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM command_event`).Scan(&total); err != nil || total != 1 {
+		t.Errorf("%d events left (%v), want 1", total, err)
+	}
+
+	// An exact delete leaves commands that only contain the text.
+	resp, err = server.DeleteCommand(ctx, &pb.DeleteCommandRequest{Pattern: "git", Exact: true})
+	if err != nil || resp.Commands != 0 || resp.Events != 0 {
+		t.Errorf("exact DeleteCommand(git) = %+v, %v; want nothing removed", resp, err)
+	}
+	resp, err = server.DeleteCommand(ctx, &pb.DeleteCommandRequest{Pattern: "git status", Exact: true})
+	if err != nil || resp.Commands != 1 || resp.Events != 1 || countEvents() != 0 {
+		t.Errorf("exact DeleteCommand(git status) = %+v, %v; want one of each removed", resp, err)
+	}
+
+	// An exact purge erases the whole-text match, hidden or not, and nothing
+	// that merely contains it.
+	resp, err = server.PurgeCommand(ctx, &pb.DeleteCommandRequest{Pattern: "git", Exact: true})
+	if err != nil || resp.Events != 0 {
+		t.Errorf("exact PurgeCommand(git) = %+v, %v; want nothing erased", resp, err)
+	}
+	resp, err = server.PurgeCommand(ctx, &pb.DeleteCommandRequest{Pattern: "git status", Exact: true})
+	if err != nil || resp.Events != 1 {
+		t.Errorf("exact PurgeCommand(git status) = %+v, %v; want one event erased", resp, err)
+	}
+	if err := v2db.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM command_event`).Scan(&total); err != nil || total != 0 {
+		t.Errorf("%d events left (%v), want 0", total, err)
+	}
+
+	for _, call := range []func(context.Context, *pb.DeleteCommandRequest) (*pb.DeleteCommandResponse, error){
+		server.DeleteCommand, server.PurgeCommand,
+	} {
+		if _, err := call(ctx, &pb.DeleteCommandRequest{}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("empty pattern error = %v, want InvalidArgument", err)
+		}
+	}
+}
+
 func newFeedbackStoreWithDB(t *testing.T) (*feedback.Store, func()) {
 	t.Helper()
 	ctx := context.Background()
@@ -746,6 +1273,9 @@ func TestHandler_Suggest_ReturnsHistorySuggestions(t *testing.T) {
 	if resp.Suggestions[0].Text != "git status" {
 		t.Errorf("expected first suggestion to be 'git status', got %s", resp.Suggestions[0].Text)
 	}
+	if got := resp.Suggestions[0].WordBoundaries; len(got) != 2 || got[0] != 4 || got[1] != 10 {
+		t.Errorf("WordBoundaries = %v, want [4 10]", got)
+	}
 }
 
 func TestHandler_Suggest_V1IncludesWhyDetailsWhenAvailable(t *testing.T) {
@@ -919,15 +1449,142 @@ func TestHandler_Diagnose_Success(t *testing.T) {
 	}
 }
 
-func TestHandler_Ping_Success(t *testing.T) {
+func TestHandler_AutoDiagnoseRepeatedFailure(t *testing.T) {
 	t.Parallel()
 
 	server := createTestServer(t)
+	cfg := config.DefaultConfig()
+	cfg.AI.AutoDiagnose = true
+	server.config = cfg
 	ctx := context.Background()
+	sub := server.events.Subscribe(&pb.SubscribeEventsRequest{
+		Types: []pb.ShellEventType{pb.ShellEventType_SHELL_EVENT_TYPE_DIAGNOSIS},
+	})
 
-	resp, err := server.Ping(ctx, &pb.Ack{})
-	if err != nil {
-		t.Fatalf("Ping failed: %v", err)
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+		SessionId: "diag-session",
+		Cwd:       "/tmp",
+		Client:    &pb.ClientInfo{Shell: "zsh"},
+	})
+	for i, command := range []string{"npm run biuld", "npm run biuld"} {
+		id := fmt.Sprintf("diag-%d", i)
+		_, _ = server.CommandStarted(ctx, &pb.CommandStartRequest{SessionId: "diag-session", CommandId: id, Cwd: "/tmp", Command: command})
+		_, _ = server.CommandEnded(ctx, &pb.CommandEndRequest{SessionId: "diag-session", CommandId: id, ExitCode: 1})
+	}
+
+	select {
+	case ev := <-sub.ch:
+		if ev.CommandId != "diag-1" || ev.ExitCode != 1 || ev.Diagnosis != "Test explanation" ||
+			len(ev.Fixes) != 1 || ev.Fixes[0] != "echo hello" {
+			t.Errorf("diagnosis event = %v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no diagnosis published after two failures in a row")
+	}
+
+	resp, err := server.Suggest(ctx, &pb.SuggestRequest{SessionId: "diag-session", Cwd: "/tmp"})
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	if len(resp.Suggestions) == 0 || resp.Suggestions[0].Source != sourceDiagnosis || resp.Suggestions[0].Text != "echo hello" {
+		t.Errorf("Suggest did not put the diagnosis fix first: %v", resp.Suggestions)
+	}
+}
+
+func TestHandler_AutoDiagnoseSharesDiagnoseRateLimit(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	cfg := config.DefaultConfig()
+	cfg.AI.AutoDiagnose = true
+	cfg.Daemon.RateLimitAIPerMin = 1
+	server.config = cfg
+	ctx := context.Background()
+	sub := server.events.Subscribe(&pb.SubscribeEventsRequest{
+		Types: []pb.ShellEventType{pb.ShellEventType_SHELL_EVENT_TYPE_DIAGNOSIS},
+	})
+
+	// The session spends its one Diagnose call of the minute.
+	method := pb.ClaiService_Diagnose_FullMethodName
+	if ok, _, _ := server.rateLimiter.Allow(rateLimitKey(method, "limited-session"), 1, time.Minute); !ok {
+		t.Fatal("first Diagnose call was rate limited")
+	}
+
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+		SessionId: "limited-session",
+		Cwd:       "/tmp",
+		Client:    &pb.ClientInfo{Shell: "zsh"},
+	})
+	for i := range autoDiagnoseFailures {
+		id := fmt.Sprintf("limited-%d", i)
+		_, _ = server.CommandStarted(ctx, &pb.CommandStartRequest{SessionId: "limited-session", CommandId: id, Cwd: "/tmp", Command: "make"})
+		_, _ = server.CommandEnded(ctx, &pb.CommandEndRequest{SessionId: "limited-session", CommandId: id, ExitCode: 1})
+	}
+
+	select {
+	case ev := <-sub.ch:
+		t.Errorf("diagnosis published over the rate limit: %v", ev)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestHandler_LatencyBudgetDegradesSession(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.LatencyBudget.SuggestP95Ms = 100
+	server.config = cfg
+	ctx := context.Background()
+
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+		SessionId: "slow-session",
+		Cwd:       "/tmp",
+		Client:    &pb.ClientInfo{Shell: "zsh"},
+	})
+	for i := 0; i < latencyMinSamples; i++ {
+		resp, err := server.Suggest(ctx, &pb.SuggestRequest{SessionId: "slow-session", Cwd: "/tmp", ClientLatencyMs: 400})
+		if err != nil {
+			t.Fatalf("Suggest failed: %v", err)
+		}
+		if resp.GetDegraded() {
+			t.Fatalf("call %d was degraded before the budget was exceeded", i)
+		}
+	}
+
+	resp, err := server.Suggest(ctx, &pb.SuggestRequest{SessionId: "slow-session", Cwd: "/tmp"})
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	if !resp.Degraded || len(resp.Suggestions) != 0 {
+		t.Errorf("Suggest over budget = %v, want a degraded empty response", resp)
+	}
+
+	status, err := server.GetStatus(ctx, &pb.Ack{})
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if status.DegradedSessions != 1 {
+		t.Errorf("DegradedSessions = %d, want 1", status.DegradedSessions)
+	}
+	sessions, err := server.ListSessions(ctx, &pb.Ack{})
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions.Sessions) != 1 || !strings.HasPrefix(sessions.Sessions[0].Degraded, "suggest p95 4") {
+		t.Errorf("ListSessions = %v, want the session marked degraded", sessions.Sessions)
+	}
+}
+
+func TestHandler_Ping_Success(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	resp, err := server.Ping(ctx, &pb.Ack{})
+	if err != nil {
+		t.Fatalf("Ping failed: %v", err)
 	}
 
 	if !resp.Ok {
@@ -1917,6 +2574,13 @@ func (m *mockFailingProvider) Diagnose(ctx context.Context, req *provider.Diagno
 	return &provider.DiagnoseResponse{}, nil
 }
 
+func (m *mockFailingProvider) Explain(ctx context.Context, req *provider.ExplainRequest) (*provider.ExplainResponse, error) {
+	if m.shouldFail {
+		return nil, storage.ErrSessionNotFound
+	}
+	return &provider.ExplainResponse{}, nil
+}
+
 func TestHandler_TextToCommand_NoProvider(t *testing.T) {
 	t.Parallel()
 
@@ -2442,6 +3106,40 @@ func TestHandler_GetStatus_ReturnsVersion(t *testing.T) {
 	}
 }
 
+func TestHandler_GetStatus_ReportsProvidersAndQueue(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	resp, err := server.GetStatus(ctx, &pb.Ack{})
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+
+	if resp.Pid == 0 {
+		t.Error("expected pid to be set")
+	}
+	var found *pb.ProviderStatus
+	for _, p := range resp.Providers {
+		if p.Name == "test" {
+			found = p
+		}
+	}
+	if found == nil {
+		t.Fatalf("expected provider %q in status, got %v", "test", resp.Providers)
+	}
+	if !found.Available || !found.Preferred {
+		t.Errorf("expected test provider to be available and preferred, got %+v", found)
+	}
+	if resp.IngestQueueDepth != 0 {
+		t.Errorf("expected empty ingest queue, got %d", resp.IngestQueueDepth)
+	}
+	if resp.LastMaintenanceUnixMs != 0 {
+		t.Errorf("expected no maintenance timestamp, got %d", resp.LastMaintenanceUnixMs)
+	}
+}
+
 // ============================================================================
 // FetchHistory handler tests
 // ============================================================================
@@ -2753,6 +3451,141 @@ func TestHandler_FetchHistory_EmptyResult(t *testing.T) {
 	}
 }
 
+func TestHandler_FetchHistory_MergesV2(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_history_test.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+	defer ingest.ReleaseStatements(v2db.DB())
+
+	eventIDs := make(map[string]int64)
+	for _, ev := range []*event.CommandEvent{
+		{CmdRaw: "git log", TS: 6000},
+		{CmdRaw: "make build", TS: 4500},
+	} {
+		ev.Version = event.EventVersion
+		ev.Type = event.EventTypeCommandEnd
+		ev.SessionID = "session-1"
+		ev.Shell = event.ShellZsh
+		ev.Cwd = "/tmp"
+		res, err := ingest.WritePath(ctx, v2db.DB(), ingest.PrepareWriteContext(ev, "", "", "", 0, false, nil), &ingest.WritePathConfig{})
+		if err != nil {
+			t.Fatalf("WritePath failed: %v", err)
+		}
+		eventIDs[ev.CmdRaw] = res.EventID
+	}
+
+	server, err := NewServer(&ServerConfig{Store: createTestServerWithCommands(t).store, V2DB: v2db})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	resp, err := server.FetchHistory(ctx, &pb.HistoryFetchRequest{Global: true, Limit: 3})
+	if err != nil {
+		t.Fatalf("FetchHistory failed: %v", err)
+	}
+	want := []*pb.HistoryItem{
+		{Command: "git log", TimestampMs: 6000, EventId: eventIDs["git log"], Origin: storage.HistoryOriginV2},
+		{Command: "echo hello", TimestampMs: 5000, Origin: storage.HistoryOriginV1},
+		{Command: "make build", TimestampMs: 4500, EventId: eventIDs["make build"], Origin: storage.HistoryOriginV2},
+	}
+	if len(resp.Items) != len(want) || resp.AtEnd {
+		t.Fatalf("FetchHistory() = %v (at end %v), want %d items and more", resp.Items, resp.AtEnd, len(want))
+	}
+	for i, w := range want {
+		got := resp.Items[i]
+		if got.Command != w.Command || got.TimestampMs != w.TimestampMs || got.EventId != w.EventId || got.Origin != w.Origin {
+			t.Errorf("item %d = %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestHandler_FetchHistory_MigratedReadsV2(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_history_test.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+
+	if _, err := v2db.DB().ExecContext(ctx, `
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, ephemeral) VALUES
+			('s1', 1000, '/tmp', 'make build', 'make build', 0),
+			('s1', 2000, '/tmp', 'make test', 'make test', 0),
+			('s2', 3000, '/tmp', 'make build', 'make build', 0),
+			('s2', 4000, '/tmp', 'export TOKEN=x', 'export TOKEN=x', 1);
+		INSERT INTO session (id, shell, started_at_ms, host) VALUES
+			('s1', 'zsh', 1000, 'laptop'),
+			('s2', 'zsh', 3000, 'desktop');
+		INSERT INTO storage_migration (name, completed_ms, events) VALUES (?, 5000, 3);
+	`, backfill.V1MigrationName); err != nil {
+		t.Fatalf("failed to seed V2 database: %v", err)
+	}
+
+	// The V1 store only has an unrelated command, so results must come from V2.
+	store := newMockStore()
+	store.commands["v1-only"] = &storage.Command{
+		CommandID: "v1-only", SessionID: "s1", Command: "v1 only", TSStartUnixMs: 500,
+	}
+	server, err := NewServer(&ServerConfig{Store: store, V2DB: v2db})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	resp, err := server.FetchHistory(ctx, &pb.HistoryFetchRequest{Global: true, Limit: 1})
+	if err != nil {
+		t.Fatalf("FetchHistory failed: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].Command != "make build" || resp.Items[0].TimestampMs != 3000 {
+		t.Fatalf("items = %v, want the latest make build", resp.Items)
+	}
+	if resp.AtEnd {
+		t.Error("expected at_end=false with more history")
+	}
+
+	resp, err = server.FetchHistory(ctx, &pb.HistoryFetchRequest{SessionId: "s1", Query: "TEST"})
+	if err != nil {
+		t.Fatalf("FetchHistory failed: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].Command != "make test" {
+		t.Errorf("session items = %v, want make test", resp.Items)
+	}
+
+	resp, err = server.FetchHistory(ctx, &pb.HistoryFetchRequest{Global: true, Host: "laptop"})
+	if err != nil {
+		t.Fatalf("FetchHistory failed: %v", err)
+	}
+	if len(resp.Items) != 2 || resp.Items[0].TimestampMs != 2000 || resp.Items[1].TimestampMs != 1000 {
+		t.Errorf("host items = %v, want laptop's make test and make build", resp.Items)
+	}
+
+	resp, err = server.FetchHistory(ctx, &pb.HistoryFetchRequest{
+		Global: true, Query: "KE BUI", Mode: pb.SearchMode_SEARCH_MODE_FTS, Cwd: "/tmp",
+	})
+	if err != nil {
+		t.Fatalf("FetchHistory failed: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].Command != "make build" || resp.Items[0].Cwd != "/tmp" || resp.Items[0].ExitCode != nil {
+		t.Errorf("full-text items = %v, want make build in /tmp without an exit code", resp.Items)
+	}
+
+	if _, err := server.FetchHistory(ctx, &pb.HistoryFetchRequest{Global: true, Status: "ok"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("FetchHistory(status=ok) error = %v, want InvalidArgument", err)
+	}
+}
+
 func TestStripANSI(t *testing.T) {
 	t.Parallel()
 
@@ -3086,6 +3919,66 @@ func TestCommandEnded_FeedsV2(t *testing.T) {
 	}
 }
 
+// TestCommandEnded_FeedsV2Once verifies that only the first end of the
+// session's current command reaches V2: a replayed end and the end of a
+// command that is no longer current are dropped.
+func TestCommandEnded_FeedsV2Once(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_cmd_ended_once.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), V2DB: v2db})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server.batchWriter.Start()
+
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+		SessionId: "once-session",
+		Cwd:       "/src",
+		Client:    &pb.ClientInfo{Shell: "zsh"},
+	})
+	for _, id := range []string{"cmd-1", "cmd-2"} {
+		if _, err := server.CommandStarted(ctx, &pb.CommandStartRequest{
+			SessionId: "once-session",
+			CommandId: id,
+			Cwd:       "/src",
+			Command:   "make " + id,
+		}); err != nil {
+			t.Fatalf("CommandStarted(%s) failed: %v", id, err)
+		}
+	}
+	// cmd-1 is no longer current; cmd-2 ends twice, as when replayed.
+	for _, id := range []string{"cmd-1", "cmd-2", "cmd-2"} {
+		if _, err := server.CommandEnded(ctx, &pb.CommandEndRequest{
+			SessionId: "once-session",
+			CommandId: id,
+		}); err != nil {
+			t.Fatalf("CommandEnded(%s) failed: %v", id, err)
+		}
+	}
+	server.batchWriter.Stop()
+
+	var n int
+	var raw string
+	if err := v2db.DB().QueryRowContext(ctx,
+		`SELECT COUNT(*), MAX(cmd_raw) FROM command_event WHERE session_id = 'once-session'`,
+	).Scan(&n, &raw); err != nil {
+		t.Fatalf("failed to query V2 command_event: %v", err)
+	}
+	if n != 1 || raw != "make cmd-2" {
+		t.Errorf("V2 events = %d (%q), want one for make cmd-2", n, raw)
+	}
+}
+
 // TestCommandEnded_V2NilGraceful verifies that CommandEnded works normally
 // when batchWriter is nil (V2 disabled).
 func TestCommandEnded_V2NilGraceful(t *testing.T) {
@@ -3220,3 +4113,565 @@ func TestCommandEnded_ExitCodeRecorded(t *testing.T) {
 func writeTestFile(path, content string) error {
 	return os.WriteFile(path, []byte(content), 0644)
 }
+
+// --- Session notes ---
+
+// noteRecordingProvider records the session note passed to AI requests.
+type noteRecordingProvider struct {
+	mockProvider
+	textToCommandNote string
+	diagnoseNote      string
+}
+
+func (m *noteRecordingProvider) TextToCommand(ctx context.Context, req *provider.TextToCommandRequest) (*provider.TextToCommandResponse, error) {
+	m.textToCommandNote = req.SessionNote
+	return m.mockProvider.TextToCommand(ctx, req)
+}
+
+func (m *noteRecordingProvider) Diagnose(ctx context.Context, req *provider.DiagnoseRequest) (*provider.DiagnoseResponse, error) {
+	m.diagnoseNote = req.SessionNote
+	return m.mockProvider.Diagnose(ctx, req)
+}
+
+func TestHandler_SessionNote_SetReadClear(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "note-session", Cwd: "/tmp"})
+
+	resp, err := server.SessionNote(ctx, &pb.SessionNoteRequest{SessionId: "note-session", Note: "debugging auth"})
+	if err != nil {
+		t.Fatalf("SessionNote failed: %v", err)
+	}
+	if !resp.Ok || resp.Note != "debugging auth" {
+		t.Fatalf("set: got ok=%v note=%q", resp.Ok, resp.Note)
+	}
+
+	resp, _ = server.SessionNote(ctx, &pb.SessionNoteRequest{SessionId: "note-session"})
+	if resp.Note != "debugging auth" {
+		t.Errorf("read: got note %q, want %q", resp.Note, "debugging auth")
+	}
+
+	resp, _ = server.SessionNote(ctx, &pb.SessionNoteRequest{SessionId: "note-session", Clear: true})
+	if !resp.Ok || resp.Note != "" {
+		t.Errorf("clear: got ok=%v note=%q", resp.Ok, resp.Note)
+	}
+}
+
+func TestHandler_SessionNote_UnknownSession(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	resp, err := server.SessionNote(context.Background(), &pb.SessionNoteRequest{SessionId: "missing", Note: "x"})
+	if err != nil {
+		t.Fatalf("SessionNote failed: %v", err)
+	}
+	if resp.Ok {
+		t.Error("expected ok=false for unknown session")
+	}
+}
+
+func TestHandler_SessionName(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "name-session", Cwd: "/tmp"})
+
+	resp, err := server.SessionName(ctx, &pb.SessionNameRequest{SessionId: "name-session", Name: "  deploy\n v2 "})
+	if err != nil {
+		t.Fatalf("SessionName failed: %v", err)
+	}
+	if !resp.Ok || resp.Name != "deploy v2" {
+		t.Fatalf("set: got ok=%v name=%q", resp.Ok, resp.Name)
+	}
+	if stored, _ := server.store.GetSession(ctx, "name-session"); stored.Name != "deploy v2" {
+		t.Errorf("stored name = %q, want %q", stored.Name, "deploy v2")
+	}
+	list, _ := server.ListSessions(ctx, &pb.Ack{})
+	if len(list.Sessions) != 1 || list.Sessions[0].Name != "deploy v2" {
+		t.Errorf("ListSessions() = %v, want the name in the summary", list.Sessions)
+	}
+
+	resp, _ = server.SessionName(ctx, &pb.SessionNameRequest{SessionId: "name-session"})
+	if resp.Name != "deploy v2" {
+		t.Errorf("read: got name %q", resp.Name)
+	}
+
+	resp, _ = server.SessionName(ctx, &pb.SessionNameRequest{SessionId: "name-session", Name: strings.Repeat("x", 100)})
+	if len(resp.Name) != maxSessionNameLen {
+		t.Errorf("long name: got %d runes, want %d", len(resp.Name), maxSessionNameLen)
+	}
+
+	resp, _ = server.SessionName(ctx, &pb.SessionNameRequest{SessionId: "name-session", Clear: true})
+	if !resp.Ok || resp.Name != "" {
+		t.Errorf("clear: got ok=%v name=%q", resp.Ok, resp.Name)
+	}
+
+	resp, _ = server.SessionName(ctx, &pb.SessionNameRequest{SessionId: "missing", Name: "x"})
+	if resp.Ok {
+		t.Error("expected ok=false for unknown session")
+	}
+}
+
+func TestNormalizeSessionNote(t *testing.T) {
+	t.Parallel()
+
+	got := normalizeSessionNote("  line one\n\tline\x1b[31m two\x00 ")
+	if got != "line one line two" {
+		t.Errorf("normalizeSessionNote() = %q", got)
+	}
+
+	long := normalizeSessionNote(strings.Repeat("é", maxSessionNoteLen+10))
+	if n := len([]rune(long)); n != maxSessionNoteLen {
+		t.Errorf("expected %d runes, got %d", maxSessionNoteLen, n)
+	}
+}
+
+func TestHandler_SessionNote_IncludedInAIRequests(t *testing.T) {
+	t.Parallel()
+
+	prov := &noteRecordingProvider{mockProvider: mockProvider{name: "test", available: true, suggestion: "go test ./..."}}
+	registry := provider.NewRegistry()
+	registry.Register(prov)
+	registry.SetPreferred("test")
+
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), Registry: registry})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	ctx := context.Background()
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "s1", Cwd: "/tmp"})
+	_, _ = server.SessionNote(ctx, &pb.SessionNoteRequest{SessionId: "s1", Note: "flaky auth test"})
+
+	_, _ = server.TextToCommand(ctx, &pb.TextToCommandRequest{SessionId: "s1", Prompt: "rerun tests"})
+	_, _ = server.Diagnose(ctx, &pb.DiagnoseRequest{SessionId: "s1", Command: "go test", ExitCode: 1})
+
+	if prov.textToCommandNote != "flaky auth test" {
+		t.Errorf("TextToCommand note = %q", prov.textToCommandNote)
+	}
+	if prov.diagnoseNote != "flaky auth test" {
+		t.Errorf("Diagnose note = %q", prov.diagnoseNote)
+	}
+}
+
+func TestHandler_GetCommandDetail(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_detail_test.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+	defer ingest.ReleaseStatements(v2db.DB())
+
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), V2DB: v2db})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.CaptureOutput = true
+	cfg.Suggestions.OutputMaxBytes = 16
+	server.ApplyConfig(cfg)
+
+	ev := &event.CommandEvent{
+		Version:   event.EventVersion,
+		Type:      event.EventTypeCommandEnd,
+		SessionID: "s1",
+		Shell:     event.ShellZsh,
+		Cwd:       "/src",
+		CmdRaw:    "make test",
+		ExitCode:  2,
+		TS:        1000,
+	}
+	server.attachOutput(ev, &pb.CommandEndRequest{
+		StdoutTail: "ok",
+		StderrTail: "compiling...\nFAIL: TestParse",
+	})
+	res, err := ingest.WritePath(ctx, v2db.DB(), ingest.PrepareWriteContext(ev, "", "", "", 0, false, nil), &ingest.WritePathConfig{})
+	if err != nil {
+		t.Fatalf("WritePath failed: %v", err)
+	}
+
+	resp, err := server.GetCommandDetail(ctx, &pb.GetCommandDetailRequest{EventId: res.EventID})
+	if err != nil {
+		t.Fatalf("GetCommandDetail failed: %v", err)
+	}
+	if !resp.Found || resp.Command != "make test" || resp.ExitCode != 2 || resp.Cwd != "/src" {
+		t.Errorf("GetCommandDetail = %+v, want the make test event", resp)
+	}
+	if !resp.HasOutput || resp.StdoutTail != "ok" || resp.StderrTail != "\nFAIL: TestParse" || !resp.OutputTruncated {
+		t.Errorf("output = %q / %q (truncated %v), want the stderr tail cut to 16 bytes",
+			resp.StdoutTail, resp.StderrTail, resp.OutputTruncated)
+	}
+	if got := server.recentOutput(ctx, "s1", "make test"); got != resp.StderrTail {
+		t.Errorf("recentOutput() = %q, want the stderr tail", got)
+	}
+
+	resp, err = server.GetCommandDetail(ctx, &pb.GetCommandDetailRequest{EventId: res.EventID + 1})
+	if err != nil || resp.Found {
+		t.Errorf("GetCommandDetail(missing) = %+v, %v; want not found", resp, err)
+	}
+	if _, err := server.GetCommandDetail(ctx, &pb.GetCommandDetailRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetCommandDetail without event_id error = %v, want InvalidArgument", err)
+	}
+
+	// Without capture_output nothing is attached.
+	server.ApplyConfig(config.DefaultConfig())
+	plain := &event.CommandEvent{}
+	server.attachOutput(plain, &pb.CommandEndRequest{StderrTail: "secret"})
+	if plain.Stderr != "" {
+		t.Errorf("attachOutput() with capture off set stderr %q", plain.Stderr)
+	}
+}
+
+func TestHandler_Dismissals(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_dismissals_test.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), V2DB: v2db})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	store := dismissal.NewStore(v2db.DB(), dismissal.Config{LearnedThreshold: 1}, nil)
+	if err := store.RecordDismissal(ctx, "global", "tpl-ctx", "tpl-a", 1000); err != nil {
+		t.Fatalf("RecordDismissal failed: %v", err)
+	}
+	if err := store.RecordNever(ctx, "global", "tpl-ctx", "tpl-b", 2000); err != nil {
+		t.Fatalf("RecordNever failed: %v", err)
+	}
+
+	list, err := server.ListDismissals(ctx, &pb.ListDismissalsRequest{})
+	if err != nil {
+		t.Fatalf("ListDismissals failed: %v", err)
+	}
+	if len(list.Dismissals) != 2 {
+		t.Fatalf("ListDismissals returned %d dismissals, want 2", len(list.Dismissals))
+	}
+	if d := list.Dismissals[0]; d.DismissedTemplateId != "tpl-b" || d.Level != "permanent" || d.LastDismissedMs != 2000 {
+		t.Errorf("first dismissal = %+v, want the permanent tpl-b", d)
+	}
+
+	cleared, err := server.ClearDismissals(ctx, &pb.ClearDismissalsRequest{DismissedTemplateId: "tpl-b"})
+	if err != nil {
+		t.Fatalf("ClearDismissals failed: %v", err)
+	}
+	if cleared.Cleared != 1 {
+		t.Errorf("ClearDismissals cleared %d, want 1", cleared.Cleared)
+	}
+	list, err = server.ListDismissals(ctx, &pb.ListDismissalsRequest{})
+	if err != nil {
+		t.Fatalf("ListDismissals failed: %v", err)
+	}
+	if len(list.Dismissals) != 1 || list.Dismissals[0].DismissedTemplateId != "tpl-a" {
+		t.Errorf("ListDismissals after clear = %+v, want only tpl-a", list.Dismissals)
+	}
+}
+
+func TestHandler_SuggestSlots(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_slots_test.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+	defer ingest.ReleaseStatements(v2db.DB())
+
+	for i, cmd := range []string{"kubectl get pods -n prod", "kubectl get pods -n prod", "kubectl get pods -n staging"} {
+		ev := &event.CommandEvent{
+			Version:   event.EventVersion,
+			Type:      event.EventTypeCommandEnd,
+			SessionID: "session-1",
+			Shell:     event.ShellZsh,
+			Cwd:       "/tmp",
+			CmdRaw:    cmd,
+			TS:        int64(1000 + i),
+		}
+		if _, err := ingest.WritePath(ctx, v2db.DB(), ingest.PrepareWriteContext(ev, "", "", "", 0, false, nil), &ingest.WritePathConfig{}); err != nil {
+			t.Fatalf("WritePath failed: %v", err)
+		}
+	}
+
+	server, err := NewServer(&ServerConfig{
+		Store: newMockStore(),
+		V2DB:  v2db,
+		Clock: clock.NewManual(time.UnixMilli(2000)),
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	resp, err := server.SuggestSlots(ctx, &pb.SuggestSlotsRequest{Buffer: "kubectl get pods -n "})
+	if err != nil {
+		t.Fatalf("SuggestSlots failed: %v", err)
+	}
+	if len(resp.Values) != 2 || resp.Values[0].Value != "prod" || resp.Values[1].Value != "staging" {
+		t.Errorf("SuggestSlots = %+v, want prod then staging", resp.Values)
+	}
+
+	// The cursor cuts the buffer: only the text before it is completed.
+	resp, err = server.SuggestSlots(ctx, &pb.SuggestSlotsRequest{Buffer: "kubectl get pods -n st --watch", CursorPos: 22})
+	if err != nil {
+		t.Fatalf("SuggestSlots failed: %v", err)
+	}
+	if len(resp.Values) != 1 || resp.Values[0].Value != "staging" {
+		t.Errorf("SuggestSlots at cursor = %+v, want staging", resp.Values)
+	}
+}
+
+func TestHandler_TypoCorrection(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_typo_test.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+	defer ingest.ReleaseStatements(v2db.DB())
+
+	for i, cmd := range []string{"git status", "git status", "git status", "ls"} {
+		ev := &event.CommandEvent{
+			Version:   event.EventVersion,
+			Type:      event.EventTypeCommandEnd,
+			SessionID: "session-1",
+			Shell:     event.ShellZsh,
+			Cwd:       "/tmp",
+			CmdRaw:    cmd,
+			TS:        int64(1000 + i),
+		}
+		if _, err := ingest.WritePath(ctx, v2db.DB(), ingest.PrepareWriteContext(ev, "", "", "", 0, false, nil), &ingest.WritePathConfig{}); err != nil {
+			t.Fatalf("WritePath failed: %v", err)
+		}
+	}
+
+	registry := provider.NewRegistry()
+	registry.Register(&mockProvider{name: "test", available: true, suggestion: "echo hello"})
+	registry.SetPreferred("test")
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), V2DB: v2db, Registry: registry})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{
+		SessionId: "typo-session",
+		Cwd:       "/tmp",
+		Client:    &pb.ClientInfo{Shell: "zsh"},
+	})
+	_, _ = server.CommandStarted(ctx, &pb.CommandStartRequest{
+		SessionId: "typo-session",
+		CommandId: "typo-cmd",
+		Cwd:       "/tmp",
+		Command:   "git stauts",
+	})
+	_, _ = server.CommandEnded(ctx, &pb.CommandEndRequest{
+		SessionId: "typo-session",
+		CommandId: "typo-cmd",
+		ExitCode:  127,
+	})
+
+	// The correction is looked up in the background.
+	var top *pb.Suggestion
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		resp, err := server.Suggest(ctx, &pb.SuggestRequest{SessionId: "typo-session", Cwd: "/tmp"})
+		if err != nil {
+			t.Fatalf("Suggest failed: %v", err)
+		}
+		if len(resp.Suggestions) > 0 && resp.Suggestions[0].Source == sourceTypo {
+			top = resp.Suggestions[0]
+			break
+		}
+	}
+	if top == nil {
+		t.Fatal("Suggest never proposed the typo correction")
+	}
+	if top.Text != "git status" || len(top.Reasons) != 1 || top.Reasons[0].Type != reasonTypoCorrection {
+		t.Errorf("typo suggestion = %+v, want git status with a typo_correction reason", top)
+	}
+
+	next, err := server.NextStep(ctx, &pb.NextStepRequest{SessionId: "typo-session", LastCommand: "git stauts", LastExitCode: 127})
+	if err != nil {
+		t.Fatalf("NextStep failed: %v", err)
+	}
+	if len(next.Suggestions) != 2 || next.Suggestions[0].Text != "git status" || next.Suggestions[1].Source != sourceAI {
+		t.Errorf("NextStep suggestions = %+v, want git status before the AI suggestion", next.Suggestions)
+	}
+
+	// A buffer the correction does not start with hides it, and the next
+	// command drops it.
+	resp, _ := server.Suggest(ctx, &pb.SuggestRequest{SessionId: "typo-session", Buffer: "ls"})
+	for _, sug := range resp.GetSuggestions() {
+		if sug.Source == sourceTypo {
+			t.Errorf("Suggest(ls) proposed %q", sug.Text)
+		}
+	}
+	_, _ = server.CommandStarted(ctx, &pb.CommandStartRequest{
+		SessionId: "typo-session",
+		CommandId: "next-cmd",
+		Cwd:       "/tmp",
+		Command:   "ls",
+	})
+	if got := server.typoCorrectionsFor("typo-session", ""); len(got) != 0 {
+		t.Errorf("corrections after the next command = %+v, want none", got)
+	}
+}
+
+func TestApplySessionRecency(t *testing.T) {
+	t.Parallel()
+
+	now := time.UnixMilli(10_000_000)
+	boost := config.SessionRecencyBoost{WindowMinutes: 10, Strength: 2}
+	suggestions := []*pb.Suggestion{
+		{Text: "git status"},
+		{Text: "make build"},
+		{Text: "make test"},
+		{Text: "ls"},
+	}
+	uses := map[string]time.Time{
+		"make build": now.Add(-time.Minute),      // lifted past git status
+		"ls":         now.Add(-9 * time.Minute),  // too faded to move
+		"git status": now.Add(-20 * time.Minute), // outside the window
+	}
+
+	got := applySessionRecency(suggestions, uses, now, boost, 3)
+	var texts []string
+	for _, sug := range got {
+		texts = append(texts, sug.Text)
+	}
+	if want := []string{"make build", "git status", "make test"}; !slices.Equal(texts, want) {
+		t.Fatalf("ranked = %v, want %v", texts, want)
+	}
+	if len(got[0].Reasons) != 1 || got[0].Reasons[0].Type != reasonSessionRecency {
+		t.Errorf("make build reasons = %v, want one %s reason", got[0].Reasons, reasonSessionRecency)
+	}
+	if len(got[1].Reasons) != 0 {
+		t.Errorf("git status reasons = %v, want none outside the window", got[1].Reasons)
+	}
+}
+
+func TestHandler_SessionRecencyRecordsCommands(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := createTestServer(t)
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "recency-session", Cwd: "/tmp"})
+	_, _ = server.CommandStarted(ctx, &pb.CommandStartRequest{
+		SessionId: "recency-session",
+		CommandId: "cmd-1",
+		Cwd:       "/tmp",
+		Command:   "make build ",
+	})
+
+	now := time.Now()
+	uses := server.recentSessionUses("recency-session", config.SessionRecencyBoost{WindowMinutes: 5, Strength: 1}, now)
+	if _, ok := uses["make build"]; !ok {
+		t.Errorf("recentSessionUses = %v, want make build", uses)
+	}
+	if uses := server.recentSessionUses("recency-session", config.SessionRecencyBoost{}, now); uses != nil {
+		t.Errorf("recentSessionUses with the boost off = %v, want nil", uses)
+	}
+}
+
+func TestHandler_WorkflowSuggestion(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_workflow_test.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+	defer ingest.ReleaseStatements(v2db.DB())
+
+	ts := int64(1000)
+	record := func(sessionID, cmd string) {
+		ts++
+		ev := &event.CommandEvent{
+			Version:   event.EventVersion,
+			Type:      event.EventTypeCommandEnd,
+			SessionID: sessionID,
+			Shell:     event.ShellZsh,
+			Cwd:       "/tmp",
+			CmdRaw:    cmd,
+			TS:        ts,
+		}
+		if _, err := ingest.WritePath(ctx, v2db.DB(), ingest.PrepareWriteContext(ev, "", "", "", 0, false, nil), &ingest.WritePathConfig{}); err != nil {
+			t.Fatalf("WritePath failed: %v", err)
+		}
+	}
+	for _, session := range []string{"past-1", "past-2", "past-3"} {
+		record(session, "git add .")
+		record(session, "git commit -m wip")
+		record(session, "git push")
+	}
+	record("workflow-session", "git add .")
+
+	registry := provider.NewRegistry()
+	registry.Register(&mockProvider{name: "test", available: true, suggestion: "echo hello"})
+	registry.SetPreferred("test")
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), V2DB: v2db, Registry: registry})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	if server.workflowMiner == nil {
+		t.Fatal("workflow miner not configured")
+	}
+	server.workflowMiner.MineOnce(ctx)
+
+	resp, err := server.Suggest(ctx, &pb.SuggestRequest{SessionId: "workflow-session", Cwd: "/tmp", MaxResults: 3})
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	if len(resp.Suggestions) == 0 {
+		t.Fatal("Suggest returned no suggestions")
+	}
+	top := resp.Suggestions[0]
+	if top.Kind != kindWorkflow || top.Source != sourceWorkflow {
+		t.Fatalf("top suggestion = %+v, want a workflow", top)
+	}
+	if top.Text != "git commit -m wip && git push" {
+		t.Errorf("Text = %q, want %q", top.Text, "git commit -m wip && git push")
+	}
+	if len(top.Steps) != 2 || top.Steps[0] != "git commit -m wip" || top.Steps[1] != "git push" {
+		t.Errorf("Steps = %q, want [git commit -m wip, git push]", top.Steps)
+	}
+	if !strings.HasPrefix(top.Description, "next: ") {
+		t.Errorf("Description = %q, want a next: summary", top.Description)
+	}
+
+	// The workflow is only offered while the buffer is a prefix of it.
+	resp, err = server.Suggest(ctx, &pb.SuggestRequest{SessionId: "workflow-session", Cwd: "/tmp", Buffer: "ls"})
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	for _, sug := range resp.Suggestions {
+		if sug.Kind == kindWorkflow {
+			t.Errorf("workflow suggested for buffer %q: %+v", "ls", sug)
+		}
+	}
+}
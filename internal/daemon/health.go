@@ -0,0 +1,179 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/storage"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+)
+
+// Subsystem health states, from best to worst. "disabled" subsystems are
+// not configured and do not affect the overall status.
+const (
+	healthOK       = "ok"
+	healthWarn     = "warn"
+	healthError    = "error"
+	healthDisabled = "disabled"
+)
+
+// healthCheckTimeout bounds each database probe so a locked database
+// cannot hang the Health RPC.
+const healthCheckTimeout = 2 * time.Second
+
+// maintenanceOverdueTicks is how many missed maintenance intervals make the
+// runner count as stalled.
+const maintenanceOverdueTicks = 3
+
+// Health handles the Health RPC.
+// Unlike Ping, it probes each subsystem and reports its state individually.
+func (s *Server) Health(ctx context.Context, req *pb.Ack) (*pb.HealthResponse, error) {
+	s.touchActivity()
+
+	subsystems := []*pb.SubsystemHealth{
+		s.checkStoreHealth(ctx),
+		s.checkSuggestionsDBHealth(ctx),
+		s.checkFTSHealth(ctx),
+		s.checkProvidersHealth(),
+		s.checkMaintenanceHealth(time.Now()),
+	}
+
+	resp := &pb.HealthResponse{Status: healthOK, Subsystems: subsystems}
+	for _, sub := range subsystems {
+		switch sub.Status {
+		case healthError:
+			resp.Status = healthError
+		case healthWarn:
+			if resp.Status == healthOK {
+				resp.Status = healthWarn
+			}
+		}
+	}
+	return resp, nil
+}
+
+// checkStoreHealth reads one command from the history store (state.db).
+func (s *Server) checkStoreHealth(ctx context.Context) *pb.SubsystemHealth {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	if _, err := s.store.QueryCommands(ctx, storage.CommandQuery{Limit: 1}); err != nil {
+		return &pb.SubsystemHealth{Name: "store", Status: healthError, Detail: err.Error()}
+	}
+	return &pb.SubsystemHealth{Name: "store", Status: healthOK, Detail: "state.db readable"}
+}
+
+// checkSuggestionsDBHealth reads the schema version of the V2 suggestions database.
+func (s *Server) checkSuggestionsDBHealth(ctx context.Context) *pb.SubsystemHealth {
+	if s.v2db == nil {
+		return &pb.SubsystemHealth{Name: "suggestions_db", Status: healthDisabled, Detail: "not open; using V1 suggestions"}
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	version, err := s.v2db.Version(ctx)
+	if err != nil {
+		return &pb.SubsystemHealth{Name: "suggestions_db", Status: healthError, Detail: err.Error()}
+	}
+	if version != suggestdb.SchemaVersion {
+		return &pb.SubsystemHealth{
+			Name:   "suggestions_db",
+			Status: healthWarn,
+			Detail: fmt.Sprintf("schema v%d, expected v%d", version, suggestdb.SchemaVersion),
+		}
+	}
+	return &pb.SubsystemHealth{Name: "suggestions_db", Status: healthOK, Detail: fmt.Sprintf("schema v%d", version)}
+}
+
+// checkFTSHealth queries the full-text index over command history.
+// Without it, history search falls back to substring matching.
+func (s *Server) checkFTSHealth(ctx context.Context) *pb.SubsystemHealth {
+	if s.v2db == nil {
+		return &pb.SubsystemHealth{Name: "fts", Status: healthDisabled, Detail: "requires the suggestions database"}
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	var n int
+	err := s.v2db.QueryRowContext(ctx, `SELECT COUNT(*) FROM (SELECT rowid FROM command_event_fts LIMIT 1)`).Scan(&n)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such") {
+			return &pb.SubsystemHealth{Name: "fts", Status: healthWarn, Detail: "unavailable; search uses substring matching"}
+		}
+		return &pb.SubsystemHealth{Name: "fts", Status: healthError, Detail: err.Error()}
+	}
+	return &pb.SubsystemHealth{Name: "fts", Status: healthOK, Detail: "index available"}
+}
+
+// checkProvidersHealth reports whether an AI provider can serve requests.
+func (s *Server) checkProvidersHealth() *pb.SubsystemHealth {
+	statuses := s.providerStatuses()
+	if len(statuses) == 0 {
+		return &pb.SubsystemHealth{Name: "providers", Status: healthWarn, Detail: "none registered"}
+	}
+
+	var available []string
+	var preferred *pb.ProviderStatus
+	for _, p := range statuses {
+		if p.Available {
+			available = append(available, p.Name)
+		}
+		if p.Preferred {
+			preferred = p
+		}
+	}
+
+	switch {
+	case len(available) == 0:
+		return &pb.SubsystemHealth{Name: "providers", Status: healthWarn, Detail: "no provider available"}
+	case preferred != nil && !preferred.Available:
+		return &pb.SubsystemHealth{
+			Name:   "providers",
+			Status: healthWarn,
+			Detail: fmt.Sprintf("preferred provider %s unavailable; available: %s", preferred.Name, strings.Join(available, ", ")),
+		}
+	default:
+		return &pb.SubsystemHealth{Name: "providers", Status: healthOK, Detail: "available: " + strings.Join(available, ", ")}
+	}
+}
+
+// checkMaintenanceHealth reports whether the maintenance runner is running,
+// when it last ran and whether it has fallen behind its interval. Without a
+// suggestions database there is nothing to maintain.
+func (s *Server) checkMaintenanceHealth(now time.Time) *pb.SubsystemHealth {
+	switch {
+	case s.maintenanceRunner == nil && s.v2db == nil:
+		return &pb.SubsystemHealth{Name: "maintenance", Status: healthDisabled, Detail: "no suggestions database"}
+	case s.maintenanceRunner == nil:
+		return &pb.SubsystemHealth{Name: "maintenance", Status: healthWarn, Detail: "runner not configured"}
+	case !s.maintenanceRunner.Running():
+		return &pb.SubsystemHealth{Name: "maintenance", Status: healthWarn, Detail: "runner not running"}
+	}
+
+	last := s.maintenanceRunner.GetStats().LastTickTime
+	interval := s.maintenanceRunner.Interval()
+	overdue := maintenanceOverdueTicks * interval
+	if last.IsZero() {
+		// The first tick runs one interval after startup.
+		if now.Sub(s.startTime) > overdue {
+			return &pb.SubsystemHealth{
+				Name:   "maintenance",
+				Status: healthWarn,
+				Detail: fmt.Sprintf("has not run since startup %s ago", now.Sub(s.startTime).Truncate(time.Second)),
+			}
+		}
+		return &pb.SubsystemHealth{Name: "maintenance", Status: healthOK, Detail: "not run yet"}
+	}
+
+	resp := &pb.SubsystemHealth{Name: "maintenance", Status: healthOK, LastRunUnixMs: last.UnixMilli()}
+	if now.Sub(last) > overdue {
+		resp.Status = healthWarn
+		resp.Detail = fmt.Sprintf("overdue; runs every %s", interval)
+	} else {
+		resp.Detail = fmt.Sprintf("runs every %s", interval)
+	}
+	return resp
+}
@@ -0,0 +1,182 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/provider"
+	"github.com/runger/clai/internal/storage"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/maintenance"
+)
+
+// failingQueryStore is a store whose reads fail.
+type failingQueryStore struct {
+	*mockStore
+}
+
+func (m *failingQueryStore) QueryCommands(ctx context.Context, q storage.CommandQuery) ([]storage.Command, error) {
+	return nil, errors.New("database is locked")
+}
+
+func healthByName(t *testing.T, resp *pb.HealthResponse) map[string]*pb.SubsystemHealth {
+	t.Helper()
+	byName := make(map[string]*pb.SubsystemHealth, len(resp.Subsystems))
+	for _, sub := range resp.Subsystems {
+		byName[sub.Name] = sub
+	}
+	return byName
+}
+
+func openHealthTestV2DB(t *testing.T) *suggestdb.DB {
+	t.Helper()
+	v2db, err := suggestdb.Open(context.Background(), suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "suggestions_v2.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	t.Cleanup(func() { v2db.Close() })
+	return v2db
+}
+
+func TestHandler_Health_V1Only(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	resp, err := server.Health(context.Background(), &pb.Ack{Ok: true})
+	if err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if resp.Status != healthOK {
+		t.Errorf("Status = %q, want ok", resp.Status)
+	}
+
+	want := map[string]string{
+		"store":          healthOK,
+		"suggestions_db": healthDisabled,
+		"fts":            healthDisabled,
+		"providers":      healthOK,
+		"maintenance":    healthDisabled,
+	}
+	got := healthByName(t, resp)
+	if len(got) != len(want) {
+		t.Errorf("got %d subsystems, want %d", len(got), len(want))
+	}
+	for name, status := range want {
+		if sub, ok := got[name]; !ok || sub.Status != status {
+			t.Errorf("%s = %v, want status %q", name, sub, status)
+		}
+	}
+}
+
+func TestHandler_Health_V2Subsystems(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	server.v2db = openHealthTestV2DB(t)
+
+	resp, err := server.Health(context.Background(), &pb.Ack{Ok: true})
+	if err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	got := healthByName(t, resp)
+	if got["suggestions_db"].Status != healthOK {
+		t.Errorf("suggestions_db = %v, want ok", got["suggestions_db"])
+	}
+	if got["fts"].Status != healthOK {
+		t.Errorf("fts = %v, want ok", got["fts"])
+	}
+}
+
+func TestHandler_Health_StoreErrorIsWorst(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	server.store = &failingQueryStore{mockStore: newMockStore()}
+
+	resp, err := server.Health(context.Background(), &pb.Ack{Ok: true})
+	if err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if resp.Status != healthError {
+		t.Errorf("Status = %q, want error", resp.Status)
+	}
+	if sub := healthByName(t, resp)["store"]; sub.Status != healthError || sub.Detail != "database is locked" {
+		t.Errorf("store = %v, want error with the query failure", sub)
+	}
+}
+
+func TestCheckProvidersHealth_PreferredUnavailable(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	registry := provider.NewRegistry()
+	registry.Register(&mockProvider{name: "claude", available: false})
+	registry.Register(&mockProvider{name: "gemini", available: true})
+	registry.SetPreferred("claude")
+	server.registry = registry
+
+	sub := server.checkProvidersHealth()
+	if sub.Status != healthWarn {
+		t.Errorf("Status = %q, want warn", sub.Status)
+	}
+	if !strings.HasPrefix(sub.Detail, "preferred provider claude unavailable") || !strings.Contains(sub.Detail, "gemini") {
+		t.Errorf("Detail = %q, want the unavailable preferred provider and gemini", sub.Detail)
+	}
+}
+
+func TestCheckMaintenanceHealth(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	server.v2db = openHealthTestV2DB(t)
+	now := time.Now()
+	if sub := server.checkMaintenanceHealth(now); sub.Status != healthWarn || sub.Detail != "runner not configured" {
+		t.Errorf("no runner = %v, want warn", sub)
+	}
+
+	server.maintenanceRunner = maintenance.NewRunner(server.v2db.DB(), maintenance.Config{Interval: time.Minute})
+	if sub := server.checkMaintenanceHealth(now); sub.Status != healthWarn || sub.Detail != "runner not running" {
+		t.Errorf("runner not started = %v, want warn", sub)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.maintenanceRunner.Run(ctx, nil)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	for !server.maintenanceRunner.Running() {
+		time.Sleep(time.Millisecond)
+	}
+
+	server.startTime = now.Add(-30 * time.Second)
+	if sub := server.checkMaintenanceHealth(now); sub.Status != healthOK || sub.LastRunUnixMs != 0 {
+		t.Errorf("fresh start = %v, want ok with no last run", sub)
+	}
+
+	server.startTime = now.Add(-time.Hour)
+	if sub := server.checkMaintenanceHealth(now); sub.Status != healthWarn {
+		t.Errorf("never run after an hour = %v, want warn", sub)
+	}
+
+	server.maintenanceRunner.RunOnce(context.Background())
+	sub := server.checkMaintenanceHealth(time.Now())
+	if sub.Status != healthOK || sub.LastRunUnixMs == 0 {
+		t.Errorf("after a run = %v, want ok with last run set", sub)
+	}
+	if sub := server.checkMaintenanceHealth(time.Now().Add(time.Hour)); sub.Status != healthWarn {
+		t.Errorf("an hour after the last run = %v, want warn", sub)
+	}
+}
@@ -0,0 +1,221 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+const (
+	// maxInlineDebounce caps the debounce a SuggestInline client may ask for.
+	maxInlineDebounce = 500 * time.Millisecond
+
+	// inlineRankResults is how many suggestions SuggestInline ranks to find
+	// one that completes the buffer.
+	inlineRankResults = 5
+)
+
+// inlineRequests tracks the latest SuggestInline revision of each session,
+// so a newer keystroke cancels the request for an older one, and the last
+// completion, which stays valid while the user types along it.
+type inlineRequests struct {
+	mu       sync.Mutex
+	sessions map[string]*inlineSession
+}
+
+type inlineSession struct {
+	revision   int64
+	cancel     context.CancelFunc // Cancels the in-flight request for revision
+	buffer     string             // Buffer the last completion answered
+	completion string
+	source     string
+}
+
+func newInlineRequests() *inlineRequests {
+	return &inlineRequests{sessions: make(map[string]*inlineSession)}
+}
+
+// begin registers revision as the session's latest and cancels the request
+// for the previous one. It returns the context the request runs in and a
+// func to call when it is done, or ok false if a newer revision has already
+// arrived. Revision 0 is not tracked.
+func (r *inlineRequests) begin(ctx context.Context, sessionID string, revision int64) (context.Context, func(), bool) {
+	if revision == 0 || sessionID == "" {
+		return ctx, func() {}, true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := r.sessions[sessionID]
+	if st == nil {
+		st = &inlineSession{}
+		r.sessions[sessionID] = st
+	}
+	if revision < st.revision {
+		return ctx, func() {}, false
+	}
+	if st.cancel != nil {
+		st.cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	st.revision = revision
+	st.cancel = cancel
+	return ctx, func() {
+		r.mu.Lock()
+		if st.revision == revision {
+			st.cancel = nil
+		}
+		r.mu.Unlock()
+		cancel()
+	}, true
+}
+
+// superseded reports whether a revision newer than revision has arrived.
+func (r *inlineRequests) superseded(sessionID string, revision int64) bool {
+	if revision == 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.sessions[sessionID]
+	return st != nil && st.revision > revision
+}
+
+// cached returns the session's last completion if buffer extends the buffer
+// it answered and is still a proper prefix of it.
+func (r *inlineRequests) cached(sessionID, buffer string) (completion, source string, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.sessions[sessionID]
+	if st == nil || st.completion == "" {
+		return "", "", false
+	}
+	if !strings.HasPrefix(buffer, st.buffer) || !completes(st.completion, buffer) {
+		return "", "", false
+	}
+	return st.completion, st.source, true
+}
+
+// remember stores the completion answering buffer for the session.
+func (r *inlineRequests) remember(sessionID, buffer, completion, source string) {
+	if sessionID == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.sessions[sessionID]
+	if st == nil {
+		st = &inlineSession{}
+		r.sessions[sessionID] = st
+	}
+	st.buffer, st.completion, st.source = buffer, completion, source
+}
+
+// forget drops the state of an ended session.
+func (r *inlineRequests) forget(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if st := r.sessions[sessionID]; st != nil && st.cancel != nil {
+		st.cancel()
+	}
+	delete(r.sessions, sessionID)
+}
+
+// completes reports whether completion extends buffer.
+func completes(completion, buffer string) bool {
+	return len(completion) > len(buffer) && strings.HasPrefix(completion, buffer)
+}
+
+// SuggestInline handles the SuggestInline RPC.
+// It returns the single best completion of the buffer for ghost text. While
+// the user types along the last completion it is returned without ranking
+// again. Otherwise the request waits out the debounce, giving up if a newer
+// revision arrives, and ranks within suggestions.inline_timeout_ms; when
+// the budget runs out it answers with no completion.
+func (s *Server) SuggestInline(ctx context.Context, req *pb.SuggestInlineRequest) (*pb.SuggestInlineResponse, error) {
+	s.touchActivity()
+	start := time.Now()
+
+	buffer := req.Buffer
+	if pos := int(req.CursorPos); pos > 0 && pos < len(buffer) {
+		buffer = buffer[:pos]
+	}
+	resp := &pb.SuggestInlineResponse{Revision: req.Revision}
+	if s.sessionManager.DegradedReason(req.SessionId, start) != "" {
+		return resp, nil
+	}
+	answer := func(completion, source string) (*pb.SuggestInlineResponse, error) {
+		if s.inline.superseded(req.SessionId, req.Revision) {
+			resp.Superseded = true
+		} else if completion != "" {
+			resp.Completion = completion
+			resp.Suffix = completion[len(buffer):]
+			resp.Source = source
+			resp.WordBoundaries = wordBoundaries(completion)
+		}
+		resp.LatencyMs = time.Since(start).Milliseconds()
+		return resp, nil
+	}
+	ctx, done, ok := s.inline.begin(ctx, req.SessionId, req.Revision)
+	defer done()
+	if !ok || strings.TrimSpace(buffer) == "" {
+		return answer("", "")
+	}
+	if completion, source, ok := s.inline.cached(req.SessionId, buffer); ok {
+		return answer(completion, source)
+	}
+	if debounce := min(time.Duration(req.DebounceMs)*time.Millisecond, maxInlineDebounce); debounce > 0 {
+		timer := time.NewTimer(debounce)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return answer("", "")
+		}
+	}
+
+	budget := time.Duration(s.runtimeConfig().Suggestions.InlineTimeoutMs) * time.Millisecond
+	rankCtx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+	completion, source := s.rankInline(rankCtx, req, buffer)
+	if errors.Is(rankCtx.Err(), context.DeadlineExceeded) {
+		s.logger.Debug("inline suggestion exceeded its budget",
+			"session_id", req.SessionId,
+			"budget", budget,
+		)
+	}
+	if completion != "" {
+		s.inline.remember(req.SessionId, buffer, completion, source)
+	}
+	return answer(completion, source)
+}
+
+// rankInline returns the best ranked suggestion that completes buffer.
+func (s *Server) rankInline(ctx context.Context, req *pb.SuggestInlineRequest, buffer string) (completion, source string) {
+	suggestReq := &pb.SuggestRequest{
+		SessionId:  req.SessionId,
+		Cwd:        req.Cwd,
+		Buffer:     buffer,
+		CursorPos:  int32(len(buffer)), //nolint:gosec // G115: buffer length fits in int32
+		MaxResults: inlineRankResults,
+	}
+	var resp *pb.SuggestResponse
+	if s.scorerVersion == "v2" {
+		resp = s.suggestV2Blend(ctx, suggestReq, inlineRankResults)
+	} else {
+		resp = s.suggestV1(ctx, suggestReq, inlineRankResults)
+	}
+	if resp == nil || ctx.Err() != nil {
+		return "", ""
+	}
+	for _, sug := range resp.Suggestions {
+		if sug.Kind == "" && completes(sug.Text, buffer) {
+			return sug.Text, sug.Source
+		}
+	}
+	return "", ""
+}
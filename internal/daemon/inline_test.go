@@ -0,0 +1,108 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/suggest"
+)
+
+// blockingRanker ranks nothing until its context is done.
+type blockingRanker struct{}
+
+func (blockingRanker) Rank(ctx context.Context, _ *suggest.RankRequest) ([]suggest.Suggestion, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestSuggestInline_CompletesBuffer(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	resp, err := server.SuggestInline(ctx, &pb.SuggestInlineRequest{SessionId: "s1", Buffer: "git s", Revision: 1})
+	if err != nil {
+		t.Fatalf("SuggestInline failed: %v", err)
+	}
+	if resp.Completion != "git status" || resp.Suffix != "tatus" || resp.Revision != 1 {
+		t.Errorf("got completion %q suffix %q revision %d", resp.Completion, resp.Suffix, resp.Revision)
+	}
+	if len(resp.WordBoundaries) != 2 || resp.WordBoundaries[1] != 10 {
+		t.Errorf("WordBoundaries = %v, want [4 10]", resp.WordBoundaries)
+	}
+
+	resp, _ = server.SuggestInline(ctx, &pb.SuggestInlineRequest{SessionId: "s1", Buffer: "ls -", Revision: 2})
+	if resp.Completion != "" || resp.Superseded {
+		t.Errorf("buffer not completed by any suggestion: got %+v", resp)
+	}
+}
+
+func TestSuggestInline_ReusesCompletionWhileTypingAlong(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	if resp, _ := server.SuggestInline(ctx, &pb.SuggestInlineRequest{SessionId: "s1", Buffer: "git", Revision: 1}); resp.Completion != "git status" {
+		t.Fatalf("completion = %q, want git status", resp.Completion)
+	}
+
+	// Typing along the completion does not rank again.
+	server.ranker = blockingRanker{}
+	resp, _ := server.SuggestInline(ctx, &pb.SuggestInlineRequest{SessionId: "s1", Buffer: "git sta", Revision: 2})
+	if resp.Completion != "git status" || resp.Suffix != "tus" {
+		t.Errorf("got completion %q suffix %q, want the previous completion", resp.Completion, resp.Suffix)
+	}
+
+	// Diverging from it ranks again, here running out of budget.
+	resp, _ = server.SuggestInline(ctx, &pb.SuggestInlineRequest{SessionId: "s1", Buffer: "git stash", Revision: 3})
+	if resp.Completion != "" {
+		t.Errorf("completion = %q, want none once the budget runs out", resp.Completion)
+	}
+}
+
+func TestSuggestInline_NewerRevisionSupersedes(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	ctx := context.Background()
+
+	first := make(chan *pb.SuggestInlineResponse, 1)
+	start := time.Now()
+	go func() {
+		resp, _ := server.SuggestInline(ctx, &pb.SuggestInlineRequest{
+			SessionId: "s1", Buffer: "git", Revision: 1, DebounceMs: 400,
+		})
+		first <- resp
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	resp, _ := server.SuggestInline(ctx, &pb.SuggestInlineRequest{SessionId: "s1", Buffer: "git s", Revision: 2})
+	if resp.Completion != "git status" || resp.Superseded {
+		t.Errorf("latest revision: got %+v", resp)
+	}
+
+	old := <-first
+	if !old.Superseded || old.Completion != "" {
+		t.Errorf("superseded revision: got %+v", old)
+	}
+	if elapsed := time.Since(start); elapsed >= 400*time.Millisecond {
+		t.Errorf("superseded request waited out its debounce (%v)", elapsed)
+	}
+
+	// A revision older than the latest is answered as superseded at once.
+	resp, _ = server.SuggestInline(ctx, &pb.SuggestInlineRequest{SessionId: "s1", Buffer: "gi", Revision: 1})
+	if !resp.Superseded {
+		t.Errorf("stale revision: got %+v", resp)
+	}
+
+	// Ending the session forgets its revisions.
+	server.inline.forget("s1")
+	resp, _ = server.SuggestInline(ctx, &pb.SuggestInlineRequest{SessionId: "s1", Buffer: "git", Revision: 1})
+	if resp.Superseded || resp.Completion != "git status" {
+		t.Errorf("after forget: got %+v", resp)
+	}
+}
@@ -0,0 +1,176 @@
+package daemon
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
+)
+
+// Fixed deadlines for interactive RPCs that are not configurable.
+const (
+	pingDeadline         = time.Second
+	fetchHistoryDeadline = 2 * time.Second
+)
+
+// aiMethods call an LLM and are expected to be slow; they are neither
+// deadline-limited nor reported as slow.
+var aiMethods = map[string]bool{
+	pb.ClaiService_TextToCommand_FullMethodName:     true,
+	pb.ClaiService_NextStep_FullMethodName:          true,
+	pb.ClaiService_Diagnose_FullMethodName:          true,
+	pb.ClaiService_AnalyzeStepOutput_FullMethodName: true,
+}
+
+// rpcDeadline returns the server-side deadline for method, or 0 if the
+// method runs unbounded. Suggest and SuggestSlots answer keystrokes and
+// must finish within suggestions.hard_timeout_ms; SuggestInline ranks
+// within suggestions.inline_timeout_ms after its debounce.
+func (s *Server) rpcDeadline(method string) time.Duration {
+	switch method {
+	case pb.ClaiService_Suggest_FullMethodName, pb.ClaiService_SuggestSlots_FullMethodName:
+		return time.Duration(s.runtimeConfig().Suggestions.HardTimeoutMs) * time.Millisecond
+	case pb.ClaiService_SuggestInline_FullMethodName:
+		return maxInlineDebounce + time.Duration(s.runtimeConfig().Suggestions.InlineTimeoutMs)*time.Millisecond
+	case pb.ClaiService_FetchHistory_FullMethodName:
+		return fetchHistoryDeadline
+	case pb.ClaiService_Ping_FullMethodName, pb.ClaiService_GetStatus_FullMethodName,
+		pb.ClaiService_ListSessions_FullMethodName:
+		return pingDeadline
+	default:
+		return 0
+	}
+}
+
+// slowRPCThreshold returns the duration above which a handler is logged as slow.
+func (s *Server) slowRPCThreshold() time.Duration {
+	return time.Duration(s.runtimeConfig().Daemon.SlowRPCMs) * time.Millisecond
+}
+
+// defaultRuntimeConfig backs servers started without a config.
+var defaultRuntimeConfig = config.DefaultConfig()
+
+// runtimeConfig returns the applied config, or the defaults when the server
+// was started without one.
+func (s *Server) runtimeConfig() *config.Config {
+	if cfg := s.getConfig(); cfg != nil {
+		return cfg
+	}
+	return defaultRuntimeConfig
+}
+
+// deadlineUnaryInterceptor bounds methods that have a deadline. The handler
+// runs with the bounded context, so its database queries stop at the
+// deadline too. When the deadline passes the caller gets DeadlineExceeded
+// right away; the handler finishes in the background.
+func (s *Server) deadlineUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		timeout := s.rpcDeadline(info.FullMethod)
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		type result struct {
+			resp any
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			resp, err := handler(ctx, req)
+			done <- result{resp, err}
+		}()
+
+		select {
+		case r := <-done:
+			return r.resp, r.err
+		case <-ctx.Done():
+			s.logger.Warn("rpc deadline exceeded",
+				"method", info.FullMethod,
+				"deadline_ms", timeout.Milliseconds(),
+			)
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// slowRPCUnaryInterceptor logs handlers that take longer than the slow RPC
+// threshold, together with their request parameters.
+func (s *Server) slowRPCUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		threshold := s.slowRPCThreshold()
+		elapsed := time.Since(start)
+		if threshold > 0 && elapsed > threshold && !aiMethods[info.FullMethod] {
+			attrs := []any{
+				"method", info.FullMethod,
+				"duration_ms", elapsed.Milliseconds(),
+				"threshold_ms", threshold.Milliseconds(),
+				"code", status.Code(err).String(),
+			}
+			s.logger.Warn("slow rpc", append(attrs, requestAttrs(req)...)...)
+		}
+		return resp, err
+	}
+}
+
+// requestAttrs returns log attributes describing req. Identifiers, the
+// working directory and non-string fields are logged as-is; other strings
+// (command lines, buffers, prompts) are logged by length only so command
+// text never reaches the log. Nested messages and lists are summarized.
+func requestAttrs(req any) []any {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	var attrs []any
+	msg.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := "req." + string(fd.Name())
+		switch {
+		case fd.IsList():
+			attrs = append(attrs, name+"_count", v.List().Len())
+		case fd.IsMap():
+			attrs = append(attrs, name+"_count", v.Map().Len())
+		case fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind:
+			attrs = append(attrs, name, "set")
+		case fd.Kind() == protoreflect.StringKind:
+			if isLoggableStringField(string(fd.Name())) {
+				attrs = append(attrs, name, v.String())
+			} else {
+				attrs = append(attrs, name+"_len", len(v.String()))
+			}
+		case fd.Kind() == protoreflect.BytesKind:
+			attrs = append(attrs, name+"_len", len(v.Bytes()))
+		default:
+			attrs = append(attrs, name, v.Interface())
+		}
+		return true
+	})
+	return attrs
+}
+
+func isLoggableStringField(name string) bool {
+	return strings.HasSuffix(name, "_id") || name == "cwd" || name == "shell"
+}
+
+// unaryInterceptors returns the server's interceptor chain, outermost first.
+func (s *Server) unaryInterceptors() []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		s.accessLogUnaryInterceptor(),
+		s.rateLimitUnaryInterceptor(),
+		s.deadlineUnaryInterceptor(),
+		s.slowRPCUnaryInterceptor(),
+	}
+}
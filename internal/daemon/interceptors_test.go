@@ -0,0 +1,174 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
+)
+
+func TestDeadlineInterceptor_SuggestUsesHardTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.HardTimeoutMs = 20
+	server.ApplyConfig(cfg)
+
+	handlerDone := make(chan struct{})
+	slow := func(ctx context.Context, _ any) (any, error) {
+		defer close(handlerDone)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	start := time.Now()
+	info := &grpc.UnaryServerInfo{FullMethod: pb.ClaiService_Suggest_FullMethodName}
+	_, err := server.deadlineUnaryInterceptor()(context.Background(), &pb.SuggestRequest{}, info, slow)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("interceptor returned after %v, want ~20ms", elapsed)
+	}
+	<-handlerDone
+}
+
+func TestRPCDeadline(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.HardTimeoutMs = 150
+	cfg.Suggestions.InlineTimeoutMs = 40
+	server.ApplyConfig(cfg)
+
+	for method, want := range map[string]time.Duration{
+		pb.ClaiService_Suggest_FullMethodName:       150 * time.Millisecond,
+		pb.ClaiService_SuggestSlots_FullMethodName:  150 * time.Millisecond,
+		pb.ClaiService_SuggestInline_FullMethodName: maxInlineDebounce + 40*time.Millisecond,
+		pb.ClaiService_FetchHistory_FullMethodName:  fetchHistoryDeadline,
+		pb.ClaiService_Ping_FullMethodName:          pingDeadline,
+		pb.ClaiService_Diagnose_FullMethodName:      0,
+	} {
+		if got := server.rpcDeadline(method); got != want {
+			t.Errorf("rpcDeadline(%s) = %v, want %v", method, got, want)
+		}
+	}
+}
+
+func TestDeadlineInterceptor_HandlerQueriesStopAtDeadline(t *testing.T) {
+	t.Parallel()
+
+	v2db := openHealthTestV2DB(t)
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), V2DB: v2db})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.HardTimeoutMs = 1
+	server.ApplyConfig(cfg)
+
+	// The handler only queries once the deadline has passed; the query
+	// must see the bounded context and fail instead of running.
+	var handlerErr error
+	handlerDone := make(chan struct{})
+	handler := func(ctx context.Context, req any) (any, error) {
+		defer close(handlerDone)
+		<-ctx.Done()
+		_, handlerErr = server.SuggestSlots(ctx, req.(*pb.SuggestSlotsRequest))
+		return nil, handlerErr
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: pb.ClaiService_SuggestSlots_FullMethodName}
+	req := &pb.SuggestSlotsRequest{Buffer: "git checkout ", CursorPos: 13}
+	if _, err := server.deadlineUnaryInterceptor()(context.Background(), req, info, handler); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	<-handlerDone
+	if handlerErr == nil {
+		t.Error("SuggestSlots after the deadline succeeded, want its query cancelled")
+	}
+}
+
+func TestDeadlineInterceptor_UnboundedMethodPassesThrough(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	handler := func(ctx context.Context, _ any) (any, error) {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("unbounded method should not get a deadline")
+		}
+		return &pb.Ack{Ok: true}, nil
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: pb.ClaiService_CommandEnded_FullMethodName}
+	resp, err := server.deadlineUnaryInterceptor()(context.Background(), &pb.CommandEndRequest{}, info, handler)
+	if err != nil || !resp.(*pb.Ack).Ok {
+		t.Errorf("unexpected result: %v, %v", resp, err)
+	}
+}
+
+func TestSlowRPCInterceptor_LogsParamsWithoutCommandText(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	var buf bytes.Buffer
+	server.logger = slog.New(slog.NewTextHandler(&buf, nil))
+	cfg := config.DefaultConfig()
+	cfg.Daemon.SlowRPCMs = 1
+	server.ApplyConfig(cfg)
+	buf.Reset()
+
+	handler := func(context.Context, any) (any, error) {
+		time.Sleep(5 * time.Millisecond)
+		return &pb.SuggestResponse{}, nil
+	}
+	req := &pb.SuggestRequest{SessionId: "sess-1", Cwd: "/repo", Buffer: "export TOKEN=secret", MaxResults: 3}
+	info := &grpc.UnaryServerInfo{FullMethod: pb.ClaiService_Suggest_FullMethodName}
+	if _, err := server.slowRPCUnaryInterceptor()(context.Background(), req, info, handler); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"slow rpc", "req.session_id=sess-1", "req.cwd=/repo", "req.buffer_len=19", "req.max_results=3"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "secret") {
+		t.Errorf("log leaked command text: %s", out)
+	}
+}
+
+func TestSlowRPCInterceptor_SkipsAIMethods(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	var buf bytes.Buffer
+	server.logger = slog.New(slog.NewTextHandler(&buf, nil))
+	cfg := config.DefaultConfig()
+	cfg.Daemon.SlowRPCMs = 1
+	server.ApplyConfig(cfg)
+	buf.Reset()
+
+	handler := func(context.Context, any) (any, error) {
+		time.Sleep(5 * time.Millisecond)
+		return &pb.TextToCommandResponse{}, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: pb.ClaiService_TextToCommand_FullMethodName}
+	if _, err := server.slowRPCUnaryInterceptor()(context.Background(), &pb.TextToCommandRequest{}, info, handler); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "slow rpc") {
+		t.Errorf("AI method should not be logged as slow: %s", buf.String())
+	}
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"slices"
+	"time"
+)
+
+// Operations whose client-measured latency is held against
+// suggestions.latency_budget.
+const (
+	latencyOpIngest  = "ingest"
+	latencyOpSuggest = "suggest"
+)
+
+const (
+	// latencyWindow is how many of a session's latest latencies per
+	// operation the p95 is taken over.
+	latencyWindow = 20
+
+	// latencyMinSamples is how many latencies are needed before a session
+	// can be degraded, so a slow first call after a cold start does not.
+	latencyMinSamples = 10
+)
+
+// latencySamples is a ring of a session's latest latencies of one
+// operation, in milliseconds.
+type latencySamples struct {
+	ms   [latencyWindow]int64
+	n    int // Samples held, up to latencyWindow
+	next int // Slot the next sample goes to
+}
+
+func (l *latencySamples) add(ms int64) {
+	l.ms[l.next] = ms
+	l.next = (l.next + 1) % latencyWindow
+	if l.n < latencyWindow {
+		l.n++
+	}
+}
+
+// p95 returns the 95th percentile of the samples held, or false while
+// there are fewer than latencyMinSamples.
+func (l *latencySamples) p95() (int64, bool) {
+	if l.n < latencyMinSamples {
+		return 0, false
+	}
+	sorted := slices.Clone(l.ms[:l.n])
+	slices.Sort(sorted)
+	return sorted[(l.n*95+99)/100-1], true
+}
+
+// recordLatency holds the latency of an op call that the daemon started
+// handling at started against the session's budget. clientMs is what the
+// client spent before sending; calls it did not measure are skipped.
+func (s *Server) recordLatency(sessionID, op string, clientMs int64, started time.Time) {
+	if clientMs <= 0 {
+		return
+	}
+	budget := s.runtimeConfig().Suggestions.LatencyBudget
+	limit := budget.IngestP95Ms
+	if op == latencyOpSuggest {
+		limit = budget.SuggestP95Ms
+	}
+	if limit <= 0 {
+		return
+	}
+	now := time.Now()
+	total := clientMs + now.Sub(started).Milliseconds()
+	cooldown := time.Duration(budget.CooldownMinutes) * time.Minute
+	if reason, degraded := s.sessionManager.RecordLatency(sessionID, op, total, limit, cooldown, now); degraded {
+		s.logger.Warn("session over its latency budget, serving it fire-and-forget only",
+			"session_id", sessionID,
+			"reason", reason,
+			"until", now.Add(cooldown).Format(time.RFC3339),
+		)
+	}
+}
@@ -14,13 +14,14 @@ import (
 )
 
 // ReloadFunc is a function called on SIGHUP to reload configuration.
+// When ServerConfig.ReloadFn is nil, the server re-reads its config file.
 type ReloadFunc func() error
 
 // Run starts the daemon and blocks until shutdown.
 // It handles signals for lifecycle management:
 //   - SIGTERM/SIGINT: graceful shutdown (drain queues, close DB, remove lock file)
 //   - SIGHUP: reload configuration from disk
-//   - SIGUSR1: graceful re-exec (exec self with same args after cleanup)
+//   - SIGUSR1: graceful re-exec (save session state, exec self with same args after cleanup)
 //   - SIGPIPE: ignore (prevent crashes on broken pipe)
 func Run(ctx context.Context, cfg *ServerConfig) error {
 	// Check privilege safety
@@ -45,6 +46,7 @@ func Run(ctx context.Context, cfg *ServerConfig) error {
 	if err != nil {
 		return fmt.Errorf("failed to create server: %w", err)
 	}
+	server.lockFile = lockFile
 
 	// Create context that cancels on signals
 	ctx, cancel := context.WithCancel(ctx)
@@ -69,7 +71,12 @@ func resolveRunPaths(cfg *ServerConfig) (*config.Paths, error) {
 	if paths == nil {
 		paths = config.DefaultPaths()
 	}
-	if err := EnsureSecureDirectory(paths.BaseDir); err != nil {
+	// The socket lives in the base directory.
+	var group string
+	if cfg.Config != nil {
+		group = cfg.Config.Daemon.SocketGroup
+	}
+	if err := EnsureSocketDirectory(paths.BaseDir, group); err != nil {
 		return nil, err
 	}
 	return paths, nil
@@ -114,6 +121,16 @@ func handleLifecycleSignal(
 	case syscall.SIGUSR1:
 		server.logger.Info("received SIGUSR1, initiating graceful re-exec")
 		server.Shutdown()
+		if err := server.saveRestartState(); err != nil {
+			server.logger.Error("failed to save restart state", "error", err)
+		}
+		if server.socketActivated {
+			// The activated socket does not survive exec; exit and let the
+			// service manager start the new binary on the next connection.
+			server.logger.Info("socket-activated, exiting for service manager restart")
+			cancel()
+			return true
+		}
 		lockFile.Release()
 		reExec()
 		// reExec should replace this process; reaching here means failure.
@@ -127,11 +144,12 @@ func handleLifecycleSignal(
 
 func reloadConfigOnSIGHUP(cfg *ServerConfig, server *Server) {
 	server.logger.Info("received SIGHUP, reloading configuration")
-	if cfg.ReloadFn == nil {
-		server.logger.Debug("no reload function configured, ignoring SIGHUP")
-		return
+	reload := cfg.ReloadFn
+	if reload == nil {
+		// Default: re-read the config file and apply it to the running server.
+		reload = server.ReloadConfig
 	}
-	if err := cfg.ReloadFn(); err != nil {
+	if err := reload(); err != nil {
 		server.logger.Error("failed to reload configuration", "error", err)
 		return
 	}
@@ -144,6 +162,9 @@ func reExec() {
 	if err != nil {
 		return
 	}
+	// On Linux, the path of a binary replaced by an upgrade reads as
+	// "<path> (deleted)"; exec the new binary at the original path.
+	exe = strings.TrimSuffix(exe, " (deleted)")
 	// syscall.Exec replaces the current process
 	_ = syscall.Exec(exe, os.Args, os.Environ()) //nolint:gosec // G204: exe is current binary path from os.Executable
 }
@@ -226,6 +247,84 @@ func StopWithPaths(paths *config.Paths) error {
 	return waitForProcessExit(process, 10*time.Second)
 }
 
+// Restart gracefully restarts the running daemon, preserving session state.
+func Restart() error {
+	return RestartWithPaths(config.DefaultPaths(), 10*time.Second)
+}
+
+// RestartWithPaths sends SIGUSR1 so the daemon saves its session state and
+// re-execs its binary, then waits until the new process has written its PID
+// file. A socket-activated daemon exits instead; the service manager starts
+// the new binary on the next connection.
+func RestartWithPaths(paths *config.Paths, timeout time.Duration) error {
+	pid, err := resolveStopPID(paths)
+	if err != nil {
+		return err
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find process: %w", err)
+	}
+
+	pidPath := paths.PIDFile()
+	var before time.Time
+	if info, statErr := os.Stat(pidPath); statErr == nil {
+		before = info.ModTime()
+	}
+
+	if err := process.Signal(syscall.SIGUSR1); err != nil {
+		return fmt.Errorf("failed to send SIGUSR1: %w", err)
+	}
+	return waitForReExec(process, pidPath, before, timeout)
+}
+
+// ReloadWithPaths sends SIGHUP so the running daemon re-reads its config
+// file. It returns without waiting; failures are logged by the daemon.
+func ReloadWithPaths(paths *config.Paths) error {
+	pid, err := resolveStopPID(paths)
+	if err != nil {
+		return err
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find process: %w", err)
+	}
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		return fmt.Errorf("failed to send SIGHUP: %w", err)
+	}
+	return nil
+}
+
+// waitForReExec waits until the PID file is rewritten by the re-exec'd
+// process (re-exec keeps the PID) or the process exits.
+func waitForReExec(process *os.Process, pidPath string, before time.Time, timeoutDuration time.Duration) error {
+	timeout := time.After(timeoutDuration)
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	// The old process removes the PID file during shutdown; once that has
+	// been observed, any PID file means the new process is up.
+	sawRemoved := false
+	for {
+		select {
+		case <-timeout:
+			return fmt.Errorf("daemon did not come back within %s", timeoutDuration)
+		case <-ticker.C:
+			if process.Signal(syscall.Signal(0)) != nil {
+				return nil
+			}
+			info, err := os.Stat(pidPath)
+			if err != nil {
+				sawRemoved = true
+				continue
+			}
+			if sawRemoved || !info.ModTime().Equal(before) {
+				return nil
+			}
+		}
+	}
+}
+
 func resolveStopPID(paths *config.Paths) (int, error) {
 	pid, err := ReadPID(paths.PIDFile())
 	if err == nil && pid > 0 && processExists(pid) {
@@ -435,3 +435,55 @@ func TestCleanupStale_DefaultWrapper(t *testing.T) {
 		t.Fatalf("CleanupStale() error = %v", err)
 	}
 }
+
+func TestWaitForReExec_PIDFileRewritten(t *testing.T) {
+	t.Parallel()
+
+	paths := &config.Paths{BaseDir: t.TempDir()}
+	pidFile := paths.PIDFile()
+	if err := os.WriteFile(pidFile, []byte("1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate the old process removing the PID file and the new one rewriting it.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = os.Remove(pidFile)
+		time.Sleep(100 * time.Millisecond)
+		_ = os.WriteFile(pidFile, []byte("1\n"), 0o600)
+	}()
+
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := waitForReExec(self, pidFile, info.ModTime(), 5*time.Second); err != nil {
+		t.Errorf("waitForReExec() error = %v", err)
+	}
+}
+
+func TestWaitForReExec_Timeout(t *testing.T) {
+	t.Parallel()
+
+	paths := &config.Paths{BaseDir: t.TempDir()}
+	pidFile := paths.PIDFile()
+	if err := os.WriteFile(pidFile, []byte("1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := waitForReExec(self, pidFile, info.ModTime(), 200*time.Millisecond); err == nil {
+		t.Error("waitForReExec() expected timeout error")
+	}
+}
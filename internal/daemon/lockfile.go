@@ -165,11 +165,13 @@ func (l *LockFile) retryAcquire() error {
 	return nil
 }
 
-// Release releases the lock and removes the lock file.
+// Release releases the lock and removes the lock file, unless the file at
+// the lock path has since been replaced by another daemon's.
 func (l *LockFile) Release() error {
 	if l.file == nil {
 		return nil
 	}
+	owned := l.Owned()
 
 	// Unlock
 	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN); err != nil { //nolint:gosec // G115: fd fits in int
@@ -184,6 +186,9 @@ func (l *LockFile) Release() error {
 	l.file = nil
 
 	// Remove the lock file
+	if !owned {
+		return nil
+	}
 	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove lock file: %w", err)
 	}
@@ -108,16 +108,21 @@ func (l *LockFile) retryAcquire() error {
 	return nil
 }
 
-// Release releases the lock and removes the lock file.
+// Release releases the lock and removes the lock file, unless the file at
+// the lock path has since been replaced by another daemon's.
 func (l *LockFile) Release() error {
 	if l.file == nil {
 		return nil
 	}
+	owned := l.Owned()
 	if err := l.file.Close(); err != nil {
 		return fmt.Errorf("failed to close lock file: %w", err)
 	}
 	l.file = nil
 
+	if !owned {
+		return nil
+	}
 	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove lock file: %w", err)
 	}
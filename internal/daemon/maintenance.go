@@ -0,0 +1,27 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/suggestions/maintenance"
+)
+
+// MaintenanceConfig maps the suggestions settings of cfg onto the
+// maintenance runner's config for the suggestions database at dbPath. The
+// caller sets the logger and clock.
+func MaintenanceConfig(cfg *config.Config, dbPath string) maintenance.Config {
+	s := &cfg.Suggestions
+	rules := make([]maintenance.RetentionRule, len(s.RetentionRules))
+	for i, r := range s.RetentionRules {
+		rules[i] = maintenance.RetentionRule{Repo: r.Repo, Ephemeral: r.Ephemeral, Failed: r.Failed, Days: r.Days}
+	}
+	return maintenance.Config{
+		DBPath:           dbPath,
+		Interval:         time.Duration(s.MaintenanceIntervalMs) * time.Millisecond,
+		RetentionDays:    s.RetentionDays,
+		RetentionRules:   rules,
+		ArchiveAfterDays: s.ArchiveAfterDays,
+		MaxDBSizeBytes:   int64(s.MaxDBSizeMB) << 20,
+	}
+}
@@ -0,0 +1,164 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/suggestions/maintenance"
+)
+
+func TestServer_Start_AppliesRetentionRules(t *testing.T) {
+	t.Parallel()
+
+	// Unix socket paths are length-limited; keep it short.
+	tmpDir, err := os.MkdirTemp("/tmp", "clai-test-")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	paths := &config.Paths{BaseDir: tmpDir}
+	if err := paths.EnsureDirectories(); err != nil {
+		t.Fatalf("failed to create directories: %v", err)
+	}
+
+	v2db := openHealthTestV2DB(t)
+	day := int64(24 * time.Hour / time.Millisecond)
+	now := time.Now().UnixMilli()
+	for _, e := range []struct {
+		cmd      string
+		ageDays  int64
+		exitCode int
+	}{
+		{"failed-old", 10, 1},
+		{"failed-new", 3, 1},
+		{"ok-old", 10, 0},
+	} {
+		if _, err := v2db.DB().Exec(`
+			INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, exit_code)
+			VALUES ('s', ?, '/tmp', ?, ?, ?)
+		`, now-e.ageDays*day, e.cmd, e.cmd, e.exitCode); err != nil {
+			t.Fatalf("failed to insert event: %v", err)
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.MaintenanceIntervalMs = 10
+	cfg.Suggestions.RetentionRules = []config.RetentionRule{{Failed: true, Days: 7}}
+	runner := maintenance.NewRunner(v2db.DB(), MaintenanceConfig(cfg, v2db.Path()))
+
+	server, err := NewServer(&ServerConfig{
+		Store:             newMockStore(),
+		V2DB:              v2db,
+		Paths:             paths,
+		Logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config:            cfg,
+		MaintenanceRunner: runner,
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.Start(ctx) }()
+
+	remaining := func() map[string]bool {
+		rows, err := v2db.DB().Query(`SELECT cmd_raw FROM command_event`)
+		if err != nil {
+			t.Fatalf("failed to read events: %v", err)
+		}
+		defer rows.Close()
+		got := map[string]bool{}
+		for rows.Next() {
+			var cmd string
+			if err := rows.Scan(&cmd); err != nil {
+				t.Fatal(err)
+			}
+			got[cmd] = true
+		}
+		return got
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for remaining()["failed-old"] && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	got := remaining()
+	if got["failed-old"] {
+		t.Error("failed-old should have been pruned by the failed-command rule")
+	}
+	if !got["failed-new"] || !got["ok-old"] {
+		t.Errorf("remaining events = %v, want failed-new and ok-old kept", got)
+	}
+
+	server.Shutdown()
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			t.Errorf("unexpected server error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("server did not stop in time")
+	}
+	if runner.Running() {
+		t.Error("maintenance runner still running after shutdown")
+	}
+}
+
+func TestMaintenanceConfig_RepairsFTSDrift(t *testing.T) {
+	t.Parallel()
+
+	v2db := openHealthTestV2DB(t)
+	ctx := context.Background()
+	// Events written while the index trigger did not run.
+	if _, err := v2db.DB().Exec(`DROP TRIGGER command_event_ai`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v2db.DB().Exec(`
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm)
+		VALUES ('s', 1000, '/tmp', 'docker compose up', 'docker compose up')
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := maintenance.NewRunner(v2db.DB(), MaintenanceConfig(config.DefaultConfig(), v2db.Path()))
+	runner.RunOnce(ctx)
+
+	status, err := maintenance.CheckFTS(ctx, v2db.DB())
+	if err != nil {
+		t.Fatalf("CheckFTS() error = %v", err)
+	}
+	if status.Drifted() || status.Indexed != 1 {
+		t.Errorf("CheckFTS() after a maintenance pass = %+v, want the event indexed", status)
+	}
+}
+
+func TestMaintenanceConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.MaintenanceIntervalMs = 60000
+	cfg.Suggestions.MaxDBSizeMB = 5
+	cfg.Suggestions.ArchiveAfterDays = 30
+	cfg.Suggestions.RetentionRules = []config.RetentionRule{{Repo: "/src/infra", Days: 0}}
+
+	got := MaintenanceConfig(cfg, "/data/suggestions_v2.db")
+	if got.DBPath != "/data/suggestions_v2.db" || got.Interval != time.Minute {
+		t.Errorf("DBPath, Interval = %q, %v", got.DBPath, got.Interval)
+	}
+	if got.MaxDBSizeBytes != 5<<20 {
+		t.Errorf("MaxDBSizeBytes = %d, want %d", got.MaxDBSizeBytes, 5<<20)
+	}
+	if got.RetentionDays != 90 || got.ArchiveAfterDays != 30 {
+		t.Errorf("RetentionDays, ArchiveAfterDays = %d, %d; want 90, 30", got.RetentionDays, got.ArchiveAfterDays)
+	}
+	if len(got.RetentionRules) != 1 || got.RetentionRules[0] != (maintenance.RetentionRule{Repo: "/src/infra"}) {
+		t.Errorf("RetentionRules = %+v", got.RetentionRules)
+	}
+}
@@ -0,0 +1,187 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+// rateLimitSweepInterval is how often idle, refilled buckets are dropped.
+const rateLimitSweepInterval = time.Minute
+
+// tokenBucket holds up to capacity tokens and refills rate tokens per nanosecond.
+type tokenBucket struct {
+	updated  time.Time
+	tokens   float64
+	capacity float64
+	rate     float64
+	// warned is set once a rejection has been logged, so a script stuck in
+	// a loop produces one warning per burst instead of one per call.
+	warned bool
+}
+
+// RateLimiter keeps a token bucket per key. Keys combine an RPC method and
+// a session ID, so each session gets its own budget for each method.
+type RateLimiter struct {
+	lastSweep time.Time
+	now       func() time.Time
+	buckets   map[string]*tokenBucket
+	mu        sync.Mutex
+}
+
+// NewRateLimiter creates an empty RateLimiter using wall-clock time.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow takes a token from key's bucket, which holds up to limit tokens
+// and refills at limit per period. When the bucket is empty it returns
+// false and how long until the next token. firstReject reports whether
+// this is the first rejection since the bucket last allowed a call.
+func (r *RateLimiter) Allow(key string, limit int, period time.Duration) (ok bool, retryAfter time.Duration, firstReject bool) {
+	if limit <= 0 || period <= 0 {
+		return true, 0, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.sweep(now)
+
+	// Limits can change on config reload; the bucket follows them.
+	b, exists := r.buckets[key]
+	if !exists {
+		b = &tokenBucket{tokens: float64(limit), updated: now}
+		r.buckets[key] = b
+	}
+	b.capacity = float64(limit)
+	b.rate = float64(limit) / float64(period)
+	b.tokens = b.level(now)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.warned = false
+		return true, 0, false
+	}
+
+	retryAfter = time.Duration((1 - b.tokens) / b.rate)
+	firstReject = !b.warned
+	b.warned = true
+	return false, retryAfter, firstReject
+}
+
+// level returns the tokens in b at now, after refilling.
+func (b *tokenBucket) level(now time.Time) float64 {
+	return math.Min(b.capacity, b.tokens+float64(now.Sub(b.updated))*b.rate)
+}
+
+// sweep drops buckets that have refilled completely, since a fresh bucket
+// behaves the same. Callers hold r.mu.
+func (r *RateLimiter) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	r.lastSweep = now
+	for key, b := range r.buckets {
+		if b.level(now) >= b.capacity {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+// Len returns the number of tracked buckets.
+func (r *RateLimiter) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.buckets)
+}
+
+// rateLimit returns the per-session limit and refill period for method,
+// or 0 if method is not rate limited. AI methods spend provider quota;
+// ImportHistory rewrites large parts of the history database.
+func (s *Server) rateLimit(method string) (int, time.Duration) {
+	daemonCfg := s.runtimeConfig().Daemon
+	switch method {
+	case pb.ClaiService_TextToCommand_FullMethodName, pb.ClaiService_Diagnose_FullMethodName:
+		return daemonCfg.RateLimitAIPerMin, time.Minute
+	case pb.ClaiService_ImportHistory_FullMethodName:
+		return daemonCfg.RateLimitImportPerHour, time.Hour
+	default:
+		return 0, 0
+	}
+}
+
+// rateLimitUnaryInterceptor rejects calls to expensive methods with
+// ResourceExhausted once the calling session has used up its budget.
+// Calls without a session ID share one budget.
+func (s *Server) rateLimitUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		limit, period := s.rateLimit(info.FullMethod)
+		if limit <= 0 {
+			return handler(ctx, req)
+		}
+
+		sessionID := requestSessionID(req)
+		ok, retryAfter, firstReject := s.rateLimiter.Allow(rateLimitKey(info.FullMethod, sessionID), limit, period)
+		if ok {
+			return handler(ctx, req)
+		}
+
+		retryAfter = retryAfter.Truncate(time.Second) + time.Second
+		if firstReject {
+			s.logger.Warn("rate limit exceeded",
+				"method", info.FullMethod,
+				"session_id", sessionID,
+				"limit", limit,
+				"period", formatPeriod(period),
+				"retry_after", retryAfter.String(),
+			)
+		}
+		return nil, status.Error(codes.ResourceExhausted,
+			fmt.Sprintf("rate limit of %d per %s exceeded; retry in %s", limit, formatPeriod(period), retryAfter))
+	}
+}
+
+// rateLimitKey returns the bucket key of method for the session.
+func rateLimitKey(method, sessionID string) string {
+	return method + "\x00" + sessionID
+}
+
+func formatPeriod(period time.Duration) string {
+	switch period {
+	case time.Minute:
+		return "minute"
+	case time.Hour:
+		return "hour"
+	default:
+		return period.String()
+	}
+}
+
+// requestSessionID returns the session_id field of req, or "" if it has none.
+func requestSessionID(req any) string {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return ""
+	}
+	m := msg.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName("session_id")
+	if fd == nil {
+		return ""
+	}
+	return m.Get(fd).String()
+}
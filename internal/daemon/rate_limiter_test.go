@@ -0,0 +1,157 @@
+package daemon
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
+)
+
+// fakeNow returns a time source and a function that advances it.
+func fakeNow() (now func() time.Time, advance func(time.Duration)) {
+	t := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	return func() time.Time { return t }, func(d time.Duration) { t = t.Add(d) }
+}
+
+func TestRateLimiter_BurstThenRefill(t *testing.T) {
+	t.Parallel()
+
+	r := NewRateLimiter()
+	now, advance := fakeNow()
+	r.now = now
+
+	for i := 0; i < 3; i++ {
+		if ok, _, _ := r.Allow("k", 3, time.Minute); !ok {
+			t.Fatalf("call %d rejected within the burst", i+1)
+		}
+	}
+	ok, retry, first := r.Allow("k", 3, time.Minute)
+	if ok || !first {
+		t.Fatalf("4th call = %v (first reject %v), want rejected for the first time", ok, first)
+	}
+	if retry != 20*time.Second {
+		t.Errorf("retryAfter = %v, want 20s", retry)
+	}
+	if _, _, first := r.Allow("k", 3, time.Minute); first {
+		t.Error("second rejection reported as first")
+	}
+
+	advance(20 * time.Second)
+	if ok, _, _ := r.Allow("k", 3, time.Minute); !ok {
+		t.Error("call rejected after one token refilled")
+	}
+	if ok, _, first := r.Allow("k", 3, time.Minute); ok || !first {
+		t.Error("rejection after an allowed call should be reported as first again")
+	}
+}
+
+func TestRateLimiter_KeysAreIndependent(t *testing.T) {
+	t.Parallel()
+
+	r := NewRateLimiter()
+	r.now, _ = fakeNow()
+
+	if ok, _, _ := r.Allow("a", 1, time.Minute); !ok {
+		t.Fatal("first call for a rejected")
+	}
+	if ok, _, _ := r.Allow("a", 1, time.Minute); ok {
+		t.Fatal("second call for a allowed")
+	}
+	if ok, _, _ := r.Allow("b", 1, time.Minute); !ok {
+		t.Error("b rejected because a is exhausted")
+	}
+	if ok, _, _ := r.Allow("c", 0, time.Minute); !ok {
+		t.Error("limit 0 should be unlimited")
+	}
+}
+
+func TestRateLimiter_SweepsRefilledBuckets(t *testing.T) {
+	t.Parallel()
+
+	r := NewRateLimiter()
+	now, advance := fakeNow()
+	r.now = now
+
+	r.Allow("short", 10, time.Minute)
+	r.Allow("long", 10, time.Hour)
+	advance(2 * time.Minute)
+	r.Allow("new", 10, time.Minute)
+
+	// "short" refilled and was dropped; "long" still refills.
+	if got := r.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	cfg := config.DefaultConfig()
+	cfg.Daemon.RateLimitAIPerMin = 2
+	server.ApplyConfig(cfg)
+
+	calls := 0
+	handler := func(context.Context, any) (any, error) {
+		calls++
+		return &pb.TextToCommandResponse{}, nil
+	}
+	interceptor := server.rateLimitUnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: pb.ClaiService_TextToCommand_FullMethodName}
+	call := func(sessionID string) error {
+		_, err := interceptor(context.Background(), &pb.TextToCommandRequest{SessionId: sessionID}, info, handler)
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := call("s1"); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+	err := call("s1")
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("3rd call error = %v, want ResourceExhausted", err)
+	}
+	if !strings.Contains(err.Error(), "2 per minute") {
+		t.Errorf("error = %q, want the limit in the message", err)
+	}
+	if err := call("s2"); err != nil {
+		t.Errorf("other session rejected: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want 3", calls)
+	}
+
+	// Diagnose has its own budget, and unlimited methods pass through.
+	diagInfo := &grpc.UnaryServerInfo{FullMethod: pb.ClaiService_Diagnose_FullMethodName}
+	if _, err := interceptor(context.Background(), &pb.DiagnoseRequest{SessionId: "s1"}, diagInfo, handler); err != nil {
+		t.Errorf("Diagnose rejected: %v", err)
+	}
+	suggestInfo := &grpc.UnaryServerInfo{FullMethod: pb.ClaiService_Suggest_FullMethodName}
+	for i := 0; i < 5; i++ {
+		if _, err := interceptor(context.Background(), &pb.SuggestRequest{SessionId: "s1"}, suggestInfo, handler); err != nil {
+			t.Fatalf("Suggest rejected: %v", err)
+		}
+	}
+}
+
+func TestRequestSessionID(t *testing.T) {
+	t.Parallel()
+
+	if got := requestSessionID(&pb.HistoryImportRequest{SessionId: "abc"}); got != "abc" {
+		t.Errorf("requestSessionID(import) = %q, want abc", got)
+	}
+	if got := requestSessionID(&pb.Ack{}); got != "" {
+		t.Errorf("requestSessionID(Ack) = %q, want empty", got)
+	}
+	if got := requestSessionID("not a message"); got != "" {
+		t.Errorf("requestSessionID(string) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,267 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/sanitize"
+	"github.com/runger/clai/internal/suggestions/learning"
+	suggest2 "github.com/runger/clai/internal/suggestions/suggest"
+)
+
+// configWatchInterval is how often the daemon checks the config file for changes.
+const configWatchInterval = 5 * time.Second
+
+// defaultSuggestMaxResults is the number of suggestions returned when neither
+// the request nor the config specify a limit.
+const defaultSuggestMaxResults = 5
+
+// ReloadConfig re-reads the config file from disk and applies it.
+// On error the running configuration is left untouched.
+func (s *Server) ReloadConfig() error {
+	cfg, err := config.LoadFromFile(s.paths.ConfigFile())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	s.ApplyConfig(cfg)
+	return nil
+}
+
+// ApplyConfig atomically applies cfg to the running server. The log level,
+// idle timeout (0 disables it), default suggestion count, V2 ranking
+// weights and exploration, command redaction and risk patterns are set
+// here; handlers read the rest of cfg, such as the slow RPC threshold, rate
+// limits and the history page size, when they run. The settings RequiresRestart reports
+// keep the value the daemon started with.
+func (s *Server) ApplyConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+
+	if s.logLevel != nil {
+		s.logLevel.Set(parseLogLevel(cfg.Daemon.LogLevel))
+	}
+	redactor := resolveRedactor(&cfg.Suggestions, s.logger)
+
+	s.mu.Lock()
+	s.config = cfg
+	s.redactor = redactor
+	s.idleTimeout = time.Duration(cfg.Daemon.IdleTimeoutMins) * time.Minute
+	s.defaultMaxResults = cfg.Suggestions.MaxResults
+	if s.v2Scorer != nil {
+		s.v2Scorer = s.v2Scorer.
+			WithWeights(ScorerWeightsFromConfig(&cfg.Suggestions.Weights)).
+			WithExploration(suggest2.ExplorationConfig{
+				Mode: cfg.Suggestions.Exploration,
+				Rate: cfg.Suggestions.ExplorationRate,
+			})
+	}
+	s.mu.Unlock()
+
+	if err := sanitize.SetRiskRules(sanitize.RulesFromConfig(cfg.Suggestions.RiskPatterns)); err != nil {
+		s.logger.Warn("ignoring invalid suggestions.risk_patterns", "error", err)
+	}
+
+	s.logger.Info("configuration applied",
+		"log_level", cfg.Daemon.LogLevel,
+		"idle_timeout", s.getIdleTimeout(),
+		"max_results", cfg.Suggestions.MaxResults,
+	)
+}
+
+// RequiresRestart reports whether a change to the config key (as in
+// "daemon.socket_path") only takes effect after a daemon restart. These are
+// the daemon settings ApplyConfig does not apply, the paths the databases
+// were opened from, and the maintenance runner's settings.
+func RequiresRestart(key string) bool {
+	switch key {
+	case "daemon.log_level", "daemon.idle_timeout_mins", "daemon.slow_rpc_ms",
+		"daemon.rate_limit_ai_per_min", "daemon.rate_limit_import_per_hour":
+		return false
+	case "suggestions.maintenance_interval_ms", "suggestions.retention_days",
+		"suggestions.archive_after_days", "suggestions.max_db_size_mb":
+		return true
+	}
+	if strings.HasPrefix(key, "suggestions.retention_rules") {
+		return true
+	}
+	return strings.HasPrefix(key, "daemon.") || strings.HasPrefix(key, "paths.")
+}
+
+// getConfig returns the currently applied configuration, or nil if the
+// server was started without one.
+func (s *Server) getConfig() *config.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// getIdleTimeout returns the current idle timeout.
+func (s *Server) getIdleTimeout() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.idleTimeout
+}
+
+// redact replaces the secrets in command as the applied config asks, so
+// they never reach history, event subscribers or suggestions.
+func (s *Server) redact(command string) string {
+	s.mu.RLock()
+	redactor := s.redactor
+	s.mu.RUnlock()
+	if redactor == nil {
+		return command
+	}
+	return redactor.Sanitize(command)
+}
+
+// getV2Scorer returns the current V2 scorer. The scorer may be swapped by a
+// config reload, so handlers must fetch it once per request.
+func (s *Server) getV2Scorer() *suggest2.Scorer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.v2Scorer
+}
+
+// getDefaultMaxResults returns the suggestion count used when a request
+// does not specify one.
+func (s *Server) getDefaultMaxResults() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.defaultMaxResults <= 0 {
+		return defaultSuggestMaxResults
+	}
+	return s.defaultMaxResults
+}
+
+// watchConfig polls the config file and reloads it when its modification
+// time changes. Polling keeps the daemon free of platform-specific file
+// notification dependencies; the interval is short enough for interactive use.
+func (s *Server) watchConfig(ctx context.Context) {
+	defer s.wg.Done()
+
+	path := s.paths.ConfigFile()
+	lastMod := configModTime(path)
+
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.shutdownChan:
+			return
+		case <-ticker.C:
+			mod := configModTime(path)
+			if mod.Equal(lastMod) {
+				continue
+			}
+			lastMod = mod
+			s.logger.Info("config file changed, reloading", "path", path)
+			if err := s.ReloadConfig(); err != nil {
+				s.logger.Error("failed to reload configuration", "error", err)
+			}
+		}
+	}
+}
+
+// configModTime returns the modification time of the config file, or the
+// zero time if it does not exist.
+func configModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// parseLogLevel converts a config log level string to a slog.Level.
+// Unknown values map to info.
+func parseLogLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// ScorerWeightsFromConfig maps the user-facing ranking weights onto the V2
+// scorer's point-based weights. Each config weight scales its scorer weights
+// relative to the config default, so the default config yields exactly the
+// scorer defaults.
+func ScorerWeightsFromConfig(w *config.SuggestionsWeights) suggest2.Weights {
+	def := config.DefaultSuggestionsConfig().Weights
+	base := suggest2.DefaultWeights()
+
+	transition := weightRatio(w.Transition, def.Transition)
+	frequency := weightRatio(w.Frequency, def.Frequency)
+	task := weightRatio(w.Task, def.Task)
+	risk := weightRatio(w.RiskPenalty, def.RiskPenalty)
+	timeOfDay := weightRatio(w.TimeOfDay, def.TimeOfDay)
+	dayOfWeek := weightRatio(w.DayOfWeek, def.DayOfWeek)
+	success := weightRatio(w.Success, def.Success)
+
+	return suggest2.Weights{
+		RepoTransition:   base.RepoTransition * transition,
+		GlobalTransition: base.GlobalTransition * transition,
+		DirTransition:    base.DirTransition * transition,
+		RepoFrequency:    base.RepoFrequency * frequency,
+		GlobalFrequency:  base.GlobalFrequency * frequency,
+		DirFrequency:     base.DirFrequency * frequency,
+		ProjectTask:      base.ProjectTask * task,
+		DangerousPenalty: base.DangerousPenalty * risk,
+		TimeOfDay:        base.TimeOfDay * timeOfDay,
+		DayOfWeek:        base.DayOfWeek * dayOfWeek,
+		Success:          base.Success * success,
+	}
+}
+
+// learningWeightsFromConfig returns the config weights the learner tunes.
+func learningWeightsFromConfig(w *config.SuggestionsWeights) learning.Weights {
+	return learning.Weights{
+		Transition:          w.Transition,
+		Frequency:           w.Frequency,
+		Success:             w.Success,
+		Prefix:              w.Prefix,
+		Affinity:            w.Affinity,
+		Task:                w.Task,
+		Feedback:            w.Feedback,
+		ProjectTypeAffinity: w.ProjectTypeAffinity,
+		FailureRecovery:     w.FailureRecovery,
+		RiskPenalty:         w.RiskPenalty,
+	}
+}
+
+// configWeightsWithLearned returns w with the weights the learner tunes
+// replaced by learned ones.
+func configWeightsWithLearned(w config.SuggestionsWeights, learned *learning.Weights) config.SuggestionsWeights {
+	w.Transition = learned.Transition
+	w.Frequency = learned.Frequency
+	w.Success = learned.Success
+	w.Prefix = learned.Prefix
+	w.Affinity = learned.Affinity
+	w.Task = learned.Task
+	w.Feedback = learned.Feedback
+	w.ProjectTypeAffinity = learned.ProjectTypeAffinity
+	w.FailureRecovery = learned.FailureRecovery
+	w.RiskPenalty = learned.RiskPenalty
+	return w
+}
+
+func weightRatio(value, def float64) float64 {
+	if def == 0 {
+		return 1
+	}
+	return value / def
+}
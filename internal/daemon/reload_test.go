@@ -0,0 +1,262 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/sanitize"
+	"github.com/runger/clai/internal/suggest"
+	suggest2 "github.com/runger/clai/internal/suggestions/suggest"
+)
+
+func TestServer_ApplyConfig(t *testing.T) {
+	t.Parallel()
+
+	level := new(slog.LevelVar)
+	server, err := NewServer(&ServerConfig{
+		Store:    newMockStore(),
+		LogLevel: level,
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Daemon.LogLevel = "debug"
+	cfg.Daemon.IdleTimeoutMins = 7
+	cfg.Suggestions.MaxResults = 9
+
+	server.ApplyConfig(cfg)
+
+	if got := level.Level(); got != slog.LevelDebug {
+		t.Errorf("log level = %v, want %v", got, slog.LevelDebug)
+	}
+	if got := server.getIdleTimeout(); got != 7*time.Minute {
+		t.Errorf("idle timeout = %v, want 7m", got)
+	}
+	if got := server.getDefaultMaxResults(); got != 9 {
+		t.Errorf("default max results = %d, want 9", got)
+	}
+	if server.getConfig() != cfg {
+		t.Error("applied config should be retained")
+	}
+}
+
+func TestServer_ApplyConfig_ZeroIdleTimeoutDisables(t *testing.T) {
+	t.Parallel()
+
+	server, err := NewServer(&ServerConfig{
+		Store:       newMockStore(),
+		IdleTimeout: 3 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Daemon.IdleTimeoutMins = 7
+	server.ApplyConfig(cfg)
+	server.ApplyConfig(config.DefaultConfig())
+
+	if got := server.getIdleTimeout(); got != 0 {
+		t.Errorf("idle timeout = %v, want 0 (disabled)", got)
+	}
+}
+
+func TestServer_ApplyConfig_PickerPageSize(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServerWithCommands(t)
+	fetch := func() *pb.HistoryFetchResponse {
+		t.Helper()
+		resp, err := server.FetchHistory(context.Background(), &pb.HistoryFetchRequest{Global: true})
+		if err != nil {
+			t.Fatalf("FetchHistory failed: %v", err)
+		}
+		return resp
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.History.PickerPageSize = 2
+	server.ApplyConfig(cfg)
+	if resp := fetch(); len(resp.Items) != 2 || resp.AtEnd {
+		t.Errorf("page size 2: got %d items, at_end %v; want 2 items, not at end", len(resp.Items), resp.AtEnd)
+	}
+
+	server.ApplyConfig(config.DefaultConfig())
+	if resp := fetch(); len(resp.Items) != 4 || !resp.AtEnd {
+		t.Errorf("default page size: got %d items, at_end %v; want 4 items, at end", len(resp.Items), resp.AtEnd)
+	}
+}
+
+// Not parallel: risk patterns are process-wide.
+func TestServer_ApplyConfig_RiskPatterns(t *testing.T) {
+	t.Cleanup(func() { _ = sanitize.SetRiskRules(nil) })
+
+	server, err := NewServer(&ServerConfig{Store: newMockStore()})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.RiskPatterns = []config.RiskPattern{
+		{Name: "terraform destroy prod", Pattern: `terraform\s+destroy.*prod`, Message: "destroys production"},
+		{Name: "kubectl delete", Allow: []string{`-n\s+dev\b`}},
+	}
+	server.ApplyConfig(cfg)
+
+	sug := v1SuggestionToProto(&suggest.Suggestion{Text: "terraform destroy -var env=prod"}, "", 0)
+	if sug.Risk != riskDestructive || sug.RiskMessage != "destroys production" {
+		t.Errorf("risk = %q (%q), want destructive with the configured message", sug.Risk, sug.RiskMessage)
+	}
+	if got := v2SuggestionRisk("kubectl delete pod web -n dev"); got != "" {
+		t.Errorf("allowlisted command risk = %q, want none", got)
+	}
+
+	server.ApplyConfig(config.DefaultConfig())
+	if got := v2SuggestionRisk("terraform destroy -var env=prod"); got != "" {
+		t.Errorf("risk after removing the pattern = %q, want none", got)
+	}
+}
+
+func TestServer_ApplyConfig_Redaction(t *testing.T) {
+	t.Parallel()
+
+	server, err := NewServer(&ServerConfig{Store: newMockStore()})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	if got := server.redact("tool --api-key abc123"); got != "tool --api-key [REDACTED]" {
+		t.Errorf("redact() before any config = %q, want the default redaction", got)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.RedactPatterns = []string{`corp-[0-9]{6}`}
+	server.ApplyConfig(cfg)
+	if got := server.redact("login corp-123456"); got != "login [REDACTED]" {
+		t.Errorf("redact() = %q, want the added pattern redacted", got)
+	}
+
+	cfg = config.DefaultConfig()
+	cfg.Suggestions.RedactSensitiveTokens = false
+	server.ApplyConfig(cfg)
+	if got := server.redact("tool --api-key abc123"); got != "tool --api-key abc123" {
+		t.Errorf("redact() with redaction off = %q, want the command unchanged", got)
+	}
+}
+
+func TestServer_ReloadConfig(t *testing.T) {
+	t.Parallel()
+
+	paths := &config.Paths{BaseDir: t.TempDir()}
+	server, err := NewServer(&ServerConfig{
+		Store: newMockStore(),
+		Paths: paths,
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	data := []byte("suggestions:\n  max_results: 4\n")
+	if err := os.WriteFile(paths.ConfigFile(), data, 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if err := server.ReloadConfig(); err != nil {
+		t.Fatalf("ReloadConfig failed: %v", err)
+	}
+	if got := server.getDefaultMaxResults(); got != 4 {
+		t.Errorf("default max results = %d, want 4", got)
+	}
+}
+
+func TestServer_ReloadConfig_InvalidKeepsCurrent(t *testing.T) {
+	t.Parallel()
+
+	paths := &config.Paths{BaseDir: t.TempDir()}
+	server, err := NewServer(&ServerConfig{
+		Store: newMockStore(),
+		Paths: paths,
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	data := []byte("daemon:\n  log_level: loud\n")
+	if err := os.WriteFile(paths.ConfigFile(), data, 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if err := server.ReloadConfig(); err == nil {
+		t.Fatal("expected error for invalid config")
+	}
+	if server.getConfig() != nil {
+		t.Error("invalid config must not be applied")
+	}
+}
+
+func TestScorerWeightsFromConfig_DefaultsMatchScorer(t *testing.T) {
+	t.Parallel()
+
+	w := config.DefaultSuggestionsConfig().Weights
+	if got, want := ScorerWeightsFromConfig(&w), suggest2.DefaultWeights(); got != want {
+		t.Errorf("ScorerWeightsFromConfig(defaults) = %+v, want %+v", got, want)
+	}
+
+	w.Transition *= 2
+	got := ScorerWeightsFromConfig(&w)
+	if got.RepoTransition != 2*suggest2.DefaultWeightRepoTransition {
+		t.Errorf("RepoTransition = %v, want %v", got.RepoTransition, 2*suggest2.DefaultWeightRepoTransition)
+	}
+	if got.RepoFrequency != suggest2.DefaultWeightRepoFrequency {
+		t.Errorf("RepoFrequency = %v, want unchanged %v", got.RepoFrequency, suggest2.DefaultWeightRepoFrequency)
+	}
+
+	w.TimeOfDay = 0
+	if got := ScorerWeightsFromConfig(&w); got.TimeOfDay != 0 || got.DayOfWeek != suggest2.DefaultWeightDayOfWeek {
+		t.Errorf("TimeOfDay, DayOfWeek = %v, %v, want 0, %v", got.TimeOfDay, got.DayOfWeek, suggest2.DefaultWeightDayOfWeek)
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"info":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+		"":      slog.LevelInfo,
+	}
+	for in, want := range tests {
+		if got := parseLogLevel(in); got != want {
+			t.Errorf("parseLogLevel(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestRequiresRestart(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"daemon.socket_path":                  true,
+		"daemon.log_file":                     true,
+		"daemon.log_level":                    false,
+		"daemon.slow_rpc_ms":                  false,
+		"paths.data_dir":                      true,
+		"suggestions.max_results":             false,
+		"suggestions.weights.task":            false,
+		"suggestions.max_db_size_mb":          true,
+		"suggestions.retention_rules[0].days": true,
+	}
+	for key, want := range tests {
+		if got := RequiresRestart(key); got != want {
+			t.Errorf("RequiresRestart(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
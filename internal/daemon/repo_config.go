@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/runger/clai/internal/config"
+)
+
+// repoConfigs caches the config of each repository with a
+// .clai/config.yaml merged over the daemon's, keyed by repository root.
+// An entry is rebuilt when the file, the trust store or the daemon config
+// changes.
+type repoConfigs struct {
+	entries map[string]*repoConfigEntry
+	mu      sync.Mutex
+}
+
+type repoConfigEntry struct {
+	base      *config.Config
+	cfg       *config.Config
+	fileMod   time.Time
+	trustMod  time.Time
+	status    config.RepoStatus
+	fileSize  int64
+	incognito bool
+}
+
+// repoConfig returns the config for commands run in the repository at
+// root, and whether its trusted config sets incognito. Without a trusted
+// repository config it returns the daemon config.
+func (s *Server) repoConfig(root string) (*config.Config, bool) {
+	base := s.runtimeConfig()
+	if root == "" || s.paths == nil {
+		return base, false
+	}
+	file, err := os.Stat(filepath.Join(root, config.RepoConfigFile))
+	if err != nil {
+		return base, false
+	}
+	var trustMod time.Time
+	if trust, err := os.Stat(s.paths.TrustedReposFile()); err == nil {
+		trustMod = trust.ModTime()
+	}
+
+	s.repoConfigs.mu.Lock()
+	defer s.repoConfigs.mu.Unlock()
+	e := s.repoConfigs.entries[root]
+	if e != nil && e.base == base && e.fileMod.Equal(file.ModTime()) && e.fileSize == file.Size() && e.trustMod.Equal(trustMod) {
+		return e.cfg, e.incognito
+	}
+
+	cfg, rc, status, err := base.LoadRepo(s.paths, root)
+	if e == nil || e.status != status {
+		switch status {
+		case config.RepoInvalid:
+			s.logger.Warn("ignoring invalid repository config", "repo", root, "error", err)
+		case config.RepoUntrusted:
+			s.logger.Info("ignoring untrusted repository config; run `clai config trust` in the repository", "repo", root)
+		case config.RepoApplied:
+			s.logger.Info("applying repository config", "repo", root)
+		}
+	}
+	if s.repoConfigs.entries == nil {
+		s.repoConfigs.entries = make(map[string]*repoConfigEntry)
+	}
+	s.repoConfigs.entries[root] = &repoConfigEntry{
+		base:      base,
+		cfg:       cfg,
+		fileMod:   file.ModTime(),
+		trustMod:  trustMod,
+		status:    status,
+		fileSize:  file.Size(),
+		incognito: status == config.RepoApplied && rc.Incognito,
+	}
+	return cfg, status == config.RepoApplied && rc.Incognito
+}
+
+// sessionRepoRoot returns the repository root of the session's last
+// command.
+func (s *Server) sessionRepoRoot(sessionID string) string {
+	if info, ok := s.sessionManager.Get(sessionID); ok {
+		return info.LastGitRoot
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
+)
+
+func writeRepoConfig(t *testing.T, root, content string) []byte {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, ".clai"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, config.RepoConfigFile), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return []byte(content)
+}
+
+func TestServer_RepoConfig(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	server.paths = &config.Paths{BaseDir: t.TempDir()}
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	data := writeRepoConfig(t, root, "incognito: true\nsuggestions:\n  weights:\n    task: 0.5\n")
+
+	if cfg, incognito := server.repoConfig(root); cfg != server.runtimeConfig() || incognito {
+		t.Error("untrusted repository config applied")
+	}
+	if err := config.TrustRepo(server.paths, root, data); err != nil {
+		t.Fatal(err)
+	}
+	cfg, incognito := server.repoConfig(root)
+	if !incognito || cfg.Suggestions.Weights.Task != 0.5 {
+		t.Errorf("trusted repository config: incognito = %v, task = %v", incognito, cfg.Suggestions.Weights.Task)
+	}
+	if again, _ := server.repoConfig(root); again != cfg {
+		t.Error("unchanged repository config was not cached")
+	}
+
+	ctx := context.Background()
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "repo-session", Cwd: root})
+	req := &pb.CommandStartRequest{SessionId: "repo-session", CommandId: "c1", Cwd: root, GitRepoRoot: root, Command: "deploy --token x"}
+	if resp, err := server.CommandStarted(ctx, req); err != nil || !resp.Ok {
+		t.Fatalf("CommandStarted() = %v, %v", resp, err)
+	}
+	if _, ok := server.store.(*mockStore).commands["c1"]; ok {
+		t.Error("command in an incognito repository was stored")
+	}
+
+	if _, incognito := server.repoConfig(""); incognito {
+		t.Error("no repository should never be incognito")
+	}
+}
@@ -0,0 +1,128 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// restartStateFileName is the file the daemon hands in-memory state
+	// through across a graceful restart.
+	restartStateFileName = "restart-state.json"
+
+	// restartStateMaxAge bounds how old a handoff file may be before it is
+	// ignored, so a leftover from a failed restart doesn't resurrect sessions.
+	restartStateMaxAge = time.Hour
+
+	restartStateVersion = 1
+)
+
+// restartState is the in-memory daemon state preserved across a restart.
+type restartState struct {
+	SavedAt        time.Time      `json:"saved_at"`
+	Sessions       []*SessionInfo `json:"sessions"`
+	Version        int            `json:"version"`
+	CommandsLogged int64          `json:"commands_logged"`
+
+	ScorerComparison *scorerComparisonStats `json:"scorer_comparison,omitempty"`
+
+	// Handoffs hold the suggestion snapshots and recently run commands of
+	// the sessions, by session ID.
+	Handoffs map[string]*SessionHandoff `json:"handoffs,omitempty"`
+}
+
+// RestartStatePath returns the path of the restart handoff file in baseDir.
+func RestartStatePath(baseDir string) string {
+	return filepath.Join(baseDir, restartStateFileName)
+}
+
+// saveRestartState writes the server's session state for the next process.
+func (s *Server) saveRestartState() error {
+	s.mu.RLock()
+	commandsLogged := s.commandsLogged
+	s.mu.RUnlock()
+
+	state := restartState{
+		Version:        restartStateVersion,
+		SavedAt:        time.Now(),
+		Sessions:       s.sessionManager.GetAll(),
+		CommandsLogged: commandsLogged,
+		Handoffs:       s.sessionManager.Handoffs(),
+	}
+	if stats := s.scorerComparison.snapshot(false); stats.Commands > 0 {
+		state.ScorerComparison = &stats
+	}
+	data, err := json.Marshal(&state)
+	if err != nil {
+		return fmt.Errorf("failed to encode restart state: %w", err)
+	}
+
+	// Write to a temp file and rename so the new process never reads a partial file.
+	path := RestartStatePath(s.paths.BaseDir)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write restart state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write restart state: %w", err)
+	}
+	return nil
+}
+
+// restoreRestartState loads state left by a previous process, if any, and
+// removes the handoff file. Missing or stale state is not an error.
+func (s *Server) restoreRestartState() {
+	path := RestartStatePath(s.paths.BaseDir)
+	state, err := readRestartState(path, time.Now())
+	if removeErr := os.Remove(path); removeErr != nil && !os.IsNotExist(removeErr) {
+		s.logger.Warn("failed to remove restart state", "path", path, "error", removeErr)
+	}
+	if err != nil {
+		s.logger.Warn("ignoring restart state", "path", path, "error", err)
+		return
+	}
+	if state == nil {
+		return
+	}
+
+	s.sessionManager.Restore(state.Sessions)
+	s.sessionManager.RestoreHandoffs(state.Handoffs)
+	s.mu.Lock()
+	s.commandsLogged += state.CommandsLogged
+	s.mu.Unlock()
+	s.scorerComparison.restore(state.ScorerComparison)
+
+	s.logger.Info("restored state from previous daemon",
+		"sessions", len(state.Sessions),
+		"commands_logged", state.CommandsLogged,
+	)
+}
+
+// readRestartState reads the handoff file at path. It returns nil, nil when
+// the file does not exist.
+func readRestartState(path string, now time.Time) (*restartState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state restartState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid restart state: %w", err)
+	}
+	if state.Version != restartStateVersion {
+		return nil, fmt.Errorf("unsupported restart state version %d", state.Version)
+	}
+	if now.Sub(state.SavedAt) > restartStateMaxAge {
+		return nil, fmt.Errorf("restart state is stale (saved %s)", state.SavedAt.Format(time.RFC3339))
+	}
+	return &state, nil
+}
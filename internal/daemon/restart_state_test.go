@@ -0,0 +1,106 @@
+package daemon
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
+)
+
+func TestRestartState_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	paths := &config.Paths{BaseDir: t.TempDir()}
+
+	old := createTestServer(t)
+	old.paths = paths
+	old.sessionManager.Start("session-1", "zsh", "darwin", "host1", "user1", "/tmp", time.Now())
+	old.sessionManager.SetNote("session-1", "debugging flaky test")
+	old.sessionManager.StashCommand("session-1", "cmd-1", "make test", "/repo", "repo", "/repo", "main", false)
+	old.commandsLogged = 7
+	old.scorerComparison.record("session-1", []string{"make test"}, nil, time.Now())
+	old.scorerComparison.match("session-1", "make test", time.Now())
+	old.sessionManager.SetPromptSnapshot("session-1", "ma", []*pb.Suggestion{{Text: "make test", Score: 0.9}}, nil, time.Now())
+	old.sessionManager.FreezePromptSnapshot("session-1", "make test", time.Now())
+	old.sessionManager.RecordUse("session-1", "make test", time.Now())
+
+	if err := old.saveRestartState(); err != nil {
+		t.Fatalf("saveRestartState() error = %v", err)
+	}
+
+	next := createTestServer(t)
+	next.paths = paths
+	next.restoreRestartState()
+
+	info, ok := next.sessionManager.Get("session-1")
+	if !ok {
+		t.Fatal("session-1 was not restored")
+	}
+	if info.Note != "debugging flaky test" {
+		t.Errorf("Note = %q, want %q", info.Note, "debugging flaky test")
+	}
+	if info.LastCmdID != "cmd-1" || info.LastCmdRaw != "make test" {
+		t.Errorf("stashed command = (%q, %q), want (cmd-1, make test)", info.LastCmdID, info.LastCmdRaw)
+	}
+	if next.commandsLogged != 7 {
+		t.Errorf("commandsLogged = %d, want 7", next.commandsLogged)
+	}
+	if stats := next.scorerComparison.snapshot(false); stats.Commands != 1 || stats.V1.Hits != 1 {
+		t.Errorf("scorer comparison = %+v, want one v1 hit", stats)
+	}
+	if snap, ok := next.sessionManager.CommandSnapshot("session-1"); !ok || snap.Command != "make test" ||
+		len(snap.Explained) != 1 || snap.Explained[0].Score != 0.9 {
+		t.Errorf("command snapshot = %+v, %v; want the suggestions shown before make test", snap, ok)
+	}
+	if uses := next.sessionManager.RecentUses("session-1", time.Time{}); len(uses) != 1 {
+		t.Errorf("recent uses = %v, want make test", uses)
+	}
+	if _, err := os.Stat(RestartStatePath(paths.BaseDir)); !os.IsNotExist(err) {
+		t.Errorf("restart state file should be removed after restore, stat error = %v", err)
+	}
+}
+
+func TestRestartState_NoFile(t *testing.T) {
+	t.Parallel()
+
+	s := createTestServer(t)
+	s.paths = &config.Paths{BaseDir: t.TempDir()}
+	s.restoreRestartState()
+
+	if s.sessionManager.ActiveCount() != 0 {
+		t.Errorf("ActiveCount() = %d, want 0", s.sessionManager.ActiveCount())
+	}
+}
+
+func TestReadRestartState_RejectsStaleAndUnknownVersion(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	tests := []struct {
+		name  string
+		state restartState
+	}{
+		{"stale", restartState{Version: restartStateVersion, SavedAt: now.Add(-2 * restartStateMaxAge)}},
+		{"unknown version", restartState{Version: restartStateVersion + 1, SavedAt: now}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := RestartStatePath(t.TempDir())
+			data, err := json.Marshal(&tt.state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readRestartState(path, now); err == nil {
+				t.Error("readRestartState() expected error")
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"log/slog"
 
+	"github.com/runger/clai/internal/suggestions/clock"
 	"github.com/runger/clai/internal/suggestions/discovery"
 	"github.com/runger/clai/internal/suggestions/dismissal"
 	"github.com/runger/clai/internal/suggestions/recovery"
@@ -17,7 +18,7 @@ import (
 // Dependencies that fail to initialize are left nil; the Scorer handles nil
 // stores gracefully by skipping those scoring features. This allows partial
 // operation even when V1-schema stores are not compatible with the V2 database.
-func initV2Scorer(db *sql.DB, logger *slog.Logger) *suggest2.Scorer {
+func initV2Scorer(db *sql.DB, clk clock.Clock, logger *slog.Logger) *suggest2.Scorer {
 	if logger == nil {
 		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
 	}
@@ -25,7 +26,9 @@ func initV2Scorer(db *sql.DB, logger *slog.Logger) *suggest2.Scorer {
 	var deps suggest2.ScorerDependencies
 	deps.DB = db
 
-	if fs, err := score.NewFrequencyStore(db, score.DefaultFrequencyOptions()); err != nil {
+	freqOpts := score.DefaultFrequencyOptions()
+	freqOpts.Clock = clk
+	if fs, err := score.NewFrequencyStore(db, freqOpts); err != nil {
 		logger.Warn("v2 scorer: frequency store unavailable", "error", err)
 	} else {
 		deps.FreqStore = fs
@@ -55,7 +58,9 @@ func initV2Scorer(db *sql.DB, logger *slog.Logger) *suggest2.Scorer {
 		deps.RecoveryEngine = re
 	}
 
-	scorer, err := suggest2.NewScorer(&deps, suggest2.DefaultScorerConfig())
+	scorerCfg := suggest2.DefaultScorerConfig()
+	scorerCfg.Clock = clk
+	scorer, err := suggest2.NewScorer(&deps, scorerCfg)
 	if err != nil {
 		logger.Warn("v2 scorer: failed to create scorer", "error", err)
 		return nil
@@ -3,7 +3,9 @@ package daemon
 import (
 	"fmt"
 	"os"
+	"os/user"
 	"runtime"
+	"strconv"
 )
 
 // ErrRunningAsRoot is returned when the daemon detects it is running as root.
@@ -87,3 +89,66 @@ func EnsureSecureDirectory(dirPath string) error {
 
 	return nil
 }
+
+// EnsureSocketDirectory prepares the directory holding the daemon socket.
+// Without a group it is EnsureSecureDirectory. With one, the group's members
+// must reach the socket, so the directory gets that group and mode 0o710:
+// they can enter it, but not list it or create anything in it.
+func EnsureSocketDirectory(dirPath, group string) error {
+	if err := EnsureSecureDirectory(dirPath); err != nil {
+		return err
+	}
+	if group == "" || runtime.GOOS == "windows" {
+		return nil
+	}
+
+	gid, err := lookupGroupID(group)
+	if err != nil {
+		return err
+	}
+	if err := os.Chown(dirPath, -1, gid); err != nil {
+		return fmt.Errorf("failed to set group of %s to %s: %w", dirPath, group, err)
+	}
+	if err := os.Chmod(dirPath, 0o710); err != nil { //nolint:gosec // G302: the socket group may only traverse the directory
+		return fmt.Errorf("failed to set permissions on %s: %w", dirPath, err)
+	}
+	return nil
+}
+
+// applySocketPermissions sets the socket's file mode and, when group is not
+// empty, its group owner. group may be a group name or a numeric GID; the
+// daemon's user must be a member of it.
+func applySocketPermissions(socketPath string, mode os.FileMode, group string) error {
+	if group != "" {
+		if runtime.GOOS == "windows" {
+			return fmt.Errorf("daemon.socket_group is not supported on Windows")
+		}
+		gid, err := lookupGroupID(group)
+		if err != nil {
+			return err
+		}
+		if err := os.Chown(socketPath, -1, gid); err != nil {
+			return fmt.Errorf("failed to set socket group to %s: %w", group, err)
+		}
+	}
+	if err := os.Chmod(socketPath, mode); err != nil {
+		return fmt.Errorf("failed to set socket mode %04o: %w", mode, err)
+	}
+	return nil
+}
+
+// lookupGroupID resolves a group name or numeric GID.
+func lookupGroupID(group string) (int, error) {
+	if gid, err := strconv.Atoi(group); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return 0, fmt.Errorf("unknown socket group %q: %w", group, err)
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return 0, fmt.Errorf("group %q has non-numeric GID %q", group, g.Gid)
+	}
+	return gid, nil
+}
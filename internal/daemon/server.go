@@ -10,22 +10,30 @@ import (
 	"net"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 
 	pb "github.com/runger/clai/gen/clai/v1"
 	"github.com/runger/clai/internal/config"
 	"github.com/runger/clai/internal/provider"
+	"github.com/runger/clai/internal/sanitize"
 	"github.com/runger/clai/internal/storage"
 	"github.com/runger/clai/internal/suggest"
+	"github.com/runger/clai/internal/suggestions/backfill"
 	"github.com/runger/clai/internal/suggestions/batch"
+	"github.com/runger/clai/internal/suggestions/clock"
 	suggestdb "github.com/runger/clai/internal/suggestions/db"
 	"github.com/runger/clai/internal/suggestions/feedback"
 	"github.com/runger/clai/internal/suggestions/ingest"
+	"github.com/runger/clai/internal/suggestions/learning"
 	"github.com/runger/clai/internal/suggestions/maintenance"
+	"github.com/runger/clai/internal/suggestions/projecttype"
 	suggest2 "github.com/runger/clai/internal/suggestions/suggest"
+	"github.com/runger/clai/internal/suggestions/workflow"
 )
 
 // Version is set at build time
@@ -49,21 +57,42 @@ type Server struct {
 	v2Scorer          *suggest2.Scorer
 	logger            *slog.Logger
 	sessionManager    *SessionManager
+	events            *EventBroker
 	registry          *provider.Registry
 	v2db              *suggestdb.DB
 	circuitBreaker    *CircuitBreaker
+	rateLimiter       *RateLimiter
 	shutdownChan      chan struct{}
 	ingestionQueue    *IngestionQueue
 	paths             *config.Paths
+	config            *config.Config
+	logLevel          *slog.LevelVar
 	feedbackStore     *feedback.Store
+	clock             clock.Clock
 	maintenanceRunner *maintenance.Runner
+	scorerComparison  *scorerComparison // Shadow-scoring counts (see shadow_scoring.go)
+	inline            *inlineRequests   // Latest ghost-text request per session (see inline.go)
+	repoConfigs       repoConfigs       // Per-repository config overrides (see repo_config.go)
 	batchWriter       *batch.Writer
+	workflowMiner     *workflow.Miner // Promotes recurring sequences; nil without V2
+	minerStarted      atomic.Bool
 	scorerVersion     string
+	learning          *learning.Store // Learned weight profiles; nil without V2
+	projectTypes      *projecttype.Detector
+	redactor          *sanitize.Sanitizer // Redacts secrets from incoming commands; nil when off
+	v1Migrated        atomic.Bool         // V2 holds the full history; see isV1Migrated
 	wg                sync.WaitGroup
 	idleTimeout       time.Duration
 	commandsLogged    int64
+	defaultMaxResults int
 	mu                sync.RWMutex
 	shutdownOnce      sync.Once
+	socketActivated   bool
+
+	// Rival detection (see coexist.go)
+	lockFile   *LockFile
+	socketInfo os.FileInfo
+	yielded    atomic.Bool
 }
 
 // ServerConfig contains configuration options for the daemon server.
@@ -74,11 +103,14 @@ type ServerConfig struct {
 	V2DB              *suggestdb.DB
 	Paths             *config.Paths
 	Logger            *slog.Logger
+	Config            *config.Config // Initial runtime config; enables the config file watcher
+	LogLevel          *slog.LevelVar // Level backing Logger; adjusted on config reload
 	FeedbackStore     *feedback.Store
 	MaintenanceRunner *maintenance.Runner
 	Registry          *provider.Registry
 	BatchWriter       *batch.Writer
 	V2Scorer          *suggest2.Scorer
+	Clock             clock.Clock // "Now" for suggestion scoring and decay; recorded history always uses wall time
 	ReloadFn          ReloadFunc
 	ScorerVersion     string
 	IdleTimeout       time.Duration
@@ -98,6 +130,7 @@ func NewServer(cfg *ServerConfig) (*Server, error) {
 	ranker := defaultRanker(cfg.Ranker, cfg.Store)
 	registry := defaultRegistry(cfg.Registry)
 	idleTimeout := defaultIdleTimeout(cfg.IdleTimeout)
+	clk := clock.OrSystem(cfg.Clock)
 
 	// Create ingestion queue with default capacity (8192)
 	ingestQueue := NewIngestionQueue(0, logger)
@@ -107,12 +140,13 @@ func NewServer(cfg *ServerConfig) (*Server, error) {
 		Logger: logger,
 	})
 
-	bw := resolveBatchWriter(cfg.BatchWriter, cfg.V2DB)
-	v2scorer := resolveV2Scorer(cfg.V2Scorer, cfg.V2DB, logger)
+	projectTypes := newProjectTypeDetector(cfg.Config)
+	bw := resolveBatchWriter(cfg.BatchWriter, cfg.V2DB, paths, cfg.Config, projectTypes, logger)
+	v2scorer := resolveV2Scorer(cfg.V2Scorer, cfg.V2DB, clk, logger)
 	scorerVersion := resolveScorerVersion(cfg.ScorerVersion, v2scorer, logger)
 
 	now := time.Now()
-	return &Server{
+	s := &Server{
 		store:             cfg.Store,
 		v2db:              cfg.V2DB,
 		ranker:            ranker,
@@ -121,18 +155,35 @@ func NewServer(cfg *ServerConfig) (*Server, error) {
 		paths:             paths,
 		logger:            logger,
 		sessionManager:    NewSessionManager(),
+		events:            NewEventBroker(),
 		feedbackStore:     cfg.FeedbackStore,
+		clock:             clk,
 		startTime:         now,
 		lastActivity:      now,
 		idleTimeout:       idleTimeout,
 		shutdownChan:      make(chan struct{}),
 		maintenanceRunner: cfg.MaintenanceRunner,
+		scorerComparison:  newScorerComparison(),
+		inline:            newInlineRequests(),
 		batchWriter:       bw,
+		workflowMiner:     resolveWorkflowMiner(cfg.V2DB, cfg.Config),
 		v2Scorer:          v2scorer,
 		scorerVersion:     scorerVersion,
 		ingestionQueue:    ingestQueue,
 		circuitBreaker:    cb,
-	}, nil
+		rateLimiter:       NewRateLimiter(),
+		logLevel:          cfg.LogLevel,
+	}
+	s.projectTypes = projectTypes
+	defaultSugg := config.DefaultSuggestionsConfig()
+	s.redactor = resolveRedactor(&defaultSugg, logger)
+	if cfg.V2DB != nil {
+		s.learning = learning.NewStore(cfg.V2DB.DB())
+	}
+	if cfg.Config != nil {
+		s.ApplyConfig(cfg.Config)
+	}
+	return s, nil
 }
 
 func defaultPaths(paths *config.Paths) *config.Paths {
@@ -170,26 +221,83 @@ func defaultIdleTimeout(timeout time.Duration) time.Duration {
 	return timeout
 }
 
-func resolveBatchWriter(override *batch.Writer, v2db *suggestdb.DB) *batch.Writer {
+// newProjectTypeDetector returns the project type detector, with the
+// user-defined project types of cfg added to the built-in ones.
+func newProjectTypeDetector(cfg *config.Config) *projecttype.Detector {
+	var markers []projecttype.Marker
+	if cfg != nil {
+		for _, pt := range cfg.Suggestions.ProjectTypes {
+			for _, pattern := range pt.Markers {
+				markers = append(markers, projecttype.NewMarker(pattern, pt.Name))
+			}
+		}
+	}
+	return projecttype.NewDetector(projecttype.DetectorOptions{ExtraMarkers: markers})
+}
+
+func resolveBatchWriter(
+	override *batch.Writer,
+	v2db *suggestdb.DB,
+	paths *config.Paths,
+	cfg *config.Config,
+	projectTypes *projecttype.Detector,
+	logger *slog.Logger,
+) *batch.Writer {
 	if override != nil {
 		return override
 	}
 	if v2db == nil {
 		return nil
 	}
+	sugg := config.DefaultSuggestionsConfig()
+	if cfg != nil {
+		sugg = cfg.Suggestions
+	}
+	// Commands arrive redacted (see Server.redact), under the config the
+	// daemon runs with now rather than the one it started with.
 	opts := batch.DefaultOptions()
 	opts.WritePathConfig = &ingest.WritePathConfig{}
+	if sugg.ProjectTypeDetectionEnabled {
+		opts.WritePathConfig.DetectProjectTypes = projectTypes.Detect
+	}
+	opts.Logger = logger
+
+	// Events that don't fit in the in-memory queue spill to disk, bounded
+	// by the ingest queue limits.
+	spool, err := batch.OpenSpool(paths.IngestSpoolFile(), sugg.IngestQueueMaxEvents, int64(sugg.IngestQueueMaxBytes))
+	if err != nil {
+		logger.Warn("ingest spool unavailable, dropping events when the queue is full", "error", err)
+	} else {
+		opts.Spool = spool
+	}
 	return batch.NewWriter(v2db.DB(), opts)
 }
 
-func resolveV2Scorer(override *suggest2.Scorer, v2db *suggestdb.DB, logger *slog.Logger) *suggest2.Scorer {
+// resolveRedactor returns the sanitizer that redacts secrets from incoming
+// commands before they are stored or published, or nil when
+// suggestions.redact_sensitive_tokens is off.
+func resolveRedactor(sugg *config.SuggestionsConfig, logger *slog.Logger) *sanitize.Sanitizer {
+	if !sugg.RedactSensitiveTokens {
+		return nil
+	}
+	redactor, err := sanitize.NewCommandSanitizer(sugg.RedactPatterns)
+	if err != nil {
+		logger.Warn("ignoring suggestions.redact_patterns", "error", err)
+		// Without extra patterns there is nothing to fail to compile.
+		redactor, _ = sanitize.NewCommandSanitizer(nil)
+	}
+	return redactor
+}
+
+func resolveV2Scorer(override *suggest2.Scorer, v2db *suggestdb.DB, clk clock.Clock, logger *slog.Logger) *suggest2.Scorer {
 	if override != nil {
 		return override
 	}
 	if v2db == nil {
 		return nil
 	}
-	return initV2Scorer(v2db.DB(), logger)
+	// The scorer only reads; the batch writer owns the writer connection.
+	return initV2Scorer(v2db.ReadDB(), clk, logger)
 }
 
 func resolveScorerVersion(requested string, v2scorer *suggest2.Scorer, logger *slog.Logger) string {
@@ -210,6 +318,73 @@ func resolveScorerVersion(requested string, v2scorer *suggest2.Scorer, logger *s
 	return version
 }
 
+// resolveV1Migrated reports whether 'clai db migrate-v2' has completed on
+// the V2 database, in which case history and suggestions are read from V2.
+func resolveV1Migrated(v2db *suggestdb.DB, logger *slog.Logger) bool {
+	if v2db == nil {
+		return false
+	}
+	migrated, err := backfill.V1Migrated(context.Background(), v2db.DB())
+	if err != nil {
+		logger.Warn("failed to check V1 migration status", "error", err)
+		return false
+	}
+	return migrated
+}
+
+// isV1Migrated reports whether the V1 history has been migrated into V2.
+// 'clai db migrate-v2' runs while the daemon is up and its marker is never
+// removed, so the database is asked until the migration shows up and the
+// answer is cached from then on.
+func (s *Server) isV1Migrated() bool {
+	if s.v1Migrated.Load() {
+		return true
+	}
+	if !resolveV1Migrated(s.v2db, s.logger) {
+		return false
+	}
+	s.v1Migrated.Store(true)
+	return true
+}
+
+// historyReader returns the reader FetchHistory serves from, following the
+// V1 migration status.
+func (s *Server) historyReader() storage.HistoryReader {
+	return newHistoryReader(s.store, s.v2db, s.isV1Migrated())
+}
+
+// newHistoryReader returns the reader FetchHistory serves from. Once the V1
+// history has been migrated, V2 holds all of it and is read alone, with V1
+// as a fallback should V2 fail. Before that, V2 is merged with the V1 store
+// so recent commands carry their V2 event IDs.
+func newHistoryReader(store storage.Store, v2db *suggestdb.DB, v1Migrated bool) storage.HistoryReader {
+	v1 := storage.NewV1HistoryReader(store)
+	if v2db == nil {
+		return v1
+	}
+	v2 := storage.NewV2HistoryReader(v2db.ReadDB())
+	if v1Migrated {
+		return storage.NewFallbackHistoryReader(v2, v1)
+	}
+	return storage.NewMergedHistoryReader(v2, v1)
+}
+
+// newGRPCServer creates the gRPC server and registers the clai service.
+// With daemon.debug_reflection set it also serves gRPC reflection, so tools
+// such as grpcurl can list and call methods without the .proto files.
+func (s *Server) newGRPCServer(socketPath string) *grpc.Server {
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(s.unaryInterceptors()...))
+	pb.RegisterClaiServiceServer(grpcServer, s)
+
+	if s.runtimeConfig().Daemon.DebugReflection {
+		reflection.Register(grpcServer)
+		s.logger.Warn("gRPC reflection enabled",
+			"try", "grpcurl -plaintext -unix "+socketPath+" list",
+		)
+	}
+	return grpcServer
+}
+
 // Start starts the gRPC server and listens on the Unix socket.
 func (s *Server) Start(ctx context.Context) error {
 	// Ensure runtime directory exists
@@ -217,28 +392,19 @@ func (s *Server) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to create directories: %w", err)
 	}
 
-	// Clean up stale socket
 	socketPath := s.paths.SocketFile()
-	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
-		s.logger.Warn("failed to remove stale socket", "path", socketPath, "error", err)
-	}
-
-	// Create Unix socket listener
-	listener, err := net.Listen("unix", socketPath)
+	listener, err := s.listen(socketPath)
 	if err != nil {
-		return fmt.Errorf("failed to listen on socket: %w", err)
+		return err
 	}
 	s.listener = listener
+	s.recordSocket(socketPath)
 
-	// Set socket permissions (readable/writable by owner only)
-	if err := os.Chmod(socketPath, 0o600); err != nil {
-		listener.Close()
-		return fmt.Errorf("failed to set socket permissions: %w", err)
-	}
+	s.grpcServer = s.newGRPCServer(socketPath)
 
-	// Create gRPC server
-	s.grpcServer = grpc.NewServer(grpc.ChainUnaryInterceptor(s.accessLogUnaryInterceptor()))
-	pb.RegisterClaiServiceServer(s.grpcServer, s)
+	// Pick up sessions handed over by a gracefully restarted predecessor.
+	// This happens before the PID file is written, which restart waits on.
+	s.restoreRestartState()
 
 	// Write PID file
 	if err := s.writePIDFile(); err != nil {
@@ -250,16 +416,30 @@ func (s *Server) Start(ctx context.Context) error {
 		"socket", socketPath,
 		"pid", os.Getpid(),
 		"version", Version,
+		"socket_activated", s.socketActivated,
 	)
 
-	// Start idle watcher
-	s.wg.Add(1)
-	go s.watchIdle(ctx)
+	// Start idle watcher. A socket-activated daemon is managed by the service
+	// manager and stays up; exiting would only cause a restart on next use.
+	if !s.socketActivated {
+		s.wg.Add(1)
+		go s.watchIdle(ctx)
+	}
 
 	// Start cache pruning
 	s.wg.Add(1)
 	go s.pruneCacheLoop(ctx)
 
+	// Yield to another daemon that takes over this one's socket and lock
+	s.wg.Add(1)
+	go s.watchRivals(ctx)
+
+	// Watch the config file for changes (only when started with a config)
+	if s.getConfig() != nil {
+		s.wg.Add(1)
+		go s.watchConfig(ctx)
+	}
+
 	// Start maintenance runner (if configured)
 	if s.maintenanceRunner != nil {
 		s.wg.Add(1)
@@ -274,6 +454,12 @@ func (s *Server) Start(ctx context.Context) error {
 		s.batchWriter.Start()
 	}
 
+	// Start workflow mining (if V2 is enabled)
+	if s.workflowMiner != nil {
+		s.minerStarted.Store(true)
+		s.workflowMiner.Start()
+	}
+
 	// Serve gRPC requests in a goroutine
 	errChan := make(chan error, 1)
 	go func() {
@@ -296,6 +482,47 @@ func (s *Server) Start(ctx context.Context) error {
 	}
 }
 
+// listen returns the socket-activated listener if one was passed in,
+// otherwise it creates the Unix socket at socketPath.
+func (s *Server) listen(socketPath string) (net.Listener, error) {
+	listener, err := activatedListener()
+	if err != nil {
+		return nil, err
+	}
+	if listener != nil {
+		// The service manager created the socket; its unit file sets the
+		// permissions (SocketMode=/SocketGroup= or SockPathMode).
+		s.socketActivated = true
+		return listener, nil
+	}
+
+	// Clean up stale socket
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		s.logger.Warn("failed to remove stale socket", "path", socketPath, "error", err)
+	}
+
+	// Create Unix socket listener
+	listener, err = net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on socket: %w", err)
+	}
+
+	// Restrict the socket to the owner (and optionally a group) before
+	// accepting connections. Failing closed keeps a misconfigured group
+	// from leaving the socket more open than intended.
+	daemonCfg := s.runtimeConfig().Daemon
+	mode, err := config.ParseSocketMode(daemonCfg.SocketMode)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("invalid daemon.%w", err)
+	}
+	if err := applySocketPermissions(socketPath, mode, daemonCfg.SocketGroup); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
 func (s *Server) accessLogUnaryInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 		start := time.Now()
@@ -326,6 +553,11 @@ func (s *Server) Shutdown() {
 			s.batchWriter.Stop()
 		}
 
+		// Stop workflow mining
+		if s.minerStarted.Load() {
+			s.workflowMiner.Stop()
+		}
+
 		// Stop gRPC server
 		if s.grpcServer != nil {
 			s.grpcServer.GracefulStop()
@@ -346,13 +578,21 @@ func (s *Server) Shutdown() {
 	})
 }
 
-// cleanup removes the socket and PID file.
+// cleanup removes the socket and PID file. An activated socket belongs to
+// the service manager and is left in place.
 func (s *Server) cleanup() {
 	socketPath := s.paths.SocketFile()
 	pidPath := s.paths.PIDFile()
 
-	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
-		s.logger.Warn("failed to remove socket", "path", socketPath, "error", err)
+	// After yielding, the socket and PID file belong to the other daemon.
+	if s.yielded.Load() {
+		return
+	}
+
+	if !s.socketActivated {
+		if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+			s.logger.Warn("failed to remove socket", "path", socketPath, "error", err)
+		}
 	}
 
 	if err := os.Remove(pidPath); err != nil && !os.IsNotExist(err) {
@@ -414,12 +654,15 @@ func (s *Server) watchIdle(ctx context.Context) {
 		case <-s.shutdownChan:
 			return
 		case <-ticker.C:
-			if s.sessionManager.ActiveCount() == 0 {
+			// A zero timeout disables the idle shutdown; a config reload
+			// may set or clear it at any time.
+			timeout := s.getIdleTimeout()
+			if timeout > 0 && s.sessionManager.ActiveCount() == 0 && s.events.Count() == 0 {
 				since := time.Since(s.getLastActivity())
-				if since > s.idleTimeout {
+				if since > timeout {
 					s.logger.Info("idle timeout reached",
 						"idle_duration", since,
-						"timeout", s.idleTimeout,
+						"timeout", timeout,
 					)
 					go s.Shutdown()
 					return
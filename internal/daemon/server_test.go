@@ -96,6 +96,29 @@ func TestNewServer_DefaultIdleTimeout(t *testing.T) {
 	}
 }
 
+func TestNewProjectTypeDetector_CustomTypes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, ".bazel"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.ProjectTypes = []config.ProjectType{
+		{Name: "tf", Markers: []string{"*.tf"}},
+		{Name: "bazel", Markers: []string{".bazel/"}},
+		{Name: "pants", Markers: []string{"pants.toml"}},
+	}
+	got := newProjectTypeDetector(cfg).Detect(dir)
+	if len(got) != 2 || got[0] != "tf" || got[1] != "bazel" {
+		t.Errorf("Detect() = %v, want [tf bazel]", got)
+	}
+}
+
 func TestServer_TouchActivity(t *testing.T) {
 	t.Parallel()
 
@@ -1318,6 +1341,26 @@ func TestNewServer_BatchWriterNilWithoutV2(t *testing.T) {
 	}
 }
 
+func TestResolveRedactor(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	sugg := config.DefaultSuggestionsConfig()
+	sugg.RedactPatterns = []string{`corp-[0-9]{6}`}
+	redactor := resolveRedactor(&sugg, logger)
+	if redactor == nil {
+		t.Fatal("resolveRedactor() = nil with redact_sensitive_tokens on")
+	}
+	if got := redactor.Sanitize("login corp-123456"); got != "login [REDACTED]" {
+		t.Errorf("Sanitize() = %q, want the custom pattern redacted", got)
+	}
+
+	sugg.RedactSensitiveTokens = false
+	if resolveRedactor(&sugg, logger) != nil {
+		t.Error("resolveRedactor() != nil with redact_sensitive_tokens off")
+	}
+}
+
 // TestNewServer_CustomBatchWriter verifies a custom batch writer is used when provided.
 func TestNewServer_CustomBatchWriter(t *testing.T) {
 	t.Parallel()
@@ -1584,3 +1627,23 @@ var _ storage.Store = (*mockStore)(nil)
 var _ storage.Store = (*mockStoreWithPruning)(nil)
 var _ suggest.Ranker = (*mockRanker)(nil)
 var _ provider.Provider = (*mockProvider)(nil)
+
+func TestNewGRPCServer_DebugReflection(t *testing.T) {
+	t.Parallel()
+
+	server := createTestServer(t)
+	if _, ok := server.newGRPCServer("/tmp/clai.sock").GetServiceInfo()["grpc.reflection.v1.ServerReflection"]; ok {
+		t.Error("reflection served without daemon.debug_reflection")
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Daemon.DebugReflection = true
+	server.ApplyConfig(cfg)
+	services := server.newGRPCServer("/tmp/clai.sock").GetServiceInfo()
+	if _, ok := services["grpc.reflection.v1.ServerReflection"]; !ok {
+		t.Errorf("reflection not served with daemon.debug_reflection; services: %v", services)
+	}
+	if _, ok := services["clai.v1.ClaiService"]; !ok {
+		t.Error("clai service not registered")
+	}
+}
@@ -1,8 +1,13 @@
 package daemon
 
 import (
+	"fmt"
+	"maps"
 	"sync"
 	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/suggestions/typo"
 )
 
 // SessionInfo contains metadata about an active session.
@@ -23,8 +28,88 @@ type SessionInfo struct {
 	LastGitRoot   string // Git repo root from CommandStarted
 	LastGitBranch string // Git branch from CommandStarted
 	LastCmdID     string // Command ID from CommandStarted
+
+	// LastCmdPrevCWD and LastCmdEnv are the environment context sent with
+	// CommandStarted, if any.
+	LastCmdPrevCWD string
+	LastCmdEnv     map[string]string
+
+	// LastCmdTruncated reports whether LastCmdRaw was cut to the byte limit.
+	LastCmdTruncated bool
+
+	// LastCmdEphemeral reports whether the last command is kept in memory
+	// only, for an incognito session or a suggestions.ephemeral_dirs match.
+	LastCmdEphemeral bool
+
+	// LastCmdEnded reports whether the end of the last command was already
+	// handed to the V2 writer, so a replayed CommandEnded is not.
+	LastCmdEnded bool
+
+	// Note is a short free-text note set via `clai note`, included in AI prompts.
+	Note string
+
+	// Name is set via `clai session name` and also stored with the session.
+	Name string
+
+	// TypoCorrections are corrections of LastCmdRaw after it exited with
+	// "command not found", best first.
+	TypoCorrections []typo.Correction
+
+	// DiagnosisFixes are the fixes of an automatic diagnosis of LastCmdRaw
+	// after it failed repeatedly (ai.auto_diagnose).
+	DiagnosisFixes []*pb.Suggestion
+
+	// Degraded says why the session is served fire-and-forget only, after
+	// its clients exceeded suggestions.latency_budget, until DegradedUntil.
+	// Read it with DegradedReason, which clears it once expired.
+	Degraded      string
+	DegradedUntil time.Time
+
+	// ingestLatency and suggestLatency are the latest latencies the
+	// session's clients reported. See RecordLatency.
+	ingestLatency  latencySamples
+	suggestLatency latencySamples
+
+	// failedNorm is the normalized command that failed failStreak times
+	// in a row as the session's latest commands. See RecordExit.
+	failedNorm string
+	failStreak int
+
+	// recentUses maps the commands run in the session to when each was
+	// last run, for the session recency boost. Read it with RecentUses.
+	recentUses map[string]time.Time
+
+	// promptSnapshot holds the suggestions last shown at the current
+	// prompt; commandSnapshot those shown at the prompt the last command
+	// ran from. Read it with CommandSnapshot.
+	promptSnapshot  *SuggestSnapshot
+	commandSnapshot *SuggestSnapshot
+}
+
+// SuggestSnapshot is what was suggested at a prompt, for explicit feedback
+// and for explaining the ranking.
+type SuggestSnapshot struct {
+	At          time.Time          `json:"at"`
+	Scoring     *pb.SuggestScoring `json:"scoring,omitempty"`   // How the suggestions were ranked; nil if not reported
+	Prefix      string             `json:"prefix"`              // Buffer the suggestions were made for
+	Command     string             `json:"command,omitempty"`   // Command run from the prompt, once it started
+	Suggestions []string           `json:"suggestions"`         // Best first
+	Explained   []*pb.Suggestion   `json:"explained,omitempty"` // Suggestions with reasons and score breakdown, best first
+}
+
+// SessionHandoff is the per-session state SessionInfo does not export,
+// saved across a daemon restart so feedback on the last suggestions and
+// the session recency boost keep working.
+type SessionHandoff struct {
+	RecentUses      map[string]time.Time `json:"recent_uses,omitempty"`
+	PromptSnapshot  *SuggestSnapshot     `json:"prompt_snapshot,omitempty"`
+	CommandSnapshot *SuggestSnapshot     `json:"command_snapshot,omitempty"`
 }
 
+// maxRecentUses caps the distinct commands remembered per session for the
+// session recency boost; the least recently run is dropped first.
+const maxRecentUses = 64
+
 // SessionManager tracks active sessions.
 type SessionManager struct {
 	sessions map[string]*SessionInfo
@@ -100,7 +185,7 @@ func (m *SessionManager) UpdateCWD(sessionID, cwd string) {
 }
 
 // StashCommand stores command data from CommandStarted for later retrieval by CommandEnded.
-func (m *SessionManager) StashCommand(sessionID, cmdID, cmdRaw, cwd, gitRepo, gitRoot, gitBranch string) {
+func (m *SessionManager) StashCommand(sessionID, cmdID, cmdRaw, cwd, gitRepo, gitRoot, gitBranch string, truncated bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -111,10 +196,378 @@ func (m *SessionManager) StashCommand(sessionID, cmdID, cmdRaw, cwd, gitRepo, gi
 		info.LastGitRoot = gitRoot
 		info.LastGitBranch = gitBranch
 		info.LastCmdID = cmdID
+		info.LastCmdTruncated = truncated
+		info.LastCmdEphemeral = false
+		info.LastCmdEnded = false
+		info.LastCmdPrevCWD = ""
+		info.LastCmdEnv = nil
+		info.TypoCorrections = nil
+		info.DiagnosisFixes = nil
 		info.LastActivity = time.Now()
 	}
 }
 
+// StashContext stores the environment context of the command last stashed
+// by StashCommand.
+func (m *SessionManager) StashContext(sessionID, prevCWD string, env map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if info, ok := m.sessions[sessionID]; ok {
+		info.LastCmdPrevCWD = prevCWD
+		info.LastCmdEnv = env
+	}
+}
+
+// MarkEphemeral marks the command last stashed by StashCommand as
+// ephemeral.
+func (m *SessionManager) MarkEphemeral(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if info, ok := m.sessions[sessionID]; ok {
+		info.LastCmdEphemeral = true
+	}
+}
+
+// IsEphemeral reports whether cmdID is the session's last command and was
+// marked ephemeral.
+func (m *SessionManager) IsEphemeral(sessionID, cmdID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	info, ok := m.sessions[sessionID]
+	return ok && info.LastCmdID == cmdID && info.LastCmdEphemeral
+}
+
+// EndCommand marks cmdID as ended and returns a copy of the session as it
+// was stashed for it. It returns false when cmdID is not the session's last
+// command, or when it already ended, as for a replayed CommandEnded.
+func (m *SessionManager) EndCommand(sessionID, cmdID string) (*SessionInfo, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, ok := m.sessions[sessionID]
+	if !ok || info.LastCmdID != cmdID || info.LastCmdEnded {
+		return nil, false
+	}
+	info.LastCmdEnded = true
+	infoCopy := *info
+	return &infoCopy, true
+}
+
+// RecordUse notes that cmd was run in the session at ts.
+func (m *SessionManager) RecordUse(sessionID, cmd string, ts time.Time) {
+	if cmd == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, ok := m.sessions[sessionID]
+	if !ok {
+		return
+	}
+	if info.recentUses == nil {
+		info.recentUses = make(map[string]time.Time)
+	}
+	if last, seen := info.recentUses[cmd]; !seen || ts.After(last) {
+		info.recentUses[cmd] = ts
+	}
+	if len(info.recentUses) > maxRecentUses {
+		oldest, oldestTS := "", time.Time{}
+		for c, t := range info.recentUses {
+			if oldest == "" || t.Before(oldestTS) {
+				oldest, oldestTS = c, t
+			}
+		}
+		delete(info.recentUses, oldest)
+	}
+}
+
+// RecentUses returns the commands run in the session since the given time,
+// mapped to when each was last run.
+func (m *SessionManager) RecentUses(sessionID string, since time.Time) map[string]time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	info, ok := m.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+	var uses map[string]time.Time
+	for cmd, ts := range info.recentUses {
+		if ts.Before(since) {
+			continue
+		}
+		if uses == nil {
+			uses = make(map[string]time.Time)
+		}
+		uses[cmd] = ts
+	}
+	return uses
+}
+
+// SetPromptSnapshot records the suggestions shown at the session's prompt
+// for prefix, ranked as scoring describes, replacing earlier ones from the
+// same prompt. The suggestions must not be changed afterwards.
+func (m *SessionManager) SetPromptSnapshot(sessionID, prefix string, suggestions []*pb.Suggestion, scoring *pb.SuggestScoring, at time.Time) {
+	texts := make([]string, len(suggestions))
+	for i, sug := range suggestions {
+		texts[i] = sug.GetText()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if info, ok := m.sessions[sessionID]; ok {
+		info.promptSnapshot = &SuggestSnapshot{
+			At:          at,
+			Scoring:     scoring,
+			Prefix:      prefix,
+			Suggestions: texts,
+			Explained:   suggestions,
+		}
+	}
+}
+
+// FreezePromptSnapshot notes that cmd ran from the session's prompt: the
+// prompt's suggestions, if any, become the command snapshot.
+func (m *SessionManager) FreezePromptSnapshot(sessionID, cmd string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, ok := m.sessions[sessionID]
+	if !ok {
+		return
+	}
+	snap := info.promptSnapshot
+	if snap == nil {
+		snap = &SuggestSnapshot{At: at}
+	}
+	snap.Command = cmd
+	info.commandSnapshot = snap
+	info.promptSnapshot = nil
+}
+
+// CommandSnapshot returns the suggestions shown at the prompt the
+// session's last command ran from.
+func (m *SessionManager) CommandSnapshot(sessionID string) (SuggestSnapshot, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	info, ok := m.sessions[sessionID]
+	if !ok || info.commandSnapshot == nil {
+		return SuggestSnapshot{}, false
+	}
+	return *info.commandSnapshot, true
+}
+
+// SetTypoCorrections stores corrections of the command cmdID. They are
+// dropped when a later command has started since.
+func (m *SessionManager) SetTypoCorrections(sessionID, cmdID string, corrections []typo.Correction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if info, ok := m.sessions[sessionID]; ok && info.LastCmdID == cmdID {
+		info.TypoCorrections = corrections
+	}
+}
+
+// RecordExit notes how the session's latest command, normalized to norm,
+// exited and returns how many times in a row it has now failed; 0 when it
+// succeeded.
+func (m *SessionManager) RecordExit(sessionID, norm string, exitCode int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, ok := m.sessions[sessionID]
+	if !ok {
+		return 0
+	}
+	switch {
+	case exitCode == 0:
+		info.failedNorm, info.failStreak = "", 0
+	case norm == info.failedNorm:
+		info.failStreak++
+	default:
+		info.failedNorm, info.failStreak = norm, 1
+	}
+	return info.failStreak
+}
+
+// SetDiagnosisFixes stores the fixes of an automatic diagnosis of the
+// command cmdID. They are dropped when a later command has started since.
+func (m *SessionManager) SetDiagnosisFixes(sessionID, cmdID string, fixes []*pb.Suggestion) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if info, ok := m.sessions[sessionID]; ok && info.LastCmdID == cmdID {
+		info.DiagnosisFixes = fixes
+	}
+}
+
+// RecordLatency adds a latency of op (latencyOpIngest or latencyOpSuggest)
+// in milliseconds. When the p95 of the latest samples exceeds budgetMs,
+// the session is degraded for cooldown and the reason is returned with
+// true. Samples are ignored while the session is degraded.
+func (m *SessionManager) RecordLatency(sessionID, op string, ms int64, budgetMs int, cooldown time.Duration, now time.Time) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, ok := m.sessions[sessionID]
+	if !ok || (info.Degraded != "" && now.Before(info.DegradedUntil)) {
+		return "", false
+	}
+	samples := &info.ingestLatency
+	if op == latencyOpSuggest {
+		samples = &info.suggestLatency
+	}
+	samples.add(ms)
+	p95, ok := samples.p95()
+	if !ok || p95 <= int64(budgetMs) {
+		return "", false
+	}
+	info.Degraded = fmt.Sprintf("%s p95 %dms over the %dms budget", op, p95, budgetMs)
+	info.DegradedUntil = now.Add(cooldown)
+	info.ingestLatency, info.suggestLatency = latencySamples{}, latencySamples{}
+	return info.Degraded, true
+}
+
+// DegradedReason returns why the session is served fire-and-forget only,
+// or "" if it is not. A degradation that has expired is cleared, so the
+// session is measured afresh.
+func (m *SessionManager) DegradedReason(sessionID string, now time.Time) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, ok := m.sessions[sessionID]
+	if !ok || info.Degraded == "" {
+		return ""
+	}
+	if !now.Before(info.DegradedUntil) {
+		info.Degraded, info.DegradedUntil = "", time.Time{}
+		return ""
+	}
+	return info.Degraded
+}
+
+// DegradedCount returns how many sessions are served fire-and-forget only.
+func (m *SessionManager) DegradedCount(now time.Time) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n := 0
+	for _, info := range m.sessions {
+		if info.Degraded != "" && now.Before(info.DegradedUntil) {
+			n++
+		}
+	}
+	return n
+}
+
+// SetNote sets the session note. An empty note clears it.
+// Returns false if the session does not exist.
+func (m *SessionManager) SetNote(sessionID, note string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, ok := m.sessions[sessionID]
+	if !ok {
+		return false
+	}
+	info.Note = note
+	info.LastActivity = time.Now()
+	return true
+}
+
+// SetName sets the session name. An empty name clears it.
+// Returns false if the session does not exist.
+func (m *SessionManager) SetName(sessionID, name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, ok := m.sessions[sessionID]
+	if !ok {
+		return false
+	}
+	info.Name = name
+	info.LastActivity = time.Now()
+	return true
+}
+
+// Handoffs returns the SessionHandoff of every session that has one, by
+// session ID.
+func (m *SessionManager) Handoffs() map[string]*SessionHandoff {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	handoffs := make(map[string]*SessionHandoff)
+	for id, info := range m.sessions {
+		if len(info.recentUses) == 0 && info.promptSnapshot == nil && info.commandSnapshot == nil {
+			continue
+		}
+		handoffs[id] = &SessionHandoff{
+			RecentUses:      maps.Clone(info.recentUses),
+			PromptSnapshot:  copySnapshot(info.promptSnapshot),
+			CommandSnapshot: copySnapshot(info.commandSnapshot),
+		}
+	}
+	return handoffs
+}
+
+// copySnapshot returns a copy of snap that FreezePromptSnapshot cannot
+// change; the suggestions themselves are never changed once recorded.
+func copySnapshot(snap *SuggestSnapshot) *SuggestSnapshot {
+	if snap == nil {
+		return nil
+	}
+	snapCopy := *snap
+	return &snapCopy
+}
+
+// RestoreHandoffs puts handoffs saved by Handoffs back into the sessions
+// they belong to. Sessions that are not registered are skipped, and state
+// a session already has is kept.
+func (m *SessionManager) RestoreHandoffs(handoffs map[string]*SessionHandoff) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, h := range handoffs {
+		info, ok := m.sessions[id]
+		if !ok || h == nil {
+			continue
+		}
+		if info.recentUses == nil {
+			info.recentUses = h.RecentUses
+		}
+		if info.promptSnapshot == nil {
+			info.promptSnapshot = h.PromptSnapshot
+		}
+		if info.commandSnapshot == nil {
+			info.commandSnapshot = h.CommandSnapshot
+		}
+	}
+}
+
+// Restore registers previously saved sessions, e.g. after a daemon restart.
+// Sessions that are already registered are left untouched.
+func (m *SessionManager) Restore(infos []*SessionInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, info := range infos {
+		if info == nil || info.SessionID == "" {
+			continue
+		}
+		if _, ok := m.sessions[info.SessionID]; ok {
+			continue
+		}
+		infoCopy := *info
+		m.sessions[info.SessionID] = &infoCopy
+	}
+}
+
 // Exists checks if a session exists.
 func (m *SessionManager) Exists(sessionID string) bool {
 	m.mu.RLock()
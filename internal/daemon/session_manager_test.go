@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -198,7 +199,7 @@ func TestSessionManager_StashCommandInfo(t *testing.T) {
 
 	m.Start("sess-stash", "zsh", "darwin", "host1", "user1", "/tmp", now)
 
-	m.StashCommand("sess-stash", "cmd-1", "git status", "/home/user/repo", "myrepo", "/home/user/repo", "main")
+	m.StashCommand("sess-stash", "cmd-1", "git status", "/home/user/repo", "myrepo", "/home/user/repo", "main", false)
 
 	info, ok := m.Get("sess-stash")
 	if !ok {
@@ -222,6 +223,54 @@ func TestSessionManager_StashCommandInfo(t *testing.T) {
 	}
 }
 
+func TestSessionManager_EndCommand(t *testing.T) {
+	t.Parallel()
+
+	m := NewSessionManager()
+	m.Start("sess-end", "zsh", "linux", "", "", "/tmp", time.Now())
+	m.StashCommand("sess-end", "cmd-1", "make", "/src", "", "", "", false)
+
+	if _, ok := m.EndCommand("sess-end", "cmd-0"); ok {
+		t.Error("EndCommand() of an older command = true, want false")
+	}
+	if info, ok := m.EndCommand("sess-end", "cmd-1"); !ok || info.LastCmdRaw != "make" {
+		t.Errorf("EndCommand() = %+v, %v; want the stashed command", info, ok)
+	}
+	if _, ok := m.EndCommand("sess-end", "cmd-1"); ok {
+		t.Error("EndCommand() of a command that already ended = true, want false")
+	}
+
+	m.StashCommand("sess-end", "cmd-2", "make test", "/src", "", "", "", false)
+	if _, ok := m.EndCommand("sess-end", "cmd-2"); !ok {
+		t.Error("EndCommand() of the next command = false, want true")
+	}
+}
+
+func TestSessionManager_StashContext(t *testing.T) {
+	t.Parallel()
+
+	m := NewSessionManager()
+	m.Start("sess-ctx", "zsh", "linux", "", "", "/tmp", time.Now())
+
+	m.StashCommand("sess-ctx", "cmd-1", "pytest", "/repo", "", "", "", false)
+	m.StashContext("sess-ctx", "/home/user", map[string]string{"VIRTUAL_ENV": ".venv"})
+
+	info, _ := m.Get("sess-ctx")
+	if info.LastCmdPrevCWD != "/home/user" {
+		t.Errorf("expected LastCmdPrevCWD '/home/user', got %s", info.LastCmdPrevCWD)
+	}
+	if info.LastCmdEnv["VIRTUAL_ENV"] != ".venv" {
+		t.Errorf("expected VIRTUAL_ENV '.venv', got %v", info.LastCmdEnv)
+	}
+
+	// The next command starts without context.
+	m.StashCommand("sess-ctx", "cmd-2", "ls", "/repo", "", "", "", false)
+	info, _ = m.Get("sess-ctx")
+	if info.LastCmdPrevCWD != "" || info.LastCmdEnv != nil {
+		t.Errorf("expected context cleared, got %q %v", info.LastCmdPrevCWD, info.LastCmdEnv)
+	}
+}
+
 func TestSessionManager_StashOverwrites(t *testing.T) {
 	t.Parallel()
 
@@ -230,8 +279,8 @@ func TestSessionManager_StashOverwrites(t *testing.T) {
 
 	m.Start("sess-overwrite", "bash", "linux", "", "", "/tmp", now)
 
-	m.StashCommand("sess-overwrite", "cmd-1", "ls -la", "/first", "repo1", "/first", "dev")
-	m.StashCommand("sess-overwrite", "cmd-2", "cat file.txt", "/second", "repo2", "/second", "feature")
+	m.StashCommand("sess-overwrite", "cmd-1", "ls -la", "/first", "repo1", "/first", "dev", false)
+	m.StashCommand("sess-overwrite", "cmd-2", "cat file.txt", "/second", "repo2", "/second", "feature", false)
 
 	info, ok := m.Get("sess-overwrite")
 	if !ok {
@@ -262,7 +311,7 @@ func TestSessionManager_StashClearedOnEnd(t *testing.T) {
 	now := time.Now()
 
 	m.Start("sess-clear", "zsh", "darwin", "", "", "/tmp", now)
-	m.StashCommand("sess-clear", "cmd-1", "make build", "/project", "proj", "/project", "main")
+	m.StashCommand("sess-clear", "cmd-1", "make build", "/project", "proj", "/project", "main", false)
 
 	m.End("sess-clear")
 
@@ -278,7 +327,7 @@ func TestSessionManager_StashOnNonexistentSession(t *testing.T) {
 	m := NewSessionManager()
 
 	// Should not panic
-	m.StashCommand("nonexistent", "cmd-1", "echo hello", "/tmp", "repo", "/tmp", "main")
+	m.StashCommand("nonexistent", "cmd-1", "echo hello", "/tmp", "repo", "/tmp", "main", false)
 
 	_, ok := m.Get("nonexistent")
 	if ok {
@@ -286,6 +335,64 @@ func TestSessionManager_StashOnNonexistentSession(t *testing.T) {
 	}
 }
 
+func TestSessionManager_SetNote(t *testing.T) {
+	t.Parallel()
+
+	m := NewSessionManager()
+	m.Start("session-1", "zsh", "darwin", "host1", "user1", "/tmp", time.Now())
+
+	if !m.SetNote("session-1", "debugging auth") {
+		t.Fatal("SetNote returned false for existing session")
+	}
+	info, _ := m.Get("session-1")
+	if info.Note != "debugging auth" {
+		t.Errorf("expected note 'debugging auth', got %q", info.Note)
+	}
+
+	m.SetNote("session-1", "")
+	info, _ = m.Get("session-1")
+	if info.Note != "" {
+		t.Errorf("expected note to be cleared, got %q", info.Note)
+	}
+
+	if m.SetNote("nonexistent", "x") {
+		t.Error("SetNote returned true for nonexistent session")
+	}
+}
+
+func TestSessionManager_RecentUses(t *testing.T) {
+	t.Parallel()
+
+	m := NewSessionManager()
+	m.Start("session-1", "zsh", "darwin", "host1", "user1", "/tmp", time.Now())
+	base := time.UnixMilli(1_000_000)
+
+	m.RecordUse("session-1", "make build", base)
+	m.RecordUse("session-1", "make test", base.Add(5*time.Minute))
+	m.RecordUse("session-1", "make build", base.Add(8*time.Minute))
+	m.RecordUse("session-1", "make build", base.Add(time.Minute)) // older, ignored
+	m.RecordUse("nonexistent", "ls", base)
+
+	uses := m.RecentUses("session-1", base.Add(6*time.Minute))
+	if len(uses) != 1 || !uses["make build"].Equal(base.Add(8*time.Minute)) {
+		t.Errorf("RecentUses = %v, want only make build at +8m", uses)
+	}
+	if uses := m.RecentUses("nonexistent", base); uses != nil {
+		t.Errorf("RecentUses for nonexistent session = %v, want nil", uses)
+	}
+
+	for i := range maxRecentUses + 1 {
+		m.RecordUse("session-1", fmt.Sprintf("cmd-%d", i), base.Add(time.Hour+time.Duration(i)*time.Second))
+	}
+	uses = m.RecentUses("session-1", base)
+	if len(uses) != maxRecentUses {
+		t.Errorf("RecentUses kept %d commands, want %d", len(uses), maxRecentUses)
+	}
+	if _, ok := uses["make build"]; ok {
+		t.Error("expected the least recently run command to be dropped")
+	}
+}
+
 func TestSessionManager_Concurrent(t *testing.T) {
 	t.Parallel()
 
@@ -321,3 +428,100 @@ func TestSessionManager_Concurrent(t *testing.T) {
 		<-done
 	}
 }
+
+func TestSessionManager_Restore(t *testing.T) {
+	t.Parallel()
+
+	m := NewSessionManager()
+	m.Start("session-1", "zsh", "darwin", "host1", "user1", "/live", time.Now())
+
+	m.Restore([]*SessionInfo{
+		{SessionID: "session-1", CWD: "/saved"},
+		{SessionID: "session-2", Shell: "bash", Note: "release prep"},
+		{SessionID: ""},
+		nil,
+	})
+
+	if m.ActiveCount() != 2 {
+		t.Fatalf("expected 2 sessions, got %d", m.ActiveCount())
+	}
+	info, _ := m.Get("session-1")
+	if info.CWD != "/live" {
+		t.Errorf("restore should not overwrite a live session, got CWD %s", info.CWD)
+	}
+	info, ok := m.Get("session-2")
+	if !ok || info.Note != "release prep" {
+		t.Errorf("expected session-2 restored with note, got %+v", info)
+	}
+}
+
+func TestSessionManager_RecordExit(t *testing.T) {
+	m := NewSessionManager()
+	m.Start("s1", "zsh", "linux", "host", "user", "/tmp", time.Now())
+
+	steps := []struct {
+		norm     string
+		exitCode int
+		want     int
+	}{
+		{"make build", 2, 1},
+		{"make build", 2, 2},
+		{"make build", 2, 3},
+		{"make test", 1, 1},
+		{"make test", 0, 0},
+		{"make test", 1, 1},
+	}
+	for i, st := range steps {
+		if got := m.RecordExit("s1", st.norm, st.exitCode); got != st.want {
+			t.Errorf("step %d: RecordExit(%q, %d) = %d, want %d", i, st.norm, st.exitCode, got, st.want)
+		}
+	}
+	if got := m.RecordExit("missing", "make", 1); got != 0 {
+		t.Errorf("RecordExit for an unknown session = %d, want 0", got)
+	}
+}
+
+func TestSessionManager_RecordLatency(t *testing.T) {
+	m := NewSessionManager()
+	now := time.Now()
+	m.Start("s1", "zsh", "linux", "host", "user", "/tmp", now)
+
+	// An outlier among fast calls stays within budget.
+	for i := 0; i < latencyWindow; i++ {
+		ms := int64(20)
+		if i == latencyWindow-1 {
+			ms = 900
+		}
+		if _, degraded := m.RecordLatency("s1", latencyOpSuggest, ms, 100, time.Minute, now); degraded {
+			t.Fatalf("sample %d degraded the session", i)
+		}
+	}
+	// Slow ingest calls do not count against suggest.
+	for i := 0; i < latencyMinSamples-1; i++ {
+		if _, degraded := m.RecordLatency("s1", latencyOpIngest, 900, 100, time.Minute, now); degraded {
+			t.Fatalf("ingest sample %d degraded the session before latencyMinSamples", i)
+		}
+	}
+	reason, degraded := m.RecordLatency("s1", latencyOpIngest, 900, 100, time.Minute, now)
+	if !degraded || reason != "ingest p95 900ms over the 100ms budget" {
+		t.Fatalf("RecordLatency = %q, %v; want the session degraded", reason, degraded)
+	}
+	if got := m.DegradedReason("s1", now.Add(30*time.Second)); got != reason {
+		t.Errorf("DegradedReason during cooldown = %q, want %q", got, reason)
+	}
+	if got := m.DegradedCount(now); got != 1 {
+		t.Errorf("DegradedCount = %d, want 1", got)
+	}
+
+	// After the cooldown the session is measured afresh.
+	later := now.Add(time.Minute)
+	if got := m.DegradedReason("s1", later); got != "" {
+		t.Errorf("DegradedReason after cooldown = %q, want empty", got)
+	}
+	if _, degraded := m.RecordLatency("s1", latencyOpIngest, 900, 100, time.Minute, later); degraded {
+		t.Error("old samples survived the cooldown")
+	}
+	if got := m.DegradedCount(later); got != 0 {
+		t.Errorf("DegradedCount after cooldown = %d, want 0", got)
+	}
+}
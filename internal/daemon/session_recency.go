@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
+)
+
+const (
+	reasonSessionRecency = "session_recency"
+
+	// sessionRecencyPoolFactor is how many times maxResults suggestions
+	// are ranked when the session has recent commands, so that one ranked
+	// just below the cut can still be lifted into the results.
+	sessionRecencyPoolFactor = 2
+)
+
+// recentSessionUses returns the commands run in sessionID within the
+// session recency boost window, or nil when the boost is off.
+func (s *Server) recentSessionUses(sessionID string, boost config.SessionRecencyBoost, now time.Time) map[string]time.Time {
+	if boost.WindowMinutes <= 0 || boost.Strength <= 0 || sessionID == "" {
+		return nil
+	}
+	window := time.Duration(boost.WindowMinutes) * time.Minute
+	return s.sessionManager.RecentUses(sessionID, now.Add(-window))
+}
+
+// applySessionRecency re-ranks suggestions in favor of commands run
+// recently in the session and keeps at most maxResults. Ranks rather than
+// scores are boosted, since blended results mix scores of both rankers:
+// a command run just now moves up as if it ranked 1+strength times
+// higher, fading linearly to no boost at the end of the window.
+func applySessionRecency(
+	suggestions []*pb.Suggestion,
+	uses map[string]time.Time,
+	now time.Time,
+	boost config.SessionRecencyBoost,
+	maxResults int,
+) []*pb.Suggestion {
+	window := time.Duration(boost.WindowMinutes) * time.Minute
+	keys := make(map[*pb.Suggestion]float64, len(suggestions))
+	for i, sug := range suggestions {
+		keys[sug] = float64(i + 1)
+		last, ok := uses[strings.TrimSpace(sug.GetText())]
+		if !ok || window <= 0 {
+			continue
+		}
+		age := max(now.Sub(last), 0)
+		if age >= window {
+			continue
+		}
+		lift := boost.Strength * (1 - float64(age)/float64(window))
+		keys[sug] /= 1 + lift
+		sug.Reasons = append(sug.Reasons, &pb.SuggestionReason{
+			Type:         reasonSessionRecency,
+			Description:  fmt.Sprintf("run %s ago in this session", formatAgo(age.Milliseconds())),
+			Contribution: float32(lift),
+		})
+	}
+
+	ranked := append([]*pb.Suggestion(nil), suggestions...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return keys[ranked[i]] < keys[ranked[j]]
+	})
+	if len(ranked) > maxResults {
+		ranked = ranked[:maxResults]
+	}
+	return ranked
+}
@@ -0,0 +1,227 @@
+package daemon
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+const (
+	// shadowScoreTimeout bounds the background ranking of one request by
+	// both scorers.
+	shadowScoreTimeout = 2 * time.Second
+
+	// shadowRankingMaxAge is how long a shadow ranking waits for the next
+	// command of its session before it no longer counts.
+	shadowRankingMaxAge = 5 * time.Minute
+)
+
+// scorerComparison accumulates shadow-scoring outcomes: for each command
+// run, whether the V1 ranker's and the V2 scorer's suggestions for the
+// session's last Suggest request held it.
+type scorerComparison struct {
+	pending map[string]shadowRanking // By session ID
+	stats   scorerComparisonStats
+	mu      sync.Mutex
+}
+
+// shadowRanking is what both scorers suggested for one request.
+type shadowRanking struct {
+	at time.Time
+	v1 []string
+	v2 []string
+}
+
+// scorerComparisonStats are the comparison counts. They are carried
+// across daemon restarts.
+type scorerComparisonStats struct {
+	Since    time.Time  `json:"since"`
+	V1       scorerHits `json:"v1"`
+	V2       scorerHits `json:"v2"`
+	Commands int64      `json:"commands"`
+}
+
+// scorerHits counts how often one scorer suggested the command run next.
+type scorerHits struct {
+	ReciprocalRankSum float64 `json:"reciprocal_rank_sum"`
+	Hits              int64   `json:"hits"`
+	Top1              int64   `json:"top1"`
+	Exclusive         int64   `json:"exclusive"`
+}
+
+func newScorerComparison() *scorerComparison {
+	return &scorerComparison{pending: make(map[string]shadowRanking)}
+}
+
+// record keeps both scorers' suggestions for the session's latest request.
+func (c *scorerComparison) record(sessionID string, v1, v2 []string, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, r := range c.pending {
+		if at.Sub(r.at) > shadowRankingMaxAge {
+			delete(c.pending, id)
+		}
+	}
+	c.pending[sessionID] = shadowRanking{at: at, v1: v1, v2: v2}
+}
+
+// match counts cmd, run in the session at the given time, against the
+// session's pending shadow ranking. It returns the 1-based rank of cmd in
+// each scorer's suggestions, 0 when missing, and false when there was no
+// ranking to compare.
+func (c *scorerComparison) match(sessionID, cmd string, at time.Time) (v1Rank, v2Rank int, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, ok := c.pending[sessionID]
+	if !ok {
+		return 0, 0, false
+	}
+	delete(c.pending, sessionID)
+	if at.Sub(r.at) > shadowRankingMaxAge {
+		return 0, 0, false
+	}
+
+	v1Rank, v2Rank = suggestionRank(r.v1, cmd), suggestionRank(r.v2, cmd)
+	if c.stats.Since.IsZero() {
+		c.stats.Since = at
+	}
+	c.stats.Commands++
+	c.stats.V1.count(v1Rank, v2Rank)
+	c.stats.V2.count(v2Rank, v1Rank)
+	return v1Rank, v2Rank, true
+}
+
+// count adds a command the scorer ranked at rank, and the other scorer at
+// otherRank.
+func (h *scorerHits) count(rank, otherRank int) {
+	if rank == 0 {
+		return
+	}
+	h.Hits++
+	h.ReciprocalRankSum += 1 / float64(rank)
+	if rank == 1 {
+		h.Top1++
+	}
+	if otherRank == 0 {
+		h.Exclusive++
+	}
+}
+
+// snapshot returns the counts, and restarts counting when reset is set.
+func (c *scorerComparison) snapshot(reset bool) scorerComparisonStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	if reset {
+		c.stats = scorerComparisonStats{}
+	}
+	return stats
+}
+
+// restore adds counts saved by a previous daemon.
+func (c *scorerComparison) restore(saved *scorerComparisonStats) {
+	if saved == nil || saved.Commands == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stats.Since.IsZero() || saved.Since.Before(c.stats.Since) {
+		c.stats.Since = saved.Since
+	}
+	c.stats.Commands += saved.Commands
+	c.stats.V1.add(saved.V1)
+	c.stats.V2.add(saved.V2)
+}
+
+func (h *scorerHits) add(o scorerHits) {
+	h.Hits += o.Hits
+	h.Top1 += o.Top1
+	h.Exclusive += o.Exclusive
+	h.ReciprocalRankSum += o.ReciprocalRankSum
+}
+
+// suggestionRank returns the 1-based position of cmd in suggestions, or 0.
+func suggestionRank(suggestions []string, cmd string) int {
+	for i, text := range suggestions {
+		if text == cmd {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// shadowScore ranks req with both the V1 ranker and the V2 scorer,
+// whichever one serves suggestions, and keeps both rankings to compare with the
+// session's next command. It runs in the background so the served
+// request is not slowed down.
+func (s *Server) shadowScore(req *pb.SuggestRequest, maxResults int) {
+	if req.SessionId == "" || s.getV2Scorer() == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shadowScoreTimeout)
+	defer cancel()
+
+	v1 := suggestionTexts(s.suggestV1(ctx, req, maxResults))
+	v2 := suggestionTexts(s.suggestV2(ctx, req, maxResults))
+	s.scorerComparison.record(req.SessionId, v1, v2, time.Now())
+}
+
+// compareShadowRanking counts cmd, which just started in sessionID,
+// against the scorers' last suggestions there.
+func (s *Server) compareShadowRanking(sessionID, cmd string, at time.Time) {
+	v1Rank, v2Rank, ok := s.scorerComparison.match(sessionID, strings.TrimSpace(cmd), at)
+	if !ok {
+		return
+	}
+	s.logger.Info("scorer comparison",
+		"session_id", sessionID,
+		"v1_rank", v1Rank,
+		"v2_rank", v2Rank,
+	)
+}
+
+func suggestionTexts(resp *pb.SuggestResponse) []string {
+	if resp == nil {
+		return nil
+	}
+	texts := make([]string, 0, len(resp.Suggestions))
+	for _, sug := range resp.Suggestions {
+		texts = append(texts, strings.TrimSpace(sug.GetText()))
+	}
+	return texts
+}
+
+// CompareScorers reports how often the V1 ranker's and the V2 scorer's
+// suggestions held the command run next, as counted by shadow scoring.
+func (s *Server) CompareScorers(_ context.Context, req *pb.CompareScorersRequest) (*pb.CompareScorersResponse, error) {
+	s.touchActivity()
+
+	stats := s.scorerComparison.snapshot(req.Clear)
+	resp := &pb.CompareScorersResponse{
+		Enabled:       s.runtimeConfig().Suggestions.ShadowScoring,
+		ScorerVersion: s.scorerVersion,
+		Commands:      stats.Commands,
+		V1:            scorerHitsToProto(stats.V1),
+		V2:            scorerHitsToProto(stats.V2),
+	}
+	if !stats.Since.IsZero() {
+		resp.SinceUnixMs = stats.Since.UnixMilli()
+	}
+	return resp, nil
+}
+
+func scorerHitsToProto(h scorerHits) *pb.ScorerHits {
+	return &pb.ScorerHits{
+		Hits:              h.Hits,
+		Top1:              h.Top1,
+		Exclusive:         h.Exclusive,
+		ReciprocalRankSum: h.ReciprocalRankSum,
+	}
+}
@@ -0,0 +1,118 @@
+package daemon
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/suggest"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+)
+
+func TestScorerComparison(t *testing.T) {
+	t.Parallel()
+
+	c := newScorerComparison()
+	base := time.UnixMilli(1_000_000)
+
+	// No ranking recorded for the session: nothing to compare.
+	if _, _, ok := c.match("s1", "make build", base); ok {
+		t.Fatal("match without a ranking reported ok")
+	}
+
+	c.record("s1", []string{"make test", "make build"}, []string{"make build"}, base)
+	v1Rank, v2Rank, ok := c.match("s1", "make build", base.Add(time.Second))
+	if !ok || v1Rank != 2 || v2Rank != 1 {
+		t.Fatalf("match = %d, %d, %v; want 2, 1, true", v1Rank, v2Rank, ok)
+	}
+	// Each ranking is compared with one command only.
+	if _, _, ok := c.match("s1", "make build", base.Add(2*time.Second)); ok {
+		t.Error("ranking was compared twice")
+	}
+
+	c.record("s1", []string{"ls"}, nil, base)
+	c.match("s1", "ls", base.Add(time.Second))
+	c.record("s1", []string{"ls"}, []string{"ls"}, base)
+	if _, _, ok := c.match("s1", "ls", base.Add(shadowRankingMaxAge+time.Second)); ok {
+		t.Error("stale ranking was compared")
+	}
+
+	stats := c.snapshot(true)
+	if stats.Commands != 2 || !stats.Since.Equal(base.Add(time.Second)) {
+		t.Errorf("stats = %+v, want 2 commands since the first match", stats)
+	}
+	want1 := scorerHits{Hits: 2, Top1: 1, Exclusive: 1, ReciprocalRankSum: 1.5}
+	want2 := scorerHits{Hits: 1, Top1: 1, Exclusive: 0, ReciprocalRankSum: 1}
+	if stats.V1 != want1 || stats.V2 != want2 {
+		t.Errorf("v1 = %+v, v2 = %+v; want %+v, %+v", stats.V1, stats.V2, want1, want2)
+	}
+	if after := c.snapshot(false); after.Commands != 0 {
+		t.Errorf("counts after reset = %+v, want none", after)
+	}
+
+	c.restore(&stats)
+	if restored := c.snapshot(false); restored != stats {
+		t.Errorf("restored = %+v, want %+v", restored, stats)
+	}
+}
+
+func TestHandler_CompareScorers(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "v2_shadow_test.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+
+	// The V1 ranker suggests the command; the empty V2 database has nothing.
+	ranker := &mockRanker{suggestions: []suggest.Suggestion{
+		{Text: "git stash", Source: "history", Score: 0.9},
+		{Text: "git status", Source: "history", Score: 0.8},
+	}}
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), Ranker: ranker, V2DB: v2db})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "shadow-session", Cwd: "/tmp"})
+
+	server.shadowScore(&pb.SuggestRequest{SessionId: "shadow-session", Cwd: "/tmp", Buffer: "git st"}, 3)
+	_, _ = server.CommandStarted(ctx, &pb.CommandStartRequest{
+		SessionId: "shadow-session",
+		CommandId: "cmd-1",
+		Cwd:       "/tmp",
+		Command:   "git status",
+	})
+
+	resp, err := server.CompareScorers(ctx, &pb.CompareScorersRequest{Clear: true})
+	if err != nil {
+		t.Fatalf("CompareScorers failed: %v", err)
+	}
+	if resp.Commands != 1 || resp.SinceUnixMs == 0 {
+		t.Fatalf("CompareScorers = %+v, want one command compared", resp)
+	}
+	if resp.V1.Hits != 1 || resp.V1.Top1 != 0 || resp.V1.Exclusive != 1 || math.Abs(resp.V1.ReciprocalRankSum-0.5) > 1e-9 {
+		t.Errorf("v1 = %+v, want one exclusive hit at rank 2", resp.V1)
+	}
+	if resp.V2.Hits != 0 {
+		t.Errorf("v2 = %+v, want no hits", resp.V2)
+	}
+	if resp.Enabled {
+		t.Error("Enabled = true, want false with the default config")
+	}
+
+	resp, err = server.CompareScorers(ctx, &pb.CompareScorersRequest{})
+	if err != nil {
+		t.Fatalf("CompareScorers failed: %v", err)
+	}
+	if resp.Commands != 0 || resp.SinceUnixMs != 0 {
+		t.Errorf("CompareScorers after clear = %+v, want no counts", resp)
+	}
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/suggestions/feedback"
+)
+
+// recordPromptSnapshot remembers what Suggest showed at the session's
+// prompt, so explicit feedback and `clai why` can refer to it after a
+// command ran. The scoring details it keeps are left out of resp: shells
+// do not use them.
+func (s *Server) recordPromptSnapshot(req *pb.SuggestRequest, resp *pb.SuggestResponse) {
+	if resp == nil {
+		return
+	}
+	scoring := resp.Scoring
+	resp.Scoring = nil
+	var explained []*pb.Suggestion
+	if req.SessionId != "" {
+		explained = make([]*pb.Suggestion, len(resp.Suggestions))
+	}
+	for i, sug := range resp.Suggestions {
+		if explained != nil {
+			explained[i] = proto.Clone(sug).(*pb.Suggestion)
+		}
+		sug.Breakdown = nil
+	}
+	if len(explained) == 0 {
+		return
+	}
+	s.sessionManager.SetPromptSnapshot(req.SessionId, req.Buffer, explained, scoring, time.Now())
+}
+
+// freezePromptSnapshot ties the prompt's suggestions to cmd, which just
+// started in the session. Feedback and `clai why` leave the snapshot of the
+// command before them in place, since that is what they are about.
+func (s *Server) freezePromptSnapshot(sessionID, cmd string, at time.Time) {
+	if isSnapshotCommand(cmd) {
+		return
+	}
+	s.sessionManager.FreezePromptSnapshot(sessionID, strings.TrimSpace(cmd), at)
+}
+
+// isSnapshotCommand reports whether cmd runs `clai feedback` or `clai why`,
+// or the same through clai-shim.
+func isSnapshotCommand(cmd string) bool {
+	fields := strings.Fields(cmd)
+	if len(fields) < 2 || (fields[1] != "feedback" && fields[1] != "why") {
+		return false
+	}
+	name := filepath.Base(fields[0])
+	return name == "clai" || name == "clai-shim"
+}
+
+// SnapshotFeedback handles the SnapshotFeedback RPC.
+// It records a thumbs up or down for a suggestion shown at the prompt the
+// session's last command ran from: the suggestion the command matches, even
+// if it was typed by hand, otherwise the top suggestion. With last_command
+// set the command itself is rated as a suggestion for that prompt.
+func (s *Server) SnapshotFeedback(ctx context.Context, req *pb.SnapshotFeedbackRequest) (*pb.SnapshotFeedbackResponse, error) {
+	s.touchActivity()
+
+	snap, ok := s.sessionManager.CommandSnapshot(req.SessionId)
+	if !ok {
+		return snapshotFeedbackError("E_NO_SNAPSHOT", "no command has run in this session yet"), nil
+	}
+	target := snapshotFeedbackTarget(&snap, req.LastCommand)
+	if target == "" {
+		return snapshotFeedbackError("E_NO_SNAPSHOT",
+			"no suggestion was shown before the last command; rate the command itself with --last"), nil
+	}
+
+	action := feedback.ActionDismissed
+	if req.Helpful {
+		action = feedback.ActionAccepted
+	}
+	resp, err := s.handleRecordFeedback(ctx, &pb.RecordFeedbackRequest{
+		SessionId:     req.SessionId,
+		Action:        string(action),
+		SuggestedText: target,
+		ExecutedText:  snap.Command,
+		Prefix:        snap.Prefix,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Ok {
+		return &pb.SnapshotFeedbackResponse{Ok: false, Error: resp.Error}, nil
+	}
+	return &pb.SnapshotFeedbackResponse{Ok: true, SuggestedText: target, Action: string(action)}, nil
+}
+
+// snapshotFeedbackTarget returns the command feedback on snap is about.
+func snapshotFeedbackTarget(snap *SuggestSnapshot, lastCommand bool) string {
+	if lastCommand {
+		return snap.Command
+	}
+	if slices.Contains(snap.Suggestions, snap.Command) {
+		return snap.Command
+	}
+	if len(snap.Suggestions) > 0 {
+		return snap.Suggestions[0]
+	}
+	return ""
+}
+
+func snapshotFeedbackError(code, message string) *pb.SnapshotFeedbackResponse {
+	return &pb.SnapshotFeedbackResponse{
+		Ok:    false,
+		Error: &pb.ApiError{Code: code, Message: message},
+	}
+}
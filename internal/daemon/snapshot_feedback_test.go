@@ -0,0 +1,95 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/suggest"
+)
+
+func TestIsSnapshotCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"clai feedback up":                  true,
+		"/usr/local/bin/clai feedback down": true,
+		"clai-shim feedback up --last":      true,
+		"clai why":                          true,
+		"clai note feedback":                false,
+		"feedback up":                       false,
+		"clai":                              false,
+	}
+	for cmd, want := range tests {
+		if got := isSnapshotCommand(cmd); got != want {
+			t.Errorf("isSnapshotCommand(%q) = %v, want %v", cmd, got, want)
+		}
+	}
+}
+
+func TestHandler_SnapshotFeedback(t *testing.T) {
+	feedbackStore, cleanup := newFeedbackStoreWithDB(t)
+	defer cleanup()
+
+	ranker := &mockRanker{suggestions: []suggest.Suggestion{
+		{Text: "make test", Source: "history", Score: 0.9},
+		{Text: "make build", Source: "history", Score: 0.8},
+	}}
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), Ranker: ranker, FeedbackStore: feedbackStore})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	ctx := context.Background()
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "fb-session", Cwd: "/tmp"})
+	start := func(id, cmd string) {
+		_, _ = server.CommandStarted(ctx, &pb.CommandStartRequest{SessionId: "fb-session", CommandId: id, Cwd: "/tmp", Command: cmd})
+	}
+
+	resp, err := server.SnapshotFeedback(ctx, &pb.SnapshotFeedbackRequest{SessionId: "fb-session", Helpful: true})
+	if err != nil {
+		t.Fatalf("SnapshotFeedback failed: %v", err)
+	}
+	if resp.Ok || resp.Error.GetCode() != "E_NO_SNAPSHOT" {
+		t.Fatalf("SnapshotFeedback before any command = %+v, want E_NO_SNAPSHOT", resp)
+	}
+
+	// The second suggestion is typed by hand; the feedback command that
+	// follows does not replace the snapshot it rates.
+	if _, err := server.Suggest(ctx, &pb.SuggestRequest{SessionId: "fb-session", Buffer: "mak"}); err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	start("cmd-1", "make build")
+	start("cmd-2", "clai feedback up")
+
+	resp, err = server.SnapshotFeedback(ctx, &pb.SnapshotFeedbackRequest{SessionId: "fb-session", Helpful: true})
+	if err != nil {
+		t.Fatalf("SnapshotFeedback failed: %v", err)
+	}
+	if !resp.Ok || resp.SuggestedText != "make build" || resp.Action != "accepted" {
+		t.Fatalf("SnapshotFeedback = %+v, want make build accepted", resp)
+	}
+
+	// Without suggestions at its prompt only the command itself can be rated.
+	start("cmd-3", "ls -la")
+	resp, _ = server.SnapshotFeedback(ctx, &pb.SnapshotFeedbackRequest{SessionId: "fb-session"})
+	if resp.Ok {
+		t.Fatalf("SnapshotFeedback without suggestions = %+v, want an error", resp)
+	}
+	resp, _ = server.SnapshotFeedback(ctx, &pb.SnapshotFeedbackRequest{SessionId: "fb-session", LastCommand: true})
+	if !resp.Ok || resp.SuggestedText != "ls -la" || resp.Action != "dismissed" {
+		t.Fatalf("SnapshotFeedback --last = %+v, want ls -la dismissed", resp)
+	}
+
+	recs, err := feedbackStore.QueryFeedback(ctx, "fb-session", 10)
+	if err != nil {
+		t.Fatalf("QueryFeedback failed: %v", err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("recorded %d feedback entries, want 2", len(recs))
+	}
+	for _, rec := range recs {
+		if rec.SuggestedText == "make build" && (rec.PromptPrefix != "mak" || rec.ExecutedText != "make build") {
+			t.Errorf("make build feedback = %+v, want prefix mak and executed make build", rec)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd (SD_LISTEN_FDS_START).
+const listenFDsStart = 3
+
+// activatedListener returns the listener passed by systemd socket
+// activation, or nil if the daemon was not socket-activated. launchd's
+// socket check-in needs cgo, which release builds are made without, so the
+// LaunchAgent starts claid as a plain process instead.
+func activatedListener() (net.Listener, error) {
+	return systemdListener()
+}
+
+// systemdListener returns the socket passed via LISTEN_PID/LISTEN_FDS.
+// The activation environment is cleared so child processes don't inherit it.
+func systemdListener() (net.Listener, error) {
+	n, err := listenFDCount(os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getpid())
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	// claid only serves one socket; use the first one passed.
+	f := os.NewFile(uintptr(listenFDsStart), "claid-activated-socket")
+	defer f.Close()
+
+	listener, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use activated socket: %w", err)
+	}
+	return listener, nil
+}
+
+// listenFDCount returns how many sockets systemd passed to this process.
+// It returns 0 when the variables are unset or meant for another process.
+func listenFDCount(pidEnv, fdsEnv string, pid int) (int, error) {
+	if pidEnv == "" || fdsEnv == "" {
+		return 0, nil
+	}
+	listenPID, err := strconv.Atoi(pidEnv)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LISTEN_PID %q: %w", pidEnv, err)
+	}
+	if listenPID != pid {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(fdsEnv)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid LISTEN_FDS %q", fdsEnv)
+	}
+	return n, nil
+}
@@ -0,0 +1,49 @@
+package daemon
+
+import "testing"
+
+func TestListenFDCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		pidEnv  string
+		fdsEnv  string
+		pid     int
+		want    int
+		wantErr bool
+	}{
+		{name: "not activated", pid: 100},
+		{name: "matching pid", pidEnv: "100", fdsEnv: "1", pid: 100, want: 1},
+		{name: "other process", pidEnv: "200", fdsEnv: "1", pid: 100},
+		{name: "invalid pid", pidEnv: "abc", fdsEnv: "1", pid: 100, wantErr: true},
+		{name: "invalid fds", pidEnv: "100", fdsEnv: "-1", pid: 100, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := listenFDCount(tt.pidEnv, tt.fdsEnv, tt.pid)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listenFDCount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("listenFDCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActivatedListener_NotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	t.Setenv("LISTEN_FDS", "")
+
+	listener, err := activatedListener()
+	if err != nil {
+		t.Fatalf("activatedListener() error = %v", err)
+	}
+	if listener != nil {
+		t.Fatal("expected no listener when not socket-activated")
+	}
+}
@@ -0,0 +1,116 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/runger/clai/internal/config"
+)
+
+func TestApplySocketPermissions(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "clai.sock")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	gid := os.Getgid()
+	if err := applySocketPermissions(path, 0o660, strconv.Itoa(gid)); err != nil {
+		t.Fatalf("applySocketPermissions() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o660 {
+		t.Errorf("mode = %o, want 660", perm)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Gid) != gid {
+		t.Errorf("gid = %d, want %d", st.Gid, gid)
+	}
+}
+
+func TestApplySocketPermissions_UnknownGroup(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "clai.sock")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := applySocketPermissions(path, 0o660, "clai-no-such-group"); err == nil {
+		t.Error("applySocketPermissions() succeeded for an unknown group")
+	}
+}
+
+func TestListen_AppliesConfiguredSocketMode(t *testing.T) {
+	t.Parallel()
+
+	s := createTestServer(t)
+	cfg := config.DefaultConfig()
+	cfg.Daemon.SocketMode = "0640"
+	s.config = cfg
+
+	// Unix socket paths are length-limited; keep it short.
+	dir, err := os.MkdirTemp("", "clai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "s.sock")
+
+	listener, err := s.listen(socketPath)
+	if err != nil {
+		t.Fatalf("listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o640 {
+		t.Errorf("socket mode = %o, want 640", perm)
+	}
+}
+
+func TestResolveRunPaths_SocketGroupOpensDirectory(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "clai")
+	gid := os.Getgid()
+	cfg := config.DefaultConfig()
+	cfg.Daemon.SocketGroup = strconv.Itoa(gid)
+	serverCfg := &ServerConfig{Paths: &config.Paths{BaseDir: dir}, Config: cfg}
+
+	if _, err := resolveRunPaths(serverCfg); err != nil {
+		t.Fatalf("resolveRunPaths() error = %v", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o710 {
+		t.Errorf("directory mode = %o, want 710", perm)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Gid) != gid {
+		t.Errorf("directory gid = %d, want %d", st.Gid, gid)
+	}
+
+	// Without a group the directory is closed again.
+	cfg.Daemon.SocketGroup = ""
+	if _, err := resolveRunPaths(serverCfg); err != nil {
+		t.Fatalf("resolveRunPaths() error = %v", err)
+	}
+	if info, err = os.Stat(dir); err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		t.Errorf("directory mode = %o, want 700", perm)
+	}
+}
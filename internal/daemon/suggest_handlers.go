@@ -3,32 +3,49 @@ package daemon
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
 	pb "github.com/runger/clai/gen/clai/v1"
 	"github.com/runger/clai/internal/sanitize"
+	"github.com/runger/clai/internal/suggestions/clock"
 	"github.com/runger/clai/internal/suggestions/dirscope"
 	"github.com/runger/clai/internal/suggestions/explain"
+	"github.com/runger/clai/internal/suggestions/learning"
 	"github.com/runger/clai/internal/suggestions/normalize"
 	suggest2 "github.com/runger/clai/internal/suggestions/suggest"
 )
 
+// Scorers named in SuggestScoring.scorer.
+const (
+	scorerV1    = "v1"
+	scorerV2    = "v2"
+	scorerBlend = "blend" // V1 and V2 results interleaved
+)
+
 // suggestV2 generates suggestions using only the V2 scorer.
 // Returns nil response if the V2 scorer is not available (caller should fall back).
 func (s *Server) suggestV2(ctx context.Context, req *pb.SuggestRequest, maxResults int) *pb.SuggestResponse {
-	if s.v2Scorer == nil {
+	scorer := s.getV2Scorer()
+	if scorer == nil {
 		return nil
 	}
 
 	suggestCtx := s.buildV2SuggestContext(req)
 	if suggestCtx.NowMs == 0 {
-		suggestCtx.NowMs = time.Now().UnixMilli()
+		suggestCtx.NowMs = clock.NowMs(s.clock)
 	}
+	scorer, learned := s.learnedWeights(ctx, scorer, s.sessionRepoRoot(req.SessionId), suggestCtx.RepoKey, s.detectProjectTypes(req.SessionId, req.Cwd))
 
-	suggestions, err := s.v2Scorer.Suggest(ctx, &suggestCtx)
+	suggestions, err := scorer.Suggest(ctx, &suggestCtx)
 	if err != nil {
-		s.logger.Warn("V2 scorer failed", "error", err)
+		level := slog.LevelWarn
+		if ctx.Err() != nil {
+			level = slog.LevelDebug
+		}
+		s.logger.Log(ctx, level, "V2 scorer failed", "error", err)
 		return nil
 	}
 
@@ -36,15 +53,30 @@ func (s *Server) suggestV2(ctx context.Context, req *pb.SuggestRequest, maxResul
 		suggestions = suggestions[:maxResults]
 	}
 
-	return s.v2SuggestionsToProto(suggestions, suggestCtx.LastCmd, suggestCtx.NowMs)
+	resp := s.v2SuggestionsToProto(suggestions, suggestCtx.LastCmd, suggestCtx.NowMs)
+	resp.Scoring = &pb.SuggestScoring{
+		Scorer:  scorerV2,
+		Weights: v2WeightComponents(scorer.Weights()),
+		Learned: learnedLevelsToProto(learned),
+		RepoKey: suggestCtx.RepoKey,
+		LastCmd: suggestCtx.LastCmd,
+	}
+	return resp
 }
 
 // suggestV2Blend generates suggestions by running V1 and V2 concurrently
 // and merging the results. V2 results are interleaved with V1, deduplicated
 // by command text, with V2 suggestions taking priority on conflicts.
-// If V2 is unavailable, falls back to V1 only.
+// If V2 is unavailable, falls back to V1 only. Once the V1 history has been
+// migrated into V2, V2 alone is used and V1 only fills in when V2 has nothing.
 func (s *Server) suggestV2Blend(ctx context.Context, req *pb.SuggestRequest, maxResults int) *pb.SuggestResponse {
-	if s.v2Scorer == nil {
+	if s.getV2Scorer() == nil {
+		return s.suggestV1(ctx, req, maxResults)
+	}
+	if s.isV1Migrated() {
+		if v2Resp := s.suggestV2(ctx, req, maxResults); v2Resp != nil && len(v2Resp.Suggestions) > 0 {
+			return v2Resp
+		}
 		return s.suggestV1(ctx, req, maxResults)
 	}
 
@@ -72,10 +104,14 @@ func mergeResponses(v1, v2 *pb.SuggestResponse, maxResults int) *pb.SuggestRespo
 		return v2
 	}
 	merged := interleaveUniqueSuggestions(v2.Suggestions, v1.Suggestions, maxResults)
+	if v2.Scoring != nil {
+		v2.Scoring.Scorer = scorerBlend
+	}
 
 	return &pb.SuggestResponse{
 		Suggestions: merged,
 		FromCache:   v1.FromCache,
+		Scoring:     v2.Scoring,
 	}
 }
 
@@ -123,17 +159,70 @@ func (s *Server) buildV2SuggestContext(req *pb.SuggestRequest) suggest2.SuggestC
 	}
 
 	// Try to get the last command from session for transition scoring
+	var repoRoot string
 	if info, ok := s.sessionManager.Get(req.SessionId); ok {
 		// V2 scorer expects normalized command strings.
 		suggestCtx.LastCmd = normalize.NormalizeSimple(info.LastCmdRaw)
 		suggestCtx.RepoKey = info.LastGitRepo
-		// Directory scope key for cwd-scoped transitions/frequency (best-effort).
-		suggestCtx.DirScopeKey = dirscope.ComputeScopeKey(req.Cwd, info.LastGitRoot, dirscope.DefaultMaxDepth)
+		repoRoot = info.LastGitRoot
+	}
+
+	// Directory scopes of cwd and its parents within the repository, for
+	// cwd-scoped transitions/frequency weighted by depth.
+	if cfg := s.runtimeConfig().Suggestions; cfg.DirectoryScopingEnabled {
+		suggestCtx.DirScopes = dirscope.AncestorScopes(req.Cwd, repoRoot, cfg.DirectoryScopeMaxDepth, cfg.DirectoryAffinityWeights)
 	}
 
 	return suggestCtx
 }
 
+// detectProjectTypes returns the project types of cwd within the session's
+// repository, including those of the packages nested in it.
+func (s *Server) detectProjectTypes(sessionID, cwd string) []string {
+	var repoRoot string
+	if info, ok := s.sessionManager.Get(sessionID); ok {
+		repoRoot = info.LastGitRoot
+	}
+	return s.projectTypes.DetectInRepo(cwd, repoRoot)
+}
+
+// withLearnedWeights returns scorer ranking with the weight profiles learned
+// for the repository, the given project types and globally, blended over
+// the configured weights, which a trusted .clai/config.yaml at repoRoot
+// may override. scorer is returned unchanged when neither applies or the
+// profiles cannot be read.
+func (s *Server) withLearnedWeights(ctx context.Context, scorer *suggest2.Scorer, repoRoot, repoKey string, projectTypes []string) *suggest2.Scorer {
+	scorer, _ = s.learnedWeights(ctx, scorer, repoRoot, repoKey, projectTypes)
+	return scorer
+}
+
+// learnedWeights is withLearnedWeights that also returns the learned
+// profiles blended in, least specific first.
+func (s *Server) learnedWeights(ctx context.Context, scorer *suggest2.Scorer, repoRoot, repoKey string, projectTypes []string) (*suggest2.Scorer, []learning.ResolvedLevel) {
+	cfg, _ := s.repoConfig(repoRoot)
+	weights := cfg.Suggestions.Weights
+	if cfg != s.runtimeConfig() {
+		scorer = scorer.WithWeights(ScorerWeightsFromConfig(&weights))
+	}
+	if s.learning == nil {
+		return scorer, nil
+	}
+	base := learningWeightsFromConfig(&weights)
+	res, err := s.learning.Resolve(ctx, &base, learning.ScopeChain{
+		RepoKey:      repoKey,
+		ProjectTypes: projectTypes,
+	})
+	if err != nil {
+		s.logger.Debug("learned weights unavailable", "error", err)
+		return scorer, nil
+	}
+	if len(res.Levels) == 0 {
+		return scorer, nil
+	}
+	learned := configWeightsWithLearned(weights, &res.Weights)
+	return scorer.WithWeights(ScorerWeightsFromConfig(&learned)), res.Levels
+}
+
 // v2SuggestionsToProto converts V2 scorer suggestions to protobuf format.
 func (s *Server) v2SuggestionsToProto(suggestions []suggest2.Suggestion, prevCmd string, nowMs int64) *pb.SuggestResponse {
 	// Use default explain config; CLI can request more, but the picker UI
@@ -163,18 +252,89 @@ func v2SuggestionToProto(
 		Source:      v2SuggestionSource(sug),
 		Score:       sug.Score,
 		Risk:        v2SuggestionRisk(sug.Command),
+		RiskMessage: riskMessage(sug.Command),
 		CmdNorm:     sug.Command,
 		Confidence:  sug.Confidence,
 		Reasons:     v2SuggestionReasons(sug, why, nowMs),
+		Breakdown:   v2ScoreBreakdown(sug),
+
+		SuccessProbability: sug.SuccessProbability,
 	}
 }
 
+// v2ScoreBreakdown returns the features that moved the score of sug.
+func v2ScoreBreakdown(sug *suggest2.Suggestion) []*pb.ScoreComponent {
+	b := sug.ScoreBreakdown()
+	return scoreComponents([]*pb.ScoreComponent{
+		{Name: "repo_transition", Value: b.RepoTransition},
+		{Name: "global_transition", Value: b.GlobalTransition},
+		{Name: "dir_transition", Value: b.DirTransition},
+		{Name: "repo_frequency", Value: b.RepoFrequency},
+		{Name: "global_frequency", Value: b.GlobalFrequency},
+		{Name: "dir_frequency", Value: b.DirFrequency},
+		{Name: "project_task", Value: b.ProjectTask},
+		{Name: "workflow_boost", Value: b.WorkflowBoost},
+		{Name: "pipeline_confidence", Value: b.PipelineConf},
+		{Name: "recovery_boost", Value: b.RecoveryBoost},
+		{Name: "time_of_day", Value: b.TimeOfDay},
+		{Name: "day_of_week", Value: b.DayOfWeek},
+		{Name: "success", Value: b.Success},
+		{Name: "dangerous", Value: b.Dangerous},
+		{Name: "dismissal_penalty", Value: b.DismissalPenalty},
+	}, true)
+}
+
+// v2WeightComponents returns the feature weights the V2 scorer ranked with.
+func v2WeightComponents(w suggest2.Weights) []*pb.ScoreComponent {
+	return scoreComponents([]*pb.ScoreComponent{
+		{Name: "repo_transition", Value: w.RepoTransition},
+		{Name: "global_transition", Value: w.GlobalTransition},
+		{Name: "dir_transition", Value: w.DirTransition},
+		{Name: "repo_frequency", Value: w.RepoFrequency},
+		{Name: "global_frequency", Value: w.GlobalFrequency},
+		{Name: "dir_frequency", Value: w.DirFrequency},
+		{Name: "project_task", Value: w.ProjectTask},
+		{Name: "time_of_day", Value: w.TimeOfDay},
+		{Name: "day_of_week", Value: w.DayOfWeek},
+		{Name: "success", Value: w.Success},
+		{Name: "dangerous_penalty", Value: w.DangerousPenalty},
+	}, false)
+}
+
+// scoreComponents returns components, leaving out zeros when skipZero is set.
+func scoreComponents(components []*pb.ScoreComponent, skipZero bool) []*pb.ScoreComponent {
+	if !skipZero {
+		return components
+	}
+	kept := components[:0]
+	for _, c := range components {
+		if c.Value != 0 {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// learnedLevelsToProto converts the learned profiles blended into the
+// ranking weights.
+func learnedLevelsToProto(levels []learning.ResolvedLevel) []*pb.LearnedWeightLevel {
+	out := make([]*pb.LearnedWeightLevel, len(levels))
+	for i := range levels {
+		out[i] = &pb.LearnedWeightLevel{
+			Scope:       levels[i].Scope,
+			SampleCount: levels[i].SampleCount,
+			Trust:       levels[i].Trust,
+		}
+	}
+	return out
+}
+
 func v2SuggestionSource(sug *suggest2.Suggestion) string {
 	breakdown := sug.ScoreBreakdown()
 
 	cwdScore := breakdown.DirTransition + breakdown.DirFrequency
 	repoScore := breakdown.RepoTransition + breakdown.RepoFrequency + breakdown.ProjectTask
-	globalScore := breakdown.GlobalTransition + breakdown.GlobalFrequency
+	globalScore := breakdown.GlobalTransition + breakdown.GlobalFrequency + breakdown.TimeOfDay + breakdown.DayOfWeek
 	sessionScore := breakdown.WorkflowBoost + breakdown.PipelineConf + breakdown.RecoveryBoost
 
 	source := "global"
@@ -237,6 +397,12 @@ func v2SuggestionReasons(
 			Description: fmt.Sprintf("trans %d", tc),
 		})
 	}
+	if slices.Contains(sug.Reasons, suggest2.ReasonExploration) {
+		reasons = append(reasons, &pb.SuggestionReason{
+			Type:        suggest2.ReasonExploration,
+			Description: "shown to learn whether it helps",
+		})
+	}
 	return reasons
 }
 
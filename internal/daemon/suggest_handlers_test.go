@@ -5,12 +5,16 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 	"unsafe"
 
 	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/storage"
 	"github.com/runger/clai/internal/suggest"
+	"github.com/runger/clai/internal/suggestions/backfill"
 	suggestdb "github.com/runger/clai/internal/suggestions/db"
 	"github.com/runger/clai/internal/suggestions/explain"
+	"github.com/runger/clai/internal/suggestions/learning"
 	suggest2 "github.com/runger/clai/internal/suggestions/suggest"
 )
 
@@ -117,6 +121,49 @@ func TestScorerVersion_V2WorksWithDB(t *testing.T) {
 	}
 }
 
+// TestWithLearnedWeights verifies that learned profiles are blended over the
+// configured weights, and that the scorer is left alone without them.
+func TestWithLearnedWeights(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "suggestions_v2.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), V2DB: v2db})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	scorer := server.getV2Scorer()
+
+	if got := server.withLearnedWeights(ctx, scorer, "", "clai", nil); got != scorer {
+		t.Error("withLearnedWeights without profiles returned a different scorer")
+	}
+
+	// A fully trusted repo profile that doubles the transition weight.
+	w := learning.DefaultWeights()
+	w.Transition *= 2
+	if err := learning.NewStore(v2db.DB()).SaveWeights(ctx, "repo:clai", &w, learning.FullTrustSamples, 0.01); err != nil {
+		t.Fatal(err)
+	}
+
+	got := server.withLearnedWeights(ctx, scorer, "", "clai", nil).Weights()
+	if got.RepoTransition != 2*suggest2.DefaultWeightRepoTransition {
+		t.Errorf("RepoTransition = %v, want %v", got.RepoTransition, 2*suggest2.DefaultWeightRepoTransition)
+	}
+	if got.RepoFrequency != suggest2.DefaultWeightRepoFrequency {
+		t.Errorf("RepoFrequency = %v, want unchanged %v", got.RepoFrequency, suggest2.DefaultWeightRepoFrequency)
+	}
+	if other := server.withLearnedWeights(ctx, scorer, "", "other", nil); other != scorer {
+		t.Error("another repository's profile was applied")
+	}
+}
+
 // ============================================================================
 // Suggest handler tests (10 tests)
 // ============================================================================
@@ -328,6 +375,65 @@ func TestSuggest_V2_MergesResults(t *testing.T) {
 	}
 }
 
+// TestSuggest_V2_MigratedFallsBackToV1 verifies that once the V1 history has
+// been migrated, V1 still fills in when V2 has no suggestions.
+func TestSuggest_V2_MigratedFallsBackToV1(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "suggestions_v2.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+
+	v1, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer v1.Close()
+
+	ranker := &mockRanker{
+		suggestions: []suggest.Suggestion{{Text: "git commit", Source: "history", Score: 0.8}},
+	}
+	server, err := NewServer(&ServerConfig{
+		Store:         newMockStore(),
+		Ranker:        ranker,
+		V2DB:          v2db,
+		ScorerVersion: "v2",
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	if server.isV1Migrated() {
+		t.Fatal("expected isV1Migrated()=false before MigrateV1")
+	}
+
+	// The migration runs while the daemon is up and is picked up without a
+	// restart.
+	if _, err := backfill.MigrateV1(ctx, v2db.DB(), v1, time.Now().UnixMilli()); err != nil {
+		t.Fatalf("MigrateV1 failed: %v", err)
+	}
+	if !server.isV1Migrated() {
+		t.Fatal("expected isV1Migrated()=true after MigrateV1")
+	}
+
+	req := &pb.SuggestRequest{SessionId: "test-session", Cwd: "/tmp", MaxResults: 5}
+
+	// V2 has no history yet, so V1 fills in.
+	resp, err := server.Suggest(ctx, req)
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	if len(resp.Suggestions) != 1 || resp.Suggestions[0].Text != "git commit" {
+		t.Fatalf("suggestions = %v, want the V1 fallback", resp.Suggestions)
+	}
+
+}
+
 // TestMergeResponses_Deduplication verifies mergeResponses deduplicates by command text.
 func TestMergeResponses_Deduplication(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,38 @@
+package daemon
+
+import (
+	"context"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+// ExplainSuggestions handles the ExplainSuggestions RPC.
+// It returns the suggestions shown at the prompt the session's last command
+// ran from, with their reasons and score breakdown, and the weights they
+// were ranked with, for `clai why`.
+func (s *Server) ExplainSuggestions(_ context.Context, req *pb.ExplainSuggestionsRequest) (*pb.ExplainSuggestionsResponse, error) {
+	s.touchActivity()
+
+	snap, ok := s.sessionManager.CommandSnapshot(req.SessionId)
+	if !ok {
+		return explainSuggestionsError("E_NO_SNAPSHOT", "no command has run in this session yet"), nil
+	}
+	if len(snap.Explained) == 0 {
+		return explainSuggestionsError("E_NO_SNAPSHOT", "no suggestion was shown before the last command"), nil
+	}
+	return &pb.ExplainSuggestionsResponse{
+		Ok:            true,
+		Prefix:        snap.Prefix,
+		Command:       snap.Command,
+		ShownAtUnixMs: snap.At.UnixMilli(),
+		Suggestions:   snap.Explained,
+		Scoring:       snap.Scoring,
+	}, nil
+}
+
+func explainSuggestionsError(code, message string) *pb.ExplainSuggestionsResponse {
+	return &pb.ExplainSuggestionsResponse{
+		Ok:    false,
+		Error: &pb.ApiError{Code: code, Message: message},
+	}
+}
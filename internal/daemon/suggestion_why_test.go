@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/suggest"
+	suggest2 "github.com/runger/clai/internal/suggestions/suggest"
+)
+
+func TestHandler_ExplainSuggestions(t *testing.T) {
+	t.Parallel()
+
+	ranker := &mockRanker{suggestions: []suggest.Suggestion{
+		{Text: "make test", Source: "history", Score: 0.9, Reasons: []suggest.Reason{{Type: "prefix", Description: "matches mak", Contribution: 0.4}}},
+		{Text: "make build", Source: "history", Score: 0.8},
+	}}
+	server, err := NewServer(&ServerConfig{Store: newMockStore(), Ranker: ranker})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	ctx := context.Background()
+	_, _ = server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "why-session", Cwd: "/tmp"})
+	start := func(id, cmd string) {
+		_, _ = server.CommandStarted(ctx, &pb.CommandStartRequest{SessionId: "why-session", CommandId: id, Cwd: "/tmp", Command: cmd})
+	}
+
+	resp, err := server.ExplainSuggestions(ctx, &pb.ExplainSuggestionsRequest{SessionId: "why-session"})
+	if err != nil {
+		t.Fatalf("ExplainSuggestions failed: %v", err)
+	}
+	if resp.Ok || resp.Error.GetCode() != "E_NO_SNAPSHOT" {
+		t.Fatalf("ExplainSuggestions before any command = %+v, want E_NO_SNAPSHOT", resp)
+	}
+
+	suggested, err := server.Suggest(ctx, &pb.SuggestRequest{SessionId: "why-session", Buffer: "mak"})
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	if suggested.Scoring != nil {
+		t.Errorf("Suggest response scoring = %v, want it kept out of the response", suggested.Scoring)
+	}
+	// `clai why` itself does not replace the snapshot it explains.
+	start("cmd-1", "make build")
+	start("cmd-2", "clai why")
+
+	resp, err = server.ExplainSuggestions(ctx, &pb.ExplainSuggestionsRequest{SessionId: "why-session"})
+	if err != nil {
+		t.Fatalf("ExplainSuggestions failed: %v", err)
+	}
+	if !resp.Ok || resp.Prefix != "mak" || resp.Command != "make build" || len(resp.Suggestions) != 2 {
+		t.Fatalf("ExplainSuggestions = %+v, want the two suggestions for mak before make build", resp)
+	}
+	if resp.Scoring.GetScorer() != scorerV1 {
+		t.Errorf("scorer = %q, want %q", resp.Scoring.GetScorer(), scorerV1)
+	}
+	if r := resp.Suggestions[0].Reasons; len(r) == 0 || r[0].Type != "prefix" || r[0].Contribution != 0.4 {
+		t.Errorf("reasons of make test = %v, want the prefix reason first", r)
+	}
+
+	// Without suggestions at its prompt there is nothing to explain.
+	start("cmd-3", "ls -la")
+	resp, _ = server.ExplainSuggestions(ctx, &pb.ExplainSuggestionsRequest{SessionId: "why-session"})
+	if resp.Ok {
+		t.Fatalf("ExplainSuggestions without suggestions = %+v, want an error", resp)
+	}
+}
+
+func TestV2WeightComponents(t *testing.T) {
+	t.Parallel()
+
+	w := suggest2.DefaultWeights()
+	components := v2WeightComponents(w)
+	if len(components) != 11 {
+		t.Fatalf("got %d weights, want all 11", len(components))
+	}
+	if components[0].Name != "repo_transition" || components[0].Value != w.RepoTransition {
+		t.Errorf("first weight = %v, want repo_transition %v", components[0], w.RepoTransition)
+	}
+
+	kept := scoreComponents([]*pb.ScoreComponent{{Name: "a", Value: 1}, {Name: "b"}, {Name: "c", Value: -0.5}}, true)
+	if len(kept) != 2 || kept[0].Name != "a" || kept[1].Name != "c" {
+		t.Errorf("scoreComponents(skipZero) = %v, want a and c", kept)
+	}
+}
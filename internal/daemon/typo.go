@@ -0,0 +1,138 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/sanitize"
+	"github.com/runger/clai/internal/suggestions/discovery"
+	"github.com/runger/clai/internal/suggestions/typo"
+)
+
+const (
+	sourceTypo           = "typo"
+	reasonTypoCorrection = "typo_correction"
+
+	// typoCorrectionTimeout bounds the background correction lookup.
+	typoCorrectionTimeout = 2 * time.Second
+)
+
+// correctTypoAsync looks up corrections of the session's command cmdID,
+// which was not found, in the background and stashes them on the session
+// for Suggest and NextStep to propose.
+func (s *Server) correctTypoAsync(sessionID, cmdID string) {
+	if s.v2db == nil {
+		return
+	}
+	info, ok := s.sessionManager.Get(sessionID)
+	if !ok || info.LastCmdID != cmdID || info.LastCmdRaw == "" {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), typoCorrectionTimeout)
+		defer cancel()
+		corrections := s.correctTypo(ctx, info.LastCmdRaw, info.LastGitRepo)
+		s.sessionManager.SetTypoCorrections(sessionID, cmdID, corrections)
+	}()
+}
+
+// correctTypo returns corrections of failedCmd from the frequent commands
+// and the project tasks of repoKey, best first.
+func (s *Server) correctTypo(ctx context.Context, failedCmd, repoKey string) []typo.Correction {
+	db := s.v2db.ReadDB()
+	cfg := typo.DefaultCorrectorConfig()
+	cfg.Logger = s.logger
+	if tasks, err := discovery.NewService(db, discovery.DefaultOptions()); err != nil {
+		s.logger.Debug("typo correction: project tasks unavailable", "error", err)
+	} else {
+		defer tasks.Close()
+		cfg.Tasks = tasks
+	}
+
+	corrector, err := typo.NewCorrector(db, cfg)
+	if err != nil {
+		s.logger.Debug("typo correction: corrector unavailable", "error", err)
+		return nil
+	}
+	defer corrector.Close()
+
+	corrections, err := corrector.Correct(ctx, failedCmd, repoKey)
+	if err != nil {
+		s.logger.Debug("typo correction failed", "error", err)
+		return nil
+	}
+	return corrections
+}
+
+// typoCorrectionsFor returns the corrections stashed for the session's
+// last command that start with buffer.
+func (s *Server) typoCorrectionsFor(sessionID, buffer string) []typo.Correction {
+	info, ok := s.sessionManager.Get(sessionID)
+	if !ok {
+		return nil
+	}
+	var out []typo.Correction
+	for _, c := range info.TypoCorrections {
+		if strings.HasPrefix(c.Suggested, buffer) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// nextStepCorrections returns corrections of req's last command when it
+// was not found: the ones stashed by CommandEnded, or freshly looked up
+// when the lookup has not finished or the command is not the session's.
+func (s *Server) nextStepCorrections(ctx context.Context, req *pb.NextStepRequest) []typo.Correction {
+	if !typo.ShouldCorrect(int(req.LastExitCode)) || req.LastCommand == "" {
+		return nil
+	}
+	repoKey := ""
+	if info, ok := s.sessionManager.Get(req.SessionId); ok {
+		if info.LastCmdRaw == req.LastCommand && len(info.TypoCorrections) > 0 {
+			return info.TypoCorrections
+		}
+		repoKey = info.LastGitRepo
+	}
+	if s.v2db == nil {
+		return nil
+	}
+	return s.correctTypo(ctx, req.LastCommand, repoKey)
+}
+
+// withTypoCorrections puts corrections ahead of suggestions, dropping
+// duplicates and keeping at most maxResults.
+func withTypoCorrections(corrections []typo.Correction, suggestions []*pb.Suggestion, maxResults int) []*pb.Suggestion {
+	if len(corrections) == 0 {
+		return suggestions
+	}
+	pbCorrections := make([]*pb.Suggestion, len(corrections))
+	for i := range corrections {
+		pbCorrections[i] = typoCorrectionToProto(&corrections[i])
+	}
+	return prependSuggestions(pbCorrections, suggestions, maxResults)
+}
+
+func typoCorrectionToProto(c *typo.Correction) *pb.Suggestion {
+	risk := ""
+	if sanitize.IsDestructive(c.Suggested) {
+		risk = riskDestructive
+	}
+	return &pb.Suggestion{
+		Text:        c.Suggested,
+		Description: fmt.Sprintf("Did you mean this? %q was not found", c.Original),
+		Source:      sourceTypo,
+		Score:       c.Similarity,
+		Risk:        risk,
+		RiskMessage: riskMessage(c.Suggested),
+		Confidence:  c.Similarity,
+		Reasons: []*pb.SuggestionReason{{
+			Type:         reasonTypoCorrection,
+			Description:  fmt.Sprintf("corrects %q", c.Original),
+			Contribution: float32(c.Similarity),
+		}},
+	}
+}
@@ -737,3 +737,65 @@ func TestV2Integration_CommandEndedFeedsBatchWriter(t *testing.T) {
 		t.Error("expected at least one event written after batch writer flush")
 	}
 }
+
+// TestV2Integration_SessionStartSeedsColdStart verifies that a session
+// starting in a Go project seeds the curated commands into an empty V2
+// database, including the common commands of custom project types.
+func TestV2Integration_SessionStartSeedsColdStart(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+
+	v2db, err := suggestdb.Open(ctx, suggestdb.Options{
+		Path:     filepath.Join(tmpDir, "suggestions_v2.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open V2 database: %v", err)
+	}
+	defer v2db.Close()
+
+	projectDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module demo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Suggestions.ProjectTypes = []config.ProjectType{
+		{Name: "go", Markers: []string{"go.mod"}, CommonCommands: []string{"task lint"}},
+	}
+	server, err := NewServer(&ServerConfig{
+		Store:  newMockStore(),
+		V2DB:   v2db,
+		Config: cfg,
+		Logger: slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	if _, err := server.SessionStart(ctx, &pb.SessionStartRequest{SessionId: "cold", Cwd: projectDir}); err != nil {
+		t.Fatalf("SessionStart failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var n int
+		err := v2db.DB().QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM project_type_stat s
+			JOIN command_template t ON t.template_id = s.template_id
+			WHERE s.project_type = 'go' AND t.cmd_norm IN ('go mod tidy', 'task lint')
+		`).Scan(&n)
+		if err == nil && n == 2 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cold-start commands not seeded: n=%d err=%v", n, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/sanitize"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/workflow"
+)
+
+const (
+	sourceWorkflow       = "workflow"
+	reasonWorkflowPrefix = "workflow_prefix"
+
+	// kindWorkflow marks a suggestion that runs several commands; its steps
+	// are listed so the shell widget can expand them.
+	kindWorkflow = "workflow"
+
+	// maxWorkflowSuggestions caps the workflows offered per Suggest call.
+	maxWorkflowSuggestions = 1
+)
+
+// resolveWorkflowMiner returns the background miner that promotes recurring
+// command sequences to workflows, or nil without V2 or when workflow
+// detection is off.
+func resolveWorkflowMiner(v2db *suggestdb.DB, cfg *config.Config) *workflow.Miner {
+	if v2db == nil {
+		return nil
+	}
+	sugg := config.DefaultSuggestionsConfig()
+	if cfg != nil {
+		sugg = cfg.Suggestions
+	}
+	if !sugg.WorkflowDetectionEnabled {
+		return nil
+	}
+	minerCfg := workflow.DefaultMinerConfig()
+	minerCfg.MinSteps = sugg.WorkflowMinSteps
+	minerCfg.MaxSteps = sugg.WorkflowMaxSteps
+	minerCfg.MinOccurrences = sugg.WorkflowMinOccurrences
+	minerCfg.MaxGap = sugg.WorkflowMaxGap
+	minerCfg.MineIntervalMs = sugg.WorkflowMineIntervalMs
+	return workflow.NewMiner(v2db.DB(), minerCfg)
+}
+
+// workflowSuggestions returns the rest of the known workflows the session's
+// last commands started, as single suggestions that start with buffer.
+func (s *Server) workflowSuggestions(ctx context.Context, sessionID, buffer string) []*pb.Suggestion {
+	if s.v2db == nil || s.workflowMiner == nil || sessionID == "" {
+		return nil
+	}
+	minOccurrences := s.runtimeConfig().Suggestions.WorkflowMinOccurrences
+	conts, err := workflow.Continuations(ctx, s.v2db.ReadDB(), sessionID, minOccurrences)
+	if err != nil {
+		s.logger.Debug("workflow continuations failed", "error", err)
+		return nil
+	}
+	var out []*pb.Suggestion
+	for i := range conts {
+		sug := workflowToProto(&conts[i])
+		if !strings.HasPrefix(sug.Text, buffer) {
+			continue
+		}
+		out = append(out, sug)
+		if len(out) >= maxWorkflowSuggestions {
+			break
+		}
+	}
+	return out
+}
+
+func workflowToProto(c *workflow.Continuation) *pb.Suggestion {
+	text := strings.Join(c.Steps, " && ")
+	risk, message := "", ""
+	for _, step := range c.Steps {
+		if level, msg := sanitize.Classify(step); level == sanitize.RiskDestructive {
+			risk = riskDestructive
+			if message == "" {
+				message = msg
+			}
+		}
+	}
+	// Confidence grows with how much of the workflow has been matched.
+	confidence := float64(c.Matched) / float64(c.Matched+len(c.Steps))
+	return &pb.Suggestion{
+		Text:        text,
+		Description: "next: " + c.Summary(),
+		Source:      sourceWorkflow,
+		Score:       confidence,
+		Risk:        risk,
+		RiskMessage: message,
+		CmdNorm:     strings.Join(c.DisplayNames, " && "),
+		Confidence:  confidence,
+		Reasons: []*pb.SuggestionReason{{
+			Type:         reasonWorkflowPrefix,
+			Description:  fmt.Sprintf("continues a workflow run %d times", c.Pattern.OccurrenceCount),
+			Contribution: float32(confidence),
+		}},
+		Kind:  kindWorkflow,
+		Steps: c.Steps,
+	}
+}
+
+// prependSuggestions puts first ahead of suggestions, dropping duplicates
+// and keeping at most maxResults.
+func prependSuggestions(first, suggestions []*pb.Suggestion, maxResults int) []*pb.Suggestion {
+	if len(first) == 0 {
+		return suggestions
+	}
+	merged := make([]*pb.Suggestion, 0, maxResults)
+	seen := make(map[string]struct{}, maxResults)
+	for _, src := range [][]*pb.Suggestion{first, suggestions} {
+		for _, sug := range src {
+			if len(merged) >= maxResults {
+				return merged
+			}
+			if _, dup := seen[sug.Text]; dup {
+				continue
+			}
+			seen[sug.Text] = struct{}{}
+			merged = append(merged, sug)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,197 @@
+// Package dbcrypt provides optional encryption at rest for clai's SQLite
+// databases (state.db and suggestions_v2.db).
+//
+// Plaintext databases are opened with the modernc.org/sqlite driver as
+// before. Encrypted ones are opened with the ncruces SQLite driver through
+// its "xts" VFS, which encrypts every page, the WAL and temporary files with
+// AES-256-XTS. Both drivers speak the same SQL and file format, so the rest
+// of clai does not care which one a *sql.DB came from.
+package dbcrypt
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	_ "github.com/ncruces/go-sqlite3/driver"  // Registers the "sqlite3" driver
+	_ "github.com/ncruces/go-sqlite3/embed"   // SQLite build with FTS5, which the suggestions database needs
+	_ "github.com/ncruces/go-sqlite3/vfs/xts" // Registers the "xts" VFS
+	_ "modernc.org/sqlite"                    // Pure Go SQLite driver for plaintext databases
+)
+
+const (
+	plainDriver     = "sqlite"
+	encryptedDriver = "sqlite3"
+)
+
+// sectorSize is the unit the xts VFS encrypts in.
+const sectorSize = 512
+
+// sqliteHeader starts every plaintext SQLite database file.
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+var (
+	// ErrEncrypted is returned by Open when a database is encrypted but no
+	// key was configured.
+	ErrEncrypted = errors.New("database is encrypted")
+
+	// ErrWrongKey is returned by Open when a database is encrypted with
+	// a different key. It is kept distinct from SQLite's "file is not a
+	// database" so corruption recovery never replaces the database.
+	ErrWrongKey = errors.New("database key does not match")
+)
+
+// Open opens the SQLite database at path with the given URI parameters
+// (e.g. "_pragma=busy_timeout(5000)"). The driver follows the file: an
+// encrypted database needs key (ErrEncrypted without one, ErrWrongKey for
+// another one), and a plaintext database is opened as is, even with a key,
+// until Encrypt converts it. A new database is encrypted when key is set.
+func Open(path, params string, key []byte) (*sql.DB, error) {
+	dsn := "file:" + path + "?" + params
+
+	state, err := classify(path)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case state == filePlaintext || (state != fileEncrypted && key == nil):
+		return sql.Open(plainDriver, dsn)
+	case key == nil:
+		return nil, fmt.Errorf("%s: %w; set privacy.db_encryption to the key source it was created with", path, ErrEncrypted)
+	case state == fileEncrypted:
+		if err := checkKey(path, key); err != nil {
+			return nil, err
+		}
+	}
+	return sql.Open(encryptedDriver, encryptedDSN(dsn, key))
+}
+
+func encryptedDSN(dsn string, key []byte) string {
+	return dsn + "&vfs=xts&hexkey=" + hex.EncodeToString(key)
+}
+
+// checkKey reads the schema of the encrypted database at path, which fails
+// unless key decrypts it.
+func checkKey(path string, key []byte) error {
+	db, err := sql.Open(encryptedDriver, encryptedDSN("file:"+path+"?mode=ro", key))
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer db.Close()
+
+	var version int
+	if err := db.QueryRow(`PRAGMA schema_version`).Scan(&version); err != nil {
+		if strings.Contains(err.Error(), "not a database") {
+			return fmt.Errorf("%s: %w", path, ErrWrongKey)
+		}
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	return nil
+}
+
+// Encrypt converts the plaintext database at path to one encrypted with
+// key and reports whether it did; a missing or already encrypted database
+// is left alone. Nothing else may have the database open: the daemon calls
+// this at startup, before opening its stores.
+func Encrypt(path string, key []byte) (bool, error) {
+	state, err := classify(path)
+	if err != nil || state != filePlaintext {
+		return false, err
+	}
+	if err := encryptInPlace(path, key); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IsEncrypted reports whether the database at path is encrypted.
+func IsEncrypted(path string) (bool, error) {
+	state, err := classify(path)
+	return state == fileEncrypted, err
+}
+
+// fileState is what a database file looks like on disk.
+type fileState int
+
+const (
+	fileMissing   fileState = iota // No file, or an empty one
+	filePlaintext                  // Starts with the SQLite header
+	fileEncrypted                  // Whole encrypted sectors
+	fileUnknown                    // Neither; SQLite will call it corrupt
+)
+
+// classify tells plaintext and encrypted databases apart. Encrypted files
+// consist of whole 512-byte sectors, so a short or ragged file without the
+// SQLite header is garbage rather than an encrypted database, and is left
+// to corruption recovery.
+func classify(path string) (fileState, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: database path is from trusted config
+	if errors.Is(err, os.ErrNotExist) {
+		return fileMissing, nil
+	}
+	if err != nil {
+		return fileUnknown, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fileUnknown, err
+	}
+	if info.Size() == 0 {
+		return fileMissing, nil
+	}
+
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(f, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return fileUnknown, nil
+		}
+		return fileUnknown, err
+	}
+	switch {
+	case bytes.Equal(header, sqliteHeader):
+		return filePlaintext, nil
+	case info.Size()%sectorSize == 0:
+		return fileEncrypted, nil
+	default:
+		return fileUnknown, nil
+	}
+}
+
+// encryptInPlace rewrites the plaintext database at path as an encrypted
+// copy and swaps it in. The copy is written with VACUUM INTO, which also
+// folds in any pending WAL content.
+func encryptInPlace(path string, key []byte) error {
+	tmp := path + ".encrypting"
+	_ = os.Remove(tmp)
+
+	src, err := sql.Open(encryptedDriver, "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return fmt.Errorf("open %s for encryption: %w", path, err)
+	}
+	_, err = src.Exec(`VACUUM INTO ?`, encryptedDSN("file:"+tmp+"?mode=rwc", key))
+	closeErr := src.Close()
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("encrypt %s: %w", path, err)
+	}
+	if closeErr != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("close %s: %w", path, closeErr)
+	}
+
+	// The WAL and shared-memory files belong to the plaintext database.
+	_ = os.Remove(path + "-wal")
+	_ = os.Remove(path + "-shm")
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("replace %s with encrypted copy: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package dbcrypt
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testParams = "_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func mustExec(t *testing.T, db *sql.DB, query string) {
+	t.Helper()
+	if _, err := db.Exec(query); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+func countRows(t *testing.T, db *sql.DB, query string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+func TestOpen_NewDatabaseIsEncrypted(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "state.db")
+	key := testKey(1)
+
+	db, err := Open(path, testParams, key)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	mustExec(t, db, `CREATE TABLE command (text TEXT)`)
+	mustExec(t, db, `INSERT INTO command VALUES ('export TOKEN=hunter2')`)
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("hunter2")) {
+		t.Error("database file contains plaintext command")
+	}
+	if encrypted, err := IsEncrypted(path); err != nil || !encrypted {
+		t.Errorf("IsEncrypted() = %v, %v; want true", encrypted, err)
+	}
+
+	db, err = Open(path, testParams, key)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer db.Close()
+	if n := countRows(t, db, `SELECT COUNT(*) FROM command`); n != 1 {
+		t.Errorf("rows = %d, want 1", n)
+	}
+}
+
+func TestOpen_EncryptedNeedsMatchingKey(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "state.db")
+
+	db, err := Open(path, testParams, testKey(1))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	mustExec(t, db, `CREATE TABLE t (x INTEGER)`)
+	db.Close()
+
+	if _, err := Open(path, testParams, nil); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("Open() without key error = %v, want ErrEncrypted", err)
+	}
+	if _, err := Open(path, testParams, testKey(2)); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Open() with another key error = %v, want ErrWrongKey", err)
+	}
+}
+
+func TestEncrypt_ConvertsPlaintextDatabase(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "suggestions_v2.db")
+	key := testKey(3)
+
+	db, err := Open(path, testParams, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	mustExec(t, db, `CREATE TABLE command (text TEXT)`)
+	mustExec(t, db, `CREATE VIRTUAL TABLE command_fts USING fts5(text, tokenize='trigram')`)
+	mustExec(t, db, `INSERT INTO command VALUES ('kubectl get pods')`)
+	mustExec(t, db, `INSERT INTO command_fts VALUES ('kubectl get pods')`)
+	db.Close()
+
+	// A plaintext database opens as is until it is converted.
+	db, err = Open(path, testParams, key)
+	if err != nil {
+		t.Fatalf("Open() plaintext with key error = %v", err)
+	}
+	db.Close()
+
+	converted, err := Encrypt(path, key)
+	if err != nil || !converted {
+		t.Fatalf("Encrypt() = %v, %v; want true", converted, err)
+	}
+	if converted, err := Encrypt(path, key); err != nil || converted {
+		t.Errorf("second Encrypt() = %v, %v; want false", converted, err)
+	}
+	if _, err := os.Stat(path + ".encrypting"); !os.IsNotExist(err) {
+		t.Error("temporary copy left behind")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("kubectl")) {
+		t.Error("converted database contains plaintext command")
+	}
+
+	db, err = Open(path, testParams, key)
+	if err != nil {
+		t.Fatalf("Open() converted error = %v", err)
+	}
+	defer db.Close()
+	if n := countRows(t, db, `SELECT COUNT(*) FROM command`); n != 1 {
+		t.Errorf("rows = %d, want 1", n)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM command_fts WHERE command_fts MATCH 'get p'`); n != 1 {
+		t.Errorf("fts matches = %d, want 1", n)
+	}
+}
+
+func TestEncrypt_MissingDatabase(t *testing.T) {
+	t.Parallel()
+
+	converted, err := Encrypt(filepath.Join(t.TempDir(), "missing.db"), testKey(1))
+	if err != nil || converted {
+		t.Errorf("Encrypt() = %v, %v; want false, nil", converted, err)
+	}
+}
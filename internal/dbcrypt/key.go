@@ -0,0 +1,236 @@
+package dbcrypt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/runger/clai/internal/config"
+)
+
+// KeySize is the database key length in bytes. AES-256-XTS uses two
+// 256-bit keys.
+const KeySize = 64
+
+// Key sources for privacy.db_encryption.
+const (
+	SourceOff      = "off"
+	SourceKeychain = "keychain"
+	SourceFile     = "file"
+)
+
+// keychainService is the service name clai's key is stored under.
+const keychainService = "clai"
+
+// keychainTimeout bounds a call to the OS keychain tool, which may prompt.
+const keychainTimeout = 30 * time.Second
+
+// errKeyNotFound is returned by keychain lookups when no key is stored.
+var errKeyNotFound = errors.New("no key in keychain")
+
+// runCommand runs an external command with stdin and returns its stdout.
+// Tests replace it.
+var runCommand = func(ctx context.Context, stdin, name string, args ...string) (string, []byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.Stderr, err
+	}
+	return string(out), nil, err
+}
+
+// LoadKey returns the database key selected by privacy.db_encryption, or
+// nil when encryption is off. A key that does not exist yet is generated
+// and stored, so enabling encryption needs no setup.
+func LoadKey(cfg *config.Config, paths *config.Paths) ([]byte, error) {
+	switch cfg.Privacy.DBEncryption {
+	case "", SourceOff:
+		return nil, nil
+	case SourceFile:
+		path := cfg.Privacy.DBKeyFile
+		if path == "" {
+			path = paths.DBKeyFile()
+		}
+		return loadKeyFile(path)
+	case SourceKeychain:
+		return loadKeychainKey(keychainAccount())
+	default:
+		return nil, fmt.Errorf("unknown privacy.db_encryption: %s", cfg.Privacy.DBEncryption)
+	}
+}
+
+// loadKeyFile reads a hex-encoded key from path, creating the file with a
+// new key if it does not exist. The file must not be readable by others.
+func loadKeyFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return createKeyFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("key file %s is accessible by other users (mode %04o); run: chmod 600 %s",
+			path, info.Mode().Perm(), path)
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // G304: key file path is from trusted config
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	key, err := decodeKey(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("key file %s: %w", path, err)
+	}
+	return key, nil
+}
+
+func createKeyFile(path string) ([]byte, error) {
+	key := newKey()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create key file directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec // G304: key file path is from trusted config
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key file: %w", err)
+	}
+	if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	return key, nil
+}
+
+// loadKeychainKey reads the key for account from the OS keychain, storing
+// a new key if there is none.
+func loadKeychainKey(account string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keychainTimeout)
+	defer cancel()
+
+	secret, err := keychainGet(ctx, account)
+	if errors.Is(err, errKeyNotFound) {
+		key := newKey()
+		if err := keychainSet(ctx, account, hex.EncodeToString(key)); err != nil {
+			return nil, fmt.Errorf("failed to store key in keychain: %w", err)
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key from keychain: %w", err)
+	}
+
+	key, err := decodeKey(secret)
+	if err != nil {
+		return nil, fmt.Errorf("keychain entry %s/%s: %w", keychainService, account, err)
+	}
+	return key, nil
+}
+
+// keychainAccount names the keychain entry; each CLAI_PROFILE has its own.
+func keychainAccount() string {
+	if profile := config.Profile(); profile != "" {
+		return "database-" + profile
+	}
+	return "database"
+}
+
+// keychainGet looks up a secret with the platform's keychain tool: the
+// macOS Keychain via security(1), or the Secret Service (GNOME Keyring,
+// KWallet) via secret-tool(1) elsewhere.
+func keychainGet(ctx context.Context, account string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, stderr, err := runCommand(ctx, "", "security", "find-generic-password",
+			"-s", keychainService, "-a", account, "-w")
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			// security exits 44 when no item matches.
+			return "", errKeyNotFound
+		}
+		if err != nil {
+			return "", keychainToolError(err, stderr)
+		}
+		return strings.TrimSpace(out), nil
+	case "windows":
+		return "", errors.New("the keychain is not supported on Windows; use privacy.db_encryption=file")
+	default:
+		out, stderr, err := runCommand(ctx, "", "secret-tool", "lookup",
+			"service", keychainService, "account", account)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(strings.TrimSpace(string(stderr))) == 0 {
+			// secret-tool exits 1 without a message when nothing matches.
+			return "", errKeyNotFound
+		}
+		if err != nil {
+			return "", keychainToolError(err, stderr)
+		}
+		if out == "" {
+			return "", errKeyNotFound
+		}
+		return strings.TrimSpace(out), nil
+	}
+}
+
+// keychainSet stores a secret. The secret is passed on stdin so it never
+// shows up in a process listing.
+func keychainSet(ctx context.Context, account, secret string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		cmd := fmt.Sprintf("add-generic-password -U -s %s -a %s -l %q -w %s\n",
+			keychainService, account, "clai database key", secret)
+		_, stderr, err := runCommand(ctx, cmd, "security", "-i")
+		if err != nil {
+			return keychainToolError(err, stderr)
+		}
+		return nil
+	case "windows":
+		return errors.New("the keychain is not supported on Windows; use privacy.db_encryption=file")
+	default:
+		_, stderr, err := runCommand(ctx, secret, "secret-tool", "store",
+			"--label=clai database key", "service", keychainService, "account", account)
+		if err != nil {
+			return keychainToolError(err, stderr)
+		}
+		return nil
+	}
+}
+
+func keychainToolError(err error, stderr []byte) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%w (install libsecret-tools, or use privacy.db_encryption=file)", err)
+	}
+	if msg := strings.TrimSpace(string(stderr)); msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
+
+func newKey() []byte {
+	key := make([]byte, KeySize)
+	_, _ = rand.Read(key) // crypto/rand.Read never returns an error
+	return key
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("key is not hex-encoded")
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key is %d bytes, want %d", len(key), KeySize)
+	}
+	return key, nil
+}
@@ -0,0 +1,119 @@
+package dbcrypt
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/runger/clai/internal/config"
+)
+
+func TestLoadKey_Off(t *testing.T) {
+	t.Parallel()
+
+	key, err := LoadKey(config.DefaultConfig(), &config.Paths{BaseDir: t.TempDir()})
+	if err != nil || key != nil {
+		t.Errorf("LoadKey() = %x, %v; want nil, nil", key, err)
+	}
+}
+
+func TestLoadKey_FileCreatesKeyOnce(t *testing.T) {
+	t.Parallel()
+	paths := &config.Paths{BaseDir: t.TempDir()}
+	cfg := config.DefaultConfig()
+	cfg.Privacy.DBEncryption = SourceFile
+
+	key, err := LoadKey(cfg, paths)
+	if err != nil {
+		t.Fatalf("LoadKey() error = %v", err)
+	}
+	if len(key) != KeySize {
+		t.Fatalf("key is %d bytes, want %d", len(key), KeySize)
+	}
+
+	info, err := os.Stat(paths.DBKeyFile())
+	if err != nil {
+		t.Fatalf("key file not created: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("key file mode = %04o, want 0600", info.Mode().Perm())
+	}
+
+	again, err := LoadKey(cfg, paths)
+	if err != nil {
+		t.Fatalf("second LoadKey() error = %v", err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Error("second LoadKey() returned a different key")
+	}
+}
+
+func TestLoadKeyFile_Rejects(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	short := filepath.Join(dir, "short.key")
+	if err := os.WriteFile(short, []byte("abcd\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadKeyFile(short); err == nil || !strings.Contains(err.Error(), "2 bytes") {
+		t.Errorf("loadKeyFile(short) error = %v, want a length error", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	open := filepath.Join(dir, "open.key")
+	if err := os.WriteFile(open, []byte(strings.Repeat("ab", KeySize)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadKeyFile(open); err == nil || !strings.Contains(err.Error(), "chmod 600") {
+		t.Errorf("loadKeyFile(0644) error = %v, want a permissions error", err)
+	}
+}
+
+func TestLoadKeychainKey_SecretService(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("exercises the secret-tool backend")
+	}
+
+	stored := map[string]string{}
+	var calls []string
+	orig := runCommand
+	runCommand = func(_ context.Context, stdin, name string, args ...string) (string, []byte, error) {
+		calls = append(calls, name+" "+args[0])
+		account := args[len(args)-1]
+		switch args[0] {
+		case "lookup":
+			return stored[account], nil, nil
+		case "store":
+			stored[account] = stdin
+		}
+		return "", nil, nil
+	}
+	t.Cleanup(func() { runCommand = orig })
+
+	key, err := loadKeychainKey("database-test")
+	if err != nil {
+		t.Fatalf("loadKeychainKey() error = %v", err)
+	}
+	if len(key) != KeySize {
+		t.Fatalf("key is %d bytes, want %d", len(key), KeySize)
+	}
+	again, err := loadKeychainKey("database-test")
+	if err != nil {
+		t.Fatalf("second loadKeychainKey() error = %v", err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Error("second loadKeychainKey() returned a different key")
+	}
+
+	want := []string{"secret-tool lookup", "secret-tool store", "secret-tool lookup"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
@@ -0,0 +1,258 @@
+// Package dbrepair detects corrupt SQLite databases and salvages what it
+// can from them. It is shared by clai's two stores (state.db and
+// suggestions_v2.db), which both move a corrupt file aside, start over with
+// a fresh schema and copy readable rows across from the damaged copy.
+package dbrepair
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// copyBatchSize is how many rows CopyRows reads and writes at a time.
+	copyBatchSize = 500
+
+	// skipRows is how far past the last good rowid CopyRows jumps after a
+	// read fails, to get beyond the damaged page.
+	skipRows = 1000
+
+	// maxReadFailures bounds how many failed reads CopyRows tolerates in a
+	// row before giving up on a table.
+	maxReadFailures = 16
+)
+
+// ErrCorrupt is returned by QuickCheck when SQLite finds damage.
+var ErrCorrupt = errors.New("database is corrupt")
+
+// corruptionMessages are SQLite error messages that mean the file itself
+// is damaged, as opposed to being locked, read-only or out of space.
+var corruptionMessages = []string{
+	"database disk image is malformed",
+	"file is not a database",
+	"file is encrypted or is not a database",
+	"disk i/o error",
+	"sqlite_corrupt",
+	"sqlite3: corrupt",
+	"sqlite_notadb",
+	"not a database",
+}
+
+// IsCorruption reports whether err indicates a damaged database file
+// (SQLITE_CORRUPT, SQLITE_NOTADB and the messages that go with them).
+func IsCorruption(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrCorrupt) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, cm := range corruptionMessages {
+		if strings.Contains(msg, cm) {
+			return true
+		}
+	}
+	return false
+}
+
+// QuickCheck runs PRAGMA quick_check, which verifies the b-tree structure
+// of every table and index without cross-checking index contents, and is
+// cheap enough to run at every startup. It returns nil for a healthy
+// database and ErrCorrupt, with SQLite's findings, for a damaged one.
+func QuickCheck(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, "PRAGMA quick_check")
+	if err != nil {
+		return fmt.Errorf("quick check failed: %w", err)
+	}
+	defer rows.Close()
+
+	var results []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return fmt.Errorf("quick check failed: %w", err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("quick check failed: %w", err)
+	}
+	if len(results) == 1 && results[0] == "ok" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrCorrupt, strings.Join(results, "; "))
+}
+
+// MoveAside renames the database at path and its WAL and shared-memory
+// files to <name>.corrupt.<unix time>, and returns the new path of the main
+// file ("" if there was none).
+func MoveAside(path string) (string, error) {
+	suffix := fmt.Sprintf(".corrupt.%d", time.Now().Unix())
+
+	var backup string
+	for _, f := range []string{path, path + "-wal", path + "-shm"} {
+		if _, err := os.Stat(f); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Rename(f, f+suffix); err != nil {
+			return "", fmt.Errorf("failed to rotate %s: %w", f, err)
+		}
+		if f == path {
+			backup = f + suffix
+		}
+	}
+	return backup, nil
+}
+
+// CopyResult reports what CopyRows managed to salvage.
+type CopyResult struct {
+	Copied     int // Rows written to the destination
+	Unreadable int // Reads that failed on damaged pages; each skips a range of rows
+	Rejected   int // Rows the destination refused (constraint violations)
+}
+
+// CopyRows copies the rows of table matching where (an SQL condition, or ""
+// for all rows) from src, a damaged database, into the same table in dst,
+// in rowid order. Columns are taken from dst's schema. A read that fails on
+// a corrupt page skips ahead and carries on, so one bad page loses the rows
+// on it rather than the rest of the table. An error is returned when src's
+// schema cannot be read at all, and for problems that are not corruption,
+// such as a missing column in src or a failure writing to dst.
+func CopyRows(ctx context.Context, src, dst *sql.DB, table, where string) (CopyResult, error) {
+	var res CopyResult
+	columns, err := tableColumns(ctx, dst, table)
+	if err != nil {
+		return res, err
+	}
+	var tables int
+	if err := src.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master").Scan(&tables); err != nil {
+		return res, fmt.Errorf("read schema: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT rowid, %s FROM %s WHERE rowid > ?", strings.Join(columns, ", "), table)
+	if where != "" {
+		query += " AND (" + where + ")"
+	}
+	query += " ORDER BY rowid LIMIT ?"
+	insert := fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (?%s)",
+		table, strings.Join(columns, ", "), strings.Repeat(", ?", len(columns)-1))
+
+	var afterRowID int64
+	failures := 0
+	for failures < maxReadFailures {
+		batch, lastRowID, readErr := readBatch(ctx, src, query, afterRowID, len(columns))
+		if readErr != nil && !IsCorruption(readErr) {
+			return res, fmt.Errorf("read %s: %w", table, readErr)
+		}
+		if err := writeBatch(ctx, dst, insert, batch, &res); err != nil {
+			return res, fmt.Errorf("write %s: %w", table, err)
+		}
+		if lastRowID > afterRowID {
+			afterRowID = lastRowID
+		}
+
+		switch {
+		case readErr != nil:
+			res.Unreadable++
+			failures++
+			afterRowID += skipRows
+		case len(batch) < copyBatchSize:
+			return res, nil
+		default:
+			failures = 0
+		}
+	}
+	return res, nil
+}
+
+func tableColumns(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("read columns of %s: %w", table, err)
+		}
+		columns = append(columns, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read columns of %s: %w", table, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s does not exist", table)
+	}
+	return columns, nil
+}
+
+// readBatch reads up to copyBatchSize rows after afterRowID. On a read
+// error it returns the rows read before it, along with the error.
+func readBatch(ctx context.Context, src *sql.DB, query string, afterRowID int64, ncols int) ([][]any, int64, error) {
+	rows, err := src.QueryContext(ctx, query, afterRowID, copyBatchSize)
+	if err != nil {
+		return nil, afterRowID, err
+	}
+	defer rows.Close()
+
+	var batch [][]any
+	lastRowID := afterRowID
+	for rows.Next() {
+		var rowID int64
+		values := make([]any, ncols)
+		dest := make([]any, ncols+1)
+		dest[0] = &rowID
+		for i := range values {
+			dest[i+1] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return batch, lastRowID, err
+		}
+		batch = append(batch, values)
+		lastRowID = rowID
+	}
+	return batch, lastRowID, rows.Err()
+}
+
+func writeBatch(ctx context.Context, dst *sql.DB, insert string, batch [][]any, res *CopyResult) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	tx, err := dst.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // best-effort rollback on error
+
+	stmt, err := tx.PrepareContext(ctx, insert)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, values := range batch {
+		result, err := stmt.ExecContext(ctx, values...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			res.Rejected++
+			continue
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			res.Copied++
+		} else {
+			res.Rejected++
+		}
+	}
+	return tx.Commit()
+}
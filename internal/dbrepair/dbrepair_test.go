@@ -0,0 +1,154 @@
+package dbrepair
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func mustExec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+// seedCorruptDB writes a database with n rows in items and then overwrites
+// the root page of its index, which quick_check notices but which leaves
+// the table itself readable.
+func seedCorruptDB(t *testing.T, n int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "items.db")
+	db := openTestDB(t, path)
+	mustExec(t, db, `CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`)
+	mustExec(t, db, `CREATE INDEX idx_items_name ON items(name)`)
+	for i := 1; i <= n; i++ {
+		mustExec(t, db, `INSERT INTO items (id, name) VALUES (?, ?)`, i, fmt.Sprintf("item-%d", i))
+	}
+	var root, pageSize int64
+	if err := db.QueryRow(`SELECT rootpage FROM sqlite_master WHERE name = 'idx_items_name'`).Scan(&root); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteAt(bytes.Repeat([]byte{0xAB}, int(pageSize)), (root-1)*pageSize); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestQuickCheck(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	healthy := openTestDB(t, filepath.Join(t.TempDir(), "ok.db"))
+	mustExec(t, healthy, `CREATE TABLE items (id INTEGER PRIMARY KEY)`)
+	if err := QuickCheck(ctx, healthy); err != nil {
+		t.Errorf("QuickCheck() on healthy DB = %v", err)
+	}
+
+	corrupt := openTestDB(t, seedCorruptDB(t, 50))
+	err := QuickCheck(ctx, corrupt)
+	if !IsCorruption(err) {
+		t.Errorf("QuickCheck() on corrupt DB = %v, want corruption", err)
+	}
+}
+
+func TestIsCorruption(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{ErrCorrupt, true},
+		{fmt.Errorf("open: %w", ErrCorrupt), true},
+		{errors.New("database disk image is malformed (11)"), true},
+		{errors.New("sqlite3: file is not a database"), true},
+		{errors.New("database is locked"), false},
+		{errors.New("no such table: items"), false},
+	}
+	for _, tt := range tests {
+		if got := IsCorruption(tt.err); got != tt.want {
+			t.Errorf("IsCorruption(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestMoveAside(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.db")
+	for _, suffix := range []string{"", "-wal"} {
+		if err := os.WriteFile(path+suffix, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backup, err := MoveAside(path)
+	if err != nil {
+		t.Fatalf("MoveAside() error = %v", err)
+	}
+	if !strings.HasPrefix(backup, path+".corrupt.") {
+		t.Errorf("backup = %s", backup)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("database still in place")
+	}
+	if matches, _ := filepath.Glob(path + "-wal.corrupt.*"); len(matches) != 1 {
+		t.Errorf("WAL backups = %v", matches)
+	}
+}
+
+func TestCopyRows(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	src := openTestDB(t, seedCorruptDB(t, 1200))
+
+	dst := openTestDB(t, filepath.Join(t.TempDir(), "fresh.db"))
+	mustExec(t, dst, `CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`)
+	mustExec(t, dst, `INSERT INTO items (id, name) VALUES (7, 'already here')`)
+
+	res, err := CopyRows(ctx, src, dst, "items", "id % 2 = 1")
+	if err != nil {
+		t.Fatalf("CopyRows() error = %v", err)
+	}
+	if res.Copied != 599 || res.Rejected != 1 || res.Unreadable != 0 {
+		t.Errorf("CopyRows() = %+v, want 599 copied and 1 rejected", res)
+	}
+	var name string
+	if err := dst.QueryRow(`SELECT name FROM items WHERE id = 1199`).Scan(&name); err != nil || name != "item-1199" {
+		t.Errorf("row 1199 = %q, %v", name, err)
+	}
+
+	if _, err := CopyRows(ctx, src, dst, "missing", ""); err == nil {
+		t.Error("CopyRows() of a table dst lacks should fail")
+	}
+}
@@ -2,6 +2,7 @@ package ipc
 
 import (
 	"context"
+	"errors"
 	"os"
 	"runtime"
 	"time"
@@ -9,12 +10,17 @@ import (
 	"google.golang.org/grpc"
 
 	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/cmdutil"
 )
 
 // Client wraps the gRPC client with convenience methods and proper timeouts.
 type Client struct {
 	conn   *grpc.ClientConn
 	client pb.ClaiServiceClient
+
+	// measureFrom is when the calling process started, if it reports its
+	// latency. See MeasureFrom.
+	measureFrom time.Time
 }
 
 // NewClient creates a new IPC client connected to the daemon.
@@ -51,6 +57,22 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// MeasureFrom makes the client report, with each CommandStarted and Suggest
+// call, the time elapsed since start, usually when the process started. The
+// daemon holds it against suggestions.latency_budget.
+func (c *Client) MeasureFrom(start time.Time) {
+	c.measureFrom = start
+}
+
+// clientLatencyMs returns the latency to report with a call, or 0 when the
+// client does not measure it. A measured call reports at least 1ms.
+func (c *Client) clientLatencyMs() int64 {
+	if c.measureFrom.IsZero() {
+		return 0
+	}
+	return max(time.Since(c.measureFrom).Milliseconds(), 1)
+}
+
 // --- Session Lifecycle (Fire-and-Forget) ---
 
 // SessionStart notifies the daemon of a new shell session.
@@ -85,6 +107,48 @@ func (c *Client) SessionEnd(sessionID string) {
 	_, _ = c.client.SessionEnd(ctx, req)
 }
 
+// SessionNote sets, clears, or reads the note attached to a session.
+// An empty note with clear=false only reads the current note.
+// Returns the note in effect after the request.
+func (c *Client) SessionNote(ctx context.Context, sessionID, note string, clear bool) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	resp, err := c.client.SessionNote(ctx, &pb.SessionNoteRequest{
+		SessionId: sessionID,
+		Note:      note,
+		Clear:     clear,
+	})
+	if err != nil {
+		return "", err
+	}
+	if !resp.Ok {
+		return "", errors.New(resp.Error)
+	}
+	return resp.Note, nil
+}
+
+// SessionName sets, clears, or reads the name of a session.
+// An empty name with clear=false only reads the current name.
+// Returns the name in effect after the request.
+func (c *Client) SessionName(ctx context.Context, sessionID, name string, clear bool) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	resp, err := c.client.SessionName(ctx, &pb.SessionNameRequest{
+		SessionId: sessionID,
+		Name:      name,
+		Clear:     clear,
+	})
+	if err != nil {
+		return "", err
+	}
+	if !resp.Ok {
+		return "", errors.New(resp.Error)
+	}
+	return resp.Name, nil
+}
+
 // --- Command Lifecycle (Fire-and-Forget) ---
 
 // CommandContext contains optional context for a command execution.
@@ -93,6 +157,7 @@ type CommandContext struct {
 	GitRepoName   string // Git repo basename
 	GitRepoRoot   string // Git repo full path
 	PrevCommandID string // Previous command ID in session
+	Ephemeral     bool   // Incognito session: not persisted by the daemon
 }
 
 // LogStart logs the start of a command execution.
@@ -121,30 +186,59 @@ func (c *Client) LogStartWithContext(sessionID, commandID, cwd, command string,
 		req.GitRepoName = ctxInfo.GitRepoName
 		req.GitRepoRoot = ctxInfo.GitRepoRoot
 		req.PrevCommandId = ctxInfo.PrevCommandID
+		req.Ephemeral = ctxInfo.Ephemeral
 	}
+	req.ClientLatencyMs = c.clientLatencyMs()
 
 	// Fire and forget - ignore errors
 	_, _ = c.client.CommandStarted(ctx, req)
 }
 
+// MaxOutputTailBytes caps each output stream LogEndWithOutput sends; the
+// daemon cuts it further to suggestions.output_max_bytes.
+const MaxOutputTailBytes = 64 << 10
+
 // LogEnd logs the completion of a command execution.
 // Uses fire-and-forget semantics - errors are silently ignored.
 func (c *Client) LogEnd(sessionID, commandID string, exitCode int, durationMs int64) {
+	c.LogEndWithOutput(sessionID, commandID, exitCode, durationMs, "", "")
+}
+
+// LogEndWithOutput logs the completion of a command execution with the
+// tails of what it printed, which the daemon stores when
+// suggestions.capture_output is on.
+// Uses fire-and-forget semantics - errors are silently ignored.
+func (c *Client) LogEndWithOutput(sessionID, commandID string, exitCode int, durationMs int64, stdout, stderr string) {
 	ctx, cancel := context.WithTimeout(context.Background(), FireAndForgetTimeout)
 	defer cancel()
 
+	stdout, cutOut := cmdutil.TailUTF8(stdout, MaxOutputTailBytes)
+	stderr, cutErr := cmdutil.TailUTF8(stderr, MaxOutputTailBytes)
 	req := &pb.CommandEndRequest{
-		SessionId:  sessionID,
-		CommandId:  commandID,
-		TsUnixMs:   time.Now().UnixMilli(),
-		ExitCode:   int32(exitCode), //nolint:gosec // G115: exit codes are bounded 0-255
-		DurationMs: durationMs,
+		SessionId:       sessionID,
+		CommandId:       commandID,
+		TsUnixMs:        time.Now().UnixMilli(),
+		ExitCode:        int32(exitCode), //nolint:gosec // G115: exit codes are bounded 0-255
+		DurationMs:      durationMs,
+		StdoutTail:      stdout,
+		StderrTail:      stderr,
+		OutputTruncated: cutOut || cutErr,
 	}
 
 	// Fire and forget - ignore errors
 	_, _ = c.client.CommandEnded(ctx, req)
 }
 
+// CommandEventsBatch sends many command start and end events in one call,
+// for flushing events spooled while the daemon was unreachable. Unlike
+// LogStart and LogEnd it waits for the daemon and reports per-event failures.
+func (c *Client) CommandEventsBatch(ctx context.Context, events []*pb.CommandLifecycleEvent) (*pb.CommandEventsBatchResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	return c.client.CommandEventsBatch(ctx, &pb.CommandEventsBatchRequest{Events: events})
+}
+
 // --- Suggestions (With Timeout) ---
 
 // Suggest requests command suggestions from the daemon.
@@ -166,6 +260,7 @@ func (c *Client) Suggest(ctx context.Context, sessionID, cwd, buffer string, cur
 		IncludeAi:  includeAI,
 		MaxResults: int32(maxResults), //nolint:gosec // G115: max results is a small positive integer
 	}
+	req.ClientLatencyMs = c.clientLatencyMs()
 
 	resp, err := c.client.Suggest(ctx, req)
 	if err != nil {
@@ -175,6 +270,53 @@ func (c *Client) Suggest(ctx context.Context, sessionID, cwd, buffer string, cur
 	return resp.Suggestions
 }
 
+// SuggestSlots requests completions for the argument being typed at the end
+// of buffer. Returns values or nil on timeout/error.
+// The provided context is used for cancellation; a timeout is applied internally.
+func (c *Client) SuggestSlots(ctx context.Context, sessionID, cwd, buffer string, maxResults int) []*pb.SlotSuggestion {
+	ctx, cancel := context.WithTimeout(ctx, SuggestTimeout)
+	defer cancel()
+
+	req := &pb.SuggestSlotsRequest{
+		SessionId:  sessionID,
+		Cwd:        cwd,
+		Buffer:     buffer,
+		MaxResults: int32(maxResults), //nolint:gosec // G115: max results is a small positive integer
+	}
+
+	resp, err := c.client.SuggestSlots(ctx, req)
+	if err != nil {
+		return nil
+	}
+
+	return resp.Values
+}
+
+// SuggestInline requests the ghost-text completion of buffer. revision
+// orders the requests of a session, so the daemon can drop superseded ones;
+// debounce asks it to wait that long for a newer revision first.
+// Returns nil on timeout/error.
+// The provided context is used for cancellation; a timeout is applied internally.
+func (c *Client) SuggestInline(ctx context.Context, sessionID, cwd, buffer string, revision int64, debounce time.Duration) *pb.SuggestInlineResponse {
+	ctx, cancel := context.WithTimeout(ctx, SuggestInlineTimeout+debounce)
+	defer cancel()
+
+	req := &pb.SuggestInlineRequest{
+		SessionId:  sessionID,
+		Cwd:        cwd,
+		Buffer:     buffer,
+		Revision:   revision,
+		DebounceMs: int32(debounce.Milliseconds()), //nolint:gosec // G115: the daemon caps debounce at 500ms
+	}
+
+	resp, err := c.client.SuggestInline(ctx, req)
+	if err != nil {
+		return nil
+	}
+
+	return resp
+}
+
 // TextToCommand converts natural language to shell commands.
 // Uses a longer timeout suitable for AI operations.
 // The provided context is used for cancellation; a timeout is applied internally.
@@ -237,6 +379,20 @@ func (c *Client) RecordFeedbackSync(ctx context.Context, sessionID, action, sugg
 	return resp.Ok, nil
 }
 
+// SnapshotFeedback records a thumbs up (helpful) or down for the suggestion
+// shown before the session's last command, or for that command itself when
+// lastCommand is set.
+func (c *Client) SnapshotFeedback(ctx context.Context, sessionID string, helpful, lastCommand bool) (*pb.SnapshotFeedbackResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	return c.client.SnapshotFeedback(ctx, &pb.SnapshotFeedbackRequest{
+		SessionId:   sessionID,
+		Helpful:     helpful,
+		LastCommand: lastCommand,
+	})
+}
+
 // --- Ops ---
 
 // Ping checks if the daemon is responsive.
@@ -256,6 +412,66 @@ func (c *Client) GetStatus() (*pb.StatusResponse, error) {
 	return c.client.GetStatus(ctx, &pb.Ack{Ok: true})
 }
 
+// Health asks the daemon to check each of its subsystems.
+// Database probes can take longer than a status read, so it uses the interactive timeout.
+func (c *Client) Health() (*pb.HealthResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), InteractiveTimeout)
+	defer cancel()
+
+	return c.client.Health(ctx, &pb.Ack{Ok: true})
+}
+
+// --- Admin ---
+
+// ListSessions returns the sessions the daemon is tracking, oldest first.
+func (c *Client) ListSessions(ctx context.Context) ([]*pb.SessionSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	resp, err := c.client.ListSessions(ctx, &pb.Ack{Ok: true})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Sessions, nil
+}
+
+// KillSession ends a session on the daemon.
+func (c *Client) KillSession(ctx context.Context, sessionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	resp, err := c.client.KillSession(ctx, &pb.KillSessionRequest{SessionId: sessionID})
+	if err != nil {
+		return err
+	}
+	if !resp.Ok {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// FlushCaches clears the daemon's caches.
+// Returns the number of AI response and suggestion cache entries removed.
+func (c *Client) FlushCaches(ctx context.Context) (*pb.FlushCachesResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	resp, err := c.client.FlushCaches(ctx, &pb.Ack{Ok: true})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Ok {
+		return nil, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// SubscribeEvents opens a stream of shell events. The stream ends when ctx
+// is canceled or the daemon stops; there is no timeout.
+func (c *Client) SubscribeEvents(ctx context.Context, req *pb.SubscribeEventsRequest) (pb.ClaiService_SubscribeEventsClient, error) {
+	return c.client.SubscribeEvents(ctx, req)
+}
+
 // ImportHistoryResponse contains the result of an import operation.
 type ImportHistoryResponse struct {
 	Error         string
@@ -266,7 +482,8 @@ type ImportHistoryResponse struct {
 // ImportHistory imports shell history into the daemon's database.
 // Shell can be "bash", "zsh", "fish", or "auto" (detect from SHELL env).
 // If ifNotExists is true, skip if history was already imported for this shell.
-func (c *Client) ImportHistory(ctx context.Context, shell, historyPath string, ifNotExists, force bool) (*ImportHistoryResponse, error) {
+// sessionID identifies the caller for the daemon's rate limit and may be empty.
+func (c *Client) ImportHistory(ctx context.Context, sessionID, shell, historyPath string, ifNotExists, force bool) (*ImportHistoryResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout*2) // Longer timeout for import
 	defer cancel()
 
@@ -275,6 +492,7 @@ func (c *Client) ImportHistory(ctx context.Context, shell, historyPath string, i
 		HistoryPath: historyPath,
 		IfNotExists: ifNotExists,
 		Force:       force,
+		SessionId:   sessionID,
 	}
 
 	resp, err := c.client.ImportHistory(ctx, req)
@@ -289,6 +507,109 @@ func (c *Client) ImportHistory(ctx context.Context, shell, historyPath string, i
 	}, nil
 }
 
+// FetchHistory returns a page of deduplicated history matching req, most
+// recent first, as the history picker reads it.
+func (c *Client) FetchHistory(ctx context.Context, req *pb.HistoryFetchRequest) (*pb.HistoryFetchResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	return c.client.FetchHistory(ctx, req)
+}
+
+// ImportEvents imports commands from a 'clai history export' file into the
+// daemon's history and suggestion databases. Callers send large files in
+// batches; commands the daemon already has are counted as duplicates.
+func (c *Client) ImportEvents(ctx context.Context, events []*pb.ImportedEvent) (*pb.ImportEventsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout*6) // Each batch writes both databases
+	defer cancel()
+
+	return c.client.ImportEvents(ctx, &pb.ImportEventsRequest{Events: events})
+}
+
+// DeleteCommand hides the commands containing pattern from history and
+// suggestions. With dryRun set it only reports what would be hidden.
+func (c *Client) DeleteCommand(ctx context.Context, pattern string, dryRun bool) (*pb.DeleteCommandResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout*6) // Scans archived history
+	defer cancel()
+
+	return c.client.DeleteCommand(ctx, &pb.DeleteCommandRequest{Pattern: pattern, DryRun: dryRun})
+}
+
+// DeleteExactCommand hides the commands that are exactly command from
+// history and suggestions, leaving commands that only contain it. With
+// dryRun set it only reports what would be hidden.
+func (c *Client) DeleteExactCommand(ctx context.Context, command string, dryRun bool) (*pb.DeleteCommandResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout*6) // Scans archived history
+	defer cancel()
+
+	return c.client.DeleteCommand(ctx, &pb.DeleteCommandRequest{Pattern: command, DryRun: dryRun, Exact: true})
+}
+
+// PurgeCommand permanently erases the commands containing pattern and what
+// was learned from them. With dryRun set it only reports what would be erased.
+func (c *Client) PurgeCommand(ctx context.Context, pattern string, dryRun bool) (*pb.DeleteCommandResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout*6) // Scans archived history
+	defer cancel()
+
+	return c.client.PurgeCommand(ctx, &pb.DeleteCommandRequest{Pattern: pattern, DryRun: dryRun})
+}
+
+// PurgeExactCommand permanently erases the commands that are exactly
+// command and what was learned from them, leaving commands that only contain
+// it. With dryRun set it only reports what would be erased.
+func (c *Client) PurgeExactCommand(ctx context.Context, command string, dryRun bool) (*pb.DeleteCommandResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout*6) // Scans archived history
+	defer cancel()
+
+	return c.client.PurgeCommand(ctx, &pb.DeleteCommandRequest{Pattern: command, DryRun: dryRun, Exact: true})
+}
+
+// GetCommandDetail returns a recorded command and the output captured for
+// it, by the event ID of its history item.
+func (c *Client) GetCommandDetail(ctx context.Context, eventID int64) (*pb.GetCommandDetailResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	return c.client.GetCommandDetail(ctx, &pb.GetCommandDetailRequest{EventId: eventID})
+}
+
+// ExplainSuggestions returns the suggestions shown at the prompt the
+// session's last command ran from, with how each was scored.
+func (c *Client) ExplainSuggestions(ctx context.Context, sessionID string) (*pb.ExplainSuggestionsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	return c.client.ExplainSuggestions(ctx, &pb.ExplainSuggestionsRequest{SessionId: sessionID})
+}
+
+// ListDismissals returns the suggestions suppressed by dismissal learning
+// in scope, or in every scope when it is empty. With includeTemporary set it
+// also returns patterns that only lower a suggestion's score.
+func (c *Client) ListDismissals(ctx context.Context, scope string, includeTemporary bool) (*pb.ListDismissalsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	return c.client.ListDismissals(ctx, &pb.ListDismissalsRequest{Scope: scope, IncludeTemporary: includeTemporary})
+}
+
+// ClearDismissals un-suppresses the template dismissedTemplateID in scope.
+// Empty values clear every template or every scope.
+func (c *Client) ClearDismissals(ctx context.Context, scope, dismissedTemplateID string) (*pb.ClearDismissalsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	return c.client.ClearDismissals(ctx, &pb.ClearDismissalsRequest{Scope: scope, DismissedTemplateId: dismissedTemplateID})
+}
+
+// CompareScorers returns the shadow-scoring comparison of the V1 ranker and
+// the V2 scorer, and restarts counting when reset is set.
+func (c *Client) CompareScorers(ctx context.Context, reset bool) (*pb.CompareScorersResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, InteractiveTimeout)
+	defer cancel()
+
+	return c.client.CompareScorers(ctx, &pb.CompareScorersRequest{Clear: reset})
+}
+
 // --- Helper Types ---
 
 // ClientInfo contains information about the client environment.
@@ -25,6 +25,7 @@ type mockServer struct {
 	textToCommandCalled bool
 	pingCalled          bool
 	statusCalled        bool
+	sessionNote         string
 }
 
 func (m *mockServer) SessionStart(ctx context.Context, req *pb.SessionStartRequest) (*pb.Ack, error) {
@@ -69,6 +70,19 @@ func (m *mockServer) TextToCommand(ctx context.Context, req *pb.TextToCommandReq
 	}, nil
 }
 
+func (m *mockServer) SessionNote(ctx context.Context, req *pb.SessionNoteRequest) (*pb.SessionNoteResponse, error) {
+	if req.SessionId == "" {
+		return &pb.SessionNoteResponse{Ok: false, Error: "session not found"}, nil
+	}
+	switch {
+	case req.Clear:
+		m.sessionNote = ""
+	case req.Note != "":
+		m.sessionNote = req.Note
+	}
+	return &pb.SessionNoteResponse{Ok: true, Note: m.sessionNote}, nil
+}
+
 func (m *mockServer) Ping(ctx context.Context, req *pb.Ack) (*pb.Ack, error) {
 	m.pingCalled = true
 	return &pb.Ack{Ok: true}, nil
@@ -84,6 +98,28 @@ func (m *mockServer) GetStatus(ctx context.Context, req *pb.Ack) (*pb.StatusResp
 	}, nil
 }
 
+func (m *mockServer) ListSessions(ctx context.Context, req *pb.Ack) (*pb.ListSessionsResponse, error) {
+	return &pb.ListSessionsResponse{Sessions: []*pb.SessionSummary{
+		{SessionId: "s1", Shell: "zsh"},
+		{SessionId: "s2", Shell: "bash"},
+	}}, nil
+}
+
+func (m *mockServer) KillSession(ctx context.Context, req *pb.KillSessionRequest) (*pb.Ack, error) {
+	if req.SessionId != "s1" {
+		return &pb.Ack{Ok: false, Error: "session not found"}, nil
+	}
+	return &pb.Ack{Ok: true}, nil
+}
+
+func (m *mockServer) FlushCaches(ctx context.Context, req *pb.Ack) (*pb.FlushCachesResponse, error) {
+	return &pb.FlushCachesResponse{Ok: true, AiCacheEntries: 4, SuggestionCacheEntries: 2}, nil
+}
+
+func (m *mockServer) CommandEventsBatch(ctx context.Context, req *pb.CommandEventsBatchRequest) (*pb.CommandEventsBatchResponse, error) {
+	return &pb.CommandEventsBatchResponse{Accepted: int32(len(req.Events))}, nil //nolint:gosec // G115: test batches are small
+}
+
 // startMockServer starts a mock gRPC server on a Unix socket
 func startMockServer(t *testing.T) (string, *mockServer, func()) {
 	t.Helper()
@@ -354,3 +390,99 @@ func TestTextToCommandDefaultMaxSuggestions(t *testing.T) {
 		t.Error("TextToCommand with maxSuggestions=0 returned nil response")
 	}
 }
+
+// dialMockServer returns a client connected to the mock server at sockPath.
+func dialMockServer(t *testing.T, sockPath string) *Client {
+	t.Helper()
+
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", sockPath)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	//nolint:staticcheck // Using deprecated Dial for blocking connection behavior in tests
+	conn, err := grpc.DialContext(
+		ctx,
+		"passthrough:///"+sockPath,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(dialer),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	client := NewClientWithConn(conn)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestSessionNote(t *testing.T) {
+	sockPath, _, cleanup := startMockServer(t)
+	defer cleanup()
+
+	client := dialMockServer(t, sockPath)
+	ctx := context.Background()
+
+	note, err := client.SessionNote(ctx, "s1", "debugging auth", false)
+	if err != nil || note != "debugging auth" {
+		t.Fatalf("SessionNote(set) = %q, %v", note, err)
+	}
+
+	note, err = client.SessionNote(ctx, "s1", "", false)
+	if err != nil || note != "debugging auth" {
+		t.Errorf("SessionNote(read) = %q, %v", note, err)
+	}
+
+	note, err = client.SessionNote(ctx, "s1", "", true)
+	if err != nil || note != "" {
+		t.Errorf("SessionNote(clear) = %q, %v", note, err)
+	}
+
+	if _, err := client.SessionNote(ctx, "", "x", false); err == nil {
+		t.Error("SessionNote() expected error when daemon reports failure")
+	}
+}
+
+func TestAdminRPCs(t *testing.T) {
+	sockPath, _, cleanup := startMockServer(t)
+	defer cleanup()
+
+	client := dialMockServer(t, sockPath)
+	ctx := context.Background()
+
+	sessions, err := client.ListSessions(ctx)
+	if err != nil || len(sessions) != 2 || sessions[0].SessionId != "s1" {
+		t.Errorf("ListSessions() = %v, %v", sessions, err)
+	}
+
+	if err := client.KillSession(ctx, "s1"); err != nil {
+		t.Errorf("KillSession(s1) error = %v", err)
+	}
+	if err := client.KillSession(ctx, "missing"); err == nil || err.Error() != "session not found" {
+		t.Errorf("KillSession(missing) error = %v, want session not found", err)
+	}
+
+	flushed, err := client.FlushCaches(ctx)
+	if err != nil || flushed.AiCacheEntries != 4 || flushed.SuggestionCacheEntries != 2 {
+		t.Errorf("FlushCaches() = %v, %v", flushed, err)
+	}
+}
+
+func TestCommandEventsBatch(t *testing.T) {
+	sockPath, _, cleanup := startMockServer(t)
+	defer cleanup()
+
+	client := dialMockServer(t, sockPath)
+	events := []*pb.CommandLifecycleEvent{
+		{Event: &pb.CommandLifecycleEvent_Start{Start: &pb.CommandStartRequest{SessionId: "s1", CommandId: "c1"}}},
+		{Event: &pb.CommandLifecycleEvent_End{End: &pb.CommandEndRequest{SessionId: "s1", CommandId: "c1"}}},
+	}
+	resp, err := client.CommandEventsBatch(context.Background(), events)
+	if err != nil || resp.Accepted != 2 {
+		t.Errorf("CommandEventsBatch() = %v, %v; want 2 accepted", resp, err)
+	}
+}
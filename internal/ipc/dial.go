@@ -22,6 +22,10 @@ const (
 	// SuggestTimeout is used for suggestion requests
 	SuggestTimeout = 50 * time.Millisecond
 
+	// SuggestInlineTimeout is used for ghost-text requests, on top of the
+	// debounce they ask the daemon for
+	SuggestInlineTimeout = 100 * time.Millisecond
+
 	// InteractiveTimeout is used for longer operations like text-to-command
 	InteractiveTimeout = 5 * time.Second
 
@@ -34,9 +38,20 @@ func SocketPath() string {
 	if path := os.Getenv("CLAI_SOCKET"); path != "" {
 		return path
 	}
+	if RemoteMode() {
+		return config.DefaultPaths().RemoteSocketFile()
+	}
 	return config.DefaultPaths().SocketFile()
 }
 
+// RemoteMode reports whether clai runs on a remote host and talks to the
+// daemon of the local machine through an SSH-forwarded socket
+// (CLAI_REMOTE=1). In remote mode no daemon is spawned, and the socket is
+// never removed as stale: it belongs to the SSH connection.
+func RemoteMode() bool {
+	return os.Getenv("CLAI_REMOTE") == "1"
+}
+
 // RunDir returns the directory containing runtime files (socket, pid)
 func RunDir() string {
 	return config.DefaultPaths().BaseDir
@@ -33,6 +33,27 @@ func TestSocketPathEnvOverride(t *testing.T) {
 	}
 }
 
+func TestSocketPathRemoteMode(t *testing.T) {
+	t.Setenv("CLAI_SOCKET", "")
+	t.Setenv("CLAI_REMOTE", "1")
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "/tmp"
+	}
+	// Must match config.Paths.RemoteSocketFile()
+	expected := filepath.Join(home, ".clai", "remote.sock")
+	if path := SocketPath(); path != expected {
+		t.Errorf("SocketPath() in remote mode = %q, want %q", path, expected)
+	}
+
+	// CLAI_SOCKET still wins
+	t.Setenv("CLAI_SOCKET", "/tmp/forwarded.sock")
+	if path := SocketPath(); path != "/tmp/forwarded.sock" {
+		t.Errorf("SocketPath() with CLAI_SOCKET = %q, want /tmp/forwarded.sock", path)
+	}
+}
+
 func TestRunDir(t *testing.T) {
 	// Handle fallback to /tmp when home dir unavailable
 	home, err := os.UserHomeDir()
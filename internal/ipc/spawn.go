@@ -28,6 +28,12 @@ func LogPath() string {
 	return config.DefaultPaths().LogFile()
 }
 
+// StderrPath returns the path that receives a spawned daemon's stdout and
+// stderr.
+func StderrPath() string {
+	return config.DefaultPaths().StderrFile()
+}
+
 // DaemonBinaryName is the name of the daemon executable
 const DaemonBinaryName = "claid"
 
@@ -45,9 +51,19 @@ var (
 	staleSocketRetryDelay   = 25 * time.Millisecond
 )
 
+// ErrRemoteMode is returned when a daemon would be spawned in remote mode,
+// where the daemon is the local machine's, reached over SSH.
+var ErrRemoteMode = errors.New("remote mode (CLAI_REMOTE=1): the daemon runs on the local machine; check the SSH socket forward")
+
 // EnsureDaemon ensures the daemon is running, spawning it if necessary.
 // Returns nil if daemon is available, error otherwise.
 func EnsureDaemon() error {
+	if RemoteMode() {
+		if socketExistsFn() && isSocketDialable() {
+			return nil
+		}
+		return ErrRemoteMode
+	}
 	ready, err := ensureHealthySocket()
 	if ready || err != nil {
 		return err
@@ -118,6 +134,9 @@ func SpawnDaemonContext(ctx context.Context) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
+	if RemoteMode() {
+		return ErrRemoteMode
+	}
 
 	// Ensure run directory exists
 	if err := os.MkdirAll(RunDir(), 0o750); err != nil {
@@ -132,15 +151,16 @@ func SpawnDaemonContext(ctx context.Context) error {
 	}
 
 	// Find daemon binary
-	daemonPath, err := findDaemonBinary()
+	daemonPath, err := FindDaemonBinary()
 	if err != nil {
 		return err
 	}
 
-	// Create log file
-	logFile, err := os.OpenFile(LogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	// The daemon logs through its own rotating writer; its raw output, such
+	// as a panic, goes to a file of its own that rotation never renames.
+	logFile, err := os.OpenFile(StderrPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
 	if err != nil {
-		// Log file creation failed, use /dev/null
+		// Output file creation failed, use /dev/null
 		logFile, _ = os.Open(os.DevNull)
 	}
 	defer logFile.Close()
@@ -207,8 +227,9 @@ func SpawnAndWaitContext(ctx context.Context, timeout time.Duration) error {
 	}
 }
 
-// findDaemonBinary locates the daemon executable
-func findDaemonBinary() (string, error) {
+// FindDaemonBinary locates the daemon executable (CLAI_DAEMON_PATH, next to
+// the current executable, PATH, then common install locations).
+func FindDaemonBinary() (string, error) {
 	// Check CLAI_DAEMON_PATH environment variable
 	if path := os.Getenv("CLAI_DAEMON_PATH"); path != "" {
 		absPath, err := filepath.Abs(path)
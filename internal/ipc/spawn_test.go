@@ -60,12 +60,12 @@ func TestFindDaemonBinaryFromEnv(t *testing.T) {
 	os.Setenv("CLAI_DAEMON_PATH", tmpFile.Name())
 	defer os.Unsetenv("CLAI_DAEMON_PATH")
 
-	path, err := findDaemonBinary()
+	path, err := FindDaemonBinary()
 	if err != nil {
-		t.Errorf("findDaemonBinary() error = %v", err)
+		t.Errorf("FindDaemonBinary() error = %v", err)
 	}
 	if path != tmpFile.Name() {
-		t.Errorf("findDaemonBinary() = %q, want %q", path, tmpFile.Name())
+		t.Errorf("FindDaemonBinary() = %q, want %q", path, tmpFile.Name())
 	}
 }
 
@@ -89,9 +89,9 @@ func TestFindDaemonBinaryNotFound(t *testing.T) {
 	os.Setenv("HOME", tmpDir)
 	defer os.Setenv("HOME", oldHome)
 
-	_, err = findDaemonBinary()
+	_, err = FindDaemonBinary()
 	if err == nil {
-		t.Error("findDaemonBinary() should fail when binary not found")
+		t.Error("FindDaemonBinary() should fail when binary not found")
 	}
 }
 
@@ -401,12 +401,12 @@ func TestFindDaemonBinaryEnvPathIsAbsolute(t *testing.T) {
 	defer os.Setenv("CLAI_DAEMON_PATH", old)
 	_ = os.Setenv("CLAI_DAEMON_PATH", daemonPath)
 
-	got, err := findDaemonBinary()
+	got, err := FindDaemonBinary()
 	if err != nil {
-		t.Fatalf("findDaemonBinary() error = %v", err)
+		t.Fatalf("FindDaemonBinary() error = %v", err)
 	}
 	if !filepath.IsAbs(got) {
-		t.Fatalf("findDaemonBinary() = %q, want absolute path", got)
+		t.Fatalf("FindDaemonBinary() = %q, want absolute path", got)
 	}
 }
 
@@ -564,6 +564,49 @@ func TestEnsureHealthySocket(t *testing.T) {
 	})
 }
 
+func TestEnsureDaemonRemoteMode(t *testing.T) {
+	t.Setenv("CLAI_REMOTE", "1")
+
+	oldSocketExists := socketExistsFn
+	oldQuickDial := quickDialFn
+	oldRemove := removeFileFn
+	t.Cleanup(func() {
+		socketExistsFn = oldSocketExists
+		quickDialFn = oldQuickDial
+		removeFileFn = oldRemove
+	})
+	removeFileFn = func(path string) error {
+		t.Fatalf("forwarded socket %s must not be removed", path)
+		return nil
+	}
+
+	t.Run("forward down", func(t *testing.T) {
+		socketExistsFn = func() bool { return true }
+		quickDialFn = func() (io.Closer, error) {
+			return nil, errors.New("connection refused")
+		}
+		if err := EnsureDaemon(); !errors.Is(err, ErrRemoteMode) {
+			t.Fatalf("EnsureDaemon() error = %v, want ErrRemoteMode", err)
+		}
+	})
+
+	t.Run("forward up", func(t *testing.T) {
+		socketExistsFn = func() bool { return true }
+		quickDialFn = func() (io.Closer, error) {
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+		if err := EnsureDaemon(); err != nil {
+			t.Fatalf("EnsureDaemon() error = %v", err)
+		}
+	})
+
+	t.Run("no spawn", func(t *testing.T) {
+		if err := SpawnDaemonContext(context.Background()); !errors.Is(err, ErrRemoteMode) {
+			t.Fatalf("SpawnDaemonContext() error = %v, want ErrRemoteMode", err)
+		}
+	})
+}
+
 func TestRecoverMissingSocketDaemon(t *testing.T) {
 	t.Run("kill stale daemon when socket not published", func(t *testing.T) {
 		oldSocketExists := socketExistsFn
@@ -700,3 +743,14 @@ func TestTerminatePID(t *testing.T) {
 		t.Fatalf("terminatePID(nonexistent) error = %v, want nil, got %v", err, err)
 	}
 }
+
+func TestStderrPath(t *testing.T) {
+	// Rotation renames the log file, so a spawned daemon's raw output must
+	// not share it.
+	if StderrPath() == LogPath() {
+		t.Errorf("StderrPath() = LogPath() = %q", StderrPath())
+	}
+	if filepath.Dir(StderrPath()) != filepath.Dir(LogPath()) {
+		t.Errorf("StderrPath() = %q, want it next to %q", StderrPath(), LogPath())
+	}
+}
@@ -0,0 +1,55 @@
+// Package logging builds the daemon's slog handler and log file writer.
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/runger/clai/internal/config"
+)
+
+// Stderr is the log_file value that sends daemon logs to stderr.
+const Stderr = "-"
+
+// NewHandler returns a JSON handler when format is "json" and a text
+// handler otherwise.
+func NewHandler(w io.Writer, format string, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// OpenOutput returns the writer daemon logs go to: stderr when log_file is
+// "-", otherwise a RotatingFile at log_file (default paths.LogFile()).
+// The writer must be closed on shutdown.
+func OpenOutput(cfg *config.DaemonConfig, paths *config.Paths) (io.WriteCloser, error) {
+	if cfg.LogFile == Stderr {
+		return nopCloser{os.Stderr}, nil
+	}
+	path := cfg.LogFile
+	if path == "" {
+		path = paths.LogFile()
+	}
+	f, err := OpenRotatingFile(path, rotateOptions(cfg))
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// rotateOptions converts the daemon log settings to rotation options.
+func rotateOptions(cfg *config.DaemonConfig) RotateOptions {
+	return RotateOptions{
+		MaxBytes:   int64(cfg.LogMaxSizeMB) * 1024 * 1024,
+		MaxAge:     time.Duration(cfg.LogMaxAgeDays) * 24 * time.Hour,
+		MaxBackups: cfg.LogMaxBackups,
+	}
+}
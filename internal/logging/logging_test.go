@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runger/clai/internal/config"
+)
+
+func TestNewHandler_JSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	slog.New(NewHandler(&buf, "json", slog.LevelInfo)).Info("hello", "k", "v")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("output is not JSON: %v: %s", err, buf.String())
+	}
+	if entry["msg"] != "hello" || entry["k"] != "v" {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+}
+
+func TestNewHandler_TextIsDefault(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	slog.New(NewHandler(&buf, "", slog.LevelInfo)).Info("hello")
+
+	if !bytes.Contains(buf.Bytes(), []byte("msg=hello")) {
+		t.Errorf("expected text output, got %q", buf.String())
+	}
+}
+
+func TestOpenOutput_DefaultsToPathsLogFile(t *testing.T) {
+	t.Parallel()
+
+	paths := &config.Paths{BaseDir: t.TempDir()}
+	cfg := config.DefaultConfig().Daemon
+
+	w, err := OpenOutput(&cfg, paths)
+	if err != nil {
+		t.Fatalf("OpenOutput() error = %v", err)
+	}
+	if _, err := w.Write([]byte("line\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(paths.LogFile()); err != nil {
+		t.Errorf("log file not created: %v", err)
+	}
+}
+
+func TestOpenOutput_CustomFileAndStderr(t *testing.T) {
+	t.Parallel()
+
+	paths := &config.Paths{BaseDir: t.TempDir()}
+	cfg := config.DefaultConfig().Daemon
+	cfg.LogFile = filepath.Join(t.TempDir(), "custom", "claid.log")
+
+	w, err := OpenOutput(&cfg, paths)
+	if err != nil {
+		t.Fatalf("OpenOutput() error = %v", err)
+	}
+	w.Close()
+	if _, err := os.Stat(cfg.LogFile); err != nil {
+		t.Errorf("custom log file not created: %v", err)
+	}
+
+	cfg.LogFile = Stderr
+	w, err = OpenOutput(&cfg, paths)
+	if err != nil {
+		t.Fatalf("OpenOutput(stderr) error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("closing stderr output should be a no-op, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names rotated files so they sort chronologically.
+const backupTimeFormat = "20060102T150405.000"
+
+// RotateOptions controls when a RotatingFile rotates and which backups it keeps.
+type RotateOptions struct {
+	// MaxBytes rotates the file before a write would exceed this size (0 = never).
+	MaxBytes int64
+	// MaxAge deletes rotated files older than this (0 = keep regardless of age).
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files to keep (0 = keep all).
+	MaxBackups int
+}
+
+// RotatingFile is an io.WriteCloser that appends to a file and rotates it
+// by size. Rotated files are named <path>.<timestamp> and pruned by age and
+// count. It is safe for concurrent use.
+type RotatingFile struct {
+	now  func() time.Time
+	file *os.File
+	path string
+	opts RotateOptions
+	size int64
+	mu   sync.Mutex
+}
+
+// OpenRotatingFile opens (or creates) path for appending.
+// Rotated files past opts.MaxAge or opts.MaxBackups are pruned on open.
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path: path,
+		opts: opts,
+		now:  time.Now,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.prune()
+	return r, nil
+}
+
+// Write appends p, rotating first if p would push the file past MaxBytes.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.opts.MaxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.opts.MaxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600) //nolint:gosec // G304: log path from trusted config
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// rotate renames the current file to a timestamped backup and reopens path.
+// Caller must hold r.mu.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	r.file = nil
+
+	backup := r.path + "." + r.now().Format(backupTimeFormat)
+	if err := os.Rename(r.path, backup); err != nil && !os.IsNotExist(err) {
+		// Keep logging to the existing file rather than dropping output.
+		if openErr := r.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.prune()
+	return nil
+}
+
+// prune removes rotated files beyond MaxBackups or older than MaxAge.
+func (r *RotatingFile) prune() {
+	backups := r.backups()
+	cutoff := time.Time{}
+	if r.opts.MaxAge > 0 {
+		cutoff = r.now().Add(-r.opts.MaxAge)
+	}
+
+	// backups is sorted newest first.
+	for i, path := range backups {
+		remove := r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups
+		if !remove && !cutoff.IsZero() {
+			if info, err := os.Stat(path); err == nil && info.ModTime().Before(cutoff) {
+				remove = true
+			}
+		}
+		if remove {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// backups returns rotated files for r.path, newest first.
+func (r *RotatingFile) backups() []string {
+	matches, err := filepath.Glob(r.path + ".*")
+	if err != nil {
+		return nil
+	}
+	prefix := r.path + "."
+	backups := matches[:0]
+	for _, m := range matches {
+		if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(m, prefix)); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups
+}
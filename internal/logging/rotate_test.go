@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "claid.log")
+	r, err := OpenRotatingFile(path, RotateOptions{MaxBytes: 10})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile() error = %v", err)
+	}
+	defer r.Close()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	if _, err := r.Write([]byte("12345678\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("abc\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current) != "abc\n" {
+		t.Errorf("current log = %q, want %q", current, "abc\n")
+	}
+	backup, err := os.ReadFile(path + "." + now.Format(backupTimeFormat))
+	if err != nil {
+		t.Fatalf("rotated file missing: %v", err)
+	}
+	if string(backup) != "12345678\n" {
+		t.Errorf("rotated log = %q, want %q", backup, "12345678\n")
+	}
+}
+
+func TestRotatingFile_OversizedWriteIsNotSplit(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "claid.log")
+	r, err := OpenRotatingFile(path, RotateOptions{MaxBytes: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	line := strings.Repeat("x", 20)
+	if _, err := r.Write([]byte(line)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != line {
+		t.Errorf("log = %q, want %q", got, line)
+	}
+}
+
+func TestRotatingFile_PrunesByCountAndAge(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "claid.log")
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	// Three recent backups and one that is too old.
+	var backups []string
+	for i, age := range []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour, 30 * 24 * time.Hour} {
+		ts := now.Add(-age)
+		name := path + "." + ts.Format(backupTimeFormat)
+		if err := os.WriteFile(name, []byte{byte('a' + i)}, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, ts, ts); err != nil {
+			t.Fatal(err)
+		}
+		backups = append(backups, name)
+	}
+	unrelated := path + ".bak"
+	if err := os.WriteFile(unrelated, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &RotatingFile{
+		path: path,
+		opts: RotateOptions{MaxBackups: 2, MaxAge: 7 * 24 * time.Hour},
+		now:  func() time.Time { return now },
+	}
+	r.prune()
+
+	for i, name := range backups {
+		_, err := os.Stat(name)
+		if kept := err == nil; kept != (i < 2) {
+			t.Errorf("backup %d kept = %v, want %v", i, kept, i < 2)
+		}
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("prune removed unrelated file: %v", err)
+	}
+}
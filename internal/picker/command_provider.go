@@ -0,0 +1,152 @@
+package picker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commandTimeout bounds a tab command; its output is fetched once per
+// picker session.
+const commandTimeout = 5 * time.Second
+
+// maxCommandItems caps the lines kept from a tab command.
+const maxCommandItems = 10000
+
+// CommandProvider implements Provider with the output of a shell command,
+// one item per line, for the "command" tab provider. The command runs once,
+// on the first Fetch; later fetches filter and page its output.
+type CommandProvider struct {
+	// run is injected for testing; defaults to running command with sh -c.
+	run func(ctx context.Context, command, dir string) ([]byte, error)
+
+	err     error
+	done    chan struct{}
+	command string
+	dir     string
+	items   []Item
+	once    sync.Once
+}
+
+// Compile-time check that CommandProvider implements Provider.
+var _ Provider = (*CommandProvider)(nil)
+
+// NewCommandProvider creates a provider that runs command with sh -c in
+// dir ("" = the current directory).
+func NewCommandProvider(command, dir string) *CommandProvider {
+	return &CommandProvider{
+		command: command,
+		dir:     dir,
+		run:     runShellCommand,
+		done:    make(chan struct{}),
+	}
+}
+
+// Fetch returns the lines of the command output containing req.Query,
+// ignoring case.
+func (p *CommandProvider) Fetch(ctx context.Context, req Request) (Response, error) {
+	// The command outlives the request: typing cancels requests, and the
+	// output serves every later one.
+	p.once.Do(func() { go p.load() })
+	select {
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	case <-p.done:
+	}
+	if p.err != nil {
+		return Response{}, p.err
+	}
+
+	query := strings.ToLower(req.Query)
+	var matched []Item
+	for _, it := range p.items {
+		if query == "" || strings.Contains(strings.ToLower(it.Value), query) {
+			matched = append(matched, it)
+		}
+	}
+	start := min(req.Offset, len(matched))
+	end := len(matched)
+	if req.Limit > 0 {
+		end = min(start+req.Limit, end)
+	}
+	return Response{
+		Items:     matched[start:end],
+		RequestID: req.RequestID,
+		AtEnd:     end == len(matched),
+	}, nil
+}
+
+// load runs the command and keeps its non-empty, distinct lines.
+func (p *CommandProvider) load() {
+	defer close(p.done)
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	out, err := p.run(ctx, p.command, p.dir)
+	if err != nil {
+		p.err = fmt.Errorf("tab command %q: %w", p.command, err)
+		return
+	}
+	seen := make(map[string]bool)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() && len(p.items) < maxCommandItems {
+		line := strings.TrimSpace(ValidateUTF8(StripANSI(sc.Text())))
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		p.items = append(p.items, Item{Value: line})
+	}
+}
+
+func runShellCommand(ctx context.Context, command, dir string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // G204: the user's own configured command
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s", commandTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// TabProviders sends each request to the provider of its tab, and the
+// requests of tabs without one to Default.
+type TabProviders struct {
+	Default Provider
+	Tabs    map[string]Provider
+}
+
+// Compile-time check that TabProviders implements Provider.
+var _ Provider = (*TabProviders)(nil)
+
+// Fetch implements Provider.
+func (t *TabProviders) Fetch(ctx context.Context, req Request) (Response, error) {
+	if p, ok := t.Tabs[req.TabID]; ok {
+		return p.Fetch(ctx, req)
+	}
+	return t.Default.Fetch(ctx, req)
+}
+
+// Detail implements DetailProvider for the items of Default. Items of the
+// other providers carry no ID, so the picker never asks about them.
+func (t *TabProviders) Detail(ctx context.Context, item Item) ([]string, error) {
+	if dp, ok := t.Default.(DetailProvider); ok {
+		return dp.Detail(ctx, item)
+	}
+	return nil, nil
+}
@@ -0,0 +1,129 @@
+package picker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestCommandProvider(out string, err error) (*CommandProvider, *int) {
+	runs := 0
+	p := NewCommandProvider("list", "/work")
+	p.run = func(_ context.Context, command, dir string) ([]byte, error) {
+		runs++
+		if command != "list" || dir != "/work" {
+			return nil, errors.New("unexpected command " + command + " in " + dir)
+		}
+		return []byte(out), err
+	}
+	return p, &runs
+}
+
+func values(items []Item) string {
+	v := make([]string, len(items))
+	for i, it := range items {
+		v[i] = it.Value
+	}
+	return strings.Join(v, ",")
+}
+
+func TestCommandProvider_Fetch(t *testing.T) {
+	t.Parallel()
+
+	p, runs := newTestCommandProvider("prod-eu\n\nstaging\n\x1b[1mprod-us\x1b[0m\nprod-eu\n  dev  \n", nil)
+	ctx := context.Background()
+
+	resp, err := p.Fetch(ctx, Request{RequestID: 7, Limit: 10})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if got := values(resp.Items); got != "prod-eu,staging,prod-us,dev" {
+		t.Errorf("items = %q, want trimmed, distinct lines without escapes", got)
+	}
+	if resp.RequestID != 7 || !resp.AtEnd {
+		t.Errorf("RequestID = %d, AtEnd = %v", resp.RequestID, resp.AtEnd)
+	}
+
+	resp, err = p.Fetch(ctx, Request{Query: "PROD", Limit: 1})
+	if err != nil {
+		t.Fatalf("Fetch(PROD) error = %v", err)
+	}
+	if got := values(resp.Items); got != "prod-eu" || resp.AtEnd {
+		t.Errorf("first page = %q, AtEnd %v; want prod-eu and more", got, resp.AtEnd)
+	}
+	resp, _ = p.Fetch(ctx, Request{Query: "PROD", Limit: 1, Offset: 1})
+	if got := values(resp.Items); got != "prod-us" || !resp.AtEnd {
+		t.Errorf("second page = %q, AtEnd %v; want prod-us at end", got, resp.AtEnd)
+	}
+	if *runs != 1 {
+		t.Errorf("command ran %d times, want once", *runs)
+	}
+}
+
+func TestCommandProvider_Error(t *testing.T) {
+	t.Parallel()
+
+	p, _ := newTestCommandProvider("", errors.New("exit status 1: not found"))
+	if _, err := p.Fetch(context.Background(), Request{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Fetch() error = %v, want the command's error", err)
+	}
+}
+
+func TestCommandProvider_CancelledRequestKeepsCommand(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	p := NewCommandProvider("list", "")
+	p.run = func(context.Context, string, string) ([]byte, error) {
+		<-release
+		return []byte("a\n"), nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Fetch(ctx, Request{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Fetch(cancelled) error = %v", err)
+	}
+	close(release)
+	resp, err := p.Fetch(context.Background(), Request{})
+	if err != nil || values(resp.Items) != "a" {
+		t.Errorf("Fetch() = %q, %v; want the output of the first run", values(resp.Items), err)
+	}
+}
+
+func TestRunShellCommand(t *testing.T) {
+	t.Parallel()
+
+	out, err := runShellCommand(context.Background(), "pwd; echo b", "/")
+	if err != nil || string(out) != "/\nb\n" {
+		t.Errorf("runShellCommand() = %q, %v", out, err)
+	}
+	if _, err := runShellCommand(context.Background(), "echo oops >&2; exit 3", ""); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("runShellCommand(failing) error = %v, want stderr in it", err)
+	}
+}
+
+type staticProvider struct{ value string }
+
+func (s staticProvider) Fetch(_ context.Context, req Request) (Response, error) {
+	return Response{Items: []Item{{Value: s.value, ID: 1}}, RequestID: req.RequestID, AtEnd: true}, nil
+}
+
+func TestTabProviders(t *testing.T) {
+	t.Parallel()
+
+	p := &TabProviders{
+		Default: staticProvider{"history"},
+		Tabs:    map[string]Provider{"hosts": staticProvider{"host"}},
+	}
+	for tab, want := range map[string]string{"hosts": "host", "session": "history", "": "history"} {
+		resp, err := p.Fetch(context.Background(), Request{TabID: tab})
+		if err != nil || values(resp.Items) != want {
+			t.Errorf("Fetch(tab %q) = %q, %v; want %q", tab, values(resp.Items), err, want)
+		}
+	}
+	if lines, err := p.Detail(context.Background(), Item{ID: 1}); lines != nil || err != nil {
+		t.Errorf("Detail() = %v, %v; want nothing from a default without details", lines, err)
+	}
+}
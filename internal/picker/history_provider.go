@@ -282,6 +282,10 @@ func (p *HistoryProvider) sessionSeenSnapshot(st *sessionQueryState) map[string]
 	return out
 }
 
+// truncatedMarker is appended to the display of commands the daemon stored
+// only in part. The value inserted into the prompt is left unchanged.
+const truncatedMarker = " …[truncated]"
+
 func (p *HistoryProvider) fetchHistoryItems(
 	ctx context.Context,
 	client pb.ClaiServiceClient,
@@ -310,11 +314,64 @@ func (p *HistoryProvider) fetchHistoryItems(
 		if cmd == "" {
 			continue
 		}
-		items = append(items, Item{Value: cmd, Display: cmd})
+		display := cmd
+		if item.Truncated {
+			display += truncatedMarker
+		}
+		items = append(items, Item{Value: cmd, Display: display, ID: item.EventId})
 	}
 	return items, grpcResp.AtEnd, nil
 }
 
+// outputPreviewLines is how many lines of a command's output the picker
+// previews.
+const outputPreviewLines = 2
+
+// Detail implements DetailProvider: it returns the last lines a history
+// item's command printed, stderr first, when the daemon captured its
+// output.
+func (p *HistoryProvider) Detail(ctx context.Context, item Item) ([]string, error) {
+	if item.ID == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	conn, err := grpc.NewClient(
+		"unix://"+p.socketPath,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("history provider: dial: %w", err)
+	}
+	defer conn.Close()
+
+	resp, err := pb.NewClaiServiceClient(conn).GetCommandDetail(ctx, &pb.GetCommandDetailRequest{EventId: item.ID})
+	if err != nil {
+		return nil, fmt.Errorf("history provider: detail rpc: %w", err)
+	}
+	if !resp.HasOutput {
+		return nil, nil
+	}
+	text := resp.StderrTail
+	if strings.TrimSpace(text) == "" {
+		text = resp.StdoutTail
+	}
+	return lastLines(ValidateUTF8(StripANSI(text)), outputPreviewLines), nil
+}
+
+// lastLines returns the last n non-blank lines of text.
+func lastLines(text string, n int) []string {
+	lines := strings.Split(text, "\n")
+	var out []string
+	for i := len(lines) - 1; i >= 0 && len(out) < n; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			out = append(out, line)
+		}
+	}
+	return reverseLines(out)
+}
+
 func (p *HistoryProvider) fetchCompositeGlobalPage(
 	ctx context.Context,
 	client pb.ClaiServiceClient,
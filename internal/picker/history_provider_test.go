@@ -28,6 +28,7 @@ type mockClaiService struct {
 	lastReq  *pb.HistoryFetchRequest
 	items    []*pb.HistoryItem
 	reqs     []*pb.HistoryFetchRequest
+	details  map[int64]*pb.GetCommandDetailResponse
 	delay    time.Duration
 	atEnd    bool
 }
@@ -50,6 +51,13 @@ func (m *mockClaiService) FetchHistory(_ context.Context, req *pb.HistoryFetchRe
 	}, nil
 }
 
+func (m *mockClaiService) GetCommandDetail(_ context.Context, req *pb.GetCommandDetailRequest) (*pb.GetCommandDetailResponse, error) {
+	if d, ok := m.details[req.EventId]; ok {
+		return d, nil
+	}
+	return &pb.GetCommandDetailResponse{}, nil
+}
+
 // startMockServer starts a gRPC server on a temporary Unix socket and returns
 // the socket path. Uses /tmp directly with short names to stay within the
 // macOS 104-character Unix socket path limit.
@@ -220,6 +228,75 @@ func TestHistoryProvider_ANSIStripping(t *testing.T) {
 	}
 }
 
+func TestHistoryProvider_TruncatedIndicator(t *testing.T) {
+	t.Parallel()
+
+	svc := &mockClaiService{
+		items: []*pb.HistoryItem{
+			{Command: "echo aaaa", TimestampMs: 1000, Truncated: true},
+			{Command: "ls", TimestampMs: 900},
+		},
+		atEnd: true,
+	}
+	socketPath := startMockServer(t, svc)
+	provider := NewHistoryProvider(socketPath)
+
+	resp, err := provider.Fetch(context.Background(), Request{Limit: 50})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(resp.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(resp.Items))
+	}
+
+	if resp.Items[0].Value != "echo aaaa" || resp.Items[0].Display != "echo aaaa"+truncatedMarker {
+		t.Errorf("truncated item = %+v, want marker on Display only", resp.Items[0])
+	}
+	if resp.Items[1].Display != "ls" {
+		t.Errorf("untruncated item Display = %q, want %q", resp.Items[1].Display, "ls")
+	}
+}
+
+func TestHistoryProvider_Detail(t *testing.T) {
+	t.Parallel()
+
+	svc := &mockClaiService{
+		items: []*pb.HistoryItem{{Command: "make test", TimestampMs: 1000, EventId: 7}},
+		details: map[int64]*pb.GetCommandDetailResponse{7: {
+			Found:      true,
+			HasOutput:  true,
+			StdoutTail: "ok",
+			StderrTail: "compiling\n\x1b[31mFAIL\x1b[0m: TestParse\nexit status 1\n\n",
+		}},
+		atEnd: true,
+	}
+	socketPath := startMockServer(t, svc)
+	provider := NewHistoryProvider(socketPath)
+
+	resp, err := provider.Fetch(context.Background(), Request{Limit: 50})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].ID != 7 {
+		t.Fatalf("items = %+v, want make test with ID 7", resp.Items)
+	}
+
+	lines, err := provider.Detail(context.Background(), resp.Items[0])
+	if err != nil {
+		t.Fatalf("Detail failed: %v", err)
+	}
+	want := []string{"FAIL: TestParse", "exit status 1"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("Detail() = %q, want %q", lines, want)
+	}
+
+	// Commands without captured output have no detail.
+	lines, err = provider.Detail(context.Background(), Item{Value: "ls", ID: 8})
+	if err != nil || len(lines) != 0 {
+		t.Errorf("Detail(no output) = %q, %v; want none", lines, err)
+	}
+}
+
 func TestHistoryProvider_SessionScoping(t *testing.T) {
 	t.Parallel()
 
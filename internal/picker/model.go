@@ -50,6 +50,15 @@ type fetchDoneMsg struct {
 	atEnd     bool
 }
 
+// detailDoneMsg is sent when an async DetailProvider.Detail completes.
+type detailDoneMsg struct {
+	lines []string
+	id    int64
+}
+
+// detailTimeout bounds a DetailProvider.Detail call.
+const detailTimeout = 200 * time.Millisecond
+
 // debounceMsg fires after the debounce timer expires.
 type debounceMsg struct {
 	id uint64 // Must match current requestID to be accepted
@@ -77,6 +86,7 @@ type Model struct {
 	result      string
 	tabs        []config.TabDef
 	items       []Item
+	details     map[int64][]string // DetailProvider lines by item ID; nil while pending
 	textInput   textinput.Model
 	debounceID  uint64
 	requestID   uint64
@@ -104,6 +114,7 @@ func NewModel(tabs []config.TabDef, provider Provider) Model {
 		activeTab: 0,
 		selection: -1,
 		provider:  provider,
+		details:   make(map[int64][]string),
 		textInput: ti,
 	}
 }
@@ -160,6 +171,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) { //nolint:gocritic // h
 	case fetchDoneMsg:
 		return m.handleFetchDone(msg)
 
+	case detailDoneMsg:
+		m.details[msg.id] = msg.lines
+		return m, nil
+
 	case debounceMsg:
 		return m.handleDebounce(msg)
 
@@ -212,11 +227,11 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) { //nolint:gocriti
 
 	case tea.KeyUp:
 		m.moveSelection(-1)
-		return m, nil
+		return m, m.fetchDetail()
 
 	case tea.KeyDown:
 		m.moveSelection(+1)
-		return m, nil
+		return m, m.fetchDetail()
 
 	case tea.KeyRight:
 		return m.handleRightRefineKey()
@@ -347,7 +362,7 @@ func (m Model) handleFetchDone(msg fetchDoneMsg) (tea.Model, tea.Cmd) { //nolint
 		m.clampSelection()
 	}
 
-	return m, nil
+	return m, m.fetchDetail()
 }
 
 // handleDebounce fires the fetch if the debounce timer is still current.
@@ -403,6 +418,34 @@ func (m *Model) startFetch() tea.Cmd {
 	}
 }
 
+// fetchDetail returns a tea.Cmd that asks a DetailProvider about the
+// selected item, or nil if there is nothing to ask. Each item is asked
+// about once.
+func (m *Model) fetchDetail() tea.Cmd {
+	dp, ok := m.provider.(DetailProvider)
+	if !ok || m.selection < 0 || m.selection >= len(m.items) {
+		return nil
+	}
+	item := m.items[m.selection]
+	if item.ID == 0 {
+		return nil
+	}
+	if _, seen := m.details[item.ID]; seen {
+		return nil
+	}
+	m.details[item.ID] = nil
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), detailTimeout)
+		defer cancel()
+		lines, err := dp.Detail(ctx, item)
+		if err != nil {
+			lines = nil
+		}
+		return detailDoneMsg{id: item.ID, lines: lines}
+	}
+}
+
 // cancelInflight cancels any in-progress fetch context.
 func (m *Model) cancelInflight() {
 	if m.cancelFetch != nil {
@@ -574,7 +617,11 @@ func (m Model) footerDetailLines() []string { //nolint:gocritic // hugeParam: bu
 	if m.state != stateLoaded || len(m.items) == 0 || m.selection < 0 || m.selection >= len(m.items) {
 		return nil
 	}
-	details := m.items[m.selection].Details
+	item := m.items[m.selection]
+	details := item.Details
+	if lines := m.details[item.ID]; item.ID != 0 && len(lines) > 0 {
+		details = append(details[:len(details):len(details)], lines...)
+	}
 	if len(details) == 0 {
 		return nil
 	}
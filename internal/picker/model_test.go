@@ -538,6 +538,46 @@ func TestViewFooter_ShowsShortcuts(t *testing.T) {
 	assert.Contains(t, footer, "Esc cancel")
 }
 
+// detailProvider is a mockProvider that describes items by ID.
+type detailProvider struct {
+	mockProvider
+	details map[int64][]string
+	asked   []int64
+}
+
+func (p *detailProvider) Detail(_ context.Context, item Item) ([]string, error) {
+	p.asked = append(p.asked, item.ID)
+	return p.details[item.ID], nil
+}
+
+func TestViewFooter_ShowsSelectedItemDetail(t *testing.T) {
+	p := &detailProvider{
+		mockProvider: mockProvider{items: []Item{
+			{Value: "make test", ID: 7},
+			{Value: "ls"},
+		}, atEnd: true},
+		details: map[int64][]string{7: {"FAIL: TestParse"}},
+	}
+	m := newTestModel(p)
+
+	initCmd := m.Init()
+	m, fetchCmd := drainBatch(t, m, initCmd)
+	result, detailCmd := m.Update(runCmd(fetchCmd))
+	m = result.(Model)
+	require.NotNil(t, detailCmd, "loading items should ask about the selection")
+	result, _ = m.Update(runCmd(detailCmd))
+	m = result.(Model)
+
+	assert.Contains(t, m.viewFooter(), "FAIL: TestParse")
+	assert.Equal(t, []int64{7}, p.asked)
+
+	// Items without an ID, and items already asked about, are not asked about.
+	m.moveSelection(+1)
+	assert.Nil(t, m.fetchDetail())
+	m.moveSelection(-1)
+	assert.Nil(t, m.fetchDetail())
+}
+
 // --- Query / debounce tests ---
 
 func TestTyping_AppendsToQuery(t *testing.T) {
@@ -8,10 +8,12 @@ import "context"
 //
 // Value is the string inserted into the shell when selected.
 // Display is what the picker renders (defaults to Value when empty).
+// ID identifies the item to its provider for DetailProvider (0 = none).
 type Item struct {
 	Value   string
 	Display string
 	Details []string
+	ID      int64
 }
 
 func (it Item) displayText() string {
@@ -27,6 +29,13 @@ type Provider interface {
 	Fetch(ctx context.Context, req Request) (Response, error)
 }
 
+// DetailProvider is implemented by providers that can describe an item
+// beyond its Details, e.g. with what a command printed. The picker asks
+// for the selected item only, and shows the lines below its Details.
+type DetailProvider interface {
+	Detail(ctx context.Context, item Item) ([]string, error)
+}
+
 // Request describes what items the picker wants from a Provider.
 type Request struct {
 	Options   map[string]string // Tab-specific options (session_id, global flag, etc.)
@@ -301,6 +301,9 @@ func baseSuggestionDetailParts(s *pb.Suggestion) []string {
 	if s.Confidence > 0 {
 		parts = append(parts, fmt.Sprintf("conf %.2f", s.Confidence))
 	}
+	if p := s.SuccessProbability; p > 0 && !math.IsNaN(p) {
+		parts = append(parts, fmt.Sprintf("succeeds %d%%", int(math.Round(min(p, 1)*100))))
+	}
 	if suggestionHasCwdSignal(s) {
 		parts = append(parts, "cwd")
 	}
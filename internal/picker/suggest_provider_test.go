@@ -2,6 +2,7 @@ package picker
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -123,3 +124,17 @@ func TestSuggestProvider_RPCError_Propagates(t *testing.T) {
 		t.Fatalf("expected rpc error, got nil")
 	}
 }
+
+func TestFormatSuggestionDetails_SuccessProbability(t *testing.T) {
+	t.Parallel()
+
+	details := formatSuggestionDetails(&pb.Suggestion{Text: "make test", Source: "global", Score: 0.5, SuccessProbability: 0.867})
+	if len(details) == 0 || !strings.Contains(details[0], "succeeds 87%") {
+		t.Fatalf("details = %q, want the success estimate", details)
+	}
+
+	details = formatSuggestionDetails(&pb.Suggestion{Text: "make test", Source: "global", Score: 0.5})
+	if strings.Contains(details[0], "succeeds") {
+		t.Fatalf("details = %q, want no estimate when unknown", details)
+	}
+}
@@ -58,7 +58,8 @@ func (p *AnthropicProvider) TextToCommand(ctx context.Context, req *TextToComman
 	start := time.Now()
 
 	// Build context
-	builder := NewContextBuilder(req.OS, req.Shell, req.CWD, TrimRecentCommands(req.RecentCmds))
+	builder := NewContextBuilder(req.OS, req.Shell, req.CWD, TrimRecentCommands(req.RecentCmds)).
+		WithSessionNote(p.sanitizer.Sanitize(req.SessionNote))
 
 	// Sanitize the prompt
 	sanitizedPrompt := p.sanitizer.Sanitize(req.Prompt)
@@ -112,7 +113,8 @@ func (p *AnthropicProvider) Diagnose(ctx context.Context, req *DiagnoseRequest)
 	start := time.Now()
 
 	// Build context
-	builder := NewContextBuilder(req.OS, req.Shell, req.CWD, TrimRecentCommands(req.RecentCmds))
+	builder := NewContextBuilder(req.OS, req.Shell, req.CWD, TrimRecentCommands(req.RecentCmds)).
+		WithSessionNote(p.sanitizer.Sanitize(req.SessionNote))
 
 	// Sanitize inputs
 	sanitizedCmd := p.sanitizer.Sanitize(req.Command)
@@ -136,6 +138,34 @@ func (p *AnthropicProvider) Diagnose(ctx context.Context, req *DiagnoseRequest)
 	}, nil
 }
 
+// Explain describes what a command does
+func (p *AnthropicProvider) Explain(ctx context.Context, req *ExplainRequest) (*ExplainResponse, error) {
+	start := time.Now()
+
+	// Build context
+	builder := NewContextBuilder(req.OS, req.Shell, req.CWD, nil)
+
+	// Sanitize the command
+	sanitizedCmd := p.sanitizer.Sanitize(req.Command)
+	fullPrompt := builder.BuildExplainPrompt(sanitizedCmd)
+
+	// Query Claude
+	response, err := p.query(ctx, fullPrompt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse explanation response
+	summary, parts := ParseExplainResponse(response)
+
+	return &ExplainResponse{
+		Summary:      summary,
+		Parts:        parts,
+		ProviderName: p.Name(),
+		LatencyMs:    time.Since(start).Milliseconds(),
+	}, nil
+}
+
 // query sends a prompt to Claude CLI, using the daemon when no custom model is set.
 func (p *AnthropicProvider) query(ctx context.Context, prompt string) (string, error) {
 	// Apply timeout
@@ -11,6 +11,7 @@ const (
 	fmtOS          = "- OS: %s\n"
 	fmtShell       = "- Shell: %s\n"
 	fmtWorkDir     = "- Working Directory: %s\n"
+	fmtNote        = "- Session note from the user: %s\n"
 	fmtCmdHistItem = "%d. %s (exit %d)\n"
 )
 
@@ -19,6 +20,7 @@ type ContextBuilder struct {
 	os         string
 	shell      string
 	cwd        string
+	note       string
 	recentCmds []CommandContext
 }
 
@@ -32,6 +34,20 @@ func NewContextBuilder(os, shell, cwd string, recentCmds []CommandContext) *Cont
 	}
 }
 
+// WithSessionNote attaches a user-provided session note to the prompt context.
+// The note should already be sanitized.
+func (b *ContextBuilder) WithSessionNote(note string) *ContextBuilder {
+	b.note = note
+	return b
+}
+
+// writeNote writes the session note line, if any.
+func (b *ContextBuilder) writeNote(sb *strings.Builder) {
+	if b.note != "" {
+		fmt.Fprintf(sb, fmtNote, b.note)
+	}
+}
+
 // BuildTextToCommandPrompt builds the prompt for text-to-command requests
 func (b *ContextBuilder) BuildTextToCommandPrompt(userPrompt string) string {
 	var sb strings.Builder
@@ -41,6 +57,7 @@ func (b *ContextBuilder) BuildTextToCommandPrompt(userPrompt string) string {
 	fmt.Fprintf(&sb, fmtOS, b.os)
 	fmt.Fprintf(&sb, fmtShell, b.shell)
 	fmt.Fprintf(&sb, fmtWorkDir, b.cwd)
+	b.writeNote(&sb)
 
 	if len(b.recentCmds) > 0 {
 		sb.WriteString("\nRecent commands:\n")
@@ -88,6 +105,7 @@ func (b *ContextBuilder) BuildDiagnosePrompt(command string, exitCode int, stder
 	fmt.Fprintf(&sb, fmtOS, b.os)
 	fmt.Fprintf(&sb, fmtShell, b.shell)
 	fmt.Fprintf(&sb, fmtWorkDir, b.cwd)
+	b.writeNote(&sb)
 	fmt.Fprintf(&sb, "\nFailed command: %s\n", command)
 	fmt.Fprintf(&sb, "Exit code: %d\n", exitCode)
 
@@ -109,6 +127,29 @@ func (b *ContextBuilder) BuildDiagnosePrompt(command string, exitCode int, stder
 	return sb.String()
 }
 
+// explainPartSeparator separates a command part from its meaning in
+// explanation responses.
+const explainPartSeparator = " :: "
+
+// BuildExplainPrompt builds the prompt for explaining a command
+func (b *ContextBuilder) BuildExplainPrompt(command string) string {
+	var sb strings.Builder
+
+	sb.WriteString("You are a command-line assistant explaining a shell command.\n\n")
+	sb.WriteString(contextHeader)
+	fmt.Fprintf(&sb, fmtOS, b.os)
+	fmt.Fprintf(&sb, fmtShell, b.shell)
+	fmt.Fprintf(&sb, fmtWorkDir, b.cwd)
+	fmt.Fprintf(&sb, "\nCommand: %s\n", command)
+
+	sb.WriteString("\nRespond without markdown, in this format:\n")
+	sb.WriteString("SUMMARY: what the command does as a whole (1-2 sentences)\n")
+	fmt.Fprintf(&sb, "Then one line per program, subcommand, flag or argument, in command order: <part>%s<what it does>\n", explainPartSeparator)
+	sb.WriteString("Keep a flag and its value on one line, e.g. \"-C /tmp\". Mention anything dangerous in the summary.\n")
+
+	return sb.String()
+}
+
 // maxStderrLen is the maximum number of characters of stderr to include in prompts.
 // The tail is kept since the most relevant error info is typically at the end.
 const maxStderrLen = 4096
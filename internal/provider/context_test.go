@@ -59,6 +59,25 @@ func TestContextBuilder_BuildTextToCommandPrompt(t *testing.T) {
 	}
 }
 
+func TestContextBuilder_SessionNote(t *testing.T) {
+	builder := NewContextBuilder("linux", "bash", "/home", nil).
+		WithSessionNote("debugging the flaky auth test")
+
+	for name, prompt := range map[string]string{
+		"text-to-command": builder.BuildTextToCommandPrompt("run the tests"),
+		"diagnose":        builder.BuildDiagnosePrompt("go test ./auth", 1, ""),
+	} {
+		if !strings.Contains(prompt, "Session note from the user: debugging the flaky auth test") {
+			t.Errorf("%s prompt missing session note\nPrompt:\n%s", name, prompt)
+		}
+	}
+
+	plain := NewContextBuilder("linux", "bash", "/home", nil).BuildTextToCommandPrompt("run the tests")
+	if strings.Contains(plain, "Session note") {
+		t.Error("prompt should not mention a session note when none is set")
+	}
+}
+
 func TestContextBuilder_BuildTextToCommandPrompt_NoRecentCmds(t *testing.T) {
 	builder := NewContextBuilder("linux", "bash", "/home", nil)
 
@@ -159,6 +178,28 @@ func TestContextBuilder_BuildDiagnosePrompt_NoStderr(t *testing.T) {
 	}
 }
 
+func TestContextBuilder_BuildExplainPrompt(t *testing.T) {
+	builder := NewContextBuilder("linux", "bash", "/srv", nil)
+
+	prompt := builder.BuildExplainPrompt("tar -xzf backup.tgz -C /tmp")
+
+	checks := []string{
+		"explaining a shell command",
+		"OS: linux",
+		"Shell: bash",
+		"Working Directory: /srv",
+		"Command: tar -xzf backup.tgz -C /tmp",
+		"SUMMARY:",
+		"<part> :: <what it does>",
+	}
+
+	for _, check := range checks {
+		if !strings.Contains(prompt, check) {
+			t.Errorf("BuildExplainPrompt() missing %q\nPrompt:\n%s", check, prompt)
+		}
+	}
+}
+
 func TestTrimRecentCommands(t *testing.T) {
 	tests := []struct {
 		name     string
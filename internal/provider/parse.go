@@ -188,3 +188,45 @@ func ParseDiagnoseResponse(response string) (string, []Suggestion) {
 
 	return strings.TrimSpace(explanation.String()), fixes
 }
+
+// summaryPrefix starts the summary line of explanation responses.
+const summaryPrefix = "summary:"
+
+// ParseExplainResponse parses an AI explanation response into a summary and
+// the explained parts of the command. Lines that are not
+// "<part> :: <meaning>" make up the summary.
+func ParseExplainResponse(response string) (string, []ExplainedPart) {
+	var summary strings.Builder
+	var parts []ExplainedPart
+
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || isCodeFence(line) {
+			continue
+		}
+
+		// Summary line: "SUMMARY: ..." in any case
+		if len(line) >= len(summaryPrefix) && strings.EqualFold(line[:len(summaryPrefix)], summaryPrefix) {
+			line = strings.TrimSpace(line[len(summaryPrefix):])
+		} else if text, meaning, ok := strings.Cut(line, explainPartSeparator); ok {
+			// Part line, possibly bulleted and with the part in backticks
+			text = strings.TrimPrefix(strings.TrimPrefix(text, "- "), "* ")
+			text = strings.Trim(strings.TrimSpace(text), "`")
+			meaning = strings.TrimSpace(meaning)
+			if text != "" && meaning != "" {
+				parts = append(parts, ExplainedPart{Text: text, Meaning: meaning})
+				continue
+			}
+		}
+
+		if line == "" {
+			continue
+		}
+		if summary.Len() > 0 {
+			summary.WriteString(" ")
+		}
+		summary.WriteString(line)
+	}
+
+	return summary.String(), parts
+}
@@ -182,6 +182,66 @@ $ npm install`,
 	}
 }
 
+func TestParseExplainResponse(t *testing.T) {
+	tests := []struct {
+		name            string
+		response        string
+		expectedSummary string
+		expectedParts   []ExplainedPart
+	}{
+		{
+			name: "summary and parts",
+			response: `SUMMARY: Extracts backup.tgz into /tmp.
+tar :: the archive tool
+-xzf :: extract (-x) a gzip-compressed (-z) archive file (-f)
+-C /tmp :: change to /tmp before extracting`,
+			expectedSummary: "Extracts backup.tgz into /tmp.",
+			expectedParts: []ExplainedPart{
+				{Text: "tar", Meaning: "the archive tool"},
+				{Text: "-xzf", Meaning: "extract (-x) a gzip-compressed (-z) archive file (-f)"},
+				{Text: "-C /tmp", Meaning: "change to /tmp before extracting"},
+			},
+		},
+		{
+			name:            "markdown bullets, backticks and fences",
+			response:        "```\nsummary: Lists files.\n- `ls` :: lists a directory\n* `-la` :: all files, long format\n```",
+			expectedSummary: "Lists files.",
+			expectedParts: []ExplainedPart{
+				{Text: "ls", Meaning: "lists a directory"},
+				{Text: "-la", Meaning: "all files, long format"},
+			},
+		},
+		{
+			name:            "multi-line summary without prefix",
+			response:        "Deletes the build directory.\nIt cannot be undone.",
+			expectedSummary: "Deletes the build directory. It cannot be undone.",
+		},
+		{
+			name:            "part without meaning stays in summary",
+			response:        "SUMMARY: Prints a module.\nperl -MFoo::Bar :: ",
+			expectedSummary: "Prints a module. perl -MFoo::Bar ::",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, parts := ParseExplainResponse(tt.response)
+
+			if summary != tt.expectedSummary {
+				t.Errorf("ParseExplainResponse() summary = %q, want %q", summary, tt.expectedSummary)
+			}
+			if len(parts) != len(tt.expectedParts) {
+				t.Fatalf("ParseExplainResponse() parts = %v, want %v", parts, tt.expectedParts)
+			}
+			for i, expected := range tt.expectedParts {
+				if parts[i] != expected {
+					t.Errorf("ParseExplainResponse() part[%d] = %v, want %v", i, parts[i], expected)
+				}
+			}
+		})
+	}
+}
+
 // TestParseDiagnoseResponse_FixScores verifies that fix scores decrease with position
 func TestParseDiagnoseResponse_FixScores(t *testing.T) {
 	response := `Error message.
@@ -1,5 +1,5 @@
 // Package provider implements AI provider adapters for text-to-command,
-// next step prediction, and error diagnosis.
+// next step prediction, error diagnosis, and command explanation.
 package provider
 
 import (
@@ -32,6 +32,9 @@ type Provider interface {
 
 	// Diagnose analyzes a failed command and suggests fixes
 	Diagnose(ctx context.Context, req *DiagnoseRequest) (*DiagnoseResponse, error)
+
+	// Explain describes what a command does, part by part
+	Explain(ctx context.Context, req *ExplainRequest) (*ExplainResponse, error)
 }
 
 // CommandContext represents context about a previously executed command
@@ -42,11 +45,12 @@ type CommandContext struct {
 
 // TextToCommandRequest is the request for text-to-command conversion
 type TextToCommandRequest struct {
-	Prompt     string
-	CWD        string
-	OS         string
-	Shell      string
-	RecentCmds []CommandContext
+	Prompt      string
+	CWD         string
+	OS          string
+	Shell       string
+	SessionNote string // Free-text note the user attached to the session
+	RecentCmds  []CommandContext
 }
 
 // TextToCommandResponse is the response from text-to-command conversion
@@ -76,14 +80,15 @@ type NextStepResponse struct {
 
 // DiagnoseRequest is the request for error diagnosis
 type DiagnoseRequest struct {
-	SessionID  string
-	Command    string
-	CWD        string
-	OS         string
-	Shell      string
-	StdErr     string
-	RecentCmds []CommandContext
-	ExitCode   int
+	SessionID   string
+	Command     string
+	CWD         string
+	OS          string
+	Shell       string
+	StdErr      string
+	SessionNote string // Free-text note the user attached to the session
+	RecentCmds  []CommandContext
+	ExitCode    int
 }
 
 // DiagnoseResponse is the response from error diagnosis
@@ -94,6 +99,29 @@ type DiagnoseResponse struct {
 	LatencyMs    int64
 }
 
+// ExplainRequest is the request for explaining a command
+type ExplainRequest struct {
+	Command string
+	CWD     string
+	OS      string
+	Shell   string
+}
+
+// ExplainResponse is the response from command explanation
+type ExplainResponse struct {
+	Summary      string // What the command does as a whole
+	ProviderName string
+	Parts        []ExplainedPart
+	LatencyMs    int64
+}
+
+// ExplainedPart is one program, subcommand, flag or argument of an
+// explained command, in command order
+type ExplainedPart struct {
+	Text    string // The part as written, e.g. "-C /tmp"
+	Meaning string
+}
+
 // Suggestion represents a command suggestion
 type Suggestion struct {
 	Text        string  // The suggested command
@@ -44,6 +44,15 @@ func (m *MockProvider) Diagnose(_ context.Context, req *DiagnoseRequest) (*Diagn
 	}, nil
 }
 
+func (m *MockProvider) Explain(_ context.Context, req *ExplainRequest) (*ExplainResponse, error) {
+	return &ExplainResponse{
+		Summary:      "Mock summary",
+		Parts:        []ExplainedPart{{Text: "mock", Meaning: "mock part"}},
+		ProviderName: m.name,
+		LatencyMs:    10,
+	}, nil
+}
+
 func TestNewRegistry(t *testing.T) {
 	r := NewRegistry()
 	if r == nil {
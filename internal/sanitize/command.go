@@ -0,0 +1,90 @@
+package sanitize
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// commandPatterns are redacted from commands before they are stored, in
+// addition to the secret patterns applied before AI calls.
+var commandPatterns = []Pattern{
+	{
+		// --password hunter2, --api-key=..., -token ...
+		Name:        "Secret Flag",
+		Regex:       regexp.MustCompile(`(?i)(^|\s)(--?(?:[a-z0-9]+[_-])*(?:password|passwd|token|secret|api[_-]?key))(=|\s+)[^\s-]\S*`),
+		Replacement: "$1$2$3[REDACTED]",
+	},
+}
+
+// High-entropy tokens: a run of base64/base64url characters at least
+// minEntropyTokenLen long, holding letters and digits, whose Shannon entropy
+// is at least minTokenEntropy bits per character. Hex digests such as git
+// SHAs stay below the threshold (at most 4 bits per character).
+const (
+	minEntropyTokenLen = 20
+	minTokenEntropy    = 4.3
+)
+
+var entropyTokenRegexp = regexp.MustCompile(`[A-Za-z0-9+/_-]{20,}={0,2}`)
+
+// HighEntropyReplacement replaces high-entropy tokens in stored commands.
+const HighEntropyReplacement = "[HIGH_ENTROPY_REDACTED]"
+
+// NewCommandSanitizer creates a Sanitizer for commands about to be written
+// to the history databases. Besides the secret patterns it redacts secret
+// flags such as --password and high-entropy tokens, and replaces every
+// match of the extra regular expressions with "[REDACTED]".
+func NewCommandSanitizer(extra []string) (*Sanitizer, error) {
+	patterns := GetSecretPatterns()
+	patterns = append(patterns, commandPatterns...)
+	for _, expr := range extra {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", expr, err)
+		}
+		patterns = append(patterns, Pattern{Name: "Custom", Regex: re, Replacement: "[REDACTED]"})
+	}
+	return &Sanitizer{patterns: patterns, redactHighEntropy: true}, nil
+}
+
+// redactHighEntropyTokens replaces the high-entropy tokens in s.
+func redactHighEntropyTokens(s string) string {
+	return entropyTokenRegexp.ReplaceAllStringFunc(s, func(tok string) string {
+		if isHighEntropy(strings.TrimRight(tok, "=")) {
+			return HighEntropyReplacement
+		}
+		return tok
+	})
+}
+
+func isHighEntropy(tok string) bool {
+	if len(tok) < minEntropyTokenLen {
+		return false
+	}
+	var counts [256]int
+	var letters, digits bool
+	for i := 0; i < len(tok); i++ {
+		c := tok[i]
+		counts[c]++
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			letters = true
+		}
+	}
+	if !letters || !digits {
+		return false
+	}
+	var entropy float64
+	n := float64(len(tok))
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / n
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy >= minTokenEntropy
+}
@@ -0,0 +1,19 @@
+package sanitize
+
+import "github.com/runger/clai/internal/config"
+
+// RulesFromConfig converts suggestions.risk_patterns to the rules
+// SetRiskRules merges with the built-in patterns.
+func RulesFromConfig(patterns []config.RiskPattern) []RiskRule {
+	rules := make([]RiskRule, len(patterns))
+	for i, p := range patterns {
+		rules[i] = RiskRule{
+			Name:    p.Name,
+			Pattern: p.Pattern,
+			Level:   RiskLevel(p.Level),
+			Message: p.Message,
+			Allow:   p.Allow,
+		}
+	}
+	return rules
+}
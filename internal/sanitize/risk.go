@@ -1,8 +1,11 @@
 package sanitize
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
 	"strings"
+	"sync/atomic"
 )
 
 // RiskLevel represents the risk level of a command
@@ -19,6 +22,21 @@ const (
 type riskPattern struct {
 	Pattern *regexp.Regexp
 	Name    string
+	Level   RiskLevel        // Empty means RiskDestructive
+	Message string           // Shown with the warning; empty for built-ins
+	Allow   []*regexp.Regexp // Matching commands are exempt from this pattern
+}
+
+// RiskRule is a user-defined risk pattern, merged with the built-in ones.
+// A rule with a Pattern flags matching commands with Level; one with only
+// the Name of a built-in pattern extends that pattern's allowlist and
+// message. Allow lists regular expressions exempting matching commands.
+type RiskRule struct {
+	Name    string
+	Pattern string
+	Level   RiskLevel // RiskDestructive (default) or RiskSafe
+	Message string
+	Allow   []string
 }
 
 // destructivePatterns contains patterns for detecting destructive commands
@@ -77,36 +95,162 @@ var destructivePatterns = []riskPattern{
 	{Name: "kubectl delete", Pattern: regexp.MustCompile(`\bkubectl\s+delete\b`)},
 }
 
-// IsDestructive checks if a command contains destructive patterns
-func IsDestructive(command string) bool {
+// activeRiskPatterns holds the custom patterns followed by the built-in ones.
+var activeRiskPatterns atomic.Pointer[[]riskPattern]
+
+func init() {
+	activeRiskPatterns.Store(&destructivePatterns)
+}
+
+// SetRiskRules replaces the custom risk rules used by IsDestructive,
+// GetRiskLevel and Classify. Custom rules are checked before the built-in
+// patterns, in order, and the first pattern matching a command decides its
+// level, so a RiskSafe rule can exempt commands a built-in pattern flags.
+// Invalid rules are skipped and reported in the returned error.
+func SetRiskRules(rules []RiskRule) error {
+	builtins := make([]riskPattern, len(destructivePatterns))
+	copy(builtins, destructivePatterns)
+
+	var custom []riskPattern
+	var errs []error
+	for i := range rules {
+		r := &rules[i]
+		allow, err := compileAllow(r.Allow)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("risk rule %q: %w", ruleLabel(r, i), err))
+			continue
+		}
+		if r.Level != "" && r.Level != RiskDestructive && r.Level != RiskSafe {
+			errs = append(errs, fmt.Errorf("risk rule %q: invalid level %q", ruleLabel(r, i), r.Level))
+			continue
+		}
+		if r.Pattern == "" {
+			if !extendBuiltin(builtins, r, allow) {
+				errs = append(errs, fmt.Errorf("risk rule %q: no pattern and no built-in pattern of that name", ruleLabel(r, i)))
+			}
+			continue
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("risk rule %q: invalid pattern: %w", ruleLabel(r, i), err))
+			continue
+		}
+		custom = append(custom, riskPattern{Name: ruleLabel(r, i), Pattern: re, Level: r.Level, Message: r.Message, Allow: allow})
+	}
+
+	custom = append(custom, builtins...)
+	activeRiskPatterns.Store(&custom)
+	return errors.Join(errs...)
+}
+
+// extendBuiltin adds r's allowlist and message to the built-in pattern
+// named r.Name and reports whether there is one.
+func extendBuiltin(builtins []riskPattern, r *RiskRule, allow []*regexp.Regexp) bool {
+	for i := range builtins {
+		if builtins[i].Name != r.Name {
+			continue
+		}
+		builtins[i].Allow = append(builtins[i].Allow, allow...)
+		if r.Message != "" {
+			builtins[i].Message = r.Message
+		}
+		if r.Level != "" {
+			builtins[i].Level = r.Level
+		}
+		return true
+	}
+	return false
+}
+
+func compileAllow(exprs []string) ([]*regexp.Regexp, error) {
+	allow := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow pattern %q: %w", expr, err)
+		}
+		allow = append(allow, re)
+	}
+	return allow, nil
+}
+
+func ruleLabel(r *RiskRule, i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+// Classify returns the risk level of a command and the message of the
+// pattern that decided it, empty for safe commands and built-in patterns
+// without a configured message.
+func Classify(command string) (level RiskLevel, message string) {
 	// Normalize whitespace
 	cmd := strings.TrimSpace(command)
+	if p := decidingPattern(cmd); p != nil && p.Level != RiskSafe {
+		return RiskDestructive, p.Message
+	}
+	return RiskSafe, ""
+}
+
+// Match returns the name and message of the pattern flagging command as
+// destructive, and whether there is one.
+func Match(command string) (name, message string, ok bool) {
+	p := decidingPattern(strings.TrimSpace(command))
+	if p == nil || p.Level == RiskSafe {
+		return "", "", false
+	}
+	return p.Name, p.Message, true
+}
+
+// decidingPattern returns the first active pattern matching cmd that does
+// not exempt it, or nil.
+func decidingPattern(cmd string) *riskPattern {
 	if cmd == "" {
-		return false
+		return nil
+	}
+	active := *activeRiskPatterns.Load()
+	for i := range active {
+		if active[i].Pattern.MatchString(cmd) && !allowed(active[i].Allow, cmd) {
+			return &active[i]
+		}
 	}
+	return nil
+}
 
-	for _, p := range destructivePatterns {
-		if p.Pattern.MatchString(cmd) {
+func allowed(allow []*regexp.Regexp, cmd string) bool {
+	for _, re := range allow {
+		if re.MatchString(cmd) {
 			return true
 		}
 	}
 	return false
 }
 
+// IsDestructive checks if a command contains destructive patterns
+func IsDestructive(command string) bool {
+	level, _ := Classify(command)
+	return level == RiskDestructive
+}
+
 // GetRiskLevel returns the risk level for a command
 func GetRiskLevel(command string) RiskLevel {
-	if IsDestructive(command) {
-		return RiskDestructive
-	}
-	return RiskSafe
+	level, _ := Classify(command)
+	return level
 }
 
 // GetDestructivePatterns returns the list of destructive command patterns
 // Useful for testing and documentation
 func GetDestructivePatterns() []string {
-	patterns := make([]string, len(destructivePatterns))
-	for i, p := range destructivePatterns {
-		patterns[i] = p.Name
+	active := *activeRiskPatterns.Load()
+	patterns := make([]string, 0, len(active))
+	for _, p := range active {
+		if p.Level != RiskSafe {
+			patterns = append(patterns, p.Name)
+		}
 	}
 	return patterns
 }
@@ -1,6 +1,7 @@
 package sanitize
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -372,3 +373,107 @@ func TestRiskLevel_String(t *testing.T) {
 		t.Errorf("RiskDestructive = %q, want %q", RiskDestructive, "destructive")
 	}
 }
+
+func TestSetRiskRules(t *testing.T) {
+	t.Cleanup(func() { _ = SetRiskRules(nil) })
+
+	err := SetRiskRules([]RiskRule{
+		{
+			Name:    "terraform destroy prod",
+			Pattern: `\bterraform\s+destroy\b.*\bprod\b`,
+			Message: "destroys production infrastructure",
+		},
+		{Name: "kubectl delete", Allow: []string{`-n\s+dev\b`}},
+		{Name: "kill test servers", Pattern: `\bpkill\s+-f\s+test-server\b`, Level: RiskSafe},
+	})
+	if err != nil {
+		t.Fatalf("SetRiskRules() error = %v", err)
+	}
+
+	tests := []struct {
+		command     string
+		wantLevel   RiskLevel
+		wantMessage string
+	}{
+		{"terraform destroy -var env=prod", RiskDestructive, "destroys production infrastructure"},
+		{"terraform destroy -var env=staging", RiskSafe, ""},
+		{"kubectl delete pod web -n dev", RiskSafe, ""},
+		{"kubectl delete pod web -n prod", RiskDestructive, ""},
+		{"pkill -f test-server", RiskSafe, ""},
+		{"pkill node", RiskDestructive, ""},
+		{"rm -rf /tmp/test", RiskDestructive, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			level, message := Classify(tt.command)
+			if level != tt.wantLevel || message != tt.wantMessage {
+				t.Errorf("Classify(%q) = (%v, %q), want (%v, %q)", tt.command, level, message, tt.wantLevel, tt.wantMessage)
+			}
+			if got := IsDestructive(tt.command); got != (tt.wantLevel == RiskDestructive) {
+				t.Errorf("IsDestructive(%q) = %v", tt.command, got)
+			}
+		})
+	}
+
+	if err := SetRiskRules(nil); err != nil {
+		t.Fatalf("SetRiskRules(nil) error = %v", err)
+	}
+	if IsDestructive("terraform destroy -var env=prod") {
+		t.Error("custom rule still applied after clearing the rules")
+	}
+	if !IsDestructive("kubectl delete pod web -n dev") {
+		t.Error("built-in allowlist still applied after clearing the rules")
+	}
+}
+
+func TestSetRiskRules_Invalid(t *testing.T) {
+	t.Cleanup(func() { _ = SetRiskRules(nil) })
+
+	err := SetRiskRules([]RiskRule{
+		{Name: "bad regex", Pattern: `(`},
+		{Name: "bad allow", Pattern: `\bhelm\s+uninstall\b`, Allow: []string{`[`}},
+		{Name: "bad level", Pattern: `\bhelm\s+rollback\b`, Level: "scary"},
+		{Name: "no such built-in", Allow: []string{`x`}},
+		{Name: "valid", Pattern: `\bhelm\s+delete\b`},
+	})
+	if err == nil {
+		t.Fatal("SetRiskRules() error = nil, want errors for the invalid rules")
+	}
+	for _, name := range []string{"bad regex", "bad allow", "bad level", "no such built-in"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not mention rule %q", err, name)
+		}
+	}
+	if !IsDestructive("helm delete web") {
+		t.Error("valid rule not applied alongside invalid ones")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	t.Cleanup(func() { _ = SetRiskRules(nil) })
+
+	if err := SetRiskRules([]RiskRule{
+		{Name: "terraform destroy", Pattern: `\bterraform\s+destroy\b`, Message: "destroys infrastructure"},
+		{Name: "clean node_modules", Pattern: `\brm\s+-rf\s+node_modules$`, Level: RiskSafe},
+	}); err != nil {
+		t.Fatalf("SetRiskRules() error = %v", err)
+	}
+
+	tests := []struct {
+		command     string
+		wantName    string
+		wantMessage string
+	}{
+		{"  terraform destroy", "terraform destroy", "destroys infrastructure"},
+		{"git reset --hard HEAD~1", "git reset hard", ""},
+		{"rm -rf node_modules", "", ""},
+		{"ls -la", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		name, message, ok := Match(tt.command)
+		if name != tt.wantName || message != tt.wantMessage || ok != (tt.wantName != "") {
+			t.Errorf("Match(%q) = (%q, %q, %v), want (%q, %q)", tt.command, name, message, ok, tt.wantName, tt.wantMessage)
+		}
+	}
+}
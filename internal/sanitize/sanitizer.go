@@ -2,7 +2,8 @@ package sanitize
 
 // Sanitizer provides methods for sanitizing sensitive data from text
 type Sanitizer struct {
-	patterns []Pattern
+	patterns          []Pattern
+	redactHighEntropy bool
 }
 
 // NewSanitizer creates a new Sanitizer with default patterns
@@ -30,6 +31,9 @@ func (s *Sanitizer) Sanitize(input string) string {
 	for _, p := range s.patterns {
 		result = p.Regex.ReplaceAllString(result, p.Replacement)
 	}
+	if s.redactHighEntropy {
+		result = redactHighEntropyTokens(result)
+	}
 	return result
 }
 
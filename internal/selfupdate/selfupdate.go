@@ -0,0 +1,307 @@
+// Package selfupdate downloads clai release binaries and installs them in
+// place of the running ones.
+//
+// Releases are published by goreleaser as one archive per platform named
+// clai_<version>_<os>_<arch>.tar.gz, alongside a checksums.txt listing the
+// SHA256 of every archive. An archive is only unpacked once its checksum
+// matches.
+package selfupdate
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRepo is the GitHub repository releases are published to.
+	DefaultRepo = "runger/clai"
+
+	// DefaultAPIBase is the GitHub API endpoint.
+	DefaultAPIBase = "https://api.github.com"
+
+	// ChecksumsAsset is the name of the checksum file in every release.
+	ChecksumsAsset = "checksums.txt"
+
+	// maxDownloadBytes bounds a single download.
+	maxDownloadBytes = 256 << 20
+)
+
+// ErrNoAsset is returned when a release has no archive for this platform.
+var ErrNoAsset = errors.New("release has no archive for this platform")
+
+// ErrChecksumMismatch is returned when a downloaded archive does not match
+// the checksum published with the release.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Release is a published clai release.
+type Release struct {
+	// Assets maps asset names to download URLs.
+	Assets map[string]string
+
+	// Tag is the release tag, e.g. "v0.4.0".
+	Tag string
+}
+
+// Version returns the release version without the leading "v".
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// Client fetches releases from GitHub.
+type Client struct {
+	HTTP    *http.Client
+	APIBase string
+	Repo    string
+}
+
+// NewClient returns a client for the default repository.
+func NewClient() *Client {
+	return &Client{
+		HTTP:    &http.Client{Timeout: 2 * time.Minute},
+		APIBase: DefaultAPIBase,
+		Repo:    DefaultRepo,
+	}
+}
+
+// FetchRelease returns the release tagged version, or the latest release
+// when version is empty. A version without a leading "v" is accepted.
+func (c *Client) FetchRelease(ctx context.Context, version string) (*Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", c.APIBase, c.Repo)
+	if version != "" {
+		if !strings.HasPrefix(version, "v") {
+			version = "v" + version
+		}
+		url = fmt.Sprintf("%s/repos/%s/releases/tags/%s", c.APIBase, c.Repo, version)
+	}
+
+	body, err := c.get(ctx, url, "application/vnd.github+json")
+	if err != nil {
+		return nil, fmt.Errorf("fetch release: %w", err)
+	}
+
+	var payload struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decode release: %w", err)
+	}
+	if payload.TagName == "" {
+		return nil, errors.New("decode release: missing tag name")
+	}
+
+	rel := &Release{Tag: payload.TagName, Assets: make(map[string]string, len(payload.Assets))}
+	for _, a := range payload.Assets {
+		rel.Assets[a.Name] = a.URL
+	}
+	return rel, nil
+}
+
+// ArchiveName returns the name of the release archive for a platform.
+func ArchiveName(version, goos, goarch string) string {
+	return fmt.Sprintf("clai_%s_%s_%s.tar.gz", strings.TrimPrefix(version, "v"), goos, goarch)
+}
+
+// DownloadArchive downloads the archive for goos/goarch from rel and
+// verifies it against the release's checksums.txt.
+func (c *Client) DownloadArchive(ctx context.Context, rel *Release, goos, goarch string) ([]byte, error) {
+	name := ArchiveName(rel.Version(), goos, goarch)
+	archiveURL, ok := rel.Assets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoAsset, name)
+	}
+	sumsURL, ok := rel.Assets[ChecksumsAsset]
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s", rel.Tag, ChecksumsAsset)
+	}
+
+	sums, err := c.get(ctx, sumsURL, "")
+	if err != nil {
+		return nil, fmt.Errorf("download checksums: %w", err)
+	}
+	want, ok := ParseChecksums(sums)[name]
+	if !ok {
+		return nil, fmt.Errorf("%s does not list %s", ChecksumsAsset, name)
+	}
+
+	archive, err := c.get(ctx, archiveURL, "")
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", name, err)
+	}
+	if err := VerifyChecksum(archive, want); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return archive, nil
+}
+
+func (c *Client) get(ctx context.Context, url, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadBytes {
+		return nil, fmt.Errorf("GET %s: response larger than %d bytes", url, maxDownloadBytes)
+	}
+	return data, nil
+}
+
+// ParseChecksums parses a goreleaser checksums.txt ("<sha256>  <name>" per
+// line) into a map from file name to lowercase hex digest.
+func ParseChecksums(data []byte) map[string]string {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return sums
+}
+
+// VerifyChecksum checks that data hashes to the hex SHA256 digest want.
+func VerifyChecksum(data []byte, want string) error {
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != strings.ToLower(want) {
+		return fmt.Errorf("%w: got %s, want %s", ErrChecksumMismatch, got, want)
+	}
+	return nil
+}
+
+// ExtractBinary returns the contents of the file called name from a
+// .tar.gz archive. The file may sit in a subdirectory of the archive.
+func ExtractBinary(archive []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("archive does not contain %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || path.Base(hdr.Name) != name {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxDownloadBytes))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		return data, nil
+	}
+}
+
+// ReplaceFile atomically replaces the executable at target with data. The
+// new file is written next to target and renamed over it, so a running
+// process keeps its old binary and the next exec picks up the new one.
+func ReplaceFile(target string, data []byte) error {
+	return ReplaceFiles([]File{{Path: target, Data: data}})
+}
+
+// File is an executable to install with ReplaceFiles.
+type File struct {
+	Path string
+	Data []byte
+}
+
+// ReplaceFiles replaces several executables the way ReplaceFile does. Every
+// new file is written next to its target before any is renamed into place,
+// so a target directory that cannot be written leaves all of them
+// untouched.
+func ReplaceFiles(files []File) error {
+	staged := make([]string, 0, len(files))
+	defer func() {
+		for _, tmpPath := range staged {
+			os.Remove(tmpPath) //nolint:errcheck // gone after a successful rename
+		}
+	}()
+
+	for _, f := range files {
+		tmpPath, err := stageFile(f.Path, f.Data)
+		if err != nil {
+			return err
+		}
+		staged = append(staged, tmpPath)
+	}
+	for i, f := range files {
+		if err := os.Rename(staged[i], f.Path); err != nil {
+			return fmt.Errorf("replace %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// stageFile writes data to an executable temp file next to target and
+// returns its path.
+func stageFile(target string, data []byte) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".new-*")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	if err := writeExecutable(tmp, data); err != nil {
+		os.Remove(tmpPath) //nolint:errcheck // best-effort cleanup
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+func writeExecutable(tmp *os.File, data []byte) error {
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", tmp.Name(), err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil { //nolint:gosec // G302: executables must be world-executable
+		return fmt.Errorf("chmod %s: %w", tmp.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func makeArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sha(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// newReleaseServer serves a v1.2.3 release with one linux/amd64 archive.
+// checksum overrides the published digest when non-empty.
+func newReleaseServer(t *testing.T, archive []byte, checksum string) *httptest.Server {
+	t.Helper()
+	name := ArchiveName("1.2.3", "linux", "amd64")
+	if checksum == "" {
+		checksum = sha(archive)
+	}
+
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/repos/runger/clai/releases/latest", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"tag_name": "v1.2.3",
+			"assets": []map[string]string{
+				{"name": name, "browser_download_url": srv.URL + "/dl/" + name},
+				{"name": ChecksumsAsset, "browser_download_url": srv.URL + "/dl/" + ChecksumsAsset},
+			},
+		})
+	})
+	mux.HandleFunc("/dl/"+name, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(archive)
+	})
+	mux.HandleFunc("/dl/"+ChecksumsAsset, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(checksum + "  " + name + "\n"))
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testClient(srv *httptest.Server) *Client {
+	return &Client{HTTP: srv.Client(), APIBase: srv.URL, Repo: DefaultRepo}
+}
+
+func TestDownloadArchive_VerifiesAndExtracts(t *testing.T) {
+	t.Parallel()
+
+	archive := makeArchive(t, map[string]string{"clai": "cli", "claid": "daemon-v1.2.3"})
+	c := testClient(newReleaseServer(t, archive, ""))
+
+	rel, err := c.FetchRelease(context.Background(), "")
+	if err != nil {
+		t.Fatalf("FetchRelease() error = %v", err)
+	}
+	if rel.Version() != "1.2.3" {
+		t.Errorf("Version() = %q, want 1.2.3", rel.Version())
+	}
+
+	data, err := c.DownloadArchive(context.Background(), rel, "linux", "amd64")
+	if err != nil {
+		t.Fatalf("DownloadArchive() error = %v", err)
+	}
+	bin, err := ExtractBinary(data, "claid")
+	if err != nil {
+		t.Fatalf("ExtractBinary() error = %v", err)
+	}
+	if string(bin) != "daemon-v1.2.3" {
+		t.Errorf("claid = %q, want daemon-v1.2.3", bin)
+	}
+}
+
+func TestDownloadArchive_ChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	archive := makeArchive(t, map[string]string{"claid": "x"})
+	c := testClient(newReleaseServer(t, archive, sha([]byte("something else"))))
+
+	rel, err := c.FetchRelease(context.Background(), "")
+	if err != nil {
+		t.Fatalf("FetchRelease() error = %v", err)
+	}
+	if _, err := c.DownloadArchive(context.Background(), rel, "linux", "amd64"); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("DownloadArchive() error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestDownloadArchive_NoAssetForPlatform(t *testing.T) {
+	t.Parallel()
+
+	c := testClient(newReleaseServer(t, makeArchive(t, nil), ""))
+	rel, err := c.FetchRelease(context.Background(), "")
+	if err != nil {
+		t.Fatalf("FetchRelease() error = %v", err)
+	}
+	if _, err := c.DownloadArchive(context.Background(), rel, "plan9", "386"); !errors.Is(err, ErrNoAsset) {
+		t.Errorf("DownloadArchive() error = %v, want ErrNoAsset", err)
+	}
+}
+
+func TestExtractBinary_Missing(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ExtractBinary(makeArchive(t, map[string]string{"clai": "x"}), "claid"); err == nil {
+		t.Error("ExtractBinary() succeeded for a missing file")
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	t.Parallel()
+
+	sums := ParseChecksums([]byte("ABC123  clai_1.0.0_linux_amd64.tar.gz\ndef456 *checksums.sig\n\ngarbage\n"))
+	if sums["clai_1.0.0_linux_amd64.tar.gz"] != "abc123" {
+		t.Errorf("archive digest = %q, want abc123", sums["clai_1.0.0_linux_amd64.tar.gz"])
+	}
+	if sums["checksums.sig"] != "def456" {
+		t.Errorf("binary-mode digest = %q, want def456", sums["checksums.sig"])
+	}
+	if len(sums) != 2 {
+		t.Errorf("len(sums) = %d, want 2", len(sums))
+	}
+}
+
+func TestReplaceFile(t *testing.T) {
+	t.Parallel()
+
+	target := filepath.Join(t.TempDir(), "claid")
+	if err := os.WriteFile(target, []byte("old"), 0o755); err != nil { //nolint:gosec // test executable
+		t.Fatal(err)
+	}
+	if err := ReplaceFile(target, []byte("new")); err != nil {
+		t.Fatalf("ReplaceFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "new" {
+		t.Errorf("target = %q, %v; want new", data, err)
+	}
+	info, err := os.Stat(target)
+	if err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("target mode = %v, %v; want executable", info.Mode(), err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(target))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the target", len(entries))
+	}
+}
+
+func TestReplaceFiles_UnwritableTargetReplacesNone(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "clai")
+	if err := os.WriteFile(first, []byte("old"), 0o755); err != nil { //nolint:gosec // test executable
+		t.Fatal(err)
+	}
+	err := ReplaceFiles([]File{
+		{Path: first, Data: []byte("new")},
+		{Path: filepath.Join(dir, "missing", "claid"), Data: []byte("new")},
+	})
+	if err == nil {
+		t.Fatal("ReplaceFiles() error = nil, want error for missing directory")
+	}
+
+	data, err := os.ReadFile(first)
+	if err != nil || string(data) != "old" {
+		t.Errorf("first target = %q, %v; want old", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the first target", len(entries))
+	}
+}
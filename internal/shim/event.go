@@ -33,11 +33,19 @@ type ShimEvent struct {
 	GitRepoRoot   string `json:"git_repo_root,omitempty"`
 	PrevCommandID string `json:"prev_command_id,omitempty"`
 
+	// Ephemeral marks a command of an incognito session (command_start).
+	Ephemeral bool `json:"ephemeral,omitempty"`
+
 	// DurationMs is the command duration in milliseconds (command_end).
 	DurationMs int64 `json:"duration_ms,omitempty"`
 
 	// ExitCode is the command exit code (command_end).
 	ExitCode int `json:"exit_code,omitempty"`
+
+	// Stdout and Stderr are what the command printed, for shells that
+	// capture it (command_end). Only their tails are sent to the daemon.
+	Stdout string `json:"stdout,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
 }
 
 // Event type constants.
@@ -74,7 +74,7 @@ func testCommandStart(t *testing.T) {
 
 func testCommandEnd(t *testing.T) {
 	t.Helper()
-	data := []byte(`{"type":"command_end","session_id":"s1","command_id":"c1","exit_code":1,"duration_ms":500}`)
+	data := []byte(`{"type":"command_end","session_id":"s1","command_id":"c1","exit_code":1,"duration_ms":500,"stderr":"no such file"}`)
 	ev, err := ParseShimEvent(data)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -88,6 +88,9 @@ func testCommandEnd(t *testing.T) {
 	if ev.DurationMs != 500 {
 		t.Errorf("duration_ms = %d, want %d", ev.DurationMs, 500)
 	}
+	if ev.Stderr != "no such file" {
+		t.Errorf("stderr = %q, want %q", ev.Stderr, "no such file")
+	}
 }
 
 func testParseErrors(t *testing.T) {
@@ -59,12 +59,13 @@ func (d *ClientDispatcher) Dispatch(ev *ShimEvent) error {
 			GitRepoName:   ev.GitRepoName,
 			GitRepoRoot:   ev.GitRepoRoot,
 			PrevCommandID: ev.PrevCommandID,
+			Ephemeral:     ev.Ephemeral,
 		}
 		d.client.LogStartWithContext(ev.SessionID, ev.CommandID, ev.Cwd, ev.Command, cmdCtx)
 		return nil
 
 	case EventCommandEnd:
-		d.client.LogEnd(ev.SessionID, ev.CommandID, ev.ExitCode, ev.DurationMs)
+		d.client.LogEndWithOutput(ev.SessionID, ev.CommandID, ev.ExitCode, ev.DurationMs, ev.Stdout, ev.Stderr)
 		return nil
 
 	default:
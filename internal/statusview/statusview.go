@@ -0,0 +1,221 @@
+// Package statusview renders daemon status for `clai status` and `clai-shim status`.
+package statusview
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+// Provider is the availability of a single AI provider.
+type Provider struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Preferred bool   `json:"preferred,omitempty"`
+}
+
+// Subsystem is the health of a single daemon subsystem.
+type Subsystem struct {
+	Name          string `json:"name"`
+	Status        string `json:"status"`
+	Detail        string `json:"detail,omitempty"`
+	LastRunUnixMs int64  `json:"last_run_unix_ms,omitempty"`
+}
+
+// Health is the result of the daemon's subsystem checks.
+type Health struct {
+	Status     string      `json:"status"`
+	Subsystems []Subsystem `json:"subsystems"`
+}
+
+// View is the client-side view of daemon status.
+// JSON keys are stable for scripts; the original four keys are unchanged.
+type View struct {
+	Version               string     `json:"version"`
+	ClientVersion         string     `json:"client_version"`
+	ScorerVersion         string     `json:"scorer_version,omitempty"`
+	Providers             []Provider `json:"providers"`
+	UptimeSeconds         int64      `json:"uptime_seconds"`
+	CommandsLogged        int64      `json:"commands_logged"`
+	StateDBBytes          int64      `json:"state_db_bytes"`
+	SuggestionsDBBytes    int64      `json:"suggestions_db_bytes"`
+	LastMaintenanceUnixMs int64      `json:"last_maintenance_unix_ms"`
+	PID                   int32      `json:"pid,omitempty"`
+	ActiveSessions        int32      `json:"active_sessions"`
+	DegradedSessions      int32      `json:"degraded_sessions,omitempty"` // Sessions over their latency budget
+	IngestQueueDepth      int32      `json:"ingest_queue_depth"`
+	Health                *Health    `json:"health,omitempty"` // Only with --verbose
+}
+
+// FromProto builds a View from a daemon status response.
+func FromProto(resp *pb.StatusResponse, clientVersion string) *View {
+	v := &View{
+		Version:               resp.Version,
+		ClientVersion:         clientVersion,
+		ScorerVersion:         resp.ScorerVersion,
+		Providers:             make([]Provider, 0, len(resp.Providers)),
+		UptimeSeconds:         resp.UptimeSeconds,
+		CommandsLogged:        resp.CommandsLogged,
+		StateDBBytes:          resp.StateDbBytes,
+		SuggestionsDBBytes:    resp.SuggestionsDbBytes,
+		LastMaintenanceUnixMs: resp.LastMaintenanceUnixMs,
+		PID:                   resp.Pid,
+		ActiveSessions:        resp.ActiveSessions,
+		DegradedSessions:      resp.DegradedSessions,
+		IngestQueueDepth:      resp.IngestQueueDepth,
+	}
+	for _, p := range resp.Providers {
+		v.Providers = append(v.Providers, Provider{
+			Name:      p.Name,
+			Available: p.Available,
+			Preferred: p.Preferred,
+		})
+	}
+	return v
+}
+
+// SetHealth attaches the result of a Health RPC to the view.
+func (v *View) SetHealth(resp *pb.HealthResponse) {
+	h := &Health{Status: resp.Status, Subsystems: make([]Subsystem, 0, len(resp.Subsystems))}
+	for _, sub := range resp.Subsystems {
+		h.Subsystems = append(h.Subsystems, Subsystem{
+			Name:          sub.Name,
+			Status:        sub.Status,
+			Detail:        sub.Detail,
+			LastRunUnixMs: sub.LastRunUnixMs,
+		})
+	}
+	v.Health = h
+}
+
+// VersionMismatch reports whether the daemon and client were built from different versions.
+func (v *View) VersionMismatch() bool {
+	return v.ClientVersion != "" && v.Version != v.ClientVersion
+}
+
+// WriteJSON writes the view as a single line of JSON.
+func (v *View) WriteJSON(w io.Writer) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// Render writes the human-readable status view. now is used for relative times.
+func (v *View) Render(w io.Writer, now time.Time) {
+	row := func(label, value string) {
+		fmt.Fprintf(w, "  %-13s %s\n", label, value)
+	}
+
+	daemon := "running"
+	if v.PID > 0 {
+		daemon = fmt.Sprintf("running (pid %d)", v.PID)
+	}
+	row("Daemon", daemon)
+
+	version := fmt.Sprintf("daemon %s, client %s", v.Version, v.ClientVersion)
+	if v.VersionMismatch() {
+		version += " (mismatch: run 'clai daemon restart')"
+	}
+	row("Version", version)
+	row("Uptime", FormatUptime(time.Duration(v.UptimeSeconds)*time.Second))
+	sessions := fmt.Sprintf("%d active, %d commands logged", v.ActiveSessions, v.CommandsLogged)
+	if v.DegradedSessions > 0 {
+		sessions += fmt.Sprintf(" (%d fire-and-forget only: over latency budget)", v.DegradedSessions)
+	}
+	row("Sessions", sessions)
+	row("Databases", fmt.Sprintf("state %s, suggestions %s",
+		FormatSize(v.StateDBBytes), FormatSize(v.SuggestionsDBBytes)))
+	row("Queue", fmt.Sprintf("%d pending", v.IngestQueueDepth))
+	row("Providers", formatProviders(v.Providers))
+	row("Maintenance", formatLastMaintenance(v.LastMaintenanceUnixMs, now))
+	if v.ScorerVersion != "" {
+		row("Scorer", v.ScorerVersion)
+	}
+	if v.Health != nil {
+		v.Health.render(w, now)
+	}
+}
+
+// render writes one line per subsystem below an overall health row.
+func (h *Health) render(w io.Writer, now time.Time) {
+	fmt.Fprintf(w, "  %-13s %s\n", "Health", h.Status)
+	for _, sub := range h.Subsystems {
+		detail := sub.Detail
+		if sub.LastRunUnixMs > 0 {
+			if detail != "" {
+				detail += ", "
+			}
+			detail += "last run " + formatLastMaintenance(sub.LastRunUnixMs, now)
+		}
+		fmt.Fprintf(w, "    %-16s %-9s %s\n", sub.Name, "["+sub.Status+"]", detail)
+	}
+}
+
+func formatProviders(providers []Provider) string {
+	if len(providers) == 0 {
+		return "none registered"
+	}
+	parts := make([]string, 0, len(providers))
+	for _, p := range providers {
+		state := "unavailable"
+		if p.Available {
+			state = "ok"
+		}
+		if p.Preferred {
+			state += ", preferred"
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", p.Name, state))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatLastMaintenance(unixMs int64, now time.Time) string {
+	if unixMs == 0 {
+		return "never"
+	}
+	ago := now.Sub(time.UnixMilli(unixMs))
+	if ago < time.Minute {
+		return "just now"
+	}
+	return FormatUptime(ago) + " ago"
+}
+
+// FormatUptime formats a duration coarsely, e.g. "3d 4h", "2h 5m", "42s".
+func FormatUptime(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	days := int64(d / (24 * time.Hour))
+	hours := int64(d/time.Hour) % 24
+	mins := int64(d/time.Minute) % 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	case mins > 0:
+		return fmt.Sprintf("%dm %ds", mins, int64(d/time.Second)%60)
+	default:
+		return fmt.Sprintf("%ds", int64(d/time.Second))
+	}
+}
+
+// FormatSize formats a byte count using binary units, e.g. "1.5 MB".
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,179 @@
+package statusview
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/runger/clai/gen/clai/v1"
+)
+
+func sampleResponse() *pb.StatusResponse {
+	return &pb.StatusResponse{
+		Version:               "1.2.0",
+		ActiveSessions:        2,
+		UptimeSeconds:         2*3600 + 5*60,
+		CommandsLogged:        42,
+		Pid:                   1234,
+		StateDbBytes:          2048,
+		SuggestionsDbBytes:    3 * 1024 * 1024,
+		IngestQueueDepth:      7,
+		LastMaintenanceUnixMs: time.Date(2026, 1, 1, 11, 50, 0, 0, time.UTC).UnixMilli(),
+		ScorerVersion:         "v2",
+		Providers: []*pb.ProviderStatus{
+			{Name: "anthropic", Available: true, Preferred: true},
+			{Name: "openai"},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	v := FromProto(sampleResponse(), "1.2.0")
+	var buf bytes.Buffer
+	v.Render(&buf, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	out := buf.String()
+
+	for _, want := range []string{
+		"running (pid 1234)",
+		"daemon 1.2.0, client 1.2.0",
+		"2h 5m",
+		"2 active, 42 commands logged",
+		"state 2.0 KB, suggestions 3.0 MB",
+		"7 pending",
+		"anthropic (ok, preferred), openai (unavailable)",
+		"10m 0s ago",
+		"v2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Render() missing %q\nOutput:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "mismatch") {
+		t.Errorf("Render() reported a mismatch for equal versions\nOutput:\n%s", out)
+	}
+}
+
+func TestRender_VersionMismatchAndNoMaintenance(t *testing.T) {
+	t.Parallel()
+
+	resp := sampleResponse()
+	resp.LastMaintenanceUnixMs = 0
+	v := FromProto(resp, "1.3.0")
+
+	var buf bytes.Buffer
+	v.Render(&buf, time.Now())
+	out := buf.String()
+
+	if !strings.Contains(out, "mismatch") {
+		t.Errorf("Render() should flag version mismatch\nOutput:\n%s", out)
+	}
+	if !strings.Contains(out, "never") {
+		t.Errorf("Render() should show maintenance as never run\nOutput:\n%s", out)
+	}
+}
+
+func TestRender_DegradedSessions(t *testing.T) {
+	t.Parallel()
+
+	resp := sampleResponse()
+	resp.DegradedSessions = 1
+	v := FromProto(resp, "1.2.0")
+
+	var buf bytes.Buffer
+	v.Render(&buf, time.Now())
+	if want := "2 active, 42 commands logged (1 fire-and-forget only: over latency budget)"; !strings.Contains(buf.String(), want) {
+		t.Errorf("Render() missing %q\nOutput:\n%s", want, buf.String())
+	}
+}
+
+func TestWriteJSON_KeepsLegacyKeys(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := FromProto(sampleResponse(), "1.2.0").WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	for _, key := range []string{"version", "active_sessions", "uptime_seconds", "commands_logged", "providers", "client_version"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("JSON output missing key %q: %s", key, buf.String())
+		}
+	}
+}
+
+func TestFormatUptime(t *testing.T) {
+	t.Parallel()
+
+	tests := map[time.Duration]string{
+		42 * time.Second:                 "42s",
+		3*time.Minute + 5*time.Second:    "3m 5s",
+		2*time.Hour + 5*time.Minute:      "2h 5m",
+		(3*24+4)*time.Hour + time.Minute: "3d 4h",
+	}
+	for in, want := range tests {
+		if got := FormatUptime(in); got != want {
+			t.Errorf("FormatUptime(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRender_Health(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	v := FromProto(sampleResponse(), "1.2.0")
+	v.SetHealth(&pb.HealthResponse{
+		Status: "warn",
+		Subsystems: []*pb.SubsystemHealth{
+			{Name: "store", Status: "ok", Detail: "state.db readable"},
+			{Name: "fts", Status: "warn", Detail: "unavailable; search uses substring matching"},
+			{Name: "maintenance", Status: "ok", Detail: "runs every 5m0s", LastRunUnixMs: now.Add(-2 * time.Hour).UnixMilli()},
+		},
+	})
+
+	var buf bytes.Buffer
+	v.Render(&buf, now)
+	out := buf.String()
+	for _, want := range []string{
+		"Health        warn",
+		"store            [ok]      state.db readable",
+		"fts              [warn]    unavailable",
+		"runs every 5m0s, last run 2h 0m ago",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Render() missing %q\nOutput:\n%s", want, out)
+		}
+	}
+
+	var buf2 bytes.Buffer
+	if err := v.WriteJSON(&buf2); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf2.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decoded["health"]; !ok {
+		t.Errorf("JSON missing health: %s", buf2.String())
+	}
+}
+
+func TestWriteJSON_OmitsHealthByDefault(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := FromProto(sampleResponse(), "1.2.0").WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `"health"`) {
+		t.Errorf("JSON without --verbose contains health: %s", buf.String())
+	}
+}
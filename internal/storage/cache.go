@@ -117,6 +117,22 @@ func (s *SQLiteStore) PruneExpiredCache(ctx context.Context) (int64, error) {
 	return rows, nil
 }
 
+// ClearCache removes all cache entries, expired or not.
+// Returns the number of entries removed.
+func (s *SQLiteStore) ClearCache(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM ai_cache`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear cache: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows, nil
+}
+
 // CacheStats returns statistics about the cache.
 type CacheStats struct {
 	TotalEntries   int64
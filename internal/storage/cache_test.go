@@ -388,6 +388,40 @@ func TestSQLiteStore_PruneExpiredCache_RemovesExpired(t *testing.T) {
 	}
 }
 
+func TestSQLiteStore_ClearCache_RemovesAll(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		entry := &CacheEntry{
+			CacheKey:        generateTestCacheKey(i),
+			ResponseJSON:    `{}`,
+			Provider:        "test",
+			CreatedAtUnixMs: time.Now().UnixMilli(),
+			ExpiresAtUnixMs: time.Now().Add(1 * time.Hour).UnixMilli(),
+		}
+		if err := store.SetCached(ctx, entry); err != nil {
+			t.Fatalf("SetCached() error = %v", err)
+		}
+	}
+
+	removed, err := store.ClearCache(ctx)
+	if err != nil {
+		t.Fatalf("ClearCache() error = %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("Removed %d entries, want 3", removed)
+	}
+
+	if _, err := store.GetCached(ctx, generateTestCacheKey(0)); !errors.Is(err, ErrCacheNotFound) {
+		t.Errorf("Expected cache to be empty, got err = %v", err)
+	}
+}
+
 func TestSQLiteStore_PruneExpiredCache_NoExpired(t *testing.T) {
 	t.Parallel()
 
@@ -12,10 +12,16 @@ import (
 // ErrCommandNotFound is returned when a command is not found.
 var ErrCommandNotFound = errors.New("command not found")
 
+// ErrCommandExists is returned by CreateCommand when a command with the
+// same command_id was already recorded. Nothing is written, so callers
+// replaying commands can treat it as success.
+var ErrCommandExists = errors.New("command already exists")
+
 const commandNormLikeClause = " AND command_norm LIKE ?"
 
 // CreateCommand creates a new command record.
-// It automatically normalizes the command and generates a hash.
+// It automatically normalizes the command and generates a hash. Recording
+// a command_id twice leaves the first record and returns ErrCommandExists.
 func (s *SQLiteStore) CreateCommand(ctx context.Context, cmd *Command) error {
 	if err := validateCreateCommandInput(cmd); err != nil {
 		return err
@@ -45,8 +51,10 @@ func (s *SQLiteStore) CreateCommand(ctx context.Context, cmd *Command) error {
 			duration_ms, cwd, command, command_norm, command_hash,
 			exit_code, is_success,
 			git_branch, git_repo_name, git_repo_root, prev_command_id,
-			is_sudo, pipe_count, word_count
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			is_sudo, pipe_count, word_count,
+			command_truncated, command_full_hash
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(command_id) DO NOTHING
 	`,
 		cmd.CommandID,
 		cmd.SessionID,
@@ -66,17 +74,19 @@ func (s *SQLiteStore) CreateCommand(ctx context.Context, cmd *Command) error {
 		cmd.IsSudo,
 		cmd.PipeCount,
 		cmd.WordCount,
+		cmd.Truncated,
+		nullableString(cmd.FullHash),
 	)
 	if err != nil {
 		// Check for foreign key violation (invalid session_id)
 		if isForeignKeyError(err) {
 			return fmt.Errorf("session_id %s does not exist", cmd.SessionID)
 		}
-		if isDuplicateKeyError(err) {
-			return fmt.Errorf("command with id %s already exists", cmd.CommandID)
-		}
 		return fmt.Errorf("failed to create command: %w", err)
 	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("command with id %s: %w", cmd.CommandID, ErrCommandExists)
+	}
 
 	// Get the auto-generated ID
 	id, err := result.LastInsertId()
@@ -173,6 +183,43 @@ func (s *SQLiteStore) QueryCommands(ctx context.Context, q CommandQuery) ([]Comm
 	return commands, nil
 }
 
+// ScanCommands returns up to limit commands with an ID greater than afterID,
+// oldest first by ID, leaving out deleted commands. It walks the whole
+// history in pages, e.g. for migrating it to another store.
+func (s *SQLiteStore) ScanCommands(ctx context.Context, afterID int64, limit int) ([]Command, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, command_id, session_id, ts_start_unix_ms, ts_end_unix_ms,
+		       duration_ms, cwd, command, command_norm, command_hash,
+		       exit_code, is_success,
+		       git_branch, git_repo_name, git_repo_root, prev_command_id,
+		       is_sudo, pipe_count, word_count,
+		       command_truncated, command_full_hash
+		FROM commands
+		WHERE id > ? AND deleted = 0
+		ORDER BY id
+		LIMIT ?
+	`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan commands: %w", err)
+	}
+	defer rows.Close()
+
+	var commands []Command
+	for rows.Next() {
+		cmd, err := scanCommandRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating commands: %w", err)
+	}
+
+	return commands, nil
+}
+
 // QueryHistoryCommands queries command history rows intended for interactive pickers.
 // When q.Deduplicate is true, it groups by raw command text and returns the most
 // recent timestamp for each unique command string.
@@ -192,7 +239,7 @@ func (s *SQLiteStore) QueryHistoryCommands(ctx context.Context, q CommandQuery)
 	var results []HistoryRow
 	for rows.Next() {
 		var row HistoryRow
-		if err := rows.Scan(&row.Command, &row.TimestampMs); err != nil {
+		if err := rows.Scan(&row.Command, &row.TimestampMs, &row.Truncated); err != nil {
 			return nil, fmt.Errorf("failed to scan history row: %w", err)
 		}
 		results = append(results, row)
@@ -208,16 +255,18 @@ func (s *SQLiteStore) QueryHistoryCommands(ctx context.Context, q CommandQuery)
 func buildHistoryQuerySQL(q *CommandQuery) (query string, args []interface{}) {
 	// Deduplicate by exact command text. Do not group by command_norm: command_norm
 	// intentionally normalizes variable arguments (paths, URLs, numbers) and is too
-	// aggressive for history browsing.
+	// aggressive for history browsing. Truncated commands group by the hash of
+	// their full text, so long commands sharing a stored prefix stay apart.
 	query = `
-		SELECT command, MAX(ts_start_unix_ms) as latest_ts
+		SELECT command, MAX(ts_start_unix_ms) as latest_ts,
+		       MAX(COALESCE(command_truncated, 0)) as truncated
 		FROM commands
 		WHERE 1=1
 	`
 	args = make([]interface{}, 0)
 	query, args = appendCommandQueryFilters(query, args, q)
 
-	query += " GROUP BY command ORDER BY latest_ts DESC"
+	query += " GROUP BY COALESCE(command_full_hash, command) ORDER BY latest_ts DESC"
 	query, args = appendCommandQueryLimitOffset(query, args, q)
 
 	return query, args
@@ -229,7 +278,8 @@ func buildCommandQuerySQL(q *CommandQuery) (query string, args []interface{}) {
 		       duration_ms, cwd, command, command_norm, command_hash,
 		       exit_code, is_success,
 		       git_branch, git_repo_name, git_repo_root, prev_command_id,
-		       is_sudo, pipe_count, word_count
+		       is_sudo, pipe_count, word_count,
+		       command_truncated, command_full_hash
 		FROM commands
 		WHERE 1=1
 	`
@@ -243,6 +293,7 @@ func buildCommandQuerySQL(q *CommandQuery) (query string, args []interface{}) {
 }
 
 func appendCommandQueryFilters(query string, args []interface{}, q *CommandQuery) (_ string, _ []interface{}) {
+	query += " AND deleted = 0"
 	if q.SessionID != nil {
 		query += " AND session_id = ?"
 		args = append(args, *q.SessionID)
@@ -255,6 +306,10 @@ func appendCommandQueryFilters(query string, args []interface{}, q *CommandQuery
 		query += " AND cwd = ?"
 		args = append(args, *q.CWD)
 	}
+	if q.Host != "" {
+		query += " AND session_id IN (SELECT session_id FROM sessions WHERE hostname = ?)"
+		args = append(args, q.Host)
+	}
 	if q.Prefix != "" {
 		query += commandNormLikeClause
 		args = append(args, q.Prefix+"%")
@@ -292,7 +347,8 @@ func scanCommandRow(rows *sql.Rows) (Command, error) {
 	var endTime, duration sql.NullInt64
 	var exitCode, isSuccess sql.NullInt32
 	var gitBranch, gitRepoName, gitRepoRoot, prevCommandID sql.NullString
-	var isSudo, pipeCount, wordCount sql.NullInt32
+	var isSudo, pipeCount, wordCount, truncated sql.NullInt32
+	var fullHash sql.NullString
 
 	err := rows.Scan(
 		&cmd.ID, &cmd.CommandID, &cmd.SessionID, &cmd.TSStartUnixMs,
@@ -300,6 +356,7 @@ func scanCommandRow(rows *sql.Rows) (Command, error) {
 		&cmd.CommandHash, &exitCode, &isSuccess,
 		&gitBranch, &gitRepoName, &gitRepoRoot, &prevCommandID,
 		&isSudo, &pipeCount, &wordCount,
+		&truncated, &fullHash,
 	)
 	if err != nil {
 		return cmd, fmt.Errorf("failed to scan command: %w", err)
@@ -340,6 +397,10 @@ func scanCommandRow(rows *sql.Rows) (Command, error) {
 	if wordCount.Valid {
 		cmd.WordCount = int(wordCount.Int32)
 	}
+	cmd.Truncated = truncated.Valid && truncated.Int32 == 1
+	if fullHash.Valid {
+		cmd.FullHash = fullHash.String
+	}
 
 	return cmd, nil
 }
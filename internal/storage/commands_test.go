@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -93,6 +94,48 @@ func TestSQLiteStore_CreateCommand_AutoNormalization(t *testing.T) {
 	}
 }
 
+func TestSQLiteStore_CreateCommand_Duplicate(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.CreateSession(ctx, &Session{
+		SessionID:       "dup-session",
+		StartedAtUnixMs: 1700000000000,
+		Shell:           "zsh",
+		OS:              "linux",
+		InitialCWD:      "/tmp",
+	}); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	newCmd := func(command string) *Command {
+		return &Command{
+			CommandID:     "dup-cmd",
+			SessionID:     "dup-session",
+			TSStartUnixMs: 1700000001000,
+			CWD:           "/tmp",
+			Command:       command,
+		}
+	}
+	if err := store.CreateCommand(ctx, newCmd("make")); err != nil {
+		t.Fatalf("CreateCommand() error = %v", err)
+	}
+	if err := store.CreateCommand(ctx, newCmd("make test")); !errors.Is(err, ErrCommandExists) {
+		t.Fatalf("CreateCommand() of a recorded command_id = %v, want ErrCommandExists", err)
+	}
+
+	cmds, err := store.QueryCommands(ctx, CommandQuery{SessionID: strPtr("dup-session")})
+	if err != nil {
+		t.Fatalf("QueryCommands() error = %v", err)
+	}
+	if len(cmds) != 1 || cmds[0].Command != "make" {
+		t.Errorf("commands = %+v, want only the first record", cmds)
+	}
+}
+
 func TestSQLiteStore_CreateCommand_InvalidSessionID(t *testing.T) {
 	t.Parallel()
 
@@ -647,6 +690,65 @@ func TestSQLiteStore_QueryCommands_Limit(t *testing.T) {
 	}
 }
 
+func TestSQLiteStore_ScanCommands(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	session := &Session{
+		SessionID:       "scan-session",
+		StartedAtUnixMs: 1700000000000,
+		Shell:           "zsh",
+		OS:              "darwin",
+		InitialCWD:      "/tmp",
+	}
+	if err := store.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	// Timestamps run backwards so ID order differs from time order.
+	for i := 0; i < 5; i++ {
+		cmd := &Command{
+			CommandID:     generateTestCommandID(i + 200),
+			SessionID:     "scan-session",
+			TSStartUnixMs: int64(1700000009000 - i),
+			CWD:           "/tmp",
+			Command:       "ls",
+		}
+		if err := store.CreateCommand(ctx, cmd); err != nil {
+			t.Fatalf("CreateCommand() error = %v", err)
+		}
+	}
+
+	var seen []string
+	var afterID int64
+	for {
+		page, err := store.ScanCommands(ctx, afterID, 2)
+		if err != nil {
+			t.Fatalf("ScanCommands() error = %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, cmd := range page {
+			seen = append(seen, cmd.CommandID)
+		}
+		afterID = page[len(page)-1].ID
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("scanned %d commands, want 5", len(seen))
+	}
+	for i, id := range seen {
+		if want := generateTestCommandID(i + 200); id != want {
+			t.Errorf("seen[%d] = %s, want %s", i, id, want)
+		}
+	}
+}
+
 func TestSQLiteStore_QueryCommands_OrderByRecent(t *testing.T) {
 	t.Parallel()
 
@@ -1191,6 +1293,36 @@ func TestSQLiteStore_QueryHistoryCommands_SessionScoped(t *testing.T) {
 	}
 }
 
+func TestSQLiteStore_QueryHistoryCommands_HostScoped(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	if err := store.CreateSession(ctx, &Session{
+		SessionID: "hist-sess-host", StartedAtUnixMs: 1700000000000,
+		Shell: "zsh", OS: "linux", Hostname: "build-box", InitialCWD: "/tmp",
+	}); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := store.CreateCommand(ctx, &Command{
+		CommandID: "hist-cmd-host", SessionID: "hist-sess-host",
+		TSStartUnixMs: 6000, CWD: "/tmp", Command: "make release",
+	}); err != nil {
+		t.Fatalf("CreateCommand() error = %v", err)
+	}
+	seedHistoryTestData(t, store)
+
+	results, err := store.QueryHistoryCommands(ctx, CommandQuery{Host: "build-box", Limit: 100})
+	if err != nil {
+		t.Fatalf("QueryHistoryCommands() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Command != "make release" {
+		t.Errorf("Got %v, want only make release", results)
+	}
+}
+
 func TestSQLiteStore_QueryHistoryCommands_SubstringFilter(t *testing.T) {
 	t.Parallel()
 
@@ -1296,6 +1428,86 @@ func TestSQLiteStore_QueryHistoryCommands_EmptyResult(t *testing.T) {
 	}
 }
 
+func TestSQLiteStore_QueryHistoryCommands_Truncated(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+
+	seedHistoryTestData(t, store)
+	ctx := context.Background()
+
+	if err := store.CreateCommand(ctx, &Command{
+		CommandID:     "hist-cmd-long",
+		SessionID:     "hist-sess-1",
+		TSStartUnixMs: 6000,
+		CWD:           "/tmp",
+		Command:       "echo aaaa",
+		Truncated:     true,
+		FullHash:      "fullhash",
+	}); err != nil {
+		t.Fatalf("CreateCommand() error = %v", err)
+	}
+
+	results, err := store.QueryHistoryCommands(ctx, CommandQuery{Limit: 100})
+	if err != nil {
+		t.Fatalf("QueryHistoryCommands() error = %v", err)
+	}
+	if len(results) == 0 || results[0].Command != "echo aaaa" || !results[0].Truncated {
+		t.Fatalf("first result = %+v, want truncated echo aaaa", results)
+	}
+	for _, r := range results[1:] {
+		if r.Truncated {
+			t.Errorf("%q marked truncated", r.Command)
+		}
+	}
+
+	cmds, err := store.QueryCommands(ctx, CommandQuery{Prefix: "echo aaaa", Limit: 1})
+	if err != nil {
+		t.Fatalf("QueryCommands() error = %v", err)
+	}
+	if len(cmds) != 1 || !cmds[0].Truncated || cmds[0].FullHash != "fullhash" {
+		t.Errorf("QueryCommands() = %+v, want truncated row with full hash", cmds)
+	}
+}
+
+func TestSQLiteStore_QueryHistoryCommands_TruncatedDedupByFullHash(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+
+	seedHistoryTestData(t, store)
+	ctx := context.Background()
+
+	// The first two share a stored prefix but differ past the cut; the
+	// third repeats the first.
+	for i, hash := range []string{"full-a", "full-b", "full-a"} {
+		if err := store.CreateCommand(ctx, &Command{
+			CommandID:     fmt.Sprintf("hist-cmd-long-%d", i),
+			SessionID:     "hist-sess-1",
+			TSStartUnixMs: int64(6000 + i),
+			CWD:           "/tmp",
+			Command:       "echo aaaa",
+			Truncated:     true,
+			FullHash:      hash,
+		}); err != nil {
+			t.Fatalf("CreateCommand() error = %v", err)
+		}
+	}
+
+	results, err := store.QueryHistoryCommands(ctx, CommandQuery{Prefix: "echo aaaa", Limit: 100})
+	if err != nil {
+		t.Fatalf("QueryHistoryCommands() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2 (one per full command): %+v", len(results), results)
+	}
+	if results[0].TimestampMs != 6002 || results[1].TimestampMs != 6001 {
+		t.Errorf("timestamps = %d, %d; want 6002, 6001", results[0].TimestampMs, results[1].TimestampMs)
+	}
+}
+
 func TestSQLiteStore_QueryHistoryCommands_DoesNotCollapseNormalizedArgs(t *testing.T) {
 	t.Parallel()
 
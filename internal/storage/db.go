@@ -11,6 +11,10 @@ import (
 	"time"
 
 	_ "modernc.org/sqlite"
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/dbcrypt"
+	"github.com/runger/clai/internal/dbrepair"
 )
 
 const (
@@ -28,19 +32,26 @@ type SQLiteStore struct {
 	closeOnce sync.Once     // ensures Close() is idempotent
 }
 
-// DefaultDBPath returns the default database path (~/.clai/state.db).
+// DefaultDBPath returns the default database path (~/.clai/state.db), in
+// the data directory of config.DefaultPaths.
+// Under a CLAI_PROFILE the file lives in ~/.clai/profiles/<name>/.
 func DefaultDBPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-	return filepath.Join(home, ".clai", "state.db"), nil
+	return config.DefaultPaths().DatabaseFile(), nil
 }
 
 // NewSQLiteStore creates a new SQLiteStore with the given database path.
 // If the path is empty, it uses the default path (~/.clai/state.db).
-// The database is opened with WAL mode enabled for better concurrency.
+// The database is opened with WAL mode enabled for better concurrency and
+// gets a PRAGMA quick_check; a corrupt database is moved aside and replaced
+// by a fresh one holding whatever history could be salvaged from it.
 func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	return NewSQLiteStoreWithKey(dbPath, nil)
+}
+
+// NewSQLiteStoreWithKey is like NewSQLiteStore but opens the database
+// encrypted with key (see privacy.db_encryption). A nil key opens a
+// plaintext database.
+func NewSQLiteStoreWithKey(dbPath string, key []byte) (*SQLiteStore, error) {
 	if dbPath == "" {
 		var err error
 		dbPath, err = DefaultDBPath()
@@ -55,10 +66,26 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
+	ctx := context.Background()
+	store, err := openSQLiteStore(ctx, dbPath, key)
+	if err != nil && canRecover(err) {
+		store, err = recoverSQLiteStore(ctx, dbPath, key, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Start background WAL checkpointing
+	go store.walCheckpointLoop()
+
+	return store, nil
+}
+
+// openSQLiteStore opens, checks and migrates the database at dbPath.
+func openSQLiteStore(ctx context.Context, dbPath string, key []byte) (*SQLiteStore, error) {
 	// Open database with pragmas in DSN
-	// modernc.org/sqlite uses _pragma=name(value) syntax
-	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)", dbPath)
-	db, err := sql.Open("sqlite", dsn)
+	// Both SQLite drivers use _pragma=name(value) syntax
+	db, err := dbcrypt.Open(dbPath, "_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)", key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -69,11 +96,16 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 	db.SetConnMaxLifetime(0) // Don't close connections
 
 	// Ping to establish connection and ensure pragmas are applied
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := dbrepair.QuickCheck(ctx, db); err != nil && dbrepair.IsCorruption(err) {
+		db.Close()
+		return nil, err
+	}
+
 	store := &SQLiteStore{
 		db:        db,
 		stopCh:    make(chan struct{}),
@@ -81,14 +113,10 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 	}
 
 	// Run migrations
-	if err := store.migrate(context.Background()); err != nil {
+	if err := store.migrate(ctx); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
-
-	// Start background WAL checkpointing
-	go store.walCheckpointLoop()
-
 	return store, nil
 }
 
@@ -175,6 +203,18 @@ func (s *SQLiteStore) migrate(ctx context.Context) error {
 			version: 3,
 			sql:     migrationV3,
 		},
+		{
+			version: 4,
+			sql:     migrationV4,
+		},
+		{
+			version: 5,
+			sql:     migrationV5,
+		},
+		{
+			version: 6,
+			sql:     migrationV6,
+		},
 	}
 
 	for _, m := range migrations {
@@ -357,3 +397,22 @@ CREATE TABLE IF NOT EXISTS workflow_analyses (
 CREATE INDEX IF NOT EXISTS idx_workflow_analyses_step ON workflow_analyses(run_id, step_id, matrix_key);
 CREATE INDEX IF NOT EXISTS idx_workflow_analyses_decision ON workflow_analyses(decision);
 `
+
+// migrationV4 records whether the stored command text was truncated, with a
+// hash of the full command so truncated commands can still be told apart.
+const migrationV4 = `
+ALTER TABLE commands ADD COLUMN command_truncated INTEGER DEFAULT 0;
+ALTER TABLE commands ADD COLUMN command_full_hash TEXT;
+`
+
+// migrationV5 adds soft deletion: deleted commands stay in the table but are
+// left out of history, export and suggestions.
+const migrationV5 = `
+ALTER TABLE commands ADD COLUMN deleted INTEGER NOT NULL DEFAULT 0;
+`
+
+// migrationV6 adds session names, set with `clai session name`.
+const migrationV6 = `
+ALTER TABLE sessions ADD COLUMN name TEXT;
+CREATE INDEX IF NOT EXISTS idx_sessions_name ON sessions(name);
+`
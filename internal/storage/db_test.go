@@ -1,12 +1,16 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/runger/clai/internal/dbcrypt"
 )
 
 func TestNewSQLiteStore_CreatesDatabase(t *testing.T) {
@@ -27,6 +31,39 @@ func TestNewSQLiteStore_CreatesDatabase(t *testing.T) {
 	}
 }
 
+func TestNewSQLiteStoreWithKey_Encrypted(t *testing.T) {
+	t.Parallel()
+
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	key := bytes.Repeat([]byte{9}, dbcrypt.KeySize)
+
+	store, err := NewSQLiteStoreWithKey(dbPath, key)
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreWithKey() error = %v", err)
+	}
+	ctx := context.Background()
+	if err := store.CreateSession(ctx, &Session{SessionID: "s1", StartedAtUnixMs: 1000, Shell: "zsh", OS: "linux", InitialCWD: "/tmp"}); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	store.Close()
+
+	if encrypted, err := dbcrypt.IsEncrypted(dbPath); err != nil || !encrypted {
+		t.Errorf("IsEncrypted() = %v, %v; want true", encrypted, err)
+	}
+	if _, err := NewSQLiteStore(dbPath); !errors.Is(err, dbcrypt.ErrEncrypted) {
+		t.Errorf("NewSQLiteStore() error = %v, want ErrEncrypted", err)
+	}
+
+	store, err = NewSQLiteStoreWithKey(dbPath, key)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer store.Close()
+	if _, err := store.GetSession(ctx, "s1"); err != nil {
+		t.Errorf("GetSession() error = %v", err)
+	}
+}
+
 func TestNewSQLiteStore_CreatesDirectory(t *testing.T) {
 	t.Parallel()
 
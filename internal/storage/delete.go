@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrEmptyPattern is returned when a deletion pattern is empty, which would
+// match every command.
+var ErrEmptyPattern = errors.New("pattern must not be empty")
+
+// commandMatch returns the condition selecting the commands whose text
+// contains the pattern argument, or equals it when exact is set.
+func commandMatch(exact bool) string {
+	if exact {
+		return "command = ?"
+	}
+	return "instr(command, ?) > 0"
+}
+
+// MatchCommands reports the commands whose text contains pattern
+// (case-sensitive), or equals it when exact is set, with up to limit
+// distinct samples. Deleted commands are included only when includeDeleted
+// is set.
+func (s *SQLiteStore) MatchCommands(ctx context.Context, pattern string, exact, includeDeleted bool, limit int) (*CommandMatch, error) {
+	if pattern == "" {
+		return nil, ErrEmptyPattern
+	}
+	where := commandMatch(exact)
+	if !includeDeleted {
+		where += " AND deleted = 0"
+	}
+
+	m := &CommandMatch{}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM commands WHERE `+where, pattern).Scan(&m.Count); err != nil {
+		return nil, fmt.Errorf("failed to count matching commands: %w", err)
+	}
+	if limit <= 0 || m.Count == 0 {
+		return m, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT command FROM commands
+		WHERE `+where+`
+		GROUP BY command
+		ORDER BY MAX(ts_start_unix_ms) DESC
+		LIMIT ?
+	`, pattern, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query matching commands: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var command string
+		if err := rows.Scan(&command); err != nil {
+			return nil, fmt.Errorf("failed to scan matching command: %w", err)
+		}
+		m.Samples = append(m.Samples, command)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating matching commands: %w", err)
+	}
+	return m, nil
+}
+
+// DeleteCommands soft-deletes the commands whose text contains pattern
+// (case-sensitive), or equals it when exact is set. They stay in the
+// database but no longer appear in history, exports or suggestions.
+// Returns the number of commands deleted.
+func (s *SQLiteStore) DeleteCommands(ctx context.Context, pattern string, exact bool) (int64, error) {
+	if pattern == "" {
+		return 0, ErrEmptyPattern
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE commands SET deleted = 1
+		WHERE deleted = 0 AND `+commandMatch(exact), pattern)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete commands: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// PurgeCommands permanently removes the commands whose text contains
+// pattern (case-sensitive), or equals it when exact is set, soft-deleted
+// ones included. The delete runs with
+// secure_delete, so the removed text is overwritten in the database file
+// rather than left in free pages, and the WAL is checkpointed afterwards.
+// Returns the number of commands removed.
+func (s *SQLiteStore) PurgeCommands(ctx context.Context, pattern string, exact bool) (int64, error) {
+	if pattern == "" {
+		return 0, ErrEmptyPattern
+	}
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `PRAGMA secure_delete = ON`); err != nil {
+		return 0, fmt.Errorf("failed to enable secure delete: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `PRAGMA secure_delete = OFF`) //nolint:errcheck // best-effort reset of a pooled connection
+
+	res, err := conn.ExecContext(ctx, `DELETE FROM commands WHERE `+commandMatch(exact), pattern)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge commands: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		_, _ = conn.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`)
+	}
+	return n, nil
+}
+
+// SummarizeCommands returns each distinct command text of the visible
+// history with its number of runs, for finding commands to delete. Runs
+// imported without an exit code do not count as not found.
+func (s *SQLiteStore) SummarizeCommands(ctx context.Context) ([]CommandSummary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT command, COUNT(*), COALESCE(SUM(exit_code = 127), 0), MAX(ts_start_unix_ms)
+		FROM commands
+		WHERE deleted = 0
+		GROUP BY command
+		ORDER BY MAX(ts_start_unix_ms) DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize commands: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []CommandSummary
+	for rows.Next() {
+		var c CommandSummary
+		if err := rows.Scan(&c.Command, &c.Runs, &c.NotFound, &c.LastUnixMs); err != nil {
+			return nil, fmt.Errorf("failed to scan command summary: %w", err)
+		}
+		summaries = append(summaries, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating command summaries: %w", err)
+	}
+	return summaries, nil
+}
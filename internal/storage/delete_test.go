@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteCommands_HidesFromHistory(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+	seedHistoryTestData(t, store)
+	ctx := context.Background()
+
+	m, err := store.MatchCommands(ctx, "git ", false, false, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), m.Count)
+	assert.ElementsMatch(t, []string{"git status", "git log"}, m.Samples)
+
+	n, err := store.DeleteCommands(ctx, "git status", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	cmds, err := store.QueryCommands(ctx, CommandQuery{Limit: 100})
+	require.NoError(t, err)
+	for _, c := range cmds {
+		assert.NotEqual(t, "git status", c.Command)
+	}
+	rows, err := store.QueryHistoryCommands(ctx, CommandQuery{Substring: "status", Limit: 100})
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+	scanned, err := store.ScanCommands(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Len(t, scanned, len(cmds))
+
+	// Deleted commands still match when asked for, and deleting again is a no-op.
+	m, err = store.MatchCommands(ctx, "git status", false, true, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), m.Count)
+	n, err = store.DeleteCommands(ctx, "git status", false)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestDeleteCommands_Exact(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+	seedHistoryTestData(t, store)
+	ctx := context.Background()
+
+	// "git" is part of several commands but none of them is just "git".
+	m, err := store.MatchCommands(ctx, "git", true, false, 10)
+	require.NoError(t, err)
+	assert.Zero(t, m.Count)
+	n, err := store.DeleteCommands(ctx, "git", true)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	m, err = store.MatchCommands(ctx, "git log", true, false, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), m.Count)
+	assert.Equal(t, []string{"git log"}, m.Samples)
+	n, err = store.DeleteCommands(ctx, "git log", true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	m, err = store.MatchCommands(ctx, "git ", false, false, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), m.Count, "only the exact command is deleted")
+}
+
+func TestSummarizeCommands(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+	seedHistoryTestData(t, store)
+	ctx := context.Background()
+	_, err := store.db.ExecContext(ctx, `UPDATE commands SET exit_code = 127 WHERE command = 'git status'`)
+	require.NoError(t, err)
+	_, err = store.DeleteCommands(ctx, "git log", true)
+	require.NoError(t, err)
+
+	summaries, err := store.SummarizeCommands(ctx)
+	require.NoError(t, err)
+	byCommand := make(map[string]CommandSummary)
+	for _, c := range summaries {
+		byCommand[c.Command] = c
+	}
+	assert.Equal(t, CommandSummary{Command: "git status", Runs: 2, NotFound: 2, LastUnixMs: 3000}, byCommand["git status"])
+	assert.NotContains(t, byCommand, "git log", "deleted commands are left out")
+	assert.Equal(t, int64(1), byCommand["ls -la"].Runs)
+	assert.Zero(t, byCommand["ls -la"].NotFound)
+}
+
+func TestPurgeCommands(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+	seedHistoryTestData(t, store)
+	ctx := context.Background()
+
+	_, err := store.DeleteCommands(ctx, "git log", false)
+	require.NoError(t, err)
+
+	// Purge removes soft-deleted commands too; matching is case-sensitive.
+	n, err := store.PurgeCommands(ctx, "GIT", false)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	n, err = store.PurgeCommands(ctx, "git", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	var remaining int
+	require.NoError(t, store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM commands WHERE instr(command, 'git') > 0`).Scan(&remaining))
+	assert.Zero(t, remaining)
+}
+
+func TestPurgeCommands_Exact(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+	seedHistoryTestData(t, store)
+	ctx := context.Background()
+
+	_, err := store.DeleteCommands(ctx, "git log", true)
+	require.NoError(t, err)
+
+	// An exact purge takes the hidden command and leaves the others.
+	n, err := store.PurgeCommands(ctx, "git", true)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	n, err = store.PurgeCommands(ctx, "git log", true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	m, err := store.MatchCommands(ctx, "git", false, true, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), m.Count)
+}
+
+func TestDeleteCommands_EmptyPattern(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	_, err := store.MatchCommands(ctx, "", false, false, 10)
+	assert.ErrorIs(t, err, ErrEmptyPattern)
+	_, err = store.DeleteCommands(ctx, "", false)
+	assert.ErrorIs(t, err, ErrEmptyPattern)
+	_, err = store.PurgeCommands(ctx, "", false)
+	assert.ErrorIs(t, err, ErrEmptyPattern)
+}
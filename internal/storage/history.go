@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// History origins, the database a HistoryEntry was read from.
+const (
+	HistoryOriginV1 = "v1" // the V1 commands table (state.db)
+	HistoryOriginV2 = "v2" // the V2 command_event table (suggestions_v2.db)
+)
+
+// HistoryQuery defines parameters for reading picker history.
+type HistoryQuery struct {
+	SessionID   string // Include only this session; empty reads all sessions
+	Host        string // Include only sessions on this host
+	Cwd         string // Include only commands run in this directory
+	Substring   string // Case-insensitive substring of the command
+	FullText    bool   // Match Substring through the full-text index where there is one
+	SuccessOnly bool   // Include only commands that exited 0
+	FailureOnly bool   // Include only commands that exited non-zero
+	Limit       int
+	Offset      int
+}
+
+// HistoryEntry is a deduplicated history command and where it came from.
+type HistoryEntry struct {
+	Command     string
+	Origin      string // HistoryOriginV1 or HistoryOriginV2
+	TimestampMs int64  // Most recent use
+	EventID     int64  // Latest V2 command_event ID; 0 if the command is not in V2
+	Cwd         string // Working directory of the most recent use; V2 only
+	ExitCode    *int   // Exit code of the most recent use; nil if unknown or V1
+	Truncated   bool
+}
+
+// HistoryReader reads picker history: each command once, most recent first.
+// Deleted commands are left out.
+type HistoryReader interface {
+	ReadHistory(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error)
+}
+
+// v1HistoryReader reads history from the V1 store.
+type v1HistoryReader struct {
+	store Store
+}
+
+// NewV1HistoryReader returns a HistoryReader over the V1 commands table.
+func NewV1HistoryReader(store Store) HistoryReader {
+	return &v1HistoryReader{store: store}
+}
+
+func (r *v1HistoryReader) ReadHistory(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error) { //nolint:gocritic // hugeParam: interface contract
+	cq := CommandQuery{
+		Limit:  q.Limit,
+		Offset: q.Offset,
+		Host:   q.Host,
+		// Substring matches command_norm, which is lowercase.
+		Substring:   strings.ToLower(q.Substring),
+		SuccessOnly: q.SuccessOnly,
+		FailureOnly: q.FailureOnly,
+	}
+	if q.SessionID != "" {
+		cq.SessionID = &q.SessionID
+	}
+	if q.Cwd != "" {
+		cq.CWD = &q.Cwd
+	}
+	rows, err := r.store.QueryHistoryCommands(ctx, cq)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]HistoryEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = HistoryEntry{
+			Command:     row.Command,
+			Origin:      HistoryOriginV1,
+			TimestampMs: row.TimestampMs,
+			Truncated:   row.Truncated,
+		}
+	}
+	return entries, nil
+}
+
+// v2HistoryReader reads history from the V2 suggestions database.
+type v2HistoryReader struct {
+	db *sql.DB
+}
+
+// NewV2HistoryReader returns a HistoryReader over the command_event table of
+// the V2 suggestions database db. Archived and ephemeral events are left out.
+func NewV2HistoryReader(db *sql.DB) HistoryReader {
+	return &v2HistoryReader{db: db}
+}
+
+func (r *v2HistoryReader) ReadHistory(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error) { //nolint:gocritic // hugeParam: interface contract
+	// The inner query finds each command's latest event; the join reads
+	// where it ran and how it exited.
+	inner := `
+		SELECT cmd_raw, MAX(ts_ms) AS latest_ts, MAX(cmd_truncated) AS truncated, MAX(id) AS latest_id
+		FROM command_event
+		WHERE ephemeral = 0 AND deleted = 0 AND archive_chunk_id IS NULL`
+	var args []any
+	if q.SessionID != "" {
+		inner += ` AND session_id = ?`
+		args = append(args, q.SessionID)
+	}
+	if q.Host != "" {
+		inner += ` AND session_id IN (SELECT id FROM session WHERE host = ?)`
+		args = append(args, q.Host)
+	}
+	if q.Cwd != "" {
+		inner += ` AND cwd = ?`
+		args = append(args, q.Cwd)
+	}
+	if q.SuccessOnly {
+		inner += ` AND exit_code = 0`
+	}
+	if q.FailureOnly {
+		inner += ` AND exit_code != 0`
+	}
+	switch {
+	case q.Substring == "":
+	case q.FullText && utf8.RuneCountInString(q.Substring) >= 3:
+		// The trigram index matches any substring of at least three
+		// characters, ignoring case, without scanning every event.
+		inner += ` AND id IN (SELECT rowid FROM command_event_fts WHERE command_event_fts MATCH ?)`
+		args = append(args, `"`+strings.ReplaceAll(q.Substring, `"`, `""`)+`"`)
+	default:
+		inner += ` AND LOWER(cmd_raw) LIKE ?`
+		args = append(args, "%"+strings.ToLower(q.Substring)+"%")
+	}
+	inner += ` GROUP BY cmd_raw`
+
+	query := `
+		SELECT h.cmd_raw, h.latest_ts, h.truncated, h.latest_id, e.cwd, e.exit_code
+		FROM (` + inner + `) h
+		JOIN command_event e ON e.id = h.latest_id
+		ORDER BY h.latest_ts DESC`
+	if q.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, q.Limit, q.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query V2 history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		e := HistoryEntry{Origin: HistoryOriginV2}
+		var exitCode sql.NullInt64
+		if err := rows.Scan(&e.Command, &e.TimestampMs, &e.Truncated, &e.EventID, &e.Cwd, &exitCode); err != nil {
+			return nil, fmt.Errorf("failed to scan V2 history row: %w", err)
+		}
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			e.ExitCode = &code
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating V2 history rows: %w", err)
+	}
+	return entries, nil
+}
+
+// mergedHistoryReader reads from several readers and merges the results.
+type mergedHistoryReader struct {
+	readers []HistoryReader
+}
+
+// NewMergedHistoryReader returns a HistoryReader that merges the history of
+// readers, which are listed in order of preference. A command found by more
+// than one reader appears once, with the timestamp and origin of its most
+// recent use (ties go to the earlier reader) and any V2 event ID.
+//
+// A reader that fails does not fail the read while another succeeds: the
+// merged entries of the others are returned together with the error.
+func NewMergedHistoryReader(readers ...HistoryReader) HistoryReader {
+	return &mergedHistoryReader{readers: readers}
+}
+
+func (r *mergedHistoryReader) ReadHistory(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error) { //nolint:gocritic // hugeParam: interface contract
+	// Any entry of the requested page is within the first Offset+Limit
+	// entries of the reader that saw its most recent use.
+	sub := q
+	sub.Offset = 0
+	if q.Limit > 0 {
+		sub.Limit = q.Offset + q.Limit
+	}
+
+	var merged []HistoryEntry
+	index := make(map[string]int) // command -> position in merged
+	var errs []error
+	for _, reader := range r.readers {
+		entries, err := reader.ReadHistory(ctx, sub)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, e := range entries {
+			i, ok := index[e.Command]
+			if !ok {
+				index[e.Command] = len(merged)
+				merged = append(merged, e)
+				continue
+			}
+			merged[i] = mergeHistoryEntry(merged[i], e)
+		}
+	}
+	if len(errs) == len(r.readers) && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].TimestampMs > merged[j].TimestampMs
+	})
+	if q.Offset >= len(merged) {
+		merged = nil
+	} else {
+		merged = merged[q.Offset:]
+	}
+	if q.Limit > 0 && len(merged) > q.Limit {
+		merged = merged[:q.Limit]
+	}
+	return merged, errors.Join(errs...)
+}
+
+// fallbackHistoryReader reads from a fallback reader when the primary fails.
+type fallbackHistoryReader struct {
+	primary, fallback HistoryReader
+}
+
+// NewFallbackHistoryReader returns a HistoryReader that reads from primary,
+// or from fallback when primary fails. The fallback's entries are returned
+// together with the primary's error.
+func NewFallbackHistoryReader(primary, fallback HistoryReader) HistoryReader {
+	return &fallbackHistoryReader{primary: primary, fallback: fallback}
+}
+
+func (r *fallbackHistoryReader) ReadHistory(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error) { //nolint:gocritic // hugeParam: interface contract
+	entries, err := r.primary.ReadHistory(ctx, q)
+	if err == nil {
+		return entries, nil
+	}
+	entries, fallbackErr := r.fallback.ReadHistory(ctx, q)
+	if fallbackErr != nil {
+		return nil, errors.Join(err, fallbackErr)
+	}
+	return entries, err
+}
+
+// mergeHistoryEntry combines two entries for the same command, where kept
+// came from a preferred reader.
+func mergeHistoryEntry(kept, other HistoryEntry) HistoryEntry {
+	if other.TimestampMs > kept.TimestampMs {
+		kept.TimestampMs = other.TimestampMs
+		kept.Origin = other.Origin
+	}
+	if kept.EventID == 0 {
+		kept.EventID = other.EventID
+	}
+	if kept.Cwd == "" && kept.ExitCode == nil {
+		kept.Cwd, kept.ExitCode = other.Cwd, other.ExitCode
+	}
+	kept.Truncated = kept.Truncated || other.Truncated
+	return kept
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestV2DB returns a database with the parts of the V2 schema the V2
+// history reader queries.
+func newTestV2DB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "suggestions_v2.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+		CREATE TABLE session (id TEXT PRIMARY KEY, host TEXT);
+		CREATE TABLE command_event (
+			id               INTEGER PRIMARY KEY,
+			session_id       TEXT NOT NULL,
+			ts_ms            INTEGER NOT NULL,
+			cmd_raw          TEXT NOT NULL,
+			cwd              TEXT NOT NULL DEFAULT '',
+			exit_code        INTEGER,
+			cmd_truncated    INTEGER NOT NULL DEFAULT 0,
+			ephemeral        INTEGER NOT NULL DEFAULT 0,
+			deleted          INTEGER NOT NULL DEFAULT 0,
+			archive_chunk_id INTEGER
+		);
+		INSERT INTO session (id, host) VALUES ('hist-sess-1', 'laptop'), ('hist-sess-2', 'server');
+		INSERT INTO command_event (id, session_id, ts_ms, cmd_raw, cwd, exit_code) VALUES
+			(1, 'hist-sess-1', 6000, 'git status', '/repo', 0),
+			(2, 'hist-sess-1', 3500, 'make test', '/repo', 2),
+			(3, 'hist-sess-2', 100, 'ls -la', '/tmp', NULL);
+		INSERT INTO command_event (id, session_id, ts_ms, cmd_raw, deleted) VALUES
+			(4, 'hist-sess-1', 7000, 'rm -rf build', 1);
+		CREATE VIRTUAL TABLE command_event_fts USING fts5(
+			cmd_raw, content='command_event', content_rowid='id', tokenize='trigram'
+		);
+		INSERT INTO command_event_fts (command_event_fts) VALUES ('rebuild');
+	`)
+	require.NoError(t, err)
+	return db
+}
+
+func historyCommands(entries []HistoryEntry) []string {
+	cmds := make([]string, len(entries))
+	for i, e := range entries {
+		cmds[i] = e.Command
+	}
+	return cmds
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestV2HistoryReader(t *testing.T) {
+	t.Parallel()
+	r := NewV2HistoryReader(newTestV2DB(t))
+	ctx := context.Background()
+
+	entries, err := r.ReadHistory(ctx, HistoryQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git status", "make test", "ls -la"}, historyCommands(entries))
+	assert.Equal(t, HistoryEntry{Command: "git status", Origin: HistoryOriginV2, TimestampMs: 6000, EventID: 1, Cwd: "/repo", ExitCode: intPtr(0)}, entries[0])
+	assert.Nil(t, entries[2].ExitCode, "unknown exit code")
+
+	entries, err = r.ReadHistory(ctx, HistoryQuery{Host: "server", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ls -la"}, historyCommands(entries))
+
+	entries, err = r.ReadHistory(ctx, HistoryQuery{SessionID: "hist-sess-1", Substring: "MAKE", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"make test"}, historyCommands(entries))
+
+	entries, err = r.ReadHistory(ctx, HistoryQuery{Cwd: "/repo", FailureOnly: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"make test"}, historyCommands(entries))
+
+	entries, err = r.ReadHistory(ctx, HistoryQuery{SuccessOnly: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git status"}, historyCommands(entries))
+}
+
+func TestV2HistoryReader_FullText(t *testing.T) {
+	t.Parallel()
+	r := NewV2HistoryReader(newTestV2DB(t))
+	ctx := context.Background()
+
+	entries, err := r.ReadHistory(ctx, HistoryQuery{Substring: "T STA", FullText: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git status"}, historyCommands(entries))
+
+	// Quotes are matched literally rather than parsed as query syntax.
+	entries, err = r.ReadHistory(ctx, HistoryQuery{Substring: `"rm" OR`, FullText: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Too short for the trigram index: matched with LIKE instead.
+	entries, err = r.ReadHistory(ctx, HistoryQuery{Substring: "ls", FullText: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ls -la"}, historyCommands(entries))
+}
+
+func TestMergedHistoryReader(t *testing.T) {
+	t.Parallel()
+	store := newTestStore(t)
+	defer store.Close()
+	seedHistoryTestData(t, store)
+	r := NewMergedHistoryReader(NewV2HistoryReader(newTestV2DB(t)), NewV1HistoryReader(store))
+	ctx := context.Background()
+
+	entries, err := r.ReadHistory(ctx, HistoryQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []HistoryEntry{
+		{Command: "git status", Origin: HistoryOriginV2, TimestampMs: 6000, EventID: 1, Cwd: "/repo", ExitCode: intPtr(0)},
+		{Command: "echo hello world", Origin: HistoryOriginV1, TimestampMs: 5000},
+		// Last used per V1, but the V2 event ID is kept.
+		{Command: "ls -la", Origin: HistoryOriginV1, TimestampMs: 4000, EventID: 3, Cwd: "/tmp"},
+		{Command: "make test", Origin: HistoryOriginV2, TimestampMs: 3500, EventID: 2, Cwd: "/repo", ExitCode: intPtr(2)},
+		{Command: "git log", Origin: HistoryOriginV1, TimestampMs: 2000},
+	}, entries)
+
+	entries, err = r.ReadHistory(ctx, HistoryQuery{Offset: 1, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo hello world", "ls -la"}, historyCommands(entries))
+
+	entries, err = r.ReadHistory(ctx, HistoryQuery{Offset: 10, Limit: 2})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+type failingHistoryReader struct{}
+
+func (failingHistoryReader) ReadHistory(context.Context, HistoryQuery) ([]HistoryEntry, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestMergedHistoryReader_PartialFailure(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	r := NewMergedHistoryReader(failingHistoryReader{}, NewV2HistoryReader(newTestV2DB(t)))
+	entries, err := r.ReadHistory(ctx, HistoryQuery{Limit: 10})
+	require.EqualError(t, err, "database is locked")
+	assert.Equal(t, []string{"git status", "make test", "ls -la"}, historyCommands(entries))
+
+	r = NewMergedHistoryReader(failingHistoryReader{}, failingHistoryReader{})
+	entries, err = r.ReadHistory(ctx, HistoryQuery{Limit: 10})
+	require.Error(t, err)
+	assert.Nil(t, entries)
+}
+
+func TestFallbackHistoryReader(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	v2 := NewV2HistoryReader(newTestV2DB(t))
+
+	entries, err := NewFallbackHistoryReader(v2, failingHistoryReader{}).ReadHistory(ctx, HistoryQuery{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git status"}, historyCommands(entries))
+
+	entries, err = NewFallbackHistoryReader(failingHistoryReader{}, v2).ReadHistory(ctx, HistoryQuery{Limit: 1})
+	require.EqualError(t, err, "database is locked")
+	assert.Equal(t, []string{"git status"}, historyCommands(entries))
+}
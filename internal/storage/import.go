@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"runtime"
 	"time"
@@ -153,6 +154,110 @@ func importEntryStartTS(entry history.ImportEntry, now int64, imported int) int6
 	return now + int64(imported)
 }
 
+// ImportCommands inserts commands exported from this or another machine,
+// creating their sessions as needed. A command is skipped when its session
+// already has one with the same start time and text, so importing a file
+// twice adds nothing. CommandID, IsSuccess and the derived fields are
+// filled in. Returns the number of commands inserted.
+func (s *SQLiteStore) ImportCommands(ctx context.Context, cmds []Command) (int, error) {
+	if len(cmds) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	sessionStmt, err := tx.PrepareContext(ctx, `
+		INSERT OR IGNORE INTO sessions (
+			session_id, started_at_unix_ms, ended_at_unix_ms,
+			shell, os, hostname, username, initial_cwd
+		) VALUES (?, ?, NULL, '', '', NULL, NULL, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare session insert: %w", err)
+	}
+	defer sessionStmt.Close()
+
+	existsStmt, err := tx.PrepareContext(ctx, `
+		SELECT 1 FROM commands
+		WHERE session_id = ? AND ts_start_unix_ms = ? AND command = ?
+		LIMIT 1
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare duplicate check: %w", err)
+	}
+	defer existsStmt.Close()
+
+	insertStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO commands (
+			command_id, session_id, ts_start_unix_ms, ts_end_unix_ms,
+			duration_ms, cwd, command, command_norm, command_hash,
+			exit_code, is_success,
+			git_branch, git_repo_name, git_repo_root, prev_command_id,
+			is_sudo, pipe_count, word_count
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer insertStmt.Close()
+
+	imported := 0
+	for i := range cmds {
+		cmd := &cmds[i]
+		if cmd.Command == "" || cmd.SessionID == "" {
+			continue
+		}
+
+		var exists int
+		err := existsStmt.QueryRowContext(ctx, cmd.SessionID, cmd.TSStartUnixMs, cmd.Command).Scan(&exists)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("failed to check for duplicate command: %w", err)
+		}
+
+		if _, err := sessionStmt.ExecContext(ctx, cmd.SessionID, cmd.TSStartUnixMs, cmd.CWD); err != nil {
+			return 0, fmt.Errorf("failed to create session %s: %w", cmd.SessionID, err)
+		}
+
+		cmd.CommandID = uuid.New().String()
+		cmd.CommandNorm = cmdutil.NormalizeCommand(cmd.Command)
+		cmd.CommandHash = cmdutil.HashCommand(cmd.CommandNorm)
+		if cmd.ExitCode != nil {
+			ok := *cmd.ExitCode == 0
+			cmd.IsSuccess = &ok
+		}
+		if cmd.DurationMs != nil && cmd.TSEndUnixMs == nil {
+			end := cmd.TSStartUnixMs + *cmd.DurationMs
+			cmd.TSEndUnixMs = &end
+		}
+		cmd.IsSudo = cmdutil.IsSudo(cmd.Command)
+		cmd.PipeCount = cmdutil.CountPipes(cmd.Command)
+		cmd.WordCount = cmdutil.CountWords(cmd.Command)
+
+		if _, err := insertStmt.ExecContext(ctx,
+			cmd.CommandID, cmd.SessionID, cmd.TSStartUnixMs, cmd.TSEndUnixMs,
+			cmd.DurationMs, cmd.CWD, cmd.Command, cmd.CommandNorm, cmd.CommandHash,
+			cmd.ExitCode, boolPtrToIntPtr(cmd.IsSuccess),
+			cmd.GitBranch, cmd.GitRepoName, cmd.GitRepoRoot,
+			boolToInt(cmd.IsSudo), cmd.PipeCount, cmd.WordCount,
+		); err != nil {
+			return 0, fmt.Errorf("failed to import command: %w", err)
+		}
+		imported++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return imported, nil
+}
+
 // boolToInt converts a bool to an int (0 or 1) for SQLite storage.
 func boolToInt(b bool) int {
 	if b {
@@ -219,3 +219,44 @@ func TestImportHistory_CommandMetadata(t *testing.T) {
 	assert.Equal(t, 1, cmd.PipeCount)
 	assert.Greater(t, cmd.WordCount, 0)
 }
+
+func TestImportCommands_DedupesAndCreatesSessions(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	exitFail := 1
+	duration := int64(200)
+	branch, root, name := "main", "/src/clai", "clai"
+	cmds := []Command{
+		{SessionID: "laptop-1", TSStartUnixMs: 1000, CWD: "/src/clai", Command: "make test",
+			ExitCode: &exitFail, DurationMs: &duration, GitBranch: &branch, GitRepoRoot: &root, GitRepoName: &name},
+		{SessionID: "laptop-1", TSStartUnixMs: 2000, CWD: "/src/clai", Command: "git status"},
+		{SessionID: "laptop-1", TSStartUnixMs: 2000, CWD: "/src/clai", Command: "git status"},
+		{SessionID: "laptop-2", TSStartUnixMs: 3000, CWD: "/tmp", Command: "ls"},
+		{SessionID: "laptop-2", TSStartUnixMs: 4000, CWD: "/tmp", Command: ""},
+	}
+
+	imported, err := store.ImportCommands(ctx, cmds)
+	require.NoError(t, err)
+	assert.Equal(t, 3, imported)
+
+	// Importing the same file again adds nothing.
+	imported, err = store.ImportCommands(ctx, cmds)
+	require.NoError(t, err)
+	assert.Equal(t, 0, imported)
+
+	sess, err := store.GetSession(ctx, "laptop-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), sess.StartedAtUnixMs)
+
+	sessionID := "laptop-1"
+	got, err := store.QueryCommands(ctx, CommandQuery{SessionID: &sessionID, Substring: "make"})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, 1, *got[0].ExitCode)
+	assert.False(t, *got[0].IsSuccess)
+	assert.Equal(t, int64(1200), *got[0].TSEndUnixMs)
+	assert.Equal(t, "main", *got[0].GitBranch)
+	assert.Equal(t, "clai", *got[0].GitRepoName)
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/runger/clai/internal/dbcrypt"
+	"github.com/runger/clai/internal/dbrepair"
+)
+
+// salvageTables are copied from a corrupt database into its replacement.
+// Sessions come first because commands reference them.
+var salvageTables = []string{"sessions", "commands"}
+
+// canRecover reports whether an error opening the store means the file is
+// damaged. A missing or wrong encryption key is not damage: replacing the
+// database then would throw away history that is fine.
+func canRecover(err error) bool {
+	if errors.Is(err, dbcrypt.ErrWrongKey) || errors.Is(err, dbcrypt.ErrEncrypted) {
+		return false
+	}
+	return dbrepair.IsCorruption(err)
+}
+
+// recoverSQLiteStore moves the corrupt database at dbPath aside, creates a
+// fresh one in its place and salvages sessions and commands from the old
+// file, logging a report of what it found and saved.
+func recoverSQLiteStore(ctx context.Context, dbPath string, key []byte, cause error) (*SQLiteStore, error) {
+	backup, err := dbrepair.MoveAside(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("database is corrupt (%v) and could not be moved aside: %w", cause, err)
+	}
+	log.Printf("database %s is corrupt: %v; moved to %s and starting a fresh one", dbPath, cause, backup)
+
+	store, err := openSQLiteStore(ctx, dbPath, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recreate database after corruption: %w", err)
+	}
+	if backup == "" {
+		return store, nil
+	}
+
+	src, err := dbcrypt.Open(backup, "mode=ro", key)
+	if err != nil {
+		log.Printf("database recovery: cannot open %s for salvage: %v", backup, err)
+		return store, nil
+	}
+	defer src.Close()
+	src.SetMaxOpenConns(1)
+
+	for _, table := range salvageTables {
+		res, err := dbrepair.CopyRows(ctx, src, store.db, table, "")
+		if err != nil {
+			log.Printf("database recovery: salvage of %s stopped early: %v", table, err)
+		}
+		log.Printf("database recovery: salvaged %d %s (%d unreadable ranges skipped, %d rows rejected)",
+			res.Copied, table, res.Unreadable, res.Rejected)
+	}
+	return store, nil
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// corruptIndex overwrites the root page of the named index in the closed
+// database at path, damage that PRAGMA quick_check reports while the
+// tables stay readable.
+func corruptIndex(t *testing.T, path, index string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root, pageSize int64
+	if err := db.QueryRow(`SELECT rootpage FROM sqlite_master WHERE name = ?`, index).Scan(&root); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteAt(bytes.Repeat([]byte{0xAB}, int(pageSize)), (root-1)*pageSize); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewSQLiteStore_RecoversCorruptDatabase(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	if err := store.CreateSession(ctx, &Session{SessionID: "s1", StartedAtUnixMs: 1000, Shell: "zsh", OS: "linux", InitialCWD: "/tmp"}); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		cmd := &Command{
+			CommandID:     fmt.Sprintf("cmd-%d", i),
+			SessionID:     "s1",
+			TSStartUnixMs: int64(2000 + i),
+			CWD:           "/tmp",
+			Command:       fmt.Sprintf("echo %d", i),
+		}
+		if err := store.CreateCommand(ctx, cmd); err != nil {
+			t.Fatalf("CreateCommand() error = %v", err)
+		}
+	}
+	store.Close()
+	corruptIndex(t, dbPath, "idx_commands_ts")
+
+	store, err = NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() on corrupt database error = %v", err)
+	}
+	defer store.Close()
+
+	if matches, _ := filepath.Glob(dbPath + ".corrupt.*"); len(matches) == 0 {
+		t.Error("corrupt database was not moved aside")
+	}
+	var commands, sessions int
+	if err := store.DB().QueryRow(`SELECT COUNT(*) FROM commands`).Scan(&commands); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DB().QueryRow(`SELECT COUNT(*) FROM sessions`).Scan(&sessions); err != nil {
+		t.Fatal(err)
+	}
+	if commands != 20 || sessions != 1 {
+		t.Errorf("salvaged %d commands and %d sessions, want 20 and 1", commands, sessions)
+	}
+	if err := store.DB().QueryRow(`PRAGMA quick_check`).Scan(new(string)); err != nil {
+		t.Errorf("quick_check on recovered database: %v", err)
+	}
+}
+
+func TestNewSQLiteStore_GarbageFileIsReplaced(t *testing.T) {
+	t.Parallel()
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	if err := os.WriteFile(dbPath, []byte("this is not a sqlite database, just some garbage bytes"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	defer store.Close()
+
+	if err := store.CreateSession(context.Background(), &Session{SessionID: "s1", StartedAtUnixMs: 1000, Shell: "zsh", OS: "linux", InitialCWD: "/tmp"}); err != nil {
+		t.Errorf("CreateSession() on fresh database error = %v", err)
+	}
+}
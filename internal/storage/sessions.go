@@ -86,42 +86,17 @@ func (s *SQLiteStore) GetSession(ctx context.Context, sessionID string) (*Sessio
 	}
 
 	row := s.db.QueryRowContext(ctx, `
-		SELECT session_id, started_at_unix_ms, ended_at_unix_ms,
-		       shell, os, hostname, username, initial_cwd
+		SELECT `+sessionColumns+`
 		FROM sessions WHERE session_id = ?
 	`, sessionID)
 
-	var session Session
-	var endedAt sql.NullInt64
-	var hostname, username sql.NullString
-
-	err := row.Scan(
-		&session.SessionID,
-		&session.StartedAtUnixMs,
-		&endedAt,
-		&session.Shell,
-		&session.OS,
-		&hostname,
-		&username,
-		&session.InitialCWD,
-	)
+	session, err := scanSession(row)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrSessionNotFound
 		}
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
-
-	if endedAt.Valid {
-		session.EndedAtUnixMs = &endedAt.Int64
-	}
-	if hostname.Valid {
-		session.Hostname = hostname.String
-	}
-	if username.Valid {
-		session.Username = username.String
-	}
-
 	return &session, nil
 }
 
@@ -138,8 +113,7 @@ func (s *SQLiteStore) GetSessionByPrefix(ctx context.Context, prefix string) (*S
 
 	// Query for sessions matching the prefix
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT session_id, started_at_unix_ms, ended_at_unix_ms,
-		       shell, os, hostname, username, initial_cwd
+		SELECT `+sessionColumns+`
 		FROM sessions WHERE session_id LIKE ? || '%'
 		ORDER BY started_at_unix_ms DESC
 		LIMIT 2
@@ -151,34 +125,10 @@ func (s *SQLiteStore) GetSessionByPrefix(ctx context.Context, prefix string) (*S
 
 	var sessions []Session
 	for rows.Next() {
-		var session Session
-		var endedAt sql.NullInt64
-		var hostname, username sql.NullString
-
-		err := rows.Scan(
-			&session.SessionID,
-			&session.StartedAtUnixMs,
-			&endedAt,
-			&session.Shell,
-			&session.OS,
-			&hostname,
-			&username,
-			&session.InitialCWD,
-		)
+		session, err := scanSession(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan session: %w", err)
 		}
-
-		if endedAt.Valid {
-			session.EndedAtUnixMs = &endedAt.Int64
-		}
-		if hostname.Valid {
-			session.Hostname = hostname.String
-		}
-		if username.Valid {
-			session.Username = username.String
-		}
-
 		sessions = append(sessions, session)
 	}
 
@@ -197,6 +147,132 @@ func (s *SQLiteStore) GetSessionByPrefix(ctx context.Context, prefix string) (*S
 	return &sessions[0], nil
 }
 
+// GetSessionByName retrieves the most recently started session with the
+// given name. Returns ErrSessionNotFound if no session has it.
+func (s *SQLiteStore) GetSessionByName(ctx context.Context, name string) (*Session, error) {
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions WHERE name = ?
+		ORDER BY started_at_unix_ms DESC
+		LIMIT 1
+	`, name)
+
+	session, err := scanSession(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &session, nil
+}
+
+// SetSessionName names a session. An empty name clears it.
+func (s *SQLiteStore) SetSessionName(ctx context.Context, sessionID, name string) error {
+	if sessionID == "" {
+		return errors.New(errSessionIDRequired)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE sessions SET name = ? WHERE session_id = ?
+	`, nullableString(name), sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to name session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// ListSessions returns sessions with their command counts, most recently
+// started first.
+func (s *SQLiteStore) ListSessions(ctx context.Context, q SessionQuery) ([]SessionListing, error) {
+	query := `
+		SELECT ` + sessionColumns + `,
+		       (SELECT COUNT(*) FROM commands c WHERE c.session_id = sessions.session_id AND c.deleted = 0),
+		       (SELECT MAX(ts_start_unix_ms) FROM commands c WHERE c.session_id = sessions.session_id AND c.deleted = 0)
+		FROM sessions`
+	switch {
+	case q.ActiveOnly:
+		query += " WHERE ended_at_unix_ms IS NULL"
+	case q.EndedOnly:
+		query += " WHERE ended_at_unix_ms IS NOT NULL"
+	}
+	query += " ORDER BY started_at_unix_ms DESC"
+	var args []interface{}
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []SessionListing
+	for rows.Next() {
+		var l SessionListing
+		var lastCommand sql.NullInt64
+		l.Session, err = scanSession(rows, &l.CommandCount, &lastCommand)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		l.LastCommandUnixMs = lastCommand.Int64
+		sessions = append(sessions, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// sessionColumns are the columns scanSession reads, in order.
+const sessionColumns = `session_id, started_at_unix_ms, ended_at_unix_ms,
+		       shell, os, hostname, username, initial_cwd, name`
+
+// scanSession scans a row selected with sessionColumns, then extra.
+func scanSession(row interface{ Scan(dest ...any) error }, extra ...any) (Session, error) {
+	var session Session
+	var endedAt sql.NullInt64
+	var hostname, username, name sql.NullString
+
+	dest := append([]any{
+		&session.SessionID,
+		&session.StartedAtUnixMs,
+		&endedAt,
+		&session.Shell,
+		&session.OS,
+		&hostname,
+		&username,
+		&session.InitialCWD,
+		&name,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return Session{}, err
+	}
+
+	if endedAt.Valid {
+		session.EndedAtUnixMs = &endedAt.Int64
+	}
+	session.Hostname = hostname.String
+	session.Username = username.String
+	session.Name = name.String
+	return session, nil
+}
+
 // nullableString converts an empty string to a nil sql.NullString.
 func nullableString(s string) sql.NullString {
 	if s == "" {
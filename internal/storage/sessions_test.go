@@ -434,3 +434,111 @@ func TestSQLiteStore_GetSessionByPrefix_EmptyPrefix(t *testing.T) {
 		t.Error("Expected error for empty prefix")
 	}
 }
+
+func TestSQLiteStore_SetSessionName(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	for i, id := range []string{"named-old", "named-new"} {
+		if err := store.CreateSession(ctx, &Session{
+			SessionID:       id,
+			StartedAtUnixMs: int64(1700000000000 + i),
+			Shell:           "zsh",
+			OS:              "linux",
+			InitialCWD:      "/tmp",
+		}); err != nil {
+			t.Fatalf("CreateSession() error = %v", err)
+		}
+		if err := store.SetSessionName(ctx, id, "deploy"); err != nil {
+			t.Fatalf("SetSessionName() error = %v", err)
+		}
+	}
+
+	got, err := store.GetSessionByName(ctx, "deploy")
+	if err != nil {
+		t.Fatalf("GetSessionByName() error = %v", err)
+	}
+	if got.SessionID != "named-new" || got.Name != "deploy" {
+		t.Errorf("GetSessionByName() = %s %q, want the latest session named deploy", got.SessionID, got.Name)
+	}
+
+	if err := store.SetSessionName(ctx, "named-new", ""); err != nil {
+		t.Fatalf("SetSessionName(clear) error = %v", err)
+	}
+	got, err = store.GetSession(ctx, "named-new")
+	if err != nil || got.Name != "" {
+		t.Errorf("after clearing: name = %q, err = %v", got.Name, err)
+	}
+
+	if _, err := store.GetSessionByName(ctx, "missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("GetSessionByName(missing) error = %v, want ErrSessionNotFound", err)
+	}
+	if err := store.SetSessionName(ctx, "missing", "x"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("SetSessionName(missing) error = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestSQLiteStore_ListSessions(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	for i, id := range []string{"list-ended", "list-active"} {
+		if err := store.CreateSession(ctx, &Session{
+			SessionID:       id,
+			StartedAtUnixMs: int64(1700000000000 + i*1000),
+			Shell:           "bash",
+			OS:              "linux",
+			InitialCWD:      "/srv",
+		}); err != nil {
+			t.Fatalf("CreateSession() error = %v", err)
+		}
+	}
+	if err := store.EndSession(ctx, "list-ended", 1700000000500); err != nil {
+		t.Fatalf("EndSession() error = %v", err)
+	}
+	for i, ts := range []int64{1700000001100, 1700000001200} {
+		if err := store.CreateCommand(ctx, &Command{
+			CommandID:     "list-cmd-" + string(rune('a'+i)),
+			SessionID:     "list-active",
+			TSStartUnixMs: ts,
+			CWD:           "/srv",
+			Command:       "make",
+		}); err != nil {
+			t.Fatalf("CreateCommand() error = %v", err)
+		}
+	}
+
+	all, err := store.ListSessions(ctx, SessionQuery{})
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(all) != 2 || all[0].SessionID != "list-active" || all[1].SessionID != "list-ended" {
+		t.Fatalf("ListSessions() = %+v, want both sessions, newest first", all)
+	}
+	if all[0].CommandCount != 2 || all[0].LastCommandUnixMs != 1700000001200 {
+		t.Errorf("active session: %d commands, last at %d", all[0].CommandCount, all[0].LastCommandUnixMs)
+	}
+	if all[1].CommandCount != 0 || all[1].LastCommandUnixMs != 0 || all[1].EndedAtUnixMs == nil {
+		t.Errorf("ended session = %+v", all[1])
+	}
+
+	for q, want := range map[SessionQuery]string{
+		{ActiveOnly: true}: "list-active",
+		{EndedOnly: true}:  "list-ended",
+		{Limit: 1}:         "list-active",
+	} {
+		got, err := store.ListSessions(ctx, q)
+		if err != nil {
+			t.Fatalf("ListSessions(%+v) error = %v", q, err)
+		}
+		if len(got) != 1 || got[0].SessionID != want {
+			t.Errorf("ListSessions(%+v) = %+v, want only %s", q, got, want)
+		}
+	}
+}
@@ -16,6 +16,7 @@ type Store interface {
 	EndSession(ctx context.Context, sessionID string, endTime int64) error
 	GetSession(ctx context.Context, sessionID string) (*Session, error)
 	GetSessionByPrefix(ctx context.Context, prefix string) (*Session, error)
+	SetSessionName(ctx context.Context, sessionID, name string) error
 
 	// Commands
 	CreateCommand(ctx context.Context, c *Command) error
@@ -27,10 +28,18 @@ type Store interface {
 	GetCached(ctx context.Context, key string) (*CacheEntry, error)
 	SetCached(ctx context.Context, entry *CacheEntry) error
 	PruneExpiredCache(ctx context.Context) (int64, error)
+	ClearCache(ctx context.Context) (int64, error)
 
 	// History Import
 	HasImportedHistory(ctx context.Context, shell string) (bool, error)
 	ImportHistory(ctx context.Context, entries []history.ImportEntry, shell string) (int, error)
+	ImportCommands(ctx context.Context, cmds []Command) (int, error)
+
+	// Deletion by a case-sensitive substring of the command text, or by
+	// the whole text when exact is set
+	MatchCommands(ctx context.Context, pattern string, exact, includeDeleted bool, limit int) (*CommandMatch, error)
+	DeleteCommands(ctx context.Context, pattern string, exact bool) (int64, error)
+	PurgeCommands(ctx context.Context, pattern string, exact bool) (int64, error)
 
 	// Workflow methods
 	CreateWorkflowRun(ctx context.Context, run *WorkflowRun) error
@@ -56,9 +65,24 @@ type Session struct {
 	Hostname        string
 	Username        string
 	InitialCWD      string
+	Name            string // Set with `clai session name`; empty if unnamed
 	StartedAtUnixMs int64
 }
 
+// SessionQuery defines parameters for listing sessions.
+type SessionQuery struct {
+	Limit      int
+	ActiveOnly bool // Only sessions that have not ended
+	EndedOnly  bool // Only sessions that have ended
+}
+
+// SessionListing is a session with a summary of its commands.
+type SessionListing struct {
+	Session
+	CommandCount      int64
+	LastCommandUnixMs int64 // Start of the last command; 0 if there are none
+}
+
 // Command represents a command executed in a session.
 type Command struct {
 	TSEndUnixMs *int64
@@ -87,6 +111,12 @@ type Command struct {
 	PipeCount int
 	WordCount int
 	IsSudo    bool
+
+	// Truncation: Command holds at most the configured byte limit. FullHash
+	// is the hash of the command as typed, set when Truncated is true;
+	// QueryHistoryCommands deduplicates truncated commands by it.
+	FullHash  string
+	Truncated bool
 }
 
 // CommandQuery defines parameters for querying commands.
@@ -94,6 +124,7 @@ type CommandQuery struct {
 	SessionID        *string // Include only this session
 	ExcludeSessionID string  // Exclude this session (for global queries)
 	CWD              *string
+	Host             string // Include only sessions on this host
 	Prefix           string
 	Substring        string // Substring match (case-insensitive via command_norm)
 	Limit            int
@@ -103,10 +134,26 @@ type CommandQuery struct {
 	Deduplicate      bool // Group by command_norm, return most recent per unique command
 }
 
+// CommandMatch reports the commands a deletion pattern matches.
+type CommandMatch struct {
+	Samples []string // Distinct matching commands, most recent first
+	Count   int64    // Matching rows
+}
+
+// CommandSummary is one distinct command text of the history and how it
+// ran.
+type CommandSummary struct {
+	Command    string
+	Runs       int64
+	NotFound   int64 // Runs that exited 127, the shell's "command not found"
+	LastUnixMs int64 // Start of the most recent run
+}
+
 // HistoryRow represents a deduplicated command history entry.
 type HistoryRow struct {
 	Command     string
 	TimestampMs int64
+	Truncated   bool
 }
 
 // CacheEntry represents a cached AI response.
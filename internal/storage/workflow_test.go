@@ -786,14 +786,14 @@ func TestMigrationV3_CreatesWorkflowTables(t *testing.T) {
 		}
 	}
 
-	// Verify schema_meta has version 3
+	// Verify schema_meta has at least version 3
 	var version int
 	err := store.DB().QueryRowContext(ctx,
 		"SELECT version FROM schema_meta ORDER BY version DESC LIMIT 1").Scan(&version)
 	if err != nil {
 		t.Fatalf("failed to read schema version: %v", err)
 	}
-	if version != 3 {
-		t.Errorf("schema version = %d, want 3", version)
+	if version < 3 {
+		t.Errorf("schema version = %d, want >= 3", version)
 	}
 }
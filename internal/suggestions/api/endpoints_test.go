@@ -64,13 +64,16 @@ func createTestDB(t *testing.T) *sql.DB {
 			PRIMARY KEY(scope, prev_norm, next_norm)
 		);
 
-		CREATE TABLE project_task (
-			repo_key      TEXT NOT NULL,
-			kind          TEXT NOT NULL,
-			name          TEXT NOT NULL,
-			command       TEXT NOT NULL,
-			description   TEXT,
-			discovered_ts INTEGER NOT NULL,
+		CREATE TABLE task_candidate (
+			repo_key          TEXT NOT NULL,
+			kind              TEXT NOT NULL,
+			name              TEXT NOT NULL,
+			command_text      TEXT NOT NULL,
+			description       TEXT,
+			source            TEXT NOT NULL DEFAULT 'auto',
+			priority_boost    REAL NOT NULL DEFAULT 0,
+			source_checksum   TEXT,
+			discovered_ms     INTEGER NOT NULL,
 			PRIMARY KEY(repo_key, kind, name)
 		);
 	`)
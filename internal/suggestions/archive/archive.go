@@ -0,0 +1,348 @@
+// Package archive compacts old command_event payloads in the V2 suggestions
+// database.
+//
+// Archiving an event moves its cmd_raw into a zlib-compressed chunk shared
+// with neighbouring events and removes the event from full-text search. The
+// row itself stays, so templates, stats and every learned aggregate derived
+// from cmd_norm and template_id are unaffected. Chunks compress far better
+// than single commands because shell history is highly repetitive.
+package archive
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultChunkSize is the number of events packed into one archive chunk.
+const DefaultChunkSize = 500
+
+// ErrNotFound is returned by CmdRaw when the event does not exist.
+var ErrNotFound = errors.New("command event not found")
+
+// record is one archived event inside a chunk payload.
+type record struct {
+	CmdRaw string `json:"cmd_raw"`
+	ID     int64  `json:"id"`
+}
+
+// Result summarizes an Archive call.
+type Result struct {
+	Events int64 // Events archived
+	Chunks int64 // Chunks written
+}
+
+// Archive archives non-ephemeral events with ts_ms < cutoffMs, chunkSize
+// events per chunk (DefaultChunkSize when <= 0). Each chunk is written in
+// its own transaction so progress survives cancellation.
+func Archive(ctx context.Context, db *sql.DB, cutoffMs int64, chunkSize int, nowMs int64) (Result, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	var result Result
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		n, err := archiveChunk(ctx, db, cutoffMs, chunkSize, nowMs)
+		if err != nil {
+			return result, err
+		}
+		if n == 0 {
+			return result, nil
+		}
+		result.Events += int64(n)
+		result.Chunks++
+		if n < chunkSize {
+			return result, nil
+		}
+	}
+}
+
+type eventRow struct {
+	cmdRaw    string
+	cmdNorm   string
+	repoKey   sql.NullString
+	sessionID string
+	id        int64
+}
+
+// archiveChunk archives up to chunkSize events and returns how many it archived.
+func archiveChunk(ctx context.Context, db *sql.DB, cutoffMs int64, chunkSize int, nowMs int64) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Best effort rollback on error
+
+	rows, err := selectEvents(ctx, tx, cutoffMs, chunkSize)
+	if err != nil || len(rows) == 0 {
+		return 0, err
+	}
+
+	records := make([]record, len(rows))
+	for i, r := range rows {
+		records[i] = record{ID: r.id, CmdRaw: r.cmdRaw}
+	}
+	payload, err := encode(records)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO command_event_archive (first_event_id, last_event_id, event_count, created_ms, payload)
+		VALUES (?, ?, ?, ?, ?)
+	`, rows[0].id, rows[len(rows)-1].id, len(rows), nowMs, payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert archive chunk: %w", err)
+	}
+	chunkID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get archive chunk id: %w", err)
+	}
+
+	for _, r := range rows {
+		// External-content FTS needs the indexed values to delete an entry,
+		// so remove it before cmd_raw is cleared.
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO command_event_fts(command_event_fts, rowid, cmd_raw, cmd_norm, repo_key, session_id)
+			VALUES ('delete', ?, ?, ?, ?, ?)
+		`, r.id, r.cmdRaw, r.cmdNorm, r.repoKey, r.sessionID); err != nil {
+			return 0, fmt.Errorf("failed to remove event %d from search index: %w", r.id, err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE command_event SET cmd_raw = '', archive_chunk_id = ? WHERE id = ?
+		`, chunkID, r.id); err != nil {
+			return 0, fmt.Errorf("failed to archive event %d: %w", r.id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit archive chunk: %w", err)
+	}
+	return len(rows), nil
+}
+
+func selectEvents(ctx context.Context, tx *sql.Tx, cutoffMs int64, limit int) ([]eventRow, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, cmd_raw, cmd_norm, repo_key, session_id
+		FROM command_event
+		WHERE ts_ms < ? AND archive_chunk_id IS NULL AND ephemeral = 0
+		ORDER BY id
+		LIMIT ?
+	`, cutoffMs, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select events to archive: %w", err)
+	}
+	defer rows.Close()
+
+	var events []eventRow
+	for rows.Next() {
+		var r eventRow
+		if err := rows.Scan(&r.id, &r.cmdRaw, &r.cmdNorm, &r.repoKey, &r.sessionID); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, r)
+	}
+	return events, rows.Err()
+}
+
+// CmdRaw returns the raw command of an event, decompressing it from its
+// archive chunk when the event has been archived.
+func CmdRaw(ctx context.Context, db *sql.DB, eventID int64) (string, error) {
+	var cmdRaw string
+	var chunkID sql.NullInt64
+	err := db.QueryRowContext(ctx, `
+		SELECT cmd_raw, archive_chunk_id FROM command_event WHERE id = ?
+	`, eventID).Scan(&cmdRaw, &chunkID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if !chunkID.Valid {
+		return cmdRaw, nil
+	}
+
+	var payload []byte
+	if err := db.QueryRowContext(ctx, `
+		SELECT payload FROM command_event_archive WHERE id = ?
+	`, chunkID.Int64).Scan(&payload); err != nil {
+		return "", fmt.Errorf("failed to read archive chunk %d: %w", chunkID.Int64, err)
+	}
+	records, err := decode(payload)
+	if err != nil {
+		return "", fmt.Errorf("archive chunk %d: %w", chunkID.Int64, err)
+	}
+	for _, r := range records {
+		if r.ID == eventID {
+			return r.CmdRaw, nil
+		}
+	}
+	return "", fmt.Errorf("archive chunk %d does not contain event %d", chunkID.Int64, eventID)
+}
+
+// PruneOrphanChunks deletes archive chunks no longer referenced by any
+// event, e.g. after retention pruning. Returns the number of chunks deleted.
+func PruneOrphanChunks(ctx context.Context, db *sql.DB) (int64, error) {
+	res, err := db.ExecContext(ctx, `
+		DELETE FROM command_event_archive
+		WHERE id NOT IN (
+			SELECT DISTINCT archive_chunk_id
+			FROM command_event
+			WHERE archive_chunk_id IS NOT NULL
+		)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune archive chunks: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// Querier is satisfied by *sql.DB and *sql.Tx.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Find returns the archived events whose raw command contains pattern
+// (case-sensitive), or equals it when exact is set, keyed by event ID.
+// Every chunk is decompressed, so this is meant for rare operations such as
+// deleting a command from history.
+func Find(ctx context.Context, q Querier, pattern string, exact bool) (map[int64]string, error) {
+	found := make(map[int64]string)
+	err := eachChunk(ctx, q, func(_ int64, records []record) error {
+		for _, r := range records {
+			if r.CmdRaw == pattern || !exact && strings.Contains(r.CmdRaw, pattern) {
+				found[r.ID] = r.CmdRaw
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+// Redact rewrites the archive chunks that hold events whose raw command
+// contains pattern, or equals it when exact is set, without those events, deleting chunks left empty. It
+// returns the IDs of the events removed; their command_event rows still
+// point at the chunk and are the caller's to delete in the same
+// transaction.
+func Redact(ctx context.Context, tx *sql.Tx, pattern string, exact bool) ([]int64, error) {
+	type rewrite struct {
+		records []record
+		id      int64
+	}
+	var removed []int64
+	var rewrites []rewrite
+	err := eachChunk(ctx, tx, func(chunkID int64, records []record) error {
+		kept := records[:0:0]
+		for _, r := range records {
+			if r.CmdRaw == pattern || !exact && strings.Contains(r.CmdRaw, pattern) {
+				removed = append(removed, r.ID)
+			} else {
+				kept = append(kept, r)
+			}
+		}
+		if len(kept) < len(records) {
+			rewrites = append(rewrites, rewrite{id: chunkID, records: kept})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rw := range rewrites {
+		if len(rw.records) == 0 {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM command_event_archive WHERE id = ?`, rw.id); err != nil {
+				return nil, fmt.Errorf("failed to delete archive chunk %d: %w", rw.id, err)
+			}
+			continue
+		}
+		payload, err := encode(rw.records)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE command_event_archive SET payload = ?, event_count = ? WHERE id = ?
+		`, payload, len(rw.records), rw.id); err != nil {
+			return nil, fmt.Errorf("failed to rewrite archive chunk %d: %w", rw.id, err)
+		}
+	}
+	return removed, nil
+}
+
+// CmdRaws returns the raw command of every archived event, keyed by event
+// ID.
+func CmdRaws(ctx context.Context, q Querier) (map[int64]string, error) {
+	cmds := make(map[int64]string)
+	err := eachChunk(ctx, q, func(_ int64, records []record) error {
+		for _, r := range records {
+			cmds[r.ID] = r.CmdRaw
+		}
+		return nil
+	})
+	return cmds, err
+}
+
+// eachChunk decodes every archive chunk in turn and passes it to fn.
+func eachChunk(ctx context.Context, q Querier, fn func(chunkID int64, records []record) error) error {
+	rows, err := q.QueryContext(ctx, `SELECT id, payload FROM command_event_archive ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to query archive chunks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var payload []byte
+		if err := rows.Scan(&id, &payload); err != nil {
+			return fmt.Errorf("failed to scan archive chunk: %w", err)
+		}
+		records, err := decode(payload)
+		if err != nil {
+			return fmt.Errorf("archive chunk %d: %w", id, err)
+		}
+		if err := fn(id, records); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func encode(records []record) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(records); err != nil {
+		return nil, fmt.Errorf("failed to encode archive chunk: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress archive chunk: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decode(payload []byte) ([]record, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+	var records []record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,218 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := suggestdb.Open(context.Background(), suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "suggestions_v2.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db.DB()
+}
+
+func insertEvent(t *testing.T, db *sql.DB, tsMs int64, cmd string, ephemeral bool) int64 {
+	t.Helper()
+	eph := 0
+	if ephemeral {
+		eph = 1
+	}
+	res, err := db.Exec(`
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, template_id, ephemeral)
+		VALUES ('s1', ?, '/tmp', ?, ?, 'tmpl', ?)
+	`, tsMs, cmd, cmd, eph)
+	if err != nil {
+		t.Fatalf("insert event: %v", err)
+	}
+	id, _ := res.LastInsertId()
+	return id
+}
+
+func ftsMatches(t *testing.T, db *sql.DB, term string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM command_event_fts WHERE command_event_fts MATCH ?`, term).Scan(&n); err != nil {
+		t.Fatalf("fts query: %v", err)
+	}
+	return n
+}
+
+func TestArchive_CompactsOldEventsOnly(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	var oldIDs []int64
+	for i := 0; i < 5; i++ {
+		oldIDs = append(oldIDs, insertEvent(t, db, 1000, fmt.Sprintf("kubectl apply -f deploy-%d.yaml", i), false))
+	}
+	ephemeralID := insertEvent(t, db, 1000, "kubectl secret", true)
+	recentID := insertEvent(t, db, 5000, "kubectl get pods", false)
+
+	res, err := Archive(ctx, db, 2000, 2, 9000)
+	if err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	if res.Events != 5 || res.Chunks != 3 {
+		t.Errorf("Archive() = %+v, want 5 events in 3 chunks", res)
+	}
+
+	// Archived events leave search; recent ones stay.
+	if n := ftsMatches(t, db, `"deploy"`); n != 0 {
+		t.Errorf("archived events still searchable: %d matches", n)
+	}
+	if n := ftsMatches(t, db, `"get pods"`); n != 1 {
+		t.Errorf("recent event matches = %d, want 1", n)
+	}
+
+	// Raw commands are still recoverable, and cmd_norm/template are kept.
+	for i, id := range oldIDs {
+		got, err := CmdRaw(ctx, db, id)
+		if err != nil {
+			t.Fatalf("CmdRaw(%d) error = %v", id, err)
+		}
+		if want := fmt.Sprintf("kubectl apply -f deploy-%d.yaml", i); got != want {
+			t.Errorf("CmdRaw(%d) = %q, want %q", id, got, want)
+		}
+	}
+	var norm, tmpl string
+	if err := db.QueryRow(`SELECT cmd_norm, template_id FROM command_event WHERE id = ?`, oldIDs[0]).Scan(&norm, &tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if norm == "" || tmpl != "tmpl" {
+		t.Errorf("archived event lost cmd_norm/template: %q, %q", norm, tmpl)
+	}
+
+	for _, id := range []int64{ephemeralID, recentID} {
+		var chunk sql.NullInt64
+		if err := db.QueryRow(`SELECT archive_chunk_id FROM command_event WHERE id = ?`, id).Scan(&chunk); err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Valid {
+			t.Errorf("event %d should not be archived", id)
+		}
+	}
+
+	// A second pass has nothing left to do.
+	res, err = Archive(ctx, db, 2000, 2, 9000)
+	if err != nil || res.Events != 0 {
+		t.Errorf("second Archive() = %+v, %v; want no-op", res, err)
+	}
+}
+
+func TestArchive_DeleteArchivedEventKeepsIndexConsistent(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	insertEvent(t, db, 1000, "terraform plan", false)
+	insertEvent(t, db, 5000, "terraform apply", false)
+	if _, err := Archive(ctx, db, 2000, 0, 9000); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(`DELETE FROM command_event WHERE ts_ms < 2000`); err != nil {
+		t.Fatalf("delete archived event: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO command_event_fts(command_event_fts, rank) VALUES('integrity-check', 1)`); err != nil {
+		t.Errorf("FTS integrity check failed: %v", err)
+	}
+
+	n, err := PruneOrphanChunks(ctx, db)
+	if err != nil || n != 1 {
+		t.Errorf("PruneOrphanChunks() = %d, %v; want 1", n, err)
+	}
+}
+
+func TestCmdRaw_NotArchivedAndMissing(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	ctx := context.Background()
+	id := insertEvent(t, db, 1000, "ls -la", false)
+
+	got, err := CmdRaw(ctx, db, id)
+	if err != nil || got != "ls -la" {
+		t.Errorf("CmdRaw() = %q, %v; want %q", got, err, "ls -la")
+	}
+	if _, err := CmdRaw(ctx, db, id+100); err != ErrNotFound {
+		t.Errorf("CmdRaw(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFindAndRedact(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	secretID := insertEvent(t, db, 1000, "export TOKEN=hunter2", false)
+	keptID := insertEvent(t, db, 1000, "ls -la", false)
+	aloneID := insertEvent(t, db, 1100, "echo hunter2", false)
+	if _, err := Archive(ctx, db, 2000, 2, 9000); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := CmdRaws(ctx, db)
+	if err != nil || len(all) != 3 || all[keptID] != "ls -la" {
+		t.Errorf("CmdRaws() = %v, %v; want all three archived events", all, err)
+	}
+
+	found, err := Find(ctx, db, "hunter2", false)
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if len(found) != 2 || found[secretID] != "export TOKEN=hunter2" || found[aloneID] != "echo hunter2" {
+		t.Errorf("Find() = %v, want the two hunter2 events", found)
+	}
+	if found, _ := Find(ctx, db, "echo hunter2", true); len(found) != 1 || found[aloneID] == "" {
+		t.Errorf("Find(exact) = %v, want only the echo event", found)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := Redact(ctx, tx, "hunter2", false)
+	if err != nil {
+		t.Fatalf("Redact() error = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("Redact() removed %v, want 2 events", removed)
+	}
+
+	// The shared chunk keeps its other event; the emptied one is gone.
+	if got, err := CmdRaw(ctx, db, keptID); err != nil || got != "ls -la" {
+		t.Errorf("CmdRaw(kept) = %q, %v; want ls -la", got, err)
+	}
+	if _, err := CmdRaw(ctx, db, secretID); err == nil {
+		t.Error("redacted event is still in its chunk")
+	}
+	var chunks int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM command_event_archive`).Scan(&chunks); err != nil {
+		t.Fatal(err)
+	}
+	if chunks != 1 {
+		t.Errorf("%d archive chunks left, want 1", chunks)
+	}
+	if found, _ := Find(ctx, db, "hunter2", false); len(found) != 0 {
+		t.Errorf("Find() after Redact = %v, want none", found)
+	}
+}
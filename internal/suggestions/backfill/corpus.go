@@ -0,0 +1,209 @@
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/runger/clai/internal/suggestions/normalize"
+)
+
+// CorpusCommon names the corpus of everyday shell commands seeded whatever
+// the project type.
+const CorpusCommon = "common"
+
+// corpusMigrationPrefix prefixes the storage_migration rows that record a
+// seeded corpus, e.g. corpus:go.
+const corpusMigrationPrefix = "corpus:"
+
+// ColdStartMaxEvents is the number of recorded commands from which a
+// database counts as warm and SeedCorpus leaves it alone.
+const ColdStartMaxEvents = 200
+
+// corpusMaxScore is the frequency score of a corpus's first command. Later
+// commands score less, and any real use (score 1 and up) outranks them.
+const corpusMaxScore = 0.5
+
+// corpora holds the curated commands seeded for each project type, most
+// useful first.
+var corpora = map[string][]string{
+	CorpusCommon: {
+		"git status", "git diff", "git log --oneline -10", "git pull",
+		"git add -p", "git commit", "git push", "git switch -c feature",
+		"ls -la", "cd ..", "grep -rn pattern .", "find . -name '*.txt'",
+		"du -sh *", "df -h", "tar -xzf archive.tar.gz", "curl -fsSL https://example.com",
+	},
+	"go": {
+		"go test ./...", "go build ./...", "go run .", "go mod tidy",
+		"go vet ./...", "go test -run TestName ./...", "go generate ./...", "gofmt -l -w .",
+	},
+	"node": {
+		"npm install", "npm test", "npm run build", "npm start",
+		"npm run dev", "npm run lint", "npx tsc --noEmit", "npm ci",
+	},
+	"python": {
+		"pytest", "python -m pytest -x", "pip install -r requirements.txt", "python -m venv .venv",
+		"source .venv/bin/activate", "ruff check .", "mypy .", "pip install -e .",
+	},
+	"rust": {
+		"cargo build", "cargo test", "cargo run", "cargo check",
+		"cargo clippy", "cargo fmt", "cargo build --release", "cargo update",
+	},
+	"docker": {
+		"docker compose up -d", "docker compose down", "docker ps", "docker compose logs -f",
+		"docker build -t app .", "docker images", "docker exec -it container sh", "docker system prune",
+	},
+	"java": {
+		"mvn test", "mvn package", "mvn clean install", "./gradlew build",
+		"./gradlew test", "mvn dependency:tree",
+	},
+	"ruby": {
+		"bundle install", "bundle exec rspec", "bundle exec rake", "rails server",
+		"rails console", "bundle exec rubocop",
+	},
+	"make": {"make", "make test", "make build", "make clean", "make install"},
+	"terraform": {
+		"terraform init", "terraform plan", "terraform apply", "terraform fmt -recursive",
+		"terraform validate", "terraform output",
+	},
+	"cpp":     {"cmake -B build", "cmake --build build", "ctest --test-dir build", "make"},
+	"haskell": {"cabal build", "cabal test", "cabal run", "stack build", "stack test"},
+	"nix":     {"nix build", "nix develop", "nix flake check", "nix flake update", "nix run"},
+}
+
+// CorpusOptions selects what SeedCorpus seeds.
+type CorpusOptions struct {
+	// ProjectTypes are the detected project types to seed corpora for; the
+	// common corpus is always seeded.
+	ProjectTypes []string
+
+	// Extra holds more commands per project type, such as the common
+	// commands of custom project types, ranked above the built-in ones.
+	Extra map[string][]string
+
+	NowMs int64
+}
+
+// SeedCorpus seeds command_template, command_stat (global scope) and
+// project_type_stat with curated commands, so a fresh install suggests
+// something useful before it has recorded any history.
+//
+// It only writes to a cold database, one with fewer than
+// ColdStartMaxEvents recorded commands, and seeds each corpus once: a
+// storage_migration row named corpus:<type> records it. Seeded commands
+// never replace existing stats and score below a single real use, so
+// history takes over as soon as it accumulates. It returns the number of
+// commands seeded.
+func SeedCorpus(ctx context.Context, db *sql.DB, opts CorpusOptions) (int, error) {
+	var events int
+	if err := db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM (SELECT 1 FROM command_event LIMIT ?)`, ColdStartMaxEvents,
+	).Scan(&events); err != nil {
+		return 0, fmt.Errorf("count events: %w", err)
+	}
+	if events >= ColdStartMaxEvents {
+		return 0, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // best-effort rollback on error
+
+	seeded := 0
+	for _, name := range append([]string{CorpusCommon}, opts.ProjectTypes...) {
+		commands := append(append([]string(nil), opts.Extra[name]...), corpora[name]...)
+		if len(commands) == 0 {
+			continue
+		}
+		n, err := seedCorpusInTx(ctx, tx, name, commands, opts.NowMs)
+		if err != nil {
+			return 0, fmt.Errorf("seed corpus %s: %w", name, err)
+		}
+		seeded += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return seeded, nil
+}
+
+// seedCorpusInTx seeds one corpus unless it was seeded before, and records
+// it. Commands are scored by their position, the first highest.
+func seedCorpusInTx(ctx context.Context, tx *sql.Tx, name string, commands []string, nowMs int64) (int, error) {
+	marker := corpusMigrationPrefix + name
+	var existing string
+	err := tx.QueryRowContext(ctx,
+		`SELECT name FROM storage_migration WHERE name = ?`, marker,
+	).Scan(&existing)
+	if err == nil {
+		return 0, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	seen := make(map[string]bool, len(commands))
+	for i, cmd := range commands {
+		preNorm := normalize.PreNormalize(cmd, normalize.PreNormConfig{})
+		if preNorm.TemplateID == "" || seen[preNorm.TemplateID] {
+			continue
+		}
+		seen[preNorm.TemplateID] = true
+		score := corpusMaxScore * float64(len(commands)-i) / float64(len(commands))
+		if err := insertCorpusCommand(ctx, tx, name, &preNorm, score, nowMs); err != nil {
+			return 0, fmt.Errorf("command %q: %w", cmd, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO storage_migration (name, completed_ms, events) VALUES (?, ?, ?)`,
+		marker, nowMs, len(seen),
+	); err != nil {
+		return 0, fmt.Errorf("record corpus: %w", err)
+	}
+	return len(seen), nil
+}
+
+// insertCorpusCommand writes the template and stats of one corpus command,
+// keeping any that already exist.
+func insertCorpusCommand(ctx context.Context, tx *sql.Tx, corpus string, preNorm *normalize.PreNormResult, score float64, nowMs int64) error {
+	tagsJSON := "null"
+	if len(preNorm.Tags) > 0 {
+		b, err := json.Marshal(preNorm.Tags)
+		if err != nil {
+			return fmt.Errorf("marshal tags: %w", err)
+		}
+		tagsJSON = string(b)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO command_template (
+			template_id, cmd_norm, tags, slot_count, first_seen_ms, last_seen_ms
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`, preNorm.TemplateID, preNorm.CmdNorm, tagsJSON, preNorm.SlotCount, nowMs, nowMs); err != nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO command_stat (
+			scope, template_id, score, success_count, failure_count, last_seen_ms
+		) VALUES (?, ?, ?, 0, 0, ?)
+	`, scopeGlobal, preNorm.TemplateID, score, nowMs); err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+	if corpus == CorpusCommon {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO project_type_stat (
+			project_type, template_id, score, count, last_seen_ms
+		) VALUES (?, ?, ?, 0, ?)
+	`, corpus, preNorm.TemplateID, score, nowMs); err != nil {
+		return fmt.Errorf("project type stat: %w", err)
+	}
+	return nil
+}
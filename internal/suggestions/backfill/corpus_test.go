@@ -0,0 +1,85 @@
+package backfill
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedCorpus_SeedsProjectTypes(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	ctx := context.Background()
+	nowMs := time.Now().UnixMilli()
+
+	n, err := SeedCorpus(ctx, sqlDB, CorpusOptions{
+		ProjectTypes: []string{"go", "unknown"},
+		Extra:        map[string][]string{"go": {"task lint"}},
+		NowMs:        nowMs,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, len(corpora[CorpusCommon])+len(corpora["go"])+1, n)
+	assert.Equal(t, n, countRows(t, sqlDB, "command_stat"))
+	assert.Equal(t, len(corpora["go"])+1, countRows(t, sqlDB, "project_type_stat"))
+	assert.Equal(t, 0, countRows(t, sqlDB, "command_event"), "seeding must not invent history")
+
+	// Extra commands rank first, and no seeded command outranks a real use.
+	var cmdNorm string
+	var score float64
+	require.NoError(t, sqlDB.QueryRowContext(ctx, `
+		SELECT t.cmd_norm, s.score FROM project_type_stat s
+		JOIN command_template t ON t.template_id = s.template_id
+		WHERE s.project_type = 'go' ORDER BY s.score DESC LIMIT 1
+	`).Scan(&cmdNorm, &score))
+	assert.Equal(t, "task lint", cmdNorm)
+	assert.LessOrEqual(t, score, corpusMaxScore)
+
+	// Each corpus is seeded once.
+	n, err = SeedCorpus(ctx, sqlDB, CorpusOptions{ProjectTypes: []string{"go"}, NowMs: nowMs})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	n, err = SeedCorpus(ctx, sqlDB, CorpusOptions{ProjectTypes: []string{"rust"}, NowMs: nowMs})
+	require.NoError(t, err)
+	assert.Equal(t, len(corpora["rust"]), n)
+}
+
+func TestSeedCorpus_KeepsExistingStats(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, Seed(ctx, sqlDB, makeEntries(3, func(int) string { return "git status" }), "zsh"))
+	var before float64
+	require.NoError(t, sqlDB.QueryRowContext(ctx, `
+		SELECT s.score FROM command_stat s JOIN command_template t ON t.template_id = s.template_id
+		WHERE t.cmd_norm = 'git status'
+	`).Scan(&before))
+
+	_, err := SeedCorpus(ctx, sqlDB, CorpusOptions{NowMs: time.Now().UnixMilli()})
+	require.NoError(t, err)
+
+	var after float64
+	require.NoError(t, sqlDB.QueryRowContext(ctx, `
+		SELECT s.score FROM command_stat s JOIN command_template t ON t.template_id = s.template_id
+		WHERE t.cmd_norm = 'git status'
+	`).Scan(&after))
+	assert.Equal(t, before, after)
+}
+
+func TestSeedCorpus_SkipsWarmDatabase(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, Seed(ctx, sqlDB, makeEntries(ColdStartMaxEvents, nil), "bash"))
+	templates := countRows(t, sqlDB, "command_template")
+
+	n, err := SeedCorpus(ctx, sqlDB, CorpusOptions{ProjectTypes: []string{"go"}, NowMs: time.Now().UnixMilli()})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, templates, countRows(t, sqlDB, "command_template"))
+}
@@ -0,0 +1,104 @@
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/runger/clai/internal/storage"
+)
+
+// ImportCommands writes commands exported from this or another machine to
+// the V2 suggestion tables, merging them into existing stats the way
+// MigrateV1 does. A command is skipped when its session already has an
+// event with the same text recorded between the command's start and end,
+// which covers both earlier imports (stamped with the start time) and live
+// events (stamped with the end time). Returns the number of events written.
+func ImportCommands(ctx context.Context, db *sql.DB, commands []storage.Command) (int, error) {
+	fresh, err := selectNewCommands(ctx, db, commands)
+	if err != nil {
+		return 0, err
+	}
+	if len(fresh) == 0 {
+		return 0, nil
+	}
+	sort.SliceStable(fresh, func(i, j int) bool {
+		return fresh[i].TSStartUnixMs < fresh[j].TSStartUnixMs
+	})
+
+	sessions := make(map[string]*storage.Session)
+	for i := range fresh {
+		if _, ok := sessions[fresh[i].SessionID]; !ok {
+			sessions[fresh[i].SessionID] = &storage.Session{StartedAtUnixMs: fresh[i].TSStartUnixMs}
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // best-effort rollback on error
+
+	if err := insertV1Sessions(ctx, tx, sessions); err != nil {
+		return 0, err
+	}
+	normalized := normalizeV1Commands(ctx, fresh)
+	aggregates := seedAggregates{templates: buildTemplateAggregates(normalized)}
+	aggregates.transitions, aggregates.transitionLastMs = buildTransitionAggregates(normalized)
+	if err := insertSeedData(ctx, tx, normalized, "", aggregates); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return len(fresh), nil
+}
+
+// selectNewCommands returns the commands db does not have yet. Archived
+// events have no cmd_raw, so any archived event in the window counts.
+func selectNewCommands(ctx context.Context, db *sql.DB, commands []storage.Command) ([]storage.Command, error) {
+	stmt, err := db.PrepareContext(ctx, `
+		SELECT 1 FROM command_event
+		WHERE session_id = ? AND ts_ms BETWEEN ? AND ?
+		  AND (cmd_raw = ? OR archive_chunk_id IS NOT NULL)
+		LIMIT 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("prepare duplicate check: %w", err)
+	}
+	defer stmt.Close()
+
+	type eventKey struct {
+		sessionID string
+		tsMs      int64
+		cmd       string
+	}
+	seen := make(map[eventKey]bool)
+
+	var fresh []storage.Command
+	for i := range commands {
+		cmd := commands[i]
+		key := eventKey{cmd.SessionID, cmd.TSStartUnixMs, cmd.Command}
+		if cmd.Command == "" || cmd.SessionID == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		endMs := cmd.TSStartUnixMs
+		if cmd.DurationMs != nil && *cmd.DurationMs > 0 {
+			endMs += *cmd.DurationMs
+		}
+
+		var exists int
+		err := stmt.QueryRowContext(ctx, cmd.SessionID, cmd.TSStartUnixMs, endMs, cmd.Command).Scan(&exists)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("check for duplicate event: %w", err)
+		}
+		fresh = append(fresh, cmd)
+	}
+	return fresh, nil
+}
@@ -0,0 +1,55 @@
+package backfill
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/runger/clai/internal/storage"
+)
+
+func TestImportCommands_SkipsKnownEvents(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	ctx := context.Background()
+
+	// A live event for "git log" started at 2000 and was stamped at its end.
+	_, err := sqlDB.ExecContext(ctx, `
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, template_id, ephemeral)
+		VALUES ('s1', 2040, '/work', 'git log', 'git log', 'tpl-log', 0)
+	`)
+	require.NoError(t, err)
+
+	repo, name, branch := "/work/clai", "clai", "main"
+	failed := 2
+	duration := int64(50)
+	cmds := []storage.Command{
+		{SessionID: "s1", TSStartUnixMs: 1000, CWD: "/work", Command: "make test", ExitCode: &failed,
+			GitRepoRoot: &repo, GitRepoName: &name, GitBranch: &branch},
+		{SessionID: "s1", TSStartUnixMs: 2000, CWD: "/work", Command: "git log", DurationMs: &duration},
+		{SessionID: "s2", TSStartUnixMs: 3000, CWD: "/tmp", Command: "ls"},
+		{SessionID: "s2", TSStartUnixMs: 3000, CWD: "/tmp", Command: "ls"},
+	}
+
+	n, err := ImportCommands(ctx, sqlDB, cmds)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 3, countRows(t, sqlDB, "command_event"))
+
+	var repoKey, eventBranch string
+	var exitCode int
+	require.NoError(t, sqlDB.QueryRowContext(ctx,
+		`SELECT repo_key, branch, exit_code FROM command_event WHERE cmd_raw = 'make test'`,
+	).Scan(&repoKey, &eventBranch, &exitCode))
+	assert.Equal(t, "clai", repoKey)
+	assert.Equal(t, "main", eventBranch)
+	assert.Equal(t, 2, exitCode)
+
+	// A second import of the same commands writes nothing.
+	n, err = ImportCommands(ctx, sqlDB, cmds)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 3, countRows(t, sqlDB, "command_event"))
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"runtime"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/runger/clai/internal/history"
 	"github.com/runger/clai/internal/suggestions/normalize"
+	"github.com/runger/clai/internal/suggestions/usage"
 )
 
 // tauMs is the decay time constant (7 days in milliseconds), matching the
@@ -29,12 +31,29 @@ const scopeGlobal = "global"
 
 // normalizedEntry holds the result of parallel normalization for one history entry.
 type normalizedEntry struct {
+	meta    *eventMeta // nil for imported shell history
 	cmdRaw  string
 	preNorm normalize.PreNormResult
 	tsMs    int64
 	index   int // original position in sorted entries
 }
 
+// eventMeta carries the context recorded with a command, which imported
+// shell history does not have.
+type eventMeta struct {
+	exitCode   *int
+	durationMs *int64
+	sessionID  string
+	cwd        string
+	repoKey    string
+	branch     string
+}
+
+// failed reports whether the command exited non-zero.
+func (m *eventMeta) failed() bool {
+	return m != nil && m.exitCode != nil && *m.exitCode != 0
+}
+
 // templateInfo tracks aggregate data for a unique command template during the
 // in-memory dedup phase.
 type templateInfo struct {
@@ -45,6 +64,7 @@ type templateInfo struct {
 	firstSeenMs     int64
 	lastSeenMs      int64
 	occurrenceCount int
+	failureCount    int
 }
 
 // transitionKey identifies a directed bigram between two command templates.
@@ -139,6 +159,9 @@ func buildTemplateAggregates(normalized []normalizedEntry) map[string]*templateI
 			templates[tid] = info
 		}
 		info.occurrenceCount++
+		if ne.meta.failed() {
+			info.failureCount++
+		}
 		info.timestamps = append(info.timestamps, ne.tsMs)
 		if ne.tsMs < info.firstSeenMs {
 			info.firstSeenMs = ne.tsMs
@@ -150,15 +173,24 @@ func buildTemplateAggregates(normalized []normalizedEntry) map[string]*templateI
 	return templates
 }
 
+// buildTransitionAggregates counts bigrams between consecutive commands of
+// the same session. Imported shell history is one sequence.
 func buildTransitionAggregates(normalized []normalizedEntry) (transitions map[transitionKey]int, lastMs map[transitionKey]int64) {
 	transitions = make(map[transitionKey]int)
 	lastMs = make(map[transitionKey]int64)
-	for i := 1; i < len(normalized); i++ {
-		prev := normalized[i-1].preNorm.TemplateID
+	prevBySession := make(map[string]string)
+	for i := range normalized {
+		var sessionID string
+		if normalized[i].meta != nil {
+			sessionID = normalized[i].meta.sessionID
+		}
 		next := normalized[i].preNorm.TemplateID
-		key := transitionKey{prevTemplateID: prev, nextTemplateID: next}
-		transitions[key]++
-		lastMs[key] = normalized[i].tsMs
+		if prev, ok := prevBySession[sessionID]; ok {
+			key := transitionKey{prevTemplateID: prev, nextTemplateID: next}
+			transitions[key]++
+			lastMs[key] = normalized[i].tsMs
+		}
+		prevBySession[sessionID] = next
 	}
 	return transitions, lastMs
 }
@@ -180,6 +212,18 @@ func seedNormalizedEntries(
 	if sessErr := insertBackfillSession(ctx, tx, sessionID, shell, normalized[0].tsMs); sessErr != nil {
 		return sessErr
 	}
+	if err := insertSeedData(ctx, tx, normalized, sessionID, aggregates); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// insertSeedData writes events and merges their aggregates into existing
+// stats. sessionID applies to entries without their own session.
+func insertSeedData(ctx context.Context, tx *sql.Tx, normalized []normalizedEntry, sessionID string, aggregates seedAggregates) error {
 	eventIDs, err := insertCommandEvents(ctx, tx, normalized, sessionID)
 	if err != nil {
 		return fmt.Errorf("insert command_events: %w", err)
@@ -196,12 +240,30 @@ func seedNormalizedEntries(
 	if err := insertPipelineData(ctx, tx, normalized, eventIDs); err != nil {
 		return fmt.Errorf("insert pipeline data: %w", err)
 	}
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit transaction: %w", err)
+	if err := usage.Record(ctx, tx, usageEvents(normalized)...); err != nil {
+		return fmt.Errorf("insert usage: %w", err)
 	}
 	return nil
 }
 
+// usageEvents converts seeded entries for the usage tables. Imported shell
+// history has no exit codes or directories, so it counts towards commands
+// only.
+func usageEvents(normalized []normalizedEntry) []usage.Event {
+	events := make([]usage.Event, len(normalized))
+	for i := range normalized {
+		events[i] = usage.Event{
+			TSMs:       normalized[i].tsMs,
+			TemplateID: normalized[i].preNorm.TemplateID,
+		}
+		if m := normalized[i].meta; m != nil {
+			events[i].ExitCode = m.exitCode
+			events[i].Cwd = m.cwd
+		}
+	}
+	return events
+}
+
 func insertBackfillSession(ctx context.Context, tx *sql.Tx, sessionID, shell string, startedAtMs int64) error {
 	_, err := tx.ExecContext(ctx,
 		`INSERT OR IGNORE INTO session (id, shell, started_at_ms) VALUES (?, ?, ?)`,
@@ -293,14 +355,15 @@ func sanitizeUTF8(s string) string {
 }
 
 // insertCommandEvents inserts all command_event rows and returns a slice of
-// their auto-generated IDs (parallel to normalized entries).
+// their auto-generated IDs (parallel to normalized entries). Entries without
+// metadata get sessionID, cwd "/" and no exit code.
 func insertCommandEvents(ctx context.Context, tx *sql.Tx, entries []normalizedEntry, sessionID string) ([]int64, error) {
 	stmt, err := tx.PrepareContext(ctx, `
 		INSERT INTO command_event (
 			session_id, ts_ms, cwd, repo_key, branch,
 			cmd_raw, cmd_norm, cmd_truncated, template_id,
 			exit_code, duration_ms, ephemeral
-		) VALUES (?, ?, ?, NULL, NULL, ?, ?, ?, ?, NULL, NULL, 0)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0)
 	`)
 	if err != nil {
 		return nil, err
@@ -315,14 +378,33 @@ func insertCommandEvents(ctx context.Context, tx *sql.Tx, entries []normalizedEn
 			truncated = 1
 		}
 
+		eventSession, cwd := sessionID, "/"
+		var repoKey, branch sql.NullString
+		var exitCode, durationMs sql.NullInt64
+		if m := ne.meta; m != nil {
+			eventSession, cwd = m.sessionID, m.cwd
+			repoKey = sql.NullString{String: m.repoKey, Valid: m.repoKey != ""}
+			branch = sql.NullString{String: m.branch, Valid: m.branch != ""}
+			if m.exitCode != nil {
+				exitCode = sql.NullInt64{Int64: int64(*m.exitCode), Valid: true}
+			}
+			if m.durationMs != nil {
+				durationMs = sql.NullInt64{Int64: *m.durationMs, Valid: true}
+			}
+		}
+
 		res, err := stmt.ExecContext(ctx,
-			sessionID,
+			eventSession,
 			ne.tsMs,
-			"/",
+			cwd,
+			repoKey,
+			branch,
 			ne.cmdRaw,
 			ne.preNorm.CmdNorm,
 			truncated,
 			ne.preNorm.TemplateID,
+			exitCode,
+			durationMs,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("row %d: %w", i, err)
@@ -336,12 +418,16 @@ func insertCommandEvents(ctx context.Context, tx *sql.Tx, entries []normalizedEn
 	return ids, nil
 }
 
-// insertCommandTemplates inserts unique command_template rows.
+// insertCommandTemplates inserts unique command_template rows, widening the
+// seen range of templates that already exist.
 func insertCommandTemplates(ctx context.Context, tx *sql.Tx, templates map[string]*templateInfo) error {
 	stmt, err := tx.PrepareContext(ctx, `
-		INSERT OR IGNORE INTO command_template (
+		INSERT INTO command_template (
 			template_id, cmd_norm, tags, slot_count, first_seen_ms, last_seen_ms
 		) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(template_id) DO UPDATE SET
+			first_seen_ms = MIN(command_template.first_seen_ms, excluded.first_seen_ms),
+			last_seen_ms = MAX(command_template.last_seen_ms, excluded.last_seen_ms)
 	`)
 	if err != nil {
 		return err
@@ -377,17 +463,29 @@ func insertCommandTemplates(ctx context.Context, tx *sql.Tx, templates map[strin
 // For each template, the score is computed by iterating occurrences chronologically:
 //
 //	score = score * exp(-(t_new - t_old) / tauMs) + 1.0
+//
+// A template that already has a stat row keeps it: counts are added and the
+// older score is decayed to the newer one's last_seen_ms before summing.
 func insertCommandStats(ctx context.Context, tx *sql.Tx, templates map[string]*templateInfo) error {
 	stmt, err := tx.PrepareContext(ctx, `
 		INSERT OR REPLACE INTO command_stat (
 			scope, template_id, score, success_count, failure_count, last_seen_ms
-		) VALUES (?, ?, ?, ?, 0, ?)
+		) VALUES (?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
+	existingStmt, err := tx.PrepareContext(ctx, `
+		SELECT score, success_count, failure_count, last_seen_ms
+		FROM command_stat WHERE scope = ? AND template_id = ?
+	`)
+	if err != nil {
+		return err
+	}
+	defer existingStmt.Close()
+
 	for tid, info := range templates {
 		// Sort timestamps to compute decay correctly.
 		ts := info.timestamps
@@ -406,12 +504,30 @@ func insertCommandStats(ctx context.Context, tx *sql.Tx, templates map[string]*t
 			lastMs = t
 		}
 
-		_, err := stmt.ExecContext(ctx,
+		successCount := info.occurrenceCount - info.failureCount
+		failureCount := info.failureCount
+		lastSeenMs := info.lastSeenMs
+
+		var oldScore float64
+		var oldSuccess, oldFailure int
+		var oldLastMs int64
+		err := existingStmt.QueryRowContext(ctx, scopeGlobal, tid).Scan(&oldScore, &oldSuccess, &oldFailure, &oldLastMs)
+		switch {
+		case err == nil:
+			score, lastSeenMs = mergeDecayedScores(score, lastSeenMs, oldScore, oldLastMs)
+			successCount += oldSuccess
+			failureCount += oldFailure
+		case !errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("read stat for %s: %w", tid, err)
+		}
+
+		_, err = stmt.ExecContext(ctx,
 			scopeGlobal,
 			tid,
 			score,
-			info.occurrenceCount,
-			info.lastSeenMs,
+			successCount,
+			failureCount,
+			lastSeenMs,
 		)
 		if err != nil {
 			return fmt.Errorf("stat for %s: %w", tid, err)
@@ -420,12 +536,27 @@ func insertCommandStats(ctx context.Context, tx *sql.Tx, templates map[string]*t
 	return nil
 }
 
-// insertTransitionStats inserts transition_stat rows for command bigrams.
+// mergeDecayedScores combines two decayed scores by decaying the older one
+// to the newer one's timestamp. It returns the score and that timestamp.
+func mergeDecayedScores(scoreA float64, lastMsA int64, scoreB float64, lastMsB int64) (float64, int64) {
+	if lastMsA < lastMsB {
+		scoreA, lastMsA, scoreB, lastMsB = scoreB, lastMsB, scoreA, lastMsA
+	}
+	decay := math.Exp(-float64(lastMsA-lastMsB) / float64(tauMs))
+	return scoreA + scoreB*decay, lastMsA
+}
+
+// insertTransitionStats inserts transition_stat rows for command bigrams,
+// adding to the counts of bigrams that already exist.
 func insertTransitionStats(ctx context.Context, tx *sql.Tx, transitions map[transitionKey]int, lastMs map[transitionKey]int64) error {
 	stmt, err := tx.PrepareContext(ctx, `
-		INSERT OR REPLACE INTO transition_stat (
+		INSERT INTO transition_stat (
 			scope, prev_template_id, next_template_id, weight, count, last_seen_ms
 		) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(scope, prev_template_id, next_template_id) DO UPDATE SET
+			weight = transition_stat.weight + excluded.weight,
+			count = transition_stat.count + excluded.count,
+			last_seen_ms = MAX(transition_stat.last_seen_ms, excluded.last_seen_ms)
 	`)
 	if err != nil {
 		return err
@@ -0,0 +1,254 @@
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/runger/clai/internal/history"
+	"github.com/runger/clai/internal/storage"
+)
+
+// V1MigrationName names the storage_migration row written by MigrateV1.
+const V1MigrationName = "v1-history"
+
+// v1ScanPageSize is the number of V1 commands read per query.
+const v1ScanPageSize = 5000
+
+// ErrAlreadyMigrated is returned by MigrateV1 when the V1 history has
+// already been migrated.
+var ErrAlreadyMigrated = errors.New("V1 history already migrated")
+
+// V1Result summarizes a MigrateV1 call.
+type V1Result struct {
+	Migrated int   // Commands written to the V2 database
+	Skipped  int   // Commands the V2 database already had
+	Sessions int   // Sessions the migrated commands belong to
+	CutoffMs int64 // Recorded commands from this time on were skipped (0 = none)
+}
+
+// V1Migrated reports whether MigrateV1 has completed on db.
+func V1Migrated(ctx context.Context, db *sql.DB) (bool, error) {
+	var name string
+	err := db.QueryRowContext(ctx,
+		`SELECT name FROM storage_migration WHERE name = ?`, V1MigrationName,
+	).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MigrateV1 backfills the V2 suggestion tables (events, templates, stats,
+// transitions, pipelines and the FTS index) from the V1 history store.
+//
+// The daemon has written both stores since V2 was enabled, so recorded
+// commands from the first live V2 event on are skipped. Imported shell
+// history is moved to the backfill-<shell> session Seed uses, and skipped
+// if that shell was already seeded. Everything, including the
+// storage_migration marker, is written in one transaction.
+func MigrateV1(ctx context.Context, db *sql.DB, v1 *storage.SQLiteStore, nowMs int64) (V1Result, error) {
+	var result V1Result
+
+	migrated, err := V1Migrated(ctx, db)
+	if err != nil {
+		return result, fmt.Errorf("check migration status: %w", err)
+	}
+	if migrated {
+		return result, ErrAlreadyMigrated
+	}
+
+	result.CutoffMs, err = liveEventCutoff(ctx, db)
+	if err != nil {
+		return result, fmt.Errorf("find first live event: %w", err)
+	}
+
+	commands, skipped, err := selectV1Commands(ctx, db, v1, result.CutoffMs)
+	if err != nil {
+		return result, err
+	}
+	result.Skipped = skipped
+
+	sessions, err := v1Sessions(ctx, v1, commands)
+	if err != nil {
+		return result, err
+	}
+	result.Sessions = len(sessions)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // best-effort rollback on error
+
+	if len(commands) > 0 {
+		if err := insertV1Sessions(ctx, tx, sessions); err != nil {
+			return result, err
+		}
+		normalized := normalizeV1Commands(ctx, commands)
+		aggregates := seedAggregates{templates: buildTemplateAggregates(normalized)}
+		aggregates.transitions, aggregates.transitionLastMs = buildTransitionAggregates(normalized)
+		if err := insertSeedData(ctx, tx, normalized, "", aggregates); err != nil {
+			return result, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO storage_migration (name, completed_ms, events) VALUES (?, ?, ?)`,
+		V1MigrationName, nowMs, len(commands),
+	); err != nil {
+		return result, fmt.Errorf("record migration: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	result.Migrated = len(commands)
+	return result, nil
+}
+
+// liveEventCutoff returns the timestamp of the oldest event the daemon
+// recorded in the V2 database, or 0 if it has none.
+func liveEventCutoff(ctx context.Context, db *sql.DB) (int64, error) {
+	var cutoff sql.NullInt64
+	err := db.QueryRowContext(ctx,
+		`SELECT MIN(ts_ms) FROM command_event WHERE session_id NOT LIKE 'backfill-%'`,
+	).Scan(&cutoff)
+	return cutoff.Int64, err
+}
+
+// selectV1Commands reads the V1 commands to migrate, rewriting imported
+// history to its backfill session. It returns them oldest first and the
+// number skipped.
+func selectV1Commands(ctx context.Context, db *sql.DB, v1 *storage.SQLiteStore, cutoffMs int64) ([]storage.Command, int, error) {
+	seededShells := make(map[string]bool)
+
+	var commands []storage.Command
+	var skipped int
+	var afterID int64
+	for {
+		page, err := v1.ScanCommands(ctx, afterID, v1ScanPageSize)
+		if err != nil {
+			return nil, 0, fmt.Errorf("read V1 commands: %w", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		afterID = page[len(page)-1].ID
+
+		for i := range page {
+			cmd := page[i]
+			if shell, ok := strings.CutPrefix(cmd.SessionID, "imported-"); ok {
+				seeded, known := seededShells[shell]
+				if !known {
+					if seeded, err = isBackfillSeeded(ctx, db, "backfill-"+shell); err != nil {
+						return nil, 0, fmt.Errorf("check backfill for %s: %w", shell, err)
+					}
+					seededShells[shell] = seeded
+				}
+				if seeded {
+					skipped++
+					continue
+				}
+				cmd.SessionID = "backfill-" + shell
+			} else if cutoffMs > 0 && cmd.TSStartUnixMs >= cutoffMs {
+				skipped++
+				continue
+			}
+			commands = append(commands, cmd)
+		}
+	}
+
+	sort.SliceStable(commands, func(i, j int) bool {
+		return commands[i].TSStartUnixMs < commands[j].TSStartUnixMs
+	})
+	return commands, skipped, nil
+}
+
+// v1Sessions returns the V1 session of each migrated command, keyed by the
+// V2 session ID. Sessions missing from the V1 store get an empty shell.
+func v1Sessions(ctx context.Context, v1 *storage.SQLiteStore, commands []storage.Command) (map[string]*storage.Session, error) {
+	sessions := make(map[string]*storage.Session)
+	for i := range commands {
+		id := commands[i].SessionID
+		if _, ok := sessions[id]; ok {
+			continue
+		}
+
+		v1ID := id
+		if shell, ok := strings.CutPrefix(id, "backfill-"); ok {
+			v1ID = storage.ImportSessionID(shell)
+		}
+		sess, err := v1.GetSession(ctx, v1ID)
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			sess = &storage.Session{StartedAtUnixMs: commands[i].TSStartUnixMs}
+		} else if err != nil {
+			return nil, fmt.Errorf("read V1 session %s: %w", v1ID, err)
+		}
+		sessions[id] = sess
+	}
+	return sessions, nil
+}
+
+func insertV1Sessions(ctx context.Context, tx *sql.Tx, sessions map[string]*storage.Session) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT OR IGNORE INTO session (id, shell, started_at_ms, host, user_name)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for id, sess := range sessions {
+		if _, err := stmt.ExecContext(ctx, id, sess.Shell, sess.StartedAtUnixMs,
+			nullString(sess.Hostname), nullString(sess.Username)); err != nil {
+			return fmt.Errorf("insert session %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// normalizeV1Commands normalizes commands in parallel and attaches the
+// context each was recorded with.
+func normalizeV1Commands(ctx context.Context, commands []storage.Command) []normalizedEntry {
+	entries := make([]history.ImportEntry, len(commands))
+	for i := range commands {
+		entries[i] = history.ImportEntry{
+			Command:   commands[i].Command,
+			Timestamp: time.UnixMilli(commands[i].TSStartUnixMs),
+		}
+	}
+
+	normalized := parallelNormalize(ctx, entries)
+	for i := range normalized {
+		cmd := &commands[i]
+		normalized[i].meta = &eventMeta{
+			sessionID:  cmd.SessionID,
+			cwd:        cmd.CWD,
+			repoKey:    derefString(cmd.GitRepoName),
+			branch:     derefString(cmd.GitBranch),
+			exitCode:   cmd.ExitCode,
+			durationMs: cmd.DurationMs,
+		}
+	}
+	return normalized
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,196 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/runger/clai/internal/history"
+	"github.com/runger/clai/internal/storage"
+)
+
+// newTestV1Store opens a fresh V1 history store in a temp directory.
+func newTestV1Store(t *testing.T) *storage.SQLiteStore {
+	t.Helper()
+
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// addV1Command records a finished command in the V1 store.
+func addV1Command(t *testing.T, store *storage.SQLiteStore, sessionID, command string, tsMs int64, exitCode int) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := store.GetSession(ctx, sessionID); err != nil {
+		require.NoError(t, store.CreateSession(ctx, &storage.Session{
+			SessionID:       sessionID,
+			StartedAtUnixMs: tsMs,
+			Shell:           "zsh",
+			OS:              "linux",
+			Hostname:        "host",
+			InitialCWD:      "/work",
+		}))
+	}
+
+	repo, branch := "clai", "main"
+	commandID := fmt.Sprintf("%s-%d", sessionID, tsMs)
+	require.NoError(t, store.CreateCommand(ctx, &storage.Command{
+		CommandID:     commandID,
+		SessionID:     sessionID,
+		TSStartUnixMs: tsMs,
+		CWD:           "/work",
+		Command:       command,
+		GitRepoName:   &repo,
+		GitBranch:     &branch,
+	}))
+	require.NoError(t, store.UpdateCommandEnd(ctx, commandID, exitCode, tsMs+50, 50))
+}
+
+func TestMigrateV1_Basic(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	v1 := newTestV1Store(t)
+	ctx := context.Background()
+
+	addV1Command(t, v1, "s1", "make build", 1000, 0)
+	addV1Command(t, v1, "s1", "make test", 2000, 2)
+	addV1Command(t, v1, "s1", "make test", 3000, 0)
+	addV1Command(t, v1, "s2", "git status", 1500, 0)
+	_, err := v1.ImportHistory(ctx, []history.ImportEntry{
+		{Command: "ls -la", Timestamp: time.UnixMilli(500)},
+	}, "bash")
+	require.NoError(t, err)
+
+	result, err := MigrateV1(ctx, sqlDB, v1, 10_000)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Migrated)
+	assert.Equal(t, 0, result.Skipped)
+	assert.Equal(t, 3, result.Sessions)
+
+	assert.Equal(t, 5, countRows(t, sqlDB, "command_event"))
+	assert.Equal(t, 5, countRows(t, sqlDB, "command_event_fts"))
+
+	// Recorded context is kept.
+	var cwd, repoKey, branch string
+	var exitCode, durationMs int64
+	require.NoError(t, sqlDB.QueryRowContext(ctx, `
+		SELECT cwd, repo_key, branch, exit_code, duration_ms
+		FROM command_event WHERE session_id = 's1' AND ts_ms = 2000
+	`).Scan(&cwd, &repoKey, &branch, &exitCode, &durationMs))
+	assert.Equal(t, "/work", cwd)
+	assert.Equal(t, "clai", repoKey)
+	assert.Equal(t, "main", branch)
+	assert.Equal(t, int64(2), exitCode)
+	assert.Equal(t, int64(50), durationMs)
+
+	// Imported history lands in the backfill session Seed uses.
+	var imported int
+	require.NoError(t, sqlDB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM command_event WHERE session_id = 'backfill-bash'`).Scan(&imported))
+	assert.Equal(t, 1, imported)
+
+	// Failures are counted per template.
+	var success, failure int
+	require.NoError(t, sqlDB.QueryRowContext(ctx, `
+		SELECT cs.success_count, cs.failure_count
+		FROM command_stat cs JOIN command_template ct ON ct.template_id = cs.template_id
+		WHERE ct.cmd_norm = 'make test'
+	`).Scan(&success, &failure))
+	assert.Equal(t, 1, success)
+	assert.Equal(t, 1, failure)
+
+	// Transitions stay within a session: build->test, test->test.
+	assert.Equal(t, 2, countRows(t, sqlDB, "transition_stat"))
+
+	migrated, err := V1Migrated(ctx, sqlDB)
+	require.NoError(t, err)
+	assert.True(t, migrated)
+
+	_, err = MigrateV1(ctx, sqlDB, v1, 20_000)
+	assert.ErrorIs(t, err, ErrAlreadyMigrated)
+	assert.Equal(t, 5, countRows(t, sqlDB, "command_event"))
+}
+
+func TestMigrateV1_SkipsCommandsAlreadyInV2(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	v1 := newTestV1Store(t)
+	ctx := context.Background()
+
+	// The daemon started writing V2 at ts 2000; both stores have "git log".
+	_, err := sqlDB.ExecContext(ctx, `
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, template_id, ephemeral)
+		VALUES ('s1', 2000, '/work', 'git log', 'git log', 'tpl-log', 0)
+	`)
+	require.NoError(t, err)
+	addV1Command(t, v1, "s1", "git status", 1000, 0)
+	addV1Command(t, v1, "s1", "git log", 2000, 0)
+
+	// Shell history already seeded for bash is not imported twice.
+	require.NoError(t, Seed(ctx, sqlDB, makeEntries(2, nil), "bash"))
+	_, err = v1.ImportHistory(ctx, makeEntries(2, nil), "bash")
+	require.NoError(t, err)
+
+	result, err := MigrateV1(ctx, sqlDB, v1, 10_000)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Migrated)
+	assert.Equal(t, 3, result.Skipped)
+	assert.Equal(t, int64(2000), result.CutoffMs)
+	assert.Equal(t, 4, countRows(t, sqlDB, "command_event"))
+}
+
+func TestMigrateV1_MergesExistingStats(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	v1 := newTestV1Store(t)
+	ctx := context.Background()
+
+	// Live usage already recorded "git status" at a later time.
+	require.NoError(t, Seed(ctx, sqlDB, makeEntries(1, func(int) string { return "git status" }), "zsh"))
+	var tid string
+	var liveLastMs int64
+	require.NoError(t, sqlDB.QueryRowContext(ctx,
+		`SELECT template_id, last_seen_ms FROM command_stat`).Scan(&tid, &liveLastMs))
+
+	addV1Command(t, v1, "old", "git status", liveLastMs-1000, 1)
+
+	_, err := MigrateV1(ctx, sqlDB, v1, liveLastMs+1000)
+	require.NoError(t, err)
+
+	var score float64
+	var success, failure int
+	var lastMs int64
+	require.NoError(t, sqlDB.QueryRowContext(ctx, `
+		SELECT score, success_count, failure_count, last_seen_ms
+		FROM command_stat WHERE template_id = ?
+	`, tid).Scan(&score, &success, &failure, &lastMs))
+	assert.Equal(t, 1, success)
+	assert.Equal(t, 1, failure)
+	assert.Equal(t, liveLastMs, lastMs)
+	want, _ := mergeDecayedScores(1, liveLastMs, 1, liveLastMs-1000)
+	assert.InDelta(t, want, score, 1e-9)
+	assert.Greater(t, score, 1.9)
+}
+
+func TestMigrateV1_EmptyV1Store(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	v1 := newTestV1Store(t)
+	ctx := context.Background()
+
+	result, err := MigrateV1(ctx, sqlDB, v1, 10_000)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Migrated)
+
+	migrated, err := V1Migrated(ctx, sqlDB)
+	require.NoError(t, err)
+	assert.True(t, migrated, "an empty migration still completes")
+}
@@ -0,0 +1,269 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/runger/clai/internal/suggestions/event"
+)
+
+// ErrSpoolFull is returned by Spool.Append when the spool has reached its
+// event or byte limit.
+var ErrSpoolFull = errors.New("ingest spool full")
+
+// Spool is an append-only on-disk queue of command events. The writer
+// spills events into it when its in-memory queue is full and replays them
+// once the database keeps up again.
+//
+// Events are stored one JSON object per line. The offset of the first
+// event not yet replayed is kept in a sidecar file, so events survive a
+// daemon restart and are not replayed twice. The file is truncated once
+// everything in it has been replayed.
+type Spool struct {
+	file      *os.File
+	path      string
+	readOff   int64
+	size      int64
+	maxBytes  int64
+	pending   int
+	maxEvents int
+	mu        sync.Mutex
+}
+
+// OpenSpool opens the spool at path, picking up events left by a previous
+// run. The file is only created when the first event is spilled. Limits
+// of zero or less mean unlimited.
+func OpenSpool(path string, maxEvents int, maxBytes int64) (*Spool, error) {
+	s := &Spool{path: path, maxEvents: maxEvents, maxBytes: maxBytes}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stat spool: %w", err)
+	}
+	s.size = info.Size()
+	s.readOff = s.loadOffset()
+	if s.readOff > s.size {
+		s.readOff = 0
+	}
+
+	pending, end, err := s.scanFrom(s.readOff)
+	if err != nil {
+		return nil, err
+	}
+	// Drop a partial last line left by a crash so new events start on a
+	// fresh line.
+	if end < s.size {
+		if err := os.Truncate(path, end); err != nil {
+			return nil, fmt.Errorf("truncate spool: %w", err)
+		}
+		s.size = end
+	}
+	s.pending = pending
+	return s, nil
+}
+
+// Append writes ev to the end of the spool.
+func (s *Spool) Append(ev *event.CommandEvent) error {
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxEvents > 0 && s.pending >= s.maxEvents {
+		return ErrSpoolFull
+	}
+	if s.maxBytes > 0 && s.size-s.readOff+int64(len(line)) > s.maxBytes {
+		return ErrSpoolFull
+	}
+
+	if s.file == nil {
+		if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+			return fmt.Errorf("create spool dir: %w", err)
+		}
+		f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("open spool: %w", err)
+		}
+		s.file = f
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write spool: %w", err)
+	}
+	s.pending++
+	return nil
+}
+
+// SpoolPos marks how far a Peek read into the spool.
+type SpoolPos struct {
+	off   int64
+	lines int
+}
+
+// Peek returns up to n of the oldest spooled events without removing them,
+// and the position to pass to Ack once they are written. Lines that cannot
+// be decoded, such as a partial line from a crash, are skipped.
+func (s *Spool) Peek(n int) ([]*event.CommandEvent, SpoolPos, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pos := SpoolPos{off: s.readOff}
+	if s.pending == 0 {
+		return nil, pos, nil
+	}
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, pos, fmt.Errorf("open spool: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(s.readOff, io.SeekStart); err != nil {
+		return nil, pos, fmt.Errorf("seek spool: %w", err)
+	}
+
+	var events []*event.CommandEvent
+	r := bufio.NewReader(f)
+	for pos.lines < n && pos.lines < s.pending {
+		line, err := r.ReadBytes('\n')
+		if len(line) == 0 || line[len(line)-1] != '\n' {
+			break
+		}
+		pos.off += int64(len(line))
+		pos.lines++
+		var ev event.CommandEvent
+		if json.Unmarshal(line, &ev) == nil {
+			events = append(events, &ev)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return events, pos, nil
+}
+
+// Ack marks everything up to pos as replayed. When the spool is empty
+// afterwards, the file is truncated.
+func (s *Spool) Ack(pos SpoolPos) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pos.off <= s.readOff {
+		return nil
+	}
+	s.readOff = pos.off
+	s.pending -= pos.lines
+	if s.pending < 0 {
+		s.pending = 0
+	}
+
+	if s.pending == 0 && s.readOff >= s.size {
+		return s.reset()
+	}
+	return s.saveOffset()
+}
+
+// Len returns the number of events waiting to be replayed.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
+
+// Bytes returns the size of the events waiting to be replayed.
+func (s *Spool) Bytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size - s.readOff
+}
+
+// Close closes the spool file. Pending events stay on disk.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// reset empties the spool file and removes the offset file.
+func (s *Spool) reset() error {
+	if s.file != nil {
+		if err := s.file.Truncate(0); err != nil {
+			return fmt.Errorf("truncate spool: %w", err)
+		}
+	} else if err := os.Truncate(s.path, 0); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("truncate spool: %w", err)
+	}
+	s.size = 0
+	s.readOff = 0
+	if err := os.Remove(s.offsetPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove spool offset: %w", err)
+	}
+	return nil
+}
+
+// scanFrom counts the complete lines in the spool file from off onwards
+// and returns the offset just past the last one.
+func (s *Spool) scanFrom(off int64) (int, int64, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return 0, off, fmt.Errorf("open spool: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(off, io.SeekStart); err != nil {
+		return 0, off, fmt.Errorf("seek spool: %w", err)
+	}
+
+	n := 0
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			n++
+			off += int64(len(line))
+		}
+		if err != nil {
+			return n, off, nil
+		}
+	}
+}
+
+func (s *Spool) offsetPath() string {
+	return s.path + ".offset"
+}
+
+func (s *Spool) loadOffset() int64 {
+	data, err := os.ReadFile(s.offsetPath())
+	if err != nil {
+		return 0
+	}
+	off, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || off < 0 {
+		return 0
+	}
+	return off
+}
+
+func (s *Spool) saveOffset() error {
+	return os.WriteFile(s.offsetPath(), []byte(strconv.FormatInt(s.readOff, 10)), 0o600)
+}
@@ -0,0 +1,114 @@
+package batch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/runger/clai/internal/suggestions/event"
+)
+
+func spoolEvent(cmd string) *event.CommandEvent {
+	return &event.CommandEvent{
+		Version:   event.EventVersion,
+		Type:      event.EventTypeCommandEnd,
+		SessionID: "s1",
+		Shell:     event.ShellZsh,
+		Cwd:       "/repo",
+		CmdRaw:    cmd,
+		TS:        1,
+	}
+}
+
+func TestSpool_AppendPeekAck(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "ingest.spool")
+	s, err := OpenSpool(path, 0, 0)
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, statErr := os.Stat(path)
+	assert.True(t, os.IsNotExist(statErr), "spool file created before first append")
+
+	for _, cmd := range []string{"a", "b", "c"} {
+		require.NoError(t, s.Append(spoolEvent(cmd)))
+	}
+	assert.Equal(t, 3, s.Len())
+
+	events, pos, err := s.Peek(2)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "a", events[0].CmdRaw)
+	assert.Equal(t, 3, s.Len(), "Peek must not consume events")
+
+	require.NoError(t, s.Ack(pos))
+	assert.Equal(t, 1, s.Len())
+
+	events, pos, err = s.Peek(10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "c", events[0].CmdRaw)
+	require.NoError(t, s.Ack(pos))
+
+	assert.Equal(t, 0, s.Len())
+	assert.Equal(t, int64(0), s.Bytes())
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), info.Size(), "spool not truncated once drained")
+}
+
+func TestSpool_ReopenResumesAfterAckedEvents(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "ingest.spool")
+	s, err := OpenSpool(path, 0, 0)
+	require.NoError(t, err)
+	for _, cmd := range []string{"a", "b", "c"} {
+		require.NoError(t, s.Append(spoolEvent(cmd)))
+	}
+	_, pos, err := s.Peek(1)
+	require.NoError(t, err)
+	require.NoError(t, s.Ack(pos))
+	require.NoError(t, s.Close())
+
+	// Simulate a crash in the middle of writing another event.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"cmd_raw":"trunc`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s, err = OpenSpool(path, 0, 0)
+	require.NoError(t, err)
+	defer s.Close()
+	assert.Equal(t, 2, s.Len())
+
+	require.NoError(t, s.Append(spoolEvent("d")))
+	events, _, err := s.Peek(10)
+	require.NoError(t, err)
+	var cmds []string
+	for _, ev := range events {
+		cmds = append(cmds, ev.CmdRaw)
+	}
+	assert.Equal(t, []string{"b", "c", "d"}, cmds)
+}
+
+func TestSpool_Limits(t *testing.T) {
+	t.Parallel()
+
+	s, err := OpenSpool(filepath.Join(t.TempDir(), "ingest.spool"), 2, 0)
+	require.NoError(t, err)
+	defer s.Close()
+	require.NoError(t, s.Append(spoolEvent("a")))
+	require.NoError(t, s.Append(spoolEvent("b")))
+	assert.ErrorIs(t, s.Append(spoolEvent("c")), ErrSpoolFull)
+
+	small, err := OpenSpool(filepath.Join(t.TempDir(), "ingest.spool"), 0, 10)
+	require.NoError(t, err)
+	defer small.Close()
+	assert.ErrorIs(t, small.Append(spoolEvent("too big for ten bytes")), ErrSpoolFull)
+}
@@ -12,6 +12,7 @@ package batch
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log/slog"
 	"sync"
 	"time"
@@ -51,6 +52,11 @@ type Options struct {
 	// Defaults to DefaultMaxBatchSize (100).
 	MaxBatchSize int
 
+	// Spool receives events while the queue is full, so a slow database
+	// delays events instead of dropping them. Spooled events are replayed
+	// once the queue has drained. Optional; the writer closes it on Stop.
+	Spool *Spool
+
 	// QueueSize is the size of the event channel buffer.
 	// Defaults to DefaultQueueSize (500).
 	QueueSize int
@@ -92,6 +98,8 @@ type Writer struct {
 	logger         *slog.Logger
 	opts           Options
 	eventsDropped  int64
+	eventsSpilled  int64
+	eventsReplayed int64
 	batchesWritten int64
 	eventsWritten  int64
 	writeErrors    int64
@@ -142,11 +150,18 @@ func (w *Writer) Stop() {
 	w.stopOnce.Do(func() {
 		close(w.doneCh)
 		<-w.stoppedCh // Wait for write loop to finish
+		ingest.ReleaseStatements(w.db)
+		if w.opts.Spool != nil {
+			if err := w.opts.Spool.Close(); err != nil {
+				w.logger.Warn("failed to close ingest spool", "error", err)
+			}
+		}
 	})
 }
 
 // Enqueue adds an event to the write queue.
-// Returns true if the event was queued, false if dropped (queue full).
+// When the queue is full the event is spilled to the spool, if configured.
+// Returns true if the event was queued or spilled, false if dropped.
 // This method is non-blocking and safe for concurrent use.
 func (w *Writer) Enqueue(ev *event.CommandEvent) bool {
 	if ev == nil || ev.Ephemeral {
@@ -154,10 +169,18 @@ func (w *Writer) Enqueue(ev *event.CommandEvent) bool {
 		return true
 	}
 
+	// Keep order: once events are spooled, newer ones queue up behind them.
+	if w.opts.Spool != nil && w.opts.Spool.Len() > 0 {
+		return w.spill(ev)
+	}
+
 	select {
 	case w.eventCh <- ev:
 		return true
 	default:
+		if w.opts.Spool != nil {
+			return w.spill(ev)
+		}
 		// Queue full, drop the event
 		w.mu.Lock()
 		w.eventsDropped++
@@ -166,6 +189,58 @@ func (w *Writer) Enqueue(ev *event.CommandEvent) bool {
 	}
 }
 
+// spill appends ev to the spool, dropping it if the spool is full or
+// cannot be written.
+func (w *Writer) spill(ev *event.CommandEvent) bool {
+	err := w.opts.Spool.Append(ev)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.eventsDropped++
+		if !errors.Is(err, ErrSpoolFull) {
+			w.lastWriteError = err
+			w.lastErrorTime = time.Now()
+		}
+		return false
+	}
+	w.eventsSpilled++
+	return true
+}
+
+// replaySpool writes one batch of spooled events once the queue is empty.
+// Events stay in the spool until they are written.
+func (w *Writer) replaySpool() {
+	spool := w.opts.Spool
+	if spool == nil || spool.Len() == 0 || len(w.eventCh) > 0 {
+		return
+	}
+
+	events, pos, err := spool.Peek(w.opts.MaxBatchSize)
+	if err == nil && len(events) > 0 {
+		err = w.writeBatch(events)
+	}
+	if err == nil {
+		err = spool.Ack(pos)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.writeErrors++
+		w.lastWriteError = err
+		w.lastErrorTime = time.Now()
+		return
+	}
+	if len(events) > 0 {
+		w.eventsReplayed += int64(len(events))
+		w.eventsWritten += int64(len(events))
+		w.batchesWritten++
+		w.lastFlushTime = time.Now()
+		w.lastBatchSize = len(events)
+	}
+}
+
 // Flush triggers an immediate flush of the current batch.
 // This is non-blocking; the actual flush happens asynchronously.
 func (w *Writer) Flush() {
@@ -217,6 +292,7 @@ func (w *Writer) writeLoop() {
 
 		case <-ticker.C:
 			flush()
+			w.replaySpool()
 
 		case <-w.flushCh:
 			flush()
@@ -399,13 +475,21 @@ type Stats struct {
 	EventsWritten  int64
 	BatchesWritten int64
 	EventsDropped  int64
+	EventsSpilled  int64
+	EventsReplayed int64
 	WriteErrors    int64
 	QueueLength    int
+	SpoolLength    int
 	LastBatchSize  int
 }
 
 // Stats returns the current writer statistics.
 func (w *Writer) Stats() Stats {
+	spoolLen := 0
+	if w.opts.Spool != nil {
+		spoolLen = w.opts.Spool.Len()
+	}
+
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
@@ -413,7 +497,10 @@ func (w *Writer) Stats() Stats {
 		EventsWritten:  w.eventsWritten,
 		BatchesWritten: w.batchesWritten,
 		EventsDropped:  w.eventsDropped,
+		EventsSpilled:  w.eventsSpilled,
+		EventsReplayed: w.eventsReplayed,
 		QueueLength:    len(w.eventCh),
+		SpoolLength:    spoolLen,
 		LastFlushTime:  w.lastFlushTime,
 		LastBatchSize:  w.lastBatchSize,
 		WriteErrors:    w.writeErrors,
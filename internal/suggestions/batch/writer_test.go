@@ -264,6 +264,40 @@ func TestWriter_QueueFull(t *testing.T) {
 	assert.Greater(t, stats.EventsDropped, int64(0))
 }
 
+func TestWriter_SpillsToSpoolAndReplays(t *testing.T) {
+	t.Parallel()
+
+	db := createTestDB(t)
+	spool, err := OpenSpool(filepath.Join(t.TempDir(), "ingest.spool"), 0, 0)
+	require.NoError(t, err)
+	w := NewWriter(db, Options{
+		FlushInterval: 10 * time.Millisecond,
+		MaxBatchSize:  4,
+		QueueSize:     2,
+		Spool:         spool,
+	})
+
+	// Not started yet: the queue fills up and the rest spills to disk.
+	for i := 0; i < 10; i++ {
+		assert.True(t, w.Enqueue(spoolEvent("echo spill")))
+	}
+	stats := w.Stats()
+	assert.Equal(t, int64(0), stats.EventsDropped)
+	assert.Equal(t, int64(8), stats.EventsSpilled)
+	assert.Equal(t, 8, stats.SpoolLength)
+
+	w.Start()
+	defer w.Stop()
+	require.Eventually(t, func() bool {
+		var count int
+		return db.QueryRow("SELECT COUNT(*) FROM command_event").Scan(&count) == nil && count == 10
+	}, 2*time.Second, 10*time.Millisecond)
+
+	stats = w.Stats()
+	assert.Equal(t, int64(8), stats.EventsReplayed)
+	assert.Equal(t, 0, stats.SpoolLength)
+}
+
 func TestWriter_GracefulShutdown(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,88 @@
+// Package clock provides an injectable time source for decay, retention and
+// maintenance. Production code uses System; replay and benchmark tooling use
+// Manual to simulate weeks of usage in seconds, and Offset lets users see
+// how retention and decay settings will treat their real data in the future.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock reports the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// NowMs returns c.Now() in Unix milliseconds, the unit used by the V2 stores.
+// A nil clock falls back to the system clock.
+func NowMs(c Clock) int64 {
+	if c == nil {
+		return time.Now().UnixMilli()
+	}
+	return c.Now().UnixMilli()
+}
+
+// OrSystem returns c, or System if c is nil.
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return System
+	}
+	return c
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// System is the wall clock.
+var System Clock = systemClock{}
+
+// Manual is a clock that only moves when told to. It is safe for concurrent use.
+type Manual struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+// NewManual returns a Manual clock set to start.
+func NewManual(start time.Time) *Manual {
+	return &Manual{now: start}
+}
+
+// Now returns the clock's current time.
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Set moves the clock to t.
+func (m *Manual) Set(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = t
+}
+
+// Advance moves the clock forward by d and returns the new time.
+func (m *Manual) Advance(d time.Duration) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+	return m.now
+}
+
+type offsetClock struct {
+	base   Clock
+	offset time.Duration
+}
+
+func (o offsetClock) Now() time.Time { return o.base.Now().Add(o.offset) }
+
+// Offset returns a clock that runs at base's pace shifted by offset.
+// A positive offset "travels" into the future.
+func Offset(base Clock, offset time.Duration) Clock {
+	if offset == 0 {
+		return OrSystem(base)
+	}
+	return offsetClock{base: OrSystem(base), offset: offset}
+}
@@ -0,0 +1,52 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManual(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewManual(start)
+
+	if got := c.Now(); !got.Equal(start) {
+		t.Fatalf("Now() = %v, want %v", got, start)
+	}
+	if got := c.Advance(14 * 24 * time.Hour); !got.Equal(start.AddDate(0, 0, 14)) {
+		t.Errorf("Advance() = %v, want %v", got, start.AddDate(0, 0, 14))
+	}
+	c.Set(start)
+	if got := NowMs(c); got != start.UnixMilli() {
+		t.Errorf("NowMs() = %d, want %d", got, start.UnixMilli())
+	}
+}
+
+func TestOffset(t *testing.T) {
+	t.Parallel()
+
+	base := NewManual(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := Offset(base, 30*24*time.Hour)
+
+	if got, want := c.Now(), base.Now().AddDate(0, 0, 30); !got.Equal(want) {
+		t.Errorf("Offset().Now() = %v, want %v", got, want)
+	}
+	base.Advance(time.Hour)
+	if got, want := c.Now(), base.Now().AddDate(0, 0, 30); !got.Equal(want) {
+		t.Errorf("Offset clock should follow base: got %v, want %v", got, want)
+	}
+	if Offset(nil, 0) != System {
+		t.Error("Offset(nil, 0) should be the system clock")
+	}
+}
+
+func TestNowMs_NilUsesSystem(t *testing.T) {
+	t.Parallel()
+
+	before := time.Now().UnixMilli()
+	got := NowMs(nil)
+	if got < before || got > time.Now().UnixMilli() {
+		t.Errorf("NowMs(nil) = %d, outside of wall clock range", got)
+	}
+}
@@ -13,6 +13,10 @@ import (
 	"time"
 
 	_ "modernc.org/sqlite" // Pure Go SQLite driver
+
+	"github.com/runger/clai/internal/config"
+	"github.com/runger/clai/internal/dbcrypt"
+	"github.com/runger/clai/internal/dbrepair"
 )
 
 // ErrDatabaseClosed is returned when an operation is attempted on a closed database.
@@ -22,6 +26,9 @@ const (
 	// walCheckpointInterval is how often we checkpoint the WAL file
 	// to prevent unbounded growth during long-running daemon sessions.
 	walCheckpointInterval = 5 * time.Minute
+
+	// DefaultReadConns is the size of the daemon's read-only query pool.
+	DefaultReadConns = 4
 )
 
 // DB is the main database wrapper for the suggestions engine.
@@ -29,6 +36,7 @@ const (
 type DB struct {
 	closeErr  error
 	db        *sql.DB
+	readDB    *sql.DB // Read-only query pool; nil when not opened
 	lock      *LockFile
 	stopCh    chan struct{}
 	stoppedCh chan struct{}
@@ -42,7 +50,9 @@ type DB struct {
 type Options struct {
 	Logger            *slog.Logger
 	Path              string
+	EncryptionKey     []byte // Open encrypted (see privacy.db_encryption); nil = plaintext
 	LockTimeout       time.Duration
+	ReadConns         int // Size of a separate read-only pool for queries (see ReadDB); 0 = none
 	SkipLock          bool
 	ReadOnly          bool
 	UseV1             bool
@@ -50,31 +60,28 @@ type Options struct {
 	RunIntegrityCheck bool
 }
 
-// DefaultDBPath returns the default V2 database path (~/.clai/suggestions_v2.db).
+// DefaultDBPath returns the default V2 database path (~/.clai/suggestions_v2.db),
+// in the data directory of config.DefaultPaths.
+// Under a CLAI_PROFILE the file lives in ~/.clai/profiles/<name>/.
 func DefaultDBPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-	return filepath.Join(home, ".clai", "suggestions_v2.db"), nil
+	return config.DefaultPaths().SuggestionsDBFile(), nil
 }
 
 // DefaultV1DBPath returns the default V1 database path (~/.clai/suggestions.db).
 // This is retained for backward compatibility with existing V1 data.
 func DefaultV1DBPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-	return filepath.Join(home, ".clai", "suggestions.db"), nil
+	return config.DefaultPaths().SuggestionsV1DBFile(), nil
 }
 
 // Open opens the database, acquires the daemon lock, and runs migrations.
 // The caller must call Close() when done.
 //
-// When EnableRecovery is true (V2 only), corruption detected during open or
-// migration triggers automatic recovery: corrupt files are rotated to
-// .corrupt.<timestamp> and a fresh database is initialized.
+// When EnableRecovery is true (V2 only), the database gets a PRAGMA
+// quick_check after opening (a full integrity_check with RunIntegrityCheck),
+// and corruption found then or during open or migration triggers automatic
+// recovery: corrupt files are rotated to .corrupt.<timestamp>, a fresh
+// database is initialized, and readable command events are salvaged from
+// the rotated copy.
 func Open(ctx context.Context, opts Options) (*DB, error) {
 	dbPath, err := resolveDBPath(opts)
 	if err != nil {
@@ -97,7 +104,11 @@ func Open(ctx context.Context, opts Options) (*DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	return buildDB(sqlDB, lock, dbPath, opts.ReadOnly), nil
+	d := buildDB(sqlDB, lock, dbPath, opts.ReadOnly)
+	if opts.ReadConns > 0 && !opts.ReadOnly {
+		d.readDB = openReadPool(ctx, dbPath, opts)
+	}
+	return d, nil
 }
 
 func resolveDBPath(opts Options) (string, error) {
@@ -135,7 +146,7 @@ func openDatabaseWithRecovery(ctx context.Context, dbPath string, opts Options,
 		return nil, err
 	}
 	logger := resolveRecoveryLogger(opts.Logger)
-	sqlDB, recErr := recoverAndReopen(ctx, dbPath, sqlDB, err.Error(), logger)
+	sqlDB, recErr := recoverAndReopen(ctx, dbPath, sqlDB, err.Error(), opts.EncryptionKey, logger)
 	if recErr != nil {
 		releaseLock(lock)
 		return nil, fmt.Errorf("recovery failed: %w", recErr)
@@ -155,15 +166,25 @@ func runIntegrityRecoveryIfNeeded(
 	opts Options,
 	lock *LockFile,
 ) (*sql.DB, error) {
-	if !opts.EnableRecovery || !opts.RunIntegrityCheck || opts.UseV1 || opts.ReadOnly {
+	if !opts.EnableRecovery || opts.UseV1 || opts.ReadOnly {
 		return sqlDB, nil
 	}
-	intErr := RunIntegrityCheck(ctx, sqlDB)
+	var intErr error
+	if opts.RunIntegrityCheck {
+		intErr = RunIntegrityCheck(ctx, sqlDB)
+	} else {
+		intErr = dbrepair.QuickCheck(ctx, sqlDB)
+		if intErr != nil && !isCorruptionError(intErr) {
+			// The check itself failed (e.g. the database is busy); that is
+			// no reason to throw the database away.
+			return sqlDB, nil
+		}
+	}
 	if intErr == nil {
 		return sqlDB, nil
 	}
 	logger := resolveRecoveryLogger(opts.Logger)
-	recovered, err := recoverAndReopen(ctx, dbPath, sqlDB, intErr.Error(), logger)
+	recovered, err := recoverAndReopen(ctx, dbPath, sqlDB, intErr.Error(), opts.EncryptionKey, logger)
 	if err != nil {
 		releaseLock(lock)
 		return nil, fmt.Errorf("integrity check recovery failed: %w", err)
@@ -204,14 +225,14 @@ func buildDB(sqlDB *sql.DB, lock *LockFile, dbPath string, readOnly bool) *DB {
 // openAndInit opens the SQLite database, configures it, pings it, and
 // runs migrations. It is extracted from Open to allow recovery to call it.
 func openAndInit(ctx context.Context, dbPath string, opts Options) (*sql.DB, error) {
-	// Build connection string with pragmas
-	// modernc.org/sqlite uses _pragma=name(value) syntax
-	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)", dbPath)
+	// Build connection parameters with pragmas
+	// Both SQLite drivers use _pragma=name(value) syntax
+	params := "_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
 	if opts.ReadOnly {
-		dsn += "&mode=ro"
+		params += "&mode=ro"
 	}
 
-	db, err := sql.Open("sqlite", dsn)
+	db, err := dbcrypt.Open(dbPath, params, opts.EncryptionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -245,6 +266,31 @@ func openAndInit(ctx context.Context, dbPath string, opts Options) (*sql.DB, err
 	return db, nil
 }
 
+// openReadPool opens a read-only pool of opts.ReadConns connections beside
+// the single writer connection. Under WAL its readers see the last
+// committed state without waiting for the writer's transactions. If it
+// cannot be opened, the failure is logged and queries stay on the writer.
+func openReadPool(ctx context.Context, dbPath string, opts Options) *sql.DB {
+	logger := resolveRecoveryLogger(opts.Logger)
+	params := "_pragma=busy_timeout(5000)&_pragma=query_only(1)&mode=ro"
+
+	db, err := dbcrypt.Open(dbPath, params, opts.EncryptionKey)
+	if err != nil {
+		logger.Warn("read-only query pool unavailable, queries share the writer connection", "error", err)
+		return nil
+	}
+	db.SetMaxOpenConns(opts.ReadConns)
+	db.SetMaxIdleConns(opts.ReadConns)
+	db.SetConnMaxLifetime(0)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		logger.Warn("read-only query pool unavailable, queries share the writer connection", "error", err)
+		return nil
+	}
+	return db
+}
+
 // Close closes the database connection and releases the daemon lock.
 // It is safe to call Close multiple times.
 func (d *DB) Close() error {
@@ -263,6 +309,10 @@ func (d *DB) Close() error {
 		d.stmts = nil
 		d.stmtMu.Unlock()
 
+		// Readers go first so the final checkpoint can truncate the WAL.
+		if d.readDB != nil {
+			d.readDB.Close()
+		}
 		if d.db != nil {
 			// Final checkpoint before closing to merge WAL into main db
 			_, _ = d.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
@@ -285,6 +335,17 @@ func (d *DB) DB() *sql.DB {
 	return d.db
 }
 
+// ReadDB returns the read-only query pool, or the writer connection when
+// the database was opened without one (see Options.ReadConns). Long
+// queries, such as history searches, should use it so they never queue
+// behind the writer's transactions.
+func (d *DB) ReadDB() *sql.DB {
+	if d.readDB != nil {
+		return d.readDB
+	}
+	return d.db
+}
+
 // Path returns the path to the database file.
 func (d *DB) Path() string {
 	return d.dbPath
@@ -1,13 +1,17 @@
 package db
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/runger/clai/internal/dbcrypt"
 )
 
 // =============================================================================
@@ -464,6 +468,55 @@ func TestGetLockHolderPID(t *testing.T) {
 	}
 }
 
+func TestOpen_Encrypted(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "suggestions_v2.db")
+	key := bytes.Repeat([]byte{7}, dbcrypt.KeySize)
+
+	db, err := Open(ctx, Options{Path: dbPath, SkipLock: true, EncryptionKey: key})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, ephemeral)
+		VALUES ('s1', 1000, '/tmp', 'terraform apply', 'terraform apply', 0)
+	`); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+	db.Close()
+
+	data, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("terraform")) {
+		t.Error("database file contains plaintext command")
+	}
+
+	db, err = Open(ctx, Options{Path: dbPath, ReadOnly: true, EncryptionKey: key})
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer db.Close()
+	var n int
+	if err := db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM command_event_fts WHERE command_event_fts MATCH 'terraform'`).Scan(&n); err != nil {
+		t.Fatalf("fts query error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("fts matches = %d, want 1", n)
+	}
+
+	// Without the key the database is reported as encrypted, never
+	// treated as corrupt and replaced.
+	_, err = Open(ctx, Options{Path: dbPath, SkipLock: true, EnableRecovery: true})
+	if !errors.Is(err, dbcrypt.ErrEncrypted) {
+		t.Errorf("Open() without key error = %v, want ErrEncrypted", err)
+	}
+}
+
 func TestOpen_ReadOnly(t *testing.T) {
 	t.Parallel()
 
@@ -553,6 +606,77 @@ func TestDB_ConcurrentReads(t *testing.T) {
 	}
 }
 
+func TestDB_ReadPool(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := Open(ctx, Options{
+		Path:      filepath.Join(t.TempDir(), "test.db"),
+		SkipLock:  true,
+		ReadConns: 2,
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	if db.ReadDB() == db.DB() {
+		t.Fatal("ReadDB() returned the writer connection")
+	}
+
+	// Readers are not blocked by an open write transaction and see the last
+	// committed state.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // test cleanup
+	if _, err = tx.ExecContext(ctx, `INSERT INTO session (id, shell, started_at_ms) VALUES ('s1', 'zsh', 1000)`); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	var n int
+	if err = db.ReadDB().QueryRowContext(readCtx, `SELECT COUNT(*) FROM session`).Scan(&n); err != nil {
+		t.Fatalf("read during write transaction error = %v", err)
+	}
+	if n != 0 {
+		t.Errorf("read saw %d uncommitted sessions", n)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err = db.ReadDB().QueryRowContext(ctx, `SELECT COUNT(*) FROM session`).Scan(&n); err != nil {
+		t.Fatalf("read after commit error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("read saw %d sessions after commit, want 1", n)
+	}
+
+	if _, err = db.ReadDB().ExecContext(ctx, `DELETE FROM session`); err == nil {
+		t.Error("write through the read pool should fail")
+	}
+}
+
+func TestDB_ReadDBWithoutPool(t *testing.T) {
+	t.Parallel()
+
+	db, err := Open(context.Background(), Options{
+		Path:     filepath.Join(t.TempDir(), "test.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	if db.ReadDB() != db.DB() {
+		t.Error("ReadDB() should fall back to the writer connection")
+	}
+}
+
 func TestSchemaValidity_SessionTable(t *testing.T) {
 	t.Parallel()
 
@@ -873,9 +997,11 @@ func TestV2Open_ValidateAll23Tables(t *testing.T) {
 		t.Fatalf("ValidateV2() error = %v", err)
 	}
 
-	// Also verify the exact count of tables (23)
-	if len(V2AllTables) != 23 {
-		t.Errorf("V2AllTables has %d entries, want 23", len(V2AllTables))
+	// Also verify the exact count of tables (23 from the spec + command_event_archive
+	// + storage_migration + the two usage tables + command_output
+	// + command_time_stat + command_context + usage_daily_dir)
+	if len(V2AllTables) != 31 {
+		t.Errorf("V2AllTables has %d entries, want 31", len(V2AllTables))
 	}
 }
 
@@ -1006,6 +1132,100 @@ func TestV2_CommandEventTable(t *testing.T) {
 	}
 }
 
+func TestV5_UsageBucketsExistingHistory(t *testing.T) {
+	t.Parallel()
+
+	db := newTestV2DB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	ts := time.Date(2026, 3, 1, 14, 30, 0, 0, time.Local)
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, template_id, exit_code, ephemeral) VALUES
+			('s1', ?1, '/tmp', 'make', 'make', 'tpl-make', 0, 0),
+			('s1', ?1, '/tmp', 'make', 'make', 'tpl-make', 2, 0),
+			('s1', ?1, '/tmp', 'ls', 'ls', 'tpl-ls', NULL, 0),
+			('s1', ?1, '/tmp', 'secret', 'secret', 'tpl-secret', 0, 1)
+	`, ts.UnixMilli())
+	if err != nil {
+		t.Fatalf("Insert command_event error = %v", err)
+	}
+	// Start over as if upgrading a V4 database that already had this history.
+	if _, err := db.ExecContext(ctx, `DROP TABLE usage_hourly; DROP TABLE usage_daily_template;`); err != nil {
+		t.Fatalf("Drop usage tables error = %v", err)
+	}
+	if _, err := db.ExecContext(ctx, schemaV5Usage); err != nil {
+		t.Fatalf("schemaV5Usage error = %v", err)
+	}
+
+	var day string
+	var hour, commands, successes, failures int
+	err = db.QueryRowContext(ctx, `SELECT day, hour, commands, successes, failures FROM usage_hourly`).
+		Scan(&day, &hour, &commands, &successes, &failures)
+	if err != nil {
+		t.Fatalf("Query usage_hourly error = %v", err)
+	}
+	if day != "2026-03-01" || hour != 14 || commands != 3 || successes != 1 || failures != 1 {
+		t.Errorf("usage_hourly = %s %d: %d commands, %d ok, %d failed; want 2026-03-01 14: 3, 1, 1",
+			day, hour, commands, successes, failures)
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT count FROM usage_daily_template WHERE template_id = 'tpl-make'`).Scan(&count); err != nil || count != 2 {
+		t.Errorf("tpl-make count = %d, %v; want 2", count, err)
+	}
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM usage_daily_template WHERE template_id = 'tpl-secret'`).Scan(&count); err != nil || count != 0 {
+		t.Errorf("ephemeral template rows = %d, %v; want 0", count, err)
+	}
+}
+
+func TestV10_UsageDirBucketsExistingHistory(t *testing.T) {
+	t.Parallel()
+
+	db := newTestV2DB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	ts := time.Date(2026, 3, 1, 14, 30, 0, 0, time.Local)
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, exit_code, ephemeral) VALUES
+			('s1', ?1, '/tmp', 'make', 'make', 0, 0),
+			('s1', ?1, '/tmp', 'make', 'make', NULL, 0),
+			('s1', ?1, '/', 'ls', 'ls', 0, 0),
+			('s1', ?1, '/', 'ls', 'ls', NULL, 0),
+			('s1', ?1, '/tmp', 'secret', 'secret', 0, 1)
+	`, ts.UnixMilli())
+	if err != nil {
+		t.Fatalf("Insert command_event error = %v", err)
+	}
+	// Start over as if upgrading a V9 database that already had this history.
+	if _, err := db.ExecContext(ctx, `DROP TABLE usage_daily_dir`); err != nil {
+		t.Fatalf("Drop usage_daily_dir error = %v", err)
+	}
+	if _, err := db.ExecContext(ctx, schemaV10UsageDir); err != nil {
+		t.Fatalf("schemaV10UsageDir error = %v", err)
+	}
+
+	counts := map[string]int{}
+	rows, err := db.QueryContext(ctx, `SELECT cwd, count FROM usage_daily_dir WHERE day = '2026-03-01'`)
+	if err != nil {
+		t.Fatalf("Query usage_daily_dir error = %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cwd string
+		var count int
+		if err := rows.Scan(&cwd, &count); err != nil {
+			t.Fatalf("Scan usage_daily_dir error = %v", err)
+		}
+		counts[cwd] = count
+	}
+	// Imported history ("/" without an exit code) and ephemeral events are left out.
+	if len(counts) != 2 || counts["/tmp"] != 2 || counts["/"] != 1 {
+		t.Errorf("usage_daily_dir = %v, want /tmp: 2, /: 1", counts)
+	}
+}
+
 func TestV2_CommandTemplateTable(t *testing.T) {
 	t.Parallel()
 
@@ -1687,9 +1907,10 @@ func TestV2_SchemaCountIs23(t *testing.T) {
 		}
 	}
 
-	// Verify V2AllTables has exactly 23 entries
-	if len(V2AllTables) != 23 {
-		t.Errorf("V2AllTables has %d entries, want 23", len(V2AllTables))
+	// Verify V2AllTables has exactly 23 spec entries plus command_event_archive,
+	// storage_migration, the three usage tables, command_output and command_context
+	if len(V2AllTables) != 31 {
+		t.Errorf("V2AllTables has %d entries, want 31", len(V2AllTables))
 	}
 }
 
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/runger/clai/internal/config"
+)
+
+// legacyDBFiles are the suggestion databases that lived in ~/.clai before
+// they followed CLAI_HOME and the data directory.
+var legacyDBFiles = []string{"suggestions_v2.db", "suggestions.db"}
+
+// LegacyDir returns the directory the suggestion databases lived in before
+// they followed CLAI_HOME and the data directory (~/.clai, or
+// ~/.clai/profiles/<name> under a CLAI_PROFILE).
+func LegacyDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return config.ProfileDir(filepath.Join(home, ".clai")), nil
+}
+
+// MigrateLegacyDBs moves the suggestion databases, with their WAL and
+// shared-memory files, from legacyDir into dataDir. A database that
+// already exists in dataDir is kept and its legacy copy left alone. The
+// move holds the daemon lock of legacyDir, so a daemon still using the
+// legacy files makes it fail with ErrLockTimeout. It returns the moved
+// databases' new paths.
+func MigrateLegacyDBs(legacyDir, dataDir string) ([]string, error) {
+	if filepath.Clean(legacyDir) == filepath.Clean(dataDir) {
+		return nil, nil
+	}
+	var pending []string
+	for _, name := range legacyDBFiles {
+		if fileExists(filepath.Join(legacyDir, name)) && !fileExists(filepath.Join(dataDir, name)) {
+			pending = append(pending, name)
+		}
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	lock, err := AcquireLock(legacyDir, LockOptions{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = lock.Release() }()
+
+	if err := os.MkdirAll(dataDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	var moved []string
+	for _, name := range pending {
+		// The WAL and shared-memory files go first: a database that moved
+		// without its WAL would lose the writes still in it.
+		for _, suffix := range []string{"-wal", "-shm", ""} {
+			src := filepath.Join(legacyDir, name+suffix)
+			err := os.Rename(src, filepath.Join(dataDir, name+suffix))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return moved, fmt.Errorf("failed to move %s: %w", src, err)
+			}
+		}
+		moved = append(moved, filepath.Join(dataDir, name))
+	}
+	return moved, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateLegacyDBs(t *testing.T) {
+	t.Parallel()
+
+	legacyDir := t.TempDir()
+	dataDir := filepath.Join(t.TempDir(), "data")
+	for _, name := range []string{"suggestions_v2.db", "suggestions_v2.db-wal", "suggestions.db"} {
+		if err := os.WriteFile(filepath.Join(legacyDir, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The V1 database already exists at the new location and must win.
+	if err := os.MkdirAll(dataDir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "suggestions.db"), []byte("current"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := MigrateLegacyDBs(legacyDir, dataDir)
+	if err != nil {
+		t.Fatalf("MigrateLegacyDBs() error = %v", err)
+	}
+	if len(moved) != 1 || moved[0] != filepath.Join(dataDir, "suggestions_v2.db") {
+		t.Errorf("moved = %v, want only the V2 database", moved)
+	}
+	for _, name := range []string{"suggestions_v2.db", "suggestions_v2.db-wal"} {
+		if data, err := os.ReadFile(filepath.Join(dataDir, name)); err != nil || string(data) != name {
+			t.Errorf("%s in data dir = %q, %v; want the legacy file", name, data, err)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(dataDir, "suggestions.db")); string(data) != "current" {
+		t.Errorf("suggestions.db = %q, want the existing file kept", data)
+	}
+	if _, err := os.Stat(filepath.Join(legacyDir, "suggestions.db")); err != nil {
+		t.Errorf("legacy suggestions.db should be left alone: %v", err)
+	}
+
+	moved, err = MigrateLegacyDBs(legacyDir, dataDir)
+	if err != nil || len(moved) != 0 {
+		t.Errorf("second MigrateLegacyDBs() = %v, %v; want nothing moved", moved, err)
+	}
+}
+
+func TestMigrateLegacyDBs_LockedByDaemon(t *testing.T) {
+	t.Parallel()
+
+	legacyDir := t.TempDir()
+	dataDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(legacyDir, "suggestions_v2.db"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := AcquireLock(legacyDir, DefaultLockOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+
+	if _, err := MigrateLegacyDBs(legacyDir, dataDir); err == nil {
+		t.Error("MigrateLegacyDBs() succeeded while another daemon holds the lock")
+	}
+	if _, err := os.Stat(filepath.Join(legacyDir, "suggestions_v2.db")); err != nil {
+		t.Errorf("locked database was moved: %v", err)
+	}
+}
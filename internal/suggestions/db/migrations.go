@@ -32,6 +32,14 @@ func V1Migrations() []Migration {
 func V2Migrations() []Migration {
 	return []Migration{
 		{Version: 2, SQL: schemaV2},
+		{Version: 3, SQL: schemaV3Archive},
+		{Version: 4, SQL: schemaV4StorageMigration},
+		{Version: 5, SQL: schemaV5Usage},
+		{Version: 6, SQL: schemaV6Deleted},
+		{Version: 7, SQL: schemaV7Output},
+		{Version: 8, SQL: schemaV8TimeStat},
+		{Version: 9, SQL: schemaV9Context},
+		{Version: 10, SQL: schemaV10UsageDir},
 	}
 }
 
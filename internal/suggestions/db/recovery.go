@@ -12,6 +12,9 @@ import (
 	"strings"
 	"syscall"
 	"time"
+
+	"github.com/runger/clai/internal/dbcrypt"
+	"github.com/runger/clai/internal/dbrepair"
 )
 
 // CorruptionEvent records details about a database corruption incident.
@@ -22,6 +25,7 @@ type CorruptionEvent struct {
 	Reason            string    `json:"reason"`
 	OriginalSizeBytes int64     `json:"original_size_bytes"`
 	RecoverySuccess   bool      `json:"recovery_success"`
+	SalvagedEvents    int       `json:"salvaged_events"`
 }
 
 // CorruptionHistory holds the history of corruption events.
@@ -35,37 +39,7 @@ const corruptionHistoryFilename = "corruption_history.json"
 // isCorruptionError checks if an error indicates SQLite database corruption.
 // It detects SQLITE_CORRUPT (11), SQLITE_NOTADB (26), and related error messages.
 func isCorruptionError(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	msg := err.Error()
-
-	// Check for known corruption error messages
-	corruptionMessages := []string{
-		"database disk image is malformed",
-		"file is not a database",
-		"file is encrypted or is not a database",
-		"disk I/O error",
-	}
-
-	for _, cm := range corruptionMessages {
-		if strings.Contains(strings.ToLower(msg), strings.ToLower(cm)) {
-			return true
-		}
-	}
-
-	// Check for SQLite error codes embedded in the error message.
-	// modernc.org/sqlite wraps errors with the code number.
-	// SQLITE_CORRUPT = 11, SQLITE_NOTADB = 26
-	if strings.Contains(msg, "SQLITE_CORRUPT") || strings.Contains(msg, "sqlite3: corrupt") {
-		return true
-	}
-	if strings.Contains(msg, "SQLITE_NOTADB") || strings.Contains(msg, "not a database") {
-		return true
-	}
-
-	return false
+	return dbrepair.IsCorruption(err)
 }
 
 // isPermissionError checks if an error is a permission-related error.
@@ -112,31 +86,7 @@ func isDiskFullError(err error) bool {
 // rotateCorruptDB renames all database files (main, WAL, SHM) with a corruption
 // timestamp suffix. Returns the backup path of the main DB file.
 func rotateCorruptDB(dbPath string) (string, error) {
-	ts := time.Now().Unix()
-	suffix := fmt.Sprintf(".corrupt.%d", ts)
-
-	// Files to rotate: main db, WAL, SHM
-	files := []string{
-		dbPath,
-		dbPath + "-wal",
-		dbPath + "-shm",
-	}
-
-	var mainBackup string
-	for _, f := range files {
-		if _, err := os.Stat(f); os.IsNotExist(err) {
-			continue // File doesn't exist, skip
-		}
-		backup := f + suffix
-		if err := os.Rename(f, backup); err != nil {
-			return "", fmt.Errorf("failed to rotate %s: %w", f, err)
-		}
-		if f == dbPath {
-			mainBackup = backup
-		}
-	}
-
-	return mainBackup, nil
+	return dbrepair.MoveAside(dbPath)
 }
 
 // RunIntegrityCheck runs PRAGMA integrity_check on the database.
@@ -178,9 +128,10 @@ type RecoverOptions struct {
 // 1. Closing the corrupted connection (if any)
 // 2. Rotating all DB files to .corrupt.<timestamp>
 // 3. Opening a fresh database with the full schema
+// 4. Copying the readable command events from the rotated file
 //
 // It returns a new *sql.DB or an error if recovery fails.
-func recoverAndReopen(ctx context.Context, dbPath string, corruptDB *sql.DB, reason string, logger *slog.Logger) (*sql.DB, error) {
+func recoverAndReopen(ctx context.Context, dbPath string, corruptDB *sql.DB, reason string, key []byte, logger *slog.Logger) (*sql.DB, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
@@ -214,8 +165,7 @@ func recoverAndReopen(ctx context.Context, dbPath string, corruptDB *sql.DB, rea
 	)
 
 	// Step 3: Open a fresh database
-	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)", dbPath)
-	newDB, err := sql.Open("sqlite", dsn)
+	newDB, err := dbcrypt.Open(dbPath, "_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)", key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open fresh database after recovery: %w", err)
 	}
@@ -235,7 +185,15 @@ func recoverAndReopen(ctx context.Context, dbPath string, corruptDB *sql.DB, rea
 		return nil, fmt.Errorf("failed to run migrations on fresh database: %w", err)
 	}
 
-	// Step 5: Record the corruption event
+	// Step 5: Salvage command history from the damaged copy
+	salvaged := salvageEvents(ctx, newDB, backupPath, key, logger)
+	logger.Warn("database recovered from corruption",
+		"path", dbPath,
+		"backup", backupPath,
+		"salvaged_events", salvaged,
+	)
+
+	// Step 6: Record the corruption event
 	event := &CorruptionEvent{
 		Timestamp:         time.Now(),
 		OriginalPath:      dbPath,
@@ -243,6 +201,7 @@ func recoverAndReopen(ctx context.Context, dbPath string, corruptDB *sql.DB, rea
 		CorruptBackup:     backupPath,
 		Reason:            reason,
 		RecoverySuccess:   true,
+		SalvagedEvents:    salvaged,
 	}
 	if err := recordCorruptionEvent(dbPath, event); err != nil {
 		// Non-fatal: log but continue
@@ -254,6 +213,52 @@ func recoverAndReopen(ctx context.Context, dbPath string, corruptDB *sql.DB, rea
 	return newDB, nil
 }
 
+// salvageTables are copied from a corrupt database into its replacement,
+// with the condition rows must meet. Templates come first so salvaged
+// events keep theirs; archived events have no command text left to save.
+var salvageTables = []struct {
+	name  string
+	where string
+}{
+	{name: "command_template"},
+	{name: "command_event", where: "archive_chunk_id IS NULL"},
+}
+
+// salvageEvents copies the command events (and their templates) that can
+// still be read from the corrupt database at backupPath into db, and
+// returns the number of events saved. Scores and transitions are not
+// copied; they rebuild as new commands arrive.
+func salvageEvents(ctx context.Context, db *sql.DB, backupPath string, key []byte, logger *slog.Logger) int {
+	if backupPath == "" {
+		return 0
+	}
+	src, err := dbcrypt.Open(backupPath, "mode=ro", key)
+	if err != nil {
+		logger.Warn("cannot open corrupt database for salvage", "backup", backupPath, "error", err)
+		return 0
+	}
+	defer src.Close()
+	src.SetMaxOpenConns(1)
+
+	events := 0
+	for _, t := range salvageTables {
+		res, err := dbrepair.CopyRows(ctx, src, db, t.name, t.where)
+		if err != nil {
+			logger.Warn("salvage stopped early", "table", t.name, "error", err)
+		}
+		logger.Info("salvaged rows from corrupt database",
+			"table", t.name,
+			"copied", res.Copied,
+			"unreadable_ranges", res.Unreadable,
+			"rejected", res.Rejected,
+		)
+		if t.name == "command_event" {
+			events = res.Copied
+		}
+	}
+	return events
+}
+
 // recordCorruptionEvent appends a corruption event to the history file.
 func recordCorruptionEvent(dbPath string, event *CorruptionEvent) error {
 	historyPath := corruptionHistoryPath(dbPath)
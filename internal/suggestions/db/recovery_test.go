@@ -1,9 +1,12 @@
 package db
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -415,7 +418,7 @@ func TestRecoverAndReopen_SuccessfulRecovery(t *testing.T) {
 
 	// Now simulate corruption recovery
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
-	recoveredDB, err := recoverAndReopen(context.Background(), dbPath, nil, "test corruption", logger)
+	recoveredDB, err := recoverAndReopen(context.Background(), dbPath, nil, "test corruption", nil, logger)
 	if err != nil {
 		t.Fatalf("recoverAndReopen() error = %v", err)
 	}
@@ -882,3 +885,70 @@ type mockError struct {
 func (e *mockError) Error() string {
 	return e.msg
 }
+
+func TestOpen_WithRecovery_QuickCheckSalvagesEvents(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "suggestions_v2.db")
+
+	db, err := Open(ctx, Options{Path: dbPath, SkipLock: true})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for i := 0; i < 30; i++ {
+		if _, err := db.ExecContext(ctx, `
+			INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm)
+			VALUES ('sess-1', ?, '/tmp', ?, ?)
+		`, 1000+i, fmt.Sprintf("echo %d", i), "echo <arg>"); err != nil {
+			t.Fatalf("insert event: %v", err)
+		}
+	}
+	db.Close()
+
+	// Overwrite the root page of an index: quick_check reports it, while
+	// command_event itself stays readable.
+	raw, err := sql.Open("sqlite", "file:"+dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root, pageSize int64
+	if err := raw.QueryRow(`SELECT rootpage FROM sqlite_master WHERE name = 'idx_event_ts'`).Scan(&root); err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		t.Fatal(err)
+	}
+	raw.Close()
+	f, err := os.OpenFile(dbPath, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt(bytes.Repeat([]byte{0xAB}, int(pageSize)), (root-1)*pageSize); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db, err = Open(ctx, Options{Path: dbPath, SkipLock: true, EnableRecovery: true, Logger: logger})
+	if err != nil {
+		t.Fatalf("Open() with recovery error = %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.DB().QueryRowContext(ctx, "SELECT COUNT(*) FROM command_event").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 30 {
+		t.Errorf("salvaged %d events, want 30", count)
+	}
+
+	history, err := LoadCorruptionHistory(corruptionHistoryPath(dbPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Events) != 1 || history.Events[0].SalvagedEvents != 30 {
+		t.Errorf("corruption history = %+v, want one event with 30 salvaged", history.Events)
+	}
+}
@@ -7,13 +7,21 @@ package db
 // Version history:
 //   - V1: Original schema (suggestions.db) - 7 tables
 //   - V2: Extended schema (suggestions_v2.db) - 23 tables, separate DB file
+//   - V3: command_event archival (command_event_archive, archive_chunk_id)
+//   - V4: storage_migration (one-off data migrations, e.g. from the V1 store)
+//   - V5: usage_hourly and usage_daily_template (time-bucketed usage analytics)
+//   - V6: command_event.deleted (soft-deleted commands)
+//   - V7: command_output (opt-in stdout/stderr tails of commands)
+//   - V8: command_time_stat (hour-of-day and day-of-week usage per template)
+//   - V9: command_context (directory change and env context of commands)
+//   - V10: usage_daily_dir (commands per directory per day)
 const (
 	// V1SchemaVersion is the schema version for V1 database files (suggestions.db).
 	V1SchemaVersion = 1
 
-	// SchemaVersion is the current supported schema version (V2).
+	// SchemaVersion is the current supported schema version of suggestions_v2.db.
 	// The daemon will refuse to run if the DB schema version exceeds this.
-	SchemaVersion = 2
+	SchemaVersion = 10
 )
 
 // schemaV1 creates the initial V1 schema for the suggestions engine.
@@ -438,8 +446,170 @@ CREATE TABLE IF NOT EXISTS schema_migrations (
 );
 `
 
+// schemaV3Archive adds archival of old command_event payloads.
+//
+// Archived events keep every column except cmd_raw, which moves into a
+// zlib-compressed chunk in command_event_archive; archive_chunk_id points at
+// that chunk. Archived events are removed from command_event_fts, so the
+// delete trigger must skip them.
+const schemaV3Archive = `
+CREATE TABLE IF NOT EXISTS command_event_archive (
+  id              INTEGER PRIMARY KEY AUTOINCREMENT,
+  first_event_id  INTEGER NOT NULL,
+  last_event_id   INTEGER NOT NULL,
+  event_count     INTEGER NOT NULL,
+  created_ms      INTEGER NOT NULL,
+  payload         BLOB NOT NULL
+);
+
+ALTER TABLE command_event ADD COLUMN archive_chunk_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_event_archive_chunk ON command_event(archive_chunk_id);
+
+DROP TRIGGER IF EXISTS command_event_ad;
+CREATE TRIGGER command_event_ad AFTER DELETE ON command_event
+WHEN OLD.archive_chunk_id IS NULL
+BEGIN
+  INSERT INTO command_event_fts(command_event_fts, rowid, cmd_raw, cmd_norm, repo_key, session_id)
+  VALUES ('delete', OLD.id, OLD.cmd_raw, OLD.cmd_norm, OLD.repo_key, OLD.session_id);
+END;
+`
+
+// schemaV4StorageMigration records one-off data migrations into this
+// database, such as the backfill from the V1 history store. A row is written
+// in the same transaction as the migrated data, so its presence means the
+// migration completed.
+const schemaV4StorageMigration = `
+CREATE TABLE IF NOT EXISTS storage_migration (
+  name          TEXT PRIMARY KEY,
+  completed_ms  INTEGER NOT NULL,
+  events        INTEGER NOT NULL
+);
+`
+
+// schemaV5Usage adds the time-bucketed usage tables behind 'clai stats'. The
+// write path keeps them current, so reports never scan command_event, and
+// they outlive the events retention deletes. Days and hours are in local
+// time. Commands without a recorded exit code (imported shell history) count
+// towards commands but neither successes nor failures. Existing history is
+// bucketed once here.
+const schemaV5Usage = `
+CREATE TABLE IF NOT EXISTS usage_hourly (
+  day             TEXT NOT NULL,
+  hour            INTEGER NOT NULL,
+  commands        INTEGER NOT NULL,
+  successes       INTEGER NOT NULL,
+  failures        INTEGER NOT NULL,
+  PRIMARY KEY(day, hour)
+);
+
+CREATE TABLE IF NOT EXISTS usage_daily_template (
+  day             TEXT NOT NULL,
+  template_id     TEXT NOT NULL,
+  count           INTEGER NOT NULL,
+  PRIMARY KEY(day, template_id)
+);
+
+INSERT OR IGNORE INTO usage_hourly (day, hour, commands, successes, failures)
+SELECT strftime('%Y-%m-%d', ts_ms / 1000, 'unixepoch', 'localtime'),
+       CAST(strftime('%H', ts_ms / 1000, 'unixepoch', 'localtime') AS INTEGER),
+       COUNT(*),
+       SUM(CASE WHEN exit_code = 0 THEN 1 ELSE 0 END),
+       SUM(CASE WHEN exit_code != 0 THEN 1 ELSE 0 END)
+FROM command_event
+WHERE ephemeral = 0
+GROUP BY 1, 2;
+
+INSERT OR IGNORE INTO usage_daily_template (day, template_id, count)
+SELECT strftime('%Y-%m-%d', ts_ms / 1000, 'unixepoch', 'localtime'), template_id, COUNT(*)
+FROM command_event
+WHERE ephemeral = 0 AND template_id IS NOT NULL AND template_id != ''
+GROUP BY 1, 2;
+`
+
+// schemaV6Deleted adds soft deletion of command events. Deleted events stay
+// in every aggregate but are left out of history, and suggestions for a
+// cmd_norm none of whose events remain are suppressed. The partial index
+// keeps that lookup cheap while few events are deleted.
+const schemaV6Deleted = `
+ALTER TABLE command_event ADD COLUMN deleted INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_event_deleted ON command_event(cmd_norm) WHERE deleted = 1;
+`
+
+// schemaV7Output adds the output tails clients may send with a command's
+// end, stored only when suggestions.capture_output is on. Each tail is
+// zlib-compressed; truncated records that the client or the daemon cut it
+// to suggestions.output_max_bytes. Rows go with their event.
+const schemaV7Output = `
+CREATE TABLE IF NOT EXISTS command_output (
+  event_id      INTEGER PRIMARY KEY REFERENCES command_event(id) ON DELETE CASCADE,
+  stdout        BLOB,
+  stderr        BLOB,
+  truncated     INTEGER NOT NULL DEFAULT 0
+);
+`
+
+// schemaV8TimeStat adds the time-of-day and day-of-week buckets behind the
+// ranker's time features. The write path counts each use of a template in
+// its scope under the local weekday (0 = Sunday) and hour it ran at.
+// Existing history is bucketed once here for the global scope.
+const schemaV8TimeStat = `
+CREATE TABLE IF NOT EXISTS command_time_stat (
+  scope           TEXT NOT NULL,
+  template_id     TEXT NOT NULL,
+  weekday         INTEGER NOT NULL,
+  hour            INTEGER NOT NULL,
+  count           INTEGER NOT NULL,
+  last_seen_ms    INTEGER NOT NULL,
+  PRIMARY KEY(scope, template_id, weekday, hour)
+);
+
+INSERT OR IGNORE INTO command_time_stat (scope, template_id, weekday, hour, count, last_seen_ms)
+SELECT 'global', template_id,
+       CAST(strftime('%w', ts_ms / 1000, 'unixepoch', 'localtime') AS INTEGER),
+       CAST(strftime('%H', ts_ms / 1000, 'unixepoch', 'localtime') AS INTEGER),
+       COUNT(*), MAX(ts_ms)
+FROM command_event
+WHERE template_id IS NOT NULL AND template_id != ''
+GROUP BY 2, 3, 4;
+`
+
+// schemaV9Context adds the environment context clients may send with a
+// command: the directory it changed from, and allowlisted env vars such as
+// the active virtualenv as a JSON object. Only commands with context get a
+// row; rows go with their event.
+const schemaV9Context = `
+CREATE TABLE IF NOT EXISTS command_context (
+  event_id      INTEGER PRIMARY KEY REFERENCES command_event(id) ON DELETE CASCADE,
+  prev_cwd      TEXT,
+  env           TEXT
+);
+`
+
+// schemaV10UsageDir adds the per-directory usage behind the busiest
+// directories of 'clai stats', kept by the write path like the other usage
+// tables. Imported shell history has no directory: the seeder stores it
+// under "/" without an exit code, so existing history is bucketed here
+// without those events.
+const schemaV10UsageDir = `
+CREATE TABLE IF NOT EXISTS usage_daily_dir (
+  day             TEXT NOT NULL,
+  cwd             TEXT NOT NULL,
+  count           INTEGER NOT NULL,
+  PRIMARY KEY(day, cwd)
+);
+
+INSERT OR IGNORE INTO usage_daily_dir (day, cwd, count)
+SELECT strftime('%Y-%m-%d', ts_ms / 1000, 'unixepoch', 'localtime'), cwd, COUNT(*)
+FROM command_event
+WHERE ephemeral = 0 AND cwd != '' AND NOT (cwd = '/' AND exit_code IS NULL)
+GROUP BY 1, 2;
+`
+
 // V2AllTables lists all tables in the V2 schema for validation purposes.
-// This includes all 23 tables from spec Section 4.1.
+// This includes all 23 tables from spec Section 4.1 plus the V3 archive,
+// V4 storage_migration, V5 usage, V7 command_output, V8 command_time_stat,
+// V9 command_context and V10 usage_daily_dir tables.
 var V2AllTables = []string{
 	"session",
 	"command_event",
@@ -464,6 +634,14 @@ var V2AllTables = []string{
 	"rank_weight_profile",
 	"command_event_fts",
 	"schema_migrations",
+	"command_event_archive",
+	"storage_migration",
+	"usage_hourly",
+	"usage_daily_template",
+	"command_output",
+	"command_time_stat",
+	"command_context",
+	"usage_daily_dir",
 }
 
 // V2AllIndexes lists all indexes in the V2 schema for validation purposes.
@@ -474,6 +652,8 @@ var V2AllIndexes = []string{
 	"idx_event_session_ts",
 	"idx_event_norm_repo",
 	"idx_event_template",
+	"idx_event_archive_chunk",
+	"idx_event_deleted",
 	"idx_transition_stat_prev",
 	"idx_command_stat_scope",
 	"idx_slot_stat_lookup",
@@ -482,6 +662,40 @@ var V2AllIndexes = []string{
 	"idx_feedback_session",
 }
 
+// TemplateColumn is a table column holding command_template IDs.
+type TemplateColumn struct {
+	Table  string
+	Column string
+}
+
+// V2TemplateColumns lists every column outside command_template that
+// references a template ID. Code that renames or removes templates must
+// cover all of them.
+var V2TemplateColumns = []TemplateColumn{
+	{"command_event", "template_id"},
+	{"command_stat", "template_id"},
+	{"command_time_stat", "template_id"},
+	{"transition_stat", "prev_template_id"},
+	{"transition_stat", "next_template_id"},
+	{"slot_stat", "template_id"},
+	{"slot_correlation", "template_id"},
+	{"project_type_stat", "template_id"},
+	{"project_type_transition", "prev_template_id"},
+	{"project_type_transition", "next_template_id"},
+	{"pipeline_event", "template_id"},
+	{"pipeline_transition", "prev_template_id"},
+	{"pipeline_transition", "next_template_id"},
+	{"failure_recovery", "failed_template_id"},
+	{"failure_recovery", "recovery_template_id"},
+	{"workflow_step", "template_id"},
+	{"dismissal_pattern", "context_template_id"},
+	{"dismissal_pattern", "dismissed_template_id"},
+	{"usage_daily_template", "template_id"},
+}
+
+// V2TemplateChainTables store template IDs inside a template_chain string.
+var V2TemplateChainTables = []string{"pipeline_pattern", "workflow_pattern"}
+
 // V2AllTriggers lists all triggers in the V2 schema for validation purposes.
 var V2AllTriggers = []string{
 	"command_event_ai",
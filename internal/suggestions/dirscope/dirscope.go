@@ -6,6 +6,7 @@ package dirscope
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"path/filepath"
 	"strings"
 )
@@ -16,6 +17,59 @@ const ScopePrefix = "dir:"
 // DefaultMaxDepth is the default maximum directory depth for scope keys.
 const DefaultMaxDepth = 3
 
+// DefaultAffinityWeights weigh the directory-scoped signals of cwd, its
+// parent and its grandparent, so the exact directory counts most.
+var DefaultAffinityWeights = []float64{1.0, 0.5, 0.25}
+
+// Scope is the directory scope of cwd or one of its ancestors, with the
+// weight the ranker gives its signals.
+type Scope struct {
+	Key    string
+	Depth  int // 0 for cwd, 1 for its parent, ...
+	Weight float64
+}
+
+// PathKey returns the scope key the write path keeps the directory-scoped
+// stats of commands run in dir under: dir:<sha256(dir) prefix>.
+func PathKey(dir string) string {
+	h := sha256.Sum256([]byte(dir))
+	return fmt.Sprintf("%s%x", ScopePrefix, h[:8])
+}
+
+// AncestorScopes returns the scopes of cwd and its ancestors, nearest
+// first, for at most maxDepth directories and as many as there are
+// weights. weights[i] weighs the directory i levels above cwd; directories
+// weighted 0 are left out. The walk stops at repoRoot when cwd is inside it,
+// else at the filesystem root. Returns nil if cwd is empty.
+func AncestorScopes(cwd, repoRoot string, maxDepth int, weights []float64) []Scope {
+	if cwd == "" {
+		return nil
+	}
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	dir := filepath.Clean(cwd)
+	if repoRoot != "" {
+		repoRoot = filepath.Clean(repoRoot)
+		if rel, err := filepath.Rel(repoRoot, dir); err != nil || strings.HasPrefix(rel, "..") {
+			repoRoot = ""
+		}
+	}
+
+	var scopes []Scope
+	for depth := 0; depth < min(maxDepth, len(weights)); depth++ {
+		if weights[depth] > 0 {
+			scopes = append(scopes, Scope{Key: PathKey(dir), Depth: depth, Weight: weights[depth]})
+		}
+		parent := filepath.Dir(dir)
+		if dir == repoRoot || parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return scopes
+}
+
 // ComputeScopeKey computes a directory scope key from cwd relative to the git
 // repo root. The key format is: dir:sha256(repoName/truncated_path).
 // Returns "" if the cwd is outside the repo root or inputs are empty.
@@ -175,3 +175,49 @@ func TestIsDirScope(t *testing.T) {
 		})
 	}
 }
+
+func TestPathKey(t *testing.T) {
+	t.Parallel()
+
+	key := PathKey("/home/user/repo")
+	assert.True(t, IsDirScope(key))
+	assert.Len(t, key, len(ScopePrefix)+16)
+	assert.Equal(t, key, PathKey("/home/user/repo"))
+	assert.NotEqual(t, key, PathKey("/home/user/repo/api"))
+}
+
+func TestAncestorScopes(t *testing.T) {
+	t.Parallel()
+
+	weights := []float64{1.0, 0.5, 0.25}
+	keys := func(scopes []Scope) []string {
+		var out []string
+		for _, s := range scopes {
+			out = append(out, s.Key)
+		}
+		return out
+	}
+
+	scopes := AncestorScopes("/repo/services/api/handlers/", "/repo", 3, weights)
+	assert.Equal(t, []Scope{
+		{Key: PathKey("/repo/services/api/handlers"), Depth: 0, Weight: 1.0},
+		{Key: PathKey("/repo/services/api"), Depth: 1, Weight: 0.5},
+		{Key: PathKey("/repo/services"), Depth: 2, Weight: 0.25},
+	}, scopes)
+
+	// The walk stops at the repository root.
+	assert.Equal(t, []string{PathKey("/repo/web"), PathKey("/repo")},
+		keys(AncestorScopes("/repo/web", "/repo", 3, weights)))
+
+	// Outside the repository it continues up to the filesystem root.
+	assert.Equal(t, []string{PathKey("/tmp/x"), PathKey("/tmp"), PathKey("/")},
+		keys(AncestorScopes("/tmp/x", "/repo", 5, weights)))
+
+	// maxDepth and the number of weights both limit the walk; zero weights
+	// leave a directory out.
+	assert.Len(t, AncestorScopes("/a/b/c/d", "", 2, weights), 2)
+	assert.Equal(t, []string{PathKey("/a/b/c/d"), PathKey("/a/b")},
+		keys(AncestorScopes("/a/b/c/d", "", 3, []float64{1, 0, 0.5})))
+
+	assert.Nil(t, AncestorScopes("", "/repo", 3, weights))
+}
@@ -46,6 +46,11 @@ type DiscoverConfig struct {
 	ProjectTypes []string
 	Limit        int
 	CooldownMs   int64
+
+	// ProjectTypePriors adds common commands per project type, such as
+	// those of user-defined project types. They rank before the built-in
+	// priors of the same type.
+	ProjectTypePriors map[string][]string
 }
 
 // Engine is the V2 discovery engine. It is safe for concurrent use.
@@ -76,7 +81,7 @@ func (e *Engine) Discover(_ context.Context, config DiscoverConfig) []Candidate
 
 	now := e.nowFn()
 	candidates := appendPlaybookCandidates(nil, config.PlaybookPath)
-	candidates = appendProjectTypeCandidates(candidates, config.ProjectTypes)
+	candidates = appendProjectTypeCandidates(candidates, config.ProjectTypes, config.ProjectTypePriors)
 	candidates = appendToolCommonCandidates(candidates)
 
 	// Deduplicate by command text, keeping highest priority / first seen source
@@ -129,12 +134,9 @@ func appendPlaybookCandidates(candidates []Candidate, playbookPath string) []Can
 	return candidates
 }
 
-func appendProjectTypeCandidates(candidates []Candidate, projectTypes []string) []Candidate {
+func appendProjectTypeCandidates(candidates []Candidate, projectTypes []string, extra map[string][]string) []Candidate {
 	for _, pt := range projectTypes {
-		priors, ok := projectTypePriors[pt]
-		if !ok {
-			continue
-		}
+		priors := append(append([]string(nil), extra[pt]...), projectTypePriors[pt]...)
 		for i, cmd := range priors {
 			candidates = append(candidates, Candidate{
 				Command:  cmd,
@@ -79,6 +79,30 @@ func TestDiscoverWithProjectTypes(t *testing.T) {
 	assert.True(t, cmds["go build ./..."])
 }
 
+func TestDiscoverWithProjectTypePriors(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	candidates := engine.Discover(context.Background(), DiscoverConfig{
+		ProjectTypes: []string{"bazel", "go"},
+		ProjectTypePriors: map[string][]string{
+			"bazel": {"bazel build //...", "bazel test //..."},
+			"go":    {"go test -race ./..."},
+		},
+		Limit: 20,
+	})
+
+	index := make(map[string]int)
+	for i, c := range candidates {
+		index[c.Command] = i
+	}
+	require.Contains(t, index, "bazel build //...")
+	require.Contains(t, index, "bazel test //...")
+	require.Contains(t, index, "go test -race ./...")
+	assert.Less(t, index["go test -race ./..."], index["go test ./..."]) // before the built-in go priors
+	assert.Equal(t, []string{"bazel"}, candidates[index["bazel build //..."]].Tags)
+}
+
 func TestDiscoverWithToolCommonFallback(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,62 @@
+package discovery
+
+import (
+	"context"
+)
+
+// Files cargo discovery reads besides Cargo.toml.
+const (
+	cargoConfigPath       = ".cargo/config.toml"
+	cargoLegacyConfigPath = ".cargo/config"
+	cargoMainPath         = "src/main.rs"
+)
+
+// cargoSubcommands are the cargo subcommands suggested for every crate.
+var cargoSubcommands = map[string]string{
+	"build":  "Compile the package",
+	"check":  "Check the package for errors",
+	"test":   "Run the tests",
+	"clippy": "Run the Clippy lints",
+	"fmt":    "Format the code",
+	"doc":    "Build the documentation",
+}
+
+// parseCargo discovers tasks for a Rust crate: the common cargo
+// subcommands, `cargo run` for crates with a binary, and the aliases of the
+// project's .cargo/config.toml.
+func (s *Service) parseCargo(ctx context.Context, files *sourceFiles) ([]Task, error) {
+	tasks := make(map[string]Task, len(cargoSubcommands))
+	add := func(name, desc string) {
+		tasks[name] = Task{
+			RepoKey:     files.repoRoot,
+			Kind:        KindCargo,
+			Name:        name,
+			Command:     "cargo " + name,
+			Description: desc,
+		}
+	}
+
+	for name, desc := range cargoSubcommands {
+		add(name, desc)
+	}
+	if files.markers[cargoMainPath] || tomlHasTable(files.data, "bin") {
+		add("run", "Run the binary")
+	}
+
+	config, ok := files.related[cargoConfigPath]
+	if !ok {
+		config = files.related[cargoLegacyConfigPath]
+	}
+	for _, alias := range tomlTable(config, "alias") {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		add(alias.Key, "cargo "+alias.Value)
+	}
+
+	result := make([]Task, 0, len(tasks))
+	for _, t := range tasks {
+		result = append(result, t)
+	}
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// composerJSON represents the relevant parts of a composer.json file.
+type composerJSON struct {
+	Scripts             map[string]json.RawMessage `json:"scripts"`
+	ScriptsDescriptions map[string]string          `json:"scripts-descriptions"`
+}
+
+// parseComposer discovers tasks from composer.json scripts. Scripts named
+// after Composer events (pre-*, post-*) run on their own and are skipped.
+// The description comes from scripts-descriptions, else the script itself.
+func (s *Service) parseComposer(ctx context.Context, files *sourceFiles) ([]Task, error) {
+	var composer composerJSON
+	if err := json.Unmarshal(files.data, &composer); err != nil {
+		return nil, err
+	}
+
+	tasks := make([]Task, 0, len(composer.Scripts))
+	for name, raw := range composer.Scripts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(name, "pre-") || strings.HasPrefix(name, "post-") {
+			continue
+		}
+		desc := composer.ScriptsDescriptions[name]
+		if desc == "" {
+			desc = composerScript(raw)
+		}
+		tasks = append(tasks, Task{
+			RepoKey:     files.repoRoot,
+			Kind:        KindComposer,
+			Name:        name,
+			Command:     "composer run " + name,
+			Description: desc,
+		})
+	}
+	return tasks, nil
+}
+
+// composerScript returns what a script given as a command or a list of
+// commands runs.
+func composerScript(raw json.RawMessage) string {
+	var cmd string
+	if err := json.Unmarshal(raw, &cmd); err == nil {
+		return cmd
+	}
+	var cmds []string
+	if err := json.Unmarshal(raw, &cmds); err == nil {
+		return strings.Join(cmds, " && ")
+	}
+	return ""
+}
@@ -5,6 +5,7 @@ package discovery
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -19,6 +20,14 @@ type TaskKind string
 const (
 	KindPackageJSON TaskKind = "package.json"
 	KindMakefile    TaskKind = "makefile"
+	KindJustfile    TaskKind = "justfile"
+	KindTaskfile    TaskKind = "taskfile"
+	KindCargo       TaskKind = "cargo"
+	KindGradle      TaskKind = "gradle"
+	KindPoetry      TaskKind = "poetry"
+	KindPipenv      TaskKind = "pipenv"
+	KindComposer    TaskKind = "composer"
+	KindRakefile    TaskKind = "rakefile"
 )
 
 // Default discovery configuration.
@@ -62,14 +71,16 @@ func DefaultOptions() Options {
 
 // Service manages task discovery for repositories.
 type Service struct {
-	db         *sql.DB
-	insertStmt *sql.Stmt
-	selectStmt *sql.Stmt
-	deleteStmt *sql.Stmt
-	lastTSStmt *sql.Stmt
-	cache      map[string]time.Time
-	opts       Options
-	cacheMu    sync.RWMutex
+	db           *sql.DB
+	insertStmt   *sql.Stmt
+	selectStmt   *sql.Stmt
+	deleteStmt   *sql.Stmt
+	lastTSStmt   *sql.Stmt
+	checksumStmt *sql.Stmt
+	touchStmt    *sql.Stmt
+	cache        map[string]time.Time
+	opts         Options
+	cacheMu      sync.RWMutex
 }
 
 // NewService creates a new discovery service.
@@ -102,45 +113,42 @@ func NewService(db *sql.DB, opts Options) (*Service, error) {
 
 // prepareStatements creates prepared SQL statements.
 func (s *Service) prepareStatements() error {
-	var err error
-
-	s.insertStmt, err = s.db.Prepare(`
-		INSERT OR REPLACE INTO project_task (repo_key, kind, name, command, description, discovered_ts)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`)
-	if err != nil {
-		return err
-	}
-
-	s.selectStmt, err = s.db.Prepare(`
-		SELECT kind, name, command, description FROM project_task
-		WHERE repo_key = ?
-		ORDER BY kind, name
-	`)
-	if err != nil {
-		s.insertStmt.Close()
-		return err
+	queries := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&s.insertStmt, `
+			INSERT OR REPLACE INTO task_candidate
+				(repo_key, kind, name, command_text, description, source_checksum, discovered_ms)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`},
+		{&s.selectStmt, `
+			SELECT kind, name, command_text, description FROM task_candidate
+			WHERE repo_key = ?
+			ORDER BY kind, name
+		`},
+		{&s.deleteStmt, `
+			DELETE FROM task_candidate WHERE repo_key = ? AND kind = ?
+		`},
+		{&s.lastTSStmt, `
+			SELECT MAX(discovered_ms) FROM task_candidate WHERE repo_key = ?
+		`},
+		{&s.checksumStmt, `
+			SELECT source_checksum FROM task_candidate WHERE repo_key = ? AND kind = ? LIMIT 1
+		`},
+		{&s.touchStmt, `
+			UPDATE task_candidate SET discovered_ms = ? WHERE repo_key = ? AND kind = ?
+		`},
 	}
 
-	s.deleteStmt, err = s.db.Prepare(`
-		DELETE FROM project_task WHERE repo_key = ? AND kind = ?
-	`)
-	if err != nil {
-		s.insertStmt.Close()
-		s.selectStmt.Close()
-		return err
-	}
-
-	s.lastTSStmt, err = s.db.Prepare(`
-		SELECT MAX(discovered_ts) FROM project_task WHERE repo_key = ?
-	`)
-	if err != nil {
-		s.insertStmt.Close()
-		s.selectStmt.Close()
-		s.deleteStmt.Close()
-		return err
+	for _, q := range queries {
+		stmt, err := s.db.Prepare(q.query)
+		if err != nil {
+			s.Close()
+			return err
+		}
+		*q.stmt = stmt
 	}
-
 	return nil
 }
 
@@ -158,11 +166,18 @@ func (s *Service) Close() error {
 	if s.lastTSStmt != nil {
 		s.lastTSStmt.Close()
 	}
+	if s.checksumStmt != nil {
+		s.checksumStmt.Close()
+	}
+	if s.touchStmt != nil {
+		s.touchStmt.Close()
+	}
 	return nil
 }
 
 // Discover runs all built-in discovery for a repository if TTL has expired.
-// It returns true if discovery was performed, false if cached.
+// It returns true if discovery was performed, false if cached. Each source
+// is only parsed again when the checksum of its files changed.
 func (s *Service) Discover(ctx context.Context, repoRoot string) (bool, error) {
 	if repoRoot == "" {
 		return false, nil
@@ -200,16 +215,13 @@ func (s *Service) Discover(ctx context.Context, repoRoot string) (bool, error) {
 
 	nowMs := time.Now().UnixMilli()
 
-	// Discover package.json scripts
-	if err := s.discoverPackageJSON(discoverCtx, repoRoot, nowMs); err != nil {
-		s.opts.Logger.Warn("package.json discovery failed", "error", err, "repo", repoRoot)
-		// Continue with other discovery sources
-	}
-
-	// Discover Makefile targets
-	if err := s.discoverMakefile(discoverCtx, repoRoot, nowMs); err != nil {
-		s.opts.Logger.Warn("Makefile discovery failed", "error", err, "repo", repoRoot)
-		// Continue with other discovery sources
+	// Sources whose files are unchanged since the last pass keep their
+	// tasks; a failing source never stops the others.
+	for i := range taskSources {
+		src := &taskSources[i]
+		if err := s.discoverSource(discoverCtx, repoRoot, src, nowMs); err != nil {
+			s.opts.Logger.Warn("task discovery failed", "kind", src.kind, "error", err, "repo", repoRoot)
+		}
 	}
 
 	// Update cache
@@ -266,8 +278,9 @@ func (s *Service) GetTasksByKind(ctx context.Context, repoRoot string, kind Task
 	return filtered, nil
 }
 
-// saveTasks saves discovered tasks to the database.
-func (s *Service) saveTasks(ctx context.Context, repoRoot string, kind TaskKind, tasks []Task, nowMs int64) error {
+// saveTasks replaces the repository's tasks of one kind with tasks,
+// recording the checksum of the source files they were parsed from.
+func (s *Service) saveTasks(ctx context.Context, repoRoot string, kind TaskKind, tasks []Task, checksum string, nowMs int64) error {
 	// Delete existing tasks of this kind for the repo
 	if _, err := s.deleteStmt.ExecContext(ctx, repoRoot, kind); err != nil {
 		return err
@@ -275,7 +288,7 @@ func (s *Service) saveTasks(ctx context.Context, repoRoot string, kind TaskKind,
 
 	// Insert new tasks
 	for _, t := range tasks {
-		_, err := s.insertStmt.ExecContext(ctx, repoRoot, t.Kind, t.Name, t.Command, t.Description, nowMs)
+		_, err := s.insertStmt.ExecContext(ctx, repoRoot, t.Kind, t.Name, t.Command, t.Description, checksum, nowMs)
 		if err != nil {
 			return err
 		}
@@ -284,6 +297,17 @@ func (s *Service) saveTasks(ctx context.Context, repoRoot string, kind TaskKind,
 	return nil
 }
 
+// storedChecksum returns the checksum the repository's tasks of kind were
+// parsed from, or "" if there are none.
+func (s *Service) storedChecksum(ctx context.Context, repoRoot string, kind TaskKind) (string, error) {
+	var checksum sql.NullString
+	err := s.checksumStmt.QueryRowContext(ctx, repoRoot, kind).Scan(&checksum)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return checksum.String, err
+}
+
 // fileExists checks if a file exists in the given directory.
 func fileExists(dir, filename string) bool {
 	path := filepath.Join(dir, filename)
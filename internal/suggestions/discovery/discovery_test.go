@@ -27,18 +27,21 @@ func createTestDB(t *testing.T) *sql.DB {
 	require.NoError(t, err)
 	t.Cleanup(func() { db.Close() })
 
-	// Create project_task table
+	// Create task_candidate table
 	_, err = db.Exec(`
-		CREATE TABLE project_task (
-			repo_key      TEXT NOT NULL,
-			kind          TEXT NOT NULL,
-			name          TEXT NOT NULL,
-			command       TEXT NOT NULL,
-			description   TEXT,
-			discovered_ts INTEGER NOT NULL,
+		CREATE TABLE task_candidate (
+			repo_key          TEXT NOT NULL,
+			kind              TEXT NOT NULL,
+			name              TEXT NOT NULL,
+			command_text      TEXT NOT NULL,
+			description       TEXT,
+			source            TEXT NOT NULL DEFAULT 'auto',
+			priority_boost    REAL NOT NULL DEFAULT 0,
+			source_checksum   TEXT,
+			discovered_ms     INTEGER NOT NULL,
 			PRIMARY KEY(repo_key, kind, name)
 		);
-		CREATE INDEX idx_project_task_repo ON project_task(repo_key);
+		CREATE INDEX idx_task_candidate_repo ON task_candidate(repo_key);
 	`)
 	require.NoError(t, err)
 
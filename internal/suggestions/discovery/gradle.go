@@ -0,0 +1,85 @@
+package discovery
+
+import (
+	"context"
+	"regexp"
+)
+
+// gradleWrapper is the wrapper script tasks run through when a project has
+// one.
+const gradleWrapper = "gradlew"
+
+// gradleTaskRegex matches task declarations in Groovy and Kotlin build
+// scripts: tasks.register("name"), tasks.create<Type>("name"),
+// task name { ... } and task("name").
+var gradleTaskRegex = regexp.MustCompile(
+	`(?m)(?:tasks\.(?:register|create)(?:<[^>]*>)?\(\s*["']([\w-]+)["']|^\s*task\s*\(\s*["']([\w-]+)["']|^\s*task\s+([\w-]+))`)
+
+// gradleApplicationRegex matches applying the application plugin, which
+// adds the run task.
+var gradleApplicationRegex = regexp.MustCompile(
+	`(?m)(?:id\s*\(?\s*["']application["']|apply\s+plugin:\s*["']application["']|^\s*application\s*(?:\{|$))`)
+
+// gradleSpringBootRegex matches applying the Spring Boot plugin, which adds
+// the bootRun task.
+var gradleSpringBootRegex = regexp.MustCompile(`["']org\.springframework\.boot["']`)
+
+// gradleTasks are the tasks of the base and java plugins suggested for
+// every build.
+var gradleTasks = map[string]string{
+	"build":    "Assemble and test the project",
+	"test":     "Run the tests",
+	"clean":    "Delete the build directory",
+	"assemble": "Assemble the outputs",
+	"check":    "Run all checks",
+}
+
+// parseGradle discovers tasks from a Gradle build script: the common
+// lifecycle tasks, the tasks its plugins add and the tasks it declares.
+// They run through the Gradle wrapper when the project has one.
+func (s *Service) parseGradle(ctx context.Context, files *sourceFiles) ([]Task, error) {
+	gradle := "gradle "
+	if files.markers[gradleWrapper] {
+		gradle = "./gradlew "
+	}
+
+	tasks := make(map[string]Task, len(gradleTasks))
+	add := func(name, desc string) {
+		tasks[name] = Task{
+			RepoKey:     files.repoRoot,
+			Kind:        KindGradle,
+			Name:        name,
+			Command:     gradle + name,
+			Description: desc,
+		}
+	}
+
+	for name, desc := range gradleTasks {
+		add(name, desc)
+	}
+	if gradleApplicationRegex.Match(files.data) {
+		add("run", "Run the application")
+	}
+	if gradleSpringBootRegex.Match(files.data) {
+		add("bootRun", "Run the Spring Boot application")
+	}
+	for _, m := range gradleTaskRegex.FindAllSubmatch(files.data, -1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, name := range m[1:] {
+			if len(name) > 0 {
+				if _, ok := tasks[string(name)]; !ok {
+					add(string(name), "")
+				}
+				break
+			}
+		}
+	}
+
+	result := make([]Task, 0, len(tasks))
+	for _, t := range tasks {
+		result = append(result, t)
+	}
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+)
+
+// justRecipeRegex matches justfile recipe headers such as "build:",
+// "@test *args: build" or "deploy env='prod':". Assignments ("name := value")
+// are rejected by requiring the colon not to be followed by "=".
+var justRecipeRegex = regexp.MustCompile(`^@?([a-zA-Z_][a-zA-Z0-9_-]*)[^:]*:([^=]|$)`)
+
+// parseJustfile discovers tasks from justfile recipes. A comment line right
+// above a recipe is its description; private recipes, named with a leading
+// underscore or marked [private], are skipped.
+func (s *Service) parseJustfile(ctx context.Context, files *sourceFiles) ([]Task, error) {
+	seen := make(map[string]bool)
+	var tasks []Task
+	var doc string
+	var private bool
+
+	scanner := bufio.NewScanner(bytes.NewReader(files.data))
+	scanner.Buffer(make([]byte, 64*1024), int(s.opts.MaxOutputBytes))
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#!"):
+			doc = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			continue
+		case strings.HasPrefix(line, "["):
+			// Attributes keep the comment above them.
+			if strings.Contains(trimmed, "private") {
+				private = true
+			}
+			continue
+		}
+
+		name, ok := parseJustRecipe(line)
+		if ok && !private && !strings.HasPrefix(name, "_") && !seen[name] {
+			seen[name] = true
+			tasks = append(tasks, Task{
+				RepoKey:     files.repoRoot,
+				Kind:        KindJustfile,
+				Name:        name,
+				Command:     "just " + name,
+				Description: doc,
+			})
+		}
+		doc, private = "", false
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// parseJustRecipe returns the recipe name declared by line, if any.
+func parseJustRecipe(line string) (string, bool) {
+	if line == "" || line[0] == ' ' || line[0] == '\t' {
+		return "", false
+	}
+	matches := justRecipeRegex.FindStringSubmatch(line)
+	if len(matches) < 2 {
+		return "", false
+	}
+	switch matches[1] {
+	case "set", "export", "alias", "import", "mod":
+		return "", false
+	}
+	return matches[1], true
+}
@@ -2,11 +2,9 @@ package discovery
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"os"
-	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
 )
 
@@ -42,40 +40,12 @@ var commonTargets = map[string]string{
 	"update":   "Update dependencies",
 }
 
-// discoverMakefile discovers tasks from Makefile targets.
+// parseMakefile discovers tasks from Makefile targets.
 // Per spec Section 10.1, this uses "Mode A heuristic" - parsing the Makefile directly.
-func (s *Service) discoverMakefile(ctx context.Context, repoRoot string, nowMs int64) error {
-	makefilePath := findMakefilePath(repoRoot)
-	if makefilePath == "" {
-		return nil // Not an error, just no Makefile
-	}
-
-	// Read Makefile
-	file, err := os.Open(makefilePath) //nolint:gosec // reads user-specified path
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
+func (s *Service) parseMakefile(ctx context.Context, files *sourceFiles) ([]Task, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(files.data))
 	scanner.Buffer(make([]byte, 64*1024), int(s.opts.MaxOutputBytes))
-	tasks, err := s.parseMakefileTargets(ctx, repoRoot, scanner)
-	if err != nil {
-		return err
-	}
-	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].Name < tasks[j].Name
-	})
-	return s.saveTasks(ctx, repoRoot, KindMakefile, tasks, nowMs)
-}
-
-func findMakefilePath(repoRoot string) string {
-	for _, name := range []string{"Makefile", "makefile", "GNUmakefile"} {
-		if fileExists(repoRoot, name) {
-			return filepath.Join(repoRoot, name)
-		}
-	}
-	return ""
+	return s.parseMakefileTargets(ctx, files.repoRoot, scanner)
 }
 
 func (s *Service) parseMakefileTargets(ctx context.Context, repoRoot string, scanner *bufio.Scanner) ([]Task, error) {
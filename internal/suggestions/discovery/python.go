@@ -0,0 +1,45 @@
+package discovery
+
+import (
+	"context"
+)
+
+// parsePoetry discovers the scripts of a Poetry project's pyproject.toml,
+// run with `poetry run`. Projects declaring them the PEP 621 way under
+// [project.scripts] count only when pyproject.toml configures Poetry.
+func (s *Service) parsePoetry(ctx context.Context, files *sourceFiles) ([]Task, error) {
+	scripts := tomlTable(files.data, "tool.poetry.scripts")
+	if tomlHasTable(files.data, "tool.poetry") || len(scripts) > 0 {
+		scripts = append(scripts, tomlTable(files.data, "project.scripts")...)
+	}
+	return scriptTasks(ctx, files.repoRoot, KindPoetry, "poetry run ", scripts)
+}
+
+// parsePipenv discovers the scripts of a Pipfile, run with `pipenv run`.
+func (s *Service) parsePipenv(ctx context.Context, files *sourceFiles) ([]Task, error) {
+	return scriptTasks(ctx, files.repoRoot, KindPipenv, "pipenv run ", tomlTable(files.data, "scripts"))
+}
+
+// scriptTasks turns named scripts into tasks run with runner, described by
+// what they run. The first definition of a name wins.
+func scriptTasks(ctx context.Context, repoRoot string, kind TaskKind, runner string, scripts []tomlEntry) ([]Task, error) {
+	seen := make(map[string]bool, len(scripts))
+	tasks := make([]Task, 0, len(scripts))
+	for _, script := range scripts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if seen[script.Key] {
+			continue
+		}
+		seen[script.Key] = true
+		tasks = append(tasks, Task{
+			RepoKey:     repoRoot,
+			Kind:        kind,
+			Name:        script.Key,
+			Command:     runner + script.Key,
+			Description: script.Value,
+		})
+	}
+	return tasks, nil
+}
@@ -0,0 +1,94 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+)
+
+// rakeTaskRegex matches Rake task declarations: task :name, task name: deps,
+// task "name" and multitask variants.
+var rakeTaskRegex = regexp.MustCompile(`^(?:multi)?task\s*\(?\s*(?::([\w-]+)|["']([\w:-]+)["']|([\w-]+):)`)
+
+// rakeNamespaceRegex matches namespace :name do and namespace "name" do.
+var rakeNamespaceRegex = regexp.MustCompile(`^namespace\s*\(?\s*(?::([\w-]+)|["']([\w:-]+)["'])`)
+
+// rakeDescRegex matches desc "text" and desc 'text'.
+var rakeDescRegex = regexp.MustCompile(`^desc\s*\(?\s*["'](.*)["']`)
+
+// rakeNamespace is a namespace block open at the current line.
+type rakeNamespace struct {
+	name   string
+	indent int
+}
+
+// parseRakefile discovers tasks from a Rakefile, prefixing tasks declared in
+// namespace blocks with the namespace as in `rake db:migrate`. A desc line
+// above a task is its description. Blocks are tracked by indentation.
+func (s *Service) parseRakefile(ctx context.Context, files *sourceFiles) ([]Task, error) {
+	seen := make(map[string]bool)
+	var tasks []Task
+	var namespaces []rakeNamespace
+	var desc string
+
+	scanner := bufio.NewScanner(bytes.NewReader(files.data))
+	scanner.Buffer(make([]byte, 64*1024), int(s.opts.MaxOutputBytes))
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		for len(namespaces) > 0 && namespaces[len(namespaces)-1].indent >= indent {
+			namespaces = namespaces[:len(namespaces)-1]
+		}
+
+		if m := rakeDescRegex.FindStringSubmatch(trimmed); m != nil {
+			desc = m[1]
+			continue
+		}
+		if name := firstSubmatch(rakeNamespaceRegex.FindStringSubmatch(trimmed)); name != "" {
+			namespaces = append(namespaces, rakeNamespace{name: name, indent: indent})
+			continue
+		}
+		name := firstSubmatch(rakeTaskRegex.FindStringSubmatch(trimmed))
+		if name == "" {
+			continue
+		}
+		for i := len(namespaces) - 1; i >= 0; i-- {
+			name = namespaces[i].name + ":" + name
+		}
+		if !seen[name] {
+			seen[name] = true
+			tasks = append(tasks, Task{
+				RepoKey:     files.repoRoot,
+				Kind:        KindRakefile,
+				Name:        name,
+				Command:     "rake " + name,
+				Description: desc,
+			})
+		}
+		desc = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// firstSubmatch returns the first non-empty capture group of a match.
+func firstSubmatch(matches []string) string {
+	for _, m := range matches[min(1, len(matches)):] {
+		if m != "" {
+			return m
+		}
+	}
+	return ""
+}
@@ -0,0 +1,188 @@
+package discovery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// taskSource describes one ecosystem's task definitions: the files they live
+// in and the parser that turns them into tasks.
+type taskSource struct {
+	// parse turns the source files into tasks.
+	parse func(s *Service, ctx context.Context, files *sourceFiles) ([]Task, error)
+
+	kind TaskKind
+
+	// files are the candidate names of the file defining the tasks; the
+	// first that exists is parsed. Without one the source is absent.
+	files []string
+
+	// related are optional files whose content the parser also reads,
+	// such as cargo's alias config.
+	related []string
+
+	// markers are optional files whose presence, not content, changes the
+	// tasks, such as the gradle wrapper.
+	markers []string
+}
+
+// sourceFiles holds what a parser reads for one repository.
+type sourceFiles struct {
+	related  map[string][]byte // content of the related files that exist
+	markers  map[string]bool   // markers that exist
+	repoRoot string
+	name     string // name of the parsed source file
+	data     []byte // content of the parsed source file
+}
+
+// taskSources lists the built-in sources in discovery order.
+var taskSources = []taskSource{
+	{kind: KindPackageJSON, files: []string{"package.json"}, parse: (*Service).parsePackageJSON},
+	{kind: KindMakefile, files: []string{"Makefile", "makefile", "GNUmakefile"}, parse: (*Service).parseMakefile},
+	{kind: KindJustfile, files: []string{"justfile", "Justfile", ".justfile"}, parse: (*Service).parseJustfile},
+	{kind: KindTaskfile, files: []string{"Taskfile.yml", "Taskfile.yaml", "taskfile.yml", "taskfile.yaml"}, parse: (*Service).parseTaskfile},
+	{
+		kind:    KindCargo,
+		files:   []string{"Cargo.toml"},
+		related: []string{cargoConfigPath, cargoLegacyConfigPath},
+		markers: []string{cargoMainPath},
+		parse:   (*Service).parseCargo,
+	},
+	{
+		kind:    KindGradle,
+		files:   []string{"build.gradle.kts", "build.gradle"},
+		markers: []string{gradleWrapper},
+		parse:   (*Service).parseGradle,
+	},
+	{kind: KindPoetry, files: []string{"pyproject.toml"}, parse: (*Service).parsePoetry},
+	{kind: KindPipenv, files: []string{"Pipfile"}, parse: (*Service).parsePipenv},
+	{kind: KindComposer, files: []string{"composer.json"}, parse: (*Service).parseComposer},
+	{kind: KindRakefile, files: []string{"Rakefile", "rakefile", "Rakefile.rb", "rakefile.rb"}, parse: (*Service).parseRakefile},
+}
+
+// discoverSource refreshes the repository's tasks from src. Tasks parsed
+// from files with an unchanged checksum are kept as they are, and the tasks
+// of a source that no longer exists are removed.
+func (s *Service) discoverSource(ctx context.Context, repoRoot string, src *taskSource, nowMs int64) error {
+	files, err := s.readSourceFiles(repoRoot, src)
+	if err != nil {
+		return err
+	}
+	if files == nil {
+		return s.saveTasks(ctx, repoRoot, src.kind, nil, "", nowMs)
+	}
+
+	checksum := files.checksum()
+	stored, err := s.storedChecksum(ctx, repoRoot, src.kind)
+	if err != nil {
+		return err
+	}
+	if stored == checksum {
+		_, err := s.touchStmt.ExecContext(ctx, nowMs, repoRoot, src.kind)
+		return err
+	}
+
+	tasks, err := src.parse(s, ctx, files)
+	if err != nil {
+		return err
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+	return s.saveTasks(ctx, repoRoot, src.kind, tasks, checksum, nowMs)
+}
+
+// readSourceFiles reads the files of src in repoRoot. It returns nil if the
+// source file does not exist or exceeds the output limit.
+func (s *Service) readSourceFiles(repoRoot string, src *taskSource) (*sourceFiles, error) {
+	files := &sourceFiles{repoRoot: repoRoot}
+	for _, name := range src.files {
+		if fileExists(repoRoot, name) {
+			files.name = name
+			break
+		}
+	}
+	if files.name == "" {
+		return nil, nil
+	}
+
+	data, ok, err := s.readLimited(repoRoot, files.name)
+	if err != nil || !ok {
+		return nil, err
+	}
+	files.data = data
+
+	for _, name := range src.related {
+		if !fileExists(repoRoot, name) {
+			continue
+		}
+		data, ok, err := s.readLimited(repoRoot, name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if files.related == nil {
+				files.related = make(map[string][]byte)
+			}
+			files.related[name] = data
+		}
+	}
+	for _, name := range src.markers {
+		if fileExists(repoRoot, name) {
+			if files.markers == nil {
+				files.markers = make(map[string]bool)
+			}
+			files.markers[name] = true
+		}
+	}
+	return files, nil
+}
+
+// readLimited reads a file of repoRoot, skipping it if it exceeds the
+// output limit.
+func (s *Service) readLimited(repoRoot, name string) ([]byte, bool, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, name)) //nolint:gosec // reads a file of the user's repository
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(data)) > s.opts.MaxOutputBytes {
+		s.opts.Logger.Warn("task source too large, skipping",
+			"file", name,
+			"size", len(data),
+			"limit", s.opts.MaxOutputBytes,
+			"repo", repoRoot,
+		)
+		return nil, false, nil
+	}
+	return data, true, nil
+}
+
+// checksum identifies the content of the source files, so a source is only
+// parsed again after one of them changed.
+func (f *sourceFiles) checksum() string {
+	h := sha256.New()
+	writeField := func(name string, data []byte) {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write(data)
+		h.Write([]byte{0})
+	}
+
+	writeField(f.name, f.data)
+	names := make([]string, 0, len(f.related)+len(f.markers))
+	for name := range f.related {
+		names = append(names, name)
+	}
+	for name := range f.markers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeField(name, f.related[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
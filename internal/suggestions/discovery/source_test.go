@@ -0,0 +1,289 @@
+package discovery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRepoFile writes a file of a test repository, creating its directory.
+func writeRepoFile(t *testing.T, repoRoot, name, content string) {
+	t.Helper()
+	path := filepath.Join(repoRoot, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+// discoverCommands runs discovery on a repository made of files and returns
+// the discovered commands of kind with their descriptions.
+func discoverCommands(t *testing.T, kind TaskKind, files map[string]string) map[string]string {
+	t.Helper()
+
+	svc, err := NewService(createTestDB(t), Options{TTL: time.Millisecond})
+	require.NoError(t, err)
+	defer svc.Close()
+
+	repoRoot := createTestRepo(t)
+	for name, content := range files {
+		writeRepoFile(t, repoRoot, name, content)
+	}
+
+	ctx := context.Background()
+	_, err = svc.Discover(ctx, repoRoot)
+	require.NoError(t, err)
+	tasks, err := svc.GetTasksByKind(ctx, repoRoot, kind)
+	require.NoError(t, err)
+
+	commands := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		commands[task.Command] = task.Description
+	}
+	return commands
+}
+
+func TestService_DiscoverJustfile(t *testing.T) {
+	t.Parallel()
+
+	commands := discoverCommands(t, KindJustfile, map[string]string{"justfile": `set shell := ["bash", "-c"]
+version := "1.0"
+alias b := build
+
+# Build the binary
+build:
+    go build ./...
+
+[private]
+helper:
+    echo hidden
+
+_internal:
+    echo hidden
+
+# Deploy to an environment
+[no-cd]
+@deploy env='prod': build
+    ./deploy {{env}}
+`})
+
+	assert.Equal(t, map[string]string{
+		"just build":  "Build the binary",
+		"just deploy": "Deploy to an environment",
+	}, commands)
+}
+
+func TestService_DiscoverTaskfile(t *testing.T) {
+	t.Parallel()
+
+	commands := discoverCommands(t, KindTaskfile, map[string]string{"Taskfile.yml": `version: '3'
+tasks:
+  build:
+    desc: Build the project
+    cmds:
+      - go build ./...
+  lint: golangci-lint run
+  setup:
+    internal: true
+    cmds:
+      - go mod download
+`})
+
+	assert.Equal(t, map[string]string{
+		"task build": "Build the project",
+		"task lint":  "",
+	}, commands)
+}
+
+func TestService_DiscoverCargo(t *testing.T) {
+	t.Parallel()
+
+	commands := discoverCommands(t, KindCargo, map[string]string{
+		"Cargo.toml":  "[package]\nname = \"demo\"\n",
+		"src/main.rs": "fn main() {}\n",
+		".cargo/config.toml": `[alias]
+b = "build"
+rr = ["run", "--release"] # release run
+`,
+	})
+
+	assert.Equal(t, "cargo build", commands["cargo b"])
+	assert.Equal(t, "cargo run --release", commands["cargo rr"])
+	assert.Equal(t, "Run the binary", commands["cargo run"])
+	assert.Contains(t, commands, "cargo test")
+	assert.Contains(t, commands, "cargo clippy")
+
+	library := discoverCommands(t, KindCargo, map[string]string{"Cargo.toml": "[package]\nname = \"lib\"\n"})
+	assert.NotContains(t, library, "cargo run")
+	assert.Contains(t, library, "cargo build")
+}
+
+func TestService_DiscoverGradle(t *testing.T) {
+	t.Parallel()
+
+	commands := discoverCommands(t, KindGradle, map[string]string{
+		"gradlew": "#!/bin/sh\n",
+		"build.gradle.kts": `plugins {
+    application
+}
+
+tasks.register<Copy>("copyDocs") {
+    from("docs")
+}
+tasks.register("integrationTest")
+`,
+	})
+
+	assert.Contains(t, commands, "./gradlew build")
+	assert.Contains(t, commands, "./gradlew run")
+	assert.Contains(t, commands, "./gradlew copyDocs")
+	assert.Contains(t, commands, "./gradlew integrationTest")
+
+	groovy := discoverCommands(t, KindGradle, map[string]string{"build.gradle": "task hello {\n    doLast { println 'hi' }\n}\n"})
+	assert.Contains(t, groovy, "gradle hello")
+	assert.NotContains(t, groovy, "gradle run")
+}
+
+func TestService_DiscoverPoetryAndPipenv(t *testing.T) {
+	t.Parallel()
+
+	poetry := discoverCommands(t, KindPoetry, map[string]string{"pyproject.toml": `[tool.poetry]
+name = "demo"
+
+[tool.poetry.scripts]
+serve = "demo.app:main"
+
+[tool.pytest.ini_options]
+addopts = "-q"
+`})
+	assert.Equal(t, map[string]string{"poetry run serve": "demo.app:main"}, poetry)
+
+	notPoetry := discoverCommands(t, KindPoetry, map[string]string{"pyproject.toml": "[project.scripts]\nserve = \"demo.app:main\"\n"})
+	assert.Empty(t, notPoetry)
+
+	pipenv := discoverCommands(t, KindPipenv, map[string]string{"Pipfile": `[packages]
+requests = "*"
+
+[scripts]
+test = "pytest -q"
+'lint' = 'ruff check .'
+`})
+	assert.Equal(t, map[string]string{
+		"pipenv run test": "pytest -q",
+		"pipenv run lint": "ruff check .",
+	}, pipenv)
+}
+
+func TestService_DiscoverComposer(t *testing.T) {
+	t.Parallel()
+
+	commands := discoverCommands(t, KindComposer, map[string]string{"composer.json": `{
+		"scripts": {
+			"test": "phpunit",
+			"check": ["@cs", "@test"],
+			"post-install-cmd": "php artisan key:generate"
+		},
+		"scripts-descriptions": {"test": "Run the test suite"}
+	}`})
+
+	assert.Equal(t, map[string]string{
+		"composer run test":  "Run the test suite",
+		"composer run check": "@cs && @test",
+	}, commands)
+}
+
+func TestService_DiscoverRakefile(t *testing.T) {
+	t.Parallel()
+
+	commands := discoverCommands(t, KindRakefile, map[string]string{"Rakefile": `desc "Run the specs"
+task :spec do
+  sh "rspec"
+end
+
+task default: :spec
+
+namespace :db do
+  desc 'Migrate the database'
+  task :migrate do
+    sh "bin/migrate"
+  end
+end
+
+task "assets:precompile"
+`})
+
+	assert.Equal(t, map[string]string{
+		"rake spec":              "Run the specs",
+		"rake default":           "",
+		"rake db:migrate":        "Migrate the database",
+		"rake assets:precompile": "",
+	}, commands)
+}
+
+func TestService_RediscoversChangedSources(t *testing.T) {
+	t.Parallel()
+
+	svc, err := NewService(createTestDB(t), Options{TTL: time.Hour})
+	require.NoError(t, err)
+	defer svc.Close()
+
+	repoRoot := createTestRepo(t)
+	ctx := context.Background()
+	discover := func() []Task {
+		t.Helper()
+		svc.InvalidateAll()
+		nowMs := time.Now().UnixMilli()
+		for i := range taskSources {
+			require.NoError(t, svc.discoverSource(ctx, repoRoot, &taskSources[i], nowMs))
+		}
+		tasks, err := svc.GetTasks(ctx, repoRoot)
+		require.NoError(t, err)
+		return tasks
+	}
+
+	writeRepoFile(t, repoRoot, "justfile", "build:\n    go build\n")
+	tasks := discover()
+	require.Len(t, tasks, 1)
+	checksum, err := svc.storedChecksum(ctx, repoRoot, KindJustfile)
+	require.NoError(t, err)
+	assert.NotEmpty(t, checksum)
+
+	// Unchanged files keep their tasks without being parsed again.
+	_, err = svc.db.Exec(`UPDATE task_candidate SET description = 'kept' WHERE kind = ?`, KindJustfile)
+	require.NoError(t, err)
+	tasks = discover()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "kept", tasks[0].Description)
+
+	writeRepoFile(t, repoRoot, "justfile", "build:\n    go build\n\ntest:\n    go test\n")
+	tasks = discover()
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "just test", tasks[1].Command)
+
+	// Tasks of a removed source go away.
+	require.NoError(t, os.Remove(filepath.Join(repoRoot, "justfile")))
+	assert.Empty(t, discover())
+}
+
+func TestTOMLTable(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`[alias]
+b = "build"   # build
+"t" = 'test'
+rr = ["run", "--release"]
+
+[[bin]]
+name = "demo"
+`)
+	assert.Equal(t, []tomlEntry{
+		{Key: "b", Value: "build"},
+		{Key: "t", Value: "test"},
+		{Key: "rr", Value: "run --release"},
+	}, tomlTable(data, "alias"))
+	assert.True(t, tomlHasTable(data, "bin"))
+	assert.False(t, tomlHasTable(data, "package"))
+}
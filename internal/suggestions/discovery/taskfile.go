@@ -0,0 +1,60 @@
+package discovery
+
+import (
+	"context"
+
+	"gopkg.in/yaml.v3"
+)
+
+// taskfileYAML represents the relevant parts of a Taskfile.yml.
+type taskfileYAML struct {
+	Tasks map[string]taskfileTask `yaml:"tasks"`
+}
+
+// taskfileTask is a Taskfile task. Tasks given as a bare command or a list
+// of commands have no description.
+type taskfileTask struct {
+	Desc     string `yaml:"desc"`
+	Summary  string `yaml:"summary"`
+	Internal bool   `yaml:"internal"`
+}
+
+// UnmarshalYAML accepts the short forms of a task besides the mapping.
+func (t *taskfileTask) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	type plain taskfileTask
+	return node.Decode((*plain)(t))
+}
+
+// parseTaskfile discovers tasks from a Taskfile's tasks, skipping internal
+// ones.
+func (s *Service) parseTaskfile(ctx context.Context, files *sourceFiles) ([]Task, error) {
+	var taskfile taskfileYAML
+	if err := yaml.Unmarshal(files.data, &taskfile); err != nil {
+		return nil, err
+	}
+
+	tasks := make([]Task, 0, len(taskfile.Tasks))
+	for name, task := range taskfile.Tasks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if task.Internal {
+			continue
+		}
+		desc := task.Desc
+		if desc == "" {
+			desc = task.Summary
+		}
+		tasks = append(tasks, Task{
+			RepoKey:     files.repoRoot,
+			Kind:        KindTaskfile,
+			Name:        name,
+			Command:     "task " + name,
+			Description: desc,
+		})
+	}
+	return tasks, nil
+}
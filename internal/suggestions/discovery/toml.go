@@ -0,0 +1,110 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// tomlEntry is a key/value pair of a TOML table.
+type tomlEntry struct {
+	Key   string
+	Value string
+}
+
+// tomlTable returns the entries of the named table of a TOML document, in
+// file order. It understands the subset of TOML task definitions use:
+// single-line keys with string, array or inline-table values. Strings are
+// unquoted, arrays of strings are joined with spaces, and other values are
+// returned as written.
+func tomlTable(data []byte, table string) []tomlEntry {
+	var entries []tomlEntry
+	inTable := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inTable = tomlHeader(line) == table
+			continue
+		}
+		if !inTable {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = tomlUnquote(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		entries = append(entries, tomlEntry{Key: key, Value: tomlValue(strings.TrimSpace(value))})
+	}
+	return entries
+}
+
+// tomlHasTable reports whether a TOML document declares the named table or
+// array of tables.
+func tomlHasTable(data []byte, table string) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && tomlHeader(line) == table {
+			return true
+		}
+	}
+	return false
+}
+
+// tomlHeader returns the name of the table a "[name]" or "[[name]]" header
+// line opens.
+func tomlHeader(line string) string {
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+	return strings.TrimSpace(line)
+}
+
+// tomlValue simplifies a value for use as a task description or command.
+func tomlValue(value string) string {
+	switch {
+	case strings.HasPrefix(value, `"`), strings.HasPrefix(value, "'"):
+		quote := value[:1]
+		if end := strings.Index(value[1:], quote); end >= 0 {
+			return value[1 : end+1]
+		}
+		return value[1:]
+	case strings.HasPrefix(value, "["):
+		end := strings.LastIndex(value, "]")
+		if end < 0 {
+			return value
+		}
+		var parts []string
+		for _, part := range strings.Split(value[1:end], ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, tomlUnquote(part))
+			}
+		}
+		return strings.Join(parts, " ")
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value
+	}
+}
+
+// tomlUnquote strips the quotes of a quoted key or string.
+func tomlUnquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
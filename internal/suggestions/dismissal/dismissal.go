@@ -89,6 +89,12 @@ type PatternRecord struct {
 	SuppressionLevel    State
 	DismissalCount      int
 	LastDismissedMs     int64
+
+	// ContextCmd and DismissedCmd are the normalized commands of the two
+	// templates. Only List fills them; they are empty for templates that
+	// no longer exist.
+	ContextCmd   string
+	DismissedCmd string
 }
 
 // RecordDismissal increments the dismissal count for the given pattern and
@@ -352,3 +358,77 @@ func (s *Store) IsSuppressed(ctx context.Context, scope, contextTemplateID, dism
 	}
 	return state == StateLearned || state == StatePermanent, nil
 }
+
+// ListOptions selects the patterns List returns.
+type ListOptions struct {
+	// Scope limits the patterns to one scope; empty lists every scope.
+	Scope string
+	// IncludeTemporary also lists TEMPORARY patterns, which only lower a
+	// suggestion's score for a while instead of suppressing it.
+	IncludeTemporary bool
+}
+
+// List returns the stored dismissal patterns, most recently dismissed first.
+func (s *Store) List(ctx context.Context, opts ListOptions) ([]PatternRecord, error) {
+	query := `
+		SELECT d.scope, d.context_template_id, d.dismissed_template_id,
+		       d.dismissal_count, d.last_dismissed_ms, d.suppression_level,
+		       COALESCE(ct.cmd_norm, ''), COALESCE(dt.cmd_norm, '')
+		FROM dismissal_pattern d
+		LEFT JOIN command_template ct ON ct.template_id = d.context_template_id
+		LEFT JOIN command_template dt ON dt.template_id = d.dismissed_template_id
+		WHERE (? = '' OR d.scope = ?)`
+	if !opts.IncludeTemporary {
+		query += ` AND d.suppression_level IN ('learned', 'permanent')`
+	}
+	query += ` ORDER BY d.last_dismissed_ms DESC, d.scope, d.dismissed_template_id`
+
+	rows, err := s.db.QueryContext(ctx, query, opts.Scope, opts.Scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dismissal patterns: %w", err)
+	}
+	defer rows.Close()
+
+	var records []PatternRecord
+	for rows.Next() {
+		var rec PatternRecord
+		var level string
+		if err := rows.Scan(
+			&rec.Scope, &rec.ContextTemplateID, &rec.DismissedTemplateID,
+			&rec.DismissalCount, &rec.LastDismissedMs, &level,
+			&rec.ContextCmd, &rec.DismissedCmd); err != nil {
+			return nil, fmt.Errorf("failed to scan dismissal pattern: %w", err)
+		}
+		rec.SuppressionLevel = State(level)
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate dismissal patterns: %w", err)
+	}
+	return records, nil
+}
+
+// Clear deletes the dismissal patterns of dismissedTemplateID in scope,
+// whatever their state, so the template is suggested again in every
+// context. An empty scope clears every scope and an empty
+// dismissedTemplateID every template. It returns the number of patterns
+// deleted.
+func (s *Store) Clear(ctx context.Context, scope, dismissedTemplateID string) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		"DELETE FROM dismissal_pattern WHERE (? = '' OR scope = ?) AND (? = '' OR dismissed_template_id = ?)",
+		scope, scope, dismissedTemplateID, dismissedTemplateID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear dismissal patterns: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear dismissal patterns: %w", err)
+	}
+
+	s.logger.Debug("cleared dismissal patterns",
+		"scope", scope,
+		"dismissed_template_id", dismissedTemplateID,
+		"count", n,
+	)
+	return n, nil
+}
@@ -28,6 +28,14 @@ func setupTestDB(t *testing.T) *sql.DB {
 			suppression_level       TEXT NOT NULL,
 			PRIMARY KEY(scope, context_template_id, dismissed_template_id)
 		);
+		CREATE TABLE IF NOT EXISTS command_template (
+			template_id     TEXT PRIMARY KEY,
+			cmd_norm        TEXT NOT NULL,
+			tags            TEXT,
+			slot_count      INTEGER NOT NULL,
+			first_seen_ms   INTEGER NOT NULL,
+			last_seen_ms    INTEGER NOT NULL
+		);
 	`)
 	if err != nil {
 		t.Fatal(err)
@@ -1012,3 +1020,91 @@ func TestConfigurableThreshold(t *testing.T) {
 		})
 	}
 }
+
+// --- List / Clear ---
+
+func TestList(t *testing.T) {
+	store, db := newStore(t, 2)
+	ctx := context.Background()
+
+	if _, err := db.Exec(`INSERT INTO command_template VALUES
+		('ctx-1', 'git status', NULL, 0, 1, 1),
+		('tmpl-a', 'git push', NULL, 0, 1, 1)`); err != nil {
+		t.Fatal(err)
+	}
+	store.RecordDismissal(ctx, "global", "ctx-1", "tmpl-a", 1000)
+	store.RecordDismissal(ctx, "global", "ctx-1", "tmpl-a", 2000) // learned
+	store.RecordDismissal(ctx, "global", "ctx-1", "tmpl-b", 3000) // temporary
+	store.RecordNever(ctx, "repo-x", "ctx-1", "tmpl-c", 4000)
+
+	records, err := store.List(ctx, ListOptions{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 suppressed patterns, got %d: %+v", len(records), records)
+	}
+	if records[0].DismissedTemplateID != "tmpl-c" || records[0].SuppressionLevel != StatePermanent {
+		t.Errorf("expected the permanent pattern first, got %+v", records[0])
+	}
+	rec := records[1]
+	if rec.DismissedTemplateID != "tmpl-a" || rec.DismissalCount != 2 || rec.LastDismissedMs != 2000 {
+		t.Errorf("unexpected learned pattern: %+v", rec)
+	}
+	if rec.ContextCmd != "git status" || rec.DismissedCmd != "git push" {
+		t.Errorf("expected template commands, got %q and %q", rec.ContextCmd, rec.DismissedCmd)
+	}
+	if records[0].DismissedCmd != "" {
+		t.Errorf("expected no command for an unknown template, got %q", records[0].DismissedCmd)
+	}
+
+	records, err = store.List(ctx, ListOptions{Scope: "global", IncludeTemporary: true})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 2 || records[0].DismissedTemplateID != "tmpl-b" {
+		t.Errorf("expected the global patterns including the temporary one, got %+v", records)
+	}
+}
+
+func TestClear(t *testing.T) {
+	store, _ := newStore(t, 1)
+	ctx := context.Background()
+
+	store.RecordDismissal(ctx, "global", "ctx-1", "tmpl-a", 1000)
+	store.RecordDismissal(ctx, "global", "ctx-2", "tmpl-a", 1000)
+	store.RecordNever(ctx, "global", "ctx-1", "tmpl-b", 1000)
+	store.RecordDismissal(ctx, "repo-x", "ctx-1", "tmpl-a", 1000)
+
+	n, err := store.Clear(ctx, "global", "tmpl-a")
+	if err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 patterns cleared, got %d", n)
+	}
+	for _, scope := range []string{"global", "repo-x"} {
+		suppressed, err := store.IsSuppressed(ctx, scope, "ctx-1", "tmpl-a")
+		if err != nil {
+			t.Fatalf("IsSuppressed: %v", err)
+		}
+		if suppressed == (scope == "global") {
+			t.Errorf("scope %s: unexpected suppressed=%v after clearing global", scope, suppressed)
+		}
+	}
+
+	n, err = store.Clear(ctx, "", "")
+	if err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected the remaining 2 patterns cleared, got %d", n)
+	}
+	records, err := store.List(ctx, ListOptions{IncludeTemporary: true})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("expected no patterns left, got %+v", records)
+	}
+}
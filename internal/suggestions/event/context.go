@@ -0,0 +1,66 @@
+package event
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/runger/clai/internal/cmdutil"
+)
+
+// Limits on the environment context of one event.
+const (
+	// MaxEnvContextVars caps the entries of CommandEvent.Env.
+	MaxEnvContextVars = 16
+
+	// MaxEnvContextValueBytes caps the length of one value.
+	MaxEnvContextValueBytes = 256
+)
+
+// Keys of the environment context an event may carry. Besides these,
+// asdf's per-tool version overrides (ASDF_<TOOL>_VERSION) are allowed.
+const (
+	EnvVirtualEnv  = "VIRTUAL_ENV"       // Directory name of the active virtualenv
+	EnvCondaEnv    = "CONDA_DEFAULT_ENV" // Active conda environment
+	EnvNodeVersion = "NVM_NODE_VERSION"  // Node version nvm put on PATH
+	EnvKubeContext = "KUBE_CONTEXT"      // current-context of the first KUBECONFIG file
+)
+
+// IsContextEnvKey reports whether key may appear in an event's environment
+// context.
+func IsContextEnvKey(key string) bool {
+	switch key {
+	case EnvVirtualEnv, EnvCondaEnv, EnvNodeVersion, EnvKubeContext:
+		return true
+	}
+	tool, ok := strings.CutPrefix(key, "ASDF_")
+	if !ok {
+		return false
+	}
+	tool, ok = strings.CutSuffix(tool, "_VERSION")
+	return ok && tool != ""
+}
+
+// FilterEnvContext returns the allowed, non-empty entries of env with
+// values cut to MaxEnvContextValueBytes, keeping the first
+// MaxEnvContextVars by key. It returns nil when none remain.
+func FilterEnvContext(env map[string]string) map[string]string {
+	keys := make([]string, 0, len(env))
+	for k, v := range env {
+		if v != "" && IsContextEnvKey(k) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	slices.Sort(keys)
+	if len(keys) > MaxEnvContextVars {
+		keys = keys[:MaxEnvContextVars]
+	}
+
+	filtered := make(map[string]string, len(keys))
+	for _, k := range keys {
+		filtered[k], _ = cmdutil.TruncateUTF8(env[k], MaxEnvContextValueBytes)
+	}
+	return filtered
+}
@@ -0,0 +1,54 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsContextEnvKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"VIRTUAL_ENV", true},
+		{"CONDA_DEFAULT_ENV", true},
+		{"NVM_NODE_VERSION", true},
+		{"KUBE_CONTEXT", true},
+		{"ASDF_NODEJS_VERSION", true},
+		{"ASDF__VERSION", false},
+		{"ASDF_DIR", false},
+		{"KUBECONFIG", false},
+		{"HOME", false},
+		{"AWS_SECRET_ACCESS_KEY", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, IsContextEnvKey(tt.key), tt.key)
+	}
+}
+
+func TestFilterEnvContext(t *testing.T) {
+	assert.Nil(t, FilterEnvContext(nil))
+	assert.Nil(t, FilterEnvContext(map[string]string{"HOME": "/root", "VIRTUAL_ENV": ""}))
+
+	got := FilterEnvContext(map[string]string{
+		"VIRTUAL_ENV":  "venv",
+		"TOKEN":        "secret",
+		"KUBE_CONTEXT": strings.Repeat("x", MaxEnvContextValueBytes+10),
+	})
+	assert.Equal(t, map[string]string{
+		"VIRTUAL_ENV":  "venv",
+		"KUBE_CONTEXT": strings.Repeat("x", MaxEnvContextValueBytes),
+	}, got)
+
+	many := make(map[string]string)
+	for i := range MaxEnvContextVars + 5 {
+		many[fmt.Sprintf("ASDF_TOOL%02d_VERSION", i)] = "1.0"
+	}
+	got = FilterEnvContext(many)
+	assert.Len(t, got, MaxEnvContextVars)
+	assert.Contains(t, got, "ASDF_TOOL00_VERSION")
+	assert.NotContains(t, got, fmt.Sprintf("ASDF_TOOL%02d_VERSION", MaxEnvContextVars))
+}
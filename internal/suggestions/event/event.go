@@ -10,12 +10,14 @@ const (
 	ShellBash Shell = "bash"
 	ShellZsh  Shell = "zsh"
 	ShellFish Shell = "fish"
+	ShellPwsh Shell = "pwsh"
+	ShellNu   Shell = "nu"
 )
 
 // ValidShell returns true if s is a valid shell type.
 func ValidShell(s string) bool {
 	switch Shell(s) {
-	case ShellBash, ShellZsh, ShellFish:
+	case ShellBash, ShellZsh, ShellFish, ShellPwsh, ShellNu:
 		return true
 	default:
 		return false
@@ -28,17 +30,31 @@ func ValidShell(s string) bool {
 // See spec Section 15.1 for the JSON format.
 type CommandEvent struct {
 	DurationMs *int64 `json:"duration_ms,omitempty"`
-	Type       string `json:"type"`
-	SessionID  string `json:"session_id"`
-	Shell      Shell  `json:"shell"`
-	Cwd        string `json:"cwd"`
-	CmdRaw     string `json:"cmd_raw"`
-	RepoKey    string `json:"repo_key,omitempty"`
-	Branch     string `json:"branch,omitempty"`
-	Version    int    `json:"v"`
-	TS         int64  `json:"ts"`
-	ExitCode   int    `json:"exit_code"`
-	Ephemeral  bool   `json:"ephemeral"`
+	// Env is allowlisted environment context the command ran in, such as
+	// the active virtualenv (see IsContextEnvKey).
+	Env       map[string]string `json:"env,omitempty"`
+	Type      string            `json:"type"`
+	SessionID string            `json:"session_id"`
+	Shell     Shell             `json:"shell"`
+	Cwd       string            `json:"cwd"`
+	CmdRaw    string            `json:"cmd_raw"`
+	RepoKey   string            `json:"repo_key,omitempty"`
+	Branch    string            `json:"branch,omitempty"`
+	Host      string            `json:"host,omitempty"`
+	// PrevCwd is the working directory before the command, set when the
+	// command changed it (OLDPWD to PWD, e.g. cd).
+	PrevCwd string `json:"prev_cwd,omitempty"`
+	// Stdout and Stderr are tails of what the command printed, present only
+	// when the client captured them and suggestions.capture_output is on.
+	Stdout    string `json:"stdout,omitempty"`
+	Stderr    string `json:"stderr,omitempty"`
+	Version   int    `json:"v"`
+	TS        int64  `json:"ts"`
+	ExitCode  int    `json:"exit_code"`
+	Ephemeral bool   `json:"ephemeral"`
+	Truncated bool   `json:"truncated,omitempty"`
+	// OutputTruncated reports that Stdout or Stderr was cut to its tail.
+	OutputTruncated bool `json:"output_truncated,omitempty"`
 }
 
 // EventType constants for the Type field.
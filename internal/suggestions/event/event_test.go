@@ -16,6 +16,8 @@ func TestValidShell(t *testing.T) {
 		{"bash", true},
 		{"zsh", true},
 		{"fish", true},
+		{"pwsh", true},
+		{"nu", true},
 		{"BASH", false}, // case sensitive
 		{"ZSH", false},
 		{"FISH", false},
@@ -100,7 +100,7 @@ func Explain(s *suggest.Suggestion, cfg Config, prevCmd string) []Reason {
 
 // collectFeatures gathers all non-zero score features from the breakdown.
 func collectFeatures(b *suggest.ScoreBreakdown, includeAmplifiers bool) []featureEntry {
-	entries := make([]featureEntry, 0, 12)
+	entries := make([]featureEntry, 0, 14)
 
 	addIfNonZero := func(tag string, val float64) {
 		if val != 0 {
@@ -116,6 +116,9 @@ func collectFeatures(b *suggest.ScoreBreakdown, includeAmplifiers bool) []featur
 	addIfNonZero(suggest.ReasonGlobalFrequency, b.GlobalFrequency)
 	addIfNonZero(suggest.ReasonDirFrequency, b.DirFrequency)
 	addIfNonZero(suggest.ReasonProjectTask, b.ProjectTask)
+	addIfNonZero(suggest.ReasonTimeOfDay, b.TimeOfDay)
+	addIfNonZero(suggest.ReasonDayOfWeek, b.DayOfWeek)
+	addIfNonZero(suggest.ReasonSuccess, b.Success)
 	addIfNonZero(suggest.ReasonDangerous, b.Dangerous)
 
 	// Amplifiers (gated by config).
@@ -163,6 +166,12 @@ func descriptionForTag(tag, prevCmd string) string {
 		return "Frequently used in this directory"
 	case suggest.ReasonProjectTask:
 		return "From project playbook"
+	case suggest.ReasonTimeOfDay:
+		return "Often used at this time of day"
+	case suggest.ReasonDayOfWeek:
+		return "Often used on this day of the week"
+	case suggest.ReasonSuccess:
+		return "Based on how often it succeeded before"
 	case suggest.ReasonDangerous:
 		return "Flagged as potentially destructive"
 	case suggest.ReasonWorkflowBoost:
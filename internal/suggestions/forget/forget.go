@@ -0,0 +1,535 @@
+// Package forget removes commands from the V2 suggestions database on
+// request, typically after a secret was typed on the command line.
+//
+// Commands are matched by a case-sensitive substring of their raw text, or
+// optionally by the whole text, archived events included. Hide soft-deletes
+// the matching events: they leave history and suggestions but keep counting
+// towards the learned stats. Purge deletes them outright, takes them back
+// out of the stats and usage tables, and removes every other row that still
+// holds the text.
+package forget
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/runger/clai/internal/suggestions/archive"
+	"github.com/runger/clai/internal/suggestions/ingest"
+	"github.com/runger/clai/internal/suggestions/maintenance"
+	"github.com/runger/clai/internal/suggestions/usage"
+)
+
+// ErrEmptyPattern is returned when the pattern is empty, which would match
+// every command.
+var ErrEmptyPattern = errors.New("pattern must not be empty")
+
+// Match describes the events a pattern matches.
+type Match struct {
+	Samples []string // Distinct raw commands, most recent first
+	Events  int64
+}
+
+// PurgeResult summarizes a Purge call.
+type PurgeResult struct {
+	Events    int64 // Command events deleted
+	Templates int64 // Templates whose normalized text matched, deleted with all their rows
+}
+
+// eventMatch returns the condition selecting the events whose raw command
+// contains the pattern argument, or equals it when exact is set.
+func eventMatch(exact bool) string {
+	if exact {
+		return "cmd_raw = ?"
+	}
+	return "instr(cmd_raw, ?) > 0"
+}
+
+// Find reports the events whose raw command contains pattern, or equals it
+// when exact is set, with up to limit distinct samples. Deleted events are
+// included only when includeDeleted is set.
+func Find(ctx context.Context, db *sql.DB, pattern string, exact, includeDeleted bool, limit int) (*Match, error) {
+	if pattern == "" {
+		return nil, ErrEmptyPattern
+	}
+	where := eventMatch(exact)
+	if !includeDeleted {
+		where += " AND deleted = 0"
+	}
+
+	m := &Match{}
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM command_event WHERE `+where, pattern).Scan(&m.Events); err != nil {
+		return nil, fmt.Errorf("count matching events: %w", err)
+	}
+	if limit > 0 {
+		rows, err := db.QueryContext(ctx, `
+			SELECT cmd_raw FROM command_event
+			WHERE `+where+`
+			GROUP BY cmd_raw
+			ORDER BY MAX(ts_ms) DESC
+			LIMIT ?
+		`, pattern, limit)
+		if err != nil {
+			return nil, fmt.Errorf("query matching events: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var cmd string
+			if err := rows.Scan(&cmd); err != nil {
+				return nil, fmt.Errorf("scan matching event: %w", err)
+			}
+			m.Samples = append(m.Samples, cmd)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("query matching events: %w", err)
+		}
+	}
+
+	archived, err := archive.Find(ctx, db, pattern, exact)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(m.Samples))
+	for _, s := range m.Samples {
+		seen[s] = true
+	}
+	for id, cmd := range archived {
+		if !includeDeleted {
+			var deleted bool
+			if err := db.QueryRowContext(ctx, `SELECT deleted FROM command_event WHERE id = ?`, id).Scan(&deleted); err != nil || deleted {
+				continue
+			}
+		}
+		m.Events++
+		if len(m.Samples) < limit && !seen[cmd] {
+			seen[cmd] = true
+			m.Samples = append(m.Samples, cmd)
+		}
+	}
+	return m, nil
+}
+
+// Hide soft-deletes the events whose raw command contains pattern, or
+// equals it when exact is set, and returns how many it deleted.
+func Hide(ctx context.Context, db *sql.DB, pattern string, exact bool) (int64, error) {
+	if pattern == "" {
+		return 0, ErrEmptyPattern
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // best-effort rollback on error
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE command_event SET deleted = 1
+		WHERE deleted = 0 AND `+eventMatch(exact), pattern)
+	if err != nil {
+		return 0, fmt.Errorf("delete events: %w", err)
+	}
+	n, _ := res.RowsAffected()
+
+	archived, err := archive.Find(ctx, tx, pattern, exact)
+	if err != nil {
+		return 0, err
+	}
+	for id := range archived {
+		res, err := tx.ExecContext(ctx, `UPDATE command_event SET deleted = 1 WHERE id = ? AND deleted = 0`, id)
+		if err != nil {
+			return 0, fmt.Errorf("delete archived event %d: %w", id, err)
+		}
+		affected, _ := res.RowsAffected()
+		n += affected
+	}
+
+	// Cached suggestions may offer the deleted commands.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM suggestion_cache`); err != nil {
+		return 0, fmt.Errorf("clear suggestion cache: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return n, nil
+}
+
+// purgedEvent is a command_event row being purged.
+type purgedEvent struct {
+	exitCode   sql.NullInt64
+	sessionID  string
+	cwd        string
+	repoKey    string
+	templateID string
+	host       string
+	id         int64
+	tsMs       int64
+	ephemeral  bool
+}
+
+func (e *purgedEvent) scopes() []string {
+	return ingest.EventScopes(e.repoKey, e.host, e.cwd)
+}
+
+const selectEvent = `
+	SELECT e.id, e.session_id, e.ts_ms, e.cwd, COALESCE(e.repo_key, ''), COALESCE(e.template_id, ''),
+	       e.exit_code, e.ephemeral, COALESCE(s.host, '')
+	FROM command_event e
+	LEFT JOIN session s ON s.id = e.session_id
+`
+
+// Purge permanently deletes the events whose raw command contains pattern,
+// or equals it when exact is set, soft-deleted ones included, and
+// everything derived from their text:
+//
+//   - each event is taken back out of command_stat, command_time_stat,
+//     transition_stat and the usage tables in every scope it was counted in;
+//   - archived copies are cut from their archive chunks, and search index
+//     entries go with the events;
+//   - slot values, slot correlations, feedback, pipeline and workflow
+//     patterns containing pattern are deleted;
+//   - templates whose normalized text contains pattern are deleted with
+//     every row referencing them. With exact set, only a template whose
+//     normalized text is pattern is, so events of other commands sharing a
+//     template survive.
+//
+// Deletes run with secure_delete, and the search index is merged and the WAL
+// checkpointed afterwards, so the text does not linger in free pages.
+func Purge(ctx context.Context, db *sql.DB, pattern string, exact bool) (PurgeResult, error) {
+	if pattern == "" {
+		return PurgeResult{}, ErrEmptyPattern
+	}
+	res, err := purge(ctx, db, pattern, exact)
+	if err != nil {
+		return PurgeResult{}, err
+	}
+	if res.Events > 0 || res.Templates > 0 {
+		if err := maintenance.OptimizeFTS(ctx, db); err != nil {
+			return res, err
+		}
+		_, _ = db.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`)
+	}
+	return res, nil
+}
+
+// purge runs the deletes of Purge in one transaction on a connection with
+// secure_delete enabled. The connection is released before returning, as
+// the writer database allows only one.
+func purge(ctx context.Context, db *sql.DB, pattern string, exact bool) (PurgeResult, error) {
+	var res PurgeResult
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return res, fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `PRAGMA secure_delete = ON`); err != nil {
+		return res, fmt.Errorf("enable secure delete: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `PRAGMA secure_delete = OFF`) //nolint:errcheck // best-effort reset of a pooled connection
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return res, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // best-effort rollback on error
+
+	events, err := matchingEvents(ctx, tx, pattern, exact)
+	if err != nil {
+		return res, err
+	}
+	purged := make(map[int64]bool, len(events))
+	for _, e := range events {
+		purged[e.id] = true
+	}
+	if err := subtractEvents(ctx, tx, events, purged); err != nil {
+		return res, err
+	}
+	for _, e := range events {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM pipeline_event WHERE command_event_id = ?`, e.id); err != nil {
+			return res, fmt.Errorf("delete pipeline events: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM command_event WHERE id = ?`, e.id); err != nil {
+			return res, fmt.Errorf("delete event %d: %w", e.id, err)
+		}
+	}
+	res.Events = int64(len(events))
+
+	if err := deleteDerivedText(ctx, tx, pattern); err != nil {
+		return res, err
+	}
+	if res.Templates, err = deleteTemplates(ctx, tx, pattern, exact); err != nil {
+		return res, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM suggestion_cache`); err != nil {
+		return res, fmt.Errorf("clear suggestion cache: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return res, fmt.Errorf("commit transaction: %w", err)
+	}
+	return res, nil
+}
+
+// matchingEvents loads the events to purge and cuts the archived ones out
+// of their chunks.
+func matchingEvents(ctx context.Context, tx *sql.Tx, pattern string, exact bool) ([]*purgedEvent, error) {
+	events, err := queryEvents(ctx, tx, selectEvent+` WHERE e.id IN (
+		SELECT id FROM command_event WHERE `+eventMatch(exact)+`) ORDER BY e.id`, pattern)
+	if err != nil {
+		return nil, err
+	}
+	archivedIDs, err := archive.Redact(ctx, tx, pattern, exact)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range archivedIDs {
+		archived, err := queryEvents(ctx, tx, selectEvent+` WHERE e.id = ?`, id)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, archived...)
+	}
+	return events, nil
+}
+
+func queryEvents(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]*purgedEvent, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*purgedEvent
+	for rows.Next() {
+		e := &purgedEvent{}
+		if err := rows.Scan(&e.id, &e.sessionID, &e.tsMs, &e.cwd, &e.repoKey, &e.templateID,
+			&e.exitCode, &e.ephemeral, &e.host); err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// subtractEvents takes events back out of the stats and usage tables. The
+// transition into an event is counted under the event's scopes, and the
+// transition out of it under those of the next event in the session, unless
+// that one is purged too and takes the transition with it.
+func subtractEvents(ctx context.Context, tx *sql.Tx, events []*purgedEvent, purged map[int64]bool) error {
+	var used []usage.Event
+	for _, e := range events {
+		if e.templateID == "" {
+			continue
+		}
+		success := !e.exitCode.Valid || e.exitCode.Int64 == 0
+		for _, scope := range e.scopes() {
+			if err := subtractCommandStat(ctx, tx, scope, e.templateID, success, e.tsMs); err != nil {
+				return err
+			}
+		}
+		weekday, hour := ingest.TimeBucket(e.tsMs)
+		for _, scope := range ingest.TimeStatScopes(e.repoKey, e.host) {
+			if err := subtractTimeStat(ctx, tx, scope, e.templateID, weekday, hour); err != nil {
+				return err
+			}
+		}
+
+		prev, err := neighbour(ctx, tx, e, `e.id < ? ORDER BY e.id DESC`)
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			for _, scope := range e.scopes() {
+				if err := subtractTransition(ctx, tx, scope, prev.templateID, e.templateID, e.tsMs); err != nil {
+					return err
+				}
+			}
+		}
+		next, err := neighbour(ctx, tx, e, `e.id > ? ORDER BY e.id`)
+		if err != nil {
+			return err
+		}
+		if next != nil && !purged[next.id] {
+			for _, scope := range next.scopes() {
+				if err := subtractTransition(ctx, tx, scope, e.templateID, next.templateID, next.tsMs); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !e.ephemeral {
+			ev := usage.Event{TSMs: e.tsMs, TemplateID: e.templateID, Cwd: e.cwd}
+			if e.exitCode.Valid {
+				code := int(e.exitCode.Int64)
+				ev.ExitCode = &code
+			} else if e.cwd == "/" {
+				// Imported shell history, which was not counted by directory.
+				ev.Cwd = ""
+			}
+			used = append(used, ev)
+		}
+	}
+	return usage.Subtract(ctx, tx, used...)
+}
+
+// neighbour returns the closest templated event before or after e in its
+// session, as selected by order, or nil if there is none.
+func neighbour(ctx context.Context, tx *sql.Tx, e *purgedEvent, order string) (*purgedEvent, error) {
+	events, err := queryEvents(ctx, tx, selectEvent+`
+		WHERE e.session_id = ? AND e.template_id IS NOT NULL AND e.template_id != '' AND `+order+` LIMIT 1`,
+		e.sessionID, e.id)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return events[0], nil
+}
+
+// contribution is what one use at tsMs still adds to a score decayed to
+// lastSeenMs.
+func contribution(lastSeenMs, tsMs int64) float64 {
+	age := lastSeenMs - tsMs
+	if age <= 0 {
+		return 1
+	}
+	return math.Exp(-float64(age) / ingest.DefaultTauMs)
+}
+
+func subtractCommandStat(ctx context.Context, tx *sql.Tx, scope, templateID string, success bool, tsMs int64) error {
+	var score float64
+	var successes, failures int
+	var lastSeenMs int64
+	err := tx.QueryRowContext(ctx, `
+		SELECT score, success_count, failure_count, last_seen_ms
+		FROM command_stat WHERE scope = ? AND template_id = ?
+	`, scope, templateID).Scan(&score, &successes, &failures, &lastSeenMs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read command_stat: %w", err)
+	}
+
+	switch {
+	case success && successes > 0:
+		successes--
+	case !success && failures > 0:
+		failures--
+	}
+	if successes+failures == 0 {
+		_, err = tx.ExecContext(ctx, `DELETE FROM command_stat WHERE scope = ? AND template_id = ?`, scope, templateID)
+	} else {
+		score = math.Max(score-contribution(lastSeenMs, tsMs), 0)
+		_, err = tx.ExecContext(ctx, `
+			UPDATE command_stat SET score = ?, success_count = ?, failure_count = ?
+			WHERE scope = ? AND template_id = ?
+		`, score, successes, failures, scope, templateID)
+	}
+	if err != nil {
+		return fmt.Errorf("update command_stat: %w", err)
+	}
+	return nil
+}
+
+func subtractTimeStat(ctx context.Context, tx *sql.Tx, scope, templateID string, weekday, hour int) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE command_time_stat SET count = count - 1
+		WHERE scope = ? AND template_id = ? AND weekday = ? AND hour = ?
+	`, scope, templateID, weekday, hour); err != nil {
+		return fmt.Errorf("update command_time_stat: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM command_time_stat
+		WHERE scope = ? AND template_id = ? AND weekday = ? AND hour = ? AND count <= 0
+	`, scope, templateID, weekday, hour); err != nil {
+		return fmt.Errorf("prune command_time_stat: %w", err)
+	}
+	return nil
+}
+
+func subtractTransition(ctx context.Context, tx *sql.Tx, scope, prevID, nextID string, tsMs int64) error {
+	var weight float64
+	var count int
+	var lastSeenMs int64
+	err := tx.QueryRowContext(ctx, `
+		SELECT weight, count, last_seen_ms FROM transition_stat
+		WHERE scope = ? AND prev_template_id = ? AND next_template_id = ?
+	`, scope, prevID, nextID).Scan(&weight, &count, &lastSeenMs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read transition_stat: %w", err)
+	}
+
+	if count <= 1 {
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM transition_stat
+			WHERE scope = ? AND prev_template_id = ? AND next_template_id = ?
+		`, scope, prevID, nextID)
+	} else {
+		weight = math.Max(weight-contribution(lastSeenMs, tsMs), 0)
+		_, err = tx.ExecContext(ctx, `
+			UPDATE transition_stat SET weight = ?, count = ?
+			WHERE scope = ? AND prev_template_id = ? AND next_template_id = ?
+		`, weight, count-1, scope, prevID, nextID)
+	}
+	if err != nil {
+		return fmt.Errorf("update transition_stat: %w", err)
+	}
+	return nil
+}
+
+// deleteDerivedText deletes the rows outside command_event and
+// command_template that store command text containing pattern.
+func deleteDerivedText(ctx context.Context, tx *sql.Tx, pattern string) error {
+	for _, q := range []string{
+		`DELETE FROM slot_stat WHERE instr(value, ?1) > 0`,
+		`DELETE FROM slot_correlation WHERE instr(tuple_value_json, ?1) > 0`,
+		`DELETE FROM pipeline_event WHERE instr(cmd_raw, ?1) > 0 OR instr(cmd_norm, ?1) > 0`,
+		`DELETE FROM pipeline_pattern WHERE instr(cmd_norm_display, ?1) > 0`,
+		`DELETE FROM suggestion_feedback WHERE instr(suggested_text, ?1) > 0 OR instr(COALESCE(executed_text, ''), ?1) > 0`,
+		// Steps go before the workflows they belong to.
+		`DELETE FROM workflow_step WHERE pattern_id IN (
+			SELECT pattern_id FROM workflow_pattern WHERE instr(display_chain, ?1) > 0)`,
+		`DELETE FROM workflow_pattern WHERE instr(display_chain, ?1) > 0`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, pattern); err != nil {
+			return fmt.Errorf("delete derived rows: %w", err)
+		}
+	}
+	return nil
+}
+
+// deleteTemplates deletes the templates whose normalized text contains
+// pattern, or equals it when exact is set, i.e. where normalization kept the
+// text rather than replacing it with a slot, and returns how many it
+// deleted.
+func deleteTemplates(ctx context.Context, tx *sql.Tx, pattern string, exact bool) (int64, error) {
+	where := "instr(cmd_norm, ?) > 0"
+	if exact {
+		where = "cmd_norm = ?"
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT template_id FROM command_template WHERE `+where, pattern)
+	if err != nil {
+		return 0, fmt.Errorf("query templates: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan template: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("query templates: %w", err)
+	}
+
+	for _, id := range ids {
+		if _, err := maintenance.DeleteTemplate(ctx, tx, id); err != nil {
+			return 0, fmt.Errorf("delete template %s: %w", id, err)
+		}
+	}
+	return int64(len(ids)), nil
+}
@@ -0,0 +1,212 @@
+package forget
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/runger/clai/internal/suggestions/archive"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/event"
+	"github.com/runger/clai/internal/suggestions/ingest"
+	"github.com/runger/clai/internal/suggestions/normalize"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	d, err := suggestdb.Open(context.Background(), suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "suggestions_v2.db"),
+		SkipLock: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { d.Close() })
+	t.Cleanup(func() { ingest.ReleaseStatements(d.DB()) })
+
+	_, err = d.DB().Exec(`INSERT INTO session (id, shell, started_at_ms) VALUES ('s1', 'zsh', 0)`)
+	require.NoError(t, err)
+	return d.DB()
+}
+
+// record runs cmds through the write path as one session and returns the
+// template ID of each.
+func record(t *testing.T, db *sql.DB, tsMs int64, cmds ...string) []string {
+	t.Helper()
+	normalizer := normalize.NewNormalizer()
+	var ids []string
+	prev := ""
+	for i, cmd := range cmds {
+		ev := &event.CommandEvent{
+			Version:   1,
+			Type:      event.EventTypeCommandEnd,
+			TS:        tsMs + int64(i)*1000,
+			SessionID: "s1",
+			Shell:     event.ShellZsh,
+			Cwd:       "/home/user/project",
+			CmdRaw:    cmd,
+			Host:      "laptop",
+		}
+		_, slots := normalizer.Normalize(cmd)
+		res, err := ingest.WritePath(context.Background(), db, &ingest.WritePathContext{
+			Event:          ev,
+			RepoKey:        "repo:project",
+			PrevTemplateID: prev,
+			PreNorm:        normalize.PreNormalize(cmd, normalize.PreNormConfig{}),
+			Slots:          slots,
+			NowMs:          ev.TS,
+		}, &ingest.WritePathConfig{})
+		require.NoError(t, err)
+		prev = res.TemplateID
+		ids = append(ids, res.TemplateID)
+	}
+	return ids
+}
+
+func count(t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+	var n int
+	require.NoError(t, db.QueryRow(query, args...).Scan(&n))
+	return n
+}
+
+func TestFindAndHide(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	record(t, db, 1000, "make build", "curl -H 'Authorization: hunter2' example.com", "make test", "echo hunter2")
+
+	m, err := Find(ctx, db, "hunter2", false, false, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), m.Events)
+	assert.Equal(t, []string{"echo hunter2", "curl -H 'Authorization: hunter2' example.com"}, m.Samples)
+
+	n, err := Hide(ctx, db, "hunter2", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	m, err = Find(ctx, db, "hunter2", false, false, 10)
+	require.NoError(t, err)
+	assert.Zero(t, m.Events)
+	m, err = Find(ctx, db, "hunter2", false, true, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), m.Events)
+	// Hidden events still count towards the stats.
+	assert.Equal(t, 4, count(t, db, `SELECT COUNT(*) FROM command_event`))
+}
+
+func TestFindAndHide_Exact(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	record(t, db, 1000, "sl", "sleep 1", "sl", "make test")
+	// The first "sl" goes to the archive; the second stays live.
+	_, err := archive.Archive(ctx, db, 2001, 0, 10000)
+	require.NoError(t, err)
+
+	m, err := Find(ctx, db, "sl", true, false, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), m.Events)
+	assert.Equal(t, []string{"sl"}, m.Samples)
+
+	n, err := Hide(ctx, db, "sl", true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	m, err = Find(ctx, db, "sl", false, false, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sleep 1"}, m.Samples, "commands containing the text are kept")
+}
+
+func TestPurge(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	ids := record(t, db, 1000, "make build", "export API_TOKEN=s3cr3t", "make test")
+	build, secret, test := ids[0], ids[1], ids[2]
+	// The first two events go to the archive; a later one stays live.
+	_, err := archive.Archive(ctx, db, 2500, 0, 10000)
+	require.NoError(t, err)
+	record(t, db, 5000, "echo s3cr3t", "make build")
+	_, err = Hide(ctx, db, "echo s3cr3t", false)
+	require.NoError(t, err)
+
+	res, err := Purge(ctx, db, "s3cr3t", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.Events)
+
+	m, err := Find(ctx, db, "s3cr3t", false, true, 10)
+	require.NoError(t, err)
+	assert.Zero(t, m.Events)
+	assert.Zero(t, count(t, db, `SELECT COUNT(*) FROM command_event_fts WHERE command_event_fts MATCH 's3cr3t'`))
+
+	// The secret's uses are gone from every scope, and so are the
+	// transitions into and out of it.
+	assert.Zero(t, count(t, db, `SELECT COUNT(*) FROM command_stat WHERE template_id = ?`, secret))
+	assert.Zero(t, count(t, db, `
+		SELECT COUNT(*) FROM transition_stat WHERE prev_template_id = ?1 OR next_template_id = ?1`, secret))
+	assert.Zero(t, count(t, db, `SELECT COUNT(*) FROM transition_stat WHERE prev_template_id = ? AND next_template_id = ?`, build, secret))
+	assert.Equal(t, 2, count(t, db, `SELECT success_count FROM command_stat WHERE scope = 'global' AND template_id = ?`, build))
+	assert.Equal(t, 1, count(t, db, `SELECT success_count FROM command_stat WHERE scope = 'host:laptop' AND template_id = ?`, test))
+	assert.Equal(t, 3, count(t, db, `SELECT SUM(count) FROM command_time_stat WHERE scope = 'global'`))
+	assert.Equal(t, 3, count(t, db, `SELECT SUM(commands) FROM usage_hourly`))
+	assert.Zero(t, count(t, db, `SELECT COUNT(*) FROM usage_daily_template WHERE template_id = ?`, secret))
+
+	// Purging again finds nothing.
+	res, err = Purge(ctx, db, "s3cr3t", false)
+	require.NoError(t, err)
+	assert.Zero(t, res.Events)
+}
+
+func TestPurge_Exact(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	record(t, db, 1000, "export TOKEN=s3cr3t", "export TOKEN=s3cr3t && make", "make test")
+	// The first event goes to the archive; the others stay live.
+	_, err := archive.Archive(ctx, db, 1500, 0, 10000)
+	require.NoError(t, err)
+	record(t, db, 5000, "export TOKEN=s3cr3t")
+
+	res, err := Purge(ctx, db, "export TOKEN=s3cr3t", true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.Events)
+
+	m, err := Find(ctx, db, "s3cr3t", false, true, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"export TOKEN=s3cr3t && make"}, m.Samples, "commands containing the text are kept")
+}
+
+func TestPurge_DeletesTemplatesKeepingText(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	ids := record(t, db, 1000, "make build", "vault login topsecret")
+
+	res, err := Purge(ctx, db, "topsecret", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Events)
+
+	var norm string
+	err = db.QueryRow(`SELECT cmd_norm FROM command_template WHERE template_id = ?`, ids[1]).Scan(&norm)
+	if err == nil {
+		assert.NotContains(t, norm, "topsecret")
+	} else {
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+	assert.Zero(t, count(t, db, `SELECT COUNT(*) FROM slot_stat WHERE instr(value, 'topsecret') > 0`))
+}
+
+func TestEmptyPattern(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	_, err := Find(ctx, db, "", false, false, 10)
+	assert.ErrorIs(t, err, ErrEmptyPattern)
+	_, err = Hide(ctx, db, "", false)
+	assert.ErrorIs(t, err, ErrEmptyPattern)
+	_, err = Purge(ctx, db, "", false)
+	assert.ErrorIs(t, err, ErrEmptyPattern)
+}
@@ -0,0 +1,172 @@
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// Statements the write path runs once per scope for every event.
+const (
+	selectCommandStatQuery = `
+		SELECT score, success_count, failure_count, last_seen_ms
+		FROM command_stat
+		WHERE scope = ? AND template_id = ?
+	`
+	upsertCommandStatQuery = `
+		INSERT INTO command_stat (scope, template_id, score, success_count, failure_count, last_seen_ms)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(scope, template_id) DO UPDATE SET
+			score = ?,
+			success_count = ?,
+			failure_count = ?,
+			last_seen_ms = ?
+	`
+	upsertCommandTimeStatQuery = `
+		INSERT INTO command_time_stat (scope, template_id, weekday, hour, count, last_seen_ms)
+		VALUES (?, ?, ?, ?, 1, ?)
+		ON CONFLICT(scope, template_id, weekday, hour) DO UPDATE SET
+			count = count + 1,
+			last_seen_ms = MAX(last_seen_ms, excluded.last_seen_ms)
+	`
+	selectTransitionStatQuery = `
+		SELECT weight, count, last_seen_ms
+		FROM transition_stat
+		WHERE scope = ? AND prev_template_id = ? AND next_template_id = ?
+	`
+	upsertTransitionStatQuery = `
+		INSERT INTO transition_stat (scope, prev_template_id, next_template_id, weight, count, last_seen_ms)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(scope, prev_template_id, next_template_id) DO UPDATE SET
+			weight = ?,
+			count = ?,
+			last_seen_ms = ?
+	`
+	selectSlotStatQuery = `
+		SELECT weight, count, last_seen_ms
+		FROM slot_stat
+		WHERE scope = ? AND template_id = ? AND slot_index = ? AND value = ?
+	`
+	upsertSlotStatQuery = `
+		INSERT INTO slot_stat (scope, template_id, slot_index, value, weight, count, last_seen_ms)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(scope, template_id, slot_index, value) DO UPDATE SET
+				weight = ?,
+				count = ?,
+				last_seen_ms = ?
+	`
+)
+
+// pooledQueries are prepared once per database by statementsFor.
+var pooledQueries = []string{
+	selectCommandStatQuery,
+	upsertCommandStatQuery,
+	upsertCommandTimeStatQuery,
+	selectTransitionStatQuery,
+	upsertTransitionStatQuery,
+	selectSlotStatQuery,
+	upsertSlotStatQuery,
+}
+
+// stmtPool holds the write path's prepared statements for one database.
+// Statements are bound to each transaction with Tx.StmtContext, which
+// reuses the handle already prepared on the transaction's connection, so
+// SQLite parses each hot upsert once per connection instead of once per
+// event and scope.
+type stmtPool struct {
+	stmts map[string]*sql.Stmt
+}
+
+var (
+	stmtPoolsMu sync.Mutex
+	stmtPools   = make(map[*sql.DB]*stmtPool)
+)
+
+// statementsFor returns the statement pool of db, preparing it on first
+// use. It must be called outside a transaction: the database allows a
+// single connection, which an open transaction holds. A pool that fails to
+// prepare is returned empty, and its queries run unprepared.
+func statementsFor(ctx context.Context, db *sql.DB) *stmtPool {
+	stmtPoolsMu.Lock()
+	defer stmtPoolsMu.Unlock()
+
+	if p := stmtPools[db]; p != nil {
+		return p
+	}
+	p := &stmtPool{stmts: make(map[string]*sql.Stmt, len(pooledQueries))}
+	for _, query := range pooledQueries {
+		stmt, err := db.PrepareContext(ctx, query)
+		if err != nil {
+			p.close()
+			return p
+		}
+		p.stmts[query] = stmt
+	}
+	stmtPools[db] = p
+	return p
+}
+
+// ReleaseStatements closes the statements WritePath prepared for db. Call
+// it before closing a database the write path has written to; a later
+// WritePath on the same db prepares them again.
+func ReleaseStatements(db *sql.DB) {
+	stmtPoolsMu.Lock()
+	p := stmtPools[db]
+	delete(stmtPools, db)
+	stmtPoolsMu.Unlock()
+	if p != nil {
+		p.close()
+	}
+}
+
+func (p *stmtPool) close() {
+	for query, stmt := range p.stmts {
+		stmt.Close()
+		delete(p.stmts, query)
+	}
+}
+
+// writeTx is a write-path transaction. Queries in the statement pool run
+// through their prepared statements; everything else uses the embedded
+// *sql.Tx directly.
+type writeTx struct {
+	*sql.Tx
+	pool  *stmtPool
+	bound map[string]*sql.Stmt // Pool statements bound to this transaction
+}
+
+func newWriteTx(tx *sql.Tx, pool *stmtPool) *writeTx {
+	return &writeTx{Tx: tx, pool: pool, bound: make(map[string]*sql.Stmt)}
+}
+
+// stmt returns the pooled statement for query bound to the transaction, or
+// nil if the pool does not hold query. Bound statements are closed when the
+// transaction ends.
+func (tx *writeTx) stmt(ctx context.Context, query string) *sql.Stmt {
+	if stmt, ok := tx.bound[query]; ok {
+		return stmt
+	}
+	stmt, ok := tx.pool.stmts[query]
+	if !ok {
+		return nil
+	}
+	bound := tx.StmtContext(ctx, stmt)
+	tx.bound[query] = bound
+	return bound
+}
+
+// execCached is ExecContext through the statement pool.
+func (tx *writeTx) execCached(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if stmt := tx.stmt(ctx, query); stmt != nil {
+		return stmt.ExecContext(ctx, args...)
+	}
+	return tx.ExecContext(ctx, query, args...)
+}
+
+// queryRowCached is QueryRowContext through the statement pool.
+func (tx *writeTx) queryRowCached(ctx context.Context, query string, args ...any) *sql.Row {
+	if stmt := tx.stmt(ctx, query); stmt != nil {
+		return stmt.QueryRowContext(ctx, args...)
+	}
+	return tx.QueryRowContext(ctx, query, args...)
+}
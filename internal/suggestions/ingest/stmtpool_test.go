@@ -0,0 +1,72 @@
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/runger/clai/internal/suggestions/event"
+)
+
+func TestWritePath_ReusesPreparedStatements(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	ctx := context.Background()
+
+	write := func(ts int64, prev string) *WritePathResult {
+		ev := makeEvent(func(e *event.CommandEvent) {
+			e.TS = ts
+			e.CmdRaw = "git checkout main"
+		})
+		wctx := makeWriteContext(ev, func(w *WritePathContext) {
+			w.RepoKey = "repo:abc"
+			w.PrevTemplateID = prev
+		})
+		result, err := WritePath(ctx, sqlDB, wctx, &WritePathConfig{})
+		require.NoError(t, err)
+		return result
+	}
+
+	first := write(1700000000000, "")
+	pool := statementsFor(ctx, sqlDB)
+	assert.Len(t, pool.stmts, len(pooledQueries))
+
+	write(1700000001000, first.TemplateID)
+	write(1700000002000, first.TemplateID)
+	assert.Same(t, pool, statementsFor(ctx, sqlDB), "repeat events reuse the pool")
+
+	var successes, transitions int
+	require.NoError(t, sqlDB.QueryRow(`
+		SELECT success_count FROM command_stat WHERE scope = ? AND template_id = ?
+	`, ScopeGlobal, first.TemplateID).Scan(&successes))
+	assert.Equal(t, 3, successes)
+	require.NoError(t, sqlDB.QueryRow(`
+		SELECT count FROM transition_stat WHERE scope = ? AND prev_template_id = ?
+	`, ScopeGlobal, first.TemplateID).Scan(&transitions))
+	assert.Equal(t, 2, transitions)
+
+	// Released statements are prepared again on the next write.
+	ReleaseStatements(sqlDB)
+	assert.Empty(t, pool.stmts)
+	write(1700000003000, first.TemplateID)
+	assert.NotSame(t, pool, statementsFor(ctx, sqlDB))
+}
+
+func TestStatementsFor_PrepareFailure(t *testing.T) {
+	t.Parallel()
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// Without the stat tables nothing prepares; queries then run unprepared.
+	pool := statementsFor(context.Background(), sqlDB)
+	assert.Empty(t, pool.stmts)
+
+	stmtPoolsMu.Lock()
+	_, cached := stmtPools[sqlDB]
+	stmtPoolsMu.Unlock()
+	assert.False(t, cached, "a failed pool is retried on the next write")
+}
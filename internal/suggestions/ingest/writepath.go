@@ -14,17 +14,27 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/runger/clai/internal/sanitize"
+	"github.com/runger/clai/internal/suggestions/dirscope"
 	"github.com/runger/clai/internal/suggestions/event"
 	"github.com/runger/clai/internal/suggestions/normalize"
+	"github.com/runger/clai/internal/suggestions/output"
 	"github.com/runger/clai/internal/suggestions/recovery"
+	"github.com/runger/clai/internal/suggestions/usage"
 )
 
 // ScopeGlobal is the global scope identifier for aggregate tables.
 const ScopeGlobal = "global"
 
+// HostScopePrefix prefixes per-host scopes in command_stat and
+// transition_stat, which keep machines that share a home directory (and so
+// a database) over NFS apart.
+const HostScopePrefix = "host:"
+
 // DefaultTauMs is the default decay time constant (7 days in milliseconds)
 // for decayed frequency scoring.
 const DefaultTauMs = 7 * 24 * 60 * 60 * 1000
@@ -44,6 +54,15 @@ type WritePathConfig struct {
 	SlotCorrelationKeys [][]int
 	TauMs               int64
 	PipelineMaxSegments int
+
+	// Redactor, when set, replaces secrets in the command before anything
+	// derived from it is written (see sanitize.NewCommandSanitizer).
+	Redactor *sanitize.Sanitizer
+
+	// DetectProjectTypes, when set, returns the project types of an
+	// event's working directory; they count toward its project type
+	// statistics along with ProjectTypes.
+	DetectProjectTypes func(cwd string) []string
 }
 
 // WritePathContext holds the enriched context for a single event ingestion.
@@ -54,10 +73,15 @@ type WritePathContext struct {
 	Branch         string
 	PrevTemplateID string
 	Slots          []normalize.SlotValue
+	Aliases        map[string]string
 	PreNorm        normalize.PreNormResult
 	PrevExitCode   int
 	NowMs          int64
 	PrevFailed     bool
+
+	// ProjectTypes are the project types the event counts toward, resolved
+	// by WritePath from its config before the transaction begins.
+	ProjectTypes []string
 }
 
 // WritePathResult holds the output of a successful write-path transaction.
@@ -73,29 +97,44 @@ type WritePathResult struct {
 // WritePath orchestrates all database writes for a single ingested event within
 // a single BEGIN IMMEDIATE transaction. All steps succeed or fail atomically.
 //
+// When cfg.Redactor is set, secrets in the command are redacted first, so
+// cmd_raw, cmd_norm and slot values never hold them.
+//
 // Transaction steps (in order):
-//  1. Insert command_event row
+//  1. Insert command_event row (and record the session's host and, when
+//     captured, the command's output)
 //  2. Upsert command_template
-//  3. Update command_stat (frequency + success/failure counts)
-//  4. Update transition_stat (if previous template known)
+//  3. Update command_stat (frequency + success/failure counts) and the
+//     command_time_stat hour/weekday buckets for the global, repo and
+//     host:<name> scopes
+//  4. Update transition_stat (if previous template known), same scopes
 //  5. Update slot_stat values (from normalized placeholders)
 //  6. Update slot_correlation for configured tuples
 //  7. Update project_type_stat/project_type_transition (when project types active)
 //  8. Update directory-scoped aggregates (scope=dir:<hash>)
 //  9. Update pipeline_event/pipeline_transition/pipeline_pattern (for compound commands)
 //  10. Update failure_recovery (when previous command failed)
-//  11. Invalidate cache index (after commit)
+//  11. Update usage_hourly/usage_daily_template/usage_daily_dir (not for ephemeral events)
+//  12. Invalidate cache index (after commit)
+//
+// The stat upserts run through statements prepared once per db; call
+// ReleaseStatements before closing it.
 func WritePath(ctx context.Context, db *sql.DB, wctx *WritePathContext, cfg *WritePathConfig) (*WritePathResult, error) {
 	if err := validateWritePathInputs(db, wctx); err != nil {
 		return nil, err
 	}
 	tauMs := resolveTauMs(cfg.TauMs)
+	redactCommand(wctx, cfg.Redactor)
+	wctx.ProjectTypes = cfg.projectTypes(wctx.Event.Cwd)
 
 	result := &WritePathResult{
 		TemplateID: wctx.PreNorm.TemplateID,
 		CmdNorm:    wctx.PreNorm.CmdNorm,
 	}
 
+	// Prepare the pooled statements first; the transaction holds the connection.
+	stmts := statementsFor(ctx, db)
+
 	// Use BEGIN IMMEDIATE to avoid SQLITE_BUSY on concurrent reads.
 	// The standard database/sql BeginTx doesn't support IMMEDIATE directly,
 	// so we issue the BEGIN IMMEDIATE manually and wrap in a helper.
@@ -105,7 +144,7 @@ func WritePath(ctx context.Context, db *sql.DB, wctx *WritePathContext, cfg *Wri
 	}
 	defer tx.Rollback() //nolint:errcheck // best-effort rollback after commit
 
-	_, err = executeWritePathSteps(ctx, tx, wctx, cfg, tauMs, result)
+	_, err = executeWritePathSteps(ctx, newWriteTx(tx, stmts), wctx, cfg, tauMs, result)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +154,7 @@ func WritePath(ctx context.Context, db *sql.DB, wctx *WritePathContext, cfg *Wri
 		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
 
-	// Step 11: Invalidate cache (after commit, non-transactional)
+	// Step 12: Invalidate cache (after commit, non-transactional)
 	if cfg.Cache != nil {
 		cfg.Cache.Invalidate(wctx.Event.SessionID)
 	}
@@ -142,7 +181,7 @@ func resolveTauMs(tauMs int64) int64 {
 
 func executeWritePathSteps(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx *writeTx,
 	wctx *WritePathContext,
 	cfg *WritePathConfig,
 	tauMs int64,
@@ -153,6 +192,19 @@ func executeWritePathSteps(
 		return 0, fmt.Errorf("step 1 (command_event): %w", err)
 	}
 	result.EventID = eventID
+	if err := recordSessionHost(ctx, tx, wctx); err != nil {
+		return 0, fmt.Errorf("step 1 (session host): %w", err)
+	}
+	if err := output.Store(ctx, tx, eventID, output.Output{
+		Stdout:    wctx.Event.Stdout,
+		Stderr:    wctx.Event.Stderr,
+		Truncated: wctx.Event.OutputTruncated,
+	}); err != nil {
+		return 0, fmt.Errorf("step 1 (command_output): %w", err)
+	}
+	if err := storeCommandContext(ctx, tx, eventID, wctx.Event); err != nil {
+		return 0, fmt.Errorf("step 1 (command_context): %w", err)
+	}
 	if err := upsertCommandTemplate(ctx, tx, wctx); err != nil {
 		return 0, fmt.Errorf("step 2 (command_template): %w", err)
 	}
@@ -162,12 +214,15 @@ func executeWritePathSteps(
 	if err := runOptionalWritePathSteps(ctx, tx, wctx, cfg, tauMs, eventID, result); err != nil {
 		return 0, err
 	}
+	if err := updateUsage(ctx, tx, wctx); err != nil {
+		return 0, fmt.Errorf("step 11 (usage): %w", err)
+	}
 	return eventID, nil
 }
 
 func runOptionalWritePathSteps(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx *writeTx,
 	wctx *WritePathContext,
 	cfg *WritePathConfig,
 	tauMs int64,
@@ -188,8 +243,8 @@ func runOptionalWritePathSteps(
 			return fmt.Errorf("step 6 (slot_correlation): %w", err)
 		}
 	}
-	if len(cfg.ProjectTypes) > 0 {
-		if err := updateProjectTypeStats(ctx, tx, wctx, cfg.ProjectTypes, tauMs); err != nil {
+	if len(wctx.ProjectTypes) > 0 {
+		if err := updateProjectTypeStats(ctx, tx, wctx, wctx.ProjectTypes, tauMs); err != nil {
 			return fmt.Errorf("step 7 (project_type_stat): %w", err)
 		}
 	}
@@ -204,7 +259,7 @@ func runOptionalWritePathSteps(
 
 func runPipelineAndRecoverySteps(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx *writeTx,
 	wctx *WritePathContext,
 	cfg *WritePathConfig,
 	eventID int64,
@@ -254,14 +309,14 @@ func beginImmediate(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
 }
 
 // Step 1: Insert command_event row
-func insertCommandEvent(ctx context.Context, tx *sql.Tx, wctx *WritePathContext) (int64, error) {
+func insertCommandEvent(ctx context.Context, tx *writeTx, wctx *WritePathContext) (int64, error) {
 	var durationMs *int64
 	if wctx.Event.DurationMs != nil {
 		durationMs = wctx.Event.DurationMs
 	}
 
 	truncated := 0
-	if wctx.PreNorm.Truncated {
+	if wctx.PreNorm.Truncated || wctx.Event.Truncated {
 		truncated = 1
 	}
 
@@ -297,8 +352,46 @@ func insertCommandEvent(ctx context.Context, tx *sql.Tx, wctx *WritePathContext)
 	return result.LastInsertId()
 }
 
+// storeCommandContext records the directory change and env context of an
+// event. Events without context get no row.
+func storeCommandContext(ctx context.Context, tx *writeTx, eventID int64, ev *event.CommandEvent) error {
+	env := event.FilterEnvContext(ev.Env)
+	if ev.PrevCwd == "" && env == nil {
+		return nil
+	}
+	var envJSON string
+	if env != nil {
+		data, err := json.Marshal(env)
+		if err != nil {
+			return err
+		}
+		envJSON = string(data)
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO command_context (event_id, prev_cwd, env)
+		VALUES (?, ?, ?)
+	`, eventID, nullableString(ev.PrevCwd), nullableString(envJSON))
+	return err
+}
+
+// recordSessionHost stores the host an event's session runs on, so history
+// can be filtered by host. Sessions are created on their first event from a
+// known host; an existing session keeps the host it was first seen with.
+func recordSessionHost(ctx context.Context, tx *writeTx, wctx *WritePathContext) error {
+	if wctx.Event.Host == "" {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO session (id, shell, started_at_ms, host)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET host = excluded.host
+		WHERE session.host IS NULL OR session.host = ''
+	`, wctx.Event.SessionID, string(wctx.Event.Shell), wctx.NowMs, wctx.Event.Host)
+	return err
+}
+
 // Step 2: Upsert command_template
-func upsertCommandTemplate(ctx context.Context, tx *sql.Tx, wctx *WritePathContext) error {
+func upsertCommandTemplate(ctx context.Context, tx *writeTx, wctx *WritePathContext) error {
 	tagsJSON := "null"
 	if len(wctx.PreNorm.Tags) > 0 {
 		b, err := json.Marshal(wctx.PreNorm.Tags)
@@ -326,34 +419,40 @@ func upsertCommandTemplate(ctx context.Context, tx *sql.Tx, wctx *WritePathConte
 	return err
 }
 
-// Step 3: Update command_stat (frequency + success/failure counts)
-func updateCommandStat(ctx context.Context, tx *sql.Tx, wctx *WritePathContext, tauMs int64) error {
-	scopes := []string{ScopeGlobal}
-	if wctx.RepoKey != "" {
-		scopes = append(scopes, wctx.RepoKey)
-	}
+// Step 3: Update command_stat (frequency + success/failure counts) and
+// command_time_stat
+func updateCommandStat(ctx context.Context, tx *writeTx, wctx *WritePathContext, tauMs int64) error {
+	scopes := statScopes(wctx)
 
 	isSuccess := wctx.Event.ExitCode == 0
+	weekday, hour := TimeBucket(wctx.NowMs)
 
 	for _, scope := range scopes {
 		if err := upsertCommandStatInTx(ctx, tx, scope, wctx.PreNorm.TemplateID, isSuccess, wctx.NowMs, tauMs); err != nil {
 			return err
 		}
+		if _, err := tx.execCached(ctx, upsertCommandTimeStatQuery,
+			scope, wctx.PreNorm.TemplateID, weekday, hour, wctx.NowMs); err != nil {
+			return fmt.Errorf("command_time_stat: %w", err)
+		}
 	}
 	return nil
 }
 
-func upsertCommandStatInTx(ctx context.Context, tx *sql.Tx, scope, templateID string, isSuccess bool, nowMs, tauMs int64) error {
+// TimeBucket returns the local weekday (0 = Sunday) and hour of tsMs, the
+// command_time_stat bucket a command run at tsMs is counted in.
+func TimeBucket(tsMs int64) (weekday, hour int) {
+	t := time.UnixMilli(tsMs).Local()
+	return int(t.Weekday()), t.Hour()
+}
+
+func upsertCommandStatInTx(ctx context.Context, tx *writeTx, scope, templateID string, isSuccess bool, nowMs, tauMs int64) error {
 	// Read existing score
 	var currentScore float64
 	var lastSeenMs int64
 	var successCount, failureCount int
 
-	err := tx.QueryRowContext(ctx, `
-		SELECT score, success_count, failure_count, last_seen_ms
-		FROM command_stat
-		WHERE scope = ? AND template_id = ?
-	`, scope, templateID).Scan(&currentScore, &successCount, &failureCount, &lastSeenMs)
+	err := tx.queryRowCached(ctx, selectCommandStatQuery, scope, templateID).Scan(&currentScore, &successCount, &failureCount, &lastSeenMs)
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return err
@@ -377,15 +476,7 @@ func upsertCommandStatInTx(ctx context.Context, tx *sql.Tx, scope, templateID st
 		failureCount++
 	}
 
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO command_stat (scope, template_id, score, success_count, failure_count, last_seen_ms)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(scope, template_id) DO UPDATE SET
-			score = ?,
-			success_count = ?,
-			failure_count = ?,
-			last_seen_ms = ?
-	`,
+	_, err = tx.execCached(ctx, upsertCommandStatQuery,
 		scope, templateID, newScore, successCount, failureCount, nowMs,
 		newScore, successCount, failureCount, nowMs,
 	)
@@ -393,11 +484,8 @@ func upsertCommandStatInTx(ctx context.Context, tx *sql.Tx, scope, templateID st
 }
 
 // Step 4: Update transition_stat
-func updateTransitionStat(ctx context.Context, tx *sql.Tx, wctx *WritePathContext, tauMs int64) error {
-	scopes := []string{ScopeGlobal}
-	if wctx.RepoKey != "" {
-		scopes = append(scopes, wctx.RepoKey)
-	}
+func updateTransitionStat(ctx context.Context, tx *writeTx, wctx *WritePathContext, tauMs int64) error {
+	scopes := statScopes(wctx)
 
 	for _, scope := range scopes {
 		if err := upsertTransitionStatInTx(ctx, tx, scope, wctx.PrevTemplateID, wctx.PreNorm.TemplateID, wctx.NowMs, tauMs); err != nil {
@@ -407,24 +495,13 @@ func updateTransitionStat(ctx context.Context, tx *sql.Tx, wctx *WritePathContex
 	return nil
 }
 
-func upsertTransitionStatInTx(ctx context.Context, tx *sql.Tx, scope, prevTemplateID, nextTemplateID string, nowMs, tauMs int64) error {
-	newWeight, newCount, err := loadDecayedTransitionWeightAndCount(ctx, tx, `
-		SELECT weight, count, last_seen_ms
-		FROM transition_stat
-		WHERE scope = ? AND prev_template_id = ? AND next_template_id = ?
-	`, []any{scope, prevTemplateID, nextTemplateID}, nowMs, tauMs)
+func upsertTransitionStatInTx(ctx context.Context, tx *writeTx, scope, prevTemplateID, nextTemplateID string, nowMs, tauMs int64) error {
+	newWeight, newCount, err := loadDecayedTransitionWeightAndCount(ctx, tx, selectTransitionStatQuery, []any{scope, prevTemplateID, nextTemplateID}, nowMs, tauMs)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO transition_stat (scope, prev_template_id, next_template_id, weight, count, last_seen_ms)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(scope, prev_template_id, next_template_id) DO UPDATE SET
-			weight = ?,
-			count = ?,
-			last_seen_ms = ?
-	`,
+	_, err = tx.execCached(ctx, upsertTransitionStatQuery,
 		scope, prevTemplateID, nextTemplateID, newWeight, newCount, nowMs,
 		newWeight, newCount, nowMs,
 	)
@@ -432,7 +509,7 @@ func upsertTransitionStatInTx(ctx context.Context, tx *sql.Tx, scope, prevTempla
 }
 
 // Step 5: Update slot_stat values
-func updateSlotStats(ctx context.Context, tx *sql.Tx, wctx *WritePathContext, tauMs int64) error {
+func updateSlotStats(ctx context.Context, tx *writeTx, wctx *WritePathContext, tauMs int64) error {
 	if len(wctx.Slots) == 0 {
 		return nil
 	}
@@ -468,16 +545,12 @@ type slotStatUpsertInput struct {
 	tauMs      int64
 }
 
-func upsertSlotStatInTx(ctx context.Context, tx *sql.Tx, input slotStatUpsertInput) error {
+func upsertSlotStatInTx(ctx context.Context, tx *writeTx, input slotStatUpsertInput) error {
 	var currentWeight float64
 	var currentCount int
 	var lastSeenMs int64
 
-	err := tx.QueryRowContext(ctx, `
-		SELECT weight, count, last_seen_ms
-		FROM slot_stat
-		WHERE scope = ? AND template_id = ? AND slot_index = ? AND value = ?
-	`, input.scope, input.templateID, input.slotIndex, input.value).Scan(&currentWeight, &currentCount, &lastSeenMs)
+	err := tx.queryRowCached(ctx, selectSlotStatQuery, input.scope, input.templateID, input.slotIndex, input.value).Scan(&currentWeight, &currentCount, &lastSeenMs)
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return err
@@ -495,14 +568,7 @@ func upsertSlotStatInTx(ctx context.Context, tx *sql.Tx, input slotStatUpsertInp
 		newCount = currentCount + 1
 	}
 
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO slot_stat (scope, template_id, slot_index, value, weight, count, last_seen_ms)
-			VALUES (?, ?, ?, ?, ?, ?, ?)
-			ON CONFLICT(scope, template_id, slot_index, value) DO UPDATE SET
-				weight = ?,
-				count = ?,
-				last_seen_ms = ?
-	`,
+	_, err = tx.execCached(ctx, upsertSlotStatQuery,
 		input.scope, input.templateID, input.slotIndex, input.value, newWeight, newCount, input.nowMs,
 		newWeight, newCount, input.nowMs,
 	)
@@ -510,7 +576,7 @@ func upsertSlotStatInTx(ctx context.Context, tx *sql.Tx, input slotStatUpsertInp
 }
 
 // Step 6: Update slot_correlation for configured tuples
-func updateSlotCorrelations(ctx context.Context, tx *sql.Tx, wctx *WritePathContext, correlationKeys [][]int) error {
+func updateSlotCorrelations(ctx context.Context, tx *writeTx, wctx *WritePathContext, correlationKeys [][]int) error {
 	if len(wctx.Slots) < 2 {
 		return nil
 	}
@@ -548,6 +614,45 @@ func writePathScopes(repoKey string) []string {
 	return scopes
 }
 
+// statScopes returns the scopes command_stat and transition_stat are
+// updated in: global, the repo, and the host the event ran on.
+func statScopes(wctx *WritePathContext) []string {
+	return TimeStatScopes(wctx.RepoKey, wctx.Event.Host)
+}
+
+// TimeStatScopes returns the scopes command_time_stat is updated in for an
+// event: global, the repo and the host. Unlike command_stat it has no
+// directory scope.
+func TimeStatScopes(repoKey, host string) []string {
+	scopes := writePathScopes(repoKey)
+	if scope := HostScope(host); scope != "" {
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// SlotScopes returns the scopes slot_stat is updated in: global and the
+// repo.
+func SlotScopes(repoKey string) []string {
+	return writePathScopes(repoKey)
+}
+
+// EventScopes returns every scope the write path updates command_stat and
+// transition_stat in for an event: global, the repo, the host and the
+// directory. Code that takes an event back out of the stats must cover all
+// of them.
+func EventScopes(repoKey, host, cwd string) []string {
+	return append(TimeStatScopes(repoKey, host), computeDirScope(cwd))
+}
+
+// HostScope returns the aggregate scope for host, or "" if host is empty.
+func HostScope(host string) string {
+	if host == "" {
+		return ""
+	}
+	return HostScopePrefix + host
+}
+
 func buildCorrelationTuple(indices []int, slotMap map[int]string) (slotKey, tupleHash, tupleValueJSON string, ok bool, err error) {
 	if len(indices) < 2 {
 		return "", "", "", false, nil
@@ -571,7 +676,7 @@ func buildCorrelationTuple(indices []int, slotMap map[int]string) (slotKey, tupl
 
 func upsertCorrelationTuple(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx *writeTx,
 	scopes []string,
 	wctx *WritePathContext,
 	slotKey string,
@@ -596,8 +701,22 @@ func upsertCorrelationTuple(
 	return nil
 }
 
+// projectTypes returns the project types an event in cwd counts toward.
+func (cfg *WritePathConfig) projectTypes(cwd string) []string {
+	if cfg.DetectProjectTypes == nil || cwd == "" {
+		return cfg.ProjectTypes
+	}
+	types := slices.Clone(cfg.ProjectTypes)
+	for _, pt := range cfg.DetectProjectTypes(cwd) {
+		if !slices.Contains(types, pt) {
+			types = append(types, pt)
+		}
+	}
+	return types
+}
+
 // Step 7: Update project_type_stat and project_type_transition
-func updateProjectTypeStats(ctx context.Context, tx *sql.Tx, wctx *WritePathContext, projectTypes []string, tauMs int64) error {
+func updateProjectTypeStats(ctx context.Context, tx *writeTx, wctx *WritePathContext, projectTypes []string, tauMs int64) error {
 	for _, pt := range projectTypes {
 		// Update project_type_stat
 		if err := upsertProjectTypeStatInTx(ctx, tx, pt, wctx.PreNorm.TemplateID, wctx.NowMs, tauMs); err != nil {
@@ -614,7 +733,7 @@ func updateProjectTypeStats(ctx context.Context, tx *sql.Tx, wctx *WritePathCont
 	return nil
 }
 
-func upsertProjectTypeStatInTx(ctx context.Context, tx *sql.Tx, projectType, templateID string, nowMs, tauMs int64) error {
+func upsertProjectTypeStatInTx(ctx context.Context, tx *writeTx, projectType, templateID string, nowMs, tauMs int64) error {
 	var currentScore float64
 	var currentCount int
 	var lastSeenMs int64
@@ -655,7 +774,7 @@ func upsertProjectTypeStatInTx(ctx context.Context, tx *sql.Tx, projectType, tem
 	return err
 }
 
-func upsertProjectTypeTransitionInTx(ctx context.Context, tx *sql.Tx, projectType, prevTemplateID, nextTemplateID string, nowMs, tauMs int64) error {
+func upsertProjectTypeTransitionInTx(ctx context.Context, tx *writeTx, projectType, prevTemplateID, nextTemplateID string, nowMs, tauMs int64) error {
 	newWeight, newCount, err := loadDecayedTransitionWeightAndCount(ctx, tx, `
 		SELECT weight, count, last_seen_ms
 		FROM project_type_transition
@@ -681,7 +800,7 @@ func upsertProjectTypeTransitionInTx(ctx context.Context, tx *sql.Tx, projectTyp
 
 func loadDecayedTransitionWeightAndCount(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx *writeTx,
 	query string,
 	args []any,
 	nowMs, tauMs int64,
@@ -690,7 +809,7 @@ func loadDecayedTransitionWeightAndCount(
 	var currentCount int
 	var lastSeenMs int64
 
-	err = tx.QueryRowContext(ctx, query, args...).Scan(&currentWeight, &currentCount, &lastSeenMs)
+	err = tx.queryRowCached(ctx, query, args...).Scan(&currentWeight, &currentCount, &lastSeenMs)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return 0, 0, err
 	}
@@ -707,7 +826,7 @@ func loadDecayedTransitionWeightAndCount(
 }
 
 // Step 8: Update directory-scoped aggregates
-func updateDirectoryScopedAggregates(ctx context.Context, tx *sql.Tx, wctx *WritePathContext, tauMs int64) error {
+func updateDirectoryScopedAggregates(ctx context.Context, tx *writeTx, wctx *WritePathContext, tauMs int64) error {
 	dirScope := computeDirScope(wctx.Event.Cwd)
 
 	// Update command_stat for dir scope
@@ -727,7 +846,7 @@ func updateDirectoryScopedAggregates(ctx context.Context, tx *sql.Tx, wctx *Writ
 }
 
 // Step 9: Update pipeline tables (pipeline_event, pipeline_transition, pipeline_pattern)
-func updatePipelineTables(ctx context.Context, tx *sql.Tx, wctx *WritePathContext, eventID int64, maxSegments int) (int, error) {
+func updatePipelineTables(ctx context.Context, tx *writeTx, wctx *WritePathContext, eventID int64, maxSegments int) (int, error) {
 	segments := trimPipelineSegments(wctx.PreNorm.Segments, maxSegments)
 	if len(segments) <= 1 {
 		return 0, nil
@@ -778,7 +897,7 @@ func buildWritePathPipelineInfos(normalizer *normalize.Normalizer, segments []no
 	return infos
 }
 
-func insertWritePathPipelineEvents(ctx context.Context, tx *sql.Tx, eventID int64, segInfos []writePathPipelineInfo) error {
+func insertWritePathPipelineEvents(ctx context.Context, tx *writeTx, eventID int64, segInfos []writePathPipelineInfo) error {
 	for i, si := range segInfos {
 		_, err := tx.ExecContext(ctx, `
 			INSERT INTO pipeline_event (command_event_id, position, operator, cmd_raw, cmd_norm, template_id)
@@ -795,7 +914,7 @@ func insertWritePathPipelineEvents(ctx context.Context, tx *sql.Tx, eventID int6
 
 func insertWritePathPipelineTransitions(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx *writeTx,
 	nowMs int64,
 	scopes []string,
 	segInfos []writePathPipelineInfo,
@@ -826,7 +945,7 @@ func insertWritePathPipelineTransitions(
 
 func upsertWritePathPipelinePatterns(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx *writeTx,
 	wctx *WritePathContext,
 	scopes []string,
 	segments []normalize.Segment,
@@ -857,7 +976,7 @@ func upsertWritePathPipelinePatterns(
 }
 
 // Step 10: Update failure_recovery
-func updateFailureRecovery(ctx context.Context, tx *sql.Tx, wctx *WritePathContext) error {
+func updateFailureRecovery(ctx context.Context, tx *writeTx, wctx *WritePathContext) error {
 	exitCodeClass := classifyExitCode(wctx.PrevExitCode)
 	scopes := writePathScopes(wctx.RepoKey)
 	isRecoverySuccess := wctx.Event.ExitCode == 0
@@ -883,7 +1002,7 @@ type failureRecoveryState struct {
 
 func readFailureRecoveryState(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx *writeTx,
 	scope string,
 	wctx *WritePathContext,
 	exitCodeClass string,
@@ -926,7 +1045,7 @@ func computeFailureRecoveryState(current failureRecoveryState, success bool) fai
 
 func upsertFailureRecoveryState(
 	ctx context.Context,
-	tx *sql.Tx,
+	tx *writeTx,
 	scope string,
 	wctx *WritePathContext,
 	exitCodeClass string,
@@ -948,6 +1067,23 @@ func upsertFailureRecoveryState(
 	return err
 }
 
+// Step 11: Update usage analytics
+
+// updateUsage counts the event in the daily usage tables behind 'clai stats'.
+// Ephemeral events are not part of history and are left out.
+func updateUsage(ctx context.Context, tx *writeTx, wctx *WritePathContext) error {
+	if wctx.Event.Ephemeral {
+		return nil
+	}
+	exitCode := wctx.Event.ExitCode
+	return usage.Record(ctx, tx, usage.Event{
+		TSMs:       wctx.NowMs,
+		TemplateID: wctx.PreNorm.TemplateID,
+		Cwd:        wctx.Event.Cwd,
+		ExitCode:   &exitCode,
+	})
+}
+
 // Helper functions
 
 // nullableString returns a sql.NullString for the given value.
@@ -962,8 +1098,7 @@ func nullableString(s string) sql.NullString {
 // computeDirScope returns a directory-scoped scope key.
 // Format: "dir:<sha256_hex_prefix>"
 func computeDirScope(cwd string) string {
-	h := sha256.Sum256([]byte(cwd))
-	return fmt.Sprintf("dir:%x", h[:8])
+	return dirscope.PathKey(cwd)
 }
 
 // computeHash returns a truncated SHA-256 hex hash of the input.
@@ -1002,6 +1137,31 @@ func classifyExitCode(exitCode int) string {
 	return classifier.ClassifyToKey(exitCode)
 }
 
+// redactCommand replaces secrets in the event's command and, when any were
+// found, normalizes the redacted command again.
+func redactCommand(wctx *WritePathContext, redactor *sanitize.Sanitizer) {
+	if redactor == nil {
+		return
+	}
+	redacted := redactor.Sanitize(wctx.Event.CmdRaw)
+	if redacted == wctx.Event.CmdRaw {
+		return
+	}
+	wctx.Event.CmdRaw = redacted
+	wctx.PreNorm, wctx.Slots = normalizeCommand(redacted, wctx.Aliases)
+}
+
+// normalizeCommand runs the pre-normalization pipeline and slot extraction
+// on cmdRaw.
+func normalizeCommand(cmdRaw string, aliases map[string]string) (normalize.PreNormResult, []normalize.SlotValue) {
+	preNorm := normalize.PreNormalize(cmdRaw, normalize.PreNormConfig{
+		Aliases: aliases,
+	})
+	normalizer := normalize.NewNormalizer()
+	_, slots := normalizer.Normalize(cmdRaw)
+	return preNorm, slots
+}
+
 // PrepareWriteContext creates a WritePathContext from a validated event.
 // This performs normalization and context enrichment before the transaction.
 func PrepareWriteContext(
@@ -1011,14 +1171,7 @@ func PrepareWriteContext(
 	prevFailed bool,
 	aliases map[string]string,
 ) *WritePathContext {
-	// Run pre-normalization pipeline
-	preNorm := normalize.PreNormalize(ev.CmdRaw, normalize.PreNormConfig{
-		Aliases: aliases,
-	})
-
-	// Run normalizer for slot extraction
-	normalizer := normalize.NewNormalizer()
-	_, slots := normalizer.Normalize(ev.CmdRaw)
+	preNorm, slots := normalizeCommand(ev.CmdRaw, aliases)
 
 	nowMs := ev.TS
 	if nowMs == 0 {
@@ -1029,6 +1182,7 @@ func PrepareWriteContext(
 		Event:          ev,
 		PreNorm:        preNorm,
 		Slots:          slots,
+		Aliases:        aliases,
 		RepoKey:        repoKey,
 		Branch:         branch,
 		PrevTemplateID: prevTemplateID,
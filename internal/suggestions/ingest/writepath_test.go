@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/runger/clai/internal/sanitize"
 	"github.com/runger/clai/internal/suggestions/db"
 	"github.com/runger/clai/internal/suggestions/event"
 	"github.com/runger/clai/internal/suggestions/normalize"
@@ -52,6 +53,7 @@ func newTestDB(t *testing.T) *sql.DB {
 	})
 	require.NoError(t, err)
 	t.Cleanup(func() { d.Close() })
+	t.Cleanup(func() { ReleaseStatements(d.DB()) })
 
 	// Ensure session exists for tests
 	_, err = d.DB().ExecContext(context.Background(), `
@@ -167,6 +169,34 @@ func TestWritePath_CommandEventInserted(t *testing.T) {
 	assert.Equal(t, 0, exitCode)
 }
 
+func TestWritePath_CommandContextStored(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	ctx := context.Background()
+
+	ev := makeEvent()
+	ev.PrevCwd = "/home/user"
+	ev.Env = map[string]string{"VIRTUAL_ENV": ".venv", "HOME": "/home/user"}
+	result, err := WritePath(ctx, sqlDB, makeWriteContext(ev), &WritePathConfig{})
+	require.NoError(t, err)
+
+	var prevCwd, env string
+	err = sqlDB.QueryRowContext(ctx, `
+		SELECT prev_cwd, env FROM command_context WHERE event_id = ?
+	`, result.EventID).Scan(&prevCwd, &env)
+	require.NoError(t, err)
+	assert.Equal(t, "/home/user", prevCwd)
+	assert.JSONEq(t, `{"VIRTUAL_ENV":".venv"}`, env)
+
+	// Events without context get no row.
+	result, err = WritePath(ctx, sqlDB, makeWriteContext(makeEvent()), &WritePathConfig{})
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, sqlDB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM command_context WHERE event_id = ?`, result.EventID).Scan(&n))
+	assert.Equal(t, 0, n)
+}
+
 func TestWritePath_CommandTemplateUpserted(t *testing.T) {
 	t.Parallel()
 	sqlDB := newTestDB(t)
@@ -279,6 +309,44 @@ func TestWritePath_CommandStatFailure(t *testing.T) {
 	assert.Equal(t, 1, failureCount)
 }
 
+func TestWritePath_CommandTimeStatUpdated(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	ctx := context.Background()
+
+	var result *WritePathResult
+	// Two runs in the same hour, one a day later.
+	for _, ts := range []int64{1700000000000, 1700000060000, 1700000000000 + 24*3600*1000} {
+		ev := makeEvent(func(e *event.CommandEvent) { e.TS = ts })
+		var err error
+		result, err = WritePath(ctx, sqlDB, makeWriteContext(ev, func(w *WritePathContext) {
+			w.RepoKey = "repo-abc123"
+		}), &WritePathConfig{})
+		require.NoError(t, err)
+	}
+
+	weekday, hour := TimeBucket(1700000000000)
+	var count int
+	var lastSeenMs int64
+	err := sqlDB.QueryRowContext(ctx, `
+		SELECT count, last_seen_ms FROM command_time_stat
+		WHERE scope = 'global' AND template_id = ? AND weekday = ? AND hour = ?
+	`, result.TemplateID, weekday, hour).Scan(&count, &lastSeenMs)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, int64(1700000060000), lastSeenMs)
+
+	var buckets, repoCount int
+	require.NoError(t, sqlDB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM command_time_stat WHERE scope = 'global'
+	`).Scan(&buckets))
+	assert.Equal(t, 2, buckets)
+	require.NoError(t, sqlDB.QueryRowContext(ctx, `
+		SELECT SUM(count) FROM command_time_stat WHERE scope = 'repo-abc123'
+	`).Scan(&repoCount))
+	assert.Equal(t, 3, repoCount)
+}
+
 func TestWritePath_CommandStatRepoScope(t *testing.T) {
 	t.Parallel()
 	sqlDB := newTestDB(t)
@@ -397,6 +465,71 @@ func TestWritePath_TransitionStatRecorded(t *testing.T) {
 	assert.Equal(t, 1, count)
 }
 
+func TestWritePath_HostScope(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	ctx := context.Background()
+
+	prevID := "prev-template-id-host"
+	ev := makeEvent(func(e *event.CommandEvent) {
+		e.Host = "build-box"
+	})
+	wctx := makeWriteContext(ev, func(w *WritePathContext) {
+		w.PrevTemplateID = prevID
+	})
+
+	result, err := WritePath(ctx, sqlDB, wctx, &WritePathConfig{})
+	require.NoError(t, err)
+
+	var freq float64
+	err = sqlDB.QueryRowContext(ctx, `
+		SELECT score FROM command_stat WHERE scope = 'host:build-box' AND template_id = ?
+	`, result.TemplateID).Scan(&freq)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, freq)
+
+	var count int
+	err = sqlDB.QueryRowContext(ctx, `
+		SELECT count FROM transition_stat
+		WHERE scope = 'host:build-box' AND prev_template_id = ? AND next_template_id = ?
+	`, prevID, result.TemplateID).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	var host string
+	err = sqlDB.QueryRowContext(ctx, `SELECT host FROM session WHERE id = ?`, ev.SessionID).Scan(&host)
+	require.NoError(t, err)
+	assert.Equal(t, "build-box", host)
+}
+
+func TestWritePath_UsageRecorded(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	ctx := context.Background()
+
+	result, err := WritePath(ctx, sqlDB, makeWriteContext(makeEvent(func(e *event.CommandEvent) {
+		e.ExitCode = 1
+	})), &WritePathConfig{})
+	require.NoError(t, err)
+	_, err = WritePath(ctx, sqlDB, makeWriteContext(makeEvent(func(e *event.CommandEvent) {
+		e.Ephemeral = true
+	})), &WritePathConfig{})
+	require.NoError(t, err)
+
+	var commands, failures int
+	err = sqlDB.QueryRowContext(ctx, `SELECT SUM(commands), SUM(failures) FROM usage_hourly`).Scan(&commands, &failures)
+	require.NoError(t, err)
+	assert.Equal(t, 1, commands, "ephemeral events are not counted")
+	assert.Equal(t, 1, failures)
+
+	var count int
+	err = sqlDB.QueryRowContext(ctx, `
+		SELECT count FROM usage_daily_template WHERE template_id = ?
+	`, result.TemplateID).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
 func TestWritePath_TransitionStatRepoScope(t *testing.T) {
 	t.Parallel()
 	sqlDB := newTestDB(t)
@@ -424,6 +557,39 @@ func TestWritePath_TransitionStatRepoScope(t *testing.T) {
 
 // --- Slot Tests ---
 
+func TestWritePath_RedactsSecrets(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	ctx := context.Background()
+
+	redactor, err := sanitize.NewCommandSanitizer(nil)
+	require.NoError(t, err)
+	ev := makeEvent(func(e *event.CommandEvent) {
+		e.CmdRaw = "mysql --password hunter2 -e x7Gq2LmP9vRt4WzK8nB3cY6h"
+	})
+	wctx := makeWriteContext(ev)
+
+	result, err := WritePath(ctx, sqlDB, wctx, &WritePathConfig{Redactor: redactor})
+	require.NoError(t, err)
+
+	var cmdRaw, cmdNorm string
+	err = sqlDB.QueryRowContext(ctx, `
+		SELECT cmd_raw, cmd_norm FROM command_event WHERE id = ?
+	`, result.EventID).Scan(&cmdRaw, &cmdNorm)
+	require.NoError(t, err)
+	assert.Equal(t, "mysql --password [REDACTED] -e "+sanitize.HighEntropyReplacement, cmdRaw)
+	assert.Equal(t, cmdNorm, result.CmdNorm)
+
+	var leaked int
+	err = sqlDB.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM command_event WHERE cmd_norm LIKE '%hunter2%')
+		     + (SELECT COUNT(*) FROM command_template WHERE cmd_norm LIKE '%hunter2%')
+		     + (SELECT COUNT(*) FROM slot_stat WHERE value LIKE '%hunter2%' OR value LIKE '%x7Gq2%')
+	`).Scan(&leaked)
+	require.NoError(t, err)
+	assert.Zero(t, leaked, "secrets must not reach derived columns")
+}
+
 func TestWritePath_SlotStatsUpdated(t *testing.T) {
 	t.Parallel()
 	sqlDB := newTestDB(t)
@@ -601,6 +767,42 @@ func TestWritePath_ProjectTypeTransitionUpdated(t *testing.T) {
 	assert.Equal(t, 1.0, weight)
 }
 
+func TestWritePath_DetectedProjectTypes(t *testing.T) {
+	t.Parallel()
+	sqlDB := newTestDB(t)
+	ctx := context.Background()
+
+	ev := makeEvent(func(e *event.CommandEvent) {
+		e.Cwd = "/home/user/infra"
+	})
+	wctx := makeWriteContext(ev)
+
+	var detectedIn string
+	cfg := WritePathConfig{
+		ProjectTypes: []string{"go"},
+		DetectProjectTypes: func(cwd string) []string {
+			detectedIn = cwd
+			return []string{"terraform", "go"}
+		},
+	}
+
+	_, err := WritePath(ctx, sqlDB, wctx, &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "/home/user/infra", detectedIn)
+
+	var types []string
+	rows, err := sqlDB.QueryContext(ctx, `SELECT project_type FROM project_type_stat ORDER BY project_type`)
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var pt string
+		require.NoError(t, rows.Scan(&pt))
+		types = append(types, pt)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"go", "terraform"}, types)
+}
+
 func TestWritePath_NoProjectTypesSkipsStep(t *testing.T) {
 	t.Parallel()
 	sqlDB := newTestDB(t)
@@ -758,3 +758,108 @@ func TestNoOpUpdate(t *testing.T) {
 			w.Transition, initial.Transition)
 	}
 }
+
+// -----------------------------------------------------------------------
+// Hierarchical profile resolution
+// -----------------------------------------------------------------------
+
+func TestResolveNoProfiles(t *testing.T) {
+	store := NewStore(setupTestDB(t))
+	base := DefaultWeights()
+
+	res, err := store.Resolve(context.Background(), &base, ScopeChain{RepoKey: "clai", ProjectTypes: []string{"go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Weights != base {
+		t.Errorf("Weights = %+v, want base %+v", res.Weights, base)
+	}
+	if len(res.Levels) != 0 {
+		t.Errorf("Levels = %+v, want none", res.Levels)
+	}
+}
+
+func TestResolveBlendsLevels(t *testing.T) {
+	store := NewStore(setupTestDB(t))
+	ctx := context.Background()
+	base := Weights{Transition: 0.30, RiskPenalty: 0.20}
+
+	// global: fully trusted; go and node: half and a quarter trusted;
+	// repo: half trusted.
+	for _, p := range []struct {
+		scope      string
+		transition float64
+		samples    int64
+	}{
+		{"global", 0.40, FullTrustSamples * 2},
+		{"project_type:go", 0.10, FullTrustSamples / 2},
+		{"project_type:node", 0.40, FullTrustSamples / 4},
+		{"repo:clai", 0.50, FullTrustSamples / 2},
+		{"repo:other", 0.00, FullTrustSamples},
+	} {
+		w := Weights{Transition: p.transition, RiskPenalty: 0.20}
+		if err := store.SaveWeights(ctx, p.scope, &w, p.samples, 0.01); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := store.Resolve(ctx, &base, ScopeChain{RepoKey: "clai", ProjectTypes: []string{"go", "node", "rust"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// global replaces base: 0.40. Project types average to
+	// (0.10*0.5 + 0.40*0.25) / 0.75 = 0.20 at trust 0.5: 0.30.
+	// repo at trust 0.5: 0.40.
+	if math.Abs(res.Weights.Transition-0.40) > epsilon {
+		t.Errorf("Transition = %v, want 0.40", res.Weights.Transition)
+	}
+	if math.Abs(res.Weights.RiskPenalty-0.20) > epsilon {
+		t.Errorf("RiskPenalty = %v, want 0.20", res.Weights.RiskPenalty)
+	}
+
+	var scopes []string
+	for _, l := range res.Levels {
+		scopes = append(scopes, l.Scope)
+	}
+	want := []string{"global", "project_type:go", "project_type:node", "repo:clai"}
+	if len(scopes) != len(want) {
+		t.Fatalf("Levels = %v, want %v", scopes, want)
+	}
+	for i := range want {
+		if scopes[i] != want[i] {
+			t.Errorf("Levels[%d] = %s, want %s", i, scopes[i], want[i])
+		}
+	}
+	if res.Levels[0].Trust != 1 || res.Levels[3].Trust != 0.5 {
+		t.Errorf("Trust = %v, %v, want 1, 0.5", res.Levels[0].Trust, res.Levels[3].Trust)
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  ScopeChain
+	}{
+		{"", ScopeChain{}},
+		{"global", ScopeChain{}},
+		{"repo:clai", ScopeChain{RepoKey: "clai"}},
+		{"project_type:go", ScopeChain{ProjectTypes: []string{"go"}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseScope(tt.scope)
+		if err != nil {
+			t.Errorf("ParseScope(%q) error = %v", tt.scope, err)
+			continue
+		}
+		if got.RepoKey != tt.want.RepoKey || len(got.ProjectTypes) != len(tt.want.ProjectTypes) {
+			t.Errorf("ParseScope(%q) = %+v, want %+v", tt.scope, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"repo:", "session:abc", "clai"} {
+		if _, err := ParseScope(bad); err == nil {
+			t.Errorf("ParseScope(%q) returned no error", bad)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Profile scopes. Profiles are learned per repository, per project type
+// and globally; a suggestion is ranked with all of them that apply.
+const (
+	ScopeGlobal            = "global"
+	RepoScopePrefix        = "repo:"
+	ProjectTypeScopePrefix = "project_type:"
+)
+
+// FullTrustSamples is the sample count at which a profile fully replaces
+// the weights of the levels below it. A profile with fewer samples is
+// blended in proportionally, so a new repository starts from the global
+// weights instead of from a handful of noisy updates.
+const FullTrustSamples = 200
+
+// ScopeChain names the profiles that apply to a suggestion.
+type ScopeChain struct {
+	RepoKey      string   // Repository key; empty outside a repository
+	ProjectTypes []string // Detected project types, e.g. "go" or "node"
+}
+
+// ParseScope parses a profile scope ("global", "repo:<key>" or
+// "project_type:<type>") into the chain resolved for it.
+func ParseScope(scope string) (ScopeChain, error) {
+	if scope == "" || scope == ScopeGlobal {
+		return ScopeChain{}, nil
+	}
+	if key, ok := strings.CutPrefix(scope, RepoScopePrefix); ok && strings.TrimSpace(key) != "" {
+		return ScopeChain{RepoKey: key}, nil
+	}
+	if pt, ok := strings.CutPrefix(scope, ProjectTypeScopePrefix); ok && strings.TrimSpace(pt) != "" {
+		return ScopeChain{ProjectTypes: []string{pt}}, nil
+	}
+	return ScopeChain{}, fmt.Errorf("invalid scope %q: expected %s, %s<key> or %s<type>",
+		scope, ScopeGlobal, RepoScopePrefix, ProjectTypeScopePrefix)
+}
+
+// ResolvedLevel is a stored profile that took part in a resolution.
+type ResolvedLevel struct {
+	Scope       string  `json:"scope"`
+	Weights     Weights `json:"weights"`
+	SampleCount int64   `json:"sample_count"`
+	// Trust is the share of the blended weights the profile took over
+	// from the levels below it, in [0, 1].
+	Trust float64 `json:"trust"`
+}
+
+// Resolution is the result of resolving a ScopeChain.
+type Resolution struct {
+	// Weights are the blended weights to rank with.
+	Weights Weights
+	// Levels are the stored profiles found, from least to most specific:
+	// global, project types, repository. Empty when none exists.
+	Levels []ResolvedLevel
+}
+
+// Resolve blends the stored profiles of chain over base, from least to most
+// specific: the global profile, then the project type profiles, then the
+// repository's. Each level moves the weights towards its profile by its
+// trust, min(1, sample_count / FullTrustSamples); the profiles of several
+// project types are averaged by trust first. Levels without a stored
+// profile are skipped, so with none base is returned unchanged.
+func (s *Store) Resolve(ctx context.Context, base *Weights, chain ScopeChain) (*Resolution, error) {
+	res := &Resolution{Weights: *base}
+
+	global, err := s.loadLevel(ctx, ScopeGlobal)
+	if err != nil {
+		return nil, err
+	}
+	if global != nil {
+		res.Weights = blend(&res.Weights, &global.Weights, global.Trust)
+		res.Levels = append(res.Levels, *global)
+	}
+
+	var types []ResolvedLevel
+	for _, pt := range chain.ProjectTypes {
+		level, err := s.loadLevel(ctx, ProjectTypeScopePrefix+pt)
+		if err != nil {
+			return nil, err
+		}
+		if level != nil {
+			types = append(types, *level)
+		}
+	}
+	if len(types) > 0 {
+		combined, trust := averageLevels(types)
+		res.Weights = blend(&res.Weights, &combined, trust)
+		res.Levels = append(res.Levels, types...)
+	}
+
+	if chain.RepoKey != "" {
+		repo, err := s.loadLevel(ctx, RepoScopePrefix+chain.RepoKey)
+		if err != nil {
+			return nil, err
+		}
+		if repo != nil {
+			res.Weights = blend(&res.Weights, &repo.Weights, repo.Trust)
+			res.Levels = append(res.Levels, *repo)
+		}
+	}
+	return res, nil
+}
+
+// loadLevel loads the profile of scope, or returns nil if it has none.
+func (s *Store) loadLevel(ctx context.Context, scope string) (*ResolvedLevel, error) {
+	p, err := s.LoadWeights(ctx, scope)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	return &ResolvedLevel{
+		Scope:       scope,
+		Weights:     p.Weights,
+		SampleCount: p.SampleCount,
+		Trust:       trust(p.SampleCount),
+	}, nil
+}
+
+// trust returns how far a profile with sampleCount samples overrides the
+// levels below it.
+func trust(sampleCount int64) float64 {
+	if sampleCount <= 0 {
+		return 0
+	}
+	return clamp(float64(sampleCount)/FullTrustSamples, 0, 1)
+}
+
+// averageLevels returns the trust-weighted average of the levels' weights
+// and the highest trust among them.
+func averageLevels(levels []ResolvedLevel) (Weights, float64) {
+	var sum Weights
+	var totalTrust, maxTrust float64
+	for i := range levels {
+		l := &levels[i]
+		addScaled(&sum, &l.Weights, l.Trust)
+		totalTrust += l.Trust
+		maxTrust = max(maxTrust, l.Trust)
+	}
+	if totalTrust == 0 {
+		return sum, 0
+	}
+	scaleWeights(&sum, 1/totalTrust)
+	return sum, maxTrust
+}
+
+// blend returns a moved towards b by t: a*(1-t) + b*t.
+func blend(a, b *Weights, t float64) Weights {
+	out := *a
+	scaleWeights(&out, 1-t)
+	addScaled(&out, b, t)
+	return out
+}
+
+// addScaled adds b*k to w.
+func addScaled(w, b *Weights, k float64) {
+	src := b.fields()
+	for i, f := range w.fields() {
+		*f += *src[i] * k
+	}
+}
+
+// scaleWeights multiplies every weight of w by k.
+func scaleWeights(w *Weights, k float64) {
+	for _, f := range w.fields() {
+		*f *= k
+	}
+}
+
+// fields returns pointers to every weight of w, in declaration order.
+func (w *Weights) fields() []*float64 {
+	return []*float64{
+		&w.Transition, &w.Frequency, &w.Success, &w.Prefix, &w.Affinity,
+		&w.Task, &w.Feedback, &w.ProjectTypeAffinity, &w.FailureRecovery,
+		&w.RiskPenalty,
+	}
+}
@@ -14,16 +14,16 @@ import (
 // Weights represents the 10-feature weight vector used by the ranking model.
 // Field names correspond to the rank_weight_profile table columns.
 type Weights struct {
-	Transition          float64
-	Frequency           float64
-	Success             float64
-	Prefix              float64
-	Affinity            float64
-	Task                float64
-	Feedback            float64
-	ProjectTypeAffinity float64
-	FailureRecovery     float64
-	RiskPenalty         float64
+	Transition          float64 `json:"transition"`
+	Frequency           float64 `json:"frequency"`
+	Success             float64 `json:"success"`
+	Prefix              float64 `json:"prefix"`
+	Affinity            float64 `json:"affinity"`
+	Task                float64 `json:"task"`
+	Feedback            float64 `json:"feedback"`
+	ProjectTypeAffinity float64 `json:"project_type_affinity"`
+	FailureRecovery     float64 `json:"failure_recovery"`
+	RiskPenalty         float64 `json:"risk_penalty"`
 }
 
 // DefaultWeights returns the spec-default initial weights per Section 7.1.
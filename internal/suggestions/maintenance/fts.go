@@ -0,0 +1,148 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// DefaultFTSMaxSegments is the number of b-tree segments in
+// command_event_fts above which maintenance runs an FTS5 'optimize'.
+const DefaultFTSMaxSegments = 16
+
+// fts5StructureRowID is the row of command_event_fts_data that holds the
+// index structure record (FTS5_STRUCTURE_ROWID).
+const fts5StructureRowID = 10
+
+// fts5StructureV2 marks a structure record in the newer format, which
+// stores an extra field after the cookie.
+var fts5StructureV2 = []byte{0xFF, 0x00, 0x00, 0x01}
+
+// FTSStatus describes the full-text index over command history.
+type FTSStatus struct {
+	Indexed    int64 // Events in command_event_fts
+	Searchable int64 // Events that should be: not ephemeral and not archived
+	Segments   int   // b-tree segments; 'optimize' merges them into one
+}
+
+// Drifted reports whether the index no longer matches command_event, which
+// happens when a crash interrupts the triggers that keep them in sync.
+func (s FTSStatus) Drifted() bool {
+	return s.Indexed != s.Searchable
+}
+
+// CheckFTS reports how many events command_event_fts indexes, how many it
+// should index, and how fragmented it is. It is cheap enough to run on
+// every maintenance tick: the counts come from the docsize shadow table and
+// the segment count from the index structure record.
+func CheckFTS(ctx context.Context, db *sql.DB) (FTSStatus, error) {
+	var s FTSStatus
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM command_event_fts_docsize`).Scan(&s.Indexed); err != nil {
+		return s, fmt.Errorf("count indexed events: %w", err)
+	}
+	if err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM command_event
+		WHERE ephemeral = 0 AND archive_chunk_id IS NULL
+	`).Scan(&s.Searchable); err != nil {
+		return s, fmt.Errorf("count searchable events: %w", err)
+	}
+
+	var structure []byte
+	err := db.QueryRowContext(ctx, `SELECT block FROM command_event_fts_data WHERE id = ?`, fts5StructureRowID).Scan(&structure)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// A new index has no structure record until the first write.
+	case err != nil:
+		return s, fmt.Errorf("read index structure: %w", err)
+	default:
+		s.Segments, err = structureSegments(structure)
+		if err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// structureSegments decodes the total segment count from an FTS5 structure
+// record: a 4-byte cookie, an optional V2 marker, then varints for the
+// number of levels and the number of segments.
+func structureSegments(rec []byte) (int, error) {
+	if len(rec) < 4 {
+		return 0, errors.New("index structure record is truncated")
+	}
+	i := 4
+	if len(rec) >= 8 && string(rec[4:8]) == string(fts5StructureV2) {
+		i = 8
+	}
+	_, n := sqliteVarint(rec[i:])
+	if n == 0 {
+		return 0, errors.New("index structure record is truncated")
+	}
+	segments, m := sqliteVarint(rec[i+n:])
+	if m == 0 {
+		return 0, errors.New("index structure record is truncated")
+	}
+	return int(segments), nil //nolint:gosec // G115: segment counts are small
+}
+
+// sqliteVarint decodes one SQLite varint (big-endian, seven bits per byte,
+// the ninth byte contributing all eight) and returns it with the number of
+// bytes read, or 0 bytes if b is too short.
+func sqliteVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 9; i++ {
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7F)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// OptimizeFTS merges the segments of command_event_fts into one.
+func OptimizeFTS(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `INSERT INTO command_event_fts(command_event_fts) VALUES('optimize')`); err != nil {
+		return fmt.Errorf("optimize full-text index: %w", err)
+	}
+	return nil
+}
+
+// RebuildFTS rebuilds command_event_fts from command_event and optimizes it,
+// returning the number of events indexed. FTS5's own 'rebuild' would index
+// every row of the content table, including ephemeral and archived events,
+// so the index is cleared and refilled with the rows the triggers would
+// have indexed.
+func RebuildFTS(ctx context.Context, db *sql.DB) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // best-effort rollback on error
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO command_event_fts(command_event_fts) VALUES('delete-all')`); err != nil {
+		return 0, fmt.Errorf("clear full-text index: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO command_event_fts(rowid, cmd_raw, cmd_norm, repo_key, session_id)
+		SELECT id, cmd_raw, cmd_norm, repo_key, session_id FROM command_event
+		WHERE ephemeral = 0 AND archive_chunk_id IS NULL
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("fill full-text index: %w", err)
+	}
+	indexed, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("fill full-text index: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO command_event_fts(command_event_fts) VALUES('optimize')`); err != nil {
+		return 0, fmt.Errorf("optimize full-text index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return indexed, nil
+}
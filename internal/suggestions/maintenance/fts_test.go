@@ -0,0 +1,129 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCheckFTS_EmptyIndex(t *testing.T) {
+	db := openTestDB(t)
+
+	status, err := CheckFTS(context.Background(), db)
+	if err != nil {
+		t.Fatalf("CheckFTS() error = %v", err)
+	}
+	if status != (FTSStatus{}) || status.Drifted() {
+		t.Errorf("CheckFTS() = %+v, want zero status", status)
+	}
+}
+
+func TestCheckFTS_SegmentsAndOptimize(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		insertEvent(t, db, time.Now().UnixMilli(), fmt.Sprintf("git commit -m change-%d", i), nil)
+	}
+
+	status, err := CheckFTS(ctx, db)
+	if err != nil {
+		t.Fatalf("CheckFTS() error = %v", err)
+	}
+	if status.Indexed != 10 || status.Searchable != 10 || status.Drifted() {
+		t.Errorf("CheckFTS() = %+v, want 10 indexed and searchable", status)
+	}
+	if status.Segments < 2 {
+		t.Errorf("Segments = %d, want one per insert before merging", status.Segments)
+	}
+
+	if err := OptimizeFTS(ctx, db); err != nil {
+		t.Fatalf("OptimizeFTS() error = %v", err)
+	}
+	status, err = CheckFTS(ctx, db)
+	if err != nil {
+		t.Fatalf("CheckFTS() error = %v", err)
+	}
+	if status.Segments != 1 {
+		t.Errorf("Segments after optimize = %d, want 1", status.Segments)
+	}
+}
+
+func TestRebuildFTS_RepairsDrift(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	insertEvent(t, db, 1000, "make build", nil)
+
+	// Simulate events written while the sync trigger did not run.
+	if _, err := db.Exec(`DROP TRIGGER command_event_ai`); err != nil {
+		t.Fatal(err)
+	}
+	insertEvent(t, db, 2000, "docker compose up", nil)
+	if _, err := db.Exec(`
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, ephemeral)
+		VALUES ('incognito', 3000, '/tmp', 'secret-command', 'secret-command', 1)
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := CheckFTS(ctx, db)
+	if err != nil {
+		t.Fatalf("CheckFTS() error = %v", err)
+	}
+	if !status.Drifted() || status.Indexed != 1 || status.Searchable != 2 {
+		t.Fatalf("CheckFTS() = %+v, want drift with 1 of 2 indexed", status)
+	}
+
+	r := NewRunner(db, Config{})
+	r.ftsMaintain(ctx)
+	if stats := r.GetStats(); stats.FTSRebuilds != 1 {
+		t.Errorf("FTSRebuilds: got %d, want 1", stats.FTSRebuilds)
+	}
+
+	var matches int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM command_event_fts WHERE command_event_fts MATCH 'compose'`).Scan(&matches); err != nil {
+		t.Fatal(err)
+	}
+	if matches != 1 {
+		t.Errorf("search for rebuilt event: %d matches, want 1", matches)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM command_event_fts WHERE command_event_fts MATCH 'secret'`).Scan(&matches); err != nil {
+		t.Fatal(err)
+	}
+	if matches != 0 {
+		t.Errorf("ephemeral event was indexed by the rebuild")
+	}
+}
+
+func TestFTSMaintain_OptimizesFragmentedIndex(t *testing.T) {
+	db := openTestDB(t)
+	for i := 0; i < 3; i++ {
+		insertEvent(t, db, time.Now().UnixMilli(), fmt.Sprintf("ls dir-%d", i), nil)
+	}
+
+	r := NewRunner(db, Config{FTSMaxSegments: 1})
+	r.ftsMaintain(context.Background())
+	stats := r.GetStats()
+	if stats.FTSOptimizations != 1 || stats.FTSRebuilds != 0 {
+		t.Errorf("FTS optimizations/rebuilds: got %d/%d, want 1/0", stats.FTSOptimizations, stats.FTSRebuilds)
+	}
+}
+
+func TestSQLiteVarint(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want uint64
+		n    int
+	}{
+		{[]byte{0x05}, 5, 1},
+		{[]byte{0x81, 0x00}, 128, 2},
+		{[]byte{0x81, 0x80, 0x01}, 16385, 3},
+		{[]byte{0x80}, 0, 0},
+	}
+	for _, tt := range tests {
+		got, n := sqliteVarint(tt.in)
+		if got != tt.want || n != tt.n {
+			t.Errorf("sqliteVarint(%x) = %d, %d; want %d, %d", tt.in, got, n, tt.want, tt.n)
+		}
+	}
+}
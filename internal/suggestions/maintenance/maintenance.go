@@ -1,6 +1,8 @@
 // Package maintenance implements background database maintenance tasks for the
 // suggestions engine. It runs as a goroutine inside the daemon, performing
-// WAL checkpointing, retention pruning, FTS optimization, and VACUUM.
+// WAL checkpointing, retention pruning, archival of old command payloads,
+// full-text index repair and optimization, storage quota enforcement, and
+// VACUUM.
 //
 // Per spec Section 4.3: ticker-based maintenance goroutine.
 package maintenance
@@ -10,9 +12,13 @@ import (
 	"database/sql"
 	"log/slog"
 	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/runger/clai/internal/suggestions/archive"
+	"github.com/runger/clai/internal/suggestions/clock"
 )
 
 // Default configuration values.
@@ -42,15 +48,54 @@ const (
 // Config configures the maintenance runner.
 type Config struct {
 	Logger               *slog.Logger
+	Clock                clock.Clock // Time source for pruning cutoffs and stats; defaults to the system clock
 	DBPath               string
 	Interval             time.Duration
 	RetentionDays        int
+	RetentionRules       []RetentionRule // Per-scope overrides of RetentionDays; the first matching rule wins
+	ArchiveAfterDays     int             // Archive cmd_raw of events older than this; 0 disables archival
+	ArchiveChunkSize     int
 	PruneBatchSize       int
 	PruneYieldDuration   time.Duration
 	LowActivityThreshold int
+	FTSMaxSegments       int   // Optimize the full-text index when it has more segments than this
+	MaxDBSizeBytes       int64 // Evict templates when the database holds more than this; 0 disables the quota
+	QuotaEvictBatch      int   // Templates evicted per batch while over MaxDBSizeBytes
 	VacuumGrowthRatio    float64
 }
 
+// RetentionRule overrides RetentionDays for the command events it matches.
+// An event matches when it meets every criterion that is set; a rule with no
+// criteria matches nothing.
+type RetentionRule struct {
+	Repo      string // repo_key (git root) of the event
+	Ephemeral bool   // Only events from ephemeral (incognito) sessions
+	Failed    bool   // Only events with a non-zero exit code
+	Days      int    // Keep matching events this many days; 0 keeps them forever
+}
+
+// where returns the SQL condition selecting the events r matches, and its
+// arguments. NULL columns are coalesced so the condition is never NULL and
+// can be negated safely.
+func (r RetentionRule) where() (string, []any) {
+	var conds []string
+	var args []any
+	if r.Repo != "" {
+		conds = append(conds, "COALESCE(repo_key, '') = ?")
+		args = append(args, r.Repo)
+	}
+	if r.Ephemeral {
+		conds = append(conds, "ephemeral = 1")
+	}
+	if r.Failed {
+		conds = append(conds, "COALESCE(exit_code, 0) <> 0")
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "(" + strings.Join(conds, " AND ") + ")", args
+}
+
 // applyDefaults fills in zero-valued fields with defaults.
 func (c *Config) applyDefaults() {
 	if c.Interval <= 0 {
@@ -59,6 +104,12 @@ func (c *Config) applyDefaults() {
 	if c.RetentionDays < 0 {
 		c.RetentionDays = DefaultRetentionDays
 	}
+	if c.ArchiveAfterDays < 0 {
+		c.ArchiveAfterDays = 0
+	}
+	if c.ArchiveChunkSize <= 0 {
+		c.ArchiveChunkSize = archive.DefaultChunkSize
+	}
 	if c.PruneBatchSize <= 0 {
 		c.PruneBatchSize = DefaultPruneBatchSize
 	}
@@ -68,12 +119,22 @@ func (c *Config) applyDefaults() {
 	if c.LowActivityThreshold <= 0 {
 		c.LowActivityThreshold = DefaultLowActivityThreshold
 	}
+	if c.FTSMaxSegments <= 0 {
+		c.FTSMaxSegments = DefaultFTSMaxSegments
+	}
+	if c.MaxDBSizeBytes < 0 {
+		c.MaxDBSizeBytes = 0
+	}
+	if c.QuotaEvictBatch <= 0 {
+		c.QuotaEvictBatch = DefaultQuotaEvictBatch
+	}
 	if c.VacuumGrowthRatio <= 0 {
 		c.VacuumGrowthRatio = DefaultVacuumGrowthRatio
 	}
 	if c.Logger == nil {
 		c.Logger = slog.Default()
 	}
+	c.Clock = clock.OrSystem(c.Clock)
 }
 
 // Stats holds cumulative maintenance statistics.
@@ -81,20 +142,25 @@ type Stats struct {
 	LastTickTime        time.Time
 	Ticks               int64
 	EventsPruned        int64
+	EventsArchived      int64
 	OrphansCleaned      int64
 	WALCheckpoints      int64
 	FTSOptimizations    int64
+	FTSRebuilds         int64
+	TemplatesEvicted    int64
+	EventsEvicted       int64
 	VacuumsPerformed    int64
 	LastVacuumSizeBytes int64
 }
 
 // Runner executes periodic maintenance tasks on the suggestions database.
 type Runner struct {
-	cfg    Config
-	db     *sql.DB
-	stats  Stats
-	events atomic.Int64
-	mu     sync.Mutex
+	cfg     Config
+	db      *sql.DB
+	stats   Stats
+	events  atomic.Int64
+	running atomic.Bool
+	mu      sync.Mutex
 }
 
 // NewRunner creates a new maintenance runner.
@@ -120,15 +186,31 @@ func (r *Runner) GetStats() Stats {
 	return r.stats
 }
 
+// Running reports whether Run is executing.
+func (r *Runner) Running() bool {
+	return r.running.Load()
+}
+
+// Interval returns the time between maintenance ticks.
+func (r *Runner) Interval() time.Duration {
+	return r.cfg.Interval
+}
+
 // Run starts the maintenance loop. It blocks until ctx is cancelled
 // or stopCh is closed. Intended to be called as a goroutine.
 func (r *Runner) Run(ctx context.Context, stopCh <-chan struct{}) {
+	r.running.Store(true)
+	defer r.running.Store(false)
+
 	ticker := time.NewTicker(r.cfg.Interval)
 	defer ticker.Stop()
 
 	r.cfg.Logger.Info("maintenance runner started",
 		"interval", r.cfg.Interval,
 		"retention_days", r.cfg.RetentionDays,
+		"retention_rules", len(r.cfg.RetentionRules),
+		"archive_after_days", r.cfg.ArchiveAfterDays,
+		"max_db_size_bytes", r.cfg.MaxDBSizeBytes,
 		"batch_size", r.cfg.PruneBatchSize,
 	)
 
@@ -146,11 +228,17 @@ func (r *Runner) Run(ctx context.Context, stopCh <-chan struct{}) {
 	}
 }
 
+// RunOnce performs a single maintenance pass immediately. Simulation
+// tooling calls it after advancing the configured clock.
+func (r *Runner) RunOnce(ctx context.Context) {
+	r.tick(ctx)
+}
+
 // tick performs a single maintenance pass.
 func (r *Runner) tick(ctx context.Context) {
 	r.mu.Lock()
 	r.stats.Ticks++
-	r.stats.LastTickTime = time.Now()
+	r.stats.LastTickTime = r.cfg.Clock.Now()
 	tickNum := r.stats.Ticks
 	r.mu.Unlock()
 
@@ -168,7 +256,7 @@ func (r *Runner) tick(ctx context.Context) {
 	r.walCheckpoint(ctx, lowActivity)
 
 	// 2. Retention pruning (every tick, if enabled)
-	if r.cfg.RetentionDays > 0 {
+	if r.retentionEnabled() {
 		pruned := r.retentionPrune(ctx)
 		if pruned > 0 {
 			// Also clean orphaned templates after pruning
@@ -176,15 +264,28 @@ func (r *Runner) tick(ctx context.Context) {
 			if orphans > 0 {
 				r.cfg.Logger.Info("cleaned orphaned templates", "count", orphans)
 			}
+			r.pruneArchiveChunks(ctx)
 		}
 	}
 
-	// 3. FTS optimize (low activity only)
+	// 3. Archive old command payloads (low activity only, if enabled)
+	if lowActivity && r.cfg.ArchiveAfterDays > 0 {
+		r.archiveOldEvents(ctx)
+	}
+
+	// 4. FTS repair and optimize (low activity only)
 	if lowActivity {
-		r.ftsOptimize(ctx)
+		r.ftsMaintain(ctx)
 	}
 
-	// 4. VACUUM (low activity only, when size threshold exceeded)
+	// 5. Storage quota (low activity only, if enabled); a VACUUM follows any
+	// eviction so the file actually shrinks
+	if lowActivity && r.cfg.MaxDBSizeBytes > 0 && r.enforceQuota(ctx) {
+		r.vacuum(ctx)
+		return
+	}
+
+	// 6. VACUUM (low activity only, when size threshold exceeded)
 	if lowActivity {
 		r.maybeVacuum(ctx)
 	}
@@ -214,10 +315,78 @@ func (r *Runner) walCheckpoint(ctx context.Context, lowActivity bool) {
 	r.cfg.Logger.Debug("WAL checkpoint completed", "mode", mode)
 }
 
-// retentionPrune deletes old command_event rows in batches.
-// Uses V2 schema's ts_ms column. Returns total rows deleted.
+// retentionEnabled reports whether any events can expire.
+func (r *Runner) retentionEnabled() bool {
+	if r.cfg.RetentionDays > 0 {
+		return true
+	}
+	for _, rule := range r.cfg.RetentionRules {
+		if rule.Days > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// retentionPrune deletes expired command_event rows in batches. Each event
+// expires after the Days of the first RetentionRule it matches, or after
+// RetentionDays if it matches none. Uses V2 schema's ts_ms column. Returns
+// total rows deleted.
 func (r *Runner) retentionPrune(ctx context.Context) int64 {
-	cutoffMs := time.Now().UnixMilli() - int64(r.cfg.RetentionDays)*24*60*60*1000
+	nowMs := clock.NowMs(r.cfg.Clock)
+	var totalDeleted int64
+
+	// Events matched by an earlier rule are excluded from later passes.
+	var earlier []string
+	var earlierArgs []any
+	for _, rule := range r.cfg.RetentionRules {
+		cond, args := rule.where()
+		if cond == "" {
+			continue
+		}
+		if rule.Days > 0 {
+			where := cond
+			whereArgs := append([]any{}, args...)
+			if len(earlier) > 0 {
+				where += " AND NOT (" + strings.Join(earlier, " OR ") + ")"
+				whereArgs = append(whereArgs, earlierArgs...)
+			}
+			totalDeleted += r.pruneBatches(ctx, nowMs-daysMs(rule.Days), where, whereArgs)
+		}
+		earlier = append(earlier, cond)
+		earlierArgs = append(earlierArgs, args...)
+	}
+
+	if r.cfg.RetentionDays > 0 {
+		where := "1"
+		if len(earlier) > 0 {
+			where = "NOT (" + strings.Join(earlier, " OR ") + ")"
+		}
+		totalDeleted += r.pruneBatches(ctx, nowMs-daysMs(r.cfg.RetentionDays), where, earlierArgs)
+	}
+
+	if totalDeleted > 0 {
+		r.cfg.Logger.Info("retention prune completed",
+			"deleted", totalDeleted,
+			"rules", len(r.cfg.RetentionRules),
+		)
+	}
+
+	return totalDeleted
+}
+
+// pruneBatches deletes command_event rows older than cutoffMs that satisfy
+// where, yielding between batches. Returns rows deleted.
+func (r *Runner) pruneBatches(ctx context.Context, cutoffMs int64, where string, args []any) int64 {
+	query := `
+		DELETE FROM command_event
+		WHERE id IN (
+			SELECT id FROM command_event
+			WHERE ts_ms < ? AND ` + where + `
+			LIMIT ?
+		)
+	`
+	queryArgs := append(append([]any{cutoffMs}, args...), r.cfg.PruneBatchSize)
 	var totalDeleted int64
 
 	for {
@@ -229,14 +398,7 @@ func (r *Runner) retentionPrune(ctx context.Context) int64 {
 		}
 
 		// Delete a batch using subquery for SQLite compatibility
-		res, err := r.db.ExecContext(ctx, `
-			DELETE FROM command_event
-			WHERE id IN (
-				SELECT id FROM command_event
-				WHERE ts_ms < ?
-				LIMIT ?
-			)
-		`, cutoffMs, r.cfg.PruneBatchSize)
+		res, err := r.db.ExecContext(ctx, query, queryArgs...)
 		if err != nil {
 			r.cfg.Logger.Warn("retention prune batch failed", "error", err)
 			break
@@ -266,16 +428,15 @@ func (r *Runner) retentionPrune(ctx context.Context) int64 {
 		}
 	}
 
-	if totalDeleted > 0 {
-		r.cfg.Logger.Info("retention prune completed",
-			"deleted", totalDeleted,
-			"cutoff_ms", cutoffMs,
-		)
-	}
-
+	r.cfg.Logger.Debug("retention prune pass", "deleted", totalDeleted, "cutoff_ms", cutoffMs)
 	return totalDeleted
 }
 
+// daysMs converts a number of days to milliseconds.
+func daysMs(days int) int64 {
+	return int64(days) * 24 * 60 * 60 * 1000
+}
+
 // cleanOrphanedTemplates removes command_template rows that are no longer
 // referenced by any command_event.
 func (r *Runner) cleanOrphanedTemplates(ctx context.Context) int64 {
@@ -304,12 +465,72 @@ func (r *Runner) cleanOrphanedTemplates(ctx context.Context) int64 {
 	return deleted
 }
 
+// archiveOldEvents compresses cmd_raw of events older than ArchiveAfterDays
+// into archive chunks and drops them from full-text search.
+func (r *Runner) archiveOldEvents(ctx context.Context) {
+	nowMs := clock.NowMs(r.cfg.Clock)
+	cutoffMs := nowMs - daysMs(r.cfg.ArchiveAfterDays)
+
+	res, err := archive.Archive(ctx, r.db, cutoffMs, r.cfg.ArchiveChunkSize, nowMs)
+	if res.Events > 0 {
+		r.mu.Lock()
+		r.stats.EventsArchived += res.Events
+		r.mu.Unlock()
+		r.cfg.Logger.Info("archived old command events",
+			"events", res.Events,
+			"chunks", res.Chunks,
+			"cutoff_ms", cutoffMs,
+		)
+	}
+	if err != nil {
+		r.cfg.Logger.Warn("command event archival failed", "error", err)
+	}
+}
+
+// pruneArchiveChunks removes archive chunks whose events were all pruned.
+func (r *Runner) pruneArchiveChunks(ctx context.Context) {
+	n, err := archive.PruneOrphanChunks(ctx, r.db)
+	if err != nil {
+		r.cfg.Logger.Warn("archive chunk cleanup failed", "error", err)
+		return
+	}
+	if n > 0 {
+		r.cfg.Logger.Info("removed empty archive chunks", "count", n)
+	}
+}
+
+// ftsMaintain checks command_event_fts and rebuilds it when it has drifted
+// from command_event, or runs the FTS5 'optimize' command to merge b-tree
+// segments when it has fragmented.
+func (r *Runner) ftsMaintain(ctx context.Context) {
+	status, err := CheckFTS(ctx, r.db)
+	if err != nil {
+		r.cfg.Logger.Warn("FTS check failed", "error", err)
+		return
+	}
+
+	switch {
+	case status.Drifted():
+		indexed, err := RebuildFTS(ctx, r.db)
+		if err != nil {
+			r.cfg.Logger.Warn("FTS rebuild failed", "error", err)
+			return
+		}
+		r.mu.Lock()
+		r.stats.FTSRebuilds++
+		r.mu.Unlock()
+		r.cfg.Logger.Info("rebuilt full-text index",
+			"was_indexed", status.Indexed,
+			"indexed", indexed,
+		)
+	case status.Segments > r.cfg.FTSMaxSegments:
+		r.ftsOptimize(ctx)
+	}
+}
+
 // ftsOptimize runs the FTS5 'optimize' command to merge b-tree segments.
 func (r *Runner) ftsOptimize(ctx context.Context) {
-	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO command_event_fts(command_event_fts) VALUES('optimize')
-	`)
-	if err != nil {
+	if err := OptimizeFTS(ctx, r.db); err != nil {
 		r.cfg.Logger.Warn("FTS optimize failed", "error", err)
 		return
 	}
@@ -320,6 +541,59 @@ func (r *Runner) ftsOptimize(ctx context.Context) {
 	r.cfg.Logger.Debug("FTS optimize completed")
 }
 
+// enforceQuota evicts the lowest-scoring, least recently used templates in
+// batches while the database holds more than MaxDBSizeBytes. It reports
+// whether anything was evicted.
+func (r *Runner) enforceQuota(ctx context.Context) bool {
+	var total EvictResult
+	for round := 0; round < quotaMaxRounds && ctx.Err() == nil; round++ {
+		used, err := UsedBytes(ctx, r.db)
+		if err != nil {
+			r.cfg.Logger.Warn("storage quota check failed", "error", err)
+			break
+		}
+		if used <= r.cfg.MaxDBSizeBytes {
+			break
+		}
+
+		res, err := EvictTemplates(ctx, r.db, r.cfg.QuotaEvictBatch, clock.NowMs(r.cfg.Clock))
+		if err != nil {
+			r.cfg.Logger.Warn("template eviction failed", "error", err)
+			break
+		}
+		if res.Templates == 0 {
+			r.cfg.Logger.Warn("database is over its size budget but only recently used templates remain",
+				"used_bytes", used,
+				"max_db_size_bytes", r.cfg.MaxDBSizeBytes,
+			)
+			break
+		}
+		total.Templates += res.Templates
+		total.Events += res.Events
+
+		// FTS5 records deletes as markers in new segments; until they are
+		// merged the index grows, and the next measurement would evict more.
+		if err := OptimizeFTS(ctx, r.db); err != nil {
+			r.cfg.Logger.Warn("full-text optimize after eviction failed", "error", err)
+		}
+	}
+	if total.Templates == 0 {
+		return false
+	}
+
+	r.mu.Lock()
+	r.stats.TemplatesEvicted += total.Templates
+	r.stats.EventsEvicted += total.Events
+	r.mu.Unlock()
+	r.cfg.Logger.Info("evicted templates to stay within the storage quota",
+		"templates", total.Templates,
+		"events", total.Events,
+		"max_db_size_bytes", r.cfg.MaxDBSizeBytes,
+	)
+	r.pruneArchiveChunks(ctx)
+	return true
+}
+
 // maybeVacuum checks if the database has grown enough to warrant a VACUUM.
 // It compares the current file size against the size at last vacuum.
 func (r *Runner) maybeVacuum(ctx context.Context) {
@@ -355,7 +629,11 @@ func (r *Runner) maybeVacuum(ctx context.Context) {
 		"last_vacuum_size", lastSize,
 		"ratio", ratio,
 	)
+	r.vacuum(ctx)
+}
 
+// vacuum runs VACUUM and records the database size afterwards.
+func (r *Runner) vacuum(ctx context.Context) {
 	if _, err := r.db.ExecContext(ctx, "VACUUM"); err != nil {
 		r.cfg.Logger.Warn("VACUUM failed", "error", err)
 		return
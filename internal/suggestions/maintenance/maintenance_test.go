@@ -11,6 +11,8 @@ import (
 	"time"
 
 	_ "modernc.org/sqlite"
+
+	"github.com/runger/clai/internal/suggestions/clock"
 )
 
 // testSchema creates the minimal V2 schema needed for maintenance tests.
@@ -28,11 +30,21 @@ CREATE TABLE IF NOT EXISTS command_event (
   template_id     TEXT,
   exit_code       INTEGER,
   duration_ms     INTEGER,
-  ephemeral       INTEGER NOT NULL DEFAULT 0
+  ephemeral       INTEGER NOT NULL DEFAULT 0,
+  archive_chunk_id INTEGER
 );
 
 CREATE INDEX IF NOT EXISTS idx_event_ts ON command_event(ts_ms);
 
+CREATE TABLE IF NOT EXISTS command_event_archive (
+  id              INTEGER PRIMARY KEY AUTOINCREMENT,
+  first_event_id  INTEGER NOT NULL,
+  last_event_id   INTEGER NOT NULL,
+  event_count     INTEGER NOT NULL,
+  created_ms      INTEGER NOT NULL,
+  payload         BLOB NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS command_template (
   template_id     TEXT PRIMARY KEY,
   cmd_norm        TEXT NOT NULL,
@@ -60,6 +72,7 @@ BEGIN
 END;
 
 CREATE TRIGGER IF NOT EXISTS command_event_ad AFTER DELETE ON command_event
+WHEN OLD.archive_chunk_id IS NULL
 BEGIN
   INSERT INTO command_event_fts(command_event_fts, rowid, cmd_raw, cmd_norm, repo_key, session_id)
   VALUES ('delete', OLD.id, OLD.cmd_raw, OLD.cmd_norm, OLD.repo_key, OLD.session_id);
@@ -298,6 +311,31 @@ func TestRetentionPrune_DeletesOldRows(t *testing.T) {
 	}
 }
 
+func TestRunOnce_UsesConfiguredClock(t *testing.T) {
+	db := openTestDB(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewManual(start)
+	r := NewRunner(db, Config{RetentionDays: 30, Clock: clk})
+	ctx := context.Background()
+
+	insertEvent(t, db, start.UnixMilli(), "git status", nil)
+
+	r.RunOnce(ctx)
+	if count := countEvents(t, db); count != 1 {
+		t.Fatalf("event pruned before retention window elapsed, %d left", count)
+	}
+	if got := r.GetStats().LastTickTime; !got.Equal(start) {
+		t.Errorf("LastTickTime = %v, want %v", got, start)
+	}
+
+	// Simulate six weeks passing.
+	clk.Advance(6 * 7 * 24 * time.Hour)
+	r.RunOnce(ctx)
+	if count := countEvents(t, db); count != 0 {
+		t.Errorf("expected event to be pruned after 6 weeks, %d left", count)
+	}
+}
+
 func TestRetentionPrune_BatchProcessing(t *testing.T) {
 	db := openTestDB(t)
 	r := NewRunner(db, Config{
@@ -343,6 +381,88 @@ func TestRetentionPrune_DisabledWhenZero(t *testing.T) {
 	}
 }
 
+func TestRetentionPrune_Rules(t *testing.T) {
+	db := openTestDB(t)
+	r := NewRunner(db, Config{
+		RetentionDays: 90,
+		RetentionRules: []RetentionRule{
+			{Repo: "/src/keep", Days: 0},
+			{Ephemeral: true, Days: 7},
+			{Failed: true, Days: 30},
+			{Days: 1}, // no criteria: ignored
+		},
+	})
+	ctx := context.Background()
+
+	now := time.Now().UnixMilli()
+	day := int64(24 * 60 * 60 * 1000)
+	insert := func(name string, ageDays int64, repo any, ephemeral, exitCode int) {
+		t.Helper()
+		if _, err := db.Exec(`
+			INSERT INTO command_event (session_id, ts_ms, cwd, repo_key, cmd_raw, cmd_norm, ephemeral, exit_code)
+			VALUES ('s', ?, '/tmp', ?, ?, ?, ?, ?)
+		`, now-ageDays*day, repo, name, name, ephemeral, exitCode); err != nil {
+			t.Fatalf("failed to insert event: %v", err)
+		}
+	}
+
+	insert("kept-repo-old", 400, "/src/keep", 0, 0)
+	insert("kept-repo-failed", 400, "/src/keep", 0, 1)
+	insert("ephemeral-new", 3, nil, 1, 0)
+	insert("ephemeral-old", 10, nil, 1, 0)
+	insert("failed-new", 20, "/src/other", 0, 2)
+	insert("failed-old", 40, "/src/other", 0, 2)
+	insert("ok-mid", 40, "/src/other", 0, 0)
+	insert("ok-old", 100, nil, 0, 0)
+
+	if deleted := r.retentionPrune(ctx); deleted != 3 {
+		t.Errorf("deleted: got %d, want 3", deleted)
+	}
+
+	rows, err := db.Query(`SELECT cmd_raw FROM command_event ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var cmd string
+		if err := rows.Scan(&cmd); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, cmd)
+	}
+	want := []string{"kept-repo-old", "kept-repo-failed", "ephemeral-new", "failed-new", "ok-mid"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("remaining events = %v, want %v", got, want)
+	}
+}
+
+func TestRetentionPrune_RulesWithoutGlobalRetention(t *testing.T) {
+	db := openTestDB(t)
+	r := NewRunner(db, Config{
+		RetentionDays:  0,
+		RetentionRules: []RetentionRule{{Failed: true, Days: 30}},
+	})
+	ctx := context.Background()
+
+	now := time.Now().UnixMilli()
+	oldTS := now - 1000*24*60*60*1000
+	insertEvent(t, db, oldTS, "old-ok", nil)
+	if _, err := db.Exec(`
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, exit_code)
+		VALUES ('s', ?, '/tmp', 'old-failed', 'old-failed', 1)
+	`, oldTS); err != nil {
+		t.Fatal(err)
+	}
+
+	r.tick(ctx)
+
+	if count := countEvents(t, db); count != 1 {
+		t.Errorf("remaining events: got %d, want 1 (only the failed one pruned)", count)
+	}
+}
+
 func TestRetentionPrune_NoOldRows(t *testing.T) {
 	db := openTestDB(t)
 	r := NewRunner(db, Config{RetentionDays: 90})
@@ -598,9 +718,9 @@ func TestTick_LowActivity(t *testing.T) {
 	if stats.WALCheckpoints != 1 {
 		t.Errorf("WALCheckpoints: got %d, want 1", stats.WALCheckpoints)
 	}
-	// FTS optimize should run during low activity
-	if stats.FTSOptimizations != 1 {
-		t.Errorf("FTSOptimizations: got %d, want 1", stats.FTSOptimizations)
+	// An empty index is neither drifted nor fragmented, so FTS is left alone
+	if stats.FTSOptimizations != 0 || stats.FTSRebuilds != 0 {
+		t.Errorf("FTS optimizations/rebuilds: got %d/%d, want 0/0", stats.FTSOptimizations, stats.FTSRebuilds)
 	}
 }
 
@@ -699,6 +819,9 @@ func TestRun_StopChannel(t *testing.T) {
 	}()
 
 	time.Sleep(50 * time.Millisecond)
+	if !r.Running() {
+		t.Error("Running() = false while Run executes")
+	}
 	close(stopCh)
 
 	select {
@@ -707,6 +830,9 @@ func TestRun_StopChannel(t *testing.T) {
 	case <-time.After(2 * time.Second):
 		t.Fatal("Run did not stop after stopCh closed")
 	}
+	if r.Running() {
+		t.Error("Running() = true after Run returned")
+	}
 }
 
 func TestRun_MultipleTicks(t *testing.T) {
@@ -779,6 +905,44 @@ func TestIntegration_FullMaintenancePass(t *testing.T) {
 	}
 }
 
+func TestTick_ArchivesThenPrunesChunks(t *testing.T) {
+	db := openTestDB(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewManual(start)
+	r := NewRunner(db, Config{RetentionDays: 90, ArchiveAfterDays: 30, Clock: clk})
+	ctx := context.Background()
+
+	insertEvent(t, db, start.Add(-40*24*time.Hour).UnixMilli(), "make release", nil)
+	insertEvent(t, db, start.UnixMilli(), "git status", nil)
+
+	r.RunOnce(ctx)
+	if got := r.GetStats().EventsArchived; got != 1 {
+		t.Fatalf("EventsArchived: got %d, want 1", got)
+	}
+	var chunks int
+	if err := db.QueryRow("SELECT COUNT(*) FROM command_event_archive").Scan(&chunks); err != nil {
+		t.Fatal(err)
+	}
+	if chunks != 1 {
+		t.Fatalf("archive chunks: got %d, want 1", chunks)
+	}
+
+	var firstChunk int64
+	if err := db.QueryRow("SELECT id FROM command_event_archive").Scan(&firstChunk); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once retention prunes the archived event, its chunk goes too.
+	clk.Advance(60 * 24 * time.Hour)
+	r.RunOnce(ctx)
+	if err := db.QueryRow("SELECT COUNT(*) FROM command_event_archive WHERE id = ?", firstChunk).Scan(&chunks); err != nil {
+		t.Fatal(err)
+	}
+	if chunks != 0 {
+		t.Errorf("chunk of pruned event still present")
+	}
+}
+
 // --- RecordEvent tests ---
 
 func TestRecordEvent_ThreadSafety(t *testing.T) {
@@ -0,0 +1,179 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/score"
+)
+
+// DefaultQuotaEvictBatch is how many templates quota enforcement evicts
+// before measuring the database again.
+const DefaultQuotaEvictBatch = 50
+
+// quotaMaxRounds bounds the eviction batches run in one maintenance tick, so
+// a budget far below the current size is approached over several ticks
+// instead of holding the writer for one long pass.
+const quotaMaxRounds = 20
+
+// quotaProtectMs keeps templates used within the last day from eviction, so
+// a budget too small for recent activity cannot evict what the user is
+// typing right now.
+const quotaProtectMs = 24 * 60 * 60 * 1000
+
+// UsedBytes returns the bytes of the database that hold data: its pages
+// minus those on the freelist. Unlike the file size it drops as soon as
+// rows are deleted, before a VACUUM returns the space to the filesystem.
+func UsedBytes(ctx context.Context, db *sql.DB) (int64, error) {
+	var pageCount, freePages, pageSize int64
+	if err := db.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pageCount); err != nil {
+		return 0, fmt.Errorf("read page count: %w", err)
+	}
+	if err := db.QueryRowContext(ctx, `PRAGMA freelist_count`).Scan(&freePages); err != nil {
+		return 0, fmt.Errorf("read freelist count: %w", err)
+	}
+	if err := db.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("read page size: %w", err)
+	}
+	return (pageCount - freePages) * pageSize, nil
+}
+
+// EvictResult reports what EvictTemplates removed.
+type EvictResult struct {
+	Templates int64 // Templates removed along with their stats
+	Events    int64 // command_event rows of those templates
+}
+
+// evictionCandidate is a template with its current decayed score.
+type evictionCandidate struct {
+	id         string
+	score      float64
+	lastSeenMs int64
+}
+
+// EvictTemplates removes up to n templates with the lowest decayed global
+// frequency score, least recently used first among equal scores, together
+// with their events and every stat, transition and pattern that references
+// them. Templates used within the last day are kept. All deletes happen in
+// one transaction.
+func EvictTemplates(ctx context.Context, db *sql.DB, n int, nowMs int64) (EvictResult, error) {
+	var res EvictResult
+	candidates, err := evictionCandidates(ctx, db, nowMs)
+	if err != nil {
+		return res, err
+	}
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	if len(candidates) == 0 {
+		return res, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // best-effort rollback on error
+
+	for _, c := range candidates {
+		events, err := DeleteTemplate(ctx, tx, c.id)
+		if err != nil {
+			return EvictResult{}, fmt.Errorf("evict template %s: %w", c.id, err)
+		}
+		res.Templates++
+		res.Events += events
+	}
+	// Cached suggestions may offer the evicted commands.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM suggestion_cache`); err != nil {
+		return EvictResult{}, fmt.Errorf("clear suggestion cache: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return EvictResult{}, fmt.Errorf("commit transaction: %w", err)
+	}
+	return res, nil
+}
+
+// evictionCandidates returns the templates that may be evicted, in eviction
+// order. Scores are decayed to nowMs, as the scorer would see them.
+func evictionCandidates(ctx context.Context, db *sql.DB, nowMs int64) ([]evictionCandidate, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.template_id, COALESCE(s.score, 0), COALESCE(s.last_seen_ms, t.last_seen_ms), t.last_seen_ms
+		FROM command_template t
+		LEFT JOIN command_stat s ON s.scope = ? AND s.template_id = t.template_id
+		WHERE t.last_seen_ms < ?
+	`, score.ScopeGlobal, nowMs-quotaProtectMs)
+	if err != nil {
+		return nil, fmt.Errorf("query templates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []evictionCandidate
+	for rows.Next() {
+		var c evictionCandidate
+		var scoreMs int64
+		if err := rows.Scan(&c.id, &c.score, &scoreMs, &c.lastSeenMs); err != nil {
+			return nil, fmt.Errorf("scan template: %w", err)
+		}
+		if age := nowMs - scoreMs; age > 0 {
+			c.score *= math.Exp(-float64(age) / score.DefaultTauMs)
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query templates: %w", err)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score < candidates[j].score
+		}
+		return candidates[i].lastSeenMs < candidates[j].lastSeenMs
+	})
+	return candidates, nil
+}
+
+// DeleteTemplate deletes template id and every row referencing it, and
+// returns how many command events went with it.
+func DeleteTemplate(ctx context.Context, tx *sql.Tx, id string) (int64, error) {
+	// pipeline_event rows reference their command_event by id.
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM pipeline_event
+		WHERE command_event_id IN (SELECT id FROM command_event WHERE template_id = ?)
+	`, id); err != nil {
+		return 0, fmt.Errorf("pipeline_event: %w", err)
+	}
+
+	var events int64
+	for _, c := range suggestdb.V2TemplateColumns {
+		q := fmt.Sprintf(`DELETE FROM %s WHERE %s = ?`, c.Table, c.Column)
+		res, err := tx.ExecContext(ctx, q, id)
+		if err != nil {
+			return 0, fmt.Errorf("%s.%s: %w", c.Table, c.Column, err)
+		}
+		if c.Table == "command_event" {
+			events, _ = res.RowsAffected()
+		}
+	}
+	// Template IDs embed a fixed-length hash, so instr only matches whole IDs.
+	// Steps go before the workflows they belong to.
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM workflow_step
+		WHERE pattern_id IN (SELECT pattern_id FROM workflow_pattern WHERE instr(template_chain, ?) > 0)
+	`, id); err != nil {
+		return 0, fmt.Errorf("workflow_step: %w", err)
+	}
+	for _, table := range suggestdb.V2TemplateChainTables {
+		q := fmt.Sprintf(`DELETE FROM %s WHERE instr(template_chain, ?) > 0`, table)
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			return 0, fmt.Errorf("%s.template_chain: %w", table, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM command_template WHERE template_id = ?`, id); err != nil {
+		return 0, fmt.Errorf("command_template: %w", err)
+	}
+	return events, nil
+}
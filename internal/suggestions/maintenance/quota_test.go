@@ -0,0 +1,182 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runger/clai/internal/suggestions/clock"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+)
+
+// openQuotaTestDB opens a database with the full V2 schema, which eviction
+// touches table by table.
+func openQuotaTestDB(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "suggestions_v2.db")
+	sdb, err := suggestdb.Open(context.Background(), suggestdb.Options{Path: path, SkipLock: true})
+	if err != nil {
+		t.Fatalf("suggestdb.Open() error = %v", err)
+	}
+	t.Cleanup(func() { sdb.Close() })
+	return sdb.DB(), path
+}
+
+func mustExec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", strings.TrimSpace(query), err)
+	}
+}
+
+// seedQuotaTemplate adds a template last used at lastSeenMs with a global
+// frequency score, n events, and a transition to itself.
+func seedQuotaTemplate(t *testing.T, db *sql.DB, id string, score float64, lastSeenMs int64, n int) {
+	t.Helper()
+	mustExec(t, db, `
+		INSERT INTO command_template (template_id, cmd_norm, slot_count, first_seen_ms, last_seen_ms)
+		VALUES (?, ?, 0, ?, ?)`, id, "cmd "+id, lastSeenMs, lastSeenMs)
+	mustExec(t, db, `
+		INSERT INTO command_stat (scope, template_id, score, success_count, failure_count, last_seen_ms)
+		VALUES ('global', ?, ?, 1, 0, ?)`, id, score, lastSeenMs)
+	mustExec(t, db, `
+		INSERT INTO transition_stat (scope, prev_template_id, next_template_id, weight, count, last_seen_ms)
+		VALUES ('global', ?1, ?1, 1, 1, ?2)`, id, lastSeenMs)
+	for i := 0; i < n; i++ {
+		mustExec(t, db, `
+			INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, template_id)
+			VALUES ('s1', ?, '/tmp', ?, ?, ?)`, lastSeenMs, fmt.Sprintf("cmd %s %d %s", id, i, strings.Repeat("x", 200)), "cmd "+id, id)
+	}
+}
+
+func countRows(t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+func TestEvictTemplates(t *testing.T) {
+	db, _ := openQuotaTestDB(t)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	nowMs := now.UnixMilli()
+	day := int64(24 * time.Hour / time.Millisecond)
+
+	// tpl-stale scored high a year ago and has decayed below tpl-rare.
+	seedQuotaTemplate(t, db, "tpl-stale", 50, nowMs-365*day, 2)
+	seedQuotaTemplate(t, db, "tpl-rare", 1, nowMs-2*day, 1)
+	seedQuotaTemplate(t, db, "tpl-busy", 40, nowMs-2*day, 3)
+	seedQuotaTemplate(t, db, "tpl-recent", 0.1, nowMs-time.Hour.Milliseconds(), 1)
+
+	// A workflow and a pipeline that involve the stale template.
+	mustExec(t, db, `
+		INSERT INTO workflow_pattern (pattern_id, template_chain, display_chain, scope, step_count, occurrence_count, last_seen_ms)
+		VALUES ('wf-1', 'tpl-busy|tpl-stale', 'busy|stale', 'global', 2, 3, 1)`)
+	mustExec(t, db, `INSERT INTO workflow_step (pattern_id, step_index, template_id) VALUES ('wf-1', 0, 'tpl-busy'), ('wf-1', 1, 'tpl-stale')`)
+	mustExec(t, db, `
+		INSERT INTO pipeline_event (command_event_id, position, cmd_raw, cmd_norm, template_id)
+		SELECT id, 0, cmd_raw, cmd_norm, 'tpl-busy' FROM command_event WHERE template_id = 'tpl-stale' LIMIT 1`)
+
+	res, err := EvictTemplates(ctx, db, 2, nowMs)
+	if err != nil {
+		t.Fatalf("EvictTemplates() error = %v", err)
+	}
+	if res.Templates != 2 || res.Events != 3 {
+		t.Errorf("EvictTemplates() = %+v, want 2 templates and 3 events", res)
+	}
+
+	for _, id := range []string{"tpl-stale", "tpl-rare"} {
+		if n := countRows(t, db, `SELECT COUNT(*) FROM command_template WHERE template_id = ?`, id); n != 0 {
+			t.Errorf("%s was not evicted", id)
+		}
+		for _, q := range []string{
+			`SELECT COUNT(*) FROM command_event WHERE template_id = ?`,
+			`SELECT COUNT(*) FROM command_stat WHERE template_id = ?`,
+			`SELECT COUNT(*) FROM transition_stat WHERE prev_template_id = ?`,
+		} {
+			if n := countRows(t, db, q, id); n != 0 {
+				t.Errorf("%s: %d rows of %s left", q, n, id)
+			}
+		}
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM workflow_pattern`); n != 0 {
+		t.Errorf("workflow mentioning an evicted template was kept")
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM workflow_step`); n != 0 {
+		t.Errorf("%d workflow steps left", n)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM pipeline_event`); n != 0 {
+		t.Errorf("pipeline event of an evicted command was kept")
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM command_event WHERE template_id = 'tpl-busy'`); n != 3 {
+		t.Errorf("tpl-busy has %d events, want 3", n)
+	}
+
+	// Only the busy template is old enough to evict; the recent one stays.
+	res, err = EvictTemplates(ctx, db, 10, nowMs)
+	if err != nil {
+		t.Fatalf("EvictTemplates() error = %v", err)
+	}
+	if res.Templates != 1 {
+		t.Errorf("second EvictTemplates() = %+v, want 1 template", res)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM command_template`); n != 1 {
+		t.Errorf("%d templates left, want only tpl-recent", n)
+	}
+}
+
+func TestTick_EnforcesQuota(t *testing.T) {
+	db, path := openQuotaTestDB(t)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	nowMs := now.UnixMilli()
+	day := int64(24 * time.Hour / time.Millisecond)
+
+	empty, err := UsedBytes(ctx, db)
+	if err != nil {
+		t.Fatalf("UsedBytes() error = %v", err)
+	}
+	for i := 0; i < 40; i++ {
+		seedQuotaTemplate(t, db, fmt.Sprintf("tpl-%02d", i), float64(i), nowMs-10*day, 20)
+	}
+	before, err := UsedBytes(ctx, db)
+	if err != nil {
+		t.Fatalf("UsedBytes() error = %v", err)
+	}
+	// Room for the schema and about half of the seeded history.
+	budget := empty + (before-empty)/2
+
+	runner := NewRunner(db, Config{
+		DBPath:          path,
+		Clock:           clock.NewManual(now),
+		MaxDBSizeBytes:  budget,
+		QuotaEvictBatch: 5,
+	})
+	runner.tick(ctx)
+
+	after, err := UsedBytes(ctx, db)
+	if err != nil {
+		t.Fatalf("UsedBytes() error = %v", err)
+	}
+	if after > budget {
+		t.Errorf("used %d bytes after tick, want at most %d", after, budget)
+	}
+	stats := runner.GetStats()
+	if stats.TemplatesEvicted == 0 || stats.EventsEvicted != 20*stats.TemplatesEvicted {
+		t.Errorf("stats = %+v, want evicted templates with 20 events each", stats)
+	}
+	if stats.VacuumsPerformed != 1 {
+		t.Errorf("VacuumsPerformed = %d, want 1 after eviction", stats.VacuumsPerformed)
+	}
+	// The highest-scoring template is among those kept.
+	if n := countRows(t, db, `SELECT COUNT(*) FROM command_template WHERE template_id = 'tpl-39'`); n != 1 {
+		t.Error("highest-scoring template was evicted")
+	}
+}
@@ -3,6 +3,7 @@ package normalize
 import (
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -56,3 +57,11 @@ func TestEnforceEventSize_EmptyString(t *testing.T) {
 	assert.Equal(t, "", result)
 	assert.False(t, truncated)
 }
+
+func TestEnforceEventSize_MultibyteBoundary(t *testing.T) {
+	cmd := "echo " + strings.Repeat("é", 10)
+	result, truncated := EnforceEventSize(cmd, 8)
+	assert.True(t, truncated)
+	assert.True(t, utf8.ValidString(result))
+	assert.Equal(t, "echo é", result)
+}
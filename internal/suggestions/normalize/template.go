@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -88,10 +89,73 @@ func (s *TemplateStore) Get(ctx context.Context, templateID string) (*Template,
 	return &t, nil
 }
 
-// ComputeTemplateID computes the sha256 hex digest of a normalized command.
-func ComputeTemplateID(cmdNorm string) string {
+// TemplateIDVersion is the version of the template ID scheme used by
+// ComputeTemplateID. Bump it whenever a normalization or hashing change
+// alters the IDs of existing commands; the daemon then re-keys stored
+// stats to the new IDs on startup instead of orphaning them.
+const TemplateIDVersion = 1
+
+// IDStrategy derives template IDs from normalized commands.
+type IDStrategy interface {
+	// Version returns the ID scheme version encoded in the IDs.
+	Version() int
+	// TemplateID returns the ID for a normalized command.
+	TemplateID(cmdNorm string) string
+}
+
+// SHA256Strategy hashes the normalized command with sha256. Version 1 IDs
+// are the bare hex digest, as used before IDs were versioned; later
+// versions are prefixed with "v<N>:" so stale IDs can be told apart.
+type SHA256Strategy struct {
+	V int
+}
+
+// Version implements IDStrategy.
+func (s SHA256Strategy) Version() int { return s.V }
+
+// TemplateID implements IDStrategy.
+func (s SHA256Strategy) TemplateID(cmdNorm string) string {
 	h := sha256.Sum256([]byte(cmdNorm))
-	return fmt.Sprintf("%x", h)
+	if s.V <= 1 {
+		return fmt.Sprintf("%x", h)
+	}
+	return fmt.Sprintf("v%d:%x", s.V, h)
+}
+
+// CurrentIDStrategy returns the strategy used for newly recorded commands.
+func CurrentIDStrategy() IDStrategy {
+	return SHA256Strategy{V: TemplateIDVersion}
+}
+
+// ComputeTemplateID computes the current template ID of a normalized command.
+func ComputeTemplateID(cmdNorm string) string {
+	return CurrentIDStrategy().TemplateID(cmdNorm)
+}
+
+// TemplateIDVersionOf returns the ID scheme version of a template ID, or 0
+// for IDs not produced by an IDStrategy (such as bootstrap IDs).
+func TemplateIDVersionOf(id string) int {
+	if rest, ok := strings.CutPrefix(id, "v"); ok {
+		if i := strings.IndexByte(rest, ':'); i > 0 {
+			if v, err := strconv.Atoi(rest[:i]); err == nil && v > 1 {
+				return v
+			}
+		}
+	}
+	if len(id) == sha256.Size*2 && isLowerHex(id) {
+		return 1
+	}
+	return 0
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
 }
 
 // CountSlots counts the number of placeholder slots in a normalized command.
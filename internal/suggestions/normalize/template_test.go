@@ -168,3 +168,19 @@ func TestCountSlots(t *testing.T) {
 		})
 	}
 }
+
+func TestSHA256Strategy_Versions(t *testing.T) {
+	v1 := SHA256Strategy{V: 1}.TemplateID("ls -la")
+	v2 := SHA256Strategy{V: 2}.TemplateID("ls -la")
+
+	assert.Len(t, v1, 64, "version 1 IDs are bare sha256 hex")
+	assert.Equal(t, "v2:"+v1, v2)
+	assert.Equal(t, ComputeTemplateID("ls -la"), CurrentIDStrategy().TemplateID("ls -la"))
+}
+
+func TestTemplateIDVersionOf(t *testing.T) {
+	assert.Equal(t, TemplateIDVersion, TemplateIDVersionOf(ComputeTemplateID("ls")))
+	assert.Equal(t, 3, TemplateIDVersionOf(SHA256Strategy{V: 3}.TemplateID("ls")))
+	assert.Equal(t, 0, TemplateIDVersionOf("bootstrap:git push"))
+	assert.Equal(t, 0, TemplateIDVersionOf("t-build"))
+}
@@ -0,0 +1,137 @@
+// Package output stores the stdout and stderr tails clients may send with a
+// command's end, so diagnosis and the history picker can show what a
+// command printed. Storing them is opt-in (suggestions.capture_output).
+//
+// Each stream is kept to its last bytes and zlib-compressed into one
+// command_output row per command_event; the row is deleted with its event.
+package output
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/runger/clai/internal/cmdutil"
+)
+
+// Output is what a command printed.
+type Output struct {
+	Stdout string
+	Stderr string
+	// Truncated reports that either stream was cut to its last bytes.
+	Truncated bool
+}
+
+// Empty reports whether o holds no output.
+func (o Output) Empty() bool {
+	return o.Stdout == "" && o.Stderr == ""
+}
+
+// Tail keeps the last maxBytes bytes of each stream of o, marking it
+// truncated when either was cut. A maxBytes of zero or less keeps o whole.
+func Tail(o Output, maxBytes int) Output {
+	var cutOut, cutErr bool
+	o.Stdout, cutOut = cmdutil.TailUTF8(o.Stdout, maxBytes)
+	o.Stderr, cutErr = cmdutil.TailUTF8(o.Stderr, maxBytes)
+	o.Truncated = o.Truncated || cutOut || cutErr
+	return o
+}
+
+// Execer executes statements, e.g. a *sql.DB or *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Querier runs single-row queries, e.g. a *sql.DB or *sql.Tx.
+type Querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Store records o as the output of event eventID, replacing any earlier
+// output. Empty output is not stored.
+func Store(ctx context.Context, db Execer, eventID int64, o Output) error {
+	if o.Empty() {
+		return nil
+	}
+	stdout, err := compress(o.Stdout)
+	if err != nil {
+		return err
+	}
+	stderr, err := compress(o.Stderr)
+	if err != nil {
+		return err
+	}
+	truncated := 0
+	if o.Truncated {
+		truncated = 1
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO command_output (event_id, stdout, stderr, truncated)
+		VALUES (?, ?, ?, ?)
+	`, eventID, stdout, stderr, truncated)
+	if err != nil {
+		return fmt.Errorf("failed to store command output: %w", err)
+	}
+	return nil
+}
+
+// Get returns the output stored for event eventID. ok is false when none
+// was stored.
+func Get(ctx context.Context, db Querier, eventID int64) (o Output, ok bool, err error) {
+	var stdout, stderr []byte
+	var truncated int
+	err = db.QueryRowContext(ctx, `
+		SELECT stdout, stderr, truncated FROM command_output WHERE event_id = ?
+	`, eventID).Scan(&stdout, &stderr, &truncated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Output{}, false, nil
+	}
+	if err != nil {
+		return Output{}, false, fmt.Errorf("failed to load command output: %w", err)
+	}
+	if o.Stdout, err = decompress(stdout); err != nil {
+		return Output{}, false, fmt.Errorf("event %d stdout: %w", eventID, err)
+	}
+	if o.Stderr, err = decompress(stderr); err != nil {
+		return Output{}, false, fmt.Errorf("event %d stderr: %w", eventID, err)
+	}
+	o.Truncated = truncated != 0
+	return o, true, nil
+}
+
+// compress returns s zlib-compressed, or nil for an empty stream.
+func compress(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := io.WriteString(zw, s); err != nil {
+		return nil, fmt.Errorf("failed to compress output: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress output: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(payload []byte) (string, error) {
+	if len(payload) == 0 {
+		return "", nil
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress: %w", err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	d, err := suggestdb.Open(context.Background(), suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "suggestions_v2.db"),
+		SkipLock: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { d.Close() })
+	return d.DB()
+}
+
+func insertEvent(t *testing.T, db *sql.DB) int64 {
+	t.Helper()
+	res, err := db.Exec(`
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm)
+		VALUES ('s1', 1000, '/tmp', 'make test', 'make test')
+	`)
+	require.NoError(t, err)
+	id, err := res.LastInsertId()
+	require.NoError(t, err)
+	return id
+}
+
+func TestTail(t *testing.T) {
+	t.Parallel()
+
+	o := Tail(Output{Stdout: "ok", Stderr: "line 1\nline 2"}, 6)
+	assert.Equal(t, Output{Stdout: "ok", Stderr: "line 2", Truncated: true}, o)
+
+	o = Tail(Output{Stdout: "ok", Truncated: true}, 6)
+	assert.True(t, o.Truncated, "truncation by the client is kept")
+	assert.Equal(t, "ok", o.Stdout)
+}
+
+func TestStoreAndGet(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	id := insertEvent(t, db)
+
+	_, ok, err := Get(ctx, db, id)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Empty output is not stored.
+	require.NoError(t, Store(ctx, db, id, Output{}))
+	_, ok, err = Get(ctx, db, id)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	want := Output{Stderr: strings.Repeat("FAIL: TestParse\n", 100), Truncated: true}
+	require.NoError(t, Store(ctx, db, id, want))
+	got, ok, err := Get(ctx, db, id)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, want, got)
+
+	var stored int
+	require.NoError(t, db.QueryRow(`SELECT length(stderr) FROM command_output WHERE event_id = ?`, id).Scan(&stored))
+	assert.Less(t, stored, len(want.Stderr), "output is compressed")
+}
+
+func TestDeletedWithEvent(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	id := insertEvent(t, db)
+	require.NoError(t, Store(ctx, db, id, Output{Stdout: "ok"}))
+
+	_, err := db.Exec(`DELETE FROM command_event WHERE id = ?`, id)
+	require.NoError(t, err)
+
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM command_output`).Scan(&n))
+	assert.Zero(t, n)
+}
@@ -1,6 +1,9 @@
 // Package projecttype provides marker-based project type detection for the
 // clai suggestions engine. It scans upward from cwd for marker files/directories
 // to determine active project types (e.g., "go", "rust", "docker").
+// Within a repository the scan stops at the repository root and also looks
+// one level down from each scanned directory, so the nested packages of a
+// monorepo contribute their types.
 //
 // Detection results are cached with a configurable TTL to avoid re-scanning
 // on every command. A .clai/project.yaml override file can be used to manually
@@ -25,6 +28,20 @@ const DefaultCacheTTL = 60 * time.Second
 // DefaultMaxScanDepth limits how many parent directories we scan upward.
 const DefaultMaxScanDepth = 10
 
+// DefaultMaxNestedDirs limits how many subdirectories of one directory are
+// scanned for nested packages.
+const DefaultMaxNestedDirs = 64
+
+// skippedNestedDirs are subdirectories that hold dependencies or build
+// output rather than nested packages.
+var skippedNestedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"build":        true,
+	"dist":         true,
+}
+
 // Marker defines a filesystem marker that indicates a project type.
 type Marker struct {
 	// Name is the filename or directory name to look for.
@@ -40,6 +57,19 @@ type Marker struct {
 	IsGlob bool
 }
 
+// NewMarker returns the marker for a user-defined project type. A pattern
+// with glob metacharacters matches any file name it describes; a trailing
+// slash makes it match a directory instead of a file.
+func NewMarker(pattern, projectType string) Marker {
+	name := strings.TrimSuffix(pattern, "/")
+	return Marker{
+		Name:        name,
+		ProjectType: projectType,
+		IsDir:       name != pattern,
+		IsGlob:      strings.ContainsAny(name, "*?["),
+	}
+}
+
 // builtinMarkers defines the default set of marker files per spec appendix 20.1.
 // Order does not matter; all markers are checked and multiple types can be detected.
 var builtinMarkers = []Marker{
@@ -65,17 +95,29 @@ type overrideConfig struct {
 	ProjectTypes []string `yaml:"project_types"`
 }
 
+// ScopedType is a detected project type with the directory whose marker
+// revealed it.
+type ScopedType struct {
+	Type string
+	Dir  string
+
+	// Nested is set for types of a package in a subdirectory of the
+	// scanned directories rather than in cwd or one of its parents.
+	Nested bool
+}
+
 // cacheEntry holds a cached detection result.
 type cacheEntry struct {
 	expiresAt time.Time
-	types     []string
+	types     []ScopedType
 }
 
 // DetectorOptions configures the project type detector.
 type DetectorOptions struct {
-	ExtraMarkers []Marker
-	CacheTTL     time.Duration
-	MaxScanDepth int
+	ExtraMarkers  []Marker
+	CacheTTL      time.Duration
+	MaxScanDepth  int
+	MaxNestedDirs int
 }
 
 // Detector detects project types by scanning for marker files.
@@ -96,6 +138,9 @@ func NewDetector(opts DetectorOptions) *Detector {
 	if opts.MaxScanDepth <= 0 {
 		opts.MaxScanDepth = DefaultMaxScanDepth
 	}
+	if opts.MaxNestedDirs <= 0 {
+		opts.MaxNestedDirs = DefaultMaxNestedDirs
+	}
 
 	markers := make([]Marker, len(builtinMarkers), len(builtinMarkers)+len(opts.ExtraMarkers))
 	copy(markers, builtinMarkers)
@@ -112,13 +157,31 @@ func NewDetector(opts DetectorOptions) *Detector {
 // Detect returns the detected project types for the given directory.
 // Results are cached; subsequent calls within the TTL return cached results.
 func (d *Detector) Detect(cwd string) []string {
+	return typeNames(d.DetectScoped(cwd, ""))
+}
+
+// DetectInRepo returns the project types for cwd within the repository at
+// repoRoot, nearest first: those of cwd and its parents up to repoRoot,
+// then those of packages nested below them.
+func (d *Detector) DetectInRepo(cwd, repoRoot string) []string {
+	return typeNames(d.DetectScoped(cwd, repoRoot))
+}
+
+// DetectScoped returns the project types for cwd with the directory each was
+// found in. Each type is reported once, at the directory nearest to cwd.
+// With a repoRoot containing cwd, the upward scan stops at repoRoot and the
+// direct subdirectories of each scanned directory are checked for nested
+// packages; otherwise only cwd and its parents are scanned.
+// Results are cached; subsequent calls within the TTL return cached results.
+func (d *Detector) DetectScoped(cwd, repoRoot string) []ScopedType {
 	if cwd == "" {
 		return nil
 	}
+	key := cacheKey(cwd, repoRoot)
 
 	// Check cache first
 	d.mu.RLock()
-	entry, ok := d.cache[cwd]
+	entry, ok := d.cache[key]
 	d.mu.RUnlock()
 
 	if ok && d.nowFunc().Before(entry.expiresAt) {
@@ -126,11 +189,11 @@ func (d *Detector) Detect(cwd string) []string {
 	}
 
 	// Compute fresh result
-	types := d.detect(cwd)
+	types := d.detect(cwd, repoRoot)
 
 	// Store in cache
 	d.mu.Lock()
-	d.cache[cwd] = &cacheEntry{
+	d.cache[key] = &cacheEntry{
 		types:     types,
 		expiresAt: d.nowFunc().Add(d.opts.CacheTTL),
 	}
@@ -139,38 +202,121 @@ func (d *Detector) Detect(cwd string) []string {
 	return types
 }
 
+// cacheKey identifies a detection of cwd within repoRoot.
+func cacheKey(cwd, repoRoot string) string {
+	if repoRoot == "" {
+		return cwd
+	}
+	return cwd + "\x00" + repoRoot
+}
+
 // detect performs the actual detection (uncached).
-func (d *Detector) detect(cwd string) []string {
+func (d *Detector) detect(cwd, repoRoot string) []ScopedType {
+	dir := filepath.Clean(cwd)
+
 	// Check for .clai/project.yaml override first
 	if override := d.readOverride(cwd); len(override) > 0 {
-		return override
+		types := make([]ScopedType, len(override))
+		for i, t := range override {
+			types[i] = ScopedType{Type: t, Dir: dir}
+		}
+		return types
+	}
+
+	root := ""
+	if repoRoot != "" && isWithin(dir, filepath.Clean(repoRoot)) {
+		root = filepath.Clean(repoRoot)
 	}
 
 	// Scan upward from cwd for marker files
 	seen := make(map[string]bool)
-	var types []string
-
-	dir := filepath.Clean(cwd)
+	var types []ScopedType
+	var scanned []string
 	for depth := 0; depth < d.opts.MaxScanDepth; depth++ {
-		for _, marker := range d.markers {
-			if seen[marker.ProjectType] {
-				continue
-			}
+		types = d.appendTypes(types, seen, dir, false)
+		scanned = append(scanned, dir)
 
-			if d.markerExists(dir, marker) {
-				seen[marker.ProjectType] = true
-				types = append(types, marker.ProjectType)
-			}
-		}
-
-		// Move to parent directory
+		// Move to parent directory, stopping at the repository root
 		parent := filepath.Dir(dir)
-		if parent == dir {
-			break // Reached filesystem root
+		if parent == dir || dir == root {
+			break // Reached filesystem or repository root
 		}
 		dir = parent
 	}
 
+	if root == "" {
+		return types
+	}
+
+	// Scan one level down from each scanned directory for nested packages
+	for i, dir := range scanned {
+		skip := ""
+		if i > 0 {
+			skip = scanned[i-1] // already scanned on the way up
+		}
+		for _, sub := range d.nestedDirs(dir, skip) {
+			types = d.appendTypes(types, seen, sub, true)
+		}
+	}
+	return types
+}
+
+// appendTypes appends the types whose markers exist in dir and are not
+// seen yet.
+func (d *Detector) appendTypes(types []ScopedType, seen map[string]bool, dir string, nested bool) []ScopedType {
+	for _, marker := range d.markers {
+		if seen[marker.ProjectType] {
+			continue
+		}
+
+		if d.markerExists(dir, marker) {
+			seen[marker.ProjectType] = true
+			types = append(types, ScopedType{Type: marker.ProjectType, Dir: dir, Nested: nested})
+		}
+	}
+	return types
+}
+
+// nestedDirs returns the subdirectories of dir that may hold nested
+// packages, except skip, in name order.
+func (d *Detector) nestedDirs(dir, skip string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || strings.HasPrefix(name, ".") || skippedNestedDirs[name] {
+			continue
+		}
+		sub := filepath.Join(dir, name)
+		if sub == skip {
+			continue
+		}
+		dirs = append(dirs, sub)
+		if len(dirs) >= d.opts.MaxNestedDirs {
+			break
+		}
+	}
+	return dirs
+}
+
+// isWithin reports whether dir is root or below it.
+func isWithin(dir, root string) bool {
+	rel, err := filepath.Rel(root, dir)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// typeNames returns the types of scoped, in order.
+func typeNames(scoped []ScopedType) []string {
+	if scoped == nil {
+		return nil
+	}
+	types := make([]string, len(scoped))
+	for i, t := range scoped {
+		types[i] = t.Type
+	}
 	return types
 }
 
@@ -237,10 +383,14 @@ func parseOverride(data []byte) []string {
 	return types
 }
 
-// Invalidate removes the cached result for the given directory.
+// Invalidate removes the cached results for the given directory.
 func (d *Detector) Invalidate(cwd string) {
 	d.mu.Lock()
-	delete(d.cache, cwd)
+	for key := range d.cache {
+		if key == cwd || strings.HasPrefix(key, cwd+"\x00") {
+			delete(d.cache, key)
+		}
+	}
 	d.mu.Unlock()
 }
 
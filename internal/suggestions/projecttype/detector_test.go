@@ -324,6 +324,51 @@ func TestDetect_ScanUpwardExactDepth(t *testing.T) {
 	assert.Contains(t, types, "go")
 }
 
+func TestDetectInRepo_NestedPackages(t *testing.T) {
+	t.Parallel()
+
+	root := createTestDir(t)
+	touchFile(t, filepath.Join(root, "go.mod"))
+	touchFile(t, filepath.Join(root, "apps", "web", "package.json"))
+	touchFile(t, filepath.Join(root, "apps", "api", "Dockerfile"))
+	touchFile(t, filepath.Join(root, "apps", "web", "node_modules", "dep", "Cargo.toml"))
+	touchFile(t, filepath.Join(root, "vendor", "Gemfile"))
+
+	d := NewDetector(DetectorOptions{})
+
+	// From apps/api the sibling package is one level below apps.
+	api := filepath.Join(root, "apps", "api")
+	assert.Equal(t, []ScopedType{
+		{Type: "docker", Dir: api},
+		{Type: "go", Dir: root},
+		{Type: "node", Dir: filepath.Join(root, "apps", "web"), Nested: true},
+	}, d.DetectScoped(api, root))
+
+	// At the root the packages below apps are out of reach; vendored and
+	// installed dependencies never count.
+	assert.Equal(t, []string{"go"}, d.DetectInRepo(root, root))
+	assert.Equal(t, []string{"go", "docker", "node"}, d.DetectInRepo(filepath.Join(root, "apps"), root))
+
+	// Without a repository root only cwd and its parents are scanned.
+	assert.Equal(t, []string{"docker", "go"}, d.Detect(api))
+}
+
+func TestDetectInRepo_StopsAtRepoRoot(t *testing.T) {
+	t.Parallel()
+
+	parent := createTestDir(t)
+	touchFile(t, filepath.Join(parent, "Makefile"))
+	root := filepath.Join(parent, "repo")
+	touchFile(t, filepath.Join(root, "Cargo.toml"))
+
+	d := NewDetector(DetectorOptions{})
+	assert.Equal(t, []string{"rust"}, d.DetectInRepo(root, root))
+	assert.Equal(t, []string{"rust", "make"}, d.Detect(root))
+
+	// A cwd outside the repository falls back to the plain upward scan.
+	assert.Equal(t, []string{"make"}, d.DetectInRepo(parent, root))
+}
+
 func TestDetect_OverrideFile(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,302 @@
+// Package recompute rebuilds the learned frequency, transition and slot
+// statistics of the V2 suggestions database from its command events.
+//
+// The write path updates command_stat, command_time_stat, transition_stat
+// and slot_stat incrementally as events arrive. Bulk operations such as
+// purges, imports or a restored backup can leave them out of step with
+// command_event; Run replays every event in order, exactly as the write path
+// would have, and replaces the stored rows with the result.
+package recompute
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/runger/clai/internal/suggestions/archive"
+	"github.com/runger/clai/internal/suggestions/ingest"
+	"github.com/runger/clai/internal/suggestions/normalize"
+)
+
+// Options configures a recomputation.
+type Options struct {
+	// Scope limits the rebuild to one scope, e.g. "global", "repo:x" or
+	// "host:laptop". Empty rebuilds every scope.
+	Scope string
+
+	// TauMs is the decay time constant. Defaults to ingest.DefaultTauMs.
+	TauMs int64
+}
+
+// Result summarizes a recomputation.
+type Result struct {
+	Events          int64 // Events replayed
+	CommandStats    int64 // command_stat rows written
+	TimeStats       int64 // command_time_stat rows written
+	TransitionStats int64 // transition_stat rows written
+	SlotStats       int64 // slot_stat rows written
+}
+
+type commandKey struct {
+	scope, templateID string
+}
+
+type commandAgg struct {
+	score      float64
+	lastSeenMs int64
+	successes  int
+	failures   int
+}
+
+type timeKey struct {
+	scope, templateID string
+	weekday, hour     int
+}
+
+type timeAgg struct {
+	count      int
+	lastSeenMs int64
+}
+
+type transitionKey struct {
+	scope, prevID, nextID string
+}
+
+type slotKey struct {
+	scope, templateID, value string
+	index                    int
+}
+
+// decayed is a weight that decays with time, and how often it was bumped.
+type decayed struct {
+	weight     float64
+	lastSeenMs int64
+	count      int
+}
+
+// bump decays d to tsMs and adds one use, as the write path does.
+func (d *decayed) bump(tsMs, tauMs int64) {
+	if d.count > 0 {
+		d.weight *= math.Exp(-float64(tsMs-d.lastSeenMs) / float64(tauMs))
+	}
+	d.weight++
+	d.count++
+	d.lastSeenMs = tsMs
+}
+
+// stats holds the recomputed aggregates.
+type stats struct {
+	commands    map[commandKey]*commandAgg
+	times       map[timeKey]*timeAgg
+	transitions map[transitionKey]*decayed
+	slots       map[slotKey]*decayed
+	scope       string
+	tauMs       int64
+}
+
+func (s *stats) wants(scope string) bool {
+	return s.scope == "" || s.scope == scope
+}
+
+// Run recomputes the statistics in one transaction: the stored rows in
+// scope are deleted and replaced by the replayed ones, so concurrent readers
+// see either the old or the new statistics. The suggestion cache is cleared.
+func Run(ctx context.Context, db *sql.DB, opts Options) (Result, error) {
+	s := &stats{
+		commands:    make(map[commandKey]*commandAgg),
+		times:       make(map[timeKey]*timeAgg),
+		transitions: make(map[transitionKey]*decayed),
+		slots:       make(map[slotKey]*decayed),
+		scope:       opts.Scope,
+		tauMs:       opts.TauMs,
+	}
+	if s.tauMs <= 0 {
+		s.tauMs = ingest.DefaultTauMs
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // best-effort rollback on error
+
+	var res Result
+	if res.Events, err = s.replay(ctx, tx); err != nil {
+		return Result{}, err
+	}
+	if err := s.deleteStored(ctx, tx); err != nil {
+		return Result{}, err
+	}
+	if err := s.write(ctx, tx, &res); err != nil {
+		return Result{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM suggestion_cache`); err != nil {
+		return Result{}, fmt.Errorf("clear suggestion cache: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Result{}, fmt.Errorf("commit transaction: %w", err)
+	}
+	return res, nil
+}
+
+// replay feeds every templated event to the aggregates in the order the
+// write path saw them, and returns how many it replayed.
+func (s *stats) replay(ctx context.Context, tx *sql.Tx) (int64, error) {
+	archived, err := archive.CmdRaws(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT e.id, e.session_id, e.ts_ms, e.cwd, COALESCE(e.repo_key, ''), e.template_id,
+		       e.cmd_raw, e.exit_code, COALESCE(s.host, '')
+		FROM command_event e
+		LEFT JOIN session s ON s.id = e.session_id
+		WHERE e.template_id IS NOT NULL AND e.template_id != ''
+		ORDER BY e.ts_ms, e.id
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("query events: %w", err)
+	}
+	defer rows.Close()
+
+	normalizer := normalize.NewNormalizer()
+	prevTemplate := make(map[string]string) // session ID -> last template ID
+	var n int64
+	for rows.Next() {
+		var id, tsMs int64
+		var sessionID, cwd, repoKey, templateID, cmdRaw, host string
+		var exitCode sql.NullInt64
+		if err := rows.Scan(&id, &sessionID, &tsMs, &cwd, &repoKey, &templateID, &cmdRaw, &exitCode, &host); err != nil {
+			return 0, fmt.Errorf("scan event: %w", err)
+		}
+		if cmdRaw == "" {
+			cmdRaw = archived[id]
+		}
+		success := !exitCode.Valid || exitCode.Int64 == 0
+
+		for _, scope := range ingest.EventScopes(repoKey, host, cwd) {
+			if !s.wants(scope) {
+				continue
+			}
+			s.addCommand(commandKey{scope, templateID}, success, tsMs)
+			if prev := prevTemplate[sessionID]; prev != "" {
+				bumpKey(s.transitions, transitionKey{scope, prev, templateID}, tsMs, s.tauMs)
+			}
+		}
+		weekday, hour := ingest.TimeBucket(tsMs)
+		for _, scope := range ingest.TimeStatScopes(repoKey, host) {
+			if s.wants(scope) {
+				s.addTime(timeKey{scope, templateID, weekday, hour}, tsMs)
+			}
+		}
+		if cmdRaw != "" {
+			_, slots := normalizer.Normalize(cmdRaw)
+			for _, scope := range ingest.SlotScopes(repoKey) {
+				if !s.wants(scope) {
+					continue
+				}
+				for _, slot := range slots {
+					bumpKey(s.slots, slotKey{scope: scope, templateID: templateID, index: slot.Index, value: slot.Value}, tsMs, s.tauMs)
+				}
+			}
+		}
+		prevTemplate[sessionID] = templateID
+		n++
+	}
+	return n, rows.Err()
+}
+
+func (s *stats) addCommand(key commandKey, success bool, tsMs int64) {
+	agg := s.commands[key]
+	if agg == nil {
+		agg = &commandAgg{}
+		s.commands[key] = agg
+	} else {
+		agg.score *= math.Exp(-float64(tsMs-agg.lastSeenMs) / float64(s.tauMs))
+	}
+	agg.score++
+	agg.lastSeenMs = tsMs
+	if success {
+		agg.successes++
+	} else {
+		agg.failures++
+	}
+}
+
+func (s *stats) addTime(key timeKey, tsMs int64) {
+	agg := s.times[key]
+	if agg == nil {
+		agg = &timeAgg{}
+		s.times[key] = agg
+	}
+	agg.count++
+	agg.lastSeenMs = tsMs
+}
+
+// bumpKey bumps the weight stored under key, creating it on first use.
+func bumpKey[K comparable](m map[K]*decayed, key K, tsMs, tauMs int64) {
+	d := m[key]
+	if d == nil {
+		d = &decayed{}
+		m[key] = d
+	}
+	d.bump(tsMs, tauMs)
+}
+
+// deleteStored deletes the stored rows the recomputation replaces.
+func (s *stats) deleteStored(ctx context.Context, tx *sql.Tx) error {
+	for _, table := range []string{"command_stat", "command_time_stat", "transition_stat", "slot_stat"} {
+		query := `DELETE FROM ` + table
+		var args []any
+		if s.scope != "" {
+			query += ` WHERE scope = ?`
+			args = append(args, s.scope)
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("clear %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func (s *stats) write(ctx context.Context, tx *sql.Tx, res *Result) error {
+	for k, agg := range s.commands {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO command_stat (scope, template_id, score, success_count, failure_count, last_seen_ms)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, k.scope, k.templateID, agg.score, agg.successes, agg.failures, agg.lastSeenMs); err != nil {
+			return fmt.Errorf("insert command_stat: %w", err)
+		}
+		res.CommandStats++
+	}
+	for k, agg := range s.times {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO command_time_stat (scope, template_id, weekday, hour, count, last_seen_ms)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, k.scope, k.templateID, k.weekday, k.hour, agg.count, agg.lastSeenMs); err != nil {
+			return fmt.Errorf("insert command_time_stat: %w", err)
+		}
+		res.TimeStats++
+	}
+	for k, d := range s.transitions {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO transition_stat (scope, prev_template_id, next_template_id, weight, count, last_seen_ms)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, k.scope, k.prevID, k.nextID, d.weight, d.count, d.lastSeenMs); err != nil {
+			return fmt.Errorf("insert transition_stat: %w", err)
+		}
+		res.TransitionStats++
+	}
+	for k, d := range s.slots {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO slot_stat (scope, template_id, slot_index, value, weight, count, last_seen_ms)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, k.scope, k.templateID, k.index, k.value, d.weight, d.count, d.lastSeenMs); err != nil {
+			return fmt.Errorf("insert slot_stat: %w", err)
+		}
+		res.SlotStats++
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package recompute
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/runger/clai/internal/suggestions/archive"
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/event"
+	"github.com/runger/clai/internal/suggestions/ingest"
+	"github.com/runger/clai/internal/suggestions/normalize"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	d, err := suggestdb.Open(context.Background(), suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "suggestions_v2.db"),
+		SkipLock: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { d.Close() })
+	t.Cleanup(func() { ingest.ReleaseStatements(d.DB()) })
+
+	_, err = d.DB().Exec(`INSERT INTO session (id, shell, started_at_ms, host) VALUES ('s1', 'zsh', 0, 'laptop')`)
+	require.NoError(t, err)
+	return d.DB()
+}
+
+// record runs cmds through the write path as one session, failing the
+// commands listed in failed.
+func record(t *testing.T, db *sql.DB, tsMs int64, failed map[int]bool, cmds ...string) {
+	t.Helper()
+	normalizer := normalize.NewNormalizer()
+	prev := ""
+	for i, cmd := range cmds {
+		ev := &event.CommandEvent{
+			Version:   1,
+			Type:      event.EventTypeCommandEnd,
+			TS:        tsMs + int64(i)*60_000,
+			SessionID: "s1",
+			Shell:     event.ShellZsh,
+			Cwd:       "/home/user/project",
+			CmdRaw:    cmd,
+			Host:      "laptop",
+		}
+		if failed[i] {
+			ev.ExitCode = 1
+		}
+		_, slots := normalizer.Normalize(cmd)
+		res, err := ingest.WritePath(context.Background(), db, &ingest.WritePathContext{
+			Event:          ev,
+			RepoKey:        "repo:project",
+			PrevTemplateID: prev,
+			PreNorm:        normalize.PreNormalize(cmd, normalize.PreNormConfig{}),
+			Slots:          slots,
+			NowMs:          ev.TS,
+		}, &ingest.WritePathConfig{})
+		require.NoError(t, err)
+		prev = res.TemplateID
+	}
+}
+
+type statRow struct {
+	key            string
+	weight         float64
+	count1, count2 int
+	lastSeenMs     int64
+}
+
+// snapshot returns every command, time, transition and slot stat row.
+func snapshot(t *testing.T, db *sql.DB) map[string]statRow {
+	t.Helper()
+	out := make(map[string]statRow)
+	for _, query := range []string{
+		`SELECT 'cmd|' || scope || '|' || template_id, score, success_count, failure_count, last_seen_ms FROM command_stat`,
+		`SELECT 'time|' || scope || '|' || template_id || '|' || weekday || '|' || hour, 0, count, 0, last_seen_ms FROM command_time_stat`,
+		`SELECT 'tr|' || scope || '|' || prev_template_id || '|' || next_template_id, weight, count, 0, last_seen_ms FROM transition_stat`,
+		`SELECT 'slot|' || scope || '|' || template_id || '|' || slot_index || '|' || value, weight, count, 0, last_seen_ms FROM slot_stat`,
+	} {
+		rows, err := db.Query(query)
+		require.NoError(t, err)
+		for rows.Next() {
+			var r statRow
+			require.NoError(t, rows.Scan(&r.key, &r.weight, &r.count1, &r.count2, &r.lastSeenMs))
+			out[r.key] = r
+		}
+		require.NoError(t, rows.Err())
+		rows.Close()
+	}
+	return out
+}
+
+func assertSameStats(t *testing.T, want, got map[string]statRow) {
+	t.Helper()
+	require.Len(t, got, len(want))
+	for key, w := range want {
+		g, ok := got[key]
+		if !assert.True(t, ok, "missing %s", key) {
+			continue
+		}
+		assert.InDelta(t, w.weight, g.weight, 1e-9, key)
+		assert.Equal(t, w.count1, g.count1, key)
+		assert.Equal(t, w.count2, g.count2, key)
+		assert.Equal(t, w.lastSeenMs, g.lastSeenMs, key)
+	}
+}
+
+func TestRun_MatchesWritePath(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	record(t, db, 1_000_000, map[int]bool{2: true}, "git checkout main", "make build", "make test", "git checkout feature", "make build")
+	// Archived events are replayed from their archive chunks.
+	_, err := archive.Archive(ctx, db, 1_100_000, 0, 10000)
+	require.NoError(t, err)
+	want := snapshot(t, db)
+
+	_, err = db.Exec(`DELETE FROM transition_stat`)
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE command_time_stat SET count = 7`)
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE command_stat SET score = 99, success_count = 42`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO slot_stat (scope, template_id, slot_index, value, weight, count, last_seen_ms)
+		VALUES ('global', 'gone', 0, 'stale', 1, 1, 0)`)
+	require.NoError(t, err)
+
+	res, err := Run(ctx, db, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), res.Events)
+	assert.Equal(t, int64(len(want)), res.CommandStats+res.TimeStats+res.TransitionStats+res.SlotStats)
+	assertSameStats(t, want, snapshot(t, db))
+}
+
+func TestRun_Scope(t *testing.T) {
+	t.Parallel()
+	db := newTestDB(t)
+	ctx := context.Background()
+	record(t, db, 1_000_000, nil, "make build", "make test")
+
+	_, err := db.Exec(`UPDATE command_stat SET score = 99`)
+	require.NoError(t, err)
+
+	res, err := Run(ctx, db, Options{Scope: "repo:project"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.CommandStats)
+
+	var stale, fixed int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM command_stat WHERE score = 99`).Scan(&stale))
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM command_stat WHERE scope = 'repo:project' AND score < 99`).Scan(&fixed))
+	assert.Equal(t, 2, fixed)
+	assert.NotZero(t, stale, "other scopes are left alone")
+}
@@ -0,0 +1,325 @@
+// Package rekey remaps learned suggestion data to new template IDs.
+//
+// Template IDs are derived from normalized commands, so a change to the
+// normalizer or to the ID scheme changes the ID of commands that were
+// already learned. Run re-normalizes every stored template, computes its
+// new ID and rewrites every table that references the old one, keeping
+// stats, transitions and workflows attached to the command.
+package rekey
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/normalize"
+)
+
+// Options configures a re-keying run.
+type Options struct {
+	// Normalize maps a stored cmd_norm to its form under the new
+	// normalizer. Defaults to normalize.PreNormalize without aliases.
+	Normalize func(cmdNorm string) string
+
+	// Strategy computes the new IDs. Defaults to normalize.CurrentIDStrategy().
+	Strategy normalize.IDStrategy
+
+	// DryRun computes the mapping without writing anything.
+	DryRun bool
+}
+
+// Result summarizes a re-keying run.
+type Result struct {
+	// Rows counts rewritten rows per table.
+	Rows map[string]int64
+
+	// Templates is the number of templates examined.
+	Templates int
+
+	// Remapped is the number of templates whose ID changed.
+	Remapped int
+
+	// Merged is the number of remapped templates whose new ID already
+	// existed. Where both had a row for the same key, the rows are merged
+	// into the one stored under the new ID (see mergeRules).
+	Merged int
+}
+
+// mapping is one template whose ID changes.
+type mapping struct {
+	oldID   string
+	newID   string
+	newNorm string
+}
+
+// NeedsRekey reports whether any stored template was produced by an older
+// ID scheme than the current one.
+func NeedsRekey(ctx context.Context, db *sql.DB) (bool, error) {
+	current := normalize.CurrentIDStrategy().Version()
+
+	rows, err := db.QueryContext(ctx, `SELECT template_id FROM command_template`)
+	if err != nil {
+		return false, fmt.Errorf("query templates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return false, fmt.Errorf("scan template: %w", err)
+		}
+		if v := normalize.TemplateIDVersionOf(id); v > 0 && v < current {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// Run re-keys all templates in db. All writes happen in one transaction.
+func Run(ctx context.Context, db *sql.DB, opts Options) (*Result, error) {
+	if opts.Normalize == nil {
+		opts.Normalize = func(cmdNorm string) string {
+			return normalize.PreNormalize(cmdNorm, normalize.PreNormConfig{}).CmdNorm
+		}
+	}
+	if opts.Strategy == nil {
+		opts.Strategy = normalize.CurrentIDStrategy()
+	}
+
+	mappings, total, err := computeMappings(ctx, db, opts)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Templates: total, Remapped: len(mappings), Rows: make(map[string]int64)}
+	if opts.DryRun || len(mappings) == 0 {
+		return result, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after commit
+
+	// Move every old ID to a temporary ID first, so a template whose new
+	// ID is another template's old ID cannot be merged into it by accident.
+	for _, m := range mappings {
+		if _, err := remapTemplate(ctx, tx, m.oldID, tempID(m.oldID), "", nil); err != nil {
+			return nil, fmt.Errorf("re-key template %s: %w", m.oldID, err)
+		}
+	}
+	for _, m := range mappings {
+		merged, err := remapTemplate(ctx, tx, tempID(m.oldID), m.newID, m.newNorm, result.Rows)
+		if err != nil {
+			return nil, fmt.Errorf("re-key template %s: %w", m.oldID, err)
+		}
+		if merged {
+			result.Merged++
+		}
+	}
+
+	// Cached suggestions reference the old IDs.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM suggestion_cache`); err != nil {
+		return nil, fmt.Errorf("clear suggestion cache: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return result, nil
+}
+
+// computeMappings returns the templates whose ID changes under opts, and
+// the total number of templates examined. Bootstrap and other IDs not
+// produced by an IDStrategy are left alone.
+func computeMappings(ctx context.Context, db *sql.DB, opts Options) ([]mapping, int, error) {
+	rows, err := db.QueryContext(ctx, `SELECT template_id, cmd_norm FROM command_template ORDER BY template_id`)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query templates: %w", err)
+	}
+	defer rows.Close()
+
+	var mappings []mapping
+	total := 0
+	for rows.Next() {
+		var id, cmdNorm string
+		if err := rows.Scan(&id, &cmdNorm); err != nil {
+			return nil, 0, fmt.Errorf("scan template: %w", err)
+		}
+		if normalize.TemplateIDVersionOf(id) == 0 {
+			continue
+		}
+		total++
+		newNorm := opts.Normalize(cmdNorm)
+		if newID := opts.Strategy.TemplateID(newNorm); newID != id {
+			mappings = append(mappings, mapping{oldID: id, newID: newID, newNorm: newNorm})
+		}
+	}
+	return mappings, total, rows.Err()
+}
+
+// mergeRules say how remapTemplate folds a row into one already keyed by
+// the new template ID, by column name. Counts and decayed scores add up,
+// the last-seen time is the later one and success rates are weighted by
+// count. Other columns keep the surviving row's value.
+var mergeRules = map[string]string{
+	"count":             "dst.count + src.count",
+	"success_count":     "dst.success_count + src.success_count",
+	"failure_count":     "dst.failure_count + src.failure_count",
+	"dismissal_count":   "dst.dismissal_count + src.dismissal_count",
+	"score":             "dst.score + src.score",
+	"weight":            "dst.weight + src.weight",
+	"first_seen_ms":     "min(dst.first_seen_ms, src.first_seen_ms)",
+	"last_seen_ms":      "max(dst.last_seen_ms, src.last_seen_ms)",
+	"last_dismissed_ms": "max(dst.last_dismissed_ms, src.last_dismissed_ms)",
+	"success_rate": `(dst.success_rate * dst.count + src.success_rate * src.count) /
+		max(dst.count + src.count, 1)`,
+}
+
+// tempID returns the placeholder ID used for id between the two passes of Run.
+func tempID(id string) string {
+	return "rekey:" + id
+}
+
+// remapTemplate moves everything referencing from to the template ID to.
+// Rows that would collide with a row already keyed by to are merged into
+// it and then dropped. A non-empty norm replaces the template's cmd_norm. Rewritten rows are added
+// to counts when it is non-nil. Reports whether to already existed as a
+// template.
+func remapTemplate(ctx context.Context, tx *sql.Tx, from, to, norm string, counts map[string]int64) (bool, error) {
+	res, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO command_template (template_id, cmd_norm, tags, slot_count, first_seen_ms, last_seen_ms)
+		SELECT ?1,
+			CASE WHEN ?2 = '' THEN cmd_norm ELSE ?2 END,
+			tags,
+			CASE WHEN ?2 = '' THEN slot_count ELSE ?3 END,
+			first_seen_ms, last_seen_ms
+		FROM command_template WHERE template_id = ?4
+	`, to, norm, normalize.CountSlots(norm), from)
+	if err != nil {
+		return false, err
+	}
+	inserted, _ := res.RowsAffected()
+	if inserted == 0 {
+		if err := mergeRows(ctx, tx, "command_template", "template_id", from, to); err != nil {
+			return false, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM command_template WHERE template_id = ?`, from); err != nil {
+		return false, err
+	}
+	addCount(counts, "command_template", 1)
+
+	for _, c := range suggestdb.V2TemplateColumns {
+		if err := mergeRows(ctx, tx, c.Table, c.Column, from, to); err != nil {
+			return false, err
+		}
+		q := fmt.Sprintf(`UPDATE OR IGNORE %s SET %s = ? WHERE %s = ?`, c.Table, c.Column, c.Column)
+		res, err := tx.ExecContext(ctx, q, to, from)
+		if err != nil {
+			return false, fmt.Errorf("%s.%s: %w", c.Table, c.Column, err)
+		}
+		n, _ := res.RowsAffected()
+		addCount(counts, c.Table, n)
+
+		q = fmt.Sprintf(`DELETE FROM %s WHERE %s = ?`, c.Table, c.Column)
+		if _, err := tx.ExecContext(ctx, q, from); err != nil {
+			return false, fmt.Errorf("%s.%s: %w", c.Table, c.Column, err)
+		}
+	}
+
+	if norm != "" {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE command_event SET cmd_norm = ? WHERE template_id = ?`, norm, to); err != nil {
+			return false, fmt.Errorf("command_event.cmd_norm: %w", err)
+		}
+	}
+
+	// Template IDs embed a fixed-length hash, so a substring replace only
+	// ever matches whole IDs.
+	for _, table := range suggestdb.V2TemplateChainTables {
+		q := fmt.Sprintf(`UPDATE %s SET template_chain = REPLACE(template_chain, ?1, ?2)
+			WHERE instr(template_chain, ?1) > 0`, table)
+		res, err := tx.ExecContext(ctx, q, from, to)
+		if err != nil {
+			return false, fmt.Errorf("%s.template_chain: %w", table, err)
+		}
+		n, _ := res.RowsAffected()
+		addCount(counts, table, n)
+	}
+
+	return inserted == 0, nil
+}
+
+// mergeRows folds each row of table whose column is from into the row that
+// has the same primary key with to in its place, as mergeRules say. The
+// rows keyed by from are left for the caller to remove. Tables without a
+// mergeable column, or where column is not part of the primary key, are
+// left alone.
+func mergeRows(ctx context.Context, tx *sql.Tx, table, column, from, to string) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT name, pk FROM pragma_table_info('%s')`, table))
+	if err != nil {
+		return fmt.Errorf("%s columns: %w", table, err)
+	}
+	var sets, match []string
+	keyed := false
+	for rows.Next() {
+		var name string
+		var pk int
+		if err := rows.Scan(&name, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("%s columns: %w", table, err)
+		}
+		switch {
+		case name == column:
+			keyed = pk > 0
+		case pk > 0:
+			match = append(match, fmt.Sprintf("src.%s = dst.%s", name, name))
+		}
+		if rule, ok := mergeRules[name]; ok {
+			sets = append(sets, name+" = "+rule)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s columns: %w", table, err)
+	}
+	if !keyed || len(sets) == 0 {
+		return nil
+	}
+
+	q := fmt.Sprintf(`UPDATE %[1]s AS dst SET %[2]s
+		FROM %[1]s AS src
+		WHERE dst.%[3]s = ?2 AND src.%[3]s = ?1`, table, strings.Join(sets, ", "), column)
+	for _, m := range match {
+		q += " AND " + m
+	}
+	if _, err := tx.ExecContext(ctx, q, from, to); err != nil {
+		return fmt.Errorf("merge %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+func addCount(counts map[string]int64, table string, n int64) {
+	if counts != nil && n > 0 {
+		counts[table] += n
+	}
+}
+
+// Summary formats the per-table row counts of r as "table=n" pairs.
+func (r *Result) Summary() string {
+	tables := make([]string, 0, len(r.Rows))
+	for table := range r.Rows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	parts := make([]string, len(tables))
+	for i, table := range tables {
+		parts[i] = fmt.Sprintf("%s=%d", table, r.Rows[table])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,225 @@
+package rekey
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/normalize"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := suggestdb.Open(context.Background(), suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "suggestions_v2.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db.DB()
+}
+
+func mustExec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("exec %q: %v", query, err)
+	}
+}
+
+func count(t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("query %q: %v", query, err)
+	}
+	return n
+}
+
+// seedTemplate records cmdNorm as a template with a command stat, an
+// event and a workflow step, all under the current ID scheme.
+func seedTemplate(t *testing.T, db *sql.DB, cmdNorm string, successCount int) string {
+	t.Helper()
+	id := normalize.ComputeTemplateID(cmdNorm)
+	mustExec(t, db, `INSERT INTO command_template (template_id, cmd_norm, slot_count, first_seen_ms, last_seen_ms)
+		VALUES (?, ?, 0, 1, 2)`, id, cmdNorm)
+	mustExec(t, db, `INSERT INTO command_stat (scope, template_id, score, success_count, failure_count, last_seen_ms)
+		VALUES ('global', ?, 1, ?, 0, 1)`, id, successCount)
+	mustExec(t, db, `INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, template_id)
+		VALUES ('s1', 1, '/', ?, ?, ?)`, cmdNorm, cmdNorm, id)
+	return id
+}
+
+func TestRun_NoChangesWithCurrentNormalizer(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	seedTemplate(t, db, "make test", 1)
+	seedTemplate(t, db, "git status", 1)
+	mustExec(t, db, `INSERT INTO command_template (template_id, cmd_norm, slot_count, first_seen_ms, last_seen_ms)
+		VALUES ('bootstrap:git push', 'git push', 0, 1, 1)`)
+
+	result, err := Run(context.Background(), db, Options{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Templates != 2 || result.Remapped != 0 {
+		t.Errorf("Run() = %+v, want 2 templates and nothing remapped", result)
+	}
+}
+
+func TestRun_NewVersionRemapsAllReferences(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	build := seedTemplate(t, db, "make build", 3)
+	test := seedTemplate(t, db, "make test", 2)
+	mustExec(t, db, `INSERT INTO transition_stat (scope, prev_template_id, next_template_id, weight, count, last_seen_ms)
+		VALUES ('global', ?, ?, 1, 1, 1)`, build, test)
+	mustExec(t, db, `INSERT INTO workflow_pattern (pattern_id, template_chain, display_chain, scope, step_count, occurrence_count, last_seen_ms)
+		VALUES ('wf', ?, '[]', 'global', 2, 1, 1)`, `["`+build+`","`+test+`"]`)
+
+	v2 := normalize.SHA256Strategy{V: 2}
+	result, err := Run(context.Background(), db, Options{Strategy: v2})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Remapped != 2 || result.Merged != 0 {
+		t.Errorf("Run() = %+v, want 2 remapped", result)
+	}
+
+	newBuild, newTest := v2.TemplateID("make build"), v2.TemplateID("make test")
+	if n := count(t, db, `SELECT success_count FROM command_stat WHERE template_id = ?`, newBuild); n != 3 {
+		t.Errorf("command_stat success_count = %d, want 3", n)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM transition_stat WHERE prev_template_id = ? AND next_template_id = ?`,
+		newBuild, newTest); n != 1 {
+		t.Error("transition_stat not re-keyed")
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM workflow_pattern WHERE template_chain = ?`,
+		`["`+newBuild+`","`+newTest+`"]`); n != 1 {
+		t.Error("workflow_pattern chain not re-keyed")
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM command_template WHERE template_id IN (?, ?)`, build, test); n != 0 {
+		t.Error("old templates left behind")
+	}
+
+	needs, err := NeedsRekey(context.Background(), db)
+	if err != nil || needs {
+		t.Errorf("NeedsRekey() = %v, %v after re-keying", needs, err)
+	}
+}
+
+func TestRun_ChainedRemapDoesNotMerge(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	a := seedTemplate(t, db, "a", 1)
+	b := seedTemplate(t, db, "b", 2)
+
+	// "a" now normalizes to "b", and "b" to "c": a's stats must land on
+	// b's new ID, not follow b to c.
+	renorm := map[string]string{"a": "b", "b": "c"}
+	result, err := Run(context.Background(), db, Options{
+		Normalize: func(cmdNorm string) string { return renorm[cmdNorm] },
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Remapped != 2 || result.Merged != 0 {
+		t.Errorf("Run() = %+v, want 2 remapped, 0 merged", result)
+	}
+	if n := count(t, db, `SELECT success_count FROM command_stat WHERE template_id = ?`, b); n != 1 {
+		t.Errorf("stat for new b = %d, want a's 1", n)
+	}
+	c := normalize.ComputeTemplateID("c")
+	if n := count(t, db, `SELECT success_count FROM command_stat WHERE template_id = ?`, c); n != 2 {
+		t.Errorf("stat for c = %d, want b's 2", n)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM command_template WHERE template_id = ?`, a); n != 0 {
+		t.Error("template a left behind")
+	}
+	var norm string
+	if err := db.QueryRow(`SELECT cmd_norm FROM command_event WHERE template_id = ?`, c).Scan(&norm); err != nil || norm != "c" {
+		t.Errorf("command_event cmd_norm = %q, %v; want c", norm, err)
+	}
+}
+
+func TestRun_MergeCombinesRows(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	la := seedTemplate(t, db, "ls -la", 5)
+	al := seedTemplate(t, db, "ls -al", 7)
+	mustExec(t, db, `UPDATE command_stat SET score = 2, failure_count = 1, last_seen_ms = 50 WHERE template_id = ?`, al)
+	mustExec(t, db, `UPDATE command_template SET first_seen_ms = 0, last_seen_ms = 40 WHERE template_id = ?`, la)
+	mustExec(t, db, `INSERT INTO failure_recovery
+		(scope, failed_template_id, exit_code_class, recovery_template_id, weight, count, success_rate, last_seen_ms)
+		VALUES ('global', 'make', 'code:2', ?, 1, 1, 1.0, 10), ('global', 'make', 'code:2', ?, 1, 3, 0.0, 30)`, la, al)
+
+	result, err := Run(context.Background(), db, Options{
+		Normalize: func(string) string { return "ls <flags>" },
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Remapped != 2 || result.Merged != 1 {
+		t.Errorf("Run() = %+v, want 2 remapped, 1 merged", result)
+	}
+	id := normalize.ComputeTemplateID("ls <flags>")
+	if n := count(t, db, `SELECT COUNT(*) FROM command_stat WHERE template_id = ?`, id); n != 1 {
+		t.Errorf("command_stat rows = %d, want 1", n)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM command_event WHERE template_id = ?`, id); n != 2 {
+		t.Errorf("command_event rows = %d, want both events", n)
+	}
+
+	var score float64
+	var success, failure, lastSeen int
+	if err := db.QueryRow(`SELECT score, success_count, failure_count, last_seen_ms FROM command_stat
+		WHERE template_id = ?`, id).Scan(&score, &success, &failure, &lastSeen); err != nil {
+		t.Fatalf("merged command_stat: %v", err)
+	}
+	if score != 3 || success != 12 || failure != 1 || lastSeen != 50 {
+		t.Errorf("merged command_stat = score %v, %d/%d, last seen %d; want 3, 12/1, 50",
+			score, success, failure, lastSeen)
+	}
+	var firstSeen int
+	if err := db.QueryRow(`SELECT first_seen_ms, last_seen_ms FROM command_template WHERE template_id = ?`,
+		id).Scan(&firstSeen, &lastSeen); err != nil {
+		t.Fatalf("merged command_template: %v", err)
+	}
+	if firstSeen != 0 || lastSeen != 40 {
+		t.Errorf("merged template seen %d..%d, want 0..40", firstSeen, lastSeen)
+	}
+	var recoveries int
+	var rate float64
+	if err := db.QueryRow(`SELECT count, success_rate FROM failure_recovery WHERE recovery_template_id = ?`,
+		id).Scan(&recoveries, &rate); err != nil {
+		t.Fatalf("merged failure_recovery: %v", err)
+	}
+	if recoveries != 4 || rate != 0.25 {
+		t.Errorf("merged failure_recovery = %d at %v, want 4 at 0.25", recoveries, rate)
+	}
+}
+
+func TestRun_DryRunWritesNothing(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	id := seedTemplate(t, db, "make test", 1)
+
+	result, err := Run(context.Background(), db, Options{Strategy: normalize.SHA256Strategy{V: 2}, DryRun: true})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Remapped != 1 {
+		t.Errorf("Remapped = %d, want 1", result.Remapped)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM command_template WHERE template_id = ?`, id); n != 1 {
+		t.Error("dry run modified command_template")
+	}
+}
@@ -0,0 +1,199 @@
+package replay
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/runger/clai/internal/suggestions/normalize"
+	"github.com/runger/clai/internal/suggestions/suggest"
+)
+
+// Metrics summarizes how well the scorer predicted each next command.
+// A command the scorer did not suggest within its top-k counts as a miss.
+type Metrics struct {
+	// Predictions is the number of commands the scorer was asked to predict.
+	Predictions int
+
+	// Hits1 is the number of commands that were the top suggestion.
+	Hits1 int
+
+	// Hits5 is the number of commands among the top five suggestions.
+	Hits5 int
+
+	// ReciprocalRanks is the sum of 1/rank over all predictions.
+	ReciprocalRanks float64
+}
+
+// HitAt1 returns the share of commands that were the top suggestion.
+func (m *Metrics) HitAt1() float64 {
+	return m.share(m.Hits1)
+}
+
+// HitAt5 returns the share of commands among the top five suggestions.
+func (m *Metrics) HitAt5() float64 {
+	return m.share(m.Hits5)
+}
+
+// MRR returns the mean reciprocal rank of the commands.
+func (m *Metrics) MRR() float64 {
+	if m.Predictions == 0 {
+		return 0
+	}
+	return m.ReciprocalRanks / float64(m.Predictions)
+}
+
+func (m *Metrics) share(n int) float64 {
+	if m.Predictions == 0 {
+		return 0
+	}
+	return float64(n) / float64(m.Predictions)
+}
+
+// record counts one prediction that ranked the command at rank, starting
+// at 1, or 0 if it was not suggested.
+func (m *Metrics) record(rank int) {
+	m.Predictions++
+	if rank <= 0 {
+		return
+	}
+	if rank == 1 {
+		m.Hits1++
+	}
+	if rank <= 5 {
+		m.Hits5++
+	}
+	m.ReciprocalRanks += 1 / float64(rank)
+}
+
+// evalStep locates one command of the sessions passed to Evaluate.
+type evalStep struct {
+	tsMs    int64
+	session int
+	cmd     int
+}
+
+// Evaluate replays sessions into one fresh database in timestamp order, as
+// the commands happened, and asks the scorer for the next command before
+// each one is recorded. The first warmup commands are only recorded, to
+// build up history to predict from. Expected top-k lists are ignored.
+func (r *Runner) Evaluate(ctx context.Context, tmpDir string, sessions []Session, warmup int) (*Metrics, error) {
+	runtime, cleanup, err := r.setupReplayRuntime(ctx, tmpDir, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	var steps []evalStep
+	for i, session := range sessions {
+		for j, cmd := range session.Commands {
+			steps = append(steps, evalStep{tsMs: r.resolveReplayTimestamp(j, cmd), session: i, cmd: j})
+		}
+	}
+	// Stable, so commands with equal timestamps keep their session order.
+	slices.SortStableFunc(steps, func(a, b evalStep) int { return cmp.Compare(a.tsMs, b.tsMs) })
+
+	metrics := &Metrics{}
+	// The previous command of each session, normalized.
+	prevCmds := make([]string, len(sessions))
+	for n, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		session := sessions[step.session]
+		cmd := session.Commands[step.cmd]
+		input := &replayIngestInput{
+			session:        session,
+			cmdIdx:         step.cmd,
+			cmd:            cmd,
+			prevTemplateID: prevCmds[step.session],
+			tsMs:           step.tsMs,
+			cwd:            r.resolveReplayCWD(cmd),
+			cmdRaw:         resolveReplayCmdRaw(cmd),
+		}
+		if n >= warmup {
+			rank, err := r.rankNextCommand(ctx, runtime, input)
+			if err != nil {
+				return nil, err
+			}
+			metrics.record(rank)
+		}
+		preNorm, err := r.ingestReplayCommand(ctx, runtime, input)
+		if err != nil {
+			return nil, err
+		}
+		prevCmds[step.session] = preNorm.CmdNorm
+	}
+	return metrics, nil
+}
+
+// rankNextCommand asks the scorer for suggestions at the prompt input's
+// command was typed at and returns the command's rank among them, starting
+// at 1, or 0 if it was not suggested.
+func (r *Runner) rankNextCommand(ctx context.Context, runtime *replayRuntime, input *replayIngestInput) (int, error) {
+	prevExitCode, prevFailed := previousReplayStatus(input.session.Commands, input.cmdIdx)
+	suggestions, err := runtime.scorer.Suggest(ctx, &suggest.SuggestContext{
+		SessionID:    r.cfg.SessionID,
+		RepoKey:      r.resolveReplayRepoKey(input.cmd),
+		LastCmd:      input.prevTemplateID,
+		LastExitCode: prevExitCode,
+		LastFailed:   prevFailed,
+		Cwd:          input.cwd,
+		NowMs:        input.tsMs,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("suggest before command %d of session %q: %w", input.cmdIdx, input.session.ID, err)
+	}
+	target := normalize.PreNormalize(input.cmdRaw, normalize.PreNormConfig{}).CmdNorm
+	for i := range suggestions {
+		if suggestions[i].Command == target {
+			return i + 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// LoadHistory reads the most recent limit commands of the suggestions
+// database at db into sessions, oldest first, for Evaluate. Deleted
+// commands and those of incognito sessions are left out; commands
+// imported without an exit code count as successful.
+func LoadHistory(ctx context.Context, db *sql.DB, limit int) ([]Session, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT session_id, ts_ms, cwd, repo_key, cmd_raw, exit_code FROM (
+			SELECT id, session_id, ts_ms, cwd, COALESCE(repo_key, '') AS repo_key,
+			       cmd_raw, COALESCE(exit_code, 0) AS exit_code
+			FROM command_event
+			WHERE ephemeral = 0 AND deleted = 0
+			ORDER BY ts_ms DESC, id DESC
+			LIMIT ?
+		)
+		ORDER BY ts_ms, id
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query command_event: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	index := make(map[string]int)
+	for rows.Next() {
+		var sessionID string
+		var cmd Command
+		if err := rows.Scan(&sessionID, &cmd.TimestampMs, &cmd.CWD, &cmd.RepoKey, &cmd.CmdRaw, &cmd.ExitCode); err != nil {
+			return nil, fmt.Errorf("scan command_event: %w", err)
+		}
+		i, ok := index[sessionID]
+		if !ok {
+			i = len(sessions)
+			index[sessionID] = i
+			sessions = append(sessions, Session{ID: sessionID})
+		}
+		sessions[i].Commands = append(sessions[i].Commands, cmd)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query command_event: %w", err)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,112 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/runger/clai/internal/suggestions/db"
+)
+
+// repeatedSessions returns n copies of session, each starting 10s after the
+// previous one.
+func repeatedSessions(session Session, n int) []Session {
+	sessions := make([]Session, n)
+	for i := range sessions {
+		s := Session{ID: fmt.Sprintf("%s-%d", session.ID, i)}
+		for _, cmd := range session.Commands {
+			cmd.TimestampMs += int64(i) * 10_000
+			s.Commands = append(s.Commands, cmd)
+		}
+		sessions[i] = s
+	}
+	return sessions
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	var m Metrics
+	assert.Zero(t, m.MRR())
+	for _, rank := range []int{1, 2, 6, 0} {
+		m.record(rank)
+	}
+	assert.Equal(t, 4, m.Predictions)
+	assert.InDelta(t, 0.25, m.HitAt1(), 1e-9)
+	assert.InDelta(t, 0.5, m.HitAt5(), 1e-9)
+	assert.InDelta(t, (1+0.5+1.0/6)/4, m.MRR(), 1e-9)
+}
+
+func TestEvaluate_LearnsRepeatedWorkflow(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultRunnerConfig()
+	cfg.TopK = 10
+	runner := NewRunner(cfg)
+	sessions := repeatedSessions(gitWorkflowSession, 6)
+
+	m, err := runner.Evaluate(context.Background(), t.TempDir(), sessions, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 25, m.Predictions, "every command after the warm-up is predicted")
+	assert.Greater(t, m.HitAt5(), 0.5, "a workflow seen before should be predicted")
+	assert.GreaterOrEqual(t, m.HitAt5(), m.HitAt1())
+	assert.Greater(t, m.MRR(), 0.0)
+
+	again, err := runner.Evaluate(context.Background(), t.TempDir(), sessions, 5)
+	require.NoError(t, err)
+	assert.Equal(t, m, again, "evaluation must be deterministic")
+}
+
+func TestEvaluate_AllWarmup(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner(DefaultRunnerConfig())
+	m, err := runner.Evaluate(context.Background(), t.TempDir(), TestCorpus, 100)
+	require.NoError(t, err)
+	assert.Zero(t, m.Predictions)
+}
+
+func TestLoadHistory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	d, err := db.Open(ctx, db.Options{Path: filepath.Join(t.TempDir(), "history.db"), SkipLock: true})
+	require.NoError(t, err)
+	defer d.Close()
+
+	_, err = d.DB().ExecContext(ctx, `
+		INSERT INTO session (id, shell, started_at_ms) VALUES ('a', 'zsh', 1), ('b', 'bash', 1);
+		INSERT INTO command_event (session_id, ts_ms, cwd, repo_key, cmd_raw, cmd_norm, exit_code, ephemeral, deleted) VALUES
+			('a', 1000, '/src', '/src', 'ls', 'ls', 0, 0, 0),
+			('a', 2000, '/src', '/src', 'make test', 'make test', 2, 0, 0),
+			('b', 2500, '/tmp', NULL, 'cat notes', 'cat notes', NULL, 0, 0),
+			('a', 3000, '/src', '/src', 'secret', 'secret', 0, 1, 0),
+			('a', 3500, '/src', '/src', 'oops', 'oops', 0, 0, 1),
+			('a', 4000, '/src', '/src', 'git push', 'git push', 0, 0, 0)
+	`)
+	require.NoError(t, err)
+
+	sessions, err := LoadHistory(ctx, d.DB(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []Session{
+		{ID: "a", Commands: []Command{
+			{CmdRaw: "ls", CWD: "/src", RepoKey: "/src", TimestampMs: 1000},
+			{CmdRaw: "make test", CWD: "/src", RepoKey: "/src", TimestampMs: 2000, ExitCode: 2},
+			{CmdRaw: "git push", CWD: "/src", RepoKey: "/src", TimestampMs: 4000},
+		}},
+		{ID: "b", Commands: []Command{
+			{CmdRaw: "cat notes", CWD: "/tmp", TimestampMs: 2500},
+		}},
+	}, sessions)
+
+	// The limit keeps the most recent commands.
+	sessions, err = LoadHistory(ctx, d.DB(), 2)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "b", sessions[0].ID)
+	assert.Equal(t, "git push", sessions[1].Commands[0].CmdRaw)
+}
@@ -48,6 +48,10 @@ type Command struct {
 	// TimestampMs is the command timestamp in milliseconds.
 	TimestampMs int64
 
+	// RepoKey is the repository the command ran in. If empty, the runner's
+	// RepoKey is used.
+	RepoKey string
+
 	// ExitCode is the exit status of the command.
 	ExitCode int
 }
@@ -113,6 +117,10 @@ type RunnerConfig struct {
 	// Default: 1000.
 	TimestampIncrementMs int64
 
+	// Weights are the ranking weights of the scorer.
+	// Default: suggest.DefaultWeights().
+	Weights *suggest.Weights
+
 	// TopK is the number of suggestions to request.
 	// Default: suggest.DefaultTopK (3).
 	TopK int
@@ -182,7 +190,7 @@ func (r *Runner) Replay(ctx context.Context, tmpDir string, session Session) ([]
 		return nil, nil
 	}
 
-	runtime, cleanup, err := r.setupReplayRuntime(ctx, tmpDir, session)
+	runtime, cleanup, err := r.setupReplayRuntime(ctx, tmpDir, session.Expected)
 	if err != nil {
 		return nil, err
 	}
@@ -211,13 +219,16 @@ type replayRuntime struct {
 	expectationMap map[int][]int
 }
 
-func (r *Runner) setupReplayRuntime(ctx context.Context, tmpDir string, session Session) (*replayRuntime, func(), error) {
+func (r *Runner) setupReplayRuntime(ctx context.Context, tmpDir string, expected []ExpectedTopK) (*replayRuntime, func(), error) {
 	d, err := openReplayDB(tmpDir)
 	if err != nil {
 		return nil, nil, err
 	}
-	cleanup := func() { d.Close() }
 	sqlDB := d.DB()
+	cleanup := func() {
+		ingest.ReleaseStatements(sqlDB)
+		d.Close()
+	}
 	if err = createScorerTables(ctx, sqlDB); err != nil {
 		cleanup()
 		return nil, nil, fmt.Errorf("create scorer tables: %w", err)
@@ -237,12 +248,16 @@ func (r *Runner) setupReplayRuntime(ctx context.Context, tmpDir string, session
 		cleanup()
 		return nil, nil, fmt.Errorf("create transition store: %w", err)
 	}
+	weights := suggest.DefaultWeights()
+	if r.cfg.Weights != nil {
+		weights = *r.cfg.Weights
+	}
 	scorer, err := suggest.NewScorer(&suggest.ScorerDependencies{
 		DB:              sqlDB,
 		FreqStore:       freqStore,
 		TransitionStore: transStore,
 	}, &suggest.ScorerConfig{
-		Weights:    suggest.DefaultWeights(),
+		Weights:    weights,
 		Amplifiers: suggest.DefaultAmplifierConfig(),
 		TopK:       r.cfg.TopK,
 	})
@@ -262,7 +277,7 @@ func (r *Runner) setupReplayRuntime(ctx context.Context, tmpDir string, session
 		freqStore:      freqStore,
 		transStore:     transStore,
 		scorer:         scorer,
-		expectationMap: buildExpectationMap(session.Expected),
+		expectationMap: buildExpectationMap(expected),
 	}, finalCleanup, nil
 }
 
@@ -328,6 +343,13 @@ func (r *Runner) resolveReplayCWD(cmd Command) string {
 	return r.cfg.CWD
 }
 
+func (r *Runner) resolveReplayRepoKey(cmd Command) string {
+	if cmd.RepoKey != "" {
+		return cmd.RepoKey
+	}
+	return r.cfg.RepoKey
+}
+
 func resolveReplayCmdRaw(cmd Command) string {
 	if cmd.CmdRaw != "" {
 		return cmd.CmdRaw
@@ -360,23 +382,24 @@ func (r *Runner) ingestReplayCommand(
 		CmdRaw:    input.cmdRaw,
 		ExitCode:  input.cmd.ExitCode,
 	}
+	repoKey := r.resolveReplayRepoKey(input.cmd)
 	prevExitCode, prevFailed := previousReplayStatus(input.session.Commands, input.cmdIdx)
-	wctx := ingest.PrepareWriteContext(ev, r.cfg.RepoKey, "", input.prevTemplateID, prevExitCode, prevFailed, nil)
+	wctx := ingest.PrepareWriteContext(ev, repoKey, "", input.prevTemplateID, prevExitCode, prevFailed, nil)
 	if _, err := ingest.WritePath(ctx, runtime.sqlDB, wctx, &ingest.WritePathConfig{}); err != nil {
-		return normalize.PreNormResult{}, fmt.Errorf("write path for command %d (%q): %w", input.cmdIdx, input.cmd.CmdNorm, err)
+		return normalize.PreNormResult{}, fmt.Errorf("write path for command %d (%q): %w", input.cmdIdx, input.cmdRaw, err)
 	}
 	preNorm := normalize.PreNormalize(input.cmdRaw, normalize.PreNormConfig{})
 	if err := runtime.freqStore.Update(ctx, score.ScopeGlobal, preNorm.CmdNorm, input.tsMs); err != nil {
 		return normalize.PreNormResult{}, fmt.Errorf("update global frequency for command %d: %w", input.cmdIdx, err)
 	}
-	if err := runtime.freqStore.Update(ctx, r.cfg.RepoKey, preNorm.CmdNorm, input.tsMs); err != nil {
+	if err := runtime.freqStore.Update(ctx, repoKey, preNorm.CmdNorm, input.tsMs); err != nil {
 		return normalize.PreNormResult{}, fmt.Errorf("update repo frequency for command %d: %w", input.cmdIdx, err)
 	}
 	if input.prevTemplateID != "" {
 		if err := runtime.transStore.RecordTransition(ctx, score.ScopeGlobal, input.prevTemplateID, preNorm.CmdNorm, input.tsMs); err != nil {
 			return normalize.PreNormResult{}, fmt.Errorf("record global transition for command %d: %w", input.cmdIdx, err)
 		}
-		if err := runtime.transStore.RecordTransition(ctx, r.cfg.RepoKey, input.prevTemplateID, preNorm.CmdNorm, input.tsMs); err != nil {
+		if err := runtime.transStore.RecordTransition(ctx, repoKey, input.prevTemplateID, preNorm.CmdNorm, input.tsMs); err != nil {
 			return normalize.PreNormResult{}, fmt.Errorf("record repo transition for command %d: %w", input.cmdIdx, err)
 		}
 	}
@@ -484,17 +507,6 @@ func createScorerTables(ctx context.Context, sqlDB *sql.DB) error {
 		);
 		CREATE INDEX IF NOT EXISTS idx_transition_prev
 			ON transition(scope, prev_norm);
-
-		-- V1 project_task table used by discovery.Service
-		CREATE TABLE IF NOT EXISTS project_task (
-			repo_key      TEXT NOT NULL,
-			kind          TEXT NOT NULL,
-			name          TEXT NOT NULL,
-			command       TEXT NOT NULL,
-			description   TEXT,
-			discovered_ts INTEGER NOT NULL,
-			PRIMARY KEY(repo_key, kind, name)
-		);
 	`)
 	if err != nil {
 		return err
@@ -7,6 +7,8 @@ import (
 	"database/sql"
 	"log/slog"
 	"time"
+
+	"github.com/runger/clai/internal/suggestions/clock"
 )
 
 // Default configuration values per spec Section 20.1.
@@ -27,6 +29,7 @@ const (
 // Policy defines the retention policy for stored data.
 type Policy struct {
 	Logger        *slog.Logger
+	Clock         clock.Clock // Supplies "now" for Purge and EstimatePurgeCount; defaults to the system clock
 	RetentionDays int
 	AutoVacuum    bool
 }
@@ -63,6 +66,7 @@ func NewPurger(db *sql.DB, policy *Policy) *Purger {
 	if policy.Logger == nil {
 		policy.Logger = slog.Default()
 	}
+	policy.Clock = clock.OrSystem(policy.Clock)
 
 	return &Purger{
 		db:     db,
@@ -87,7 +91,7 @@ type PurgeResult struct {
 //
 //	DELETE FROM command_event WHERE ts < (now_ms - retention_days * 86400000)
 func (p *Purger) Purge(ctx context.Context) (*PurgeResult, error) {
-	return p.PurgeAt(ctx, time.Now().UnixMilli())
+	return p.PurgeAt(ctx, clock.NowMs(p.policy.Clock))
 }
 
 // PurgeAt deletes old command_event data using a specific current time.
@@ -169,7 +173,7 @@ func (p *Purger) GetRetentionDays() int {
 
 // EstimatePurgeCount returns the estimated number of rows that would be purged.
 func (p *Purger) EstimatePurgeCount(ctx context.Context) (int64, error) {
-	return p.EstimatePurgeCountAt(ctx, time.Now().UnixMilli())
+	return p.EstimatePurgeCountAt(ctx, clock.NowMs(p.policy.Clock))
 }
 
 // EstimatePurgeCountAt returns the estimated purge count for a specific time.
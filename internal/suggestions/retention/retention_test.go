@@ -6,10 +6,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/runger/clai/internal/suggestions/clock"
+
 	_ "modernc.org/sqlite"
 )
 
@@ -118,6 +121,32 @@ func TestPurger_Purge(t *testing.T) {
 	assert.Equal(t, 2, count)
 }
 
+func TestPurger_Purge_UsesPolicyClock(t *testing.T) {
+	t.Parallel()
+
+	db := createTestDB(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	insertEvent(t, db, start.UnixMilli())
+
+	clk := clock.NewManual(start)
+	p := NewPurger(db, &Policy{RetentionDays: 30, Clock: clk})
+	ctx := context.Background()
+
+	result, err := p.Purge(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), result.DeletedEvents)
+
+	// Travel past the retention window.
+	clk.Advance(31 * 24 * time.Hour)
+	estimate, err := p.EstimatePurgeCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), estimate)
+
+	result, err = p.Purge(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.DeletedEvents)
+}
+
 func TestPurger_Purge_NoOldData(t *testing.T) {
 	t.Parallel()
 
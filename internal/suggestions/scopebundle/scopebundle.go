@@ -0,0 +1,288 @@
+// Package scopebundle exports and imports the learned suggestion data of a
+// single repository scope in the V2 suggestions database.
+//
+// A bundle holds the repo-scoped aggregates (command, transition, slot,
+// pipeline and failure-recovery stats), discovered tasks, workflow patterns
+// and the command templates they reference. Importing a bundle into a fresh
+// clone gives a teammate good suggestions before they have typed anything.
+// Raw command history is never included.
+package scopebundle
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FormatVersion is the bundle format written by Export.
+const FormatVersion = 1
+
+// RepoScopePrefix prefixes repository scopes on the command line.
+const RepoScopePrefix = "repo:"
+
+// ErrEmptyScope is returned by Export when the scope has no learned data.
+var ErrEmptyScope = errors.New("no learned data for scope")
+
+// Row is one table row keyed by column name.
+type Row map[string]any
+
+// Bundle is the portable form of one repository scope.
+type Bundle struct {
+	Tables     map[string][]Row `json:"tables"`
+	RepoKey    string           `json:"repo_key"`
+	Version    int              `json:"version"`
+	ExportedMs int64            `json:"exported_ms"`
+}
+
+// Result summarizes an Import call.
+type Result struct {
+	Imported int64 // Rows inserted
+	Skipped  int64 // Rows already present locally
+}
+
+// table describes how one table is exported and re-scoped on import.
+// where selects the scope's rows; ?1 is bound to the repo key.
+type table struct {
+	name     string
+	where    string
+	scopeCol string // column rewritten to the target repo key, if any
+	columns  []string
+}
+
+// tables are listed in import order: workflow_pattern precedes workflow_step
+// for its foreign key.
+var tables = []table{
+	{
+		name: "command_template",
+		where: `template_id IN (
+			SELECT template_id FROM command_stat WHERE scope = ?1
+			UNION SELECT prev_template_id FROM transition_stat WHERE scope = ?1
+			UNION SELECT next_template_id FROM transition_stat WHERE scope = ?1
+			UNION SELECT prev_template_id FROM pipeline_transition WHERE scope = ?1
+			UNION SELECT next_template_id FROM pipeline_transition WHERE scope = ?1
+			UNION SELECT failed_template_id FROM failure_recovery WHERE scope = ?1
+			UNION SELECT recovery_template_id FROM failure_recovery WHERE scope = ?1
+			UNION SELECT ws.template_id FROM workflow_step ws
+				JOIN workflow_pattern wp ON wp.pattern_id = ws.pattern_id WHERE wp.scope = ?1)`,
+		columns: []string{"template_id", "cmd_norm", "tags", "slot_count", "first_seen_ms", "last_seen_ms"},
+	},
+	{
+		name: "command_stat", where: "scope = ?1", scopeCol: "scope",
+		columns: []string{"scope", "template_id", "score", "success_count", "failure_count", "last_seen_ms"},
+	},
+	{
+		name: "transition_stat", where: "scope = ?1", scopeCol: "scope",
+		columns: []string{"scope", "prev_template_id", "next_template_id", "weight", "count", "last_seen_ms"},
+	},
+	{
+		name: "slot_stat", where: "scope = ?1", scopeCol: "scope",
+		columns: []string{"scope", "template_id", "slot_index", "value", "weight", "count", "last_seen_ms"},
+	},
+	{
+		name: "slot_correlation", where: "scope = ?1", scopeCol: "scope",
+		columns: []string{"scope", "template_id", "slot_key", "tuple_hash", "tuple_value_json", "weight", "count", "last_seen_ms"},
+	},
+	{
+		name: "pipeline_transition", where: "scope = ?1", scopeCol: "scope",
+		columns: []string{"scope", "prev_template_id", "next_template_id", "operator", "weight", "count", "last_seen_ms"},
+	},
+	{
+		name: "pipeline_pattern", where: "scope = ?1", scopeCol: "scope",
+		columns: []string{"pattern_hash", "template_chain", "operator_chain", "scope", "count", "last_seen_ms", "cmd_norm_display"},
+	},
+	{
+		name: "failure_recovery", where: "scope = ?1", scopeCol: "scope",
+		columns: []string{"scope", "failed_template_id", "exit_code_class", "recovery_template_id", "weight", "count", "success_rate", "last_seen_ms", "source"},
+	},
+	{
+		name: "workflow_pattern", where: "scope = ?1", scopeCol: "scope",
+		columns: []string{"pattern_id", "template_chain", "display_chain", "scope", "step_count", "occurrence_count", "last_seen_ms", "avg_duration_ms"},
+	},
+	{
+		name:    "workflow_step",
+		where:   "pattern_id IN (SELECT pattern_id FROM workflow_pattern WHERE scope = ?1)",
+		columns: []string{"pattern_id", "step_index", "template_id"},
+	},
+	{
+		name: "task_candidate", where: "repo_key = ?1", scopeCol: "repo_key",
+		columns: []string{"repo_key", "kind", "name", "command_text", "description", "source", "priority_boost", "source_checksum", "discovered_ms"},
+	},
+}
+
+// ParseRepoScope parses a "repo:<key>" argument and returns the key.
+func ParseRepoScope(arg string) (string, error) {
+	key, ok := strings.CutPrefix(arg, RepoScopePrefix)
+	if !ok || strings.TrimSpace(key) == "" {
+		return "", fmt.Errorf("invalid scope %q: expected %s<key>", arg, RepoScopePrefix)
+	}
+	return key, nil
+}
+
+// Export reads every row that belongs to repoKey.
+// Returns ErrEmptyScope if nothing but templates would be exported.
+func Export(ctx context.Context, db *sql.DB, repoKey string, nowMs int64) (*Bundle, error) {
+	b := &Bundle{
+		Version:    FormatVersion,
+		RepoKey:    repoKey,
+		ExportedMs: nowMs,
+		Tables:     make(map[string][]Row, len(tables)),
+	}
+
+	scoped := 0
+	for _, t := range tables {
+		rows, err := exportTable(ctx, db, t, repoKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		b.Tables[t.name] = rows
+		if t.name != "command_template" {
+			scoped += len(rows)
+		}
+	}
+	if scoped == 0 {
+		return nil, fmt.Errorf("%w %s%s", ErrEmptyScope, RepoScopePrefix, repoKey)
+	}
+	return b, nil
+}
+
+func exportTable(ctx context.Context, db *sql.DB, t table, repoKey string) ([]Row, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(t.columns, ", "), t.name, t.where) //nolint:gosec // G201: table and column names are constants
+	rows, err := db.QueryContext(ctx, query, repoKey)
+	if err != nil {
+		return nil, fmt.Errorf("export %s: %w", t.name, err)
+	}
+	defer rows.Close()
+
+	var out []Row
+	for rows.Next() {
+		values := make([]any, len(t.columns))
+		ptrs := make([]any, len(t.columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("export %s: %w", t.name, err)
+		}
+		row := make(Row, len(t.columns))
+		for i, col := range t.columns {
+			if v, ok := values[i].([]byte); ok {
+				values[i] = string(v)
+			}
+			row[col] = values[i]
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("export %s: %w", t.name, err)
+	}
+	return out, nil
+}
+
+// Import merges b into the repoKey scope in a single transaction. Rows that
+// already exist locally are kept, so importing never overwrites what the
+// user has learned on their own.
+func Import(ctx context.Context, db *sql.DB, b *Bundle, repoKey string) (Result, error) {
+	var result Result
+	if b.Version != FormatVersion {
+		return result, fmt.Errorf("unsupported bundle version %d (want %d)", b.Version, FormatVersion)
+	}
+	if b.RepoKey == "" || repoKey == "" {
+		return result, errors.New("bundle and target repo key are required")
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // best-effort rollback on error
+
+	for _, t := range tables {
+		rows := b.Tables[t.name]
+		if len(rows) == 0 {
+			continue
+		}
+		query := fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (%s)", //nolint:gosec // G201: table and column names are constants
+			t.name, strings.Join(t.columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(t.columns)), ", "))
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return result, fmt.Errorf("import %s: %w", t.name, err)
+		}
+		for _, row := range rows {
+			args := importArgs(t, row, b.RepoKey, repoKey)
+			res, err := stmt.ExecContext(ctx, args...)
+			if err != nil {
+				stmt.Close()
+				return result, fmt.Errorf("import %s: %w", t.name, err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				result.Imported += n
+			} else {
+				result.Skipped++
+			}
+		}
+		stmt.Close()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Result{}, fmt.Errorf("commit: %w", err)
+	}
+	return result, nil
+}
+
+// importArgs orders row values by the table's columns and moves the row
+// from the bundle's scope to the target scope.
+func importArgs(t table, row Row, fromKey, toKey string) []any {
+	args := make([]any, len(t.columns))
+	for i, col := range t.columns {
+		v := jsonValue(row[col])
+		switch {
+		case col == t.scopeCol:
+			v = toKey
+		case t.name == "pipeline_pattern" && col == "pattern_hash":
+			// Pipeline pattern hashes carry their scope as a suffix.
+			if s, ok := v.(string); ok {
+				v = strings.TrimSuffix(s, "_"+fromKey) + "_" + toKey
+			}
+		}
+		args[i] = v
+	}
+	return args
+}
+
+// jsonValue converts numbers decoded with UseNumber back to SQL values.
+func jsonValue(v any) any {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// Write encodes b as JSON.
+func (b *Bundle) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+// Read decodes a bundle written by Write.
+func Read(r io.Reader) (*Bundle, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var b Bundle
+	if err := dec.Decode(&b); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	return &b, nil
+}
@@ -0,0 +1,206 @@
+package scopebundle
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := suggestdb.Open(context.Background(), suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "suggestions_v2.db"),
+		SkipLock: true,
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db.DB()
+}
+
+func mustExec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("exec %q: %v", query, err)
+	}
+}
+
+func count(t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("query %q: %v", query, err)
+	}
+	return n
+}
+
+// seedRepo writes a small amount of learned data for repoKey.
+func seedRepo(t *testing.T, db *sql.DB, repoKey string) {
+	t.Helper()
+	mustExec(t, db, `INSERT OR IGNORE INTO command_template (template_id, cmd_norm, slot_count, first_seen_ms, last_seen_ms)
+		VALUES ('t-build', 'make build', 0, 1, 2), ('t-test', 'make test', 0, 1, 2), ('t-unused', 'ls', 0, 1, 2)`)
+	mustExec(t, db, `INSERT INTO command_stat (scope, template_id, score, success_count, failure_count, last_seen_ms)
+		VALUES (?, 't-build', 2.5, 3, 1, 1700000000000)`, repoKey)
+	mustExec(t, db, `INSERT INTO transition_stat (scope, prev_template_id, next_template_id, weight, count, last_seen_ms)
+		VALUES (?, 't-build', 't-test', 1.5, 2, 1700000000000)`, repoKey)
+	mustExec(t, db, `INSERT INTO pipeline_pattern (pattern_hash, template_chain, operator_chain, scope, count, last_seen_ms, cmd_norm_display)
+		VALUES (?, 't-build|t-test', '&&', ?, 1, 1, 'make build && make test')`, "abc_"+repoKey, repoKey)
+	mustExec(t, db, `INSERT INTO workflow_pattern (pattern_id, template_chain, display_chain, scope, step_count, occurrence_count, last_seen_ms)
+		VALUES (?, '[]', '[]', ?, 2, 4, 1)`, "wf-"+repoKey, repoKey)
+	mustExec(t, db, `INSERT INTO workflow_step (pattern_id, step_index, template_id) VALUES (?, 0, 't-build'), (?, 1, 't-test')`,
+		"wf-"+repoKey, "wf-"+repoKey)
+	mustExec(t, db, `INSERT INTO task_candidate (repo_key, kind, name, command_text, discovered_ms)
+		VALUES (?, 'make', 'build', 'make build', 1)`, repoKey)
+}
+
+func TestExport_OnlyScopeRows(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	seedRepo(t, db, "clai")
+	mustExec(t, db, `INSERT INTO command_stat (scope, template_id, score, success_count, failure_count, last_seen_ms)
+		VALUES ('global', 't-unused', 1, 1, 0, 1), ('other', 't-unused', 1, 1, 0, 1)`)
+
+	b, err := Export(context.Background(), db, "clai", 42)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	if b.Version != FormatVersion || b.RepoKey != "clai" || b.ExportedMs != 42 {
+		t.Errorf("bundle header = %+v", b)
+	}
+	wantCounts := map[string]int{
+		"command_template": 2,
+		"command_stat":     1,
+		"transition_stat":  1,
+		"pipeline_pattern": 1,
+		"workflow_pattern": 1,
+		"workflow_step":    2,
+		"task_candidate":   1,
+	}
+	for name, want := range wantCounts {
+		if got := len(b.Tables[name]); got != want {
+			t.Errorf("len(Tables[%s]) = %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestExport_EmptyScope(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	seedRepo(t, db, "clai")
+
+	if _, err := Export(context.Background(), db, "missing", 0); !errors.Is(err, ErrEmptyScope) {
+		t.Errorf("Export() error = %v, want ErrEmptyScope", err)
+	}
+}
+
+func TestImport_RoundTripIntoOtherScope(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	src := openTestDB(t)
+	seedRepo(t, src, "clai")
+
+	b, err := Export(ctx, src, "clai", 1)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	decoded, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	dst := openTestDB(t)
+	result, err := Import(ctx, dst, decoded, "clai-fork")
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Imported != 9 || result.Skipped != 0 {
+		t.Errorf("Import() = %+v, want 9 imported", result)
+	}
+
+	var score float64
+	var success int
+	if err := dst.QueryRow(`SELECT score, success_count FROM command_stat WHERE scope = 'clai-fork' AND template_id = 't-build'`).
+		Scan(&score, &success); err != nil {
+		t.Fatalf("imported command_stat: %v", err)
+	}
+	if score != 2.5 || success != 3 {
+		t.Errorf("command_stat = (%v, %d), want (2.5, 3)", score, success)
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM pipeline_pattern WHERE pattern_hash = 'abc_clai-fork' AND scope = 'clai-fork'`); n != 1 {
+		t.Errorf("pipeline_pattern not re-scoped")
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM task_candidate WHERE repo_key = 'clai-fork'`); n != 1 {
+		t.Errorf("task_candidate not re-scoped")
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM command_stat WHERE scope = 'clai'`); n != 0 {
+		t.Errorf("rows leaked into the source scope")
+	}
+}
+
+func TestImport_KeepsLocalRows(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	src := openTestDB(t)
+	seedRepo(t, src, "clai")
+	b, err := Export(ctx, src, "clai", 1)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	dst := openTestDB(t)
+	mustExec(t, dst, `INSERT INTO command_stat (scope, template_id, score, success_count, failure_count, last_seen_ms)
+		VALUES ('clai', 't-build', 9, 9, 0, 1)`)
+
+	result, err := Import(ctx, dst, b, "clai")
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", result.Skipped)
+	}
+	var score float64
+	if err := dst.QueryRow(`SELECT score FROM command_stat WHERE scope = 'clai' AND template_id = 't-build'`).Scan(&score); err != nil {
+		t.Fatal(err)
+	}
+	if score != 9 {
+		t.Errorf("local score overwritten: %v", score)
+	}
+}
+
+func TestImport_RejectsUnknownVersion(t *testing.T) {
+	t.Parallel()
+
+	db := openTestDB(t)
+	_, err := Import(context.Background(), db, &Bundle{Version: FormatVersion + 1, RepoKey: "clai"}, "clai")
+	if err == nil {
+		t.Error("Import() expected error for unknown version")
+	}
+}
+
+func TestParseRepoScope(t *testing.T) {
+	t.Parallel()
+
+	if key, err := ParseRepoScope("repo:clai"); err != nil || key != "clai" {
+		t.Errorf("ParseRepoScope(repo:clai) = %q, %v", key, err)
+	}
+	for _, bad := range []string{"clai", "repo:", "dir:abc", "global"} {
+		if _, err := ParseRepoScope(bad); err == nil {
+			t.Errorf("ParseRepoScope(%q) expected error", bad)
+		}
+	}
+}
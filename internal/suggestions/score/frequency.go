@@ -7,7 +7,8 @@ import (
 	"database/sql"
 	"errors"
 	"math"
-	"time"
+
+	"github.com/runger/clai/internal/suggestions/clock"
 )
 
 // Default configuration values per spec Section 9.1.
@@ -28,6 +29,7 @@ type FrequencyStore struct {
 	db         *sql.DB
 	getStmt    *sql.Stmt
 	upsertStmt *sql.Stmt
+	clock      clock.Clock
 	tauMs      int64
 }
 
@@ -36,6 +38,10 @@ type FrequencyOptions struct {
 	// TauMs is the decay time constant in milliseconds.
 	// Defaults to DefaultTauMs (7 days).
 	TauMs int64
+
+	// Clock supplies "now" for methods without an explicit timestamp.
+	// Defaults to the system clock.
+	Clock clock.Clock
 }
 
 // DefaultFrequencyOptions returns the default options.
@@ -59,6 +65,7 @@ func NewFrequencyStore(db *sql.DB, opts FrequencyOptions) (*FrequencyStore, erro
 	fs := &FrequencyStore{
 		db:    db,
 		tauMs: tauMs,
+		clock: clock.OrSystem(opts.Clock),
 	}
 
 	if err := fs.prepareStatements(); err != nil {
@@ -233,7 +240,7 @@ func (fs *FrequencyStore) updateInTx(ctx context.Context, tx *sql.Tx, scope, cmd
 // GetScore retrieves the current score for a command in the given scope.
 // The returned score is the decayed score at the current time.
 func (fs *FrequencyStore) GetScore(ctx context.Context, scope, cmdNorm string) (float64, error) {
-	return fs.GetScoreAt(ctx, scope, cmdNorm, time.Now().UnixMilli())
+	return fs.GetScoreAt(ctx, scope, cmdNorm, clock.NowMs(fs.clock))
 }
 
 // GetScoreAt retrieves the decayed score at a specific time.
@@ -257,7 +264,7 @@ func (fs *FrequencyStore) GetScoreAt(ctx context.Context, scope, cmdNorm string,
 
 // GetTopCommands retrieves the top N commands by decayed score in the given scope.
 func (fs *FrequencyStore) GetTopCommands(ctx context.Context, scope string, limit int) ([]ScoredCommand, error) {
-	return fs.GetTopCommandsAt(ctx, scope, limit, time.Now().UnixMilli())
+	return fs.GetTopCommandsAt(ctx, scope, limit, clock.NowMs(fs.clock))
 }
 
 // ScoredCommand represents a command with its score.
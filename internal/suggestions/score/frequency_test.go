@@ -7,10 +7,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/runger/clai/internal/suggestions/clock"
+
 	_ "modernc.org/sqlite"
 )
 
@@ -283,6 +286,28 @@ func TestFrequencyStore_GetTopCommands(t *testing.T) {
 	})
 }
 
+func TestFrequencyStore_GetScore_UsesClock(t *testing.T) {
+	t.Parallel()
+
+	db := createTestDB(t)
+	clk := clock.NewManual(time.UnixMilli(0))
+	fs, err := NewFrequencyStore(db, FrequencyOptions{TauMs: DefaultTauMs, Clock: clk})
+	require.NoError(t, err)
+	defer fs.Close()
+
+	ctx := context.Background()
+	require.NoError(t, fs.Update(ctx, ScopeGlobal, "make test", 0))
+
+	score, err := fs.GetScore(ctx, ScopeGlobal, "make test")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, score)
+
+	clk.Advance(time.Duration(DefaultTauMs) * time.Millisecond)
+	score, err = fs.GetScore(ctx, ScopeGlobal, "make test")
+	require.NoError(t, err)
+	assert.InDelta(t, math.Exp(-1.0), score, 0.001)
+}
+
 func TestFrequencyStore_GetScore_Nonexistent(t *testing.T) {
 	t.Parallel()
 
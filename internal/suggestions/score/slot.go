@@ -7,7 +7,8 @@ import (
 	"database/sql"
 	"errors"
 	"math"
-	"time"
+
+	"github.com/runger/clai/internal/suggestions/clock"
 )
 
 // Slot histogram configuration defaults per spec Section 9.3.3.
@@ -32,6 +33,7 @@ type SlotStore struct {
 	getStmt    *sql.Stmt
 	upsertStmt *sql.Stmt
 	topKStmt   *sql.Stmt
+	clock      clock.Clock
 	tauMs      int64
 	topK       int
 }
@@ -45,6 +47,10 @@ type SlotOptions struct {
 	// TopK is the number of top values to keep per slot.
 	// Defaults to DefaultTopK (20).
 	TopK int
+
+	// Clock supplies "now" for methods without an explicit timestamp.
+	// Defaults to the system clock.
+	Clock clock.Clock
 }
 
 // DefaultSlotOptions returns the default options.
@@ -80,6 +86,7 @@ func NewSlotStore(db *sql.DB, opts SlotOptions) (*SlotStore, error) {
 		db:    db,
 		tauMs: tauMs,
 		topK:  topK,
+		clock: clock.OrSystem(opts.Clock),
 	}
 
 	if err := ss.prepareStatements(); err != nil {
@@ -243,7 +250,7 @@ func (ss *SlotStore) updateInTx(ctx context.Context, tx *sql.Tx, scope, cmdNorm
 
 // GetTopValues retrieves the top values for a slot, decayed to the current time.
 func (ss *SlotStore) GetTopValues(ctx context.Context, scope, cmdNorm string, slotIdx, limit int) ([]SlotValue, error) {
-	return ss.GetTopValuesAt(ctx, scope, cmdNorm, slotIdx, limit, time.Now().UnixMilli())
+	return ss.GetTopValuesAt(ctx, scope, cmdNorm, slotIdx, limit, clock.NowMs(ss.clock))
 }
 
 // GetTopValuesAt retrieves the top values for a slot, decayed to a specific time.
@@ -302,7 +309,7 @@ func sortSlotValues(values []SlotValue) {
 // Per spec Section 9.3.4: repo-scoped top value > global-scoped top value > last-used fallback.
 // Returns empty string if no suitable value found.
 func (ss *SlotStore) GetBestValue(ctx context.Context, cmdNorm string, slotIdx int, repoKey string) (string, error) {
-	return ss.GetBestValueAt(ctx, cmdNorm, slotIdx, repoKey, time.Now().UnixMilli())
+	return ss.GetBestValueAt(ctx, cmdNorm, slotIdx, repoKey, clock.NowMs(ss.clock))
 }
 
 // GetBestValueAt returns the best value for a slot at a specific time.
@@ -190,14 +190,15 @@ func (s *DescribeService) parseTemplateTags(row *templateRow) ([]string, bool) {
 }
 
 // queryTemplatesWithTags retrieves templates with tags joined to their most recent event.
+// Archived events have an empty cmd_raw; the template's cmd_norm stands in for it.
 func (s *DescribeService) queryTemplatesWithTags(ctx context.Context, opts SearchOptions) (*sql.Rows, error) {
 	query := `
-		SELECT ce.id, ce.cmd_raw, COALESCE(ce.repo_key, ''), ce.cwd, ce.ts_ms,
+		SELECT ce.id, COALESCE(NULLIF(ce.cmd_raw, ''), ct.cmd_norm), COALESCE(ce.repo_key, ''), ce.cwd, ce.ts_ms,
 		       ct.template_id, ct.cmd_norm, ct.tags
 		FROM command_template ct
 		JOIN command_event ce ON ce.template_id = ct.template_id
 		WHERE ct.tags IS NOT NULL AND ct.tags != 'null' AND ct.tags != ''
-		  AND ce.ephemeral = 0
+		  AND ce.ephemeral = 0 AND ce.deleted = 0
 		  AND (? = '' OR ce.repo_key = ?)
 		  AND (? = '' OR ce.cwd = ?)
 		ORDER BY ce.ts_ms DESC
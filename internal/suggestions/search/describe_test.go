@@ -36,7 +36,8 @@ func createDescribeTestDB(t *testing.T) *sql.DB {
 			cwd           TEXT NOT NULL,
 			repo_key      TEXT,
 			template_id   TEXT,
-			ephemeral     INTEGER NOT NULL DEFAULT 0
+			ephemeral     INTEGER NOT NULL DEFAULT 0,
+			deleted       INTEGER NOT NULL DEFAULT 0
 		);
 		CREATE INDEX idx_event_ts ON command_event(ts_ms);
 	`)
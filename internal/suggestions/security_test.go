@@ -96,6 +96,16 @@ func createSecurityTestDB(t *testing.T) *sql.DB { //nolint:funlen // security te
 			PRIMARY KEY(scope, template_id)
 		);
 
+		CREATE TABLE command_time_stat (
+			scope           TEXT NOT NULL,
+			template_id     TEXT NOT NULL,
+			weekday         INTEGER NOT NULL,
+			hour            INTEGER NOT NULL,
+			count           INTEGER NOT NULL,
+			last_seen_ms    INTEGER NOT NULL,
+			PRIMARY KEY(scope, template_id, weekday, hour)
+		);
+
 		CREATE TABLE slot_stat (
 			scope           TEXT NOT NULL,
 			template_id     TEXT NOT NULL,
@@ -183,6 +193,29 @@ func createSecurityTestDB(t *testing.T) *sql.DB { //nolint:funlen // security te
 			PRIMARY KEY(scope, failed_template_id, exit_code_class, recovery_template_id)
 		);
 
+		CREATE TABLE usage_hourly (
+			day        TEXT NOT NULL,
+			hour       INTEGER NOT NULL,
+			commands   INTEGER NOT NULL,
+			successes  INTEGER NOT NULL,
+			failures   INTEGER NOT NULL,
+			PRIMARY KEY(day, hour)
+		);
+
+		CREATE TABLE usage_daily_template (
+			day          TEXT NOT NULL,
+			template_id  TEXT NOT NULL,
+			count        INTEGER NOT NULL,
+			PRIMARY KEY(day, template_id)
+		);
+
+		CREATE TABLE usage_daily_dir (
+			day    TEXT NOT NULL,
+			cwd    TEXT NOT NULL,
+			count  INTEGER NOT NULL,
+			PRIMARY KEY(day, cwd)
+		);
+
 		CREATE TABLE schema_migrations (
 			version     INTEGER PRIMARY KEY,
 			applied_ms  INTEGER NOT NULL
@@ -0,0 +1,196 @@
+// Package slotcomplete completes command arguments mid-line from the values
+// recorded for them in slot_stat.
+//
+// Suggestions otherwise complete whole commands. When the text before the
+// cursor is the start of known command templates, such as "kubectl get -n ",
+// the argument being typed is a slot of those templates, and the values
+// seen for that slot, decayed by age and filtered by the partly typed word,
+// are offered as completions.
+package slotcomplete
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/runger/clai/internal/suggestions/ingest"
+	"github.com/runger/clai/internal/suggestions/normalize"
+)
+
+// DefaultLimit is the number of values returned when a request sets none.
+const DefaultLimit = 10
+
+// probeToken stands in for the argument being completed when locating its
+// slot. It must normalize to a generic argument slot.
+const probeToken = "x"
+
+// Request describes an argument completion request.
+type Request struct {
+	// Buffer is the command line up to the cursor.
+	Buffer string
+
+	// RepoKey adds the repository's slot values to the global ones.
+	RepoKey string
+
+	NowMs int64
+	Limit int
+}
+
+// Value is a completion candidate for the argument under the cursor.
+type Value struct {
+	Value string
+	Score float64 // Decayed use count, summed over matching templates and scopes
+}
+
+// Completer looks up argument completions.
+type Completer struct {
+	db         *sql.DB
+	normalizer *normalize.Normalizer
+	tauMs      int64
+}
+
+// New creates a Completer. tauMs is the decay time constant applied to slot
+// weights; zero uses ingest.DefaultTauMs, the one they are recorded with.
+func New(db *sql.DB, tauMs int64) *Completer {
+	if tauMs <= 0 {
+		tauMs = ingest.DefaultTauMs
+	}
+	return &Completer{
+		db:         db,
+		normalizer: normalize.NewNormalizer(),
+		tauMs:      tauMs,
+	}
+}
+
+// Complete returns the most used values of the argument being typed at the
+// end of req.Buffer, best first. It returns nothing when the word being
+// typed is the command itself, a flag or a subcommand, or when no recorded
+// template starts with the completed words.
+func (c *Completer) Complete(ctx context.Context, req Request) ([]Value, error) {
+	completed, partial, ok := splitBuffer(req.Buffer)
+	if !ok || strings.HasPrefix(partial, "-") {
+		return nil, nil
+	}
+	slotIndex, ok := c.nextSlotIndex(completed)
+	if !ok {
+		return nil, nil
+	}
+	prefix := normalize.PreNormalize(completed, normalize.PreNormConfig{}).CmdNorm + " "
+
+	scores, err := c.slotValues(ctx, prefix, slotIndex, partial, req)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]Value, 0, len(scores))
+	for v, score := range scores {
+		values = append(values, Value{Value: v, Score: score})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Score != values[j].Score {
+			return values[i].Score > values[j].Score
+		}
+		return values[i].Value < values[j].Value
+	})
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return values[:min(limit, len(values))], nil
+}
+
+// splitBuffer splits buffer into the completed words and the partly typed
+// last word. ok is false while the first word is still being typed.
+func splitBuffer(buffer string) (completed, partial string, ok bool) {
+	i := strings.LastIndexAny(buffer, " \t")
+	if i < 0 {
+		return "", "", false
+	}
+	completed = strings.TrimSpace(buffer[:i])
+	if completed == "" {
+		return "", "", false
+	}
+	return completed, buffer[i+1:], true
+}
+
+// nextSlotIndex returns the slot index the normalizer gives the word after
+// completed, the index ingestion recorded its values under. ok is false when
+// that word is not a slot, e.g. a subcommand.
+func (c *Completer) nextSlotIndex(completed string) (int, bool) {
+	_, slots := c.normalizer.Normalize(completed + " " + probeToken)
+	if len(slots) == 0 {
+		return 0, false
+	}
+	last := slots[len(slots)-1]
+	if last.Value != probeToken {
+		return 0, false
+	}
+	return last.Index, true
+}
+
+// slotValues sums the decayed weights of the values recorded at slotIndex
+// for templates starting with prefix that begin with partial.
+func (c *Completer) slotValues(ctx context.Context, prefix string, slotIndex int, partial string, req Request) (map[string]float64, error) {
+	scopes := ingest.SlotScopes(req.RepoKey)
+	args := []any{utf8.RuneCountInString(prefix), prefix, slotIndex}
+	for _, scope := range scopes {
+		args = append(args, scope)
+	}
+	query := `
+		SELECT t.cmd_norm, s.value, s.weight, s.last_seen_ms
+		FROM command_template t
+		JOIN slot_stat s ON s.template_id = t.template_id
+		WHERE substr(t.cmd_norm, 1, ?) = ?
+		  AND s.slot_index = ?
+		  AND s.scope IN (?` + strings.Repeat(", ?", len(scopes)-1) + `)`
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query slot values: %w", err)
+	}
+	defer rows.Close()
+
+	scores := make(map[string]float64)
+	for rows.Next() {
+		var cmdNorm, value string
+		var weight float64
+		var lastSeenMs int64
+		if err := rows.Scan(&cmdNorm, &value, &weight, &lastSeenMs); err != nil {
+			return nil, fmt.Errorf("scan slot value: %w", err)
+		}
+		if value == "" || !strings.HasPrefix(value, partial) {
+			continue
+		}
+		if !matchesTemplateWord(nextWord(cmdNorm[len(prefix):]), value) {
+			continue
+		}
+		if req.NowMs > lastSeenMs {
+			weight *= math.Exp(-float64(req.NowMs-lastSeenMs) / float64(c.tauMs))
+		}
+		scores[value] += weight
+	}
+	return scores, rows.Err()
+}
+
+// nextWord returns the first word of s.
+func nextWord(s string) string {
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// matchesTemplateWord reports whether value is what the template has at the
+// slot's position: the literal word, or a pre-normalization placeholder.
+// A flag there means the slot recorded at this index lies further along.
+func matchesTemplateWord(word, value string) bool {
+	switch word {
+	case normalize.PlaceholderPath, normalize.PlaceholderUUID, normalize.PlaceholderURL, normalize.PlaceholderNum:
+		return true
+	}
+	return word == value
+}
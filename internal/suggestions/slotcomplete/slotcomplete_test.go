@@ -0,0 +1,118 @@
+package slotcomplete
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+	"github.com/runger/clai/internal/suggestions/event"
+	"github.com/runger/clai/internal/suggestions/ingest"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	v2db, err := suggestdb.Open(context.Background(), suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "slots.db"),
+		SkipLock: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ingest.ReleaseStatements(v2db.DB())
+		v2db.Close()
+	})
+	return v2db.DB()
+}
+
+func ingestCommand(t *testing.T, db *sql.DB, cmd, repoKey string, ts int64) {
+	t.Helper()
+	ev := &event.CommandEvent{
+		Version:   event.EventVersion,
+		Type:      event.EventTypeCommandEnd,
+		SessionID: "session-1",
+		Shell:     event.ShellZsh,
+		Cwd:       "/tmp",
+		CmdRaw:    cmd,
+		TS:        ts,
+	}
+	_, err := ingest.WritePath(context.Background(), db, ingest.PrepareWriteContext(ev, repoKey, "", "", 0, false, nil), &ingest.WritePathConfig{})
+	require.NoError(t, err)
+}
+
+func values(vs []Value) []string {
+	out := make([]string, len(vs))
+	for i := range vs {
+		out[i] = vs[i].Value
+	}
+	return out
+}
+
+func TestComplete(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t)
+	ts := int64(1_000_000)
+	for _, cmd := range []string{
+		"kubectl get pods -n prod",
+		"kubectl get pods -n prod",
+		"kubectl get pods -n prod",
+		"kubectl get pods -n staging",
+		"kubectl get svc -n dev",
+		"cat ./notes.txt",
+	} {
+		ts++
+		ingestCommand(t, db, cmd, "", ts)
+	}
+	c := New(db, 0)
+
+	tests := []struct {
+		name   string
+		buffer string
+		want   []string
+	}{
+		{"flag value ranked by use", "kubectl get pods -n ", []string{"prod", "staging"}},
+		{"filtered by partial word", "kubectl get pods -n s", []string{"staging"}},
+		{"positional argument", "kubectl get ", []string{"pods", "svc"}},
+		{"placeholder in template", "cat ", []string{"./notes.txt"}},
+		{"command name", "kub", nil},
+		{"subcommand", "kubectl ", nil},
+		{"flag", "kubectl get pods -", nil},
+		{"unknown prefix", "helm install ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Complete(context.Background(), Request{Buffer: tt.buffer, NowMs: ts})
+			require.NoError(t, err)
+			if tt.want == nil {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, values(got))
+		})
+	}
+}
+
+func TestComplete_RepoScopeAndLimit(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t)
+	ingestCommand(t, db, "kubectl get pods -n prod", "", 1000)
+	ingestCommand(t, db, "kubectl get pods -n prod", "", 1001)
+	ingestCommand(t, db, "kubectl get pods -n prod", "", 1002)
+	ingestCommand(t, db, "kubectl get pods -n team", "repo:/work", 1003)
+	ingestCommand(t, db, "kubectl get pods -n team", "repo:/work", 1004)
+	ingestCommand(t, db, "kubectl get pods -n qa", "", 1005)
+	c := New(db, 0)
+
+	got, err := c.Complete(context.Background(), Request{Buffer: "kubectl get pods -n ", NowMs: 1005})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod", "team", "qa"}, values(got))
+
+	got, err = c.Complete(context.Background(), Request{Buffer: "kubectl get pods -n ", RepoKey: "repo:/work", NowMs: 1005, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team", "prod"}, values(got))
+}
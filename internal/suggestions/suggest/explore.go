@@ -0,0 +1,205 @@
+package suggest
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"sync"
+)
+
+// Exploration modes.
+//
+// Ranking only by past usage means a command that was never shown never gets
+// the feedback that could promote it. With exploration on, the scorer now
+// and then swaps the last visible suggestion for a lower-ranked candidate
+// whose usefulness is still uncertain. Its accept or dismiss feedback lands
+// in suggestion_feedback like any other, which narrows the uncertainty the
+// next exploration decision is based on.
+const (
+	ExplorationOff      = "off"
+	ExplorationEpsilon  = "epsilon"  // Promote the least-observed candidate with probability Rate
+	ExplorationThompson = "thompson" // Promote a candidate whose sampled accept rate beats the last slot
+)
+
+// ExplorationPoolFactor bounds how deep below the visible suggestions
+// exploration looks, as a multiple of TopK. Candidates further down are
+// too weakly related to the context to be worth showing.
+const ExplorationPoolFactor = 2
+
+// ExplorationConfig configures the exploration layer of the ranker.
+type ExplorationConfig struct {
+	Mode string  // ExplorationOff, ExplorationEpsilon or ExplorationThompson
+	Rate float64 // Share of requests that may explore, in [0, 1]
+
+	// Rand is the random source; nil uses a randomly seeded one. Tests
+	// set a seeded source for deterministic draws.
+	Rand *rand.Rand
+}
+
+// Enabled reports whether the configuration explores at all.
+func (c *ExplorationConfig) Enabled() bool {
+	return (c.Mode == ExplorationEpsilon || c.Mode == ExplorationThompson) && c.Rate > 0
+}
+
+// explorer holds the random state shared by a scorer and its copies.
+type explorer struct {
+	mu   sync.Mutex
+	cfg  ExplorationConfig
+	rand *rand.Rand
+}
+
+func newExplorer(cfg ExplorationConfig) *explorer {
+	r := cfg.Rand
+	if r == nil {
+		r = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())) //nolint:gosec // G404: ranking noise, not security
+	}
+	return &explorer{cfg: cfg, rand: r}
+}
+
+// armStats are the outcomes recorded for a suggestion.
+type armStats struct {
+	accepted  float64
+	dismissed float64
+}
+
+// explore possibly replaces the last of the top k ranked suggestions with a
+// candidate from the ones ranked below it and returns the top k.
+func (s *Scorer) explore(ctx context.Context, ranked []Suggestion, k int) []Suggestion {
+	e := s.explorer
+	if e == nil || !e.cfg.Enabled() || len(ranked) <= k || k < 1 {
+		return ranked[:min(k, len(ranked))]
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.rand.Float64() >= e.cfg.Rate {
+		return ranked[:k]
+	}
+
+	last := k - 1
+	pool := ranked[k:min(len(ranked), k*ExplorationPoolFactor)]
+	stats := s.feedbackStats(ctx, append([]Suggestion{ranked[last]}, pool...))
+
+	var pick int
+	switch e.cfg.Mode {
+	case ExplorationEpsilon:
+		pick = leastObserved(pool, stats)
+	case ExplorationThompson:
+		pick = e.thompsonPick(ranked[last], pool, stats)
+	}
+	if pick < 0 {
+		return ranked[:k]
+	}
+
+	top := make([]Suggestion, k)
+	copy(top, ranked[:k])
+	top[last] = pool[pick]
+	top[last].Reasons = append(append([]string(nil), top[last].Reasons...), ReasonExploration)
+
+	s.cfg.Logger.Debug("exploring suggestion",
+		"mode", e.cfg.Mode,
+		"cmd", top[last].Command,
+		"replaced", ranked[last].Command,
+	)
+	return top
+}
+
+// leastObserved returns the index of the pool candidate with the fewest
+// recorded outcomes, preferring the higher ranked on ties.
+func leastObserved(pool []Suggestion, stats map[string]armStats) int {
+	best, bestN := -1, math.Inf(1)
+	for i := range pool {
+		st := stats[pool[i].Command]
+		if n := st.accepted + st.dismissed; n < bestN {
+			best, bestN = i, n
+		}
+	}
+	return best
+}
+
+// thompsonPick draws an accept rate for the incumbent last suggestion and
+// for each pool candidate from their Beta(1+accepted, 1+dismissed)
+// posteriors and returns the index of the best candidate if its draw beats
+// the incumbent's, or -1.
+func (e *explorer) thompsonPick(incumbent Suggestion, pool []Suggestion, stats map[string]armStats) int {
+	st := stats[incumbent.Command]
+	bar := e.betaSample(1+st.accepted, 1+st.dismissed)
+
+	best := -1
+	for i := range pool {
+		st := stats[pool[i].Command]
+		if theta := e.betaSample(1+st.accepted, 1+st.dismissed); theta > bar {
+			best, bar = i, theta
+		}
+	}
+	return best
+}
+
+// betaSample draws from Beta(a, b) as X/(X+Y) with X ~ Gamma(a), Y ~ Gamma(b).
+func (e *explorer) betaSample(a, b float64) float64 {
+	x := e.gammaSample(a)
+	y := e.gammaSample(b)
+	if x+y == 0 {
+		return 0.5
+	}
+	return x / (x + y)
+}
+
+// gammaSample draws from Gamma(shape, 1) with the Marsaglia-Tsang method.
+// Shapes are always >= 1 here since posteriors start from Beta(1, 1).
+func (e *explorer) gammaSample(shape float64) float64 {
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := e.rand.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := e.rand.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
+
+// feedbackStats loads the accept and dismiss counts recorded for the
+// suggestions' commands. Edits count as accepts; "never" as a dismissal.
+// Errors leave the counts empty, which reads as maximal uncertainty.
+func (s *Scorer) feedbackStats(ctx context.Context, sugs []Suggestion) map[string]armStats {
+	stats := make(map[string]armStats, len(sugs))
+	if s.db == nil || len(sugs) == 0 {
+		return stats
+	}
+
+	args := make([]any, len(sugs))
+	for i := range sugs {
+		args[i] = sugs[i].Command
+	}
+	query := `
+		SELECT suggested_text,
+		       SUM(CASE WHEN action IN ('accepted', 'edited') THEN 1 ELSE 0 END),
+		       SUM(CASE WHEN action IN ('dismissed', 'never') THEN 1 ELSE 0 END)
+		FROM suggestion_feedback
+		WHERE suggested_text IN (?` + strings.Repeat(", ?", len(sugs)-1) + `)
+		GROUP BY suggested_text`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		s.cfg.Logger.Debug("exploration feedback query failed", "error", err)
+		return stats
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cmd string
+		var st armStats
+		if err := rows.Scan(&cmd, &st.accepted, &st.dismissed); err != nil {
+			s.cfg.Logger.Debug("exploration feedback scan failed", "error", err)
+			return stats
+		}
+		stats[cmd] = st
+	}
+	return stats
+}
@@ -0,0 +1,147 @@
+package suggest
+
+import (
+	"context"
+	"database/sql"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "modernc.org/sqlite"
+)
+
+// newExploreScorer returns a scorer that explores as configured, backed by
+// a database holding only the suggestion_feedback table.
+func newExploreScorer(t *testing.T, cfg ExplorationConfig) (*Scorer, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`
+		CREATE TABLE suggestion_feedback (
+			id              INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id      TEXT NOT NULL,
+			ts_ms           INTEGER NOT NULL,
+			prompt_prefix   TEXT,
+			suggested_text  TEXT NOT NULL,
+			action          TEXT NOT NULL,
+			executed_text   TEXT,
+			latency_ms      INTEGER
+		)
+	`)
+	require.NoError(t, err)
+
+	cfg.Rand = rand.New(rand.NewPCG(1, 2))
+	scorer, err := NewScorer(&ScorerDependencies{DB: db}, &ScorerConfig{Exploration: cfg})
+	require.NoError(t, err)
+	return scorer, db
+}
+
+func addFeedback(t *testing.T, db *sql.DB, cmd, action string, n int) {
+	t.Helper()
+	for range n {
+		_, err := db.Exec(`INSERT INTO suggestion_feedback (session_id, ts_ms, suggested_text, action)
+			VALUES ('s1', 1, ?, ?)`, cmd, action)
+		require.NoError(t, err)
+	}
+}
+
+func ranked(cmds ...string) []Suggestion {
+	sugs := make([]Suggestion, len(cmds))
+	for i, cmd := range cmds {
+		sugs[i] = Suggestion{Command: cmd, Score: float64(len(cmds) - i)}
+	}
+	return sugs
+}
+
+func commands(sugs []Suggestion) []string {
+	cmds := make([]string, len(sugs))
+	for i := range sugs {
+		cmds[i] = sugs[i].Command
+	}
+	return cmds
+}
+
+func TestExplore_Off(t *testing.T) {
+	t.Parallel()
+
+	scorer, _ := newExploreScorer(t, ExplorationConfig{Mode: ExplorationOff, Rate: 1})
+	assert.Nil(t, scorer.explorer)
+
+	top := scorer.explore(context.Background(), ranked("a", "b", "c", "d"), 2)
+	assert.Equal(t, []string{"a", "b"}, commands(top))
+
+	top = scorer.explore(context.Background(), ranked("a"), 2)
+	assert.Equal(t, []string{"a"}, commands(top))
+}
+
+func TestExplore_Epsilon(t *testing.T) {
+	t.Parallel()
+
+	scorer, db := newExploreScorer(t, ExplorationConfig{Mode: ExplorationEpsilon, Rate: 1})
+	addFeedback(t, db, "c", "dismissed", 3)
+
+	input := ranked("a", "b", "c", "d", "e")
+	top := scorer.explore(context.Background(), input, 2)
+
+	// "c" has feedback already, so the least observed candidate is "d";
+	// "e" is below the exploration pool.
+	assert.Equal(t, []string{"a", "d"}, commands(top))
+	assert.Contains(t, top[1].Reasons, ReasonExploration)
+	assert.NotContains(t, input[3].Reasons, ReasonExploration, "input must not be modified")
+}
+
+func TestExplore_Thompson(t *testing.T) {
+	t.Parallel()
+
+	scorer, db := newExploreScorer(t, ExplorationConfig{Mode: ExplorationThompson, Rate: 1})
+	addFeedback(t, db, "b", "accepted", 40)
+	addFeedback(t, db, "c", "dismissed", 40)
+	addFeedback(t, db, "d", "never", 40)
+
+	ctx := context.Background()
+	for range 50 {
+		top := scorer.explore(ctx, ranked("a", "b", "c", "d"), 2)
+		assert.Equal(t, []string{"a", "b"}, commands(top), "a rejected candidate must not beat an accepted one")
+	}
+
+	// Once the incumbent keeps being dismissed, an accepted candidate wins.
+	addFeedback(t, db, "b", "dismissed", 400)
+	addFeedback(t, db, "c", "accepted", 400)
+	for range 50 {
+		top := scorer.explore(ctx, ranked("a", "b", "c", "d"), 2)
+		assert.Equal(t, []string{"a", "c"}, commands(top))
+	}
+}
+
+func TestExplore_RateZeroNeverExplores(t *testing.T) {
+	t.Parallel()
+
+	scorer, _ := newExploreScorer(t, ExplorationConfig{Mode: ExplorationEpsilon})
+	assert.Nil(t, scorer.explorer)
+
+	scorer = scorer.WithExploration(ExplorationConfig{Mode: ExplorationEpsilon, Rate: 1})
+	top := scorer.explore(context.Background(), ranked("a", "b", "c"), 2)
+	assert.Equal(t, []string{"a", "c"}, commands(top))
+}
+
+func TestBetaSample(t *testing.T) {
+	t.Parallel()
+
+	e := newExplorer(ExplorationConfig{Rand: rand.New(rand.NewPCG(3, 4))})
+	for _, tc := range []struct{ a, b float64 }{{1, 1}, {2, 8}, {30, 10}} {
+		var sum float64
+		const n = 5000
+		for range n {
+			x := e.betaSample(tc.a, tc.b)
+			require.True(t, x >= 0 && x <= 1, "sample %f outside [0, 1]", x)
+			sum += x
+		}
+		assert.InDelta(t, tc.a/(tc.a+tc.b), sum/n, 0.02, "mean of Beta(%v, %v)", tc.a, tc.b)
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/runger/clai/internal/suggestions/clock"
+	"github.com/runger/clai/internal/suggestions/dirscope"
 	"github.com/runger/clai/internal/suggestions/discovery"
 	"github.com/runger/clai/internal/suggestions/dismissal"
 	"github.com/runger/clai/internal/suggestions/normalize"
@@ -33,6 +35,17 @@ const (
 	// because it captures location-specific patterns within a repository.
 	DefaultWeightDirTransition = 90
 	DefaultWeightDirFrequency  = 40
+
+	// Time-of-day and day-of-week weights. They only boost existing
+	// candidates, by how strongly their use concentrates in the current
+	// hour or weekday.
+	DefaultWeightTimeOfDay = 20
+	DefaultWeightDayOfWeek = 15
+
+	// DefaultWeightSuccess scales the success-probability feature, which
+	// ranges from -1 for commands that always fail to 1 for ones that
+	// always succeed.
+	DefaultWeightSuccess = 20
 )
 
 // Default amplifier factors per spec Section 7.1.
@@ -69,6 +82,10 @@ const (
 	ReasonPipelineConf     = "pipeline_conf"
 	ReasonDismissalPenalty = "dismissal_penalty"
 	ReasonRecoveryBoost    = "recovery_boost"
+	ReasonTimeOfDay        = "time_of_day"
+	ReasonDayOfWeek        = "day_of_week"
+	ReasonSuccess          = "success_rate"
+	ReasonExploration      = "exploration"
 )
 
 // Weights configures the scoring weights.
@@ -81,6 +98,9 @@ type Weights struct {
 	DangerousPenalty float64
 	DirTransition    float64
 	DirFrequency     float64
+	TimeOfDay        float64
+	DayOfWeek        float64
+	Success          float64
 }
 
 // AmplifierConfig configures the post-score amplifier factors.
@@ -138,15 +158,20 @@ func DefaultWeights() Weights {
 		DangerousPenalty: DefaultWeightDangerous,
 		DirTransition:    DefaultWeightDirTransition,
 		DirFrequency:     DefaultWeightDirFrequency,
+		TimeOfDay:        DefaultWeightTimeOfDay,
+		DayOfWeek:        DefaultWeightDayOfWeek,
+		Success:          DefaultWeightSuccess,
 	}
 }
 
 // ScorerConfig configures the scorer.
 type ScorerConfig struct {
-	Logger     *slog.Logger
-	Weights    Weights
-	Amplifiers AmplifierConfig
-	TopK       int
+	Logger      *slog.Logger
+	Clock       clock.Clock // Supplies NowMs when the request has none; defaults to the system clock
+	Weights     Weights
+	Amplifiers  AmplifierConfig
+	Exploration ExplorationConfig
+	TopK        int
 }
 
 // DefaultScorerConfig returns the default scorer configuration.
@@ -171,6 +196,15 @@ type Suggestion struct {
 	lastSeenMs    int64
 	maxFreqScore  float64
 	maxTransCount int
+
+	// SuccessProbability estimates the chance the command exits 0 if run
+	// now; 0 when there is no history to base it on.
+	SuccessProbability float64
+
+	// Success rate and count of the command after failures like the last
+	// one, from failure_recovery.
+	recoveryRate  float64
+	recoveryCount int
 }
 
 // LastSeenMs returns the best-effort last-seen timestamp (unix ms) for this suggestion.
@@ -197,6 +231,9 @@ type scoreInfo struct {
 	pipelineConf     float64
 	dismissalPenalty float64
 	recoveryBoost    float64
+	timeOfDay        float64
+	dayOfWeek        float64
+	success          float64
 }
 
 // ScoreBreakdown provides the per-feature score contributions for a suggestion.
@@ -214,6 +251,9 @@ type ScoreBreakdown struct {
 	PipelineConf     float64
 	DismissalPenalty float64
 	RecoveryBoost    float64
+	TimeOfDay        float64
+	DayOfWeek        float64
+	Success          float64
 }
 
 // ScoreBreakdown returns the per-feature score contributions for this suggestion.
@@ -231,6 +271,9 @@ func (s *Suggestion) ScoreBreakdown() ScoreBreakdown {
 		PipelineConf:     s.scores.pipelineConf,
 		DismissalPenalty: s.scores.dismissalPenalty,
 		RecoveryBoost:    s.scores.recoveryBoost,
+		TimeOfDay:        s.scores.timeOfDay,
+		DayOfWeek:        s.scores.dayOfWeek,
+		Success:          s.scores.success,
 	}
 }
 
@@ -245,6 +288,7 @@ type Scorer struct {
 	dismissalStore    *dismissal.Store
 	recoveryEngine    *recovery.Engine
 	dangerousCommands map[string]bool
+	explorer          *explorer // nil when exploration is off
 	cfg               ScorerConfig
 }
 
@@ -276,7 +320,7 @@ func NewScorer(deps *ScorerDependencies, cfg *ScorerConfig) (*Scorer, error) {
 		cfg.Amplifiers.RecencyDecayTauMs = DefaultRecencyDecayTauMs
 	}
 
-	return &Scorer{
+	scorer := &Scorer{
 		db:                deps.DB,
 		freqStore:         deps.FreqStore,
 		transitionStore:   deps.TransitionStore,
@@ -287,7 +331,11 @@ func NewScorer(deps *ScorerDependencies, cfg *ScorerConfig) (*Scorer, error) {
 		recoveryEngine:    deps.RecoveryEngine,
 		dangerousCommands: buildDangerousCommands(),
 		cfg:               *cfg,
-	}, nil
+	}
+	if cfg.Exploration.Enabled() {
+		scorer.explorer = newExplorer(cfg.Exploration)
+	}
+	return scorer, nil
 }
 
 // buildDangerousCommands returns a set of dangerous command patterns.
@@ -318,10 +366,26 @@ type SuggestContext struct {
 	LastExitCode   int
 	NowMs          int64
 	LastFailed     bool
+
+	// DirScopes are the directory scopes of cwd and its ancestors with the
+	// weight of their signals; they replace DirScopeKey when set.
+	DirScopes []dirscope.Scope
+}
+
+// dirScopes returns the directory scopes to collect candidates from.
+func (c *SuggestContext) dirScopes() []dirscope.Scope {
+	if len(c.DirScopes) > 0 {
+		return c.DirScopes
+	}
+	if c.DirScopeKey == "" {
+		return nil
+	}
+	return []dirscope.Scope{{Key: c.DirScopeKey, Weight: 1}}
 }
 
 // Suggest generates scored suggestions based on the current context.
-// Implements the 10-feature weighted scoring from spec Section 7.1:
+// Implements the 10-feature weighted scoring from spec Section 7.1, plus
+// time-of-day and day-of-week features:
 //  1. Repo transitions
 //  2. Global transitions
 //  3. Dir-scoped transitions
@@ -332,6 +396,8 @@ type SuggestContext struct {
 //  8. Workflow boost
 //  9. Pipeline confidence
 //  10. Recovery boost (after failure)
+//  11. Time of day
+//  12. Day of week
 //
 // Plus amplifiers: dismissal penalty, recency decay, prefix filtering,
 // near-duplicate suppression, and deterministic tie-breaking.
@@ -346,13 +412,14 @@ func (s *Scorer) Suggest(ctx context.Context, suggestCtx *SuggestContext) ([]Sug
 
 	candidates = s.applyPrefixFilter(candidates, suggestCtx.Prefix)
 	s.suppressLastCommand(candidates, suggestCtx.LastCmd)
+	s.suppressDeleted(ctx, candidates)
 
-	return s.finalizeSuggestions(candidates), nil
+	return s.finalizeSuggestions(ctx, candidates), nil
 }
 
 func (s *Scorer) normalizeSuggestContext(suggestCtx *SuggestContext) {
 	if suggestCtx.NowMs == 0 {
-		suggestCtx.NowMs = time.Now().UnixMilli()
+		suggestCtx.NowMs = clock.NowMs(s.cfg.Clock)
 	}
 	if suggestCtx.Scope == "" {
 		suggestCtx.Scope = score.ScopeGlobal
@@ -368,10 +435,6 @@ func (s *Scorer) collectCandidates(ctx context.Context, suggestCtx *SuggestConte
 		ctx, candidates, score.ScopeGlobal, suggestCtx.LastCmd,
 		ReasonGlobalTransition, s.cfg.Weights.GlobalTransition, "global transitions query failed",
 	)
-	s.collectTransitionCandidates(
-		ctx, candidates, suggestCtx.DirScopeKey, suggestCtx.LastCmd,
-		ReasonDirTransition, s.cfg.Weights.DirTransition, "dir transitions query failed",
-	)
 
 	s.collectFrequencyCandidates(
 		ctx, candidates, suggestCtx.RepoKey, ReasonRepoFrequency,
@@ -381,10 +444,18 @@ func (s *Scorer) collectCandidates(ctx context.Context, suggestCtx *SuggestConte
 		ctx, candidates, score.ScopeGlobal, ReasonGlobalFrequency,
 		s.cfg.Weights.GlobalFrequency, suggestCtx.NowMs, "global frequency query failed",
 	)
-	s.collectFrequencyCandidates(
-		ctx, candidates, suggestCtx.DirScopeKey, ReasonDirFrequency,
-		s.cfg.Weights.DirFrequency, suggestCtx.NowMs, "dir frequency query failed",
-	)
+
+	// Signals of cwd's ancestors count less the further up they are.
+	for _, dir := range suggestCtx.dirScopes() {
+		s.collectTransitionCandidates(
+			ctx, candidates, dir.Key, suggestCtx.LastCmd,
+			ReasonDirTransition, s.cfg.Weights.DirTransition*dir.Weight, "dir transitions query failed",
+		)
+		s.collectFrequencyCandidates(
+			ctx, candidates, dir.Key, ReasonDirFrequency,
+			s.cfg.Weights.DirFrequency*dir.Weight, suggestCtx.NowMs, "dir frequency query failed",
+		)
+	}
 
 	s.collectProjectTasks(ctx, candidates, suggestCtx.RepoKey)
 }
@@ -457,6 +528,8 @@ func (s *Scorer) applyContextBoosts(ctx context.Context, candidates map[string]*
 	s.applyWorkflowBoost(candidates, suggestCtx)
 	s.applyPipelineConfidence(ctx, candidates, suggestCtx)
 	s.applyRecoveryBoost(ctx, candidates, suggestCtx)
+	s.applyTimeBoost(ctx, candidates, suggestCtx.NowMs)
+	s.applySuccessEstimate(ctx, candidates, suggestCtx.RepoKey)
 }
 
 func (s *Scorer) applyDangerousPenalties(candidates map[string]*Suggestion) {
@@ -490,7 +563,35 @@ func (s *Scorer) suppressLastCommand(candidates map[string]*Suggestion, lastCmd
 	}
 }
 
-func (s *Scorer) finalizeSuggestions(candidates map[string]*Suggestion) []Suggestion {
+// suppressDeleted removes candidates whose every recorded event has been
+// deleted with 'clai history delete'. Deleted events still count towards the
+// stats the candidates come from, so they are filtered here.
+func (s *Scorer) suppressDeleted(ctx context.Context, candidates map[string]*Suggestion) {
+	if s.db == nil || len(candidates) == 0 {
+		return
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT d.cmd_norm FROM command_event d
+		WHERE d.deleted = 1 AND NOT EXISTS (
+			SELECT 1 FROM command_event l WHERE l.cmd_norm = d.cmd_norm AND l.deleted = 0
+		)
+	`)
+	if err != nil {
+		s.cfg.Logger.Debug("deleted commands query failed", "error", err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cmdNorm string
+		if err := rows.Scan(&cmdNorm); err != nil {
+			s.cfg.Logger.Debug("deleted commands scan failed", "error", err)
+			return
+		}
+		delete(candidates, cmdNorm)
+	}
+}
+
+func (s *Scorer) finalizeSuggestions(ctx context.Context, candidates map[string]*Suggestion) []Suggestion {
 	suggestions := make([]Suggestion, 0, len(candidates))
 	for _, sug := range candidates {
 		sug.Confidence = s.calculateConfidence(sug)
@@ -500,10 +601,7 @@ func (s *Scorer) finalizeSuggestions(candidates map[string]*Suggestion) []Sugges
 	suggestions = suppressNearDuplicates(suggestions)
 	sortSuggestions(suggestions)
 
-	if len(suggestions) > s.cfg.TopK {
-		return suggestions[:s.cfg.TopK]
-	}
-	return suggestions
+	return s.explore(ctx, suggestions, s.cfg.TopK)
 }
 
 func sortSuggestions(suggestions []Suggestion) {
@@ -649,7 +747,8 @@ func (s *Scorer) applyRecoveryBoost(ctx context.Context, candidates map[string]*
 		if rc.RecoveryCmdNorm == "" {
 			continue
 		}
-		if existing, ok := candidates[rc.RecoveryCmdNorm]; ok {
+		existing, ok := candidates[rc.RecoveryCmdNorm]
+		if ok {
 			// Boost existing candidate
 			boostAmount := existing.Score * (boostFactor - 1.0)
 			existing.Score += boostAmount
@@ -658,12 +757,182 @@ func (s *Scorer) applyRecoveryBoost(ctx context.Context, candidates map[string]*
 		} else {
 			// Add as new candidate with recovery-based score
 			recoveryScore := rc.SuccessRate * rc.Weight * boostFactor * 10.0
-			candidates[rc.RecoveryCmdNorm] = &Suggestion{
+			existing = &Suggestion{
 				Command: rc.RecoveryCmdNorm,
 				Score:   recoveryScore,
 				Reasons: []string{ReasonRecoveryBoost},
 				scores:  scoreInfo{recoveryBoost: recoveryScore},
 			}
+			candidates[rc.RecoveryCmdNorm] = existing
+		}
+		if rc.Count > existing.recoveryCount {
+			existing.recoveryRate = rc.SuccessRate
+			existing.recoveryCount = rc.Count
+		}
+	}
+}
+
+// applyTimeBoost boosts candidates whose global use concentrates in the
+// current local hour or weekday, as counted in command_time_stat. Each
+// feature is the Laplace-smoothed share of uses in the current bucket,
+// rescaled so that a command used evenly across buckets scores 0 and one
+// used only in this bucket approaches 1, and is multiplied by its weight.
+func (s *Scorer) applyTimeBoost(ctx context.Context, candidates map[string]*Suggestion, nowMs int64) {
+	if s.db == nil || len(candidates) == 0 || (s.cfg.Weights.TimeOfDay == 0 && s.cfg.Weights.DayOfWeek == 0) {
+		return
+	}
+
+	now := time.UnixMilli(nowMs).Local()
+	args := []any{now.Hour(), int(now.Weekday())}
+	placeholders := make([]string, 0, len(candidates))
+	for cmd := range candidates {
+		placeholders = append(placeholders, "?")
+		args = append(args, cmd)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.cmd_norm, SUM(s.count),
+		       SUM(CASE WHEN s.hour = ? THEN s.count ELSE 0 END),
+		       SUM(CASE WHEN s.weekday = ? THEN s.count ELSE 0 END)
+		FROM command_time_stat s
+		JOIN command_template t ON t.template_id = s.template_id
+		WHERE s.scope = 'global' AND t.cmd_norm IN (`+strings.Join(placeholders, ", ")+`)
+		GROUP BY t.cmd_norm
+	`, args...)
+	if err != nil {
+		s.cfg.Logger.Debug("time stats query failed", "error", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cmd string
+		var total, inHour, onWeekday float64
+		if err := rows.Scan(&cmd, &total, &inHour, &onWeekday); err != nil {
+			s.cfg.Logger.Debug("time stats scan failed", "error", err)
+			return
+		}
+		sug := candidates[cmd]
+		if sug == nil {
+			continue
+		}
+		if boost := s.cfg.Weights.TimeOfDay * timeFeature(inHour, total, 24); boost > 0 {
+			sug.Score += boost
+			sug.scores.timeOfDay += boost
+			sug.Reasons = append(sug.Reasons, ReasonTimeOfDay)
+		}
+		if boost := s.cfg.Weights.DayOfWeek * timeFeature(onWeekday, total, 7); boost > 0 {
+			sug.Score += boost
+			sug.scores.dayOfWeek += boost
+			sug.Reasons = append(sug.Reasons, ReasonDayOfWeek)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		s.cfg.Logger.Debug("time stats query failed", "error", err)
+	}
+}
+
+// timeFeature scores how strongly uses concentrate in one of buckets
+// buckets: 0 when inBucket/total is no more than an even share, up to 1.
+// The share is Laplace-smoothed so that a handful of uses scores little.
+func timeFeature(inBucket, total float64, buckets int) float64 {
+	share := (inBucket + 1) / (total + float64(buckets))
+	even := 1 / float64(buckets)
+	return math.Max((share-even)/(1-even), 0)
+}
+
+// successPriorRuns is how many runs a broader success estimate counts as
+// when it is refined with narrower evidence.
+const successPriorRuns = 2
+
+// SuccessProbability estimates the chance that a command exits 0 from its
+// past successes and failures: the Laplace-smoothed success rate, 0.5
+// without history and approaching the observed rate as runs add up.
+func SuccessProbability(successes, failures float64) float64 {
+	return (successes + 1) / (successes + failures + 2)
+}
+
+// refineSuccess shrinks the success rate of a narrower set of runs toward the
+// broader estimate prior.
+func refineSuccess(prior, successes, runs float64) float64 {
+	return (successes + successPriorRuns*prior) / (runs + successPriorRuns)
+}
+
+// applySuccessEstimate sets each candidate's SuccessProbability and adds
+// the success feature, Weights.Success * (2p - 1). The estimate starts from
+// the global success and failure counts in command_stat, is refined with
+// the counts in the current repo, and after a failure with the success
+// rate of the candidate as a recovery from the same exit-code class.
+func (s *Scorer) applySuccessEstimate(ctx context.Context, candidates map[string]*Suggestion, repoKey string) {
+	if s.db == nil || len(candidates) == 0 {
+		return
+	}
+
+	type counts struct{ global, repo [2]float64 }
+	stats := make(map[string]*counts, len(candidates))
+	args := []any{repoKey}
+	placeholders := make([]string, 0, len(candidates))
+	for cmd := range candidates {
+		placeholders = append(placeholders, "?")
+		args = append(args, cmd)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.cmd_norm, s.scope, SUM(s.success_count), SUM(s.failure_count)
+		FROM command_stat s
+		JOIN command_template t ON t.template_id = s.template_id
+		WHERE s.scope IN ('global', ?) AND t.cmd_norm IN (`+strings.Join(placeholders, ", ")+`)
+		GROUP BY t.cmd_norm, s.scope
+	`, args...)
+	if err != nil {
+		s.cfg.Logger.Debug("success stats query failed", "error", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cmd, scope string
+		var successes, failures float64
+		if err := rows.Scan(&cmd, &scope, &successes, &failures); err != nil {
+			s.cfg.Logger.Debug("success stats scan failed", "error", err)
+			return
+		}
+		c := stats[cmd]
+		if c == nil {
+			c = &counts{}
+			stats[cmd] = c
+		}
+		if scope == "global" {
+			c.global = [2]float64{successes, failures}
+		} else {
+			c.repo = [2]float64{successes, failures}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		s.cfg.Logger.Debug("success stats query failed", "error", err)
+		return
+	}
+
+	for cmd, sug := range candidates {
+		c := stats[cmd]
+		if c == nil && sug.recoveryCount == 0 {
+			continue
+		}
+		p := SuccessProbability(0, 0)
+		if c != nil {
+			p = SuccessProbability(c.global[0], c.global[1])
+			if runs := c.repo[0] + c.repo[1]; runs > 0 {
+				p = refineSuccess(p, c.repo[0], runs)
+			}
+		}
+		if sug.recoveryCount > 0 {
+			runs := float64(sug.recoveryCount)
+			p = refineSuccess(p, sug.recoveryRate*runs, runs)
+		}
+		sug.SuccessProbability = p
+
+		if boost := s.cfg.Weights.Success * (2*p - 1); boost != 0 {
+			sug.Score += boost
+			sug.scores.success += boost
+			sug.Reasons = append(sug.Reasons, ReasonSuccess)
 		}
 	}
 }
@@ -1015,3 +1284,25 @@ func (s *Scorer) DebugScores(ctx context.Context, limit int) ([]DebugScore, erro
 
 	return scores, rows.Err()
 }
+
+// WithWeights returns a copy of the scorer that uses the given weights.
+// The copy shares all stores and dependencies with the original, so it is
+// cheap to create. The original scorer is left unchanged, which lets callers
+// swap scorers atomically while suggestions are being served.
+func (s *Scorer) WithWeights(w Weights) *Scorer {
+	clone := *s
+	clone.cfg.Weights = w
+	return &clone
+}
+
+// WithExploration returns a copy of the scorer that explores as configured
+// by cfg, sharing everything else with the original like WithWeights.
+func (s *Scorer) WithExploration(cfg ExplorationConfig) *Scorer {
+	clone := *s
+	clone.cfg.Exploration = cfg
+	clone.explorer = nil
+	if cfg.Enabled() {
+		clone.explorer = newExplorer(cfg)
+	}
+	return &clone
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/runger/clai/internal/suggestions/dirscope"
 	"github.com/runger/clai/internal/suggestions/discovery"
 	"github.com/runger/clai/internal/suggestions/dismissal"
 	"github.com/runger/clai/internal/suggestions/recovery"
@@ -44,7 +45,8 @@ func createTestDB(t testing.TB) *sql.DB { //nolint:funlen // test DB setup with
 			cmd_raw       TEXT NOT NULL,
 			cmd_norm      TEXT NOT NULL,
 			cwd           TEXT NOT NULL,
-			repo_key      TEXT
+			repo_key      TEXT,
+			deleted       INTEGER NOT NULL DEFAULT 0
 		);
 
 		-- Command score table
@@ -66,14 +68,17 @@ func createTestDB(t testing.TB) *sql.DB { //nolint:funlen // test DB setup with
 			PRIMARY KEY(scope, prev_norm, next_norm)
 		);
 
-		-- Project task table
-		CREATE TABLE project_task (
-			repo_key      TEXT NOT NULL,
-			kind          TEXT NOT NULL,
-			name          TEXT NOT NULL,
-			command       TEXT NOT NULL,
-			description   TEXT,
-			discovered_ts INTEGER NOT NULL,
+		-- Task candidate table
+		CREATE TABLE task_candidate (
+			repo_key          TEXT NOT NULL,
+			kind              TEXT NOT NULL,
+			name              TEXT NOT NULL,
+			command_text      TEXT NOT NULL,
+			description       TEXT,
+			source            TEXT NOT NULL DEFAULT 'auto',
+			priority_boost    REAL NOT NULL DEFAULT 0,
+			source_checksum   TEXT,
+			discovered_ms     INTEGER NOT NULL,
 			PRIMARY KEY(repo_key, kind, name)
 		);
 
@@ -99,6 +104,28 @@ func createTestDB(t testing.TB) *sql.DB { //nolint:funlen // test DB setup with
 			last_seen_ms    INTEGER NOT NULL
 		);
 
+		-- Command time stat table
+		CREATE TABLE command_time_stat (
+			scope           TEXT NOT NULL,
+			template_id     TEXT NOT NULL,
+			weekday         INTEGER NOT NULL,
+			hour            INTEGER NOT NULL,
+			count           INTEGER NOT NULL,
+			last_seen_ms    INTEGER NOT NULL,
+			PRIMARY KEY(scope, template_id, weekday, hour)
+		);
+
+		-- Command stat table (success/failure counts)
+		CREATE TABLE command_stat (
+			scope           TEXT NOT NULL,
+			template_id     TEXT NOT NULL,
+			score           REAL NOT NULL,
+			success_count   INTEGER NOT NULL,
+			failure_count   INTEGER NOT NULL,
+			last_seen_ms    INTEGER NOT NULL,
+			PRIMARY KEY(scope, template_id)
+		);
+
 		-- Failure recovery table
 		CREATE TABLE failure_recovery (
 			scope                 TEXT NOT NULL,
@@ -186,6 +213,41 @@ func TestScorer_Suggest_DoesNotSuggestLastCommand(t *testing.T) {
 	assert.True(t, foundBuild, "expected at least one non-last candidate to remain")
 }
 
+func TestScorer_Suggest_SuppressesDeletedCommands(t *testing.T) {
+	t.Parallel()
+
+	db := createTestDB(t)
+
+	freqStore, err := score.NewFrequencyStore(db, score.DefaultFrequencyOptions())
+	require.NoError(t, err)
+	defer freqStore.Close()
+
+	ctx := context.Background()
+	nowMs := int64(1000000)
+	for _, cmd := range []string{"export TOKEN=<arg>", "make build"} {
+		require.NoError(t, freqStore.Update(ctx, score.ScopeGlobal, cmd, nowMs))
+	}
+	// Every event of the export was deleted; make build keeps one of two.
+	_, err = db.Exec(`
+		INSERT INTO command_event (session_id, ts, cmd_raw, cmd_norm, cwd, deleted) VALUES
+			('s1', 1, 'export TOKEN=abc', 'export TOKEN=<arg>', '/tmp', 1),
+			('s1', 2, 'make build', 'make build', '/tmp', 1),
+			('s1', 3, 'make build', 'make build', '/tmp', 0)
+	`)
+	require.NoError(t, err)
+
+	scorer, err := NewScorer(&ScorerDependencies{
+		DB:        db,
+		FreqStore: freqStore,
+	}, DefaultScorerConfig())
+	require.NoError(t, err)
+
+	suggestions, err := scorer.Suggest(ctx, &SuggestContext{NowMs: nowMs})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, "make build", suggestions[0].Command)
+}
+
 func TestScorer_NewScorer(t *testing.T) {
 	t.Parallel()
 
@@ -215,6 +277,24 @@ func TestScorer_NewScorer_CustomConfig(t *testing.T) {
 	assert.Equal(t, float64(100), scorer.Weights().RepoTransition)
 }
 
+func TestScorer_WithWeights(t *testing.T) {
+	t.Parallel()
+
+	db := createTestDB(t)
+
+	scorer, err := NewScorer(&ScorerDependencies{DB: db}, DefaultScorerConfig())
+	require.NoError(t, err)
+
+	w := DefaultWeights()
+	w.RepoTransition = 10
+	clone := scorer.WithWeights(w)
+
+	assert.Equal(t, float64(10), clone.Weights().RepoTransition)
+	assert.Equal(t, float64(DefaultWeightRepoTransition), scorer.Weights().RepoTransition,
+		"original scorer must keep its weights")
+	assert.Equal(t, scorer.TopK(), clone.TopK())
+}
+
 func TestScorer_Suggest_Empty(t *testing.T) {
 	t.Parallel()
 
@@ -314,7 +394,7 @@ func TestScorer_Suggest_WithProjectTasks(t *testing.T) {
 
 	// Insert a project task directly
 	_, err = db.Exec(`
-		INSERT INTO project_task (repo_key, kind, name, command, description, discovered_ts)
+		INSERT INTO task_candidate (repo_key, kind, name, command_text, description, discovered_ms)
 		VALUES (?, ?, ?, ?, ?, ?)
 	`, "/test/repo", "makefile", "test", "make test", "Run tests", 1000000)
 	require.NoError(t, err)
@@ -673,6 +753,42 @@ func TestScorer_Suggest_DirScopeEmpty(t *testing.T) {
 	assert.Equal(t, "ls -la", suggestions[0].Command)
 }
 
+func TestScorer_Suggest_DirAffinityByDepth(t *testing.T) {
+	t.Parallel()
+
+	db := createTestDB(t)
+
+	freqStore, err := score.NewFrequencyStore(db, score.DefaultFrequencyOptions())
+	require.NoError(t, err)
+	defer freqStore.Close()
+
+	ctx := context.Background()
+	nowMs := int64(1000000)
+	scopes := dirscope.AncestorScopes("/repo/services/api", "/repo", 3, []float64{1.0, 0.5, 0})
+
+	// Equally frequent in cwd and its parent; only the grandparent (weighted
+	// 0) has the third command.
+	require.NoError(t, freqStore.Update(ctx, dirscope.PathKey("/repo/services/api"), "go test", nowMs))
+	require.NoError(t, freqStore.Update(ctx, dirscope.PathKey("/repo/services"), "make lint", nowMs))
+	require.NoError(t, freqStore.Update(ctx, dirscope.PathKey("/repo"), "make release", nowMs))
+
+	scorer, err := NewScorer(&ScorerDependencies{
+		DB:        db,
+		FreqStore: freqStore,
+	}, DefaultScorerConfig())
+	require.NoError(t, err)
+
+	suggestions, err := scorer.Suggest(ctx, &SuggestContext{DirScopes: scopes, NowMs: nowMs})
+	require.NoError(t, err)
+
+	var commands []string
+	for _, s := range suggestions {
+		commands = append(commands, s.Command)
+	}
+	assert.Equal(t, []string{"go test", "make lint"}, commands)
+	assert.Greater(t, suggestions[0].Score, suggestions[1].Score)
+}
+
 func TestDefaultWeights(t *testing.T) {
 	t.Parallel()
 
@@ -684,6 +800,8 @@ func TestDefaultWeights(t *testing.T) {
 	assert.Equal(t, float64(DefaultWeightDangerous), weights.DangerousPenalty)
 	assert.Equal(t, float64(DefaultWeightDirTransition), weights.DirTransition)
 	assert.Equal(t, float64(DefaultWeightDirFrequency), weights.DirFrequency)
+	assert.Equal(t, float64(DefaultWeightTimeOfDay), weights.TimeOfDay)
+	assert.Equal(t, float64(DefaultWeightDayOfWeek), weights.DayOfWeek)
 }
 
 func TestDefaultScorerConfig(t *testing.T) {
@@ -740,6 +858,8 @@ func TestReasonConstants(t *testing.T) {
 	assert.Equal(t, "pipeline_conf", ReasonPipelineConf)
 	assert.Equal(t, "dismissal_penalty", ReasonDismissalPenalty)
 	assert.Equal(t, "recovery_boost", ReasonRecoveryBoost)
+	assert.Equal(t, "time_of_day", ReasonTimeOfDay)
+	assert.Equal(t, "day_of_week", ReasonDayOfWeek)
 }
 
 func TestScorer_DefaultScorerConfig_HasAmplifiers(t *testing.T) {
@@ -1064,6 +1184,162 @@ func TestScorer_RecoveryBoost_NilEngine(t *testing.T) {
 	assert.Empty(t, suggestions) // No crash with nil engine
 }
 
+// seedTimeStats records count global uses of cmdNorm in the bucket of tsMs.
+func seedTimeStats(t *testing.T, db *sql.DB, cmdNorm string, tsMs int64, count int) {
+	t.Helper()
+	templateID := "tpl-" + cmdNorm
+	local := time.UnixMilli(tsMs).Local()
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO command_template (template_id, cmd_norm, slot_count, first_seen_ms, last_seen_ms)
+		VALUES (?, ?, 0, ?, ?)
+	`, templateID, cmdNorm, tsMs, tsMs)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		INSERT INTO command_time_stat (scope, template_id, weekday, hour, count, last_seen_ms)
+		VALUES ('global', ?, ?, ?, ?, ?)
+	`, templateID, int(local.Weekday()), local.Hour(), count, tsMs)
+	require.NoError(t, err)
+}
+
+func TestScorer_TimeBoost(t *testing.T) {
+	t.Parallel()
+
+	db := createTestDB(t)
+	freqStore, err := score.NewFrequencyStore(db, score.DefaultFrequencyOptions())
+	require.NoError(t, err)
+	defer freqStore.Close()
+
+	ctx := context.Background()
+	nowMs := time.Date(2026, 3, 6, 9, 30, 0, 0, time.Local).UnixMilli() // Friday morning
+	for _, cmd := range []string{"make deploy", "npm run dev"} {
+		for i := 0; i < 3; i++ {
+			require.NoError(t, freqStore.Update(ctx, score.ScopeGlobal, cmd, nowMs))
+		}
+	}
+	// npm run dev runs on Friday mornings; make deploy on Tuesday evenings.
+	seedTimeStats(t, db, "npm run dev", nowMs-7*24*3600*1000, 20)
+	seedTimeStats(t, db, "make deploy", nowMs-3*24*3600*1000+9*3600*1000, 20)
+
+	scorer, err := NewScorer(&ScorerDependencies{DB: db, FreqStore: freqStore}, DefaultScorerConfig())
+	require.NoError(t, err)
+
+	suggestions, err := scorer.Suggest(ctx, &SuggestContext{NowMs: nowMs})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, "npm run dev", suggestions[0].Command)
+	assert.Contains(t, suggestions[0].Reasons, ReasonTimeOfDay)
+	assert.Contains(t, suggestions[0].Reasons, ReasonDayOfWeek)
+	breakdown := suggestions[0].ScoreBreakdown()
+	assert.Greater(t, breakdown.TimeOfDay, 0.0)
+	assert.Greater(t, breakdown.DayOfWeek, 0.0)
+	assert.NotContains(t, suggestions[1].Reasons, ReasonTimeOfDay)
+	assert.NotContains(t, suggestions[1].Reasons, ReasonDayOfWeek)
+
+	// Zero weights turn the features off.
+	w := DefaultWeights()
+	w.TimeOfDay, w.DayOfWeek = 0, 0
+	suggestions, err = scorer.WithWeights(w).Suggest(ctx, &SuggestContext{NowMs: nowMs})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 2)
+	assert.Zero(t, suggestions[0].ScoreBreakdown().TimeOfDay)
+	assert.Zero(t, suggestions[1].ScoreBreakdown().TimeOfDay)
+}
+
+func TestTimeFeature(t *testing.T) {
+	t.Parallel()
+
+	assert.Zero(t, timeFeature(0, 0, 24), "no uses")
+	assert.Zero(t, timeFeature(1, 24, 24), "an even share")
+	assert.Less(t, timeFeature(2, 2, 24), 0.1, "a handful of uses")
+	assert.Greater(t, timeFeature(50, 50, 24), 0.6)
+	assert.Less(t, timeFeature(50, 50, 24), 1.0)
+}
+
+func seedCommandStat(t *testing.T, db *sql.DB, scope, cmdNorm string, successes, failures int) {
+	t.Helper()
+	templateID := "tpl-" + cmdNorm
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO command_template (template_id, cmd_norm, slot_count, first_seen_ms, last_seen_ms)
+		VALUES (?, ?, 0, 0, 0)
+	`, templateID, cmdNorm)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		INSERT INTO command_stat (scope, template_id, score, success_count, failure_count, last_seen_ms)
+		VALUES (?, ?, 1, ?, ?, 0)
+	`, scope, templateID, successes, failures)
+	require.NoError(t, err)
+}
+
+func TestScorer_SuccessEstimate(t *testing.T) {
+	t.Parallel()
+
+	db := createTestDB(t)
+	freqStore, err := score.NewFrequencyStore(db, score.DefaultFrequencyOptions())
+	require.NoError(t, err)
+	defer freqStore.Close()
+
+	ctx := context.Background()
+	nowMs := int64(1_000_000)
+	for _, cmd := range []string{"make build", "make flaky", "make new"} {
+		for i := 0; i < 3; i++ {
+			require.NoError(t, freqStore.Update(ctx, score.ScopeGlobal, cmd, nowMs))
+		}
+	}
+	seedCommandStat(t, db, "global", "make build", 18, 0)
+	seedCommandStat(t, db, "global", "make flaky", 2, 16)
+	// make build fails in this repo, though it works elsewhere.
+	seedCommandStat(t, db, "/repo", "make build", 0, 8)
+
+	scorer, err := NewScorer(&ScorerDependencies{DB: db, FreqStore: freqStore}, DefaultScorerConfig())
+	require.NoError(t, err)
+
+	suggestions, err := scorer.Suggest(ctx, &SuggestContext{NowMs: nowMs})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 3)
+	byCmd := make(map[string]*Suggestion)
+	for i := range suggestions {
+		byCmd[suggestions[i].Command] = &suggestions[i]
+	}
+	assert.Equal(t, "make build", suggestions[0].Command)
+	assert.InDelta(t, 0.95, byCmd["make build"].SuccessProbability, 0.001)
+	assert.InDelta(t, 0.15, byCmd["make flaky"].SuccessProbability, 0.001)
+	assert.Zero(t, byCmd["make new"].SuccessProbability, "no history")
+	assert.Greater(t, byCmd["make build"].ScoreBreakdown().Success, 0.0)
+	assert.Less(t, byCmd["make flaky"].ScoreBreakdown().Success, 0.0)
+	assert.Contains(t, byCmd["make flaky"].Reasons, ReasonSuccess)
+	assert.NotContains(t, byCmd["make new"].Reasons, ReasonSuccess)
+
+	suggestions, err = scorer.Suggest(ctx, &SuggestContext{NowMs: nowMs, RepoKey: "/repo"})
+	require.NoError(t, err)
+	for _, sug := range suggestions {
+		if sug.Command == "make build" {
+			assert.InDelta(t, 0.19, sug.SuccessProbability, 0.001, "repo failures outweigh the global record")
+		}
+	}
+
+	// A zero weight keeps the estimate but drops the feature.
+	w := DefaultWeights()
+	w.Success = 0
+	suggestions, err = scorer.WithWeights(w).Suggest(ctx, &SuggestContext{NowMs: nowMs})
+	require.NoError(t, err)
+	for _, sug := range suggestions {
+		assert.Zero(t, sug.ScoreBreakdown().Success)
+		if sug.Command == "make build" {
+			assert.InDelta(t, 0.95, sug.SuccessProbability, 0.001)
+		}
+	}
+}
+
+func TestSuccessProbability(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 0.5, SuccessProbability(0, 0), 1e-9, "no runs")
+	assert.InDelta(t, 2.0/3.0, SuccessProbability(1, 0), 1e-9, "one success")
+	assert.Greater(t, SuccessProbability(100, 0), 0.99)
+	assert.Less(t, SuccessProbability(0, 100), 0.01)
+	assert.InDelta(t, 0.7, refineSuccess(0.9, 1, 2), 1e-9, "two runs move the prior halfway")
+}
+
 func TestScorer_DismissalPenalty_Learned(t *testing.T) {
 	t.Parallel()
 
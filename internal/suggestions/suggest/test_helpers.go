@@ -21,6 +21,8 @@ func SuggestionForTest(totalScore float64, b *ScoreBreakdown) Suggestion {
 			pipelineConf:     b.PipelineConf,
 			dismissalPenalty: b.DismissalPenalty,
 			recoveryBoost:    b.RecoveryBoost,
+			timeOfDay:        b.TimeOfDay,
+			dayOfWeek:        b.DayOfWeek,
 		},
 	}
 }
@@ -6,8 +6,11 @@ import (
 	"context"
 	"database/sql"
 	"log/slog"
+	"slices"
 	"sort"
 	"strings"
+
+	"github.com/runger/clai/internal/suggestions/discovery"
 )
 
 // Configuration defaults per spec Section 11.6.3.
@@ -32,10 +35,19 @@ const (
 	ExitCodeNotFound = 127
 )
 
+// scopeGlobal is the command_stat scope holding every command.
+const scopeGlobal = "global"
+
+// TaskSource lists the tasks discovered for a repository.
+type TaskSource interface {
+	GetTasks(ctx context.Context, repoKey string) ([]discovery.Task, error)
+}
+
 // Corrector provides typo correction for failed commands.
 type Corrector struct {
 	db                  *sql.DB
 	logger              *slog.Logger
+	tasks               TaskSource
 	stmtGetTopCommands  *sql.Stmt
 	similarityThreshold float64
 	topPercent          int
@@ -45,7 +57,11 @@ type Corrector struct {
 
 // CorrectorConfig configures the typo corrector.
 type CorrectorConfig struct {
-	Logger              *slog.Logger
+	Logger *slog.Logger
+
+	// Tasks adds a repository's project tasks to the candidates; optional.
+	Tasks TaskSource
+
 	SimilarityThreshold float64
 	TopPercent          int
 	CandidateLimit      int
@@ -65,16 +81,17 @@ func DefaultCorrectorConfig() CorrectorConfig {
 
 // Correction represents a typo correction suggestion.
 type Correction struct {
-	// Original is the misspelled command/token.
+	// Original is the failed command.
 	Original string
 
-	// Suggested is the corrected command/token.
+	// Suggested is the failed command with its misspelled words corrected.
 	Suggested string
 
-	// Similarity is the similarity score (0-1).
+	// Similarity is the similarity score (0-1) of the least similar
+	// corrected word.
 	Similarity float64
 
-	// Score is the frequency score of the suggested command.
+	// Score is the frequency score of the command the correction is based on.
 	Score float64
 }
 
@@ -98,6 +115,7 @@ func NewCorrector(db *sql.DB, cfg CorrectorConfig) (*Corrector, error) {
 
 	c := &Corrector{
 		db:                  db,
+		tasks:               cfg.Tasks,
 		similarityThreshold: cfg.SimilarityThreshold,
 		topPercent:          cfg.TopPercent,
 		candidateLimit:      cfg.CandidateLimit,
@@ -108,10 +126,11 @@ func NewCorrector(db *sql.DB, cfg CorrectorConfig) (*Corrector, error) {
 	// Prepare statements
 	var err error
 	c.stmtGetTopCommands, err = db.Prepare(`
-		SELECT cmd_norm, score
-		FROM command_score
-		WHERE scope = ?
-		ORDER BY score DESC
+		SELECT t.cmd_norm, s.score
+		FROM command_stat s
+		JOIN command_template t ON t.template_id = s.template_id
+		WHERE s.scope = ?
+		ORDER BY s.score DESC
 		LIMIT ?
 	`)
 	if err != nil {
@@ -137,10 +156,14 @@ func ShouldCorrect(exitCode int) bool {
 
 // Correct attempts to correct a failed command.
 // Per spec Section 11.6.
+//
+// The command name is compared with the names of frequent commands and
+// project tasks, and the word after it with the subcommands seen for the
+// (corrected) name, so "gti status" and "git stauts" both become
+// "git status". Arguments are kept as typed.
 func (c *Corrector) Correct(ctx context.Context, failedCmd, repoKey string) ([]Correction, error) {
-	// Extract the first token (command name) for comparison
-	failedToken := extractFirstToken(failedCmd)
-	if failedToken == "" {
+	words := strings.Fields(failedCmd)
+	if len(words) == 0 {
 		return nil, nil
 	}
 
@@ -150,42 +173,22 @@ func (c *Corrector) Correct(ctx context.Context, failedCmd, repoKey string) ([]C
 		return nil, err
 	}
 
-	if len(candidates) == 0 {
-		return nil, nil
-	}
-
 	// Apply top percent filter
 	// Per spec: only suggest when candidate is in top 10% by score
 	topCount := (len(candidates) * c.topPercent) / 100
 	if topCount < 1 {
 		topCount = 1
 	}
-	candidates = candidates[:topCount]
+	candidates = candidates[:min(topCount, len(candidates))]
 
-	// Find matches above threshold
-	var corrections []Correction
-	for _, cand := range candidates {
-		candToken := extractFirstToken(cand.cmdNorm)
-		if candToken == "" {
-			continue
-		}
-
-		// Skip if tokens are identical (not a typo)
-		if candToken == failedToken {
-			continue
-		}
-
-		similarity := Similarity(failedToken, candToken)
-		if similarity >= c.similarityThreshold {
-			corrections = append(corrections, Correction{
-				Original:   failedToken,
-				Suggested:  cand.cmdNorm,
-				Similarity: similarity,
-				Score:      cand.score,
-			})
-		}
+	// Project tasks are always candidates: they are what the project runs.
+	candidates = append(candidates, c.getTaskCandidates(ctx, repoKey)...)
+	if len(candidates) == 0 {
+		return nil, nil
 	}
 
+	corrections := buildVocabulary(candidates).correct(failedCmd, words, c.similarityThreshold)
+
 	// Sort by similarity (highest first), then by score
 	sort.Slice(corrections, func(i, j int) bool {
 		if corrections[i].Similarity != corrections[j].Similarity {
@@ -224,7 +227,7 @@ func (c *Corrector) getCandidates(ctx context.Context, repoKey string) ([]candid
 	}
 
 	// Add global candidates
-	globalCandidates, err := c.queryTopCommands(ctx, "__global__")
+	globalCandidates, err := c.queryTopCommands(ctx, scopeGlobal)
 	if err != nil {
 		c.logger.Debug("global candidates query failed", "error", err)
 	} else {
@@ -251,6 +254,23 @@ func (c *Corrector) getCandidates(ctx context.Context, repoKey string) ([]candid
 	return deduped, nil
 }
 
+// getTaskCandidates returns the repository's project tasks as candidates.
+func (c *Corrector) getTaskCandidates(ctx context.Context, repoKey string) []candidate {
+	if c.tasks == nil || repoKey == "" {
+		return nil
+	}
+	tasks, err := c.tasks.GetTasks(ctx, repoKey)
+	if err != nil {
+		c.logger.Debug("project tasks query failed", "error", err)
+		return nil
+	}
+	candidates := make([]candidate, 0, len(tasks))
+	for _, t := range tasks {
+		candidates = append(candidates, candidate{cmdNorm: t.Command})
+	}
+	return candidates
+}
+
 // queryTopCommands queries top commands for a scope.
 func (c *Corrector) queryTopCommands(ctx context.Context, scope string) ([]candidate, error) {
 	rows, err := c.stmtGetTopCommands.QueryContext(ctx, scope, c.candidateLimit)
@@ -271,19 +291,99 @@ func (c *Corrector) queryTopCommands(ctx context.Context, scope string) ([]candi
 	return candidates, rows.Err()
 }
 
-// extractFirstToken extracts the first word from a command.
-func extractFirstToken(cmd string) string {
-	cmd = strings.TrimSpace(cmd)
-	if cmd == "" {
-		return ""
+// vocabulary holds the known command names and the subcommands seen for
+// each, with the best score of a candidate using them.
+type vocabulary struct {
+	commands    map[string]float64
+	subcommands map[string]map[string]float64
+}
+
+func buildVocabulary(candidates []candidate) *vocabulary {
+	v := &vocabulary{
+		commands:    make(map[string]float64),
+		subcommands: make(map[string]map[string]float64),
+	}
+	for _, cand := range candidates {
+		words := strings.Fields(cand.cmdNorm)
+		if len(words) == 0 {
+			continue
+		}
+		name := words[0]
+		v.commands[name] = max(v.commands[name], cand.score)
+		if len(words) < 2 || !isSubcommandWord(words[1]) {
+			continue
+		}
+		if v.subcommands[name] == nil {
+			v.subcommands[name] = make(map[string]float64)
+		}
+		v.subcommands[name][words[1]] = max(v.subcommands[name][words[1]], cand.score)
 	}
+	return v
+}
 
-	// Find end of first token
-	for i, ch := range cmd {
-		if ch == ' ' || ch == '\t' {
-			return cmd[:i]
+// correct returns the corrections of failedCmd, split into words, whose
+// corrected words are at least threshold similar to the typed ones.
+func (v *vocabulary) correct(failedCmd string, words []string, threshold float64) []Correction {
+	var corrections []Correction
+	for _, name := range matchWords(words[0], v.commands, threshold) {
+		fixed := slices.Clone(words)
+		fixed[0] = name.word
+		similarity, score := name.similarity, name.score
+
+		if len(words) > 1 && isSubcommandWord(words[1]) {
+			if subs := matchWords(words[1], v.subcommands[name.word], threshold); len(subs) > 0 {
+				fixed[1] = subs[0].word
+				similarity = min(similarity, subs[0].similarity)
+				score = max(score, subs[0].score)
+			}
 		}
+
+		if slices.Equal(fixed, words) {
+			continue
+		}
+		corrections = append(corrections, Correction{
+			Original:   failedCmd,
+			Suggested:  strings.Join(fixed, " "),
+			Similarity: similarity,
+			Score:      score,
+		})
+	}
+	return corrections
+}
+
+// wordMatch is a known word similar to a typed one.
+type wordMatch struct {
+	word       string
+	similarity float64
+	score      float64
+}
+
+// matchWords returns the known words at least threshold similar to word,
+// best first. A word that is known is only matched by itself.
+func matchWords(word string, known map[string]float64, threshold float64) []wordMatch {
+	if score, ok := known[word]; ok {
+		return []wordMatch{{word: word, similarity: 1, score: score}}
 	}
+	var matches []wordMatch
+	for k, score := range known {
+		if similarity := Similarity(word, k); similarity >= threshold {
+			matches = append(matches, wordMatch{word: k, similarity: similarity, score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].similarity != matches[j].similarity {
+			return matches[i].similarity > matches[j].similarity
+		}
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].word < matches[j].word
+	})
+	return matches
+}
 
-	return cmd
+// isSubcommandWord reports whether word, following a command name, can be
+// a subcommand rather than a flag or a normalized argument placeholder.
+func isSubcommandWord(word string) bool {
+	return word != "" && !strings.HasPrefix(word, "-") && !strings.HasPrefix(word, "<")
 }
@@ -11,6 +11,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	_ "modernc.org/sqlite"
+
+	"github.com/runger/clai/internal/suggestions/discovery"
 )
 
 // createTestDB creates a temporary SQLite database for testing.
@@ -26,21 +28,47 @@ func createTestDB(t *testing.T) *sql.DB {
 	require.NoError(t, err)
 	t.Cleanup(func() { db.Close() })
 
-	// Create command_score table
+	// Create the command_template and command_stat tables (from V2 schema).
 	_, err = db.Exec(`
-		CREATE TABLE command_score (
-			scope     TEXT NOT NULL,
-			cmd_norm  TEXT NOT NULL,
-			score     REAL NOT NULL,
-			last_ts   INTEGER NOT NULL,
-			PRIMARY KEY(scope, cmd_norm)
-		)
+		CREATE TABLE command_template (
+			template_id     TEXT PRIMARY KEY,
+			cmd_norm        TEXT NOT NULL,
+			tags            TEXT,
+			slot_count      INTEGER NOT NULL,
+			first_seen_ms   INTEGER NOT NULL,
+			last_seen_ms    INTEGER NOT NULL
+		);
+		CREATE TABLE command_stat (
+			scope           TEXT NOT NULL,
+			template_id     TEXT NOT NULL,
+			score           REAL NOT NULL,
+			success_count   INTEGER NOT NULL,
+			failure_count   INTEGER NOT NULL,
+			last_seen_ms    INTEGER NOT NULL,
+			PRIMARY KEY(scope, template_id)
+		);
 	`)
 	require.NoError(t, err)
 
 	return db
 }
 
+// insertCommand records cmdNorm with score in scope.
+func insertCommand(t *testing.T, db *sql.DB, scope, cmdNorm string, score float64) {
+	t.Helper()
+
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO command_template (template_id, cmd_norm, slot_count, first_seen_ms, last_seen_ms)
+		VALUES (?, ?, 0, 1000, 1000)
+	`, "tpl:"+cmdNorm, cmdNorm)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		INSERT INTO command_stat (scope, template_id, score, success_count, failure_count, last_seen_ms)
+		VALUES (?, ?, ?, 1, 0, 1000)
+	`, scope, "tpl:"+cmdNorm, score)
+	require.NoError(t, err)
+}
+
 func TestLevenshteinDistance(t *testing.T) {
 	t.Parallel()
 
@@ -155,13 +183,9 @@ func TestCorrector_Correct_SimpleTypo(t *testing.T) {
 	db := createTestDB(t)
 
 	// Insert candidate commands
-	_, err := db.Exec(`
-		INSERT INTO command_score (scope, cmd_norm, score, last_ts) VALUES
-		('__global__', 'git status', 100, 1000),
-		('__global__', 'git commit', 80, 1000),
-		('__global__', 'git push', 60, 1000)
-	`)
-	require.NoError(t, err)
+	insertCommand(t, db, "global", "git status", 100)
+	insertCommand(t, db, "global", "git commit", 80)
+	insertCommand(t, db, "global", "git push", 60)
 
 	// Use lower threshold since "gti" vs "git" has similarity ~0.66
 	corrector, err := NewCorrector(db, CorrectorConfig{
@@ -197,11 +221,7 @@ func TestCorrector_Correct_NoMatch(t *testing.T) {
 	db := createTestDB(t)
 
 	// Insert candidate commands
-	_, err := db.Exec(`
-		INSERT INTO command_score (scope, cmd_norm, score, last_ts) VALUES
-		('__global__', 'git status', 100, 1000)
-	`)
-	require.NoError(t, err)
+	insertCommand(t, db, "global", "git status", 100)
 
 	corrector, err := NewCorrector(db, DefaultCorrectorConfig())
 	require.NoError(t, err)
@@ -237,12 +257,8 @@ func TestCorrector_Correct_RepoScope(t *testing.T) {
 	db := createTestDB(t)
 
 	// Insert repo-specific and global commands
-	_, err := db.Exec(`
-		INSERT INTO command_score (scope, cmd_norm, score, last_ts) VALUES
-		('/test/repo', 'npm test', 100, 1000),
-		('__global__', 'git status', 100, 1000)
-	`)
-	require.NoError(t, err)
+	insertCommand(t, db, "/test/repo", "npm test", 100)
+	insertCommand(t, db, "global", "git status", 100)
 
 	// Use lower threshold since "npn" vs "npm" has similarity ~0.66
 	corrector, err := NewCorrector(db, CorrectorConfig{
@@ -271,15 +287,11 @@ func TestCorrector_Correct_MaxSuggestions(t *testing.T) {
 	db := createTestDB(t)
 
 	// Insert many similar commands
-	_, err := db.Exec(`
-		INSERT INTO command_score (scope, cmd_norm, score, last_ts) VALUES
-		('__global__', 'git status', 100, 1000),
-		('__global__', 'git stash', 90, 1000),
-		('__global__', 'git stage', 80, 1000),
-		('__global__', 'git stats', 70, 1000),
-		('__global__', 'git start', 60, 1000)
-	`)
-	require.NoError(t, err)
+	insertCommand(t, db, "global", "git status", 100)
+	insertCommand(t, db, "global", "git stash", 90)
+	insertCommand(t, db, "global", "git stage", 80)
+	insertCommand(t, db, "global", "git stats", 70)
+	insertCommand(t, db, "global", "git start", 60)
 
 	corrector, err := NewCorrector(db, CorrectorConfig{
 		SimilarityThreshold: 0.5, // Lower threshold to match more
@@ -314,24 +326,88 @@ func TestCorrector_Correct_NoCandidates(t *testing.T) {
 	assert.Empty(t, corrections)
 }
 
-func TestExtractFirstToken(t *testing.T) {
+func TestCorrector_Correct_Subcommand(t *testing.T) {
+	t.Parallel()
+
+	db := createTestDB(t)
+	insertCommand(t, db, "global", "git status", 100)
+	insertCommand(t, db, "global", "git push origin <arg>", 80)
+	insertCommand(t, db, "global", "ls -la", 60)
+
+	corrector, err := NewCorrector(db, CorrectorConfig{TopPercent: 100})
+	require.NoError(t, err)
+	defer corrector.Close()
+
+	ctx := context.Background()
+
+	corrections, err := corrector.Correct(ctx, "git stauts", "")
+	require.NoError(t, err)
+	require.Len(t, corrections, 1)
+	assert.Equal(t, "git status", corrections[0].Suggested)
+	assert.Equal(t, "git stauts", corrections[0].Original)
+
+	// Arguments are kept as typed.
+	corrections, err = corrector.Correct(ctx, "git psuh origin main", "")
+	require.NoError(t, err)
+	require.Len(t, corrections, 1)
+	assert.Equal(t, "git push origin main", corrections[0].Suggested)
+
+	// Known commands and unknown arguments are not corrections.
+	for _, cmd := range []string{"git status", "ls -la /tmp", "git frobnicate"} {
+		corrections, err = corrector.Correct(ctx, cmd, "")
+		require.NoError(t, err)
+		assert.Empty(t, corrections, cmd)
+	}
+}
+
+type fakeTasks []discovery.Task
+
+func (f fakeTasks) GetTasks(_ context.Context, _ string) ([]discovery.Task, error) {
+	return f, nil
+}
+
+func TestCorrector_Correct_ProjectTasks(t *testing.T) {
+	t.Parallel()
+
+	db := createTestDB(t)
+	insertCommand(t, db, "global", "git status", 100)
+
+	corrector, err := NewCorrector(db, CorrectorConfig{
+		Tasks: fakeTasks{{Kind: discovery.KindMakefile, Name: "build", Command: "make build"}},
+	})
+	require.NoError(t, err)
+	defer corrector.Close()
+
+	ctx := context.Background()
+
+	corrections, err := corrector.Correct(ctx, "mkae build", "/test/repo")
+	require.NoError(t, err)
+	require.NotEmpty(t, corrections)
+	assert.Equal(t, "make build", corrections[0].Suggested)
+
+	// Tasks are per repository.
+	corrections, err = corrector.Correct(ctx, "mkae build", "")
+	require.NoError(t, err)
+	assert.Empty(t, corrections)
+}
+
+func TestIsSubcommandWord(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
 		input string
-		want  string
+		want  bool
 	}{
-		{"", ""},
-		{"git", "git"},
-		{"git status", "git"},
-		{"  git status  ", "git"},
-		{"git\tstatus", "git"},
-		{"single", "single"},
+		{"", false},
+		{"status", true},
+		{"-la", false},
+		{"--force", false},
+		{"<PATH>", false},
 	}
 
 	for _, tc := range tests {
-		got := extractFirstToken(tc.input)
-		assert.Equal(t, tc.want, got, "extractFirstToken(%q)", tc.input)
+		got := isSubcommandWord(tc.input)
+		assert.Equal(t, tc.want, got, "isSubcommandWord(%q)", tc.input)
 	}
 }
 
@@ -0,0 +1,432 @@
+// Package usage maintains and reports time-bucketed usage analytics for the
+// V2 suggestions database: commands per local hour of each day with their
+// outcomes, and how often each command template ran and each directory was
+// used per day.
+//
+// The ingest write path and the history seeders call Record in the same
+// transaction that stores the events, so Report answers 'clai stats' from a
+// few hundred aggregate rows instead of scanning command_event. Suggestion
+// acceptance comes from suggestion_feedback, which holds one row per
+// reaction to a suggestion rather than per command.
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DayLayout is the format of the day column in the usage tables.
+const DayLayout = "2006-01-02"
+
+// Execer is satisfied by *sql.DB and *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Event is one command to count.
+type Event struct {
+	ExitCode   *int // nil when unknown, e.g. for imported shell history
+	TemplateID string
+	Cwd        string // "" when unknown, e.g. for imported shell history
+	TSMs       int64
+}
+
+type hourKey struct {
+	day  string
+	hour int
+}
+
+type hourCounts struct {
+	commands, successes, failures int64
+}
+
+type templateKey struct {
+	day        string
+	templateID string
+}
+
+type dirKey struct {
+	day string
+	cwd string
+}
+
+// Record adds events to the usage tables, bucketed by local day and hour.
+// Events are summed in memory first, so a large batch costs one upsert per
+// bucket rather than per event.
+func Record(ctx context.Context, db Execer, events ...Event) error {
+	hours, templates, dirs := bucket(events)
+	for k, c := range hours {
+		if _, err := db.ExecContext(ctx, `
+			INSERT INTO usage_hourly (day, hour, commands, successes, failures)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(day, hour) DO UPDATE SET
+				commands = commands + excluded.commands,
+				successes = successes + excluded.successes,
+				failures = failures + excluded.failures
+		`, k.day, k.hour, c.commands, c.successes, c.failures); err != nil {
+			return fmt.Errorf("update usage_hourly: %w", err)
+		}
+	}
+	for k, n := range templates {
+		if _, err := db.ExecContext(ctx, `
+			INSERT INTO usage_daily_template (day, template_id, count)
+			VALUES (?, ?, ?)
+			ON CONFLICT(day, template_id) DO UPDATE SET count = count + excluded.count
+		`, k.day, k.templateID, n); err != nil {
+			return fmt.Errorf("update usage_daily_template: %w", err)
+		}
+	}
+	for k, n := range dirs {
+		if _, err := db.ExecContext(ctx, `
+			INSERT INTO usage_daily_dir (day, cwd, count)
+			VALUES (?, ?, ?)
+			ON CONFLICT(day, cwd) DO UPDATE SET count = count + excluded.count
+		`, k.day, k.cwd, n); err != nil {
+			return fmt.Errorf("update usage_daily_dir: %w", err)
+		}
+	}
+	return nil
+}
+
+// Subtract takes previously recorded events back out of the usage tables,
+// e.g. when they are purged from history. Buckets left empty are deleted.
+func Subtract(ctx context.Context, db Execer, events ...Event) error {
+	hours, templates, dirs := bucket(events)
+	for k, c := range hours {
+		if _, err := db.ExecContext(ctx, `
+			UPDATE usage_hourly SET
+				commands = MAX(commands - ?, 0),
+				successes = MAX(successes - ?, 0),
+				failures = MAX(failures - ?, 0)
+			WHERE day = ? AND hour = ?
+		`, c.commands, c.successes, c.failures, k.day, k.hour); err != nil {
+			return fmt.Errorf("update usage_hourly: %w", err)
+		}
+	}
+	for k, n := range templates {
+		if _, err := db.ExecContext(ctx, `
+			UPDATE usage_daily_template SET count = MAX(count - ?, 0)
+			WHERE day = ? AND template_id = ?
+		`, n, k.day, k.templateID); err != nil {
+			return fmt.Errorf("update usage_daily_template: %w", err)
+		}
+	}
+	for k, n := range dirs {
+		if _, err := db.ExecContext(ctx, `
+			UPDATE usage_daily_dir SET count = MAX(count - ?, 0)
+			WHERE day = ? AND cwd = ?
+		`, n, k.day, k.cwd); err != nil {
+			return fmt.Errorf("update usage_daily_dir: %w", err)
+		}
+	}
+	if len(hours) > 0 {
+		if _, err := db.ExecContext(ctx, `DELETE FROM usage_hourly WHERE commands = 0`); err != nil {
+			return fmt.Errorf("prune usage_hourly: %w", err)
+		}
+	}
+	if len(templates) > 0 {
+		if _, err := db.ExecContext(ctx, `DELETE FROM usage_daily_template WHERE count = 0`); err != nil {
+			return fmt.Errorf("prune usage_daily_template: %w", err)
+		}
+	}
+	if len(dirs) > 0 {
+		if _, err := db.ExecContext(ctx, `DELETE FROM usage_daily_dir WHERE count = 0`); err != nil {
+			return fmt.Errorf("prune usage_daily_dir: %w", err)
+		}
+	}
+	return nil
+}
+
+// bucket sums events per local hour, per day and template, and per day and
+// directory.
+func bucket(events []Event) (map[hourKey]*hourCounts, map[templateKey]int64, map[dirKey]int64) {
+	hours := make(map[hourKey]*hourCounts)
+	templates := make(map[templateKey]int64)
+	dirs := make(map[dirKey]int64)
+	for _, ev := range events {
+		t := time.UnixMilli(ev.TSMs).Local()
+		day := t.Format(DayLayout)
+
+		hk := hourKey{day: day, hour: t.Hour()}
+		c := hours[hk]
+		if c == nil {
+			c = &hourCounts{}
+			hours[hk] = c
+		}
+		c.commands++
+		if ev.ExitCode != nil {
+			if *ev.ExitCode == 0 {
+				c.successes++
+			} else {
+				c.failures++
+			}
+		}
+		if ev.TemplateID != "" {
+			templates[templateKey{day: day, templateID: ev.TemplateID}]++
+		}
+		if ev.Cwd != "" {
+			dirs[dirKey{day: day, cwd: ev.Cwd}]++
+		}
+	}
+	return hours, templates, dirs
+}
+
+// Counts are commands run and their outcomes. Commands whose exit code was
+// not recorded are neither successes nor failures.
+type Counts struct {
+	Commands  int64 `json:"commands"`
+	Successes int64 `json:"successes"`
+	Failures  int64 `json:"failures"`
+}
+
+// SuccessRate returns the share of commands with a known outcome that
+// succeeded, or 0 if there were none.
+func (c Counts) SuccessRate() float64 {
+	known := c.Successes + c.Failures
+	if known == 0 {
+		return 0
+	}
+	return float64(c.Successes) / float64(known)
+}
+
+func (c *Counts) add(o Counts) {
+	c.Commands += o.Commands
+	c.Successes += o.Successes
+	c.Failures += o.Failures
+}
+
+// Day is the usage of one local calendar day.
+type Day struct {
+	Day string `json:"day"`
+	Counts
+}
+
+// Template is a command template and how often it ran.
+type Template struct {
+	TemplateID string `json:"template_id"`
+	CmdNorm    string `json:"cmd_norm"`
+	Count      int64  `json:"count"`
+}
+
+// Dir is a working directory and how many commands ran in it.
+type Dir struct {
+	Cwd   string `json:"cwd"`
+	Count int64  `json:"count"`
+}
+
+// Feedback counts reactions to suggestions. Accepted includes suggestions
+// accepted and then edited; Rejected includes those dismissed or blocked.
+// Suggestions that were ignored or timed out are in neither.
+type Feedback struct {
+	Accepted int64 `json:"accepted"`
+	Rejected int64 `json:"rejected"`
+}
+
+// AcceptanceRate returns the share of accepted suggestions among those
+// accepted or rejected, or 0 if there were none.
+func (f Feedback) AcceptanceRate() float64 {
+	total := f.Accepted + f.Rejected
+	if total == 0 {
+		return 0
+	}
+	return float64(f.Accepted) / float64(total)
+}
+
+// Summary is usage over a range of days.
+type Summary struct {
+	From         string     `json:"from"`
+	To           string     `json:"to"`
+	Days         []Day      `json:"days"` // Days with activity, oldest first
+	TopTemplates []Template `json:"top_templates"`
+	TopDirs      []Dir      `json:"top_dirs"`
+	Hours        [24]int64  `json:"hours"` // Commands by local hour of day
+	Total        Counts     `json:"total"`
+	Feedback     Feedback   `json:"feedback"`
+}
+
+// BusiestHours returns the n hours of the day with the most commands, in
+// descending order, leaving out hours with none.
+func (s *Summary) BusiestHours(n int) []int {
+	hours := make([]int, 0, len(s.Hours))
+	for h, c := range s.Hours {
+		if c > 0 {
+			hours = append(hours, h)
+		}
+	}
+	sort.SliceStable(hours, func(i, j int) bool { return s.Hours[hours[i]] > s.Hours[hours[j]] })
+	if len(hours) > n {
+		hours = hours[:n]
+	}
+	return hours
+}
+
+// Report summarizes usage on the days from through to (inclusive, in
+// DayLayout), with the top most-run templates and most-used directories.
+func Report(ctx context.Context, db *sql.DB, from, to string, top int) (*Summary, error) {
+	s := &Summary{From: from, To: to, Days: []Day{}, TopTemplates: []Template{}, TopDirs: []Dir{}}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT day, hour, commands, successes, failures FROM usage_hourly
+		WHERE day BETWEEN ? AND ?
+		ORDER BY day, hour
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("query usage_hourly: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var day string
+		var hour int
+		var c Counts
+		if err := rows.Scan(&day, &hour, &c.Commands, &c.Successes, &c.Failures); err != nil {
+			return nil, fmt.Errorf("scan usage_hourly: %w", err)
+		}
+		if n := len(s.Days); n == 0 || s.Days[n-1].Day != day {
+			s.Days = append(s.Days, Day{Day: day})
+		}
+		s.Days[len(s.Days)-1].add(c)
+		if hour >= 0 && hour < len(s.Hours) {
+			s.Hours[hour] += c.Commands
+		}
+		s.Total.add(c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query usage_hourly: %w", err)
+	}
+	if err := reportFeedback(ctx, db, s); err != nil {
+		return nil, err
+	}
+
+	if top <= 0 {
+		return s, nil
+	}
+	trows, err := db.QueryContext(ctx, `
+		SELECT u.template_id, COALESCE(t.cmd_norm, ''), SUM(u.count) AS total
+		FROM usage_daily_template u
+		LEFT JOIN command_template t ON t.template_id = u.template_id
+		WHERE u.day BETWEEN ? AND ?
+		GROUP BY u.template_id
+		ORDER BY total DESC, u.template_id
+		LIMIT ?
+	`, from, to, top)
+	if err != nil {
+		return nil, fmt.Errorf("query usage_daily_template: %w", err)
+	}
+	defer trows.Close()
+	for trows.Next() {
+		var t Template
+		if err := trows.Scan(&t.TemplateID, &t.CmdNorm, &t.Count); err != nil {
+			return nil, fmt.Errorf("scan usage_daily_template: %w", err)
+		}
+		s.TopTemplates = append(s.TopTemplates, t)
+	}
+	if err := trows.Err(); err != nil {
+		return nil, fmt.Errorf("query usage_daily_template: %w", err)
+	}
+
+	drows, err := db.QueryContext(ctx, `
+		SELECT cwd, SUM(count) AS total
+		FROM usage_daily_dir
+		WHERE day BETWEEN ? AND ?
+		GROUP BY cwd
+		ORDER BY total DESC, cwd
+		LIMIT ?
+	`, from, to, top)
+	if err != nil {
+		return nil, fmt.Errorf("query usage_daily_dir: %w", err)
+	}
+	defer drows.Close()
+	for drows.Next() {
+		var d Dir
+		if err := drows.Scan(&d.Cwd, &d.Count); err != nil {
+			return nil, fmt.Errorf("scan usage_daily_dir: %w", err)
+		}
+		s.TopDirs = append(s.TopDirs, d)
+	}
+	if err := drows.Err(); err != nil {
+		return nil, fmt.Errorf("query usage_daily_dir: %w", err)
+	}
+	return s, nil
+}
+
+// TemplateUsage is how one command template has been used over all the
+// recorded days. Successes and failures come from the global command_stat
+// row, which counts every command recorded with an exit code.
+type TemplateUsage struct {
+	TemplateID string `json:"template_id"`
+	CmdNorm    string `json:"cmd_norm"`
+	FirstDay   string `json:"first_day,omitempty"`
+	LastDay    string `json:"last_day,omitempty"`
+	Runs       int64  `json:"runs"`
+	Days       int64  `json:"days"` // Days it ran on
+	Successes  int64  `json:"successes"`
+	Failures   int64  `json:"failures"`
+}
+
+// SuccessRate returns the share of runs with a known outcome that
+// succeeded, or 0 if there were none.
+func (u *TemplateUsage) SuccessRate() float64 {
+	return Counts{Successes: u.Successes, Failures: u.Failures}.SuccessRate()
+}
+
+// ForTemplate reports the usage of templateID. A template that never ran
+// has zero Runs.
+func ForTemplate(ctx context.Context, db *sql.DB, templateID string) (*TemplateUsage, error) {
+	u := &TemplateUsage{TemplateID: templateID}
+
+	var first, last sql.NullString
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(count), 0), COUNT(*), MIN(day), MAX(day)
+		FROM usage_daily_template
+		WHERE template_id = ?
+	`, templateID).Scan(&u.Runs, &u.Days, &first, &last)
+	if err != nil {
+		return nil, fmt.Errorf("query usage_daily_template: %w", err)
+	}
+	u.FirstDay, u.LastDay = first.String, last.String
+
+	err = db.QueryRowContext(ctx, `
+		SELECT cmd_norm FROM command_template WHERE template_id = ?
+	`, templateID).Scan(&u.CmdNorm)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("query command_template: %w", err)
+	}
+
+	err = db.QueryRowContext(ctx, `
+		SELECT success_count, failure_count FROM command_stat
+		WHERE scope = 'global' AND template_id = ?
+	`, templateID).Scan(&u.Successes, &u.Failures)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("query command_stat: %w", err)
+	}
+	return u, nil
+}
+
+// reportFeedback counts the reactions to suggestions between the start of
+// s.From and the end of s.To, local time.
+func reportFeedback(ctx context.Context, db *sql.DB, s *Summary) error {
+	start, err := time.ParseInLocation(DayLayout, s.From, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid from day: %w", err)
+	}
+	end, err := time.ParseInLocation(DayLayout, s.To, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid to day: %w", err)
+	}
+	end = end.AddDate(0, 0, 1)
+
+	err = db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(CASE WHEN action IN ('accepted', 'edited') THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN action IN ('dismissed', 'never') THEN 1 ELSE 0 END), 0)
+		FROM suggestion_feedback
+		WHERE ts_ms >= ? AND ts_ms < ?
+	`, start.UnixMilli(), end.UnixMilli()).Scan(&s.Feedback.Accepted, &s.Feedback.Rejected)
+	if err != nil {
+		return fmt.Errorf("query suggestion_feedback: %w", err)
+	}
+	return nil
+}
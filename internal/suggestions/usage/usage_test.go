@@ -0,0 +1,192 @@
+package usage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	suggestdb "github.com/runger/clai/internal/suggestions/db"
+)
+
+func newTestDB(t *testing.T) *suggestdb.DB {
+	t.Helper()
+	db, err := suggestdb.Open(context.Background(), suggestdb.Options{
+		Path:     filepath.Join(t.TempDir(), "usage_test.db"),
+		SkipLock: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func localMs(day string, hour int) int64 {
+	t, err := time.ParseInLocation(DayLayout, day, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t.Add(time.Duration(hour)*time.Hour + 30*time.Minute).UnixMilli()
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestRecordAndReport(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	sdb := newTestDB(t)
+	db := sdb.DB()
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO command_template (template_id, cmd_norm, slot_count, first_seen_ms, last_seen_ms)
+		VALUES ('tpl-git', 'git status', 0, 1, 1), ('tpl-make', 'make test', 0, 1, 1)
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, Record(ctx, db,
+		Event{TSMs: localMs("2026-03-01", 9), TemplateID: "tpl-git", ExitCode: intPtr(0)},
+		Event{TSMs: localMs("2026-03-01", 9), TemplateID: "tpl-git", ExitCode: intPtr(0)},
+		Event{TSMs: localMs("2026-03-01", 14), TemplateID: "tpl-make", ExitCode: intPtr(2)},
+		Event{TSMs: localMs("2026-03-02", 9), TemplateID: "tpl-git"},
+	))
+	// A second call adds to the same buckets.
+	require.NoError(t, Record(ctx, db,
+		Event{TSMs: localMs("2026-03-02", 9), TemplateID: "tpl-make", ExitCode: intPtr(0)},
+		Event{TSMs: localMs("2026-02-27", 9), TemplateID: "tpl-make", ExitCode: intPtr(0)},
+	))
+
+	s, err := Report(ctx, db, "2026-03-01", "2026-03-02", 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Day{
+		{Day: "2026-03-01", Counts: Counts{Commands: 3, Successes: 2, Failures: 1}},
+		{Day: "2026-03-02", Counts: Counts{Commands: 2, Successes: 1}},
+	}, s.Days)
+	assert.Equal(t, Counts{Commands: 5, Successes: 3, Failures: 1}, s.Total)
+	assert.InDelta(t, 0.75, s.Total.SuccessRate(), 1e-9)
+	assert.Equal(t, int64(4), s.Hours[9])
+	assert.Equal(t, int64(1), s.Hours[14])
+	assert.Equal(t, []int{9, 14}, s.BusiestHours(3))
+	assert.Equal(t, []Template{{TemplateID: "tpl-git", CmdNorm: "git status", Count: 3}}, s.TopTemplates)
+}
+
+func TestReport_Empty(t *testing.T) {
+	t.Parallel()
+	sdb := newTestDB(t)
+
+	s, err := Report(context.Background(), sdb.DB(), "2026-03-01", "2026-03-31", 10)
+	require.NoError(t, err)
+	assert.Empty(t, s.Days)
+	assert.Empty(t, s.TopTemplates)
+	assert.Zero(t, s.Total.SuccessRate())
+	assert.Empty(t, s.BusiestHours(3))
+}
+
+func TestSubtract(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	sdb := newTestDB(t)
+	db := sdb.DB()
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO command_template (template_id, cmd_norm, slot_count, first_seen_ms, last_seen_ms)
+		VALUES ('tpl-git', 'git status', 0, 1, 1), ('tpl-make', 'make test', 0, 1, 1)
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, Record(ctx, db,
+		Event{TSMs: localMs("2026-03-01", 9), TemplateID: "tpl-git", ExitCode: intPtr(0)},
+		Event{TSMs: localMs("2026-03-01", 9), TemplateID: "tpl-make", ExitCode: intPtr(1)},
+		Event{TSMs: localMs("2026-03-01", 14), TemplateID: "tpl-make", ExitCode: intPtr(0)},
+	))
+	require.NoError(t, Subtract(ctx, db,
+		Event{TSMs: localMs("2026-03-01", 9), TemplateID: "tpl-make", ExitCode: intPtr(1)},
+		Event{TSMs: localMs("2026-03-01", 14), TemplateID: "tpl-make", ExitCode: intPtr(0)},
+	))
+
+	s, err := Report(ctx, db, "2026-03-01", "2026-03-01", 10)
+	require.NoError(t, err)
+	assert.Equal(t, Counts{Commands: 1, Successes: 1}, s.Total)
+	assert.Zero(t, s.Hours[14])
+	assert.Equal(t, []Template{{TemplateID: "tpl-git", CmdNorm: "git status", Count: 1}}, s.TopTemplates)
+
+	var rows int
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM usage_hourly`).Scan(&rows))
+	assert.Equal(t, 1, rows, "emptied hour buckets are deleted")
+}
+
+func TestReport_DirsAndFeedback(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	sdb := newTestDB(t)
+	db := sdb.DB()
+
+	require.NoError(t, Record(ctx, db,
+		Event{TSMs: localMs("2026-03-01", 9), Cwd: "/src/clai"},
+		Event{TSMs: localMs("2026-03-01", 10), Cwd: "/src/clai"},
+		Event{TSMs: localMs("2026-03-02", 9), Cwd: "/tmp"},
+		Event{TSMs: localMs("2026-03-02", 9)},
+		Event{TSMs: localMs("2026-03-03", 9), Cwd: "/tmp"},
+	))
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO suggestion_feedback (session_id, ts_ms, suggested_text, action) VALUES
+			('s1', ?1, 'git status', 'accepted'),
+			('s1', ?1, 'git push', 'edited'),
+			('s1', ?1, 'make', 'dismissed'),
+			('s1', ?1, 'ls', 'ignored'),
+			('s1', ?2, 'make', 'accepted')
+	`, localMs("2026-03-02", 23), localMs("2026-03-03", 0))
+	require.NoError(t, err)
+
+	s, err := Report(ctx, db, "2026-03-01", "2026-03-02", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []Dir{{Cwd: "/src/clai", Count: 2}, {Cwd: "/tmp", Count: 1}}, s.TopDirs)
+	assert.Equal(t, Feedback{Accepted: 2, Rejected: 1}, s.Feedback)
+	assert.InDelta(t, 2.0/3, s.Feedback.AcceptanceRate(), 1e-9)
+
+	require.NoError(t, Subtract(ctx, db, Event{TSMs: localMs("2026-03-02", 9), Cwd: "/tmp"}))
+	s, err = Report(ctx, db, "2026-03-01", "2026-03-02", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []Dir{{Cwd: "/src/clai", Count: 2}}, s.TopDirs)
+}
+
+func TestForTemplate(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	sdb := newTestDB(t)
+	db := sdb.DB()
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO command_template (template_id, cmd_norm, slot_count, first_seen_ms, last_seen_ms)
+		VALUES ('tpl-make', 'make test', 0, 1, 1);
+		INSERT INTO command_stat (scope, template_id, score, success_count, failure_count, last_seen_ms)
+		VALUES ('global', 'tpl-make', 1, 2, 1, 1), ('repo:x', 'tpl-make', 1, 9, 9, 1)
+	`)
+	require.NoError(t, err)
+	require.NoError(t, Record(ctx, db,
+		Event{TSMs: localMs("2026-03-01", 9), TemplateID: "tpl-make", ExitCode: intPtr(0)},
+		Event{TSMs: localMs("2026-03-01", 10), TemplateID: "tpl-make", ExitCode: intPtr(2)},
+		Event{TSMs: localMs("2026-03-04", 9), TemplateID: "tpl-make", ExitCode: intPtr(0)},
+		Event{TSMs: localMs("2026-03-05", 9), TemplateID: "tpl-git", ExitCode: intPtr(0)},
+	))
+
+	u, err := ForTemplate(ctx, db, "tpl-make")
+	require.NoError(t, err)
+	assert.Equal(t, &TemplateUsage{
+		TemplateID: "tpl-make",
+		CmdNorm:    "make test",
+		FirstDay:   "2026-03-01",
+		LastDay:    "2026-03-04",
+		Runs:       3,
+		Days:       2,
+		Successes:  2,
+		Failures:   1,
+	}, u)
+	assert.InDelta(t, 2.0/3, u.SuccessRate(), 1e-9)
+
+	u, err = ForTemplate(ctx, db, "tpl-unknown")
+	require.NoError(t, err)
+	assert.Equal(t, &TemplateUsage{TemplateID: "tpl-unknown"}, u)
+}
@@ -0,0 +1,156 @@
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Continuation matching limits.
+const (
+	// MaxMatchedSteps is how many of the session's last commands are
+	// matched against the start of a workflow.
+	MaxMatchedSteps = 2
+
+	// MinContinuationSteps is the number of steps a workflow must have left
+	// to be offered as a whole; single next steps are ranked by the scorer.
+	MinContinuationSteps = 2
+)
+
+// Continuation is the rest of a known workflow whose first steps are the
+// last commands run in a session.
+type Continuation struct {
+	Pattern Pattern
+
+	// Matched is the number of leading workflow steps already run.
+	Matched int
+
+	// Steps are the commands of the remaining steps, in order: the latest
+	// command run for each step's template, or its normalized form.
+	Steps []string
+
+	// DisplayNames are the normalized forms of the remaining steps.
+	DisplayNames []string
+}
+
+// Summary describes the continuation, e.g. "git commit → git push".
+func (c *Continuation) Summary() string {
+	summary := ""
+	for i, name := range c.DisplayNames {
+		if i > 0 {
+			summary += " → "
+		}
+		summary += name
+	}
+	return summary
+}
+
+// Continuations returns the promoted workflows whose first one or two steps
+// are the last commands run in sessionID, longest match first, then most
+// frequent. Workflows with fewer than MinContinuationSteps steps left are
+// skipped.
+func Continuations(ctx context.Context, db *sql.DB, sessionID string, minOccurrences int) ([]Continuation, error) {
+	recent, err := recentTemplates(ctx, db, sessionID)
+	if err != nil || len(recent) == 0 {
+		return nil, err
+	}
+	patterns, err := LoadPromotedPatterns(ctx, db, minOccurrences)
+	if err != nil {
+		return nil, err
+	}
+
+	var conts []Continuation
+	seen := make(map[string]bool)
+	for _, p := range patterns {
+		matched := matchedSteps(recent, p.TemplateIDs)
+		if matched == 0 || len(p.TemplateIDs)-matched < MinContinuationSteps {
+			continue
+		}
+		rest := p.TemplateIDs[matched:]
+		if key := patternKey(rest); !seen[key] {
+			seen[key] = true
+			conts = append(conts, Continuation{
+				Pattern:      p,
+				Matched:      matched,
+				DisplayNames: p.DisplayNames[min(matched, len(p.DisplayNames)):],
+			})
+		}
+	}
+	sort.SliceStable(conts, func(i, j int) bool {
+		if conts[i].Matched != conts[j].Matched {
+			return conts[i].Matched > conts[j].Matched
+		}
+		return conts[i].Pattern.OccurrenceCount > conts[j].Pattern.OccurrenceCount
+	})
+
+	for i := range conts {
+		if conts[i].Steps, err = stepCommands(ctx, db, &conts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return conts, nil
+}
+
+// matchedSteps returns the largest k <= MaxMatchedSteps for which the last
+// k recent templates are the first k steps of chain, or 0.
+func matchedSteps(recent, chain []string) int {
+	for k := min(MaxMatchedSteps, len(recent), len(chain)); k > 0; k-- {
+		if slices.Equal(recent[len(recent)-k:], chain[:k]) {
+			return k
+		}
+	}
+	return 0
+}
+
+// recentTemplates returns the template IDs of the last MaxMatchedSteps
+// commands run in sessionID, oldest first.
+func recentTemplates(ctx context.Context, db *sql.DB, sessionID string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT template_id
+		FROM command_event
+		WHERE session_id = ? AND template_id IS NOT NULL AND template_id != ''
+		ORDER BY ts_ms DESC, id DESC
+		LIMIT ?
+	`, sessionID, MaxMatchedSteps)
+	if err != nil {
+		return nil, fmt.Errorf("fetch recent templates: %w", err)
+	}
+	defer rows.Close()
+
+	var recent []string
+	for rows.Next() {
+		var tid string
+		if err := rows.Scan(&tid); err != nil {
+			return nil, fmt.Errorf("scan recent template: %w", err)
+		}
+		recent = append(recent, tid)
+	}
+	slices.Reverse(recent)
+	return recent, rows.Err()
+}
+
+// stepCommands returns a runnable command for each remaining step of c:
+// the latest command run with the step's template, which keeps the
+// arguments normalization replaced, or the step's normalized form.
+func stepCommands(ctx context.Context, db *sql.DB, c *Continuation) ([]string, error) {
+	rest := c.Pattern.TemplateIDs[c.Matched:]
+	steps := make([]string, len(rest))
+	for i, tid := range rest {
+		err := db.QueryRowContext(ctx, `
+			SELECT cmd_raw
+			FROM command_event
+			WHERE template_id = ? AND ephemeral = 0
+			ORDER BY ts_ms DESC
+			LIMIT 1
+		`, tid).Scan(&steps[i])
+		if errors.Is(err, sql.ErrNoRows) && i < len(c.DisplayNames) {
+			steps[i] = c.DisplayNames[i]
+		} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("fetch step command: %w", err)
+		}
+	}
+	return steps, nil
+}
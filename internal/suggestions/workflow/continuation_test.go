@@ -0,0 +1,74 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContinuations(t *testing.T) {
+	t.Parallel()
+	db := createTestDB(t)
+	ctx := context.Background()
+
+	for _, session := range []string{"mined1", "mined2", "mined3"} {
+		insertEvent(t, db, session, 1000, "git add <path>", "tmpl_add")
+		insertEvent(t, db, session, 2000, "git commit -m <msg>", "tmpl_commit")
+		insertEvent(t, db, session, 3000, "git push <arg> <arg>", "tmpl_push")
+	}
+	_, err := db.Exec(`
+		INSERT INTO command_event (session_id, ts_ms, cwd, cmd_raw, cmd_norm, template_id)
+		VALUES ('other', 40000, '/', 'git push origin main', 'git push <arg> <arg>', 'tmpl_push')
+	`)
+	require.NoError(t, err)
+
+	cfg := DefaultMinerConfig()
+	NewMiner(db, cfg).MineOnce(ctx)
+
+	t.Run("first step run", func(t *testing.T) {
+		insertEvent(t, db, "session2", 50000, "git add <path>", "tmpl_add")
+
+		conts, err := Continuations(ctx, db, "session2", cfg.MinOccurrences)
+		require.NoError(t, err)
+		require.Len(t, conts, 1)
+		assert.Equal(t, 1, conts[0].Matched)
+		assert.Equal(t, []string{"git commit -m <msg>", "git push origin main"}, conts[0].Steps)
+		assert.Equal(t, "git commit -m <msg> → git push <arg> <arg>", conts[0].Summary())
+	})
+
+	t.Run("one step left", func(t *testing.T) {
+		insertEvent(t, db, "session3", 50000, "git add <path>", "tmpl_add")
+		insertEvent(t, db, "session3", 51000, "git commit -m <msg>", "tmpl_commit")
+
+		conts, err := Continuations(ctx, db, "session3", cfg.MinOccurrences)
+		require.NoError(t, err)
+		assert.Empty(t, conts)
+	})
+
+	t.Run("no history", func(t *testing.T) {
+		conts, err := Continuations(ctx, db, "session4", cfg.MinOccurrences)
+		require.NoError(t, err)
+		assert.Empty(t, conts)
+	})
+}
+
+func TestMatchedSteps(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		recent []string
+		chain  []string
+		want   int
+	}{
+		{"two steps", []string{"a", "b"}, []string{"a", "b", "c"}, 2},
+		{"last step only", []string{"x", "a"}, []string{"a", "b", "c"}, 1},
+		{"no match", []string{"x", "y"}, []string{"a", "b", "c"}, 0},
+		{"middle of chain", []string{"b"}, []string{"a", "b", "c"}, 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchedSteps(tt.recent, tt.chain), tt.name)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/runger/clai/internal/config"
@@ -14,7 +15,7 @@ import (
 func WorkflowSearchDirs() []string {
 	return []string{
 		filepath.Join(".clai", "workflows"),
-		filepath.Join(config.DefaultPaths().BaseDir, "workflows"),
+		filepath.Join(config.DefaultPaths().ConfigDir(), "workflows"),
 	}
 }
 
@@ -50,3 +51,50 @@ func DiscoverWorkflow(name string) (string, error) {
 	return "", fmt.Errorf("workflow %q not found in search paths: %s",
 		name, strings.Join(WorkflowSearchDirs(), ", "))
 }
+
+// WorkflowFile is a workflow file found in the search directories.
+type WorkflowFile struct {
+	Name string // File name without extension
+	Path string
+}
+
+// FindWorkflows returns the workflow files in the search directories,
+// sorted by name. A name in a project-local directory shadows the same name
+// in the user-global one, so each name is listed once.
+func FindWorkflows() []WorkflowFile {
+	seen := make(map[string]bool)
+	var files []WorkflowFile
+	for _, dir := range WorkflowSearchDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			ext := strings.ToLower(filepath.Ext(e.Name()))
+			if ext != ".yaml" && ext != ".yml" {
+				continue
+			}
+			name := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+			if !seen[name] {
+				seen[name] = true
+				files = append(files, WorkflowFile{Name: name, Path: filepath.Join(dir, e.Name())})
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files
+}
+
+// ListWorkflows returns the names of the workflow files in the search
+// directories, sorted, as FindWorkflows finds them.
+func ListWorkflows() []string {
+	files := FindWorkflows()
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Name
+	}
+	return names
+}
@@ -246,6 +246,10 @@ func (p *errorProvider) Diagnose(_ context.Context, _ *provider.DiagnoseRequest)
 	return nil, p.err
 }
 
+func (p *errorProvider) Explain(_ context.Context, _ *provider.ExplainRequest) (*provider.ExplainResponse, error) {
+	return nil, p.err
+}
+
 // slowProvider is a provider that takes a long time to respond.
 type slowProvider struct {
 	name  string
@@ -287,6 +291,15 @@ func (p *slowProvider) Diagnose(ctx context.Context, req *provider.DiagnoseReque
 	}
 }
 
+func (p *slowProvider) Explain(ctx context.Context, req *provider.ExplainRequest) (*provider.ExplainResponse, error) {
+	select {
+	case <-time.After(p.delay):
+		return &provider.ExplainResponse{}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // setupEnvWithProvider creates a test environment with a specific provider.
 func setupEnvWithProvider(t *testing.T, prov provider.Provider) *TestEnv {
 	t.Helper()
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	pb "github.com/runger/clai/gen/clai/v1"
+	"github.com/runger/clai/internal/config"
 )
 
 // stressRunSessionCommands runs commands concurrently within a single session.
@@ -215,6 +216,12 @@ func TestStress_MixedOperations(t *testing.T) {
 	env := SetupTestEnvWithSuggestions(t)
 	defer env.Teardown()
 
+	// All TextToCommand calls share one session; lift the per-session AI
+	// rate limit so the test measures concurrency, not the limiter.
+	cfg := config.DefaultConfig()
+	cfg.Daemon.RateLimitAIPerMin = 0
+	env.Server.ApplyConfig(cfg)
+
 	ctx := context.Background()
 	numOperations := 100
 
@@ -334,6 +334,15 @@ func (m *mockProvider) Diagnose(ctx context.Context, req *provider.DiagnoseReque
 	}, nil
 }
 
+func (m *mockProvider) Explain(ctx context.Context, req *provider.ExplainRequest) (*provider.ExplainResponse, error) {
+	return &provider.ExplainResponse{
+		Summary:      "Mock summary.",
+		Parts:        []provider.ExplainedPart{{Text: "mock", Meaning: "Mock part"}},
+		ProviderName: m.name,
+		LatencyMs:    10,
+	}, nil
+}
+
 // idCounter is used to generate unique IDs for testing.
 var (
 	idCounter   int64